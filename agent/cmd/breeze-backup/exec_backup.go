@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/breeze-rmm/agent/internal/backup"
@@ -29,6 +32,52 @@ type sseConfigurableProvider interface {
 	SetServerSideEncryption(algorithm, kmsKeyID string)
 }
 
+// clientEncryptionMode is the storageEncryption mode for client-side
+// encryption: the payload's clientEncryption key wraps the provider in
+// providers.EncryptedProvider instead of asking the storage to encrypt.
+const clientEncryptionMode = "client-aes-gcm"
+
+// commandClientEncryption is the per-device data key the API sends (already
+// unwrapped from the org key it is escrowed under) for client-side encryption.
+// PlaintextSnapshot is set on restore and verify payloads for a snapshot the
+// server records as written before the device's backups were encrypted.
+type commandClientEncryption struct {
+	KeyID             string `json:"keyId"`
+	Key               string `json:"key"` // base64
+	PlaintextSnapshot bool   `json:"plaintextSnapshot,omitempty"`
+}
+
+// withClientEncryption wraps provider in client-side encryption when the
+// payload carries a clientEncryption key, and returns it unchanged otherwise.
+// Restore and verify payloads carry the key of the device that wrote the
+// snapshot.
+func withClientEncryption(provider providers.BackupProvider, payload json.RawMessage) (providers.BackupProvider, error) {
+	if provider == nil || len(payload) == 0 {
+		return provider, nil
+	}
+	var p struct {
+		ClientEncryption *commandClientEncryption `json:"clientEncryption"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, fmt.Errorf("invalid backup encryption payload: %w", err)
+	}
+	if p.ClientEncryption == nil {
+		return provider, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(p.ClientEncryption.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup encryption key: %w", err)
+	}
+	encrypted, err := providers.NewEncryptedProvider(provider, p.ClientEncryption.KeyID, key)
+	if err != nil {
+		return nil, err
+	}
+	if p.ClientEncryption.PlaintextSnapshot {
+		encrypted.AllowPlaintextObjects()
+	}
+	return encrypted, nil
+}
+
 func applyCommandStorageEncryption(provider providers.BackupProvider, payload json.RawMessage) error {
 	if len(payload) == 0 {
 		return nil
@@ -46,6 +95,14 @@ func applyCommandStorageEncryption(provider providers.BackupProvider, payload js
 	if provider == nil {
 		return fmt.Errorf("backup storage encryption is required but backup storage is not configured")
 	}
+	if p.StorageEncryption.Mode == clientEncryptionMode {
+		// The key only arrives with payload-built providers (backup_run);
+		// agent.yaml, MSSQL and Hyper-V runs cannot honour it yet.
+		if _, ok := provider.(*providers.EncryptedProvider); !ok {
+			return fmt.Errorf("backup client-side encryption is required but no encryption key was applied to this backup")
+		}
+		return nil
+	}
 
 	sseProvider, ok := provider.(sseConfigurableProvider)
 	if !ok {
@@ -76,6 +133,94 @@ type backupRunProviderConfig struct {
 	AccessKey string `json:"accessKey"`
 	SecretKey string `json:"secretKey"`
 	Path      string `json:"path"` // local provider destination
+
+	// Azure Blob
+	AccountName string `json:"accountName"`
+	AccountKey  string `json:"accountKey"`
+	Container   string `json:"container"`
+	// Google Cloud Storage: service account key, as a JSON object or a
+	// JSON-encoded string. Empty falls back to application default credentials.
+	CredentialsJSON json.RawMessage `json:"credentialsJson"`
+	// Backblaze B2
+	KeyID          string `json:"keyId"`
+	ApplicationKey string `json:"applicationKey"`
+	// SFTP (path is the remote base directory) and WebDAV
+	Host                 string `json:"host"`
+	Port                 int    `json:"port"`
+	Username             string `json:"username"`
+	Password             string `json:"password"`
+	PrivateKey           string `json:"privateKey"`
+	PrivateKeyPassphrase string `json:"privateKeyPassphrase"`
+	HostKeyFingerprint   string `json:"hostKeyFingerprint"`
+	URL                  string `json:"url"`
+	TLSFingerprint       string `json:"tlsFingerprint"`
+	BandwidthLimitKBps   int64  `json:"bandwidthLimitKBps"`
+}
+
+// providerFromRunConfig builds the storage provider named by a command
+// payload. Provider names follow the API's backup_configs.provider enum, with
+// the short agent.yaml spellings accepted as aliases.
+func providerFromRunConfig(name string, cfg *backupRunProviderConfig) (providers.BackupProvider, error) {
+	switch name {
+	case "s3":
+		return providers.NewS3ProviderWithEndpoint(
+			cfg.Bucket, cfg.Region, cfg.Endpoint, cfg.AccessKey, cfg.SecretKey, ""), nil
+	case "local":
+		return providers.NewLocalProvider(cfg.Path), nil
+	case "azure_blob", "azure":
+		return providers.NewAzureProvider(cfg.AccountName, cfg.AccountKey, cfg.Container)
+	case "google_cloud", "gcs":
+		credentials, err := gcsCredentialsFromPayload(cfg.CredentialsJSON)
+		if err != nil {
+			return nil, err
+		}
+		return providers.NewGCSProvider(cfg.Bucket, credentials)
+	case "backblaze", "b2":
+		return providers.NewB2Provider(cfg.KeyID, cfg.ApplicationKey, cfg.Bucket)
+	case "sftp":
+		return providers.NewSFTPProvider(providers.SFTPConfig{
+			Host:                 cfg.Host,
+			Port:                 cfg.Port,
+			Username:             cfg.Username,
+			Password:             cfg.Password,
+			PrivateKey:           []byte(cfg.PrivateKey),
+			PrivateKeyPassphrase: cfg.PrivateKeyPassphrase,
+			HostKeyFingerprint:   cfg.HostKeyFingerprint,
+			BasePath:             cfg.Path,
+			BandwidthLimit:       cfg.BandwidthLimitKBps * 1024,
+		})
+	case "webdav":
+		return providers.NewWebDAVProvider(providers.WebDAVConfig{
+			URL:            cfg.URL,
+			Username:       cfg.Username,
+			Password:       cfg.Password,
+			TLSFingerprint: cfg.TLSFingerprint,
+			BandwidthLimit: cfg.BandwidthLimitKBps * 1024,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported backup provider %q", name)
+	}
+}
+
+func gcsCredentialsFromPayload(raw json.RawMessage) ([]byte, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil, nil
+	}
+	if trimmed[0] != '"' {
+		return trimmed, nil
+	}
+	var encoded string
+	if err := json.Unmarshal(trimmed, &encoded); err != nil {
+		return nil, fmt.Errorf("invalid gcs credentials: %w", err)
+	}
+	if strings.TrimSpace(encoded) == "" {
+		return nil, nil
+	}
+	if !json.Valid([]byte(encoded)) {
+		return nil, fmt.Errorf("invalid gcs credentials: not a JSON service account key")
+	}
+	return []byte(encoded), nil
 }
 
 // defaultVSS decides whether VSS shadow-copy defaults on for a backup_run,
@@ -106,6 +251,10 @@ func managerFromBackupRunPayload(payload json.RawMessage) (*backup.BackupManager
 		// by apps/api/src/jobs/backupWorker.ts (a future policy toggle can); when
 		// absent the agent defaults it itself below.
 		Vss *bool `json:"vss,omitempty"`
+		// BandwidthLimitKBps and Windows come from the policy schedule and
+		// keep a late (missed-run) backup off the link during business hours.
+		BandwidthLimitKBps int64                 `json:"bandwidthLimitKBps"`
+		Windows            []backup.BackupWindow `json:"windows"`
 	}
 	if err := json.Unmarshal(payload, &p); err != nil {
 		return nil, fmt.Errorf("invalid backup_run payload: %w", err)
@@ -113,6 +262,9 @@ func managerFromBackupRunPayload(payload json.RawMessage) (*backup.BackupManager
 	if p.ProviderConfig == nil || p.Provider == "" {
 		return nil, nil
 	}
+	if err := backup.ValidateBackupWindows(p.Windows); err != nil {
+		return nil, err
+	}
 	// vssEnabled defaults to on for server-dispatched Windows file backups so
 	// locked files (open documents, DB files) aren't silently skipped — VSS
 	// failure is already non-fatal (backup.go's RunBackupWithExcludes proceeds
@@ -125,16 +277,13 @@ func managerFromBackupRunPayload(payload json.RawMessage) (*backup.BackupManager
 	if p.Vss != nil {
 		vssEnabled = *p.Vss
 	}
-	var provider providers.BackupProvider
-	switch p.Provider {
-	case "s3":
-		provider = providers.NewS3ProviderWithEndpoint(
-			p.ProviderConfig.Bucket, p.ProviderConfig.Region, p.ProviderConfig.Endpoint,
-			p.ProviderConfig.AccessKey, p.ProviderConfig.SecretKey, "")
-	case "local":
-		provider = providers.NewLocalProvider(p.ProviderConfig.Path)
-	default:
-		return nil, fmt.Errorf("unsupported backup provider %q", p.Provider)
+	provider, err := providerFromRunConfig(p.Provider, p.ProviderConfig)
+	if err != nil {
+		return nil, err
+	}
+	provider, err = withClientEncryption(provider, payload)
+	if err != nil {
+		return nil, err
 	}
 	// system_image mode carries no file paths: the backup content is the
 	// collected system-state staging dir. The server fans a `system_image`
@@ -150,6 +299,8 @@ func managerFromBackupRunPayload(payload json.RawMessage) (*backup.BackupManager
 			Provider:           provider,
 			SystemStateEnabled: true,
 			VSSEnabled:         vssEnabled,
+			BandwidthLimit:     p.BandwidthLimitKBps * 1024,
+			Windows:            p.Windows,
 		}), nil
 	}
 	if len(p.Paths) == 0 {
@@ -163,10 +314,12 @@ func managerFromBackupRunPayload(payload json.RawMessage) (*backup.BackupManager
 	// Retention: 0 makes DeleteSnapshotContext a no-op (it returns early on
 	// retention <= 0), leaving the server as the sole retention authority.
 	return backup.NewBackupManager(backup.BackupConfig{
-		Provider:   provider,
-		Paths:      p.Paths,
-		Retention:  0,
-		VSSEnabled: vssEnabled,
+		Provider:       provider,
+		Paths:          p.Paths,
+		Retention:      0,
+		VSSEnabled:     vssEnabled,
+		BandwidthLimit: p.BandwidthLimitKBps * 1024,
+		Windows:        p.Windows,
 	}), nil
 }
 
@@ -206,16 +359,11 @@ func restoreProviderFromPayload(payload json.RawMessage) (providers.BackupProvid
 	if p.ProviderConfig == nil || p.Provider == "" {
 		return nil, nil
 	}
-	switch p.Provider {
-	case "s3":
-		return providers.NewS3ProviderWithEndpoint(
-			p.ProviderConfig.Bucket, p.ProviderConfig.Region, p.ProviderConfig.Endpoint,
-			p.ProviderConfig.AccessKey, p.ProviderConfig.SecretKey, ""), nil
-	case "local":
-		return providers.NewLocalProvider(p.ProviderConfig.Path), nil
-	default:
-		return nil, fmt.Errorf("unsupported backup provider %q", p.Provider)
+	provider, err := providerFromRunConfig(p.Provider, p.ProviderConfig)
+	if err != nil {
+		return nil, err
 	}
+	return withClientEncryption(provider, payload)
 }
 
 // restoreProviderForCommand resolves the provider to use for restore/verify/
@@ -346,11 +494,12 @@ func execBackupTestRestore(payload json.RawMessage, mgr *backup.BackupManager, v
 	}
 	var p struct {
 		SnapshotID string `json:"snapshotId"`
+		SampleSize int    `json:"sampleSize"`
 	}
 	if err := json.Unmarshal(payload, &p); err != nil {
 		return fail("invalid test restore payload: " + err.Error())
 	}
-	result, err := backup.TestRestore(restoreProvider, p.SnapshotID, nil)
+	result, err := backup.TestRestoreSample(restoreProvider, p.SnapshotID, p.SampleSize, nil)
 	return marshalResult(result, err)
 }
 
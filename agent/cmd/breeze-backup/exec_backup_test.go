@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net"
 	"os"
 	"path/filepath"
@@ -198,6 +199,96 @@ func TestApplyCommandStorageEncryptionAllowsDisabledPayload(t *testing.T) {
 	}
 }
 
+func TestClientEncryptionWrapsPayloadProvider(t *testing.T) {
+	payload, err := json.Marshal(map[string]any{
+		"provider":       "local",
+		"providerConfig": map[string]any{"path": t.TempDir()},
+		"paths":          []string{t.TempDir()},
+		"storageEncryption": map[string]any{
+			"required": true,
+			"mode":     "client-aes-gcm",
+		},
+		"clientEncryption": map[string]any{
+			"keyId": "device-key-1",
+			"key":   "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=",
+		},
+	})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	mgr, err := managerFromBackupRunPayload(payload)
+	if err != nil {
+		t.Fatalf("managerFromBackupRunPayload: %v", err)
+	}
+	encrypted, ok := mgr.GetProvider().(*providers.EncryptedProvider)
+	if !ok || encrypted.KeyID() != "device-key-1" {
+		t.Fatalf("expected an encrypted provider, got %T", mgr.GetProvider())
+	}
+	if err := applyCommandStorageEncryption(mgr.GetProvider(), payload); err != nil {
+		t.Fatalf("apply encryption: %v", err)
+	}
+
+	restoreProvider, err := restoreProviderFromPayload(payload)
+	if err != nil {
+		t.Fatalf("restoreProviderFromPayload: %v", err)
+	}
+	if _, ok := restoreProvider.(*providers.EncryptedProvider); !ok {
+		t.Fatalf("expected an encrypted restore provider, got %T", restoreProvider)
+	}
+}
+
+func TestClientEncryptionPlaintextSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "legacy"), []byte("written before encryption"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	restoreFrom := func(plaintextSnapshot bool) error {
+		payload, _ := json.Marshal(map[string]any{
+			"provider":       "local",
+			"providerConfig": map[string]any{"path": dir},
+			"clientEncryption": map[string]any{
+				"keyId":             "device-key-1",
+				"key":               "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=",
+				"plaintextSnapshot": plaintextSnapshot,
+			},
+		})
+		provider, err := restoreProviderFromPayload(payload)
+		if err != nil {
+			t.Fatalf("restoreProviderFromPayload: %v", err)
+		}
+		return provider.Download("legacy", filepath.Join(t.TempDir(), "out"))
+	}
+
+	if err := restoreFrom(false); !errors.Is(err, providers.ErrUnencryptedObject) {
+		t.Fatalf("restore of an encrypted snapshot = %v, want ErrUnencryptedObject", err)
+	}
+	if err := restoreFrom(true); err != nil {
+		t.Fatalf("restore of a pre-encryption snapshot: %v", err)
+	}
+}
+
+func TestClientEncryptionFailsClosedWithoutKey(t *testing.T) {
+	payload, err := json.Marshal(map[string]any{
+		"storageEncryption": map[string]any{
+			"required": true,
+			"mode":     "client-aes-gcm",
+		},
+	})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	if err := applyCommandStorageEncryption(providers.NewLocalProvider(t.TempDir()), payload); err == nil {
+		t.Fatal("expected client-side encryption without a key to fail")
+	}
+
+	bad, _ := json.Marshal(map[string]any{"clientEncryption": map[string]any{"keyId": "k", "key": "c2hvcnQ="}})
+	if _, err := withClientEncryption(providers.NewLocalProvider(t.TempDir()), bad); err == nil {
+		t.Fatal("expected a short key to be rejected")
+	}
+}
+
 func TestExecBMRRecoverRequiresTokenAndServer(t *testing.T) {
 	payload, err := json.Marshal(map[string]any{
 		"snapshotId": "snap-1",
@@ -374,6 +465,11 @@ func TestManagerFromBackupRunPayload(t *testing.T) {
 			payload: `{"provider":`,
 			wantErr: true,
 		},
+		{
+			name:    "invalid backup window errors",
+			payload: `{"provider":"local","providerConfig":{"path":"/var/backups"},"paths":["/data"],"windows":[{"start":"7pm","durationMinutes":60}]}`,
+			wantErr: true,
+		},
 		{
 			// The server fans a `system_image` selection out as a backup_run
 			// carrying `systemImage:true` and no `paths` (backupWorker.ts
@@ -457,6 +553,109 @@ func TestManagerFromBackupRunPayload(t *testing.T) {
 	}
 }
 
+func TestProviderFromRunConfigRemoteProviders(t *testing.T) {
+	tests := []struct {
+		name         string
+		provider     string
+		config       string
+		wantIdentity string
+		wantErr      bool
+	}{
+		{
+			name:         "azure blob",
+			provider:     "azure_blob",
+			config:       `{"accountName":"acct","accountKey":"a2V5","container":"backups"}`,
+			wantIdentity: "azure|acct|backups",
+		},
+		{
+			name:     "azure blob without key",
+			provider: "azure",
+			config:   `{"accountName":"acct","container":"backups"}`,
+			wantErr:  true,
+		},
+		{
+			name:         "gcs with credentials object",
+			provider:     "google_cloud",
+			config:       `{"bucket":"gcs-bucket","credentialsJson":{"type":"service_account"}}`,
+			wantIdentity: "gcs|gcs-bucket",
+		},
+		{
+			name:         "gcs with credentials string",
+			provider:     "gcs",
+			config:       `{"bucket":"gcs-bucket","credentialsJson":"{\"type\":\"service_account\"}"}`,
+			wantIdentity: "gcs|gcs-bucket",
+		},
+		{
+			name:     "gcs with non-JSON credentials",
+			provider: "gcs",
+			config:   `{"bucket":"gcs-bucket","credentialsJson":"not-json"}`,
+			wantErr:  true,
+		},
+		{
+			name:         "backblaze",
+			provider:     "backblaze",
+			config:       `{"bucket":"b2-bucket","keyId":"kid","applicationKey":"app"}`,
+			wantIdentity: "b2|b2-bucket",
+		},
+		{
+			name:         "sftp",
+			provider:     "sftp",
+			config:       `{"host":"nas.local","port":2222,"username":"backup","password":"pw","hostKeyFingerprint":"SHA256:abc","path":"/volume1/backups","bandwidthLimitKBps":512}`,
+			wantIdentity: "sftp|backup@nas.local:2222|/volume1/backups",
+		},
+		{
+			name:     "sftp without host key fingerprint",
+			provider: "sftp",
+			config:   `{"host":"nas.local","username":"backup","password":"pw"}`,
+			wantErr:  true,
+		},
+		{
+			name:         "webdav",
+			provider:     "webdav",
+			config:       `{"url":"https://nas.local:5006/backups","username":"backup","password":"pw"}`,
+			wantIdentity: "webdav|https://nas.local:5006/backups",
+		},
+		{
+			name:     "webdav over plain http",
+			provider: "webdav",
+			config:   `{"url":"http://nas.local:5005/backups"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "backblaze without application key",
+			provider: "b2",
+			config:   `{"bucket":"b2-bucket","keyId":"kid"}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg backupRunProviderConfig
+			if err := json.Unmarshal([]byte(tt.config), &cfg); err != nil {
+				t.Fatalf("unmarshal config: %v", err)
+			}
+			provider, err := providerFromRunConfig(tt.provider, &cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got provider %T", provider)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			idp, ok := provider.(providers.JournalIdentity)
+			if !ok {
+				t.Fatalf("provider %T does not report a backup identity", provider)
+			}
+			if got := idp.BackupIdentity(); got != tt.wantIdentity {
+				t.Fatalf("identity = %q, want %q", got, tt.wantIdentity)
+			}
+		})
+	}
+}
+
 func TestParseBackupRunExcludes(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -592,3 +791,19 @@ func TestBackupStopCancelsPayloadDispatchedBackupRun(t *testing.T) {
 		t.Fatal("backup_run did not unwind after backup_stop cancelled it")
 	}
 }
+
+func TestManagerFromBackupRunPayload_BandwidthAndWindows(t *testing.T) {
+	payload := `{"provider":"local","providerConfig":{"path":"/var/backups"},"paths":["/data"],` +
+		`"bandwidthLimitKBps":512,"windows":[{"start":"19:00","durationMinutes":720,"timezone":"UTC"}]}`
+	mgr, err := managerFromBackupRunPayload(json.RawMessage(payload))
+	if err != nil || mgr == nil {
+		t.Fatalf("managerFromBackupRunPayload: mgr=%v err=%v", mgr, err)
+	}
+	if got := mgr.GetBandwidthLimit(); got != 512*1024 {
+		t.Fatalf("BandwidthLimit = %d, want %d", got, 512*1024)
+	}
+	windows := mgr.GetWindows()
+	if len(windows) != 1 || windows[0].Start != "19:00" || windows[0].DurationMinutes != 720 || windows[0].Timezone != "UTC" {
+		t.Fatalf("Windows = %+v", windows)
+	}
+}
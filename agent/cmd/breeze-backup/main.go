@@ -217,7 +217,7 @@ func authenticate(conn *ipc.Conn) error {
 }
 
 func initBackupManager(cfg *config.Config) *backup.BackupManager {
-	if cfg == nil || !cfg.BackupEnabled || len(cfg.BackupPaths) == 0 {
+	if cfg == nil || !cfg.BackupEnabled || (len(cfg.BackupPaths) == 0 && len(cfg.BackupPathPolicies) == 0) {
 		return nil
 	}
 
@@ -228,6 +228,13 @@ func initBackupManager(cfg *config.Config) *backup.BackupManager {
 			cfg.BackupS3Bucket, cfg.BackupS3Region,
 			cfg.BackupS3AccessKey, cfg.BackupS3SecretKey, "",
 		)
+	case "azure", "azure_blob", "gcs", "google_cloud", "b2", "backblaze", "sftp", "webdav":
+		provider, err := remoteProviderFromConfig(cfg)
+		if err != nil {
+			slog.Error("backup provider misconfigured, backups from agent.yaml disabled", "provider", cfg.BackupProvider, "error", err.Error())
+			return nil
+		}
+		backupProvider = provider
 	default:
 		localPath := cfg.BackupLocalPath
 		if localPath == "" {
@@ -250,18 +257,100 @@ func initBackupManager(cfg *config.Config) *backup.BackupManager {
 		}
 	}
 
+	windows := make([]backup.BackupWindow, 0, len(cfg.BackupWindows))
+	for _, w := range cfg.BackupWindows {
+		windows = append(windows, backup.BackupWindow{
+			Days:            w.Days,
+			Start:           w.Start,
+			DurationMinutes: w.DurationMinutes,
+			Timezone:        w.Timezone,
+		})
+	}
+	// A bad window must not silently become "back up any time".
+	if err := backup.ValidateBackupWindows(windows); err != nil {
+		slog.Error("backup windows misconfigured, backups from agent.yaml disabled", "error", err.Error())
+		return nil
+	}
+
+	pathPolicies := make([]backup.PathPolicy, 0, len(cfg.BackupPathPolicies))
+	for _, p := range cfg.BackupPathPolicies {
+		pathPolicies = append(pathPolicies, backup.PathPolicy{
+			Path:          p.Path,
+			Include:       p.Include,
+			Exclude:       p.Exclude,
+			RetentionDays: p.RetentionDays,
+		})
+	}
+	if err := backup.ValidatePathPolicies(pathPolicies, cfg.BackupPaths); err != nil {
+		slog.Error("backup path policies misconfigured, backups from agent.yaml disabled", "error", err.Error())
+		return nil
+	}
+
 	mgr := backup.NewBackupManager(backup.BackupConfig{
 		Provider:           backupProvider,
 		Paths:              cfg.BackupPaths,
+		PathPolicies:       pathPolicies,
 		Retention:          retention,
 		VSSEnabled:         cfg.BackupVSSEnabled,
 		SystemStateEnabled: cfg.BackupSystemStateEnabled,
 		StagingDir:         stagingDir,
+		BandwidthLimit:     int64(cfg.BackupBandwidthLimitKBps) * 1024,
+		Windows:            windows,
 	})
 
 	return mgr
 }
 
+// remoteProviderFromConfig builds the Azure Blob, GCS, B2, SFTP or WebDAV
+// provider named by backup_provider from its agent.yaml settings.
+func remoteProviderFromConfig(cfg *config.Config) (providers.BackupProvider, error) {
+	switch cfg.BackupProvider {
+	case "azure", "azure_blob":
+		return providers.NewAzureProvider(cfg.BackupAzureAccount, cfg.BackupAzureAccessKey, cfg.BackupAzureContainer)
+	case "gcs", "google_cloud":
+		var credentials []byte
+		if cfg.BackupGCSCredentialsFile != "" {
+			data, err := os.ReadFile(cfg.BackupGCSCredentialsFile)
+			if err != nil {
+				return nil, fmt.Errorf("read gcs credentials file: %w", err)
+			}
+			credentials = data
+		}
+		return providers.NewGCSProvider(cfg.BackupGCSBucket, credentials)
+	case "b2", "backblaze":
+		return providers.NewB2Provider(cfg.BackupB2KeyID, cfg.BackupB2SecretKey, cfg.BackupB2Bucket)
+	case "sftp":
+		var privateKey []byte
+		if cfg.BackupSFTPPrivateKeyFile != "" {
+			data, err := os.ReadFile(cfg.BackupSFTPPrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("read sftp private key file: %w", err)
+			}
+			privateKey = data
+		}
+		return providers.NewSFTPProvider(providers.SFTPConfig{
+			Host:               cfg.BackupSFTPHost,
+			Port:               cfg.BackupSFTPPort,
+			Username:           cfg.BackupSFTPUsername,
+			Password:           cfg.BackupSFTPPassword,
+			PrivateKey:         privateKey,
+			HostKeyFingerprint: cfg.BackupSFTPHostKeyFingerprint,
+			BasePath:           cfg.BackupSFTPPath,
+			BandwidthLimit:     int64(cfg.BackupBandwidthLimitKBps) * 1024,
+		})
+	case "webdav":
+		return providers.NewWebDAVProvider(providers.WebDAVConfig{
+			URL:            cfg.BackupWebDAVURL,
+			Username:       cfg.BackupWebDAVUsername,
+			Password:       cfg.BackupWebDAVPassword,
+			TLSFingerprint: cfg.BackupWebDAVTLSFingerprint,
+			BandwidthLimit: int64(cfg.BackupBandwidthLimitKBps) * 1024,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported backup provider %q", cfg.BackupProvider)
+	}
+}
+
 type vaultManagerRef struct {
 	mu  sync.RWMutex
 	mgr *backup.VaultManager
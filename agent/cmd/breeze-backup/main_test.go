@@ -11,6 +11,7 @@ import (
 	"github.com/breeze-rmm/agent/internal/backup"
 	"github.com/breeze-rmm/agent/internal/backup/providers"
 	"github.com/breeze-rmm/agent/internal/backupipc"
+	"github.com/breeze-rmm/agent/internal/config"
 	"github.com/breeze-rmm/agent/internal/ipc"
 )
 
@@ -365,3 +366,133 @@ func TestHandleBackupCommand_AsyncResultDurationTracksElapsed(t *testing.T) {
 
 	<-done
 }
+
+func TestInitBackupManager_RemoteProviders(t *testing.T) {
+	credsPath := filepath.Join(t.TempDir(), "gcs.json")
+	if err := os.WriteFile(credsPath, []byte(`{"type":"service_account"}`), 0o600); err != nil {
+		t.Fatalf("write credentials: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		cfg          config.Config
+		wantIdentity string // "" means no manager
+	}{
+		{
+			name: "azure",
+			cfg: config.Config{BackupProvider: "azure", BackupAzureAccount: "acct",
+				BackupAzureAccessKey: "a2V5", BackupAzureContainer: "backups"},
+			wantIdentity: "azure|acct|backups",
+		},
+		{
+			name:         "gcs",
+			cfg:          config.Config{BackupProvider: "gcs", BackupGCSBucket: "gcs-bucket", BackupGCSCredentialsFile: credsPath},
+			wantIdentity: "gcs|gcs-bucket",
+		},
+		{
+			name: "b2",
+			cfg: config.Config{BackupProvider: "b2", BackupB2Bucket: "b2-bucket",
+				BackupB2KeyID: "kid", BackupB2SecretKey: "app"},
+			wantIdentity: "b2|b2-bucket",
+		},
+		{
+			name: "sftp",
+			cfg: config.Config{BackupProvider: "sftp", BackupSFTPHost: "nas.local", BackupSFTPUsername: "backup",
+				BackupSFTPPassword: "pw", BackupSFTPHostKeyFingerprint: "SHA256:abc", BackupSFTPPath: "/volume1/backups"},
+			wantIdentity: "sftp|backup@nas.local:22|/volume1/backups",
+		},
+		{
+			name:         "webdav",
+			cfg:          config.Config{BackupProvider: "webdav", BackupWebDAVURL: "https://nas.local:5006/backups"},
+			wantIdentity: "webdav|https://nas.local:5006/backups",
+		},
+		{
+			// A misconfigured cloud provider must not silently fall back to
+			// local storage.
+			name: "b2 missing key disables the manager",
+			cfg:  config.Config{BackupProvider: "b2", BackupB2Bucket: "b2-bucket"},
+		},
+		{
+			name: "gcs unreadable credentials file disables the manager",
+			cfg: config.Config{BackupProvider: "gcs", BackupGCSBucket: "gcs-bucket",
+				BackupGCSCredentialsFile: filepath.Join(t.TempDir(), "missing.json")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.BackupEnabled = true
+			cfg.BackupPaths = []string{t.TempDir()}
+			mgr := initBackupManager(&cfg)
+			if tt.wantIdentity == "" {
+				if mgr != nil {
+					t.Fatalf("expected no manager, got provider %T", mgr.GetProvider())
+				}
+				return
+			}
+			if mgr == nil {
+				t.Fatal("expected a manager, got nil")
+			}
+			idp, ok := mgr.GetProvider().(providers.JournalIdentity)
+			if !ok {
+				t.Fatalf("provider %T does not report a backup identity", mgr.GetProvider())
+			}
+			if got := idp.BackupIdentity(); got != tt.wantIdentity {
+				t.Fatalf("identity = %q, want %q", got, tt.wantIdentity)
+			}
+		})
+	}
+}
+
+func TestInitBackupManager_BandwidthAndWindows(t *testing.T) {
+	cfg := config.Config{
+		BackupEnabled:            true,
+		BackupPaths:              []string{t.TempDir()},
+		BackupLocalPath:          t.TempDir(),
+		BackupBandwidthLimitKBps: 256,
+		BackupWindows: []config.BackupWindowConfig{
+			{Days: []string{"mon", "fri"}, Start: "19:00", DurationMinutes: 600, Timezone: "UTC"},
+		},
+	}
+	mgr := initBackupManager(&cfg)
+	if mgr == nil {
+		t.Fatal("expected a manager, got nil")
+	}
+	if got := mgr.GetBandwidthLimit(); got != 256*1024 {
+		t.Fatalf("BandwidthLimit = %d, want %d", got, 256*1024)
+	}
+	if windows := mgr.GetWindows(); len(windows) != 1 || len(windows[0].Days) != 2 || windows[0].DurationMinutes != 600 {
+		t.Fatalf("Windows = %+v", windows)
+	}
+
+	// A window that doesn't parse must not widen to "back up any time".
+	cfg.BackupWindows = []config.BackupWindowConfig{{Start: "19:00"}}
+	if mgr := initBackupManager(&cfg); mgr != nil {
+		t.Fatal("expected no manager for an invalid backup window")
+	}
+}
+
+func TestInitBackupManager_PathPolicies(t *testing.T) {
+	desktop := t.TempDir()
+	cfg := config.Config{
+		BackupEnabled:   true,
+		BackupLocalPath: t.TempDir(),
+		BackupPathPolicies: []config.BackupPathPolicyConfig{
+			{Path: desktop, Exclude: []string{"*.lnk"}, RetentionDays: 30},
+		},
+	}
+	mgr := initBackupManager(&cfg)
+	if mgr == nil {
+		t.Fatal("path policies alone must be enough to enable agent.yaml backups")
+	}
+	if policies := mgr.GetPathPolicies(); len(policies) != 1 || policies[0].Path != desktop || policies[0].RetentionDays != 30 {
+		t.Fatalf("PathPolicies = %+v", policies)
+	}
+
+	// The same root under backup_paths and a policy is ambiguous.
+	cfg.BackupPaths = []string{desktop}
+	if mgr := initBackupManager(&cfg); mgr != nil {
+		t.Fatal("expected no manager for a root configured twice")
+	}
+}
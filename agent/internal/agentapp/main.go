@@ -1232,6 +1232,16 @@ func enrollDevice(enrollmentKey string) {
 		}
 	}
 
+	// The transcript signing key is pinned server-side at enrollment. Enroll
+	// without it if the key can't be loaded; the first transcript export
+	// registers it instead.
+	var transcriptPublicKey string
+	if key, err := audit.LoadOrCreateTranscriptKey(filepath.Join(config.GetDataDir(), audit.TranscriptKeyFile)); err != nil {
+		enrollLog.Warn("transcript signing key unavailable, enrolling without it", "error", err.Error())
+	} else {
+		transcriptPublicKey = audit.TranscriptPublicKey(key)
+	}
+
 	enrollReq := &api.EnrollRequest{
 		EnrollmentKey:          enrollmentKey,
 		EnrollmentSecret:       secret,
@@ -1243,6 +1253,7 @@ func enrollDevice(enrollmentKey string) {
 		DeviceRole:             deviceRole,
 		IsVirtual:              virt.IsVirtual,
 		VirtualizationPlatform: virt.Platform,
+		TranscriptPublicKey:    transcriptPublicKey,
 		HardwareInfo: &api.HardwareInfo{
 			CPUModel:                hardwareInfo.CPUModel,
 			CPUCores:                hardwareInfo.CPUCores,
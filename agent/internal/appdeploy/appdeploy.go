@@ -0,0 +1,131 @@
+// Package appdeploy installs third-party applications the server defines in
+// its deployment catalog: custom MSI, EXE, PKG, DMG, DEB and RPM packages
+// that no package manager provider (winget, Chocolatey, Homebrew) carries.
+//
+// Each app definition names a download URL, its SHA-256, the silent
+// install arguments and a detection rule. The agent skips apps the rule
+// already finds, downloads and verifies the rest, runs the installer and
+// re-evaluates the rule so the reported status reflects the device's real
+// state. Results use the same shape as install_patches so the server can
+// record them with the patch job results.
+package appdeploy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/breeze-rmm/agent/internal/logging"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+var log = logging.L("appdeploy")
+
+// Actions an app_deploy command can request.
+const (
+	ActionInstall = "install"
+	ActionDetect  = "detect"
+)
+
+// Per-app statuses. Installs report installed, skipped or failed; detect-only
+// runs report detected, not_detected or unknown when the rule cannot be
+// evaluated on this platform.
+const (
+	StatusInstalled   = "installed"
+	StatusSkipped     = "skipped"
+	StatusFailed      = "failed"
+	StatusDetected    = "detected"
+	StatusNotDetected = "not_detected"
+	StatusUnknown     = "unknown"
+)
+
+// App is one catalog entry as sent by the server.
+type App struct {
+	ID                string
+	Name              string
+	Version           string
+	DownloadURL       string
+	FileName          string
+	FileType          string
+	Checksum          string
+	SilentInstallArgs string
+	ForceReinstall    bool
+
+	// detectionRules keeps the raw payload clauses so the install path hands
+	// tools.InstallSoftware exactly what the server sent.
+	detectionRules []any
+}
+
+// DetectionRules returns the app's parsed detection rule clauses.
+func (a App) DetectionRules() []tools.DetectionRule {
+	return tools.ParseDetectionRules(map[string]any{"detectionRules": a.detectionRules})
+}
+
+// Title returns the name shown in progress and results, falling back to the
+// catalog id.
+func (a App) Title() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.ID
+}
+
+// ParseApps reads the app definitions from a command payload: either an
+// "apps" array or a single definition at the top level. Entries that are not
+// objects are ignored; an entry missing its id fails the whole payload so a
+// result can always be tied back to its catalog row.
+func ParseApps(payload map[string]any) ([]App, error) {
+	var raw []any
+	if list, ok := payload["apps"].([]any); ok {
+		raw = list
+	} else if _, ok := payload["id"]; ok {
+		raw = []any{payload}
+	}
+
+	apps := make([]App, 0, len(raw))
+	for i, item := range raw {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		app := App{
+			ID:                strings.TrimSpace(tools.GetPayloadString(obj, "id", "")),
+			Name:              tools.GetPayloadString(obj, "name", ""),
+			Version:           tools.GetPayloadString(obj, "version", ""),
+			DownloadURL:       tools.GetPayloadString(obj, "downloadUrl", ""),
+			FileName:          tools.GetPayloadString(obj, "fileName", ""),
+			FileType:          strings.ToLower(strings.TrimSpace(tools.GetPayloadString(obj, "fileType", ""))),
+			Checksum:          tools.GetPayloadString(obj, "checksum", ""),
+			SilentInstallArgs: tools.GetPayloadString(obj, "silentInstallArgs", ""),
+			ForceReinstall:    tools.GetPayloadBool(obj, "forceReinstall", false),
+		}
+		if rules, ok := obj["detectionRules"].([]any); ok {
+			app.detectionRules = rules
+		}
+		if app.ID == "" {
+			return nil, fmt.Errorf("app %d has no id", i+1)
+		}
+		apps = append(apps, app)
+	}
+	if len(apps) == 0 {
+		return nil, fmt.Errorf("no apps provided")
+	}
+	return apps, nil
+}
+
+// installPayload builds the software_install payload for the app.
+func (a App) installPayload() map[string]any {
+	payload := map[string]any{
+		"downloadUrl":       a.DownloadURL,
+		"fileName":          a.FileName,
+		"fileType":          a.FileType,
+		"checksum":          a.Checksum,
+		"silentInstallArgs": a.SilentInstallArgs,
+		"softwareName":      a.Title(),
+		"version":           a.Version,
+		"forceReinstall":    a.ForceReinstall,
+	}
+	if len(a.detectionRules) > 0 {
+		payload["detectionRules"] = a.detectionRules
+	}
+	return payload
+}
@@ -0,0 +1,139 @@
+package appdeploy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+func catalogApp() map[string]any {
+	return map[string]any{
+		"id":                "app-1",
+		"name":              "Acme Client",
+		"version":           "4.2.0",
+		"downloadUrl":       "https://downloads.example.com/acme.msi",
+		"fileName":          "acme.msi",
+		"fileType":          "MSI",
+		"checksum":          strings.Repeat("a", 64),
+		"silentInstallArgs": "/qn /norestart",
+		"detectionRules": []any{
+			map[string]any{"type": "msi_product_code", "productCode": "{11111111-2222-3333-4444-555555555555}"},
+		},
+	}
+}
+
+func TestParseAppsAcceptsListOrSingleDefinition(t *testing.T) {
+	apps, err := ParseApps(map[string]any{"apps": []any{catalogApp(), "garbage"}})
+	if err != nil {
+		t.Fatalf("ParseApps: %v", err)
+	}
+	if len(apps) != 1 || apps[0].FileType != "msi" || len(apps[0].DetectionRules()) != 1 {
+		t.Fatalf("unexpected apps %+v", apps)
+	}
+
+	apps, err = ParseApps(catalogApp())
+	if err != nil || len(apps) != 1 || apps[0].ID != "app-1" {
+		t.Fatalf("expected the top-level definition, got %+v (%v)", apps, err)
+	}
+}
+
+func TestParseAppsRejectsMissingIDAndEmptyPayload(t *testing.T) {
+	noID := catalogApp()
+	delete(noID, "id")
+	if _, err := ParseApps(map[string]any{"apps": []any{noID}}); err == nil {
+		t.Fatal("expected an error for an app without an id")
+	}
+	if _, err := ParseApps(map[string]any{}); err == nil {
+		t.Fatal("expected an error for an empty payload")
+	}
+}
+
+func testDeployer(install func(map[string]any) tools.CommandResult) (*Deployer, *map[string]any) {
+	var sent map[string]any
+	return &Deployer{
+		install: func(payload map[string]any) tools.CommandResult {
+			sent = payload
+			return install(payload)
+		},
+		detect: tools.EvaluateDetectionRules,
+	}, &sent
+}
+
+func TestInstallPassesDefinitionToInstaller(t *testing.T) {
+	d, sent := testDeployer(func(map[string]any) tools.CommandResult {
+		return tools.NewSuccessResult(map[string]any{"exitCode": 3010, "detectionPerformed": true, "detail": "product code found"}, 5)
+	})
+	apps, _ := ParseApps(catalogApp())
+
+	result := d.Install(apps[0])
+	if result.Status != StatusInstalled || !result.RebootRequired || result.ExitCode != 3010 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if (*sent)["softwareName"] != "Acme Client" || (*sent)["silentInstallArgs"] != "/qn /norestart" || (*sent)["detectionRules"] == nil {
+		t.Fatalf("unexpected install payload %+v", *sent)
+	}
+	fields := result.Fields()
+	if fields["source"] != Source || fields["status"] != "installed" || fields["rebootRequired"] != true {
+		t.Fatalf("unexpected result fields %+v", fields)
+	}
+}
+
+func TestInstallReportsSkipAndFailure(t *testing.T) {
+	apps, _ := ParseApps(catalogApp())
+
+	d, _ := testDeployer(func(map[string]any) tools.CommandResult {
+		return tools.NewSuccessResult(map[string]any{"skipped": true, "detail": "already installed; product code found"}, 1)
+	})
+	if result := d.Install(apps[0]); result.Status != StatusSkipped || result.Message == "" {
+		t.Fatalf("expected a skip, got %+v", result)
+	}
+
+	d, _ = testDeployer(func(map[string]any) tools.CommandResult {
+		return tools.CommandResult{Status: "failed", ExitCode: 1603, Error: "installer exited with code 1603"}
+	})
+	if result := d.Install(apps[0]); result.Status != StatusFailed || result.ExitCode != 1603 || result.Error == "" {
+		t.Fatalf("expected a failure, got %+v", result)
+	}
+}
+
+func TestInstallRequiresChecksum(t *testing.T) {
+	def := catalogApp()
+	delete(def, "checksum")
+	apps, _ := ParseApps(def)
+	d, sent := testDeployer(func(map[string]any) tools.CommandResult {
+		t.Fatal("installer must not run without a checksum")
+		return tools.CommandResult{}
+	})
+
+	if result := d.Install(apps[0]); result.Status != StatusFailed || !strings.Contains(result.Error, "checksum") {
+		t.Fatalf("expected a checksum failure, got %+v", result)
+	}
+	if *sent != nil {
+		t.Fatal("expected no install payload")
+	}
+}
+
+func TestDetectReportsPresence(t *testing.T) {
+	present := t.TempDir()
+	def := catalogApp()
+	def["detectionRules"] = []any{map[string]any{"type": "file_exists", "path": present}}
+	apps, _ := ParseApps(def)
+	d := NewDeployer()
+
+	if result := d.Detect(apps[0]); result.Status != StatusDetected {
+		t.Fatalf("expected detected, got %+v", result)
+	}
+
+	def["detectionRules"] = []any{map[string]any{"type": "file_exists", "path": present + "/missing"}}
+	apps, _ = ParseApps(def)
+	if result := d.Detect(apps[0]); result.Status != StatusNotDetected {
+		t.Fatalf("expected not_detected, got %+v", result)
+	}
+
+	delete(def, "detectionRules")
+	apps, _ = ParseApps(def)
+	if result := d.Detect(apps[0]); result.Status != StatusUnknown {
+		t.Fatalf("expected unknown without rules, got %+v", result)
+	}
+}
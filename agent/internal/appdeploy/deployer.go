@@ -0,0 +1,136 @@
+package appdeploy
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+// Source is the patch result "source" for catalog apps.
+const Source = "app_catalog"
+
+// Result is the outcome for one app, reported in the patch result schema.
+type Result struct {
+	App            App
+	Status         string
+	Error          string
+	Message        string
+	ExitCode       int
+	RebootRequired bool
+}
+
+// Fields returns the result as an install_patches result entry.
+func (r Result) Fields() map[string]any {
+	fields := map[string]any{
+		"id":             r.App.ID,
+		"source":         Source,
+		"externalId":     r.App.ID,
+		"title":          r.App.Title(),
+		"version":        r.App.Version,
+		"status":         r.Status,
+		"rebootRequired": r.RebootRequired,
+	}
+	if r.Error != "" {
+		fields["error"] = r.Error
+	}
+	if r.Message != "" {
+		fields["message"] = r.Message
+	}
+	if r.Status == StatusInstalled || r.Status == StatusFailed {
+		fields["exitCode"] = r.ExitCode
+	}
+	return fields
+}
+
+// Deployer installs and detects catalog apps.
+type Deployer struct {
+	install func(payload map[string]any) tools.CommandResult
+	detect  func(rules []tools.DetectionRule) tools.DetectionOutcome
+}
+
+// NewDeployer creates a Deployer backed by the software_install pipeline.
+func NewDeployer() *Deployer {
+	return &Deployer{
+		install: tools.InstallSoftware,
+		detect:  tools.EvaluateDetectionRules,
+	}
+}
+
+// Install downloads, verifies and installs the app unless its detection rule
+// already finds it. A SHA-256 is required: catalog URLs point at vendor sites
+// and file shares the server does not control.
+func (d *Deployer) Install(app App) Result {
+	result := Result{App: app}
+	if app.DownloadURL == "" {
+		result.Status = StatusFailed
+		result.Error = "no download URL configured"
+		return result
+	}
+	if app.Checksum == "" {
+		result.Status = StatusFailed
+		result.Error = "no SHA-256 checksum configured"
+		return result
+	}
+
+	out := d.install(app.installPayload())
+	result.ExitCode = out.ExitCode
+	if out.Status != "completed" {
+		result.Status = StatusFailed
+		result.Error = out.Error
+		if result.Error == "" {
+			result.Error = fmt.Sprintf("installer exited with code %d", out.ExitCode)
+		}
+		return result
+	}
+
+	var summary struct {
+		Skipped            bool   `json:"skipped"`
+		ExitCode           int    `json:"exitCode"`
+		DetectionPerformed *bool  `json:"detectionPerformed"`
+		Detail             string `json:"detail"`
+	}
+	if err := json.Unmarshal([]byte(out.Stdout), &summary); err != nil {
+		log.Warn("unparseable install result", "app", app.ID, "error", err.Error())
+	}
+	result.ExitCode = summary.ExitCode
+	if summary.Skipped {
+		result.Status = StatusSkipped
+		result.Message = summary.Detail
+		return result
+	}
+
+	result.Status = StatusInstalled
+	// 3010 and 1641 are the Windows installer "succeeded, reboot pending or
+	// underway" codes; tools.InstallSoftware already counts them as success.
+	result.RebootRequired = summary.ExitCode == 3010 || summary.ExitCode == 1641
+	if summary.DetectionPerformed != nil && !*summary.DetectionPerformed {
+		result.Message = "detection not performed: " + summary.Detail
+	} else {
+		result.Message = summary.Detail
+	}
+	return result
+}
+
+// Detect evaluates the app's detection rule without installing anything.
+func (d *Deployer) Detect(app App) Result {
+	result := Result{App: app}
+	rules := app.DetectionRules()
+	if len(rules) == 0 {
+		result.Status = StatusUnknown
+		result.Message = "no detection rules"
+		return result
+	}
+
+	outcome := d.detect(rules)
+	result.Message = outcome.Detail
+	switch {
+	case !outcome.Supported:
+		result.Status = StatusUnknown
+	case outcome.Detected:
+		result.Status = StatusDetected
+	default:
+		result.Status = StatusNotDetected
+	}
+	return result
+}
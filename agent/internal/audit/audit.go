@@ -23,6 +23,8 @@ const (
 	EventCommandExecuted  = "command_executed"
 	EventScriptExecution  = "script_execution"
 	EventServiceAction    = "service_action"
+	EventProcessAction    = "process_action"
+	EventRegistryAction   = "registry_action"
 	EventFileModification = "file_modification"
 	EventConfigChange     = "config_change"
 	EventPrivilegedOp     = "privileged_operation"
@@ -39,6 +41,32 @@ const (
 	// indexing started but never that it stopped, leaving the window
 	// unbounded (#2425).
 	EventWorkspaceIndexDeactivated = "workspace_index_deactivated"
+	// EventRemoteSessionConsent records how the end user's consent was
+	// handled for an attended remote desktop session: allowed, denied, or
+	// not asked because policy was notify-only or silent.
+	EventRemoteSessionConsent = "remote_session_consent"
+	// EventRemoteSessionChat records the chat transcript between the
+	// technician and the end user, written when the session ends.
+	EventRemoteSessionChat = "remote_session_chat"
+	// EventRemoteSessionRecording references a terminal session's asciinema
+	// recording by SHA-256, so the copy uploaded to the server can be checked
+	// against the local hash chain.
+	EventRemoteSessionRecording = "remote_session_recording"
+	// EventDefenderPolicyChange records a Microsoft Defender preference
+	// change made from the console, with each setting's before and after
+	// value.
+	EventDefenderPolicyChange = "defender_policy_change"
+	// EventFirewallChange records a host firewall rule or profile change
+	// made from the console.
+	EventFirewallChange = "firewall_change"
+	// EventNetworkIsolation records the host being isolated from the network
+	// or released, whether from the console or by a tripped ransomware
+	// canary.
+	EventNetworkIsolation = "network_isolation"
+	// EventLocalAccountChange records a local account removed from the
+	// administrators group, disabled, or given a rotated password. The
+	// password itself is never logged.
+	EventLocalAccountChange = "local_account_change"
 )
 
 // criticalEvents are event types that require fsync after writing.
@@ -49,6 +77,12 @@ var criticalEvents = map[string]bool{
 	EventConfigChange:              true,
 	EventWorkspaceIndexActivated:   true,
 	EventWorkspaceIndexDeactivated: true,
+	EventRemoteSessionConsent:      true,
+	EventRemoteSessionRecording:    true,
+	EventDefenderPolicyChange:      true,
+	EventFirewallChange:            true,
+	EventNetworkIsolation:          true,
+	EventLocalAccountChange:        true,
 }
 
 // Entry is a single audit log record.
@@ -135,7 +135,7 @@ func TestRotationWritesSentinel(t *testing.T) {
 }
 
 func TestCriticalEventsSet(t *testing.T) {
-	expected := []string{EventPrivilegedOp, EventAgentStart, EventAgentStop, EventConfigChange}
+	expected := []string{EventPrivilegedOp, EventAgentStart, EventAgentStop, EventConfigChange, EventRemoteSessionConsent}
 	for _, e := range expected {
 		if !criticalEvents[e] {
 			t.Errorf("event %q should be in criticalEvents", e)
@@ -0,0 +1,291 @@
+package audit
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Command transcripts for customer audits ("show me everything you did to my
+// server last month"). A transcript is compiled from the local hash-chained
+// audit log — the current file plus its rotated backups — so it covers every
+// command the agent actually received, not just what the server recorded. The
+// archive is signed with a per-device Ed25519 key. The agent sends its public
+// half at enrollment and the server verifies every upload against that pinned
+// key, so a leaked agent token alone can't forge a transcript.
+
+// TranscriptKeyFile is the signing key's file name in the agent data dir.
+const TranscriptKeyFile = "transcript_signing.key"
+
+// maxTranscriptLineBytes bounds a single audit line while reading. Entries
+// are small, so a longer line means a corrupt log and fails the read.
+const maxTranscriptLineBytes = 1 << 20
+
+// TranscriptCommand is one command reconstructed from its received/executed
+// audit pair.
+type TranscriptCommand struct {
+	CommandID    string `json:"commandId"`
+	Type         string `json:"type"`
+	ReceivedAt   string `json:"receivedAt,omitempty"`
+	CompletedAt  string `json:"completedAt,omitempty"`
+	Status       string `json:"status,omitempty"`
+	DurationMs   int64  `json:"durationMs,omitempty"`
+	Executor     string `json:"executor,omitempty"`
+	ScriptID     string `json:"scriptId,omitempty"`
+	ScriptSHA256 string `json:"scriptSha256,omitempty"`
+}
+
+// TranscriptManifest describes a transcript archive. The archive's
+// manifest.sig is the Ed25519 signature over the manifest.json bytes.
+type TranscriptManifest struct {
+	AgentID       string            `json:"agentId"`
+	Hostname      string            `json:"hostname,omitempty"`
+	From          time.Time         `json:"from"`
+	To            time.Time         `json:"to"`
+	GeneratedAt   time.Time         `json:"generatedAt"`
+	CommandCount  int               `json:"commandCount"`
+	EntryCount    int               `json:"entryCount"`
+	ChainVerified bool              `json:"chainVerified"`
+	Files         map[string]string `json:"files"` // name → sha256
+	PublicKey     string            `json:"publicKey"`
+}
+
+// ReadRange returns the audit entries timestamped within [from, to], oldest
+// first, across the current log and its rotated backups. chainOK reports
+// whether every retained entry hashes correctly and links to its predecessor
+// (a "genesis" link marks an agent restart and is accepted).
+func (l *Logger) ReadRange(from, to time.Time) ([]Entry, bool, error) {
+	if l == nil {
+		return nil, false, errors.New("audit logger unavailable")
+	}
+
+	var files []string
+	for i := l.maxBackups; i >= 1; i-- {
+		files = append(files, l.backupName(i))
+	}
+	files = append(files, l.filePath)
+
+	var (
+		entries []Entry
+		prev    string
+		chainOK = true
+	)
+	for _, path := range files {
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("open audit log %s: %w", path, err)
+		}
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), maxTranscriptLineBytes)
+		for scanner.Scan() {
+			var entry Entry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				chainOK = false
+				continue
+			}
+			if !l.entryLinks(entry, prev) {
+				chainOK = false
+			}
+			prev = entry.EntryHash
+
+			ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+			if err != nil || ts.Before(from) || ts.After(to) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		scanErr := scanner.Err()
+		f.Close()
+		if scanErr != nil {
+			return nil, false, fmt.Errorf("read audit log %s: %w", path, scanErr)
+		}
+	}
+	return entries, chainOK, nil
+}
+
+// entryLinks checks an entry's own hash and its link to the previous entry.
+func (l *Logger) entryLinks(entry Entry, prev string) bool {
+	hash, err := l.computeHash(entry)
+	if err != nil || hash != entry.EntryHash {
+		return false
+	}
+	return prev == "" || entry.PrevHash == "genesis" || entry.PrevHash == prev
+}
+
+// CompileTranscript folds command_received/command_executed pairs into one
+// record per command, ordered by receipt.
+func CompileTranscript(entries []Entry) []TranscriptCommand {
+	byID := make(map[string]*TranscriptCommand)
+	var order []string
+	get := func(id string) *TranscriptCommand {
+		if cmd, ok := byID[id]; ok {
+			return cmd
+		}
+		cmd := &TranscriptCommand{CommandID: id}
+		byID[id] = cmd
+		order = append(order, id)
+		return cmd
+	}
+	str := func(d map[string]any, key string) string {
+		s, _ := d[key].(string)
+		return s
+	}
+
+	for _, entry := range entries {
+		if entry.CommandID == "" {
+			continue
+		}
+		switch entry.EventType {
+		case EventCommandReceived:
+			cmd := get(entry.CommandID)
+			cmd.ReceivedAt = entry.Timestamp
+			if t := str(entry.Details, "type"); t != "" {
+				cmd.Type = t
+			}
+			if v := str(entry.Details, "executor"); v != "" {
+				cmd.Executor = v
+			}
+			if v := str(entry.Details, "scriptId"); v != "" {
+				cmd.ScriptID = v
+			}
+			if v := str(entry.Details, "scriptSha256"); v != "" {
+				cmd.ScriptSHA256 = v
+			}
+		case EventCommandExecuted:
+			cmd := get(entry.CommandID)
+			cmd.CompletedAt = entry.Timestamp
+			if t := str(entry.Details, "type"); t != "" && cmd.Type == "" {
+				cmd.Type = t
+			}
+			cmd.Status = str(entry.Details, "status")
+			if ms, ok := entry.Details["durationMs"].(float64); ok {
+				cmd.DurationMs = int64(ms)
+			}
+		}
+	}
+
+	out := make([]TranscriptCommand, 0, len(order))
+	for _, id := range order {
+		out = append(out, *byID[id])
+	}
+	return out
+}
+
+// WriteTranscriptArchive writes a gzip'd tar containing transcript.json
+// (compiled commands), audit.jsonl (the raw entries), manifest.json and
+// manifest.sig. manifest's Files, counts and PublicKey are filled in.
+func WriteTranscriptArchive(w io.Writer, manifest TranscriptManifest, entries []Entry, key ed25519.PrivateKey) (TranscriptManifest, error) {
+	commands := CompileTranscript(entries)
+	transcriptJSON, err := json.MarshalIndent(commands, "", "  ")
+	if err != nil {
+		return manifest, fmt.Errorf("marshal transcript: %w", err)
+	}
+
+	var auditJSONL []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return manifest, fmt.Errorf("marshal audit entry: %w", err)
+		}
+		auditJSONL = append(append(auditJSONL, line...), '\n')
+	}
+
+	manifest.CommandCount = len(commands)
+	manifest.EntryCount = len(entries)
+	manifest.PublicKey = TranscriptPublicKey(key)
+	manifest.Files = map[string]string{
+		"transcript.json": sha256Hex(transcriptJSON),
+		"audit.jsonl":     sha256Hex(auditJSONL),
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, fmt.Errorf("marshal manifest: %w", err)
+	}
+	signature := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifestJSON)))
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"manifest.json", manifestJSON},
+		{"manifest.sig", signature},
+		{"transcript.json", transcriptJSON},
+		{"audit.jsonl", auditJSONL},
+	}
+	for _, file := range files {
+		hdr := &tar.Header{
+			Name:    file.name,
+			Mode:    0o600,
+			Size:    int64(len(file.data)),
+			ModTime: manifest.GeneratedAt,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return manifest, fmt.Errorf("write %s header: %w", file.name, err)
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return manifest, fmt.Errorf("write %s: %w", file.name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return manifest, fmt.Errorf("close tar: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return manifest, fmt.Errorf("close gzip: %w", err)
+	}
+	return manifest, nil
+}
+
+// LoadOrCreateTranscriptKey returns the device's transcript signing key
+// stored at path, generating and persisting one (0600) on first use.
+func LoadOrCreateTranscriptKey(path string) (ed25519.PrivateKey, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		seed, decodeErr := hex.DecodeString(string(data))
+		if decodeErr == nil && len(seed) == ed25519.SeedSize {
+			return ed25519.NewKeyFromSeed(seed), nil
+		}
+		return nil, fmt.Errorf("transcript signing key %s is corrupt", path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read transcript signing key: %w", err)
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate transcript signing key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("create transcript key dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key.Seed())), 0o600); err != nil {
+		return nil, fmt.Errorf("write transcript signing key: %w", err)
+	}
+	return key, nil
+}
+
+// TranscriptPublicKey returns the base64 public half of key, as pinned by the
+// server.
+func TranscriptPublicKey(key ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadRangeAcrossRotationAndCompile(t *testing.T) {
+	l := newTestLogger(t)
+	l.Log(EventCommandReceived, "cmd-1", map[string]any{"type": "script", "executor": "system", "scriptSha256": "abc"})
+	l.Log(EventCommandExecuted, "cmd-1", map[string]any{"type": "script", "status": "completed", "durationMs": 42})
+	if err := l.rotate(); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	l.Log(EventCommandReceived, "cmd-2", map[string]any{"type": "reboot", "executor": "system"})
+	l.Log(EventCommandExecuted, "cmd-2", map[string]any{"type": "reboot", "status": "failed"})
+	l.Close()
+
+	from := time.Now().Add(-time.Hour)
+	to := time.Now().Add(time.Hour)
+	entries, chainOK, err := l.ReadRange(from, to)
+	if err != nil {
+		t.Fatalf("ReadRange: %v", err)
+	}
+	if !chainOK {
+		t.Fatal("untampered log should verify")
+	}
+	if len(entries) != 5 { // 4 command entries + rotation sentinel
+		t.Fatalf("got %d entries, want 5", len(entries))
+	}
+
+	commands := CompileTranscript(entries)
+	if len(commands) != 2 {
+		t.Fatalf("got %d commands, want 2", len(commands))
+	}
+	if c := commands[0]; c.CommandID != "cmd-1" || c.Status != "completed" || c.DurationMs != 42 || c.ScriptSHA256 != "abc" || c.Executor != "system" {
+		t.Fatalf("unexpected cmd-1 record: %+v", c)
+	}
+	if c := commands[1]; c.CommandID != "cmd-2" || c.Status != "failed" || c.ReceivedAt == "" || c.CompletedAt == "" {
+		t.Fatalf("unexpected cmd-2 record: %+v", c)
+	}
+
+	// Entries outside the window are excluded.
+	if got, _, _ := l.ReadRange(to, to.Add(time.Hour)); len(got) != 0 {
+		t.Fatalf("future window returned %d entries", len(got))
+	}
+}
+
+func TestReadRangeDetectsTampering(t *testing.T) {
+	l := newTestLogger(t)
+	l.Log(EventCommandExecuted, "cmd-1", map[string]any{"status": "failed"})
+	l.Log(EventCommandExecuted, "cmd-2", map[string]any{"status": "completed"})
+	l.Close()
+
+	data, err := os.ReadFile(l.filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(data), `"status":"failed"`, `"status":"completed"`, 1)
+	if err := os.WriteFile(l.filePath, []byte(tampered), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, chainOK, err := l.ReadRange(time.Time{}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ReadRange: %v", err)
+	}
+	if chainOK {
+		t.Fatal("edited entry should break chain verification")
+	}
+}
+
+func TestWriteTranscriptArchiveIsSigned(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "keys", "transcript.key")
+	key, err := LoadOrCreateTranscriptKey(keyPath)
+	if err != nil {
+		t.Fatalf("LoadOrCreateTranscriptKey: %v", err)
+	}
+
+	entries := []Entry{
+		{Timestamp: "2026-01-02T03:04:05Z", EventType: EventCommandReceived, CommandID: "cmd-1", Details: map[string]any{"type": "script"}},
+		{Timestamp: "2026-01-02T03:04:06Z", EventType: EventCommandExecuted, CommandID: "cmd-1", Details: map[string]any{"status": "completed"}},
+	}
+	var buf bytes.Buffer
+	manifest, err := WriteTranscriptArchive(&buf, TranscriptManifest{AgentID: "agent-1", GeneratedAt: time.Now().UTC()}, entries, key)
+	if err != nil {
+		t.Fatalf("WriteTranscriptArchive: %v", err)
+	}
+	if manifest.CommandCount != 1 || manifest.EntryCount != 2 {
+		t.Fatalf("unexpected counts: %+v", manifest)
+	}
+
+	files := readArchive(t, buf.Bytes())
+	for _, name := range []string{"manifest.json", "manifest.sig", "transcript.json", "audit.jsonl"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("archive missing %s", name)
+		}
+	}
+
+	pub, _ := base64.StdEncoding.DecodeString(manifest.PublicKey)
+	sig, _ := base64.StdEncoding.DecodeString(string(files["manifest.sig"]))
+	if !ed25519.Verify(ed25519.PublicKey(pub), files["manifest.json"], sig) {
+		t.Fatal("manifest signature does not verify")
+	}
+	var signed TranscriptManifest
+	if err := json.Unmarshal(files["manifest.json"], &signed); err != nil {
+		t.Fatal(err)
+	}
+	if signed.Files["transcript.json"] != sha256Hex(files["transcript.json"]) {
+		t.Fatal("manifest transcript hash mismatch")
+	}
+
+	// The signing key persists across loads.
+	again, err := LoadOrCreateTranscriptKey(keyPath)
+	if err != nil || !again.Equal(key) {
+		t.Fatalf("reloaded key differs (err=%v)", err)
+	}
+}
+
+func readArchive(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	files := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		body, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = body
+	}
+	return files
+}
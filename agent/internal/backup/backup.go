@@ -13,6 +13,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/breeze-rmm/agent/internal/backup/providers"
@@ -46,6 +47,19 @@ type BackupConfig struct {
 	VSSEnabled         bool   // Windows only: create VSS shadow copy before backup
 	SystemStateEnabled bool   // Collect system state alongside file backup
 	StagingDir         string // Base directory for temporary staging (empty = OS temp dir)
+	// BandwidthLimit caps the run's combined upload rate in bytes per second
+	// (0 = unlimited). Shared by all concurrent uploads; see
+	// providers.WithUploadLimit for which providers honour it.
+	BandwidthLimit int64
+	// Windows restricts when a run may upload. Empty means any time; otherwise
+	// a run started outside every window fails with ErrOutsideBackupWindow
+	// and one still uploading when its window closes is stopped and resumes
+	// from the checkpoint journal next time.
+	Windows []BackupWindow
+	// PathPolicies are extra roots, each with its own include/exclude globs
+	// and retention (see PathPolicy). They are walked after Paths and are
+	// validated by ValidatePathPolicies.
+	PathPolicies []PathPolicy
 }
 
 // BackupJob tracks the state of a backup run.
@@ -82,7 +96,12 @@ type BackupJob struct {
 	// persist it to the job's error_count column alongside the Warning text —
 	// without it a partial snapshot presents server-side as a green job with
 	// zero errors.
-	ErrorCount          int                              `json:"errorCount,omitempty"`
+	ErrorCount int `json:"errorCount,omitempty"`
+	// DurationMs is the wall-clock run time measured on the agent, from the
+	// start of the run to CompletedAt. The server's own started/completed
+	// timestamps include dispatch and helper queueing, so this is the run
+	// time the console reports. Set on every exit that stamps CompletedAt.
+	DurationMs          int64                            `json:"durationMs,omitempty"`
 	VSSMetadata         *vss.VSSMetadata                 `json:"vssMetadata,omitempty"`         // nil when VSS was not used
 	SystemStateManifest *systemstate.SystemStateManifest `json:"systemStateManifest,omitempty"` // nil when system state was not collected
 	// ReferencedFiles/ReferencedBytes count how much of FilesBackedUp/
@@ -94,6 +113,10 @@ type BackupJob struct {
 	// manifest was usable) or any run predating incremental backups.
 	ReferencedFiles int   `json:"referencedFiles,omitempty"`
 	ReferencedBytes int64 `json:"referencedBytes,omitempty"`
+	// ClientEncryptionKeyID is the client-side encryption key the snapshot's
+	// objects were written under, empty for a plaintext snapshot. The server
+	// records it so restores of this snapshot reject headerless objects.
+	ClientEncryptionKeyID string `json:"clientEncryptionKeyId,omitempty"`
 }
 
 // BackupManager orchestrates on-demand backups. Backup scheduling is owned by
@@ -166,6 +189,22 @@ func (m *BackupManager) GetVSSEnabled() bool {
 	return m.config.VSSEnabled
 }
 
+// GetBandwidthLimit returns the run's upload cap in bytes per second (0 =
+// unlimited).
+func (m *BackupManager) GetBandwidthLimit() int64 {
+	return m.config.BandwidthLimit
+}
+
+// GetPathPolicies returns the configured per-path policies.
+func (m *BackupManager) GetPathPolicies() []PathPolicy {
+	return append([]PathPolicy(nil), m.config.PathPolicies...)
+}
+
+// GetWindows returns the configured backup windows (empty = any time).
+func (m *BackupManager) GetWindows() []BackupWindow {
+	return m.config.Windows
+}
+
 // Stop cancels an in-flight backup job and waits for it to unwind. It reports
 // whether a job was actually running (false = nothing to stop).
 func (m *BackupManager) Stop() bool {
@@ -230,12 +269,17 @@ func (m *BackupManager) RunBackupContext(ctx context.Context, excludes []string)
 	// paths — the collected system-state staging dir is appended to
 	// backupPaths below and becomes the entire snapshot. Only require file
 	// paths when system-state collection is off.
-	if len(m.config.Paths) == 0 && !m.config.SystemStateEnabled {
+	if len(m.fileRoots()) == 0 && !m.config.SystemStateEnabled {
 		return nil, errors.New("backup paths are required")
 	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	windows, err := compileBackupWindows(m.config.Windows)
+	if err != nil {
+		return nil, err
+	}
+	windowOpen, windowCloses := backupWindowClosesAt(windows, backupWindowNow())
 
 	m.mu.Lock()
 	if m.jobRunning {
@@ -265,14 +309,41 @@ func (m *BackupManager) RunBackupContext(ctx context.Context, excludes []string)
 		StartedAt: time.Now().UTC(),
 		Status:    jobStatusRunning,
 	}
-	backupPaths := append([]string(nil), m.config.Paths...)
+	if enc, ok := m.config.Provider.(*providers.EncryptedProvider); ok {
+		job.ClientEncryptionKeyID = enc.KeyID()
+	}
+	defer func() {
+		if !job.CompletedAt.IsZero() {
+			job.DurationMs = job.CompletedAt.Sub(job.StartedAt).Milliseconds()
+		}
+	}()
+	backupPaths := m.fileRoots()
+	var windowClosed atomic.Bool
 	stopBackupRun := func() (*BackupJob, error) {
+		stopErr := errBackupStopped
+		if windowClosed.Load() {
+			stopErr = errBackupWindowClosed
+		}
 		job.Status = jobStatusStopped
 		job.CompletedAt = time.Now().UTC()
-		job.Error = errBackupStopped
-		return job, errBackupStopped
+		job.Error = stopErr
+		return job, stopErr
 	}
 
+	if !windowOpen {
+		job.Status = jobStatusSkipped
+		job.CompletedAt = time.Now().UTC()
+		job.Error = ErrOutsideBackupWindow
+		return job, ErrOutsideBackupWindow
+	}
+	stopWindowWatch := watchBackupWindow(windows, windowCloses, func() {
+		log.Printf("[backup] backup window closed, stopping run %s", job.ID)
+		windowClosed.Store(true)
+		cancel()
+	})
+	defer stopWindowWatch()
+	runCtx = providers.WithUploadLimit(runCtx, m.config.BandwidthLimit)
+
 	// Whole-run progress keepalive. The long pre-upload phases below (VSS
 	// creation up to 10min, system-state collection, tree walk,
 	// previous-manifest download) emit no progress of their own, so the API's
@@ -299,10 +370,14 @@ func (m *BackupManager) RunBackupContext(ctx context.Context, excludes []string)
 		vssStart := time.Now()
 		provider := vss.NewProvider(vss.DefaultConfig())
 		vssCtx, cancel := context.WithTimeout(runCtx, 10*time.Minute)
-		session, vssErr := provider.CreateShadowCopy(vssCtx, extractVolumes(m.config.Paths))
+		session, vssErr := provider.CreateShadowCopy(vssCtx, extractVolumes(m.fileRoots()))
 		cancel()
 		if vssErr != nil {
 			log.Printf("[backup] VSS shadow copy failed, proceeding without VSS: %v", vssErr)
+			// Surface it: without a shadow copy, files held open by other
+			// processes (Outlook OSTs, SQLite DBs) can't be read and would
+			// otherwise only be discovered missing at restore time.
+			appendWarning(job, fmt.Sprintf("VSS shadow copy unavailable, open files may be missing or inconsistent: %v", vssErr))
 		} else {
 			vssSession = session
 			job.VSSMetadata = &vss.VSSMetadata{
@@ -316,6 +391,7 @@ func (m *BackupManager) RunBackupContext(ctx context.Context, excludes []string)
 			if len(session.Warnings) > 0 {
 				log.Printf("[backup] VSS completed with %d warning(s): %v", len(session.Warnings), session.Warnings)
 			}
+			appendWarning(job, summarizeVSSWriterFailures(session.Writers))
 			defer func() {
 				if releaseErr := provider.ReleaseShadowCopy(session); releaseErr != nil {
 					log.Printf("[backup] failed to release VSS shadow copy: %v", releaseErr)
@@ -380,7 +456,7 @@ func (m *BackupManager) RunBackupContext(ctx context.Context, excludes []string)
 	if err := runCtx.Err(); err != nil {
 		return stopBackupRun()
 	}
-	files, scanErr := m.collectBackupFilesFromPaths(runCtx, backupPaths, newExcludeMatcher(excludes))
+	files, scanErr := m.collectBackupFilesFromRoots(runCtx, backupPaths, m.rootFilters(len(backupPaths), excludes))
 	if scanErr != nil {
 		if errors.Is(scanErr, errBackupStopped) {
 			return stopBackupRun()
@@ -402,7 +478,7 @@ func (m *BackupManager) RunBackupContext(ctx context.Context, excludes []string)
 		// nothing is a hard failure, not a no-op skip: there are no files to
 		// fall back on, so a green empty snapshot would silently protect
 		// nothing. Surface the collection error (or a synthetic one).
-		if m.config.SystemStateEnabled && len(m.config.Paths) == 0 {
+		if m.config.SystemStateEnabled && len(m.fileRoots()) == 0 {
 			runErr := systemStateErr
 			if runErr == nil {
 				runErr = errors.New("system state collection produced no artifacts")
@@ -429,7 +505,7 @@ func (m *BackupManager) RunBackupContext(ctx context.Context, excludes []string)
 	// there is nothing eligible to dedupe against — the extra remote
 	// list+manifest-download would be pure waste.
 	var prevSnapshot *Snapshot
-	incrementalDedupeActive := !m.config.SystemStateEnabled || len(m.config.Paths) > 0
+	incrementalDedupeActive := !m.config.SystemStateEnabled || len(m.fileRoots()) > 0
 	if incrementalDedupeActive {
 		prev, reason := previousManifest(runCtx, m.config.Provider)
 		if prev == nil {
@@ -473,7 +549,7 @@ func (m *BackupManager) RunBackupContext(ctx context.Context, excludes []string)
 		log.Printf("[backup] no secure checkpoint journal directory available (only the world-writable temp dir); proceeding without resume support")
 	} else {
 		var journalErr error
-		journal, resumedJournal, journalErr = openSnapshotJournal(journalDir, backupIdentity(m.config.Provider, m.config.Paths), journalMaxAge)
+		journal, resumedJournal, journalErr = openSnapshotJournal(journalDir, backupIdentity(m.config.Provider, m.fileRoots()), journalMaxAge)
 		if journalErr != nil {
 			// A journal is a best-effort checkpoint, never a correctness
 			// requirement: degrade to a journal-less run rather than failing
@@ -547,6 +623,18 @@ func (m *BackupManager) RunBackupContext(ctx context.Context, excludes []string)
 			log.Printf("[backup] failed to enforce snapshot retention: %v", retentionErr)
 		}
 	}
+	// Per-path retention is reference-aware (it only deletes objects no
+	// remaining manifest points at), so unlike the count-based pruning above
+	// it stays safe with incremental dedupe active.
+	if snapshot != nil && snapErr == nil {
+		if pathErr := prunePathRetention(runCtx, m.config.Provider, m.config.PathPolicies, time.Now().UTC()); pathErr != nil {
+			if errors.Is(pathErr, errBackupStopped) {
+				return stopBackupRun()
+			}
+			log.Printf("[backup] failed to enforce path retention: %v", pathErr)
+			retentionErr = errors.Join(retentionErr, pathErr)
+		}
+	}
 
 	if snapErr != nil {
 		if errors.Is(snapErr, errBackupStopped) {
@@ -580,13 +668,26 @@ func (m *BackupManager) RunBackupContext(ctx context.Context, excludes []string)
 	// BackupJob.Error marshals to `{}` and the server never reads it (see the
 	// Error field's doc comment). Success path only: on a hard failure scanErr
 	// already rides job.Error alongside the fatal error above.
-	if scanFailures := flattenJoinedErrors(scanErr); len(scanFailures) > 0 {
+	scanFailures := flattenJoinedErrors(scanErr)
+	if len(scanFailures) > 0 {
 		job.ErrorCount += len(scanFailures)
 		scanWarning := summarizeScanErrors(scanFailures)
 		appendWarning(job, scanWarning)
 		log.Printf("[backup] %s", scanWarning)
 	}
 
+	// Files skipped because another process held them open get their own
+	// count and a pointer at the fix, since the generic per-file summary
+	// only lists the first few paths.
+	var uploadFailures []error
+	if snapshot != nil {
+		uploadFailures = snapshot.UploadFailures
+	}
+	if lockedWarning := summarizeLockedFiles(append(scanFailures, uploadFailures...), vssSession != nil); lockedWarning != "" {
+		appendWarning(job, lockedWarning)
+		log.Printf("[backup] %s", lockedWarning)
+	}
+
 	job.Status = jobStatusCompleted
 	job.Error = errors.Join(scanErr, retentionErr)
 	return job, nil
@@ -643,6 +744,46 @@ func summarizeScanErrors(failures []error) string {
 	return summary
 }
 
+// summarizeLockedFiles counts per-file failures caused by another process
+// holding the file open. Returns "" when there are none.
+func summarizeLockedFiles(failures []error, vssActive bool) string {
+	locked := 0
+	for _, err := range failures {
+		if isFileLocked(err) {
+			locked++
+		}
+	}
+	if locked == 0 {
+		return ""
+	}
+	summary := fmt.Sprintf("%d file(s) were in use by another process and were not backed up", locked)
+	if !vssActive {
+		summary += " (enable VSS to capture open files)"
+	}
+	return summary
+}
+
+// summarizeVSSWriterFailures names the VSS writers that did not reach a
+// stable state. Their application data (Exchange, SQL Server, Hyper-V, ...)
+// is in the shadow copy but may not be application-consistent.
+func summarizeVSSWriterFailures(writers []vss.WriterStatus) string {
+	var failed []string
+	for _, w := range writers {
+		if w.State != "failed" {
+			continue
+		}
+		name := w.Name
+		if w.LastError != "" {
+			name += " (" + w.LastError + ")"
+		}
+		failed = append(failed, name)
+	}
+	if len(failed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("VSS writer(s) failed, their data may be inconsistent: %s", strings.Join(failed, ", "))
+}
+
 // flattenJoinedErrors unwraps an errors.Join tree (or a single wrapped error)
 // into its individual leaf errors so per-file failures can be counted. Returns
 // nil for a nil error. collectBackupFilesFromPaths returns its per-file errors
@@ -777,10 +918,49 @@ type backupFile struct {
 }
 
 func (m *BackupManager) collectBackupFiles() ([]backupFile, error) {
-	return m.collectBackupFilesFromPaths(context.Background(), m.config.Paths, newExcludeMatcher(m.config.Excludes))
+	roots := m.fileRoots()
+	return m.collectBackupFilesFromRoots(context.Background(), roots, m.rootFilters(len(roots), m.config.Excludes))
+}
+
+// fileRoots returns the configured file roots: Paths, then each PathPolicy's
+// path. The order is positional (root i is stored under "path_i"), so
+// policies are always appended after Paths.
+func (m *BackupManager) fileRoots() []string {
+	roots := append([]string(nil), m.config.Paths...)
+	for _, p := range m.config.PathPolicies {
+		roots = append(roots, p.Path)
+	}
+	return roots
+}
+
+// rootFilters returns one filter per root for n roots laid out as fileRoots
+// plus any extra roots (the system-state staging dir). Paths and extra roots
+// get the run-wide excludes; policy roots get their own selection on top.
+func (m *BackupManager) rootFilters(n int, excludes []string) []pathFilter {
+	runWide := pathFilter{exclude: newExcludeMatcher(excludes)}
+	filters := make([]pathFilter, n)
+	for i := range filters {
+		filters[i] = runWide
+	}
+	for i, p := range m.config.PathPolicies {
+		if idx := len(m.config.Paths) + i; idx < n {
+			filters[idx] = pathPolicyFilter(p, excludes)
+		}
+	}
+	return filters
 }
 
 func (m *BackupManager) collectBackupFilesFromPaths(ctx context.Context, paths []string, excl *excludeMatcher) ([]backupFile, error) {
+	filters := make([]pathFilter, len(paths))
+	for i := range filters {
+		filters[i] = pathFilter{exclude: excl}
+	}
+	return m.collectBackupFilesFromRoots(ctx, paths, filters)
+}
+
+// collectBackupFilesFromRoots walks each root in paths, applying filters[i]
+// to root i.
+func (m *BackupManager) collectBackupFilesFromRoots(ctx context.Context, paths []string, filters []pathFilter) ([]backupFile, error) {
 	var files []backupFile
 	var errs []error
 	seen := make(map[string]struct{})
@@ -801,12 +981,13 @@ func (m *BackupManager) collectBackupFilesFromPaths(ctx context.Context, paths [
 		}
 
 		rootLabel := fmt.Sprintf("path_%d", idx)
+		filter := filters[idx]
 		if !info.IsDir() {
 			if !info.Mode().IsRegular() {
 				continue
 			}
 			relPath := filepath.Base(cleanRoot)
-			if excl.matches(relPath) {
+			if filter.skipFile(relPath) {
 				continue
 			}
 			snapshotPath := filepath.ToSlash(filepath.Join(rootLabel, relPath))
@@ -836,9 +1017,9 @@ func (m *BackupManager) collectBackupFilesFromPaths(ctx context.Context, paths [
 			if entry.IsDir() {
 				// An excluded directory is skipped entirely (fs.SkipDir), not
 				// just its immediate files (#2418).
-				if excl != nil && path != cleanRoot {
+				if filter.exclude != nil && path != cleanRoot {
 					relPath, relErr := filepath.Rel(cleanRoot, path)
-					if relErr == nil && excl.matches(filepath.ToSlash(relPath)) {
+					if relErr == nil && filter.skipDir(filepath.ToSlash(relPath)) {
 						return fs.SkipDir
 					}
 				}
@@ -860,7 +1041,7 @@ func (m *BackupManager) collectBackupFilesFromPaths(ctx context.Context, paths [
 				errs = append(errs, fmt.Errorf("failed to resolve relative path for %s: %w", path, err))
 				return nil
 			}
-			if excl.matches(filepath.ToSlash(relPath)) {
+			if filter.skipFile(filepath.ToSlash(relPath)) {
 				return nil
 			}
 			snapshotPath := filepath.ToSlash(filepath.Join(rootLabel, relPath))
@@ -12,7 +12,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/breeze-rmm/agent/internal/backup/providers"
 	"github.com/breeze-rmm/agent/internal/backup/systemstate"
+	"github.com/breeze-rmm/agent/internal/backup/vss"
 )
 
 type blockingUploadProvider struct {
@@ -92,6 +94,24 @@ func TestStop_NoActiveJob(t *testing.T) {
 	}
 }
 
+// The server marks the snapshot client-encrypted from the job, so restores
+// of it reject headerless objects.
+func TestRunBackupReportsClientEncryptionKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTempFile(t, tmpDir, "data.txt", "secret")
+	provider, err := providers.NewEncryptedProvider(providers.NewLocalProvider(t.TempDir()), "device-key-1", make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewEncryptedProvider: %v", err)
+	}
+	job, err := NewBackupManager(BackupConfig{Provider: provider, Paths: []string{tmpDir}}).RunBackup()
+	if err != nil {
+		t.Fatalf("RunBackup: %v", err)
+	}
+	if job.ClientEncryptionKeyID != "device-key-1" {
+		t.Fatalf("ClientEncryptionKeyID = %q", job.ClientEncryptionKeyID)
+	}
+}
+
 // Backups are server-scheduled and dispatched as backup_run commands, so the
 // only thing Stop has to unwind is an in-flight on-demand job (#2452).
 func TestStop_CancelsActiveBackup(t *testing.T) {
@@ -412,6 +432,9 @@ func TestRunBackup_SingleFile(t *testing.T) {
 	if job.CompletedAt.IsZero() {
 		t.Error("job CompletedAt should not be zero")
 	}
+	if want := job.CompletedAt.Sub(job.StartedAt).Milliseconds(); job.DurationMs != want {
+		t.Errorf("job DurationMs = %d, want %d", job.DurationMs, want)
+	}
 	if job.Snapshot == nil {
 		t.Error("job Snapshot should not be nil")
 	}
@@ -984,6 +1007,36 @@ func TestSummarizeUploadFailures(t *testing.T) {
 	}
 }
 
+func TestSummarizeVSSWriterFailures(t *testing.T) {
+	if got := summarizeVSSWriterFailures(nil); got != "" {
+		t.Fatalf("no writers must summarize to empty, got %q", got)
+	}
+	stable := []vss.WriterStatus{{Name: "System Writer", State: "stable"}}
+	if got := summarizeVSSWriterFailures(stable); got != "" {
+		t.Fatalf("stable writers must summarize to empty, got %q", got)
+	}
+
+	got := summarizeVSSWriterFailures([]vss.WriterStatus{
+		{Name: "System Writer", State: "stable"},
+		{Name: "SqlServerWriter", State: "failed", LastError: "retryable error"},
+		{Name: "Microsoft Exchange Writer", State: "failed"},
+	})
+	if !strings.Contains(got, "SqlServerWriter (retryable error)") ||
+		!strings.Contains(got, "Microsoft Exchange Writer") {
+		t.Fatalf("unexpected summary: %q", got)
+	}
+	if strings.Contains(got, "System Writer") {
+		t.Fatalf("stable writer must not be listed: %q", got)
+	}
+}
+
+func TestSummarizeLockedFiles_IgnoresOtherErrors(t *testing.T) {
+	failures := []error{errors.New("permission denied"), os.ErrNotExist}
+	if got := summarizeLockedFiles(failures, false); got != "" {
+		t.Fatalf("non-lock failures must summarize to empty, got %q", got)
+	}
+}
+
 // The whole-run keepalive must heartbeat during the pre-upload phases and then
 // stop cleanly with no further emissions and no goroutine leak.
 func TestStartRunKeepalive_EmitsThenStopsCleanly(t *testing.T) {
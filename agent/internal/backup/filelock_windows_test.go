@@ -0,0 +1,26 @@
+//go:build windows
+
+package backup
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestSummarizeLockedFiles_CountsSharingViolations(t *testing.T) {
+	failures := []error{
+		fmt.Errorf("failed to upload C:\\Users\\a\\mail.ost: %w", syscall.Errno(32)),
+		fmt.Errorf("failed to upload C:\\data\\app.db: %w", syscall.Errno(33)),
+		fmt.Errorf("failed to upload C:\\x: %w", syscall.Errno(5)),
+	}
+
+	got := summarizeLockedFiles(failures, false)
+	if !strings.HasPrefix(got, "2 file(s) were in use") || !strings.Contains(got, "enable VSS") {
+		t.Fatalf("unexpected summary: %q", got)
+	}
+	if got := summarizeLockedFiles(failures, true); strings.Contains(got, "enable VSS") {
+		t.Fatalf("VSS hint must be omitted when a shadow copy was used: %q", got)
+	}
+}
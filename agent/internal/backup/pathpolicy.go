@@ -0,0 +1,221 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/backup/providers"
+)
+
+// PathPolicy backs up one root with its own file selection and retention,
+// instead of the run-wide Paths/Excludes/Retention. Include and Exclude use
+// the excludeMatcher glob dialect; when Include is non-empty only files
+// matching one of its patterns are kept (directories are still walked unless
+// excluded). RetentionDays > 0 drops the root's files from snapshots older
+// than that many days; 0 keeps them as long as their snapshot.
+type PathPolicy struct {
+	Path          string   `json:"path"`
+	Include       []string `json:"include,omitempty"`
+	Exclude       []string `json:"exclude,omitempty"`
+	RetentionDays int      `json:"retentionDays,omitempty"`
+}
+
+// pathFilter is the compiled file selection for one backup root. Both
+// matchers may be nil: a nil include keeps everything, a nil exclude drops
+// nothing.
+type pathFilter struct {
+	include *excludeMatcher
+	exclude *excludeMatcher
+}
+
+// skipDir reports whether the directory at relPath is pruned from the walk.
+// Includes never prune directories: a file deeper down may still match.
+func (f pathFilter) skipDir(relPath string) bool {
+	return f.exclude.matches(relPath)
+}
+
+// skipFile reports whether the regular file at relPath is left out.
+func (f pathFilter) skipFile(relPath string) bool {
+	if f.exclude.matches(relPath) {
+		return true
+	}
+	return f.include != nil && !f.include.matches(relPath)
+}
+
+// ValidatePathPolicies rejects policies the manager cannot apply, so
+// configuration errors surface before a run instead of as a partial backup.
+func ValidatePathPolicies(policies []PathPolicy, paths []string) error {
+	seen := make(map[string]struct{}, len(paths)+len(policies))
+	for _, p := range paths {
+		seen[filepath.Clean(p)] = struct{}{}
+	}
+	for i, p := range policies {
+		if strings.TrimSpace(p.Path) == "" {
+			return fmt.Errorf("path policy %d: path is required", i)
+		}
+		if p.RetentionDays < 0 {
+			return fmt.Errorf("path policy %d (%s): retention days must not be negative", i, p.Path)
+		}
+		clean := filepath.Clean(p.Path)
+		if _, dup := seen[clean]; dup {
+			return fmt.Errorf("path policy %d: %s is configured more than once", i, p.Path)
+		}
+		seen[clean] = struct{}{}
+	}
+	return nil
+}
+
+// pathPolicyFilter compiles a policy's selection. The run-wide excludes still
+// apply, so a policy can narrow a backup but never re-include an excluded
+// pattern such as "*.tmp".
+func pathPolicyFilter(p PathPolicy, runExcludes []string) pathFilter {
+	return pathFilter{
+		include: newExcludeMatcher(p.Include),
+		exclude: newExcludeMatcher(append(append([]string(nil), runExcludes...), p.Exclude...)),
+	}
+}
+
+// pathRetentionPolicy returns the most specific retention-limited policy
+// whose root contains sourcePath, or false when none applies.
+func pathRetentionPolicy(policies []PathPolicy, sourcePath string) (PathPolicy, bool) {
+	var best PathPolicy
+	found := false
+	for _, p := range policies {
+		if p.RetentionDays <= 0 || !isUnderDir(sourcePath, filepath.Clean(p.Path)) {
+			continue
+		}
+		if !found || len(filepath.Clean(p.Path)) > len(filepath.Clean(best.Path)) {
+			best = p
+			found = true
+		}
+	}
+	return best, found
+}
+
+// prunePathRetention drops files under retention-limited policy roots from
+// every snapshot older than the root's RetentionDays and rewrites those
+// manifests. A dropped file's object is deleted only when no remaining
+// manifest references it: unchanged files are carried forward as reference
+// entries pointing at the snapshot that first uploaded them, so the bytes of
+// a file still inside a newer snapshot must survive. Nothing is deleted if
+// any manifest failed to load, since its references would be unknown.
+func prunePathRetention(ctx context.Context, provider providers.BackupProvider, policies []PathPolicy, now time.Time) error {
+	limited := false
+	for _, p := range policies {
+		if p.RetentionDays > 0 {
+			limited = true
+			break
+		}
+	}
+	if !limited {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return errBackupStopped
+	}
+
+	snapshots, err := ListSnapshots(provider)
+	if err != nil {
+		return fmt.Errorf("path retention skipped, snapshot listing incomplete: %w", err)
+	}
+
+	var errs []error
+	var dropped []SnapshotFile
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		var kept, expired []SnapshotFile
+		for _, f := range snapshot.Files {
+			source := f.SourcePath
+			if f.OriginalPath != "" {
+				source = f.OriginalPath
+			}
+			p, ok := pathRetentionPolicy(policies, source)
+			if ok && snapshot.Timestamp.Before(now.AddDate(0, 0, -p.RetentionDays)) {
+				expired = append(expired, f)
+				continue
+			}
+			kept = append(kept, f)
+		}
+		if len(expired) == 0 {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return errBackupStopped
+		}
+
+		manifestKey := path.Join(snapshotRootDir, snapshot.ID, snapshotManifestKey)
+		var rewriteErr error
+		if len(kept) == 0 {
+			rewriteErr = provider.Delete(manifestKey)
+		} else {
+			rewritten := *snapshot
+			rewritten.Files = kept
+			rewritten.Size = 0
+			for _, f := range kept {
+				rewritten.Size += f.Size
+			}
+			rewriteErr = uploadSnapshotManifest(ctx, provider, &rewritten, manifestKey)
+		}
+		if rewriteErr != nil {
+			if errors.Is(rewriteErr, errBackupStopped) {
+				return errBackupStopped
+			}
+			// The old manifest is still in place, so its entries stay
+			// referenced and none of its objects may be deleted.
+			errs = append(errs, fmt.Errorf("failed to rewrite snapshot %s: %w", snapshot.ID, rewriteErr))
+			continue
+		}
+		log.Printf("[backup] path retention dropped %d file(s) from snapshot %s", len(expired), snapshot.ID)
+		snapshot.Files = kept
+		dropped = append(dropped, expired...)
+	}
+
+	referenced := make(map[string]struct{})
+	for _, snapshot := range snapshots {
+		for _, f := range snapshot.Files {
+			referenced[f.BackupPath] = struct{}{}
+		}
+	}
+	for _, f := range dropped {
+		if _, live := referenced[f.BackupPath]; live {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return errBackupStopped
+		}
+		// Mark it so an object dropped from several manifests is deleted once.
+		referenced[f.BackupPath] = struct{}{}
+		if err := provider.Delete(f.BackupPath); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", f.BackupPath, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// uploadSnapshotManifest writes snapshot as the manifest at manifestKey.
+func uploadSnapshotManifest(ctx context.Context, provider providers.BackupProvider, snapshot *Snapshot, manifestKey string) error {
+	manifestPath, err := writeSnapshotManifest(snapshot)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(manifestPath)
+
+	var size int64
+	if info, statErr := os.Stat(manifestPath); statErr == nil {
+		size = info.Size()
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, uploadDeadline(size))
+	defer cancel()
+	uploadErr := uploadSnapshotFile(attemptCtx, provider, manifestPath, manifestKey)
+	if errors.Is(uploadErr, errBackupStopped) && ctx.Err() == nil {
+		uploadErr = fmt.Errorf("manifest upload stalled: no completion within %s", uploadDeadline(size))
+	}
+	return uploadErr
+}
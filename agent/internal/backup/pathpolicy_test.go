@@ -0,0 +1,195 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestCollectBackupFiles_PathPolicySelection(t *testing.T) {
+	plain := t.TempDir()
+	createTempFile(t, plain, "notes.txt", "plain")
+	createTempFile(t, plain, "scratch.tmp", "run-wide exclude")
+
+	docs := t.TempDir()
+	createTempFile(t, docs, "report.docx", "kept")
+	createTempFile(t, docs, "readme.txt", "not included")
+	createTempFile(t, docs, "draft.tmp", "run-wide exclude still applies")
+	for _, dir := range []string{"sub", "skip"} {
+		if err := os.Mkdir(filepath.Join(docs, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createTempFile(t, filepath.Join(docs, "sub"), "nested.docx", "kept")
+	createTempFile(t, filepath.Join(docs, "skip"), "hidden.docx", "policy exclude")
+
+	mgr := NewBackupManager(BackupConfig{
+		Provider: newMockProvider(),
+		Paths:    []string{plain},
+		Excludes: []string{"*.tmp"},
+		PathPolicies: []PathPolicy{
+			{Path: docs, Include: []string{"*.docx"}, Exclude: []string{"skip"}},
+		},
+	})
+	files, err := mgr.collectBackupFiles()
+	if err != nil {
+		t.Fatalf("collectBackupFiles: %v", err)
+	}
+
+	var got []string
+	for _, f := range files {
+		got = append(got, f.snapshotPath)
+	}
+	want := []string{"path_0/notes.txt", "path_1/report.docx", "path_1/sub/nested.docx"}
+	if len(got) != len(want) {
+		t.Fatalf("collected %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("collected %v, want %v", got, want)
+		}
+	}
+}
+
+func TestValidatePathPolicies(t *testing.T) {
+	root := t.TempDir()
+	if err := ValidatePathPolicies([]PathPolicy{{Path: root, RetentionDays: 30}}, nil); err != nil {
+		t.Fatalf("valid policy rejected: %v", err)
+	}
+	cases := map[string]struct {
+		policies []PathPolicy
+		paths    []string
+	}{
+		"empty path":         {policies: []PathPolicy{{Path: " "}}},
+		"negative retention": {policies: []PathPolicy{{Path: root, RetentionDays: -1}}},
+		"duplicate policy":   {policies: []PathPolicy{{Path: root}, {Path: root + string(filepath.Separator)}}},
+		"also in paths":      {policies: []PathPolicy{{Path: root}}, paths: []string{root}},
+	}
+	for name, tc := range cases {
+		if err := ValidatePathPolicies(tc.policies, tc.paths); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPrunePathRetention_KeepsReferencedObjects(t *testing.T) {
+	base := t.TempDir()
+	desktop := filepath.Join(base, "Desktop")
+	data := filepath.Join(base, "Data")
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+
+	object := func(snapshotID, name string) string {
+		return path.Join(snapshotRootDir, snapshotID, "files", name)
+	}
+	provider := newMockProvider()
+	for _, key := range []string{
+		object("snap-old", "path_0/unchanged.txt"),
+		object("snap-old", "path_0/deleted.txt"),
+		object("snap-old", "path_1/ledger.db"),
+		object("snap-new", "path_0/edited.txt"),
+	} {
+		provider.files[key] = []byte("x")
+	}
+	storeManifest(t, provider, &Snapshot{
+		ID:        "snap-old",
+		Timestamp: now.AddDate(0, 0, -40),
+		Files: []SnapshotFile{
+			{SourcePath: filepath.Join(desktop, "unchanged.txt"), BackupPath: object("snap-old", "path_0/unchanged.txt"), Size: 1},
+			{SourcePath: filepath.Join(desktop, "deleted.txt"), BackupPath: object("snap-old", "path_0/deleted.txt"), Size: 1},
+			{SourcePath: filepath.Join(data, "ledger.db"), BackupPath: object("snap-old", "path_1/ledger.db"), Size: 1},
+		},
+		Size: 3,
+	})
+	storeManifest(t, provider, &Snapshot{
+		ID:        "snap-new",
+		Timestamp: now.AddDate(0, 0, -1),
+		Files: []SnapshotFile{
+			// Unchanged since snap-old: a reference into its prefix.
+			{SourcePath: filepath.Join(desktop, "unchanged.txt"), BackupPath: object("snap-old", "path_0/unchanged.txt"), Size: 1},
+			{SourcePath: filepath.Join(desktop, "edited.txt"), BackupPath: object("snap-new", "path_0/edited.txt"), Size: 1},
+			{SourcePath: filepath.Join(data, "ledger.db"), BackupPath: object("snap-old", "path_1/ledger.db"), Size: 1},
+		},
+		Size: 3,
+	})
+
+	policies := []PathPolicy{
+		{Path: desktop, RetentionDays: 30},
+		{Path: data, RetentionDays: 90},
+	}
+	if err := prunePathRetention(context.Background(), provider, policies, now); err != nil {
+		t.Fatalf("prunePathRetention: %v", err)
+	}
+
+	if _, ok := provider.files[object("snap-old", "path_0/deleted.txt")]; ok {
+		t.Error("an expired object no manifest references must be deleted")
+	}
+	for _, key := range []string{
+		object("snap-old", "path_0/unchanged.txt"), // still referenced by snap-new
+		object("snap-old", "path_1/ledger.db"),     // 90-day root, not expired
+		object("snap-new", "path_0/edited.txt"),
+	} {
+		if _, ok := provider.files[key]; !ok {
+			t.Errorf("%s was deleted but is still needed", key)
+		}
+	}
+
+	var rewritten Snapshot
+	raw := provider.files[path.Join(snapshotRootDir, "snap-old", snapshotManifestKey)]
+	if err := json.Unmarshal(raw, &rewritten); err != nil {
+		t.Fatalf("decode rewritten manifest: %v", err)
+	}
+	if len(rewritten.Files) != 1 || rewritten.Files[0].SourcePath != filepath.Join(data, "ledger.db") || rewritten.Size != 1 {
+		t.Fatalf("old manifest should keep only the Data file, got %+v", rewritten)
+	}
+}
+
+func TestPrunePathRetention_RemovesEmptiedSnapshot(t *testing.T) {
+	desktop := filepath.Join(t.TempDir(), "Desktop")
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	provider := newMockProvider()
+	backupPath := path.Join(snapshotRootDir, "snap-old", "files", "path_0", "a.txt")
+	provider.files[backupPath] = []byte("x")
+	storeManifest(t, provider, &Snapshot{
+		ID:        "snap-old",
+		Timestamp: now.AddDate(0, 0, -10),
+		Files:     []SnapshotFile{{SourcePath: filepath.Join(desktop, "a.txt"), BackupPath: backupPath, Size: 1}},
+		Size:      1,
+	})
+
+	if err := prunePathRetention(context.Background(), provider, []PathPolicy{{Path: desktop, RetentionDays: 7}}, now); err != nil {
+		t.Fatalf("prunePathRetention: %v", err)
+	}
+	var left []string
+	for key := range provider.files {
+		left = append(left, key)
+	}
+	sort.Strings(left)
+	if len(left) != 0 {
+		t.Fatalf("an emptied snapshot should be removed entirely, left %v", left)
+	}
+}
+
+func TestPrunePathRetention_UnreadableManifestDeletesNothing(t *testing.T) {
+	desktop := filepath.Join(t.TempDir(), "Desktop")
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	provider := newMockProvider()
+	storeManifest(t, provider, &Snapshot{
+		ID:        "snap-old",
+		Timestamp: now.AddDate(0, 0, -10),
+		Files:     []SnapshotFile{{SourcePath: filepath.Join(desktop, "a.txt"), BackupPath: "snapshots/snap-old/files/path_0/a.txt"}},
+	})
+	provider.downloadErr = errors.New("network down")
+
+	if err := prunePathRetention(context.Background(), provider, []PathPolicy{{Path: desktop, RetentionDays: 7}}, now); err == nil {
+		t.Fatal("expected an error when manifests cannot be read")
+	}
+	if len(provider.deleteCalls) != 0 {
+		t.Fatalf("nothing may be deleted without every manifest, deleted %v", provider.deleteCalls)
+	}
+}
@@ -80,6 +80,14 @@ func (a *AzureProvider) UploadContext(ctx context.Context, localPath, remotePath
 		"blob", remotePath,
 	)
 
+	// UploadFile needs the *os.File for its parallel block reads, so a
+	// rate-limited run streams the blob through the limiter instead.
+	if body := uploadReader(ctx, file); body != io.Reader(file) {
+		if _, err := client.UploadStream(ctx, a.containerName, remotePath, body, nil); err != nil {
+			return fmt.Errorf("failed to upload file to azure: %w", err)
+		}
+		return nil
+	}
 	if _, err := client.UploadFile(ctx, a.containerName, remotePath, file, nil); err != nil {
 		return fmt.Errorf("failed to upload file to azure: %w", err)
 	}
@@ -0,0 +1,325 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Client-side encrypted object layout:
+//
+//	magic "BZE1" | keyID length (1 byte) | keyID | nonce prefix (8 bytes)
+//	then chunks of: ciphertext length (uint32 BE) | AES-256-GCM ciphertext
+//
+// Each chunk seals up to encryptedChunkSize bytes of plaintext with the nonce
+// prefix plus a big-endian chunk counter. The header and a final-chunk flag
+// are bound in as additional data, so reordered, spliced or truncated
+// objects fail authentication.
+const (
+	encryptedMagic       = "BZE1"
+	encryptedChunkSize   = 1 << 20
+	encryptedNoncePrefix = 8
+	encryptedKeySize     = 32
+)
+
+// ErrEncryptionKeyMismatch is returned when an object was encrypted with a
+// different key than the provider holds.
+var ErrEncryptionKeyMismatch = errors.New("backup object was encrypted with a different key")
+
+// ErrUnencryptedObject is returned when an object carries no encryption
+// header and the provider was not told to expect plaintext objects.
+var ErrUnencryptedObject = errors.New("backup object is not encrypted")
+
+// EncryptedProvider encrypts every object with AES-256-GCM before it reaches
+// the wrapped provider and decrypts it again on download, so the storage
+// backend only ever holds ciphertext.
+type EncryptedProvider struct {
+	inner          BackupProvider
+	keyID          string
+	aead           cipher.AEAD
+	allowPlaintext bool
+}
+
+// NewEncryptedProvider wraps inner with client-side encryption under a
+// 32-byte key. keyID is recorded in each object so a restore with the wrong
+// key fails clearly instead of as an authentication error.
+func NewEncryptedProvider(inner BackupProvider, keyID string, key []byte) (*EncryptedProvider, error) {
+	if inner == nil {
+		return nil, errors.New("encrypted provider requires a backing provider")
+	}
+	if len(key) != encryptedKeySize {
+		return nil, fmt.Errorf("backup encryption key must be %d bytes, got %d", encryptedKeySize, len(key))
+	}
+	if keyID == "" || len(keyID) > 255 {
+		return nil, errors.New("backup encryption key ID must be 1-255 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedProvider{inner: inner, keyID: keyID, aead: aead}, nil
+}
+
+// KeyID reports the key the provider encrypts with.
+func (e *EncryptedProvider) KeyID() string {
+	return e.keyID
+}
+
+// AllowPlaintextObjects makes Download pass objects without an encryption
+// header through unchanged. Only for snapshots the server records as written
+// before client-side encryption was enabled; otherwise a headerless object
+// means the storage was tampered with and the download fails.
+func (e *EncryptedProvider) AllowPlaintextObjects() {
+	e.allowPlaintext = true
+}
+
+// BackupIdentity implements JournalIdentity. Encrypted objects differ from
+// plaintext ones, so a journal from an unencrypted run is never resumed.
+func (e *EncryptedProvider) BackupIdentity() string {
+	if idp, ok := e.inner.(JournalIdentity); ok {
+		return "encrypted|" + e.keyID + "|" + idp.BackupIdentity()
+	}
+	return fmt.Sprintf("encrypted|%s|%T", e.keyID, e.inner)
+}
+
+// Upload encrypts the file and sends the ciphertext to the wrapped provider.
+func (e *EncryptedProvider) Upload(localPath, remotePath string) error {
+	return e.UploadContext(context.Background(), localPath, remotePath)
+}
+
+// UploadContext encrypts the file and sends the ciphertext to the wrapped
+// provider with cancellation support.
+func (e *EncryptedProvider) UploadContext(ctx context.Context, localPath, remotePath string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	src, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file for encryption: %w", err)
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp("", "breeze-enc-*")
+	if err != nil {
+		return fmt.Errorf("failed to create encryption temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if err := e.encrypt(ctx, tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write encrypted temp file: %w", err)
+	}
+
+	if uploader, ok := e.inner.(interface {
+		UploadContext(context.Context, string, string) error
+	}); ok {
+		return uploader.UploadContext(ctx, tmpPath, remotePath)
+	}
+	return e.inner.Upload(tmpPath, remotePath)
+}
+
+// Download fetches the object from the wrapped provider and decrypts it to
+// localPath. An object without an encryption header fails with
+// ErrUnencryptedObject unless AllowPlaintextObjects was called.
+func (e *EncryptedProvider) Download(remotePath, localPath string) error {
+	dir := filepath.Dir(localPath)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create download directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".breeze-dec-*")
+	if err != nil {
+		return fmt.Errorf("failed to create decryption temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath)
+
+	if err := e.inner.Download(remotePath, tmpPath); err != nil {
+		return err
+	}
+
+	src, err := os.Open(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to open downloaded object: %w", err)
+	}
+	defer src.Close()
+
+	outPath := localPath + ".decrypting"
+	dst, err := os.OpenFile(outPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create decrypted file: %w", err)
+	}
+	if err := e.decrypt(dst, src); err != nil {
+		dst.Close()
+		os.Remove(outPath)
+		return fmt.Errorf("failed to decrypt %s: %w", remotePath, err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(outPath)
+		return fmt.Errorf("failed to write decrypted file: %w", err)
+	}
+	if err := os.Rename(outPath, localPath); err != nil {
+		os.Remove(outPath)
+		return fmt.Errorf("failed to move decrypted file into place: %w", err)
+	}
+	return nil
+}
+
+// List delegates to the wrapped provider; object names are not encrypted.
+func (e *EncryptedProvider) List(prefix string) ([]string, error) {
+	return e.inner.List(prefix)
+}
+
+// Delete delegates to the wrapped provider.
+func (e *EncryptedProvider) Delete(remotePath string) error {
+	return e.inner.Delete(remotePath)
+}
+
+func (e *EncryptedProvider) header(noncePrefix []byte) []byte {
+	header := make([]byte, 0, len(encryptedMagic)+1+len(e.keyID)+encryptedNoncePrefix)
+	header = append(header, encryptedMagic...)
+	header = append(header, byte(len(e.keyID)))
+	header = append(header, e.keyID...)
+	return append(header, noncePrefix...)
+}
+
+func (e *EncryptedProvider) encrypt(ctx context.Context, dst io.Writer, src io.Reader) error {
+	noncePrefix := make([]byte, encryptedNoncePrefix)
+	if _, err := rand.Read(noncePrefix); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	header := e.header(noncePrefix)
+	w := bufio.NewWriter(dst)
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write encrypted header: %w", err)
+	}
+
+	r := bufio.NewReaderSize(src, encryptedChunkSize)
+	plain := make([]byte, encryptedChunkSize)
+	var sealed []byte
+	for counter := uint32(0); ; counter++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := io.ReadFull(r, plain)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("failed to read file for encryption: %w", err)
+		}
+		final := n < encryptedChunkSize
+		if !final {
+			if _, peekErr := r.Peek(1); peekErr == io.EOF {
+				final = true
+			}
+		}
+		sealed = e.aead.Seal(sealed[:0], chunkNonce(noncePrefix, counter), plain[:n], chunkAAD(header, final))
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(sealed)))
+		if _, err := w.Write(size[:]); err != nil {
+			return fmt.Errorf("failed to write encrypted chunk: %w", err)
+		}
+		if _, err := w.Write(sealed); err != nil {
+			return fmt.Errorf("failed to write encrypted chunk: %w", err)
+		}
+		if final {
+			break
+		}
+		if counter == ^uint32(0) {
+			return errors.New("file too large to encrypt")
+		}
+	}
+	return w.Flush()
+}
+
+func (e *EncryptedProvider) decrypt(dst io.Writer, src io.Reader) error {
+	r := bufio.NewReaderSize(src, encryptedChunkSize+e.aead.Overhead()+4)
+	magic, err := r.Peek(len(encryptedMagic))
+	if err != nil || !bytes.Equal(magic, []byte(encryptedMagic)) {
+		if !e.allowPlaintext {
+			return ErrUnencryptedObject
+		}
+		// Written before client-side encryption was enabled.
+		_, copyErr := io.Copy(dst, r)
+		return copyErr
+	}
+	if _, err := r.Discard(len(encryptedMagic)); err != nil {
+		return err
+	}
+	keyLen, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("truncated header: %w", err)
+	}
+	keyID := make([]byte, keyLen)
+	if _, err := io.ReadFull(r, keyID); err != nil {
+		return fmt.Errorf("truncated header: %w", err)
+	}
+	if string(keyID) != e.keyID {
+		return fmt.Errorf("%w (object key %q, provider key %q)", ErrEncryptionKeyMismatch, keyID, e.keyID)
+	}
+	noncePrefix := make([]byte, encryptedNoncePrefix)
+	if _, err := io.ReadFull(r, noncePrefix); err != nil {
+		return fmt.Errorf("truncated header: %w", err)
+	}
+	header := e.header(noncePrefix)
+
+	maxSealed := encryptedChunkSize + e.aead.Overhead()
+	sealed := make([]byte, maxSealed)
+	var plain []byte
+	for counter := uint32(0); ; counter++ {
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return errors.New("encrypted object is truncated")
+		}
+		n := int(binary.BigEndian.Uint32(size[:]))
+		if n < e.aead.Overhead() || n > maxSealed {
+			return fmt.Errorf("invalid encrypted chunk size %d", n)
+		}
+		if _, err := io.ReadFull(r, sealed[:n]); err != nil {
+			return errors.New("encrypted object is truncated")
+		}
+		_, peekErr := r.Peek(1)
+		final := peekErr == io.EOF
+		plain, err = e.aead.Open(plain[:0], chunkNonce(noncePrefix, counter), sealed[:n], chunkAAD(header, final))
+		if err != nil {
+			return errors.New("encrypted object failed authentication")
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return fmt.Errorf("failed to write decrypted data: %w", err)
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+func chunkNonce(prefix []byte, counter uint32) []byte {
+	nonce := make([]byte, encryptedNoncePrefix+4)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[encryptedNoncePrefix:], counter)
+	return nonce
+}
+
+func chunkAAD(header []byte, final bool) []byte {
+	aad := make([]byte, len(header)+1)
+	copy(aad, header)
+	if final {
+		aad[len(header)] = 1
+	}
+	return aad
+}
@@ -0,0 +1,169 @@
+package providers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testEncryptionKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("rand: %v", err)
+	}
+	return key
+}
+
+func TestEncryptedProvider_RoundTrip(t *testing.T) {
+	baseDir := t.TempDir()
+	key := testEncryptionKey(t)
+	p, err := NewEncryptedProvider(NewLocalProvider(baseDir), "device-key-1", key)
+	if err != nil {
+		t.Fatalf("NewEncryptedProvider: %v", err)
+	}
+
+	sizes := map[string]int{
+		"empty":      0,
+		"small":      100,
+		"exactChunk": encryptedChunkSize,
+		"multiChunk": 2*encryptedChunkSize + 17,
+	}
+	for name, size := range sizes {
+		t.Run(name, func(t *testing.T) {
+			content := make([]byte, size)
+			if _, err := rand.Read(content); err != nil {
+				t.Fatalf("rand: %v", err)
+			}
+			srcPath := filepath.Join(t.TempDir(), "src.bin")
+			if err := os.WriteFile(srcPath, content, 0o600); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if err := p.Upload(srcPath, "snapshots/"+name); err != nil {
+				t.Fatalf("Upload: %v", err)
+			}
+
+			stored, err := os.ReadFile(filepath.Join(baseDir, "snapshots", name))
+			if err != nil {
+				t.Fatalf("read stored: %v", err)
+			}
+			if size > 0 && bytes.Contains(stored, content[:min(size, 64)]) {
+				t.Fatal("stored object contains plaintext")
+			}
+
+			destPath := filepath.Join(t.TempDir(), "restored.bin")
+			if err := p.Download("snapshots/"+name, destPath); err != nil {
+				t.Fatalf("Download: %v", err)
+			}
+			restored, err := os.ReadFile(destPath)
+			if err != nil {
+				t.Fatalf("read restored: %v", err)
+			}
+			if !bytes.Equal(restored, content) {
+				t.Fatalf("restored %d bytes, want %d matching bytes", len(restored), len(content))
+			}
+		})
+	}
+}
+
+func TestEncryptedProvider_RejectsTamperingAndWrongKey(t *testing.T) {
+	baseDir := t.TempDir()
+	p, err := NewEncryptedProvider(NewLocalProvider(baseDir), "device-key-1", testEncryptionKey(t))
+	if err != nil {
+		t.Fatalf("NewEncryptedProvider: %v", err)
+	}
+	srcPath := filepath.Join(t.TempDir(), "src.txt")
+	if err := os.WriteFile(srcPath, bytes.Repeat([]byte("customer data "), 1000), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := p.Upload(srcPath, "obj"); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	storedPath := filepath.Join(baseDir, "obj")
+	stored, err := os.ReadFile(storedPath)
+	if err != nil {
+		t.Fatalf("read stored: %v", err)
+	}
+
+	otherKey, err := NewEncryptedProvider(NewLocalProvider(baseDir), "device-key-1", testEncryptionKey(t))
+	if err != nil {
+		t.Fatalf("NewEncryptedProvider: %v", err)
+	}
+	if err := otherKey.Download("obj", filepath.Join(t.TempDir(), "out")); err == nil {
+		t.Fatal("expected a different key to fail authentication")
+	}
+
+	otherID, err := NewEncryptedProvider(NewLocalProvider(baseDir), "device-key-2", testEncryptionKey(t))
+	if err != nil {
+		t.Fatalf("NewEncryptedProvider: %v", err)
+	}
+	if err := otherID.Download("obj", filepath.Join(t.TempDir(), "out")); !errors.Is(err, ErrEncryptionKeyMismatch) {
+		t.Fatalf("expected ErrEncryptionKeyMismatch, got %v", err)
+	}
+
+	tampered := append([]byte(nil), stored...)
+	tampered[len(tampered)-1] ^= 0xff
+	if err := os.WriteFile(storedPath, tampered, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	outPath := filepath.Join(t.TempDir(), "out")
+	if err := p.Download("obj", outPath); err == nil {
+		t.Fatal("expected a tampered object to fail authentication")
+	}
+	if _, err := os.Stat(outPath); !os.IsNotExist(err) {
+		t.Fatal("expected no output file after a failed decrypt")
+	}
+
+	if err := os.WriteFile(storedPath, stored[:len(stored)-20], 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := p.Download("obj", outPath); err == nil {
+		t.Fatal("expected a truncated object to fail")
+	}
+}
+
+func TestEncryptedProvider_PlaintextObjectsOnlyWhenAllowed(t *testing.T) {
+	baseDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(baseDir, "legacy"), []byte("written before encryption"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	p, err := NewEncryptedProvider(NewLocalProvider(baseDir), "device-key-1", testEncryptionKey(t))
+	if err != nil {
+		t.Fatalf("NewEncryptedProvider: %v", err)
+	}
+	outPath := filepath.Join(t.TempDir(), "out")
+	// A swapped-in plaintext object must not restore as if it were genuine.
+	if err := p.Download("legacy", outPath); !errors.Is(err, ErrUnencryptedObject) {
+		t.Fatalf("Download = %v, want ErrUnencryptedObject", err)
+	}
+	if _, err := os.Stat(outPath); !os.IsNotExist(err) {
+		t.Fatal("rejected object must not be written to the destination")
+	}
+
+	p.AllowPlaintextObjects()
+	if err := p.Download("legacy", outPath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if got, _ := os.ReadFile(outPath); string(got) != "written before encryption" {
+		t.Fatalf("unexpected content %q", got)
+	}
+}
+
+func TestNewEncryptedProvider_ValidatesKey(t *testing.T) {
+	if _, err := NewEncryptedProvider(NewLocalProvider(t.TempDir()), "k", make([]byte, 16)); err == nil {
+		t.Fatal("expected a short key to be rejected")
+	}
+	if _, err := NewEncryptedProvider(NewLocalProvider(t.TempDir()), "", make([]byte, 32)); err == nil {
+		t.Fatal("expected an empty key ID to be rejected")
+	}
+	p, err := NewEncryptedProvider(NewLocalProvider("/tmp/a"), "k", make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewEncryptedProvider: %v", err)
+	}
+	if p.BackupIdentity() == NewLocalProvider("/tmp/a").BackupIdentity() {
+		t.Fatal("encrypted identity must differ from the plaintext destination")
+	}
+}
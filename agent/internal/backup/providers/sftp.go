@@ -0,0 +1,471 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/time/rate"
+)
+
+const (
+	sftpDialTimeout = 30 * time.Second
+	// sftpCopyBuffer is how much each download step asks the client for;
+	// pkg/sftp splits it into packets and keeps them in flight together
+	// instead of waiting out a round trip per packet.
+	sftpCopyBuffer = 1 << 20
+	// sftpConcurrentRequests bounds the packets in flight per transfer.
+	sftpConcurrentRequests = 64
+)
+
+// SFTPConfig configures an SFTPProvider.
+type SFTPConfig struct {
+	Host     string
+	Port     int // 22 when zero
+	Username string
+	// Password and/or PrivateKey (PEM or OpenSSH format) authenticate the
+	// user; at least one is required.
+	Password             string
+	PrivateKey           []byte
+	PrivateKeyPassphrase string
+	// HostKeyFingerprint pins the server's host key, in the "SHA256:..."
+	// form printed by `ssh-keygen -lf`. Required: NAS boxes rarely have
+	// keys anyone can verify any other way.
+	HostKeyFingerprint string
+	// BasePath is the directory on the server that backups are written under.
+	BasePath string
+	// BandwidthLimit caps transfer speed in bytes per second (0 = unlimited).
+	BandwidthLimit int64
+}
+
+// SFTPProvider stores backups on an SSH server (Synology, TrueNAS, any
+// OpenSSH host) over SFTP.
+type SFTPProvider struct {
+	addr        string
+	username    string
+	auth        []ssh.AuthMethod
+	fingerprint string
+	basePath    string
+	limiter     *rate.Limiter
+
+	mu   sync.Mutex
+	conn *sftpConn
+}
+
+type sftpConn struct {
+	ssh    *ssh.Client
+	client *sftp.Client
+	// done is closed once the SFTP session has ended.
+	done chan struct{}
+}
+
+func (c *sftpConn) close() {
+	c.client.Close()
+	c.ssh.Close()
+}
+
+// broken reports whether the session has ended, e.g. the NAS closed an
+// idle connection.
+func (c *sftpConn) broken() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewSFTPProvider creates an SFTPProvider. No connection is made until the
+// first operation.
+func NewSFTPProvider(cfg SFTPConfig) (*SFTPProvider, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("sftp host is required")
+	}
+	if cfg.Username == "" {
+		return nil, errors.New("sftp username is required")
+	}
+	fingerprint := strings.TrimSpace(cfg.HostKeyFingerprint)
+	if fingerprint == "" {
+		return nil, errors.New("sftp host key fingerprint is required")
+	}
+	if !strings.HasPrefix(fingerprint, "SHA256:") {
+		fingerprint = "SHA256:" + fingerprint
+	}
+	var auth []ssh.AuthMethod
+	if len(cfg.PrivateKey) > 0 {
+		var signer ssh.Signer
+		var err error
+		if cfg.PrivateKeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(cfg.PrivateKey, []byte(cfg.PrivateKeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(cfg.PrivateKey)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid sftp private key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		auth = append(auth, ssh.Password(cfg.Password))
+	}
+	if len(auth) == 0 {
+		return nil, errors.New("sftp password or private key is required")
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 22
+	}
+	basePath := path.Clean("/" + strings.TrimSpace(filepath.ToSlash(cfg.BasePath)))
+	return &SFTPProvider{
+		addr:        net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		username:    cfg.Username,
+		auth:        auth,
+		fingerprint: fingerprint,
+		basePath:    basePath,
+		limiter:     newBandwidthLimiter(cfg.BandwidthLimit),
+	}, nil
+}
+
+// BackupIdentity implements JournalIdentity.
+func (p *SFTPProvider) BackupIdentity() string {
+	return fmt.Sprintf("sftp|%s@%s|%s", p.username, p.addr, p.basePath)
+}
+
+// checkHostKey rejects any host key other than the pinned one.
+func (p *SFTPProvider) checkHostKey(_ string, _ net.Addr, key ssh.PublicKey) error {
+	if got := ssh.FingerprintSHA256(key); got != p.fingerprint {
+		return fmt.Errorf("sftp host key mismatch: server presented %s, expected %s", got, p.fingerprint)
+	}
+	return nil
+}
+
+func (p *SFTPProvider) dial(ctx context.Context) (*sftpConn, error) {
+	dialer := net.Dialer{Timeout: sftpDialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to sftp server: %w", err)
+	}
+	config := &ssh.ClientConfig{
+		User:            p.username,
+		Auth:            p.auth,
+		HostKeyCallback: p.checkHostKey,
+		Timeout:         sftpDialTimeout,
+	}
+	// Bound the handshake; ssh.ClientConfig.Timeout only covers the dial.
+	netConn.SetDeadline(time.Now().Add(sftpDialTimeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, p.addr, config)
+	if err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("sftp ssh handshake failed: %w", err)
+	}
+	netConn.SetDeadline(time.Time{})
+	client := ssh.NewClient(sshConn, chans, reqs)
+
+	sftpClient, err := sftp.NewClient(client,
+		sftp.UseConcurrentWrites(true),
+		sftp.UseConcurrentReads(true),
+		sftp.MaxConcurrentRequestsPerFile(sftpConcurrentRequests),
+	)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to start sftp session: %w", err)
+	}
+	conn := &sftpConn{ssh: client, client: sftpClient, done: make(chan struct{})}
+	go func() {
+		sftpClient.Wait()
+		close(conn.done)
+	}()
+	return conn, nil
+}
+
+// withClient runs fn on the cached connection, dialling one if needed. A
+// connection that dropped (e.g. the NAS closed it while idle) is replaced
+// and fn retried once.
+func (p *SFTPProvider) withClient(ctx context.Context, fn func(*sftp.Client) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for attempt := 0; ; attempt++ {
+		p.mu.Lock()
+		conn := p.conn
+		if conn == nil {
+			var err error
+			conn, err = p.dial(ctx)
+			if err != nil {
+				p.mu.Unlock()
+				return err
+			}
+			p.conn = conn
+		}
+		p.mu.Unlock()
+
+		err := fn(conn.client)
+		if err == nil || !conn.broken() {
+			return err
+		}
+		p.mu.Lock()
+		if p.conn == conn {
+			p.conn = nil
+		}
+		p.mu.Unlock()
+		conn.close()
+		if attempt > 0 || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// Close drops the cached connection.
+func (p *SFTPProvider) Close() error {
+	p.mu.Lock()
+	conn := p.conn
+	p.conn = nil
+	p.mu.Unlock()
+	if conn != nil {
+		conn.close()
+	}
+	return nil
+}
+
+// remotePath resolves a backup-relative path under basePath, refusing paths
+// that climb out of it.
+func (p *SFTPProvider) remotePath(rel string) (string, error) {
+	return joinRemotePath(p.basePath, rel)
+}
+
+func joinRemotePath(base, rel string) (string, error) {
+	rel = filepath.ToSlash(rel)
+	for _, segment := range strings.Split(rel, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("path traversal detected: %q resolves outside base %q", rel, base)
+		}
+	}
+	return path.Join(base, rel), nil
+}
+
+// Upload sends a local file to the SFTP server.
+func (p *SFTPProvider) Upload(localPath, remotePath string) error {
+	return p.UploadContext(context.Background(), localPath, remotePath)
+}
+
+// UploadContext sends a local file to the SFTP server with cancellation
+// support. The file is written beside its destination and renamed into
+// place, so an interrupted upload never leaves a truncated object.
+func (p *SFTPProvider) UploadContext(ctx context.Context, localPath, remotePath string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if localPath == "" {
+		return errors.New("local source path is required")
+	}
+	if remotePath == "" {
+		return errors.New("remote path is required")
+	}
+	dest, err := p.remotePath(remotePath)
+	if err != nil {
+		return err
+	}
+
+	return p.withClient(ctx, func(c *sftp.Client) error {
+		src, err := os.Open(localPath)
+		if err != nil {
+			return fmt.Errorf("failed to open local file: %w", err)
+		}
+		defer src.Close()
+
+		if err := sftpMkdirAll(c, p.basePath, path.Dir(dest)); err != nil {
+			return fmt.Errorf("failed to create sftp directory: %w", err)
+		}
+		partial := dest + ".partial"
+		f, err := c.OpenFile(partial, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", partial, err)
+		}
+		r := &contextReader{ctx: ctx, reader: uploadReader(ctx, throttleReader(ctx, src, p.limiter))}
+		if _, err := f.ReadFromWithConcurrency(r, sftpConcurrentRequests); err != nil {
+			f.Close()
+			c.Remove(partial)
+			return fmt.Errorf("failed to upload %s: %w", remotePath, err)
+		}
+		if err := f.Close(); err != nil {
+			c.Remove(partial)
+			return fmt.Errorf("failed to finish upload of %s: %w", remotePath, err)
+		}
+		if err := sftpRename(c, partial, dest); err != nil {
+			c.Remove(partial)
+			return fmt.Errorf("failed to move %s into place: %w", remotePath, err)
+		}
+		return nil
+	})
+}
+
+// sftpRename moves oldPath over newPath. SFTPv3 RENAME refuses to replace an
+// existing file, so servers without posix-rename get a remove first.
+func sftpRename(c *sftp.Client, oldPath, newPath string) error {
+	if _, ok := c.HasExtension("posix-rename@openssh.com"); ok {
+		return c.PosixRename(oldPath, newPath)
+	}
+	if err := c.Remove(newPath); err != nil && !isSFTPNotExist(err) {
+		return err
+	}
+	return c.Rename(oldPath, newPath)
+}
+
+func isSFTPNotExist(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// Download retrieves a file from the SFTP server.
+func (p *SFTPProvider) Download(remotePath, localPath string) error {
+	if remotePath == "" {
+		return errors.New("remote path is required")
+	}
+	if localPath == "" {
+		return errors.New("local destination path is required")
+	}
+	src, err := p.remotePath(remotePath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	ctx := context.Background()
+	return p.withClient(ctx, func(c *sftp.Client) error {
+		f, err := c.Open(src)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", remotePath, err)
+		}
+		defer f.Close()
+
+		tmpPath := localPath + ".download"
+		dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to create local file: %w", err)
+		}
+		// Reads of a whole buffer are split into concurrent requests.
+		reader := throttleReader(ctx, f, p.limiter)
+		if _, err := io.CopyBuffer(dst, reader, make([]byte, sftpCopyBuffer)); err != nil {
+			dst.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to download %s: %w", remotePath, err)
+		}
+		if err := dst.Close(); err != nil {
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to write local file: %w", err)
+		}
+		if err := os.Rename(tmpPath, localPath); err != nil {
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to move download into place: %w", err)
+		}
+		return nil
+	})
+}
+
+// List enumerates files under the given prefix, relative to the base path.
+func (p *SFTPProvider) List(prefix string) ([]string, error) {
+	root, err := p.remotePath(prefix)
+	if err != nil {
+		return nil, err
+	}
+	results := []string{}
+	err = p.withClient(context.Background(), func(c *sftp.Client) error {
+		results = results[:0]
+		attrs, err := c.Stat(root)
+		if err != nil {
+			if isSFTPNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to stat prefix %s: %w", root, err)
+		}
+		if !attrs.IsDir() {
+			results = append(results, p.relative(root))
+			return nil
+		}
+		return p.walk(c, root, &results)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backup files: %w", err)
+	}
+	return results, nil
+}
+
+func (p *SFTPProvider) walk(c *sftp.Client, dir string, results *[]string) error {
+	entries, err := c.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		full := path.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if err := p.walk(c, full, results); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasSuffix(entry.Name(), ".partial") {
+			continue
+		}
+		*results = append(*results, p.relative(full))
+	}
+	return nil
+}
+
+func (p *SFTPProvider) relative(full string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(full, p.basePath), "/")
+}
+
+// Delete removes a file from the SFTP server. Missing files are not an error.
+func (p *SFTPProvider) Delete(remotePath string) error {
+	if remotePath == "" {
+		return errors.New("remote path is required")
+	}
+	target, err := p.remotePath(remotePath)
+	if err != nil {
+		return err
+	}
+	return p.withClient(context.Background(), func(c *sftp.Client) error {
+		if err := c.Remove(target); err != nil && !isSFTPNotExist(err) {
+			return fmt.Errorf("failed to delete backup file: %w", err)
+		}
+		return nil
+	})
+}
+
+// sftpMkdirAll creates dir and its parents below base, which must exist.
+func sftpMkdirAll(c *sftp.Client, base, dir string) error {
+	if dir == base || !strings.HasPrefix(dir, strings.TrimSuffix(base, "/")+"/") {
+		return nil
+	}
+	if attrs, err := c.Stat(dir); err == nil {
+		if !attrs.IsDir() {
+			return fmt.Errorf("%s exists and is not a directory", dir)
+		}
+		return nil
+	} else if !isSFTPNotExist(err) {
+		return err
+	}
+	if err := sftpMkdirAll(c, base, path.Dir(dir)); err != nil {
+		return err
+	}
+	if err := c.Mkdir(dir); err != nil {
+		// Lost a race with a concurrent upload creating the same directory.
+		if attrs, statErr := c.Stat(dir); statErr == nil && attrs.IsDir() {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
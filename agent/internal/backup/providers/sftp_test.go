@@ -0,0 +1,348 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Compile-time interface compliance check.
+var _ BackupProvider = (*SFTPProvider)(nil)
+var _ JournalIdentity = (*SFTPProvider)(nil)
+
+// testSFTPServer is an SSH server on localhost whose "sftp" subsystem is the
+// pkg/sftp server over the local filesystem. Providers it hands out are
+// rooted under root.
+type testSFTPServer struct {
+	addr        string
+	fingerprint string
+	root        string
+}
+
+func startTestSFTPServer(t *testing.T, password string) *testSFTPServer {
+	t.Helper()
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate host key: %v", err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatalf("host signer: %v", err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) == password {
+				return nil, nil
+			}
+			return nil, errors.New("bad password")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &testSFTPServer{
+		addr:        ln.Addr().String(),
+		fingerprint: ssh.FingerprintSHA256(hostSigner.PublicKey()),
+		root:        t.TempDir(),
+	}
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		ln.Close()
+		wg.Wait()
+	})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				srv.serveConn(conn, config)
+			}()
+		}
+	}()
+	return srv
+}
+
+func (s *testSFTPServer) serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	defer conn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newCh := range chans {
+		if newCh.ChannelType() != "session" {
+			newCh.Reject(ssh.UnknownChannelType, "session only")
+			continue
+		}
+		ch, chReqs, err := newCh.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range chReqs {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+				if ok {
+					go func() {
+						defer ch.Close()
+						server, err := sftp.NewServer(ch)
+						if err != nil {
+							return
+						}
+						server.Serve()
+					}()
+				}
+			}
+		}()
+	}
+}
+
+func (s *testSFTPServer) provider(t *testing.T, cfg SFTPConfig) *SFTPProvider {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(s.addr)
+	cfg.Host = host
+	cfg.Port, _ = strconv.Atoi(port)
+	if cfg.Username == "" {
+		cfg.Username = "backup"
+	}
+	cfg.BasePath = path.Join(filepath.ToSlash(s.root), cfg.BasePath)
+	p, err := NewSFTPProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewSFTPProvider: %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestSFTPProvider_RoundTrip(t *testing.T) {
+	srv := startTestSFTPServer(t, "s3cret")
+	if err := os.Mkdir(filepath.Join(srv.root, "backups"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	p := srv.provider(t, SFTPConfig{
+		Password:           "s3cret",
+		HostKeyFingerprint: srv.fingerprint,
+		BasePath:           "/backups",
+	})
+
+	content := make([]byte, 3*sftpCopyBuffer+123)
+	if _, err := rand.Read(content); err != nil {
+		t.Fatalf("rand: %v", err)
+	}
+	srcPath := filepath.Join(t.TempDir(), "src.bin")
+	if err := os.WriteFile(srcPath, content, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	for _, remote := range []string{"snapshots/snap-1/files/a.bin", "snapshots/snap-1/manifest.json", "other/b.bin"} {
+		if err := p.Upload(srcPath, remote); err != nil {
+			t.Fatalf("Upload %s: %v", remote, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(srv.root, "backups", "snapshots", "snap-1", "files", "a.bin.partial")); !os.IsNotExist(err) {
+		t.Fatal("expected the partial upload file to be renamed away")
+	}
+
+	listed, err := p.List("snapshots/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	sort.Strings(listed)
+	want := []string{"snapshots/snap-1/files/a.bin", "snapshots/snap-1/manifest.json"}
+	if strings.Join(listed, ",") != strings.Join(want, ",") {
+		t.Fatalf("List = %v, want %v", listed, want)
+	}
+	if missing, err := p.List("does-not-exist"); err != nil || len(missing) != 0 {
+		t.Fatalf("List(missing) = %v, %v; want empty", missing, err)
+	}
+
+	destPath := filepath.Join(t.TempDir(), "out", "a.bin")
+	if err := p.Download("snapshots/snap-1/files/a.bin", destPath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	got, err := os.ReadFile(destPath)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("downloaded %d bytes, want %d matching bytes", len(got), len(content))
+	}
+
+	if err := p.Delete("other/b.bin"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := p.Delete("other/b.bin"); err != nil {
+		t.Fatalf("Delete of a missing file should succeed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(srv.root, "backups", "other", "b.bin")); !os.IsNotExist(err) {
+		t.Fatal("expected file to be deleted")
+	}
+}
+
+func TestSFTPProvider_UploadReplacesExistingFile(t *testing.T) {
+	srv := startTestSFTPServer(t, "s3cret")
+	p := srv.provider(t, SFTPConfig{Password: "s3cret", HostKeyFingerprint: srv.fingerprint})
+
+	dir := t.TempDir()
+	for i, content := range []string{"first version, longer than the second", "second"} {
+		src := filepath.Join(dir, strconv.Itoa(i))
+		if err := os.WriteFile(src, []byte(content), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := p.Upload(src, "index/latest.json"); err != nil {
+			t.Fatalf("Upload %d: %v", i, err)
+		}
+	}
+	got, err := os.ReadFile(filepath.Join(srv.root, "index", "latest.json"))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != "second" {
+		t.Fatalf("remote file = %q, want the second upload", got)
+	}
+}
+
+func TestSFTPProvider_UploadContextCancelled(t *testing.T) {
+	srv := startTestSFTPServer(t, "s3cret")
+	p := srv.provider(t, SFTPConfig{Password: "s3cret", HostKeyFingerprint: srv.fingerprint})
+
+	src := filepath.Join(t.TempDir(), "src.bin")
+	if err := os.WriteFile(src, make([]byte, 1<<20), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	// Connect first so the cancellation stops the transfer, not the dial.
+	if _, err := p.List(""); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.UploadContext(ctx, src, "cancelled.bin"); err == nil {
+		t.Fatal("expected a cancelled upload to fail")
+	}
+	for _, name := range []string{"cancelled.bin", "cancelled.bin.partial"} {
+		if _, err := os.Stat(filepath.Join(srv.root, name)); !os.IsNotExist(err) {
+			t.Fatalf("%s should not exist after a cancelled upload", name)
+		}
+	}
+}
+
+func TestSFTPProvider_ReconnectsAfterDrop(t *testing.T) {
+	srv := startTestSFTPServer(t, "s3cret")
+	p := srv.provider(t, SFTPConfig{Password: "s3cret", HostKeyFingerprint: srv.fingerprint})
+	if _, err := p.List(""); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	// Drop the cached connection underneath the provider, as an idle NAS does.
+	p.mu.Lock()
+	p.conn.ssh.Close()
+	conn := p.conn
+	p.mu.Unlock()
+	select {
+	case <-conn.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the dropped session was not noticed")
+	}
+
+	if _, err := p.List(""); err != nil {
+		t.Fatalf("List after the connection dropped: %v", err)
+	}
+}
+
+func TestSFTPProvider_RejectsUnpinnedHostKey(t *testing.T) {
+	srv := startTestSFTPServer(t, "s3cret")
+	p := srv.provider(t, SFTPConfig{
+		Password:           "s3cret",
+		HostKeyFingerprint: "SHA256:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+	})
+	_, err := p.List("")
+	if err == nil || !strings.Contains(err.Error(), "host key mismatch") {
+		t.Fatalf("expected host key mismatch, got %v", err)
+	}
+}
+
+func TestSFTPProvider_RejectsPathTraversal(t *testing.T) {
+	p, err := NewSFTPProvider(SFTPConfig{Host: "nas", Username: "u", Password: "p", HostKeyFingerprint: "SHA256:x", BasePath: "/backups"})
+	if err != nil {
+		t.Fatalf("NewSFTPProvider: %v", err)
+	}
+	if err := p.Upload("/etc/hosts", "../escape"); err == nil {
+		t.Fatal("expected traversal to be rejected")
+	}
+	if err := p.Delete("snapshots/../../etc/passwd"); err == nil {
+		t.Fatal("expected traversal to be rejected")
+	}
+}
+
+func TestSFTPProvider_BandwidthLimit(t *testing.T) {
+	srv := startTestSFTPServer(t, "s3cret")
+	p := srv.provider(t, SFTPConfig{
+		Password:           "s3cret",
+		HostKeyFingerprint: srv.fingerprint,
+		BandwidthLimit:     64 << 10,
+	})
+	srcPath := filepath.Join(t.TempDir(), "src.bin")
+	// 64 KiB burst plus 32 KiB at 64 KiB/s: at least ~0.5s.
+	if err := os.WriteFile(srcPath, make([]byte, 96<<10), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	start := time.Now()
+	if err := p.Upload(srcPath, "limited.bin"); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("upload took %v, expected the bandwidth limit to slow it down", elapsed)
+	}
+}
+
+func TestNewSFTPProvider_Validation(t *testing.T) {
+	base := SFTPConfig{Host: "nas", Username: "u", Password: "p", HostKeyFingerprint: "SHA256:x"}
+	cases := map[string]func(c *SFTPConfig){
+		"missing host":        func(c *SFTPConfig) { c.Host = "" },
+		"missing username":    func(c *SFTPConfig) { c.Username = "" },
+		"missing fingerprint": func(c *SFTPConfig) { c.HostKeyFingerprint = "" },
+		"missing credentials": func(c *SFTPConfig) { c.Password = "" },
+		"bad private key":     func(c *SFTPConfig) { c.PrivateKey = []byte("not a key") },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			cfg := base
+			mutate(&cfg)
+			if _, err := NewSFTPProvider(cfg); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+
+	a, _ := NewSFTPProvider(SFTPConfig{Host: "nas", Username: "u", Password: "hunter2", HostKeyFingerprint: "x", BasePath: "/a"})
+	b, _ := NewSFTPProvider(SFTPConfig{Host: "nas", Username: "u", Password: "hunter2", HostKeyFingerprint: "x", BasePath: "/b"})
+	if a.BackupIdentity() == b.BackupIdentity() {
+		t.Fatal("different base paths must produce different identities")
+	}
+	if strings.Contains(a.BackupIdentity(), "hunter2") {
+		t.Fatal("identity must not include credentials")
+	}
+}
@@ -0,0 +1,98 @@
+package providers
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// throttleBurst caps how many bytes a single read may take from the limiter,
+// so low limits still produce a steady stream rather than long stalls.
+const throttleBurst = 64 << 10
+
+// newBandwidthLimiter returns a limiter for bytesPerSec, or nil when the
+// limit is zero or negative (unlimited).
+func newBandwidthLimiter(bytesPerSec int64) *rate.Limiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	burst := throttleBurst
+	if bytesPerSec < int64(burst) {
+		burst = int(bytesPerSec)
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSec), burst)
+}
+
+// throttledReader paces reads from r to the limiter's rate.
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+// throttleReader wraps r so reads are paced by limiter. A nil limiter
+// returns r unchanged.
+func throttleReader(ctx context.Context, r io.Reader, limiter *rate.Limiter) io.Reader {
+	if limiter == nil {
+		return r
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &throttledReader{ctx: ctx, r: r, limiter: limiter}
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if burst := t.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 {
+		if waitErr := t.limiter.WaitN(t.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// throttledReadSeeker keeps a throttled file seekable, which the S3 SDK
+// needs to size and retry a PutObject body.
+type throttledReadSeeker struct {
+	*throttledReader
+	seeker io.Seeker
+}
+
+func (t *throttledReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	return t.seeker.Seek(offset, whence)
+}
+
+type uploadLimiterKey struct{}
+
+// WithUploadLimit returns a context under which every upload made by the
+// remote providers shares one bytesPerSec budget, so concurrent uploads in a
+// backup run cannot together exceed it. bytesPerSec <= 0 returns ctx as is.
+// LocalProvider ignores the limit: it writes to a disk or LAN share, not the
+// uplink the limit protects.
+func WithUploadLimit(ctx context.Context, bytesPerSec int64) context.Context {
+	limiter := newBandwidthLimiter(bytesPerSec)
+	if limiter == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, uploadLimiterKey{}, limiter)
+}
+
+// uploadReader paces r by the upload limit carried on ctx, if any. A
+// seekable r stays seekable.
+func uploadReader(ctx context.Context, r io.Reader) io.Reader {
+	limiter, _ := ctx.Value(uploadLimiterKey{}).(*rate.Limiter)
+	throttled := throttleReader(ctx, r, limiter)
+	tr, ok := throttled.(*throttledReader)
+	if !ok {
+		return r
+	}
+	if seeker, ok := r.(io.Seeker); ok {
+		return &throttledReadSeeker{throttledReader: tr, seeker: seeker}
+	}
+	return tr
+}
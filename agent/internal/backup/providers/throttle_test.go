@@ -0,0 +1,47 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithUploadLimit_UnlimitedLeavesReaderAlone(t *testing.T) {
+	ctx := context.Background()
+	if got := WithUploadLimit(ctx, 0); got != ctx {
+		t.Fatal("a zero limit must return the context unchanged")
+	}
+	r := strings.NewReader("data")
+	if got := uploadReader(ctx, r); got != io.Reader(r) {
+		t.Fatal("without a limit the reader must pass through unchanged")
+	}
+}
+
+func TestUploadReader_KeepsSeekerAndPaces(t *testing.T) {
+	const limit = 64 << 10
+	ctx := WithUploadLimit(context.Background(), limit)
+
+	src := bytes.NewReader(make([]byte, 2*limit))
+	r := uploadReader(ctx, src)
+	seeker, ok := r.(io.ReadSeeker)
+	if !ok {
+		t.Fatal("a seekable source must stay seekable")
+	}
+
+	start := time.Now()
+	n, err := io.Copy(io.Discard, r)
+	if err != nil || n != 2*limit {
+		t.Fatalf("copy: n=%d err=%v", n, err)
+	}
+	// The first burst is free; the second must wait about a second.
+	if elapsed := time.Since(start); elapsed < 700*time.Millisecond {
+		t.Fatalf("upload was not paced: %d bytes in %v", n, elapsed)
+	}
+
+	if pos, err := seeker.Seek(0, io.SeekStart); err != nil || pos != 0 {
+		t.Fatalf("seek: pos=%d err=%v", pos, err)
+	}
+}
@@ -0,0 +1,380 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// WebDAVConfig configures a WebDAVProvider.
+type WebDAVConfig struct {
+	// URL is the collection backups are written under, e.g.
+	// https://nas.local:5006/backups. Only https is accepted.
+	URL      string
+	Username string
+	Password string
+	// TLSFingerprint pins the server certificate by the hex SHA-256 of its
+	// DER encoding (colons optional). When set it replaces CA validation, so
+	// a NAS with a self-signed certificate can be used safely.
+	TLSFingerprint string
+	// BandwidthLimit caps transfer speed in bytes per second (0 = unlimited).
+	BandwidthLimit int64
+}
+
+// WebDAVProvider stores backups on a WebDAV share (Synology WebDAV Server,
+// TrueNAS, Nextcloud).
+type WebDAVProvider struct {
+	baseURL  *url.URL
+	username string
+	password string
+	client   *http.Client
+	limiter  *rate.Limiter
+}
+
+// NewWebDAVProvider creates a WebDAVProvider.
+func NewWebDAVProvider(cfg WebDAVConfig) (*WebDAVProvider, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("webdav URL is required")
+	}
+	baseURL, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webdav URL: %w", err)
+	}
+	if baseURL.Scheme != "https" || baseURL.Host == "" {
+		return nil, errors.New("webdav URL must be an https:// URL")
+	}
+	baseURL.Path = strings.TrimSuffix(baseURL.Path, "/")
+	baseURL.RawQuery = ""
+	baseURL.Fragment = ""
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if fp := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(cfg.TLSFingerprint), ":", "")); fp != "" {
+		want, err := hex.DecodeString(fp)
+		if err != nil || len(want) != sha256.Size {
+			return nil, errors.New("webdav TLS fingerprint must be a hex SHA-256 digest")
+		}
+		tlsConfig.InsecureSkipVerify = true // replaced by the pin check below
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("webdav server presented no certificate")
+			}
+			got := sha256.Sum256(state.PeerCertificates[0].Raw)
+			if subtle.ConstantTimeCompare(got[:], want) != 1 {
+				return fmt.Errorf("webdav certificate mismatch: server presented %s", hex.EncodeToString(got[:]))
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &WebDAVProvider{
+		baseURL:  baseURL,
+		username: cfg.Username,
+		password: cfg.Password,
+		client: &http.Client{
+			Transport: transport,
+			// WebDAV redirects would resend credentials elsewhere.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		limiter: newBandwidthLimiter(cfg.BandwidthLimit),
+	}, nil
+}
+
+// BackupIdentity implements JournalIdentity.
+func (w *WebDAVProvider) BackupIdentity() string {
+	return "webdav|" + w.baseURL.String()
+}
+
+func (w *WebDAVProvider) objectURL(rel string) (string, error) {
+	joined, err := joinRemotePath(w.baseURL.Path, rel)
+	if err != nil {
+		return "", err
+	}
+	u := *w.baseURL
+	u.Path = joined
+	return u.String(), nil
+}
+
+func (w *WebDAVProvider) do(ctx context.Context, method, target string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return w.client.Do(req)
+}
+
+func webdavStatusError(op string, resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("webdav %s failed: %s %s", op, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// Upload sends a local file to the WebDAV share.
+func (w *WebDAVProvider) Upload(localPath, remotePath string) error {
+	return w.UploadContext(context.Background(), localPath, remotePath)
+}
+
+// UploadContext sends a local file to the WebDAV share with cancellation
+// support, creating parent collections as needed.
+func (w *WebDAVProvider) UploadContext(ctx context.Context, localPath, remotePath string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if localPath == "" {
+		return errors.New("local source path is required")
+	}
+	if remotePath == "" {
+		return errors.New("remote path is required")
+	}
+	target, err := w.objectURL(remotePath)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat local file: %w", err)
+	}
+
+	if err := w.mkcolAll(ctx, path.Dir(path.Clean("/"+filepath.ToSlash(remotePath)))); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, uploadReader(ctx, throttleReader(ctx, file, w.limiter)))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", remotePath, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return webdavStatusError("upload", resp)
+	}
+	return nil
+}
+
+// mkcolAll creates each collection on the way to dir (relative to the base
+// URL). Existing collections answer 405, which is fine.
+func (w *WebDAVProvider) mkcolAll(ctx context.Context, dir string) error {
+	if dir == "/" || dir == "." {
+		return nil
+	}
+	current := ""
+	for _, segment := range strings.Split(strings.Trim(dir, "/"), "/") {
+		current += "/" + segment
+		target, err := w.objectURL(current)
+		if err != nil {
+			return err
+		}
+		resp, err := w.do(ctx, "MKCOL", target+"/", nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create collection %s: %w", current, err)
+		}
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode <= 299, resp.StatusCode == http.StatusMethodNotAllowed:
+		default:
+			return fmt.Errorf("failed to create collection %s: %s", current, resp.Status)
+		}
+	}
+	return nil
+}
+
+// Download retrieves a file from the WebDAV share.
+func (w *WebDAVProvider) Download(remotePath, localPath string) error {
+	if remotePath == "" {
+		return errors.New("remote path is required")
+	}
+	if localPath == "" {
+		return errors.New("local destination path is required")
+	}
+	target, err := w.objectURL(remotePath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	ctx := context.Background()
+	resp, err := w.do(ctx, http.MethodGet, target, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", remotePath, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return webdavStatusError("download", resp)
+	}
+
+	tmpPath := localPath + ".download"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %w", err)
+	}
+	if _, err := io.Copy(dst, throttleReader(ctx, resp.Body, w.limiter)); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to download %s: %w", remotePath, err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write local file: %w", err)
+	}
+	if err := os.Rename(tmpPath, localPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to move download into place: %w", err)
+	}
+	return nil
+}
+
+const webdavPropfindBody = `<?xml version="1.0" encoding="utf-8"?><d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/></d:prop></d:propfind>`
+
+type webdavPropstat struct {
+	Prop struct {
+		ResourceType struct {
+			Collection *struct{} `xml:"collection"`
+		} `xml:"resourcetype"`
+	} `xml:"prop"`
+}
+
+type webdavMultistatus struct {
+	Responses []struct {
+		Href     string           `xml:"href"`
+		Propstat []webdavPropstat `xml:"propstat"`
+	} `xml:"response"`
+}
+
+func isCollection(propstat []webdavPropstat) bool {
+	for _, ps := range propstat {
+		if ps.Prop.ResourceType.Collection != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// List enumerates files under the given prefix, relative to the base URL.
+// Collections are walked with Depth: 1 requests, since many servers refuse
+// Depth: infinity.
+func (w *WebDAVProvider) List(prefix string) ([]string, error) {
+	if _, err := joinRemotePath("/", prefix); err != nil {
+		return nil, err
+	}
+	results := []string{}
+	root := path.Clean("/" + filepath.ToSlash(prefix))
+	if err := w.walk(context.Background(), root, &results, true); err != nil {
+		return nil, fmt.Errorf("failed to list backup files: %w", err)
+	}
+	return results, nil
+}
+
+func (w *WebDAVProvider) walk(ctx context.Context, rel string, results *[]string, isRoot bool) error {
+	target, err := w.objectURL(rel)
+	if err != nil {
+		return err
+	}
+	resp, err := w.do(ctx, "PROPFIND", target, bytes.NewReader([]byte(webdavPropfindBody)), map[string]string{
+		"Depth":        "1",
+		"Content-Type": "application/xml",
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound && isRoot {
+		return nil
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		return webdavStatusError("list", resp)
+	}
+	var ms webdavMultistatus
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(&ms); err != nil {
+		return fmt.Errorf("invalid PROPFIND response: %w", err)
+	}
+
+	self := strings.TrimSuffix(path.Join(w.baseURL.Path, rel), "/")
+	var subdirs []string
+	for _, r := range ms.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil {
+			continue
+		}
+		hrefPath := strings.TrimSuffix(href.Path, "/")
+		if hrefPath == self {
+			if !isCollection(r.Propstat) && isRoot {
+				*results = append(*results, strings.TrimPrefix(rel, "/"))
+			}
+			continue
+		}
+		childRel, ok := strings.CutPrefix(hrefPath, strings.TrimSuffix(w.baseURL.Path, "/")+"/")
+		if !ok {
+			continue
+		}
+		if isCollection(r.Propstat) {
+			subdirs = append(subdirs, "/"+childRel)
+			continue
+		}
+		*results = append(*results, childRel)
+	}
+	for _, dir := range subdirs {
+		if err := w.walk(ctx, dir, results, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes a file from the WebDAV share. Missing files are not an error.
+func (w *WebDAVProvider) Delete(remotePath string) error {
+	if remotePath == "" {
+		return errors.New("remote path is required")
+	}
+	target, err := w.objectURL(remotePath)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	resp, err := w.do(ctx, http.MethodDelete, target, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete backup file: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || (resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+		return nil
+	}
+	return webdavStatusError("delete", resp)
+}
@@ -0,0 +1,148 @@
+package providers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+// Compile-time interface compliance check.
+var _ BackupProvider = (*WebDAVProvider)(nil)
+var _ JournalIdentity = (*WebDAVProvider)(nil)
+
+// startTestWebDAVServer serves a temp dir over WebDAV at /dav/ behind TLS and
+// basic auth, and returns the server, its root and its certificate pin.
+func startTestWebDAVServer(t *testing.T) (*httptest.Server, string, string) {
+	t.Helper()
+	root := t.TempDir()
+	handler := &webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: webdav.Dir(root),
+		LockSystem: webdav.NewMemLS(),
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "backup" || pass != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	return srv, root, hex.EncodeToString(sum[:])
+}
+
+func TestWebDAVProvider_RoundTrip(t *testing.T) {
+	srv, root, pin := startTestWebDAVServer(t)
+	p, err := NewWebDAVProvider(WebDAVConfig{
+		URL:            srv.URL + "/dav/",
+		Username:       "backup",
+		Password:       "s3cret",
+		TLSFingerprint: pin,
+	})
+	if err != nil {
+		t.Fatalf("NewWebDAVProvider: %v", err)
+	}
+
+	content := bytes.Repeat([]byte("backup data "), 10000)
+	srcPath := filepath.Join(t.TempDir(), "src.bin")
+	if err := os.WriteFile(srcPath, content, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	for _, remote := range []string{"snapshots/snap-1/files/a b.bin", "snapshots/snap-1/manifest.json", "other/c.bin"} {
+		if err := p.Upload(srcPath, remote); err != nil {
+			t.Fatalf("Upload %s: %v", remote, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "snapshots", "snap-1", "files", "a b.bin")); err != nil {
+		t.Fatalf("expected uploaded file on the share: %v", err)
+	}
+
+	listed, err := p.List("snapshots")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	sort.Strings(listed)
+	want := []string{"snapshots/snap-1/files/a b.bin", "snapshots/snap-1/manifest.json"}
+	if strings.Join(listed, ",") != strings.Join(want, ",") {
+		t.Fatalf("List = %v, want %v", listed, want)
+	}
+	if missing, err := p.List("does-not-exist"); err != nil || len(missing) != 0 {
+		t.Fatalf("List(missing) = %v, %v; want empty", missing, err)
+	}
+
+	destPath := filepath.Join(t.TempDir(), "out.bin")
+	if err := p.Download("snapshots/snap-1/files/a b.bin", destPath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if got, _ := os.ReadFile(destPath); !bytes.Equal(got, content) {
+		t.Fatalf("downloaded %d bytes, want %d matching bytes", len(got), len(content))
+	}
+
+	if err := p.Delete("other/c.bin"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := p.Delete("other/c.bin"); err != nil {
+		t.Fatalf("Delete of a missing file should succeed: %v", err)
+	}
+}
+
+func TestWebDAVProvider_RejectsWrongCertificatePin(t *testing.T) {
+	srv, _, _ := startTestWebDAVServer(t)
+	p, err := NewWebDAVProvider(WebDAVConfig{
+		URL:            srv.URL + "/dav",
+		Username:       "backup",
+		Password:       "s3cret",
+		TLSFingerprint: strings.Repeat("ab", 32),
+	})
+	if err != nil {
+		t.Fatalf("NewWebDAVProvider: %v", err)
+	}
+	if _, err := p.List(""); err == nil || !strings.Contains(err.Error(), "certificate mismatch") {
+		t.Fatalf("expected certificate mismatch, got %v", err)
+	}
+}
+
+func TestWebDAVProvider_UnpinnedSelfSignedCertificateFails(t *testing.T) {
+	srv, _, _ := startTestWebDAVServer(t)
+	p, err := NewWebDAVProvider(WebDAVConfig{URL: srv.URL + "/dav", Username: "backup", Password: "s3cret"})
+	if err != nil {
+		t.Fatalf("NewWebDAVProvider: %v", err)
+	}
+	if _, err := p.List(""); err == nil {
+		t.Fatal("expected CA validation to reject the self-signed test certificate")
+	}
+}
+
+func TestNewWebDAVProvider_Validation(t *testing.T) {
+	cases := map[string]WebDAVConfig{
+		"missing URL":     {},
+		"plain http":      {URL: "http://nas.local/backups"},
+		"bad fingerprint": {URL: "https://nas.local/backups", TLSFingerprint: "zz"},
+	}
+	for name, cfg := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewWebDAVProvider(cfg); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+
+	p, err := NewWebDAVProvider(WebDAVConfig{URL: "https://nas.local/backups/", TLSFingerprint: strings.Repeat("AB:", 31) + "AB"})
+	if err != nil {
+		t.Fatalf("colon-separated fingerprint should be accepted: %v", err)
+	}
+	if err := p.Delete("../escape"); err == nil {
+		t.Fatal("expected traversal to be rejected")
+	}
+}
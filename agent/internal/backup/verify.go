@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -32,10 +34,13 @@ type VerifyResult struct {
 
 // TestRestoreResult holds the outcome of a test restore operation.
 type TestRestoreResult struct {
-	SnapshotID         string   `json:"snapshotId"`
-	Status             string   `json:"status"`
-	FilesVerified      int      `json:"filesVerified"`
-	FilesFailed        int      `json:"filesFailed"`
+	SnapshotID    string `json:"snapshotId"`
+	Status        string `json:"status"`
+	FilesVerified int    `json:"filesVerified"`
+	FilesFailed   int    `json:"filesFailed"`
+	// FilesInSnapshot is the manifest's file count, set only when a sample
+	// was requested, so FilesVerified can be read against the whole set.
+	FilesInSnapshot    int      `json:"filesInSnapshot,omitempty"`
 	SizeBytes          int64    `json:"sizeBytes"`
 	RestoreTimeSeconds int      `json:"restoreTimeSeconds"`
 	RestorePath        string   `json:"restorePath"`
@@ -172,6 +177,14 @@ const restoreTestPrefix = "breeze-restore-test"
 // TestRestore downloads a snapshot to a temp directory and verifies each file.
 // progressFn is called after each file with (current, total) counts. Can be nil.
 func TestRestore(provider providers.BackupProvider, snapshotID string, progressFn func(current, total int)) (*TestRestoreResult, error) {
+	return TestRestoreSample(provider, snapshotID, 0, progressFn)
+}
+
+// TestRestoreSample is TestRestore over a random subset of at most sampleSize
+// files, so a periodic restore test of a large snapshot stays cheap while
+// still catching an unreadable store over time. sampleSize <= 0 restores
+// every file.
+func TestRestoreSample(provider providers.BackupProvider, snapshotID string, sampleSize int, progressFn func(current, total int)) (*TestRestoreResult, error) {
 	start := time.Now()
 	result := &TestRestoreResult{SnapshotID: snapshotID}
 
@@ -216,9 +229,15 @@ func TestRestore(provider providers.BackupProvider, snapshotID string, progressF
 	}
 	result.RestorePath = restoreDir
 
+	files := snapshot.Files
+	if sampleSize > 0 {
+		result.FilesInSnapshot = len(files)
+		files = sampleSnapshotFiles(files, sampleSize)
+	}
+
 	// Restore each file
-	total := len(snapshot.Files)
-	for i, file := range snapshot.Files {
+	total := len(files)
+	for i, file := range files {
 		destPath := resolveTargetPath(restoreDir, file.SourcePath)
 		if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
 			result.FilesFailed++
@@ -288,6 +307,21 @@ func TestRestore(provider providers.BackupProvider, snapshotID string, progressF
 	return result, nil
 }
 
+// sampleSnapshotFiles picks n files uniformly at random, keeping manifest
+// order. Returns files unchanged when n covers the whole set.
+func sampleSnapshotFiles(files []SnapshotFile, n int) []SnapshotFile {
+	if n >= len(files) {
+		return files
+	}
+	picked := rand.Perm(len(files))[:n]
+	sort.Ints(picked)
+	sample := make([]SnapshotFile, 0, n)
+	for _, idx := range picked {
+		sample = append(sample, files[idx])
+	}
+	return sample
+}
+
 // CleanupRestoreDir removes a test restore directory after validating the path
 // is within the expected prefix to prevent path traversal.
 func CleanupRestoreDir(dirPath string) error {
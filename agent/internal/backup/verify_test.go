@@ -2,6 +2,7 @@ package backup
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
@@ -21,7 +22,7 @@ func (p *recordingTestRestoreProvider) Upload(localPath, remotePath string) erro
 }
 
 func (p *recordingTestRestoreProvider) Download(remotePath, localPath string) error {
-	if remotePath == path.Join(snapshotRootDir, "dup-basenames", snapshotManifestKey) {
+	if path.Base(remotePath) == snapshotManifestKey {
 		if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
 			return err
 		}
@@ -305,3 +306,52 @@ func TestTestRestorePreservesDistinctPathsForDuplicateBasenames(t *testing.T) {
 		t.Fatalf("duplicate basenames restored to the same path %q", pathA)
 	}
 }
+
+func TestTestRestoreSampleRestoresSubset(t *testing.T) {
+	const snapshotID = "sampled"
+	var snapshot Snapshot
+	files := map[string][]byte{}
+	snapshot.ID = snapshotID
+	for i := 0; i < 10; i++ {
+		backupPath := path.Join(snapshotRootDir, snapshotID, "files", fmt.Sprintf("f%d.gz", i))
+		snapshot.Files = append(snapshot.Files, SnapshotFile{
+			SourcePath: fmt.Sprintf("/data/f%d.txt", i),
+			BackupPath: backupPath,
+			Size:       2,
+		})
+		files[backupPath] = []byte("ok")
+	}
+	manifest, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("marshal snapshot: %v", err)
+	}
+	provider := &recordingTestRestoreProvider{manifest: manifest, files: files}
+
+	result, err := TestRestoreSample(provider, snapshotID, 3, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != "passed" {
+		t.Fatalf("expected passed, got %s", result.Status)
+	}
+	if result.FilesVerified != 3 || result.FilesInSnapshot != 10 {
+		t.Fatalf("expected 3 of 10 files restored, got %d of %d", result.FilesVerified, result.FilesInSnapshot)
+	}
+	if len(provider.downloads) != 3 {
+		t.Fatalf("expected 3 file downloads, got %d", len(provider.downloads))
+	}
+}
+
+func TestSampleSnapshotFilesKeepsOrderAndCoversSmallSets(t *testing.T) {
+	files := []SnapshotFile{{SourcePath: "a"}, {SourcePath: "b"}, {SourcePath: "c"}, {SourcePath: "d"}}
+	if got := sampleSnapshotFiles(files, 10); len(got) != 4 {
+		t.Fatalf("sample larger than set must return all files, got %d", len(got))
+	}
+	got := sampleSnapshotFiles(files, 2)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(got))
+	}
+	if got[0].SourcePath >= got[1].SourcePath {
+		t.Fatalf("sample must keep manifest order, got %q then %q", got[0].SourcePath, got[1].SourcePath)
+	}
+}
@@ -0,0 +1,173 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrOutsideBackupWindow is returned by RunBackupContext when backup windows
+// are configured and none of them is open.
+var ErrOutsideBackupWindow = errors.New("outside backup window")
+
+// errBackupWindowClosed stops a run whose window closed mid-upload. The
+// checkpoint journal is kept, so the next run resumes where this one stopped.
+var errBackupWindowClosed = fmt.Errorf("%w: backup window closed, remaining files resume on the next run", errBackupStopped)
+
+// maxBackupWindowMinutes is one week: a longer window is always open.
+const maxBackupWindowMinutes = 7 * 24 * 60
+
+// BackupWindow is a recurring window in which a backup may upload. It opens
+// at Start ("HH:MM") on each of Days (English weekday names or three-letter
+// abbreviations; empty means every day) in Timezone (IANA name; empty means
+// agent local time) and stays open for DurationMinutes, which may run past
+// midnight. Same shape as the patch maintenance windows.
+type BackupWindow struct {
+	Days            []string `json:"days,omitempty"`
+	Start           string   `json:"start"`
+	DurationMinutes int      `json:"durationMinutes"`
+	Timezone        string   `json:"timezone,omitempty"`
+}
+
+// compiledBackupWindow is a validated BackupWindow.
+type compiledBackupWindow struct {
+	loc         *time.Location
+	days        [7]bool
+	startMinute int
+	duration    time.Duration
+}
+
+var backupWindowDays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// ValidateBackupWindows reports the first window that does not compile, so
+// configuration can be rejected before a run is attempted.
+func ValidateBackupWindows(windows []BackupWindow) error {
+	_, err := compileBackupWindows(windows)
+	return err
+}
+
+func compileBackupWindows(windows []BackupWindow) ([]compiledBackupWindow, error) {
+	compiled := make([]compiledBackupWindow, 0, len(windows))
+	for i, w := range windows {
+		c, err := compileBackupWindow(w)
+		if err != nil {
+			return nil, fmt.Errorf("backup window %d: %w", i, err)
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+func compileBackupWindow(w BackupWindow) (compiledBackupWindow, error) {
+	var c compiledBackupWindow
+
+	var hour, minute int
+	if _, err := fmt.Sscanf(w.Start, "%d:%d", &hour, &minute); err != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return c, fmt.Errorf("invalid window start %q: want HH:MM", w.Start)
+	}
+	c.startMinute = hour*60 + minute
+
+	if w.DurationMinutes < 1 || w.DurationMinutes > maxBackupWindowMinutes {
+		return c, fmt.Errorf("invalid window duration %d: must be 1-%d minutes", w.DurationMinutes, maxBackupWindowMinutes)
+	}
+	c.duration = time.Duration(w.DurationMinutes) * time.Minute
+
+	c.loc = time.Local
+	if w.Timezone != "" {
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return c, fmt.Errorf("invalid window timezone %q: %w", w.Timezone, err)
+		}
+		c.loc = loc
+	}
+
+	if len(w.Days) == 0 {
+		for i := range c.days {
+			c.days[i] = true
+		}
+	}
+	for _, name := range w.Days {
+		day, ok := backupWindowDays[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return c, fmt.Errorf("invalid window day %q", name)
+		}
+		c.days[day] = true
+	}
+	return c, nil
+}
+
+// closesAt returns when the occurrence of c containing t closes, or the zero
+// time if c is not open at t.
+func (c compiledBackupWindow) closesAt(t time.Time) time.Time {
+	// A window can last up to a week, so one that opened up to 7 days ago
+	// may still be open.
+	var closes time.Time
+	local := t.In(c.loc)
+	for offset := 0; offset >= -7; offset-- {
+		opens := time.Date(local.Year(), local.Month(), local.Day()+offset, c.startMinute/60, c.startMinute%60, 0, 0, c.loc)
+		end := opens.Add(c.duration)
+		if c.days[opens.Weekday()] && !t.Before(opens) && t.Before(end) && end.After(closes) {
+			closes = end
+		}
+	}
+	return closes
+}
+
+// backupWindowClosesAt reports whether a run may upload at now and, if so,
+// when the latest open window closes. With no windows it is always open and
+// the close time is zero (never).
+func backupWindowClosesAt(windows []compiledBackupWindow, now time.Time) (bool, time.Time) {
+	if len(windows) == 0 {
+		return true, time.Time{}
+	}
+	var closes time.Time
+	for _, c := range windows {
+		if end := c.closesAt(now); end.After(closes) {
+			closes = end
+		}
+	}
+	return !closes.IsZero(), closes
+}
+
+// backupWindowNow is a seam so tests can place a run inside or outside a
+// window without waiting for the clock.
+var backupWindowNow = time.Now
+
+// watchBackupWindow calls onClose once no window is open any more, checking
+// again whenever the latest open window closes so adjacent or overlapping
+// windows keep the run going. The returned func stops the watch.
+func watchBackupWindow(windows []compiledBackupWindow, closesAt time.Time, onClose func()) (stop func()) {
+	if closesAt.IsZero() {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			timer := time.NewTimer(closesAt.Sub(backupWindowNow()))
+			select {
+			case <-done:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			open, next := backupWindowClosesAt(windows, backupWindowNow())
+			if !open {
+				onClose()
+				return
+			}
+			closesAt = next
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
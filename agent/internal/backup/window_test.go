@@ -0,0 +1,145 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setBackupWindowNowForTest(t *testing.T, now func() time.Time) {
+	t.Helper()
+	prev := backupWindowNow
+	backupWindowNow = now
+	t.Cleanup(func() { backupWindowNow = prev })
+}
+
+func TestValidateBackupWindows(t *testing.T) {
+	valid := []BackupWindow{
+		{Start: "19:00", DurationMinutes: 12 * 60},
+		{Days: []string{"Sat", "sunday"}, Start: "00:00", DurationMinutes: 24 * 60, Timezone: "UTC"},
+	}
+	if err := ValidateBackupWindows(valid); err != nil {
+		t.Fatalf("valid windows rejected: %v", err)
+	}
+
+	cases := map[string]BackupWindow{
+		"start":    {Start: "25:00", DurationMinutes: 60},
+		"duration": {Start: "19:00", DurationMinutes: 0},
+		"day":      {Days: []string{"someday"}, Start: "19:00", DurationMinutes: 60},
+		"timezone": {Start: "19:00", DurationMinutes: 60, Timezone: "Not/AZone"},
+	}
+	for field, w := range cases {
+		err := ValidateBackupWindows([]BackupWindow{valid[0], w})
+		if err == nil || !strings.Contains(err.Error(), "backup window 1") || !strings.Contains(err.Error(), field) {
+			t.Errorf("%s: expected an error naming window 1, got %v", field, err)
+		}
+	}
+}
+
+func TestBackupWindowClosesAt(t *testing.T) {
+	windows, err := compileBackupWindows([]BackupWindow{
+		// Weeknights, running past midnight into the next morning.
+		{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "19:00", DurationMinutes: 12 * 60, Timezone: "UTC"},
+	})
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+
+	// 2026-10-19 is a Monday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name   string
+		now    time.Time
+		open   bool
+		closes time.Time
+	}{
+		{"monday evening", at(19, 22, 0), true, at(20, 7, 0)},
+		{"tuesday before work", at(20, 6, 59), true, at(20, 7, 0)},
+		{"tuesday business hours", at(20, 9, 30), false, time.Time{}},
+		{"saturday morning after friday night", at(24, 6, 0), true, at(24, 7, 0)},
+		{"saturday evening", at(24, 20, 0), false, time.Time{}},
+	}
+	for _, tt := range tests {
+		open, closes := backupWindowClosesAt(windows, tt.now)
+		if open != tt.open || !closes.Equal(tt.closes) {
+			t.Errorf("%s: got open=%v closes=%v, want open=%v closes=%v", tt.name, open, closes, tt.open, tt.closes)
+		}
+	}
+
+	if open, closes := backupWindowClosesAt(nil, at(20, 9, 30)); !open || !closes.IsZero() {
+		t.Errorf("no windows must always be open with no close, got open=%v closes=%v", open, closes)
+	}
+}
+
+func TestRunBackupContext_OutsideWindowIsSkipped(t *testing.T) {
+	setBackupWindowNowForTest(t, func() time.Time {
+		return time.Date(2026, time.October, 20, 9, 30, 0, 0, time.UTC)
+	})
+	tmpDir := t.TempDir()
+	createTempFile(t, tmpDir, "data.txt", "not now")
+
+	provider := newBlockingUploadProvider()
+	mgr := NewBackupManager(BackupConfig{
+		Provider: provider,
+		Paths:    []string{tmpDir},
+		Windows:  []BackupWindow{{Start: "19:00", DurationMinutes: 12 * 60, Timezone: "UTC"}},
+	})
+
+	job, err := mgr.RunBackupContext(context.Background(), nil)
+	if !errors.Is(err, ErrOutsideBackupWindow) {
+		t.Fatalf("expected ErrOutsideBackupWindow, got %v", err)
+	}
+	if job == nil || job.Status != jobStatusSkipped {
+		t.Fatalf("expected a skipped job, got %+v", job)
+	}
+	select {
+	case <-provider.started:
+		t.Fatal("no upload may start outside the window")
+	default:
+	}
+}
+
+func TestRunBackupContext_WindowCloseStopsRun(t *testing.T) {
+	// The clock starts 50ms before the window closes and then runs in real
+	// time, so the in-flight upload is cut off almost immediately.
+	base := time.Date(2026, time.October, 19, 10, 59, 59, 950_000_000, time.UTC)
+	realStart := time.Now()
+	setBackupWindowNowForTest(t, func() time.Time { return base.Add(time.Since(realStart)) })
+
+	tmpDir := t.TempDir()
+	createTempFile(t, tmpDir, "data.txt", "slow link")
+
+	provider := newBlockingUploadProvider()
+	mgr := NewBackupManager(BackupConfig{
+		Provider: provider,
+		Paths:    []string{tmpDir},
+		Windows:  []BackupWindow{{Start: "10:00", DurationMinutes: 60, Timezone: "UTC"}},
+	})
+
+	type outcome struct {
+		job *BackupJob
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		job, err := mgr.RunBackupContext(context.Background(), nil)
+		done <- outcome{job, err}
+	}()
+
+	select {
+	case got := <-done:
+		if !errors.Is(got.err, errBackupWindowClosed) {
+			t.Fatalf("expected the window-closed stop error, got %v", got.err)
+		}
+		if got.job == nil || got.job.Status != jobStatusStopped {
+			t.Fatalf("expected a stopped job, got %+v", got.job)
+		}
+	case <-time.After(5 * time.Second):
+		mgr.Stop()
+		t.Fatal("run was not stopped when its window closed")
+	}
+}
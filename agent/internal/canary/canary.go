@@ -0,0 +1,421 @@
+// Package canary plants hidden decoy files in user-writable locations and
+// watches them. Nobody has a reason to touch them, so a canary that is
+// rewritten or removed is an early sign of ransomware encrypting the
+// user's files.
+package canary
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/breeze-rmm/agent/internal/logging"
+	"github.com/breeze-rmm/agent/internal/observability"
+)
+
+var log = logging.L("canary")
+
+const (
+	// FileName is the canary's name in every location. Its document
+	// extension keeps it on ransomware's list of files worth encrypting.
+	FileName = ".breeze-canary.docx"
+
+	manifestFile = "ransomware_canaries.json"
+
+	// checkInterval re-hashes every canary as a backstop for missed or
+	// unsupported file notifications.
+	checkInterval = 30 * time.Second
+
+	// ChangeModified and ChangeDeleted are the Trigger changes.
+	ChangeModified = "modified"
+	ChangeDeleted  = "deleted"
+)
+
+// userSubdirs are the folders under each home that get a canary, besides
+// the home itself. Missing ones are skipped.
+var userSubdirs = []string{"Documents", "Desktop"}
+
+// Canary is a planted file and the hash it was planted with.
+type Canary struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// Trigger reports a canary that no longer matches what was planted.
+type Trigger struct {
+	Path       string    `json:"path"`
+	Change     string    `json:"change"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// Monitor owns the planted canaries. A canary fires once: it leaves the
+// watched set when it triggers and is planted again on the next Start.
+type Monitor struct {
+	manifestPath string
+	homes        func() []string
+	onTrigger    func(Trigger)
+
+	mu      sync.Mutex
+	active  map[string]Canary
+	watcher *fsnotify.Watcher
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// New returns a monitor that keeps its manifest in dataDir and reports
+// triggers to onTrigger on a separate goroutine.
+func New(dataDir string, onTrigger func(Trigger)) *Monitor {
+	return &Monitor{
+		manifestPath: filepath.Join(dataDir, manifestFile),
+		homes:        userHomes,
+		onTrigger:    onTrigger,
+		active:       map[string]Canary{},
+	}
+}
+
+// Start checks the canaries left by a previous run, plants the missing ones
+// and starts watching. It is a no-op while already running.
+func (m *Monitor) Start() error {
+	m.mu.Lock()
+	if m.stop != nil {
+		m.mu.Unlock()
+		return nil
+	}
+	for _, c := range m.loadManifest() {
+		m.active[c.Path] = c
+	}
+	m.mu.Unlock()
+
+	// A canary tampered with while the agent was down is still a trigger.
+	m.checkAll()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, dir := range m.canaryDirs() {
+		path := filepath.Join(dir, FileName)
+		if _, ok := m.active[path]; ok {
+			continue
+		}
+		c, err := plant(path)
+		if err != nil {
+			log.Warn("failed to plant canary", "path", path, "error", err.Error())
+			continue
+		}
+		m.active[path] = c
+	}
+	if err := m.saveManifest(); err != nil {
+		log.Warn("failed to save canary manifest", "error", err.Error())
+	}
+	if len(m.active) == 0 {
+		return errors.New("no canary could be planted")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Warn("file notifications unavailable; relying on periodic checks", "error", err.Error())
+		watcher = nil
+	} else {
+		watched := map[string]bool{}
+		for path := range m.active {
+			dir := filepath.Dir(path)
+			if watched[dir] {
+				continue
+			}
+			watched[dir] = true
+			if err := watcher.Add(dir); err != nil {
+				log.Warn("failed to watch canary directory", "dir", dir, "error", err.Error())
+			}
+		}
+	}
+	m.watcher = watcher
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go m.run(watcher, m.stop, m.done)
+	log.Info("ransomware canaries active", "count", len(m.active))
+	return nil
+}
+
+// Stop stops watching and leaves the canaries in place.
+func (m *Monitor) Stop() {
+	m.mu.Lock()
+	stop, done := m.stop, m.done
+	m.stop, m.done = nil, nil
+	m.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// Remove stops watching and deletes the planted canaries and the manifest.
+// Files that no longer match what was planted are left alone.
+func (m *Monitor) Remove() {
+	m.Stop()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.loadManifest() {
+		m.active[c.Path] = c
+	}
+	for path, c := range m.active {
+		// Only a regular file is ours to delete; a symlink put in its place
+		// is left for the user.
+		if info, err := os.Lstat(path); err == nil && info.Mode().IsRegular() {
+			if hash, err := hashFile(path); err == nil && hash == c.SHA256 {
+				_ = os.Remove(path)
+			}
+		}
+		delete(m.active, path)
+	}
+	_ = os.Remove(m.manifestPath)
+}
+
+// Active returns the number of canaries being watched.
+func (m *Monitor) Active() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.active)
+}
+
+func (m *Monitor) run(watcher *fsnotify.Watcher, stop, done chan struct{}) {
+	defer close(done)
+	defer observability.Recoverer("canary.monitor")
+	var events chan fsnotify.Event
+	var errs chan error
+	if watcher != nil {
+		defer watcher.Close()
+		events, errs = watcher.Events, watcher.Errors
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if filepath.Base(event.Name) == FileName && event.Op&(fsnotify.Write|fsnotify.Remove|fsnotify.Rename|fsnotify.Create) != 0 {
+				m.check(event.Name)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			log.Warn("canary watcher error", "error", err.Error())
+		case <-ticker.C:
+			m.checkAll()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (m *Monitor) checkAll() {
+	m.mu.Lock()
+	paths := make([]string, 0, len(m.active))
+	for path := range m.active {
+		paths = append(paths, path)
+	}
+	m.mu.Unlock()
+	sort.Strings(paths)
+	for _, path := range paths {
+		m.check(path)
+	}
+}
+
+// check fires the trigger for path if it is an active canary whose content
+// changed or which is gone.
+func (m *Monitor) check(path string) {
+	m.mu.Lock()
+	c, ok := m.active[path]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	change := ""
+	hash, err := hashFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		change = ChangeDeleted
+	case err != nil:
+		// Unreadable (e.g. locked mid-write): look again on the next check.
+	case hash != c.SHA256:
+		change = ChangeModified
+	}
+	if change == "" {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.active, path)
+	if err := m.saveManifest(); err != nil {
+		log.Warn("failed to save canary manifest", "error", err.Error())
+	}
+	m.mu.Unlock()
+
+	log.Warn("ransomware canary triggered", "path", path, "change", change)
+	if m.onTrigger != nil {
+		go m.onTrigger(Trigger{Path: path, Change: change, DetectedAt: time.Now().UTC()})
+	}
+}
+
+// canaryDirs lists the existing directories that should hold a canary.
+func (m *Monitor) canaryDirs() []string {
+	var dirs []string
+	for _, home := range m.homes() {
+		for _, dir := range append([]string{home}, joinAll(home, userSubdirs)...) {
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+func joinAll(base string, names []string) []string {
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(base, name)
+	}
+	return paths
+}
+
+func (m *Monitor) loadManifest() []Canary {
+	data, err := os.ReadFile(m.manifestPath)
+	if err != nil {
+		return nil
+	}
+	var canaries []Canary
+	if err := json.Unmarshal(data, &canaries); err != nil {
+		log.Warn("ignoring unreadable canary manifest", "error", err.Error())
+		return nil
+	}
+	return canaries
+}
+
+// saveManifest writes the active set. Callers hold m.mu.
+func (m *Monitor) saveManifest() error {
+	canaries := make([]Canary, 0, len(m.active))
+	for _, c := range m.active {
+		canaries = append(canaries, c)
+	}
+	sort.Slice(canaries, func(i, j int) bool { return canaries[i].Path < canaries[j].Path })
+	data, err := json.MarshalIndent(canaries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.manifestPath), 0700); err != nil {
+		return err
+	}
+	tmp := m.manifestPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.manifestPath)
+}
+
+// plant writes a fresh canary with a random marker, owned like its
+// directory so it looks like any other file of the user's. The agent runs
+// privileged inside directories the user controls, so it never follows a
+// symlink there: a symlinked directory or a non-regular file under the
+// canary's name is skipped, and the file is created exclusively.
+func plant(path string) (Canary, error) {
+	dir := filepath.Dir(path)
+	dirInfo, err := os.Lstat(dir)
+	if err != nil {
+		return Canary{}, err
+	}
+	if !dirInfo.IsDir() {
+		return Canary{}, errors.New("canary directory is not a plain directory")
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if !info.Mode().IsRegular() {
+			return Canary{}, errors.New("existing canary path is not a regular file")
+		}
+		// A stale canary from a lost manifest; unlinking never follows links.
+		if err := os.Remove(path); err != nil {
+			return Canary{}, err
+		}
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return Canary{}, err
+	}
+	content := "This file is a Breeze ransomware canary. It is monitored for changes; " +
+		"please do not edit, move or delete it.\nid: " + hex.EncodeToString(nonce) + "\n"
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL|openNoFollow, 0644)
+	if err != nil {
+		return Canary{}, err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		_ = os.Remove(path)
+		return Canary{}, err
+	}
+	if err := hideAndOwn(f, dirInfo); err != nil {
+		log.Debug("could not set canary attributes", "path", path, "error", err.Error())
+	}
+	if err := f.Close(); err != nil {
+		return Canary{}, err
+	}
+	sum := sha256.Sum256([]byte(content))
+	return Canary{Path: path, SHA256: hex.EncodeToString(sum[:])}, nil
+}
+
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// userHomes lists local user profile directories, skipping shared and
+// template profiles.
+func userHomes() []string {
+	var root string
+	skip := map[string]bool{}
+	switch runtime.GOOS {
+	case "windows":
+		drive := os.Getenv("SystemDrive")
+		if drive == "" {
+			drive = "C:"
+		}
+		root = drive + `\Users`
+		skip = map[string]bool{"public": true, "default": true, "default user": true, "all users": true, "defaultapppool": true}
+	case "darwin":
+		root = "/Users"
+		skip = map[string]bool{"shared": true, "guest": true}
+	default:
+		root = "/home"
+	}
+
+	var homes []string
+	entries, _ := os.ReadDir(root)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") || skip[strings.ToLower(name)] {
+			continue
+		}
+		homes = append(homes, filepath.Join(root, name))
+	}
+	if runtime.GOOS == "linux" {
+		homes = append(homes, "/root")
+	}
+	return homes
+}
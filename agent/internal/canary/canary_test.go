@@ -0,0 +1,143 @@
+package canary
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// newTestMonitor returns a monitor over one fake home with a Documents
+// folder, reporting triggers on the returned channel.
+func newTestMonitor(t *testing.T) (*Monitor, string, chan Trigger) {
+	t.Helper()
+	home := filepath.Join(t.TempDir(), "alice")
+	if err := os.MkdirAll(filepath.Join(home, "Documents"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	triggers := make(chan Trigger, 4)
+	m := New(t.TempDir(), func(tr Trigger) { triggers <- tr })
+	m.homes = func() []string { return []string{home} }
+	return m, home, triggers
+}
+
+func waitTrigger(t *testing.T, triggers chan Trigger) Trigger {
+	t.Helper()
+	select {
+	case tr := <-triggers:
+		return tr
+	case <-time.After(5 * time.Second):
+		t.Fatal("canary did not trigger")
+	}
+	return Trigger{}
+}
+
+func TestMonitorPlantsAndDetectsEncryption(t *testing.T) {
+	m, home, triggers := newTestMonitor(t)
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+	if m.Active() != 2 {
+		t.Fatalf("active = %d, want canaries in the home and Documents", m.Active())
+	}
+
+	target := filepath.Join(home, "Documents", FileName)
+	if err := os.WriteFile(target, []byte("\x8f\x11encrypted"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tr := waitTrigger(t, triggers)
+	if tr.Path != target || tr.Change != ChangeModified {
+		t.Fatalf("trigger = %+v", tr)
+	}
+	if m.Active() != 1 {
+		t.Fatalf("a fired canary must leave the watched set, active = %d", m.Active())
+	}
+}
+
+func TestMonitorDetectsTamperWhileStopped(t *testing.T) {
+	m, home, triggers := newTestMonitor(t)
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	m.Stop()
+
+	target := filepath.Join(home, FileName)
+	if err := os.Remove(target); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+	if tr := waitTrigger(t, triggers); tr.Path != target || tr.Change != ChangeDeleted {
+		t.Fatalf("trigger = %+v", tr)
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Fatalf("the deleted canary should be planted again: %v", err)
+	}
+}
+
+func TestMonitorRemoveKeepsChangedFiles(t *testing.T) {
+	m, home, _ := newTestMonitor(t)
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	m.Stop()
+	changed := filepath.Join(home, "Documents", FileName)
+	if err := os.WriteFile(changed, []byte("user content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Remove()
+	if _, err := os.Stat(filepath.Join(home, FileName)); !os.IsNotExist(err) {
+		t.Fatalf("planted canary should be removed, stat err = %v", err)
+	}
+	if _, err := os.Stat(changed); err != nil {
+		t.Fatalf("a file that no longer matches must be kept: %v", err)
+	}
+	if _, err := os.Stat(m.manifestPath); !os.IsNotExist(err) {
+		t.Fatal("manifest should be removed")
+	}
+}
+
+func TestPlantDoesNotFollowSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks needs privileges on Windows")
+	}
+	m, home, _ := newTestMonitor(t)
+	victim := filepath.Join(t.TempDir(), "shadow")
+	if err := os.WriteFile(victim, []byte("root:secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(home, FileName)
+	if err := os.Symlink(victim, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(home, "Documents")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Dir(victim), filepath.Join(home, "Documents")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing can be planted, so Start reports it rather than writing
+	// through either link.
+	if err := m.Start(); err == nil {
+		m.Stop()
+		t.Fatal("Start should fail with only symlinked locations")
+	}
+	if data, _ := os.ReadFile(victim); string(data) != "root:secret" {
+		t.Fatalf("symlink target was overwritten: %q", data)
+	}
+	if _, err := os.Lstat(filepath.Join(filepath.Dir(victim), FileName)); !os.IsNotExist(err) {
+		t.Fatal("canary was planted through a symlinked directory")
+	}
+
+	m.active[link] = Canary{Path: link}
+	m.Remove()
+	if _, err := os.Lstat(link); err != nil {
+		t.Fatalf("Remove must leave a symlink alone: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package canary
+
+import (
+	"os"
+	"syscall"
+)
+
+// openNoFollow makes creating a canary fail on a symlink instead of
+// writing through it.
+const openNoFollow = syscall.O_NOFOLLOW
+
+// hideAndOwn gives the open canary its directory's owner; the leading dot
+// in FileName already hides it. Chown on the descriptor cannot be
+// redirected by a path swapped in after the file was created.
+func hideAndOwn(f *os.File, dir os.FileInfo) error {
+	st, ok := dir.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return f.Chown(int(st.Uid), int(st.Gid))
+}
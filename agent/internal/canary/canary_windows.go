@@ -0,0 +1,23 @@
+//go:build windows
+
+package canary
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// openNoFollow is unneeded on Windows: O_EXCL maps to CREATE_NEW, which
+// fails on any existing name, reparse points included.
+const openNoFollow = 0
+
+// hideAndOwn marks the canary hidden. Files created in a profile inherit
+// the profile's ACL, so ownership needs no change.
+func hideAndOwn(f *os.File, _ os.FileInfo) error {
+	p, err := windows.UTF16PtrFromString(f.Name())
+	if err != nil {
+		return err
+	}
+	return windows.SetFileAttributes(p, windows.FILE_ATTRIBUTE_HIDDEN)
+}
@@ -0,0 +1,299 @@
+// Package changelog keeps a small, persisted history of agent-affecting
+// changes on the device — binary upgrades, config profiles applied,
+// server-driven feature toggles and mTLS certificate renewals — so that when a
+// device starts misbehaving, "what changed?" is answerable from the device
+// record instead of from scattered agent logs.
+//
+// The log lives in a single JSON file in the agent data dir. Alongside the
+// entries it keeps the last-seen agent version, config version and flag
+// values, which is what lets changes be detected across restarts (an upgrade
+// is only visible once the new binary starts) and keeps repeated heartbeat
+// directives carrying the same value from producing duplicate entries.
+package changelog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/logging"
+)
+
+var log = logging.L("changelog")
+
+// FileName is the changelog file inside the agent data dir.
+const FileName = "agent_changelog.json"
+
+// maxEntries bounds the persisted history; the oldest entries are dropped
+// first. A few hundred covers months of normal churn.
+const maxEntries = 250
+
+// Entry kinds.
+const (
+	KindAgentUpgrade  = "agent_upgrade"
+	KindConfigApplied = "config_applied"
+	KindFeatureFlag   = "feature_flag"
+	KindCertRenewal   = "cert_renewal"
+)
+
+// Initiators of an agent upgrade.
+const (
+	// InitiatorAutoUpdate is a server-directed upgrade (heartbeat upgradeTo).
+	InitiatorAutoUpdate = "auto_update"
+	// InitiatorDevUpdate is an operator-pushed build (dev_update command).
+	InitiatorDevUpdate = "dev_update"
+	// InitiatorExternal is a version change the agent did not start itself:
+	// an installer, package manager or manual binary swap.
+	InitiatorExternal = "external"
+)
+
+// pendingUpgradeMaxAge bounds how long an upgrade marker is trusted. A marker
+// older than this belongs to an attempt that never completed, so a later
+// version change is attributed to an external install instead.
+const pendingUpgradeMaxAge = 24 * time.Hour
+
+// Entry is one recorded change.
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Kind      string    `json:"kind"`
+	// Name identifies the changed item for feature flags.
+	Name      string `json:"name,omitempty"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+	Initiator string `json:"initiator,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+type pendingUpgrade struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Initiator string    `json:"initiator"`
+	MarkedAt  time.Time `json:"markedAt"`
+}
+
+type fileState struct {
+	AgentVersion   string            `json:"agentVersion,omitempty"`
+	ConfigVersion  string            `json:"configVersion,omitempty"`
+	Flags          map[string]string `json:"flags,omitempty"`
+	PendingUpgrade *pendingUpgrade   `json:"pendingUpgrade,omitempty"`
+	Entries        []Entry           `json:"entries"`
+}
+
+// Log is the persisted changelog. All methods are safe for concurrent use and
+// are no-ops on a nil *Log, so code paths that run without one (tests, CLI
+// tools) need no guards.
+type Log struct {
+	path string
+	now  func() time.Time
+
+	mu       sync.Mutex
+	st       fileState
+	revision uint64
+}
+
+// Open loads the changelog at path. A missing or unreadable file starts an
+// empty log rather than failing: the history is diagnostic, never a reason to
+// block startup.
+func Open(path string) *Log {
+	l := &Log{path: path, now: time.Now}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("failed to read changelog, starting empty", "path", path, "error", err.Error())
+		}
+		return l
+	}
+	if err := json.Unmarshal(raw, &l.st); err != nil {
+		log.Warn("failed to decode changelog, starting empty", "path", path, "error", err.Error())
+		l.st = fileState{}
+	}
+	return l
+}
+
+// RecordStartup compares the running version with the one persisted by the
+// previous run and records an upgrade (or downgrade) when they differ. The
+// initiator comes from a matching MarkUpgradePending marker; otherwise the
+// change is attributed to an external install. The first run on a device
+// only establishes the baseline.
+func (l *Log) RecordStartup(version string) {
+	if l == nil || version == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	prev := l.st.AgentVersion
+	pending := l.st.PendingUpgrade
+	l.st.PendingUpgrade = nil
+	l.st.AgentVersion = version
+
+	if prev != "" && prev != version {
+		initiator := InitiatorExternal
+		if pending != nil && pending.To == version && pending.From == prev &&
+			l.now().Sub(pending.MarkedAt) < pendingUpgradeMaxAge {
+			initiator = pending.Initiator
+		}
+		l.appendLocked(Entry{Kind: KindAgentUpgrade, From: prev, To: version, Initiator: initiator})
+	}
+	l.saveLocked()
+}
+
+// MarkUpgradePending records that this agent is about to replace itself, so
+// the next startup can attribute the version change to initiator. Call it
+// immediately before handing off to the updater.
+func (l *Log) MarkUpgradePending(from, to, initiator string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.st.PendingUpgrade = &pendingUpgrade{From: from, To: to, Initiator: initiator, MarkedAt: l.now().UTC()}
+	l.saveLocked()
+}
+
+// RecordConfig records a config profile being applied. version is the
+// server-assigned profile version when one was sent, otherwise a content
+// hash of the update. Re-applying the same version is not recorded.
+func (l *Log) RecordConfig(version, detail string) {
+	if l == nil || version == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.st.ConfigVersion == version {
+		return
+	}
+	l.appendLocked(Entry{Kind: KindConfigApplied, From: l.st.ConfigVersion, To: version, Detail: detail})
+	l.st.ConfigVersion = version
+	l.saveLocked()
+}
+
+// RecordFlag records a server-driven feature toggle changing value. The
+// first observation of a flag only establishes its baseline, so a fresh
+// install doesn't log every default.
+func (l *Log) RecordFlag(name, value string) {
+	if l == nil || name == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prev, known := l.st.Flags[name]
+	if known && prev == value {
+		return
+	}
+	if l.st.Flags == nil {
+		l.st.Flags = make(map[string]string)
+	}
+	l.st.Flags[name] = value
+	if known {
+		l.appendLocked(Entry{Kind: KindFeatureFlag, Name: name, From: prev, To: value})
+	}
+	l.saveLocked()
+}
+
+// RecordCertRenewal records a successful mTLS certificate renewal.
+func (l *Log) RecordCertRenewal(previousExpiry, newExpiry string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.appendLocked(Entry{Kind: KindCertRenewal, From: previousExpiry, To: newExpiry, Initiator: "server"})
+	l.saveLocked()
+}
+
+// Entries returns a copy of the history, oldest first, together with a
+// revision that changes whenever an entry is added. Callers use the revision
+// to skip re-sending an unchanged log.
+func (l *Log) Entries() ([]Entry, uint64) {
+	if l == nil {
+		return nil, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]Entry, len(l.st.Entries))
+	copy(out, l.st.Entries)
+	return out, l.revision
+}
+
+func (l *Log) appendLocked(e Entry) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = l.now().UTC()
+	}
+	l.st.Entries = append(l.st.Entries, e)
+	if over := len(l.st.Entries) - maxEntries; over > 0 {
+		l.st.Entries = append([]Entry(nil), l.st.Entries[over:]...)
+	}
+	l.revision++
+	log.Info("agent change recorded", "kind", e.Kind, "name", e.Name, "from", e.From, "to", e.To, "initiator", e.Initiator)
+}
+
+// saveLocked persists the state atomically. Failures are logged and
+// otherwise ignored — the in-memory log keeps working and the next change
+// retries the write.
+func (l *Log) saveLocked() {
+	if l.path == "" {
+		return
+	}
+	if err := writeAtomic(l.path, &l.st); err != nil {
+		log.Warn("failed to persist changelog", "path", l.path, "error", err.Error())
+	}
+}
+
+func writeAtomic(path string, st *fileState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create changelog directory: %w", err)
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal changelog: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write changelog temp file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("rename changelog: %w", err)
+	}
+	return nil
+}
+
+// ConfigVersion derives the version recorded for a configUpdate: the
+// server-assigned profile version when present (config_profile_version /
+// configProfileVersion), otherwise a short hash of the update's canonical
+// JSON. The second return value lists the top-level keys for the entry
+// detail.
+func ConfigVersion(update map[string]any) (string, string) {
+	if len(update) == 0 {
+		return "", ""
+	}
+	keys := make([]string, 0, len(update))
+	for k := range update {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	detail := fmt.Sprintf("keys=%v", keys)
+
+	for _, k := range []string{"config_profile_version", "configProfileVersion"} {
+		if v, ok := update[k]; ok && v != nil {
+			if s := fmt.Sprint(v); s != "" {
+				return s, detail
+			}
+		}
+	}
+
+	// encoding/json sorts map keys, so the hash is stable across key order.
+	raw, err := json.Marshal(update)
+	if err != nil {
+		return "", detail
+	}
+	sum := sha256.Sum256(raw)
+	return "sha256:" + hex.EncodeToString(sum[:6]), detail
+}
@@ -0,0 +1,110 @@
+package changelog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordStartupAttributesPendingUpgrade(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	l := Open(path)
+	l.RecordStartup("1.0.0")
+	if entries, _ := l.Entries(); len(entries) != 0 {
+		t.Fatalf("first startup should only set the baseline, got %+v", entries)
+	}
+	l.MarkUpgradePending("1.0.0", "1.1.0", InitiatorAutoUpdate)
+
+	l = Open(path)
+	l.RecordStartup("1.1.0")
+	entries, _ := l.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected one upgrade entry, got %+v", entries)
+	}
+	e := entries[0]
+	if e.Kind != KindAgentUpgrade || e.From != "1.0.0" || e.To != "1.1.0" || e.Initiator != InitiatorAutoUpdate {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+
+	// An install the agent didn't start itself is attributed externally.
+	l = Open(path)
+	l.RecordStartup("1.2.0")
+	entries, _ = l.Entries()
+	if got := entries[len(entries)-1].Initiator; got != InitiatorExternal {
+		t.Fatalf("initiator = %q, want %q", got, InitiatorExternal)
+	}
+}
+
+func TestRecordStartupIgnoresStaleMarker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	l := Open(path)
+	l.RecordStartup("1.0.0")
+	l.MarkUpgradePending("1.0.0", "1.1.0", InitiatorDevUpdate)
+
+	l = Open(path)
+	l.now = func() time.Time { return time.Now().Add(2 * pendingUpgradeMaxAge) }
+	l.RecordStartup("1.1.0")
+	entries, _ := l.Entries()
+	if len(entries) != 1 || entries[0].Initiator != InitiatorExternal {
+		t.Fatalf("stale marker should not attribute the upgrade, got %+v", entries)
+	}
+}
+
+func TestRecordFlagAndConfigDedupe(t *testing.T) {
+	l := Open(filepath.Join(t.TempDir(), FileName))
+
+	l.RecordFlag("helper_enabled", "false")
+	l.RecordFlag("helper_enabled", "false")
+	l.RecordFlag("helper_enabled", "true")
+
+	v1, _ := ConfigVersion(map[string]any{"a": 1, "b": "x"})
+	v2, _ := ConfigVersion(map[string]any{"b": "x", "a": 1})
+	if v1 != v2 {
+		t.Fatalf("config hash depends on key order: %q vs %q", v1, v2)
+	}
+	l.RecordConfig(v1, "")
+	l.RecordConfig(v2, "")
+	if v, _ := ConfigVersion(map[string]any{"configProfileVersion": 7}); v != "7" {
+		t.Fatalf("explicit profile version = %q, want 7", v)
+	}
+
+	entries, rev := l.Entries()
+	if len(entries) != 2 || rev != 2 {
+		t.Fatalf("expected flag change + one config entry, got rev %d %+v", rev, entries)
+	}
+	if entries[0].Kind != KindFeatureFlag || entries[0].From != "false" || entries[0].To != "true" {
+		t.Fatalf("unexpected flag entry %+v", entries[0])
+	}
+
+	// Persisted baselines survive a reopen.
+	l2 := Open(l.path)
+	l2.RecordFlag("helper_enabled", "true")
+	l2.RecordConfig(v1, "")
+	if entries, _ := l2.Entries(); len(entries) != 2 {
+		t.Fatalf("reopen recorded duplicates: %+v", entries)
+	}
+}
+
+func TestEntriesCapped(t *testing.T) {
+	l := Open(filepath.Join(t.TempDir(), FileName))
+	for i := 0; i < maxEntries+10; i++ {
+		l.RecordCertRenewal("", time.Unix(int64(i), 0).UTC().Format(time.RFC3339))
+	}
+	entries, _ := l.Entries()
+	if len(entries) != maxEntries {
+		t.Fatalf("len = %d, want %d", len(entries), maxEntries)
+	}
+	if want := time.Unix(10, 0).UTC().Format(time.RFC3339); entries[0].To != want {
+		t.Fatalf("oldest entry = %q, want %q", entries[0].To, want)
+	}
+}
+
+func TestNilLogIsNoop(t *testing.T) {
+	var l *Log
+	l.RecordStartup("1.0.0")
+	l.RecordFlag("x", "y")
+	if entries, rev := l.Entries(); entries != nil || rev != 0 {
+		t.Fatalf("nil log returned %v %d", entries, rev)
+	}
+}
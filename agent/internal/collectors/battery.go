@@ -2,11 +2,13 @@ package collectors
 
 import (
 	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Battery / power current-state telemetry (#2142).
@@ -33,8 +35,11 @@ import (
 //     is why a read error must NOT be collapsed to Present:false — that would
 //     overwrite a real laptop's last-known snapshot with a bogus "no battery".
 //
-// Battery HEALTH (design/full capacity, cycle count, condition) is intentionally
-// out of scope for v1 — this is operational current state only.
+// Battery HEALTH (cycle count, design vs. full-charge capacity) rides the same
+// snapshot so worn batteries can be reported per site. It changes over months,
+// not minutes, so on Windows/macOS — where it costs a WMI/ioreg spawn — it is
+// cached for batteryHealthRefresh; Linux reads it from sysfs alongside the
+// current state. Capacities are normalized to mWh.
 
 // BatteryChargingState is the closed charging-state vocabulary. Mirrors the
 // BatteryChargingState union in packages/shared and the Zod enum in the API
@@ -51,14 +56,52 @@ const (
 )
 
 // BatteryInfo is the agent-side power snapshot serialized into the heartbeat
-// payload. JSON tags match the API's battery sub-schema exactly.
+// payload. JSON tags mirror the battery sub-schema of the API's heartbeat
+// schema (apps/api/src/routes/agents/schemas.ts) and BatteryStatus in
+// packages/shared — a field missing there is stripped by the API, so add it
+// to all three.
 type BatteryInfo struct {
-	Present              bool                 `json:"present"`
-	Percent              *float64             `json:"percent,omitempty"`
-	ChargingState        BatteryChargingState `json:"chargingState,omitempty"`
-	PluggedIn            *bool                `json:"pluggedIn,omitempty"`
-	TimeRemainingMinutes *int                 `json:"timeRemainingMinutes,omitempty"`
-	TimeToFullMinutes    *int                 `json:"timeToFullMinutes,omitempty"`
+	Present               bool                 `json:"present"`
+	Percent               *float64             `json:"percent,omitempty"`
+	ChargingState         BatteryChargingState `json:"chargingState,omitempty"`
+	PluggedIn             *bool                `json:"pluggedIn,omitempty"`
+	TimeRemainingMinutes  *int                 `json:"timeRemainingMinutes,omitempty"`
+	TimeToFullMinutes     *int                 `json:"timeToFullMinutes,omitempty"`
+	CycleCount            *int                 `json:"cycleCount,omitempty"`
+	DesignCapacityMWh     *int                 `json:"designCapacityMWh,omitempty"`
+	FullChargeCapacityMWh *int                 `json:"fullChargeCapacityMWh,omitempty"`
+	HealthPercent         *float64             `json:"healthPercent,omitempty"`
+}
+
+// batteryHealthRefresh is how long a platform battery-health reading is reused.
+const batteryHealthRefresh = 6 * time.Hour
+
+// batteryHealth is the slow-changing wear data merged into BatteryInfo.
+type batteryHealth struct {
+	CycleCount            *int
+	DesignCapacityMWh     *int
+	FullChargeCapacityMWh *int
+	HealthPercent         *float64
+}
+
+// apply copies the health fields onto info, leaving fields the current-state
+// reader already set untouched.
+func (h *batteryHealth) apply(info *BatteryInfo) {
+	if h == nil || info == nil {
+		return
+	}
+	if info.CycleCount == nil {
+		info.CycleCount = h.CycleCount
+	}
+	if info.DesignCapacityMWh == nil {
+		info.DesignCapacityMWh = h.DesignCapacityMWh
+	}
+	if info.FullChargeCapacityMWh == nil {
+		info.FullChargeCapacityMWh = h.FullChargeCapacityMWh
+	}
+	if info.HealthPercent == nil {
+		info.HealthPercent = h.HealthPercent
+	}
 }
 
 // powerSupplyRoot is the Linux sysfs power-supply directory. A package-level
@@ -68,7 +111,39 @@ var powerSupplyRoot = "/sys/class/power_supply"
 // CollectBattery returns the current power state, or nil when the platform
 // cannot determine it (so the heartbeat omits the field entirely).
 func (c *HardwareCollector) CollectBattery() *BatteryInfo {
-	return collectPlatformBattery()
+	info := collectPlatformBattery()
+	if info == nil || !info.Present {
+		return info
+	}
+
+	c.batteryMu.Lock()
+	defer c.batteryMu.Unlock()
+	now := time.Now()
+	if c.batteryHealthAt.IsZero() || now.Sub(c.batteryHealthAt) >= batteryHealthRefresh {
+		c.batteryHealth = collectPlatformBatteryHealth()
+		c.batteryHealthAt = now
+	}
+	c.batteryHealth.apply(info)
+	return info
+}
+
+// batteryHealthPercent is full-charge capacity as a percentage of design
+// capacity, rounded to one decimal and capped at 100 (new packs often read a
+// little over design). Either unit works as long as both match.
+func batteryHealthPercent(full, design float64) *float64 {
+	if full <= 0 || design <= 0 {
+		return nil
+	}
+	return floatPtr(clampPercent(math.Round(full/design*1000) / 10))
+}
+
+// positiveIntPtr returns nil for non-positive values: firmware reports 0 for
+// "unsupported" cycle counts and capacities.
+func positiveIntPtr(v float64) *int {
+	if v <= 0 {
+		return nil
+	}
+	return intPtr(int(math.Round(v)))
 }
 
 // floatPtr / intPtr / boolPtr are small helpers so platform collectors can set
@@ -382,6 +457,7 @@ func collectBatteryFromSysfs(root string) *BatteryInfo {
 					}
 				}
 			}
+			readBatteryHealthSysfs(dir).apply(&info)
 		}
 	}
 
@@ -400,3 +476,74 @@ func collectBatteryFromSysfs(root string) *BatteryInfo {
 	}
 	return &info
 }
+
+// readBatteryHealthSysfs reads wear data for one power-supply battery dir:
+// cycle_count plus energy_full{,_design} (µWh), or charge_full{,_design}
+// (µAh) converted to mWh via voltage_min_design (µV) when it is exposed.
+func readBatteryHealthSysfs(dir string) *batteryHealth {
+	health := &batteryHealth{}
+	if cycles, ok := readSysFloat(filepath.Join(dir, "cycle_count")); ok {
+		health.CycleCount = positiveIntPtr(cycles)
+	}
+
+	design, haveDesign := readSysFloat(filepath.Join(dir, "energy_full_design"))
+	full, haveFull := readSysFloat(filepath.Join(dir, "energy_full"))
+	if haveDesign && haveFull {
+		health.DesignCapacityMWh = positiveIntPtr(design / 1000)
+		health.FullChargeCapacityMWh = positiveIntPtr(full / 1000)
+		health.HealthPercent = batteryHealthPercent(full, design)
+		return health
+	}
+
+	design, haveDesign = readSysFloat(filepath.Join(dir, "charge_full_design"))
+	full, haveFull = readSysFloat(filepath.Join(dir, "charge_full"))
+	if haveDesign && haveFull {
+		health.HealthPercent = batteryHealthPercent(full, design)
+		if volts, ok := readSysFloat(filepath.Join(dir, "voltage_min_design")); ok && volts > 0 {
+			health.DesignCapacityMWh = positiveIntPtr(design * volts / 1e9)
+			health.FullChargeCapacityMWh = positiveIntPtr(full * volts / 1e9)
+		}
+	}
+	return health
+}
+
+// ioregIntRe matches a top-level integer property in `ioreg -rn
+// AppleSmartBattery` output, e.g. `    "CycleCount" = 512`.
+var ioregIntRe = regexp.MustCompile(`^\s*"(\w+)" = (\d+)\s*$`)
+
+// parseIORegBatteryHealth extracts wear data from `ioreg -rn
+// AppleSmartBattery`. macOS reports capacities in mAh: AppleRawMaxCapacity is
+// the real full-charge capacity on Apple Silicon (MaxCapacity is a percentage
+// there), with NominalChargeCapacity and an mAh-valued MaxCapacity as Intel
+// fallbacks. mWh uses the pack Voltage (mV), so it is an estimate.
+func parseIORegBatteryHealth(output string) *batteryHealth {
+	values := make(map[string]float64)
+	for _, line := range strings.Split(output, "\n") {
+		if m := ioregIntRe.FindStringSubmatch(line); len(m) == 3 {
+			if v, err := strconv.ParseFloat(m[2], 64); err == nil {
+				if _, seen := values[m[1]]; !seen {
+					values[m[1]] = v
+				}
+			}
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	health := &batteryHealth{CycleCount: positiveIntPtr(values["CycleCount"])}
+	design := values["DesignCapacity"]
+	full := values["AppleRawMaxCapacity"]
+	if full <= 0 {
+		full = values["NominalChargeCapacity"]
+	}
+	if full <= 0 && values["MaxCapacity"] > 100 {
+		full = values["MaxCapacity"]
+	}
+	health.HealthPercent = batteryHealthPercent(full, design)
+	if volts := values["Voltage"]; volts > 0 {
+		health.DesignCapacityMWh = positiveIntPtr(design * volts / 1000)
+		health.FullChargeCapacityMWh = positiveIntPtr(full * volts / 1000)
+	}
+	return health
+}
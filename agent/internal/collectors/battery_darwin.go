@@ -15,3 +15,15 @@ func collectPlatformBattery() *BatteryInfo {
 	}
 	return parsePmsetBatt(string(out))
 }
+
+// collectPlatformBatteryHealth reads cycle count and capacities from the
+// AppleSmartBattery IORegistry entry. Cached by CollectBattery, so the spawn
+// happens a few times a day, not every heartbeat.
+func collectPlatformBatteryHealth() *batteryHealth {
+	out, err := runCollectorOutput(collectorShortCommandTimeout, "ioreg", "-rn", "AppleSmartBattery")
+	if err != nil {
+		slog.Warn("ioreg AppleSmartBattery failed", "error", err.Error())
+		return nil
+	}
+	return parseIORegBatteryHealth(string(out))
+}
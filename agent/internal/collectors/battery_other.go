@@ -6,3 +6,5 @@ package collectors
 // nil makes the heartbeat omit the battery field, so the server keeps whatever
 // it last knew rather than clobbering it with a bogus snapshot.
 func collectPlatformBattery() *BatteryInfo { return nil }
+
+func collectPlatformBatteryHealth() *batteryHealth { return nil }
@@ -277,6 +277,40 @@ func TestCollectBatteryFromSysfs(t *testing.T) {
 		}
 	})
 
+	t.Run("reports wear from energy_full vs design", func(t *testing.T) {
+		root := t.TempDir()
+		writeSupply(t, root, "BAT0", map[string]string{
+			"type": "Battery", "present": "1", "capacity": "80", "status": "Full",
+			"cycle_count": "412", "energy_full_design": "57000000", "energy_full": "45600000",
+		})
+		b := collectBatteryFromSysfs(root)
+		if b == nil || !eqIntPtr(b.CycleCount, intPtr(412)) {
+			t.Fatalf("expected 412 cycles, got %+v", b)
+		}
+		if !eqIntPtr(b.DesignCapacityMWh, intPtr(57000)) || !eqIntPtr(b.FullChargeCapacityMWh, intPtr(45600)) {
+			t.Errorf("capacity = %v/%v mWh, want 45600/57000", derefInt(b.FullChargeCapacityMWh), derefInt(b.DesignCapacityMWh))
+		}
+		if b.HealthPercent == nil || *b.HealthPercent != 80 {
+			t.Errorf("health = %v, want 80", b.HealthPercent)
+		}
+	})
+
+	t.Run("charge-based wear converts via design voltage; zero cycles omitted", func(t *testing.T) {
+		root := t.TempDir()
+		writeSupply(t, root, "BAT0", map[string]string{
+			"type": "Battery", "present": "1", "capacity": "50", "status": "Discharging",
+			"cycle_count": "0", "charge_full_design": "4000000", "charge_full": "3000000",
+			"voltage_min_design": "11400000",
+		})
+		b := collectBatteryFromSysfs(root)
+		if b == nil || b.CycleCount != nil {
+			t.Fatalf("cycle_count 0 must be omitted, got %+v", b)
+		}
+		if !eqIntPtr(b.DesignCapacityMWh, intPtr(45600)) || b.HealthPercent == nil || *b.HealthPercent != 75 {
+			t.Errorf("design = %v mWh health = %v, want 45600 / 75", derefInt(b.DesignCapacityMWh), b.HealthPercent)
+		}
+	})
+
 	t.Run("unreadable type on the only supply omits rather than clobbers", func(t *testing.T) {
 		root := t.TempDir()
 		// A supply dir with no readable `type` file — we can't tell if it's the
@@ -347,3 +381,39 @@ func derefInt(i *int) any {
 	}
 	return *i
 }
+
+func TestParseIORegBatteryHealth(t *testing.T) {
+	output := `+-o AppleSmartBattery  <class AppleSmartBattery, id 0x100000254, registered, matched, active>
+    {
+      "CycleCount" = 318
+      "DesignCapacity" = 6075
+      "MaxCapacity" = 100
+      "AppleRawMaxCapacity" = 5164
+      "Voltage" = 12000
+      "BatteryData" = {"CycleCount"=1,"DesignCapacity"=1}
+    }
+`
+	h := parseIORegBatteryHealth(output)
+	if h == nil || !eqIntPtr(h.CycleCount, intPtr(318)) {
+		t.Fatalf("expected 318 cycles, got %+v", h)
+	}
+	if h.HealthPercent == nil || *h.HealthPercent != 85 {
+		t.Errorf("health = %v, want 85", h.HealthPercent)
+	}
+	if !eqIntPtr(h.DesignCapacityMWh, intPtr(72900)) || !eqIntPtr(h.FullChargeCapacityMWh, intPtr(61968)) {
+		t.Errorf("capacity = %v/%v mWh", derefInt(h.FullChargeCapacityMWh), derefInt(h.DesignCapacityMWh))
+	}
+
+	if parseIORegBatteryHealth("") != nil {
+		t.Error("empty output (desktop Mac) should yield nil")
+	}
+}
+
+func TestBatteryHealthPercent(t *testing.T) {
+	if p := batteryHealthPercent(52000, 50000); p == nil || *p != 100 {
+		t.Errorf("over-design capacity should cap at 100, got %v", p)
+	}
+	if p := batteryHealthPercent(1, 0); p != nil {
+		t.Errorf("zero design should yield nil, got %v", *p)
+	}
+}
@@ -3,6 +3,7 @@
 package collectors
 
 import (
+	"context"
 	"log/slog"
 	"unsafe"
 
@@ -41,3 +42,41 @@ func collectPlatformBattery() *BatteryInfo {
 		status.BatteryLifeTime,
 	)
 }
+
+// windowsBatteryHealthRow is the root\wmi battery wear data for the first
+// system battery. Capacities are mWh.
+type windowsBatteryHealthRow struct {
+	DesignedCapacity    float64 `json:"DesignedCapacity"`
+	FullChargedCapacity float64 `json:"FullChargedCapacity"`
+	CycleCount          float64 `json:"CycleCount"`
+}
+
+const windowsBatteryHealthScript = `$s = Get-CimInstance -Namespace root\wmi -ClassName BatteryStaticData -ErrorAction SilentlyContinue | Select-Object -First 1;` +
+	`$f = Get-CimInstance -Namespace root\wmi -ClassName BatteryFullChargedCapacity -ErrorAction SilentlyContinue | Select-Object -First 1;` +
+	`$c = Get-CimInstance -Namespace root\wmi -ClassName BatteryCycleCount -ErrorAction SilentlyContinue | Select-Object -First 1;` +
+	`[pscustomobject]@{ DesignedCapacity = [double]$s.DesignedCapacity; FullChargedCapacity = [double]$f.FullChargedCapacity; CycleCount = [double]$c.CycleCount } | ConvertTo-Json -Compress`
+
+// collectPlatformBatteryHealth reads cycle count and design/full-charge
+// capacity from the root\wmi battery classes. Many firmwares leave
+// BatteryCycleCount at 0, which is reported as absent. Cached by
+// CollectBattery, so the PowerShell spawn happens a few times a day.
+func collectPlatformBatteryHealth() *batteryHealth {
+	ctx, cancel := context.WithTimeout(context.Background(), collectorShortCommandTimeout)
+	defer cancel()
+
+	rows, err := runWindowsJSON[windowsBatteryHealthRow](ctx, utf8PowerShellCommand(windowsBatteryHealthScript))
+	if err != nil {
+		slog.Warn("battery health query failed", "error", err.Error())
+		return nil
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	row := rows[0]
+	return &batteryHealth{
+		CycleCount:            positiveIntPtr(row.CycleCount),
+		DesignCapacityMWh:     positiveIntPtr(row.DesignedCapacity),
+		FullChargeCapacityMWh: positiveIntPtr(row.FullChargedCapacity),
+		HealthPercent:         batteryHealthPercent(row.FullChargedCapacity, row.DesignedCapacity),
+	}
+}
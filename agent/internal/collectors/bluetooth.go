@@ -0,0 +1,421 @@
+package collectors
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Bluetooth device inventory. Reports the devices paired with (or currently
+// connected to) the machine — name, address, class and, where the OS exposes
+// it, battery level — so asset and security teams can see which endpoints
+// have Bluetooth keyboards, mice or headsets attached. Machines without a
+// Bluetooth adapter report an empty list.
+
+// Bluetooth major device classes, from the Class of Device major class (or
+// the OS's equivalent when no CoD is exposed).
+const (
+	BluetoothClassComputer   = "computer"
+	BluetoothClassPhone      = "phone"
+	BluetoothClassNetwork    = "network"
+	BluetoothClassAudioVideo = "audio_video"
+	BluetoothClassPeripheral = "peripheral"
+	BluetoothClassImaging    = "imaging"
+	BluetoothClassWearable   = "wearable"
+	BluetoothClassToy        = "toy"
+	BluetoothClassHealth     = "health"
+	BluetoothClassOther      = "other"
+)
+
+// maxBluetoothDevices caps the inventory; a machine that has paired with
+// hundreds of devices is reporting noise.
+const maxBluetoothDevices = 128
+
+// BluetoothDevice is one paired or connected device. Address is upper-case
+// colon-separated. Type is the minor class ("keyboard", "headset", ...)
+// when known. Connected is nil where the OS does not report it.
+type BluetoothDevice struct {
+	Address        string `json:"address"`
+	Name           string `json:"name,omitempty"`
+	Class          string `json:"class"`
+	Type           string `json:"type,omitempty"`
+	Paired         bool   `json:"paired"`
+	Connected      *bool  `json:"connected,omitempty"`
+	BatteryPercent *int   `json:"batteryPercent,omitempty"`
+}
+
+type BluetoothCollector struct{}
+
+func NewBluetoothCollector() *BluetoothCollector {
+	return &BluetoothCollector{}
+}
+
+// Collect returns the paired/connected devices, connected first.
+func (c *BluetoothCollector) Collect() ([]BluetoothDevice, error) {
+	devices, err := collectBluetoothDevices()
+	if err != nil {
+		return nil, err
+	}
+	return normalizeBluetoothDevices(devices), nil
+}
+
+// normalizeBluetoothDevices sanitizes, de-duplicates by address and sorts.
+func normalizeBluetoothDevices(devices []BluetoothDevice) []BluetoothDevice {
+	seen := make(map[string]bool, len(devices))
+	out := make([]BluetoothDevice, 0, len(devices))
+	for _, d := range devices {
+		d.Address = normalizeBluetoothAddress(d.Address)
+		if d.Address == "" || seen[d.Address] {
+			continue
+		}
+		seen[d.Address] = true
+		d.Name = truncateCollectorString(strings.TrimSpace(d.Name))
+		if d.Class == "" {
+			d.Class = bluetoothClassForType(d.Type)
+		}
+		if d.BatteryPercent != nil && (*d.BatteryPercent < 0 || *d.BatteryPercent > 100) {
+			d.BatteryPercent = nil
+		}
+		out = append(out, d)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ci, cj := out[i].Connected != nil && *out[i].Connected, out[j].Connected != nil && *out[j].Connected
+		if ci != cj {
+			return ci
+		}
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].Address < out[j].Address
+	})
+	if len(out) > maxBluetoothDevices {
+		out = out[:maxBluetoothDevices]
+	}
+	return out
+}
+
+// normalizeBluetoothAddress reduces "aa:bb:cc:dd:ee:ff", "AA-BB-..." or
+// "AABBCCDDEEFF" to "AA:BB:CC:DD:EE:FF". Returns "" for anything else.
+func normalizeBluetoothAddress(raw string) string {
+	hex := strings.ToUpper(strings.NewReplacer(":", "", "-", "", " ", "").Replace(strings.TrimSpace(raw)))
+	if len(hex) != 12 || strings.Trim(hex, "0123456789ABCDEF") != "" {
+		return ""
+	}
+	parts := make([]string, 6)
+	for i := range parts {
+		parts[i] = hex[i*2 : i*2+2]
+	}
+	return strings.Join(parts, ":")
+}
+
+// bluetoothClassFromCoD decodes a 24-bit Class of Device into major class
+// and minor type.
+func bluetoothClassFromCoD(cod uint32) (class, typ string) {
+	minor := (cod >> 2) & 0x3F
+	switch (cod >> 8) & 0x1F {
+	case 1:
+		return BluetoothClassComputer, "computer"
+	case 2:
+		return BluetoothClassPhone, "phone"
+	case 3:
+		return BluetoothClassNetwork, ""
+	case 4:
+		switch minor {
+		case 1:
+			typ = "headset"
+		case 2:
+			typ = "handsfree"
+		case 4:
+			typ = "microphone"
+		case 5, 10:
+			typ = "speaker"
+		case 6:
+			typ = "headphones"
+		case 7:
+			typ = "portable_audio"
+		case 8:
+			typ = "car_audio"
+		}
+		return BluetoothClassAudioVideo, typ
+	case 5:
+		switch minor >> 4 {
+		case 1:
+			typ = "keyboard"
+		case 2:
+			typ = "mouse"
+		case 3:
+			typ = "keyboard_mouse"
+		}
+		switch minor & 0x0F {
+		case 1, 2:
+			typ = "gamepad"
+		case 5:
+			typ = "tablet"
+		}
+		return BluetoothClassPeripheral, typ
+	case 6:
+		return BluetoothClassImaging, ""
+	case 7:
+		return BluetoothClassWearable, ""
+	case 8:
+		return BluetoothClassToy, ""
+	case 9:
+		return BluetoothClassHealth, ""
+	}
+	return BluetoothClassOther, ""
+}
+
+// bluetoothClassForType derives the major class from a minor type when the
+// OS reports only the latter.
+func bluetoothClassForType(typ string) string {
+	switch typ {
+	case "headset", "handsfree", "microphone", "speaker", "headphones", "portable_audio", "car_audio":
+		return BluetoothClassAudioVideo
+	case "keyboard", "mouse", "keyboard_mouse", "trackpad", "gamepad", "tablet":
+		return BluetoothClassPeripheral
+	case "phone":
+		return BluetoothClassPhone
+	case "computer":
+		return BluetoothClassComputer
+	case "printer", "camera":
+		return BluetoothClassImaging
+	case "watch":
+		return BluetoothClassWearable
+	}
+	return BluetoothClassOther
+}
+
+// bluetoothTypeFromIcon maps a BlueZ Icon property ("audio-headset",
+// "input-keyboard", ...) to a minor type.
+func bluetoothTypeFromIcon(icon string) string {
+	switch icon {
+	case "audio-headset":
+		return "headset"
+	case "audio-headphones":
+		return "headphones"
+	case "audio-card":
+		return "speaker"
+	case "input-keyboard":
+		return "keyboard"
+	case "input-mouse":
+		return "mouse"
+	case "input-tablet":
+		return "tablet"
+	case "input-gaming":
+		return "gamepad"
+	case "phone":
+		return "phone"
+	case "computer":
+		return "computer"
+	case "printer":
+		return "printer"
+	case "camera-photo", "camera-video":
+		return "camera"
+	}
+	return ""
+}
+
+// bluetoothTypeFromName maps a macOS minor-type label ("Headphones",
+// "Keyboard", ...) to a minor type.
+func bluetoothTypeFromName(name string) string {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "headphones":
+		return "headphones"
+	case "headset":
+		return "headset"
+	case "speaker", "loudspeaker":
+		return "speaker"
+	case "keyboard":
+		return "keyboard"
+	case "mouse":
+		return "mouse"
+	case "trackpad":
+		return "trackpad"
+	case "gamepad", "game controller", "joystick":
+		return "gamepad"
+	case "phone", "smartphone", "cellular":
+		return "phone"
+	case "computer", "desktop", "laptop":
+		return "computer"
+	case "watch":
+		return "watch"
+	}
+	return ""
+}
+
+// parseBluetoothPercent reads "80%", "0x5a (90)" or "90" as a percentage.
+func parseBluetoothPercent(s string) *int {
+	s = strings.TrimSpace(s)
+	if lp, rp := strings.Index(s, "("), strings.Index(s, ")"); lp >= 0 && rp > lp {
+		s = s[lp+1 : rp]
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(s, "%")))
+	if err != nil || n < 0 || n > 100 {
+		return nil
+	}
+	return &n
+}
+
+// parseBluetoothctlDevices reads the addresses from `bluetoothctl devices
+// Paired` (or the older `paired-devices`): "Device AA:BB:CC:DD:EE:FF Name".
+func parseBluetoothctlDevices(output []byte) []string {
+	var addrs []string
+	scanner := newCollectorScanner(output)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "Device" {
+			if addr := normalizeBluetoothAddress(fields[1]); addr != "" {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs
+}
+
+// parseBluetoothctlInfo reads `bluetoothctl info <addr>`.
+func parseBluetoothctlInfo(addr string, output []byte) BluetoothDevice {
+	d := BluetoothDevice{Address: addr}
+	connected := false
+	scanner := newCollectorScanner(output)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Name":
+			d.Name = value
+		case "Alias":
+			if d.Name == "" {
+				d.Name = value
+			}
+		case "Class":
+			if cod, err := strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 32); err == nil {
+				d.Class, d.Type = bluetoothClassFromCoD(uint32(cod))
+			}
+		case "Icon":
+			if typ := bluetoothTypeFromIcon(value); typ != "" {
+				d.Type = typ
+			}
+		case "Paired":
+			d.Paired = value == "yes"
+		case "Connected":
+			connected = value == "yes"
+		case "Battery Percentage":
+			d.BatteryPercent = parseBluetoothPercent(value)
+		}
+	}
+	d.Connected = &connected
+	if d.Class == "" || d.Class == BluetoothClassOther {
+		d.Class = bluetoothClassForType(d.Type)
+	}
+	return d
+}
+
+// spBluetoothDevice is one device in `system_profiler SPBluetoothDataType
+// -json`. macOS 12+ groups devices under device_connected /
+// device_not_connected, each an array of single-key objects keyed by name.
+type spBluetoothDevice struct {
+	Address      string `json:"device_address"`
+	MinorType    string `json:"device_minorType"`
+	BatteryMain  string `json:"device_batteryLevelMain"`
+	Battery      string `json:"device_batteryLevel"`
+	BatteryLeft  string `json:"device_batteryLevelLeft"`
+	BatteryRight string `json:"device_batteryLevelRight"`
+	// Pre-Monterey fields.
+	IsConnected string `json:"device_isconnected"`
+	IsPaired    string `json:"device_ispaired"`
+}
+
+// parseSPBluetoothJSON decodes system_profiler's Bluetooth report.
+func parseSPBluetoothJSON(data []byte) ([]BluetoothDevice, error) {
+	var doc struct {
+		Items []struct {
+			Connected    []map[string]spBluetoothDevice `json:"device_connected"`
+			NotConnected []map[string]spBluetoothDevice `json:"device_not_connected"`
+			DevicesList  []map[string]spBluetoothDevice `json:"devices_list"`
+		} `json:"SPBluetoothDataType"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var devices []BluetoothDevice
+	add := func(groups []map[string]spBluetoothDevice, connected *bool) {
+		for _, group := range groups {
+			names := make([]string, 0, len(group))
+			for name := range group {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				sp := group[name]
+				d := BluetoothDevice{Address: sp.Address, Name: name, Type: bluetoothTypeFromName(sp.MinorType), Paired: true}
+				d.Class = bluetoothClassForType(d.Type)
+				d.Connected = connected
+				if connected == nil {
+					// Legacy devices_list entry.
+					isConnected := sp.IsConnected == "attrib_Yes"
+					d.Connected = &isConnected
+					d.Paired = sp.IsPaired != "attrib_No"
+				}
+				for _, level := range []string{sp.BatteryMain, sp.Battery, sp.BatteryLeft, sp.BatteryRight} {
+					if p := parseBluetoothPercent(level); p != nil {
+						d.BatteryPercent = p
+						break
+					}
+				}
+				devices = append(devices, d)
+			}
+		}
+	}
+	yes, no := true, false
+	for _, item := range doc.Items {
+		add(item.Connected, &yes)
+		add(item.NotConnected, &no)
+		add(item.DevicesList, nil)
+	}
+	return devices, nil
+}
+
+// windowsBluetoothRow is one paired device from the PnP query.
+type windowsBluetoothRow struct {
+	InstanceID string  `json:"InstanceId"`
+	Name       string  `json:"Name"`
+	Address    string  `json:"Address"`
+	CoD        *uint32 `json:"CoD"`
+	Connected  *bool   `json:"Connected"`
+	Battery    *int    `json:"Battery"`
+}
+
+// bluetoothDevicesFromWindowsRows converts PnP rows. Device nodes look like
+// BTHENUM\DEV_A1B2C3D4E5F6\… (classic) or BTHLE\DEV_A1B2C3D4E5F6\… (LE);
+// the address comes from DEVPKEY_Bluetooth_DeviceAddress or, failing that,
+// the instance ID.
+func bluetoothDevicesFromWindowsRows(rows []windowsBluetoothRow) []BluetoothDevice {
+	var devices []BluetoothDevice
+	for _, row := range rows {
+		addr := normalizeBluetoothAddress(row.Address)
+		if addr == "" {
+			parts := strings.Split(strings.ToUpper(row.InstanceID), `\`)
+			if len(parts) >= 2 {
+				addr = normalizeBluetoothAddress(strings.TrimPrefix(parts[1], "DEV_"))
+			}
+		}
+		if addr == "" {
+			continue
+		}
+		d := BluetoothDevice{Address: addr, Name: row.Name, Class: BluetoothClassOther, Paired: true, Connected: row.Connected}
+		if row.CoD != nil && *row.CoD != 0 {
+			d.Class, d.Type = bluetoothClassFromCoD(*row.CoD)
+		}
+		if row.Battery != nil {
+			b := *row.Battery
+			d.BatteryPercent = &b
+		}
+		devices = append(devices, d)
+	}
+	return devices
+}
@@ -0,0 +1,11 @@
+//go:build darwin
+
+package collectors
+
+func collectBluetoothDevices() ([]BluetoothDevice, error) {
+	output, err := runCollectorOutput(collectorLongCommandTimeout, "system_profiler", "SPBluetoothDataType", "-json")
+	if err != nil {
+		return nil, err
+	}
+	return parseSPBluetoothJSON(output)
+}
@@ -0,0 +1,41 @@
+//go:build linux
+
+package collectors
+
+import (
+	"os/exec"
+)
+
+// collectBluetoothDevices queries BlueZ through bluetoothctl. Hosts without
+// BlueZ (most servers) report no devices.
+func collectBluetoothDevices() ([]BluetoothDevice, error) {
+	if _, err := exec.LookPath("bluetoothctl"); err != nil {
+		return nil, nil
+	}
+	// BlueZ 5.65+ takes a filter on "devices"; older releases only have
+	// "paired-devices".
+	output, err := runCollectorOutput(collectorShortCommandTimeout, "bluetoothctl", "devices", "Paired")
+	if err != nil || len(parseBluetoothctlDevices(output)) == 0 {
+		if legacy, legacyErr := runCollectorOutput(collectorShortCommandTimeout, "bluetoothctl", "paired-devices"); legacyErr == nil {
+			output, err = legacy, nil
+		}
+	}
+	if err != nil {
+		// No controller or bluetoothd not running.
+		return nil, nil
+	}
+
+	var devices []BluetoothDevice
+	for _, addr := range parseBluetoothctlDevices(output) {
+		info, err := runCollectorOutput(collectorShortCommandTimeout, "bluetoothctl", "info", addr)
+		if err != nil {
+			devices = append(devices, BluetoothDevice{Address: addr, Paired: true})
+			continue
+		}
+		devices = append(devices, parseBluetoothctlInfo(addr, info))
+		if len(devices) >= maxBluetoothDevices {
+			break
+		}
+	}
+	return devices, nil
+}
@@ -0,0 +1,7 @@
+//go:build !windows && !linux && !darwin
+
+package collectors
+
+func collectBluetoothDevices() ([]BluetoothDevice, error) {
+	return nil, nil
+}
@@ -0,0 +1,120 @@
+package collectors
+
+import "testing"
+
+func TestNormalizeBluetoothAddress(t *testing.T) {
+	cases := map[string]string{
+		"aa:bb:cc:dd:ee:ff": "AA:BB:CC:DD:EE:FF",
+		"AA-BB-CC-DD-EE-FF": "AA:BB:CC:DD:EE:FF",
+		"a1b2c3d4e5f6":      "A1:B2:C3:D4:E5:F6",
+		"a1b2c3d4e5":        "",
+		"zz:bb:cc:dd:ee:ff": "",
+	}
+	for in, want := range cases {
+		if got := normalizeBluetoothAddress(in); got != want {
+			t.Errorf("%q: got %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBluetoothClassFromCoD(t *testing.T) {
+	cases := []struct {
+		cod        uint32
+		class, typ string
+	}{
+		{0x240404, BluetoothClassAudioVideo, "headset"},
+		{0x240418, BluetoothClassAudioVideo, "headphones"},
+		{0x002540, BluetoothClassPeripheral, "keyboard"},
+		{0x002580, BluetoothClassPeripheral, "mouse"},
+		{0x5a020c, BluetoothClassPhone, "phone"},
+		{0x1f00, BluetoothClassOther, ""},
+	}
+	for _, tc := range cases {
+		class, typ := bluetoothClassFromCoD(tc.cod)
+		if class != tc.class || typ != tc.typ {
+			t.Errorf("%#06x: got %s/%s, want %s/%s", tc.cod, class, typ, tc.class, tc.typ)
+		}
+	}
+}
+
+func TestParseBluetoothctl(t *testing.T) {
+	addrs := parseBluetoothctlDevices([]byte("Device 11:22:33:44:55:66 MX Keys\nDevice AA:BB:CC:DD:EE:FF WH-1000XM4\nController 00:11:22:33:44:55 host\n"))
+	if !slicesEqual(addrs, []string{"11:22:33:44:55:66", "AA:BB:CC:DD:EE:FF"}) {
+		t.Fatalf("unexpected addresses %v", addrs)
+	}
+
+	info := `Device AA:BB:CC:DD:EE:FF (public)
+	Name: WH-1000XM4
+	Alias: WH-1000XM4
+	Class: 0x00240404
+	Icon: audio-headset
+	Paired: yes
+	Trusted: yes
+	Connected: yes
+	Battery Percentage: 0x46 (70)
+`
+	d := parseBluetoothctlInfo("AA:BB:CC:DD:EE:FF", []byte(info))
+	if d.Name != "WH-1000XM4" || d.Class != BluetoothClassAudioVideo || d.Type != "headset" || !d.Paired {
+		t.Fatalf("unexpected device %+v", d)
+	}
+	if d.Connected == nil || !*d.Connected || d.BatteryPercent == nil || *d.BatteryPercent != 70 {
+		t.Fatalf("unexpected state %+v", d)
+	}
+
+	// LE devices often have no Class; the icon still classifies them.
+	d = parseBluetoothctlInfo("11:22:33:44:55:66", []byte("\tName: MX Keys\n\tIcon: input-keyboard\n\tPaired: yes\n\tConnected: no\n"))
+	if d.Class != BluetoothClassPeripheral || d.Type != "keyboard" || d.Connected == nil || *d.Connected {
+		t.Fatalf("unexpected LE device %+v", d)
+	}
+}
+
+func TestParseSPBluetoothJSON(t *testing.T) {
+	out := `{"SPBluetoothDataType":[{
+		"controller_properties":{"controller_address":"00:11:22:33:44:55"},
+		"device_connected":[{"AirPods Pro":{"device_address":"AA:BB:CC:DD:EE:01","device_minorType":"Headphones","device_batteryLevelLeft":"80%","device_batteryLevelRight":"75%"}}],
+		"device_not_connected":[{"Magic Keyboard":{"device_address":"AA:BB:CC:DD:EE:02","device_minorType":"Keyboard"}},{"Phone":{"device_address":"AA:BB:CC:DD:EE:03"}}]
+	}]}`
+	devices, err := parseSPBluetoothJSON([]byte(out))
+	if err != nil || len(devices) != 3 {
+		t.Fatalf("unexpected result %+v %v", devices, err)
+	}
+	airpods := devices[0]
+	if airpods.Name != "AirPods Pro" || airpods.Type != "headphones" || airpods.Class != BluetoothClassAudioVideo ||
+		airpods.Connected == nil || !*airpods.Connected || airpods.BatteryPercent == nil || *airpods.BatteryPercent != 80 {
+		t.Fatalf("unexpected airpods %+v", airpods)
+	}
+	if kb := devices[1]; kb.Type != "keyboard" || kb.Connected == nil || *kb.Connected || !kb.Paired {
+		t.Fatalf("unexpected keyboard %+v", kb)
+	}
+	if other := devices[2]; other.Class != BluetoothClassOther {
+		t.Fatalf("unexpected unclassified device %+v", other)
+	}
+
+	legacy := `{"SPBluetoothDataType":[{"devices_list":[{"Mouse":{"device_address":"aa-bb-cc-dd-ee-04","device_minorType":"Mouse","device_isconnected":"attrib_Yes","device_ispaired":"attrib_Yes"}}]}]}`
+	devices, err = parseSPBluetoothJSON([]byte(legacy))
+	if err != nil || len(devices) != 1 || devices[0].Connected == nil || !*devices[0].Connected || devices[0].Type != "mouse" {
+		t.Fatalf("unexpected legacy result %+v %v", devices, err)
+	}
+}
+
+func TestBluetoothDevicesFromWindowsRows(t *testing.T) {
+	cod := uint32(0x002540)
+	connected := true
+	battery := 55
+	rows := []windowsBluetoothRow{
+		{InstanceID: `BTHENUM\DEV_A1B2C3D4E5F6\7&1234&0&BLUETOOTHDEVICE_A1B2C3D4E5F6`, Name: "Keyboard K380", CoD: &cod, Connected: &connected, Battery: &battery},
+		{InstanceID: `BTHLE\DEV_112233445566\7&ABC&0&112233445566`, Name: "MX Master 3", Address: "112233445566"},
+		{InstanceID: `BTHENUM\{0000110B-0000-1000-8000-00805F9B34FB}_LOCALMFG&0002\7&1&0&000000000000_00000000`, Name: "Service"},
+	}
+	devices := normalizeBluetoothDevices(bluetoothDevicesFromWindowsRows(rows))
+	if len(devices) != 2 {
+		t.Fatalf("unexpected devices %+v", devices)
+	}
+	kb := devices[0]
+	if kb.Address != "A1:B2:C3:D4:E5:F6" || kb.Type != "keyboard" || kb.BatteryPercent == nil || *kb.BatteryPercent != 55 {
+		t.Fatalf("unexpected keyboard %+v", kb)
+	}
+	if mouse := devices[1]; mouse.Address != "11:22:33:44:55:66" || mouse.Connected != nil || mouse.Class != BluetoothClassOther {
+		t.Fatalf("unexpected LE device %+v", mouse)
+	}
+}
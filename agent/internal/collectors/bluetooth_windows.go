@@ -0,0 +1,33 @@
+//go:build windows
+
+package collectors
+
+import "context"
+
+// Paired devices are the BTHENUM\DEV_* / BTHLE\DEV_* device nodes. The
+// connected flag ({83DA6326-…} 15) and battery level ({104EA319-…} 2) are
+// undocumented Bluetooth device properties; either may be absent.
+const windowsBluetoothScript = `$ErrorActionPreference='SilentlyContinue'
+@(Get-PnpDevice -Class Bluetooth | Where-Object { $_.InstanceId -match '^(BTHENUM|BTHLE)\\DEV_' } | ForEach-Object {
+  $p=@{}
+  Get-PnpDeviceProperty -InstanceId $_.InstanceId -KeyName 'DEVPKEY_Bluetooth_DeviceAddress','DEVPKEY_Bluetooth_ClassOfDevice','{83DA6326-97A6-4088-9453-A1923F573B29} 15','{104EA319-6EE2-4701-BD47-8DDBF425BBE5} 2' | ForEach-Object { $p[$_.KeyName]=$_.Data }
+  [pscustomobject]@{
+    InstanceId=$_.InstanceId
+    Name=$_.FriendlyName
+    Address=$p['DEVPKEY_Bluetooth_DeviceAddress']
+    CoD=$p['DEVPKEY_Bluetooth_ClassOfDevice']
+    Connected=$p['{83DA6326-97A6-4088-9453-A1923F573B29} 15']
+    Battery=$p['{104EA319-6EE2-4701-BD47-8DDBF425BBE5} 2']
+  }
+}) | ConvertTo-Json -Compress`
+
+func collectBluetoothDevices() ([]BluetoothDevice, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectorLongCommandTimeout)
+	defer cancel()
+
+	rows, err := runWindowsJSON[windowsBluetoothRow](ctx, utf8PowerShellCommand(windowsBluetoothScript))
+	if err != nil {
+		return nil, err
+	}
+	return bluetoothDevicesFromWindowsRows(rows), nil
+}
@@ -0,0 +1,479 @@
+package collectors
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Browser extension inventory. Walks every local user's Chromium-family
+// (Chrome, Edge, Chromium, Brave) and Firefox profiles and reports each
+// installed extension with its requested permissions, plus the extensions
+// force-installed machine-wide by policy. Everything is read from profile
+// files on disk, so no browser needs to be running.
+
+const (
+	browserChrome   = "chrome"
+	browserEdge     = "edge"
+	browserChromium = "chromium"
+	browserBrave    = "brave"
+	browserFirefox  = "firefox"
+
+	browserExtensionScopeUser    = "user"
+	browserExtensionScopeMachine = "machine"
+
+	browserExtensionSourceProfile = "profile"
+	browserExtensionSourcePolicy  = "policy"
+)
+
+// BrowserExtension is the agent-sent wire shape for one extension.
+type BrowserExtension struct {
+	Browser         string   `json:"browser"`
+	Scope           string   `json:"scope"`
+	Source          string   `json:"source"`
+	User            string   `json:"user,omitempty"`
+	Profile         string   `json:"profile,omitempty"`
+	ID              string   `json:"id"`
+	Name            string   `json:"name,omitempty"`
+	Version         string   `json:"version,omitempty"`
+	Enabled         *bool    `json:"enabled,omitempty"`
+	Permissions     []string `json:"permissions,omitempty"`
+	HostPermissions []string `json:"hostPermissions,omitempty"`
+	UpdateURL       string   `json:"updateUrl,omitempty"`
+}
+
+// browserUserHome is one local account's home directory.
+type browserUserHome struct {
+	User string
+	Dir  string
+}
+
+// chromiumUserDataDir is a Chromium-family "User Data" directory relative
+// to a user's home.
+type chromiumUserDataDir struct {
+	Browser string
+	RelPath string
+}
+
+type BrowserExtensionCollector struct{}
+
+func NewBrowserExtensionCollector() *BrowserExtensionCollector {
+	return &BrowserExtensionCollector{}
+}
+
+// Collect returns per-profile extensions for every local user followed by
+// machine-wide policy installs.
+func (c *BrowserExtensionCollector) Collect() ([]BrowserExtension, error) {
+	exts := collectUserBrowserExtensions(browserUserHomes(), chromiumUserDataDirs, firefoxProfilesRelPath)
+	exts = append(exts, machineBrowserExtensionPolicies()...)
+	if exts == nil {
+		exts = []BrowserExtension{}
+	}
+	if len(exts) > collectorResultLimit {
+		exts = exts[:collectorResultLimit]
+	}
+	return exts, nil
+}
+
+// listUserHomes returns the directories under base, skipping exclusions
+// (case-insensitive) and non-directories.
+func listUserHomes(base string, exclude ...string) []browserUserHome {
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		return nil
+	}
+	var homes []browserUserHome
+	for _, entry := range entries {
+		if !entry.IsDir() || slices.ContainsFunc(exclude, func(e string) bool { return strings.EqualFold(e, entry.Name()) }) {
+			continue
+		}
+		homes = append(homes, browserUserHome{User: entry.Name(), Dir: filepath.Join(base, entry.Name())})
+	}
+	return homes
+}
+
+func collectUserBrowserExtensions(homes []browserUserHome, chromium []chromiumUserDataDir, firefoxRel string) []BrowserExtension {
+	var exts []BrowserExtension
+	for _, home := range homes {
+		for _, dir := range chromium {
+			exts = append(exts, chromiumUserDataExtensions(filepath.Join(home.Dir, dir.RelPath), dir.Browser, home.User)...)
+		}
+		if firefoxRel != "" {
+			exts = append(exts, firefoxProfilesExtensions(filepath.Join(home.Dir, firefoxRel), home.User)...)
+		}
+	}
+	return exts
+}
+
+// chromiumProfileDirs returns the profile directories inside a User Data
+// directory ("Default", "Profile 1", ...): any subdirectory that has an
+// Extensions folder.
+func chromiumProfileDirs(userData string) []string {
+	entries, err := os.ReadDir(userData)
+	if err != nil {
+		return nil
+	}
+	var profiles []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(userData, entry.Name(), "Extensions")); err == nil && info.IsDir() {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	return profiles
+}
+
+func chromiumUserDataExtensions(userData, browser, user string) []BrowserExtension {
+	var exts []BrowserExtension
+	for _, profile := range chromiumProfileDirs(userData) {
+		profileDir := filepath.Join(userData, profile)
+		states := chromiumExtensionStates(profileDir)
+		ids, err := os.ReadDir(filepath.Join(profileDir, "Extensions"))
+		if err != nil {
+			continue
+		}
+		for _, idEntry := range ids {
+			if !idEntry.IsDir() || idEntry.Name() == "Temp" {
+				continue
+			}
+			ext, ok := chromiumExtension(filepath.Join(profileDir, "Extensions", idEntry.Name()))
+			if !ok {
+				continue
+			}
+			ext.Browser = browser
+			ext.User = user
+			ext.Profile = profile
+			if enabled, known := states[ext.ID]; known {
+				ext.Enabled = boolPtr(enabled)
+			}
+			exts = append(exts, ext)
+		}
+	}
+	return exts
+}
+
+type chromiumManifest struct {
+	Name                string            `json:"name"`
+	Version             string            `json:"version"`
+	DefaultLocale       string            `json:"default_locale"`
+	Permissions         []json.RawMessage `json:"permissions"`
+	OptionalPermissions []json.RawMessage `json:"optional_permissions"`
+	HostPermissions     []string          `json:"host_permissions"`
+	UpdateURL           string            `json:"update_url"`
+}
+
+// chromiumExtension reads the newest installed version under
+// Extensions/<id>/<version>/manifest.json.
+func chromiumExtension(idDir string) (BrowserExtension, bool) {
+	versions, err := os.ReadDir(idDir)
+	if err != nil {
+		return BrowserExtension{}, false
+	}
+	var newest string
+	for _, v := range versions {
+		if v.IsDir() && (newest == "" || compareDottedVersions(v.Name(), newest) > 0) {
+			newest = v.Name()
+		}
+	}
+	if newest == "" {
+		return BrowserExtension{}, false
+	}
+	versionDir := filepath.Join(idDir, newest)
+	data, err := os.ReadFile(filepath.Join(versionDir, "manifest.json"))
+	if err != nil {
+		return BrowserExtension{}, false
+	}
+	var manifest chromiumManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return BrowserExtension{}, false
+	}
+
+	// MV2 mixes API permissions and host patterns in "permissions"; split
+	// them so both manifest versions report the same shape.
+	var perms, hosts []string
+	for _, raw := range manifest.Permissions {
+		var p string
+		if json.Unmarshal(raw, &p) != nil {
+			continue // object-form permissions (e.g. socket rules)
+		}
+		if isHostPermission(p) {
+			hosts = append(hosts, p)
+		} else {
+			perms = append(perms, p)
+		}
+	}
+	hosts = append(hosts, manifest.HostPermissions...)
+
+	return BrowserExtension{
+		Scope:           browserExtensionScopeUser,
+		Source:          browserExtensionSourceProfile,
+		ID:              filepath.Base(idDir),
+		Name:            truncateCollectorString(resolveChromiumMessage(versionDir, manifest.DefaultLocale, manifest.Name)),
+		Version:         manifest.Version,
+		Permissions:     perms,
+		HostPermissions: hosts,
+		UpdateURL:       manifest.UpdateURL,
+	}, true
+}
+
+func isHostPermission(p string) bool {
+	return p == "<all_urls>" || strings.Contains(p, "://")
+}
+
+// resolveChromiumMessage resolves a "__MSG_key__" placeholder from
+// _locales/<locale>/messages.json (keys are case-insensitive). Unresolvable
+// placeholders are returned unchanged.
+func resolveChromiumMessage(versionDir, locale, value string) string {
+	if !strings.HasPrefix(value, "__MSG_") || !strings.HasSuffix(value, "__") || locale == "" {
+		return value
+	}
+	key := strings.TrimSuffix(strings.TrimPrefix(value, "__MSG_"), "__")
+	data, err := os.ReadFile(filepath.Join(versionDir, "_locales", locale, "messages.json"))
+	if err != nil {
+		return value
+	}
+	var messages map[string]struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &messages) != nil {
+		return value
+	}
+	for k, m := range messages {
+		if strings.EqualFold(k, key) && m.Message != "" {
+			return m.Message
+		}
+	}
+	return value
+}
+
+// chromiumExtensionStates reads extensions.settings.<id>.state (1 enabled,
+// 0 disabled) from Preferences and Secure Preferences; newer builds keep
+// settings in the latter on Windows and macOS.
+func chromiumExtensionStates(profileDir string) map[string]bool {
+	states := make(map[string]bool)
+	for _, name := range []string{"Preferences", "Secure Preferences"} {
+		data, err := os.ReadFile(filepath.Join(profileDir, name))
+		if err != nil {
+			continue
+		}
+		var prefs struct {
+			Extensions struct {
+				Settings map[string]struct {
+					State *int `json:"state"`
+				} `json:"settings"`
+			} `json:"extensions"`
+		}
+		if json.Unmarshal(data, &prefs) != nil {
+			continue
+		}
+		for id, s := range prefs.Extensions.Settings {
+			if s.State != nil {
+				states[id] = *s.State == 1
+			}
+		}
+	}
+	return states
+}
+
+// firefoxBuiltinLocations are Mozilla-shipped add-on locations, not
+// user-installed extensions.
+var firefoxBuiltinLocations = []string{"app-builtin", "app-system-defaults", "app-system-addons", "app-system-share", "app-system-local"}
+
+// firefoxProfilesExtensions reads extensions.json from every profile
+// directory under root.
+func firefoxProfilesExtensions(root, user string) []BrowserExtension {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	var exts []BrowserExtension
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, entry.Name(), "extensions.json"))
+		if err != nil {
+			continue
+		}
+		for _, ext := range parseFirefoxExtensionsJSON(data) {
+			ext.User = user
+			ext.Profile = entry.Name()
+			exts = append(exts, ext)
+		}
+	}
+	return exts
+}
+
+func parseFirefoxExtensionsJSON(data []byte) []BrowserExtension {
+	var doc struct {
+		Addons []struct {
+			ID            string `json:"id"`
+			Version       string `json:"version"`
+			Type          string `json:"type"`
+			Active        bool   `json:"active"`
+			Location      string `json:"location"`
+			UpdateURL     string `json:"updateURL"`
+			DefaultLocale struct {
+				Name string `json:"name"`
+			} `json:"defaultLocale"`
+			UserPermissions *struct {
+				Permissions []string `json:"permissions"`
+				Origins     []string `json:"origins"`
+			} `json:"userPermissions"`
+		} `json:"addons"`
+	}
+	if json.Unmarshal(data, &doc) != nil {
+		return nil
+	}
+	var exts []BrowserExtension
+	for _, a := range doc.Addons {
+		if a.Type != "extension" || slices.Contains(firefoxBuiltinLocations, a.Location) {
+			continue
+		}
+		ext := BrowserExtension{
+			Browser:   browserFirefox,
+			Scope:     browserExtensionScopeUser,
+			Source:    browserExtensionSourceProfile,
+			ID:        a.ID,
+			Name:      truncateCollectorString(a.DefaultLocale.Name),
+			Version:   a.Version,
+			Enabled:   boolPtr(a.Active),
+			UpdateURL: a.UpdateURL,
+		}
+		if a.UserPermissions != nil {
+			ext.Permissions = a.UserPermissions.Permissions
+			ext.HostPermissions = a.UserPermissions.Origins
+		}
+		exts = append(exts, ext)
+	}
+	return exts
+}
+
+// parseChromiumForcelistEntry splits an ExtensionInstallForcelist entry,
+// "<id>;<update url>" or a bare id, into a machine-scope extension.
+func parseChromiumForcelistEntry(browser, entry string) (BrowserExtension, bool) {
+	id, updateURL, _ := strings.Cut(strings.TrimSpace(entry), ";")
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return BrowserExtension{}, false
+	}
+	return BrowserExtension{
+		Browser:   browser,
+		Scope:     browserExtensionScopeMachine,
+		Source:    browserExtensionSourcePolicy,
+		ID:        id,
+		UpdateURL: strings.TrimSpace(updateURL),
+	}, true
+}
+
+// parseChromiumPolicyJSON extracts ExtensionInstallForcelist from a managed
+// policy JSON file (Linux /etc/opt/chrome/policies/managed/*.json).
+func parseChromiumPolicyJSON(browser string, data []byte) []BrowserExtension {
+	var policy struct {
+		Forcelist []string `json:"ExtensionInstallForcelist"`
+	}
+	if json.Unmarshal(data, &policy) != nil {
+		return nil
+	}
+	var exts []BrowserExtension
+	for _, entry := range policy.Forcelist {
+		if ext, ok := parseChromiumForcelistEntry(browser, entry); ok {
+			exts = append(exts, ext)
+		}
+	}
+	return exts
+}
+
+// parseFirefoxPolicyJSON extracts force/normal-installed extensions from a
+// Firefox enterprise policies.json ExtensionSettings block.
+func parseFirefoxPolicyJSON(data []byte) []BrowserExtension {
+	var doc struct {
+		Policies struct {
+			ExtensionSettings map[string]struct {
+				InstallationMode string `json:"installation_mode"`
+				InstallURL       string `json:"install_url"`
+			} `json:"ExtensionSettings"`
+		} `json:"policies"`
+	}
+	if json.Unmarshal(data, &doc) != nil {
+		return nil
+	}
+	var exts []BrowserExtension
+	for id, s := range doc.Policies.ExtensionSettings {
+		if id == "*" || (s.InstallationMode != "force_installed" && s.InstallationMode != "normal_installed") {
+			continue
+		}
+		exts = append(exts, BrowserExtension{
+			Browser:   browserFirefox,
+			Scope:     browserExtensionScopeMachine,
+			Source:    browserExtensionSourcePolicy,
+			ID:        id,
+			UpdateURL: s.InstallURL,
+		})
+	}
+	slices.SortFunc(exts, func(a, b BrowserExtension) int { return strings.Compare(a.ID, b.ID) })
+	return exts
+}
+
+// policyFileExtensions parses Chromium managed-policy directories and
+// Firefox policies.json files that exist on disk.
+func policyFileExtensions(chromiumPolicyDirs map[string][]string, firefoxPolicyFiles []string) []BrowserExtension {
+	var exts []BrowserExtension
+	for browser, dirs := range chromiumPolicyDirs {
+		for _, dir := range dirs {
+			files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+			for _, file := range files {
+				if data, err := os.ReadFile(file); err == nil {
+					exts = append(exts, parseChromiumPolicyJSON(browser, data)...)
+				}
+			}
+		}
+	}
+	for _, file := range firefoxPolicyFiles {
+		if data, err := os.ReadFile(file); err == nil {
+			exts = append(exts, parseFirefoxPolicyJSON(data)...)
+		}
+	}
+	return exts
+}
+
+// compareDottedVersions compares "1.10.2" style versions numerically,
+// falling back to string comparison for non-numeric parts.
+func compareDottedVersions(a, b string) int {
+	pa, pb := strings.Split(strings.SplitN(a, "_", 2)[0], "."), strings.Split(strings.SplitN(b, "_", 2)[0], ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var sa, sb string
+		if i < len(pa) {
+			sa = pa[i]
+		}
+		if i < len(pb) {
+			sb = pb[i]
+		}
+		if len(sa) != len(sb) && isAllDigits(sa) && isAllDigits(sb) {
+			if len(sa) < len(sb) {
+				return -1
+			}
+			return 1
+		}
+		if c := strings.Compare(sa, sb); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func isAllDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
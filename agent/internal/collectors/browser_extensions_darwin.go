@@ -0,0 +1,22 @@
+//go:build darwin
+
+package collectors
+
+func browserUserHomes() []browserUserHome {
+	return listUserHomes("/Users", "Shared", "Guest", ".localized")
+}
+
+var chromiumUserDataDirs = []chromiumUserDataDir{
+	{Browser: browserChrome, RelPath: "Library/Application Support/Google/Chrome"},
+	{Browser: browserEdge, RelPath: "Library/Application Support/Microsoft Edge"},
+	{Browser: browserChromium, RelPath: "Library/Application Support/Chromium"},
+	{Browser: browserBrave, RelPath: "Library/Application Support/BraveSoftware/Brave-Browser"},
+}
+
+const firefoxProfilesRelPath = "Library/Application Support/Firefox/Profiles"
+
+// Chromium policy on macOS lives in managed preferences (configuration
+// profiles), which are not JSON; only the Firefox distribution file is read.
+func machineBrowserExtensionPolicies() []BrowserExtension {
+	return policyFileExtensions(nil, []string{"/Applications/Firefox.app/Contents/Resources/distribution/policies.json"})
+}
@@ -0,0 +1,29 @@
+//go:build linux
+
+package collectors
+
+func browserUserHomes() []browserUserHome {
+	homes := listUserHomes("/home")
+	return append(homes, browserUserHome{User: "root", Dir: "/root"})
+}
+
+var chromiumUserDataDirs = []chromiumUserDataDir{
+	{Browser: browserChrome, RelPath: ".config/google-chrome"},
+	{Browser: browserEdge, RelPath: ".config/microsoft-edge"},
+	{Browser: browserChromium, RelPath: ".config/chromium"},
+	{Browser: browserBrave, RelPath: ".config/BraveSoftware/Brave-Browser"},
+}
+
+const firefoxProfilesRelPath = ".mozilla/firefox"
+
+func machineBrowserExtensionPolicies() []BrowserExtension {
+	return policyFileExtensions(
+		map[string][]string{
+			browserChrome:   {"/etc/opt/chrome/policies/managed"},
+			browserEdge:     {"/etc/opt/edge/policies/managed"},
+			browserChromium: {"/etc/chromium/policies/managed", "/etc/chromium-browser/policies/managed"},
+			browserBrave:    {"/etc/brave/policies/managed"},
+		},
+		[]string{"/etc/firefox/policies/policies.json", "/usr/lib/firefox/distribution/policies.json"},
+	)
+}
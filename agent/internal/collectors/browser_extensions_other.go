@@ -0,0 +1,17 @@
+//go:build !windows && !linux && !darwin
+
+package collectors
+
+func browserUserHomes() []browserUserHome {
+	return listUserHomes("/home")
+}
+
+var chromiumUserDataDirs = []chromiumUserDataDir{
+	{Browser: browserChromium, RelPath: ".config/chromium"},
+}
+
+const firefoxProfilesRelPath = ".mozilla/firefox"
+
+func machineBrowserExtensionPolicies() []BrowserExtension {
+	return nil
+}
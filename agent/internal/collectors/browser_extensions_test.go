@@ -0,0 +1,91 @@
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectUserBrowserExtensions(t *testing.T) {
+	home := t.TempDir()
+	userData := filepath.Join(home, "chrome-data")
+	ext := filepath.Join(userData, "Profile 1", "Extensions", "abcdefghijklmnopabcdefghijklmnop")
+
+	// Two installed versions: the newest (1.10.0, not 1.9.0) wins.
+	writeTestFile(t, filepath.Join(ext, "1.9.0_0", "manifest.json"), `{"name":"Old","version":"1.9.0"}`)
+	writeTestFile(t, filepath.Join(ext, "1.10.0_0", "manifest.json"), `{
+		"name": "__MSG_extName__", "version": "1.10.0", "default_locale": "en",
+		"permissions": ["tabs", "cookies", "https://*.example.com/*", {"socket": ["tcp-connect"]}],
+		"host_permissions": ["<all_urls>"],
+		"update_url": "https://clients2.google.com/service/update2/crx"}`)
+	writeTestFile(t, filepath.Join(ext, "1.10.0_0", "_locales", "en", "messages.json"), `{"EXTNAME":{"message":"Coupon Helper"}}`)
+	writeTestFile(t, filepath.Join(userData, "Profile 1", "Secure Preferences"), `{"extensions":{"settings":{"abcdefghijklmnopabcdefghijklmnop":{"state":0}}}}`)
+
+	writeTestFile(t, filepath.Join(home, "ff", "x1y2.default-release", "extensions.json"), `{"addons":[
+		{"id":"uBlock0@raymondhill.net","version":"1.58.0","type":"extension","active":true,"location":"app-profile",
+		 "defaultLocale":{"name":"uBlock Origin"},"userPermissions":{"permissions":["storage"],"origins":["<all_urls>"]}},
+		{"id":"formautofill@mozilla.org","version":"1.0","type":"extension","active":true,"location":"app-builtin"},
+		{"id":"langpack-de@firefox.mozilla.org","version":"1.0","type":"locale","active":true,"location":"app-profile"}]}`)
+
+	exts := collectUserBrowserExtensions(
+		[]browserUserHome{{User: "alice", Dir: home}},
+		[]chromiumUserDataDir{{Browser: browserChrome, RelPath: "chrome-data"}},
+		"ff",
+	)
+	if len(exts) != 2 {
+		t.Fatalf("got %d extensions, want 2: %+v", len(exts), exts)
+	}
+
+	chrome := exts[0]
+	if chrome.Name != "Coupon Helper" || chrome.Version != "1.10.0" || chrome.Profile != "Profile 1" || chrome.User != "alice" {
+		t.Errorf("chrome identity = %+v", chrome)
+	}
+	if !slices.Equal(chrome.Permissions, []string{"tabs", "cookies"}) || !slices.Equal(chrome.HostPermissions, []string{"https://*.example.com/*", "<all_urls>"}) {
+		t.Errorf("chrome permissions = %v / %v", chrome.Permissions, chrome.HostPermissions)
+	}
+	if chrome.Enabled == nil || *chrome.Enabled {
+		t.Errorf("Secure Preferences state 0 should report disabled, got %v", chrome.Enabled)
+	}
+
+	ff := exts[1]
+	if ff.Browser != browserFirefox || ff.ID != "uBlock0@raymondhill.net" || ff.Enabled == nil || !*ff.Enabled || !slices.Equal(ff.HostPermissions, []string{"<all_urls>"}) {
+		t.Errorf("firefox extension = %+v", ff)
+	}
+}
+
+func TestBrowserExtensionPolicies(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "chrome", "corp.json"), `{"ExtensionInstallForcelist":["aaaabbbbccccddddaaaabbbbccccdddd;https://clients2.google.com/service/update2/crx","  "]}`)
+	writeTestFile(t, filepath.Join(dir, "policies.json"), `{"policies":{"ExtensionSettings":{
+		"*":{"installation_mode":"blocked"},
+		"agent@corp.example":{"installation_mode":"force_installed","install_url":"https://corp.example/agent.xpi"},
+		"blocked@bad.example":{"installation_mode":"blocked"}}}}`)
+
+	exts := policyFileExtensions(map[string][]string{browserChrome: {filepath.Join(dir, "chrome")}}, []string{filepath.Join(dir, "policies.json"), filepath.Join(dir, "missing.json")})
+	if len(exts) != 2 {
+		t.Fatalf("got %+v, want one chrome and one firefox policy extension", exts)
+	}
+	if exts[0].ID != "aaaabbbbccccddddaaaabbbbccccdddd" || exts[0].Scope != browserExtensionScopeMachine || exts[0].UpdateURL == "" {
+		t.Errorf("chrome forcelist = %+v", exts[0])
+	}
+	if exts[1].ID != "agent@corp.example" || exts[1].Browser != browserFirefox {
+		t.Errorf("firefox policy = %+v", exts[1])
+	}
+}
+
+func TestCompareDottedVersions(t *testing.T) {
+	if compareDottedVersions("1.10.0_0", "1.9.0_0") <= 0 || compareDottedVersions("2.0", "2.0.1") >= 0 || compareDottedVersions("3.1", "3.1") != 0 {
+		t.Error("numeric version ordering broken")
+	}
+}
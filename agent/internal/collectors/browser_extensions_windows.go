@@ -0,0 +1,63 @@
+//go:build windows
+
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+func browserUserHomes() []browserUserHome {
+	drive := os.Getenv("SystemDrive")
+	if drive == "" {
+		drive = "C:"
+	}
+	return listUserHomes(drive+`\Users`, "Public", "Default", "Default User", "All Users", "WDAGUtilityAccount")
+}
+
+var chromiumUserDataDirs = []chromiumUserDataDir{
+	{Browser: browserChrome, RelPath: `AppData\Local\Google\Chrome\User Data`},
+	{Browser: browserEdge, RelPath: `AppData\Local\Microsoft\Edge\User Data`},
+	{Browser: browserChromium, RelPath: `AppData\Local\Chromium\User Data`},
+	{Browser: browserBrave, RelPath: `AppData\Local\BraveSoftware\Brave-Browser\User Data`},
+}
+
+const firefoxProfilesRelPath = `AppData\Roaming\Mozilla\Firefox\Profiles`
+
+// chromiumPolicyKeys are the HKLM policy keys whose
+// ExtensionInstallForcelist subkey lists force-installed extensions.
+var chromiumPolicyKeys = map[string]string{
+	browserChrome:   `SOFTWARE\Policies\Google\Chrome`,
+	browserEdge:     `SOFTWARE\Policies\Microsoft\Edge`,
+	browserChromium: `SOFTWARE\Policies\Chromium`,
+	browserBrave:    `SOFTWARE\Policies\BraveSoftware\Brave`,
+}
+
+func machineBrowserExtensionPolicies() []BrowserExtension {
+	var exts []BrowserExtension
+	for browser, path := range chromiumPolicyKeys {
+		key, err := registry.OpenKey(registry.LOCAL_MACHINE, path+`\ExtensionInstallForcelist`, registry.QUERY_VALUE|registry.WOW64_64KEY)
+		if err != nil {
+			continue
+		}
+		names, _ := key.ReadValueNames(0)
+		for _, name := range names {
+			value, _, err := key.GetStringValue(name)
+			if err != nil {
+				continue
+			}
+			if ext, ok := parseChromiumForcelistEntry(browser, value); ok {
+				exts = append(exts, ext)
+			}
+		}
+		key.Close()
+	}
+
+	programFiles := os.Getenv("ProgramFiles")
+	if programFiles == "" {
+		programFiles = `C:\Program Files`
+	}
+	return append(exts, policyFileExtensions(nil, []string{filepath.Join(programFiles, "Mozilla Firefox", "distribution", "policies.json")})...)
+}
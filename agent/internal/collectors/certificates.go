@@ -0,0 +1,248 @@
+package collectors
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Machine certificate inventory. Reports the certificates in the machine
+// store (Windows LocalMachine, macOS System keychain, well-known Linux
+// VPN/802.1X paths) so expiring client certs can be alerted on centrally.
+// Only public certificate metadata is read; private keys are never touched
+// beyond noting that one is bound to the certificate.
+
+// maxCertificates caps one inventory so a store full of stale certs cannot
+// produce an unbounded payload.
+const maxCertificates = 500
+
+// maxCertificateFileSize skips anything under the Linux scan paths that is
+// too big to be a certificate file.
+const maxCertificateFileSize = 1 << 20
+
+// CertificateInfo is the agent-sent wire shape for one certificate.
+type CertificateInfo struct {
+	Store            string    `json:"store"`
+	Path             string    `json:"path,omitempty"`
+	Subject          string    `json:"subject"`
+	Issuer           string    `json:"issuer"`
+	SerialNumber     string    `json:"serialNumber"`
+	Thumbprint       string    `json:"thumbprint"`
+	NotBefore        time.Time `json:"notBefore"`
+	NotAfter         time.Time `json:"notAfter"`
+	KeyUsage         []string  `json:"keyUsage,omitempty"`
+	ExtendedKeyUsage []string  `json:"extendedKeyUsage,omitempty"`
+	DNSNames         []string  `json:"dnsNames,omitempty"`
+	IsCA             bool      `json:"isCA"`
+	SelfSigned       bool      `json:"selfSigned"`
+	HasPrivateKey    bool      `json:"hasPrivateKey"`
+}
+
+type CertificateCollector struct{}
+
+func NewCertificateCollector() *CertificateCollector {
+	return &CertificateCollector{}
+}
+
+// Collect returns the machine certificates, de-duplicated by store and
+// thumbprint and sorted by expiry (soonest first).
+func (c *CertificateCollector) Collect() ([]CertificateInfo, error) {
+	certs, err := collectMachineCertificates()
+	if err != nil {
+		return nil, err
+	}
+	return finalizeCertificates(certs), nil
+}
+
+func finalizeCertificates(certs []CertificateInfo) []CertificateInfo {
+	seen := make(map[string]bool, len(certs))
+	out := make([]CertificateInfo, 0, len(certs))
+	for _, cert := range certs {
+		key := cert.Store + "|" + cert.Thumbprint
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, cert)
+	}
+	slices.SortStableFunc(out, func(a, b CertificateInfo) int {
+		return a.NotAfter.Compare(b.NotAfter)
+	})
+	if len(out) > maxCertificates {
+		out = out[:maxCertificates]
+	}
+	return out
+}
+
+// certificateInfoFromX509 converts a parsed certificate into its wire shape.
+func certificateInfoFromX509(cert *x509.Certificate, store string) CertificateInfo {
+	sum := sha1.Sum(cert.Raw)
+	return CertificateInfo{
+		Store:            store,
+		Subject:          truncateCollectorString(cert.Subject.String()),
+		Issuer:           truncateCollectorString(cert.Issuer.String()),
+		SerialNumber:     strings.ToUpper(cert.SerialNumber.Text(16)),
+		Thumbprint:       strings.ToUpper(hex.EncodeToString(sum[:])),
+		NotBefore:        cert.NotBefore.UTC(),
+		NotAfter:         cert.NotAfter.UTC(),
+		KeyUsage:         keyUsageNames(cert.KeyUsage),
+		ExtendedKeyUsage: extKeyUsageNames(cert),
+		DNSNames:         cert.DNSNames,
+		IsCA:             cert.IsCA,
+		SelfSigned:       bytes.Equal(cert.RawSubject, cert.RawIssuer),
+	}
+}
+
+var keyUsageBits = []struct {
+	bit  x509.KeyUsage
+	name string
+}{
+	{x509.KeyUsageDigitalSignature, "digitalSignature"},
+	{x509.KeyUsageContentCommitment, "contentCommitment"},
+	{x509.KeyUsageKeyEncipherment, "keyEncipherment"},
+	{x509.KeyUsageDataEncipherment, "dataEncipherment"},
+	{x509.KeyUsageKeyAgreement, "keyAgreement"},
+	{x509.KeyUsageCertSign, "keyCertSign"},
+	{x509.KeyUsageCRLSign, "cRLSign"},
+	{x509.KeyUsageEncipherOnly, "encipherOnly"},
+	{x509.KeyUsageDecipherOnly, "decipherOnly"},
+}
+
+func keyUsageNames(usage x509.KeyUsage) []string {
+	var names []string
+	for _, ku := range keyUsageBits {
+		if usage&ku.bit != 0 {
+			names = append(names, ku.name)
+		}
+	}
+	return names
+}
+
+var extKeyUsageNamesByID = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:             "any",
+	x509.ExtKeyUsageServerAuth:      "serverAuth",
+	x509.ExtKeyUsageClientAuth:      "clientAuth",
+	x509.ExtKeyUsageCodeSigning:     "codeSigning",
+	x509.ExtKeyUsageEmailProtection: "emailProtection",
+	x509.ExtKeyUsageIPSECEndSystem:  "ipsecEndSystem",
+	x509.ExtKeyUsageIPSECTunnel:     "ipsecTunnel",
+	x509.ExtKeyUsageIPSECUser:       "ipsecUser",
+	x509.ExtKeyUsageTimeStamping:    "timeStamping",
+	x509.ExtKeyUsageOCSPSigning:     "OCSPSigning",
+}
+
+// extKeyUsageNames lists known EKUs by name and unknown ones by OID, so
+// vendor-specific usages (e.g. smart-card logon) still reach the server.
+func extKeyUsageNames(cert *x509.Certificate) []string {
+	var names []string
+	for _, eku := range cert.ExtKeyUsage {
+		if name, ok := extKeyUsageNamesByID[eku]; ok {
+			names = append(names, name)
+		}
+	}
+	for _, oid := range cert.UnknownExtKeyUsage {
+		names = append(names, oid.String())
+	}
+	return names
+}
+
+// parseCertificateBytes extracts every certificate from PEM data, or from
+// data that is a single DER certificate. Unparseable blocks are skipped.
+func parseCertificateBytes(data []byte, store string) []CertificateInfo {
+	var out []CertificateInfo
+	rest := data
+	sawPEM := false
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		sawPEM = true
+		if block.Type != "CERTIFICATE" && block.Type != "TRUSTED CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			out = append(out, certificateInfoFromX509(cert, store))
+		}
+	}
+	if !sawPEM {
+		if cert, err := x509.ParseCertificate(data); err == nil {
+			out = append(out, certificateInfoFromX509(cert, store))
+		}
+	}
+	return out
+}
+
+// certificateFileExtensions are the file types scanned under Linux paths.
+var certificateFileExtensions = []string{".pem", ".crt", ".cer", ".der"}
+
+// scanCertificateDirs walks each root (depth-limited) and parses every
+// certificate file. Bundle files and symlinks are skipped: distro CA bundles
+// hold hundreds of public roots that are not machine certificates.
+func scanCertificateDirs(roots []string, store string) []CertificateInfo {
+	var out []CertificateInfo
+	for _, root := range roots {
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if rel, relErr := filepath.Rel(root, path); relErr == nil && strings.Count(rel, string(filepath.Separator)) >= 2 {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || len(out) >= maxCertificates {
+				return nil
+			}
+			name := strings.ToLower(d.Name())
+			if !slices.Contains(certificateFileExtensions, filepath.Ext(name)) || strings.HasPrefix(name, "ca-bundle") || strings.HasPrefix(name, "ca-certificates") {
+				return nil
+			}
+			if info, infoErr := d.Info(); infoErr != nil || info.Size() > maxCertificateFileSize {
+				return nil
+			}
+			data, readErr := os.ReadFile(path)
+			if readErr != nil {
+				return nil
+			}
+			// A combined cert+key PEM is the only private-key signal on disk.
+			hasKey := bytes.Contains(data, []byte("PRIVATE KEY-----"))
+			for _, cert := range parseCertificateBytes(data, store) {
+				cert.Path = path
+				cert.HasPrivateKey = hasKey
+				out = append(out, cert)
+			}
+			return nil
+		})
+	}
+	return out
+}
+
+// parseSecurityIdentityHashes returns the SHA-1 hashes listed by
+// `security find-identity`, i.e. the keychain certificates that have a
+// matching private key. Lines look like `1) 4E2B...9C1F "Wi-Fi Machine Cert"`.
+func parseSecurityIdentityHashes(output []byte) map[string]bool {
+	hashes := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasSuffix(fields[0], ")") {
+			continue
+		}
+		if hash := strings.ToUpper(fields[1]); len(hash) == 40 {
+			if _, err := hex.DecodeString(hash); err == nil {
+				hashes[hash] = true
+			}
+		}
+	}
+	return hashes
+}
@@ -0,0 +1,24 @@
+//go:build darwin
+
+package collectors
+
+import "fmt"
+
+const systemKeychainPath = "/Library/Keychains/System.keychain"
+
+func collectMachineCertificates() ([]CertificateInfo, error) {
+	out, err := runCollectorOutput(collectorLongCommandTimeout, "security", "find-certificate", "-a", "-p", systemKeychainPath)
+	if err != nil {
+		return nil, fmt.Errorf("security find-certificate failed: %w", err)
+	}
+	certs := parseCertificateBytes(out, "System")
+
+	// Best-effort: without identities every cert just reports no private key.
+	if idOut, idErr := runCollectorOutput(collectorShortCommandTimeout, "security", "find-identity", systemKeychainPath); idErr == nil {
+		identities := parseSecurityIdentityHashes(idOut)
+		for i := range certs {
+			certs[i].HasPrivateKey = identities[certs[i].Thumbprint]
+		}
+	}
+	return certs, nil
+}
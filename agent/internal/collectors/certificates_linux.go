@@ -0,0 +1,21 @@
+//go:build linux
+
+package collectors
+
+// linuxCertificateDirs are where VPN and 802.1X clients conventionally keep
+// machine certificates. /etc/ssl/certs is deliberately absent: it is the
+// distro trust store, not machine identity.
+var linuxCertificateDirs = []string{
+	"/etc/pki/tls/certs",
+	"/etc/ssl/private",
+	"/etc/ipsec.d/certs",
+	"/etc/strongswan/ipsec.d/certs",
+	"/etc/swanctl/x509",
+	"/etc/openvpn",
+	"/etc/wpa_supplicant",
+	"/etc/NetworkManager/certs",
+}
+
+func collectMachineCertificates() ([]CertificateInfo, error) {
+	return scanCertificateDirs(linuxCertificateDirs, "filesystem"), nil
+}
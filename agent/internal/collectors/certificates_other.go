@@ -0,0 +1,7 @@
+//go:build !windows && !linux && !darwin
+
+package collectors
+
+func collectMachineCertificates() ([]CertificateInfo, error) {
+	return nil, nil
+}
@@ -0,0 +1,47 @@
+//go:build windows
+
+package collectors
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+)
+
+// windowsCertificateRow is one certificate from a LocalMachine store; the
+// DER bytes are parsed in Go so all platforms share one field mapping.
+type windowsCertificateRow struct {
+	Store         string `json:"store"`
+	HasPrivateKey bool   `json:"hasPrivateKey"`
+	RawData       string `json:"rawData"`
+}
+
+// The personal (My), Remote Desktop and WebHosting stores hold the machine's
+// own identity certificates; the root/intermediate stores are trust anchors
+// and are not inventoried.
+const windowsCertificateScript = `@('My','Remote Desktop','WebHosting') | ForEach-Object { $s = $_; Get-ChildItem -Path ("Cert:\LocalMachine\" + $s) -ErrorAction SilentlyContinue | Where-Object { $_ -is [System.Security.Cryptography.X509Certificates.X509Certificate2] } | ForEach-Object { [pscustomobject]@{ store = $s; hasPrivateKey = $_.HasPrivateKey; rawData = [Convert]::ToBase64String($_.RawData) } } } | ConvertTo-Json -Compress`
+
+func collectMachineCertificates() ([]CertificateInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectorLongCommandTimeout)
+	defer cancel()
+
+	rows, err := runWindowsJSON[windowsCertificateRow](ctx, utf8PowerShellCommand(windowsCertificateScript))
+	if err != nil {
+		return nil, err
+	}
+	certs := make([]CertificateInfo, 0, len(rows))
+	for _, row := range rows {
+		der, decErr := base64.StdEncoding.DecodeString(row.RawData)
+		if decErr != nil {
+			continue
+		}
+		cert, parseErr := x509.ParseCertificate(der)
+		if parseErr != nil {
+			continue
+		}
+		info := certificateInfoFromX509(cert, "LocalMachine\\"+row.Store)
+		info.HasPrivateKey = row.HasPrivateKey
+		certs = append(certs, info)
+	}
+	return certs, nil
+}
@@ -26,8 +26,13 @@ const (
 	ChangeTypeUserAccount ChangeType = "user_account"
 	ChangeTypeHardware    ChangeType = "hardware"
 	ChangeTypeOS          ChangeType = "os_version"
+	ChangeTypeUSBDevice   ChangeType = "usb_device"
 )
 
+// ChangeTypeDefenderPolicy marks a Microsoft Defender preference (exclusion,
+// ASR rule, cloud protection level) changed by a console command.
+const ChangeTypeDefenderPolicy ChangeType = "defender_policy"
+
 // ChangeAction represents the type of detected change.
 type ChangeAction string
 
@@ -38,6 +43,13 @@ const (
 	ChangeActionUpdated  ChangeAction = "updated"
 )
 
+// ChangePriority flags changes that need attention before the next review.
+type ChangePriority string
+
+// ChangePriorityHigh marks physical tampering indicators: memory removed, a
+// disk removed or swapped, the TPM gone, a new USB mass-storage device.
+const ChangePriorityHigh ChangePriority = "high"
+
 // ChangeRecord represents a single detected change.
 type ChangeRecord struct {
 	Timestamp    time.Time      `json:"timestamp"`
@@ -47,6 +59,7 @@ type ChangeRecord struct {
 	BeforeValue  map[string]any `json:"beforeValue,omitempty"`
 	AfterValue   map[string]any `json:"afterValue,omitempty"`
 	Details      map[string]any `json:"details,omitempty"`
+	Priority     ChangePriority `json:"priority,omitempty"`
 }
 
 // TrackedStartupItem captures stable startup metadata for change detection.
@@ -75,14 +88,20 @@ type TrackedUserAccount struct {
 }
 
 // HardwareState is the subset of hardware inventory the change tracker diffs.
+// DiskSerials, TPMPresent and MACAddresses are nil when they could not be
+// collected (and in snapshots written by older agents) and are then not
+// diffed.
 type HardwareState struct {
-	RAMTotalMB   uint64 `json:"ramTotalMb"`
-	CPUModel     string `json:"cpuModel"`
-	CPUCores     int    `json:"cpuCores"`
-	DiskTotalGB  uint64 `json:"diskTotalGb"`
-	BIOSVersion  string `json:"biosVersion"`
-	SerialNumber string `json:"serialNumber"`
-	Motherboard  string `json:"motherboard"`
+	RAMTotalMB   uint64   `json:"ramTotalMb"`
+	CPUModel     string   `json:"cpuModel"`
+	CPUCores     int      `json:"cpuCores"`
+	DiskTotalGB  uint64   `json:"diskTotalGb"`
+	BIOSVersion  string   `json:"biosVersion"`
+	SerialNumber string   `json:"serialNumber"`
+	Motherboard  string   `json:"motherboard"`
+	DiskSerials  []string `json:"diskSerials,omitempty"`
+	TPMPresent   *bool    `json:"tpmPresent,omitempty"`
+	MACAddresses []string `json:"macAddresses,omitempty"`
 }
 
 // SystemState is the OS identity the change tracker diffs.
@@ -102,6 +121,10 @@ type Snapshot struct {
 	UserAccounts    map[string]TrackedUserAccount   `json:"userAccounts"`
 	Hardware        *HardwareState                  `json:"hardware,omitempty"`
 	System          *SystemState                    `json:"system,omitempty"`
+	// USBDevices is nil in snapshots written by older agents (and when USB
+	// enumeration has never succeeded); it is then not diffed, so an upgrade
+	// doesn't report every attached device as newly connected.
+	USBDevices map[string]TrackedUSBDevice `json:"usbDevices,omitempty"`
 }
 
 // ChangeTrackerCollector tracks changes in system configuration.
@@ -109,6 +132,8 @@ type ChangeTrackerCollector struct {
 	snapshotPath     string
 	lastSnapshot     *Snapshot
 	gatherSnapshot   func() (*Snapshot, error)
+	gatherHardware   func() (*HardwareState, error)
+	gatherUSB        func() ([]USBDevice, error)
 	now              func() time.Time
 	collectorTimeout time.Duration
 	ignoreRules      []changeIgnoreRule
@@ -178,6 +203,7 @@ func (c *ChangeTrackerCollector) CollectChanges() ([]ChangeRecord, error) {
 	changes = append(changes, c.diffUserAccounts(currentSnapshot)...)
 	changes = append(changes, c.diffHardware(currentSnapshot)...)
 	changes = append(changes, c.diffOS(currentSnapshot)...)
+	changes = append(changes, c.diffUSBDevices(currentSnapshot)...)
 	changes = c.filterNoise(changes)
 
 	c.lastSnapshot = currentSnapshot
@@ -187,6 +213,61 @@ func (c *ChangeTrackerCollector) CollectChanges() ([]ChangeRecord, error) {
 	return changes, nil
 }
 
+// CollectHardwareChanges diffs only the hardware state against the last
+// snapshot. It is cheap enough to run every few minutes, so a pulled DIMM or
+// swapped disk is reported right after the reboot instead of waiting for the
+// next full CollectChanges cycle, which then sees the hardware as unchanged.
+// Returns nothing until a full baseline snapshot exists.
+func (c *ChangeTrackerCollector) CollectHardwareChanges() ([]ChangeRecord, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lastSnapshot == nil {
+		if err := c.loadSnapshot(); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("change tracker snapshot corrupt, resetting baseline", "error", err.Error())
+			c.lastSnapshot = nil
+		}
+	}
+	if c.lastSnapshot == nil {
+		return nil, nil
+	}
+
+	gather := c.gatherHardware
+	if gather == nil {
+		hwCollector := NewHardwareCollector()
+		gather = func() (*HardwareState, error) {
+			return collectWithTimeout(context.Background(), c.collectorTimeout, func(_ context.Context) (*HardwareState, error) {
+				return collectHardwareState(hwCollector)
+			})
+		}
+	}
+	current, err := gather()
+	if err != nil {
+		return nil, fmt.Errorf("collect hardware state: %w", err)
+	}
+	if current == nil {
+		return nil, nil
+	}
+	prev := c.lastSnapshot.Hardware
+	carryHardwareIdentity(current, prev)
+
+	changes := c.filterNoise(diffHardwareState(prev, current, c.now()))
+	// Persist when something changed or a field is seen for the first time
+	// (e.g. the first run after an upgrade that added the identity fields).
+	seeded := prev == nil ||
+		(prev.DiskSerials == nil && current.DiskSerials != nil) ||
+		(prev.TPMPresent == nil && current.TPMPresent != nil) ||
+		(prev.MACAddresses == nil && current.MACAddresses != nil)
+	c.lastSnapshot.Hardware = current
+	if len(changes) == 0 && !seeded {
+		return nil, nil
+	}
+	if err := c.saveSnapshot(); err != nil {
+		return changes, err
+	}
+	return changes, nil
+}
+
 // initialInventory generates "added" records for every item in the baseline
 // snapshot so the API has full visibility into the device's initial state.
 //
@@ -285,6 +366,16 @@ func (c *ChangeTrackerCollector) initialInventory(snap *Snapshot) []ChangeRecord
 		})
 	}
 
+	for _, dev := range snap.USBDevices {
+		records = append(records, ChangeRecord{
+			Timestamp:    now,
+			ChangeType:   ChangeTypeUSBDevice,
+			ChangeAction: ChangeActionAdded,
+			Subject:      usbSubject(dev),
+			AfterValue:   usbDeviceValue(dev),
+		})
+	}
+
 	return c.filterNoise(records)
 }
 
@@ -315,7 +406,8 @@ func (c *ChangeTrackerCollector) gatherCurrentSnapshot() (*Snapshot, error) {
 		startupItems   []TrackedStartupItem
 		scheduledTasks []TrackedScheduledTask
 		userAccounts   []TrackedUserAccount
-		hardware       *HardwareInfo
+		usbDevices     []USBDevice
+		hardware       *HardwareState
 		systemInfo     *SystemInfo
 
 		softwareErr       error
@@ -324,6 +416,7 @@ func (c *ChangeTrackerCollector) gatherCurrentSnapshot() (*Snapshot, error) {
 		startupItemsErr   error
 		scheduledTasksErr error
 		userAccountsErr   error
+		usbDevicesErr     error
 		hardwareErr       error
 		systemInfoErr     error
 	)
@@ -335,8 +428,13 @@ func (c *ChangeTrackerCollector) gatherCurrentSnapshot() (*Snapshot, error) {
 
 	ctx := context.Background()
 
+	gatherUSB := c.gatherUSB
+	if gatherUSB == nil {
+		gatherUSB = collectUSBDevices
+	}
+
 	var wg sync.WaitGroup
-	wg.Add(7)
+	wg.Add(8)
 	go func() {
 		defer wg.Done()
 		software, softwareErr = collectWithTimeout(ctx, c.collectorTimeout, func(_ context.Context) ([]SoftwareItem, error) {
@@ -369,8 +467,14 @@ func (c *ChangeTrackerCollector) gatherCurrentSnapshot() (*Snapshot, error) {
 	}()
 	go func() {
 		defer wg.Done()
-		hardware, hardwareErr = collectWithTimeout(ctx, c.collectorTimeout, func(_ context.Context) (*HardwareInfo, error) {
-			return hwCollector.CollectHardware()
+		usbDevices, usbDevicesErr = collectWithTimeout(ctx, c.collectorTimeout, func(_ context.Context) ([]USBDevice, error) {
+			return gatherUSB()
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		hardware, hardwareErr = collectWithTimeout(ctx, c.collectorTimeout, func(_ context.Context) (*HardwareState, error) {
+			return collectHardwareState(hwCollector)
 		})
 		systemInfo, systemInfoErr = collectWithTimeout(ctx, c.collectorTimeout, func(_ context.Context) (*SystemInfo, error) {
 			return hwCollector.CollectSystemInfo()
@@ -454,6 +558,18 @@ func (c *ChangeTrackerCollector) gatherCurrentSnapshot() (*Snapshot, error) {
 		}
 	}
 
+	if usbDevicesErr != nil {
+		slog.Warn("usb device collection failed, using previous snapshot", "error", usbDevicesErr.Error())
+		if c.lastSnapshot != nil {
+			snapshot.USBDevices = maps.Clone(c.lastSnapshot.USBDevices)
+		}
+	} else {
+		snapshot.USBDevices = make(map[string]TrackedUSBDevice, len(usbDevices))
+		for _, dev := range usbDevices {
+			snapshot.USBDevices[usbDeviceKey(dev)] = trackedUSBDevice(dev)
+		}
+	}
+
 	if hardwareErr != nil || hardware == nil {
 		if hardwareErr != nil {
 			slog.Warn("hardware collection failed, using previous snapshot", "error", hardwareErr.Error())
@@ -462,15 +578,10 @@ func (c *ChangeTrackerCollector) gatherCurrentSnapshot() (*Snapshot, error) {
 			snapshot.Hardware = c.lastSnapshot.Hardware
 		}
 	} else {
-		snapshot.Hardware = &HardwareState{
-			RAMTotalMB:   hardware.RAMTotalMB,
-			CPUModel:     hardware.CPUModel,
-			CPUCores:     hardware.CPUCores,
-			DiskTotalGB:  hardware.DiskTotalGB,
-			BIOSVersion:  hardware.BIOSVersion,
-			SerialNumber: hardware.SerialNumber,
-			Motherboard:  strings.TrimSpace(hardware.MotherboardManufacturer + " " + hardware.MotherboardProduct),
+		if c.lastSnapshot != nil {
+			carryHardwareIdentity(hardware, c.lastSnapshot.Hardware)
 		}
+		snapshot.Hardware = hardware
 	}
 
 	if systemInfoErr != nil || systemInfo == nil {
@@ -488,6 +599,46 @@ func (c *ChangeTrackerCollector) gatherCurrentSnapshot() (*Snapshot, error) {
 	return snapshot, nil
 }
 
+// collectHardwareState reads the diffed hardware fields plus the tamper
+// identity (disk serials, TPM, MACs).
+func collectHardwareState(hwCollector *HardwareCollector) (*HardwareState, error) {
+	hardware, err := hwCollector.CollectHardware()
+	if err != nil || hardware == nil {
+		return nil, err
+	}
+	id := CollectHardwareIdentity()
+	return &HardwareState{
+		RAMTotalMB:   hardware.RAMTotalMB,
+		CPUModel:     hardware.CPUModel,
+		CPUCores:     hardware.CPUCores,
+		DiskTotalGB:  hardware.DiskTotalGB,
+		BIOSVersion:  hardware.BIOSVersion,
+		SerialNumber: hardware.SerialNumber,
+		Motherboard:  strings.TrimSpace(hardware.MotherboardManufacturer + " " + hardware.MotherboardProduct),
+		DiskSerials:  id.DiskSerials,
+		TPMPresent:   id.TPMPresent,
+		MACAddresses: id.MACAddresses,
+	}, nil
+}
+
+// carryHardwareIdentity keeps the previous identity fields when this pass
+// could not collect them, so a later successful pass is still diffed against
+// the last known disks/TPM/MACs rather than silently re-baselining.
+func carryHardwareIdentity(cur, prev *HardwareState) {
+	if cur == nil || prev == nil {
+		return
+	}
+	if cur.DiskSerials == nil {
+		cur.DiskSerials = prev.DiskSerials
+	}
+	if cur.TPMPresent == nil {
+		cur.TPMPresent = prev.TPMPresent
+	}
+	if cur.MACAddresses == nil {
+		cur.MACAddresses = prev.MACAddresses
+	}
+}
+
 func collectWithTimeout[T any](parent context.Context, timeout time.Duration, collect func(ctx context.Context) (T, error)) (T, error) {
 	if timeout <= 0 {
 		timeout = 8 * time.Second
@@ -985,10 +1136,11 @@ func (c *ChangeTrackerCollector) diffUserAccounts(current *Snapshot) []ChangeRec
 // either snapshot's Hardware is nil (first-run seeding or collection
 // unavailable): we never want to emit "changed from nothing".
 func (c *ChangeTrackerCollector) diffHardware(current *Snapshot) []ChangeRecord {
-	now := c.now()
+	return diffHardwareState(c.lastSnapshot.Hardware, current.Hardware, c.now())
+}
+
+func diffHardwareState(prev, cur *HardwareState, now time.Time) []ChangeRecord {
 	changes := make([]ChangeRecord, 0)
-	prev := c.lastSnapshot.Hardware
-	cur := current.Hardware
 	if prev == nil || cur == nil {
 		return nil // first-run seed or unavailable: no events
 	}
@@ -1006,11 +1158,16 @@ func (c *ChangeTrackerCollector) diffHardware(current *Snapshot) []ChangeRecord
 		// Emit as rounded GB strings ("4 GB → 8 GB") rather than raw MB.
 		prevGB := (prev.RAMTotalMB + 512) / 1024
 		curGB := (cur.RAMTotalMB + 512) / 1024
-		changes = append(changes, ChangeRecord{
+		record := ChangeRecord{
 			Timestamp: now, ChangeType: ChangeTypeHardware, ChangeAction: ChangeActionModified, Subject: "Memory",
 			BeforeValue: map[string]any{"value": fmt.Sprintf("%d GB", prevGB)},
 			AfterValue:  map[string]any{"value": fmt.Sprintf("%d GB", curGB)},
-		})
+		}
+		// Less memory than before means a DIMM was pulled (or failed).
+		if cur.RAMTotalMB < prev.RAMTotalMB {
+			record.Priority = ChangePriorityHigh
+		}
+		changes = append(changes, record)
 	}
 	if prev.CPUModel != cur.CPUModel || prev.CPUCores != cur.CPUCores {
 		changes = append(changes, ChangeRecord{
@@ -1039,10 +1196,87 @@ func (c *ChangeTrackerCollector) diffHardware(current *Snapshot) []ChangeRecord
 	if prev.Motherboard != cur.Motherboard {
 		emit("Motherboard", prev.Motherboard, cur.Motherboard)
 	}
+	changes = append(changes, diffHardwareIdentity(prev, cur, now)...)
 
 	return changes
 }
 
+// diffHardwareIdentity reports disks by serial, TPM presence and the MAC set.
+// A removed disk or TPM is a high-priority tampering indicator; a swapped
+// disk shows up as one removed plus one added serial. MAC changes stay normal
+// priority since docks and USB adapters legitimately come and go.
+func diffHardwareIdentity(prev, cur *HardwareState, now time.Time) []ChangeRecord {
+	var changes []ChangeRecord
+	if prev.DiskSerials != nil && cur.DiskSerials != nil {
+		removed, added := diffStringSets(prev.DiskSerials, cur.DiskSerials)
+		for _, serial := range removed {
+			changes = append(changes, ChangeRecord{
+				Timestamp: now, ChangeType: ChangeTypeHardware, ChangeAction: ChangeActionRemoved,
+				Subject:     "Disk " + serial,
+				BeforeValue: map[string]any{"serial": serial},
+				Priority:    ChangePriorityHigh,
+			})
+		}
+		for _, serial := range added {
+			record := ChangeRecord{
+				Timestamp: now, ChangeType: ChangeTypeHardware, ChangeAction: ChangeActionAdded,
+				Subject:    "Disk " + serial,
+				AfterValue: map[string]any{"serial": serial},
+			}
+			if len(removed) > 0 {
+				record.Priority = ChangePriorityHigh
+				record.Details = map[string]any{"replaces": removed}
+			}
+			changes = append(changes, record)
+		}
+	}
+	if prev.TPMPresent != nil && cur.TPMPresent != nil && *prev.TPMPresent != *cur.TPMPresent {
+		record := ChangeRecord{
+			Timestamp: now, ChangeType: ChangeTypeHardware, ChangeAction: ChangeActionAdded, Subject: "TPM",
+			BeforeValue: map[string]any{"present": *prev.TPMPresent},
+			AfterValue:  map[string]any{"present": *cur.TPMPresent},
+		}
+		if !*cur.TPMPresent {
+			record.ChangeAction = ChangeActionRemoved
+			record.Priority = ChangePriorityHigh
+		}
+		changes = append(changes, record)
+	}
+	if prev.MACAddresses != nil && cur.MACAddresses != nil {
+		removed, added := diffStringSets(prev.MACAddresses, cur.MACAddresses)
+		if len(removed) > 0 || len(added) > 0 {
+			changes = append(changes, ChangeRecord{
+				Timestamp: now, ChangeType: ChangeTypeHardware, ChangeAction: ChangeActionModified, Subject: "Network Hardware",
+				BeforeValue: map[string]any{"macAddresses": prev.MACAddresses},
+				AfterValue:  map[string]any{"macAddresses": cur.MACAddresses},
+				Details:     map[string]any{"removed": removed, "added": added},
+			})
+		}
+	}
+	return changes
+}
+
+// diffStringSets returns the values only in before and only in after.
+func diffStringSets(before, after []string) (removed, added []string) {
+	beforeSet := make(map[string]struct{}, len(before))
+	for _, v := range before {
+		beforeSet[v] = struct{}{}
+	}
+	afterSet := make(map[string]struct{}, len(after))
+	for _, v := range after {
+		afterSet[v] = struct{}{}
+		if _, ok := beforeSet[v]; !ok {
+			added = append(added, v)
+		}
+	}
+	for _, v := range before {
+		if _, ok := afterSet[v]; !ok {
+			removed = append(removed, v)
+		}
+	}
+	return removed, added
+}
+
 // diffOS compares the current OS identity against the previous snapshot.
 // Returns no records when either snapshot's System is nil (first-run seeding
 // or collection unavailable), or when the OS version is unchanged.
@@ -1061,6 +1295,61 @@ func (c *ChangeTrackerCollector) diffOS(current *Snapshot) []ChangeRecord {
 	}}
 }
 
+// diffUSBDevices reports USB devices connected or disconnected since the
+// previous snapshot. A newly connected mass-storage device is high priority
+// so device-control policies can alert on it.
+func (c *ChangeTrackerCollector) diffUSBDevices(current *Snapshot) []ChangeRecord {
+	if c.lastSnapshot.USBDevices == nil || current.USBDevices == nil {
+		return nil
+	}
+	now := c.now()
+	changes := make([]ChangeRecord, 0)
+
+	for key, dev := range current.USBDevices {
+		if _, existed := c.lastSnapshot.USBDevices[key]; existed {
+			continue
+		}
+		record := ChangeRecord{
+			Timestamp:    now,
+			ChangeType:   ChangeTypeUSBDevice,
+			ChangeAction: ChangeActionAdded,
+			Subject:      usbSubject(dev),
+			AfterValue:   usbDeviceValue(dev),
+		}
+		if dev.MassStorage {
+			record.Priority = ChangePriorityHigh
+		}
+		changes = append(changes, record)
+	}
+
+	for key, dev := range c.lastSnapshot.USBDevices {
+		if _, exists := current.USBDevices[key]; exists {
+			continue
+		}
+		changes = append(changes, ChangeRecord{
+			Timestamp:    now,
+			ChangeType:   ChangeTypeUSBDevice,
+			ChangeAction: ChangeActionRemoved,
+			Subject:      usbSubject(dev),
+			BeforeValue:  usbDeviceValue(dev),
+		})
+	}
+
+	return changes
+}
+
+func usbDeviceValue(dev TrackedUSBDevice) map[string]any {
+	return map[string]any{
+		"vendorId":     dev.VendorID,
+		"productId":    dev.ProductID,
+		"vendor":       dev.Vendor,
+		"product":      dev.Product,
+		"serialNumber": dev.SerialNumber,
+		"class":        dev.Class,
+		"massStorage":  dev.MassStorage,
+	}
+}
+
 func (c *ChangeTrackerCollector) filterNoise(changes []ChangeRecord) []ChangeRecord {
 	if len(changes) == 0 || len(c.ignoreRules) == 0 {
 		return changes
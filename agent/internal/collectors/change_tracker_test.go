@@ -112,6 +112,87 @@ func TestChangeTrackerDetectsHardwareAndOSDrift(t *testing.T) {
 	expectChange(t, changes, ChangeTypeOS, ChangeActionUpdated, "Operating System")
 }
 
+func TestChangeTrackerHardwareTamperIndicators(t *testing.T) {
+	now := time.Date(2026, 4, 2, 8, 0, 0, 0, time.UTC)
+	prev := &HardwareState{
+		RAMTotalMB:   16384,
+		DiskSerials:  []string{"S4EWNX0R123456", "WD-WX12345678"},
+		TPMPresent:   boolPtr(true),
+		MACAddresses: []string{"00:1a:2b:3c:4d:5e"},
+	}
+	cur := &HardwareState{
+		RAMTotalMB:   8192,
+		DiskSerials:  []string{"S4EWNX0R123456", "ZA1234XY"},
+		TPMPresent:   boolPtr(false),
+		MACAddresses: []string{"00:1a:2b:3c:4d:5e", "3c:22:fb:00:11:22"},
+	}
+
+	changes := diffHardwareState(prev, cur, now)
+	high := map[string]bool{}
+	for _, ch := range changes {
+		if ch.Priority == ChangePriorityHigh {
+			high[string(ch.ChangeAction)+" "+ch.Subject] = true
+		}
+	}
+	for _, want := range []string{"modified Memory", "removed Disk WD-WX12345678", "added Disk ZA1234XY", "removed TPM"} {
+		if !high[want] {
+			t.Errorf("expected high-priority %q, got %+v", want, changes)
+		}
+	}
+	expectChange(t, changes, ChangeTypeHardware, ChangeActionModified, "Network Hardware")
+	if high["modified Network Hardware"] {
+		t.Error("MAC set changes should not be high priority")
+	}
+
+	// Identity not collected on either side: nothing to diff.
+	if got := diffHardwareState(&HardwareState{RAMTotalMB: 1}, &HardwareState{RAMTotalMB: 1, DiskSerials: []string{"X"}}, now); len(got) != 0 {
+		t.Fatalf("unknown previous identity must not emit records, got %+v", got)
+	}
+}
+
+func TestChangeTrackerCollectHardwareChanges(t *testing.T) {
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.json")
+	collector := NewChangeTrackerCollector(snapshotPath)
+
+	hardware := baselineSnapshot().Hardware
+	hardware.DiskSerials = []string{"DISK-A", "DISK-B"}
+	collector.gatherHardware = func() (*HardwareState, error) {
+		h := *hardware
+		return &h, nil
+	}
+	if changes, err := collector.CollectHardwareChanges(); err != nil || changes != nil {
+		t.Fatalf("no baseline yet: got %+v, %v", changes, err)
+	}
+
+	collector.gatherSnapshot = func() (*Snapshot, error) { return baselineSnapshot(), nil }
+	if _, err := collector.CollectChanges(); err != nil {
+		t.Fatal(err)
+	}
+	// The baseline snapshot has no identity fields; the first fast pass seeds
+	// them without emitting anything.
+	if changes, err := collector.CollectHardwareChanges(); err != nil || len(changes) != 0 {
+		t.Fatalf("seeding pass: got %+v, %v", changes, err)
+	}
+
+	hardware = baselineSnapshot().Hardware
+	hardware.DiskSerials = []string{"DISK-A"}
+	changes, err := collector.CollectHardwareChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Subject != "Disk DISK-B" || changes[0].Priority != ChangePriorityHigh {
+		t.Fatalf("expected high-priority disk removal, got %+v", changes)
+	}
+
+	reloaded := NewChangeTrackerCollector(snapshotPath)
+	if err := reloaded.loadSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.lastSnapshot.Hardware.DiskSerials; len(got) != 1 || got[0] != "DISK-A" {
+		t.Fatalf("persisted disk serials = %v, want [DISK-A]", got)
+	}
+}
+
 // TestChangeTrackerCollectChanges_RealGatherPathCapturesHardwareAndOS leaves
 // gatherSnapshot nil so gatherCurrentSnapshot() runs the real host collectors
 // (NewHardwareCollector) via the WaitGroup-fanned goroutines, rather than a
@@ -9,6 +9,8 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/breeze-rmm/agent/internal/throttle"
 )
 
 const (
@@ -30,18 +32,18 @@ func utf8PowerShellCommand(command string) string {
 }
 
 func runCollectorOutput(timeout time.Duration, name string, args ...string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	return runCollectorOutputWithContext(ctx, timeout, name, args...)
+	return runCollectorOutputWithContext(context.Background(), timeout, name, args...)
 }
 
+// Collector commands are background work: they wait for CPU budget before
+// the timeout starts and run at low priority (see package throttle).
 func runCollectorOutputWithContext(parent context.Context, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	throttle.Admit(parent)
 	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, name, args...)
-	output, err := cmd.Output()
+	output, err := throttle.Output(cmd)
 	if ctx.Err() != nil {
 		return nil, fmt.Errorf("%s timed out: %w", name, ctx.Err())
 	}
@@ -52,18 +54,16 @@ func runCollectorOutputWithContext(parent context.Context, timeout time.Duration
 }
 
 func runCollectorCombinedOutput(timeout time.Duration, name string, args ...string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	return runCollectorCombinedOutputWithContext(ctx, timeout, name, args...)
+	return runCollectorCombinedOutputWithContext(context.Background(), timeout, name, args...)
 }
 
 func runCollectorCombinedOutputWithContext(parent context.Context, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	throttle.Admit(parent)
 	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, name, args...)
-	output, err := cmd.CombinedOutput()
+	output, err := throttle.CombinedOutput(cmd)
 	if ctx.Err() != nil {
 		return nil, fmt.Errorf("%s timed out: %w", name, ctx.Err())
 	}
@@ -77,6 +77,7 @@ func runCollectorCombinedOutputWithContext(parent context.Context, timeout time.
 // bytes from stdout via a pipe, discarding the rest. Safe for line-based output
 // where truncation is acceptable (e.g. pmset -g log).
 func runCollectorLimitedOutput(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	throttle.Admit(context.Background())
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
@@ -85,7 +86,7 @@ func runCollectorLimitedOutput(timeout time.Duration, name string, args ...strin
 	if err != nil {
 		return nil, fmt.Errorf("%s pipe failed: %w", name, err)
 	}
-	if err := cmd.Start(); err != nil {
+	if err := throttle.Start(cmd); err != nil {
 		return nil, fmt.Errorf("%s start failed: %w", name, err)
 	}
 	output, err := io.ReadAll(io.LimitReader(stdout, int64(collectorCommandOutputLimit)))
@@ -94,7 +95,7 @@ func runCollectorLimitedOutput(timeout time.Duration, name string, args ...strin
 	}
 	// Drain any remaining output so the process can exit cleanly.
 	_, _ = io.Copy(io.Discard, stdout)
-	_ = cmd.Wait()
+	_ = throttle.Wait(cmd)
 	return output, nil
 }
 
@@ -125,6 +126,7 @@ func (c *cappedBuffer) Write(p []byte) (int, error) {
 // failures are diagnosable from agent logs. Use for structured output (e.g.
 // JSON) where a truncated payload would be garbage anyway.
 func runCollectorBoundedOutput(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	throttle.Admit(context.Background())
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
@@ -139,7 +141,7 @@ func runCollectorBoundedOutput(timeout time.Duration, name string, args ...strin
 	if err != nil {
 		return nil, fmt.Errorf("%s pipe failed: %w", name, err)
 	}
-	if err := cmd.Start(); err != nil {
+	if err := throttle.Start(cmd); err != nil {
 		return nil, fmt.Errorf("%s start failed: %w", name, err)
 	}
 	// Read one byte past the limit so over-limit output is detectable without
@@ -147,7 +149,7 @@ func runCollectorBoundedOutput(timeout time.Duration, name string, args ...strin
 	output, readErr := io.ReadAll(io.LimitReader(stdout, int64(collectorCommandOutputLimit)+1))
 	// Drain any remaining output so the process can exit and be reaped.
 	_, _ = io.Copy(io.Discard, stdout)
-	waitErr := cmd.Wait()
+	waitErr := throttle.Wait(cmd)
 	if ctx.Err() != nil {
 		return nil, fmt.Errorf("%s timed out: %w", name, ctx.Err())
 	}
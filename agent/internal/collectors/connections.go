@@ -1,5 +1,14 @@
 package collectors
 
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
 // ConnectionInfo represents an active network connection
 type ConnectionInfo struct {
 	Protocol    string `json:"protocol"`    // tcp, tcp6, udp, udp6
@@ -12,12 +21,147 @@ type ConnectionInfo struct {
 	ProcessName string `json:"processName"` // Process name (empty if unavailable)
 }
 
-// ConnectionsCollector collects active network connections
-type ConnectionsCollector struct{}
+// ProcessNetworkUsage is one process's traffic over the sampling interval.
+type ProcessNetworkUsage struct {
+	Pid           int    `json:"pid"`
+	ProcessName   string `json:"processName"`
+	BytesSent     uint64 `json:"bytesSent"`
+	BytesReceived uint64 `json:"bytesReceived"`
+}
+
+// ProcessNetworkReport is the per-process network accounting for the
+// interval since the previous CollectProcessUsage call, busiest first.
+type ProcessNetworkReport struct {
+	Source          string                `json:"source"`
+	IntervalSeconds float64               `json:"intervalSeconds"`
+	Processes       []ProcessNetworkUsage `json:"processes"`
+}
+
+// processNetFlow is one cumulative byte counter from a platform sampler.
+// Key identifies the counter across samples (a TCP socket tuple, or a
+// process); a key absent from the previous sample is new this interval, so
+// all of its bytes count.
+type processNetFlow struct {
+	key      string
+	pid      int
+	name     string
+	sent     uint64
+	received uint64
+}
+
+// processNetSampler reads cumulative per-process (or per-socket) byte
+// counters:
+//   - Linux: TCP socket counters from `ss -tinp` (sock_diag tcp_info);
+//     UDP is not accounted.
+//   - macOS: per-process totals from nettop.
+//   - Windows: a Microsoft-Windows-Kernel-Network ETW session summing
+//     TCP/UDP send and receive sizes per PID.
+type processNetSampler interface {
+	sample() ([]processNetFlow, error)
+	source() string
+	close()
+}
+
+// maxProcessNetworkEntries bounds the report to the busiest processes.
+const maxProcessNetworkEntries = 200
+
+// ConnectionsCollector collects active network connections and per-process
+// network usage.
+type ConnectionsCollector struct {
+	mu         sync.Mutex
+	sampler    processNetSampler
+	lastFlows  map[string]processNetFlow
+	lastSample time.Time
+	now        func() time.Time
+}
 
 // NewConnectionsCollector creates a new connections collector
 func NewConnectionsCollector() *ConnectionsCollector {
-	return &ConnectionsCollector{}
+	return &ConnectionsCollector{sampler: newProcessNetSampler(), now: time.Now}
+}
+
+// CollectProcessUsage returns bytes sent/received per process since the
+// previous call. The first call only records a baseline and returns nil, as
+// does a platform with no sampler.
+func (c *ConnectionsCollector) CollectProcessUsage() (*ProcessNetworkReport, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sampler == nil {
+		return nil, nil
+	}
+	flows, err := c.sampler.sample()
+	if err != nil {
+		return nil, err
+	}
+	now := c.now()
+
+	current := make(map[string]processNetFlow, len(flows))
+	for _, f := range flows {
+		current[f.key] = f
+	}
+	prev, prevAt := c.lastFlows, c.lastSample
+	c.lastFlows, c.lastSample = current, now
+	if prevAt.IsZero() {
+		return nil, nil
+	}
+
+	return &ProcessNetworkReport{
+		Source:          c.sampler.source(),
+		IntervalSeconds: now.Sub(prevAt).Seconds(),
+		Processes:       processNetworkDeltas(prev, current),
+	}, nil
+}
+
+// Close releases the platform sampler (the ETW session on Windows).
+func (c *ConnectionsCollector) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sampler != nil {
+		c.sampler.close()
+	}
+}
+
+// processNetworkDeltas sums per-key counter growth by PID. A counter that
+// went backwards (socket tuple or PID reused) is treated as new.
+func processNetworkDeltas(prev, current map[string]processNetFlow) []ProcessNetworkUsage {
+	byPid := make(map[int]*ProcessNetworkUsage)
+	for key, cur := range current {
+		sent, received := cur.sent, cur.received
+		if p, ok := prev[key]; ok && p.pid == cur.pid && cur.sent >= p.sent && cur.received >= p.received {
+			sent -= p.sent
+			received -= p.received
+		}
+		if sent == 0 && received == 0 {
+			continue
+		}
+		u := byPid[cur.pid]
+		if u == nil {
+			u = &ProcessNetworkUsage{Pid: cur.pid}
+			byPid[cur.pid] = u
+		}
+		if u.ProcessName == "" {
+			u.ProcessName = truncateCollectorString(cur.name)
+		}
+		u.BytesSent += sent
+		u.BytesReceived += received
+	}
+
+	usage := make([]ProcessNetworkUsage, 0, len(byPid))
+	for _, u := range byPid {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		ti := usage[i].BytesSent + usage[i].BytesReceived
+		tj := usage[j].BytesSent + usage[j].BytesReceived
+		if ti != tj {
+			return ti > tj
+		}
+		return usage[i].Pid < usage[j].Pid
+	})
+	if len(usage) > maxProcessNetworkEntries {
+		usage = usage[:maxProcessNetworkEntries]
+	}
+	return usage
 }
 
 // sanitizeConnectionInfo trims and length-bounds every string field so the
@@ -33,3 +177,76 @@ func sanitizeConnectionInfo(conn ConnectionInfo) ConnectionInfo {
 	conn.ProcessName = truncateCollectorString(conn.ProcessName)
 	return conn
 }
+
+var (
+	ssUserRe      = regexp.MustCompile(`users:\(\("((?:[^"\\]|\\.)*)",pid=(\d+)`)
+	ssBytesSentRe = regexp.MustCompile(`\bbytes_sent:(\d+)`)
+	ssBytesRecvRe = regexp.MustCompile(`\bbytes_received:(\d+)`)
+)
+
+// parseSSProcessFlows parses `ss -tinpH` output: a socket line (state,
+// queues, local, peer, users) followed by an indented tcp_info line carrying
+// bytes_sent / bytes_received. Sockets not owned by a visible process are
+// skipped. The socket tuple is the flow key.
+func parseSSProcessFlows(output string) []processNetFlow {
+	var flows []processNetFlow
+	var cur *processNetFlow
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			cur = nil
+			fields := strings.Fields(line)
+			m := ssUserRe.FindStringSubmatch(line)
+			if len(fields) < 5 || fields[0] == "State" || m == nil {
+				continue
+			}
+			pid, err := strconv.Atoi(m[2])
+			if err != nil || pid <= 0 {
+				continue
+			}
+			flows = append(flows, processNetFlow{key: fields[3] + " " + fields[4], pid: pid, name: m[1]})
+			cur = &flows[len(flows)-1]
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if m := ssBytesSentRe.FindStringSubmatch(line); m != nil {
+			cur.sent, _ = strconv.ParseUint(m[1], 10, 64)
+		}
+		if m := ssBytesRecvRe.FindStringSubmatch(line); m != nil {
+			cur.received, _ = strconv.ParseUint(m[1], 10, 64)
+		}
+	}
+	return flows
+}
+
+// parseNettopProcessFlows parses `nettop -P -L 1 -x -J bytes_in,bytes_out`
+// CSV: "time,,bytes_in,bytes_out," then one "<time>,<name>.<pid>,<in>,<out>,"
+// row per process. The process (name and PID) is the flow key.
+func parseNettopProcessFlows(output string) []processNetFlow {
+	var flows []processNetFlow
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		if len(fields) < 4 || fields[0] == "time" {
+			continue
+		}
+		dot := strings.LastIndex(fields[1], ".")
+		if dot <= 0 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[1][dot+1:])
+		if err != nil || pid <= 0 {
+			continue
+		}
+		in, errIn := strconv.ParseUint(fields[2], 10, 64)
+		out, errOut := strconv.ParseUint(fields[3], 10, 64)
+		if errIn != nil || errOut != nil {
+			continue
+		}
+		flows = append(flows, processNetFlow{key: fields[1], pid: pid, name: fields[1][:dot], sent: out, received: in})
+	}
+	return flows
+}
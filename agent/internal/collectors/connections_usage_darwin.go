@@ -0,0 +1,20 @@
+//go:build darwin
+
+package collectors
+
+import "fmt"
+
+type nettopProcessNetSampler struct{}
+
+func newProcessNetSampler() processNetSampler { return nettopProcessNetSampler{} }
+
+func (nettopProcessNetSampler) sample() ([]processNetFlow, error) {
+	output, err := runCollectorOutput(collectorShortCommandTimeout, "nettop", "-P", "-L", "1", "-x", "-J", "bytes_in,bytes_out")
+	if err != nil {
+		return nil, fmt.Errorf("nettop query failed: %w", err)
+	}
+	return parseNettopProcessFlows(string(output)), nil
+}
+
+func (nettopProcessNetSampler) source() string { return "nettop" }
+func (nettopProcessNetSampler) close()         {}
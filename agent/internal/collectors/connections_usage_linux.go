@@ -0,0 +1,20 @@
+//go:build linux
+
+package collectors
+
+import "fmt"
+
+type ssProcessNetSampler struct{}
+
+func newProcessNetSampler() processNetSampler { return ssProcessNetSampler{} }
+
+func (ssProcessNetSampler) sample() ([]processNetFlow, error) {
+	output, err := runCollectorOutput(collectorShortCommandTimeout, "ss", "-tinpH")
+	if err != nil {
+		return nil, fmt.Errorf("ss socket query failed: %w", err)
+	}
+	return parseSSProcessFlows(string(output)), nil
+}
+
+func (ssProcessNetSampler) source() string { return "ss" }
+func (ssProcessNetSampler) close()         {}
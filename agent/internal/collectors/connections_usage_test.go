@@ -0,0 +1,96 @@
+package collectors
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeProcessNetSampler struct {
+	flows []processNetFlow
+}
+
+func (f *fakeProcessNetSampler) sample() ([]processNetFlow, error) { return f.flows, nil }
+func (f *fakeProcessNetSampler) source() string                    { return "fake" }
+func (f *fakeProcessNetSampler) close()                            {}
+
+func TestParseSSProcessFlows(t *testing.T) {
+	output := `ESTAB 0 0 10.0.0.5:52344 140.82.112.3:443 users:(("firefox",pid=2211,fd=87))
+	 cubic wscale:7,7 rto:220 rtt:18.2/2.1 bytes_sent:4821 bytes_acked:4822 bytes_received:91234 segs_out:40
+ESTAB 0 0 10.0.0.5:22 10.0.0.9:60122
+	 cubic bytes_sent:100 bytes_received:200
+ESTAB 0 0 [::1]:5432 [::1]:41000 users:(("postgres",pid=801,fd=9),("postgres",pid=800,fd=9))
+	 cubic bytes_received:77
+`
+	flows := parseSSProcessFlows(output)
+	if len(flows) != 2 {
+		t.Fatalf("expected 2 owned sockets, got %+v", flows)
+	}
+	if f := flows[0]; f.pid != 2211 || f.name != "firefox" || f.sent != 4821 || f.received != 91234 || f.key != "10.0.0.5:52344 140.82.112.3:443" {
+		t.Errorf("unexpected firefox flow %+v", f)
+	}
+	if f := flows[1]; f.pid != 801 || f.sent != 0 || f.received != 77 {
+		t.Errorf("unexpected postgres flow %+v", f)
+	}
+}
+
+func TestParseNettopProcessFlows(t *testing.T) {
+	output := `time,,bytes_in,bytes_out,
+10:00:01.123456,Google Chrome H.512,104857,2048,
+10:00:01.123456,mDNSResponder.199,900,1200,
+10:00:01.123456,garbage,1,2,
+`
+	flows := parseNettopProcessFlows(output)
+	if len(flows) != 2 {
+		t.Fatalf("expected 2 flows, got %+v", flows)
+	}
+	if f := flows[0]; f.pid != 512 || f.name != "Google Chrome H" || f.received != 104857 || f.sent != 2048 {
+		t.Errorf("unexpected chrome flow %+v", f)
+	}
+}
+
+func TestProcessNetworkDeltas(t *testing.T) {
+	prev := map[string]processNetFlow{
+		"a": {key: "a", pid: 10, name: "curl", sent: 100, received: 1000},
+		"b": {key: "b", pid: 20, name: "sshd", sent: 50, received: 50},
+		"c": {key: "c", pid: 30, name: "old", sent: 900, received: 900},
+	}
+	current := map[string]processNetFlow{
+		"a": {key: "a", pid: 10, name: "curl", sent: 150, received: 3000},
+		"b": {key: "b", pid: 20, name: "sshd", sent: 50, received: 50}, // idle
+		"c": {key: "c", pid: 31, name: "new", sent: 10, received: 0},   // tuple reused
+		"d": {key: "d", pid: 10, name: "curl", sent: 5, received: 5},   // new socket
+	}
+	usage := processNetworkDeltas(prev, current)
+	if len(usage) != 2 {
+		t.Fatalf("expected 2 active processes, got %+v", usage)
+	}
+	if u := usage[0]; u.Pid != 10 || u.BytesSent != 55 || u.BytesReceived != 2005 {
+		t.Errorf("unexpected curl usage %+v", u)
+	}
+	if u := usage[1]; u.Pid != 31 || u.BytesSent != 10 || u.ProcessName != "new" {
+		t.Errorf("reused key should count in full for the new owner: %+v", u)
+	}
+}
+
+func TestCollectProcessUsageBaselinesFirst(t *testing.T) {
+	sampler := &fakeProcessNetSampler{flows: []processNetFlow{{key: "p", pid: 1, name: "svc", sent: 10, received: 20}}}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &ConnectionsCollector{sampler: sampler, now: func() time.Time { return now }}
+
+	if report, err := c.CollectProcessUsage(); err != nil || report != nil {
+		t.Fatalf("first call should only baseline, got %+v, %v", report, err)
+	}
+	now = now.Add(15 * time.Minute)
+	sampler.flows = []processNetFlow{{key: "p", pid: 1, name: "svc", sent: 110, received: 20}}
+	report, err := c.CollectProcessUsage()
+	if err != nil || report == nil {
+		t.Fatalf("expected a report, got %+v, %v", report, err)
+	}
+	if report.Source != "fake" || report.IntervalSeconds != 900 || len(report.Processes) != 1 || report.Processes[0].BytesSent != 100 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	if report, err := (&ConnectionsCollector{}).CollectProcessUsage(); err != nil || report != nil {
+		t.Errorf("collector without a sampler should report nothing, got %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,571 @@
+package collectors
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Container runtime inventory. Docker, Podman and containerd are found via
+// their CLIs; for each reachable runtime we report containers (all states),
+// images and, for running Docker/Podman containers, a one-shot CPU/memory
+// sample. A runtime whose CLI is installed but whose daemon is down is still
+// reported, with Error set, so "Docker stopped" is visible centrally.
+
+const (
+	containerRuntimeDocker     = "docker"
+	containerRuntimePodman     = "podman"
+	containerRuntimeContainerd = "containerd"
+)
+
+// ContainerInventory is the agent-sent wire shape for the containers section.
+type ContainerInventory struct {
+	Runtimes []ContainerRuntime `json:"runtimes"`
+}
+
+type ContainerRuntime struct {
+	Name       string           `json:"name"`
+	Version    string           `json:"version,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	Containers []ContainerInfo  `json:"containers"`
+	Images     []ContainerImage `json:"images"`
+}
+
+type ContainerInfo struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Image            string   `json:"image"`
+	State            string   `json:"state"`
+	Status           string   `json:"status,omitempty"`
+	CreatedAt        string   `json:"createdAt,omitempty"`
+	Ports            string   `json:"ports,omitempty"`
+	Namespace        string   `json:"namespace,omitempty"`
+	CPUPercent       *float64 `json:"cpuPercent,omitempty"`
+	MemoryUsageBytes *uint64  `json:"memoryUsageBytes,omitempty"`
+	MemoryLimitBytes *uint64  `json:"memoryLimitBytes,omitempty"`
+	MemoryPercent    *float64 `json:"memoryPercent,omitempty"`
+}
+
+type ContainerImage struct {
+	ID         string `json:"id"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	SizeBytes  uint64 `json:"sizeBytes,omitempty"`
+	CreatedAt  string `json:"createdAt,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+type ContainerCollector struct{}
+
+func NewContainerCollector() *ContainerCollector {
+	return &ContainerCollector{}
+}
+
+// Collect reports every container runtime whose CLI is present. An empty
+// inventory means no runtime is installed.
+func (c *ContainerCollector) Collect() (ContainerInventory, error) {
+	var inv ContainerInventory
+	if path := findContainerCLI("docker"); path != "" {
+		inv.Runtimes = append(inv.Runtimes, collectDockerRuntime(path))
+	}
+	if path := findContainerCLI("podman"); path != "" {
+		inv.Runtimes = append(inv.Runtimes, collectPodmanRuntime(path))
+	}
+	if path := findContainerCLI("ctr"); path != "" {
+		inv.Runtimes = append(inv.Runtimes, collectContainerdRuntime(path))
+	}
+	return inv, nil
+}
+
+// findContainerCLI resolves a runtime CLI from PATH, then from the
+// platform's well-known install locations (services often run with a
+// minimal PATH that misses e.g. /usr/local/bin).
+func findContainerCLI(name string) string {
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+	for _, candidate := range containerCLICandidates(name) {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func collectDockerRuntime(cli string) ContainerRuntime {
+	rt := ContainerRuntime{Name: containerRuntimeDocker, Containers: []ContainerInfo{}, Images: []ContainerImage{}}
+	version, err := runCollectorOutput(collectorShortCommandTimeout, cli, "version", "--format", "{{.Server.Version}}")
+	if err != nil {
+		rt.Error = fmt.Sprintf("docker daemon not reachable: %v", err)
+		return rt
+	}
+	rt.Version = strings.TrimSpace(string(version))
+
+	if out, err := runCollectorOutput(collectorLongCommandTimeout, cli, "ps", "--all", "--no-trunc", "--format", "{{json .}}"); err != nil {
+		slog.Warn("docker ps failed", "error", err.Error())
+	} else {
+		rt.Containers = parseDockerPS(out)
+	}
+	if hasRunningContainer(rt.Containers) {
+		if out, err := runCollectorOutput(collectorLongCommandTimeout, cli, "stats", "--no-stream", "--no-trunc", "--format", "{{json .}}"); err != nil {
+			slog.Warn("docker stats failed", "error", err.Error())
+		} else {
+			applyContainerStats(rt.Containers, parseDockerStats(out))
+		}
+	}
+	if out, err := runCollectorOutput(collectorLongCommandTimeout, cli, "images", "--no-trunc", "--format", "{{json .}}"); err != nil {
+		slog.Warn("docker images failed", "error", err.Error())
+	} else {
+		rt.Images = parseDockerImages(out)
+	}
+	return rt
+}
+
+func collectPodmanRuntime(cli string) ContainerRuntime {
+	rt := ContainerRuntime{Name: containerRuntimePodman, Containers: []ContainerInfo{}, Images: []ContainerImage{}}
+	version, err := runCollectorOutput(collectorShortCommandTimeout, cli, "version", "--format", "{{.Client.Version}}")
+	if err != nil {
+		rt.Error = fmt.Sprintf("podman not usable: %v", err)
+		return rt
+	}
+	rt.Version = strings.TrimSpace(string(version))
+
+	if out, err := runCollectorOutput(collectorLongCommandTimeout, cli, "ps", "--all", "--no-trunc", "--format", "json"); err != nil {
+		slog.Warn("podman ps failed", "error", err.Error())
+	} else {
+		rt.Containers = parsePodmanPS(out)
+	}
+	if hasRunningContainer(rt.Containers) {
+		if out, err := runCollectorOutput(collectorLongCommandTimeout, cli, "stats", "--no-stream", "--no-reset", "--format", "json"); err != nil {
+			slog.Warn("podman stats failed", "error", err.Error())
+		} else {
+			applyContainerStats(rt.Containers, parsePodmanStats(out))
+		}
+	}
+	if out, err := runCollectorOutput(collectorLongCommandTimeout, cli, "images", "--no-trunc", "--format", "json"); err != nil {
+		slog.Warn("podman images failed", "error", err.Error())
+	} else {
+		rt.Images = parsePodmanImages(out)
+	}
+	return rt
+}
+
+// collectContainerdRuntime walks every containerd namespace (Kubernetes
+// uses k8s.io, nerdctl uses default). ctr has no resource-usage view, so
+// containerd containers carry no stats.
+func collectContainerdRuntime(cli string) ContainerRuntime {
+	rt := ContainerRuntime{Name: containerRuntimeContainerd, Containers: []ContainerInfo{}, Images: []ContainerImage{}}
+	version, err := runCollectorOutput(collectorShortCommandTimeout, cli, "version")
+	if err != nil {
+		rt.Error = fmt.Sprintf("containerd not reachable: %v", err)
+		return rt
+	}
+	rt.Version = parseCtrServerVersion(version)
+
+	namespaces, err := runCollectorOutput(collectorShortCommandTimeout, cli, "namespaces", "list", "--quiet")
+	if err != nil {
+		rt.Error = fmt.Sprintf("ctr namespaces failed: %v", err)
+		return rt
+	}
+	for _, ns := range strings.Fields(string(namespaces)) {
+		containers, cErr := runCollectorOutput(collectorLongCommandTimeout, cli, "--namespace", ns, "containers", "list")
+		if cErr != nil {
+			slog.Warn("ctr containers list failed", "namespace", ns, "error", cErr.Error())
+			continue
+		}
+		var tasks []byte
+		if out, tErr := runCollectorOutput(collectorShortCommandTimeout, cli, "--namespace", ns, "tasks", "list"); tErr == nil {
+			tasks = out
+		}
+		rt.Containers = append(rt.Containers, parseCtrContainers(containers, parseCtrTasks(tasks), ns)...)
+
+		if images, iErr := runCollectorOutput(collectorLongCommandTimeout, cli, "--namespace", ns, "images", "list"); iErr == nil {
+			rt.Images = append(rt.Images, parseCtrImages(images, ns)...)
+		}
+	}
+	if len(rt.Containers) > collectorResultLimit {
+		rt.Containers = rt.Containers[:collectorResultLimit]
+	}
+	if len(rt.Images) > collectorResultLimit {
+		rt.Images = rt.Images[:collectorResultLimit]
+	}
+	return rt
+}
+
+func hasRunningContainer(containers []ContainerInfo) bool {
+	for _, c := range containers {
+		if c.State == "running" {
+			return true
+		}
+	}
+	return false
+}
+
+// containerStats is one container's CPU/memory sample, keyed by ID.
+type containerStats struct {
+	ID            string
+	CPUPercent    *float64
+	MemUsage      *uint64
+	MemLimit      *uint64
+	MemoryPercent *float64
+}
+
+// applyContainerStats attaches samples to containers. Stats IDs may be
+// truncated, so matching is by prefix.
+func applyContainerStats(containers []ContainerInfo, stats []containerStats) {
+	for i := range containers {
+		for _, s := range stats {
+			if s.ID == "" || !strings.HasPrefix(containers[i].ID, s.ID) {
+				continue
+			}
+			containers[i].CPUPercent = s.CPUPercent
+			containers[i].MemoryUsageBytes = s.MemUsage
+			containers[i].MemoryLimitBytes = s.MemLimit
+			containers[i].MemoryPercent = s.MemoryPercent
+			break
+		}
+	}
+}
+
+// forEachJSONLine decodes `--format '{{json .}}'` output: one object per line.
+func forEachJSONLine[T any](output []byte, fn func(T)) {
+	scanner := newCollectorScanner(output)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var row T
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			continue
+		}
+		fn(row)
+	}
+}
+
+func parseDockerPS(output []byte) []ContainerInfo {
+	type row struct {
+		ID        string `json:"ID"`
+		Names     string `json:"Names"`
+		Image     string `json:"Image"`
+		State     string `json:"State"`
+		Status    string `json:"Status"`
+		CreatedAt string `json:"CreatedAt"`
+		Ports     string `json:"Ports"`
+	}
+	containers := []ContainerInfo{}
+	forEachJSONLine(output, func(r row) {
+		if len(containers) >= collectorResultLimit {
+			return
+		}
+		containers = append(containers, ContainerInfo{
+			ID:        r.ID,
+			Name:      truncateCollectorString(r.Names),
+			Image:     truncateCollectorString(r.Image),
+			State:     strings.ToLower(r.State),
+			Status:    truncateCollectorString(r.Status),
+			CreatedAt: r.CreatedAt,
+			Ports:     truncateCollectorString(r.Ports),
+		})
+	})
+	return containers
+}
+
+func parseDockerStats(output []byte) []containerStats {
+	type row struct {
+		ID       string `json:"ID"`
+		CPUPerc  string `json:"CPUPerc"`
+		MemUsage string `json:"MemUsage"`
+		MemPerc  string `json:"MemPerc"`
+	}
+	var stats []containerStats
+	forEachJSONLine(output, func(r row) {
+		usage, limit := parseContainerMemUsage(r.MemUsage)
+		stats = append(stats, containerStats{
+			ID:            r.ID,
+			CPUPercent:    parseContainerPercent(r.CPUPerc),
+			MemUsage:      usage,
+			MemLimit:      limit,
+			MemoryPercent: parseContainerPercent(r.MemPerc),
+		})
+	})
+	return stats
+}
+
+func parseDockerImages(output []byte) []ContainerImage {
+	type row struct {
+		ID         string `json:"ID"`
+		Repository string `json:"Repository"`
+		Tag        string `json:"Tag"`
+		Size       string `json:"Size"`
+		CreatedAt  string `json:"CreatedAt"`
+	}
+	images := []ContainerImage{}
+	forEachJSONLine(output, func(r row) {
+		if len(images) >= collectorResultLimit {
+			return
+		}
+		size, _ := parseContainerSize(r.Size)
+		images = append(images, ContainerImage{
+			ID:         r.ID,
+			Repository: truncateCollectorString(r.Repository),
+			Tag:        noneToEmpty(r.Tag),
+			SizeBytes:  size,
+			CreatedAt:  r.CreatedAt,
+		})
+	})
+	return images
+}
+
+func parsePodmanPS(output []byte) []ContainerInfo {
+	var rows []struct {
+		ID        string   `json:"Id"`
+		Names     []string `json:"Names"`
+		Image     string   `json:"Image"`
+		State     string   `json:"State"`
+		Status    string   `json:"Status"`
+		CreatedAt string   `json:"CreatedAt"`
+		Ports     []struct {
+			HostIP        string `json:"host_ip"`
+			HostPort      int    `json:"host_port"`
+			ContainerPort int    `json:"container_port"`
+			Protocol      string `json:"protocol"`
+		} `json:"Ports"`
+	}
+	containers := []ContainerInfo{}
+	if err := json.Unmarshal(output, &rows); err != nil {
+		return containers
+	}
+	for _, r := range rows {
+		if len(containers) >= collectorResultLimit {
+			break
+		}
+		ports := make([]string, 0, len(r.Ports))
+		for _, p := range r.Ports {
+			ports = append(ports, fmt.Sprintf("%s:%d->%d/%s", p.HostIP, p.HostPort, p.ContainerPort, p.Protocol))
+		}
+		containers = append(containers, ContainerInfo{
+			ID:        r.ID,
+			Name:      truncateCollectorString(strings.Join(r.Names, ",")),
+			Image:     truncateCollectorString(r.Image),
+			State:     strings.ToLower(r.State),
+			Status:    truncateCollectorString(r.Status),
+			CreatedAt: r.CreatedAt,
+			Ports:     truncateCollectorString(strings.Join(ports, ", ")),
+		})
+	}
+	return containers
+}
+
+func parsePodmanStats(output []byte) []containerStats {
+	var rows []struct {
+		ID         string `json:"id"`
+		CPUPercent string `json:"cpu_percent"`
+		MemUsage   string `json:"mem_usage"`
+		MemPercent string `json:"mem_percent"`
+	}
+	if err := json.Unmarshal(output, &rows); err != nil {
+		return nil
+	}
+	stats := make([]containerStats, 0, len(rows))
+	for _, r := range rows {
+		usage, limit := parseContainerMemUsage(r.MemUsage)
+		stats = append(stats, containerStats{
+			ID:            r.ID,
+			CPUPercent:    parseContainerPercent(r.CPUPercent),
+			MemUsage:      usage,
+			MemLimit:      limit,
+			MemoryPercent: parseContainerPercent(r.MemPercent),
+		})
+	}
+	return stats
+}
+
+func parsePodmanImages(output []byte) []ContainerImage {
+	var rows []struct {
+		ID       string   `json:"Id"`
+		RepoTags []string `json:"RepoTags"`
+		Names    []string `json:"Names"`
+		Size     uint64   `json:"Size"`
+		Created  int64    `json:"Created"`
+	}
+	images := []ContainerImage{}
+	if err := json.Unmarshal(output, &rows); err != nil {
+		return images
+	}
+	for _, r := range rows {
+		if len(images) >= collectorResultLimit {
+			break
+		}
+		tags := r.RepoTags
+		if len(tags) == 0 {
+			tags = r.Names
+		}
+		repo, tag := "", ""
+		if len(tags) > 0 {
+			repo, tag = splitImageReference(tags[0])
+		}
+		img := ContainerImage{
+			ID:         r.ID,
+			Repository: truncateCollectorString(repo),
+			Tag:        tag,
+			SizeBytes:  r.Size,
+		}
+		if r.Created > 0 {
+			img.CreatedAt = strconv.FormatInt(r.Created, 10)
+		}
+		images = append(images, img)
+	}
+	return images
+}
+
+// parseCtrServerVersion pulls the version from the "Server:" section of
+// `ctr version`.
+func parseCtrServerVersion(output []byte) string {
+	inServer := false
+	for _, line := range strings.Split(string(output), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "Server:" {
+			inServer = true
+			continue
+		}
+		if inServer && strings.HasPrefix(trimmed, "Version:") {
+			return strings.TrimSpace(strings.TrimPrefix(trimmed, "Version:"))
+		}
+	}
+	return ""
+}
+
+// parseCtrTasks maps container ID -> task status from `ctr tasks list`
+// (TASK PID STATUS). Containers without a task are not running.
+func parseCtrTasks(output []byte) map[string]string {
+	tasks := make(map[string]string)
+	for i, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if i == 0 || len(fields) < 3 {
+			continue
+		}
+		tasks[fields[0]] = strings.ToLower(fields[2])
+	}
+	return tasks
+}
+
+// parseCtrContainers parses `ctr containers list` (CONTAINER IMAGE RUNTIME).
+func parseCtrContainers(output []byte, tasks map[string]string, namespace string) []ContainerInfo {
+	var containers []ContainerInfo
+	for i, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if i == 0 || len(fields) < 2 {
+			continue
+		}
+		state := tasks[fields[0]]
+		if state == "" {
+			state = "created"
+		}
+		containers = append(containers, ContainerInfo{
+			ID:        fields[0],
+			Name:      fields[0],
+			Image:     truncateCollectorString(fields[1]),
+			State:     state,
+			Namespace: namespace,
+		})
+	}
+	return containers
+}
+
+// parseCtrImages parses `ctr images list` (REF TYPE DIGEST SIZE PLATFORMS
+// LABELS), where SIZE is two columns, e.g. "72.8 MiB".
+func parseCtrImages(output []byte, namespace string) []ContainerImage {
+	var images []ContainerImage
+	for i, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if i == 0 || len(fields) < 5 {
+			continue
+		}
+		repo, tag := splitImageReference(fields[0])
+		size, _ := parseContainerSize(fields[3] + fields[4])
+		images = append(images, ContainerImage{
+			ID:         fields[2],
+			Repository: truncateCollectorString(repo),
+			Tag:        tag,
+			SizeBytes:  size,
+			Namespace:  namespace,
+		})
+	}
+	return images
+}
+
+// splitImageReference splits "registry:5000/app:1.2" into repository and
+// tag; digests ("app@sha256:...") have no tag.
+func splitImageReference(ref string) (string, string) {
+	if at := strings.Index(ref, "@"); at >= 0 {
+		return ref[:at], ""
+	}
+	slash := strings.LastIndex(ref, "/")
+	if colon := strings.LastIndex(ref, ":"); colon > slash {
+		return ref[:colon], ref[colon+1:]
+	}
+	return ref, ""
+}
+
+func noneToEmpty(s string) string {
+	if s == "<none>" {
+		return ""
+	}
+	return s
+}
+
+// parseContainerPercent parses "12.34%"; "--" (not sampled) yields nil.
+func parseContainerPercent(s string) *float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%")), 64)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+// parseContainerMemUsage parses "12.5MiB / 1.944GiB".
+func parseContainerMemUsage(s string) (*uint64, *uint64) {
+	usageStr, limitStr, ok := strings.Cut(s, "/")
+	if !ok {
+		return nil, nil
+	}
+	var usage, limit *uint64
+	if v, ok := parseContainerSize(usageStr); ok {
+		usage = &v
+	}
+	if v, ok := parseContainerSize(limitStr); ok {
+		limit = &v
+	}
+	return usage, limit
+}
+
+var containerSizeUnits = map[string]float64{
+	"b":  1,
+	"kb": 1e3, "mb": 1e6, "gb": 1e9, "tb": 1e12,
+	"kib": 1 << 10, "mib": 1 << 20, "gib": 1 << 30, "tib": 1 << 40,
+}
+
+// parseContainerSize parses the human sizes the runtime CLIs print: decimal
+// ("72.8MB", "1.2kB") and binary ("12.5MiB") units, with or without a space.
+func parseContainerSize(s string) (uint64, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	split := strings.IndexFunc(s, func(r rune) bool { return unicode.IsLetter(r) })
+	if split <= 0 {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(s[:split], 64)
+	if err != nil || value < 0 {
+		return 0, false
+	}
+	mult, ok := containerSizeUnits[strings.ToLower(s[split:])]
+	if !ok {
+		return 0, false
+	}
+	return uint64(value * mult), true
+}
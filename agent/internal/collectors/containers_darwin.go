@@ -0,0 +1,16 @@
+//go:build darwin
+
+package collectors
+
+import "path/filepath"
+
+// Docker Desktop, Podman Desktop and Homebrew install outside the launchd
+// default PATH.
+func containerCLICandidates(name string) []string {
+	return []string{
+		filepath.Join("/usr/local/bin", name),
+		filepath.Join("/opt/homebrew/bin", name),
+		filepath.Join("/Applications/Docker.app/Contents/Resources/bin", name),
+		filepath.Join("/opt/podman/bin", name),
+	}
+}
@@ -0,0 +1,13 @@
+//go:build linux
+
+package collectors
+
+import "path/filepath"
+
+func containerCLICandidates(name string) []string {
+	return []string{
+		filepath.Join("/usr/bin", name),
+		filepath.Join("/usr/local/bin", name),
+		filepath.Join("/snap/bin", name),
+	}
+}
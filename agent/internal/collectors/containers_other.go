@@ -0,0 +1,7 @@
+//go:build !windows && !linux && !darwin
+
+package collectors
+
+func containerCLICandidates(name string) []string {
+	return nil
+}
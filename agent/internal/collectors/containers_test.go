@@ -0,0 +1,89 @@
+package collectors
+
+import "testing"
+
+func TestParseDockerPSAndStats(t *testing.T) {
+	ps := []byte(`{"ID":"4f1c2a9e8b7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a1f0e9d8c7b6a5f4e3d2c1b","Names":"web","Image":"nginx:1.25","State":"running","Status":"Up 3 hours","CreatedAt":"2026-05-01 09:00:00 +0000 UTC","Ports":"0.0.0.0:8080->80/tcp"}
+{"ID":"9a8b7c6d5e4f","Names":"old-job","Image":"busybox","State":"exited","Status":"Exited (0) 2 days ago","CreatedAt":"2026-04-28 09:00:00 +0000 UTC","Ports":""}
+not json
+`)
+	containers := parseDockerPS(ps)
+	if len(containers) != 2 || containers[0].Name != "web" || containers[1].State != "exited" {
+		t.Fatalf("parseDockerPS = %+v", containers)
+	}
+	if !hasRunningContainer(containers) {
+		t.Fatal("web is running")
+	}
+
+	stats := []byte(`{"ID":"4f1c2a9e8b7d","Name":"web","CPUPerc":"1.50%","MemUsage":"12.5MiB / 1.944GiB","MemPerc":"0.63%"}`)
+	applyContainerStats(containers, parseDockerStats(stats))
+	web := containers[0]
+	if web.CPUPercent == nil || *web.CPUPercent != 1.5 || web.MemoryUsageBytes == nil || *web.MemoryUsageBytes != 13107200 {
+		t.Errorf("stats not applied by ID prefix: %+v", web)
+	}
+	if containers[1].CPUPercent != nil {
+		t.Errorf("stopped container should carry no stats: %+v", containers[1])
+	}
+}
+
+func TestParsePodmanOutput(t *testing.T) {
+	ps := []byte(`[{"Id":"abc123def456","Names":["db"],"Image":"docker.io/library/postgres:16","State":"running","Status":"Up 5 minutes","CreatedAt":"2026-05-01T09:00:00Z","Ports":[{"host_ip":"","host_port":5432,"container_port":5432,"protocol":"tcp"}]}]`)
+	containers := parsePodmanPS(ps)
+	if len(containers) != 1 || containers[0].Name != "db" || containers[0].Ports != ":5432->5432/tcp" {
+		t.Fatalf("parsePodmanPS = %+v", containers)
+	}
+	applyContainerStats(containers, parsePodmanStats([]byte(`[{"id":"abc123def456","cpu_percent":"0.25%","mem_usage":"50MB / 2GB","mem_percent":"2.50%"}]`)))
+	if c := containers[0]; c.MemoryLimitBytes == nil || *c.MemoryLimitBytes != 2e9 || c.MemoryPercent == nil || *c.MemoryPercent != 2.5 {
+		t.Errorf("podman stats = %+v", c)
+	}
+
+	images := parsePodmanImages([]byte(`[{"Id":"sha256:1111","RepoTags":["registry.local:5000/team/app:2.1"],"Size":73400320,"Created":1714554000}]`))
+	if len(images) != 1 || images[0].Repository != "registry.local:5000/team/app" || images[0].Tag != "2.1" || images[0].SizeBytes != 73400320 {
+		t.Errorf("parsePodmanImages = %+v", images)
+	}
+}
+
+func TestParseContainerdOutput(t *testing.T) {
+	version := []byte("Client:\n  Version:  v1.7.13\n\nServer:\n  Version:  v1.7.14\n  UUID: x\n")
+	if got := parseCtrServerVersion(version); got != "v1.7.14" {
+		t.Errorf("parseCtrServerVersion = %q", got)
+	}
+	tasks := parseCtrTasks([]byte("TASK    PID     STATUS\nkube-proxy-1    4242    RUNNING\n"))
+	containers := parseCtrContainers([]byte("CONTAINER    IMAGE    RUNTIME\nkube-proxy-1    registry.k8s.io/kube-proxy:v1.30.0    io.containerd.runc.v2\npause-7    registry.k8s.io/pause:3.9    io.containerd.runc.v2\n"), tasks, "k8s.io")
+	if len(containers) != 2 || containers[0].State != "running" || containers[1].State != "created" || containers[0].Namespace != "k8s.io" {
+		t.Errorf("parseCtrContainers = %+v", containers)
+	}
+	images := parseCtrImages([]byte("REF    TYPE    DIGEST    SIZE    PLATFORMS    LABELS\nregistry.k8s.io/pause:3.9    application/vnd.oci.image.index.v1+json    sha256:7031    311.6 KiB    linux/amd64    -\n"), "k8s.io")
+	if len(images) != 1 || images[0].Tag != "3.9" || images[0].SizeBytes != 319078 {
+		t.Errorf("parseCtrImages = %+v", images)
+	}
+}
+
+func TestParseContainerSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint64
+		ok   bool
+	}{
+		{"72.8MB", 72800000, true},
+		{"1.2kB", 1200, true},
+		{"12.5MiB", 13107200, true},
+		{"311.6 KiB", 319078, true},
+		{"0B", 0, true},
+		{"--", 0, false},
+		{"12 parsecs", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseContainerSize(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseContainerSize(%q) = %d, %v; want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if repo, tag := splitImageReference("app@sha256:abcd"); repo != "app" || tag != "" {
+		t.Errorf("digest reference split = %q, %q", repo, tag)
+	}
+	if repo, tag := splitImageReference("localhost:5000/app"); repo != "localhost:5000/app" || tag != "" {
+		t.Errorf("registry port mistaken for tag: %q, %q", repo, tag)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// The service's PATH may predate a Docker Desktop / Podman install.
+func containerCLICandidates(name string) []string {
+	programFiles := os.Getenv("ProgramFiles")
+	if programFiles == "" {
+		programFiles = `C:\Program Files`
+	}
+	exe := name + ".exe"
+	return []string{
+		filepath.Join(programFiles, "Docker", "Docker", "resources", "bin", exe),
+		filepath.Join(programFiles, "RedHat", "Podman", exe),
+		filepath.Join(programFiles, "containerd", "bin", exe),
+	}
+}
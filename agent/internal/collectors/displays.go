@@ -0,0 +1,325 @@
+package collectors
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DisplayInfo describes one attached monitor, decoded from its EDID where
+// the platform exposes it.
+type DisplayInfo struct {
+	Manufacturer      string `json:"manufacturer,omitempty"`
+	ManufacturerID    string `json:"manufacturerId,omitempty"` // 3-letter PNP ID, e.g. "DEL"
+	Model             string `json:"model,omitempty"`
+	ProductCode       string `json:"productCode,omitempty"`
+	SerialNumber      string `json:"serialNumber,omitempty"`
+	ManufactureYear   int    `json:"manufactureYear,omitempty"`
+	ManufactureWeek   int    `json:"manufactureWeek,omitempty"`
+	NativeResolution  string `json:"nativeResolution,omitempty"`
+	CurrentResolution string `json:"currentResolution,omitempty"`
+	ConnectionType    string `json:"connectionType,omitempty"` // "HDMI", "DisplayPort", "DVI", "VGA", "Internal", ...
+	Internal          bool   `json:"internal,omitempty"`
+	WidthCm           int    `json:"widthCm,omitempty"`
+	HeightCm          int    `json:"heightCm,omitempty"`
+}
+
+// maxDisplays bounds the inventory; docking stations and video walls aside,
+// nothing real has more.
+const maxDisplays = 16
+
+// pnpVendorNames maps common PNP manufacturer IDs to display names. Unknown
+// IDs are reported as the raw ID.
+var pnpVendorNames = map[string]string{
+	"ACR": "Acer", "AOC": "AOC", "APP": "Apple", "AUO": "AU Optronics",
+	"AUS": "ASUS", "BNQ": "BenQ", "BOE": "BOE", "CMN": "Innolux",
+	"DEL": "Dell", "EIZ": "EIZO", "FUS": "Fujitsu", "GBT": "Gigabyte",
+	"GSM": "LG", "HPN": "HP", "HWP": "HP", "IVM": "iiyama",
+	"LEN": "Lenovo", "LGD": "LG Display", "MEI": "Panasonic", "MSI": "MSI",
+	"NEC": "NEC", "PHL": "Philips", "SAM": "Samsung", "SDC": "Samsung Display",
+	"SHP": "Sharp", "SNY": "Sony", "TSB": "Toshiba", "VSC": "ViewSonic",
+}
+
+var edidHeader = []byte{0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00}
+
+// decodePNPID unpacks the big-endian 3×5-bit manufacturer ID.
+func decodePNPID(v uint16) string {
+	var id [3]byte
+	for i, shift := range []uint{10, 5, 0} {
+		c := (v >> shift) & 0x1F
+		if c < 1 || c > 26 {
+			return ""
+		}
+		id[i] = byte('A' + c - 1)
+	}
+	return string(id[:])
+}
+
+// edidDescriptorText extracts the text of a display descriptor (0x0A
+// terminated, space padded).
+func edidDescriptorText(b []byte) string {
+	text := b[5:18]
+	if i := bytes.IndexByte(text, 0x0A); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7E {
+			return -1
+		}
+		return r
+	}, string(text)))
+}
+
+// parseEDID decodes the base EDID block. Extension blocks are ignored.
+func parseEDID(edid []byte) (DisplayInfo, bool) {
+	var d DisplayInfo
+	if len(edid) < 128 || !bytes.Equal(edid[:8], edidHeader) {
+		return d, false
+	}
+
+	d.ManufacturerID = decodePNPID(uint16(edid[8])<<8 | uint16(edid[9]))
+	d.Manufacturer = pnpVendorName(d.ManufacturerID)
+	d.ProductCode = fmt.Sprintf("%04X", uint16(edid[11])<<8|uint16(edid[10]))
+	if serial := uint32(edid[12]) | uint32(edid[13])<<8 | uint32(edid[14])<<16 | uint32(edid[15])<<24; serial != 0 && serial != 0x01010101 {
+		d.SerialNumber = strconv.FormatUint(uint64(serial), 10)
+	}
+	// Week 0xFF marks byte 17 as a model year rather than a manufacture year.
+	if week := int(edid[16]); week >= 1 && week <= 54 {
+		d.ManufactureWeek = week
+	}
+	if edid[17] > 0 {
+		d.ManufactureYear = 1990 + int(edid[17])
+	}
+	d.WidthCm, d.HeightCm = int(edid[21]), int(edid[22])
+
+	// EDID 1.4 digital inputs declare their interface.
+	if edid[20]&0x80 != 0 && edid[19] >= 4 {
+		switch edid[20] & 0x0F {
+		case 1:
+			d.ConnectionType = "DVI"
+		case 2, 3:
+			d.ConnectionType = "HDMI"
+		case 5:
+			d.ConnectionType = "DisplayPort"
+		}
+	}
+
+	for off := 54; off <= 108; off += 18 {
+		b := edid[off : off+18]
+		if b[0] != 0 || b[1] != 0 {
+			// Detailed timing; the first one is the preferred (native) mode.
+			if d.NativeResolution == "" {
+				h := int(b[2]) | int(b[4]&0xF0)<<4
+				v := int(b[5]) | int(b[7]&0xF0)<<4
+				if h > 0 && v > 0 {
+					d.NativeResolution = fmt.Sprintf("%dx%d", h, v)
+				}
+			}
+			continue
+		}
+		switch b[3] {
+		case 0xFC:
+			d.Model = edidDescriptorText(b)
+		case 0xFF:
+			if s := edidDescriptorText(b); s != "" {
+				d.SerialNumber = s
+			}
+		}
+	}
+
+	d.Model = truncateCollectorString(d.Model)
+	d.SerialNumber = cleanHardwareIdentityValue(d.SerialNumber)
+	return d, true
+}
+
+func pnpVendorName(id string) string {
+	if name, ok := pnpVendorNames[id]; ok {
+		return name
+	}
+	return id
+}
+
+// drmConnectorType maps a Linux DRM connector name (card0-HDMI-A-1) to a
+// connection type and whether it is a built-in panel.
+func drmConnectorType(connector string) (string, bool) {
+	if i := strings.Index(connector, "-"); i >= 0 && strings.HasPrefix(connector, "card") {
+		connector = connector[i+1:]
+	}
+	switch {
+	case strings.HasPrefix(connector, "eDP"), strings.HasPrefix(connector, "LVDS"), strings.HasPrefix(connector, "DSI"):
+		return "Internal", true
+	case strings.HasPrefix(connector, "HDMI"):
+		return "HDMI", false
+	case strings.HasPrefix(connector, "DP"):
+		return "DisplayPort", false
+	case strings.HasPrefix(connector, "DVI"):
+		return "DVI", false
+	case strings.HasPrefix(connector, "VGA"):
+		return "VGA", false
+	case strings.HasPrefix(connector, "Virtual"):
+		return "Virtual", false
+	}
+	return "", false
+}
+
+// windowsVideoOutputType maps WmiMonitorConnectionParams.VideoOutputTechnology
+// (D3DKMDT_VIDEO_OUTPUT_TECHNOLOGY) to a connection type.
+func windowsVideoOutputType(tech int64) (string, bool) {
+	switch tech {
+	case 0:
+		return "VGA", false
+	case 4:
+		return "DVI", false
+	case 5:
+		return "HDMI", false
+	case 6, 11, 13, 0x80000000:
+		return "Internal", true
+	case 10:
+		return "DisplayPort", false
+	case 15:
+		return "Miracast", false
+	case 1, 2, 3:
+		return "Analog", false
+	}
+	return "", false
+}
+
+// windowsMonitorJSON is one monitor emitted by the WMI display query.
+type windowsMonitorJSON struct {
+	Manufacturer          string `json:"Manufacturer"`
+	Name                  string `json:"Name"`
+	Serial                string `json:"Serial"`
+	ProductCode           string `json:"ProductCode"`
+	Year                  int    `json:"Year"`
+	Week                  int    `json:"Week"`
+	VideoOutputTechnology *int64 `json:"VideoOutputTechnology"`
+	EDID                  string `json:"EDID"`
+}
+
+// parseWindowsMonitorsJSON decodes the WMI display query. ConvertTo-Json
+// emits a bare object for a single monitor, so both shapes are accepted.
+func parseWindowsMonitorsJSON(data []byte) ([]DisplayInfo, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	var monitors []windowsMonitorJSON
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &monitors); err != nil {
+			return nil, err
+		}
+	} else {
+		var one windowsMonitorJSON
+		if err := json.Unmarshal(data, &one); err != nil {
+			return nil, err
+		}
+		monitors = []windowsMonitorJSON{one}
+	}
+
+	var displays []DisplayInfo
+	for _, m := range monitors {
+		var d DisplayInfo
+		if raw, err := base64.StdEncoding.DecodeString(m.EDID); err == nil {
+			d, _ = parseEDID(raw)
+		}
+		// WmiMonitorID fields fill whatever the EDID did not provide.
+		if d.ManufacturerID == "" {
+			d.ManufacturerID = truncateCollectorString(m.Manufacturer)
+			d.Manufacturer = pnpVendorName(d.ManufacturerID)
+		}
+		if d.Model == "" {
+			d.Model = truncateCollectorString(m.Name)
+		}
+		if d.SerialNumber == "" {
+			d.SerialNumber = cleanHardwareIdentityValue(m.Serial)
+		}
+		if d.ProductCode == "" {
+			d.ProductCode = truncateCollectorString(m.ProductCode)
+		}
+		if d.ManufactureYear == 0 {
+			d.ManufactureYear = m.Year
+		}
+		if d.ManufactureWeek == 0 && m.Week >= 1 && m.Week <= 54 {
+			d.ManufactureWeek = m.Week
+		}
+		if m.VideoOutputTechnology != nil {
+			if conn, internal := windowsVideoOutputType(*m.VideoOutputTechnology); conn != "" {
+				d.ConnectionType, d.Internal = conn, internal
+			}
+		}
+		displays = append(displays, d)
+		if len(displays) >= maxDisplays {
+			break
+		}
+	}
+	return displays, nil
+}
+
+// spDisplaysJSON is the subset of `system_profiler SPDisplaysDataType -json`
+// describing attached displays (nested under each GPU).
+type spDisplaysJSON struct {
+	SPDisplaysDataType []struct {
+		Displays []struct {
+			Name           string `json:"_name"`
+			VendorID       string `json:"_spdisplays_display-vendor-id"`
+			ProductID      string `json:"_spdisplays_display-product-id"`
+			Serial         string `json:"_spdisplays_display-serial-number"`
+			Week           string `json:"_spdisplays_display-week"`
+			Year           string `json:"_spdisplays_display-year"`
+			Pixels         string `json:"_spdisplays_pixels"`
+			Resolution     string `json:"_spdisplays_resolution"`
+			ConnectionType string `json:"spdisplays_connection_type"`
+		} `json:"spdisplays_ndrvs"`
+	} `json:"SPDisplaysDataType"`
+}
+
+// spResolution normalizes "3840 x 2160" or "1920 x 1080 @ 60.00Hz" to
+// "3840x2160".
+func spResolution(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) >= 3 && fields[1] == "x" {
+		if _, err := strconv.Atoi(fields[0]); err == nil {
+			if _, err := strconv.Atoi(fields[2]); err == nil {
+				return fields[0] + "x" + fields[2]
+			}
+		}
+	}
+	return ""
+}
+
+// parseSPDisplaysJSON decodes system_profiler's display report.
+func parseSPDisplaysJSON(data []byte) ([]DisplayInfo, error) {
+	var report spDisplaysJSON
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	var displays []DisplayInfo
+	for _, gpu := range report.SPDisplaysDataType {
+		for _, m := range gpu.Displays {
+			d := DisplayInfo{
+				Model:             truncateCollectorString(m.Name),
+				ProductCode:       strings.ToUpper(truncateCollectorString(m.ProductID)),
+				SerialNumber:      cleanHardwareIdentityValue(m.Serial),
+				NativeResolution:  spResolution(m.Pixels),
+				CurrentResolution: spResolution(m.Resolution),
+			}
+			if v, err := strconv.ParseUint(m.VendorID, 16, 16); err == nil {
+				d.ManufacturerID = decodePNPID(uint16(v))
+				d.Manufacturer = pnpVendorName(d.ManufacturerID)
+			}
+			d.ManufactureWeek, _ = strconv.Atoi(m.Week)
+			d.ManufactureYear, _ = strconv.Atoi(m.Year)
+			if m.ConnectionType == "spdisplays_internal" {
+				d.ConnectionType, d.Internal = "Internal", true
+			}
+			displays = append(displays, d)
+			if len(displays) >= maxDisplays {
+				return displays, nil
+			}
+		}
+	}
+	return displays, nil
+}
@@ -0,0 +1,21 @@
+//go:build darwin
+
+package collectors
+
+import "log/slog"
+
+// collectDisplays reads attached displays from system_profiler, which
+// reports the EDID-derived identity on both Intel and Apple silicon.
+func collectDisplays() []DisplayInfo {
+	out, err := runCollectorOutput(collectorLongCommandTimeout, "system_profiler", "SPDisplaysDataType", "-json")
+	if err != nil {
+		slog.Warn("system_profiler SPDisplaysDataType failed", "error", err.Error())
+		return nil
+	}
+	displays, err := parseSPDisplaysJSON(out)
+	if err != nil {
+		slog.Warn("failed to parse display inventory", "error", err.Error())
+		return nil
+	}
+	return displays
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package collectors
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// drmEDIDReadLimit bounds an EDID read: 128 bytes per block, at most 255
+// extension blocks.
+const drmEDIDReadLimit = 256 * 128
+
+// collectDisplays reads the EDID of every connected DRM connector. Headless
+// servers and VMs without a DRM driver simply report none.
+func collectDisplays() []DisplayInfo {
+	connectors, _ := filepath.Glob("/sys/class/drm/card*-*")
+	sort.Strings(connectors)
+
+	var displays []DisplayInfo
+	for _, dir := range connectors {
+		status, err := os.ReadFile(filepath.Join(dir, "status"))
+		if err != nil || strings.TrimSpace(string(status)) != "connected" {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, "edid"))
+		if err != nil {
+			continue
+		}
+		edid, err := io.ReadAll(io.LimitReader(f, drmEDIDReadLimit))
+		f.Close()
+		if err != nil {
+			continue
+		}
+		d, ok := parseEDID(edid)
+		if !ok {
+			continue
+		}
+		if conn, internal := drmConnectorType(filepath.Base(dir)); conn != "" {
+			d.ConnectionType, d.Internal = conn, internal
+		}
+		displays = append(displays, d)
+		if len(displays) >= maxDisplays {
+			break
+		}
+	}
+	return displays
+}
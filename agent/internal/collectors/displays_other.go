@@ -0,0 +1,7 @@
+//go:build !windows && !linux && !darwin
+
+package collectors
+
+func collectDisplays() []DisplayInfo {
+	return nil
+}
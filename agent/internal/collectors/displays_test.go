@@ -0,0 +1,141 @@
+package collectors
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+)
+
+// testEDID builds a minimal EDID 1.4 base block: Dell (DEL) product 0xA0B4,
+// DisplayPort input, 60x34 cm, native 2560x1440, name and serial
+// descriptors.
+func testEDID() []byte {
+	e := make([]byte, 128)
+	copy(e, edidHeader)
+	e[8], e[9] = 0x10, 0xAC // DEL
+	e[10], e[11] = 0xB4, 0xA0
+	e[12], e[13], e[14], e[15] = 0x4C, 0x33, 0x30, 0x30
+	e[16], e[17] = 12, 31 // week 12 of 2021
+	e[18], e[19] = 1, 4
+	e[20] = 0x80 | 0x05 // digital, DisplayPort
+	e[21], e[22] = 60, 34
+
+	// Detailed timing: 2560x1440.
+	dtd := e[54:72]
+	dtd[0], dtd[1] = 0x56, 0x5E
+	dtd[2], dtd[4] = 0x00, 0xA0 // 0xA00 = 2560
+	dtd[5], dtd[7] = 0xA0, 0x50 // 0x5A0 = 1440
+
+	descriptor := func(off int, tag byte, text string) {
+		b := e[off : off+18]
+		b[3] = tag
+		copy(b[5:], fmt.Sprintf("%-13s", text+"\n"))
+	}
+	descriptor(72, 0xFC, "DELL U2720Q")
+	descriptor(90, 0xFF, "CN0ABC123")
+	descriptor(108, 0xFD, "")
+	return e
+}
+
+func TestParseEDID(t *testing.T) {
+	d, ok := parseEDID(testEDID())
+	if !ok {
+		t.Fatal("expected EDID to parse")
+	}
+	want := DisplayInfo{
+		Manufacturer:     "Dell",
+		ManufacturerID:   "DEL",
+		Model:            "DELL U2720Q",
+		ProductCode:      "A0B4",
+		SerialNumber:     "CN0ABC123",
+		ManufactureYear:  2021,
+		ManufactureWeek:  12,
+		NativeResolution: "2560x1440",
+		ConnectionType:   "DisplayPort",
+		WidthCm:          60,
+		HeightCm:         34,
+	}
+	if d != want {
+		t.Fatalf("got %+v\nwant %+v", d, want)
+	}
+
+	if _, ok := parseEDID(testEDID()[:100]); ok {
+		t.Fatal("expected short EDID to be rejected")
+	}
+	bad := testEDID()
+	bad[0] = 0x01
+	if _, ok := parseEDID(bad); ok {
+		t.Fatal("expected bad header to be rejected")
+	}
+}
+
+func TestDRMConnectorType(t *testing.T) {
+	cases := map[string]struct {
+		conn     string
+		internal bool
+	}{
+		"card0-eDP-1":       {"Internal", true},
+		"card1-HDMI-A-1":    {"HDMI", false},
+		"card0-DP-3":        {"DisplayPort", false},
+		"card0-DVI-D-1":     {"DVI", false},
+		"card0-VGA-1":       {"VGA", false},
+		"card0-Writeback-1": {"", false},
+	}
+	for name, want := range cases {
+		conn, internal := drmConnectorType(name)
+		if conn != want.conn || internal != want.internal {
+			t.Errorf("%s: got %q/%v, want %q/%v", name, conn, internal, want.conn, want.internal)
+		}
+	}
+}
+
+func TestParseWindowsMonitorsJSON(t *testing.T) {
+	edid := base64.StdEncoding.EncodeToString(testEDID())
+
+	// A single monitor arrives as a bare object; HDMI overrides the EDID's
+	// declared interface, and a missing EDID falls back to WmiMonitorID.
+	one := fmt.Sprintf(`{"Manufacturer":"DEL","Name":"DELL U2720Q","Serial":"0","ProductCode":"A0B4","Year":2021,"Week":12,"VideoOutputTechnology":5,"EDID":%q}`, edid)
+	displays, err := parseWindowsMonitorsJSON([]byte(one))
+	if err != nil || len(displays) != 1 {
+		t.Fatalf("unexpected result %+v %v", displays, err)
+	}
+	if d := displays[0]; d.ConnectionType != "HDMI" || d.NativeResolution != "2560x1440" || d.SerialNumber != "CN0ABC123" {
+		t.Fatalf("unexpected display %+v", d)
+	}
+
+	many := `[{"Manufacturer":"LEN","Name":"","Serial":"V1234","Year":2019,"Week":0,"VideoOutputTechnology":2147483648,"EDID":null},
+		{"Manufacturer":"SAM","Name":"S24","Serial":"","VideoOutputTechnology":null,"EDID":null}]`
+	displays, err = parseWindowsMonitorsJSON([]byte(many))
+	if err != nil || len(displays) != 2 {
+		t.Fatalf("unexpected result %+v %v", displays, err)
+	}
+	if d := displays[0]; d.Manufacturer != "Lenovo" || d.SerialNumber != "V1234" || !d.Internal || d.ConnectionType != "Internal" || d.ManufactureYear != 2019 {
+		t.Fatalf("unexpected internal panel %+v", d)
+	}
+	if d := displays[1]; d.Manufacturer != "Samsung" || d.Model != "S24" || d.ConnectionType != "" {
+		t.Fatalf("unexpected second display %+v", d)
+	}
+
+	if displays, err := parseWindowsMonitorsJSON([]byte("")); err != nil || displays != nil {
+		t.Fatalf("empty output should yield no displays, got %+v %v", displays, err)
+	}
+}
+
+func TestParseSPDisplaysJSON(t *testing.T) {
+	out := `{"SPDisplaysDataType":[{"_name":"Apple M2","spdisplays_ndrvs":[
+		{"_name":"Color LCD","_spdisplays_display-vendor-id":"610","_spdisplays_display-product-id":"a050",
+		 "_spdisplays_pixels":"2560 x 1664","_spdisplays_resolution":"1470 x 956 @ 60.00Hz","spdisplays_connection_type":"spdisplays_internal"},
+		{"_name":"DELL U2720Q","_spdisplays_display-vendor-id":"10ac","_spdisplays_display-product-id":"a0b4",
+		 "_spdisplays_display-serial-number":"CN0ABC123","_spdisplays_display-week":"12","_spdisplays_display-year":"2021",
+		 "_spdisplays_pixels":"3840 x 2160","_spdisplays_resolution":"1920 x 1080 @ 60.00Hz"}]}]}`
+	displays, err := parseSPDisplaysJSON([]byte(out))
+	if err != nil || len(displays) != 2 {
+		t.Fatalf("unexpected result %+v %v", displays, err)
+	}
+	if d := displays[0]; d.Manufacturer != "Apple" || !d.Internal || d.NativeResolution != "2560x1664" || d.CurrentResolution != "1470x956" {
+		t.Fatalf("unexpected built-in display %+v", d)
+	}
+	if d := displays[1]; d.ManufacturerID != "DEL" || d.ProductCode != "A0B4" || d.SerialNumber != "CN0ABC123" || d.ManufactureYear != 2021 || d.Internal {
+		t.Fatalf("unexpected external display %+v", d)
+	}
+}
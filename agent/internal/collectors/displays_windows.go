@@ -0,0 +1,41 @@
+//go:build windows
+
+package collectors
+
+import "log/slog"
+
+// displayQueryScript lists active monitors from WmiMonitorID, joined with
+// their connection type and the raw EDID from the monitor's device key.
+const displayQueryScript = `$ErrorActionPreference='SilentlyContinue'
+$conn=@{}
+Get-CimInstance -Namespace root\wmi -ClassName WmiMonitorConnectionParams | ForEach-Object { $conn[$_.InstanceName]=$_.VideoOutputTechnology }
+$str={ param($a) (($a | Where-Object { $_ -ne 0 } | ForEach-Object { [char]$_ }) -join '') }
+@(Get-CimInstance -Namespace root\wmi -ClassName WmiMonitorID | Where-Object { $_.Active } | ForEach-Object {
+  $key='HKLM:\SYSTEM\CurrentControlSet\Enum\' + ($_.InstanceName -replace '_\d+$','') + '\Device Parameters'
+  $edid=(Get-ItemProperty -Path $key -Name EDID).EDID
+  [pscustomobject]@{
+    Manufacturer=& $str $_.ManufacturerName
+    Name=& $str $_.UserFriendlyName
+    Serial=& $str $_.SerialNumberID
+    ProductCode=& $str $_.ProductCodeID
+    Year=$_.YearOfManufacture
+    Week=$_.WeekOfManufacture
+    VideoOutputTechnology=$conn[$_.InstanceName]
+    EDID=$(if ($edid) { [Convert]::ToBase64String($edid) } else { $null })
+  }
+}) | ConvertTo-Json -Compress`
+
+// collectDisplays reads attached monitors from the WMI monitor classes.
+func collectDisplays() []DisplayInfo {
+	out, err := runCollectorOutput(collectorLongCommandTimeout, "powershell", "-NoProfile", "-NonInteractive", "-Command", utf8PowerShellCommand(displayQueryScript))
+	if err != nil {
+		slog.Warn("display inventory query failed", "error", err.Error())
+		return nil
+	}
+	displays, err := parseWindowsMonitorsJSON(out)
+	if err != nil {
+		slog.Warn("failed to parse display inventory", "error", err.Error())
+		return nil
+	}
+	return displays
+}
@@ -47,6 +47,15 @@ type EventLogCollector struct {
 	categories       []string
 	minimumLevel     string
 	intervalMinutes  int
+	// queries are the server-defined custom queries (see SetQueries) and
+	// queryBuckets their per-query throttles.
+	queries      []EventLogQuery
+	queryBuckets map[string]*eventLogQueryBucket
+	// syslogUnits and syslogMinimumLevel filter the Linux syslog tail (see
+	// SetSyslogFilter); syslogTail is its position in the fallback file.
+	syslogUnits        []string
+	syslogMinimumLevel string
+	syslogTail         *syslogFileTail
 }
 
 // NewEventLogCollector creates a new EventLogCollector
@@ -60,7 +69,8 @@ func NewEventLogCollector() *EventLogCollector {
 		// `log show` pass costs seconds of CPU even when it returns nothing
 		// (issue #2390) — and error-level events don't need 5-minute freshness.
 		// Server-configurable 1-60 via UpdateConfig.
-		intervalMinutes: 15,
+		intervalMinutes:    15,
+		syslogMinimumLevel: defaultSyslogMinimumLevel,
 	}
 }
 
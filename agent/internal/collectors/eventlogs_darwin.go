@@ -390,3 +390,9 @@ func truncateString(s string, maxLen int) string {
 	}
 	return s[:maxLen] + "..."
 }
+
+// runEventLogQuery is a no-op: the unified log has no channel/event-ID model
+// for custom queries to map onto.
+func (c *EventLogCollector) runEventLogQuery(EventLogQuery, time.Time) ([]EventLogEntry, error) {
+	return nil, nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"os"
 	"os/exec"
 	"strings"
 	"sync"
@@ -30,6 +31,7 @@ func (c *EventLogCollector) Collect() ([]EventLogEntry, error) {
 		{"hardware", c.collectKernelErrors},
 		{"application", c.collectServiceFailures},
 		{"system", c.collectSystemEvents},
+		{"system", c.collectSyslogEvents},
 	}
 
 	// Filter to only enabled categories
@@ -72,6 +74,10 @@ func (c *EventLogCollector) Collect() ([]EventLogEntry, error) {
 		allEvents = allEvents[:maxEvents]
 	}
 
+	// Custom queries carry their own levels and rate limits, so they are
+	// neither level-filtered nor counted against maxEvents.
+	allEvents = append(allEvents, c.collectQueryEvents(lastCollect, time.Now())...)
+
 	return allEvents, nil
 }
 
@@ -363,3 +369,94 @@ func parseJournalJSONLines(output []byte) []journalEntry {
 	}
 	return entries
 }
+
+// runEventLogQuery runs one custom query against the journal.
+func (c *EventLogCollector) runEventLogQuery(q EventLogQuery, since time.Time) ([]EventLogEntry, error) {
+	entries, err := c.queryJournal(since, journalMatchesForQuery(q)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []EventLogEntry
+	for _, e := range entries {
+		results = append(results, EventLogEntry{
+			Timestamp: parseJournalTimestamp(e.RealtimeTimestamp),
+			Level:     mapSyslogPriority(e.Priority),
+			Source:    truncateCollectorString(e.SyslogIdentifier),
+			EventID:   truncateCollectorString(fmt.Sprintf("%s:%s", e.SyslogIdentifier, e.PID)),
+			Message:   truncateString(e.Message, 500),
+			Details: map[string]any{
+				"unit": truncateCollectorString(e.Unit),
+				"pid":  truncateCollectorString(e.PID),
+			},
+		})
+	}
+	return results, nil
+}
+
+// collectSyslogEvents tails the general system log: the journal when
+// journalctl exists, otherwise the first syslog file present.
+func (c *EventLogCollector) collectSyslogEvents(since time.Time) ([]EventLogEntry, error) {
+	units, minLevel := c.SyslogFilter()
+	if _, err := exec.LookPath("journalctl"); err == nil {
+		return c.collectSyslogJournal(since, units, minLevel)
+	}
+	return c.collectSyslogFile(units, minLevel)
+}
+
+func (c *EventLogCollector) collectSyslogJournal(since time.Time, units []string, minLevel string) ([]EventLogEntry, error) {
+	// Repeated _SYSTEMD_UNIT matches are ORed; PRIORITY is ANDed with them.
+	matches := []string{journalPriorityRange(minLevel)}
+	for _, u := range units {
+		matches = append(matches, "_SYSTEMD_UNIT="+u)
+	}
+	entries, err := c.queryJournal(since, matches...)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []EventLogEntry
+	for _, e := range entries {
+		if len(units) == 0 && builtinSyslogIdentifiers[e.SyslogIdentifier] {
+			continue
+		}
+		results = append(results, EventLogEntry{
+			Timestamp: parseJournalTimestamp(e.RealtimeTimestamp),
+			Level:     mapSyslogPriority(e.Priority),
+			Category:  "system",
+			Source:    truncateCollectorString(e.SyslogIdentifier),
+			EventID:   truncateCollectorString(fmt.Sprintf("%s:%s", e.SyslogIdentifier, e.PID)),
+			Message:   truncateString(e.Message, 500),
+			Details: map[string]any{
+				"unit": truncateCollectorString(e.Unit),
+				"pid":  truncateCollectorString(e.PID),
+			},
+		})
+	}
+	return results, nil
+}
+
+func (c *EventLogCollector) collectSyslogFile(units []string, minLevel string) ([]EventLogEntry, error) {
+	c.mu.Lock()
+	tail := c.syslogTail
+	if tail == nil {
+		for _, path := range syslogFilePaths {
+			if _, err := os.Stat(path); err == nil {
+				tail = &syslogFileTail{path: path}
+				c.syslogTail = tail
+				break
+			}
+		}
+	}
+	c.mu.Unlock()
+	if tail == nil {
+		// Neither journald nor a syslog file: nothing to tail.
+		return nil, nil
+	}
+
+	lines, err := tail.readNew()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", tail.path, err)
+	}
+	return syslogLinesToEntries(lines, tail.path, units, minLevel, time.Now()), nil
+}
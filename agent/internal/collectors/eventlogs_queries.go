@@ -0,0 +1,363 @@
+package collectors
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// EventLogQuery is a server-defined event log query collected alongside the
+// built-in categories. On Windows Channel is the event log name and Provider
+// the event source; on Linux Channel is a systemd unit ("*" for the whole
+// journal) and Provider a syslog identifier, and EventIDs are ignored.
+// Keywords are case-insensitive message substrings (any may match).
+// MaxPerHour throttles the query independently of every other query.
+type EventLogQuery struct {
+	Name       string   `json:"name"`
+	Channel    string   `json:"channel"`
+	Provider   string   `json:"provider,omitempty"`
+	EventIDs   []int    `json:"eventIds,omitempty"`
+	Levels     []string `json:"levels,omitempty"`
+	Keywords   []string `json:"keywords,omitempty"`
+	MaxPerHour int      `json:"maxPerHour,omitempty"`
+	Category   string   `json:"category,omitempty"`
+}
+
+const (
+	maxEventLogQueries         = 32
+	defaultEventLogQueryRate   = 60
+	maxEventLogQueryRate       = 3600
+	maxEventLogQueryKeywords   = 16
+	maxEventLogQueryKeywordLen = 128
+	maxEventLogQueryFieldLen   = 256
+	// maxEventLogQueryEventIDs is Get-WinEvent's FilterHashtable limit.
+	maxEventLogQueryEventIDs = 23
+	// eventLogQueryFetchLimit bounds one query's fetch per pass.
+	eventLogQueryFetchLimit = 200
+)
+
+var eventLogQueryNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// normalizeEventLogQueries validates queries, applying defaults. Invalid
+// queries are dropped and reported; the rest are returned sorted by name.
+func normalizeEventLogQueries(queries []EventLogQuery) ([]EventLogQuery, []error) {
+	var out []EventLogQuery
+	var errs []error
+	seen := make(map[string]bool, len(queries))
+	for _, q := range queries {
+		if err := normalizeEventLogQuery(&q); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if seen[q.Name] {
+			errs = append(errs, fmt.Errorf("event log query %q: duplicate name", q.Name))
+			continue
+		}
+		if len(out) >= maxEventLogQueries {
+			errs = append(errs, fmt.Errorf("event log query %q: more than %d queries", q.Name, maxEventLogQueries))
+			continue
+		}
+		seen[q.Name] = true
+		out = append(out, q)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, errs
+}
+
+func normalizeEventLogQuery(q *EventLogQuery) error {
+	q.Name = strings.TrimSpace(q.Name)
+	if !eventLogQueryNameRe.MatchString(q.Name) {
+		return fmt.Errorf("event log query %q: invalid name", q.Name)
+	}
+	fail := func(format string, args ...any) error {
+		return fmt.Errorf("event log query %q: %s", q.Name, fmt.Sprintf(format, args...))
+	}
+
+	q.Channel = strings.TrimSpace(q.Channel)
+	q.Provider = strings.TrimSpace(q.Provider)
+	if q.Channel == "" {
+		return fail("channel is required")
+	}
+	for _, field := range []string{q.Channel, q.Provider} {
+		if len(field) > maxEventLogQueryFieldLen || strings.IndexFunc(field, unicode.IsControl) >= 0 {
+			return fail("invalid channel or provider")
+		}
+	}
+
+	if len(q.EventIDs) > maxEventLogQueryEventIDs {
+		return fail("at most %d event IDs", maxEventLogQueryEventIDs)
+	}
+	for _, id := range q.EventIDs {
+		if id < 0 || id > 65535 {
+			return fail("event ID %d out of range", id)
+		}
+	}
+
+	levels := make([]string, 0, len(q.Levels))
+	for _, level := range q.Levels {
+		level = strings.ToLower(strings.TrimSpace(level))
+		if _, ok := levelOrder[level]; !ok {
+			return fail("unknown level %q", level)
+		}
+		if !categoryEnabled(levels, level) {
+			levels = append(levels, level)
+		}
+	}
+	q.Levels = levels
+
+	if len(q.Keywords) > maxEventLogQueryKeywords {
+		return fail("at most %d keywords", maxEventLogQueryKeywords)
+	}
+	keywords := make([]string, 0, len(q.Keywords))
+	for _, kw := range q.Keywords {
+		kw = strings.ToLower(strings.TrimSpace(kw))
+		if kw == "" {
+			continue
+		}
+		if len(kw) > maxEventLogQueryKeywordLen {
+			return fail("keyword longer than %d bytes", maxEventLogQueryKeywordLen)
+		}
+		keywords = append(keywords, kw)
+	}
+	q.Keywords = keywords
+
+	switch {
+	case q.MaxPerHour <= 0:
+		q.MaxPerHour = defaultEventLogQueryRate
+	case q.MaxPerHour > maxEventLogQueryRate:
+		q.MaxPerHour = maxEventLogQueryRate
+	}
+
+	q.Category = strings.ToLower(strings.TrimSpace(q.Category))
+	if q.Category == "" {
+		q.Category = "application"
+	}
+	if !validCategories[q.Category] {
+		return fail("unknown category %q", q.Category)
+	}
+	return nil
+}
+
+func equalEventLogQueries(a, b []EventLogQuery) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		x, y := a[i], b[i]
+		if x.Name != y.Name || x.Channel != y.Channel || x.Provider != y.Provider ||
+			x.MaxPerHour != y.MaxPerHour || x.Category != y.Category ||
+			!slicesEqual(x.Levels, y.Levels) || !slicesEqual(x.Keywords, y.Keywords) ||
+			len(x.EventIDs) != len(y.EventIDs) {
+			return false
+		}
+		for j := range x.EventIDs {
+			if x.EventIDs[j] != y.EventIDs[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SetQueries replaces the custom queries. Invalid entries are dropped and
+// returned as errors. It reports whether the effective set changed; a
+// changed query's throttle starts over.
+func (c *EventLogCollector) SetQueries(queries []EventLogQuery) (bool, []error) {
+	normalized, errs := normalizeEventLogQueries(queries)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if equalEventLogQueries(c.queries, normalized) {
+		return false, errs
+	}
+	previous := make(map[string]EventLogQuery, len(c.queries))
+	for _, q := range c.queries {
+		previous[q.Name] = q
+	}
+	buckets := make(map[string]*eventLogQueryBucket, len(normalized))
+	for _, q := range normalized {
+		if old, ok := previous[q.Name]; ok && equalEventLogQueries([]EventLogQuery{old}, []EventLogQuery{q}) {
+			if b := c.queryBuckets[q.Name]; b != nil {
+				buckets[q.Name] = b
+			}
+		}
+	}
+	c.queries = normalized
+	c.queryBuckets = buckets
+	return true, errs
+}
+
+// Queries returns a copy of the configured custom queries.
+func (c *EventLogCollector) Queries() []EventLogQuery {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]EventLogQuery(nil), c.queries...)
+}
+
+// eventLogQueryBucket is a token bucket holding up to MaxPerHour events,
+// refilled continuously at MaxPerHour per hour.
+type eventLogQueryBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// admit returns how many of n events the query may emit at now.
+func (c *EventLogCollector) admit(q EventLogQuery, n int, now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.queryBuckets == nil {
+		c.queryBuckets = make(map[string]*eventLogQueryBucket)
+	}
+	capacity := float64(q.MaxPerHour)
+	b := c.queryBuckets[q.Name]
+	if b == nil {
+		b = &eventLogQueryBucket{tokens: capacity, last: now}
+		c.queryBuckets[q.Name] = b
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(capacity, b.tokens+elapsed.Hours()*capacity)
+		b.last = now
+	}
+	allowed := min(n, int(b.tokens))
+	b.tokens -= float64(allowed)
+	return allowed
+}
+
+// matchesEventLogKeywords reports whether message contains any keyword
+// (already lower-cased); no keywords matches everything.
+func matchesEventLogKeywords(message string, keywords []string) bool {
+	if len(keywords) == 0 {
+		return true
+	}
+	lower := strings.ToLower(message)
+	for _, kw := range keywords {
+		if strings.Contains(lower, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectQueryEvents runs every custom query from since and returns the
+// keyword-filtered, throttled events stamped with the query name.
+func (c *EventLogCollector) collectQueryEvents(since, now time.Time) []EventLogEntry {
+	var all []EventLogEntry
+	for _, q := range c.Queries() {
+		events, err := c.runEventLogQuery(q, since)
+		if err != nil {
+			slog.Warn("event log query failed", "query", q.Name, "error", err.Error())
+			continue
+		}
+		matched := events[:0]
+		for _, e := range events {
+			if matchesEventLogKeywords(e.Message, q.Keywords) {
+				matched = append(matched, e)
+			}
+		}
+		allowed := c.admit(q, len(matched), now)
+		if dropped := len(matched) - allowed; dropped > 0 {
+			slog.Warn("event log query throttled", "query", q.Name, "dropped", dropped, "maxPerHour", q.MaxPerHour)
+		}
+		for _, e := range matched[:allowed] {
+			e.Category = q.Category
+			if e.Details == nil {
+				e.Details = map[string]any{}
+			}
+			e.Details["query"] = q.Name
+			all = append(all, e)
+		}
+	}
+	return all
+}
+
+// winEventLevelsForQuery maps query levels to Windows event levels
+// (1=Critical, 2=Error, 3=Warning, 4=Information, 0=LogAlways).
+func winEventLevelsForQuery(levels []string) []int {
+	var out []int
+	for _, level := range levels {
+		switch level {
+		case "critical":
+			out = append(out, 1)
+		case "error":
+			out = append(out, 2)
+		case "warning":
+			out = append(out, 3)
+		case "info":
+			out = append(out, 0, 4)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+// psSingleQuote quotes s as a PowerShell single-quoted string literal.
+func psSingleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
+
+// buildWinEventQueryScript renders the Get-WinEvent call for a query. Every
+// server-supplied string is passed as a quoted literal.
+func buildWinEventQueryScript(q EventLogQuery, since time.Time) string {
+	filter := []string{
+		"LogName=" + psSingleQuote(q.Channel),
+		"StartTime=" + psSingleQuote(since.UTC().Format(time.RFC3339)),
+	}
+	if q.Provider != "" {
+		filter = append(filter, "ProviderName="+psSingleQuote(q.Provider))
+	}
+	if len(q.EventIDs) > 0 {
+		filter = append(filter, "Id="+joinInts(q.EventIDs))
+	}
+	if levels := winEventLevelsForQuery(q.Levels); len(levels) > 0 {
+		filter = append(filter, "Level="+joinInts(levels))
+	}
+	return fmt.Sprintf(
+		`Get-WinEvent -FilterHashtable @{%s} -MaxEvents %d -ErrorAction SilentlyContinue | `+
+			`Select-Object RecordId, LogName, Level, LevelDisplayName, @{N='TimeCreated';E={$_.TimeCreated.ToString('o')}}, ProviderName, Id, Message | `+
+			`ConvertTo-Json -Depth 2 -Compress`,
+		strings.Join(filter, "; "), eventLogQueryFetchLimit,
+	)
+}
+
+// journalMatchesForQuery renders journalctl match arguments for a query.
+// Repeated matches on one field are ORed by journalctl; different fields
+// are ANDed.
+func journalMatchesForQuery(q EventLogQuery) []string {
+	var matches []string
+	if q.Channel != "*" {
+		matches = append(matches, "_SYSTEMD_UNIT="+q.Channel)
+	}
+	if q.Provider != "" {
+		matches = append(matches, "SYSLOG_IDENTIFIER="+q.Provider)
+	}
+	var priorities []string
+	for _, level := range q.Levels {
+		switch level {
+		case "critical":
+			priorities = append(priorities, "0", "1")
+		case "error":
+			priorities = append(priorities, "2", "3")
+		case "warning":
+			priorities = append(priorities, "4")
+		case "info":
+			priorities = append(priorities, "5", "6", "7")
+		}
+	}
+	sort.Strings(priorities)
+	for _, p := range priorities {
+		matches = append(matches, "PRIORITY="+p)
+	}
+	return matches
+}
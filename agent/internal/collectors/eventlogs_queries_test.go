@@ -0,0 +1,117 @@
+package collectors
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeEventLogQueries(t *testing.T) {
+	queries, errs := normalizeEventLogQueries([]EventLogQuery{
+		{Name: "zeta", Channel: " System ", Levels: []string{"Error", "error"}, Keywords: []string{" Disk ", ""}},
+		{Name: "alpha", Channel: "Security", EventIDs: []int{4624}, MaxPerHour: 100000, Category: "Security"},
+		{Name: "bad name", Channel: "System"},
+		{Name: "nochannel"},
+		{Name: "badid", Channel: "System", EventIDs: []int{70000}},
+		{Name: "badlevel", Channel: "System", Levels: []string{"verbose"}},
+		{Name: "badcat", Channel: "System", Category: "kernel"},
+		{Name: "ctrl", Channel: "Sys\ntem"},
+		{Name: "zeta", Channel: "Application"},
+	})
+	if len(errs) != 7 {
+		t.Fatalf("expected 7 errors, got %d: %v", len(errs), errs)
+	}
+	if len(queries) != 2 || queries[0].Name != "alpha" || queries[1].Name != "zeta" {
+		t.Fatalf("unexpected queries %+v", queries)
+	}
+	alpha, zeta := queries[0], queries[1]
+	if alpha.MaxPerHour != maxEventLogQueryRate || alpha.Category != "security" {
+		t.Fatalf("alpha not normalized: %+v", alpha)
+	}
+	if zeta.Channel != "System" || zeta.MaxPerHour != defaultEventLogQueryRate || zeta.Category != "application" {
+		t.Fatalf("zeta not normalized: %+v", zeta)
+	}
+	if !slicesEqual(zeta.Levels, []string{"error"}) || !slicesEqual(zeta.Keywords, []string{"disk"}) {
+		t.Fatalf("zeta levels/keywords not normalized: %+v", zeta)
+	}
+}
+
+func TestEventLogQueryThrottle(t *testing.T) {
+	c := NewEventLogCollector()
+	q := EventLogQuery{Name: "q", Channel: "System", MaxPerHour: 10}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if got := c.admit(q, 25, now); got != 10 {
+		t.Fatalf("first burst: admitted %d, want 10", got)
+	}
+	if got := c.admit(q, 5, now.Add(time.Minute)); got != 0 {
+		t.Fatalf("after a minute: admitted %d, want 0", got)
+	}
+	if got := c.admit(q, 5, now.Add(31*time.Minute)); got != 5 {
+		t.Fatalf("after half an hour: admitted %d, want 5", got)
+	}
+
+	// A newly configured or changed query starts with a full bucket; an
+	// unchanged one keeps its bucket.
+	c.SetQueries([]EventLogQuery{q})
+	if got := c.admit(c.Queries()[0], 10, now.Add(31*time.Minute)); got != 10 {
+		t.Fatalf("new query should start with a full bucket, admitted %d", got)
+	}
+	if changed, _ := c.SetQueries([]EventLogQuery{q}); changed {
+		t.Fatal("identical queries should not report a change")
+	}
+	if got := c.admit(c.Queries()[0], 10, now.Add(31*time.Minute)); got != 0 {
+		t.Fatalf("unchanged query should keep its bucket, admitted %d", got)
+	}
+	q.MaxPerHour = 20
+	c.SetQueries([]EventLogQuery{q})
+	if got := c.admit(c.Queries()[0], 50, now.Add(31*time.Minute)); got != 20 {
+		t.Fatalf("changed query should start with a full bucket, admitted %d", got)
+	}
+}
+
+func TestMatchesEventLogKeywords(t *testing.T) {
+	if !matchesEventLogKeywords("anything", nil) {
+		t.Fatal("no keywords should match everything")
+	}
+	if !matchesEventLogKeywords("The DISK is full", []string{"network", "disk"}) {
+		t.Fatal("keywords should match case-insensitively")
+	}
+	if matchesEventLogKeywords("all good", []string{"disk"}) {
+		t.Fatal("unexpected match")
+	}
+}
+
+func TestBuildWinEventQueryScriptQuotesLiterals(t *testing.T) {
+	q := EventLogQuery{
+		Name:     "q",
+		Channel:  "Microsoft-Windows-PowerShell/Operational",
+		Provider: "O'Brien'; Remove-Item C:\\ -Recurse; '",
+		EventIDs: []int{4103, 4104},
+		Levels:   []string{"warning", "critical"},
+	}
+	script := buildWinEventQueryScript(q, time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	for _, want := range []string{
+		"LogName='Microsoft-Windows-PowerShell/Operational'",
+		"StartTime='2026-01-01T12:00:00Z'",
+		"ProviderName='O''Brien''; Remove-Item C:\\ -Recurse; '''",
+		"Id=4103,4104",
+		"Level=1,3",
+		"-MaxEvents 200",
+	} {
+		if !strings.Contains(script, want) {
+			t.Fatalf("script missing %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestJournalMatchesForQuery(t *testing.T) {
+	got := journalMatchesForQuery(EventLogQuery{Channel: "sshd.service", Provider: "sshd", Levels: []string{"warning", "critical"}})
+	want := []string{"_SYSTEMD_UNIT=sshd.service", "SYSLOG_IDENTIFIER=sshd", "PRIORITY=0", "PRIORITY=1", "PRIORITY=4"}
+	if !slicesEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := journalMatchesForQuery(EventLogQuery{Channel: "*"}); len(got) != 0 {
+		t.Fatalf("wildcard channel should add no matches, got %v", got)
+	}
+}
@@ -0,0 +1,295 @@
+package collectors
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// The syslog source tails the general system log (journald, or the
+// rsyslog/syslog-ng files where there is no journal) rather than the fixed
+// identifiers the other Linux sub-collectors query. Entries are reported in
+// the "system" category.
+
+const (
+	// defaultSyslogMinimumLevel keeps the tail from shipping every info line
+	// a busy server writes.
+	defaultSyslogMinimumLevel = "warning"
+	maxSyslogUnits            = 32
+	// syslogTailMaxBytes bounds one pass over the syslog file; a larger
+	// backlog is skipped to its most recent part.
+	syslogTailMaxBytes = 1 << 20
+)
+
+// syslogFilePaths are the fallback log files, in order of preference
+// (Debian/Ubuntu, then RHEL/SUSE/Alpine).
+var syslogFilePaths = []string{"/var/log/syslog", "/var/log/messages"}
+
+// builtinSyslogIdentifiers are already collected by the dedicated
+// security/hardware/application/system sub-collectors; the unfiltered tail
+// skips them so an event is not reported twice under two categories.
+var builtinSyslogIdentifiers = map[string]bool{
+	"sshd": true, "sudo": true, "su": true, "kernel": true,
+	"systemd": true, "systemd-coredump": true,
+}
+
+var syslogUnitRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9@_.:\\-]{0,127}$`)
+
+// normalizeSyslogUnits validates a unit filter. Bare names get ".service";
+// invalid or duplicate entries are dropped.
+func normalizeSyslogUnits(units []string) []string {
+	seen := make(map[string]bool, len(units))
+	var out []string
+	for _, u := range units {
+		u = strings.TrimSpace(u)
+		if !syslogUnitRe.MatchString(u) {
+			continue
+		}
+		if !strings.Contains(u, ".") {
+			u += ".service"
+		}
+		if seen[u] || len(out) >= maxSyslogUnits {
+			continue
+		}
+		seen[u] = true
+		out = append(out, u)
+	}
+	return out
+}
+
+// SetSyslogFilter sets the unit and severity filters of the Linux syslog
+// tail. An empty unit list tails every unit; an unknown level leaves the
+// current one. It reports whether anything changed.
+func (c *EventLogCollector) SetSyslogFilter(units []string, minimumLevel string) bool {
+	units = normalizeSyslogUnits(units)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed := false
+	if !slicesEqual(c.syslogUnits, units) {
+		c.syslogUnits = units
+		changed = true
+	}
+	if _, ok := levelOrder[minimumLevel]; ok && c.syslogMinimumLevel != minimumLevel {
+		c.syslogMinimumLevel = minimumLevel
+		changed = true
+	}
+	return changed
+}
+
+// SyslogFilter returns the syslog tail's unit and severity filters.
+func (c *EventLogCollector) SyslogFilter() (units []string, minimumLevel string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.syslogUnits...), c.syslogMinimumLevel
+}
+
+// journalPriorityRange renders the PRIORITY match for a minimum level.
+func journalPriorityRange(minimumLevel string) string {
+	switch minimumLevel {
+	case "critical":
+		return "PRIORITY=0..2"
+	case "error":
+		return "PRIORITY=0..3"
+	case "info":
+		return "PRIORITY=0..6"
+	default:
+		return "PRIORITY=0..4"
+	}
+}
+
+// syslogLine is one parsed line of a classic syslog file.
+type syslogLine struct {
+	Timestamp time.Time
+	Host      string
+	Ident     string
+	PID       string
+	Message   string
+}
+
+var (
+	// 2026-01-02T15:04:05.123456+00:00 host ident[123]: message
+	syslogISORe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\S+)\s+(\S+)\s+(.*)$`)
+	// Jan  2 15:04:05 host ident[123]: message
+	syslogBSDRe = regexp.MustCompile(`^([A-Z][a-z]{2}\s+\d{1,2}\s+\d{2}:\d{2}:\d{2})\s+(\S+)\s+(.*)$`)
+	syslogTagRe = regexp.MustCompile(`^([^\s:\[]+)(?:\[(\d+)\])?:\s?(.*)$`)
+)
+
+// parseSyslogLine parses an RFC 3339 (rsyslog high-precision) or BSD-style
+// syslog line. BSD timestamps carry no year; the one that puts the line
+// closest before now is used.
+func parseSyslogLine(line string, now time.Time) (syslogLine, bool) {
+	var out syslogLine
+	var rest string
+	if m := syslogISORe.FindStringSubmatch(line); m != nil {
+		ts, err := time.Parse(time.RFC3339Nano, m[1])
+		if err != nil {
+			return out, false
+		}
+		out.Timestamp, out.Host, rest = ts, m[2], m[3]
+	} else if m := syslogBSDRe.FindStringSubmatch(line); m != nil {
+		ts, err := time.ParseInLocation("Jan _2 15:04:05 2006",
+			fmt.Sprintf("%s %d", strings.Join(strings.Fields(m[1]), " "), now.Year()), now.Location())
+		if err != nil {
+			return out, false
+		}
+		if ts.After(now.Add(24 * time.Hour)) {
+			ts = ts.AddDate(-1, 0, 0)
+		}
+		out.Timestamp, out.Host, rest = ts, m[2], m[3]
+	} else {
+		return out, false
+	}
+
+	if m := syslogTagRe.FindStringSubmatch(rest); m != nil {
+		out.Ident, out.PID, out.Message = m[1], m[2], m[3]
+	} else {
+		out.Message = rest
+	}
+	return out, true
+}
+
+// syslogLineLevel infers a level for a file line, which (unlike the journal)
+// carries no priority.
+func syslogLineLevel(message string) string {
+	msg := strings.ToLower(message)
+	switch {
+	case strings.Contains(msg, "panic") || strings.Contains(msg, "emerg") ||
+		strings.Contains(msg, "critical") || strings.Contains(msg, "fatal") ||
+		strings.Contains(msg, "out of memory") || strings.Contains(msg, "segfault"):
+		return "critical"
+	case strings.Contains(msg, "error") || strings.Contains(msg, "failed") ||
+		strings.Contains(msg, "failure"):
+		return "error"
+	case strings.Contains(msg, "warn"):
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// syslogIdentMatchesUnits reports whether a file line's identifier belongs to
+// one of the (normalized) units. Files carry no unit, so "nginx.service"
+// matches the identifier "nginx".
+func syslogIdentMatchesUnits(ident string, units []string) bool {
+	ident = strings.ToLower(ident)
+	for _, u := range units {
+		if i := strings.LastIndex(u, "."); i > 0 {
+			u = u[:i]
+		}
+		if strings.ToLower(u) == ident {
+			return true
+		}
+	}
+	return false
+}
+
+// syslogLinesToEntries filters parsed file lines and converts them.
+func syslogLinesToEntries(lines []string, path string, units []string, minimumLevel string, now time.Time) []EventLogEntry {
+	threshold := levelOrder[minimumLevel]
+	var results []EventLogEntry
+	for _, raw := range lines {
+		line, ok := parseSyslogLine(raw, now)
+		if !ok {
+			continue
+		}
+		if len(units) > 0 {
+			if !syslogIdentMatchesUnits(line.Ident, units) {
+				continue
+			}
+		} else if builtinSyslogIdentifiers[line.Ident] {
+			continue
+		}
+		level := syslogLineLevel(line.Message)
+		if levelOrder[level] < threshold {
+			continue
+		}
+		results = append(results, EventLogEntry{
+			Timestamp: line.Timestamp.UTC().Format(time.RFC3339),
+			Level:     level,
+			Category:  "system",
+			Source:    truncateCollectorString(line.Ident),
+			EventID:   truncateCollectorString(fmt.Sprintf("%s:%s", line.Ident, line.PID)),
+			Message:   truncateString(line.Message, 500),
+			Details: map[string]any{
+				"file": path,
+				"pid":  truncateCollectorString(line.PID),
+			},
+		})
+		if len(results) >= collectorResultLimit {
+			break
+		}
+	}
+	return results
+}
+
+// syslogFileTail reads lines appended to a log file since the previous call,
+// following rotation. The first call only records the end of the file.
+type syslogFileTail struct {
+	path   string
+	info   os.FileInfo
+	offset int64
+}
+
+// readNew returns the complete lines appended since the last call.
+func (t *syslogFileTail) readNew() ([]string, error) {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if t.info == nil {
+		t.info, t.offset = info, info.Size()
+		return nil, nil
+	}
+	if !os.SameFile(t.info, info) || info.Size() < t.offset {
+		// Rotated or truncated: read the new file from the start.
+		t.offset = 0
+	}
+	t.info = info
+
+	start := t.offset
+	skipped := false
+	if info.Size()-start > syslogTailMaxBytes {
+		start = info.Size() - syslogTailMaxBytes
+		skipped = true
+	}
+	buf := make([]byte, info.Size()-start)
+	n, err := f.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	buf = buf[:n]
+	if skipped {
+		// Resynchronize on a line boundary.
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			buf, start = buf[i+1:], start+int64(i+1)
+		} else {
+			buf = nil
+		}
+	}
+
+	// Leave a trailing partial line for the next pass.
+	end := bytes.LastIndexByte(buf, '\n')
+	if end < 0 {
+		t.offset = start
+		return nil, nil
+	}
+	t.offset = start + int64(end+1)
+
+	var lines []string
+	for _, line := range strings.Split(string(buf[:end]), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,119 @@
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseSyslogLine(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	line, ok := parseSyslogLine("2026-01-02T11:59:58.123456+00:00 web01 nginx[812]: upstream timed out", now)
+	if !ok || line.Host != "web01" || line.Ident != "nginx" || line.PID != "812" || line.Message != "upstream timed out" {
+		t.Fatalf("unexpected ISO parse %+v ok=%v", line, ok)
+	}
+	if !line.Timestamp.Equal(time.Date(2026, 1, 2, 11, 59, 58, 123456000, time.UTC)) {
+		t.Fatalf("unexpected ISO timestamp %v", line.Timestamp)
+	}
+
+	line, ok = parseSyslogLine("Jan  2 11:00:00 web01 CRON: (root) CMD (run-parts)", now)
+	if !ok || line.Ident != "CRON" || line.PID != "" || line.Timestamp.Year() != 2026 {
+		t.Fatalf("unexpected BSD parse %+v ok=%v", line, ok)
+	}
+
+	// A December line read in January belongs to the previous year.
+	line, ok = parseSyslogLine("Dec 31 23:59:59 web01 app[1]: bye", now)
+	if !ok || line.Timestamp.Year() != 2025 {
+		t.Fatalf("expected previous year, got %+v ok=%v", line, ok)
+	}
+
+	if _, ok := parseSyslogLine("not a syslog line", now); ok {
+		t.Fatal("expected garbage to be rejected")
+	}
+}
+
+func TestSyslogLinesToEntriesFilters(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	lines := []string{
+		"Jan  2 11:00:00 h nginx[1]: worker process exited on signal 11: error",
+		"Jan  2 11:00:01 h nginx[1]: reloading",
+		"Jan  2 11:00:02 h sshd[2]: error: PAM authentication failed",
+		"Jan  2 11:00:03 h postgres[3]: WARNING: checkpoints are occurring too frequently",
+	}
+
+	all := syslogLinesToEntries(lines, "/var/log/syslog", nil, "warning", now)
+	if len(all) != 2 || all[0].Source != "nginx" || all[0].Level != "error" || all[1].Level != "warning" {
+		t.Fatalf("unexpected unfiltered entries %+v", all)
+	}
+	if all[0].Category != "system" || all[0].Details["file"] != "/var/log/syslog" {
+		t.Fatalf("unexpected entry fields %+v", all[0])
+	}
+
+	units := syslogLinesToEntries(lines, "/var/log/syslog", []string{"postgres.service"}, "info", now)
+	if len(units) != 1 || units[0].Source != "postgres" {
+		t.Fatalf("unexpected unit-filtered entries %+v", units)
+	}
+}
+
+func TestSetSyslogFilter(t *testing.T) {
+	c := NewEventLogCollector()
+	if _, level := c.SyslogFilter(); level != defaultSyslogMinimumLevel {
+		t.Fatalf("unexpected default level %q", level)
+	}
+	if !c.SetSyslogFilter([]string{"nginx", " nginx.service ", "bad unit", "cron.timer"}, "error") {
+		t.Fatal("expected a change")
+	}
+	units, level := c.SyslogFilter()
+	if !slicesEqual(units, []string{"nginx.service", "cron.timer"}) || level != "error" {
+		t.Fatalf("unexpected filter %v %q", units, level)
+	}
+	if c.SetSyslogFilter(units, "bogus") {
+		t.Fatal("unknown level with unchanged units should not report a change")
+	}
+	if got := journalPriorityRange(level); got != "PRIORITY=0..3" {
+		t.Fatalf("unexpected priority range %q", got)
+	}
+}
+
+func TestSyslogFileTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syslog")
+	write := func(flag int, data string) {
+		t.Helper()
+		f, err := os.OpenFile(path, flag|os.O_WRONLY|os.O_CREATE, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(os.O_TRUNC, "old line\n")
+	tail := &syslogFileTail{path: path}
+	if lines, err := tail.readNew(); err != nil || len(lines) != 0 {
+		t.Fatalf("first read should only seek to the end, got %v %v", lines, err)
+	}
+
+	write(os.O_APPEND, "one\ntwo\npart")
+	lines, err := tail.readNew()
+	if err != nil || !slicesEqual(lines, []string{"one", "two"}) {
+		t.Fatalf("unexpected lines %v %v", lines, err)
+	}
+
+	write(os.O_APPEND, "ial\n")
+	if lines, _ := tail.readNew(); !slicesEqual(lines, []string{"partial"}) {
+		t.Fatalf("partial line not completed: %v", lines)
+	}
+
+	// Rotation: the file is replaced by a new, shorter one.
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	write(os.O_TRUNC, "fresh\n")
+	if lines, _ := tail.readNew(); !slicesEqual(lines, []string{"fresh"}) {
+		t.Fatalf("rotation not followed: %v", lines)
+	}
+}
@@ -72,6 +72,10 @@ func (c *EventLogCollector) Collect() ([]EventLogEntry, error) {
 		allEvents = allEvents[:maxEvents]
 	}
 
+	// Custom queries carry their own levels and rate limits, so they are
+	// neither level-filtered nor counted against maxEvents.
+	allEvents = append(allEvents, c.collectQueryEvents(lastCollect, time.Now())...)
+
 	return allEvents, nil
 }
 
@@ -359,3 +363,29 @@ func truncateString(s string, maxLen int) string {
 	}
 	return s[:maxLen] + "..."
 }
+
+// runEventLogQuery runs one custom query through Get-WinEvent.
+func (c *EventLogCollector) runEventLogQuery(q EventLogQuery, since time.Time) ([]EventLogEntry, error) {
+	output, err := runCollectorOutput(collectorLongCommandTimeout, "powershell", "-NoProfile", "-NonInteractive", "-Command", utf8PowerShellCommand(buildWinEventQueryScript(q, since)))
+	if err != nil {
+		// No events found is not an error
+		return nil, nil
+	}
+
+	var results []EventLogEntry
+	for _, e := range parseWinEventJSON(output) {
+		results = append(results, EventLogEntry{
+			Timestamp: truncateCollectorString(e.TimeCreated),
+			Level:     mapWinLevel(e.Level),
+			Source:    truncateCollectorString(e.ProviderName),
+			EventID:   truncateCollectorString(fmt.Sprintf("%d:%d", e.Id, e.RecordId)),
+			Message:   truncateString(e.Message, 500),
+			Details: map[string]any{
+				"recordId": e.RecordId,
+				"logName":  truncateCollectorString(e.LogName),
+				"eventId":  e.Id,
+			},
+		})
+	}
+	return results, nil
+}
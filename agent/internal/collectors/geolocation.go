@@ -0,0 +1,358 @@
+package collectors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Device geolocation for lost/stolen laptop workflows. Opt-in: the server
+// only enables it where org or site policy allows, and while disabled
+// nothing is looked up or kept. The OS location service is used where it
+// exists and the agent is permitted to use it; otherwise the approximate
+// position comes from a geolocation service given the visible Wi-Fi BSSIDs
+// and the public IP. Lookups run in the background so the heartbeat never
+// waits on them.
+
+// Location sources.
+const (
+	GeoSourceOS   = "os"
+	GeoSourceWiFi = "wifi"
+	GeoSourceIP   = "ip"
+)
+
+// DefaultGeolocationLookupURL speaks the Google/MLS geolocate protocol.
+const DefaultGeolocationLookupURL = "https://api.beacondb.net/v1/geolocate"
+
+const (
+	// geolocationRefreshInterval is how often a new fix is attempted.
+	geolocationRefreshInterval = 15 * time.Minute
+	// geolocationMaxAge drops a fix that could not be refreshed for this
+	// long rather than keep reporting a stale position.
+	geolocationMaxAge        = 2 * time.Hour
+	geolocationLookupTimeout = 20 * time.Second
+	// maxGeolocationAccessPoints bounds the BSSIDs sent to the service.
+	maxGeolocationAccessPoints = 20
+)
+
+// GeoLocation is an approximate position. AccuracyMeters is the radius the
+// source reports; IP-based fixes are typically city-level.
+type GeoLocation struct {
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	AccuracyMeters float64   `json:"accuracyMeters,omitempty"`
+	Source         string    `json:"source"`
+	CollectedAt    time.Time `json:"collectedAt"`
+}
+
+type GeolocationCollector struct {
+	mu          sync.Mutex
+	enabled     bool
+	lookupURL   string
+	wifi        *WiFiCollector
+	client      *http.Client
+	current     *GeoLocation
+	lastAttempt time.Time
+	refreshing  bool
+	now         func() time.Time
+	locate      func() (*GeoLocation, error)
+}
+
+// NewGeolocationCollector uses wifi (may be nil) for nearby BSSIDs. An
+// empty or non-HTTPS lookupURL uses DefaultGeolocationLookupURL.
+func NewGeolocationCollector(enabled bool, lookupURL string, wifi *WiFiCollector) *GeolocationCollector {
+	c := &GeolocationCollector{
+		enabled:   enabled,
+		lookupURL: geolocationLookupURL(lookupURL),
+		wifi:      wifi,
+		client:    &http.Client{Timeout: geolocationLookupTimeout},
+		now:       time.Now,
+	}
+	c.locate = c.locateOnce
+	return c
+}
+
+func geolocationLookupURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return DefaultGeolocationLookupURL
+	}
+	if u, err := url.Parse(raw); err != nil || u.Scheme != "https" || u.Host == "" {
+		slog.Warn("ignoring invalid geolocation lookup URL, using default", "url", raw)
+		return DefaultGeolocationLookupURL
+	}
+	return raw
+}
+
+func (c *GeolocationCollector) Enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enabled
+}
+
+// SetEnabled turns reporting on or off. Turning it off forgets the last fix.
+func (c *GeolocationCollector) SetEnabled(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.enabled = enabled
+	if !enabled {
+		c.current = nil
+		c.lastAttempt = time.Time{}
+	}
+}
+
+// Current returns the latest fix, or nil when disabled or none is known
+// yet. A refresh is started in the background when the fix is due.
+func (c *GeolocationCollector) Current() *GeoLocation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled {
+		return nil
+	}
+	now := c.now()
+	if !c.refreshing && now.Sub(c.lastAttempt) >= geolocationRefreshInterval {
+		c.refreshing = true
+		c.lastAttempt = now
+		go c.refresh()
+	}
+	if c.current == nil || now.Sub(c.current.CollectedAt) > geolocationMaxAge {
+		return nil
+	}
+	loc := *c.current
+	return &loc
+}
+
+func (c *GeolocationCollector) refresh() {
+	loc, err := c.locate()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshing = false
+	if err != nil {
+		slog.Debug("geolocation lookup failed", "error", err.Error())
+		return
+	}
+	// Disabled while the lookup was in flight.
+	if !c.enabled || loc == nil {
+		return
+	}
+	loc.CollectedAt = c.now().UTC()
+	c.current = loc
+}
+
+// locateOnce tries the OS location service, then the lookup service.
+func (c *GeolocationCollector) locateOnce() (*GeoLocation, error) {
+	if loc, err := collectOSLocation(); err == nil && loc != nil {
+		loc.Source = GeoSourceOS
+		return loc, nil
+	} else if err != nil {
+		slog.Debug("OS location unavailable", "error", err.Error())
+	}
+
+	var networks []WiFiNetwork
+	if c.wifi != nil {
+		if status, err := c.wifi.Collect(); err == nil && status != nil {
+			networks = append(networks, status.Connected...)
+			networks = append(networks, status.Nearby...)
+		}
+	}
+	return c.lookup(networks)
+}
+
+// lookup asks the geolocation service, which falls back to the request's
+// public IP when the networks are unknown to it.
+func (c *GeolocationCollector) lookup(networks []WiFiNetwork) (*GeoLocation, error) {
+	body, err := json.Marshal(buildGeolocateRequest(networks))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), geolocationLookupTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.lookupURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geolocation lookup returned %d", resp.StatusCode)
+	}
+	return parseGeolocateResponse(data)
+}
+
+type geolocateAccessPoint struct {
+	MacAddress     string `json:"macAddress"`
+	SignalStrength int    `json:"signalStrength,omitempty"`
+}
+
+type geolocateRequest struct {
+	ConsiderIP       bool                   `json:"considerIp"`
+	WiFiAccessPoints []geolocateAccessPoint `json:"wifiAccessPoints,omitempty"`
+	Fallbacks        struct {
+		IPF bool `json:"ipf"`
+	} `json:"fallbacks"`
+}
+
+// buildGeolocateRequest lists the usable BSSIDs, strongest first as the
+// Wi-Fi collector orders them. Networks with a hidden SSID or a "_nomap"
+// suffix opted out of location databases and are skipped, as are locally
+// administered (randomized) addresses.
+func buildGeolocateRequest(networks []WiFiNetwork) geolocateRequest {
+	req := geolocateRequest{ConsiderIP: true}
+	req.Fallbacks.IPF = true
+	seen := make(map[string]bool)
+	for _, n := range networks {
+		if len(req.WiFiAccessPoints) >= maxGeolocationAccessPoints {
+			break
+		}
+		if n.SSID == "" || strings.HasSuffix(n.SSID, "_nomap") {
+			continue
+		}
+		mac, ok := normalizeBSSID(n.BSSID)
+		if !ok || seen[mac] {
+			continue
+		}
+		seen[mac] = true
+		ap := geolocateAccessPoint{MacAddress: mac}
+		if n.SignalDBM != nil {
+			ap.SignalStrength = *n.SignalDBM
+		}
+		req.WiFiAccessPoints = append(req.WiFiAccessPoints, ap)
+	}
+	return req
+}
+
+var bssidRe = regexp.MustCompile(`^[0-9a-f]{2}(:[0-9a-f]{2}){5}$`)
+
+func normalizeBSSID(raw string) (string, bool) {
+	mac := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(raw), "-", ":"))
+	if !bssidRe.MatchString(mac) || mac == "00:00:00:00:00:00" || mac == "ff:ff:ff:ff:ff:ff" {
+		return "", false
+	}
+	first, _ := strconv.ParseUint(mac[:2], 16, 8)
+	if first&0x02 != 0 {
+		return "", false
+	}
+	return mac, true
+}
+
+type geolocateResponse struct {
+	Location *struct {
+		Lat float64 `json:"lat"`
+		Lng float64 `json:"lng"`
+	} `json:"location"`
+	Accuracy float64 `json:"accuracy"`
+	Fallback string  `json:"fallback"`
+}
+
+// parseGeolocateResponse reads a geolocate reply. A "fallback" of "ipf"
+// (or "lacf") means the Wi-Fi data was not used.
+func parseGeolocateResponse(data []byte) (*GeoLocation, error) {
+	var resp geolocateResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse geolocation response: %w", err)
+	}
+	if resp.Location == nil || !validCoordinates(resp.Location.Lat, resp.Location.Lng) {
+		return nil, fmt.Errorf("geolocation response has no usable location")
+	}
+	source := GeoSourceWiFi
+	if resp.Fallback != "" {
+		source = GeoSourceIP
+	}
+	return &GeoLocation{
+		Latitude:       resp.Location.Lat,
+		Longitude:      resp.Location.Lng,
+		AccuracyMeters: math.Max(resp.Accuracy, 0),
+		Source:         source,
+	}, nil
+}
+
+func validCoordinates(lat, lng float64) bool {
+	if math.IsNaN(lat) || math.IsNaN(lng) || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return false
+	}
+	// 0,0 is what failed providers report.
+	return lat != 0 || lng != 0
+}
+
+// windowsLocationOutput is the object the Windows location script emits.
+type windowsLocationOutput struct {
+	Latitude  float64 `json:"Latitude"`
+	Longitude float64 `json:"Longitude"`
+	Accuracy  float64 `json:"Accuracy"`
+}
+
+// parseWindowsLocationJSON reads the GeoCoordinateWatcher script output.
+// Empty output (no permission, no fix) yields nil.
+func parseWindowsLocationJSON(data []byte) (*GeoLocation, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "" || trimmed == "null" {
+		return nil, nil
+	}
+	var out windowsLocationOutput
+	if err := json.Unmarshal([]byte(trimmed), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse location JSON: %w", err)
+	}
+	if !validCoordinates(out.Latitude, out.Longitude) {
+		return nil, nil
+	}
+	loc := &GeoLocation{Latitude: out.Latitude, Longitude: out.Longitude}
+	if !math.IsNaN(out.Accuracy) && out.Accuracy > 0 {
+		loc.AccuracyMeters = out.Accuracy
+	}
+	return loc, nil
+}
+
+// parseWhereAmI reads the geoclue where-am-i demo output:
+//
+//	Latitude:    51.507400°
+//	Longitude:   -0.127800°
+//	Accuracy:    25000.000000 meters
+func parseWhereAmI(output string) *GeoLocation {
+	var lat, lng, acc float64
+	var haveLat, haveLng bool
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		value = strings.TrimSuffix(value, "°")
+		value = strings.TrimSpace(strings.TrimSuffix(value, "meters"))
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "Latitude":
+			lat, haveLat = f, true
+		case "Longitude":
+			lng, haveLng = f, true
+		case "Accuracy":
+			acc = f
+		}
+	}
+	if !haveLat || !haveLng || !validCoordinates(lat, lng) {
+		return nil
+	}
+	return &GeoLocation{Latitude: lat, Longitude: lng, AccuracyMeters: math.Max(acc, 0)}
+}
@@ -0,0 +1,29 @@
+//go:build linux
+
+package collectors
+
+import (
+	"os"
+	"time"
+)
+
+// GeoClue's where-am-i demo client; distributions ship it under libexec or
+// lib. GeoClue's own agent configuration decides whether it answers.
+var whereAmIPaths = []string{
+	"/usr/libexec/geoclue-2.0/demos/where-am-i",
+	"/usr/lib/geoclue-2.0/demos/where-am-i",
+}
+
+func collectOSLocation() (*GeoLocation, error) {
+	for _, path := range whereAmIPaths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		output, err := runCollectorOutput(20*time.Second, path, "-t", "10")
+		if err != nil {
+			return nil, err
+		}
+		return parseWhereAmI(string(output)), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows && !linux
+
+package collectors
+
+// macOS only grants Core Location to bundled apps the user has approved,
+// which the agent daemon is not, so only the lookup service is used.
+func collectOSLocation() (*GeoLocation, error) {
+	return nil, nil
+}
@@ -0,0 +1,161 @@
+package collectors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBuildGeolocateRequest(t *testing.T) {
+	strong, weak := -48, -80
+	req := buildGeolocateRequest([]WiFiNetwork{
+		{SSID: "Office", BSSID: "A4-2B-B0-11-22-33", SignalDBM: &strong},
+		{SSID: "Office", BSSID: "a4:2b:b0:11:22:33", SignalDBM: &weak},
+		{SSID: "Cafe_nomap", BSSID: "a4:2b:b0:11:22:44"},
+		{SSID: "", BSSID: "a4:2b:b0:11:22:55"},
+		{SSID: "Phone", BSSID: "da:a1:19:00:00:01"},
+		{SSID: "Guest", BSSID: "00:1a:2b:3c:4d:5e", SignalDBM: &weak},
+		{SSID: "Broken", BSSID: "not-a-mac"},
+	})
+	if !req.ConsiderIP || !req.Fallbacks.IPF {
+		t.Fatalf("expected IP fallback to be allowed: %+v", req)
+	}
+	if len(req.WiFiAccessPoints) != 2 {
+		t.Fatalf("unexpected access points %+v", req.WiFiAccessPoints)
+	}
+	if req.WiFiAccessPoints[0].MacAddress != "a4:2b:b0:11:22:33" || req.WiFiAccessPoints[0].SignalStrength != -48 {
+		t.Fatalf("unexpected first access point %+v", req.WiFiAccessPoints[0])
+	}
+	if req.WiFiAccessPoints[1].MacAddress != "00:1a:2b:3c:4d:5e" {
+		t.Fatalf("unexpected second access point %+v", req.WiFiAccessPoints[1])
+	}
+}
+
+func TestParseGeolocateResponse(t *testing.T) {
+	loc, err := parseGeolocateResponse([]byte(`{"location":{"lat":52.52,"lng":13.405},"accuracy":35.5}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loc.Source != GeoSourceWiFi || loc.Latitude != 52.52 || loc.Longitude != 13.405 || loc.AccuracyMeters != 35.5 {
+		t.Fatalf("unexpected location %+v", loc)
+	}
+
+	loc, err = parseGeolocateResponse([]byte(`{"location":{"lat":52.5,"lng":13.4},"accuracy":25000,"fallback":"ipf"}`))
+	if err != nil || loc.Source != GeoSourceIP {
+		t.Fatalf("expected ip fallback, got %+v, %v", loc, err)
+	}
+
+	for _, body := range []string{`{"error":{"code":404}}`, `{"location":{"lat":0,"lng":0}}`, `{"location":{"lat":95,"lng":10}}`, `nope`} {
+		if _, err := parseGeolocateResponse([]byte(body)); err == nil {
+			t.Fatalf("expected error for %s", body)
+		}
+	}
+}
+
+func TestParseWindowsLocationJSON(t *testing.T) {
+	loc, err := parseWindowsLocationJSON([]byte(`{"Latitude":47.6062,"Longitude":-122.3321,"Accuracy":65}`))
+	if err != nil || loc == nil || loc.Latitude != 47.6062 || loc.AccuracyMeters != 65 {
+		t.Fatalf("unexpected location %+v, %v", loc, err)
+	}
+	if loc, err := parseWindowsLocationJSON([]byte("\r\n")); loc != nil || err != nil {
+		t.Fatalf("expected nil for empty output, got %+v, %v", loc, err)
+	}
+}
+
+func TestParseWhereAmI(t *testing.T) {
+	output := "Client object: /org/freedesktop/GeoClue2/Client/1\n\nNew location:\nLatitude:    51.507400°\nLongitude:   -0.127800°\nAccuracy:    25000.000000 meters\nAltitude:    Unknown\n"
+	loc := parseWhereAmI(output)
+	if loc == nil || loc.Latitude != 51.5074 || loc.Longitude != -0.1278 || loc.AccuracyMeters != 25000 {
+		t.Fatalf("unexpected location %+v", loc)
+	}
+	if loc := parseWhereAmI("Client object: /org/freedesktop/GeoClue2/Client/1\n"); loc != nil {
+		t.Fatalf("expected nil without a fix, got %+v", loc)
+	}
+}
+
+func TestGeolocationCollectorLookup(t *testing.T) {
+	var got geolocateRequest
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("bad request body: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"location":{"lat":40.7128,"lng":-74.006},"accuracy":5000,"fallback":"ipf"}`))
+	}))
+	defer srv.Close()
+
+	c := NewGeolocationCollector(true, srv.URL, nil)
+	c.client = srv.Client()
+	c.locate = func() (*GeoLocation, error) {
+		// Skip the OS source so the test exercises the lookup service alone.
+		return c.lookup(nil)
+	}
+	loc, err := c.locate()
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if loc.Source != GeoSourceIP || loc.Latitude != 40.7128 || !got.ConsiderIP {
+		t.Fatalf("unexpected result %+v (request %+v)", loc, got)
+	}
+}
+
+func TestGeolocationCollectorCurrent(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	c := NewGeolocationCollector(false, "", nil)
+	c.now = func() time.Time { return now }
+	calls := make(chan struct{}, 4)
+	c.locate = func() (*GeoLocation, error) {
+		calls <- struct{}{}
+		return &GeoLocation{Latitude: 1, Longitude: 2, Source: GeoSourceWiFi}, nil
+	}
+
+	if loc := c.Current(); loc != nil {
+		t.Fatalf("disabled collector returned %+v", loc)
+	}
+	c.SetEnabled(true)
+	if loc := c.Current(); loc != nil {
+		t.Fatalf("expected no fix before the first lookup, got %+v", loc)
+	}
+	<-calls
+	waitForGeolocationRefresh(t, c)
+
+	loc := c.Current()
+	if loc == nil || loc.Latitude != 1 || !loc.CollectedAt.Equal(now) {
+		t.Fatalf("unexpected fix %+v", loc)
+	}
+	select {
+	case <-calls:
+		t.Fatal("refreshed before the interval elapsed")
+	default:
+	}
+
+	now = now.Add(geolocationMaxAge + time.Minute)
+	if loc := c.Current(); loc != nil {
+		t.Fatalf("stale fix should not be reported, got %+v", loc)
+	}
+	<-calls
+	waitForGeolocationRefresh(t, c)
+
+	c.SetEnabled(false)
+	c.SetEnabled(true)
+	c.locate = func() (*GeoLocation, error) { return nil, nil }
+	if loc := c.Current(); loc != nil {
+		t.Fatalf("disabling should forget the fix, got %+v", loc)
+	}
+}
+
+func waitForGeolocationRefresh(t *testing.T, c *GeolocationCollector) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		done := !c.refreshing
+		c.mu.Unlock()
+		if done {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("geolocation refresh did not finish")
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package collectors
+
+import "time"
+
+// GeoCoordinateWatcher goes through the Windows location platform, so it
+// yields nothing unless location services are on and apps (including
+// desktop apps) may use them. Permission denied or no fix emits nothing.
+const windowsLocationScript = `$ErrorActionPreference='SilentlyContinue'
+Add-Type -AssemblyName System.Device
+$w=New-Object System.Device.Location.GeoCoordinateWatcher([System.Device.Location.GeoPositionAccuracy]::High)
+if(-not $w.TryStart($false,[TimeSpan]::FromSeconds(10))){return}
+$deadline=(Get-Date).AddSeconds(15)
+while($w.Status -ne 'Ready' -and $w.Permission -ne 'Denied' -and (Get-Date) -lt $deadline){Start-Sleep -Milliseconds 250}
+$loc=$w.Position.Location
+$w.Stop()
+if($w.Permission -eq 'Denied' -or $loc -eq $null -or $loc.IsUnknown){return}
+[pscustomobject]@{Latitude=$loc.Latitude;Longitude=$loc.Longitude;Accuracy=$(if([double]::IsNaN($loc.HorizontalAccuracy)){0}else{$loc.HorizontalAccuracy})} | ConvertTo-Json -Compress`
+
+func collectOSLocation() (*GeoLocation, error) {
+	output, err := runCollectorOutput(45*time.Second, "powershell", "-NoProfile", "-NonInteractive", "-Command", utf8PowerShellCommand(windowsLocationScript))
+	if err != nil {
+		return nil, err
+	}
+	return parseWindowsLocationJSON(output)
+}
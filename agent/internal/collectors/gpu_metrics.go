@@ -0,0 +1,316 @@
+package collectors
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GPU telemetry rides the heartbeat alongside CPU/RAM so CAD and video
+// workstations show GPU saturation in the same place.
+//
+// Sources, in order of preference:
+//   - NVIDIA on any OS: nvidia-smi (the NVML CLI — no cgo binding needed).
+//   - Linux AMD/Intel: DRM sysfs (gpu_busy_percent, mem_info_vram_*, hwmon).
+//   - Windows: the DXGI-backed "GPU Engine"/"GPU Adapter Memory" perf
+//     counters plus Win32_VideoController for name/driver.
+//   - macOS: IOAccelerator PerformanceStatistics from ioreg (Metal devices).
+//
+// Every dynamic field is a pointer so "not reported by this source" is
+// distinct from a real 0% / 0 MB reading.
+
+// GPUMetrics is one adapter's current utilization snapshot.
+type GPUMetrics struct {
+	Index              int      `json:"index"`
+	Name               string   `json:"name"`
+	Vendor             string   `json:"vendor,omitempty"`
+	DriverVersion      string   `json:"driverVersion,omitempty"`
+	UtilizationPercent *float64 `json:"utilizationPercent,omitempty"`
+	MemoryUsedMB       *uint64  `json:"memoryUsedMb,omitempty"`
+	MemoryTotalMB      *uint64  `json:"memoryTotalMb,omitempty"`
+	TemperatureC       *float64 `json:"temperatureC,omitempty"`
+	Source             string   `json:"source"`
+}
+
+const (
+	gpuSourceNVML   = "nvml"
+	gpuSourceSysfs  = "sysfs"
+	gpuSourceDXGI   = "dxgi"
+	gpuSourceMetal  = "metal"
+	gpuVendorNVIDIA = "nvidia"
+	gpuVendorAMD    = "amd"
+	gpuVendorIntel  = "intel"
+	gpuVendorApple  = "apple"
+
+	// gpuAbsentRecheck is how long a "no GPU telemetry available" result is
+	// trusted before probing again, so GPU-less servers don't spawn a probe
+	// process on every heartbeat.
+	gpuAbsentRecheck = 30 * time.Minute
+)
+
+// drmRoot is the Linux DRM sysfs directory. A var so tests can point
+// collectGPUsFromSysfs at a fixture tree.
+var drmRoot = "/sys/class/drm"
+
+// collectGPUs returns the GPU snapshot for this heartbeat, honoring the
+// negative-result cache so devices without GPU telemetry stay cheap. Where
+// the platform source is expensive (gpuSampleInterval > 0) the last snapshot
+// is reused until the interval has passed.
+func (c *MetricsCollector) collectGPUs(now time.Time) []GPUMetrics {
+	if now.Before(c.gpuAbsentUntil) {
+		return nil
+	}
+	if gpuSampleInterval > 0 && c.gpuLast != nil && now.Sub(c.gpuSampledAt) < gpuSampleInterval {
+		return c.gpuLast
+	}
+	gpus := collectGPUMetrics()
+	if len(gpus) == 0 {
+		c.gpuAbsentUntil = now.Add(gpuAbsentRecheck)
+		c.gpuLast = nil
+		return nil
+	}
+	for i := range gpus {
+		gpus[i].Name = truncateCollectorString(gpus[i].Name)
+		gpus[i].DriverVersion = truncateCollectorString(gpus[i].DriverVersion)
+	}
+	c.gpuLast, c.gpuSampledAt = gpus, now
+	return gpus
+}
+
+// collectGPUMetrics merges the NVIDIA source with the platform source. The
+// platform source skips NVIDIA adapters whenever nvidia-smi answered, so an
+// adapter is never reported twice.
+func collectGPUMetrics() []GPUMetrics {
+	nvidia := collectNvidiaSMI()
+	gpus := append([]GPUMetrics{}, nvidia...)
+	gpus = append(gpus, collectPlatformGPUMetrics(len(nvidia) > 0)...)
+	for i := range gpus {
+		gpus[i].Index = i
+	}
+	return gpus
+}
+
+func collectNvidiaSMI() []GPUMetrics {
+	bin, err := exec.LookPath("nvidia-smi")
+	if err != nil {
+		return nil
+	}
+	output, err := runCollectorOutput(collectorShortCommandTimeout, bin,
+		"--query-gpu=index,name,driver_version,utilization.gpu,memory.used,memory.total,temperature.gpu",
+		"--format=csv,noheader,nounits")
+	if err != nil {
+		return nil
+	}
+	return parseNvidiaSMI(string(output))
+}
+
+// parseNvidiaSMI parses `nvidia-smi --query-gpu=... --format=csv,noheader,nounits`
+// output. Fields nvidia-smi can't read come back as "[N/A]" / "[Not Supported]"
+// and are left nil.
+func parseNvidiaSMI(output string) []GPUMetrics {
+	var gpus []GPUMetrics
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) < 7 {
+			continue
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		gpu := GPUMetrics{
+			Name:          fields[1],
+			Vendor:        gpuVendorNVIDIA,
+			DriverVersion: fields[2],
+			Source:        gpuSourceNVML,
+		}
+		if v, ok := parseGPUFloat(fields[3]); ok {
+			gpu.UtilizationPercent = floatPtr(clampPercent(v))
+		}
+		if v, ok := parseGPUFloat(fields[4]); ok {
+			gpu.MemoryUsedMB = uint64Ptr(uint64(v))
+		}
+		if v, ok := parseGPUFloat(fields[5]); ok {
+			gpu.MemoryTotalMB = uint64Ptr(uint64(v))
+		}
+		if v, ok := parseGPUFloat(fields[6]); ok {
+			gpu.TemperatureC = floatPtr(v)
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus
+}
+
+func parseGPUFloat(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+func uint64Ptr(v uint64) *uint64 { return &v }
+
+// pciVendorName maps a PCI vendor ID from sysfs ("0x1002") to our vendor token.
+func pciVendorName(id string) string {
+	switch strings.ToLower(strings.TrimSpace(id)) {
+	case "0x10de":
+		return gpuVendorNVIDIA
+	case "0x1002":
+		return gpuVendorAMD
+	case "0x8086":
+		return gpuVendorIntel
+	default:
+		return ""
+	}
+}
+
+// collectGPUsFromSysfs reads DRM card devices under root. Build-tag-free so it
+// unit-tests against a fixture tree on any platform. NVIDIA cards are skipped
+// when skipNvidia is set (nvidia-smi already reported them).
+func collectGPUsFromSysfs(root string, skipNvidia bool) []GPUMetrics {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+
+	var gpus []GPUMetrics
+	for _, e := range entries {
+		name := e.Name()
+		// card0, card1 … — skip connector nodes like card0-HDMI-A-1.
+		if !strings.HasPrefix(name, "card") || strings.Contains(name, "-") {
+			continue
+		}
+		dev := filepath.Join(root, name, "device")
+		vendorID, ok := readSysTrim(filepath.Join(dev, "vendor"))
+		if !ok {
+			continue
+		}
+		vendor := pciVendorName(vendorID)
+		if vendor == gpuVendorNVIDIA && skipNvidia {
+			continue
+		}
+
+		gpu := GPUMetrics{Vendor: vendor, Source: gpuSourceSysfs}
+		if link, err := os.Readlink(filepath.Join(dev, "driver")); err == nil {
+			driver := filepath.Base(link)
+			gpu.Name = driver
+			if v, ok := readSysTrim(filepath.Join(root, "..", "..", "module", driver, "version")); ok {
+				gpu.DriverVersion = v
+			}
+		}
+		if product, ok := readSysTrim(filepath.Join(dev, "product_name")); ok && product != "" {
+			gpu.Name = product
+		}
+		if gpu.Name == "" {
+			gpu.Name = name
+		}
+		if v, ok := readSysFloat(filepath.Join(dev, "gpu_busy_percent")); ok {
+			gpu.UtilizationPercent = floatPtr(clampPercent(v))
+		}
+		if v, ok := readSysFloat(filepath.Join(dev, "mem_info_vram_used")); ok {
+			gpu.MemoryUsedMB = uint64Ptr(uint64(v) / bytesPerMiB)
+		}
+		if v, ok := readSysFloat(filepath.Join(dev, "mem_info_vram_total")); ok {
+			gpu.MemoryTotalMB = uint64Ptr(uint64(v) / bytesPerMiB)
+		}
+		if hwmons, err := filepath.Glob(filepath.Join(dev, "hwmon", "hwmon*", "temp1_input")); err == nil && len(hwmons) > 0 {
+			if milli, ok := readSysFloat(hwmons[0]); ok {
+				gpu.TemperatureC = floatPtr(milli / 1000)
+			}
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus
+}
+
+// windowsGPUVendor maps Win32_VideoController.AdapterCompatibility (falling
+// back to the adapter name) to our vendor token.
+func windowsGPUVendor(compat, name string) string {
+	s := strings.ToLower(compat + " " + name)
+	switch {
+	case strings.Contains(s, "nvidia"):
+		return gpuVendorNVIDIA
+	case strings.Contains(s, "advanced micro devices"), strings.Contains(s, "amd"), strings.Contains(s, "radeon"):
+		return gpuVendorAMD
+	case strings.Contains(s, "intel"):
+		return gpuVendorIntel
+	default:
+		return ""
+	}
+}
+
+var (
+	ioregModelRe       = regexp.MustCompile(`"model" = <?"([^"]+)"`)
+	ioregClassRe       = regexp.MustCompile(`"IOClass" = "([^"]+)"`)
+	ioregUtilizationRe = regexp.MustCompile(`"Device Utilization %"=(\d+)`)
+	ioregInUseMemRe    = regexp.MustCompile(`"In use system memory"=(\d+)`)
+	ioregVRAMUsedRe    = regexp.MustCompile(`"vramUsedBytes"=(\d+)`)
+	ioregVRAMFreeRe    = regexp.MustCompile(`"vramFreeBytes"=(\d+)`)
+	ioregTemperatureRe = regexp.MustCompile(`"Temperature\(C\)"=(\d+)`)
+)
+
+// parseIORegAccelerators parses `ioreg -r -d 1 -w 0 -c IOAccelerator` output:
+// one "+-o" block per Metal device, each with a PerformanceStatistics
+// dictionary. Pure so it is testable off macOS.
+func parseIORegAccelerators(output string) []GPUMetrics {
+	var gpus []GPUMetrics
+	for _, block := range strings.Split(output, "+-o ")[1:] {
+		gpu := GPUMetrics{Source: gpuSourceMetal}
+		className := ""
+		if m := ioregClassRe.FindStringSubmatch(block); len(m) == 2 {
+			className = m[1]
+		}
+		if m := ioregModelRe.FindStringSubmatch(block); len(m) == 2 {
+			gpu.Name = m[1]
+		} else {
+			gpu.Name = className
+		}
+		if gpu.Name == "" {
+			continue
+		}
+		switch lower := strings.ToLower(className + " " + gpu.Name); {
+		case strings.HasPrefix(className, "AGX"), strings.Contains(lower, "apple"):
+			gpu.Vendor = gpuVendorApple
+		case strings.Contains(lower, "amd"), strings.Contains(lower, "radeon"):
+			gpu.Vendor = gpuVendorAMD
+		case strings.Contains(lower, "intel"):
+			gpu.Vendor = gpuVendorIntel
+		}
+		if m := ioregUtilizationRe.FindStringSubmatch(block); len(m) == 2 {
+			if v, ok := parseGPUFloat(m[1]); ok {
+				gpu.UtilizationPercent = floatPtr(clampPercent(v))
+			}
+		}
+		// Discrete GPUs report VRAM directly; Apple Silicon shares system
+		// memory and reports the GPU's in-use share of it.
+		if m := ioregVRAMUsedRe.FindStringSubmatch(block); len(m) == 2 {
+			if used, err := strconv.ParseUint(m[1], 10, 64); err == nil {
+				gpu.MemoryUsedMB = uint64Ptr(used / bytesPerMiB)
+				if f := ioregVRAMFreeRe.FindStringSubmatch(block); len(f) == 2 {
+					if free, err := strconv.ParseUint(f[1], 10, 64); err == nil {
+						gpu.MemoryTotalMB = uint64Ptr((used + free) / bytesPerMiB)
+					}
+				}
+			}
+		} else if m := ioregInUseMemRe.FindStringSubmatch(block); len(m) == 2 {
+			if used, err := strconv.ParseUint(m[1], 10, 64); err == nil {
+				gpu.MemoryUsedMB = uint64Ptr(used / bytesPerMiB)
+			}
+		}
+		if m := ioregTemperatureRe.FindStringSubmatch(block); len(m) == 2 {
+			if v, ok := parseGPUFloat(m[1]); ok {
+				gpu.TemperatureC = floatPtr(v)
+			}
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus
+}
@@ -0,0 +1,18 @@
+//go:build darwin
+
+package collectors
+
+import "time"
+
+// gpuSampleInterval is zero: one ioreg call per heartbeat is cheap.
+var gpuSampleInterval time.Duration
+
+// collectPlatformGPUMetrics reads Metal device statistics from the
+// IOAccelerator registry class. Covers Apple Silicon and discrete AMD GPUs.
+func collectPlatformGPUMetrics(bool) []GPUMetrics {
+	output, err := runCollectorOutput(collectorShortCommandTimeout, "ioreg", "-r", "-d", "1", "-w", "0", "-c", "IOAccelerator")
+	if err != nil {
+		return nil
+	}
+	return parseIORegAccelerators(string(output))
+}
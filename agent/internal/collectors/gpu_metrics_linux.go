@@ -0,0 +1,14 @@
+//go:build linux
+
+package collectors
+
+import "time"
+
+// gpuSampleInterval is zero: sysfs reads are cheap enough for every heartbeat.
+var gpuSampleInterval time.Duration
+
+// collectPlatformGPUMetrics reads AMD/Intel (and, without nvidia-smi, NVIDIA)
+// adapters from DRM sysfs.
+func collectPlatformGPUMetrics(haveNvidia bool) []GPUMetrics {
+	return collectGPUsFromSysfs(drmRoot, haveNvidia)
+}
@@ -0,0 +1,12 @@
+//go:build !windows && !linux && !darwin
+
+package collectors
+
+import "time"
+
+// gpuSampleInterval is zero: there is no platform probe to space out.
+var gpuSampleInterval time.Duration
+
+// collectPlatformGPUMetrics has no native source on this platform; only
+// nvidia-smi (if installed) contributes GPU metrics.
+func collectPlatformGPUMetrics(bool) []GPUMetrics { return nil }
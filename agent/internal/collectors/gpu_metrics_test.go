@@ -0,0 +1,173 @@
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseNvidiaSMI(t *testing.T) {
+	output := "0, NVIDIA RTX A4000, 551.23, 87, 10240, 16376, 71\n" +
+		"1, Tesla T4, 551.23, [N/A], 0, 15360, [Not Supported]\n" +
+		"garbage line\n"
+
+	gpus := parseNvidiaSMI(output)
+	if len(gpus) != 2 {
+		t.Fatalf("got %d gpus, want 2", len(gpus))
+	}
+
+	a := gpus[0]
+	if a.Name != "NVIDIA RTX A4000" || a.DriverVersion != "551.23" || a.Vendor != gpuVendorNVIDIA || a.Source != gpuSourceNVML {
+		t.Fatalf("unexpected identity: %+v", a)
+	}
+	if a.UtilizationPercent == nil || *a.UtilizationPercent != 87 {
+		t.Fatalf("utilization = %v, want 87", a.UtilizationPercent)
+	}
+	if a.MemoryUsedMB == nil || *a.MemoryUsedMB != 10240 || a.MemoryTotalMB == nil || *a.MemoryTotalMB != 16376 {
+		t.Fatalf("memory = %v/%v", a.MemoryUsedMB, a.MemoryTotalMB)
+	}
+	if a.TemperatureC == nil || *a.TemperatureC != 71 {
+		t.Fatalf("temperature = %v, want 71", a.TemperatureC)
+	}
+
+	b := gpus[1]
+	if b.UtilizationPercent != nil || b.TemperatureC != nil {
+		t.Fatalf("N/A fields should be nil: %+v", b)
+	}
+	if b.MemoryUsedMB == nil || *b.MemoryUsedMB != 0 {
+		t.Fatalf("real zero memory must be kept, got %v", b.MemoryUsedMB)
+	}
+}
+
+func writeGPUFixture(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectGPUsFromSysfs(t *testing.T) {
+	root := t.TempDir()
+	amd := filepath.Join(root, "card0", "device")
+	writeGPUFixture(t, filepath.Join(amd, "vendor"), "0x1002\n")
+	writeGPUFixture(t, filepath.Join(amd, "gpu_busy_percent"), "42\n")
+	writeGPUFixture(t, filepath.Join(amd, "mem_info_vram_used"), "2147483648\n")
+	writeGPUFixture(t, filepath.Join(amd, "mem_info_vram_total"), "8589934592\n")
+	writeGPUFixture(t, filepath.Join(amd, "hwmon", "hwmon3", "temp1_input"), "55000\n")
+
+	nv := filepath.Join(root, "card1", "device")
+	writeGPUFixture(t, filepath.Join(nv, "vendor"), "0x10de\n")
+
+	// Connector nodes must be ignored.
+	writeGPUFixture(t, filepath.Join(root, "card0-HDMI-A-1", "device", "vendor"), "0x1002\n")
+
+	gpus := collectGPUsFromSysfs(root, true)
+	if len(gpus) != 1 {
+		t.Fatalf("got %d gpus, want 1 (nvidia skipped, connector ignored): %+v", len(gpus), gpus)
+	}
+	g := gpus[0]
+	if g.Vendor != gpuVendorAMD || g.Source != gpuSourceSysfs {
+		t.Fatalf("unexpected identity: %+v", g)
+	}
+	if g.UtilizationPercent == nil || *g.UtilizationPercent != 42 {
+		t.Fatalf("utilization = %v", g.UtilizationPercent)
+	}
+	if g.MemoryUsedMB == nil || *g.MemoryUsedMB != 2048 || g.MemoryTotalMB == nil || *g.MemoryTotalMB != 8192 {
+		t.Fatalf("memory = %v/%v", g.MemoryUsedMB, g.MemoryTotalMB)
+	}
+	if g.TemperatureC == nil || *g.TemperatureC != 55 {
+		t.Fatalf("temperature = %v", g.TemperatureC)
+	}
+
+	if got := collectGPUsFromSysfs(root, false); len(got) != 2 {
+		t.Fatalf("without nvidia-smi the NVIDIA card should be reported, got %d", len(got))
+	}
+	if got := collectGPUsFromSysfs(filepath.Join(root, "missing"), false); got != nil {
+		t.Fatalf("missing root should yield nil, got %+v", got)
+	}
+}
+
+func TestParseIORegAccelerators(t *testing.T) {
+	output := `+-o AGXAcceleratorG13G  <class AGXAcceleratorG13G, id 0x100000a2c, registered, matched, active>
+    {
+      "IOClass" = "AGXAcceleratorG13G"
+      "model" = "Apple M1"
+      "PerformanceStatistics" = {"In use system memory"=536870912,"Device Utilization %"=12,"Renderer Utilization %"=10}
+    }
++-o AMDRadeonX6000_AMDNavi14GraphicsAccelerator  <class AMDRadeonX6000_AMDNavi14GraphicsAccelerator>
+    {
+      "IOClass" = "AMDRadeonX6000_AMDNavi14GraphicsAccelerator"
+      "model" = <"AMD Radeon Pro 5500M">
+      "PerformanceStatistics" = {"vramUsedBytes"=1073741824,"vramFreeBytes"=3221225472,"Device Utilization %"=3,"Temperature(C)"=48}
+    }
+`
+	gpus := parseIORegAccelerators(output)
+	if len(gpus) != 2 {
+		t.Fatalf("got %d gpus, want 2", len(gpus))
+	}
+	m1 := gpus[0]
+	if m1.Name != "Apple M1" || m1.Vendor != gpuVendorApple {
+		t.Fatalf("unexpected M1 identity: %+v", m1)
+	}
+	if m1.UtilizationPercent == nil || *m1.UtilizationPercent != 12 || m1.MemoryUsedMB == nil || *m1.MemoryUsedMB != 512 {
+		t.Fatalf("unexpected M1 stats: util=%v mem=%v", m1.UtilizationPercent, m1.MemoryUsedMB)
+	}
+	if m1.MemoryTotalMB != nil {
+		t.Fatalf("unified memory has no VRAM total, got %v", *m1.MemoryTotalMB)
+	}
+
+	amd := gpus[1]
+	if amd.Name != "AMD Radeon Pro 5500M" || amd.Vendor != gpuVendorAMD {
+		t.Fatalf("unexpected AMD identity: %+v", amd)
+	}
+	if amd.MemoryUsedMB == nil || *amd.MemoryUsedMB != 1024 || amd.MemoryTotalMB == nil || *amd.MemoryTotalMB != 4096 {
+		t.Fatalf("unexpected AMD memory: %v/%v", amd.MemoryUsedMB, amd.MemoryTotalMB)
+	}
+	if amd.TemperatureC == nil || *amd.TemperatureC != 48 {
+		t.Fatalf("unexpected AMD temperature: %v", amd.TemperatureC)
+	}
+}
+
+func TestWindowsGPUVendor(t *testing.T) {
+	tests := []struct {
+		compat, name, want string
+	}{
+		{"NVIDIA", "NVIDIA GeForce RTX 3060", gpuVendorNVIDIA},
+		{"Advanced Micro Devices, Inc.", "AMD Radeon(TM) Graphics", gpuVendorAMD},
+		{"Intel Corporation", "Intel(R) UHD Graphics 620", gpuVendorIntel},
+		{"", "Radeon RX 6600", gpuVendorAMD},
+		{"Matrox", "Matrox G200eW3", ""},
+	}
+	for _, tt := range tests {
+		if got := windowsGPUVendor(tt.compat, tt.name); got != tt.want {
+			t.Errorf("windowsGPUVendor(%q, %q) = %q, want %q", tt.compat, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCollectGPUsReusesSnapshotWithinInterval(t *testing.T) {
+	prev := gpuSampleInterval
+	gpuSampleInterval = 5 * time.Minute
+	t.Cleanup(func() { gpuSampleInterval = prev })
+
+	now := time.Now()
+	cached := []GPUMetrics{{Name: "cached adapter", Source: gpuSourceDXGI}}
+	c := &MetricsCollector{gpuLast: cached, gpuSampledAt: now.Add(-time.Minute)}
+
+	got := c.collectGPUs(now)
+	if len(got) != 1 || got[0].Name != "cached adapter" {
+		t.Fatalf("collectGPUs within the interval = %+v, want the cached snapshot", got)
+	}
+	if !c.gpuSampledAt.Equal(now.Add(-time.Minute)) {
+		t.Fatal("a cached read must not restart the interval")
+	}
+
+	c.gpuAbsentUntil = now.Add(time.Minute)
+	if got := c.collectGPUs(now); got != nil {
+		t.Fatalf("collectGPUs while marked absent = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build windows
+
+package collectors
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// gpuSampleInterval spaces out the PowerShell probe below: it starts a
+// PowerShell process and takes two perf-counter samples, too heavy to run on
+// every heartbeat. Between probes the previous snapshot is reported.
+var gpuSampleInterval = 5 * time.Minute
+
+// windowsGPURow is one Win32_VideoController with the DXGI perf-counter
+// readings the script attributed to it.
+type windowsGPURow struct {
+	Name          string   `json:"Name"`
+	DriverVersion string   `json:"DriverVersion"`
+	Vendor        string   `json:"AdapterCompatibility"`
+	Utilization   *float64 `json:"Utilization"`
+	DedicatedMB   *float64 `json:"DedicatedMB"`
+}
+
+// windowsGPUScript sums 3D-engine utilization and dedicated memory per adapter
+// LUID from the GPU perf counters. WMI does not expose the adapter LUID, so
+// counters are paired with video controllers in enumeration order, and only
+// when the counts agree — otherwise the adapters are reported without dynamic
+// readings rather than with someone else's. Basic/Remote Display adapters are
+// skipped.
+const windowsGPUScript = `$ctrls = @(Get-CimInstance Win32_VideoController | Where-Object { $_.Name -notmatch 'Basic Display|Remote Display|Hyper-V' });` +
+	`$util = @{}; $mem = @{};` +
+	`try { (Get-Counter '\GPU Engine(*engtype_3D)\Utilization Percentage' -ErrorAction Stop).CounterSamples | ForEach-Object { if ($_.InstanceName -match 'luid_(0x[0-9a-f]+_0x[0-9a-f]+)') { $util[$matches[1]] += $_.CookedValue } } } catch {};` +
+	`try { (Get-Counter '\GPU Adapter Memory(*)\Dedicated Usage' -ErrorAction Stop).CounterSamples | ForEach-Object { if ($_.InstanceName -match 'luid_(0x[0-9a-f]+_0x[0-9a-f]+)') { $mem[$matches[1]] += $_.CookedValue } } } catch {};` +
+	`$luids = @($mem.Keys | Where-Object { $mem[$_] -gt 0 } | Sort-Object);` +
+	`for ($i = 0; $i -lt $ctrls.Count; $i++) { $c = $ctrls[$i]; $u = $null; $m = $null;` +
+	`if ($ctrls.Count -eq $luids.Count) { $l = $luids[$i]; $u = [math]::Min(100, [math]::Round($util[$l], 1)); $m = [math]::Round($mem[$l] / 1MB) };` +
+	`[pscustomobject]@{ Name = $c.Name; DriverVersion = $c.DriverVersion; AdapterCompatibility = $c.AdapterCompatibility; Utilization = $u; DedicatedMB = $m } } | ConvertTo-Json -Compress`
+
+// collectPlatformGPUMetrics reads DXGI adapter counters. NVIDIA adapters are
+// dropped when nvidia-smi already reported them (it also provides
+// temperature, which the perf counters don't).
+func collectPlatformGPUMetrics(haveNvidia bool) []GPUMetrics {
+	ctx, cancel := context.WithTimeout(context.Background(), collectorShortCommandTimeout)
+	defer cancel()
+
+	rows, err := runWindowsJSON[windowsGPURow](ctx, utf8PowerShellCommand(windowsGPUScript))
+	if err != nil {
+		return nil
+	}
+
+	gpus := make([]GPUMetrics, 0, len(rows))
+	for _, row := range rows {
+		vendor := windowsGPUVendor(row.Vendor, row.Name)
+		if vendor == gpuVendorNVIDIA && haveNvidia {
+			continue
+		}
+		gpu := GPUMetrics{
+			Name:          strings.TrimSpace(row.Name),
+			Vendor:        vendor,
+			DriverVersion: strings.TrimSpace(row.DriverVersion),
+			Source:        gpuSourceDXGI,
+		}
+		if row.Utilization != nil {
+			gpu.UtilizationPercent = floatPtr(clampPercent(*row.Utilization))
+		}
+		if row.DedicatedMB != nil && *row.DedicatedMB >= 0 {
+			gpu.MemoryUsedMB = uint64Ptr(uint64(*row.DedicatedMB))
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus
+}
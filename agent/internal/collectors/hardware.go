@@ -5,6 +5,8 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
@@ -13,20 +15,21 @@ import (
 )
 
 type HardwareInfo struct {
-	CPUModel                string `json:"cpuModel"`
-	CPUCores                int    `json:"cpuCores"`
-	CPUThreads              int    `json:"cpuThreads"`
-	RAMTotalMB              uint64 `json:"ramTotalMb"`
-	DiskTotalGB             uint64 `json:"diskTotalGb"`
-	GPUModel                string `json:"gpuModel,omitempty"`
-	SerialNumber            string `json:"serialNumber,omitempty"`
-	Manufacturer            string `json:"manufacturer,omitempty"`
-	Model                   string `json:"model,omitempty"`
-	MotherboardManufacturer string `json:"motherboardManufacturer,omitempty"`
-	MotherboardProduct      string `json:"motherboardProduct,omitempty"`
-	MotherboardVersion      string `json:"motherboardVersion,omitempty"`
-	BIOSVersion             string `json:"biosVersion,omitempty"`
-	ChassisType             string `json:"chassisType,omitempty"`
+	CPUModel                string        `json:"cpuModel"`
+	CPUCores                int           `json:"cpuCores"`
+	CPUThreads              int           `json:"cpuThreads"`
+	RAMTotalMB              uint64        `json:"ramTotalMb"`
+	DiskTotalGB             uint64        `json:"diskTotalGb"`
+	GPUModel                string        `json:"gpuModel,omitempty"`
+	SerialNumber            string        `json:"serialNumber,omitempty"`
+	Manufacturer            string        `json:"manufacturer,omitempty"`
+	Model                   string        `json:"model,omitempty"`
+	MotherboardManufacturer string        `json:"motherboardManufacturer,omitempty"`
+	MotherboardProduct      string        `json:"motherboardProduct,omitempty"`
+	MotherboardVersion      string        `json:"motherboardVersion,omitempty"`
+	BIOSVersion             string        `json:"biosVersion,omitempty"`
+	ChassisType             string        `json:"chassisType,omitempty"`
+	Displays                []DisplayInfo `json:"displays,omitempty"`
 }
 
 type SystemInfo struct {
@@ -37,7 +40,13 @@ type SystemInfo struct {
 	Architecture string `json:"architecture"`
 }
 
-type HardwareCollector struct{}
+type HardwareCollector struct {
+	// Battery health (cycle count, capacities) changes over months and costs
+	// a WMI/ioreg spawn on Windows/macOS, so it is cached across heartbeats.
+	batteryMu       sync.Mutex
+	batteryHealth   *batteryHealth
+	batteryHealthAt time.Time
+}
 
 func NewHardwareCollector() *HardwareCollector {
 	return &HardwareCollector{}
@@ -240,5 +249,8 @@ func (c *HardwareCollector) CollectHardware() (*HardwareInfo, error) {
 	// Platform-specific: serial number, manufacturer, model, BIOS, GPU
 	collectPlatformHardware(hw)
 
+	// Attached monitors (EDID) so asset tracking covers displays too
+	hw.Displays = collectDisplays()
+
 	return hw, nil
 }
@@ -0,0 +1,150 @@
+package collectors
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// HardwareIdentity is the physical hardware the change tracker watches for
+// tampering: fixed-disk serial numbers, TPM presence and the set of burned-in
+// MAC addresses. A nil field means "could not be determined" and is never
+// diffed, so a failed query cannot masquerade as a removed disk or TPM.
+type HardwareIdentity struct {
+	DiskSerials  []string
+	TPMPresent   *bool
+	MACAddresses []string
+}
+
+// CollectHardwareIdentity gathers disk serials and TPM presence from the
+// platform and MAC addresses from the network stack.
+func CollectHardwareIdentity() HardwareIdentity {
+	id := collectPlatformHardwareIdentity()
+	id.MACAddresses = physicalMACAddresses()
+	return id
+}
+
+// physicalMACAddresses returns the sorted, de-duplicated burned-in MAC
+// addresses. Loopback, non-Ethernet and locally administered addresses
+// (virtual adapters, randomized Wi-Fi MACs) are skipped because they change
+// without any hardware change.
+func physicalMACAddresses() []string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	macs := make([]string, 0, len(ifaces))
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if mac := normalizeHardwareMAC(iface.HardwareAddr); mac != "" {
+			macs = append(macs, mac)
+		}
+	}
+	slices.Sort(macs)
+	return slices.Compact(macs)
+}
+
+func normalizeHardwareMAC(addr net.HardwareAddr) string {
+	if len(addr) != 6 || addr[0]&0x02 != 0 {
+		return ""
+	}
+	allZero := true
+	for _, b := range addr {
+		if b != 0 {
+			allZero = false
+			break
+		}
+	}
+	if allZero {
+		return ""
+	}
+	return strings.ToLower(addr.String())
+}
+
+// normalizeDiskSerials trims, upper-cases, sorts and de-duplicates serials,
+// dropping blanks and the all-zero/space placeholders some controllers return.
+func normalizeDiskSerials(serials []string) []string {
+	out := make([]string, 0, len(serials))
+	for _, serial := range serials {
+		serial = strings.ToUpper(strings.TrimSpace(serial))
+		if strings.Trim(serial, "0 ._-") == "" {
+			continue
+		}
+		out = append(out, serial)
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// skippedSysfsBlockPrefixes are virtual or removable-media block devices
+// that have no physical disk behind them.
+var skippedSysfsBlockPrefixes = []string{"loop", "ram", "zram", "dm-", "md", "sr", "nbd", "fd"}
+
+// diskSerialsFromSysfs reads serial numbers for the physical disks under
+// <root>/block. NVMe and virtio expose device/serial; SCSI/SATA disks carry
+// the serial in the VPD page 0x80 (4-byte header, then ASCII).
+func diskSerialsFromSysfs(root string) []string {
+	entries, err := os.ReadDir(filepath.Join(root, "block"))
+	if err != nil {
+		return nil
+	}
+	serials := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if slices.ContainsFunc(skippedSysfsBlockPrefixes, func(p string) bool { return strings.HasPrefix(name, p) }) {
+			continue
+		}
+		device := filepath.Join(root, "block", name, "device")
+		if removable, _ := readSysTrim(filepath.Join(root, "block", name, "removable")); removable == "1" {
+			continue
+		}
+		if serial, ok := readSysTrim(filepath.Join(device, "serial")); ok && serial != "" {
+			serials = append(serials, serial)
+			continue
+		}
+		if page, err := os.ReadFile(filepath.Join(device, "vpd_pg80")); err == nil && len(page) > 4 {
+			serials = append(serials, strings.Trim(string(page[4:]), " \x00"))
+		}
+	}
+	return normalizeDiskSerials(serials)
+}
+
+// tpmPresentSysfs reports whether the kernel registered a TPM.
+func tpmPresentSysfs(root string) *bool {
+	_, err := os.Stat(filepath.Join(root, "class", "tpm", "tpm0"))
+	return boolPtr(err == nil)
+}
+
+// parseSystemProfilerDiskSerials extracts every "device_serial" value from
+// `system_profiler SPNVMeDataType SPSerialATADataType -json` output.
+func parseSystemProfilerDiskSerials(output []byte) []string {
+	var doc any
+	if err := json.Unmarshal(output, &doc); err != nil {
+		return nil
+	}
+	var serials []string
+	var walk func(v any)
+	walk = func(v any) {
+		switch node := v.(type) {
+		case map[string]any:
+			for key, child := range node {
+				if s, ok := child.(string); ok && key == "device_serial" {
+					serials = append(serials, s)
+					continue
+				}
+				walk(child)
+			}
+		case []any:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+	return normalizeDiskSerials(serials)
+}
@@ -0,0 +1,17 @@
+//go:build darwin
+
+package collectors
+
+import "log/slog"
+
+// collectPlatformHardwareIdentity reads internal NVMe/SATA serials from
+// system_profiler. Macs have no TPM (the Secure Enclave is not removable), so
+// TPMPresent stays nil and is never diffed.
+func collectPlatformHardwareIdentity() HardwareIdentity {
+	out, err := runCollectorOutput(collectorLongCommandTimeout, "system_profiler", "SPNVMeDataType", "SPSerialATADataType", "-json")
+	if err != nil {
+		slog.Warn("system_profiler disk query failed", "error", err.Error())
+		return HardwareIdentity{}
+	}
+	return HardwareIdentity{DiskSerials: parseSystemProfilerDiskSerials(out)}
+}
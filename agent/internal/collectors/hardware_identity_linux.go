@@ -0,0 +1,10 @@
+//go:build linux
+
+package collectors
+
+func collectPlatformHardwareIdentity() HardwareIdentity {
+	return HardwareIdentity{
+		DiskSerials: diskSerialsFromSysfs("/sys"),
+		TPMPresent:  tpmPresentSysfs("/sys"),
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !windows && !linux && !darwin
+
+package collectors
+
+func collectPlatformHardwareIdentity() HardwareIdentity { return HardwareIdentity{} }
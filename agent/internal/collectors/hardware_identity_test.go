@@ -0,0 +1,70 @@
+package collectors
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiskSerialsFromSysfs(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("block/nvme0n1/device/serial", "  S4EWNX0R123456  \n")
+	write("block/nvme0n1/removable", "0\n")
+	write("block/sda/device/vpd_pg80", "\x00\x80\x00\x0cWD-WX12345678")
+	write("block/sdb/device/serial", "USBSTICK01")
+	write("block/sdb/removable", "1\n")
+	write("block/loop0/device/serial", "LOOP")
+	write("block/vda/device/serial", "00000000")
+	write("class/tpm/tpm0/dev", "253:0")
+
+	got := diskSerialsFromSysfs(root)
+	want := []string{"S4EWNX0R123456", "WD-WX12345678"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diskSerialsFromSysfs = %v, want %v", got, want)
+	}
+	if p := tpmPresentSysfs(root); p == nil || !*p {
+		t.Fatal("expected TPM present")
+	}
+	if p := tpmPresentSysfs(t.TempDir()); p == nil || *p {
+		t.Fatal("expected TPM absent")
+	}
+}
+
+func TestParseSystemProfilerDiskSerials(t *testing.T) {
+	output := []byte(`{"SPNVMeDataType":[{"_items":[{"_name":"APPLE SSD AP0512Q","device_serial":"0ba0123456789abc"}]}],
+		"SPSerialATADataType":[{"_items":[{"_name":"ST2000","device_serial":" ZA1234XY "},{"_name":"empty"}]}]}`)
+	got := parseSystemProfilerDiskSerials(output)
+	want := []string{"0BA0123456789ABC", "ZA1234XY"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("serials = %v, want %v", got, want)
+	}
+}
+
+func TestNormalizeHardwareMAC(t *testing.T) {
+	cases := map[string]string{
+		"00:1A:2B:3C:4D:5E": "00:1a:2b:3c:4d:5e",
+		"02:42:ac:11:00:02": "", // locally administered (docker bridge)
+		"00:00:00:00:00:00": "",
+	}
+	for in, want := range cases {
+		addr, err := net.ParseMAC(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := normalizeHardwareMAC(addr); got != want {
+			t.Errorf("normalizeHardwareMAC(%s) = %q, want %q", in, got, want)
+		}
+	}
+}
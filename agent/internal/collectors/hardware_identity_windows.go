@@ -0,0 +1,38 @@
+//go:build windows
+
+package collectors
+
+import (
+	"context"
+	"log/slog"
+)
+
+// windowsHardwareIdentityRow is the fixed-disk serial list and TPM presence.
+type windowsHardwareIdentityRow struct {
+	DiskSerials []string `json:"diskSerials"`
+	TPMPresent  bool     `json:"tpmPresent"`
+}
+
+// The Win32_Tpm namespace only exists when a TPM is present (or enabled in
+// firmware), so a failed query means "no TPM".
+const windowsHardwareIdentityScript = `$d = @(Get-CimInstance Win32_DiskDrive -ErrorAction SilentlyContinue | Where-Object { $_.InterfaceType -ne 'USB' -and $_.MediaType -notlike 'Removable*' } | ForEach-Object { [string]$_.SerialNumber });` +
+	`$t = $false; try { $t = [bool](Get-CimInstance -Namespace 'root\cimv2\Security\MicrosoftTpm' -ClassName Win32_Tpm -ErrorAction Stop) } catch { $t = $false };` +
+	`[pscustomobject]@{ diskSerials = $d; tpmPresent = $t } | ConvertTo-Json -Compress`
+
+func collectPlatformHardwareIdentity() HardwareIdentity {
+	ctx, cancel := context.WithTimeout(context.Background(), collectorLongCommandTimeout)
+	defer cancel()
+
+	rows, err := runWindowsJSON[windowsHardwareIdentityRow](ctx, utf8PowerShellCommand(windowsHardwareIdentityScript))
+	if err != nil {
+		slog.Warn("hardware identity query failed", "error", err.Error())
+		return HardwareIdentity{}
+	}
+	if len(rows) == 0 {
+		return HardwareIdentity{}
+	}
+	return HardwareIdentity{
+		DiskSerials: normalizeDiskSerials(rows[0].DiskSerials),
+		TPMPresent:  boolPtr(rows[0].TPMPresent),
+	}
+}
@@ -0,0 +1,266 @@
+package collectors
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Windows and Office licensing. Reports the Windows edition, whether it is
+// activated and through which channel (retail, OEM, KMS, MAK), plus the
+// activation state of every installed Office product, for license
+// compliance reporting. The data comes from the same Software Protection
+// Platform WMI classes slmgr.vbs and ospp.vbs read, so nothing depends on
+// their localized text output. Other platforms report nothing.
+
+// Licensing channels, derived from ProductKeyChannel or, on older builds,
+// the "..., RETAIL channel" suffix of the product description.
+const (
+	LicenseChannelRetail       = "retail"
+	LicenseChannelOEM          = "oem"
+	LicenseChannelKMS          = "kms"
+	LicenseChannelMAK          = "mak"
+	LicenseChannelSubscription = "subscription"
+	LicenseChannelUnknown      = "unknown"
+)
+
+// SoftwareLicensingProduct.ApplicationID values.
+const (
+	windowsLicensingApplicationID = "55c92734-d682-4d71-983e-d6ec3f16059f"
+	officeLicensingApplicationID  = "0ff1ce15-a989-479d-af46-f275c6370663"
+)
+
+// maxLicenseProducts caps the product list; SPP only reports products with
+// an installed key, so a real machine has a handful.
+const maxLicenseProducts = 32
+
+// licenseStatusNames maps SoftwareLicensingProduct.LicenseStatus.
+var licenseStatusNames = map[int]string{
+	0: "unlicensed",
+	1: "licensed",
+	2: "oob_grace",
+	3: "oot_grace",
+	4: "non_genuine_grace",
+	5: "notification",
+	6: "extended_grace",
+}
+
+// LicensingInfo summarizes the machine's licensing. Status and Channel
+// describe the Windows license; OfficeActivated is nil when no Office
+// product has a key installed.
+type LicensingInfo struct {
+	Edition         string           `json:"edition,omitempty"`
+	ProductName     string           `json:"productName,omitempty"`
+	Activated       bool             `json:"activated"`
+	Status          string           `json:"status,omitempty"`
+	Channel         string           `json:"channel,omitempty"`
+	OfficeActivated *bool            `json:"officeActivated,omitempty"`
+	Products        []LicenseProduct `json:"products"`
+}
+
+// LicenseProduct is one product with an installed key, as listed by
+// slmgr /dlv or ospp /dstatus. Application is "windows" or "office".
+type LicenseProduct struct {
+	Application           string `json:"application"`
+	Name                  string `json:"name"`
+	Description           string `json:"description,omitempty"`
+	Status                string `json:"status"`
+	Activated             bool   `json:"activated"`
+	Channel               string `json:"channel"`
+	PartialProductKey     string `json:"partialProductKey,omitempty"`
+	GraceMinutesRemaining int    `json:"graceMinutesRemaining,omitempty"`
+	KMSHost               string `json:"kmsHost,omitempty"`
+}
+
+type LicensingCollector struct{}
+
+func NewLicensingCollector() *LicensingCollector {
+	return &LicensingCollector{}
+}
+
+// Collect returns the licensing summary, or nil where the platform has no
+// equivalent.
+func (c *LicensingCollector) Collect() (*LicensingInfo, error) {
+	return collectLicensing()
+}
+
+// windowsLicensingOutput is the object the licensing script emits.
+// Products is an array, or a bare object when PowerShell unwraps a single
+// element.
+type windowsLicensingOutput struct {
+	EditionID   string          `json:"EditionID"`
+	ProductName string          `json:"ProductName"`
+	Products    json.RawMessage `json:"Products"`
+}
+
+type windowsLicensingProduct struct {
+	Name                                      string `json:"Name"`
+	Description                               string `json:"Description"`
+	ApplicationID                             string `json:"ApplicationID"`
+	LicenseStatus                             *int   `json:"LicenseStatus"`
+	PartialProductKey                         string `json:"PartialProductKey"`
+	ProductKeyChannel                         string `json:"ProductKeyChannel"`
+	GracePeriodRemaining                      int    `json:"GracePeriodRemaining"`
+	KeyManagementServiceMachine               string `json:"KeyManagementServiceMachine"`
+	KeyManagementServicePort                  int    `json:"KeyManagementServicePort"`
+	DiscoveredKeyManagementServiceMachineName string `json:"DiscoveredKeyManagementServiceMachineName"`
+	DiscoveredKeyManagementServiceMachinePort int    `json:"DiscoveredKeyManagementServiceMachinePort"`
+}
+
+// parseWindowsLicensingJSON turns the licensing script output into a
+// LicensingInfo. Empty output yields nil.
+func parseWindowsLicensingJSON(data []byte) (*LicensingInfo, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "" || trimmed == "null" {
+		return nil, nil
+	}
+	var out windowsLicensingOutput
+	if err := json.Unmarshal([]byte(trimmed), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse licensing JSON: %w", err)
+	}
+
+	var rows []windowsLicensingProduct
+	if raw := strings.TrimSpace(string(out.Products)); raw != "" && raw != "null" {
+		if err := json.Unmarshal(out.Products, &rows); err != nil {
+			var single windowsLicensingProduct
+			if errSingle := json.Unmarshal(out.Products, &single); errSingle != nil {
+				return nil, fmt.Errorf("failed to parse licensing products: %w", err)
+			}
+			rows = []windowsLicensingProduct{single}
+		}
+	}
+
+	info := &LicensingInfo{
+		Edition:     truncateCollectorString(strings.TrimSpace(out.EditionID)),
+		ProductName: truncateCollectorString(strings.TrimSpace(out.ProductName)),
+		Products:    make([]LicenseProduct, 0, len(rows)),
+	}
+	for _, row := range rows {
+		if strings.TrimSpace(row.PartialProductKey) == "" || row.LicenseStatus == nil {
+			continue
+		}
+		info.Products = append(info.Products, licenseProductFromWindowsRow(row))
+	}
+
+	// Windows first, then Office; activated products before the rest so
+	// the summary below picks the key that is actually in use.
+	sort.SliceStable(info.Products, func(i, j int) bool {
+		a, b := info.Products[i], info.Products[j]
+		if a.Application != b.Application {
+			return a.Application == "windows"
+		}
+		if a.Activated != b.Activated {
+			return a.Activated
+		}
+		return a.Name < b.Name
+	})
+	if len(info.Products) > maxLicenseProducts {
+		info.Products = info.Products[:maxLicenseProducts]
+	}
+
+	for _, p := range info.Products {
+		switch p.Application {
+		case "windows":
+			if info.Status == "" {
+				info.Activated = p.Activated
+				info.Status = p.Status
+				info.Channel = p.Channel
+			}
+		case "office":
+			if info.OfficeActivated == nil {
+				activated := p.Activated
+				info.OfficeActivated = &activated
+			}
+		}
+	}
+	return info, nil
+}
+
+func licenseProductFromWindowsRow(row windowsLicensingProduct) LicenseProduct {
+	status, ok := licenseStatusNames[*row.LicenseStatus]
+	if !ok {
+		status = "unknown"
+	}
+	p := LicenseProduct{
+		Application:       licenseApplication(row.ApplicationID, row.Name),
+		Name:              truncateCollectorString(strings.TrimSpace(row.Name)),
+		Description:       truncateCollectorString(strings.TrimSpace(row.Description)),
+		Status:            status,
+		Activated:         *row.LicenseStatus == 1,
+		Channel:           licenseChannel(row.ProductKeyChannel, row.Description),
+		PartialProductKey: truncateCollectorString(strings.TrimSpace(row.PartialProductKey)),
+	}
+	// Grace time only means something while the product is not licensed;
+	// a licensed volume key reports its 180-day reactivation window here.
+	if !p.Activated && row.GracePeriodRemaining > 0 {
+		p.GraceMinutesRemaining = row.GracePeriodRemaining
+	}
+	if p.Channel == LicenseChannelKMS {
+		p.KMSHost = kmsHost(row)
+	}
+	return p
+}
+
+func licenseApplication(applicationID, name string) string {
+	switch strings.ToLower(strings.Trim(applicationID, "{} ")) {
+	case windowsLicensingApplicationID:
+		return "windows"
+	case officeLicensingApplicationID:
+		return "office"
+	}
+	// OfficeSoftwareProtectionProduct (Office 2010) uses its own ID.
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(name)), "office") {
+		return "office"
+	}
+	return "windows"
+}
+
+// licenseChannel classifies "Retail", "OEM:DM", "Volume:GVLK",
+// "Volume:MAK" (ProductKeyChannel) or the description's "VOLUME_KMSCLIENT
+// channel" style suffix.
+func licenseChannel(productKeyChannel, description string) string {
+	ch := strings.ToUpper(strings.TrimSpace(productKeyChannel))
+	if ch == "" {
+		desc := strings.ToUpper(description)
+		if idx := strings.LastIndex(desc, " CHANNEL"); idx > 0 {
+			desc = strings.TrimSpace(desc[:idx])
+			if comma := strings.LastIndex(desc, ","); comma >= 0 {
+				desc = desc[comma+1:]
+			}
+			ch = strings.TrimSpace(desc)
+		}
+	}
+	switch {
+	case ch == "":
+		return LicenseChannelUnknown
+	case strings.Contains(ch, "GVLK"), strings.Contains(ch, "KMS"):
+		return LicenseChannelKMS
+	case strings.Contains(ch, "MAK"):
+		return LicenseChannelMAK
+	case strings.HasPrefix(ch, "OEM"):
+		return LicenseChannelOEM
+	case strings.Contains(ch, "SUB"):
+		return LicenseChannelSubscription
+	case strings.HasPrefix(ch, "RETAIL"):
+		return LicenseChannelRetail
+	}
+	return LicenseChannelUnknown
+}
+
+// kmsHost prefers the administratively set KMS host over the one found
+// through DNS auto-discovery.
+func kmsHost(row windowsLicensingProduct) string {
+	host, port := strings.TrimSpace(row.KeyManagementServiceMachine), row.KeyManagementServicePort
+	if host == "" {
+		host, port = strings.TrimSpace(row.DiscoveredKeyManagementServiceMachineName), row.DiscoveredKeyManagementServiceMachinePort
+	}
+	if host == "" {
+		return ""
+	}
+	if port > 0 && port != 1688 {
+		host += ":" + strconv.Itoa(port)
+	}
+	return truncateCollectorString(host)
+}
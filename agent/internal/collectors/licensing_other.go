@@ -0,0 +1,7 @@
+//go:build !windows
+
+package collectors
+
+func collectLicensing() (*LicensingInfo, error) {
+	return nil, nil
+}
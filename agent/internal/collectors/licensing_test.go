@@ -0,0 +1,67 @@
+package collectors
+
+import "testing"
+
+func TestLicenseChannel(t *testing.T) {
+	cases := []struct{ keyChannel, description, want string }{
+		{"Retail", "", LicenseChannelRetail},
+		{"OEM:DM", "", LicenseChannelOEM},
+		{"Volume:GVLK", "", LicenseChannelKMS},
+		{"Volume:MAK", "", LicenseChannelMAK},
+		{"", "Windows(R) Operating System, VOLUME_KMSCLIENT channel", LicenseChannelKMS},
+		{"", "Office 16, TIMEBASED_SUB channel", LicenseChannelSubscription},
+		{"", "Office 15, RETAIL(Grace) channel", LicenseChannelRetail},
+		{"", "Office 16, VOLUME_MAKC2R channel", LicenseChannelMAK},
+		{"", "Windows(R) Operating System", LicenseChannelUnknown},
+	}
+	for _, tc := range cases {
+		if got := licenseChannel(tc.keyChannel, tc.description); got != tc.want {
+			t.Errorf("%q/%q: got %q, want %q", tc.keyChannel, tc.description, got, tc.want)
+		}
+	}
+}
+
+func TestParseWindowsLicensingJSON(t *testing.T) {
+	out := `{"EditionID":"Enterprise","ProductName":"Windows 10 Enterprise","Products":[
+		{"Name":"Office 16, Office16ProPlusVL_KMS_Client_edition","Description":"Office 16, VOLUME_KMSCLIENT channel","ApplicationID":"0ff1ce15-a989-479d-af46-f275c6370663","LicenseStatus":5,"PartialProductKey":"WFG99","GracePeriodRemaining":0,"DiscoveredKeyManagementServiceMachineName":"kms.corp.example","DiscoveredKeyManagementServiceMachinePort":1688},
+		{"Name":"Windows(R), Enterprise edition","Description":"Windows(R) Operating System, VOLUME_KMSCLIENT channel","ApplicationID":"55c92734-d682-4d71-983e-d6ec3f16059f","LicenseStatus":1,"PartialProductKey":"T83GX","ProductKeyChannel":"Volume:GVLK","GracePeriodRemaining":259200,"KeyManagementServiceMachine":"kms01","KeyManagementServicePort":1700},
+		{"Name":"Windows(R), Professional edition","ApplicationID":"55c92734-d682-4d71-983e-d6ec3f16059f","LicenseStatus":0,"PartialProductKey":""}
+	]}`
+	info, err := parseWindowsLicensingJSON([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Edition != "Enterprise" || !info.Activated || info.Status != "licensed" || info.Channel != LicenseChannelKMS {
+		t.Fatalf("unexpected summary %+v", info)
+	}
+	if info.OfficeActivated == nil || *info.OfficeActivated {
+		t.Fatalf("expected unactivated office, got %v", info.OfficeActivated)
+	}
+	if len(info.Products) != 2 {
+		t.Fatalf("unexpected products %+v", info.Products)
+	}
+	win := info.Products[0]
+	if win.Application != "windows" || win.KMSHost != "kms01:1700" || win.GraceMinutesRemaining != 0 {
+		t.Fatalf("unexpected windows product %+v", win)
+	}
+	office := info.Products[1]
+	if office.Application != "office" || office.Status != "notification" || office.KMSHost != "kms.corp.example" {
+		t.Fatalf("unexpected office product %+v", office)
+	}
+
+	// A single product arrives as a bare object; no Office leaves
+	// OfficeActivated unset.
+	single := `{"EditionID":"Professional","ProductName":"Windows 10 Pro","Products":{"Name":"Windows(R), Professional edition","Description":"Windows(R) Operating System, OEM_DM channel","ApplicationID":"55c92734-d682-4d71-983e-d6ec3f16059f","LicenseStatus":2,"PartialProductKey":"3V66T","GracePeriodRemaining":43200}}`
+	info, err = parseWindowsLicensingJSON([]byte(single))
+	if err != nil || len(info.Products) != 1 {
+		t.Fatalf("unexpected result %+v %v", info, err)
+	}
+	if info.Activated || info.Status != "oob_grace" || info.Channel != LicenseChannelOEM || info.OfficeActivated != nil ||
+		info.Products[0].GraceMinutesRemaining != 43200 {
+		t.Fatalf("unexpected single-product summary %+v", info)
+	}
+
+	if info, err := parseWindowsLicensingJSON([]byte("")); info != nil || err != nil {
+		t.Fatalf("empty output should yield nil, got %+v %v", info, err)
+	}
+}
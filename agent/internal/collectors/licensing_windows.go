@@ -0,0 +1,24 @@
+//go:build windows
+
+package collectors
+
+// Only products with an installed key are listed (what slmgr /dlv and
+// ospp /dstatus show). OfficeSoftwareProtectionProduct exists only where
+// Office 2010 is installed; later Office versions register with SPP.
+const windowsLicensingScript = `$ErrorActionPreference='SilentlyContinue'
+$cv=Get-ItemProperty 'HKLM:\SOFTWARE\Microsoft\Windows NT\CurrentVersion'
+$fields='Name','Description','ApplicationID','LicenseStatus','PartialProductKey','ProductKeyChannel','GracePeriodRemaining','KeyManagementServiceMachine','KeyManagementServicePort','DiscoveredKeyManagementServiceMachineName','DiscoveredKeyManagementServiceMachinePort'
+$products=@(Get-CimInstance SoftwareLicensingProduct -Filter 'PartialProductKey IS NOT NULL') + @(Get-CimInstance OfficeSoftwareProtectionProduct -Filter 'PartialProductKey IS NOT NULL')
+[pscustomobject]@{
+  EditionID=$cv.EditionID
+  ProductName=$cv.ProductName
+  Products=@($products | Where-Object { $_ } | Select-Object $fields)
+} | ConvertTo-Json -Compress -Depth 3`
+
+func collectLicensing() (*LicensingInfo, error) {
+	output, err := runCollectorOutput(collectorLongCommandTimeout, "powershell", "-NoProfile", "-NonInteractive", "-Command", utf8PowerShellCommand(windowsLicensingScript))
+	if err != nil {
+		return nil, err
+	}
+	return parseWindowsLicensingJSON(output)
+}
@@ -0,0 +1,343 @@
+package collectors
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Local users and groups inventory. This is the full-account view behind the
+// change tracker's TrackedUserAccount diffs: every local account (system
+// accounts included, flagged as such) with its group memberships, admin
+// status, last logon and password age, plus every local group with its
+// members. Fields a platform cannot supply are left empty rather than
+// guessed; macOS does not record a per-account last logon, for example.
+
+// LocalUser is the agent-sent wire shape for one local account. Username,
+// FullName, Disabled and Locked carry the same meaning as in
+// TrackedUserAccount.
+type LocalUser struct {
+	Username        string     `json:"username"`
+	FullName        string     `json:"fullName,omitempty"`
+	UID             string     `json:"uid,omitempty"` // numeric uid, or the SID on Windows
+	HomeDir         string     `json:"homeDir,omitempty"`
+	Shell           string     `json:"shell,omitempty"`
+	Disabled        bool       `json:"disabled"`
+	Locked          bool       `json:"locked"`
+	IsAdmin         bool       `json:"isAdmin"`
+	IsSystem        bool       `json:"isSystem"`
+	Groups          []string   `json:"groups"`
+	LastLogon       *time.Time `json:"lastLogon,omitempty"`
+	PasswordLastSet *time.Time `json:"passwordLastSet,omitempty"`
+	PasswordAgeDays *int       `json:"passwordAgeDays,omitempty"`
+}
+
+// LocalGroup is one local group. Members holds local usernames where the
+// member resolves to a local account, otherwise the name the OS reports
+// (e.g. DOMAIN\user on Windows).
+type LocalGroup struct {
+	Name    string   `json:"name"`
+	GID     string   `json:"gid,omitempty"` // numeric gid, or the SID on Windows
+	IsAdmin bool     `json:"isAdmin"`
+	Members []string `json:"members"`
+}
+
+// LocalUserInventory is the payload uploaded to the users inventory section.
+type LocalUserInventory struct {
+	Users  []LocalUser  `json:"users"`
+	Groups []LocalGroup `json:"groups"`
+}
+
+type LocalUserCollector struct {
+	now func() time.Time
+}
+
+func NewLocalUserCollector() *LocalUserCollector {
+	return &LocalUserCollector{now: time.Now}
+}
+
+// Collect enumerates local accounts and groups for the current platform.
+func (c *LocalUserCollector) Collect() (*LocalUserInventory, error) {
+	users, groups, err := collectLocalUsers()
+	if err != nil {
+		return nil, err
+	}
+	return buildLocalUserInventory(users, groups, c.now()), nil
+}
+
+// buildLocalUserInventory resolves each user's group list from the group
+// member lists, derives admin status and password age, sanitizes strings and
+// sorts both lists by name.
+func buildLocalUserInventory(users []LocalUser, groups []LocalGroup, now time.Time) *LocalUserInventory {
+	inv := &LocalUserInventory{Users: []LocalUser{}, Groups: []LocalGroup{}}
+
+	memberOf := make(map[string][]string)
+	adminUsers := make(map[string]bool)
+	for _, g := range groups {
+		g.Name = truncateCollectorString(strings.TrimSpace(g.Name))
+		if g.Name == "" {
+			continue
+		}
+		g.GID = truncateCollectorString(g.GID)
+		members := make([]string, 0, len(g.Members))
+		seen := make(map[string]bool, len(g.Members))
+		for _, m := range g.Members {
+			m = truncateCollectorString(strings.TrimSpace(m))
+			if m == "" || seen[m] {
+				continue
+			}
+			seen[m] = true
+			members = append(members, m)
+			memberOf[m] = append(memberOf[m], g.Name)
+			if g.IsAdmin {
+				adminUsers[m] = true
+			}
+		}
+		sort.Strings(members)
+		g.Members = members
+		inv.Groups = append(inv.Groups, g)
+		if len(inv.Groups) >= collectorResultLimit {
+			break
+		}
+	}
+
+	for _, u := range users {
+		u.Username = truncateCollectorString(strings.TrimSpace(u.Username))
+		if u.Username == "" {
+			continue
+		}
+		u.FullName = truncateCollectorString(strings.TrimSpace(u.FullName))
+		u.UID = truncateCollectorString(u.UID)
+		u.HomeDir = truncateCollectorString(u.HomeDir)
+		u.Shell = truncateCollectorString(u.Shell)
+		u.Groups = append([]string{}, memberOf[u.Username]...)
+		sort.Strings(u.Groups)
+		u.IsAdmin = u.IsAdmin || adminUsers[u.Username]
+		if u.PasswordLastSet != nil && !u.PasswordLastSet.IsZero() {
+			days := int(now.Sub(*u.PasswordLastSet).Hours() / 24)
+			if days < 0 {
+				days = 0
+			}
+			u.PasswordAgeDays = &days
+		}
+		inv.Users = append(inv.Users, u)
+		if len(inv.Users) >= collectorResultLimit {
+			break
+		}
+	}
+
+	sort.Slice(inv.Users, func(i, j int) bool { return inv.Users[i].Username < inv.Users[j].Username })
+	sort.Slice(inv.Groups, func(i, j int) bool { return inv.Groups[i].Name < inv.Groups[j].Name })
+	return inv
+}
+
+// Unix groups that grant sudo / admin rights.
+var unixAdminGroups = map[string]bool{"sudo": true, "wheel": true, "admin": true}
+
+// parseEtcPasswd parses /etc/passwd. Accounts with a nologin/false shell are
+// reported as disabled, matching the change tracker. The returned map is
+// primary gid -> usernames, for parseEtcGroup.
+func parseEtcPasswd(data string) ([]LocalUser, map[string][]string) {
+	users := make([]LocalUser, 0)
+	primaryMembers := make(map[string][]string) // gid -> usernames
+	for _, line := range strings.Split(data, "\n") {
+		parts := strings.Split(line, ":")
+		if len(parts) < 7 || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		username := strings.TrimSpace(parts[0])
+		uid, err := strconv.Atoi(parts[2])
+		if username == "" || err != nil {
+			continue
+		}
+		shell := strings.TrimSpace(parts[6])
+		users = append(users, LocalUser{
+			Username: username,
+			FullName: strings.TrimSpace(strings.Split(parts[4], ",")[0]),
+			UID:      parts[2],
+			HomeDir:  strings.TrimSpace(parts[5]),
+			Shell:    shell,
+			Disabled: shell == "/usr/sbin/nologin" || shell == "/sbin/nologin" || shell == "/bin/false",
+			IsAdmin:  uid == 0,
+			IsSystem: uid != 0 && (uid < 1000 || uid == 65534),
+		})
+		primaryMembers[parts[3]] = append(primaryMembers[parts[3]], username)
+	}
+	return users, primaryMembers
+}
+
+// shadowEntry is the part of an /etc/shadow line the inventory reports.
+type shadowEntry struct {
+	locked          bool
+	passwordLastSet *time.Time
+}
+
+// parseEtcShadow parses /etc/shadow. The last-change field counts days since
+// the epoch; 0 means "must change at next login" and is not a set date.
+func parseEtcShadow(data string) map[string]shadowEntry {
+	entries := make(map[string]shadowEntry)
+	for _, line := range strings.Split(data, "\n") {
+		parts := strings.Split(line, ":")
+		if len(parts) < 3 {
+			continue
+		}
+		username := strings.TrimSpace(parts[0])
+		if username == "" {
+			continue
+		}
+		password := strings.TrimSpace(parts[1])
+		entry := shadowEntry{locked: strings.HasPrefix(password, "!") || strings.HasPrefix(password, "*")}
+		if days, err := strconv.Atoi(strings.TrimSpace(parts[2])); err == nil && days > 0 {
+			t := time.Unix(int64(days)*86400, 0).UTC()
+			entry.passwordLastSet = &t
+		}
+		entries[username] = entry
+	}
+	return entries
+}
+
+// parseEtcGroup parses /etc/group, folding in users whose primary group is
+// the group (keyed by gid in primaryMembers) since /etc/group lists only
+// supplementary members.
+func parseEtcGroup(data string, primaryMembers map[string][]string) []LocalGroup {
+	groups := make([]LocalGroup, 0)
+	for _, line := range strings.Split(data, "\n") {
+		parts := strings.Split(line, ":")
+		if len(parts) < 4 || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		name := strings.TrimSpace(parts[0])
+		if name == "" {
+			continue
+		}
+		members := append([]string{}, primaryMembers[parts[2]]...)
+		for _, m := range strings.Split(parts[3], ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				members = append(members, m)
+			}
+		}
+		groups = append(groups, LocalGroup{
+			Name:    name,
+			GID:     parts[2],
+			IsAdmin: unixAdminGroups[name],
+			Members: members,
+		})
+	}
+	return groups
+}
+
+// parseDSCLList parses `dscl . -list <path> <attribute>` output, one
+// "<record>  <value>" line per record, into record -> value.
+func parseDSCLList(output string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		values[fields[0]] = strings.Join(fields[1:], " ")
+	}
+	return values
+}
+
+var darwinPasswordLastSetRe = regexp.MustCompile(`<key>passwordLastSetTime</key>\s*<real>([0-9.]+)</real>`)
+
+// parseDarwinAccountRecord extracts the password-last-set time and disabled
+// state from `dscl . -read /Users/<name> accountPolicyData
+// AuthenticationAuthority` output.
+func parseDarwinAccountRecord(output string) (passwordLastSet *time.Time, disabled bool) {
+	if m := darwinPasswordLastSetRe.FindStringSubmatch(output); m != nil {
+		if secs, err := strconv.ParseFloat(m[1], 64); err == nil && secs > 0 {
+			t := time.Unix(int64(secs), 0).UTC()
+			passwordLastSet = &t
+		}
+	}
+	return passwordLastSet, strings.Contains(output, ";DisabledUser;")
+}
+
+// windowsLocalUserRow is one Get-LocalUser row, with Lockout joined from
+// Win32_UserAccount and timestamps pre-formatted as RFC 3339.
+type windowsLocalUserRow struct {
+	Name            string `json:"Name"`
+	FullName        string `json:"FullName"`
+	SID             string `json:"SID"`
+	Enabled         bool   `json:"Enabled"`
+	Lockout         bool   `json:"Lockout"`
+	LastLogon       string `json:"LastLogon"`
+	PasswordLastSet string `json:"PasswordLastSet"`
+}
+
+type windowsLocalGroupMemberRow struct {
+	Name string `json:"Name"`
+	SID  string `json:"SID"`
+}
+
+type windowsLocalGroupRow struct {
+	Name    string                       `json:"Name"`
+	SID     string                       `json:"SID"`
+	Members []windowsLocalGroupMemberRow `json:"Members"`
+}
+
+// windowsAdministratorsSID is the well-known SID of BUILTIN\Administrators.
+const windowsAdministratorsSID = "S-1-5-32-544"
+
+// localUsersFromWindowsRows converts the PowerShell rows. Group members that
+// are local accounts are matched by SID and reported by username. Guest,
+// DefaultAccount and WDAGUtilityAccount (RIDs 501, 503, 504) are flagged as
+// system accounts.
+func localUsersFromWindowsRows(userRows []windowsLocalUserRow, groupRows []windowsLocalGroupRow) ([]LocalUser, []LocalGroup) {
+	users := make([]LocalUser, 0, len(userRows))
+	bySID := make(map[string]string, len(userRows))
+	for _, row := range userRows {
+		name := strings.TrimSpace(row.Name)
+		if name == "" {
+			continue
+		}
+		bySID[strings.ToUpper(row.SID)] = name
+		rid := row.SID[strings.LastIndex(row.SID, "-")+1:]
+		users = append(users, LocalUser{
+			Username:        name,
+			FullName:        row.FullName,
+			UID:             row.SID,
+			Disabled:        !row.Enabled,
+			Locked:          row.Lockout,
+			IsSystem:        rid == "501" || rid == "503" || rid == "504",
+			LastLogon:       parseWindowsRFC3339(row.LastLogon),
+			PasswordLastSet: parseWindowsRFC3339(row.PasswordLastSet),
+		})
+	}
+
+	groups := make([]LocalGroup, 0, len(groupRows))
+	for _, row := range groupRows {
+		g := LocalGroup{
+			Name:    row.Name,
+			GID:     row.SID,
+			IsAdmin: strings.EqualFold(row.SID, windowsAdministratorsSID),
+			Members: make([]string, 0, len(row.Members)),
+		}
+		for _, m := range row.Members {
+			if local, ok := bySID[strings.ToUpper(m.SID)]; ok {
+				g.Members = append(g.Members, local)
+			} else {
+				g.Members = append(g.Members, m.Name)
+			}
+		}
+		groups = append(groups, g)
+	}
+	return users, groups
+}
+
+func parseWindowsRFC3339(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil || t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
@@ -0,0 +1,84 @@
+//go:build darwin
+
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func collectLocalUsers() ([]LocalUser, []LocalGroup, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectorLongCommandTimeout)
+	defer cancel()
+
+	uids, err := darwinDSCLList(ctx, "/Users", "UniqueID")
+	if err != nil {
+		return nil, nil, err
+	}
+	// The remaining attributes are best-effort; a missing one just leaves the
+	// field empty.
+	realNames, _ := darwinDSCLList(ctx, "/Users", "RealName")
+	homes, _ := darwinDSCLList(ctx, "/Users", "NFSHomeDirectory")
+	shells, _ := darwinDSCLList(ctx, "/Users", "UserShell")
+	primaryGIDs, _ := darwinDSCLList(ctx, "/Users", "PrimaryGroupID")
+	gids, _ := darwinDSCLList(ctx, "/Groups", "PrimaryGroupID")
+	memberships, _ := darwinDSCLList(ctx, "/Groups", "GroupMembership")
+
+	users := make([]LocalUser, 0, len(uids))
+	primaryMembers := make(map[string][]string)
+	for name, uidStr := range uids {
+		uid, err := strconv.Atoi(uidStr)
+		if name == "" || err != nil {
+			continue
+		}
+		shell := shells[name]
+		u := LocalUser{
+			Username: name,
+			FullName: realNames[name],
+			UID:      uidStr,
+			HomeDir:  homes[name],
+			Shell:    shell,
+			Disabled: shell == "/usr/bin/false",
+			IsAdmin:  uid == 0,
+			// Service accounts are "_"-prefixed or below the first login uid.
+			IsSystem: uid != 0 && (uid < 500 || strings.HasPrefix(name, "_")),
+		}
+		if !u.IsSystem {
+			if out, err := runCollectorOutputWithContext(ctx, collectorShortCommandTimeout, "dscl", ".", "-read", "/Users/"+name, "accountPolicyData", "AuthenticationAuthority"); err == nil {
+				var disabled bool
+				u.PasswordLastSet, disabled = parseDarwinAccountRecord(string(out))
+				u.Disabled = u.Disabled || disabled
+			}
+		}
+		users = append(users, u)
+		if gid := primaryGIDs[name]; gid != "" {
+			primaryMembers[gid] = append(primaryMembers[gid], name)
+		}
+	}
+
+	groups := make([]LocalGroup, 0, len(gids))
+	for name, gid := range gids {
+		if name == "" {
+			continue
+		}
+		members := append([]string{}, primaryMembers[gid]...)
+		members = append(members, strings.Fields(memberships[name])...)
+		groups = append(groups, LocalGroup{
+			Name:    name,
+			GID:     gid,
+			IsAdmin: name == "admin",
+			Members: members,
+		})
+	}
+	return users, groups, nil
+}
+
+func darwinDSCLList(ctx context.Context, path, attribute string) (map[string]string, error) {
+	output, err := runCollectorOutputWithContext(ctx, collectorShortCommandTimeout, "dscl", ".", "-list", path, attribute)
+	if err != nil {
+		return nil, fmt.Errorf("dscl %s %s query failed: %w", path, attribute, err)
+	}
+	return parseDSCLList(string(output)), nil
+}
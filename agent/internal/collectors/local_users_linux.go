@@ -0,0 +1,66 @@
+//go:build linux
+
+package collectors
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// lastlogRecordSize is sizeof(struct lastlog): int32 ll_time, char
+// ll_line[32], char ll_host[256]. The file is a sparse array indexed by uid.
+const lastlogRecordSize = 292
+
+func collectLocalUsers() ([]LocalUser, []LocalGroup, error) {
+	passwdData, err := os.ReadFile("/etc/passwd")
+	if err != nil {
+		return nil, nil, fmt.Errorf("read /etc/passwd: %w", err)
+	}
+	users, primaryMembers := parseEtcPasswd(string(passwdData))
+
+	// /etc/shadow needs root; without it lock state and password age are
+	// simply left unset.
+	if shadowData, err := os.ReadFile("/etc/shadow"); err == nil {
+		shadow := parseEtcShadow(string(shadowData))
+		for i := range users {
+			if entry, ok := shadow[users[i].Username]; ok {
+				users[i].Locked = entry.locked
+				users[i].PasswordLastSet = entry.passwordLastSet
+			}
+		}
+	}
+
+	// Distros that moved to lastlog2 no longer maintain this file.
+	if lastlog, err := os.Open("/var/log/lastlog"); err == nil {
+		for i := range users {
+			uid, err := strconv.ParseInt(users[i].UID, 10, 64)
+			if err != nil || uid < 0 {
+				continue
+			}
+			users[i].LastLogon = readLastlogRecord(lastlog, uid)
+		}
+		lastlog.Close()
+	}
+
+	var groups []LocalGroup
+	if groupData, err := os.ReadFile("/etc/group"); err == nil {
+		groups = parseEtcGroup(string(groupData), primaryMembers)
+	}
+	return users, groups, nil
+}
+
+func readLastlogRecord(f *os.File, uid int64) *time.Time {
+	var buf [4]byte
+	if _, err := f.ReadAt(buf[:], uid*lastlogRecordSize); err != nil {
+		return nil
+	}
+	secs := int32(binary.NativeEndian.Uint32(buf[:]))
+	if secs <= 0 {
+		return nil
+	}
+	t := time.Unix(int64(secs), 0).UTC()
+	return &t
+}
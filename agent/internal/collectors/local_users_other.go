@@ -0,0 +1,7 @@
+//go:build !linux && !darwin && !windows
+
+package collectors
+
+func collectLocalUsers() ([]LocalUser, []LocalGroup, error) {
+	return nil, nil, nil
+}
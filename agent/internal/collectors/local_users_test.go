@@ -0,0 +1,131 @@
+package collectors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalUsersFromEtcFiles(t *testing.T) {
+	passwd := `root:x:0:0:root:/root:/bin/bash
+daemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin
+alice:x:1000:1000:Alice Example,,,:/home/alice:/bin/bash
+bob:x:1001:1001::/home/bob:/bin/zsh
+# comment:x:5:5::/:/bin/sh
+`
+	shadow := `root:*:19000:0:99999:7:::
+alice:$6$salt$hash:19700:0:99999:7:::
+bob:!$6$salt$hash:0:0:99999:7:::
+`
+	group := `root:x:0:
+sudo:x:27:alice
+alice:x:1000:
+bob:x:1001:
+docker:x:998:alice,bob
+`
+	users, primary := parseEtcPasswd(passwd)
+	shadowEntries := parseEtcShadow(shadow)
+	for i := range users {
+		if e, ok := shadowEntries[users[i].Username]; ok {
+			users[i].Locked = e.locked
+			users[i].PasswordLastSet = e.passwordLastSet
+		}
+	}
+	groups := parseEtcGroup(group, primary)
+
+	now := time.Unix(19710*86400, 0).UTC()
+	inv := buildLocalUserInventory(users, groups, now)
+	if len(inv.Users) != 4 || len(inv.Groups) != 5 {
+		t.Fatalf("unexpected inventory sizes: %d users, %d groups", len(inv.Users), len(inv.Groups))
+	}
+	byName := map[string]LocalUser{}
+	for _, u := range inv.Users {
+		byName[u.Username] = u
+	}
+
+	alice := byName["alice"]
+	if !alice.IsAdmin || alice.IsSystem || alice.FullName != "Alice Example" {
+		t.Errorf("alice: %+v", alice)
+	}
+	if got := alice.Groups; len(got) != 3 || got[0] != "alice" || got[1] != "docker" || got[2] != "sudo" {
+		t.Errorf("alice groups = %v", got)
+	}
+	if alice.PasswordAgeDays == nil || *alice.PasswordAgeDays != 10 {
+		t.Errorf("alice password age = %v", alice.PasswordAgeDays)
+	}
+
+	bob := byName["bob"]
+	if bob.IsAdmin || !bob.Locked || bob.PasswordLastSet != nil || bob.PasswordAgeDays != nil {
+		t.Errorf("bob: %+v", bob)
+	}
+	if root := byName["root"]; !root.IsAdmin || root.IsSystem {
+		t.Errorf("root: %+v", root)
+	}
+	if daemon := byName["daemon"]; !daemon.IsSystem || !daemon.Disabled {
+		t.Errorf("daemon: %+v", daemon)
+	}
+}
+
+func TestLocalUsersFromWindowsRows(t *testing.T) {
+	userRows := []windowsLocalUserRow{
+		{Name: "Administrator", SID: "S-1-5-21-1-2-3-500", Enabled: false},
+		{Name: "Guest", SID: "S-1-5-21-1-2-3-501", Enabled: false},
+		{Name: "jdoe", FullName: "Jane Doe", SID: "S-1-5-21-1-2-3-1001", Enabled: true, Lockout: true,
+			LastLogon: "2026-03-01T08:30:00.0000000Z", PasswordLastSet: "2026-01-01T00:00:00.0000000Z"},
+	}
+	groupRows := []windowsLocalGroupRow{
+		{Name: "Administrators", SID: windowsAdministratorsSID, Members: []windowsLocalGroupMemberRow{
+			{Name: `PC01\Administrator`, SID: "S-1-5-21-1-2-3-500"},
+			{Name: `PC01\jdoe`, SID: "s-1-5-21-1-2-3-1001"},
+			{Name: `CORP\Domain Admins`, SID: "S-1-5-21-9-9-9-512"},
+		}},
+		{Name: "Users", SID: "S-1-5-32-545", Members: []windowsLocalGroupMemberRow{{Name: `PC01\jdoe`, SID: "S-1-5-21-1-2-3-1001"}}},
+	}
+
+	users, groups := localUsersFromWindowsRows(userRows, groupRows)
+	inv := buildLocalUserInventory(users, groups, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC))
+
+	jdoe := inv.Users[2]
+	if jdoe.Username != "jdoe" || !jdoe.IsAdmin || !jdoe.Locked || jdoe.Disabled {
+		t.Fatalf("jdoe: %+v", jdoe)
+	}
+	if len(jdoe.Groups) != 2 || jdoe.Groups[0] != "Administrators" {
+		t.Errorf("jdoe groups = %v", jdoe.Groups)
+	}
+	if jdoe.LastLogon == nil || !jdoe.LastLogon.Equal(time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)) {
+		t.Errorf("jdoe last logon = %v", jdoe.LastLogon)
+	}
+	if jdoe.PasswordAgeDays == nil || *jdoe.PasswordAgeDays != 60 {
+		t.Errorf("jdoe password age = %v", jdoe.PasswordAgeDays)
+	}
+	if guest := inv.Users[1]; guest.Username != "Guest" || !guest.IsSystem {
+		t.Errorf("guest: %+v", guest)
+	}
+	admins := inv.Groups[0]
+	if !admins.IsAdmin || len(admins.Members) != 3 || admins.Members[1] != `CORP\Domain Admins` {
+		t.Errorf("administrators: %+v", admins)
+	}
+}
+
+func TestParseDarwinAccountRecord(t *testing.T) {
+	output := `dsAttrTypeNative:accountPolicyData:
+ <?xml version="1.0" encoding="UTF-8"?>
+ <plist version="1.0">
+ <dict>
+ 	<key>creationTime</key>
+ 	<real>1700000000.5</real>
+ 	<key>passwordLastSetTime</key>
+ 	<real>1767225600.123</real>
+ </dict>
+ </plist>
+AuthenticationAuthority: ;ShadowHash;HASHLIST:<SALTED-SHA512-PBKDF2> ;DisabledUser;
+`
+	pwd, disabled := parseDarwinAccountRecord(output)
+	if pwd == nil || pwd.Unix() != 1767225600 || !disabled {
+		t.Fatalf("pwd=%v disabled=%v", pwd, disabled)
+	}
+
+	names := parseDSCLList("alice            Alice Example\n_www             World Wide Web Server\nroot             System Administrator\n")
+	if names["alice"] != "Alice Example" || names["_www"] != "World Wide Web Server" {
+		t.Errorf("parseDSCLList = %v", names)
+	}
+}
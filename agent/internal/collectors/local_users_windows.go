@@ -0,0 +1,58 @@
+//go:build windows
+
+package collectors
+
+import "context"
+
+// Get-LocalGroupMember fails outright when a group contains an orphaned
+// (unresolvable) SID, which is common in Administrators on re-imaged or
+// domain-removed machines, so fall back to ADSI for that group.
+const windowsLocalUsersScript = `
+$lock = @{}
+Get-CimInstance Win32_UserAccount -Filter "LocalAccount=True" -ErrorAction SilentlyContinue | ForEach-Object { $lock[$_.SID] = [bool]$_.Lockout }
+$users = @(Get-LocalUser | ForEach-Object {
+  [pscustomobject]@{
+    Name = $_.Name; FullName = $_.FullName; SID = $_.SID.Value; Enabled = [bool]$_.Enabled
+    Lockout = [bool]$lock[$_.SID.Value]
+    LastLogon = if ($_.LastLogon) { $_.LastLogon.ToUniversalTime().ToString('o') } else { '' }
+    PasswordLastSet = if ($_.PasswordLastSet) { $_.PasswordLastSet.ToUniversalTime().ToString('o') } else { '' }
+  }
+})
+$groups = @(Get-LocalGroup | ForEach-Object {
+  $g = $_
+  $members = @()
+  try {
+    $members = @(Get-LocalGroupMember -Group $g -ErrorAction Stop | ForEach-Object { [pscustomobject]@{ Name = $_.Name; SID = $_.SID.Value } })
+  } catch {
+    try {
+      $members = @(([ADSI]"WinNT://$env:COMPUTERNAME/$($g.Name),group").Invoke('Members') | ForEach-Object {
+        $path = $_.GetType().InvokeMember('ADsPath', 'GetProperty', $null, $_, $null)
+        $raw = $_.GetType().InvokeMember('objectSid', 'GetProperty', $null, $_, $null)
+        [pscustomobject]@{ Name = (($path -replace '^WinNT://', '') -replace '/', '\'); SID = (New-Object System.Security.Principal.SecurityIdentifier($raw, 0)).Value }
+      })
+    } catch {}
+  }
+  [pscustomobject]@{ Name = $g.Name; SID = $g.SID.Value; Members = $members }
+})
+[pscustomobject]@{ Users = $users; Groups = $groups } | ConvertTo-Json -Compress -Depth 4
+`
+
+type windowsLocalUsersResult struct {
+	Users  []windowsLocalUserRow  `json:"Users"`
+	Groups []windowsLocalGroupRow `json:"Groups"`
+}
+
+func collectLocalUsers() ([]LocalUser, []LocalGroup, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectorLongCommandTimeout)
+	defer cancel()
+
+	rows, err := runWindowsJSON[windowsLocalUsersResult](ctx, utf8PowerShellCommand(windowsLocalUsersScript))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, nil
+	}
+	users, groups := localUsersFromWindowsRows(rows[0].Users, rows[0].Groups)
+	return users, groups, nil
+}
@@ -32,6 +32,14 @@ type SystemMetrics struct {
 	BandwidthInBps  uint64               `json:"bandwidthInBps,omitempty"`
 	BandwidthOutBps uint64               `json:"bandwidthOutBps,omitempty"`
 	InterfaceStats  []InterfaceBandwidth `json:"interfaceStats,omitempty"`
+
+	// Per-adapter GPU utilization/VRAM/temperature. Omitted on devices with no
+	// GPU telemetry source.
+	GPUs []GPUMetrics `json:"gpus,omitempty"`
+
+	// CPU temperature, fan speeds and throttling indicators. Omitted on
+	// devices with no OS-visible thermal sensors (most VMs).
+	Thermal *ThermalMetrics `json:"thermal,omitempty"`
 }
 
 // InterfaceBandwidth tracks per-interface bandwidth rates.
@@ -78,6 +86,22 @@ type MetricsCollector struct {
 	lastIface  map[string]ifaceSnapshot
 	speedCache map[string]cachedSpeed
 	lastDisk   map[string]diskSnapshot
+
+	// gpuAbsentUntil suppresses GPU probing after a run found nothing;
+	// gpuLast is the snapshot reused within gpuSampleInterval.
+	gpuAbsentUntil time.Time
+	gpuLast        []GPUMetrics
+	gpuSampledAt   time.Time
+
+	// thermalAbsentUntil suppresses thermal probing after a run found
+	// nothing; thermalLast is the snapshot reused within
+	// thermalSampleInterval; lastThrottleCount is the previous cumulative
+	// throttle count.
+	thermalAbsentUntil time.Time
+	thermalLast        *ThermalMetrics
+	thermalSampledAt   time.Time
+	lastThrottleCount  uint64
+	haveThrottleCount  bool
 }
 
 const speedCacheTTL = 5 * time.Minute
@@ -220,6 +244,9 @@ func (c *MetricsCollector) Collect() (*SystemMetrics, error) {
 
 	c.lastTime = now
 
+	metrics.GPUs = c.collectGPUs(now)
+	metrics.Thermal = c.collectThermal(now)
+
 	// Process count
 	procs, err := process.Processes()
 	if err == nil {
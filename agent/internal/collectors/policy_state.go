@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"os"
 	"path"
+	"regexp"
 	"strings"
 )
 
@@ -30,6 +31,11 @@ type ConfigStateEntry struct {
 	ConfigValue any    `json:"configValue,omitempty"`
 }
 
+type SysctlStateEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
 var allowedPolicyConfigKeysByPath = map[string]map[string]struct{}{
 	"/etc/ssh/sshd_config": {
 		"allowtcpforwarding":              {},
@@ -87,6 +93,9 @@ var allowedSysctlConfigKeys = map[string]struct{}{
 	"net.ipv6.conf.default.disable_ipv6":     {},
 }
 
+// sysctlKeyPattern keeps runtime sysctl probes inside /proc/sys.
+var sysctlKeyPattern = regexp.MustCompile(`^[a-z0-9_]+(\.[A-Za-z0-9_-]+)+$`)
+
 type PolicyStateCollector struct {
 	readFile func(string) ([]byte, error)
 }
@@ -136,6 +145,36 @@ func (c *PolicyStateCollector) CollectConfigState(probes []ConfigProbe) ([]Confi
 	return entries, nil
 }
 
+// CollectSysctlState reads the running kernel's values from /proc/sys, which
+// can differ from what the sysctl.conf files ask for until the next boot.
+func (c *PolicyStateCollector) CollectSysctlState(keys []string) ([]SysctlStateEntry, error) {
+	entries := make([]SysctlStateEntry, 0, len(keys))
+	seen := make(map[string]struct{})
+
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if !sysctlKeyPattern.MatchString(key) {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		content, err := c.readConfigFile("/proc/sys/" + strings.ReplaceAll(key, ".", "/"))
+		if err != nil {
+			continue
+		}
+
+		entries = append(entries, SysctlStateEntry{
+			Key:   key,
+			Value: strings.Join(strings.Fields(string(content)), " "),
+		})
+	}
+
+	return entries, nil
+}
+
 func (c *PolicyStateCollector) readConfigFile(filePath string) ([]byte, error) {
 	if c != nil && c.readFile != nil {
 		return c.readFile(filePath)
@@ -183,6 +222,17 @@ func isSensitiveConfigKey(configKey string) bool {
 		strings.HasSuffix(normalized, "secret")
 }
 
+// IsAllowedConfigProbe reports whether CollectConfigState will read a probe,
+// so callers can tell a rejected probe from a key that is not set.
+func IsAllowedConfigProbe(probe ConfigProbe) bool {
+	return isAllowedPolicyConfigProbe(probe.FilePath, probe.ConfigKey)
+}
+
+// IsSensitiveConfigKey reports whether a key's value must not be reported.
+func IsSensitiveConfigKey(configKey string) bool {
+	return isSensitiveConfigKey(configKey)
+}
+
 func isAllowedPolicyConfigProbe(filePath string, configKey string) bool {
 	normalizedPath := normalizePolicyConfigPath(filePath)
 	normalizedKey := normalizePolicyConfigKey(configKey)
@@ -1,6 +1,9 @@
 package collectors
 
-import "testing"
+import (
+	"os"
+	"testing"
+)
 
 func TestExtractConfigValue(t *testing.T) {
 	content := `
@@ -95,3 +98,36 @@ func TestCollectConfigStateRejectsUnsafeProbesBeforeRead(t *testing.T) {
 		t.Fatalf("expected no entries for unsafe probes, got %d", len(entries))
 	}
 }
+
+func TestCollectSysctlState(t *testing.T) {
+	var reads []string
+	collector := &PolicyStateCollector{
+		readFile: func(filePath string) ([]byte, error) {
+			reads = append(reads, filePath)
+			if filePath == "/proc/sys/net/ipv4/ip_forward" {
+				return []byte("0\n"), nil
+			}
+			if filePath == "/proc/sys/net/ipv4/ip_local_port_range" {
+				return []byte("32768\t60999\n"), nil
+			}
+			return nil, os.ErrNotExist
+		},
+	}
+
+	entries, err := collector.CollectSysctlState([]string{
+		"net.ipv4.ip_forward",
+		"net.ipv4.ip_local_port_range",
+		"kernel.missing",
+		"../../etc/shadow",
+		"net.ipv4.ip_forward",
+	})
+	if err != nil {
+		t.Fatalf("collect sysctl state failed: %v", err)
+	}
+	if len(reads) != 3 {
+		t.Fatalf("expected 3 reads, got %v", reads)
+	}
+	if len(entries) != 2 || entries[0].Value != "0" || entries[1].Value != "32768 60999" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+}
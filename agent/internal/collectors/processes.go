@@ -0,0 +1,312 @@
+package collectors
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// Process inventory (full running-process list for troubleshooting and
+// security triage).
+//
+// This is deliberately separate from the top-N process sampler
+// (tools.TopProcessSample): the sampler is a cheap 3-minute "what is hot"
+// snapshot, while the inventory is the complete table with the identity
+// fields a responder needs — on-disk path, SHA-256, owning user, and code
+// signature status. Hashing and signature verification are the expensive
+// parts, so both are cached per executable and only recomputed when the file's
+// size or mtime changes.
+
+// ProcessSignatureStatus is the closed signature vocabulary reported per
+// process. Mirrors the API's processes ingest schema.
+type ProcessSignatureStatus string
+
+const (
+	ProcessSignatureSigned   ProcessSignatureStatus = "signed"
+	ProcessSignatureUnsigned ProcessSignatureStatus = "unsigned"
+	ProcessSignatureInvalid  ProcessSignatureStatus = "invalid"
+	ProcessSignatureUnknown  ProcessSignatureStatus = "unknown"
+)
+
+// ProcessInventoryItem is one running process in the inventory payload.
+type ProcessInventoryItem struct {
+	PID             int32                  `json:"pid"`
+	ParentPID       int32                  `json:"parentPid,omitempty"`
+	Name            string                 `json:"name"`
+	Path            string                 `json:"path,omitempty"`
+	SHA256          string                 `json:"sha256,omitempty"`
+	User            string                 `json:"user,omitempty"`
+	CPUPercent      float64                `json:"cpuPercent"`
+	RSSBytes        uint64                 `json:"rssBytes"`
+	StartTime       *time.Time             `json:"startTime,omitempty"`
+	SignatureStatus ProcessSignatureStatus `json:"signatureStatus"`
+	Signer          string                 `json:"signer,omitempty"`
+}
+
+const (
+	// processCPUSampleWindow matches the interactive process list: CPU% is a
+	// rate over a short shared window, never gopsutil's lifetime average.
+	processCPUSampleWindow = 250 * time.Millisecond
+	// processHashMaxBytes skips hashing pathological executables (multi-GB
+	// game binaries, AppImages) that would stall the collection for seconds.
+	processHashMaxBytes = 512 * 1024 * 1024
+)
+
+// executableIdentity is the cached hash + signature result for one on-disk
+// executable, keyed by path and invalidated by size/mtime.
+type executableIdentity struct {
+	size      int64
+	modTime   time.Time
+	sha256    string
+	signature ProcessSignatureStatus
+	signer    string
+	lastSeen  time.Time
+}
+
+// signatureResult is the per-path output of the platform signature verifier.
+type signatureResult struct {
+	Status ProcessSignatureStatus
+	Signer string
+}
+
+// ProcessCollector enumerates running processes with identity fields.
+type ProcessCollector struct {
+	mu    sync.Mutex
+	cache map[string]*executableIdentity
+}
+
+// NewProcessCollector creates a new process inventory collector.
+func NewProcessCollector() *ProcessCollector {
+	return &ProcessCollector{cache: make(map[string]*executableIdentity)}
+}
+
+// Collect returns the running-process inventory, sorted by PID.
+func (c *ProcessCollector) Collect() ([]ProcessInventoryItem, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, fmt.Errorf("enumerate processes: %w", err)
+	}
+
+	cpuPercents := sampleCollectorCPUPercents(procs, processCPUSampleWindow)
+
+	items := make([]ProcessInventoryItem, 0, len(procs))
+	for _, p := range procs {
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		item := ProcessInventoryItem{
+			PID:             p.Pid,
+			Name:            name,
+			CPUPercent:      cpuPercents[p.Pid],
+			SignatureStatus: ProcessSignatureUnknown,
+		}
+		if ppid, err := p.Ppid(); err == nil {
+			item.ParentPID = ppid
+		}
+		if exe, err := p.Exe(); err == nil {
+			item.Path = exe
+		}
+		if user, err := p.Username(); err == nil {
+			item.User = user
+		}
+		if mem, err := p.MemoryInfo(); err == nil && mem != nil {
+			item.RSSBytes = mem.RSS
+		}
+		if created, err := p.CreateTime(); err == nil && created > 0 {
+			t := time.UnixMilli(created).UTC()
+			item.StartTime = &t
+		}
+		items = append(items, item)
+		if len(items) >= collectorResultLimit {
+			break
+		}
+	}
+
+	if len(procs) > 0 && len(items) == 0 {
+		return nil, fmt.Errorf("collected 0 of %d processes: all Name() lookups failed", len(procs))
+	}
+
+	c.resolveIdentities(items, time.Now())
+
+	for i := range items {
+		items[i] = sanitizeProcessInventoryItem(items[i])
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].PID < items[j].PID })
+	return items, nil
+}
+
+// resolveIdentities fills SHA256/signature fields from the per-path cache,
+// hashing and verifying only executables that are new or changed on disk.
+// Signature checks for all stale paths are batched into one platform call.
+func (c *ProcessCollector) resolveIdentities(items []ProcessInventoryItem, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var stale []string
+	staleSet := make(map[string]bool)
+	for _, item := range items {
+		if item.Path == "" || staleSet[item.Path] {
+			continue
+		}
+		fi, err := os.Stat(item.Path)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		cached, ok := c.cache[item.Path]
+		if ok && cached.size == fi.Size() && cached.modTime.Equal(fi.ModTime()) {
+			cached.lastSeen = now
+			continue
+		}
+		entry := &executableIdentity{
+			size:      fi.Size(),
+			modTime:   fi.ModTime(),
+			signature: ProcessSignatureUnknown,
+			lastSeen:  now,
+		}
+		if fi.Size() <= processHashMaxBytes {
+			if sum, err := hashFileSHA256(item.Path); err == nil {
+				entry.sha256 = sum
+			}
+		}
+		c.cache[item.Path] = entry
+		staleSet[item.Path] = true
+		stale = append(stale, item.Path)
+	}
+
+	if len(stale) > 0 {
+		for path, res := range verifyExecutableSignatures(stale) {
+			if entry, ok := c.cache[path]; ok && res.Status != "" {
+				entry.signature = res.Status
+				entry.signer = res.Signer
+			}
+		}
+	}
+
+	for i := range items {
+		entry, ok := c.cache[items[i].Path]
+		if !ok {
+			continue
+		}
+		items[i].SHA256 = entry.sha256
+		items[i].SignatureStatus = entry.signature
+		items[i].Signer = entry.signer
+	}
+
+	// Evict executables that no longer back any process so the cache tracks
+	// the live process set instead of growing for the agent's lifetime.
+	for path, entry := range c.cache {
+		if entry.lastSeen.Before(now) {
+			delete(c.cache, path)
+		}
+	}
+}
+
+func hashFileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sampleCollectorCPUPercents measures instantaneous per-process CPU (100% ==
+// one core) over a single shared window. Same approach as the interactive
+// process list in tools: read counters, sleep once, read again, diff.
+func sampleCollectorCPUPercents(procs []*process.Process, window time.Duration) map[int32]float64 {
+	type snapshot struct {
+		seconds float64
+		at      time.Time
+	}
+
+	first := make(map[int32]snapshot, len(procs))
+	for _, p := range procs {
+		if ts, err := p.Times(); err == nil {
+			first[p.Pid] = snapshot{seconds: ts.User + ts.System, at: time.Now()}
+		}
+	}
+
+	time.Sleep(window)
+
+	out := make(map[int32]float64, len(procs))
+	for _, p := range procs {
+		prev, ok := first[p.Pid]
+		if !ok {
+			continue
+		}
+		ts, err := p.Times()
+		if err != nil {
+			continue
+		}
+		elapsed := time.Since(prev.at).Seconds()
+		delta := ts.User + ts.System - prev.seconds
+		if elapsed <= 0 || delta < 0 {
+			continue
+		}
+		out[p.Pid] = 100 * delta / elapsed
+	}
+	return out
+}
+
+// mapAuthenticodeStatus converts a Get-AuthenticodeSignature Status
+// ("Valid", "NotSigned", "HashMismatch", "NotTrusted", ...) to our vocabulary.
+// Pure so it is table-testable off Windows.
+func mapAuthenticodeStatus(status string) ProcessSignatureStatus {
+	switch strings.TrimSpace(status) {
+	case "Valid":
+		return ProcessSignatureSigned
+	case "NotSigned":
+		return ProcessSignatureUnsigned
+	case "HashMismatch", "NotTrusted", "Incompatible":
+		return ProcessSignatureInvalid
+	default:
+		return ProcessSignatureUnknown
+	}
+}
+
+// parseCertificateCommonName extracts CN= from an X.500 subject such as
+// `CN=Microsoft Windows, O=Microsoft Corporation, L=Redmond, C=US`, falling
+// back to the whole subject when there is no CN component.
+func parseCertificateCommonName(subject string) string {
+	subject = strings.TrimSpace(subject)
+	for _, part := range strings.Split(subject, ",") {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "CN=") {
+			return strings.TrimPrefix(part, "CN=")
+		}
+	}
+	return subject
+}
+
+// parseCodesignAuthority returns the leaf signing authority (the first
+// `Authority=` line) from `codesign -dvv` output.
+func parseCodesignAuthority(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "Authority="); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func sanitizeProcessInventoryItem(item ProcessInventoryItem) ProcessInventoryItem {
+	item.Name = truncateCollectorString(item.Name)
+	item.Path = truncateCollectorString(item.Path)
+	item.User = truncateCollectorString(item.User)
+	item.Signer = truncateCollectorString(item.Signer)
+	return item
+}
@@ -0,0 +1,51 @@
+//go:build darwin
+
+package collectors
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// verifyExecutableSignatures checks each path with `codesign --verify` and, for
+// valid signatures, pulls the leaf Authority from `codesign -dvv`. Results are
+// cached by the caller per (path, size, mtime), so this runs only for new or
+// changed executables.
+func verifyExecutableSignatures(paths []string) map[string]signatureResult {
+	out := make(map[string]signatureResult, len(paths))
+	for _, path := range paths {
+		out[path] = verifyDarwinSignature(path)
+	}
+	return out
+}
+
+func verifyDarwinSignature(path string) signatureResult {
+	ctx, cancel := context.WithTimeout(context.Background(), collectorShortCommandTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "codesign", "--verify", "--strict", path).CombinedOutput()
+	if ctx.Err() != nil {
+		return signatureResult{Status: ProcessSignatureUnknown}
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return signatureResult{Status: ProcessSignatureUnknown}
+		}
+		return signatureResult{Status: classifyCodesignFailure(string(output))}
+	}
+
+	details, _ := runCollectorCombinedOutput(collectorShortCommandTimeout, "codesign", "-dvv", path)
+	return signatureResult{Status: ProcessSignatureSigned, Signer: parseCodesignAuthority(string(details))}
+}
+
+// classifyCodesignFailure separates "no signature at all" from a signature
+// that exists but fails validation (tampered binary, revoked cert).
+func classifyCodesignFailure(output string) ProcessSignatureStatus {
+	if strings.Contains(output, "not signed at all") {
+		return ProcessSignatureUnsigned
+	}
+	return ProcessSignatureInvalid
+}
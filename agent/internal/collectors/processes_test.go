@@ -0,0 +1,103 @@
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMapAuthenticodeStatus(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ProcessSignatureStatus
+	}{
+		{"Valid", ProcessSignatureSigned},
+		{"NotSigned", ProcessSignatureUnsigned},
+		{"HashMismatch", ProcessSignatureInvalid},
+		{"NotTrusted", ProcessSignatureInvalid},
+		{"NotSupportedFileFormat", ProcessSignatureUnknown},
+		{"UnknownError", ProcessSignatureUnknown},
+		{"", ProcessSignatureUnknown},
+	}
+	for _, tt := range tests {
+		if got := mapAuthenticodeStatus(tt.in); got != tt.want {
+			t.Errorf("mapAuthenticodeStatus(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseCertificateCommonName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"CN=Microsoft Windows, O=Microsoft Corporation, L=Redmond, C=US", "Microsoft Windows"},
+		{"O=NoCommonName", "O=NoCommonName"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := parseCertificateCommonName(tt.in); got != tt.want {
+			t.Errorf("parseCertificateCommonName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseCodesignAuthority(t *testing.T) {
+	output := "Executable=/Applications/Safari.app/Contents/MacOS/Safari\n" +
+		"Identifier=com.apple.Safari\n" +
+		"Authority=Software Signing\n" +
+		"Authority=Apple Code Signing Certification Authority\n" +
+		"Authority=Apple Root CA\n"
+	if got := parseCodesignAuthority(output); got != "Software Signing" {
+		t.Fatalf("parseCodesignAuthority = %q, want leaf authority", got)
+	}
+	if got := parseCodesignAuthority("code object is not signed at all"); got != "" {
+		t.Fatalf("parseCodesignAuthority(unsigned) = %q, want empty", got)
+	}
+}
+
+func TestProcessCollectorResolveIdentitiesCachesAndEvicts(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "tool")
+	if err := os.WriteFile(exe, []byte("hello"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	const helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	c := NewProcessCollector()
+	now := time.Now()
+	items := []ProcessInventoryItem{{PID: 1, Path: exe}, {PID: 2, Path: exe}, {PID: 3}}
+	c.resolveIdentities(items, now)
+
+	for _, item := range items[:2] {
+		if item.SHA256 != helloSHA256 {
+			t.Fatalf("pid %d sha256 = %q, want %q", item.PID, item.SHA256, helloSHA256)
+		}
+	}
+	if items[2].SHA256 != "" {
+		t.Fatalf("pathless process got a hash: %q", items[2].SHA256)
+	}
+	if len(c.cache) != 1 {
+		t.Fatalf("cache size = %d, want 1", len(c.cache))
+	}
+
+	// A content change with a new mtime must invalidate the cached hash.
+	if err := os.WriteFile(exe, []byte("changed"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	later := now.Add(time.Hour)
+	if err := os.Chtimes(exe, later, later); err != nil {
+		t.Fatal(err)
+	}
+	again := []ProcessInventoryItem{{PID: 1, Path: exe}}
+	c.resolveIdentities(again, now.Add(time.Minute))
+	if again[0].SHA256 == helloSHA256 || again[0].SHA256 == "" {
+		t.Fatalf("hash not recomputed after change: %q", again[0].SHA256)
+	}
+
+	// A run that no longer sees the executable evicts it.
+	c.resolveIdentities(nil, now.Add(2*time.Minute))
+	if len(c.cache) != 0 {
+		t.Fatalf("cache not evicted, size = %d", len(c.cache))
+	}
+}
@@ -0,0 +1,50 @@
+//go:build windows
+
+package collectors
+
+import (
+	"context"
+	"strings"
+)
+
+// processSignatureBatchSize bounds how many paths go into one PowerShell
+// invocation so the command line stays well under the 32K limit.
+const processSignatureBatchSize = 64
+
+type authenticodeRow struct {
+	Path   string `json:"Path"`
+	Status string `json:"Status"`
+	Signer string `json:"Signer"`
+}
+
+// verifyExecutableSignatures runs Get-AuthenticodeSignature over the paths in
+// batches (one PowerShell start per batch, not per file). A failed batch leaves
+// its paths out of the result so they stay "unknown".
+func verifyExecutableSignatures(paths []string) map[string]signatureResult {
+	out := make(map[string]signatureResult, len(paths))
+	for start := 0; start < len(paths); start += processSignatureBatchSize {
+		end := min(start+processSignatureBatchSize, len(paths))
+		quoted := make([]string, 0, end-start)
+		for _, p := range paths[start:end] {
+			quoted = append(quoted, "'"+strings.ReplaceAll(p, "'", "''")+"'")
+		}
+		script := utf8PowerShellCommand(`@(` + strings.Join(quoted, ",") + `) | ForEach-Object { ` +
+			`$s = Get-AuthenticodeSignature -LiteralPath $_ -ErrorAction SilentlyContinue; ` +
+			`[pscustomobject]@{ Path = $_; Status = [string]$s.Status; Signer = if ($s.SignerCertificate) { $s.SignerCertificate.Subject } else { '' } } ` +
+			`} | ConvertTo-Json -Compress`)
+
+		ctx, cancel := context.WithTimeout(context.Background(), collectorLongCommandTimeout)
+		rows, err := runWindowsJSON[authenticodeRow](ctx, script)
+		cancel()
+		if err != nil {
+			continue
+		}
+		for _, row := range rows {
+			out[row.Path] = signatureResult{
+				Status: mapAuthenticodeStatus(row.Status),
+				Signer: parseCertificateCommonName(row.Signer),
+			}
+		}
+	}
+	return out
+}
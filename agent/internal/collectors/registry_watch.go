@@ -0,0 +1,226 @@
+package collectors
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChangeTypeRegistry marks a value added, removed or modified under a key
+// watched by RegistryWatcher.
+const ChangeTypeRegistry ChangeType = "registry"
+
+// DefaultRegistryWatchKeys are the persistence and security-policy keys
+// watched when no list is configured. A trailing `\*` watches the subtree.
+var DefaultRegistryWatchKeys = []string{
+	`HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\Run`,
+	`HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\RunOnce`,
+	`HKLM\SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Run`,
+	`HKLM\SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\RunOnce`,
+	`HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion\Winlogon`,
+	`HKLM\SYSTEM\CurrentControlSet\Control\Lsa`,
+	`HKLM\SOFTWARE\Policies\*`,
+}
+
+const (
+	// registryWatchDebounce coalesces bursts (an installer writing a dozen
+	// values) into one diff.
+	registryWatchDebounce = 2 * time.Second
+	// registryWatchMaxValues bounds a subtree snapshot so a watch on a huge
+	// key cannot balloon memory.
+	registryWatchMaxValues = 5000
+	// registryWatchMaxDepth bounds subtree recursion.
+	registryWatchMaxDepth = 8
+	// registryWatchRetry is how often a missing or unreadable key is retried.
+	registryWatchRetry = 5 * time.Minute
+)
+
+// registryWatchSpec is a parsed watch-list entry.
+type registryWatchSpec struct {
+	path    string // as configured, without the subtree suffix
+	subtree bool
+}
+
+// parseRegistryWatchKeys normalises and de-duplicates a watch list.
+// Separators are normalised to backslashes; a trailing `\*` selects the
+// subtree.
+func parseRegistryWatchKeys(keys []string) []registryWatchSpec {
+	seen := make(map[string]bool, len(keys))
+	specs := make([]registryWatchSpec, 0, len(keys))
+	for _, raw := range keys {
+		path := strings.Trim(strings.ReplaceAll(strings.TrimSpace(raw), "/", `\`), `\`)
+		subtree := false
+		if strings.HasSuffix(path, `\*`) {
+			subtree = true
+			path = strings.TrimRight(strings.TrimSuffix(path, `\*`), `\`)
+		}
+		if path == "" || !strings.Contains(path, `\`) {
+			continue
+		}
+		dedupe := strings.ToLower(path)
+		if seen[dedupe] {
+			continue
+		}
+		seen[dedupe] = true
+		specs = append(specs, registryWatchSpec{path: path, subtree: subtree})
+	}
+	return specs
+}
+
+// registryValue is one rendered value in a key snapshot.
+type registryValue struct {
+	Type string
+	Data string
+}
+
+// registrySnapshot maps `<relative subkey>\<value name>` (the value name
+// alone for the watched key itself) to its value.
+type registrySnapshot map[string]registryValue
+
+// diffRegistrySnapshots returns one ChangeRecord per added, removed or
+// modified value. Subjects are full registry paths.
+func diffRegistrySnapshots(root string, before, after registrySnapshot, now time.Time) []ChangeRecord {
+	var changes []ChangeRecord
+	subject := func(rel string) string {
+		if rel == "" {
+			return root + `\(Default)`
+		}
+		if strings.HasSuffix(rel, `\`) {
+			return root + `\` + rel + "(Default)"
+		}
+		return root + `\` + rel
+	}
+	record := func(action ChangeAction, rel string, b, a *registryValue) {
+		c := ChangeRecord{
+			Timestamp:    now,
+			ChangeType:   ChangeTypeRegistry,
+			ChangeAction: action,
+			Subject:      truncateCollectorString(subject(rel)),
+			Details:      map[string]any{"key": root, "source": "registry_watch"},
+		}
+		if b != nil {
+			c.BeforeValue = map[string]any{"type": b.Type, "data": truncateCollectorString(b.Data)}
+		}
+		if a != nil {
+			c.AfterValue = map[string]any{"type": a.Type, "data": truncateCollectorString(a.Data)}
+		}
+		changes = append(changes, c)
+	}
+
+	for name, a := range after {
+		a := a
+		b, ok := before[name]
+		switch {
+		case !ok:
+			record(ChangeActionAdded, name, nil, &a)
+		case b != a:
+			b := b
+			record(ChangeActionModified, name, &b, &a)
+		}
+	}
+	for name, b := range before {
+		if _, ok := after[name]; !ok {
+			b := b
+			record(ChangeActionRemoved, name, &b, nil)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Subject < changes[j].Subject })
+	return changes
+}
+
+// RegistryWatcher reports registry value changes under a configurable set of
+// keys as they happen (RegNotifyChangeKeyValue on Windows), rather than on
+// the next periodic snapshot diff. Elsewhere it is inert.
+type RegistryWatcher struct {
+	emit func([]ChangeRecord)
+
+	mu      sync.Mutex
+	keys    []string
+	stop    chan struct{}
+	done    sync.WaitGroup
+	running bool
+}
+
+// NewRegistryWatcher returns a watcher for keys (DefaultRegistryWatchKeys
+// when empty) that calls emit with each debounced batch of changes.
+func NewRegistryWatcher(keys []string, emit func([]ChangeRecord)) *RegistryWatcher {
+	return &RegistryWatcher{keys: effectiveRegistryWatchKeys(keys), emit: emit}
+}
+
+func effectiveRegistryWatchKeys(keys []string) []string {
+	if len(keys) == 0 {
+		return append([]string(nil), DefaultRegistryWatchKeys...)
+	}
+	return append([]string(nil), keys...)
+}
+
+// Keys returns the effective watch list.
+func (w *RegistryWatcher) Keys() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.keys...)
+}
+
+// Start begins watching. It is a no-op when already running or when the
+// platform has no registry.
+func (w *RegistryWatcher) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.running || !registryWatchSupported {
+		return
+	}
+	w.running = true
+	w.stop = make(chan struct{})
+	for _, spec := range parseRegistryWatchKeys(w.keys) {
+		w.done.Add(1)
+		go func(spec registryWatchSpec, stop <-chan struct{}) {
+			defer w.done.Done()
+			watchRegistryKey(spec, stop, w.emit)
+		}(spec, w.stop)
+	}
+}
+
+// Stop ends all watches and waits for them to exit.
+func (w *RegistryWatcher) Stop() {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return
+	}
+	w.running = false
+	close(w.stop)
+	w.mu.Unlock()
+	w.done.Wait()
+}
+
+// SetKeys replaces the watch list (empty restores the defaults), restarting
+// the watches if running. It reports whether the list changed.
+func (w *RegistryWatcher) SetKeys(keys []string) bool {
+	keys = effectiveRegistryWatchKeys(keys)
+	w.mu.Lock()
+	if equalFoldStrings(w.keys, keys) {
+		w.mu.Unlock()
+		return false
+	}
+	w.keys = keys
+	running := w.running
+	w.mu.Unlock()
+	if running {
+		w.Stop()
+		w.Start()
+	}
+	return true
+}
+
+func equalFoldStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
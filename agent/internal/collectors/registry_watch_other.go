@@ -0,0 +1,7 @@
+//go:build !windows
+
+package collectors
+
+const registryWatchSupported = false
+
+func watchRegistryKey(registryWatchSpec, <-chan struct{}, func([]ChangeRecord)) {}
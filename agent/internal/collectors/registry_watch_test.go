@@ -0,0 +1,70 @@
+package collectors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRegistryWatchKeys(t *testing.T) {
+	specs := parseRegistryWatchKeys([]string{
+		`HKLM\SOFTWARE\Policies\*`,
+		`hklm/software/policies`,
+		` HKLM\SYSTEM\CurrentControlSet\Control\Lsa\ `,
+		`HKLM`,
+		``,
+	})
+	if len(specs) != 2 {
+		t.Fatalf("expected 2 specs after de-duplication, got %+v", specs)
+	}
+	if specs[0].path != `HKLM\SOFTWARE\Policies` || !specs[0].subtree {
+		t.Errorf("unexpected subtree spec %+v", specs[0])
+	}
+	if specs[1].path != `HKLM\SYSTEM\CurrentControlSet\Control\Lsa` || specs[1].subtree {
+		t.Errorf("unexpected key spec %+v", specs[1])
+	}
+}
+
+func TestDiffRegistrySnapshots(t *testing.T) {
+	root := `HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\Run`
+	before := registrySnapshot{
+		"SecurityHealth": {Type: "REG_EXPAND_SZ", Data: `%windir%\system32\SecurityHealthSystray.exe`},
+		"OldAgent":       {Type: "REG_SZ", Data: `C:\old\agent.exe`},
+		"":               {Type: "REG_SZ", Data: ""},
+	}
+	after := registrySnapshot{
+		"SecurityHealth": {Type: "REG_EXPAND_SZ", Data: `C:\Users\Public\evil.exe`},
+		"Updater":        {Type: "REG_SZ", Data: `C:\ProgramData\upd.exe`},
+		"":               {Type: "REG_SZ", Data: ""},
+		`Sub\`:           {Type: "REG_DWORD", Data: "1"},
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	changes := diffRegistrySnapshots(root, before, after, now)
+	if len(changes) != 4 {
+		t.Fatalf("expected 4 changes, got %+v", changes)
+	}
+	expectChange(t, changes, ChangeTypeRegistry, ChangeActionModified, root+`\SecurityHealth`)
+	expectChange(t, changes, ChangeTypeRegistry, ChangeActionAdded, root+`\Updater`)
+	expectChange(t, changes, ChangeTypeRegistry, ChangeActionRemoved, root+`\OldAgent`)
+	expectChange(t, changes, ChangeTypeRegistry, ChangeActionAdded, root+`\Sub\(Default)`)
+	for _, c := range changes {
+		if c.Subject == root+`\SecurityHealth` && (c.BeforeValue["data"] != `%windir%\system32\SecurityHealthSystray.exe` || c.AfterValue["data"] != `C:\Users\Public\evil.exe`) {
+			t.Errorf("modified change should carry before and after data: %+v", c)
+		}
+		if !c.Timestamp.Equal(now) || c.Details["key"] != root {
+			t.Errorf("unexpected metadata %+v", c)
+		}
+	}
+}
+
+func TestRegistryWatcherSetKeys(t *testing.T) {
+	w := NewRegistryWatcher(nil, nil)
+	if len(w.Keys()) != len(DefaultRegistryWatchKeys) {
+		t.Fatalf("empty list should use defaults, got %v", w.Keys())
+	}
+	if !w.SetKeys([]string{`HKLM\SOFTWARE\Contoso`}) || w.SetKeys([]string{`hklm\software\contoso`}) {
+		t.Error("SetKeys should report a change only when the list differs")
+	}
+	if !w.SetKeys(nil) || len(w.Keys()) != len(DefaultRegistryWatchKeys) {
+		t.Error("clearing the list should restore defaults")
+	}
+}
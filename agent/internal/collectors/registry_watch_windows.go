@@ -0,0 +1,169 @@
+//go:build windows
+
+package collectors
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const registryWatchSupported = true
+
+// watchRegistryKey watches one key until stop closes. Each notification is
+// debounced, the key re-read, and the diff against the previous read
+// emitted. A missing key is retried every registryWatchRetry; a key deleted
+// while watched reports its values as removed.
+func watchRegistryKey(spec registryWatchSpec, stop <-chan struct{}, emit func([]ChangeRecord)) {
+	root, subPath, err := resolveRegistryProbePath(spec.path)
+	if err != nil {
+		slog.Warn("registry watch: invalid key", "key", spec.path, "error", err.Error())
+		return
+	}
+
+	stopEvent, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		slog.Warn("registry watch: create event failed", "error", err.Error())
+		return
+	}
+	defer windows.CloseHandle(stopEvent)
+	changeEvent, err := windows.CreateEvent(nil, 0, 0, nil)
+	if err != nil {
+		slog.Warn("registry watch: create event failed", "error", err.Error())
+		return
+	}
+	defer windows.CloseHandle(changeEvent)
+
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-stop:
+			_ = windows.SetEvent(stopEvent)
+		case <-exited:
+		}
+	}()
+
+	// stopped waits up to d for stop; true means shut down.
+	stopped := func(d time.Duration) bool {
+		ev, _ := windows.WaitForSingleObject(stopEvent, uint32(d/time.Millisecond))
+		return ev == windows.WAIT_OBJECT_0
+	}
+
+	var baseline registrySnapshot
+	for {
+		key, err := registry.OpenKey(root, subPath, registry.NOTIFY|registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS|registry.WOW64_64KEY)
+		if err != nil {
+			if baseline != nil {
+				// The key was deleted (or its ACL tightened) under us.
+				emitRegistryChanges(emit, diffRegistrySnapshots(spec.path, baseline, registrySnapshot{}, time.Now().UTC()))
+				baseline = nil
+			}
+			if stopped(registryWatchRetry) {
+				return
+			}
+			continue
+		}
+
+		current := snapshotRegistryKey(key, spec.subtree)
+		if baseline != nil {
+			// Re-opened after a gap: report what changed meanwhile.
+			emitRegistryChanges(emit, diffRegistrySnapshots(spec.path, baseline, current, time.Now().UTC()))
+		}
+		baseline = current
+
+		done := watchOpenRegistryKey(key, spec, changeEvent, stopEvent, stopped, &baseline, emit)
+		key.Close()
+		if done {
+			return
+		}
+		if stopped(registryWatchDebounce) {
+			return
+		}
+	}
+}
+
+// watchOpenRegistryKey runs the notify loop on an open key. It returns true
+// on stop and false when the key must be re-opened.
+func watchOpenRegistryKey(key registry.Key, spec registryWatchSpec, changeEvent, stopEvent windows.Handle,
+	stopped func(time.Duration) bool, baseline *registrySnapshot, emit func([]ChangeRecord)) bool {
+	filter := uint32(windows.REG_NOTIFY_CHANGE_NAME | windows.REG_NOTIFY_CHANGE_LAST_SET | windows.REG_NOTIFY_THREAD_AGNOSTIC)
+	for {
+		if err := windows.RegNotifyChangeKeyValue(windows.Handle(key), spec.subtree, filter, changeEvent, true); err != nil {
+			slog.Debug("registry watch: notify registration failed", "key", spec.path, "error", err.Error())
+			return false
+		}
+		ev, err := windows.WaitForMultipleObjects([]windows.Handle{changeEvent, stopEvent}, false, windows.INFINITE)
+		if err != nil || ev == windows.WAIT_OBJECT_0+1 {
+			return true
+		}
+		if stopped(registryWatchDebounce) {
+			return true
+		}
+
+		// A deleted key still signals; its handle then reads as empty and
+		// fails re-registration, so defer to the re-open path.
+		if _, err := key.Stat(); err != nil {
+			return false
+		}
+		current := snapshotRegistryKey(key, spec.subtree)
+		emitRegistryChanges(emit, diffRegistrySnapshots(spec.path, *baseline, current, time.Now().UTC()))
+		*baseline = current
+	}
+}
+
+func emitRegistryChanges(emit func([]ChangeRecord), changes []ChangeRecord) {
+	if len(changes) > 0 && emit != nil {
+		emit(changes)
+	}
+}
+
+// snapshotRegistryKey reads every value of key (and, for subtree watches, of
+// its descendants) up to registryWatchMaxValues / registryWatchMaxDepth.
+func snapshotRegistryKey(key registry.Key, subtree bool) registrySnapshot {
+	snap := make(registrySnapshot)
+	var walk func(k registry.Key, prefix string, depth int)
+	walk = func(k registry.Key, prefix string, depth int) {
+		names, _ := k.ReadValueNames(0)
+		for _, name := range names {
+			if len(snap) >= registryWatchMaxValues {
+				return
+			}
+			snap[prefix+name] = readRegistryWatchValue(k, name)
+		}
+		if !subtree || depth >= registryWatchMaxDepth {
+			return
+		}
+		subkeys, _ := k.ReadSubKeyNames(0)
+		for _, name := range subkeys {
+			if len(snap) >= registryWatchMaxValues {
+				return
+			}
+			child, err := registry.OpenKey(k, name, registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS|registry.WOW64_64KEY)
+			if err != nil {
+				continue
+			}
+			walk(child, prefix+name+`\`, depth+1)
+			child.Close()
+		}
+	}
+	walk(key, "", 0)
+	return snap
+}
+
+func readRegistryWatchValue(key registry.Key, name string) registryValue {
+	entry, ok := readRegistryProbeValue(key, "", name)
+	if !ok {
+		// Types readRegistryProbeValue does not decode (REG_NONE, links):
+		// report the type so a change is still visible.
+		_, valueType, err := key.GetValue(name, nil)
+		if err != nil {
+			return registryValue{Type: "unknown"}
+		}
+		return registryValue{Type: registryTypeToString(valueType)}
+	}
+	return registryValue{Type: entry.ValueType, Data: fmt.Sprint(entry.ValueData)}
+}
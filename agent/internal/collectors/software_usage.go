@@ -0,0 +1,233 @@
+package collectors
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Software usage metering. User helpers sample the foreground application
+// and report the time they accumulated; this collector keeps per-day,
+// per-user, per-application totals (persisted across agent restarts) until
+// the server has them, so unused licensed software can be reclaimed.
+// Metering is opt-in: while disabled, reports are dropped and nothing is
+// stored.
+
+// softwareUsageRetention bounds how long an undelivered day is kept.
+const softwareUsageRetention = 7 * 24 * time.Hour
+
+// maxSoftwareUsageEntries caps the stored (day, user, app) rows.
+const maxSoftwareUsageEntries = 5000
+
+const softwareUsageDateLayout = "2006-01-02"
+
+// SoftwareUsage is the foreground time of one application for one user on
+// one local day (YYYY-MM-DD).
+type SoftwareUsage struct {
+	Date    string `json:"date"`
+	User    string `json:"user"`
+	Name    string `json:"name"`
+	Path    string `json:"path,omitempty"`
+	Seconds int64  `json:"seconds"`
+}
+
+type SoftwareUsageCollector struct {
+	mu        sync.Mutex
+	enabled   bool
+	statePath string
+	loaded    bool
+	usage     map[string]SoftwareUsage
+	now       func() time.Time
+}
+
+// NewSoftwareUsageCollector keeps its pending totals in statePath; an empty
+// path keeps them in memory only.
+func NewSoftwareUsageCollector(statePath string, enabled bool) *SoftwareUsageCollector {
+	return &SoftwareUsageCollector{statePath: statePath, enabled: enabled, now: time.Now}
+}
+
+func (c *SoftwareUsageCollector) Enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enabled
+}
+
+// SetEnabled turns metering on or off. Turning it off discards anything not
+// yet delivered.
+func (c *SoftwareUsageCollector) SetEnabled(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.enabled = enabled
+	if !enabled {
+		c.loaded = true
+		c.usage = make(map[string]SoftwareUsage)
+		if c.statePath != "" {
+			if err := os.Remove(c.statePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				slog.Warn("failed to remove software usage state", "path", c.statePath, "error", err.Error())
+			}
+		}
+	}
+}
+
+// Record adds reported foreground time for user. Rows with a malformed or
+// out-of-retention date are dropped, and a day's total per application is
+// capped at 24 hours.
+func (c *SoftwareUsageCollector) Record(user string, usage []SoftwareUsage) {
+	user = truncateCollectorString(strings.TrimSpace(user))
+	if user == "" || len(usage) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled {
+		return
+	}
+	c.loadLocked()
+
+	oldest := c.now().Add(-softwareUsageRetention).Format(softwareUsageDateLayout)
+	tomorrow := c.now().Add(24 * time.Hour).Format(softwareUsageDateLayout)
+	changed := false
+	for _, u := range usage {
+		u.User = user
+		u.Name = truncateCollectorString(strings.TrimSpace(u.Name))
+		u.Path = truncateCollectorString(strings.TrimSpace(u.Path))
+		if u.Name == "" || u.Seconds <= 0 {
+			continue
+		}
+		if _, err := time.Parse(softwareUsageDateLayout, u.Date); err != nil || u.Date < oldest || u.Date > tomorrow {
+			continue
+		}
+		key := softwareUsageKey(u)
+		existing, ok := c.usage[key]
+		if !ok && len(c.usage) >= maxSoftwareUsageEntries {
+			continue
+		}
+		if ok {
+			u.Seconds += existing.Seconds
+		}
+		if u.Seconds > 24*60*60 {
+			u.Seconds = 24 * 60 * 60
+		}
+		c.usage[key] = u
+		changed = true
+	}
+	if changed {
+		c.saveLocked()
+	}
+}
+
+// Collect returns the pending daily totals, oldest day first. Today's row
+// is a running total; the server replaces rather than adds.
+func (c *SoftwareUsageCollector) Collect() []SoftwareUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadLocked()
+	c.pruneLocked(c.now().Add(-softwareUsageRetention).Format(softwareUsageDateLayout))
+
+	out := make([]SoftwareUsage, 0, len(c.usage))
+	for _, u := range c.usage {
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Date != out[j].Date {
+			return out[i].Date < out[j].Date
+		}
+		if out[i].User != out[j].User {
+			return out[i].User < out[j].User
+		}
+		return out[i].Seconds > out[j].Seconds
+	})
+	return out
+}
+
+// Acknowledge drops the completed days after a successful upload. Today
+// is kept so later reports keep adding to its running total.
+func (c *SoftwareUsageCollector) Acknowledge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadLocked()
+	if c.pruneLocked(c.now().Format(softwareUsageDateLayout)) {
+		c.saveLocked()
+	}
+}
+
+// pruneLocked removes rows dated before the given day.
+func (c *SoftwareUsageCollector) pruneLocked(before string) bool {
+	pruned := false
+	for key, u := range c.usage {
+		if u.Date < before {
+			delete(c.usage, key)
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+func (c *SoftwareUsageCollector) loadLocked() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	c.usage = make(map[string]SoftwareUsage)
+	if c.statePath == "" {
+		return
+	}
+	raw, err := os.ReadFile(c.statePath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to read software usage state", "path", c.statePath, "error", err.Error())
+		}
+		return
+	}
+	var rows []SoftwareUsage
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		slog.Warn("software usage state corrupt, resetting", "path", c.statePath, "error", err.Error())
+		return
+	}
+	for _, u := range rows {
+		c.usage[softwareUsageKey(u)] = u
+	}
+}
+
+func (c *SoftwareUsageCollector) saveLocked() {
+	if c.statePath == "" {
+		return
+	}
+	rows := make([]SoftwareUsage, 0, len(c.usage))
+	for _, u := range c.usage {
+		rows = append(rows, u)
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.statePath), 0o700); err != nil {
+		slog.Warn("failed to create software usage state dir", "error", err.Error())
+		return
+	}
+	tmp := c.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		slog.Warn("failed to write software usage state", "error", err.Error())
+		return
+	}
+	if err := os.Rename(tmp, c.statePath); err != nil {
+		_ = os.Remove(tmp)
+		slog.Warn("failed to persist software usage state", "error", err.Error())
+	}
+}
+
+// softwareUsageKey identifies an application by path when known, since two
+// different executables can share a display name.
+func softwareUsageKey(u SoftwareUsage) string {
+	app := u.Path
+	if app == "" {
+		app = u.Name
+	}
+	return u.Date + "\x00" + u.User + "\x00" + strings.ToLower(app)
+}
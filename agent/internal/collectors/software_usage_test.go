@@ -0,0 +1,62 @@
+package collectors
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSoftwareUsageCollector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "software_usage.json")
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.Local)
+	c := NewSoftwareUsageCollector(path, false)
+	c.now = func() time.Time { return now }
+
+	c.Record("alice", []SoftwareUsage{{Date: "2026-03-10", Name: "WINWORD.EXE", Seconds: 60}})
+	if got := c.Collect(); len(got) != 0 {
+		t.Fatalf("disabled collector stored usage: %+v", got)
+	}
+
+	c.SetEnabled(true)
+	c.Record("alice", []SoftwareUsage{
+		{Date: "2026-03-09", Name: "WINWORD.EXE", Path: `C:\Office\WINWORD.EXE`, Seconds: 600},
+		{Date: "2026-03-10", Name: "WINWORD.EXE", Path: `C:\Office\WINWORD.EXE`, Seconds: 60},
+		{Date: "2026-03-10", Name: "EXCEL.EXE", Seconds: 30},
+		{Date: "2026-02-01", Name: "old.exe", Seconds: 30},
+		{Date: "yesterday", Name: "bad.exe", Seconds: 30},
+		{Date: "2026-03-10", Name: "", Seconds: 30},
+	})
+	c.Record("alice", []SoftwareUsage{{Date: "2026-03-10", Name: "WINWORD.EXE", Path: `c:\office\winword.exe`, Seconds: 90000}})
+	c.Record("bob", []SoftwareUsage{{Date: "2026-03-10", Name: "EXCEL.EXE", Seconds: 45}})
+
+	got := c.Collect()
+	if len(got) != 4 {
+		t.Fatalf("unexpected rows %+v", got)
+	}
+	if got[0].Date != "2026-03-09" || got[0].Seconds != 600 {
+		t.Fatalf("unexpected first row %+v", got[0])
+	}
+	if got[1].User != "alice" || got[1].Name != "WINWORD.EXE" || got[1].Seconds != 24*60*60 {
+		t.Fatalf("expected capped running total, got %+v", got[1])
+	}
+
+	// A fresh collector on the same state file picks up the pending rows.
+	reloaded := NewSoftwareUsageCollector(path, true)
+	reloaded.now = c.now
+	if rows := reloaded.Collect(); len(rows) != 4 {
+		t.Fatalf("state not persisted: %+v", rows)
+	}
+
+	// Acknowledging drops completed days but keeps today's running totals.
+	c.Acknowledge()
+	got = c.Collect()
+	if len(got) != 3 || got[0].Date != "2026-03-10" {
+		t.Fatalf("unexpected rows after acknowledge %+v", got)
+	}
+
+	c.SetEnabled(false)
+	reloaded = NewSoftwareUsageCollector(path, true)
+	if rows := reloaded.Collect(); len(rows) != 0 {
+		t.Fatalf("disabling should discard pending usage, got %+v", rows)
+	}
+}
@@ -0,0 +1,329 @@
+package collectors
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Thermal telemetry rides the heartbeat next to CPU/GPU load so overheating
+// mini-PCs and clogged laptop fans show up without third-party tools.
+//
+// Sources:
+//   - Linux: hwmon sysfs (coretemp/k10temp/zenpower/SoC sensors, fan*_input)
+//     plus the per-package thermal_throttle counters.
+//   - macOS: powermetrics (root) for SMC die temperature, fans and thermal
+//     pressure, and pmset's CPU speed limit on Intel Macs.
+//   - Windows: the ACPI thermal zone (MSAcpi_ThermalZoneTemperature) and the
+//     "% Performance Limit" processor counter. Windows has no standard fan
+//     RPM interface, so fans are not reported there.
+//
+// As with GPUs, every reading is a pointer so "not reported" is distinct from
+// a real zero, and a device with no sensors stops being probed for a while.
+
+// ThermalMetrics is the device-level thermal snapshot.
+type ThermalMetrics struct {
+	// CPUTempC is the CPU package (or die/Tctl) temperature, falling back to
+	// the hottest ACPI thermal zone where no CPU sensor is exposed.
+	CPUTempC *float64            `json:"cpuTempC,omitempty"`
+	Sensors  []TemperatureSensor `json:"sensors,omitempty"`
+	Fans     []FanReading        `json:"fans,omitempty"`
+	// Throttled is set when the platform exposes a throttling indicator;
+	// ThrottleReasons says which one(s) tripped.
+	Throttled       *bool    `json:"throttled,omitempty"`
+	ThrottleReasons []string `json:"throttleReasons,omitempty"`
+	// ThrottleEvents is the number of new package thermal-throttle events
+	// since the previous sample (Linux).
+	ThrottleEvents *uint64 `json:"throttleEvents,omitempty"`
+	// CPUSpeedLimitPercent is the OS-imposed cap on CPU performance: 100 means
+	// unthrottled.
+	CPUSpeedLimitPercent *float64 `json:"cpuSpeedLimitPercent,omitempty"`
+	Source               string   `json:"source"`
+
+	// throttleCount is the platform's cumulative throttle counter, turned
+	// into ThrottleEvents by the collector.
+	throttleCount *uint64
+}
+
+// TemperatureSensor is one named temperature reading.
+type TemperatureSensor struct {
+	Name         string  `json:"name"`
+	TemperatureC float64 `json:"temperatureC"`
+}
+
+// FanReading is one fan's speed. A stopped fan reports 0 RPM.
+type FanReading struct {
+	Name string `json:"name"`
+	RPM  int    `json:"rpm"`
+}
+
+const (
+	thermalSourceHwmon        = "hwmon"
+	thermalSourcePowermetrics = "powermetrics"
+	thermalSourceACPI         = "acpi"
+
+	thermalReasonThrottleEvents = "thermal_throttle_events"
+	thermalReasonSpeedLimit     = "cpu_speed_limit"
+	thermalReasonPressure       = "thermal_pressure"
+
+	// thermalAbsentRecheck mirrors gpuAbsentRecheck for devices (VMs, most
+	// servers' BMC-only sensors) with no OS-visible thermal source.
+	thermalAbsentRecheck = 30 * time.Minute
+
+	maxThermalSensors = 32
+	maxThermalFans    = 16
+)
+
+// hwmonRoot and cpuSysRoot are the Linux sysfs directories read for thermal
+// data. Vars so tests can point them at fixture trees.
+var (
+	hwmonRoot  = "/sys/class/hwmon"
+	cpuSysRoot = "/sys/devices/system/cpu"
+)
+
+// collectThermal returns the thermal snapshot for this heartbeat, turning
+// the cumulative throttle counter into a per-interval event count. Where the
+// platform source spawns processes (thermalSampleInterval > 0) the last
+// snapshot is reused until the interval has passed.
+func (c *MetricsCollector) collectThermal(now time.Time) *ThermalMetrics {
+	if now.Before(c.thermalAbsentUntil) {
+		return nil
+	}
+	if thermalSampleInterval > 0 && c.thermalLast != nil && now.Sub(c.thermalSampledAt) < thermalSampleInterval {
+		return c.thermalLast
+	}
+	t := collectPlatformThermal()
+	if t == nil || t.CPUTempC == nil && len(t.Sensors) == 0 && len(t.Fans) == 0 && t.Throttled == nil && t.throttleCount == nil {
+		c.thermalAbsentUntil = now.Add(thermalAbsentRecheck)
+		c.thermalLast = nil
+		return nil
+	}
+
+	if t.throttleCount != nil {
+		count := *t.throttleCount
+		if c.haveThrottleCount && count >= c.lastThrottleCount {
+			events := count - c.lastThrottleCount
+			t.ThrottleEvents = &events
+			throttled := events > 0
+			if throttled {
+				t.ThrottleReasons = append(t.ThrottleReasons, thermalReasonThrottleEvents)
+			}
+			if t.Throttled == nil || !*t.Throttled {
+				t.Throttled = &throttled
+			}
+		}
+		c.lastThrottleCount, c.haveThrottleCount = count, true
+	}
+
+	for i := range t.Sensors {
+		t.Sensors[i].Name = truncateCollectorString(t.Sensors[i].Name)
+	}
+	for i := range t.Fans {
+		t.Fans[i].Name = truncateCollectorString(t.Fans[i].Name)
+	}
+	if len(t.Sensors) > maxThermalSensors {
+		t.Sensors = t.Sensors[:maxThermalSensors]
+	}
+	if len(t.Fans) > maxThermalFans {
+		t.Fans = t.Fans[:maxThermalFans]
+	}
+	c.thermalLast, c.thermalSampledAt = t, now
+	return t
+}
+
+// hwmonCPUSensorRank orders hwmon drivers/labels by how well they represent
+// the CPU package temperature (lower is better); -1 means "not a CPU sensor".
+func hwmonCPUSensorRank(driver, label string) int {
+	switch driver {
+	case "coretemp":
+		if strings.HasPrefix(label, "Package id") {
+			return 0
+		}
+		return 3
+	case "k10temp", "zenpower":
+		switch label {
+		case "Tdie":
+			return 0
+		case "Tctl":
+			return 1
+		}
+		return 3
+	case "cpu_thermal", "soc_thermal", "cpu-thermal", "scpi_sensors":
+		return 2
+	case "acpitz":
+		return 4
+	}
+	return -1
+}
+
+// collectThermalFromSysfs reads every hwmon device under hwmon and the
+// per-CPU throttle counters under cpus. Build-tag-free so it unit-tests
+// against a fixture tree on any platform.
+func collectThermalFromSysfs(hwmon, cpus string) *ThermalMetrics {
+	t := &ThermalMetrics{Source: thermalSourceHwmon}
+
+	devices, _ := filepath.Glob(filepath.Join(hwmon, "hwmon*"))
+	sort.Strings(devices)
+	bestRank := -1
+	for _, dev := range devices {
+		driver, _ := readSysTrim(filepath.Join(dev, "name"))
+
+		temps, _ := filepath.Glob(filepath.Join(dev, "temp*_input"))
+		sort.Strings(temps)
+		for _, input := range temps {
+			milli, ok := readSysFloat(input)
+			if !ok {
+				continue
+			}
+			celsius := milli / 1000
+			// Disconnected inputs read as 0 or absurd values.
+			if celsius <= 0 || celsius > 150 {
+				continue
+			}
+			label, _ := readSysTrim(strings.TrimSuffix(input, "_input") + "_label")
+			name := driver
+			if label != "" {
+				name = driver + " " + label
+			}
+			t.Sensors = append(t.Sensors, TemperatureSensor{Name: name, TemperatureC: celsius})
+
+			rank := hwmonCPUSensorRank(driver, label)
+			if rank < 0 {
+				continue
+			}
+			// Among equally-ranked sensors (per-core readings, multiple
+			// packages) report the hottest.
+			if bestRank < 0 || rank < bestRank || rank == bestRank && celsius > *t.CPUTempC {
+				bestRank = rank
+				t.CPUTempC = floatPtr(celsius)
+			}
+		}
+
+		fans, _ := filepath.Glob(filepath.Join(dev, "fan*_input"))
+		sort.Strings(fans)
+		for _, input := range fans {
+			rpm, ok := readSysFloat(input)
+			if !ok {
+				continue
+			}
+			label, _ := readSysTrim(strings.TrimSuffix(input, "_input") + "_label")
+			if label == "" {
+				label = strings.TrimSuffix(filepath.Base(input), "_input")
+			}
+			t.Fans = append(t.Fans, FanReading{Name: strings.TrimSpace(driver + " " + label), RPM: int(rpm)})
+		}
+	}
+
+	// Every logical CPU exposes its package's counter; sum one per package.
+	counters, _ := filepath.Glob(filepath.Join(cpus, "cpu[0-9]*", "thermal_throttle", "package_throttle_count"))
+	perPackage := make(map[string]uint64)
+	for _, path := range counters {
+		v, ok := readSysTrim(path)
+		if !ok {
+			continue
+		}
+		count, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		cpuDir := filepath.Dir(filepath.Dir(path))
+		pkg, _ := readSysTrim(filepath.Join(cpuDir, "topology", "physical_package_id"))
+		if count > perPackage[pkg] {
+			perPackage[pkg] = count
+		}
+	}
+	if len(perPackage) > 0 {
+		var total uint64
+		for _, v := range perPackage {
+			total += v
+		}
+		t.throttleCount = &total
+	}
+	return t
+}
+
+var (
+	powermetricsDieTempRe  = regexp.MustCompile(`(?m)^CPU die temperature:\s*([0-9.]+)\s*C`)
+	powermetricsFanRe      = regexp.MustCompile(`(?m)^Fan:\s*([0-9.]+)\s*rpm`)
+	powermetricsPressureRe = regexp.MustCompile(`(?m)^Current pressure level:\s*(\w+)`)
+	pmsetSpeedLimitRe      = regexp.MustCompile(`CPU_Speed_Limit\s*=\s*(\d+)`)
+)
+
+// parseMacThermal parses `powermetrics --samplers smc,thermal` and
+// `pmset -g therm` output. Either may be empty. Apple Silicon has no SMC
+// sampler, so only the thermal pressure level is available there.
+func parseMacThermal(powermetrics, pmset string) *ThermalMetrics {
+	t := &ThermalMetrics{Source: thermalSourcePowermetrics}
+	if m := powermetricsDieTempRe.FindStringSubmatch(powermetrics); m != nil {
+		if v, err := strconv.ParseFloat(m[1], 64); err == nil && v > 0 {
+			t.CPUTempC = floatPtr(v)
+			t.Sensors = append(t.Sensors, TemperatureSensor{Name: "CPU die", TemperatureC: v})
+		}
+	}
+	for i, m := range powermetricsFanRe.FindAllStringSubmatch(powermetrics, -1) {
+		if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+			t.Fans = append(t.Fans, FanReading{Name: "Fan " + strconv.Itoa(i+1), RPM: int(v)})
+		}
+	}
+
+	throttled := false
+	known := false
+	if m := powermetricsPressureRe.FindStringSubmatch(powermetrics); m != nil {
+		known = true
+		if !strings.EqualFold(m[1], "Nominal") {
+			throttled = true
+			t.ThrottleReasons = append(t.ThrottleReasons, thermalReasonPressure)
+		}
+	}
+	if m := pmsetSpeedLimitRe.FindStringSubmatch(pmset); m != nil {
+		if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+			known = true
+			t.CPUSpeedLimitPercent = floatPtr(v)
+			if v < 100 {
+				throttled = true
+				t.ThrottleReasons = append(t.ThrottleReasons, thermalReasonSpeedLimit)
+			}
+		}
+	}
+	if known {
+		t.Throttled = &throttled
+	}
+	return t
+}
+
+// windowsThermalRow is the output of windowsThermalScript. ThermalZonesC are
+// already converted from tenths of a Kelvin.
+type windowsThermalRow struct {
+	ThermalZonesC    []float64 `json:"ThermalZonesC"`
+	PerformanceLimit *float64  `json:"PerformanceLimit"`
+}
+
+// thermalFromWindowsRow converts the Windows probe. A "% Performance Limit"
+// below 100 means firmware or the OS is capping CPU frequency (thermal or
+// power); it is reported as a speed limit rather than asserted as thermal.
+func thermalFromWindowsRow(row windowsThermalRow) *ThermalMetrics {
+	t := &ThermalMetrics{Source: thermalSourceACPI}
+	for i, c := range row.ThermalZonesC {
+		// Unpopulated zones read 0K (-273.2C).
+		if c <= 0 || c > 150 {
+			continue
+		}
+		t.Sensors = append(t.Sensors, TemperatureSensor{Name: "ACPI thermal zone " + strconv.Itoa(i), TemperatureC: c})
+		if t.CPUTempC == nil || c > *t.CPUTempC {
+			t.CPUTempC = floatPtr(c)
+		}
+	}
+	if row.PerformanceLimit != nil {
+		limit := clampPercent(*row.PerformanceLimit)
+		throttled := limit < 100
+		t.CPUSpeedLimitPercent = floatPtr(limit)
+		t.Throttled = &throttled
+		if throttled {
+			t.ThrottleReasons = append(t.ThrottleReasons, thermalReasonSpeedLimit)
+		}
+	}
+	return t
+}
@@ -0,0 +1,22 @@
+//go:build darwin
+
+package collectors
+
+import "time"
+
+// thermalSampleInterval limits powermetrics and pmset to about once a minute;
+// powermetrics takes a 200 ms sample each run. Between runs the previous
+// snapshot is reported.
+var thermalSampleInterval = time.Minute
+
+// collectPlatformThermal samples powermetrics once (it needs root, which the
+// agent service has). Apple Silicon rejects the smc sampler, so fall back to
+// the thermal sampler alone.
+func collectPlatformThermal() *ThermalMetrics {
+	pm, err := runCollectorOutput(collectorShortCommandTimeout, "powermetrics", "-n", "1", "-i", "200", "--samplers", "smc,thermal")
+	if err != nil {
+		pm, _ = runCollectorOutput(collectorShortCommandTimeout, "powermetrics", "-n", "1", "-i", "200", "--samplers", "thermal")
+	}
+	pmset, _ := runCollectorOutput(collectorShortCommandTimeout, "pmset", "-g", "therm")
+	return parseMacThermal(string(pm), string(pmset))
+}
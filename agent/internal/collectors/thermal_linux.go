@@ -0,0 +1,13 @@
+//go:build linux
+
+package collectors
+
+import "time"
+
+// thermalSampleInterval is zero: hwmon sysfs reads are cheap enough for every
+// heartbeat, and the throttle event count is per heartbeat.
+var thermalSampleInterval time.Duration
+
+func collectPlatformThermal() *ThermalMetrics {
+	return collectThermalFromSysfs(hwmonRoot, cpuSysRoot)
+}
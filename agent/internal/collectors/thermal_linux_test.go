@@ -0,0 +1,30 @@
+//go:build linux
+
+package collectors
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCollectThermalThrottleEvents(t *testing.T) {
+	root := t.TempDir()
+	origHwmon, origCPU := hwmonRoot, cpuSysRoot
+	hwmonRoot, cpuSysRoot = filepath.Join(root, "hwmon"), filepath.Join(root, "cpu")
+	t.Cleanup(func() { hwmonRoot, cpuSysRoot = origHwmon, origCPU })
+	counter := filepath.Join(cpuSysRoot, "cpu0", "thermal_throttle", "package_throttle_count")
+	writeGPUFixture(t, counter, "10\n")
+	c := NewMetricsCollector()
+	now := time.Now()
+
+	first := c.collectThermal(now)
+	if first == nil || first.ThrottleEvents != nil || first.Throttled != nil {
+		t.Fatalf("first sample only sets the baseline: %+v", first)
+	}
+	writeGPUFixture(t, counter, "14\n")
+	second := c.collectThermal(now)
+	if second == nil || second.ThrottleEvents == nil || *second.ThrottleEvents != 4 || second.Throttled == nil || !*second.Throttled {
+		t.Fatalf("expected 4 throttle events: %+v", second)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !windows && !linux && !darwin
+
+package collectors
+
+import "time"
+
+// thermalSampleInterval is zero: there is no platform probe to space out.
+var thermalSampleInterval time.Duration
+
+func collectPlatformThermal() *ThermalMetrics {
+	return nil
+}
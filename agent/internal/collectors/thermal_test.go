@@ -0,0 +1,92 @@
+package collectors
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCollectThermalFromSysfs(t *testing.T) {
+	root := t.TempDir()
+	hwmon := filepath.Join(root, "hwmon")
+	cpus := filepath.Join(root, "cpu")
+
+	writeGPUFixture(t, filepath.Join(hwmon, "hwmon0", "name"), "acpitz\n")
+	writeGPUFixture(t, filepath.Join(hwmon, "hwmon0", "temp1_input"), "48000\n")
+	writeGPUFixture(t, filepath.Join(hwmon, "hwmon1", "name"), "coretemp\n")
+	writeGPUFixture(t, filepath.Join(hwmon, "hwmon1", "temp1_input"), "71000\n")
+	writeGPUFixture(t, filepath.Join(hwmon, "hwmon1", "temp1_label"), "Package id 0\n")
+	writeGPUFixture(t, filepath.Join(hwmon, "hwmon1", "temp2_input"), "83000\n")
+	writeGPUFixture(t, filepath.Join(hwmon, "hwmon1", "temp2_label"), "Core 0\n")
+	writeGPUFixture(t, filepath.Join(hwmon, "hwmon1", "temp3_input"), "0\n")
+	writeGPUFixture(t, filepath.Join(hwmon, "hwmon2", "name"), "thinkpad\n")
+	writeGPUFixture(t, filepath.Join(hwmon, "hwmon2", "fan1_input"), "3120\n")
+	writeGPUFixture(t, filepath.Join(hwmon, "hwmon2", "fan2_input"), "0\n")
+	writeGPUFixture(t, filepath.Join(hwmon, "hwmon2", "fan2_label"), "GPU fan\n")
+
+	// Two packages; every logical CPU repeats its package's counter.
+	for cpu, v := range map[string][2]string{"cpu0": {"0", "5"}, "cpu1": {"0", "5"}, "cpu2": {"1", "2"}} {
+		writeGPUFixture(t, filepath.Join(cpus, cpu, "topology", "physical_package_id"), v[0]+"\n")
+		writeGPUFixture(t, filepath.Join(cpus, cpu, "thermal_throttle", "package_throttle_count"), v[1]+"\n")
+	}
+
+	th := collectThermalFromSysfs(hwmon, cpus)
+	if th.CPUTempC == nil || *th.CPUTempC != 71 {
+		t.Fatalf("package temperature should win over per-core and ACPI readings, got %v", th.CPUTempC)
+	}
+	if len(th.Sensors) != 3 {
+		t.Errorf("disconnected input should be skipped: %+v", th.Sensors)
+	}
+	if len(th.Fans) != 2 || th.Fans[0] != (FanReading{Name: "thinkpad fan1", RPM: 3120}) || th.Fans[1] != (FanReading{Name: "thinkpad GPU fan", RPM: 0}) {
+		t.Errorf("fans = %+v", th.Fans)
+	}
+	if th.throttleCount == nil || *th.throttleCount != 7 {
+		t.Errorf("throttle count = %v, want 7", th.throttleCount)
+	}
+}
+
+func TestParseMacThermal(t *testing.T) {
+	pm := "**** SMC sensors ****\n\nCPU Thermal level: 0\nFan: 1796.61 rpm\nCPU die temperature: 62.44 C\n\n**** Thermal pressure ****\n\nCurrent pressure level: Moderate\n"
+	pmset := "Note: No thermal warning level has been recorded\n2026-01-01 CPU Power notify\n\tCPU_Scheduler_Limit \t= 100\n\tCPU_Available_CPUs \t= 8\n\tCPU_Speed_Limit \t= 80\n"
+	th := parseMacThermal(pm, pmset)
+	if th.CPUTempC == nil || *th.CPUTempC != 62.44 || len(th.Fans) != 1 || th.Fans[0].RPM != 1796 {
+		t.Fatalf("parsed %+v", th)
+	}
+	if th.Throttled == nil || !*th.Throttled || len(th.ThrottleReasons) != 2 || *th.CPUSpeedLimitPercent != 80 {
+		t.Errorf("throttle = %v %v %v", th.Throttled, th.ThrottleReasons, th.CPUSpeedLimitPercent)
+	}
+
+	// Apple Silicon: thermal sampler only, nominal pressure.
+	th = parseMacThermal("**** Thermal pressure ****\n\nCurrent pressure level: Nominal\n", "")
+	if th.CPUTempC != nil || th.Throttled == nil || *th.Throttled {
+		t.Errorf("apple silicon = %+v", th)
+	}
+}
+
+func TestThermalFromWindowsRow(t *testing.T) {
+	limit := 64.0
+	th := thermalFromWindowsRow(windowsThermalRow{ThermalZonesC: []float64{-273.2, 41.9, 58.1}, PerformanceLimit: &limit})
+	if th.CPUTempC == nil || *th.CPUTempC != 58.1 || len(th.Sensors) != 2 {
+		t.Fatalf("zones = %+v", th)
+	}
+	if th.Throttled == nil || !*th.Throttled || *th.CPUSpeedLimitPercent != 64 {
+		t.Errorf("throttle = %+v", th)
+	}
+}
+
+func TestCollectThermalReusesSnapshotWithinInterval(t *testing.T) {
+	prev := thermalSampleInterval
+	thermalSampleInterval = time.Minute
+	t.Cleanup(func() { thermalSampleInterval = prev })
+
+	now := time.Now()
+	cached := &ThermalMetrics{CPUTempC: floatPtr(61), Source: thermalSourcePowermetrics}
+	c := &MetricsCollector{thermalLast: cached, thermalSampledAt: now.Add(-30 * time.Second)}
+
+	if got := c.collectThermal(now); got != cached {
+		t.Fatalf("collectThermal within the interval = %+v, want the cached snapshot", got)
+	}
+	if !c.thermalSampledAt.Equal(now.Add(-30 * time.Second)) {
+		t.Fatal("a cached read must not restart the interval")
+	}
+}
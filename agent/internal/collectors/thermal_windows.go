@@ -0,0 +1,29 @@
+//go:build windows
+
+package collectors
+
+import (
+	"context"
+	"time"
+)
+
+// thermalSampleInterval limits the PowerShell WMI/perf-counter probe to about
+// once a minute. Between runs the previous snapshot is reported.
+var thermalSampleInterval = time.Minute
+
+// windowsThermalScript reads every ACPI thermal zone (tenths of a Kelvin) and
+// the processor "% Performance Limit" counter. Either may be unavailable.
+const windowsThermalScript = `$zones = @(); try { $zones = @(Get-CimInstance -Namespace root/wmi -ClassName MSAcpi_ThermalZoneTemperature -ErrorAction Stop | ForEach-Object { [math]::Round($_.CurrentTemperature / 10 - 273.15, 1) }) } catch {};` +
+	`$limit = $null; try { $limit = [math]::Round((Get-Counter '\Processor Information(_Total)\% Performance Limit' -ErrorAction Stop).CounterSamples[0].CookedValue, 1) } catch {};` +
+	`[pscustomobject]@{ ThermalZonesC = $zones; PerformanceLimit = $limit } | ConvertTo-Json -Compress`
+
+func collectPlatformThermal() *ThermalMetrics {
+	ctx, cancel := context.WithTimeout(context.Background(), collectorShortCommandTimeout)
+	defer cancel()
+
+	rows, err := runWindowsJSON[windowsThermalRow](ctx, utf8PowerShellCommand(windowsThermalScript))
+	if err != nil || len(rows) == 0 {
+		return nil
+	}
+	return thermalFromWindowsRow(rows[0])
+}
@@ -0,0 +1,529 @@
+package collectors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// USB device classes reported in inventory, derived from the USB base class
+// code (or the OS's equivalent device class when no code is exposed).
+const (
+	USBClassMassStorage    = "mass_storage"
+	USBClassHID            = "hid"
+	USBClassAudio          = "audio"
+	USBClassVideo          = "video"
+	USBClassImage          = "image"
+	USBClassPrinter        = "printer"
+	USBClassCommunications = "communications"
+	USBClassSmartCard      = "smart_card"
+	USBClassWireless       = "wireless"
+	USBClassHub            = "hub"
+	USBClassVendorSpecific = "vendor_specific"
+	USBClassOther          = "other"
+)
+
+// usbHistoryRetention bounds how long a disconnected device stays in the
+// inventory (and in the first-seen/last-seen state file).
+const usbHistoryRetention = 90 * 24 * time.Hour
+
+// USBDevice is one USB device seen on the machine. VendorID and ProductID are
+// lower-case 4-digit hex.
+type USBDevice struct {
+	VendorID     string    `json:"vendorId"`
+	ProductID    string    `json:"productId"`
+	Vendor       string    `json:"vendor,omitempty"`
+	Product      string    `json:"product,omitempty"`
+	SerialNumber string    `json:"serialNumber,omitempty"`
+	Class        string    `json:"class"`
+	MassStorage  bool      `json:"massStorage"`
+	Location     string    `json:"location,omitempty"`
+	Connected    bool      `json:"connected"`
+	FirstSeen    time.Time `json:"firstSeen"`
+	LastSeen     time.Time `json:"lastSeen"`
+}
+
+// TrackedUSBDevice is the subset of a connected USB device the change tracker
+// diffs.
+type TrackedUSBDevice struct {
+	VendorID     string `json:"vendorId"`
+	ProductID    string `json:"productId"`
+	Vendor       string `json:"vendor,omitempty"`
+	Product      string `json:"product,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty"`
+	Class        string `json:"class"`
+	MassStorage  bool   `json:"massStorage"`
+}
+
+// USBDeviceCollector enumerates connected USB devices and keeps a persisted
+// first-seen/last-seen history so devices that have since been unplugged
+// remain visible in inventory.
+type USBDeviceCollector struct {
+	statePath string
+	enumerate func() ([]USBDevice, error)
+	now       func() time.Time
+
+	mu      sync.Mutex
+	history map[string]USBDevice
+	loaded  bool
+}
+
+// NewUSBDeviceCollector creates a collector persisting its history at
+// statePath. An empty path keeps history in memory only.
+func NewUSBDeviceCollector(statePath string) *USBDeviceCollector {
+	return &USBDeviceCollector{
+		statePath: statePath,
+		enumerate: collectUSBDevices,
+		now:       func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Collect returns every connected device plus disconnected devices seen
+// within the retention window, connected devices first.
+func (c *USBDeviceCollector) Collect() ([]USBDevice, error) {
+	current, err := c.enumerate()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadLocked()
+
+	now := c.now()
+	connected := make(map[string]bool, len(current))
+	for _, dev := range current {
+		key := usbDeviceKey(dev)
+		if connected[key] {
+			continue
+		}
+		connected[key] = true
+		dev.Connected = true
+		dev.LastSeen = now
+		dev.FirstSeen = now
+		if prev, ok := c.history[key]; ok && !prev.FirstSeen.IsZero() {
+			dev.FirstSeen = prev.FirstSeen
+		}
+		c.history[key] = dev
+	}
+	for key, dev := range c.history {
+		if connected[key] {
+			continue
+		}
+		if now.Sub(dev.LastSeen) > usbHistoryRetention {
+			delete(c.history, key)
+			continue
+		}
+		dev.Connected = false
+		c.history[key] = dev
+	}
+	c.saveLocked()
+
+	out := make([]USBDevice, 0, len(c.history))
+	for _, dev := range c.history {
+		out = append(out, dev)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Connected != out[j].Connected {
+			return out[i].Connected
+		}
+		return usbDeviceKey(out[i]) < usbDeviceKey(out[j])
+	})
+	return out, nil
+}
+
+func (c *USBDeviceCollector) loadLocked() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	c.history = make(map[string]USBDevice)
+	if c.statePath == "" {
+		return
+	}
+	raw, err := os.ReadFile(c.statePath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to read usb device history", "path", c.statePath, "error", err.Error())
+		}
+		return
+	}
+	var devices []USBDevice
+	if err := json.Unmarshal(raw, &devices); err != nil {
+		slog.Warn("usb device history corrupt, resetting", "path", c.statePath, "error", err.Error())
+		return
+	}
+	for _, dev := range devices {
+		c.history[usbDeviceKey(dev)] = dev
+	}
+}
+
+func (c *USBDeviceCollector) saveLocked() {
+	if c.statePath == "" {
+		return
+	}
+	devices := make([]USBDevice, 0, len(c.history))
+	for _, dev := range c.history {
+		devices = append(devices, dev)
+	}
+	data, err := json.Marshal(devices)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.statePath), 0o700); err != nil {
+		slog.Warn("failed to create usb device history dir", "error", err.Error())
+		return
+	}
+	tmp := c.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		slog.Warn("failed to write usb device history", "error", err.Error())
+		return
+	}
+	if err := os.Rename(tmp, c.statePath); err != nil {
+		_ = os.Remove(tmp)
+		slog.Warn("failed to persist usb device history", "error", err.Error())
+	}
+}
+
+// usbDeviceKey identifies a physical device: VID:PID plus serial when the
+// device reports one, otherwise plus its port location so two identical
+// serial-less devices on different ports stay distinct.
+func usbDeviceKey(dev USBDevice) string {
+	id := dev.SerialNumber
+	if id == "" {
+		id = "@" + dev.Location
+	}
+	return strings.ToLower(dev.VendorID + ":" + dev.ProductID + ":" + id)
+}
+
+func trackedUSBDevice(dev USBDevice) TrackedUSBDevice {
+	return TrackedUSBDevice{
+		VendorID:     dev.VendorID,
+		ProductID:    dev.ProductID,
+		Vendor:       dev.Vendor,
+		Product:      dev.Product,
+		SerialNumber: dev.SerialNumber,
+		Class:        dev.Class,
+		MassStorage:  dev.MassStorage,
+	}
+}
+
+// usbSubject is the change-record subject: "Product (vvvv:pppp)".
+func usbSubject(dev TrackedUSBDevice) string {
+	name := dev.Product
+	if name == "" {
+		name = dev.Vendor
+	}
+	ids := dev.VendorID + ":" + dev.ProductID
+	if name == "" {
+		return ids
+	}
+	return fmt.Sprintf("%s (%s)", name, ids)
+}
+
+// usbClassFromCode maps a USB base class code (bDeviceClass or
+// bInterfaceClass) to a reported class.
+func usbClassFromCode(code byte) string {
+	switch code {
+	case 0x01:
+		return USBClassAudio
+	case 0x02, 0x0a:
+		return USBClassCommunications
+	case 0x03:
+		return USBClassHID
+	case 0x06:
+		return USBClassImage
+	case 0x07:
+		return USBClassPrinter
+	case 0x08:
+		return USBClassMassStorage
+	case 0x09:
+		return USBClassHub
+	case 0x0b:
+		return USBClassSmartCard
+	case 0x0e:
+		return USBClassVideo
+	case 0xe0:
+		return USBClassWireless
+	case 0xff:
+		return USBClassVendorSpecific
+	default:
+		return USBClassOther
+	}
+}
+
+// normalizeUSBID reduces "0x05AC", "05ac" or "0x05ac  (Apple Inc.)" to
+// "05ac". Returns "" when no hex ID is present.
+func normalizeUSBID(raw string) string {
+	s := strings.TrimSpace(raw)
+	if i := strings.IndexAny(s, " \t("); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimPrefix(strings.ToLower(s), "0x")
+	if s == "" || len(s) > 4 || strings.Trim(s, "0123456789abcdef") != "" {
+		return ""
+	}
+	return strings.Repeat("0", 4-len(s)) + s
+}
+
+// usbDevicesFromSysfs reads the devices under <root> (normally
+// /sys/bus/usb/devices). Root hubs ("usbN") and interface entries
+// ("1-1:1.0") are skipped; a device's class comes from bDeviceClass, or from
+// its interfaces when the device defers classification to them (class 0 or
+// 0xef, as composite devices do).
+func usbDevicesFromSysfs(root string) []USBDevice {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	devices := make([]USBDevice, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "usb") || strings.Contains(name, ":") {
+			continue
+		}
+		dir := filepath.Join(root, name)
+		vid, _ := readSysTrim(filepath.Join(dir, "idVendor"))
+		pid, _ := readSysTrim(filepath.Join(dir, "idProduct"))
+		vid, pid = normalizeUSBID(vid), normalizeUSBID(pid)
+		if vid == "" || pid == "" {
+			continue
+		}
+		dev := USBDevice{VendorID: vid, ProductID: pid, Location: name}
+		dev.Vendor, _ = readSysTrim(filepath.Join(dir, "manufacturer"))
+		dev.Product, _ = readSysTrim(filepath.Join(dir, "product"))
+		dev.SerialNumber, _ = readSysTrim(filepath.Join(dir, "serial"))
+
+		var ifaceClasses []byte
+		ifaces, _ := filepath.Glob(filepath.Join(dir, name+":*"))
+		for _, iface := range ifaces {
+			if code, ok := readSysHexByte(filepath.Join(iface, "bInterfaceClass")); ok {
+				ifaceClasses = append(ifaceClasses, code)
+			}
+		}
+		deviceClass, _ := readSysHexByte(filepath.Join(dir, "bDeviceClass"))
+		dev.Class, dev.MassStorage = classifyUSBCodes(deviceClass, ifaceClasses)
+		devices = append(devices, sanitizeUSBDevice(dev))
+	}
+	return devices
+}
+
+// classifyUSBCodes picks the reported class from the device class code and
+// its interface class codes. Mass storage wins whenever any interface is
+// mass storage, so a composite "keyboard + flash drive" is still flagged.
+func classifyUSBCodes(deviceClass byte, ifaceClasses []byte) (string, bool) {
+	for _, code := range ifaceClasses {
+		if code == 0x08 {
+			return USBClassMassStorage, true
+		}
+	}
+	if deviceClass != 0x00 && deviceClass != 0xef {
+		class := usbClassFromCode(deviceClass)
+		return class, class == USBClassMassStorage
+	}
+	if len(ifaceClasses) > 0 {
+		return usbClassFromCode(ifaceClasses[0]), false
+	}
+	return USBClassOther, false
+}
+
+func readSysHexByte(path string) (byte, bool) {
+	s, ok := readSysTrim(path)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(s, 16, 8)
+	if err != nil {
+		return 0, false
+	}
+	return byte(v), true
+}
+
+// windowsUSBRow is one Win32_PnPEntity with a USB\ device ID.
+type windowsUSBRow struct {
+	Name         string `json:"Name"`
+	Manufacturer string `json:"Manufacturer"`
+	DeviceID     string `json:"DeviceID"`
+	PNPClass     string `json:"PNPClass"`
+	Service      string `json:"Service"`
+}
+
+// usbDevicesFromWindowsRows converts PnP entities to devices. Device IDs look
+// like USB\VID_0781&PID_5581\4C530001231119115083; the instance segment is
+// only a serial when Windows did not generate it (a generated ID contains
+// '&'). Per-interface children of composite devices (…&MI_01\…) are folded
+// into their parent so a composite device with a storage interface is still
+// flagged as mass storage.
+func usbDevicesFromWindowsRows(rows []windowsUSBRow) []USBDevice {
+	var devices []USBDevice
+	type ifaceClass struct {
+		vidPid string
+		class  string
+		mass   bool
+	}
+	var ifaces []ifaceClass
+	for _, row := range rows {
+		parts := strings.Split(row.DeviceID, `\`)
+		if len(parts) < 3 || !strings.EqualFold(parts[0], "USB") {
+			continue
+		}
+		vid, pid, iface := parseWindowsUSBHardwareID(parts[1])
+		if vid == "" || pid == "" {
+			continue
+		}
+		class, mass := classifyWindowsUSB(row.PNPClass, row.Service, row.Name)
+		if iface {
+			ifaces = append(ifaces, ifaceClass{vidPid: vid + ":" + pid, class: class, mass: mass})
+			continue
+		}
+		if class == USBClassHub && strings.Contains(strings.ToLower(row.Name), "root hub") {
+			continue
+		}
+		dev := USBDevice{
+			VendorID:    vid,
+			ProductID:   pid,
+			Vendor:      row.Manufacturer,
+			Product:     row.Name,
+			Class:       class,
+			MassStorage: mass,
+			Location:    parts[2],
+		}
+		if !strings.Contains(parts[2], "&") {
+			dev.SerialNumber = parts[2]
+		}
+		devices = append(devices, dev)
+	}
+
+	for i := range devices {
+		vidPid := devices[i].VendorID + ":" + devices[i].ProductID
+		for _, ic := range ifaces {
+			if ic.vidPid != vidPid {
+				continue
+			}
+			if ic.mass {
+				devices[i].Class, devices[i].MassStorage = USBClassMassStorage, true
+			} else if devices[i].Class == USBClassOther {
+				devices[i].Class = ic.class
+			}
+		}
+		devices[i] = sanitizeUSBDevice(devices[i])
+	}
+	return devices
+}
+
+// parseWindowsUSBHardwareID splits "VID_0781&PID_5581[&MI_00]".
+func parseWindowsUSBHardwareID(s string) (vid, pid string, iface bool) {
+	for _, field := range strings.Split(strings.ToUpper(s), "&") {
+		switch {
+		case strings.HasPrefix(field, "VID_"):
+			vid = normalizeUSBID(strings.TrimPrefix(field, "VID_"))
+		case strings.HasPrefix(field, "PID_"):
+			pid = normalizeUSBID(strings.TrimPrefix(field, "PID_"))
+		case strings.HasPrefix(field, "MI_"):
+			iface = true
+		}
+	}
+	return vid, pid, iface
+}
+
+func classifyWindowsUSB(pnpClass, service, name string) (string, bool) {
+	switch strings.ToLower(service) {
+	case "usbstor", "uaspstor":
+		return USBClassMassStorage, true
+	case "usbhub", "usbhub3":
+		return USBClassHub, false
+	}
+	switch strings.ToLower(pnpClass) {
+	case "diskdrive":
+		return USBClassMassStorage, true
+	case "hidclass", "keyboard", "mouse":
+		return USBClassHID, false
+	case "media", "audioendpoint":
+		return USBClassAudio, false
+	case "camera":
+		return USBClassVideo, false
+	case "image":
+		return USBClassImage, false
+	case "printer":
+		return USBClassPrinter, false
+	case "smartcardreader":
+		return USBClassSmartCard, false
+	case "bluetooth":
+		return USBClassWireless, false
+	case "net", "ports", "modem":
+		return USBClassCommunications, false
+	}
+	if strings.Contains(strings.ToLower(name), "hub") {
+		return USBClassHub, false
+	}
+	return USBClassOther, false
+}
+
+// systemProfilerUSBItem is a node of `system_profiler SPUSBDataType -json`.
+type systemProfilerUSBItem struct {
+	Name         string                  `json:"_name"`
+	VendorID     string                  `json:"vendor_id"`
+	ProductID    string                  `json:"product_id"`
+	Manufacturer string                  `json:"manufacturer"`
+	SerialNum    string                  `json:"serial_num"`
+	LocationID   string                  `json:"location_id"`
+	Media        []json.RawMessage       `json:"Media"`
+	Items        []systemProfilerUSBItem `json:"_items"`
+}
+
+// parseSystemProfilerUSB flattens the system_profiler USB tree. Buses and
+// hubs without a vendor/product ID are skipped but their children are kept.
+// system_profiler exposes no class codes, so only mass storage (a device
+// with Media) is classified.
+func parseSystemProfilerUSB(output []byte) ([]USBDevice, error) {
+	var doc struct {
+		Items []systemProfilerUSBItem `json:"SPUSBDataType"`
+	}
+	if err := json.Unmarshal(output, &doc); err != nil {
+		return nil, err
+	}
+	var devices []USBDevice
+	var walk func(items []systemProfilerUSBItem)
+	walk = func(items []systemProfilerUSBItem) {
+		for _, item := range items {
+			vid, pid := normalizeUSBID(item.VendorID), normalizeUSBID(item.ProductID)
+			if vid != "" && pid != "" && !strings.Contains(strings.ToLower(item.Name), "hub") {
+				dev := USBDevice{
+					VendorID:     vid,
+					ProductID:    pid,
+					Vendor:       item.Manufacturer,
+					Product:      item.Name,
+					SerialNumber: item.SerialNum,
+					Class:        USBClassOther,
+					Location:     strings.Fields(item.LocationID + " ")[0],
+				}
+				if len(item.Media) > 0 {
+					dev.Class, dev.MassStorage = USBClassMassStorage, true
+				}
+				devices = append(devices, sanitizeUSBDevice(dev))
+			}
+			walk(item.Items)
+		}
+	}
+	walk(doc.Items)
+	return devices, nil
+}
+
+func sanitizeUSBDevice(dev USBDevice) USBDevice {
+	dev.Vendor = truncateCollectorString(strings.TrimSpace(dev.Vendor))
+	dev.Product = truncateCollectorString(strings.TrimSpace(dev.Product))
+	dev.SerialNumber = truncateCollectorString(strings.TrimSpace(dev.SerialNumber))
+	dev.Location = truncateCollectorString(dev.Location)
+	if dev.Class == "" {
+		dev.Class = USBClassOther
+	}
+	return dev
+}
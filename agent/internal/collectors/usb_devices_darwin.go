@@ -0,0 +1,11 @@
+//go:build darwin
+
+package collectors
+
+func collectUSBDevices() ([]USBDevice, error) {
+	output, err := runCollectorOutput(collectorLongCommandTimeout, "system_profiler", "SPUSBDataType", "-json")
+	if err != nil {
+		return nil, err
+	}
+	return parseSystemProfilerUSB(output)
+}
@@ -0,0 +1,7 @@
+//go:build linux
+
+package collectors
+
+func collectUSBDevices() ([]USBDevice, error) {
+	return usbDevicesFromSysfs("/sys/bus/usb/devices"), nil
+}
@@ -0,0 +1,159 @@
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeSysfsUSB(t *testing.T, root, name string, files map[string]string) {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUSBDevicesFromSysfs(t *testing.T) {
+	root := t.TempDir()
+	writeSysfsUSB(t, root, "usb1", map[string]string{"idVendor": "1d6b", "idProduct": "0002", "bDeviceClass": "09"})
+	// Composite keyboard + flash drive: class deferred to interfaces.
+	writeSysfsUSB(t, root, "1-1", map[string]string{
+		"idVendor": "0781", "idProduct": "5581", "bDeviceClass": "00",
+		"manufacturer": "SanDisk", "product": "Ultra", "serial": "4C5300012311",
+	})
+	writeSysfsUSB(t, root, "1-1/1-1:1.0", map[string]string{"bInterfaceClass": "03"})
+	writeSysfsUSB(t, root, "1-1/1-1:1.1", map[string]string{"bInterfaceClass": "08"})
+	writeSysfsUSB(t, root, "1-2", map[string]string{"idVendor": "046D", "idProduct": "c52b", "bDeviceClass": "00"})
+	writeSysfsUSB(t, root, "1-2/1-2:1.0", map[string]string{"bInterfaceClass": "03"})
+
+	devices := usbDevicesFromSysfs(root)
+	if len(devices) != 2 {
+		t.Fatalf("expected 2 devices (root hub skipped), got %+v", devices)
+	}
+	byPID := map[string]USBDevice{}
+	for _, d := range devices {
+		byPID[d.ProductID] = d
+	}
+	flash := byPID["5581"]
+	if !flash.MassStorage || flash.Class != USBClassMassStorage || flash.SerialNumber != "4C5300012311" || flash.VendorID != "0781" {
+		t.Errorf("unexpected flash drive %+v", flash)
+	}
+	receiver := byPID["c52b"]
+	if receiver.MassStorage || receiver.Class != USBClassHID || receiver.VendorID != "046d" || receiver.Location != "1-2" {
+		t.Errorf("unexpected receiver %+v", receiver)
+	}
+}
+
+func TestUSBDevicesFromWindowsRows(t *testing.T) {
+	rows := []windowsUSBRow{
+		{Name: "USB Root Hub (USB 3.0)", DeviceID: `USB\ROOT_HUB30\4&1A2B3C&0&0`, Service: "USBHUB3"},
+		{Name: "USB Mass Storage Device", Manufacturer: "Compatible USB storage device", DeviceID: `USB\VID_0781&PID_5581\4C530001231119115083`, Service: "USBSTOR", PNPClass: "USB"},
+		{Name: "USB Composite Device", DeviceID: `USB\VID_046D&PID_C52B\5&2D8E7F&0&3`, Service: "usbccgp", PNPClass: "USB"},
+		{Name: "USB Input Device", DeviceID: `USB\VID_046D&PID_C52B&MI_00\6&1F&0&0000`, Service: "HidUsb", PNPClass: "HIDClass"},
+	}
+	devices := usbDevicesFromWindowsRows(rows)
+	if len(devices) != 2 {
+		t.Fatalf("expected 2 devices, got %+v", devices)
+	}
+	if d := devices[0]; !d.MassStorage || d.SerialNumber != "4C530001231119115083" || d.VendorID != "0781" {
+		t.Errorf("unexpected storage device %+v", d)
+	}
+	if d := devices[1]; d.Class != USBClassHID || d.SerialNumber != "" || d.ProductID != "c52b" {
+		t.Errorf("composite device should take its interface class and have no generated serial: %+v", d)
+	}
+}
+
+func TestParseSystemProfilerUSB(t *testing.T) {
+	output := []byte(`{"SPUSBDataType":[{"_name":"USB31Bus","_items":[
+		{"_name":"USB3.1 Hub","vendor_id":"0x2109","product_id":"0x0817","_items":[
+			{"_name":"Extreme SSD","vendor_id":"0x0781  (SanDisk Corporation)","product_id":"0x5581","serial_num":"ABC123","location_id":"0x01100000 / 2","Media":[{"_name":"Extreme SSD"}]}
+		]},
+		{"_name":"Magic Keyboard","vendor_id":"apple_vendor_id","product_id":"0x029c","manufacturer":"Apple Inc."}
+	]}]}`)
+	devices, err := parseSystemProfilerUSB(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 {
+		t.Fatalf("expected only the SSD (hub and unparseable vendor skipped), got %+v", devices)
+	}
+	if d := devices[0]; !d.MassStorage || d.VendorID != "0781" || d.Location != "0x01100000" {
+		t.Errorf("unexpected device %+v", d)
+	}
+}
+
+func TestUSBDeviceCollectorTracksFirstAndLastSeen(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "usb.json")
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	stick := USBDevice{VendorID: "0781", ProductID: "5581", SerialNumber: "S1", Class: USBClassMassStorage, MassStorage: true}
+	current := []USBDevice{stick}
+
+	newCollector := func() *USBDeviceCollector {
+		c := NewUSBDeviceCollector(statePath)
+		c.enumerate = func() ([]USBDevice, error) { return current, nil }
+		c.now = func() time.Time { return now }
+		return c
+	}
+
+	if _, err := newCollector().Collect(); err != nil {
+		t.Fatal(err)
+	}
+	first := now
+
+	now = now.Add(time.Hour)
+	current = nil
+	devices, err := newCollector().Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 || devices[0].Connected || !devices[0].FirstSeen.Equal(first) || !devices[0].LastSeen.Equal(first) {
+		t.Fatalf("unplugged device should remain with its history, got %+v", devices)
+	}
+
+	now = now.Add(usbHistoryRetention + time.Hour)
+	if devices, _ := newCollector().Collect(); len(devices) != 0 {
+		t.Fatalf("device past retention should be pruned, got %+v", devices)
+	}
+}
+
+func TestChangeTrackerDiffsUSBDevices(t *testing.T) {
+	collector := NewChangeTrackerCollector(filepath.Join(t.TempDir(), "snapshot.json"))
+
+	// A snapshot from an older agent has no USB map: nothing is diffed.
+	snap := baselineSnapshot()
+	collector.gatherSnapshot = func() (*Snapshot, error) { return snap, nil }
+	if _, err := collector.CollectChanges(); err != nil {
+		t.Fatal(err)
+	}
+	mouse := TrackedUSBDevice{VendorID: "046d", ProductID: "c52b", Product: "Receiver", Class: USBClassHID}
+	snap = baselineSnapshot()
+	snap.USBDevices = map[string]TrackedUSBDevice{"046d:c52b:@1-2": mouse}
+	if changes, err := collector.CollectChanges(); err != nil || len(changes) != 0 {
+		t.Fatalf("first USB observation should only seed, got %+v, %v", changes, err)
+	}
+
+	stick := TrackedUSBDevice{VendorID: "0781", ProductID: "5581", Product: "Ultra", Class: USBClassMassStorage, MassStorage: true}
+	snap = baselineSnapshot()
+	snap.USBDevices = map[string]TrackedUSBDevice{"0781:5581:s1": stick}
+	changes, err := collector.CollectChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectChange(t, changes, ChangeTypeUSBDevice, ChangeActionAdded, "Ultra (0781:5581)")
+	expectChange(t, changes, ChangeTypeUSBDevice, ChangeActionRemoved, "Receiver (046d:c52b)")
+	for _, c := range changes {
+		if c.ChangeAction == ChangeActionAdded && c.Priority != ChangePriorityHigh {
+			t.Errorf("new mass-storage device should be high priority: %+v", c)
+		}
+		if c.ChangeAction == ChangeActionRemoved && c.Priority != "" {
+			t.Errorf("removal should not be prioritized: %+v", c)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package collectors
+
+import "context"
+
+// Present USB devices only; Win32_PnPEntity omits phantom (unplugged) devnodes.
+const windowsUSBScript = `Get-CimInstance Win32_PnPEntity -Filter "DeviceID LIKE 'USB\\%'" | Select-Object Name, Manufacturer, DeviceID, PNPClass, Service | ConvertTo-Json -Compress`
+
+func collectUSBDevices() ([]USBDevice, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectorLongCommandTimeout)
+	defer cancel()
+
+	rows, err := runWindowsJSON[windowsUSBRow](ctx, utf8PowerShellCommand(windowsUSBScript))
+	if err != nil {
+		return nil, err
+	}
+	return usbDevicesFromWindowsRows(rows), nil
+}
@@ -0,0 +1,447 @@
+package collectors
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Wi-Fi environment. Reports the network each wireless interface is
+// associated with (SSID, BSSID, signal, channel/band, security, link rate) so
+// "laptop is slow" tickets can be correlated with poor Wi-Fi, plus, when
+// enabled, the nearby networks from the OS's most recent scan. Machines with
+// no wireless interface report an empty list.
+
+// maxNearbyWiFiNetworks caps the nearby list; dense office floors can see
+// hundreds of BSSIDs.
+const maxNearbyWiFiNetworks = 100
+
+// WiFiNetwork is one associated or nearby network (one BSSID where the OS
+// exposes it). SignalDBM and SignalPercent are both filled when the OS
+// reports either, using the common 2*(dBm+100) mapping for the missing one.
+type WiFiNetwork struct {
+	Interface     string  `json:"interface,omitempty"`
+	SSID          string  `json:"ssid"`
+	BSSID         string  `json:"bssid,omitempty"`
+	SignalDBM     *int    `json:"signalDbm,omitempty"`
+	SignalPercent *int    `json:"signalPercent,omitempty"`
+	NoiseDBM      *int    `json:"noiseDbm,omitempty"`
+	Channel       int     `json:"channel,omitempty"`
+	Band          string  `json:"band,omitempty"`
+	FrequencyMHz  int     `json:"frequencyMhz,omitempty"`
+	Security      string  `json:"security,omitempty"`
+	PHYMode       string  `json:"phyMode,omitempty"`
+	TxRateMbps    float64 `json:"txRateMbps,omitempty"`
+}
+
+// WiFiStatus is the payload uploaded to the wifi inventory section.
+type WiFiStatus struct {
+	Connected     []WiFiNetwork `json:"connected"`
+	Nearby        []WiFiNetwork `json:"nearby,omitempty"`
+	NearbyScanned bool          `json:"nearbyScanned"`
+}
+
+type WiFiCollector struct {
+	scanNearby atomic.Bool
+}
+
+func NewWiFiCollector(scanNearby bool) *WiFiCollector {
+	c := &WiFiCollector{}
+	c.scanNearby.Store(scanNearby)
+	return c
+}
+
+// SetScanNearby toggles nearby-network reporting.
+func (c *WiFiCollector) SetScanNearby(enabled bool) {
+	c.scanNearby.Store(enabled)
+}
+
+// ScanNearby reports whether nearby networks are collected.
+func (c *WiFiCollector) ScanNearby() bool {
+	return c.scanNearby.Load()
+}
+
+// Collect returns the associated networks and, if enabled, nearby networks.
+func (c *WiFiCollector) Collect() (*WiFiStatus, error) {
+	nearby := c.scanNearby.Load()
+	connected, others, err := collectWiFi(nearby)
+	if err != nil {
+		return nil, err
+	}
+	status := &WiFiStatus{Connected: normalizeWiFiNetworks(connected), NearbyScanned: nearby}
+	if nearby {
+		status.Nearby = normalizeWiFiNetworks(others)
+		sort.SliceStable(status.Nearby, func(i, j int) bool {
+			return wifiSignalRank(status.Nearby[i]) > wifiSignalRank(status.Nearby[j])
+		})
+		if len(status.Nearby) > maxNearbyWiFiNetworks {
+			status.Nearby = status.Nearby[:maxNearbyWiFiNetworks]
+		}
+	}
+	return status, nil
+}
+
+func normalizeWiFiNetworks(networks []WiFiNetwork) []WiFiNetwork {
+	out := make([]WiFiNetwork, 0, len(networks))
+	for _, n := range networks {
+		n.Interface = truncateCollectorString(strings.TrimSpace(n.Interface))
+		n.SSID = truncateCollectorString(strings.TrimSpace(n.SSID))
+		n.BSSID = strings.ToLower(strings.TrimSpace(n.BSSID))
+		n.Security = truncateCollectorString(strings.TrimSpace(n.Security))
+		n.PHYMode = truncateCollectorString(strings.TrimSpace(n.PHYMode))
+		if n.SignalDBM != nil && n.SignalPercent == nil {
+			pct := min(max(2*(*n.SignalDBM+100), 0), 100)
+			n.SignalPercent = &pct
+		} else if n.SignalPercent != nil && n.SignalDBM == nil {
+			dbm := *n.SignalPercent/2 - 100
+			n.SignalDBM = &dbm
+		}
+		if n.Band == "" {
+			n.Band = wifiBand(n.FrequencyMHz, n.Channel)
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+func wifiSignalRank(n WiFiNetwork) int {
+	if n.SignalDBM == nil {
+		return -1000
+	}
+	return *n.SignalDBM
+}
+
+// wifiBand derives "2.4GHz", "5GHz" or "6GHz" from the frequency, falling
+// back to the channel number (ambiguous channels assume 2.4/5 GHz).
+func wifiBand(freqMHz, channel int) string {
+	switch {
+	case freqMHz >= 5925:
+		return "6GHz"
+	case freqMHz >= 4900:
+		return "5GHz"
+	case freqMHz >= 2400:
+		return "2.4GHz"
+	case freqMHz > 0:
+		return ""
+	case channel >= 1 && channel <= 14:
+		return "2.4GHz"
+	case channel >= 32 && channel <= 177:
+		return "5GHz"
+	}
+	return ""
+}
+
+// leadingInt parses the leading (optionally negative) integer of s, e.g.
+// "5180 MHz" -> 5180, "-52 dBm" -> -52, "36 (5GHz, 80MHz)" -> 36.
+func leadingInt(s string) (int, bool) {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && (s[end] >= '0' && s[end] <= '9' || end == 0 && s[end] == '-') {
+		end++
+	}
+	v, err := strconv.Atoi(s[:end])
+	return v, err == nil
+}
+
+// leadingFloat is leadingInt for decimal values such as "866.7".
+func leadingFloat(s string) (float64, bool) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	return v, err == nil
+}
+
+// splitNmcliTerse splits one `nmcli -t` line on unescaped ':' and unescapes
+// "\:" and "\\".
+func splitNmcliTerse(line string) []string {
+	var fields []string
+	var cur strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			cur.WriteByte(line[i])
+		case line[i] == ':':
+			fields = append(fields, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(line[i])
+		}
+	}
+	return append(fields, cur.String())
+}
+
+// nmcliWiFiFields is the -f list parseNmcliWiFi expects, in order.
+const nmcliWiFiFields = "IN-USE,DEVICE,SSID,BSSID,CHAN,FREQ,RATE,SIGNAL,SECURITY"
+
+// parseNmcliWiFi parses `nmcli -t -f <nmcliWiFiFields> dev wifi list` into
+// associated (IN-USE "*") and other networks. SIGNAL is a percentage.
+func parseNmcliWiFi(output string) (connected, nearby []WiFiNetwork) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		f := splitNmcliTerse(scanner.Text())
+		if len(f) != 9 {
+			continue
+		}
+		n := WiFiNetwork{Interface: f[1], SSID: f[2], BSSID: f[3]}
+		n.Channel, _ = leadingInt(f[4])
+		n.FrequencyMHz, _ = leadingInt(f[5])
+		n.TxRateMbps, _ = leadingFloat(f[6])
+		if pct, ok := leadingInt(f[7]); ok {
+			n.SignalPercent = intPtr(pct)
+		}
+		if sec := strings.TrimSpace(f[8]); sec != "" && sec != "--" {
+			n.Security = sec
+		} else {
+			n.Security = "open"
+		}
+		if strings.TrimSpace(f[0]) == "*" {
+			connected = append(connected, n)
+		} else {
+			nearby = append(nearby, n)
+		}
+	}
+	return connected, nearby
+}
+
+// parseIwInterfaces returns the interface names listed by `iw dev`.
+func parseIwInterfaces(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "Interface" {
+			names = append(names, fields[1])
+		}
+	}
+	return names
+}
+
+// parseIwLink parses `iw dev <iface> link`. It returns false when the
+// interface is not associated.
+func parseIwLink(iface, output string) (WiFiNetwork, bool) {
+	n := WiFiNetwork{Interface: iface}
+	associated := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(line, "Connected to "); ok {
+			associated = true
+			if fields := strings.Fields(rest); len(fields) > 0 {
+				n.BSSID = fields[0]
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "SSID":
+			n.SSID = value
+		case "freq":
+			if f, ok := leadingFloat(value); ok {
+				n.FrequencyMHz = int(f)
+			}
+		case "signal":
+			if dbm, ok := leadingInt(value); ok {
+				n.SignalDBM = intPtr(dbm)
+			}
+		case "tx bitrate":
+			n.TxRateMbps, _ = leadingFloat(value)
+		}
+	}
+	if n.FrequencyMHz > 0 {
+		n.Channel = wifiChannelFromFrequency(n.FrequencyMHz)
+	}
+	return n, associated
+}
+
+func wifiChannelFromFrequency(freq int) int {
+	switch {
+	case freq == 2484:
+		return 14
+	case freq >= 2412 && freq < 2484:
+		return (freq - 2407) / 5
+	case freq >= 5955:
+		return (freq - 5950) / 5
+	case freq >= 5000:
+		return (freq - 5000) / 5
+	}
+	return 0
+}
+
+type systemProfilerAirPortNetwork struct {
+	Name        string          `json:"_name"`
+	Channel     json.RawMessage `json:"spairport_network_channel"`
+	PHYMode     string          `json:"spairport_network_phymode"`
+	Rate        json.RawMessage `json:"spairport_network_rate"`
+	Security    string          `json:"spairport_security_mode"`
+	SignalNoise string          `json:"spairport_signal_noise"`
+}
+
+type systemProfilerAirPortInterface struct {
+	Name    string                         `json:"_name"`
+	Current *systemProfilerAirPortNetwork  `json:"spairport_current_network_information"`
+	Others  []systemProfilerAirPortNetwork `json:"spairport_airport_other_local_wireless_networks"`
+}
+
+// parseSystemProfilerAirPort parses `system_profiler SPAirPortDataType
+// -json`. macOS does not expose BSSIDs here, and redacts SSIDs for processes
+// without location access.
+func parseSystemProfilerAirPort(data []byte) (connected, nearby []WiFiNetwork, err error) {
+	var doc struct {
+		Items []struct {
+			Interfaces []systemProfilerAirPortInterface `json:"spairport_airport_interfaces"`
+		} `json:"SPAirPortDataType"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("parse system_profiler output: %w", err)
+	}
+	for _, item := range doc.Items {
+		for _, iface := range item.Interfaces {
+			if iface.Current != nil && iface.Current.Name != "" {
+				connected = append(connected, airPortNetwork(iface.Name, *iface.Current))
+			}
+			for _, other := range iface.Others {
+				nearby = append(nearby, airPortNetwork(iface.Name, other))
+			}
+		}
+	}
+	return connected, nearby, nil
+}
+
+func airPortNetwork(iface string, raw systemProfilerAirPortNetwork) WiFiNetwork {
+	n := WiFiNetwork{
+		Interface: iface,
+		SSID:      raw.Name,
+		PHYMode:   raw.PHYMode,
+		Security:  strings.TrimPrefix(raw.Security, "spairport_security_mode_"),
+	}
+	// Channel is "36 (5GHz, 80MHz)" on current macOS and a bare number on
+	// older releases; the rate is a number of Mbps.
+	channel := strings.Trim(string(raw.Channel), `"`)
+	n.Channel, _ = leadingInt(channel)
+	if _, rest, ok := strings.Cut(channel, "("); ok {
+		band := strings.TrimSpace(strings.Split(rest, ",")[0])
+		band = strings.TrimSuffix(band, ")")
+		if band == "2GHz" {
+			band = "2.4GHz"
+		}
+		n.Band = band
+	}
+	n.TxRateMbps, _ = leadingFloat(strings.Trim(string(raw.Rate), `"`))
+	signal, noise, _ := strings.Cut(raw.SignalNoise, "/")
+	if dbm, ok := leadingInt(signal); ok {
+		n.SignalDBM = intPtr(dbm)
+	}
+	if dbm, ok := leadingInt(noise); ok {
+		n.NoiseDBM = intPtr(dbm)
+	}
+	return n
+}
+
+// netshKeyValue splits an indented "Key   : value" netsh line.
+func netshKeyValue(line string) (string, string, bool) {
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", "", false
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), true
+}
+
+// parseNetshInterfaces parses `netsh wlan show interfaces`, returning only
+// associated interfaces. netsh output is localized; only the English labels
+// are recognised.
+func parseNetshInterfaces(output string) []WiFiNetwork {
+	var networks []WiFiNetwork
+	var cur *WiFiNetwork
+	connected := false
+	flush := func() {
+		if cur != nil && connected && cur.SSID != "" {
+			networks = append(networks, *cur)
+		}
+	}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := netshKeyValue(line)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Name":
+			flush()
+			cur, connected = &WiFiNetwork{Interface: value}, false
+		case "State":
+			connected = strings.EqualFold(value, "connected")
+		}
+		if cur == nil {
+			continue
+		}
+		switch key {
+		case "SSID":
+			cur.SSID = value
+		case "AP BSSID", "BSSID":
+			cur.BSSID = value
+		case "Radio type":
+			cur.PHYMode = value
+		case "Authentication":
+			cur.Security = value
+		case "Band":
+			cur.Band = strings.ReplaceAll(value, " ", "")
+		case "Channel":
+			cur.Channel, _ = leadingInt(value)
+		case "Transmit rate (Mbps)":
+			cur.TxRateMbps, _ = leadingFloat(value)
+		case "Signal":
+			if pct, ok := leadingInt(value); ok {
+				cur.SignalPercent = intPtr(pct)
+			}
+		case "Rssi":
+			if dbm, ok := leadingInt(value); ok {
+				cur.SignalDBM = intPtr(dbm)
+			}
+		}
+	}
+	flush()
+	return networks
+}
+
+// parseNetshNetworks parses `netsh wlan show networks mode=bssid`, one entry
+// per BSSID.
+func parseNetshNetworks(output string) []WiFiNetwork {
+	var networks []WiFiNetwork
+	var ssid, security string
+	cur := -1
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := netshKeyValue(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(key, "SSID "):
+			ssid, security, cur = value, "", -1
+		case key == "Authentication":
+			security = value
+		case strings.HasPrefix(key, "BSSID "):
+			networks = append(networks, WiFiNetwork{SSID: ssid, BSSID: value, Security: security})
+			cur = len(networks) - 1
+		case cur < 0:
+		case key == "Signal":
+			if pct, ok := leadingInt(value); ok {
+				networks[cur].SignalPercent = intPtr(pct)
+			}
+		case key == "Radio type":
+			networks[cur].PHYMode = value
+		case key == "Band":
+			networks[cur].Band = strings.ReplaceAll(value, " ", "")
+		case key == "Channel":
+			networks[cur].Channel, _ = leadingInt(value)
+		}
+	}
+	return networks
+}
@@ -0,0 +1,13 @@
+//go:build darwin
+
+package collectors
+
+import "fmt"
+
+func collectWiFi(_ bool) ([]WiFiNetwork, []WiFiNetwork, error) {
+	output, err := runCollectorOutput(collectorLongCommandTimeout, "system_profiler", "SPAirPortDataType", "-json")
+	if err != nil {
+		return nil, nil, fmt.Errorf("system_profiler SPAirPortDataType failed: %w", err)
+	}
+	return parseSystemProfilerAirPort(output)
+}
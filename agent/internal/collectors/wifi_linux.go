@@ -0,0 +1,41 @@
+//go:build linux
+
+package collectors
+
+import (
+	"context"
+	"log/slog"
+)
+
+// collectWiFi prefers NetworkManager, which reports security and the nearby
+// scan; without it, iw still gives the associated network per interface.
+func collectWiFi(nearby bool) ([]WiFiNetwork, []WiFiNetwork, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectorLongCommandTimeout)
+	defer cancel()
+
+	// --rescan no reads NetworkManager's cached scan instead of triggering a
+	// new one, which would briefly disrupt throughput on some drivers.
+	output, err := runCollectorOutputWithContext(ctx, collectorShortCommandTimeout, "nmcli", "-t", "-f", nmcliWiFiFields, "dev", "wifi", "list", "--rescan", "no")
+	if err == nil {
+		connected, others := parseNmcliWiFi(string(output))
+		return connected, others, nil
+	}
+	slog.Debug("nmcli wifi query failed, falling back to iw", "error", err.Error())
+
+	devs, err := runCollectorOutputWithContext(ctx, collectorShortCommandTimeout, "iw", "dev")
+	if err != nil {
+		// Neither tool present: treat as no wireless hardware.
+		return nil, nil, nil
+	}
+	var connected []WiFiNetwork
+	for _, iface := range parseIwInterfaces(string(devs)) {
+		link, err := runCollectorOutputWithContext(ctx, collectorShortCommandTimeout, "iw", "dev", iface, "link")
+		if err != nil {
+			continue
+		}
+		if n, ok := parseIwLink(iface, string(link)); ok {
+			connected = append(connected, n)
+		}
+	}
+	return connected, nil, nil
+}
@@ -0,0 +1,7 @@
+//go:build !windows && !linux && !darwin
+
+package collectors
+
+func collectWiFi(_ bool) ([]WiFiNetwork, []WiFiNetwork, error) {
+	return nil, nil, nil
+}
@@ -0,0 +1,126 @@
+package collectors
+
+import "testing"
+
+func TestParseNmcliWiFi(t *testing.T) {
+	output := `*:wlp2s0:Office:AA\:BB\:CC\:DD\:EE\:01:36:5180 MHz:540 Mbit/s:78:WPA2 WPA3
+ :wlp2s0:Office:AA\:BB\:CC\:DD\:EE\:02:1:2412 MHz:130 Mbit/s:40:WPA2
+ :wlp2s0:Guest\:Lobby:AA\:BB\:CC\:DD\:EE\:03:6:2437 MHz:54 Mbit/s:22:--
+`
+	connected, nearby := parseNmcliWiFi(output)
+	if len(connected) != 1 || len(nearby) != 2 {
+		t.Fatalf("connected=%+v nearby=%+v", connected, nearby)
+	}
+	c := normalizeWiFiNetworks(connected)[0]
+	if c.SSID != "Office" || c.BSSID != "aa:bb:cc:dd:ee:01" || c.Channel != 36 || c.Band != "5GHz" || c.TxRateMbps != 540 {
+		t.Errorf("connected = %+v", c)
+	}
+	if c.SignalPercent == nil || *c.SignalPercent != 78 || c.SignalDBM == nil || *c.SignalDBM != -61 {
+		t.Errorf("signal = %v / %v", c.SignalPercent, c.SignalDBM)
+	}
+	if nearby[1].SSID != "Guest:Lobby" || nearby[1].Security != "open" {
+		t.Errorf("escaped/open network = %+v", nearby[1])
+	}
+}
+
+func TestParseIwLink(t *testing.T) {
+	output := `Connected to 11:22:33:44:55:66 (on wlan0)
+	SSID: Home
+	freq: 2462.0
+	RX: 123 bytes (4 packets)
+	signal: -67 dBm
+	tx bitrate: 72.2 MBit/s MCS 7 short GI
+`
+	n, ok := parseIwLink("wlan0", output)
+	if !ok {
+		t.Fatal("expected associated")
+	}
+	n = normalizeWiFiNetworks([]WiFiNetwork{n})[0]
+	if n.SSID != "Home" || n.Channel != 11 || n.FrequencyMHz != 2462 || n.Band != "2.4GHz" || n.TxRateMbps != 72.2 {
+		t.Errorf("link = %+v", n)
+	}
+	if *n.SignalDBM != -67 || *n.SignalPercent != 66 {
+		t.Errorf("signal = %d dBm / %d%%", *n.SignalDBM, *n.SignalPercent)
+	}
+	if _, ok := parseIwLink("wlan0", "Not connected.\n"); ok {
+		t.Error("unassociated interface reported as connected")
+	}
+	if got := parseIwInterfaces("phy#0\n\tInterface wlan0\n\t\tifindex 3\n"); len(got) != 1 || got[0] != "wlan0" {
+		t.Errorf("interfaces = %v", got)
+	}
+}
+
+func TestParseSystemProfilerAirPort(t *testing.T) {
+	data := []byte(`{"SPAirPortDataType":[{"spairport_airport_interfaces":[
+		{"_name":"en0",
+		 "spairport_current_network_information":{"_name":"Office","spairport_network_channel":"149 (5GHz, 80MHz)","spairport_network_phymode":"802.11ac","spairport_network_rate":866,"spairport_security_mode":"spairport_security_mode_wpa2_personal","spairport_signal_noise":"-58 dBm / -92 dBm"},
+		 "spairport_airport_other_local_wireless_networks":[{"_name":"Cafe","spairport_network_channel":6,"spairport_security_mode":"spairport_security_mode_none","spairport_signal_noise":"-80 dBm / -95 dBm"}]},
+		{"_name":"awdl0"}
+	]}]}`)
+	connected, nearby, err := parseSystemProfilerAirPort(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(connected) != 1 || len(nearby) != 1 {
+		t.Fatalf("connected=%+v nearby=%+v", connected, nearby)
+	}
+	c := connected[0]
+	if c.Interface != "en0" || c.Channel != 149 || c.Band != "5GHz" || c.Security != "wpa2_personal" || c.TxRateMbps != 866 || *c.SignalDBM != -58 || *c.NoiseDBM != -92 {
+		t.Errorf("connected = %+v", c)
+	}
+	if n := normalizeWiFiNetworks(nearby)[0]; n.Channel != 6 || n.Band != "2.4GHz" || n.Security != "none" {
+		t.Errorf("nearby = %+v", n)
+	}
+}
+
+func TestParseNetsh(t *testing.T) {
+	interfaces := `
+There is 1 interface on the system:
+
+    Name                   : Wi-Fi
+    Description            : Intel(R) Wi-Fi 6 AX201 160MHz
+    State                  : connected
+    SSID                   : Office
+    AP BSSID               : 0a:1b:2c:3d:4e:5f
+    Radio type             : 802.11ax
+    Authentication         : WPA2-Enterprise
+    Band                   : 5 GHz
+    Channel                : 44
+    Receive rate (Mbps)    : 1201
+    Transmit rate (Mbps)   : 960.5
+    Signal                 : 88%
+    Rssi                   : -52
+`
+	got := parseNetshInterfaces(interfaces)
+	if len(got) != 1 {
+		t.Fatalf("interfaces = %+v", got)
+	}
+	if n := got[0]; n.Interface != "Wi-Fi" || n.BSSID != "0a:1b:2c:3d:4e:5f" || n.Band != "5GHz" || n.Channel != 44 || n.TxRateMbps != 960.5 || *n.SignalPercent != 88 || *n.SignalDBM != -52 || n.Security != "WPA2-Enterprise" {
+		t.Errorf("interface = %+v", n)
+	}
+	if got := parseNetshInterfaces("    Name : Wi-Fi\n    State : disconnected\n"); len(got) != 0 {
+		t.Errorf("disconnected interface reported: %+v", got)
+	}
+
+	networks := parseNetshNetworks(`
+SSID 1 : Office
+    Network type            : Infrastructure
+    Authentication          : WPA2-Enterprise
+    Encryption              : CCMP
+    BSSID 1                 : 0a:1b:2c:3d:4e:5f
+         Signal             : 88%
+         Radio type         : 802.11ax
+         Band               : 5 GHz
+         Channel            : 44
+    BSSID 2                 : 0a:1b:2c:3d:4e:60
+         Signal             : 35%
+         Channel            : 1
+SSID 2 :
+    Authentication          : Open
+    BSSID 1                 : 66:77:88:99:aa:bb
+         Signal             : 20%
+`)
+	if len(networks) != 3 || networks[1].SSID != "Office" || *networks[1].SignalPercent != 35 || networks[2].SSID != "" || networks[2].Security != "Open" {
+		t.Errorf("networks = %+v", networks)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package collectors
+
+import (
+	"fmt"
+	"strings"
+)
+
+func collectWiFi(nearby bool) ([]WiFiNetwork, []WiFiNetwork, error) {
+	output, err := runCollectorOutput(collectorShortCommandTimeout, "netsh", "wlan", "show", "interfaces")
+	if err != nil {
+		// The WLAN AutoConfig service is absent or stopped on machines
+		// without wireless hardware.
+		if strings.Contains(string(output), "wlansvc") {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("netsh wlan show interfaces failed: %w", err)
+	}
+	connected := parseNetshInterfaces(string(output))
+	if !nearby {
+		return connected, nil, nil
+	}
+
+	output, err = runCollectorOutput(collectorShortCommandTimeout, "netsh", "wlan", "show", "networks", "mode=bssid")
+	if err != nil {
+		return connected, nil, nil
+	}
+	return connected, parseNetshNetworks(string(output)), nil
+}
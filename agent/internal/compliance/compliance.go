@@ -0,0 +1,249 @@
+// Package compliance evaluates server-defined controls against the local
+// system. Unlike the cis package, whose checks are compiled in, controls
+// arrive with the command: each names a probe (a registry value, a sysctl,
+// a config key, file attributes, a service or a plist key) and the value
+// it is expected to have, and the agent reports pass or fail per control.
+package compliance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/collectors"
+)
+
+// Control statuses.
+const (
+	StatusPass          = "pass"
+	StatusFail          = "fail"
+	StatusNotApplicable = "not_applicable"
+	StatusError         = "error"
+)
+
+// maxControls bounds a single evaluation; a full CIS benchmark is a few
+// hundred controls.
+const maxControls = 1000
+
+// errNotApplicable is returned by probes that do not exist on this OS.
+var errNotApplicable = errors.New("probe is not available on this platform")
+
+// Control is one server-defined check.
+type Control struct {
+	ID       string `json:"id"`
+	Title    string `json:"title,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	// Platforms limits the control to some of windows, macos and linux;
+	// empty means all.
+	Platforms []string    `json:"platforms,omitempty"`
+	Probe     Probe       `json:"probe"`
+	Expect    Expectation `json:"expect"`
+}
+
+// Probe says what to read. Which fields apply depends on Type.
+type Probe struct {
+	// Type is registry, config, sysctl, file, service or plist.
+	Type         string `json:"type"`
+	RegistryPath string `json:"registryPath,omitempty"`
+	ValueName    string `json:"valueName,omitempty"`
+	// FilePath is the config file, the file to stat, or the plist (a path
+	// or a preferences domain).
+	FilePath string `json:"filePath,omitempty"`
+	// Key is the config key, the sysctl name or the plist key.
+	Key     string `json:"key,omitempty"`
+	Service string `json:"service,omitempty"`
+	// Attribute picks mode (default), uid or gid for files, and status
+	// (default) or startType for services.
+	Attribute string `json:"attribute,omitempty"`
+}
+
+// Expectation is the value a probe must have.
+type Expectation struct {
+	// Operator is equals, not_equals, in, not_in, gt, gte, lt, lte, matches,
+	// mode_at_most, exists or absent.
+	Operator string `json:"operator"`
+	// Value is a list for in and not_in, an octal string such as "0640" for
+	// mode_at_most, and unused for exists and absent.
+	Value any `json:"value,omitempty"`
+	// AllowMissing passes the control when the value is not set at all,
+	// for settings whose default is already compliant.
+	AllowMissing bool `json:"allowMissing,omitempty"`
+}
+
+// ControlResult is the outcome of one control.
+type ControlResult struct {
+	ID       string `json:"id"`
+	Title    string `json:"title,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Status   string `json:"status"`
+	// Actual is the value the probe found; omitted when it was not set or
+	// the key is sensitive.
+	Actual  any    `json:"actual,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Report is the result of an evaluation.
+type Report struct {
+	CheckedAt     string          `json:"checkedAt"`
+	Results       []ControlResult `json:"results"`
+	Total         int             `json:"total"`
+	Passed        int             `json:"passed"`
+	Failed        int             `json:"failed"`
+	Errors        int             `json:"errors"`
+	NotApplicable int             `json:"notApplicable"`
+	// Score is the percentage of applicable controls that passed.
+	Score int `json:"score"`
+}
+
+// stateSource is the part of collectors.PolicyStateCollector the evaluator
+// reads registry, config and sysctl values through.
+type stateSource interface {
+	CollectRegistryState(probes []collectors.RegistryProbe) ([]collectors.RegistryStateEntry, error)
+	CollectConfigState(probes []collectors.ConfigProbe) ([]collectors.ConfigStateEntry, error)
+	CollectSysctlState(keys []string) ([]collectors.SysctlStateEntry, error)
+}
+
+// Evaluator runs controls. The zero value is not usable; use NewEvaluator.
+type Evaluator struct {
+	goos        string
+	policyState stateSource
+	statFile    func(path string) (fileState, error)
+	service     func(name string) (serviceState, error)
+	plistValue  func(path, key string) (value string, found bool, err error)
+}
+
+// NewEvaluator returns an evaluator for the running system.
+func NewEvaluator() *Evaluator {
+	return &Evaluator{
+		goos:        runtime.GOOS,
+		policyState: collectors.NewPolicyStateCollector(),
+		statFile:    statFile,
+		service:     getServiceState,
+		plistValue:  readPlistValue,
+	}
+}
+
+// ParseControls decodes the controls of a command payload.
+func ParseControls(raw any) ([]Control, error) {
+	if raw == nil {
+		return nil, errors.New("controls are required")
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid controls: %w", err)
+	}
+	var controls []Control
+	if err := json.Unmarshal(data, &controls); err != nil {
+		return nil, fmt.Errorf("invalid controls: %w", err)
+	}
+	if len(controls) == 0 {
+		return nil, errors.New("controls are required")
+	}
+	if len(controls) > maxControls {
+		return nil, fmt.Errorf("too many controls: %d (max %d)", len(controls), maxControls)
+	}
+	return controls, nil
+}
+
+// Evaluate runs every control in order. A control that cannot be probed is
+// reported as an error and does not stop the rest.
+func (e *Evaluator) Evaluate(controls []Control) Report {
+	report := Report{Results: make([]ControlResult, 0, len(controls))}
+	for _, control := range controls {
+		result := e.evaluateControl(control)
+		report.Results = append(report.Results, result)
+
+		switch result.Status {
+		case StatusPass:
+			report.Passed++
+		case StatusFail:
+			report.Failed++
+		case StatusError:
+			report.Errors++
+		case StatusNotApplicable:
+			report.NotApplicable++
+		}
+	}
+
+	report.Total = len(report.Results)
+	if applicable := report.Total - report.NotApplicable; applicable > 0 {
+		report.Score = (report.Passed * 100) / applicable
+	}
+	report.CheckedAt = time.Now().UTC().Format(time.RFC3339)
+	return report
+}
+
+func (e *Evaluator) evaluateControl(control Control) ControlResult {
+	result := ControlResult{ID: control.ID, Title: control.Title, Severity: control.Severity}
+	if strings.TrimSpace(control.ID) == "" {
+		result.Status = StatusError
+		result.Message = "control id is required"
+		return result
+	}
+	if !platformMatches(control.Platforms, e.goos) {
+		result.Status = StatusNotApplicable
+		result.Message = fmt.Sprintf("control does not apply to %s", e.goos)
+		return result
+	}
+
+	actual, found, err := e.probe(control.Probe)
+	if errors.Is(err, errNotApplicable) {
+		result.Status = StatusNotApplicable
+		result.Message = err.Error()
+		return result
+	}
+	if err != nil {
+		result.Status = StatusError
+		result.Message = err.Error()
+		return result
+	}
+	if found && !probeIsSensitive(control.Probe) {
+		result.Actual = actual
+	}
+
+	pass, err := evaluateExpectation(actual, found, control.Expect)
+	if err != nil {
+		result.Status = StatusError
+		result.Message = err.Error()
+		return result
+	}
+	if pass {
+		result.Status = StatusPass
+	} else {
+		result.Status = StatusFail
+		result.Message = failureMessage(actual, found, control.Expect)
+	}
+	return result
+}
+
+func platformMatches(platforms []string, goos string) bool {
+	if len(platforms) == 0 {
+		return true
+	}
+	for _, platform := range platforms {
+		platform = strings.ToLower(strings.TrimSpace(platform))
+		if platform == "macos" {
+			platform = "darwin"
+		}
+		if platform == goos {
+			return true
+		}
+	}
+	return false
+}
+
+func failureMessage(actual any, found bool, expect Expectation) string {
+	if !found {
+		if expect.Operator == "exists" {
+			return "value is not set"
+		}
+		return fmt.Sprintf("value is not set; expected %s %s", expect.Operator, formatValue(expect.Value))
+	}
+	if expect.Operator == "absent" {
+		return "value is set but must not be"
+	}
+	return fmt.Sprintf("expected %s %s", expect.Operator, formatValue(expect.Value))
+}
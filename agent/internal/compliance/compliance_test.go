@@ -0,0 +1,199 @@
+package compliance
+
+import (
+	"os"
+	"testing"
+
+	"github.com/breeze-rmm/agent/internal/collectors"
+)
+
+type fakeState struct {
+	registry map[string]any
+	config   map[string]string
+	sysctl   map[string]string
+}
+
+func (f fakeState) CollectRegistryState(probes []collectors.RegistryProbe) ([]collectors.RegistryStateEntry, error) {
+	var entries []collectors.RegistryStateEntry
+	for _, p := range probes {
+		if v, ok := f.registry[p.RegistryPath+`\`+p.ValueName]; ok {
+			entries = append(entries, collectors.RegistryStateEntry{RegistryPath: p.RegistryPath, ValueName: p.ValueName, ValueData: v})
+		}
+	}
+	return entries, nil
+}
+
+func (f fakeState) CollectConfigState(probes []collectors.ConfigProbe) ([]collectors.ConfigStateEntry, error) {
+	var entries []collectors.ConfigStateEntry
+	for _, p := range probes {
+		if v, ok := f.config[p.FilePath+":"+p.ConfigKey]; ok {
+			entries = append(entries, collectors.ConfigStateEntry{FilePath: p.FilePath, ConfigKey: p.ConfigKey, ConfigValue: v})
+		}
+	}
+	return entries, nil
+}
+
+func (f fakeState) CollectSysctlState(keys []string) ([]collectors.SysctlStateEntry, error) {
+	var entries []collectors.SysctlStateEntry
+	for _, k := range keys {
+		if v, ok := f.sysctl[k]; ok {
+			entries = append(entries, collectors.SysctlStateEntry{Key: k, Value: v})
+		}
+	}
+	return entries, nil
+}
+
+func testEvaluator(goos string) *Evaluator {
+	return &Evaluator{
+		goos: goos,
+		policyState: fakeState{
+			registry: map[string]any{
+				`HKLM\SYSTEM\CurrentControlSet\Control\Lsa\LimitBlankPasswordUse`:            uint64(1),
+				`HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion\Winlogon\DefaultPassword`: "hunter2",
+			},
+			config: map[string]string{"/etc/ssh/sshd_config:PermitRootLogin": "no"},
+			sysctl: map[string]string{"net.ipv4.ip_forward": "1"},
+		},
+		statFile: func(path string) (fileState, error) {
+			if path == "/etc/shadow" {
+				return fileState{Mode: 0o640, UID: 0, GID: 42}, nil
+			}
+			return fileState{}, os.ErrNotExist
+		},
+		service: func(name string) (serviceState, error) {
+			if name == "avahi-daemon" {
+				return serviceState{Installed: true, Status: "running", StartType: "enabled"}, nil
+			}
+			return serviceState{}, nil
+		},
+		plistValue: func(path, key string) (string, bool, error) {
+			return "1", path == "com.apple.screensaver" && key == "askForPassword", nil
+		},
+	}
+}
+
+func TestEvaluateLinuxControls(t *testing.T) {
+	controls := []Control{
+		{ID: "ssh-root", Probe: Probe{Type: "config", FilePath: "/etc/ssh/sshd_config", Key: "PermitRootLogin"}, Expect: Expectation{Operator: "equals", Value: "No"}},
+		{ID: "ip-forward", Probe: Probe{Type: "sysctl", Key: "net.ipv4.ip_forward"}, Expect: Expectation{Operator: "equals", Value: float64(0)}},
+		{ID: "shadow-mode", Probe: Probe{Type: "file", FilePath: "/etc/shadow"}, Expect: Expectation{Operator: "mode_at_most", Value: "0640"}},
+		{ID: "shadow-owner", Probe: Probe{Type: "file", FilePath: "/etc/shadow", Attribute: "uid"}, Expect: Expectation{Operator: "equals", Value: float64(0)}},
+		{ID: "avahi", Probe: Probe{Type: "service", Service: "avahi-daemon"}, Expect: Expectation{Operator: "in", Value: []any{"stopped", "disabled"}, AllowMissing: true}},
+		{ID: "telnet", Probe: Probe{Type: "service", Service: "telnet.socket"}, Expect: Expectation{Operator: "equals", Value: "disabled", AllowMissing: true}},
+		{ID: "win-only", Platforms: []string{"windows"}, Probe: Probe{Type: "registry", RegistryPath: `HKLM\X`, ValueName: "Y"}, Expect: Expectation{Operator: "exists"}},
+		{ID: "secrets", Probe: Probe{Type: "config", FilePath: "/etc/breeze/agent.yaml", Key: "auth_token"}, Expect: Expectation{Operator: "absent"}},
+	}
+
+	report := testEvaluator("linux").Evaluate(controls)
+	want := map[string]string{
+		"ssh-root":     StatusPass,
+		"ip-forward":   StatusFail,
+		"shadow-mode":  StatusPass,
+		"shadow-owner": StatusPass,
+		"avahi":        StatusFail,
+		"telnet":       StatusPass,
+		"win-only":     StatusNotApplicable,
+		"secrets":      StatusError,
+	}
+	for _, r := range report.Results {
+		if r.Status != want[r.ID] {
+			t.Errorf("%s: status %s (%s), want %s", r.ID, r.Status, r.Message, want[r.ID])
+		}
+	}
+	if report.Total != 8 || report.Passed != 4 || report.Failed != 2 || report.Errors != 1 || report.NotApplicable != 1 {
+		t.Fatalf("unexpected counts %+v", report)
+	}
+	if report.Score != 57 {
+		t.Fatalf("score should ignore not-applicable controls, got %d", report.Score)
+	}
+	if report.Results[1].Actual != "1" || report.Results[1].Message == "" {
+		t.Fatalf("failed control should carry the actual value and a message: %+v", report.Results[1])
+	}
+}
+
+func TestEvaluateWindowsAndDarwinControls(t *testing.T) {
+	report := testEvaluator("windows").Evaluate([]Control{
+		{ID: "blank-passwords", Probe: Probe{Type: "registry", RegistryPath: `HKLM\SYSTEM\CurrentControlSet\Control\Lsa`, ValueName: "LimitBlankPasswordUse"}, Expect: Expectation{Operator: "equals", Value: true}},
+		{ID: "autologon", Probe: Probe{Type: "registry", RegistryPath: `HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion\Winlogon`, ValueName: "DefaultPassword"}, Expect: Expectation{Operator: "absent"}},
+		{ID: "sysctl", Probe: Probe{Type: "sysctl", Key: "net.ipv4.ip_forward"}, Expect: Expectation{Operator: "equals", Value: "0"}},
+	})
+	if got := report.Results[0].Status; got != StatusPass {
+		t.Errorf("blank-passwords: %s", got)
+	}
+	if r := report.Results[1]; r.Status != StatusFail || r.Actual != nil {
+		t.Errorf("autologon must fail without reporting the password: %+v", r)
+	}
+	if got := report.Results[2].Status; got != StatusNotApplicable {
+		t.Errorf("sysctl on windows: %s", got)
+	}
+
+	report = testEvaluator("darwin").Evaluate([]Control{
+		{ID: "screensaver", Platforms: []string{"macos"}, Probe: Probe{Type: "plist", FilePath: "com.apple.screensaver", Key: "askForPassword"}, Expect: Expectation{Operator: "equals", Value: true}},
+		{ID: "bad-domain", Probe: Probe{Type: "plist", FilePath: "../etc/passwd", Key: "x"}, Expect: Expectation{Operator: "exists"}},
+	})
+	if report.Results[0].Status != StatusPass || report.Results[1].Status != StatusError {
+		t.Fatalf("unexpected darwin results %+v", report.Results)
+	}
+}
+
+func TestEvaluateExpectation(t *testing.T) {
+	tests := []struct {
+		name   string
+		actual any
+		found  bool
+		expect Expectation
+		want   bool
+	}{
+		{"numeric string equals number", "14", true, Expectation{Operator: "equals", Value: float64(14)}, true},
+		{"registry dword gte", uint64(24), true, Expectation{Operator: "gte", Value: float64(24)}, true},
+		{"lte fails", "90", true, Expectation{Operator: "lte", Value: float64(60)}, false},
+		{"bool from yes", "yes", true, Expectation{Operator: "equals", Value: true}, true},
+		{"not_in", "prohibit-password", true, Expectation{Operator: "not_in", Value: []any{"yes"}}, true},
+		{"matches", "aes256-ctr,aes128-ctr", true, Expectation{Operator: "matches", Value: `^aes`}, true},
+		{"mode stricter", "0600", true, Expectation{Operator: "mode_at_most", Value: "0644"}, true},
+		{"mode extra bit", "0604", true, Expectation{Operator: "mode_at_most", Value: "0640"}, false},
+		{"missing fails", nil, false, Expectation{Operator: "equals", Value: "no"}, false},
+		{"missing allowed", nil, false, Expectation{Operator: "equals", Value: "no", AllowMissing: true}, true},
+	}
+	for _, tt := range tests {
+		got, err := evaluateExpectation(tt.actual, tt.found, tt.expect)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %v, %v; want %v", tt.name, got, err, tt.want)
+		}
+	}
+
+	for _, expect := range []Expectation{{Operator: "between", Value: "1"}, {Operator: "in", Value: "no"}, {Operator: "matches", Value: "("}, {Operator: "equals"}} {
+		if _, err := evaluateExpectation("x", true, expect); err == nil {
+			t.Errorf("%+v: expected an error", expect)
+		}
+	}
+}
+
+func TestParseControls(t *testing.T) {
+	controls, err := ParseControls([]any{map[string]any{
+		"id":     "5.2.10",
+		"probe":  map[string]any{"type": "config", "filePath": "/etc/ssh/sshd_config", "key": "PermitRootLogin"},
+		"expect": map[string]any{"operator": "equals", "value": "no"},
+	}})
+	if err != nil || len(controls) != 1 || controls[0].Probe.Key != "PermitRootLogin" || controls[0].Expect.Value != "no" {
+		t.Fatalf("unexpected controls %+v, %v", controls, err)
+	}
+	if _, err := ParseControls(nil); err == nil {
+		t.Fatal("missing controls must be rejected")
+	}
+	if _, err := ParseControls("nope"); err == nil {
+		t.Fatal("malformed controls must be rejected")
+	}
+}
+
+func TestParseSystemctlShow(t *testing.T) {
+	if s := parseSystemctlShow("LoadState=not-found\nActiveState=inactive\nUnitFileState=\n"); s.Installed {
+		t.Fatalf("missing unit reported as installed: %+v", s)
+	}
+	if s := parseSystemctlShow("LoadState=loaded\nActiveState=active\nUnitFileState=enabled\n"); s.Status != "running" || s.StartType != "enabled" {
+		t.Fatalf("unexpected state %+v", s)
+	}
+	if s := parseSystemctlShow("LoadState=masked\nActiveState=inactive\nUnitFileState=masked\n"); s.Status != "disabled" {
+		t.Fatalf("masked unit should be disabled: %+v", s)
+	}
+}
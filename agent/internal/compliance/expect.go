@@ -0,0 +1,168 @@
+package compliance
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// evaluateExpectation compares what a probe found with a control's expected
+// value. Numbers compare numerically whatever their JSON or registry type,
+// a boolean expectation accepts 1/0, yes/no and on/off, and strings compare
+// case-insensitively.
+func evaluateExpectation(actual any, found bool, expect Expectation) (bool, error) {
+	switch expect.Operator {
+	case "exists":
+		return found, nil
+	case "absent":
+		return !found, nil
+	}
+	if !found {
+		return expect.AllowMissing, nil
+	}
+
+	switch expect.Operator {
+	case "equals":
+		return valuesEqual(actual, expect.Value)
+	case "not_equals":
+		equal, err := valuesEqual(actual, expect.Value)
+		return !equal, err
+	case "in", "not_in":
+		list, ok := expect.Value.([]any)
+		if !ok {
+			return false, fmt.Errorf("%s needs a list of values", expect.Operator)
+		}
+		member := false
+		for _, candidate := range list {
+			equal, err := valuesEqual(actual, candidate)
+			if err != nil {
+				return false, err
+			}
+			if equal {
+				member = true
+				break
+			}
+		}
+		return member == (expect.Operator == "in"), nil
+	case "gt", "gte", "lt", "lte":
+		a, ok := toNumber(actual)
+		if !ok {
+			return false, nil
+		}
+		b, ok := toNumber(expect.Value)
+		if !ok {
+			return false, fmt.Errorf("%s needs a numeric value", expect.Operator)
+		}
+		switch expect.Operator {
+		case "gt":
+			return a > b, nil
+		case "gte":
+			return a >= b, nil
+		case "lt":
+			return a < b, nil
+		default:
+			return a <= b, nil
+		}
+	case "matches":
+		pattern, ok := expect.Value.(string)
+		if !ok {
+			return false, fmt.Errorf("matches needs a regular expression")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern: %w", err)
+		}
+		return re.MatchString(formatValue(actual)), nil
+	case "mode_at_most":
+		allowed, err := parseMode(expect.Value)
+		if err != nil {
+			return false, err
+		}
+		mode, err := parseMode(actual)
+		if err != nil {
+			return false, nil
+		}
+		// Stricter is fine: the file may not have any bit the mask lacks.
+		return mode&^allowed == 0, nil
+	case "":
+		return false, fmt.Errorf("operator is required")
+	default:
+		return false, fmt.Errorf("unsupported operator: %s", expect.Operator)
+	}
+}
+
+func valuesEqual(actual, expected any) (bool, error) {
+	switch want := expected.(type) {
+	case nil:
+		return false, fmt.Errorf("a value is required")
+	case bool:
+		got, ok := toBool(actual)
+		return ok && got == want, nil
+	}
+	if a, ok := toNumber(actual); ok {
+		if b, ok := toNumber(expected); ok {
+			return a == b, nil
+		}
+	}
+	return strings.EqualFold(strings.TrimSpace(formatValue(actual)), strings.TrimSpace(formatValue(expected))), nil
+}
+
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toBool(v any) (bool, bool) {
+	if b, ok := v.(bool); ok {
+		return b, true
+	}
+	switch strings.ToLower(strings.TrimSpace(formatValue(v))) {
+	case "1", "true", "yes", "on", "enabled":
+		return true, true
+	case "0", "false", "no", "off", "disabled":
+		return false, true
+	}
+	return false, false
+}
+
+func parseMode(v any) (uint64, error) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("mode_at_most needs an octal string such as \"0640\"")
+	}
+	mode, err := strconv.ParseUint(strings.TrimSpace(s), 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid octal mode %q", s)
+	}
+	return mode, nil
+}
+
+func formatValue(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []any:
+		parts := make([]string, len(value))
+		for i, item := range value {
+			parts[i] = formatValue(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	default:
+		return fmt.Sprint(value)
+	}
+}
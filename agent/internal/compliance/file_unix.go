@@ -0,0 +1,21 @@
+//go:build !windows
+
+package compliance
+
+import (
+	"os"
+	"syscall"
+)
+
+func statFile(path string) (fileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	state := fileState{Mode: info.Mode(), UID: -1, GID: -1}
+	if sys, ok := info.Sys().(*syscall.Stat_t); ok {
+		state.UID = int(sys.Uid)
+		state.GID = int(sys.Gid)
+	}
+	return state, nil
+}
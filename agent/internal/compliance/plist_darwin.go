@@ -0,0 +1,30 @@
+//go:build darwin
+
+package compliance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// readPlistValue uses defaults, which takes a plist path or a preferences
+// domain and prints booleans as 1 and 0.
+func readPlistValue(path, key string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "defaults", "read", path, key).CombinedOutput()
+	text := strings.TrimSpace(string(output))
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && strings.Contains(text, "does not exist") {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("defaults read %s %s: %w", path, key, err)
+	}
+	return text, true, nil
+}
@@ -0,0 +1,9 @@
+//go:build !darwin
+
+package compliance
+
+import "fmt"
+
+func readPlistValue(string, string) (string, bool, error) {
+	return "", false, fmt.Errorf("plist: %w", errNotApplicable)
+}
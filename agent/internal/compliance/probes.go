@@ -0,0 +1,185 @@
+package compliance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/breeze-rmm/agent/internal/collectors"
+)
+
+// plistDomainPattern matches preferences domains such as
+// com.apple.screensaver, which defaults resolves to a plist itself.
+var plistDomainPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+type fileState struct {
+	Mode os.FileMode
+	UID  int
+	GID  int
+}
+
+type serviceState struct {
+	Installed bool
+	// Status is running, stopped or disabled (stopped and not allowed to
+	// start).
+	Status    string
+	StartType string
+}
+
+// probe reads the value a control is about. found is false when the value
+// is not set: a missing registry value, config key, sysctl, file, service
+// or plist key.
+func (e *Evaluator) probe(p Probe) (actual any, found bool, err error) {
+	switch p.Type {
+	case "registry":
+		if e.goos != "windows" {
+			return nil, false, errNotApplicable
+		}
+		if strings.TrimSpace(p.RegistryPath) == "" || strings.TrimSpace(p.ValueName) == "" {
+			return nil, false, errors.New("registry probe needs registryPath and valueName")
+		}
+		entries, err := e.policyState.CollectRegistryState([]collectors.RegistryProbe{{RegistryPath: p.RegistryPath, ValueName: p.ValueName}})
+		if err != nil {
+			return nil, false, err
+		}
+		if len(entries) == 0 {
+			return nil, false, nil
+		}
+		return entries[0].ValueData, true, nil
+
+	case "config":
+		probe := collectors.ConfigProbe{FilePath: p.FilePath, ConfigKey: p.Key}
+		if !collectors.IsAllowedConfigProbe(probe) {
+			return nil, false, fmt.Errorf("config probe %s %s is not allowed", p.FilePath, p.Key)
+		}
+		entries, err := e.policyState.CollectConfigState([]collectors.ConfigProbe{probe})
+		if err != nil {
+			return nil, false, err
+		}
+		if len(entries) == 0 {
+			return nil, false, nil
+		}
+		return entries[0].ConfigValue, true, nil
+
+	case "sysctl":
+		if e.goos != "linux" {
+			return nil, false, errNotApplicable
+		}
+		if strings.TrimSpace(p.Key) == "" {
+			return nil, false, errors.New("sysctl probe needs key")
+		}
+		entries, err := e.policyState.CollectSysctlState([]string{p.Key})
+		if err != nil {
+			return nil, false, err
+		}
+		if len(entries) == 0 {
+			return nil, false, nil
+		}
+		return entries[0].Value, true, nil
+
+	case "file":
+		if !filepath.IsAbs(p.FilePath) || filepath.Clean(p.FilePath) != p.FilePath {
+			return nil, false, fmt.Errorf("file probe needs a clean absolute filePath, got %q", p.FilePath)
+		}
+		state, err := e.statFile(p.FilePath)
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		switch p.Attribute {
+		case "", "mode":
+			return fmt.Sprintf("%04o", state.Mode.Perm()), true, nil
+		case "uid":
+			return state.UID, true, nil
+		case "gid":
+			return state.GID, true, nil
+		default:
+			return nil, false, fmt.Errorf("unsupported file attribute: %s", p.Attribute)
+		}
+
+	case "service":
+		if strings.TrimSpace(p.Service) == "" {
+			return nil, false, errors.New("service probe needs service")
+		}
+		state, err := e.service(p.Service)
+		if err != nil {
+			return nil, false, err
+		}
+		if !state.Installed {
+			return nil, false, nil
+		}
+		switch p.Attribute {
+		case "", "status":
+			return state.Status, true, nil
+		case "startType":
+			if state.StartType == "" {
+				return nil, false, fmt.Errorf("service start type: %w", errNotApplicable)
+			}
+			return state.StartType, true, nil
+		default:
+			return nil, false, fmt.Errorf("unsupported service attribute: %s", p.Attribute)
+		}
+
+	case "plist":
+		if e.goos != "darwin" {
+			return nil, false, errNotApplicable
+		}
+		validPath := (filepath.IsAbs(p.FilePath) && filepath.Clean(p.FilePath) == p.FilePath && strings.HasSuffix(p.FilePath, ".plist")) ||
+			plistDomainPattern.MatchString(p.FilePath)
+		if !validPath || strings.TrimSpace(p.Key) == "" {
+			return nil, false, errors.New("plist probe needs a .plist filePath or preferences domain, and key")
+		}
+		value, found, err := e.plistValue(p.FilePath, p.Key)
+		if err != nil || !found {
+			return nil, false, err
+		}
+		return value, true, nil
+
+	case "":
+		return nil, false, errors.New("probe type is required")
+	default:
+		return nil, false, fmt.Errorf("unsupported probe type: %s", p.Type)
+	}
+}
+
+// probeIsSensitive keeps values that may be secrets out of the report; the
+// control is still evaluated against them.
+func probeIsSensitive(p Probe) bool {
+	for _, name := range []string{p.ValueName, p.Key} {
+		lower := strings.ToLower(name)
+		if name != "" && (collectors.IsSensitiveConfigKey(name) || strings.Contains(lower, "password") || strings.Contains(lower, "passwd")) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSystemctlShow reads `systemctl show -p LoadState,ActiveState,UnitFileState`.
+func parseSystemctlShow(output string) serviceState {
+	props := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			props[key] = value
+		}
+	}
+	if props["LoadState"] == "" || props["LoadState"] == "not-found" {
+		return serviceState{}
+	}
+
+	state := serviceState{Installed: true, StartType: props["UnitFileState"]}
+	switch props["ActiveState"] {
+	case "active", "activating", "reloading":
+		state.Status = "running"
+	default:
+		state.Status = "stopped"
+		if props["UnitFileState"] == "disabled" || props["UnitFileState"] == "masked" || props["LoadState"] == "masked" {
+			state.Status = "disabled"
+		}
+	}
+	return state
+}
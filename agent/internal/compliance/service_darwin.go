@@ -0,0 +1,21 @@
+//go:build darwin
+
+package compliance
+
+import (
+	"strings"
+
+	"github.com/breeze-rmm/agent/internal/svcquery"
+)
+
+// launchd has no start type to report, so only status applies here.
+func getServiceState(name string) (serviceState, error) {
+	info, err := svcquery.GetStatus(name)
+	if err != nil && strings.HasSuffix(err.Error(), "not found") {
+		return serviceState{}, nil
+	}
+	if err != nil {
+		return serviceState{}, err
+	}
+	return serviceState{Installed: true, Status: string(info.Status)}, nil
+}
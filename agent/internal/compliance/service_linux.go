@@ -0,0 +1,17 @@
+//go:build linux
+
+package compliance
+
+import (
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/security"
+)
+
+func getServiceState(name string) (serviceState, error) {
+	output, err := security.RunCommand(5*time.Second, "systemctl", "show", "-p", "LoadState,ActiveState,UnitFileState", "--", name)
+	if err != nil {
+		return serviceState{}, err
+	}
+	return parseSystemctlShow(output), nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows && !linux && !darwin
+
+package compliance
+
+import "fmt"
+
+func getServiceState(string) (serviceState, error) {
+	return serviceState{}, fmt.Errorf("service state: %w", errNotApplicable)
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package compliance
+
+import (
+	"errors"
+
+	"github.com/breeze-rmm/agent/internal/svcquery"
+	"golang.org/x/sys/windows"
+)
+
+func getServiceState(name string) (serviceState, error) {
+	info, err := svcquery.GetStatus(name)
+	if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+		return serviceState{}, nil
+	}
+	if err != nil {
+		return serviceState{}, err
+	}
+	state := serviceState{Installed: true, Status: string(info.Status), StartType: info.StartType}
+	if info.Status != svcquery.StatusRunning && info.StartType == "disabled" {
+		state.Status = string(svcquery.StatusDisabled)
+	}
+	return state, nil
+}
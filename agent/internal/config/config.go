@@ -42,6 +42,33 @@ type PolicyConfigStateProbe struct {
 	ConfigKey string `mapstructure:"config_key"`
 }
 
+// CollectorSchedule overrides the cadence of one inventory collector.
+// IntervalMinutes ≤0 keeps the built-in default; Enabled nil keeps it on.
+type CollectorSchedule struct {
+	Enabled         *bool `mapstructure:"enabled"`
+	IntervalMinutes int   `mapstructure:"interval_minutes"`
+}
+
+// BackupWindowConfig is one backup_windows entry: the backup may upload
+// from Start ("HH:MM") for DurationMinutes on each of Days (empty = every
+// day) in Timezone (IANA; empty = local time).
+type BackupWindowConfig struct {
+	Days            []string `mapstructure:"days"`
+	Start           string   `mapstructure:"start"`
+	DurationMinutes int      `mapstructure:"duration_minutes"`
+	Timezone        string   `mapstructure:"timezone"`
+}
+
+// BackupPathPolicyConfig is one backup_path_policies entry: a root backed
+// up with its own include/exclude globs and, when RetentionDays > 0, dropped
+// from snapshots older than that many days.
+type BackupPathPolicyConfig struct {
+	Path          string   `mapstructure:"path"`
+	Include       []string `mapstructure:"include"`
+	Exclude       []string `mapstructure:"exclude"`
+	RetentionDays int      `mapstructure:"retention_days"`
+}
+
 type Config struct {
 	AgentID   string `mapstructure:"agent_id"`
 	ServerURL string `mapstructure:"server_url"`
@@ -66,23 +93,72 @@ type Config struct {
 	HeartbeatIntervalSeconds     int    `mapstructure:"heartbeat_interval_seconds"`
 	MetricsIntervalSeconds       int    `mapstructure:"metrics_interval_seconds"`
 	ProcessSampleIntervalSeconds int    `mapstructure:"process_sample_interval_seconds"`
+	// ProcessInventoryIntervalMinutes is the cadence of the full running-process
+	// inventory (path, hash, signature). Clamped to [15, 1440] at the use site.
+	ProcessInventoryIntervalMinutes int `mapstructure:"process_inventory_interval_minutes"`
 	// PatchScanIntervalHours is the cadence of the (expensive) patch scan, in
 	// hours. Clamped to [1, 168] at the use site; defaults to DefaultPatchScanIntervalHours.
-	PatchScanIntervalHours   int      `mapstructure:"patch_scan_interval_hours"`
-	EnabledCollectors        []string `mapstructure:"enabled_collectors"`
-	BackupEnabled            bool     `mapstructure:"backup_enabled"`
-	BackupPaths              []string `mapstructure:"backup_paths"`
-	BackupRetention          int      `mapstructure:"backup_retention"`
-	BackupProvider           string   `mapstructure:"backup_provider"`
-	BackupLocalPath          string   `mapstructure:"backup_local_path"`
-	BackupS3Bucket           string   `mapstructure:"backup_s3_bucket"`
-	BackupS3Region           string   `mapstructure:"backup_s3_region"`
-	BackupS3AccessKey        string   `mapstructure:"backup_s3_access_key"`
-	BackupS3SecretKey        string   `mapstructure:"backup_s3_secret_key"`
-	BackupVSSEnabled         bool     `mapstructure:"backup_vss_enabled"`          // Windows: VSS shadow copy before backup
-	BackupSystemStateEnabled bool     `mapstructure:"backup_system_state_enabled"` // Collect system state alongside file backup
-	BackupBinaryPath         string   `mapstructure:"backup_binary_path"`          // Path to breeze-backup helper binary
-	BackupStagingDir         string   `mapstructure:"backup_staging_dir"`          // Staging directory for Hyper-V exports, MSSQL backups, etc. (empty = OS temp dir)
+	PatchScanIntervalHours int      `mapstructure:"patch_scan_interval_hours"`
+	EnabledCollectors      []string `mapstructure:"enabled_collectors"`
+	// CollectorSchedules overrides per-collector cadence, keyed by collector
+	// name (software, patches, event_logs, ...). The server can replace it
+	// with a collector_schedules config update.
+	CollectorSchedules map[string]CollectorSchedule `mapstructure:"collector_schedules"`
+	// WiFiScanNearby adds the nearby networks from the OS's last scan to the
+	// Wi-Fi inventory. Off by default; the server can toggle it per device.
+	WiFiScanNearby bool `mapstructure:"wifi_scan_nearby"`
+	// SoftwareUsageMetering has user helpers meter foreground application
+	// time for license reclamation. Off by default; the server can toggle it
+	// per device.
+	SoftwareUsageMetering bool `mapstructure:"software_usage_metering"`
+	// GeolocationEnabled reports the device's approximate location with each
+	// heartbeat (lost/stolen laptop recovery). Off by default; the server
+	// turns it on per device only when org or site policy allows it.
+	GeolocationEnabled bool `mapstructure:"geolocation_enabled"`
+	// GeolocationLookupURL is the Wi-Fi/IP geolocation service used when the
+	// OS has no location fix. Empty uses the built-in default.
+	GeolocationLookupURL string `mapstructure:"geolocation_lookup_url"`
+	// RegistryWatchKeys lists the Windows registry keys watched for
+	// real-time changes (a trailing `\*` watches the subtree). Empty uses
+	// collectors.DefaultRegistryWatchKeys.
+	RegistryWatchKeys            []string             `mapstructure:"registry_watch_keys"`
+	BackupEnabled                bool                 `mapstructure:"backup_enabled"`
+	BackupPaths                  []string             `mapstructure:"backup_paths"`
+	BackupRetention              int                  `mapstructure:"backup_retention"`
+	BackupProvider               string               `mapstructure:"backup_provider"`
+	BackupLocalPath              string               `mapstructure:"backup_local_path"`
+	BackupS3Bucket               string               `mapstructure:"backup_s3_bucket"`
+	BackupS3Region               string               `mapstructure:"backup_s3_region"`
+	BackupS3AccessKey            string               `mapstructure:"backup_s3_access_key"`
+	BackupS3SecretKey            string               `mapstructure:"backup_s3_secret_key"`
+	BackupAzureAccount           string               `mapstructure:"backup_azure_account"`
+	BackupAzureContainer         string               `mapstructure:"backup_azure_container"`
+	BackupAzureAccessKey         string               `mapstructure:"backup_azure_access_key"` // Storage account access key
+	BackupGCSBucket              string               `mapstructure:"backup_gcs_bucket"`
+	BackupGCSCredentialsFile     string               `mapstructure:"backup_gcs_credentials_file"` // Service account JSON key file
+	BackupB2Bucket               string               `mapstructure:"backup_b2_bucket"`
+	BackupB2KeyID                string               `mapstructure:"backup_b2_key_id"`
+	BackupB2SecretKey            string               `mapstructure:"backup_b2_secret_key"` // B2 application key
+	BackupSFTPHost               string               `mapstructure:"backup_sftp_host"`
+	BackupSFTPPort               int                  `mapstructure:"backup_sftp_port"`
+	BackupSFTPUsername           string               `mapstructure:"backup_sftp_username"`
+	BackupSFTPPassword           string               `mapstructure:"backup_sftp_password"`
+	BackupSFTPPrivateKeyFile     string               `mapstructure:"backup_sftp_private_key_file"`
+	BackupSFTPHostKeyFingerprint string               `mapstructure:"backup_sftp_host_key_fingerprint"` // SHA256:... from ssh-keygen -lf
+	BackupSFTPPath               string               `mapstructure:"backup_sftp_path"`
+	BackupWebDAVURL              string               `mapstructure:"backup_webdav_url"`
+	BackupWebDAVUsername         string               `mapstructure:"backup_webdav_username"`
+	BackupWebDAVPassword         string               `mapstructure:"backup_webdav_password"`
+	BackupWebDAVTLSFingerprint   string               `mapstructure:"backup_webdav_tls_fingerprint"` // hex SHA-256 of the server certificate
+	BackupBandwidthLimitKBps     int                  `mapstructure:"backup_bandwidth_limit_kbps"`   // Backup upload cap in KB/s, also SFTP/WebDAV restores (0 = unlimited)
+	BackupWindows                []BackupWindowConfig `mapstructure:"backup_windows"`                // When backups may upload (empty = any time)
+	BackupVSSEnabled             bool                 `mapstructure:"backup_vss_enabled"`            // Windows: VSS shadow copy before backup
+	BackupSystemStateEnabled     bool                 `mapstructure:"backup_system_state_enabled"`   // Collect system state alongside file backup
+	BackupBinaryPath             string               `mapstructure:"backup_binary_path"`            // Path to breeze-backup helper binary
+	BackupStagingDir             string               `mapstructure:"backup_staging_dir"`            // Staging directory for Hyper-V exports, MSSQL backups, etc. (empty = OS temp dir)
+	// BackupPathPolicies are extra roots backed up alongside backup_paths,
+	// each with its own include/exclude globs and retention in days.
+	BackupPathPolicies []BackupPathPolicyConfig `mapstructure:"backup_path_policies"`
 
 	// Local vault (SMB share / USB drive) configuration
 	VaultEnabled        bool   `mapstructure:"vault_enabled"`
@@ -107,6 +183,38 @@ type Config struct {
 	// relay TURN, disconnect timeout, etc.) ship regardless.
 	DesktopDebug bool `mapstructure:"desktop_debug"`
 
+	// RemoteIdleTimeoutMinutes stops terminal sessions, and desktop sessions
+	// whose start_desktop carried no idle limit, after this long without
+	// viewer input. Forgotten sessions otherwise hold the GPU encoder and
+	// suppressed wallpaper indefinitely. 0 disables. Default 60.
+	RemoteIdleTimeoutMinutes int `mapstructure:"remote_idle_timeout_minutes"`
+
+	// TerminalDetachGraceSeconds is how long a terminal session whose viewer
+	// disconnected keeps running, with its output buffered, waiting for a
+	// terminal_attach. 0 makes a detach request stop the session. Default 300.
+	TerminalDetachGraceSeconds int `mapstructure:"terminal_detach_grace_seconds"`
+
+	// TerminalRecording records terminal sessions as asciinema cast files,
+	// uploaded at session end and referenced from the audit log. Default false.
+	TerminalRecording bool `mapstructure:"terminal_recording"`
+
+	// TerminalRecordInput adds viewer keystrokes to terminal recordings.
+	// Off by default: input includes passwords typed at prompts that the
+	// shell does not echo, so only the output stream is recorded.
+	TerminalRecordInput bool `mapstructure:"terminal_record_input"`
+
+	// FileManagerAllowPaths limits the remote file manager (file_* commands)
+	// to these absolute path prefixes; empty allows every path not denied.
+	// FileManagerDenyPaths are prefixes it may never touch, and win over the
+	// allow list. The server may replace both with a file_path_policy update.
+	FileManagerAllowPaths []string `mapstructure:"file_manager_allow_paths"`
+	FileManagerDenyPaths  []string `mapstructure:"file_manager_deny_paths"`
+
+	// RegistryWriteEnabled allows the registry write commands (set/delete
+	// value, create/delete key). Off by default; the server pushes the
+	// organization's policy as registry_write_enabled.
+	RegistryWriteEnabled bool `mapstructure:"registry_write_enabled"`
+
 	// PAMEnabled gates privileged access management features, including the
 	// dormant local elevation account. Default false.
 	PAMEnabled bool `mapstructure:"pam_enabled"`
@@ -141,6 +249,13 @@ type Config struct {
 	PatchMaintenanceDays       []string `mapstructure:"patch_maintenance_days"`  // ["monday",...] empty=all
 	PatchRebootMaxPerDay       int      `mapstructure:"patch_reboot_max_per_day"`
 	PatchAutoAcceptEula        bool     `mapstructure:"patch_auto_accept_eula"`
+	// PatchSnapshotBeforeInstall takes a system snapshot (restore point,
+	// Timeshift/btrfs/LVM, APFS) before each install_patches command. A
+	// command's createSnapshot field overrides it.
+	PatchSnapshotBeforeInstall bool `mapstructure:"patch_snapshot_before_install"`
+	// PatchStageBandwidthLimitKbps caps patch_stage downloads in KiB/s where
+	// the package manager supports a limit. 0 means unlimited.
+	PatchStageBandwidthLimitKbps int `mapstructure:"patch_stage_bandwidth_limit_kbps"`
 
 	// Policy state telemetry probes for registry/config checks.
 	PolicyRegistryStateProbes []PolicyRegistryStateProbe `mapstructure:"policy_registry_state_probes"`
@@ -224,26 +339,38 @@ func ConfigDir() string {
 // config default and the heartbeat clamp don't drift apart.
 const DefaultPatchScanIntervalHours = 24
 
+// DefaultProcessInventoryIntervalMinutes is the default full process inventory
+// cadence; shared with the heartbeat clamp for the same reason.
+const DefaultProcessInventoryIntervalMinutes = 60
+
 func Default() *Config {
 	return &Config{
-		HeartbeatIntervalSeconds:     60,
-		MetricsIntervalSeconds:       30,
-		ProcessSampleIntervalSeconds: 180,
-		PatchScanIntervalHours:       DefaultPatchScanIntervalHours,
-		EnabledCollectors:            []string{"hardware", "software", "metrics", "network"},
-		LogLevel:                     "info",
-		LogFormat:                    "text",
-		LogFile:                      defaultLogFile(),
-		LogMaxSizeMB:                 50,
-		LogMaxBackups:                3,
-		LogShippingLevel:             "warn",
-		PAMEnabled:                   false,
-		PAMActuatorStrategy:          "sendinput",
-		MaxConcurrentCommands:        10,
-		CommandQueueSize:             100,
-		AuditEnabled:                 true,
-		AuditMaxSizeMB:               50,
-		AuditMaxBackups:              3,
+		HeartbeatIntervalSeconds:        60,
+		MetricsIntervalSeconds:          30,
+		ProcessSampleIntervalSeconds:    180,
+		ProcessInventoryIntervalMinutes: DefaultProcessInventoryIntervalMinutes,
+		PatchScanIntervalHours:          DefaultPatchScanIntervalHours,
+		EnabledCollectors:               []string{"hardware", "software", "metrics", "network"},
+		LogLevel:                        "info",
+		LogFormat:                       "text",
+		LogFile:                         defaultLogFile(),
+		LogMaxSizeMB:                    50,
+		LogMaxBackups:                   3,
+		LogShippingLevel:                "warn",
+		PAMEnabled:                      false,
+		PAMActuatorStrategy:             "sendinput",
+		MaxConcurrentCommands:           10,
+		CommandQueueSize:                100,
+		RemoteIdleTimeoutMinutes:        60,
+		TerminalDetachGraceSeconds:      300,
+		TerminalRecording:               false,
+		TerminalRecordInput:             false,
+		AuditEnabled:                    true,
+		AuditMaxSizeMB:                  50,
+		AuditMaxBackups:                 3,
+		// Back up from a shadow copy so open files (Outlook OSTs, SQLite
+		// DBs) are captured; matches the default for server-dispatched runs.
+		BackupVSSEnabled: runtime.GOOS == "windows",
 
 		AutoUpdate:                 true,
 		PatchExcludeFeatureUpdates: true,
@@ -350,15 +477,27 @@ func Load(cfgFile string) (*Config, error) {
 		if v := sv.GetString("mtls_cert_expires"); v != "" {
 			cfg.MtlsCertExpires = v
 		}
-		// Companion: backup S3 credentials migrate to secrets.yaml via
+		// Companion: backup storage credentials migrate to secrets.yaml via
 		// isSecretYAMLKey; read them back so BackupS3AccessKey/BackupS3SecretKey
-		// are populated after Load (otherwise backup config silently breaks).
+		// etc. are populated after Load (otherwise backup config silently breaks).
 		if v := sv.GetString("backup_s3_access_key"); v != "" {
 			cfg.BackupS3AccessKey = v
 		}
 		if v := sv.GetString("backup_s3_secret_key"); v != "" {
 			cfg.BackupS3SecretKey = v
 		}
+		if v := sv.GetString("backup_azure_access_key"); v != "" {
+			cfg.BackupAzureAccessKey = v
+		}
+		if v := sv.GetString("backup_b2_secret_key"); v != "" {
+			cfg.BackupB2SecretKey = v
+		}
+		if v := sv.GetString("backup_sftp_password"); v != "" {
+			cfg.BackupSFTPPassword = v
+		}
+		if v := sv.GetString("backup_webdav_password"); v != "" {
+			cfg.BackupWebDAVPassword = v
+		}
 	}
 
 	// Validate config: fatals block startup, warnings are logged and continue.
@@ -660,16 +799,28 @@ func SaveTo(cfg *Config, cfgFile string) error {
 	sv.Set("mtls_cert_pem", cfg.MtlsCertPEM)
 	sv.Set("mtls_key_pem", cfg.MtlsKeyPEM)
 	sv.Set("mtls_cert_expires", cfg.MtlsCertExpires)
-	// Backup S3 credentials are caught by isSecretYAMLKey (suffix _access_key /
-	// _secret_key) and stripped from agent.yaml; persist them here so they
-	// survive a round-trip through SaveTo → Load. Only write non-empty values
-	// for the same reason as auth_token above.
+	// Backup storage credentials are caught by isSecretYAMLKey (suffix
+	// _access_key / _secret_key / _password) and stripped from agent.yaml; persist them
+	// here so they survive a round-trip through SaveTo → Load. Only write
+	// non-empty values for the same reason as auth_token above.
 	if cfg.BackupS3AccessKey != "" {
 		sv.Set("backup_s3_access_key", cfg.BackupS3AccessKey)
 	}
 	if cfg.BackupS3SecretKey != "" {
 		sv.Set("backup_s3_secret_key", cfg.BackupS3SecretKey)
 	}
+	if cfg.BackupAzureAccessKey != "" {
+		sv.Set("backup_azure_access_key", cfg.BackupAzureAccessKey)
+	}
+	if cfg.BackupB2SecretKey != "" {
+		sv.Set("backup_b2_secret_key", cfg.BackupB2SecretKey)
+	}
+	if cfg.BackupSFTPPassword != "" {
+		sv.Set("backup_sftp_password", cfg.BackupSFTPPassword)
+	}
+	if cfg.BackupWebDAVPassword != "" {
+		sv.Set("backup_webdav_password", cfg.BackupWebDAVPassword)
+	}
 
 	// Same atomic-write pattern for the secrets file (0600).
 	secretsYAML, err := yaml.Marshal(sv.AllSettings())
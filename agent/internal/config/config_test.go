@@ -500,8 +500,12 @@ func TestIsSecretYAMLKey(t *testing.T) {
 		"mtls_key_pem":        true,
 		"mtls_cert_expires":   true,
 		// Caught by suffix rules (_access_key, _secret_key).
-		"backup_s3_access_key": true,
-		"backup_s3_secret_key": true,
+		"backup_s3_access_key":    true,
+		"backup_s3_secret_key":    true,
+		"backup_azure_access_key": true,
+		"backup_b2_secret_key":    true,
+		"backup_sftp_password":    true,
+		"backup_webdav_password":  true,
 		// Caught by suffix rules (_password, _secret, _token).
 		"smtp_password": true,
 		"some_token":    true,
@@ -509,10 +513,13 @@ func TestIsSecretYAMLKey(t *testing.T) {
 		"helper_auth_token": false,
 		// Non-secret keys that happen to contain "key" or "token" substrings
 		// but don't match any suffix rule.
-		"server_url":       false,
-		"agent_id":         false,
-		"backup_s3_bucket": false,
-		"backup_s3_region": false,
+		"server_url":                       false,
+		"agent_id":                         false,
+		"backup_s3_bucket":                 false,
+		"backup_s3_region":                 false,
+		"backup_b2_key_id":                 false,
+		"backup_gcs_credentials_file":      false,
+		"backup_sftp_host_key_fingerprint": false,
 	}
 	for key, want := range cases {
 		if got := isSecretYAMLKey(key); got != want {
@@ -650,6 +657,54 @@ backup_s3_secret_key: wJalrXUtnFEMI/K7MDENG
 	}
 }
 
+// TestLoadReadsCloudBackupSecrets covers the Azure and B2 credentials, which
+// migrate to secrets.yaml through the same suffix rules as the S3 keys.
+func TestLoadReadsCloudBackupSecrets(t *testing.T) {
+	defer viper.Reset()
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "agent.yaml")
+
+	agentYAML := `
+agent_id: ab3c20eddb470acffd33bbe00f25e0348e89298ab80cece542bb1fbf921e5776
+server_url: https://api.example.test
+backup_provider: azure
+backup_azure_account: acct
+backup_azure_container: backups
+backup_b2_bucket: b2-bucket
+backup_b2_key_id: kid
+backup_gcs_bucket: gcs-bucket
+backup_gcs_credentials_file: /etc/breeze/gcs.json
+`
+	if err := os.WriteFile(cfgPath, []byte(agentYAML), 0o644); err != nil {
+		t.Fatalf("write agent.yaml: %v", err)
+	}
+	secretsYAML := `
+auth_token: brz_agent_cloud
+backup_azure_access_key: azure-account-key
+backup_b2_secret_key: b2-application-key
+`
+	if err := os.WriteFile(filepath.Join(dir, "secrets.yaml"), []byte(secretsYAML), 0o600); err != nil {
+		t.Fatalf("write secrets.yaml: %v", err)
+	}
+
+	loaded, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.BackupAzureAccessKey != "azure-account-key" {
+		t.Fatalf("BackupAzureAccessKey = %q, want azure-account-key", loaded.BackupAzureAccessKey)
+	}
+	if loaded.BackupB2SecretKey != "b2-application-key" {
+		t.Fatalf("BackupB2SecretKey = %q, want b2-application-key", loaded.BackupB2SecretKey)
+	}
+	if loaded.BackupAzureAccount != "acct" || loaded.BackupAzureContainer != "backups" {
+		t.Fatalf("azure account/container = %q/%q", loaded.BackupAzureAccount, loaded.BackupAzureContainer)
+	}
+	if loaded.BackupB2KeyID != "kid" || loaded.BackupGCSCredentialsFile != "/etc/breeze/gcs.json" {
+		t.Fatalf("b2 key id = %q, gcs credentials file = %q", loaded.BackupB2KeyID, loaded.BackupGCSCredentialsFile)
+	}
+}
+
 // TestSaveToFailsWhenSecretsChmodFails verifies Finding #8: SaveTo must return
 // an error (not silently log a warning) when the secrets.yaml chmod enforcement
 // fails. This prevents a race window where secrets.yaml is world-readable.
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"net/url"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -179,6 +180,25 @@ func (c *Config) ValidateTiered() ValidationResult {
 		c.CommandQueueSize = 10000
 	}
 
+	if c.RemoteIdleTimeoutMinutes < 0 {
+		result.Warnings = append(result.Warnings, fmt.Errorf("remote_idle_timeout_minutes %d is negative, clamped to 0 (disabled)", c.RemoteIdleTimeoutMinutes))
+		c.RemoteIdleTimeoutMinutes = 0
+	} else if c.RemoteIdleTimeoutMinutes > 1440 {
+		result.Warnings = append(result.Warnings, fmt.Errorf("remote_idle_timeout_minutes %d exceeds maximum 1440, clamped to 1440", c.RemoteIdleTimeoutMinutes))
+		c.RemoteIdleTimeoutMinutes = 1440
+	}
+
+	if c.TerminalDetachGraceSeconds < 0 {
+		result.Warnings = append(result.Warnings, fmt.Errorf("terminal_detach_grace_seconds %d is negative, clamped to 0 (disabled)", c.TerminalDetachGraceSeconds))
+		c.TerminalDetachGraceSeconds = 0
+	} else if c.TerminalDetachGraceSeconds > 3600 {
+		result.Warnings = append(result.Warnings, fmt.Errorf("terminal_detach_grace_seconds %d exceeds maximum 3600, clamped to 3600", c.TerminalDetachGraceSeconds))
+		c.TerminalDetachGraceSeconds = 3600
+	}
+
+	c.FileManagerAllowPaths = absolutePathsOnly("file_manager_allow_paths", c.FileManagerAllowPaths, &result)
+	c.FileManagerDenyPaths = absolutePathsOnly("file_manager_deny_paths", c.FileManagerDenyPaths, &result)
+
 	// Patch management validation
 	if c.PatchMinDiskSpaceGB != 0 {
 		if c.PatchMinDiskSpaceGB < 0.5 {
@@ -190,6 +210,11 @@ func (c *Config) ValidateTiered() ValidationResult {
 		}
 	}
 
+	if c.PatchStageBandwidthLimitKbps < 0 {
+		result.Warnings = append(result.Warnings, fmt.Errorf("patch_stage_bandwidth_limit_kbps %d is negative, treated as unlimited", c.PatchStageBandwidthLimitKbps))
+		c.PatchStageBandwidthLimitKbps = 0
+	}
+
 	if c.PatchRebootMaxPerDay != 0 {
 		if c.PatchRebootMaxPerDay < 1 {
 			result.Warnings = append(result.Warnings, fmt.Errorf("patch_reboot_max_per_day %d is below minimum 1, clamped to 1", c.PatchRebootMaxPerDay))
@@ -247,6 +272,27 @@ func (c *Config) ValidateTiered() ValidationResult {
 	return result
 }
 
+// absolutePathsOnly drops empty and relative entries from a path list, with
+// a warning for each relative one.
+func absolutePathsOnly(key string, paths []string, result *ValidationResult) []string {
+	if len(paths) == 0 {
+		return paths
+	}
+	out := make([]string, 0, len(paths))
+	for idx, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			result.Warnings = append(result.Warnings, fmt.Errorf("%s[%d] %q is not an absolute path; entry ignored", key, idx, p))
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
 // ValidateBackupServerURL enforces the backup control-plane URL contract:
 // https only, http permitted for loopback hosts, "" means unset (valid).
 func ValidateBackupServerURL(raw string) error {
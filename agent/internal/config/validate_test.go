@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -85,6 +86,63 @@ func TestValidateTieredMetricsIntervalClamping(t *testing.T) {
 	}
 }
 
+func TestValidateTieredRemoteIdleTimeoutClamping(t *testing.T) {
+	cfg := Default()
+	cfg.RemoteIdleTimeoutMinutes = -5
+	if result := cfg.ValidateTiered(); result.HasFatals() {
+		t.Fatalf("clamped idle timeout should be warning: %v", result.Fatals)
+	}
+	if cfg.RemoteIdleTimeoutMinutes != 0 {
+		t.Fatalf("RemoteIdleTimeoutMinutes = %d, want 0", cfg.RemoteIdleTimeoutMinutes)
+	}
+
+	cfg.RemoteIdleTimeoutMinutes = 100000
+	cfg.ValidateTiered()
+	if cfg.RemoteIdleTimeoutMinutes != 1440 {
+		t.Fatalf("RemoteIdleTimeoutMinutes = %d, want 1440", cfg.RemoteIdleTimeoutMinutes)
+	}
+}
+
+func TestValidateTieredTerminalDetachGraceClamping(t *testing.T) {
+	cfg := Default()
+	if cfg.TerminalDetachGraceSeconds != 300 {
+		t.Fatalf("default TerminalDetachGraceSeconds = %d, want 300", cfg.TerminalDetachGraceSeconds)
+	}
+	cfg.TerminalDetachGraceSeconds = -1
+	if result := cfg.ValidateTiered(); result.HasFatals() {
+		t.Fatalf("clamped detach grace should be warning: %v", result.Fatals)
+	}
+	if cfg.TerminalDetachGraceSeconds != 0 {
+		t.Fatalf("TerminalDetachGraceSeconds = %d, want 0", cfg.TerminalDetachGraceSeconds)
+	}
+
+	cfg.TerminalDetachGraceSeconds = 86400
+	cfg.ValidateTiered()
+	if cfg.TerminalDetachGraceSeconds != 3600 {
+		t.Fatalf("TerminalDetachGraceSeconds = %d, want 3600", cfg.TerminalDetachGraceSeconds)
+	}
+}
+
+func TestValidateTieredFileManagerPathsDropRelative(t *testing.T) {
+	abs, err := filepath.Abs("data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Default()
+	cfg.FileManagerAllowPaths = []string{abs, "relative/dir", " "}
+	cfg.FileManagerDenyPaths = []string{"secrets"}
+	result := cfg.ValidateTiered()
+	if result.HasFatals() {
+		t.Fatalf("relative file manager paths should be warnings: %v", result.Fatals)
+	}
+	if len(cfg.FileManagerAllowPaths) != 1 || cfg.FileManagerAllowPaths[0] != abs || len(cfg.FileManagerDenyPaths) != 0 {
+		t.Fatalf("allow = %v, deny = %v", cfg.FileManagerAllowPaths, cfg.FileManagerDenyPaths)
+	}
+	if len(result.Warnings) < 2 {
+		t.Fatalf("expected a warning per relative entry, got %v", result.Warnings)
+	}
+}
+
 func TestValidateTieredConcurrencyClamping(t *testing.T) {
 	cfg := Default()
 	cfg.MaxConcurrentCommands = 0
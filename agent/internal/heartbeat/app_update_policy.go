@@ -0,0 +1,125 @@
+package heartbeat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/patching"
+)
+
+// applyAppUpdatePolicyConfig handles the app_update_policies config update:
+// the full set of per-application update rings for third-party providers.
+// The set is persisted so ring delays and pins survive restarts, and native
+// package-manager pins are reconciled in the background.
+func (h *Heartbeat) applyAppUpdatePolicyConfig(raw any) {
+	if h.appPolicies == nil {
+		return
+	}
+	policies, ok := patching.ParseAppUpdatePolicies(raw)
+	if !ok {
+		log.Warn("ignoring invalid app_update_policies payload: not a list")
+		return
+	}
+
+	changed, err := h.appPolicies.Replace(policies)
+	if err != nil {
+		log.Warn("failed to persist app update policies", "error", err.Error())
+	}
+	if !changed {
+		return
+	}
+	log.Info("applied app update policies", "count", len(policies))
+	go h.syncAppUpdatePins()
+}
+
+// syncAppUpdatePins makes the provider pin lists (winget pin, choco pin,
+// brew pin) match the pin-channel policies. Failures are retried on the next
+// policy change or patch inventory run, since unrecorded pins stay pending.
+func (h *Heartbeat) syncAppUpdatePins() {
+	if h.appPolicies == nil || h.patchMgr == nil {
+		return
+	}
+
+	add, remove := h.appPolicies.PinChanges()
+	for _, pin := range remove {
+		if h.patchMgr.HasProvider(pin.Provider) {
+			if err := h.patchMgr.UnpinPackage(pin.Provider, pin.PackageID); err != nil {
+				log.Warn("failed to remove app pin", "provider", pin.Provider, "packageId", pin.PackageID, "error", err.Error())
+				continue
+			}
+		}
+		if err := h.appPolicies.RecordPin(pin, true); err != nil {
+			log.Warn("failed to persist app pin state", "error", err.Error())
+		}
+	}
+	for _, pin := range add {
+		if !h.patchMgr.HasProvider(pin.Provider) {
+			continue
+		}
+		if err := h.patchMgr.PinPackage(pin.Provider, pin.PackageID, pin.Version); err != nil {
+			log.Warn("failed to apply app pin", "provider", pin.Provider, "packageId", pin.PackageID, "version", pin.Version, "error", err.Error())
+			continue
+		}
+		if err := h.appPolicies.RecordPin(pin, false); err != nil {
+			log.Warn("failed to persist app pin state", "error", err.Error())
+		}
+	}
+}
+
+// appUpdateDecision evaluates an install ID ("provider:packageId") against
+// the app update policies. Providers without policies are always allowed.
+func (h *Heartbeat) appUpdateDecision(installID string) patching.AppUpdateDecision {
+	if h.appPolicies == nil {
+		return patching.AppUpdateDecision{Allowed: true}
+	}
+	provider, packageID, ok := splitPatchID(installID)
+	if !ok {
+		return patching.AppUpdateDecision{Allowed: true}
+	}
+	return h.appPolicies.EvaluateInstall(provider, packageID, time.Now())
+}
+
+// installWithAppPolicy installs installID as the policy decision dictates:
+// the provider's newest version, or an explicit pin / n-1 version. A native
+// pin on the package is lifted for the install and re-applied afterwards.
+func (h *Heartbeat) installWithAppPolicy(installID string, decision patching.AppUpdateDecision, progress patching.ProgressCallback) (patching.InstallResult, error) {
+	if decision.Version == "" {
+		return h.patchMgr.InstallWithProgress(installID, progress)
+	}
+
+	provider, packageID, _ := splitPatchID(installID)
+	if pin, pinned := h.appPolicies.IsPinned(provider, packageID); pinned {
+		if err := h.patchMgr.UnpinPackage(pin.Provider, pin.PackageID); err != nil {
+			return patching.InstallResult{}, fmt.Errorf("lift pin before install: %w", err)
+		}
+		if err := h.appPolicies.RecordPin(pin, true); err != nil {
+			log.Warn("failed to persist app pin state", "error", err.Error())
+		}
+		defer h.syncAppUpdatePins()
+	}
+	return h.patchMgr.InstallVersion(installID, decision.Version)
+}
+
+// reportAppUpdateCompliance records the scan's versions for governed apps and
+// uploads one compliance row per app update policy.
+func (h *Heartbeat) reportAppUpdateCompliance(available []patching.AvailablePatch, installed []patching.InstalledPatch) {
+	if h.appPolicies == nil || len(h.appPolicies.Policies()) == 0 {
+		return
+	}
+
+	now := time.Now()
+	if err := h.appPolicies.Observe(available, installed, now); err != nil {
+		log.Warn("failed to persist app update version history", "error", err.Error())
+	}
+	h.syncAppUpdatePins()
+
+	rows := h.appPolicies.Compliance(available, installed, now)
+	h.sendInventoryData(
+		"patches/app-compliance",
+		map[string]any{
+			"apps":        rows,
+			"collectedAt": now.UTC(),
+		},
+		fmt.Sprintf("app update compliance (%d)", len(rows)),
+	)
+}
@@ -0,0 +1,213 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/breeze-rmm/agent/internal/audit"
+	"github.com/breeze-rmm/agent/internal/ipc"
+	"github.com/breeze-rmm/agent/internal/sessionbroker"
+)
+
+// maxChatTranscriptMessages bounds the transcript kept per desktop session.
+// Later lines are still relayed but only counted.
+const maxChatTranscriptMessages = 500
+
+// chatTranscript is the chat history of one desktop session.
+type chatTranscript struct {
+	messages []ipc.ChatMessage
+	dropped  int
+}
+
+// chatTranscripts holds the transcript of every desktop session that has
+// chatted, until the disconnect path writes it to the audit log. Keyed by
+// desktop session ID.
+var (
+	chatTranscriptsMu sync.Mutex
+	chatTranscripts   = map[string]*chatTranscript{}
+)
+
+func recordChatMessage(msg ipc.ChatMessage) {
+	chatTranscriptsMu.Lock()
+	defer chatTranscriptsMu.Unlock()
+	t := chatTranscripts[msg.SessionID]
+	if t == nil {
+		t = &chatTranscript{}
+		chatTranscripts[msg.SessionID] = t
+	}
+	if len(t.messages) >= maxChatTranscriptMessages {
+		t.dropped++
+		return
+	}
+	t.messages = append(t.messages, msg)
+}
+
+// chatStarted reports whether the technician has chatted in the session.
+// End-user replies are only accepted once they have.
+func chatStarted(sessionID string) bool {
+	chatTranscriptsMu.Lock()
+	defer chatTranscriptsMu.Unlock()
+	return chatTranscripts[sessionID] != nil
+}
+
+func takeChatTranscript(sessionID string) *chatTranscript {
+	chatTranscriptsMu.Lock()
+	defer chatTranscriptsMu.Unlock()
+	t := chatTranscripts[sessionID]
+	delete(chatTranscripts, sessionID)
+	return t
+}
+
+// sanitizeChatMessage validates a chat line from a helper, trimming the text
+// and stamping the time when the sender left it out.
+func sanitizeChatMessage(msg ipc.ChatMessage) (ipc.ChatMessage, bool) {
+	if !desktopSessionIDPattern.MatchString(msg.SessionID) {
+		return msg, false
+	}
+	if msg.From != ipc.ChatFromTechnician && msg.From != ipc.ChatFromUser {
+		return msg, false
+	}
+	msg.Text = strings.TrimSpace(msg.Text)
+	if msg.Text == "" || len(msg.Text) > ipc.MaxChatMessageBytes || !utf8.ValidString(msg.Text) {
+		return msg, false
+	}
+	if msg.SentAtUnixMs <= 0 {
+		msg.SentAtUnixMs = time.Now().UnixMilli()
+	}
+	return msg, true
+}
+
+// handleChatFromHelper routes a chat_message from a helper. Technician lines
+// must come from the helper that owns the desktop session; user replies must
+// come from a consent-UI helper and only for a session the technician has
+// chatted in.
+func (h *Heartbeat) handleChatFromHelper(session *sessionbroker.Session, env *ipc.Envelope) {
+	var msg ipc.ChatMessage
+	if err := json.Unmarshal(env.Payload, &msg); err != nil {
+		log.Warn("invalid chat message payload", "error", err.Error())
+		return
+	}
+	msg, ok := sanitizeChatMessage(msg)
+	if !ok {
+		log.Warn("dropping invalid chat message",
+			"sessionId", msg.SessionID, "from", msg.From, "helperSession", session.SessionID)
+		return
+	}
+	switch msg.From {
+	case ipc.ChatFromTechnician:
+		if owner := h.desktopOwnerSession(msg.SessionID); owner == nil || owner.SessionID != session.SessionID {
+			log.Warn("dropping chat message for non-owned session",
+				"sessionId", msg.SessionID, "helperSession", session.SessionID)
+			return
+		}
+		h.relayChatToUser(msg)
+	case ipc.ChatFromUser:
+		if !session.HasScope(ipc.ScopeConsentUI) && !session.HasScope(ipc.ScopeConsentUIFallback) {
+			log.Warn("dropping chat reply from helper without consent UI scope",
+				"sessionId", msg.SessionID, "helperSession", session.SessionID)
+			return
+		}
+		if !chatStarted(msg.SessionID) {
+			log.Warn("dropping chat reply for a session without chat",
+				"sessionId", msg.SessionID, "helperSession", session.SessionID)
+			return
+		}
+		h.relayChatToTechnician(msg)
+	}
+}
+
+// handleDirectChatMessage is the desktopMgr.OnChatMessage hook for sessions
+// the service captures itself.
+func (h *Heartbeat) handleDirectChatMessage(sessionID, text string) {
+	msg, ok := sanitizeChatMessage(ipc.ChatMessage{
+		SessionID: sessionID,
+		From:      ipc.ChatFromTechnician,
+		Text:      text,
+	})
+	if !ok {
+		return
+	}
+	h.relayChatToUser(msg)
+}
+
+// relayChatToUser records a technician line and shows it to the end user:
+// in the consent-UI helper's chat window when one is connected, else as a
+// plain notification (which cannot take a reply).
+func (h *Heartbeat) relayChatToUser(msg ipc.ChatMessage) {
+	recordChatMessage(msg)
+	if h.sessionBroker == nil {
+		return
+	}
+	if session := h.consentUISession(); session != nil {
+		if err := session.SendNotify("chat-"+randomNotifyID(), ipc.TypeChatMessage, msg); err != nil {
+			log.Warn("failed to send chat message", "sessionId", msg.SessionID, "error", err.Error())
+		}
+		return
+	}
+	session := h.sessionBroker.PreferredSessionWithScope("notify")
+	if session == nil {
+		log.Warn("no helper to show chat message", "sessionId", msg.SessionID)
+		return
+	}
+	req := ipc.NotifyRequest{
+		Title:   "Message from your technician",
+		Body:    msg.Text,
+		Urgency: "normal",
+	}
+	if err := session.SendNotify("chat-notify-"+randomNotifyID(), ipc.TypeNotify, req); err != nil {
+		log.Warn("failed to send chat notification", "sessionId", msg.SessionID, "error", err.Error())
+	}
+}
+
+// relayChatToTechnician records an end-user reply and delivers it to the
+// viewer, through the owning helper or the service's own session.
+func (h *Heartbeat) relayChatToTechnician(msg ipc.ChatMessage) {
+	recordChatMessage(msg)
+	if owner := h.desktopOwnerSession(msg.SessionID); owner != nil {
+		if err := owner.SendNotify("chat-"+randomNotifyID(), ipc.TypeChatMessage, msg); err != nil {
+			log.Warn("failed to relay chat reply", "sessionId", msg.SessionID, "error", err.Error())
+		}
+		return
+	}
+	if h.desktopMgr == nil {
+		return
+	}
+	if err := h.desktopMgr.SendChatMessage(msg.SessionID, msg.From, msg.Text, time.UnixMilli(msg.SentAtUnixMs)); err != nil {
+		log.Warn("failed to deliver chat reply", "sessionId", msg.SessionID, "error", err.Error())
+	}
+}
+
+// chatAuditDetails builds the audit entry for a session's chat transcript.
+func chatAuditDetails(sessionID string, t *chatTranscript) map[string]any {
+	messages := make([]map[string]any, 0, len(t.messages))
+	for _, m := range t.messages {
+		messages = append(messages, map[string]any{
+			"from":         m.From,
+			"text":         m.Text,
+			"sentAtUnixMs": m.SentAtUnixMs,
+		})
+	}
+	details := map[string]any{
+		"sessionId":    sessionID,
+		"messageCount": len(t.messages) + t.dropped,
+		"messages":     messages,
+	}
+	if t.dropped > 0 {
+		details["dropped"] = t.dropped
+	}
+	return details
+}
+
+// recordChatTranscript writes the session's chat transcript to the local
+// audit log and forgets it. Called from the disconnect path; no-op when
+// nobody chatted.
+func (h *Heartbeat) recordChatTranscript(sessionID string) {
+	t := takeChatTranscript(sessionID)
+	if t == nil || h.auditLog == nil {
+		return
+	}
+	h.auditLog.Log(audit.EventRemoteSessionChat, "", chatAuditDetails(sessionID, t))
+}
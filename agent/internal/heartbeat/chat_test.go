@@ -0,0 +1,58 @@
+package heartbeat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/breeze-rmm/agent/internal/ipc"
+)
+
+func TestSanitizeChatMessage(t *testing.T) {
+	msg, ok := sanitizeChatMessage(ipc.ChatMessage{SessionID: "sess-1", From: ipc.ChatFromUser, Text: "  thanks  "})
+	if !ok || msg.Text != "thanks" || msg.SentAtUnixMs == 0 {
+		t.Fatalf("sanitizeChatMessage = %+v, %v", msg, ok)
+	}
+
+	bad := []ipc.ChatMessage{
+		{SessionID: "bad id!", From: ipc.ChatFromUser, Text: "hi"},
+		{SessionID: "sess-1", From: "admin", Text: "hi"},
+		{SessionID: "sess-1", From: ipc.ChatFromTechnician, Text: "   "},
+		{SessionID: "sess-1", From: ipc.ChatFromTechnician, Text: strings.Repeat("x", ipc.MaxChatMessageBytes+1)},
+		{SessionID: "sess-1", From: ipc.ChatFromTechnician, Text: "\xff\xfe"},
+	}
+	for _, m := range bad {
+		if _, ok := sanitizeChatMessage(m); ok {
+			t.Errorf("sanitizeChatMessage(%+v) accepted an invalid message", m)
+		}
+	}
+}
+
+func TestChatTranscript(t *testing.T) {
+	const id = "chat-transcript-test"
+	if chatStarted(id) {
+		t.Fatal("chat started before any message")
+	}
+	for i := 0; i < maxChatTranscriptMessages+3; i++ {
+		recordChatMessage(ipc.ChatMessage{SessionID: id, From: ipc.ChatFromTechnician, Text: "hi", SentAtUnixMs: int64(i)})
+	}
+	if !chatStarted(id) {
+		t.Fatal("chat not started after a technician message")
+	}
+
+	tr := takeChatTranscript(id)
+	if tr == nil || len(tr.messages) != maxChatTranscriptMessages || tr.dropped != 3 {
+		t.Fatalf("transcript = %d messages, %d dropped", len(tr.messages), tr.dropped)
+	}
+	if takeChatTranscript(id) != nil || chatStarted(id) {
+		t.Fatal("transcript not forgotten after take")
+	}
+
+	details := chatAuditDetails(id, tr)
+	if details["sessionId"] != id || details["messageCount"] != maxChatTranscriptMessages+3 || details["dropped"] != 3 {
+		t.Fatalf("chatAuditDetails = %v", details)
+	}
+	msgs, _ := details["messages"].([]map[string]any)
+	if len(msgs) != maxChatTranscriptMessages || msgs[0]["from"] != ipc.ChatFromTechnician || msgs[0]["text"] != "hi" {
+		t.Fatalf("chatAuditDetails messages = %v", msgs[:1])
+	}
+}
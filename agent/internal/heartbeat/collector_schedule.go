@@ -0,0 +1,237 @@
+package heartbeat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/config"
+)
+
+// Collector names accepted in collector_schedules. The first group is the
+// inventory fan-out (see sendInventory); the second are the collectors with
+// their own gates in the heartbeat loop.
+const (
+	collectorSoftware          = "software"
+	collectorDisks             = "disks"
+	collectorNetwork           = "network"
+	collectorChanges           = "changes"
+	collectorConnections       = "connections"
+	collectorProcessNetwork    = "process_network"
+	collectorPolicyState       = "policy_state"
+	collectorWarranty          = "warranty"
+	collectorCertificates      = "certificates"
+	collectorContainers        = "containers"
+	collectorBrowserExtensions = "browser_extensions"
+	collectorUSBDevices        = "usb_devices"
+	collectorBluetoothDevices  = "bluetooth_devices"
+	collectorLicensing         = "licensing"
+	collectorSoftwareUsage     = "software_usage"
+	collectorLocalUsers        = "local_users"
+	collectorWiFi              = "wifi"
+	collectorChangelog         = "changelog"
+	collectorVulnerabilities   = "vulnerabilities"
+
+	collectorEventLogs        = "event_logs"
+	collectorSecurity         = "security"
+	collectorSessions         = "sessions"
+	collectorPosture          = "posture"
+	collectorReliability      = "reliability"
+	collectorHardware         = "hardware"
+	collectorPatches          = "patches"
+	collectorProcessInventory = "process_inventory"
+)
+
+// inventoryInterval is the default cadence of every inventory fan-out entry.
+const inventoryInterval = 15 * time.Minute
+
+// Override bounds: one heartbeat tick to one week.
+const (
+	minCollectorIntervalMinutes = 1
+	maxCollectorIntervalMinutes = 7 * 24 * 60
+)
+
+var knownCollectorSchedules = map[string]bool{
+	collectorSoftware: true, collectorDisks: true, collectorNetwork: true,
+	collectorChanges: true, collectorConnections: true, collectorProcessNetwork: true,
+	collectorPolicyState: true, collectorWarranty: true, collectorCertificates: true,
+	collectorContainers: true, collectorBrowserExtensions: true, collectorUSBDevices: true,
+	collectorBluetoothDevices: true, collectorLicensing: true, collectorSoftwareUsage: true,
+	collectorLocalUsers: true, collectorWiFi: true, collectorChangelog: true, collectorVulnerabilities: true,
+	collectorEventLogs: true, collectorSecurity: true, collectorSessions: true,
+	collectorPosture: true, collectorReliability: true, collectorHardware: true,
+	collectorPatches: true, collectorProcessInventory: true,
+}
+
+// collectorScheduler holds the per-collector overrides and the last run of
+// each inventory fan-out entry. It has its own lock so the heartbeat loop can
+// consult it while holding h.mu. A nil scheduler means "defaults, all on".
+type collectorScheduler struct {
+	mu        sync.Mutex
+	overrides map[string]config.CollectorSchedule
+	lastRun   map[string]time.Time
+}
+
+func newCollectorScheduler(overrides map[string]config.CollectorSchedule) *collectorScheduler {
+	s := &collectorScheduler{lastRun: make(map[string]time.Time)}
+	s.apply(overrides)
+	return s
+}
+
+// apply replaces the overrides and reports whether they changed. Names are
+// case-insensitive; unknown names are kept (a newer server may know more
+// collectors than this agent) but returned so the caller can log them.
+func (s *collectorScheduler) apply(overrides map[string]config.CollectorSchedule) (changed bool, unknown []string) {
+	normalized := make(map[string]config.CollectorSchedule, len(overrides))
+	for name, o := range overrides {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !knownCollectorSchedules[name] {
+			unknown = append(unknown, name)
+		}
+		normalized[name] = o
+	}
+	sort.Strings(unknown)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed = !equalCollectorSchedules(s.overrides, normalized)
+	s.overrides = normalized
+	return changed, unknown
+}
+
+// enabled reports whether the collector may run at all.
+func (s *collectorScheduler) enabled(name string) bool {
+	if s == nil {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.overrides[name]
+	return !ok || o.Enabled == nil || *o.Enabled
+}
+
+// interval returns the collector's cadence: the clamped override when set,
+// otherwise def.
+func (s *collectorScheduler) interval(name string, def time.Duration) time.Duration {
+	if s == nil {
+		return def
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.overrides[name]
+	if !ok || o.IntervalMinutes <= 0 {
+		return def
+	}
+	return time.Duration(clampCollectorIntervalMinutes(o.IntervalMinutes)) * time.Minute
+}
+
+// take reports whether an inventory fan-out entry should run now and, if so,
+// stamps it. force skips the interval check (manual refresh) but never runs
+// a disabled collector.
+func (s *collectorScheduler) take(name string, now time.Time, force bool) bool {
+	if s == nil {
+		return true
+	}
+	if !s.enabled(name) {
+		return false
+	}
+	interval := s.interval(name, inventoryInterval)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.lastRun[name]; ok && !force && now.Sub(last) < interval {
+		return false
+	}
+	s.lastRun[name] = now
+	return true
+}
+
+func clampCollectorIntervalMinutes(minutes int) int {
+	if minutes < minCollectorIntervalMinutes {
+		return minCollectorIntervalMinutes
+	}
+	if minutes > maxCollectorIntervalMinutes {
+		return maxCollectorIntervalMinutes
+	}
+	return minutes
+}
+
+func equalCollectorSchedules(a, b map[string]config.CollectorSchedule) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, x := range a {
+		y, ok := b[name]
+		if !ok || x.IntervalMinutes != y.IntervalMinutes || (x.Enabled == nil) != (y.Enabled == nil) ||
+			(x.Enabled != nil && *x.Enabled != *y.Enabled) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCollectorSchedules decodes a collector_schedules config update: an
+// object of collector name → {enabled, intervalMinutes}. Both camelCase and
+// snake_case field names are accepted, like the rest of ConfigUpdate.
+func parseCollectorSchedules(raw any) (map[string]config.CollectorSchedule, error) {
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		if raw == nil {
+			return map[string]config.CollectorSchedule{}, nil
+		}
+		return nil, fmt.Errorf("expected an object, got %T", raw)
+	}
+	out := make(map[string]config.CollectorSchedule, len(obj))
+	for name, v := range obj {
+		entry, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected an object, got %T", name, v)
+		}
+		var sched config.CollectorSchedule
+		if e, ok := entry["enabled"]; ok && e != nil {
+			b, ok := e.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%s.enabled: expected a boolean", name)
+			}
+			sched.Enabled = &b
+		}
+		iv, ok := entry["intervalMinutes"]
+		if !ok {
+			iv, ok = entry["interval_minutes"]
+		}
+		if ok && iv != nil {
+			n, ok := iv.(float64)
+			if !ok || n != float64(int(n)) {
+				return nil, fmt.Errorf("%s.intervalMinutes: expected an integer", name)
+			}
+			sched.IntervalMinutes = int(n)
+		}
+		out[name] = sched
+	}
+	return out, nil
+}
+
+// applyCollectorScheduleConfig applies a collector_schedules config update.
+// The payload replaces the whole override set; an empty object restores the
+// built-in cadences.
+func (h *Heartbeat) applyCollectorScheduleConfig(raw any) {
+	if h.schedule == nil {
+		return
+	}
+	schedules, err := parseCollectorSchedules(raw)
+	if err != nil {
+		log.Warn("ignoring invalid collector_schedules config update", "error", err.Error())
+		return
+	}
+	changed, unknown := h.schedule.apply(schedules)
+	if len(unknown) > 0 {
+		log.Warn("collector_schedules names collectors this agent does not have", "collectors", strings.Join(unknown, ","))
+	}
+	if changed {
+		h.mu.Lock()
+		h.config.CollectorSchedules = schedules
+		h.mu.Unlock()
+		log.Info("collector schedules updated", "overrides", len(schedules))
+	}
+}
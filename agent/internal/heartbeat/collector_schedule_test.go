@@ -0,0 +1,99 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/config"
+)
+
+func TestCollectorSchedulerTake(t *testing.T) {
+	off := false
+	s := newCollectorScheduler(map[string]config.CollectorSchedule{
+		"Software": {IntervalMinutes: 60},
+		"wifi":     {Enabled: &off},
+	})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if !s.take(collectorSoftware, now, false) || !s.take(collectorDisks, now, false) {
+		t.Fatal("first pass should run every enabled collector")
+	}
+	if s.take(collectorWiFi, now, false) || s.take(collectorWiFi, now, true) {
+		t.Fatal("a disabled collector must not run, even when forced")
+	}
+
+	now = now.Add(20 * time.Minute)
+	if s.take(collectorSoftware, now, false) {
+		t.Error("software override is hourly; should not run after 20 minutes")
+	}
+	if !s.take(collectorDisks, now, false) {
+		t.Error("disks keeps the 15-minute default")
+	}
+	if !s.take(collectorSoftware, now, true) {
+		t.Error("a forced refresh should run software")
+	}
+	now = now.Add(59 * time.Minute)
+	if s.take(collectorSoftware, now, false) {
+		t.Error("a forced run should restart the interval")
+	}
+}
+
+func TestCollectorSchedulerInterval(t *testing.T) {
+	s := newCollectorScheduler(map[string]config.CollectorSchedule{
+		"sessions": {IntervalMinutes: 100000},
+		"security": {IntervalMinutes: 0},
+	})
+	if got := s.interval(collectorSessions, 5*time.Minute); got != maxCollectorIntervalMinutes*time.Minute {
+		t.Errorf("override should be clamped to a week, got %s", got)
+	}
+	if got := s.interval(collectorSecurity, 5*time.Minute); got != 5*time.Minute {
+		t.Errorf("zero interval should keep the default, got %s", got)
+	}
+
+	var nilSched *collectorScheduler
+	if !nilSched.enabled(collectorSoftware) || nilSched.interval(collectorSoftware, time.Hour) != time.Hour || !nilSched.take(collectorSoftware, time.Now(), false) {
+		t.Error("nil scheduler should behave as defaults with everything enabled")
+	}
+}
+
+func TestApplyConfigUpdateCollectorSchedules(t *testing.T) {
+	h := &Heartbeat{config: config.Default()}
+	h.schedule = newCollectorScheduler(h.config.CollectorSchedules)
+
+	var update map[string]any
+	if err := json.Unmarshal([]byte(`{"collectorSchedules":{
+		"software":{"intervalMinutes":60},
+		"patches":{"interval_minutes":120,"enabled":true},
+		"event_logs":{"enabled":false},
+		"quantum_flux":{"enabled":false}
+	}}`), &update); err != nil {
+		t.Fatal(err)
+	}
+	h.applyConfigUpdate(update)
+
+	if got := h.schedule.interval(collectorSoftware, inventoryInterval); got != time.Hour {
+		t.Errorf("software interval = %s, want 1h", got)
+	}
+	if got := h.schedule.interval(collectorPatches, 24*time.Hour); got != 2*time.Hour {
+		t.Errorf("patches interval = %s, want 2h", got)
+	}
+	if h.schedule.enabled(collectorEventLogs) {
+		t.Error("event logs should be disabled")
+	}
+	if len(h.config.CollectorSchedules) != 4 {
+		t.Errorf("config should hold the applied overrides, got %+v", h.config.CollectorSchedules)
+	}
+
+	// Invalid payloads leave the current overrides alone.
+	h.applyConfigUpdate(map[string]any{"collector_schedules": map[string]any{"software": map[string]any{"intervalMinutes": "hourly"}}})
+	if got := h.schedule.interval(collectorSoftware, inventoryInterval); got != time.Hour {
+		t.Errorf("invalid update should be ignored, software interval = %s", got)
+	}
+
+	// An empty object restores the defaults.
+	h.applyConfigUpdate(map[string]any{"collector_schedules": map[string]any{}})
+	if !h.schedule.enabled(collectorEventLogs) || h.schedule.interval(collectorSoftware, inventoryInterval) != inventoryInterval {
+		t.Error("empty collector_schedules should restore defaults")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/breeze-rmm/agent/internal/audit"
 	"github.com/breeze-rmm/agent/internal/ipc"
 	"github.com/breeze-rmm/agent/internal/remote/tools"
 	"github.com/breeze-rmm/agent/internal/sessionbroker"
@@ -231,6 +232,33 @@ func (h *Heartbeat) handleConsentSessionEnd(sessionID string) {
 	}
 }
 
+// consentAuditDetails builds the audit entry for a session's consent outcome.
+// decision is "allowed" or "denied" in consent mode, and "notify_only" or
+// "silent" when policy didn't ask the user; reason is decideConsent's.
+func consentAuditDetails(sessionID string, prompt *ipc.DesktopPrompt, decision, reason string) map[string]any {
+	details := map[string]any{
+		"sessionId": sessionID,
+		"mode":      prompt.Mode,
+		"decision":  decision,
+	}
+	if reason != "" {
+		details["reason"] = reason
+	}
+	if name := derefString(prompt.TechnicianName); name != "" {
+		details["technician"] = name
+	}
+	return details
+}
+
+// recordConsentDecision writes the consent outcome to the local audit log, so
+// the machine itself can show whether its user allowed an attended session.
+func (h *Heartbeat) recordConsentDecision(commandID, sessionID string, prompt *ipc.DesktopPrompt, decision, reason string) {
+	if h.auditLog == nil || prompt == nil {
+		return
+	}
+	h.auditLog.Log(audit.EventRemoteSessionConsent, commandID, consentAuditDetails(sessionID, prompt, decision, reason))
+}
+
 // consentDeniedResult builds the command result the API ingests when consent is
 // not granted. It is returned as a COMPLETED result (not failed) so the
 // agent->WS conversion in HandleCommand carries the marker in the `result`
@@ -73,3 +73,25 @@ func TestBannerLabel(t *testing.T) {
 		})
 	}
 }
+
+func TestConsentAuditDetails(t *testing.T) {
+	name := "Billy"
+	got := consentAuditDetails("sess-1", &ipc.DesktopPrompt{Mode: "consent", TechnicianName: &name}, "denied", "timeout")
+	want := map[string]any{"sessionId": "sess-1", "mode": "consent", "decision": "denied", "reason": "timeout", "technician": "Billy"}
+	if len(got) != len(want) {
+		t.Fatalf("consentAuditDetails = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("consentAuditDetails[%q] = %v, want %v", k, got[k], v)
+		}
+	}
+
+	got = consentAuditDetails("sess-2", &ipc.DesktopPrompt{Mode: "off"}, "silent", "")
+	if _, ok := got["reason"]; ok {
+		t.Fatal("empty reason must be omitted")
+	}
+	if _, ok := got["technician"]; ok {
+		t.Fatal("absent technician must be omitted")
+	}
+}
@@ -11,7 +11,9 @@ import (
 )
 
 // computeDesktopAccess runs an honest capability probe (real connect + auth via
-// x11.ProbeCapture, not a mere resolve) and reports capture capability. mode is
+// x11.ProbeCapture, not a mere resolve) and reports capture capability. A
+// Wayland-only box is capturable when a user helper in that session is
+// connected (see linuxWaylandHelper). mode is
 // 'user_session' (capturable) or 'unavailable' with a typed reason. Never emits
 // 'available' — the API zod mode enum has no .catch and would silently drop the
 // whole object on deployed servers. The probe connects and closes once per call
@@ -25,6 +27,10 @@ func (h *Heartbeat) computeDesktopAccess(_ *collectors.SystemInfo) *DesktopAcces
 
 	reason := "x11_connect_failed"
 	switch {
+	case errors.Is(err, x11.ErrWaylandUnsupported) && h.linuxWaylandHelper() != nil:
+		// Captured through the user helper via xdg-desktop-portal; the
+		// portal asks the user to share the screen on first use.
+		return &DesktopAccessState{Mode: "user_session", CheckedAt: now}
 	case errors.Is(err, x11.ErrWaylandUnsupported):
 		reason = "wayland_unsupported"
 	case errors.Is(err, x11.ErrNoDisplay):
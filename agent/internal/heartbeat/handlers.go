@@ -18,7 +18,7 @@ var handlerRegistry = map[string]CommandHandler{
 	// Process management
 	tools.CmdListProcesses: handleListProcesses,
 	tools.CmdGetProcess:    handleGetProcess,
-	tools.CmdKillProcess:   handleKillProcess,
+	tools.CmdKillProcess:   handleProcessKill,
 
 	// Service management
 	tools.CmdListServices:   handleListServices,
@@ -27,6 +27,12 @@ var handlerRegistry = map[string]CommandHandler{
 	tools.CmdStopService:    handleStopService,
 	tools.CmdRestartService: handleRestartService,
 
+	tools.CmdServiceList:       handleListServices,
+	tools.CmdServiceStart:      handleStartService,
+	tools.CmdServiceStop:       handleStopService,
+	tools.CmdServiceRestart:    handleRestartService,
+	tools.CmdServiceSetStartup: handleSetServiceStartup,
+
 	// Event logs (Windows)
 	tools.CmdEventLogsList:  handleEventLogsList,
 	tools.CmdEventLogsQuery: handleEventLogsQuery,
@@ -48,10 +54,9 @@ var handlerRegistry = map[string]CommandHandler{
 	tools.CmdRegistryDelete:    handleRegistryDelete,
 	tools.CmdRegistryKeyCreate: handleRegistryKeyCreate,
 	tools.CmdRegistryKeyDelete: handleRegistryKeyDelete,
+	tools.CmdRegistryExport:    handleRegistryExport,
 
 	// System
-	tools.CmdReboot:         handleReboot,
-	tools.CmdShutdown:       handleShutdown,
 	tools.CmdLock:           handleLock,
 	tools.CmdRebootSafeMode: handleRebootSafeMode,
 	tools.CmdWakeOnLan:      handleWakeOnLan,
@@ -86,12 +91,18 @@ var handlerRegistry = map[string]CommandHandler{
 	tools.CmdFileTrashPurge:     handleFileTrashPurge,
 	tools.CmdFilesystemAnalysis: handleFilesystemAnalysis,
 	tools.CmdFileListDrives:     handleFileListDrives,
+	tools.CmdFileStat:           handleFileStat,
+	tools.CmdFileMove:           handleFileMove,
+	tools.CmdFileZip:            handleFileZip,
+	tools.CmdFileUnzip:          handleFileUnzip,
+	tools.CmdFileHash:           handleFileHash,
 
 	// Terminal commands
 	tools.CmdTerminalStart:  handleTerminalStart,
 	tools.CmdTerminalData:   handleTerminalData,
 	tools.CmdTerminalResize: handleTerminalResize,
 	tools.CmdTerminalStop:   handleTerminalStop,
+	tools.CmdTerminalAttach: handleTerminalAttach,
 
 	// Log shipping
 	tools.CmdSetLogLevel: handleSetLogLevel,
@@ -133,10 +144,6 @@ func handleGetProcess(_ *Heartbeat, cmd Command) tools.CommandResult {
 	return tools.GetProcess(cmd.Payload)
 }
 
-func handleKillProcess(_ *Heartbeat, cmd Command) tools.CommandResult {
-	return tools.KillProcess(cmd.Payload)
-}
-
 func handleListServices(_ *Heartbeat, cmd Command) tools.CommandResult {
 	return tools.ListServices(cmd.Payload)
 }
@@ -157,6 +164,10 @@ func handleRestartService(_ *Heartbeat, cmd Command) tools.CommandResult {
 	return tools.RestartService(cmd.Payload)
 }
 
+func handleSetServiceStartup(_ *Heartbeat, cmd Command) tools.CommandResult {
+	return tools.SetServiceStartup(cmd.Payload)
+}
+
 func handleEventLogsList(_ *Heartbeat, cmd Command) tools.CommandResult {
 	return tools.ListEventLogs(cmd.Payload)
 }
@@ -205,28 +216,34 @@ func handleRegistryGet(_ *Heartbeat, cmd Command) tools.CommandResult {
 	return tools.GetRegistryValue(cmd.Payload)
 }
 
-func handleRegistrySet(_ *Heartbeat, cmd Command) tools.CommandResult {
-	return tools.SetRegistryValue(cmd.Payload)
-}
-
-func handleRegistryDelete(_ *Heartbeat, cmd Command) tools.CommandResult {
-	return tools.DeleteRegistryValue(cmd.Payload)
+func handleRegistrySet(h *Heartbeat, cmd Command) tools.CommandResult {
+	result := tools.SetRegistryValue(cmd.Payload)
+	h.auditRegistryAction("set_value", cmd, result)
+	return result
 }
 
-func handleRegistryKeyCreate(_ *Heartbeat, cmd Command) tools.CommandResult {
-	return tools.CreateRegistryKey(cmd.Payload)
+func handleRegistryDelete(h *Heartbeat, cmd Command) tools.CommandResult {
+	result := tools.DeleteRegistryValue(cmd.Payload)
+	h.auditRegistryAction("delete_value", cmd, result)
+	return result
 }
 
-func handleRegistryKeyDelete(_ *Heartbeat, cmd Command) tools.CommandResult {
-	return tools.DeleteRegistryKey(cmd.Payload)
+func handleRegistryKeyCreate(h *Heartbeat, cmd Command) tools.CommandResult {
+	result := tools.CreateRegistryKey(cmd.Payload)
+	h.auditRegistryAction("create_key", cmd, result)
+	return result
 }
 
-func handleReboot(_ *Heartbeat, cmd Command) tools.CommandResult {
-	return tools.Reboot(cmd.Payload)
+func handleRegistryExport(h *Heartbeat, cmd Command) tools.CommandResult {
+	result := tools.ExportRegistryKey(cmd.Payload)
+	h.auditRegistryAction("export", cmd, result)
+	return result
 }
 
-func handleShutdown(_ *Heartbeat, cmd Command) tools.CommandResult {
-	return tools.Shutdown(cmd.Payload)
+func handleRegistryKeyDelete(h *Heartbeat, cmd Command) tools.CommandResult {
+	result := tools.DeleteRegistryKey(cmd.Payload)
+	h.auditRegistryAction("delete_key", cmd, result)
+	return result
 }
 
 func handleLock(_ *Heartbeat, cmd Command) tools.CommandResult {
@@ -259,11 +276,13 @@ func handleRefreshInventory(h *Heartbeat, _ Command) tools.CommandResult {
 		go h.sendPatchInventory()
 		go h.sendSecurityStatus()
 		go h.sendSessionInventory()
+		go h.sendProcessInventory()
 		// Reset the daily gates so the scheduler doesn't immediately re-run the
 		// hardware/patch scans right after this manual refresh.
 		h.mu.Lock()
 		h.lastHardwareUpdate = time.Now()
 		h.lastPatchUpdate = time.Now()
+		h.lastProcessInvUpdate = time.Now()
 		h.mu.Unlock()
 	}
 	return tools.NewSuccessResult(map[string]any{
@@ -280,6 +299,7 @@ func handleRefreshInventory(h *Heartbeat, _ Command) tools.CommandResult {
 			"policy_config",
 			"security_status",
 			"apple_warranty",
+			"processes",
 		},
 	}, time.Since(start).Milliseconds())
 }
@@ -344,6 +364,26 @@ func handleFileCopy(_ *Heartbeat, cmd Command) tools.CommandResult {
 	return tools.CopyFile(cmd.Payload)
 }
 
+func handleFileStat(_ *Heartbeat, cmd Command) tools.CommandResult {
+	return tools.StatFile(cmd.Payload)
+}
+
+func handleFileMove(_ *Heartbeat, cmd Command) tools.CommandResult {
+	return tools.MoveFile(cmd.Payload)
+}
+
+func handleFileZip(_ *Heartbeat, cmd Command) tools.CommandResult {
+	return tools.ZipFiles(cmd.Payload)
+}
+
+func handleFileUnzip(_ *Heartbeat, cmd Command) tools.CommandResult {
+	return tools.UnzipFile(cmd.Payload)
+}
+
+func handleFileHash(_ *Heartbeat, cmd Command) tools.CommandResult {
+	return tools.HashFile(cmd.Payload)
+}
+
 func handleFileTrashList(_ *Heartbeat, cmd Command) tools.CommandResult {
 	return tools.TrashList(cmd.Payload)
 }
@@ -366,7 +406,12 @@ func handleFileListDrives(_ *Heartbeat, cmd Command) tools.CommandResult {
 
 func handleTerminalStart(h *Heartbeat, cmd Command) tools.CommandResult {
 	log.Info("handleTerminalStart ENTER", "cmdId", cmd.ID)
-	result := tools.StartTerminal(h.terminalMgr, cmd.Payload, h.sendTerminalOutput)
+	runAs, err := h.terminalCredential(cmd.Payload)
+	if err != nil {
+		log.Warn("terminal run-as context unavailable", "cmdId", cmd.ID, "error", err.Error())
+		return tools.NewErrorResult(err, 0)
+	}
+	result := tools.StartTerminalAs(h.terminalMgr, cmd.Payload, runAs, h.sendTerminalOutput)
 	log.Info("handleTerminalStart EXIT", "cmdId", cmd.ID, "status", result.Status, "error", result.Error)
 	return result
 }
@@ -379,10 +424,21 @@ func handleTerminalResize(h *Heartbeat, cmd Command) tools.CommandResult {
 	return tools.ResizeTerminal(h.terminalMgr, cmd.Payload)
 }
 
+// handleTerminalStop stops a terminal session, or detaches it when the
+// server asks to keep it for a reconnecting viewer ("detach": true) and
+// terminal_detach_grace_seconds allows it.
 func handleTerminalStop(h *Heartbeat, cmd Command) tools.CommandResult {
+	if tools.GetPayloadBool(cmd.Payload, "detach", false) && h.config != nil && h.config.TerminalDetachGraceSeconds > 0 {
+		grace := time.Duration(h.config.TerminalDetachGraceSeconds) * time.Second
+		return tools.DetachTerminal(h.terminalMgr, cmd.Payload, grace)
+	}
 	return tools.StopTerminal(h.terminalMgr, cmd.Payload)
 }
 
+func handleTerminalAttach(h *Heartbeat, cmd Command) tools.CommandResult {
+	return tools.AttachTerminal(h.terminalMgr, cmd.Payload)
+}
+
 func handleCollectBootPerformance(h *Heartbeat, cmd Command) tools.CommandResult {
 	start := time.Now()
 	metrics, err := h.bootCol.Collect()
@@ -0,0 +1,102 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/appdeploy"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+func init() {
+	handlerRegistry[tools.CmdAppDeploy] = handleAppDeploy
+}
+
+// handleAppDeploy installs the payload's catalog apps in turn, or with
+// action "detect" only reports whether each one is present. Install results
+// use the install_patches summary so the server records both the same way.
+func handleAppDeploy(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	apps, err := appdeploy.ParseApps(cmd.Payload)
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	deployer := appdeploy.NewDeployer()
+
+	action := tools.GetPayloadString(cmd.Payload, "action", appdeploy.ActionInstall)
+	switch action {
+	case appdeploy.ActionDetect:
+		return appDetectResult(deployer, apps, time.Since(start))
+	case appdeploy.ActionInstall:
+	default:
+		return tools.NewErrorResult(fmt.Errorf("unsupported app_deploy action %q", action), time.Since(start).Milliseconds())
+	}
+
+	reporter := newPatchInstallReporter(h.patchProgressSender(cmd.ID), false, len(apps))
+	results := make([]map[string]any, 0, len(apps))
+	installedCount, failedCount, skippedCount := 0, 0, 0
+	rebootRequired := false
+	for i, app := range apps {
+		ref := patchCommandRef{ID: app.ID, Source: appdeploy.Source, ExternalID: app.ID, Title: app.Title()}
+		reporter.begin(i, ref, app.ID)
+		result := deployer.Install(app)
+		switch result.Status {
+		case appdeploy.StatusInstalled:
+			installedCount++
+			rebootRequired = rebootRequired || result.RebootRequired
+			reporter.end(i, ref, app.ID, "Installed")
+		case appdeploy.StatusSkipped:
+			skippedCount++
+			reporter.end(i, ref, app.ID, "Skipped: already installed")
+		default:
+			failedCount++
+			reporter.end(i, ref, app.ID, "Install failed: "+result.Error)
+		}
+		log.Info("app deploy result", "commandId", cmd.ID, "app", app.ID, "status", result.Status, "error", result.Error)
+		results = append(results, result.Fields())
+	}
+
+	summary := map[string]any{
+		"success":        failedCount == 0,
+		"installedCount": installedCount,
+		"failedCount":    failedCount,
+		"skippedCount":   skippedCount,
+		"rebootRequired": rebootRequired,
+		"results":        results,
+	}
+	durationMs := time.Since(start).Milliseconds()
+	if failedCount > 0 {
+		stdout, _ := json.Marshal(summary)
+		return tools.CommandResult{
+			Status:     "failed",
+			ExitCode:   1,
+			Stdout:     string(stdout),
+			Error:      fmt.Sprintf("%d app deployments failed", failedCount),
+			DurationMs: durationMs,
+		}
+	}
+	return tools.NewSuccessResult(summary, durationMs)
+}
+
+func appDetectResult(deployer *appdeploy.Deployer, apps []appdeploy.App, elapsed time.Duration) tools.CommandResult {
+	results := make([]map[string]any, 0, len(apps))
+	detectedCount, notDetectedCount := 0, 0
+	for _, app := range apps {
+		result := deployer.Detect(app)
+		switch result.Status {
+		case appdeploy.StatusDetected:
+			detectedCount++
+		case appdeploy.StatusNotDetected:
+			notDetectedCount++
+		}
+		results = append(results, result.Fields())
+	}
+	return tools.NewSuccessResult(map[string]any{
+		"success":          true,
+		"action":           appdeploy.ActionDetect,
+		"detectedCount":    detectedCount,
+		"notDetectedCount": notDetectedCount,
+		"results":          results,
+	}, elapsed.Milliseconds())
+}
@@ -0,0 +1,61 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestHandleAppDeployDetectOnly(t *testing.T) {
+	present := t.TempDir()
+	result := handleAppDeploy(&Heartbeat{}, Command{ID: "cmd-1", Payload: map[string]any{
+		"action": "detect",
+		"apps": []any{
+			map[string]any{"id": "a", "name": "Present", "detectionRules": []any{
+				map[string]any{"type": "file_exists", "path": present},
+			}},
+			map[string]any{"id": "b", "name": "Missing", "detectionRules": []any{
+				map[string]any{"type": "file_exists", "path": filepath.Join(present, "missing")},
+			}},
+		},
+	}})
+	if result.Status != "completed" {
+		t.Fatalf("expected completed, got %s: %s", result.Status, result.Error)
+	}
+
+	var summary struct {
+		DetectedCount    int              `json:"detectedCount"`
+		NotDetectedCount int              `json:"notDetectedCount"`
+		Results          []map[string]any `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(result.Stdout), &summary); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if summary.DetectedCount != 1 || summary.NotDetectedCount != 1 || summary.Results[1]["status"] != "not_detected" {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+}
+
+func TestHandleAppDeployReportsFailuresInPatchSchema(t *testing.T) {
+	result := handleAppDeploy(&Heartbeat{}, Command{ID: "cmd-1", Payload: map[string]any{
+		"apps": []any{map[string]any{"id": "a", "name": "No checksum", "downloadUrl": "https://example.com/a.msi"}},
+	}})
+	if result.Status != "failed" || result.ExitCode != 1 {
+		t.Fatalf("expected failed with exit code 1, got %+v", result)
+	}
+
+	var summary map[string]any
+	if err := json.Unmarshal([]byte(result.Stdout), &summary); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if summary["failedCount"] != float64(1) || summary["success"] != false {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+}
+
+func TestHandleAppDeployRejectsUnknownAction(t *testing.T) {
+	result := handleAppDeploy(&Heartbeat{}, Command{Payload: map[string]any{"action": "uninstall", "id": "a"}})
+	if result.Status != "failed" {
+		t.Fatalf("expected failure for an unknown action, got %+v", result)
+	}
+}
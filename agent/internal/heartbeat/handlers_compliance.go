@@ -0,0 +1,26 @@
+package heartbeat
+
+import (
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/compliance"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+func init() {
+	handlerRegistry[tools.CmdComplianceEvaluate] = handleComplianceEvaluate
+}
+
+// handleComplianceEvaluate checks the server-defined controls in the payload
+// and returns pass/fail per control.
+func handleComplianceEvaluate(_ *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+
+	controls, err := compliance.ParseControls(cmd.Payload["controls"])
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	report := compliance.NewEvaluator().Evaluate(controls)
+	return tools.NewSuccessResult(report, time.Since(start).Milliseconds())
+}
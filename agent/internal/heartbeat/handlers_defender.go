@@ -0,0 +1,125 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/audit"
+	"github.com/breeze-rmm/agent/internal/collectors"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+	"github.com/breeze-rmm/agent/internal/security"
+)
+
+func init() {
+	handlerRegistry[tools.CmdDefenderGetPreferences] = handleDefenderGetPreferences
+	handlerRegistry[tools.CmdDefenderSetPreferences] = handleDefenderSetPreferences
+}
+
+func handleDefenderGetPreferences(_ *Heartbeat, _ Command) tools.CommandResult {
+	start := time.Now()
+	prefs, err := security.GetDefenderPreferences()
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	return tools.NewSuccessResult(prefs, time.Since(start).Milliseconds())
+}
+
+// handleDefenderSetPreferences applies a Defender policy change and records
+// every setting that actually changed, with its before and after value, in
+// the local audit log and as change-tracker records.
+func handleDefenderSetPreferences(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	cmdLog := log.With("commandId", cmd.ID, "commandType", cmd.Type)
+
+	raw, err := json.Marshal(cmd.Payload)
+	if err != nil {
+		return tools.NewErrorResult(fmt.Errorf("marshal payload: %w", err), time.Since(start).Milliseconds())
+	}
+	var change security.DefenderPreferenceChange
+	if err := json.Unmarshal(raw, &change); err != nil {
+		return tools.NewErrorResult(fmt.Errorf("invalid defender preference change: %w", err), time.Since(start).Milliseconds())
+	}
+
+	before, after, setErr := security.SetDefenderPreferences(change)
+	var diffs []security.DefenderPreferenceDiff
+	if before != nil && after != nil {
+		diffs = security.DiffDefenderPreferences(*before, *after)
+	}
+	h.auditDefenderPolicyChange(cmd, change, diffs, setErr)
+
+	if len(diffs) > 0 {
+		records := defenderPolicyChangeRecords(cmd.ID, diffs, time.Now().UTC())
+		go func() {
+			_ = h.sendInventoryData("changes", map[string]any{"changes": records}, fmt.Sprintf("defender policy changes (%d)", len(records)))
+		}()
+	}
+
+	if setErr != nil {
+		return tools.NewErrorResult(setErr, time.Since(start).Milliseconds())
+	}
+
+	notApplied := security.UnappliedDefenderChanges(change, *after)
+	if len(notApplied) > 0 {
+		cmdLog.Warn("defender did not apply requested preferences", "settings", notApplied)
+	}
+	if diffs == nil {
+		diffs = []security.DefenderPreferenceDiff{}
+	}
+	return tools.NewSuccessResult(map[string]any{
+		"before":     before,
+		"after":      after,
+		"changes":    diffs,
+		"notApplied": notApplied,
+	}, time.Since(start).Milliseconds())
+}
+
+// auditDefenderPolicyChange records a Defender policy change, successful or
+// not, with the request and each resulting change.
+func (h *Heartbeat) auditDefenderPolicyChange(cmd Command, change security.DefenderPreferenceChange, diffs []security.DefenderPreferenceDiff, setErr error) {
+	if h.auditLog == nil {
+		return
+	}
+	details := map[string]any{
+		"requested": change,
+		"changes":   diffs,
+		"status":    "completed",
+	}
+	if setErr != nil {
+		details["status"] = "failed"
+		details["error"] = setErr.Error()
+	}
+	h.auditLog.Log(audit.EventDefenderPolicyChange, cmd.ID, details)
+}
+
+// defenderPolicyChangeRecords turns preference diffs into change-tracker
+// records, so console-driven hardening shows up in the device change log
+// alongside drift the tracker detects on its own.
+func defenderPolicyChangeRecords(commandID string, diffs []security.DefenderPreferenceDiff, now time.Time) []collectors.ChangeRecord {
+	records := make([]collectors.ChangeRecord, 0, len(diffs))
+	for _, diff := range diffs {
+		subject := diff.Setting
+		if diff.Item != "" {
+			subject = diff.Setting + ": " + diff.Item
+		}
+		record := collectors.ChangeRecord{
+			Timestamp:    now,
+			ChangeType:   collectors.ChangeTypeDefenderPolicy,
+			ChangeAction: collectors.ChangeAction(diff.Action),
+			Subject:      subject,
+			Details: map[string]any{
+				"setting":   diff.Setting,
+				"commandId": commandID,
+				"source":    "console",
+			},
+		}
+		if diff.Before != nil {
+			record.BeforeValue = map[string]any{"value": diff.Before}
+		}
+		if diff.After != nil {
+			record.AfterValue = map[string]any{"value": diff.After}
+		}
+		records = append(records, record)
+	}
+	return records
+}
@@ -0,0 +1,39 @@
+package heartbeat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/collectors"
+	"github.com/breeze-rmm/agent/internal/security"
+)
+
+func TestDefenderPolicyChangeRecords(t *testing.T) {
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	records := defenderPolicyChangeRecords("cmd-1", []security.DefenderPreferenceDiff{
+		{Setting: "exclusionPath", Item: `C:\Build`, Action: "added", After: `C:\Build`},
+		{Setting: "cloudBlockLevel", Action: "modified", Before: "default", After: "high"},
+	}, now)
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	added := records[0]
+	if added.ChangeType != collectors.ChangeTypeDefenderPolicy || added.ChangeAction != collectors.ChangeActionAdded {
+		t.Fatalf("unexpected record %+v", added)
+	}
+	if added.Subject != `exclusionPath: C:\Build` || added.BeforeValue != nil || added.AfterValue["value"] != `C:\Build` {
+		t.Fatalf("unexpected record %+v", added)
+	}
+	if added.Details["commandId"] != "cmd-1" || !added.Timestamp.Equal(now) {
+		t.Fatalf("unexpected details %+v", added)
+	}
+
+	modified := records[1]
+	if modified.Subject != "cloudBlockLevel" || modified.ChangeAction != collectors.ChangeActionModified {
+		t.Fatalf("unexpected record %+v", modified)
+	}
+	if modified.BeforeValue["value"] != "default" || modified.AfterValue["value"] != "high" {
+		t.Fatalf("before/after not carried: %+v", modified)
+	}
+}
@@ -97,6 +97,8 @@ func serviceUnavailable(command string, start time.Time) tools.CommandResult {
 func init() {
 	handlerRegistry[tools.CmdStartDesktop] = handleStartDesktop
 	handlerRegistry[tools.CmdStopDesktop] = handleStopDesktop
+	handlerRegistry[tools.CmdJoinDesktop] = handleJoinDesktop
+	handlerRegistry[tools.CmdLeaveDesktop] = handleLeaveDesktop
 	handlerRegistry[tools.CmdDesktopStreamStart] = handleDesktopStreamStart
 	handlerRegistry[tools.CmdDesktopStreamStop] = handleDesktopStreamStop
 	handlerRegistry[tools.CmdDesktopInput] = handleDesktopInput
@@ -131,22 +133,7 @@ func handleStartDesktop(h *Heartbeat, cmd Command) tools.CommandResult {
 		}
 	}
 
-	// Parse optional ICE servers from payload
-	var iceServers []desktop.ICEServerConfig
-	if raw, ok := cmd.Payload["iceServers"].([]interface{}); ok {
-		for _, item := range raw {
-			if m, ok := item.(map[string]interface{}); ok {
-				username, _ := m["username"].(string)
-				credential, _ := m["credential"].(string)
-				s := desktop.ICEServerConfig{
-					URLs:       m["urls"],
-					Username:   username,
-					Credential: credential,
-				}
-				iceServers = append(iceServers, s)
-			}
-		}
-	}
+	iceServers := parseDesktopICEServers(cmd.Payload)
 
 	// Parse optional display index (multi-monitor selection)
 	displayIndex := 0
@@ -162,6 +149,9 @@ func handleStartDesktop(h *Heartbeat, cmd Command) tools.CommandResult {
 	}
 
 	policy := parseDesktopSessionPolicy(cmd.Payload)
+	if policy.IdleTimeout == 0 && h.config != nil && h.config.RemoteIdleTimeoutMinutes > 0 {
+		policy.IdleTimeout = time.Duration(h.config.RemoteIdleTimeoutMinutes) * time.Minute
+	}
 
 	// Consent gate (Task 9): when the API attached a `prompt` block in mode
 	// "consent", ask the end user BEFORE starting any capture. A denial (or a
@@ -175,18 +165,40 @@ func handleStartDesktop(h *Heartbeat, cmd Command) tools.CommandResult {
 		if !proceed {
 			log.Info("remote session denied by consent gate",
 				"sessionId", sessionID, "reason", reason)
+			h.recordConsentDecision(cmd.ID, sessionID, prompt, "denied", reason)
 			return consentDeniedResult(sessionID, reason, time.Since(start).Milliseconds())
 		}
+		h.recordConsentDecision(cmd.ID, sessionID, prompt, "allowed", reason)
+	} else if prompt != nil {
+		decision := "silent"
+		if prompt.Mode == "notify" {
+			decision = "notify_only"
+		}
+		h.recordConsentDecision(cmd.ID, sessionID, prompt, decision, "policy")
 	}
 
+	// Fetch TURN credentials only now: a consent prompt can outlast the
+	// short-lived credentials the API put in the payload.
+	iceServers, turnExpiry := h.desktopICEServers(sessionID, iceServers)
+
 	// Route through IPC helper when running headless (no display access).
 	// ScreenCaptureKit requires a GUI session (Aqua) — root daemons on macOS
 	// cannot capture the screen directly even with TCC permission.
-	// Linux is excluded: there is no IPC helper on Linux in Phase 1, so a booted-
-	// headless Linux box must take the direct path (the X11 capturer resolves the
-	// display itself). Never gate Linux on the latched-at-boot headless flag.
-	if (h.isService || h.isHeadless) && h.sessionBroker != nil && runtime.GOOS != "linux" {
+	// Linux X11 sessions are excluded: the root agent mirrors them directly (the
+	// X11 capturer resolves the display itself), so a booted-headless Linux box
+	// must take the direct path. Never gate Linux on the latched-at-boot
+	// headless flag. A Linux Wayland session can only be captured from inside
+	// it (xdg-desktop-portal), so it routes to the user helper whenever one is
+	// connected.
+	viaHelper := (h.isService || h.isHeadless) && runtime.GOOS != "linux"
+	if runtime.GOOS == "linux" {
+		viaHelper = h.linuxWaylandHelper() != nil
+	}
+	if viaHelper && h.sessionBroker != nil {
 		result := h.startDesktopViaHelper(sessionID, offer, iceServers, displayIndex, policy, cmd.Payload)
+		if result.Status == "completed" {
+			h.startTURNRefresh(sessionID, turnExpiry)
+		}
 		if result.Status == "completed" && prompt != nil {
 			h.afterDesktopStart(sessionID, prompt)
 			result = withConsentGranted(result, prompt)
@@ -204,6 +216,7 @@ func handleStartDesktop(h *Heartbeat, cmd Command) tools.CommandResult {
 	if err != nil {
 		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
 	}
+	h.startTURNRefresh(sessionID, turnExpiry)
 	resultData := map[string]any{
 		"sessionId": sessionID,
 		"answer":    answer,
@@ -217,6 +230,27 @@ func handleStartDesktop(h *Heartbeat, cmd Command) tools.CommandResult {
 	return tools.NewSuccessResult(resultData, time.Since(start).Milliseconds())
 }
 
+// parseDesktopICEServers reads the optional iceServers list from a desktop
+// command payload.
+func parseDesktopICEServers(payload map[string]any) []desktop.ICEServerConfig {
+	var iceServers []desktop.ICEServerConfig
+	if raw, ok := payload["iceServers"].([]interface{}); ok {
+		for _, item := range raw {
+			if m, ok := item.(map[string]interface{}); ok {
+				username, _ := m["username"].(string)
+				credential, _ := m["credential"].(string)
+				s := desktop.ICEServerConfig{
+					URLs:       m["urls"],
+					Username:   username,
+					Credential: credential,
+				}
+				iceServers = append(iceServers, s)
+			}
+		}
+	}
+	return iceServers
+}
+
 // parseDesktopSessionPolicy extracts the agent-enforced session policy from a
 // start_desktop payload. Absent clipboard fields default to permissive so an
 // older API that doesn't send them preserves existing behavior; timeouts of 0
@@ -248,6 +282,29 @@ func parseDesktopSessionPolicy(payload map[string]any) desktop.SessionPolicy {
 	if v, ok := payload["maxSessionDurationHours"].(float64); ok && v > 0 {
 		policy.MaxDuration = time.Duration(math.Min(v, maxSessionDurationHours)) * time.Hour
 	}
+	// Only an explicit true restricts; an older API that doesn't send the
+	// field keeps full control.
+	if v, ok := payload["viewOnly"].(bool); ok {
+		policy.ViewOnly = v
+	}
+	// Extra displays to stream alongside displayIndex. Entries that aren't a
+	// valid display index are dropped rather than failing the session.
+	if raw, ok := payload["displays"].([]any); ok {
+		for _, item := range raw {
+			if di, ok := item.(float64); ok && di >= 0 && di <= maxDesktopDisplayIndex && di == float64(int(di)) {
+				policy.ExtraDisplays = append(policy.ExtraDisplays, int(di))
+			}
+		}
+	}
+	// Bandwidth cap for metered sites, clamped into the range the IPC path
+	// accepts. Audio is only restricted by an explicit allowAudio=false.
+	if v, ok := payload["maxBitrateKbps"].(float64); ok && v > 0 {
+		kbps := math.Min(math.Max(v, desktop.MinSessionMaxBitrateKbps), desktop.MaxSessionMaxBitrateKbps)
+		policy.MaxBitrate = int(kbps) * 1000
+	}
+	if v, ok := payload["allowAudio"].(bool); ok {
+		policy.AudioDisabled = !v
+	}
 	return policy
 }
 
@@ -258,6 +315,7 @@ func handleStopDesktop(h *Heartbeat, cmd Command) tools.CommandResult {
 		errResult.DurationMs = time.Since(start).Milliseconds()
 		return *errResult
 	}
+	stopTURNRefresh(sessionID)
 
 	// State-based routing: if an IPC helper actually owns this session, stop it
 	// over IPC; otherwise stop the direct desktopMgr session. Never gate on the
@@ -26,9 +26,10 @@ var spawnGuards sync.Map
 const maxGUIUserUIDs = 64
 
 // ErrLinuxDesktopHelperUnsupported is returned by spawnHelperForDesktop on
-// Linux (and any other non-darwin/non-windows GOOS) until a real Linux
-// desktop-helper spawn branch exists (Phase 2 of the Linux remote-desktop
-// plan). findOrSpawnHelper treats it as terminal — there is nothing to poll for.
+// Linux (and any other non-darwin/non-windows GOOS). The agent never spawns a
+// helper there: Wayland capture uses the user helper the desktop session
+// already autostarted (see linuxWaylandHelper). findOrSpawnHelper treats it
+// as terminal — there is nothing to poll for.
 var ErrLinuxDesktopHelperUnsupported = errors.New("linux desktop-helper not yet supported")
 
 // sessionSpawnMu returns a mutex for the given session key, creating one if needed.
@@ -130,6 +131,7 @@ func (h *Heartbeat) startDesktopViaHelper(sessionID, offer string, iceServers []
 		iceRaw = data
 	}
 
+	filePolicy := tools.CurrentFilePathPolicy()
 	clipHostToViewer := policy.ClipboardHostToViewer
 	clipViewerToHost := policy.ClipboardViewerToHost
 	req := ipc.DesktopStartRequest{
@@ -142,6 +144,12 @@ func (h *Heartbeat) startDesktopViaHelper(sessionID, offer string, iceServers []
 		ClipboardViewerToHost:   &clipViewerToHost,
 		IdleTimeoutMinutes:      int(policy.IdleTimeout / time.Minute),
 		MaxSessionDurationHours: int(policy.MaxDuration / time.Hour),
+		ViewOnly:                policy.ViewOnly,
+		ExtraDisplays:           policy.ExtraDisplays,
+		MaxBitrateKbps:          policy.MaxBitrate / 1000,
+		AudioDisabled:           policy.AudioDisabled,
+		FilePathAllow:           filePolicy.Allow,
+		FilePathDeny:            filePolicy.Deny,
 	}
 
 	// Retry up to 2 times: if the helper crashes during SendCommand, respawn
@@ -324,6 +332,17 @@ func winSessionStillExists(targetSession string) bool {
 	return false
 }
 
+// linuxWaylandHelper returns a connected user helper that can capture a Linux
+// Wayland session through xdg-desktop-portal, or nil. On Linux the broker only
+// grants the desktop scope to user helpers running in a Wayland session, so
+// any capture-capable helper is one.
+func (h *Heartbeat) linuxWaylandHelper() *sessionbroker.Session {
+	if runtime.GOOS != "linux" || h.sessionBroker == nil {
+		return nil
+	}
+	return h.sessionBroker.FindCapableSession("capture", "")
+}
+
 // findOrSpawnHelper locates a capable helper session, spawning one if needed.
 func (h *Heartbeat) findOrSpawnHelper(targetSession string) *sessionbroker.Session {
 	session := h.findActiveHelper(targetSession)
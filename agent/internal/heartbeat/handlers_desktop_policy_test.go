@@ -1,6 +1,7 @@
 package heartbeat
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -44,6 +45,15 @@ func TestParseDesktopSessionPolicy(t *testing.T) {
 				ClipboardViewerToHost: false,
 			},
 		},
+		{
+			name:    "viewOnly parsed",
+			payload: map[string]any{"viewOnly": true},
+			want: desktop.SessionPolicy{
+				ClipboardHostToViewer: true,
+				ClipboardViewerToHost: true,
+				ViewOnly:              true,
+			},
+		},
 		{
 			name: "timeouts parsed from minutes/hours",
 			payload: map[string]any{
@@ -107,12 +117,45 @@ func TestParseDesktopSessionPolicy(t *testing.T) {
 				ClipboardViewerToHost: true,
 			},
 		},
+		{
+			name: "extra displays parsed, invalid entries dropped",
+			payload: map[string]any{
+				"displays": []any{float64(1), float64(-1), float64(2.5), "3", float64(maxDesktopDisplayIndex + 1), float64(2)},
+			},
+			want: desktop.SessionPolicy{
+				ClipboardHostToViewer: true,
+				ClipboardViewerToHost: true,
+				ExtraDisplays:         []int{1, 2},
+			},
+		},
+		{
+			name: "bandwidth cap parsed and clamped, audio disallowed",
+			payload: map[string]any{
+				"maxBitrateKbps": float64(3000),
+				"allowAudio":     false,
+			},
+			want: desktop.SessionPolicy{
+				ClipboardHostToViewer: true,
+				ClipboardViewerToHost: true,
+				MaxBitrate:            3_000_000,
+				AudioDisabled:         true,
+			},
+		},
+		{
+			name:    "bandwidth cap below minimum raised to it",
+			payload: map[string]any{"maxBitrateKbps": float64(10)},
+			want: desktop.SessionPolicy{
+				ClipboardHostToViewer: true,
+				ClipboardViewerToHost: true,
+				MaxBitrate:            desktop.MinSessionMaxBitrateKbps * 1000,
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := parseDesktopSessionPolicy(tt.payload)
-			if got != tt.want {
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Fatalf("parseDesktopSessionPolicy() = %+v, want %+v", got, tt.want)
 			}
 		})
@@ -0,0 +1,139 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/ipc"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+// joinDesktopViewOnly reports whether a join_desktop viewer is read-only.
+// Joining viewers shadow someone else's session, so only an explicit
+// viewOnly=false grants co-control.
+func joinDesktopViewOnly(payload map[string]any) bool {
+	if v, ok := payload["viewOnly"].(bool); ok {
+		return v
+	}
+	return true
+}
+
+// handleJoinDesktop attaches an additional viewer to a running desktop
+// session, sharing its capture and encoder, and returns the viewer's SDP
+// answer. Routed to the helper that owns the session when there is one.
+func handleJoinDesktop(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	sessionID, errResult := requireValidatedDesktopSessionID(cmd.Payload)
+	if errResult != nil {
+		errResult.DurationMs = time.Since(start).Milliseconds()
+		return *errResult
+	}
+	viewerID, _ := cmd.Payload["viewerId"].(string)
+	offer, _ := cmd.Payload["offer"].(string)
+	if offer == "" {
+		return tools.CommandResult{
+			Status:     "failed",
+			Error:      "missing offer",
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+	}
+	if err := validateDesktopSessionID(viewerID); err != nil {
+		return tools.CommandResult{
+			Status:     "failed",
+			Error:      "invalid viewerId",
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+	}
+	viewOnly := joinDesktopViewOnly(cmd.Payload)
+	iceServers := parseDesktopICEServers(cmd.Payload)
+	log.Info("join_desktop command received",
+		"commandId", cmd.ID,
+		"sessionId", sessionID,
+		"viewerId", viewerID,
+		"viewOnly", viewOnly,
+	)
+
+	if session := h.desktopOwnerSession(sessionID); session != nil {
+		var iceRaw json.RawMessage
+		if len(iceServers) > 0 {
+			data, err := json.Marshal(iceServers)
+			if err != nil {
+				return tools.NewErrorResult(fmt.Errorf("failed to marshal ICE servers: %w", err), time.Since(start).Milliseconds())
+			}
+			iceRaw = data
+		}
+		req := ipc.DesktopJoinRequest{
+			SessionID:  sessionID,
+			ViewerID:   viewerID,
+			Offer:      offer,
+			ICEServers: iceRaw,
+			ViewOnly:   viewOnly,
+		}
+		resp, err := session.SendCommand("desk-join-"+sessionID+"-"+viewerID, ipc.TypeDesktopJoin, req, 30*time.Second)
+		if err != nil {
+			return tools.NewErrorResult(fmt.Errorf("IPC desktop_join: %w", err), time.Since(start).Milliseconds())
+		}
+		if resp.Error != "" {
+			return tools.CommandResult{
+				Status:     "failed",
+				Error:      resp.Error,
+				DurationMs: time.Since(start).Milliseconds(),
+			}
+		}
+		var jResp ipc.DesktopJoinResponse
+		if err := json.Unmarshal(resp.Payload, &jResp); err != nil {
+			return tools.NewErrorResult(fmt.Errorf("failed to unmarshal desktop join response: %w", err), time.Since(start).Milliseconds())
+		}
+		return tools.NewSuccessResult(map[string]any{
+			"sessionId": sessionID,
+			"viewerId":  viewerID,
+			"answer":    jResp.Answer,
+		}, time.Since(start).Milliseconds())
+	}
+
+	answer, err := h.desktopMgr.AddViewer(sessionID, viewerID, offer, iceServers, viewOnly)
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	return tools.NewSuccessResult(map[string]any{
+		"sessionId": sessionID,
+		"viewerId":  viewerID,
+		"answer":    answer,
+	}, time.Since(start).Milliseconds())
+}
+
+// handleLeaveDesktop detaches an additional viewer without touching the
+// session or its other viewers.
+func handleLeaveDesktop(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	sessionID, errResult := requireValidatedDesktopSessionID(cmd.Payload)
+	if errResult != nil {
+		errResult.DurationMs = time.Since(start).Milliseconds()
+		return *errResult
+	}
+	viewerID, _ := cmd.Payload["viewerId"].(string)
+	if err := validateDesktopSessionID(viewerID); err != nil {
+		return tools.CommandResult{
+			Status:     "failed",
+			Error:      "invalid viewerId",
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+	}
+
+	if session := h.desktopOwnerSession(sessionID); session != nil {
+		req := ipc.DesktopLeaveRequest{SessionID: sessionID, ViewerID: viewerID}
+		resp, err := session.SendCommand("desk-leave-"+sessionID+"-"+viewerID, ipc.TypeDesktopLeave, req, 10*time.Second)
+		if err != nil {
+			return tools.NewErrorResult(fmt.Errorf("IPC desktop_leave: %w", err), time.Since(start).Milliseconds())
+		}
+		var lResp struct {
+			Left bool `json:"left"`
+		}
+		_ = json.Unmarshal(resp.Payload, &lResp)
+		return tools.NewSuccessResult(map[string]any{"left": lResp.Left}, time.Since(start).Milliseconds())
+	}
+
+	left := h.desktopMgr.RemoveViewer(sessionID, viewerID)
+	return tools.NewSuccessResult(map[string]any{"left": left}, time.Since(start).Milliseconds())
+}
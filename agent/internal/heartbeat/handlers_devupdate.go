@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/breeze-rmm/agent/internal/changelog"
 	"github.com/breeze-rmm/agent/internal/config"
 	"github.com/breeze-rmm/agent/internal/remote/tools"
 	"github.com/breeze-rmm/agent/internal/updater"
@@ -304,6 +305,7 @@ func handleDevUpdateAgent(h *Heartbeat, start time.Time, downloadURL, checksum,
 	// Run the update in a goroutine since UpdateFromURL triggers a restart
 	go func() {
 		h.sendUpdateStatus(version)
+		h.changelog.MarkUpgradePending(h.agentVersion, version, changelog.InitiatorDevUpdate)
 		// dev_push is agent-only — no user-helper swap on this path. If a
 		// future dev-push surface needs to swap a companion binary too, pass
 		// updater.UpdateOptions{UserHelper: ...} here.
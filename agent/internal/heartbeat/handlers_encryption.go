@@ -2,10 +2,14 @@ package heartbeat
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
 
+	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/ipc"
 	"github.com/breeze-rmm/agent/internal/remote/tools"
 	"github.com/breeze-rmm/agent/internal/security"
 )
@@ -13,6 +17,9 @@ import (
 func init() {
 	handlerRegistry[tools.CmdEncryptionCollectKeys] = handleEncryptionCollectKeys
 	handlerRegistry[tools.CmdEncryptionRotateKey] = handleEncryptionRotateKey
+	handlerRegistry[tools.CmdEncryptionEnableBitLocker] = handleEncryptionEnableBitLocker
+	handlerRegistry[tools.CmdEncryptionEnableFileVault] = handleEncryptionEnableFileVault
+	handlerRegistry[tools.CmdEncryptionEscrowLUKS] = handleEncryptionEscrowLUKS
 }
 
 // handleEncryptionCollectKeys re-collects BitLocker recovery keys and pushes a
@@ -82,3 +89,154 @@ func handleEncryptionRotateKey(h *Heartbeat, cmd Command) tools.CommandResult {
 		"volumeMount": key.Mount,
 	}, time.Since(start).Milliseconds())
 }
+
+// handleEncryptionEnableBitLocker turns on BitLocker once the volume's
+// recovery password is escrowed. Escrow happens before encryption starts, so
+// a failed upload leaves the volume untouched instead of parking a key. The
+// PIN arrives decrypted from the server and is not echoed in the result.
+func handleEncryptionEnableBitLocker(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	opts := security.BitLockerEnableOptions{
+		Mount:            tools.GetPayloadString(cmd.Payload, "volumeMount", ""),
+		Protector:        tools.GetPayloadString(cmd.Payload, "protector", ""),
+		PIN:              tools.GetPayloadString(cmd.Payload, "pin", ""),
+		EncryptionMethod: tools.GetPayloadString(cmd.Payload, "encryptionMethod", ""),
+		UsedSpaceOnly:    tools.GetPayloadBool(cmd.Payload, "usedSpaceOnly", false),
+		SkipHardwareTest: tools.GetPayloadBool(cmd.Payload, "skipHardwareTest", false),
+	}
+
+	var escrowed security.RecoveryKey
+	volume, err := security.EnableBitLocker(opts, func(key security.RecoveryKey) error {
+		if err := h.pushRecoveryKeys("rotation", []security.RecoveryKey{key}); err != nil {
+			return err
+		}
+		escrowed = key
+		return nil
+	})
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	result := map[string]any{
+		"enabled":          true,
+		"volumeMount":      volume.Mount,
+		"protectorId":      escrowed.ProtectorID,
+		"volumeStatus":     volume.VolumeStatus,
+		"protectionStatus": volume.ProtectionStatus,
+		"encryptionMethod": volume.EncryptionMethod,
+		"protectors":       volume.Protectors,
+		// The hardware test runs at the next boot; encryption starts after it.
+		"restartRequired": !opts.SkipHardwareTest,
+	}
+	if volume.EncryptionPercentage != nil {
+		result["encryptionPercentage"] = *volume.EncryptionPercentage
+	}
+	return tools.NewSuccessResult(result, time.Since(start).Milliseconds())
+}
+
+// fileVaultDeferralPath is where fdesetup leaves the personal recovery key
+// once a deferred enablement completes. The data directory is root-only.
+func fileVaultDeferralPath() string {
+	return filepath.Join(config.GetDataDir(), "filevault-deferral.plist")
+}
+
+// handleEncryptionEnableFileVault defers FileVault enablement to the next
+// logout or login (only then does macOS have the user's password) and tells
+// the logged-in user through the user helper. The key is escrowed by
+// escrowDeferredFileVaultKey once the user has gone through with it.
+func handleEncryptionEnableFileVault(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	opts := security.FileVaultDeferOptions{
+		MaxDeferrals:   tools.GetPayloadInt(cmd.Payload, "maxDeferrals", 0),
+		PromptAtLogout: tools.GetPayloadBool(cmd.Payload, "promptAtLogout", true),
+	}
+	status, err := security.DeferFileVault(fileVaultDeferralPath(), opts)
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	return tools.NewSuccessResult(map[string]any{
+		"deferred":     status.Deferred,
+		"deferredUser": status.DeferredUser,
+		"userNotified": h.notifyFileVaultDeferral(opts),
+	}, time.Since(start).Milliseconds())
+}
+
+// notifyFileVaultDeferral tells the logged-in user to expect the password
+// prompt. Best effort: without a helper the prompt still appears.
+func (h *Heartbeat) notifyFileVaultDeferral(opts security.FileVaultDeferOptions) bool {
+	if h.sessionBroker == nil {
+		return false
+	}
+	session := h.sessionBroker.PreferredSessionWithScope("notify")
+	if session == nil {
+		return false
+	}
+	when := "log out or log in"
+	if !opts.PromptAtLogout {
+		when = "log in"
+	}
+	req := ipc.NotifyRequest{
+		Title:   "FileVault disk encryption",
+		Body:    fmt.Sprintf("Your organization is turning on FileVault. You will be asked for your password the next time you %s.", when),
+		Urgency: "normal",
+	}
+	if err := session.SendNotify("filevault-notify-"+randomNotifyID(), ipc.TypeNotify, req); err != nil {
+		log.Warn("failed to send filevault notice", "error", err.Error())
+		return false
+	}
+	return true
+}
+
+// escrowDeferredFileVaultKey uploads the recovery key of a completed
+// deferred FileVault enablement and then deletes the file holding it. Runs
+// on the security tick; the file survives a failed upload or an agent
+// restart, so the next tick retries.
+func (h *Heartbeat) escrowDeferredFileVaultKey() {
+	if runtime.GOOS != "darwin" {
+		return
+	}
+	path := fileVaultDeferralPath()
+	key, ok, err := security.ReadDeferredFileVaultKey(path)
+	if err != nil {
+		log.Warn("deferred filevault recovery key unreadable", "error", err.Error())
+		return
+	}
+	if !ok {
+		return
+	}
+	if err := h.pushRecoveryKeys("rotation", []security.RecoveryKey{key}); err != nil {
+		log.Error("filevault enabled but recovery key escrow failed; will retry on next security tick", "error", err.Error())
+		return
+	}
+	if err := os.Remove(path); err != nil {
+		log.Warn("failed to remove escrowed filevault deferral file", "error", err.Error())
+	}
+}
+
+// handleEncryptionEscrowLUKS adds a keyslot with a generated recovery
+// passphrase and escrows it. Both the user's passphrase and the previously
+// escrowed one arrive decrypted from the server and are never echoed.
+func handleEncryptionEscrowLUKS(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	opts := security.LUKSEscrowOptions{
+		Device:            tools.GetPayloadString(cmd.Payload, "device", ""),
+		Passphrase:        tools.GetPayloadString(cmd.Payload, "passphrase", ""),
+		PreviousEscrowKey: tools.GetPayloadString(cmd.Payload, "previousEscrowKey", ""),
+	}
+	result, unescrowed, err := security.AddLUKSEscrowKey(opts, func(key security.RecoveryKey) error {
+		return h.pushRecoveryKeys("rotation", []security.RecoveryKey{key})
+	})
+	if unescrowed.Key != "" {
+		// The keyslot could be neither escrowed nor removed. Park it like a
+		// rotated key so the next security tick retries the upload.
+		h.mu.Lock()
+		h.pendingRecoveryKeys = append(h.pendingRecoveryKeys, unescrowed)
+		h.mu.Unlock()
+		log.Error("luks recovery passphrase added but escrow upload failed — key is parked in memory and will be LOST on agent restart",
+			"device", unescrowed.Mount, "keySlot", unescrowed.ProtectorID)
+	}
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	return tools.NewSuccessResult(result, time.Since(start).Milliseconds())
+}
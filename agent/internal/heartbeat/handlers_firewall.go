@@ -0,0 +1,96 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/audit"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+	"github.com/breeze-rmm/agent/internal/security"
+)
+
+func init() {
+	handlerRegistry[tools.CmdFirewallListRules] = handleFirewallListRules
+	handlerRegistry[tools.CmdFirewallAddRule] = handleFirewallAddRule
+	handlerRegistry[tools.CmdFirewallRemoveRule] = handleFirewallRemoveRule
+	handlerRegistry[tools.CmdFirewallSetProfile] = handleFirewallSetProfile
+}
+
+func handleFirewallListRules(_ *Heartbeat, _ Command) tools.CommandResult {
+	start := time.Now()
+	state, err := security.ListFirewallRules()
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	return tools.NewSuccessResult(state, time.Since(start).Milliseconds())
+}
+
+func handleFirewallAddRule(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	raw, err := json.Marshal(cmd.Payload)
+	if err != nil {
+		return tools.NewErrorResult(fmt.Errorf("marshal payload: %w", err), time.Since(start).Milliseconds())
+	}
+	var spec security.FirewallRuleSpec
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return tools.NewErrorResult(fmt.Errorf("invalid firewall rule: %w", err), time.Since(start).Milliseconds())
+	}
+
+	rule, err := security.AddFirewallRule(spec)
+	h.auditFirewallChange(cmd, "add_rule", map[string]any{"rule": spec}, err)
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	return tools.NewSuccessResult(rule, time.Since(start).Milliseconds())
+}
+
+func handleFirewallRemoveRule(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	ref := security.FirewallRuleRef{
+		ID:   tools.GetPayloadString(cmd.Payload, "id", ""),
+		Name: tools.GetPayloadString(cmd.Payload, "name", ""),
+	}
+
+	removed, err := security.RemoveFirewallRule(ref)
+	h.auditFirewallChange(cmd, "remove_rule", map[string]any{"id": ref.ID, "name": ref.Name, "removed": removed}, err)
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	return tools.NewSuccessResult(map[string]any{"removed": removed}, time.Since(start).Milliseconds())
+}
+
+func handleFirewallSetProfile(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	profile := tools.GetPayloadString(cmd.Payload, "profile", "")
+	if profile == "" {
+		return tools.NewErrorResult(errors.New("profile is required"), time.Since(start).Milliseconds())
+	}
+	// Defaulting a missing flag either way could switch a firewall off.
+	if _, ok := cmd.Payload["enabled"].(bool); !ok {
+		return tools.NewErrorResult(errors.New("enabled must be true or false"), time.Since(start).Milliseconds())
+	}
+	enabled := tools.GetPayloadBool(cmd.Payload, "enabled", true)
+
+	err := security.SetFirewallProfile(profile, enabled)
+	h.auditFirewallChange(cmd, "set_profile", map[string]any{"profile": profile, "enabled": enabled}, err)
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	return tools.NewSuccessResult(map[string]any{"profile": profile, "enabled": enabled}, time.Since(start).Milliseconds())
+}
+
+// auditFirewallChange records a firewall change, successful or not.
+func (h *Heartbeat) auditFirewallChange(cmd Command, operation string, details map[string]any, opErr error) {
+	if h.auditLog == nil {
+		return
+	}
+	details["operation"] = operation
+	details["status"] = "completed"
+	if opErr != nil {
+		details["status"] = "failed"
+		details["error"] = opErr.Error()
+	}
+	h.auditLog.Log(audit.EventFirewallChange, cmd.ID, details)
+}
@@ -0,0 +1,33 @@
+package heartbeat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+func TestHandleFirewallSetProfileRequiresExplicitState(t *testing.T) {
+	h := &Heartbeat{}
+	for name, payload := range map[string]map[string]any{
+		"missing profile": {"enabled": true},
+		"missing enabled": {"profile": "public"},
+		"string enabled":  {"profile": "public", "enabled": "false"},
+	} {
+		result := handleFirewallSetProfile(h, Command{ID: "cmd-1", Type: tools.CmdFirewallSetProfile, Payload: payload})
+		if result.Status != "failed" {
+			t.Errorf("%s: expected failure, got %s", name, result.Status)
+		}
+	}
+}
+
+func TestHandleFirewallAddRuleRejectsInvalidSpec(t *testing.T) {
+	result := handleFirewallAddRule(&Heartbeat{}, Command{
+		ID:      "cmd-1",
+		Type:    tools.CmdFirewallAddRule,
+		Payload: map[string]any{"name": "Everything", "protocol": "tcp"},
+	})
+	if result.Status != "failed" || !strings.Contains(result.Error, "port or a remote address") {
+		t.Fatalf("expected the spec to be rejected before touching the firewall, got %+v", result)
+	}
+}
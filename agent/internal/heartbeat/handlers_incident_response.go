@@ -13,6 +13,16 @@ func handleCollectEvidence(_ *Heartbeat, cmd Command) tools.CommandResult {
 	return tools.CollectEvidence(cmd.Payload)
 }
 
-func handleExecuteContainment(_ *Heartbeat, cmd Command) tools.CommandResult {
+// handleExecuteContainment runs network isolation here rather than in tools:
+// only the heartbeat knows the server address the host must stay connected
+// to, and it re-applies the isolation after a restart.
+func handleExecuteContainment(h *Heartbeat, cmd Command) tools.CommandResult {
+	actionType, _ := cmd.Payload["actionType"].(string)
+	switch actionType {
+	case "network_isolation":
+		return h.runIsolate(cmd, "containment", map[string]any{"actionType": actionType})
+	case "network_isolation_release":
+		return h.runReleaseIsolation(cmd, "containment_release", map[string]any{"actionType": actionType})
+	}
 	return tools.ExecuteContainment(cmd.Payload)
 }
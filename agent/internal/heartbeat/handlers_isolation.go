@@ -0,0 +1,53 @@
+package heartbeat
+
+import (
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+func init() {
+	handlerRegistry[tools.CmdIsolateHost] = handleIsolateHost
+	handlerRegistry[tools.CmdReleaseIsolation] = handleReleaseIsolation
+}
+
+// handleIsolateHost blocks all traffic but agent↔server, DNS and DHCP until
+// release_isolation, keeping the host reachable for remediation. The
+// isolation is re-applied whenever the agent starts, so it outlasts reboots.
+func handleIsolateHost(h *Heartbeat, cmd Command) tools.CommandResult {
+	return h.runIsolate(cmd, "command", nil)
+}
+
+func handleReleaseIsolation(h *Heartbeat, cmd Command) tools.CommandResult {
+	return h.runReleaseIsolation(cmd, "command_release", nil)
+}
+
+// runIsolate isolates the host and reports the applied allow list, merged
+// into extra.
+func (h *Heartbeat) runIsolate(cmd Command, reason string, extra map[string]any) tools.CommandResult {
+	start := time.Now()
+	state, err := h.isolateHost(cmd.ID, reason)
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	result := map[string]any{"isolated": true, "backend": state.Backend, "allowed": state.Allowed}
+	for k, v := range extra {
+		result[k] = v
+	}
+	return tools.NewSuccessResult(result, time.Since(start).Milliseconds())
+}
+
+// runReleaseIsolation releases the host. released is false when it was not
+// isolated, which is not an error.
+func (h *Heartbeat) runReleaseIsolation(cmd Command, reason string, extra map[string]any) tools.CommandResult {
+	start := time.Now()
+	released, err := h.releaseHostIsolation(cmd.ID, reason)
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	result := map[string]any{"isolated": false, "released": released}
+	for k, v := range extra {
+		result[k] = v
+	}
+	return tools.NewSuccessResult(result, time.Since(start).Milliseconds())
+}
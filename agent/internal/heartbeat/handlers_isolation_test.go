@@ -0,0 +1,95 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+	"github.com/breeze-rmm/agent/internal/security"
+)
+
+func useTempIsolationState(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), networkIsolationFile)
+	orig := networkIsolationPath
+	networkIsolationPath = func() string { return path }
+	t.Cleanup(func() { networkIsolationPath = orig })
+	return path
+}
+
+func TestHandleReleaseIsolationWhenNotIsolated(t *testing.T) {
+	useTempIsolationState(t)
+	result := handleReleaseIsolation(&Heartbeat{}, Command{ID: "cmd-1", Type: tools.CmdReleaseIsolation})
+	if result.Status != "completed" {
+		t.Fatalf("releasing a host that is not isolated should succeed, got %+v", result)
+	}
+	var out map[string]any
+	if err := json.Unmarshal([]byte(result.Stdout), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out["released"] != false || out["isolated"] != false {
+		t.Fatalf("result = %v", out)
+	}
+}
+
+func TestHandleReleaseIsolationKeepsStateOnFailure(t *testing.T) {
+	path := useTempIsolationState(t)
+	if err := os.WriteFile(path, []byte(`{"backend":"unknown","allowed":["203.0.113.10"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	result := handleReleaseIsolation(&Heartbeat{}, Command{ID: "cmd-1", Type: tools.CmdReleaseIsolation})
+	if result.Status != "failed" {
+		t.Fatalf("expected failure for an unknown backend, got %+v", result)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("the state must be kept so the release can be retried: %v", err)
+	}
+}
+
+func TestHandleExecuteContainmentRoutesIsolationRelease(t *testing.T) {
+	useTempIsolationState(t)
+	result := handleExecuteContainment(&Heartbeat{}, Command{
+		ID:      "cmd-1",
+		Type:    tools.CmdExecuteContainment,
+		Payload: map[string]any{"actionType": "network_isolation_release"},
+	})
+	if result.Status != "completed" {
+		t.Fatalf("containment release should be handled by the heartbeat, got %+v", result)
+	}
+}
+
+func TestHandleIsolateHostUndoesIsolationWhenStateCannotBeSaved(t *testing.T) {
+	// A regular file where the state directory should be makes the save fail.
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	if err := os.WriteFile(blocker, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	origPath := networkIsolationPath
+	networkIsolationPath = func() string { return filepath.Join(blocker, networkIsolationFile) }
+	t.Cleanup(func() { networkIsolationPath = origPath })
+
+	applied := &security.NetworkIsolation{Backend: "test", Allowed: []string{"127.0.0.1"}}
+	var released *security.NetworkIsolation
+	origIsolate, origRelease := isolateNetwork, releaseNetworkIsolation
+	isolateNetwork = func([]net.IP, *security.NetworkIsolation) (*security.NetworkIsolation, error) { return applied, nil }
+	releaseNetworkIsolation = func(state *security.NetworkIsolation) error { released = state; return nil }
+	t.Cleanup(func() { isolateNetwork, releaseNetworkIsolation = origIsolate, origRelease })
+	t.Cleanup(func() {
+		ransomwareCanary.Lock()
+		ransomwareCanary.pending = nil
+		ransomwareCanary.Unlock()
+	})
+
+	h := &Heartbeat{config: &config.Config{ServerURL: "https://127.0.0.1"}}
+	result := handleIsolateHost(h, Command{ID: "cmd-1", Type: tools.CmdIsolateHost})
+	if result.Status != "failed" {
+		t.Fatalf("isolate_host must fail when the state cannot be recorded, got %+v", result)
+	}
+	if released != applied {
+		t.Fatal("the unrecorded isolation must be undone")
+	}
+}
@@ -0,0 +1,77 @@
+package heartbeat
+
+import (
+	"errors"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/audit"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+	"github.com/breeze-rmm/agent/internal/security"
+)
+
+func init() {
+	handlerRegistry[tools.CmdLocalAdminRemove] = handleLocalAdminRemove
+	handlerRegistry[tools.CmdLocalAdminDisable] = handleLocalAdminDisable
+	handlerRegistry[tools.CmdLocalAdminRotatePassword] = handleLocalAdminRotatePassword
+}
+
+// handleLocalAdminRemove takes an account out of the local administrators
+// group. The account itself is left alone.
+func handleLocalAdminRemove(h *Heartbeat, cmd Command) tools.CommandResult {
+	return h.runLocalAccountChange(cmd, "remove_admin", security.RemoveLocalAdmin,
+		map[string]any{"removedFromAdmins": true})
+}
+
+// handleLocalAdminDisable disables a local account so it can no longer sign
+// in; its group memberships are left alone.
+func handleLocalAdminDisable(h *Heartbeat, cmd Command) tools.CommandResult {
+	return h.runLocalAccountChange(cmd, "disable", security.DisableLocalAccount,
+		map[string]any{"disabled": true})
+}
+
+// handleLocalAdminRotatePassword sets a generated password on a local
+// account, escrowing it through the recovery-key ingest before it is set. A
+// failed upload leaves the account untouched, so nothing is parked for
+// retry; a failed set revokes the escrowed password again. The result never
+// carries the password.
+func handleLocalAdminRotatePassword(h *Heartbeat, cmd Command) tools.CommandResult {
+	rotate := func(username string) error {
+		return security.RotateLocalAdminPassword(username,
+			func(key security.RecoveryKey) error {
+				return h.pushRecoveryKeys("rotation", []security.RecoveryKey{key})
+			},
+			func(key security.RecoveryKey) error {
+				return h.pushRecoveryKeys("revoked", []security.RecoveryKey{key})
+			})
+	}
+	return h.runLocalAccountChange(cmd, "rotate_password", rotate,
+		map[string]any{"rotated": true, "escrowed": true})
+}
+
+func (h *Heartbeat) runLocalAccountChange(cmd Command, operation string, apply func(string) error, result map[string]any) tools.CommandResult {
+	start := time.Now()
+	username := tools.GetPayloadString(cmd.Payload, "username", "")
+	if username == "" {
+		return tools.NewErrorResult(errors.New("username is required"), time.Since(start).Milliseconds())
+	}
+	err := apply(username)
+	h.auditLocalAccountChange(cmd, operation, username, err)
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	result["username"] = username
+	return tools.NewSuccessResult(result, time.Since(start).Milliseconds())
+}
+
+// auditLocalAccountChange records a local account change, successful or not.
+func (h *Heartbeat) auditLocalAccountChange(cmd Command, operation, username string, opErr error) {
+	if h.auditLog == nil {
+		return
+	}
+	details := map[string]any{"operation": operation, "username": username, "status": "completed"}
+	if opErr != nil {
+		details["status"] = "failed"
+		details["error"] = opErr.Error()
+	}
+	h.auditLog.Log(audit.EventLocalAccountChange, cmd.ID, details)
+}
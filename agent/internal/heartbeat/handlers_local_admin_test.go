@@ -0,0 +1,52 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+func TestLocalAdminHandlersRequireUsername(t *testing.T) {
+	handlers := map[string]func(*Heartbeat, Command) tools.CommandResult{
+		tools.CmdLocalAdminRemove:         handleLocalAdminRemove,
+		tools.CmdLocalAdminDisable:        handleLocalAdminDisable,
+		tools.CmdLocalAdminRotatePassword: handleLocalAdminRotatePassword,
+	}
+	for cmdType, handler := range handlers {
+		result := handler(&Heartbeat{}, Command{ID: "cmd-1", Type: cmdType, Payload: map[string]any{}})
+		if result.Status != "failed" || !strings.Contains(result.Error, "username is required") {
+			t.Errorf("%s without username = %+v", cmdType, result)
+		}
+	}
+}
+
+func TestRunLocalAccountChange(t *testing.T) {
+	h := &Heartbeat{}
+	cmd := Command{ID: "cmd-1", Payload: map[string]any{"username": "alice"}}
+
+	var got string
+	result := h.runLocalAccountChange(cmd, "disable", func(username string) error {
+		got = username
+		return nil
+	}, map[string]any{"disabled": true})
+	if result.Status != "completed" || got != "alice" {
+		t.Fatalf("result = %+v, applied to %q", result, got)
+	}
+	var out map[string]any
+	if err := json.Unmarshal([]byte(result.Stdout), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out["username"] != "alice" || out["disabled"] != true {
+		t.Fatalf("result body = %v", out)
+	}
+
+	result = h.runLocalAccountChange(cmd, "disable", func(string) error {
+		return errors.New("alice is the only administrator")
+	}, map[string]any{"disabled": true})
+	if result.Status != "failed" || !strings.Contains(result.Error, "only administrator") {
+		t.Fatalf("failure result = %+v", result)
+	}
+}
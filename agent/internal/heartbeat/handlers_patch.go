@@ -1,7 +1,6 @@
 package heartbeat
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"slices"
@@ -16,9 +15,7 @@ func init() {
 	handlerRegistry[tools.CmdInstallPatches] = handleInstallPatches
 	handlerRegistry[tools.CmdRollbackPatches] = handleRollbackPatches
 	handlerRegistry[tools.CmdDownloadPatches] = handleDownloadPatches
-	handlerRegistry[tools.CmdScheduleReboot] = handleScheduleReboot
-	handlerRegistry[tools.CmdCancelReboot] = handleCancelReboot
-	handlerRegistry[tools.CmdGetRebootStatus] = handleGetRebootStatus
+	handlerRegistry[tools.CmdPatchStage] = handlePatchStage
 }
 
 func handlePatchScan(h *Heartbeat, cmd Command) tools.CommandResult {
@@ -34,6 +31,7 @@ func handlePatchScan(h *Heartbeat, cmd Command) tools.CommandResult {
 		log.Error("patch scan failed", "source", source, "error", err.Error())
 		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
 	}
+	recordPatchPosture(pendingItems, installedItems, err)
 	if err != nil {
 		// Partial success: at least one provider produced items but another
 		// scan errored. The failed provider is excluded from coveredSources, so
@@ -112,6 +110,10 @@ func filterPatchInventoryItemsBySource(items []map[string]any, source string) []
 func handleInstallPatches(h *Heartbeat, cmd Command) tools.CommandResult {
 	start := time.Now()
 
+	if result, deferred := h.deferOutsideMaintenanceWindow(patching.DeferredInstall, cmd, cmd.Payload); deferred {
+		return result
+	}
+
 	// Run pre-flight checks before install
 	opts := patching.PreflightOptionsFromConfig(h.config)
 	pfResult := patching.RunPreflight(opts)
@@ -126,11 +128,11 @@ func handleInstallPatches(h *Heartbeat, cmd Command) tools.CommandResult {
 		return tools.NewErrorResult(pfResult.FirstError(), time.Since(start).Milliseconds())
 	}
 
-	return h.executePatchInstallCommand(cmd.Payload, false)
+	return h.executePatchInstallCommand(cmd.Payload, false, h.patchProgressSender(cmd.ID))
 }
 
 func handleRollbackPatches(h *Heartbeat, cmd Command) tools.CommandResult {
-	return h.executePatchInstallCommand(cmd.Payload, true)
+	return h.executePatchInstallCommand(cmd.Payload, true, h.patchProgressSender(cmd.ID))
 }
 
 func handleDownloadPatches(h *Heartbeat, cmd Command) tools.CommandResult {
@@ -159,15 +161,7 @@ func handleDownloadPatches(h *Heartbeat, cmd Command) tools.CommandResult {
 		return tools.NewErrorResult(fmt.Errorf("no patchIds provided"), time.Since(start).Milliseconds())
 	}
 
-	// Progress callback sends events via WebSocket
-	var progressFn patching.ProgressCallback
-	if h.wsClient != nil {
-		progressFn = func(event patching.ProgressEvent) {
-			_ = h.wsClient.SendPatchProgress(cmd.ID, event)
-		}
-	}
-
-	results, err := h.patchMgr.DownloadPatches(patchIDs, progressFn)
+	results, err := h.patchMgr.DownloadPatches(patchIDs, h.patchProgressSender(cmd.ID))
 	if err != nil {
 		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
 	}
@@ -195,72 +189,15 @@ func handleDownloadPatches(h *Heartbeat, cmd Command) tools.CommandResult {
 	}, time.Since(start).Milliseconds())
 }
 
-func handleScheduleReboot(h *Heartbeat, cmd Command) tools.CommandResult {
-	start := time.Now()
-	if h.rebootMgr == nil {
-		return tools.NewErrorResult(fmt.Errorf("reboot manager not available"), time.Since(start).Milliseconds())
-	}
-
-	delayMinutes := tools.GetPayloadInt(cmd.Payload, "delayMinutes", 60)
-	if delayMinutes < 1 || delayMinutes > 10080 { // 1 min to 7 days
-		return tools.NewErrorResult(fmt.Errorf("delayMinutes must be 1-10080, got %d", delayMinutes), time.Since(start).Milliseconds())
-	}
-	reason := tools.GetPayloadString(cmd.Payload, "reason", "Scheduled by administrator")
-	source := tools.GetPayloadString(cmd.Payload, "source", "manual")
-
-	delay := time.Duration(delayMinutes) * time.Minute
-	deadline := time.Now().Add(delay)
+// createPatchSnapshot is the seam to patching.CreateSnapshot so tests never
+// snapshot the machine running them.
+var createPatchSnapshot = patching.CreateSnapshot
 
-	// Allow overriding deadline via payload
-	if deadlineStr := tools.GetPayloadString(cmd.Payload, "deadline", ""); deadlineStr != "" {
-		if parsed, err := time.Parse(time.RFC3339, deadlineStr); err == nil {
-			deadline = parsed
-		}
-	}
-
-	if err := h.rebootMgr.Schedule(delay, deadline, reason, source); err != nil {
-		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
-	}
-
-	state := h.rebootMgr.State()
-	stateMap := rebootStateToMap(state)
-
-	return tools.NewSuccessResult(stateMap, time.Since(start).Milliseconds())
-}
-
-func handleCancelReboot(h *Heartbeat, _ Command) tools.CommandResult {
-	start := time.Now()
-	if h.rebootMgr == nil {
-		return tools.NewErrorResult(fmt.Errorf("reboot manager not available"), time.Since(start).Milliseconds())
-	}
-
-	if err := h.rebootMgr.Cancel(); err != nil {
-		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
-	}
-
-	return tools.NewSuccessResult(map[string]any{"cancelled": true}, time.Since(start).Milliseconds())
-}
-
-func handleGetRebootStatus(h *Heartbeat, _ Command) tools.CommandResult {
-	start := time.Now()
-	if h.rebootMgr == nil {
-		return tools.NewErrorResult(fmt.Errorf("reboot manager not available"), time.Since(start).Milliseconds())
-	}
-
-	state := h.rebootMgr.State()
-	stateMap := rebootStateToMap(state)
-
-	return tools.NewSuccessResult(stateMap, time.Since(start).Milliseconds())
-}
-
-func rebootStateToMap(state patching.RebootState) map[string]any {
-	stateJSON, err := json.Marshal(state)
-	if err != nil {
-		return map[string]any{"error": err.Error()}
-	}
-	var stateMap map[string]any
-	if err := json.Unmarshal(stateJSON, &stateMap); err != nil {
-		return map[string]any{"error": err.Error()}
+// patchSnapshotRequested reports whether an install takes a system snapshot
+// first: the command's createSnapshot field, otherwise the agent config.
+func (h *Heartbeat) patchSnapshotRequested(payload map[string]any) bool {
+	if v, ok := payload["createSnapshot"].(bool); ok {
+		return v
 	}
-	return stateMap
+	return h.config != nil && h.config.PatchSnapshotBeforeInstall
 }
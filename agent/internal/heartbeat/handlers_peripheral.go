@@ -53,14 +53,18 @@ func handlePeripheralPolicySync(h *Heartbeat, cmd Command) tools.CommandResult {
 
 	cmdLog.Info("peripheral scan complete", "devicesFound", len(detected))
 
-	// Evaluate detected devices against policies.
-	results := peripheral.Evaluate(detected, payload.Policies)
-
-	// Converge OS enforcement to the desired state (Windows: real block /
-	// read-only; other platforms: alert-only stub). Reversible: classes no
+	// Evaluate detected devices against policies and converge OS enforcement
+	// to the desired state (Windows: registry/pnputil; Linux: udev rules and
+	// sysfs; macOS: diskutil eject/read-only remount). Reversible: classes no
 	// longer covered by a block policy are reverted here.
-	plan := peripheral.Plan(results, payload.Policies)
-	outcome := peripheral.Enforce(peripheral.NewEnforcer(), plan, peripheral.EnforceableClasses())
+	peripheralEnforcement.Lock()
+	results, plan, outcome := applyPeripheralPolicies(detected, payload.Policies)
+	events := peripheral.ToEvents(results, outcome)
+	// The sync reports every device; record its violations so the periodic
+	// pass does not report them again.
+	_, violationKeys := selectNewViolations(results, events, peripheralEnforcement.reported)
+	peripheralEnforcement.Unlock()
+
 	cmdLog.Info("peripheral enforcement applied",
 		"gates", len(plan.BlockGates),
 		"devicesDisabled", len(plan.DisableInstanceIDs),
@@ -71,12 +75,11 @@ func handlePeripheralPolicySync(h *Heartbeat, cmd Command) tools.CommandResult {
 		cmdLog.Warn("peripheral enforcement had unverified outcomes", "unverified", enforcementUnverified)
 	}
 
-	events := peripheral.ToEvents(results, outcome)
-
 	// Submit events to the server.
 	if len(events) > 0 {
 		if err := h.submitPeripheralEvents(events); err != nil {
 			cmdLog.Error("failed to submit peripheral events", "error", err.Error())
+			forgetViolations(violationKeys)
 			return tools.NewSuccessResult(map[string]any{
 				"policiesSaved":   len(payload.Policies),
 				"devicesFound":    len(detected),
@@ -0,0 +1,185 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/patching"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+func init() {
+	handlerRegistry[tools.CmdReboot] = handleReboot
+	handlerRegistry[tools.CmdShutdown] = handleShutdown
+	handlerRegistry[tools.CmdSleep] = handleSleep
+	handlerRegistry[tools.CmdHibernate] = handleHibernate
+	handlerRegistry[tools.CmdScheduleReboot] = handleScheduleReboot
+	handlerRegistry[tools.CmdCancelReboot] = handleCancelReboot
+	handlerRegistry[tools.CmdGetRebootStatus] = handleGetRebootStatus
+}
+
+// maxPowerDelayMinutes caps the delay of reboot and shutdown (24 hours).
+const maxPowerDelayMinutes = 1440
+
+// powerDelayMinutes reads the "delay" payload field (minutes), clamped to
+// 0–maxPowerDelayMinutes.
+func powerDelayMinutes(payload map[string]any) int {
+	delay := tools.GetPayloadInt(payload, "delay", 0)
+	if delay < 0 {
+		return 0
+	}
+	if delay > maxPowerDelayMinutes {
+		return maxPowerDelayMinutes
+	}
+	return delay
+}
+
+// warnUsers shows a notification in every user session.
+func (h *Heartbeat) warnUsers(title, body, urgency string) {
+	if h.sessionBroker != nil {
+		h.sessionBroker.BroadcastNotification(title, body, urgency)
+	}
+}
+
+// handleReboot reboots now or after "delay" minutes. It goes through the
+// reboot manager like patch reboots, so users get the same staged warnings
+// and ad-hoc reboots count against the same reboots-per-day limit.
+func handleReboot(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	if h.rebootMgr == nil {
+		return tools.NewErrorResult(fmt.Errorf("reboot manager not available"), time.Since(start).Milliseconds())
+	}
+
+	delayMinutes := powerDelayMinutes(cmd.Payload)
+	reason := tools.GetPayloadString(cmd.Payload, "reason", "Reboot requested by administrator")
+	source := tools.GetPayloadString(cmd.Payload, "source", "manual")
+	if result, deferred := h.deferPatchReboot(cmd, delayMinutes, reason, source); deferred {
+		return result
+	}
+	delay := time.Duration(delayMinutes) * time.Minute
+
+	if err := h.rebootMgr.Schedule(delay, start.Add(delay), reason, source); err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	result := rebootStateToMap(h.rebootMgr.State())
+	result["command"] = tools.CmdReboot
+	result["delay"] = delayMinutes
+	return tools.NewSuccessResult(result, time.Since(start).Milliseconds())
+}
+
+func handleShutdown(h *Heartbeat, cmd Command) tools.CommandResult {
+	delayMinutes := powerDelayMinutes(cmd.Payload)
+	switch {
+	case delayMinutes == 1:
+		h.warnUsers("Shutdown Scheduled", "This computer will shut down in 1 minute. Please save your work.", "critical")
+	case delayMinutes > 1:
+		h.warnUsers("Shutdown Scheduled", fmt.Sprintf("This computer will shut down in %d minutes. Please save your work.", delayMinutes), "normal")
+	default:
+		h.warnUsers("Shutting Down", "This computer is shutting down now.", "critical")
+	}
+	return tools.Shutdown(cmd.Payload)
+}
+
+func handleSleep(h *Heartbeat, cmd Command) tools.CommandResult {
+	result := tools.Sleep(cmd.Payload)
+	if result.Status == "completed" {
+		h.warnUsers("Going to Sleep", fmt.Sprintf("This computer will go to sleep in %d seconds.", int(tools.SuspendGrace/time.Second)), "critical")
+	}
+	return result
+}
+
+func handleHibernate(h *Heartbeat, cmd Command) tools.CommandResult {
+	result := tools.Hibernate(cmd.Payload)
+	if result.Status == "completed" {
+		h.warnUsers("Hibernating", fmt.Sprintf("This computer will hibernate in %d seconds.", int(tools.SuspendGrace/time.Second)), "critical")
+	}
+	return result
+}
+
+func handleScheduleReboot(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	if h.rebootMgr == nil {
+		return tools.NewErrorResult(fmt.Errorf("reboot manager not available"), time.Since(start).Milliseconds())
+	}
+
+	delayMinutes := tools.GetPayloadInt(cmd.Payload, "delayMinutes", 60)
+	if delayMinutes < 1 || delayMinutes > 10080 { // 1 min to 7 days
+		return tools.NewErrorResult(fmt.Errorf("delayMinutes must be 1-10080, got %d", delayMinutes), time.Since(start).Milliseconds())
+	}
+	reason := tools.GetPayloadString(cmd.Payload, "reason", "Scheduled by administrator")
+	source := tools.GetPayloadString(cmd.Payload, "source", "manual")
+	if result, deferred := h.deferPatchReboot(cmd, delayMinutes, reason, source); deferred {
+		return result
+	}
+
+	delay := time.Duration(delayMinutes) * time.Minute
+	deadline := time.Now().Add(delay)
+
+	// Allow overriding deadline via payload
+	if deadlineStr := tools.GetPayloadString(cmd.Payload, "deadline", ""); deadlineStr != "" {
+		if parsed, err := time.Parse(time.RFC3339, deadlineStr); err == nil {
+			deadline = parsed
+		}
+	}
+
+	if err := h.rebootMgr.Schedule(delay, deadline, reason, source); err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	state := h.rebootMgr.State()
+	stateMap := rebootStateToMap(state)
+
+	return tools.NewSuccessResult(stateMap, time.Since(start).Milliseconds())
+}
+
+func handleCancelReboot(h *Heartbeat, _ Command) tools.CommandResult {
+	start := time.Now()
+	if h.rebootMgr == nil {
+		return tools.NewErrorResult(fmt.Errorf("reboot manager not available"), time.Since(start).Milliseconds())
+	}
+
+	if err := h.rebootMgr.Cancel(); err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	return tools.NewSuccessResult(map[string]any{"cancelled": true}, time.Since(start).Milliseconds())
+}
+
+func handleGetRebootStatus(h *Heartbeat, _ Command) tools.CommandResult {
+	start := time.Now()
+	if h.rebootMgr == nil {
+		return tools.NewErrorResult(fmt.Errorf("reboot manager not available"), time.Since(start).Milliseconds())
+	}
+
+	state := h.rebootMgr.State()
+	stateMap := rebootStateToMap(state)
+
+	return tools.NewSuccessResult(stateMap, time.Since(start).Milliseconds())
+}
+
+// deferPatchReboot queues a patch or maintenance reboot received outside
+// the patch maintenance windows. Manual reboots are never deferred.
+func (h *Heartbeat) deferPatchReboot(cmd Command, delayMinutes int, reason, source string) (tools.CommandResult, bool) {
+	if source == "manual" {
+		return tools.CommandResult{}, false
+	}
+	return h.deferOutsideMaintenanceWindow(patching.DeferredReboot, cmd, map[string]any{
+		"delayMinutes": delayMinutes,
+		"reason":       reason,
+		"source":       source,
+	})
+}
+
+func rebootStateToMap(state patching.RebootState) map[string]any {
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	var stateMap map[string]any
+	if err := json.Unmarshal(stateJSON, &stateMap); err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return stateMap
+}
@@ -0,0 +1,35 @@
+package heartbeat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+func TestPowerDelayMinutesClamps(t *testing.T) {
+	tests := []struct {
+		payload map[string]any
+		want    int
+	}{
+		{map[string]any{}, 0},
+		{map[string]any{"delay": float64(-5)}, 0},
+		{map[string]any{"delay": float64(15)}, 15},
+		{map[string]any{"delay": float64(5000)}, maxPowerDelayMinutes},
+	}
+	for _, tt := range tests {
+		if got := powerDelayMinutes(tt.payload); got != tt.want {
+			t.Errorf("powerDelayMinutes(%v) = %d, want %d", tt.payload, got, tt.want)
+		}
+	}
+}
+
+// Without a reboot manager, reboot must fail rather than fall back to an
+// unlimited OS reboot that bypasses the reboots-per-day limit.
+func TestHandleRebootRequiresRebootManager(t *testing.T) {
+	h := &Heartbeat{}
+	result := handleReboot(h, Command{ID: "cmd-1", Type: tools.CmdReboot, Payload: map[string]any{"delay": float64(5)}})
+	if result.Status != "failed" || !strings.Contains(result.Error, "reboot manager") {
+		t.Fatalf("result = %+v, want reboot manager error", result)
+	}
+}
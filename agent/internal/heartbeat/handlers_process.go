@@ -0,0 +1,256 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/audit"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+func init() {
+	handlerRegistry[tools.CmdProcessKill] = handleProcessKill
+	handlerRegistry[tools.CmdProcessSetPriority] = handleProcessSetPriority
+	handlerRegistry[tools.CmdProcessStreamStart] = handleProcessStreamStart
+	handlerRegistry[tools.CmdProcessStreamStop] = handleProcessStreamStop
+}
+
+// Live process-table streams back the console's Task Manager view. The
+// console sends process_stream_start when the view opens and re-sends it
+// (same streamId) to keep the stream alive; a renewal also forces a full
+// frame so a reconnecting viewer can resynchronize. Frames go out as
+// process_snapshot WS messages until process_stream_stop, the idle timeout,
+// or agent shutdown.
+const (
+	processStreamDefaultInterval = 3 * time.Second
+	processStreamMinInterval     = 2 * time.Second
+	processStreamMaxInterval     = 5 * time.Second
+	// processStreamIdleTimeout stops a stream whose console view went away
+	// (tab closed, browser crashed) without sending process_stream_stop.
+	processStreamIdleTimeout = 2 * time.Minute
+	// processStreamFirstFrameDelay is the CPU window of the first frame.
+	processStreamFirstFrameDelay = time.Second
+	// processStreamFullFrameEvery bounds how long a viewer that missed a
+	// delta stays wrong.
+	processStreamFullFrameEvery = 10
+	maxProcessStreams           = 4
+)
+
+var processStreamIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{8,128}$`)
+
+type processStream struct {
+	stop chan struct{}
+
+	mu        sync.Mutex
+	interval  time.Duration
+	renewedAt time.Time
+	resync    bool
+}
+
+// processStreams holds the running streams, keyed by stream ID.
+var (
+	processStreamsMu sync.Mutex
+	processStreams   = map[string]*processStream{}
+)
+
+// processStreamInterval reads intervalSeconds from the payload, clamped to
+// the 2–5s range the console's view refreshes at.
+func processStreamInterval(payload map[string]any) time.Duration {
+	interval := time.Duration(tools.GetPayloadInt(payload, "intervalSeconds", int(processStreamDefaultInterval/time.Second))) * time.Second
+	if interval < processStreamMinInterval {
+		return processStreamMinInterval
+	}
+	if interval > processStreamMaxInterval {
+		return processStreamMaxInterval
+	}
+	return interval
+}
+
+// startOrRenewProcessStream registers a new stream, or renews the running
+// one with the same ID. renewed reports which.
+func startOrRenewProcessStream(id string, interval time.Duration, now time.Time) (s *processStream, renewed bool, err error) {
+	processStreamsMu.Lock()
+	defer processStreamsMu.Unlock()
+
+	if s := processStreams[id]; s != nil {
+		s.mu.Lock()
+		s.interval = interval
+		s.renewedAt = now
+		s.resync = true
+		s.mu.Unlock()
+		return s, true, nil
+	}
+	if len(processStreams) >= maxProcessStreams {
+		return nil, false, fmt.Errorf("too many process streams (max %d)", maxProcessStreams)
+	}
+	s = &processStream{stop: make(chan struct{}), interval: interval, renewedAt: now}
+	processStreams[id] = s
+	return s, false, nil
+}
+
+// stopProcessStream stops and forgets the stream, reporting whether it was
+// running.
+func stopProcessStream(id string) bool {
+	processStreamsMu.Lock()
+	defer processStreamsMu.Unlock()
+	s := processStreams[id]
+	if s == nil {
+		return false
+	}
+	delete(processStreams, id)
+	close(s.stop)
+	return true
+}
+
+// removeProcessStream forgets a stream that ended on its own, unless it was
+// already stopped (and possibly replaced under the same ID).
+func removeProcessStream(id string, s *processStream) {
+	processStreamsMu.Lock()
+	defer processStreamsMu.Unlock()
+	if processStreams[id] == s {
+		delete(processStreams, id)
+	}
+}
+
+// tick returns the interval until the next frame and whether this frame
+// must be full, or expired once the console stopped renewing.
+func (s *processStream) tick(now time.Time) (interval time.Duration, full, expired bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.renewedAt) > processStreamIdleTimeout {
+		return 0, false, true
+	}
+	full = s.resync
+	s.resync = false
+	return s.interval, full, false
+}
+
+func (h *Heartbeat) runProcessStream(id string, s *processStream) {
+	defer removeProcessStream(id, s)
+
+	sampler := tools.NewProcessTableSampler()
+	if err := sampler.Prime(time.Now()); err != nil {
+		log.Warn("process stream: failed to read process table", "streamId", id, "error", err.Error())
+		return
+	}
+
+	timer := time.NewTimer(processStreamFirstFrameDelay)
+	defer timer.Stop()
+
+	// resync forces a full frame after a dropped send: deltas are relative
+	// to the previous frame, which the viewer never saw.
+	resync := false
+	for frame := 0; ; frame++ {
+		select {
+		case <-s.stop:
+			return
+		case <-h.stopChan:
+			return
+		case <-timer.C:
+		}
+
+		interval, full, expired := s.tick(time.Now())
+		if expired {
+			log.Info("process stream not renewed, stopping", "streamId", id)
+			return
+		}
+		full = full || resync || frame%processStreamFullFrameEvery == 0
+
+		snap, err := sampler.Sample(time.Now(), full)
+		if err != nil {
+			log.Warn("process stream: failed to read process table", "streamId", id, "error", err.Error())
+		} else if h.wsClient != nil {
+			snap.IntervalMs = interval.Milliseconds()
+			err = h.wsClient.SendProcessSnapshot(id, snap)
+			resync = err != nil
+			if err != nil {
+				log.Debug("process stream: dropped frame", "streamId", id, "error", err.Error())
+			}
+		}
+		timer.Reset(interval)
+	}
+}
+
+func handleProcessStreamStart(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	id, errResult := tools.RequirePayloadString(cmd.Payload, "streamId")
+	if errResult != nil {
+		return *errResult
+	}
+	if !processStreamIDPattern.MatchString(id) {
+		return tools.NewErrorResult(fmt.Errorf("invalid streamId"), time.Since(start).Milliseconds())
+	}
+
+	interval := processStreamInterval(cmd.Payload)
+	s, renewed, err := startOrRenewProcessStream(id, interval, start)
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	if !renewed {
+		go h.runProcessStream(id, s)
+	}
+
+	return tools.NewSuccessResult(map[string]any{
+		"streamId":      id,
+		"intervalMs":    interval.Milliseconds(),
+		"idleTimeoutMs": processStreamIdleTimeout.Milliseconds(),
+		"renewed":       renewed,
+	}, time.Since(start).Milliseconds())
+}
+
+func handleProcessStreamStop(_ *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	id, errResult := tools.RequirePayloadString(cmd.Payload, "streamId")
+	if errResult != nil {
+		return *errResult
+	}
+	return tools.NewSuccessResult(map[string]any{
+		"streamId": id,
+		"stopped":  stopProcessStream(id),
+	}, time.Since(start).Milliseconds())
+}
+
+// handleProcessKill serves both process_kill and the older kill_process.
+func handleProcessKill(h *Heartbeat, cmd Command) tools.CommandResult {
+	result := tools.KillProcess(cmd.Payload)
+	h.auditProcessAction("kill", cmd, result)
+	return result
+}
+
+func handleProcessSetPriority(h *Heartbeat, cmd Command) tools.CommandResult {
+	result := tools.SetProcessPriority(cmd.Payload)
+	h.auditProcessAction("set_priority", cmd, result)
+	return result
+}
+
+// auditProcessAction records a kill or priority change, successful or not,
+// with the process name when the agent could resolve it.
+func (h *Heartbeat) auditProcessAction(action string, cmd Command, result tools.CommandResult) {
+	if h.auditLog == nil {
+		return
+	}
+	details := map[string]any{
+		"action": action,
+		"pid":    tools.GetPayloadInt(cmd.Payload, "pid", 0),
+		"status": result.Status,
+	}
+	switch action {
+	case "kill":
+		details["force"] = tools.GetPayloadBool(cmd.Payload, "force", false)
+	case "set_priority":
+		details["priority"] = tools.GetPayloadString(cmd.Payload, "priority", "")
+	}
+	var out struct {
+		Name string `json:"name"`
+	}
+	if result.Stdout != "" && json.Unmarshal([]byte(result.Stdout), &out) == nil && out.Name != "" {
+		details["name"] = out.Name
+	}
+	if result.Error != "" {
+		details["error"] = result.Error
+	}
+	h.auditLog.Log(audit.EventProcessAction, cmd.ID, details)
+}
@@ -0,0 +1,97 @@
+package heartbeat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+func TestProcessStreamIntervalClamped(t *testing.T) {
+	for _, tc := range []struct {
+		payload map[string]any
+		want    time.Duration
+	}{
+		{map[string]any{}, 3 * time.Second},
+		{map[string]any{"intervalSeconds": 1}, 2 * time.Second},
+		{map[string]any{"intervalSeconds": 4}, 4 * time.Second},
+		{map[string]any{"intervalSeconds": 60}, 5 * time.Second},
+	} {
+		if got := processStreamInterval(tc.payload); got != tc.want {
+			t.Errorf("processStreamInterval(%v) = %v, want %v", tc.payload, got, tc.want)
+		}
+	}
+}
+
+func TestProcessStreamRenewAndExpire(t *testing.T) {
+	const id = "stream-renew-test"
+	t.Cleanup(func() { stopProcessStream(id) })
+
+	now := time.Now()
+	s, renewed, err := startOrRenewProcessStream(id, 3*time.Second, now)
+	if err != nil || renewed {
+		t.Fatalf("start: renewed=%v err=%v", renewed, err)
+	}
+	if _, full, expired := s.tick(now); full || expired {
+		t.Fatalf("fresh stream tick: full=%v expired=%v", full, expired)
+	}
+
+	later := now.Add(processStreamIdleTimeout - time.Second)
+	again, renewed, err := startOrRenewProcessStream(id, 5*time.Second, later)
+	if err != nil || !renewed || again != s {
+		t.Fatalf("renew: same=%v renewed=%v err=%v", again == s, renewed, err)
+	}
+	interval, full, expired := s.tick(later)
+	if interval != 5*time.Second || !full || expired {
+		t.Fatalf("renewed tick: interval=%v full=%v expired=%v, want 5s full frame", interval, full, expired)
+	}
+
+	if _, _, expired := s.tick(later.Add(processStreamIdleTimeout + time.Second)); !expired {
+		t.Fatal("stream not renewed within the idle timeout should expire")
+	}
+
+	if !stopProcessStream(id) {
+		t.Fatal("stop of running stream reported not running")
+	}
+	select {
+	case <-s.stop:
+	default:
+		t.Fatal("stop did not signal the stream")
+	}
+	if stopProcessStream(id) {
+		t.Fatal("second stop reported running")
+	}
+}
+
+func TestProcessStreamLimit(t *testing.T) {
+	now := time.Now()
+	var ids []string
+	t.Cleanup(func() {
+		for _, id := range ids {
+			stopProcessStream(id)
+		}
+	})
+	for i := 0; i < maxProcessStreams; i++ {
+		id := "stream-limit-" + string(rune('a'+i))
+		ids = append(ids, id)
+		if _, _, err := startOrRenewProcessStream(id, 3*time.Second, now); err != nil {
+			t.Fatalf("stream %d: %v", i, err)
+		}
+	}
+	if _, _, err := startOrRenewProcessStream("stream-limit-over", 3*time.Second, now); err == nil {
+		t.Fatal("stream beyond the limit was accepted")
+	}
+	if _, renewed, err := startOrRenewProcessStream(ids[0], 3*time.Second, now); err != nil || !renewed {
+		t.Fatalf("renewal at the limit: renewed=%v err=%v", renewed, err)
+	}
+}
+
+func TestHandleProcessStreamStartRejectsBadStreamID(t *testing.T) {
+	h := &Heartbeat{}
+	for _, id := range []string{"", "short", "has spaces in it", "../../etc/passwd"} {
+		result := handleProcessStreamStart(h, Command{ID: "cmd-1", Type: tools.CmdProcessStreamStart, Payload: map[string]any{"streamId": id}})
+		if result.Status != "failed" {
+			t.Errorf("streamId %q accepted", id)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/breeze-rmm/agent/internal/audit"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+// maxRegistryAuditDataBytes bounds value data copied into the audit log,
+// matching the API's own registry audit entries.
+const maxRegistryAuditDataBytes = 200
+
+// auditRegistryAction records a registry change or export, successful or
+// not (including policy refusals), with the value it replaced when the agent
+// could read it.
+func (h *Heartbeat) auditRegistryAction(action string, cmd Command, result tools.CommandResult) {
+	if h.auditLog == nil {
+		return
+	}
+	details := map[string]any{
+		"action": action,
+		"hive":   tools.GetPayloadString(cmd.Payload, "hive", "HKLM"),
+		"path":   tools.GetPayloadString(cmd.Payload, "path", ""),
+		"status": result.Status,
+	}
+	switch action {
+	case "set_value":
+		details["name"] = tools.GetPayloadString(cmd.Payload, "name", "")
+		details["type"] = tools.GetPayloadString(cmd.Payload, "type", "REG_SZ")
+		details["data"] = truncateRegistryAuditData(tools.GetPayloadString(cmd.Payload, "data", ""))
+	case "delete_value":
+		details["name"] = tools.GetPayloadString(cmd.Payload, "name", "")
+	case "export":
+		details["recursive"] = tools.GetPayloadBool(cmd.Payload, "recursive", true)
+	}
+
+	if result.Stdout != "" {
+		var out struct {
+			Previous *tools.RegistryValue `json:"previous"`
+			Keys     int                  `json:"keys"`
+			Values   int                  `json:"values"`
+		}
+		if json.Unmarshal([]byte(result.Stdout), &out) == nil {
+			if out.Previous != nil {
+				details["previousType"] = out.Previous.Type
+				details["previousData"] = truncateRegistryAuditData(out.Previous.Data)
+			}
+			if action == "export" {
+				details["keys"] = out.Keys
+				details["values"] = out.Values
+			}
+		}
+	}
+	if result.Error != "" {
+		details["error"] = result.Error
+	}
+	h.auditLog.Log(audit.EventRegistryAction, cmd.ID, details)
+}
+
+func truncateRegistryAuditData(data string) string {
+	if len(data) <= maxRegistryAuditDataBytes {
+		return data
+	}
+	return strings.ToValidUTF8(data[:maxRegistryAuditDataBytes], "")
+}
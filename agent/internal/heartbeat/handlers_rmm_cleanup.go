@@ -0,0 +1,68 @@
+package heartbeat
+
+// Guided removal of superseded RMM agents. rmm_cleanup runs the
+// vendor-documented silent uninstall for each named tool, removes leftover
+// services and install directories, re-runs the tool's detection signature to
+// verify it is gone, and reports the per-device migration-cleanup status.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/mgmtdetect"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+func init() {
+	handlerRegistry[tools.CmdRMMCleanup] = handleRMMCleanup
+}
+
+func handleRMMCleanup(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+
+	names := tools.GetPayloadStringSlice(cmd.Payload, "tools")
+	if len(names) == 0 {
+		return tools.NewErrorResult(fmt.Errorf("tools is required"), time.Since(start).Milliseconds())
+	}
+	dryRun := tools.GetPayloadBool(cmd.Payload, "dryRun", false)
+
+	results := make([]mgmtdetect.CleanupResult, 0, len(names))
+	incomplete := 0
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		log.Info("rmm cleanup", "tool", name, "dryRun", dryRun)
+		res := mgmtdetect.CleanupTool(name, mgmtdetect.CleanupOptions{DryRun: dryRun})
+		if res.Status == mgmtdetect.CleanupIncomplete || res.Status == mgmtdetect.CleanupUnsupported {
+			incomplete++
+		}
+		results = append(results, res)
+	}
+
+	report := map[string]any{
+		"commandId":   cmd.ID,
+		"dryRun":      dryRun,
+		"results":     results,
+		"completedAt": time.Now().UTC(),
+	}
+	_ = h.sendInventoryData("management/cleanup", report, fmt.Sprintf("rmm cleanup (%d tools)", len(results)))
+
+	// Refresh the management posture so removed tools drop off the device
+	// record without waiting for the next scheduled scan.
+	if !dryRun {
+		go h.sendManagementPosture()
+	}
+
+	// Keep the per-tool report in stdout even on failure so the console can
+	// show which probes are still present.
+	result := tools.NewSuccessResult(report, time.Since(start).Milliseconds())
+	if incomplete > 0 && result.Status == "completed" {
+		result.Status = "failed"
+		result.ExitCode = 1
+		result.Error = fmt.Sprintf("%d of %d tools were not fully removed", incomplete, len(results))
+	}
+	return result
+}
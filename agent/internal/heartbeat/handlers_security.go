@@ -1,6 +1,7 @@
 package heartbeat
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,6 +16,7 @@ import (
 func init() {
 	handlerRegistry[tools.CmdSecurityCollectStatus] = handleSecurityCollectStatus
 	handlerRegistry[tools.CmdSecurityScan] = handleSecurityScan
+	handlerRegistry[tools.CmdAVScan] = handleAVScan
 	handlerRegistry[tools.CmdSecurityThreatQuarantine] = handleSecurityThreatQuarantine
 	handlerRegistry[tools.CmdSecurityThreatRemove] = handleSecurityThreatRemove
 	handlerRegistry[tools.CmdSecurityThreatRestore] = handleSecurityThreatRestore
@@ -83,6 +85,53 @@ func handleSecurityScan(h *Heartbeat, cmd Command) tools.CommandResult {
 	}, time.Since(start).Milliseconds())
 }
 
+// avScanTimeout bounds an engine scan just under the server's two-hour
+// timeout for av_scan, so the agent reports the failure itself.
+const avScanTimeout = 115 * time.Minute
+
+// handleAVScan runs a scan through the installed antivirus engine (Defender,
+// mdatp or ClamAV), streaming av_scan_progress events while it runs.
+func handleAVScan(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	cmdLog := log.With("commandId", cmd.ID, "commandType", cmd.Type)
+
+	req := security.AVScanRequest{
+		ScanType: strings.ToLower(tools.GetPayloadString(cmd.Payload, "scanType", "quick")),
+		Paths:    tools.GetPayloadStringSlice(cmd.Payload, "paths"),
+		Engine:   tools.GetPayloadString(cmd.Payload, "engine", ""),
+	}
+	scanRecordID := tools.GetPayloadString(cmd.Payload, "scanRecordId", "")
+
+	var progress security.AVScanProgressFunc
+	if h.wsClient != nil {
+		progress = func(event security.AVScanProgress) {
+			_ = h.wsClient.SendAVScanProgress(cmd.ID, event)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), avScanTimeout)
+	defer cancel()
+	scanResult, err := security.RunAVScan(ctx, h.config, req, progress)
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	if scanResult.Warning != "" {
+		cmdLog.Warn("security status collection warning after av scan", "error", scanResult.Warning)
+	}
+	cmdLog.Info("av scan completed", "engine", scanResult.Engine, "scanType", scanResult.ScanType, "threatsFound", len(scanResult.Detections))
+
+	return tools.NewSuccessResult(map[string]any{
+		"scanRecordId": scanRecordID,
+		"engine":       scanResult.Engine,
+		"scanType":     scanResult.ScanType,
+		"durationMs":   scanResult.Duration.Milliseconds(),
+		"itemsScanned": scanResult.ItemsScanned,
+		"threatsFound": len(scanResult.Detections),
+		"threats":      scanResult.Detections,
+		"status":       scanResult.Status,
+	}, time.Since(start).Milliseconds())
+}
+
 func handleSecurityThreatQuarantine(_ *Heartbeat, cmd Command) tools.CommandResult {
 	start := time.Now()
 	path, errResult := tools.RequirePayloadString(cmd.Payload, "path")
@@ -14,30 +14,35 @@ import (
 var allCommandTypes = []string{
 	// handlers.go (direct assignments)
 	tools.CmdListProcesses, tools.CmdGetProcess, tools.CmdKillProcess,
+	tools.CmdProcessKill, tools.CmdProcessSetPriority, tools.CmdProcessStreamStart, tools.CmdProcessStreamStop,
 	tools.CmdListServices, tools.CmdGetService, tools.CmdStartService,
 	tools.CmdStopService, tools.CmdRestartService,
+	tools.CmdServiceList, tools.CmdServiceStart, tools.CmdServiceStop,
+	tools.CmdServiceRestart, tools.CmdServiceSetStartup,
 	tools.CmdEventLogsList, tools.CmdEventLogsQuery, tools.CmdEventLogGet,
 	tools.CmdTasksList, tools.CmdTaskGet, tools.CmdTaskRun,
 	tools.CmdTaskEnable, tools.CmdTaskDisable, tools.CmdTaskHistory,
 	tools.CmdRegistryKeys, tools.CmdRegistryValues, tools.CmdRegistryGet,
 	tools.CmdRegistrySet, tools.CmdRegistryDelete,
-	tools.CmdRegistryKeyCreate, tools.CmdRegistryKeyDelete,
-	tools.CmdReboot, tools.CmdShutdown, tools.CmdLock, tools.CmdRebootSafeMode, tools.CmdWakeOnLan,
+	tools.CmdRegistryKeyCreate, tools.CmdRegistryKeyDelete, tools.CmdRegistryExport,
+	tools.CmdReboot, tools.CmdShutdown, tools.CmdSleep, tools.CmdHibernate, tools.CmdLock, tools.CmdRebootSafeMode, tools.CmdWakeOnLan,
 	tools.CmdRefreshInventory,
 	tools.CmdCollectSoftware, tools.CmdSoftwareUninstall, tools.CmdSoftwareInstall, tools.CmdSoftwareUpdate,
+	tools.CmdAppDeploy,
 	tools.CmdCollectBootPerformance, tools.CmdManageStartupItem,
 	tools.CmdCollectReliabilityMetrics,
 	tools.CmdCollectAuditPolicy, tools.CmdApplyAuditPolicyBaseline,
 	tools.CmdFileList, tools.CmdFileRead, tools.CmdFileWrite,
 	tools.CmdFileDelete, tools.CmdFileMkdir, tools.CmdFileRename,
 	tools.CmdFileCopy, tools.CmdFileListDrives,
+	tools.CmdFileStat, tools.CmdFileMove, tools.CmdFileZip, tools.CmdFileUnzip, tools.CmdFileHash,
 	tools.CmdFileTrashList, tools.CmdFileTrashRestore, tools.CmdFileTrashPurge,
 	tools.CmdFilesystemAnalysis,
 	tools.CmdTerminalStart, tools.CmdTerminalData,
-	tools.CmdTerminalResize, tools.CmdTerminalStop,
+	tools.CmdTerminalResize, tools.CmdTerminalStop, tools.CmdTerminalAttach,
 
 	// handlers_desktop.go init()
-	tools.CmdStartDesktop, tools.CmdStopDesktop,
+	tools.CmdStartDesktop, tools.CmdStopDesktop, tools.CmdJoinDesktop, tools.CmdLeaveDesktop,
 	tools.CmdDesktopStreamStart, tools.CmdDesktopStreamStop,
 	tools.CmdDesktopInput, tools.CmdDesktopConfig,
 
@@ -47,7 +52,7 @@ var allCommandTypes = []string{
 
 	// handlers_patch.go init()
 	tools.CmdPatchScan, tools.CmdInstallPatches, tools.CmdRollbackPatches,
-	tools.CmdDownloadPatches,
+	tools.CmdDownloadPatches, tools.CmdPatchStage,
 	tools.CmdScheduleReboot, tools.CmdCancelReboot, tools.CmdGetRebootStatus,
 
 	// handlers_network.go init()
@@ -56,12 +61,21 @@ var allCommandTypes = []string{
 	tools.CmdNetworkHttpCheck, tools.CmdNetworkDnsCheck,
 
 	// handlers_security.go init()
-	tools.CmdSecurityCollectStatus, tools.CmdSecurityScan,
+	tools.CmdSecurityCollectStatus, tools.CmdSecurityScan, tools.CmdAVScan,
 	tools.CmdSecurityThreatQuarantine, tools.CmdSecurityThreatRemove,
 	tools.CmdSecurityThreatRestore,
 	tools.CmdSensitiveDataScan, tools.CmdQuarantineFile,
 	tools.CmdEncryptFile, tools.CmdSecureDeleteFile,
 
+	// handlers_defender.go init()
+	tools.CmdDefenderGetPreferences, tools.CmdDefenderSetPreferences,
+
+	// handlers_firewall.go init()
+	tools.CmdFirewallListRules, tools.CmdFirewallAddRule, tools.CmdFirewallRemoveRule, tools.CmdFirewallSetProfile,
+
+	// handlers_isolation.go init()
+	tools.CmdIsolateHost, tools.CmdReleaseIsolation,
+
 	// handlers_backup_forward.go init() — backup commands forwarded to breeze-backup via IPC
 	tools.CmdBackupRun, tools.CmdBackupList, tools.CmdBackupStop, tools.CmdBackupRestore,
 
@@ -93,6 +107,12 @@ var allCommandTypes = []string{
 	// handlers.go — runtime diagnostics (handlers_diag.go)
 	tools.CmdCapturePprof,
 
+	// handlers_transcript.go init()
+	tools.CmdTranscriptExport,
+
+	// handlers_rmm_cleanup.go init()
+	tools.CmdRMMCleanup,
+
 	// handlers_autoupdate.go
 	tools.CmdSetAutoUpdate,
 
@@ -108,6 +128,9 @@ var allCommandTypes = []string{
 	// handlers_cis.go init()
 	tools.CmdCisBenchmark, tools.CmdApplyCisRemediation,
 
+	// handlers_compliance.go init()
+	tools.CmdComplianceEvaluate,
+
 	// handlers_peripheral.go init()
 	tools.CmdPeripheralPolicySync,
 
@@ -125,7 +148,11 @@ var allCommandTypes = []string{
 	tools.CmdActuateElevation,
 
 	// handlers_encryption.go init()
-	tools.CmdEncryptionCollectKeys, tools.CmdEncryptionRotateKey,
+	tools.CmdEncryptionCollectKeys, tools.CmdEncryptionRotateKey, tools.CmdEncryptionEnableBitLocker,
+	tools.CmdEncryptionEnableFileVault, tools.CmdEncryptionEscrowLUKS,
+
+	// handlers_local_admin.go init()
+	tools.CmdLocalAdminRemove, tools.CmdLocalAdminDisable, tools.CmdLocalAdminRotatePassword,
 }
 
 func TestHandlerRegistryCompleteness(t *testing.T) {
@@ -182,8 +209,8 @@ func TestHandleRefreshInventoryDispatchesSendInventory(t *testing.T) {
 	if err := json.Unmarshal([]byte(result.Stdout), &payload); err != nil {
 		t.Fatalf("result.Stdout not valid JSON: %v (stdout=%q)", err, result.Stdout)
 	}
-	if len(payload.Dispatched) != 12 {
-		t.Errorf("dispatched len = %d, want 12 (one per send*Inventory collector)", len(payload.Dispatched))
+	if len(payload.Dispatched) != 13 {
+		t.Errorf("dispatched len = %d, want 13 (one per send*Inventory collector)", len(payload.Dispatched))
 	}
 }
 
@@ -0,0 +1,227 @@
+package heartbeat
+
+// Command transcript export for customer audits. transcript_export compiles
+// every command the agent received in a period — type, executor, script body
+// hash, result status — together with the raw hash-chained audit entries into
+// a signed tar.gz and uploads it to the API, which verifies the signature
+// against the key pinned at enrollment and stores it against the command ID.
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/audit"
+	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/httputil"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+const (
+	// defaultTranscriptPeriod is used when the command names no start time.
+	defaultTranscriptPeriod = 30 * 24 * time.Hour
+	// maxTranscriptArchiveBytes caps the compressed upload. The audit log
+	// retains at most a few hundred MB of JSONL, which compresses well below
+	// this, so hitting it means the period should be narrowed.
+	maxTranscriptArchiveBytes = 100 << 20
+	transcriptUploadTimeout   = 5 * time.Minute
+)
+
+func init() {
+	handlerRegistry[tools.CmdTranscriptExport] = handleTranscriptExport
+}
+
+func handleTranscriptExport(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	if h.auditLog == nil {
+		return tools.NewErrorResult(fmt.Errorf("audit log unavailable"), time.Since(start).Milliseconds())
+	}
+
+	from, to, err := transcriptPeriod(cmd.Payload, start.UTC())
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	entries, chainOK, err := h.auditLog.ReadRange(from, to)
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	key, err := audit.LoadOrCreateTranscriptKey(filepath.Join(config.GetDataDir(), audit.TranscriptKeyFile))
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	// Devices enrolled before agents sent the key get it pinned here; for
+	// everyone else this confirms the server holds the same key.
+	if err := h.registerTranscriptKey(audit.TranscriptPublicKey(key)); err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	hostname, _ := os.Hostname()
+	var archive bytes.Buffer
+	manifest, err := audit.WriteTranscriptArchive(&archive, audit.TranscriptManifest{
+		AgentID:       h.config.AgentID,
+		Hostname:      hostname,
+		From:          from,
+		To:            to,
+		GeneratedAt:   start.UTC(),
+		ChainVerified: chainOK,
+	}, entries, key)
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	if archive.Len() > maxTranscriptArchiveBytes {
+		return tools.NewErrorResult(fmt.Errorf("transcript archive is %d bytes (limit %d); narrow the period", archive.Len(), maxTranscriptArchiveBytes), time.Since(start).Milliseconds())
+	}
+
+	sum := sha256.Sum256(archive.Bytes())
+	archiveSHA := hex.EncodeToString(sum[:])
+	if err := h.uploadTranscript(cmd.ID, archive.Bytes(), archiveSHA); err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	if !chainOK {
+		log.Warn("transcript exported with a broken audit hash chain", "commandId", cmd.ID)
+	}
+	return tools.NewSuccessResult(map[string]any{
+		"from":          from,
+		"to":            to,
+		"commandCount":  manifest.CommandCount,
+		"entryCount":    manifest.EntryCount,
+		"chainVerified": chainOK,
+		"archiveSha256": archiveSHA,
+		"sizeBytes":     archive.Len(),
+		"publicKey":     manifest.PublicKey,
+	}, time.Since(start).Milliseconds())
+}
+
+// transcriptPeriod reads the optional RFC 3339 from/to bounds. to defaults to
+// now and from to 30 days before to.
+func transcriptPeriod(payload map[string]any, now time.Time) (time.Time, time.Time, error) {
+	to := now
+	if raw := strings.TrimSpace(tools.GetPayloadString(payload, "to", "")); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+		}
+		to = parsed.UTC()
+	}
+	from := to.Add(-defaultTranscriptPeriod)
+	if raw := strings.TrimSpace(tools.GetPayloadString(payload, "from", "")); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+		}
+		from = parsed.UTC()
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from (%s) must be before to (%s)", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	return from, to, nil
+}
+
+// registerTranscriptKey PUTs the signing key's public half. The server pins
+// it if the device has none and answers 409 if a different key is pinned,
+// in which case the upload would be rejected anyway.
+func (h *Heartbeat) registerTranscriptKey(publicKey string) error {
+	url := fmt.Sprintf("%s/api/v1/agents/%s/transcript-key", h.serverURL(), h.config.AgentID)
+	body, err := json.Marshal(map[string]string{"publicKey": publicKey})
+	if err != nil {
+		return fmt.Errorf("marshal transcript key: %w", err)
+	}
+	headers := http.Header{
+		"Content-Type":  {"application/json"},
+		"Authorization": {h.authHeader()},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := httputil.Do(ctx, h.httpClient(), http.MethodPut, url, body, headers, h.retryCfg)
+	if err != nil {
+		return fmt.Errorf("register transcript key: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusConflict {
+		return fmt.Errorf("register transcript key: server has a different key pinned for this device; re-enroll the agent to replace it")
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("register transcript key: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// uploadTranscript POSTs the archive to the API under the export command ID.
+func (h *Heartbeat) uploadTranscript(commandID string, archive []byte, archiveSHA string) error {
+	url := fmt.Sprintf("%s/api/v1/agents/%s/transcripts/%s", h.serverURL(), h.config.AgentID, commandID)
+	headers := http.Header{
+		"Content-Type":     {"application/gzip"},
+		"Authorization":    {h.authHeader()},
+		"X-Archive-Sha256": {archiveSHA},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), transcriptUploadTimeout)
+	defer cancel()
+
+	resp, err := httputil.Do(ctx, h.httpClient(), http.MethodPost, url, archive, headers, h.retryCfg)
+	if err != nil {
+		return fmt.Errorf("upload transcript: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("upload transcript: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// commandAuditDetails is the command_received audit record: the command type,
+// the context it executes in, for scripts the SHA-256 of the script body so a
+// transcript proves exactly what ran without storing the script itself, and
+// for desktop sessions whether the agent enforced view-only mode.
+func commandAuditDetails(cmd Command) map[string]any {
+	details := map[string]any{"type": cmd.Type}
+	if runAs := strings.TrimSpace(tools.GetPayloadString(cmd.Payload, "runAs", "")); runAs != "" {
+		details["executor"] = runAs
+	} else {
+		details["executor"] = "system"
+	}
+	if cmd.Type == tools.CmdScript || cmd.Type == tools.CmdRunScript {
+		if scriptID := tools.GetPayloadString(cmd.Payload, "scriptId", ""); scriptID != "" {
+			details["scriptId"] = scriptID
+		}
+		if content := tools.GetPayloadString(cmd.Payload, "content", ""); content != "" {
+			sum := sha256.Sum256([]byte(content))
+			details["scriptSha256"] = hex.EncodeToString(sum[:])
+		}
+	}
+	if cmd.Type == tools.CmdTerminalStart {
+		// The chosen security context; the named_user password never is.
+		if mode, err := terminalRunMode(cmd.Payload); err == nil {
+			details["terminalMode"] = mode
+			if mode == terminalModeNamedUser {
+				details["terminalUser"] = strings.TrimSpace(tools.GetPayloadString(cmd.Payload, "username", ""))
+			}
+		}
+	}
+	if cmd.Type == tools.CmdStartDesktop {
+		details["sessionMode"] = "control"
+		if parseDesktopSessionPolicy(cmd.Payload).ViewOnly {
+			details["sessionMode"] = "view_only"
+		}
+	}
+	if cmd.Type == tools.CmdJoinDesktop {
+		details["sessionMode"] = "control"
+		if joinDesktopViewOnly(cmd.Payload) {
+			details["sessionMode"] = "view_only"
+		}
+	}
+	return details
+}
@@ -0,0 +1,145 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/httputil"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+func TestTranscriptPeriod(t *testing.T) {
+	now := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	from, to, err := transcriptPeriod(map[string]any{}, now)
+	if err != nil || !to.Equal(now) || !from.Equal(now.Add(-defaultTranscriptPeriod)) {
+		t.Fatalf("default period = %v..%v (%v)", from, to, err)
+	}
+
+	from, to, err = transcriptPeriod(map[string]any{"from": "2026-03-01T00:00:00Z", "to": "2026-03-31T23:59:59Z"}, now)
+	if err != nil || from.Month() != time.March || to.Day() != 31 {
+		t.Fatalf("explicit period = %v..%v (%v)", from, to, err)
+	}
+
+	if _, _, err := transcriptPeriod(map[string]any{"from": "2026-03-31T00:00:00Z", "to": "2026-03-01T00:00:00Z"}, now); err == nil {
+		t.Fatal("inverted period should be rejected")
+	}
+	if _, _, err := transcriptPeriod(map[string]any{"from": "last month"}, now); err == nil {
+		t.Fatal("unparseable from should be rejected")
+	}
+}
+
+func TestRegisterTranscriptKey(t *testing.T) {
+	pinned := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/api/v1/agents/agent-1/transcript-key" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body struct {
+			PublicKey string `json:"publicKey"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if pinned != "" && pinned != body.PublicKey {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		pinned = body.PublicKey
+	}))
+	defer ts.Close()
+
+	h := NewWithVersion(&config.Config{AgentID: "agent-1", ServerURL: ts.URL, AuthToken: "token"}, "test", nil, nil)
+	h.retryCfg = httputil.RetryConfig{MaxRetries: 0}
+
+	if err := h.registerTranscriptKey("key-a"); err != nil {
+		t.Fatalf("first registration: %v", err)
+	}
+	if err := h.registerTranscriptKey("key-a"); err != nil {
+		t.Fatalf("re-registering the pinned key: %v", err)
+	}
+	if err := h.registerTranscriptKey("key-b"); err == nil || !strings.Contains(err.Error(), "different key") {
+		t.Fatalf("registering a different key = %v, want a conflict error", err)
+	}
+}
+
+func TestCommandAuditDetailsHashesScriptBody(t *testing.T) {
+	details := commandAuditDetails(Command{
+		ID:      "cmd-1",
+		Type:    tools.CmdScript,
+		Payload: map[string]any{"content": "hello", "scriptId": "s-1", "runAs": "user"},
+	})
+	if details["scriptSha256"] != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Fatalf("scriptSha256 = %v", details["scriptSha256"])
+	}
+	if details["executor"] != "user" || details["scriptId"] != "s-1" {
+		t.Fatalf("unexpected details: %v", details)
+	}
+	if _, ok := details["content"]; ok {
+		t.Fatal("script body must not be written to the audit log")
+	}
+
+	plain := commandAuditDetails(Command{ID: "cmd-2", Type: tools.CmdReboot})
+	if plain["executor"] != "system" || plain["scriptSha256"] != nil {
+		t.Fatalf("unexpected non-script details: %v", plain)
+	}
+}
+
+func TestCommandAuditDetailsRecordsDesktopSessionMode(t *testing.T) {
+	viewOnly := commandAuditDetails(Command{
+		ID:      "cmd-3",
+		Type:    tools.CmdStartDesktop,
+		Payload: map[string]any{"sessionId": "s-1", "viewOnly": true},
+	})
+	if viewOnly["sessionMode"] != "view_only" {
+		t.Fatalf("sessionMode = %v, want view_only", viewOnly["sessionMode"])
+	}
+
+	control := commandAuditDetails(Command{ID: "cmd-4", Type: tools.CmdStartDesktop, Payload: map[string]any{}})
+	if control["sessionMode"] != "control" {
+		t.Fatalf("sessionMode = %v, want control", control["sessionMode"])
+	}
+
+	if _, ok := commandAuditDetails(Command{ID: "cmd-5", Type: tools.CmdReboot})["sessionMode"]; ok {
+		t.Fatal("sessionMode must only be recorded for desktop sessions")
+	}
+}
+
+func TestCommandAuditDetailsJoinDesktopDefaultsToViewOnly(t *testing.T) {
+	shadow := commandAuditDetails(Command{ID: "cmd-6", Type: tools.CmdJoinDesktop, Payload: map[string]any{"sessionId": "s-1"}})
+	if shadow["sessionMode"] != "view_only" {
+		t.Fatalf("sessionMode = %v, want view_only for a join without viewOnly", shadow["sessionMode"])
+	}
+	coControl := commandAuditDetails(Command{ID: "cmd-7", Type: tools.CmdJoinDesktop, Payload: map[string]any{"viewOnly": false}})
+	if coControl["sessionMode"] != "control" {
+		t.Fatalf("sessionMode = %v, want control for viewOnly=false", coControl["sessionMode"])
+	}
+}
+
+func TestCommandAuditDetailsRecordsTerminalMode(t *testing.T) {
+	named := commandAuditDetails(Command{
+		ID:      "cmd-8",
+		Type:    tools.CmdTerminalStart,
+		Payload: map[string]any{"sessionId": "s-1", "mode": "named_user", "username": "alice", "password": "hunter2"},
+	})
+	if named["terminalMode"] != terminalModeNamedUser || named["terminalUser"] != "alice" {
+		t.Fatalf("unexpected details: %v", named)
+	}
+	for k, v := range named {
+		if s, ok := v.(string); ok && strings.Contains(s, "hunter2") {
+			t.Fatalf("password leaked into audit detail %q", k)
+		}
+	}
+
+	def := commandAuditDetails(Command{ID: "cmd-9", Type: tools.CmdTerminalStart, Payload: map[string]any{"sessionId": "s-2"}})
+	if def["terminalMode"] != terminalModeSystem {
+		t.Fatalf("terminalMode = %v, want system", def["terminalMode"])
+	}
+	if _, ok := def["terminalUser"]; ok {
+		t.Fatal("terminalUser must only be recorded for named_user")
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/breeze-rmm/agent/internal/audit"
 	"github.com/breeze-rmm/agent/internal/authstate"
 	"github.com/breeze-rmm/agent/internal/backupipc"
+	"github.com/breeze-rmm/agent/internal/changelog"
 	"github.com/breeze-rmm/agent/internal/collectors"
 	"github.com/breeze-rmm/agent/internal/config"
 	"github.com/breeze-rmm/agent/internal/executor"
@@ -41,7 +42,9 @@ import (
 	"github.com/breeze-rmm/agent/internal/onedrivehelper"
 	"github.com/breeze-rmm/agent/internal/patching"
 	"github.com/breeze-rmm/agent/internal/peripheral"
+	"github.com/breeze-rmm/agent/internal/plugins"
 	"github.com/breeze-rmm/agent/internal/privilege"
+	"github.com/breeze-rmm/agent/internal/provisioning"
 	"github.com/breeze-rmm/agent/internal/remote/desktop"
 	"github.com/breeze-rmm/agent/internal/remote/desktop/x11"
 	"github.com/breeze-rmm/agent/internal/remote/tools"
@@ -51,6 +54,7 @@ import (
 	"github.com/breeze-rmm/agent/internal/state"
 	"github.com/breeze-rmm/agent/internal/tcc"
 	"github.com/breeze-rmm/agent/internal/terminal"
+	"github.com/breeze-rmm/agent/internal/throttle"
 	"github.com/breeze-rmm/agent/internal/tunnel"
 	"github.com/breeze-rmm/agent/internal/updater"
 	"github.com/breeze-rmm/agent/internal/websocket"
@@ -97,6 +101,14 @@ type HeartbeatPayload struct {
 	// old agent (or a platform that can't report power state) omits the field
 	// and the server keeps whatever it last knew rather than clobbering it.
 	Battery *collectors.BatteryInfo `json:"battery,omitempty"`
+	// Approximate device location, only while geolocation is enabled by
+	// org/site policy. Nil until the first fix.
+	Location *collectors.GeoLocation `json:"location,omitempty"`
+	// Provisioning quiet mode (sysprep/OOBE/Autopilot ESP/imaging). Nil when
+	// the device is not being provisioned.
+	Provisioning *provisioning.Status `json:"provisioning,omitempty"`
+	// Background-work CPU budget and the measured compliance against it.
+	ResourceLimits *throttle.Stats `json:"resourceLimits,omitempty"`
 	// OneDrive helper state (Phase 2). Nil until a config has been applied on a
 	// Windows box — omitempty then drops the field entirely.
 	OneDriveDeviceState *onedrivehelper.DeviceState `json:"onedriveDeviceState,omitempty"`
@@ -163,15 +175,38 @@ type Heartbeat struct {
 	softwareCol      *collectors.SoftwareCollector
 	inventoryCol     *collectors.InventoryCollector
 	vpnCol           *collectors.VPNCollector
+	certificateCol   *collectors.CertificateCollector
+	containerCol     *collectors.ContainerCollector
+	browserExtCol    *collectors.BrowserExtensionCollector
+	usbDeviceCol     *collectors.USBDeviceCollector
+	bluetoothCol     *collectors.BluetoothCollector
+	licensingCol     *collectors.LicensingCollector
+	usageCol         *collectors.SoftwareUsageCollector
+	geoCol           *collectors.GeolocationCollector
+	regWatcher       *collectors.RegistryWatcher
+	localUserCol     *collectors.LocalUserCollector
+	wifiCol          *collectors.WiFiCollector
 	changeTrackerCol *collectors.ChangeTrackerCollector
 	sessionCol       *collectors.SessionCollector
 	policyStateCol   *collectors.PolicyStateCollector
 	patchCol         *collectors.PatchCollector
 	patchMgr         *patching.PatchManager
+	appPolicies      *patching.AppUpdatePolicyStore
+	patchCompliance  *patching.PatchComplianceStore
+	rebootVerify     *patching.RebootVerificationStore
+	provisioning     *provisioning.Tracker
+	// changelog is the persisted history of agent-affecting changes
+	// (upgrades, config profiles, feature toggles, cert renewals).
+	// changelogSentRev is the last revision successfully reported.
+	changelog        *changelog.Log
+	changelogSentRev atomic.Uint64
 	connectionsCol   *collectors.ConnectionsCollector
 	eventLogCol      *collectors.EventLogCollector
 	bootCol          *collectors.BootPerformanceCollector
 	reliabilityCol   *collectors.ReliabilityCollector
+	processCol       *collectors.ProcessCollector
+	// pluginMgr runs MSP-supplied collector plugins from <config>/plugins.
+	pluginMgr        *plugins.Manager
 	agentVersion     string
 	desktopMgr       *desktop.SessionManager
 	wsDesktopMgr     *desktop.WsSessionManager
@@ -188,7 +223,9 @@ type Heartbeat struct {
 	// nil in production — always constructed in NewWithVersion.
 	backupOutbox          *backupResultOutbox
 	mu                    sync.Mutex
-	lastInventoryUpdate   time.Time
+	// schedule holds collector_schedules overrides and the per-entry last
+	// run of the inventory fan-out. It has its own lock.
+	schedule              *collectorScheduler
 	lastEventLogUpdate    time.Time
 	lastSecurityUpdate    time.Time
 	lastRecoveryKeysFP    string
@@ -198,6 +235,7 @@ type Heartbeat struct {
 	lastReliabilityUpdate time.Time
 	lastHardwareUpdate    time.Time // stamped at startup; gate then re-runs every 24 h
 	lastPatchUpdate       time.Time // stamped at startup; gate then re-runs every PatchScanIntervalHours
+	lastProcessInvUpdate  time.Time // stamped at startup; gate then re-runs every ProcessInventoryIntervalMinutes
 
 	// User session helper (IPC)
 	helperToken     string // retained copy of the helper-scoped token for connect-time pushes
@@ -318,6 +356,9 @@ type Heartbeat struct {
 	// Service & process monitoring
 	monitor *monitoring.Monitor
 
+	// HTTP(S) availability checks against customer intranet apps (synthetics)
+	httpMonitor *monitoring.HTTPMonitor
+
 	// OneDrive helper state captured on config apply, reported next heartbeat.
 	onedriveMu    sync.Mutex
 	onedriveState *onedrivehelper.DeviceState
@@ -476,6 +517,19 @@ func NewWithVersion(cfg *config.Config, version string, token *secmem.SecureStri
 		softwareCol:  collectors.NewSoftwareCollector(),
 		inventoryCol: collectors.NewInventoryCollector(),
 		vpnCol:       collectors.NewVPNCollector(),
+		certificateCol: collectors.NewCertificateCollector(),
+		containerCol:   collectors.NewContainerCollector(),
+		browserExtCol:  collectors.NewBrowserExtensionCollector(),
+		usbDeviceCol: collectors.NewUSBDeviceCollector(
+			filepath.Join(config.GetDataDir(), "usb_devices.json"),
+		),
+		bluetoothCol: collectors.NewBluetoothCollector(),
+		licensingCol: collectors.NewLicensingCollector(),
+		usageCol: collectors.NewSoftwareUsageCollector(
+			filepath.Join(config.GetDataDir(), "software_usage.json"), cfg.SoftwareUsageMetering,
+		),
+		localUserCol: collectors.NewLocalUserCollector(),
+		wifiCol:      collectors.NewWiFiCollector(cfg.WiFiScanNearby),
 		changeTrackerCol: collectors.NewChangeTrackerCollector(
 			filepath.Join(config.GetDataDir(), "change_tracker_snapshot.json"),
 		),
@@ -483,10 +537,18 @@ func NewWithVersion(cfg *config.Config, version string, token *secmem.SecureStri
 		policyStateCol:  collectors.NewPolicyStateCollector(),
 		patchCol:        collectors.NewPatchCollector(),
 		patchMgr:        patching.NewDefaultManager(cfg),
+		appPolicies:     patching.NewAppUpdatePolicyStore(patching.DefaultAppUpdatePolicyPath(config.GetDataDir())),
+		patchCompliance: patching.NewPatchComplianceStore(patching.DefaultPatchCompliancePath(config.GetDataDir())),
+		rebootVerify:    patching.NewRebootVerificationStore(patching.DefaultRebootVerificationPath(config.GetDataDir())),
+		provisioning:    provisioning.NewTracker(filepath.Join(config.GetDataDir(), "provisioning_quiet.json")),
+		changelog:       changelog.Open(filepath.Join(config.GetDataDir(), changelog.FileName)),
 		connectionsCol:  collectors.NewConnectionsCollector(),
 		eventLogCol:     collectors.NewEventLogCollector(),
 		bootCol:         collectors.NewBootPerformanceCollector(),
 		reliabilityCol:  collectors.NewReliabilityCollector(),
+		processCol:      collectors.NewProcessCollector(),
+		pluginMgr:       plugins.NewManager(filepath.Join(config.ConfigDir(), "plugins")),
+		schedule:        newCollectorScheduler(cfg.CollectorSchedules),
 		agentVersion:    version,
 		executor:        executor.New(cfg),
 		desktopMgr:      desktop.NewSessionManager(),
@@ -500,6 +562,8 @@ func NewWithVersion(cfg *config.Config, version string, token *secmem.SecureStri
 		seenCommands:    make(map[string]time.Time),
 		backupOutbox:    newBackupResultOutbox(backupResultOutboxDir()),
 	}
+	// Geolocation reuses the Wi-Fi collector for nearby BSSIDs.
+	h.geoCol = collectors.NewGeolocationCollector(cfg.GeolocationEnabled, cfg.GeolocationLookupURL, h.wifiCol)
 	h.accepting.Store(true)
 	h.isService = cfg.IsService
 	h.isHeadless = cfg.IsHeadless
@@ -579,8 +643,19 @@ func NewWithVersion(cfg *config.Config, version string, token *secmem.SecureStri
 		)
 	}
 
+	// Collectors and patch providers run as throttled background work; the
+	// defaults apply until the server sends resource_limits.
+	throttle.Configure(throttle.DefaultLimits())
+
+	// The file manager path policy starts from agent.yaml until the server
+	// sends file_path_policy.
+	tools.SetFilePathPolicy(tools.FilePathPolicy{Allow: cfg.FileManagerAllowPaths, Deny: cfg.FileManagerDenyPaths})
+	tools.SetRegistryWriteEnabled(cfg.RegistryWriteEnabled)
+
 	// Initialize service & process monitoring
 	h.monitor = monitoring.New(h.sendMonitoringResults)
+	h.httpMonitor = monitoring.NewHTTPMonitor(h.sendSyntheticResults)
+	h.regWatcher = collectors.NewRegistryWatcher(cfg.RegistryWatchKeys, h.sendRegistryChanges)
 
 	// Trigger wallpaper crash recovery (restores wallpaper if agent crashed mid-session)
 	_ = desktop.GetWallpaperManager()
@@ -596,6 +671,12 @@ func NewWithVersion(cfg *config.Config, version string, token *secmem.SecureStri
 		}
 	}
 
+	if cfg.TerminalRecording {
+		h.terminalMgr.RecordDir = terminalRecordingDir()
+		h.terminalMgr.RecordInput = cfg.TerminalRecordInput
+		h.terminalMgr.OnRecordingClosed = h.handleTerminalRecording
+	}
+
 	// Initialize session broker for user helpers (IPC).
 	// Enable IPC session broker when running as a service, headless, or when
 	// explicitly configured. macOS daemons handle desktop capture directly
@@ -610,6 +691,7 @@ func NewWithVersion(cfg *config.Config, version string, token *secmem.SecureStri
 		h.sessionBroker = sessionbroker.New(socketPath, h.handleUserHelperMessage)
 		h.sessionBroker.SetSessionClosedHandler(h.handleHelperSessionClosed)
 		h.sessionBroker.SetSessionAuthenticatedHandler(h.handleHelperSessionAuthenticated)
+		h.sessionBroker.AddSessionLifecycleObserver(h.pushUsageMeteringConfig, nil)
 		// Retain the helper-scoped token so connect-time pushes have it even after
 		// the config copy is cleared post-persist during rotation.
 		h.setHelperToken(h.config.HelperAuthToken)
@@ -638,6 +720,7 @@ func NewWithVersion(cfg *config.Config, version string, token *secmem.SecureStri
 			h.sessionBroker.BroadcastNotification(title, body, urgency)
 		}
 	}, cfg.PatchRebootMaxPerDay)
+	h.rebootMgr.SetPromptFunc(h.promptRebootDeferral)
 
 	// Set backup binary path for IPC forwarding to breeze-backup helper
 	h.backupBinaryPath = cfg.BackupBinaryPath
@@ -655,6 +738,11 @@ func NewWithVersion(cfg *config.Config, version string, token *secmem.SecureStri
 		}
 	}
 
+	// Chat from sessions the service captures itself. Helper-owned sessions
+	// relay chat over IPC instead (handleChatFromHelper).
+	h.desktopMgr.OnChatMessage = h.handleDirectChatMessage
+	h.desktopMgr.OnQualitySample = h.recordQualitySample
+
 	// Clean up any orphaned Screen Sharing left running from a previous crash.
 	h.tunnelMgr.CleanupOrphanedVNC()
 
@@ -676,7 +764,10 @@ func (h *Heartbeat) SetWebSocketClient(ws *websocket.Client) {
 	// set here, before Start() is ever called on ws, so there's no race with
 	// the read pump goroutine that invokes it (terminal-result outbox).
 	if ws != nil {
-		ws.OnConnected = h.flushBackupResultOutbox
+		ws.OnConnected = func() {
+			h.flushBackupResultOutbox()
+			h.flushSecurityAlerts()
+		}
 		// Re-persist any command result that writePump popped but failed to
 		// deliver (conn torn down mid-write, or a WriteMessage error) so it
 		// isn't silently lost after SendResult already reported success. The
@@ -781,6 +872,12 @@ func (h *Heartbeat) handleUserHelperMessage(session *sessionbroker.Session, env
 		}
 		h.forgetDesktopOwner(notice.SessionID)
 		go h.sendDesktopDisconnectNotification(notice.SessionID)
+	case ipc.TypeChatMessage:
+		h.handleChatFromHelper(session, env)
+	case ipc.TypeDesktopQuality:
+		h.handleQualityFromHelper(session, env)
+	case ipc.TypeUsageReport:
+		h.handleUsageReport(session, env)
 	case backupipc.TypeBackupResult:
 		// NOTE: do NOT early-return when wsClient is nil. The outbox needs no
 		// live WS client, and a terminal backup result that arrives during
@@ -883,6 +980,9 @@ func (h *Heartbeat) sendDesktopDisconnectNotification(sessionID string) {
 	// no-op for un-prompted sessions. Done before the wsClient guard so the
 	// local UX still tears down even if the WS link is gone.
 	h.handleConsentSessionEnd(sessionID)
+	h.recordChatTranscript(sessionID)
+	stopTURNRefresh(sessionID)
+	h.flushQualitySamples(sessionID, true)
 
 	if h.wsClient == nil {
 		return
@@ -988,6 +1088,10 @@ func (h *Heartbeat) Start() {
 	// instead of 401-looping.
 	go h.reconcilePendingRotation()
 
+	// Record a version change since the previous run (upgrade completed by
+	// the updater, or an external reinstall) before anything else can fail.
+	h.changelog.RecordStartup(h.agentVersion)
+
 	// Proactively spawn helpers into user sessions so remote desktop works
 	// instantly after reboot (Windows service only). The SCM session event
 	// channel (created in constructor) is fed by the service handler
@@ -1015,6 +1119,10 @@ func (h *Heartbeat) Start() {
 	if h.sessionCol != nil {
 		h.sessionCol.Start(h.stopChan)
 	}
+	go h.terminalIdleLoop(h.stopChan)
+	if h.terminalMgr.RecordDir != "" {
+		go h.uploadPendingTerminalRecordings()
+	}
 
 	// Jitter: random delay before first heartbeat to avoid thundering herd
 	// after mass restart of agents. Skip jitter if restarting after self-update
@@ -1045,6 +1153,11 @@ func (h *Heartbeat) Start() {
 	defer ticker.Stop()
 	const bootCheckInterval = 5 * time.Minute
 	var lastBootCheck time.Time
+	// Hardware tamper check (RAM, disk serials, TPM, MACs) runs on its own
+	// short cadence so a pulled DIMM or swapped disk is reported right after
+	// the reboot rather than on the 15-minute change-tracker cycle.
+	const hardwareChangeCheckInterval = 5 * time.Minute
+	var lastHardwareChangeCheck time.Time
 	// Self-heal a missing breeze-user-helper.exe (Windows), decoupled from
 	// upgrades. Zero-valued timer → fires on the first tick (≈startup), then
 	// every interval after (issue #816 follow-up).
@@ -1052,16 +1165,37 @@ func (h *Heartbeat) Start() {
 	var lastUserHelperCheck time.Time
 
 	// Send initial heartbeat after jitter
+	quiet := h.refreshProvisioningState()
 	h.sendHeartbeatWithWatchdog()
 
 	// Send initial inventory in background. Hardware and patch inventory are not
 	// part of the sendInventory fan-out (they run on a daily cadence), so kick
 	// them off here too — a freshly started/enrolled agent should report hardware
 	// and pending patches promptly rather than waiting for the first daily tick.
-	go h.sendInventory()
-	go h.sendHardwareInventory()
-	go h.sendPatchInventory()
+	// In provisioning quiet mode they are left unstamped below instead, so the
+	// first tick after quiet mode ends sends everything.
+	if !quiet {
+		lastHardwareChangeCheck = time.Now()
+		go h.sendHardwareChanges()
+		go h.sendInventory()
+		if h.schedule.enabled(collectorHardware) {
+			go h.sendHardwareInventory()
+		}
+		if h.schedule.enabled(collectorPatches) {
+			go h.sendPatchInventory()
+		}
+		if h.schedule.enabled(collectorProcessInventory) {
+			go h.sendProcessInventory()
+		}
+	}
 	go h.runProcessSampler()
+	go h.runDeferredPatchLoop()
+	go h.runPeripheralEnforcementLoop()
+	go h.restoreNetworkIsolation()
+	go h.runPostRebootPatchVerification()
+	if h.regWatcher != nil {
+		h.regWatcher.Start()
+	}
 
 	// Reliability cadence persists across restarts (#1906). Seed the in-memory
 	// timer from the last persisted post instead of "now", and only post on
@@ -1074,7 +1208,7 @@ func (h *Heartbeat) Start() {
 	// so a failed startup post still retries after the next restart.
 	startupNow := time.Now()
 	persistedReliability := h.loadLastReliabilityUpdate()
-	postReliability := reliabilityPostDue(persistedReliability, startupNow)
+	postReliability := reliabilityPostDue(persistedReliability, startupNow) && !quiet && h.schedule.enabled(collectorReliability)
 	h.mu.Lock()
 	h.lastPostureUpdate = startupNow
 	if postReliability {
@@ -1082,8 +1216,11 @@ func (h *Heartbeat) Start() {
 	} else {
 		h.lastReliabilityUpdate = persistedReliability
 	}
-	h.lastHardwareUpdate = startupNow
-	h.lastPatchUpdate = startupNow
+	if !quiet {
+		h.lastHardwareUpdate = startupNow
+		h.lastPatchUpdate = startupNow
+		h.lastProcessInvUpdate = startupNow
+	}
 	h.mu.Unlock()
 	if postReliability {
 		go h.sendReliabilityMetrics(startupNow)
@@ -1110,48 +1247,65 @@ func (h *Heartbeat) Start() {
 				// scheduling — all of that work requires a valid auth token.
 				continue
 			}
+			quiet := h.refreshProvisioningState()
 			h.sendHeartbeatWithWatchdog()
+			if quiet {
+				// Provisioning quiet mode: heartbeat only. Inventory timers are
+				// left unstamped so everything is sent once quiet mode ends.
+				continue
+			}
 			now := time.Now()
-			// Send inventory every 15 minutes
+			// Each gate below uses its built-in cadence unless collector_schedules
+			// overrides it; a disabled collector is never due and stays unstamped,
+			// so it runs on the first tick after being re-enabled.
+			sched := h.schedule
 			h.mu.Lock()
-			shouldSendInventory := now.Sub(h.lastInventoryUpdate) > 15*time.Minute
-			if shouldSendInventory {
-				h.lastInventoryUpdate = now
-			}
-			shouldSendEventLogs := now.Sub(h.lastEventLogUpdate) > time.Duration(h.eventLogCol.IntervalMinutes())*time.Minute
+			shouldSendEventLogs := sched.enabled(collectorEventLogs) &&
+				now.Sub(h.lastEventLogUpdate) > sched.interval(collectorEventLogs, time.Duration(h.eventLogCol.IntervalMinutes())*time.Minute)
 			if shouldSendEventLogs {
 				h.lastEventLogUpdate = now
 			}
-			shouldSendSecurity := now.Sub(h.lastSecurityUpdate) > 5*time.Minute
+			shouldSendSecurity := sched.enabled(collectorSecurity) &&
+				now.Sub(h.lastSecurityUpdate) > sched.interval(collectorSecurity, 5*time.Minute)
 			if shouldSendSecurity {
 				h.lastSecurityUpdate = now
 			}
-			shouldSendSessions := now.Sub(h.lastSessionUpdate) > 5*time.Minute
+			shouldSendSessions := sched.enabled(collectorSessions) &&
+				now.Sub(h.lastSessionUpdate) > sched.interval(collectorSessions, 5*time.Minute)
 			if shouldSendSessions {
 				h.lastSessionUpdate = now
 			}
-			shouldSendPosture := now.Sub(h.lastPostureUpdate) > 15*time.Minute
+			shouldSendPosture := sched.enabled(collectorPosture) &&
+				now.Sub(h.lastPostureUpdate) > sched.interval(collectorPosture, 15*time.Minute)
 			if shouldSendPosture {
 				h.lastPostureUpdate = now
 			}
-			shouldSendReliability := reliabilityPostDue(h.lastReliabilityUpdate, now)
+			shouldSendReliability := sched.enabled(collectorReliability) &&
+				now.Sub(h.lastReliabilityUpdate) > sched.interval(collectorReliability, reliabilityPostInterval)
 			if shouldSendReliability {
 				h.lastReliabilityUpdate = now
 			}
 			// Hardware identity rarely changes; collect once per day. The initial
 			// send happens via the explicit startup dispatch (see Start), which
 			// stamps lastHardwareUpdate; this gate handles every subsequent day.
-			shouldSendHardware := dueForRun(now, h.lastHardwareUpdate, 24*time.Hour)
+			shouldSendHardware := sched.enabled(collectorHardware) &&
+				dueForRun(now, h.lastHardwareUpdate, sched.interval(collectorHardware, 24*time.Hour))
 			if shouldSendHardware {
 				h.lastHardwareUpdate = now
 			}
 			// Patch scan cadence is configurable (PatchScanIntervalHours, default 24 h).
 			// Initial send is the explicit startup dispatch; this gate handles the rest.
 			patchIntervalHours := clampPatchScanIntervalHours(h.config.PatchScanIntervalHours)
-			shouldSendPatch := dueForRun(now, h.lastPatchUpdate, time.Duration(patchIntervalHours)*time.Hour)
+			shouldSendPatch := sched.enabled(collectorPatches) &&
+				dueForRun(now, h.lastPatchUpdate, sched.interval(collectorPatches, time.Duration(patchIntervalHours)*time.Hour))
 			if shouldSendPatch {
 				h.lastPatchUpdate = now
 			}
+			shouldSendProcessInv := sched.enabled(collectorProcessInventory) &&
+				dueForRun(now, h.lastProcessInvUpdate, sched.interval(collectorProcessInventory, h.processInventoryInterval()))
+			if shouldSendProcessInv {
+				h.lastProcessInvUpdate = now
+			}
 			h.mu.Unlock()
 
 			// Check for recent boot every few minutes (not every heartbeat tick).
@@ -1182,6 +1336,11 @@ func (h *Heartbeat) Start() {
 				}
 			}
 
+			if now.Sub(lastHardwareChangeCheck) >= hardwareChangeCheckInterval {
+				lastHardwareChangeCheck = now
+				go h.sendHardwareChanges()
+			}
+
 			// Reconcile a missing user-helper binary on Windows (issue #816
 			// follow-up). Gated on an interval; the download only happens on the
 			// genuine-absence path. Runs in a goroutine because it does network
@@ -1195,9 +1354,10 @@ func (h *Heartbeat) Start() {
 				}()
 			}
 
-			if shouldSendInventory {
-				go h.sendInventory()
-			}
+			// Inventory fan-out entries are gated individually (default 15 min).
+			go h.sendDueInventory(now)
+			// Collector plugins carry their own per-plugin intervals.
+			go h.runCollectorPlugins()
 			// Send event logs every 5 minutes
 			if shouldSendEventLogs {
 				go h.sendEventLogs()
@@ -1224,6 +1384,9 @@ func (h *Heartbeat) Start() {
 			if shouldSendPatch {
 				go h.sendPatchInventory()
 			}
+			if shouldSendProcessInv {
+				go h.sendProcessInventory()
+			}
 		case <-h.stopChan:
 			return
 		}
@@ -1318,6 +1481,9 @@ func (h *Heartbeat) Stop() {
 		if h.monitor != nil {
 			h.monitor.Stop()
 		}
+		if h.httpMonitor != nil {
+			h.httpMonitor.Stop()
+		}
 		if h.auditLog != nil {
 			h.auditLog.Log(audit.EventAgentStop, "", nil)
 			h.auditLog.Close()
@@ -1328,6 +1494,12 @@ func (h *Heartbeat) Stop() {
 		if h.tunnelMgr != nil {
 			h.tunnelMgr.Stop()
 		}
+		if h.connectionsCol != nil {
+			h.connectionsCol.Close()
+		}
+		if h.regWatcher != nil {
+			h.regWatcher.Stop()
+		}
 	})
 }
 
@@ -1368,32 +1540,81 @@ func (h *Heartbeat) sendMonitoringResults(results []monitoring.CheckResult) {
 	}
 }
 
-// sendInventory collects and sends the 15-minute inventory set: software, disk,
-// network, configuration changes, connections, policy registry/config state, and
-// Apple warranty info. All goroutines are tracked via inventoryWg for graceful shutdown.
+// sendSyntheticResults ships a batch of HTTP(S) availability check results.
+// Results are dropped in provisioning quiet mode.
+func (h *Heartbeat) sendSyntheticResults(results []monitoring.HTTPCheckResult) {
+	if len(results) == 0 || h.provisioning.Active() {
+		return
+	}
+	payload := map[string]any{"results": results}
+	_ = h.sendInventoryData("synthetics", payload, fmt.Sprintf("synthetic http checks (%d)", len(results)))
+}
+
+// sendInventory collects and sends the whole inventory set: software, disk,
+// network, configuration changes, connections, per-process network usage,
+// policy registry/config state, Apple warranty info, machine certificates,
+// container runtimes, browser extensions, USB and Bluetooth devices, Windows
+// licensing, software usage, local users/groups, Wi-Fi and the agent changelog. Collectors disabled via
+// collector_schedules are skipped; the rest are re-stamped so the periodic
+// gate restarts their interval. All goroutines are tracked via inventoryWg
+// for graceful shutdown.
 //
 // Note: hardware inventory, patch inventory, security status, and session inventory
 // are intentionally absent here — each runs on its own independent cadence:
 //   - hardware / patch:   daily (or configured), dispatched from the tick gate
 //   - security / sessions: every 5 minutes, dispatched from their own tick gates
 func (h *Heartbeat) sendInventory() {
-	fns := []func(){
-		h.sendSoftwareInventory,
-		h.sendDiskInventory,
-		h.sendNetworkInventory,
-		h.sendConfigurationChanges,
-		h.sendConnectionsInventory,
-		h.sendPolicyRegistryState,
-		h.sendPolicyConfigState,
-		h.sendAppleWarrantyInfo,
-	}
-	for _, fn := range fns {
+	h.dispatchInventory(time.Now(), true)
+}
+
+// sendDueInventory runs the inventory entries whose interval (15 minutes by
+// default, or the collector_schedules override) has elapsed.
+func (h *Heartbeat) sendDueInventory(now time.Time) {
+	h.dispatchInventory(now, false)
+}
+
+func (h *Heartbeat) dispatchInventory(now time.Time, force bool) {
+	entries := []struct {
+		name string
+		fn   func()
+	}{
+		{collectorSoftware, h.sendSoftwareInventory},
+		{collectorDisks, h.sendDiskInventory},
+		{collectorNetwork, h.sendNetworkInventory},
+		{collectorChanges, h.sendConfigurationChanges},
+		{collectorConnections, h.sendConnectionsInventory},
+		{collectorProcessNetwork, h.sendProcessNetworkUsage},
+		{collectorPolicyState, h.sendPolicyRegistryState},
+		{collectorPolicyState, h.sendPolicyConfigState},
+		{collectorWarranty, h.sendAppleWarrantyInfo},
+		{collectorCertificates, h.sendCertificateInventory},
+		{collectorContainers, h.sendContainerInventory},
+		{collectorBrowserExtensions, h.sendBrowserExtensionInventory},
+		{collectorUSBDevices, h.sendUSBDeviceInventory},
+		{collectorBluetoothDevices, h.sendBluetoothInventory},
+		{collectorLicensing, h.sendLicensingInventory},
+		{collectorSoftwareUsage, h.sendSoftwareUsageInventory},
+		{collectorLocalUsers, h.sendLocalUserInventory},
+		{collectorWiFi, h.sendWiFiInventory},
+		{collectorChangelog, h.sendAgentChangelog},
+		{collectorVulnerabilities, h.sendVulnerabilityScan},
+	}
+	due := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		run, seen := due[e.name]
+		if !seen {
+			run = h.schedule.take(e.name, now, force)
+			due[e.name] = run
+		}
+		if !run {
+			continue
+		}
 		h.inventoryWg.Add(1)
 		go func(f func()) {
 			defer h.inventoryWg.Done()
 			defer observability.Recoverer("heartbeat.inventory")
 			f()
-		}(fn)
+		}(e.fn)
 	}
 }
 
@@ -1501,6 +1722,9 @@ func (h *Heartbeat) runProcessSampler() {
 				if h.authMon != nil && h.authMon.ShouldSkip() {
 					return
 				}
+				if h.provisioning.Active() {
+					return
+				}
 				h.sendProcessSample()
 			}()
 		case <-h.stopChan:
@@ -1705,6 +1929,41 @@ func (h *Heartbeat) sendConfigurationChanges() {
 	h.sendInventoryData("changes", map[string]any{"changes": changes}, fmt.Sprintf("changes (%d)", len(changes)))
 }
 
+// sendRegistryChanges uploads registry changes from the real-time watcher as
+// they happen. Dropped in provisioning quiet mode like other change reports.
+func (h *Heartbeat) sendRegistryChanges(changes []collectors.ChangeRecord) {
+	defer observability.Recoverer("heartbeat.registryChanges")
+	if len(changes) == 0 || h.provisioning.Active() {
+		return
+	}
+	h.sendInventoryData("changes", map[string]any{"changes": changes}, fmt.Sprintf("registry changes (%d)", len(changes)))
+}
+
+// sendHardwareChanges runs the change tracker's hardware-only diff and
+// uploads any records straight away; high-priority ones (memory, disk or TPM
+// removed) are logged as possible tampering.
+func (h *Heartbeat) sendHardwareChanges() {
+	defer observability.Recoverer("heartbeat.hardwareChanges")
+	if h.changeTrackerCol == nil {
+		return
+	}
+
+	changes, err := h.changeTrackerCol.CollectHardwareChanges()
+	if err != nil {
+		log.Error("failed to collect hardware changes", "error", err.Error())
+	}
+	if len(changes) == 0 {
+		return
+	}
+	for _, change := range changes {
+		if change.Priority == collectors.ChangePriorityHigh {
+			log.Warn("hardware change detected", "subject", change.Subject, "action", string(change.ChangeAction))
+		}
+	}
+
+	h.sendInventoryData("changes", map[string]any{"changes": changes}, fmt.Sprintf("hardware changes (%d)", len(changes)))
+}
+
 func (h *Heartbeat) policyRegistryProbes() []collectors.RegistryProbe {
 	h.mu.Lock()
 	configured := slices.Clone(h.config.PolicyRegistryStateProbes)
@@ -1753,6 +2012,29 @@ func normalizeProbeKey(value string) string {
 	return strings.ToLower(strings.TrimSpace(value))
 }
 
+// parseStringList decodes a JSON array of strings, dropping blank entries.
+// A null payload is an empty list.
+func parseStringList(raw any) ([]string, bool) {
+	if raw == nil {
+		return nil, true
+	}
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		typed, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		if typed = strings.TrimSpace(typed); typed != "" {
+			out = append(out, typed)
+		}
+	}
+	return out, true
+}
+
 func parsePolicyRegistryProbeList(raw any) ([]config.PolicyRegistryStateProbe, bool) {
 	items, ok := raw.([]any)
 	if !ok {
@@ -1953,6 +2235,29 @@ func (h *Heartbeat) applyBackupServerURLConfig(raw any) {
 	}
 }
 
+// applyFilePathPolicyConfig replaces the file manager path policy. A null
+// policy clears it back to the built-in deny lists only.
+func (h *Heartbeat) applyFilePathPolicyConfig(raw any) {
+	var policy tools.FilePathPolicy
+	if raw != nil {
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			log.Warn("ignoring invalid file_path_policy config update payload")
+			return
+		}
+		allow, okAllow := parseStringList(obj["allow"])
+		deny, okDeny := parseStringList(obj["deny"])
+		if !okAllow || !okDeny {
+			log.Warn("ignoring invalid file_path_policy config update payload")
+			return
+		}
+		policy = tools.FilePathPolicy{Allow: allow, Deny: deny}
+	}
+	tools.SetFilePathPolicy(policy)
+	current := tools.CurrentFilePathPolicy()
+	log.Info("file path policy updated", "allow", len(current.Allow), "deny", len(current.Deny))
+}
+
 func (h *Heartbeat) applyConfigUpdate(update map[string]any) {
 	if len(update) == 0 {
 		return
@@ -1979,6 +2284,31 @@ func (h *Heartbeat) applyConfigUpdate(update map[string]any) {
 		}
 	}
 
+	// Apply resource_limits if present: CPU budget and low-priority switch
+	// for collectors and patch providers.
+	rlRaw, hasRL := update["resource_limits"]
+	if !hasRL {
+		rlRaw, hasRL = update["resourceLimits"]
+	}
+	if hasRL {
+		if limits, ok := throttle.ParseLimits(rlRaw); ok && limits != throttle.Current() {
+			throttle.Configure(limits)
+			log.Info("resource limits updated", "cpuBudgetPercent", limits.CPUBudgetPercent, "lowPriority", limits.LowPriority)
+		}
+	}
+
+	// Apply http_monitors if present: the full list of synthetic HTTP(S)
+	// checks for this device. An empty list stops the scheduler.
+	httpRaw, hasHTTP := update["http_monitors"]
+	if !hasHTTP {
+		httpRaw, hasHTTP = update["httpMonitors"]
+	}
+	if hasHTTP && h.httpMonitor != nil {
+		if monitors, ok := monitoring.ParseHTTPMonitorConfigs(httpRaw); ok {
+			h.httpMonitor.ApplyConfig(monitors)
+		}
+	}
+
 	// Apply patch_source_settings if present (#1872): enforce/revert Breeze as
 	// the sole Windows Update source. No-op on non-Windows.
 	psRaw, hasPS := update["patch_source_settings"]
@@ -1989,6 +2319,46 @@ func (h *Heartbeat) applyConfigUpdate(update map[string]any) {
 		h.applyPatchSourceConfig(psRaw)
 	}
 
+	// Apply patch_maintenance_windows if present: installs and patch reboots
+	// outside these windows are queued until the next one opens.
+	pmRaw, hasPM := update["patch_maintenance_windows"]
+	if !hasPM {
+		pmRaw, hasPM = update["patchMaintenanceWindows"]
+	}
+	if hasPM {
+		h.applyPatchMaintenanceConfig(pmRaw)
+	}
+
+	// Apply app_update_policies if present: per-app update rings for
+	// third-party providers (winget, chocolatey, homebrew).
+	auRaw, hasAU := update["app_update_policies"]
+	if !hasAU {
+		auRaw, hasAU = update["appUpdatePolicies"]
+	}
+	if hasAU {
+		h.applyAppUpdatePolicyConfig(auRaw)
+	}
+
+	// Apply patch_compliance_policy if present: maximum pending age for
+	// critical/important updates, evaluated on the device after every scan.
+	pcRaw, hasPC := update["patch_compliance_policy"]
+	if !hasPC {
+		pcRaw, hasPC = update["patchCompliancePolicy"]
+	}
+	if hasPC {
+		h.applyPatchCompliancePolicyConfig(pcRaw)
+	}
+
+	// Apply patch_reboot_deferral if present: how many times the logged-in
+	// user may postpone a patch reboot from the warning prompt.
+	rdRaw, hasRD := update["patch_reboot_deferral"]
+	if !hasRD {
+		rdRaw, hasRD = update["patchRebootDeferral"]
+	}
+	if hasRD {
+		h.applyPatchRebootDeferralConfig(rdRaw)
+	}
+
 	// Backup control-plane URL (#2288). Key absent = no change; present
 	// empty string = clear. Snake_case and camelCase both accepted.
 	bsRaw, hasBS := update["backup_server_url"]
@@ -1999,6 +2369,89 @@ func (h *Heartbeat) applyConfigUpdate(update map[string]any) {
 		h.applyBackupServerURLConfig(bsRaw)
 	}
 
+	// Per-collector cadence and enable/disable overrides.
+	csRaw, hasCS := update["collector_schedules"]
+	if !hasCS {
+		csRaw, hasCS = update["collectorSchedules"]
+	}
+	if hasCS {
+		h.applyCollectorScheduleConfig(csRaw)
+	}
+
+	// Replace the real-time registry watch list; an empty list restores the
+	// defaults.
+	rwRaw, hasRW := update["registry_watch_keys"]
+	if !hasRW {
+		rwRaw, hasRW = update["registryWatchKeys"]
+	}
+	if hasRW && h.regWatcher != nil {
+		if keys, ok := parseStringList(rwRaw); !ok {
+			log.Warn("ignoring invalid registry_watch_keys config update payload")
+		} else if h.regWatcher.SetKeys(keys) {
+			log.Info("registry watch list updated", "keys", len(h.regWatcher.Keys()))
+		}
+	}
+
+	// Replace the file manager path policy ({"allow": [...], "deny": [...]}).
+	fpRaw, hasFP := update["file_path_policy"]
+	if !hasFP {
+		fpRaw, hasFP = update["filePathPolicy"]
+	}
+	if hasFP {
+		h.applyFilePathPolicyConfig(fpRaw)
+	}
+
+	// Registry editing is gated by org policy on the server.
+	regWriteRaw, hasRegWrite := update["registry_write_enabled"]
+	if !hasRegWrite {
+		regWriteRaw, hasRegWrite = update["registryWriteEnabled"]
+	}
+	if on, ok := regWriteRaw.(bool); hasRegWrite && ok && on != tools.RegistryWriteEnabled() {
+		tools.SetRegistryWriteEnabled(on)
+		log.Info("registry editing updated", "enabled", on)
+	}
+
+	// Nearby Wi-Fi network reporting is opt-in per device.
+	wsRaw, hasWS := update["wifi_scan_nearby"]
+	if !hasWS {
+		wsRaw, hasWS = update["wifiScanNearby"]
+	}
+	if on, ok := wsRaw.(bool); hasWS && ok && h.wifiCol != nil && on != h.wifiCol.ScanNearby() {
+		h.wifiCol.SetScanNearby(on)
+		log.Info("wifi nearby scan updated", "enabled", on)
+	}
+
+	// Software usage metering is opt-in per device; helpers follow the toggle.
+	umRaw, hasUM := update["software_usage_metering"]
+	if !hasUM {
+		umRaw, hasUM = update["softwareUsageMetering"]
+	}
+	if on, ok := umRaw.(bool); hasUM && ok && h.usageCol != nil && on != h.usageCol.Enabled() {
+		h.usageCol.SetEnabled(on)
+		h.broadcastUsageMeteringConfig()
+		log.Info("software usage metering updated", "enabled", on)
+	}
+
+	// Geolocation is gated by org/site policy on the server; disabling it
+	// forgets the last fix so nothing further is reported.
+	geoRaw, hasGeo := update["geolocation_enabled"]
+	if !hasGeo {
+		geoRaw, hasGeo = update["geolocationEnabled"]
+	}
+	if on, ok := geoRaw.(bool); hasGeo && ok && h.geoCol != nil && on != h.geoCol.Enabled() {
+		h.geoCol.SetEnabled(on)
+		log.Info("geolocation updated", "enabled", on)
+	}
+
+	// Ransomware canaries are enabled by org policy on the server.
+	rcRaw, hasRC := update["ransomware_canary"]
+	if !hasRC {
+		rcRaw, hasRC = update["ransomwareCanary"]
+	}
+	if hasRC {
+		h.applyRansomwareCanaryConfig(rcRaw)
+	}
+
 	// Apply onedrive_helper_settings if present (Phase 2). No-op on non-Windows.
 	odRaw, hasOD := update["onedrive_helper_settings"]
 	if !hasOD {
@@ -2132,6 +2585,35 @@ func (h *Heartbeat) applyEventLogConfig(raw any) {
 		interval = asInt("collectionIntervalMinutes")
 	}
 
+	// queries replaces the whole custom query set; an empty list clears it.
+	queriesRaw, hasQueries := m["queries"]
+	if hasQueries {
+		h.applyEventLogQueries(queriesRaw)
+	}
+
+	// Linux syslog tail filters. An explicit empty unit list tails every
+	// unit.
+	unitsKey := "syslog_units"
+	if _, ok := m[unitsKey]; !ok {
+		unitsKey = "syslogUnits"
+	}
+	_, hasUnits := m[unitsKey]
+	syslogLevel := asString("syslog_minimum_level")
+	if syslogLevel == "" {
+		syslogLevel = asString("syslogMinimumLevel")
+	}
+	hasSyslog := hasUnits || syslogLevel != ""
+	if hasSyslog {
+		units, _ := h.eventLogCol.SyslogFilter()
+		if hasUnits {
+			units = asStringSlice(unitsKey)
+		}
+		if h.eventLogCol.SetSyslogFilter(units, syslogLevel) {
+			units, level := h.eventLogCol.SyslogFilter()
+			log.Info("syslog filter updated", "units", units, "minimumLevel", level)
+		}
+	}
+
 	if maxEvents > 0 || len(categories) > 0 || minLevel != "" || interval > 0 {
 		changed := h.eventLogCol.UpdateConfig(maxEvents, categories, minLevel, interval)
 		if changed {
@@ -2150,7 +2632,7 @@ func (h *Heartbeat) applyEventLogConfig(raw any) {
 			}
 			log.Info("applied event log config update", logFields...)
 		}
-	} else if len(m) > 0 {
+	} else if len(m) > 0 && !hasQueries && !hasSyslog {
 		keys := make([]string, 0, len(m))
 		for k := range m {
 			keys = append(keys, k)
@@ -2159,6 +2641,29 @@ func (h *Heartbeat) applyEventLogConfig(raw any) {
 	}
 }
 
+// applyEventLogQueries decodes event_log_settings.queries into the event log
+// collector's custom queries. Invalid entries are logged and skipped.
+func (h *Heartbeat) applyEventLogQueries(raw any) {
+	var queries []collectors.EventLogQuery
+	if raw != nil {
+		data, err := json.Marshal(raw)
+		if err == nil {
+			err = json.Unmarshal(data, &queries)
+		}
+		if err != nil {
+			log.Warn("ignoring invalid event_log_settings.queries", "error", err.Error())
+			return
+		}
+	}
+	changed, errs := h.eventLogCol.SetQueries(queries)
+	for _, err := range errs {
+		log.Warn("skipping event log query", "error", err.Error())
+	}
+	if changed {
+		log.Info("event log queries updated", "count", len(h.eventLogCol.Queries()))
+	}
+}
+
 func (h *Heartbeat) sendPolicyRegistryState() {
 	entries, err := h.policyStateCol.CollectRegistryState(h.policyRegistryProbes())
 	if err != nil {
@@ -2196,6 +2701,7 @@ func (h *Heartbeat) sendPatchInventory() {
 	if err != nil {
 		log.Warn("patch inventory collection warning", "error", err.Error())
 	}
+	recordPatchPosture(pendingItems, installedItems, err)
 	installedItems = installedPatchStateItems(installedItems)
 
 	if len(pendingItems) == 0 && len(installedItems) == 0 {
@@ -2215,7 +2721,7 @@ func (h *Heartbeat) sendPatchInventory() {
 // sendPatchInventoryData uploads pending then installed patch inventory.
 // coveredSources only applies to full uploads: when non-nil it tells the API
 // which source buckets this scan actually covered, so pending rows from
-// skipped providers (e.g. winget without a helper session) aren't swept to
+// skipped providers (e.g. winget not resolved yet) aren't swept to
 // 'missing' (#2217). A nil coveredSources preserves the legacy full sweep.
 func (h *Heartbeat) sendPatchInventoryData(pendingItems, installedItems []map[string]any, source string, full bool, coveredSources []string) (error, error) {
 	installedItems = installedPatchStateItems(installedItems)
@@ -2229,6 +2735,7 @@ func (h *Heartbeat) sendPatchInventoryData(pendingItems, installedItems []map[st
 		if coveredSources != nil {
 			pendingPayload["coveredSources"] = coveredSources
 		}
+		pendingPayload["rebootDeferral"] = h.patchRebootDeferralReport()
 	}
 
 	pendingErr := h.sendInventoryData(
@@ -2273,12 +2780,15 @@ func (h *Heartbeat) collectPatchInventory() ([]map[string]any, []map[string]any,
 		pendingItems := h.availablePatchesToMaps(available)
 		installedItems := h.installedPatchesToMaps(installed)
 		coveredSources := h.coveredPatchSources(h.patchMgr.ProviderIDs(), coveredProviders)
+		h.reportAppUpdateCompliance(available, installed)
+		h.reportPatchCompliance(available, coveredProviders)
+		h.verifyPatchesAfterReboot(available, installed, installedErr, coveredProviders)
 
 		// Surface the coverage decision so a field operator can correlate a
 		// narrowed full-scan sweep on the server with which providers actually
 		// ran on the agent (#2217). When some registered source buckets weren't
-		// covered (a provider was skipped/failed — e.g. winget with no helper
-		// session), log at Info which buckets will NOT be swept this scan, so the
+		// covered (a provider was skipped/failed — e.g. winget not resolved
+		// yet), log at Info which buckets will NOT be swept this scan, so the
 		// chronically-unswept-bucket case is explainable in the field rather than
 		// mute on the happy path. Full coverage stays at Debug.
 		if uncovered := h.uncoveredPatchSources(h.patchMgr.ProviderIDs(), coveredSources); len(uncovered) > 0 {
@@ -2315,15 +2825,9 @@ func (h *Heartbeat) collectPatchInventory() ([]map[string]any, []map[string]any,
 // everything was skipped serializes as an empty coveredSources array (sweep
 // nothing) rather than being omitted (legacy sweep-all).
 //
-// INTERIM LIMITATION (until #2216 lands): the coverage mechanism keys off
-// providers returning patching.ErrScanSkipped, but the current winget provider
-// still returns (nil, nil) when it can't run (no connected user helper session)
-// instead of the sentinel. So a skipped winget is counted as "scanned and found
-// nothing" and its third_party bucket is treated as COVERED — the coverage guard
-// is inert-but-correct for winget: it never wrongly narrows the sweep, it just
-// can't yet protect winget's own rows. #2216 splits winget.go and has its SYSTEM
-// provider adopt ErrScanSkipped, at which point this mechanism becomes fully
-// effective for winget with no change here. Do not edit winget.go for #2217.
+// The machine-scope winget provider returns patching.ErrScanSkipped while
+// winget cannot be resolved (headless or freshly imaged machines), so its
+// third_party bucket is left unswept until it scans for real.
 func (h *Heartbeat) coveredPatchSources(providerIDs, coveredProviders []string) []string {
 	coveredSet := make(map[string]bool, len(coveredProviders))
 	for _, id := range coveredProviders {
@@ -2510,7 +3014,7 @@ func (h *Heartbeat) mapPatchSource(source string) string {
 		return "apple"
 	case "microsoft":
 		return "microsoft"
-	case "apt", "yum", "dnf":
+	case "apt", "yum", "dnf", "zypper", "pacman":
 		return "linux"
 	default:
 		return "custom"
@@ -2519,7 +3023,7 @@ func (h *Heartbeat) mapPatchSource(source string) string {
 
 func (h *Heartbeat) mapPatchProviderSource(provider string) string {
 	switch provider {
-	case "windows-update":
+	case "windows-update", "windows-drivers":
 		return "microsoft"
 	case "apple-softwareupdate":
 		return "apple"
@@ -2527,9 +3031,9 @@ func (h *Heartbeat) mapPatchProviderSource(provider string) string {
 		return "third_party"
 	case "chocolatey":
 		return "third_party"
-	case "winget":
+	case "winget", "snap", "flatpak", "mas":
 		return "third_party"
-	case "apt", "yum":
+	case "apt", "yum", "dnf", "zypper", "pacman":
 		return "linux"
 	default:
 		return "custom"
@@ -2540,9 +3044,11 @@ func (h *Heartbeat) mapPatchProviderCategory(provider string) string {
 	switch provider {
 	case "windows-update", "apple-softwareupdate":
 		return "system"
-	case "homebrew", "chocolatey", "winget":
+	case "windows-drivers":
+		return "driver"
+	case "homebrew", "chocolatey", "winget", "snap", "flatpak", "mas":
 		return "application"
-	case "apt", "yum":
+	case "apt", "yum", "dnf", "zypper", "pacman":
 		return "system"
 	default:
 		return "application"
@@ -2597,6 +3103,192 @@ func (h *Heartbeat) sendConnectionsInventory() {
 	h.sendInventoryData("connections", map[string]any{"connections": items}, fmt.Sprintf("connections (%d active)", len(connections)))
 }
 
+// runCollectorPlugins runs every due collector plugin and uploads its result
+// (output or failure) to plugins/<endpoint>. Runs are tracked by inventoryWg
+// and cancelled when the agent stops.
+func (h *Heartbeat) runCollectorPlugins() {
+	if h.pluginMgr == nil {
+		return
+	}
+	found, errs := h.pluginMgr.Discover()
+	for _, err := range errs {
+		log.Warn("collector plugin rejected", "dir", h.pluginMgr.Dir(), "error", err.Error())
+	}
+	for _, p := range h.pluginMgr.Due(found) {
+		h.inventoryWg.Add(1)
+		go func(p plugins.Plugin) {
+			defer h.inventoryWg.Done()
+			defer observability.Recoverer("heartbeat.collectorPlugin")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				select {
+				case <-h.stopChan:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			result := h.pluginMgr.Run(ctx, p)
+			if result.Error != "" {
+				log.Warn("collector plugin failed", "plugin", p.Name, "error", result.Error)
+			}
+			h.sendInventoryData("plugins/"+p.Endpoint, result, fmt.Sprintf("collector plugin %s", p.Name))
+		}(p)
+	}
+}
+
+// sendProcessNetworkUsage reports bytes sent/received per process since the
+// previous inventory pass. The first pass after startup only baselines.
+func (h *Heartbeat) sendProcessNetworkUsage() {
+	if h.connectionsCol == nil {
+		return
+	}
+	report, err := h.connectionsCol.CollectProcessUsage()
+	if err != nil {
+		log.Error("failed to collect per-process network usage", "error", err.Error())
+		return
+	}
+	if report == nil {
+		return
+	}
+
+	h.sendInventoryData("process-network", report, fmt.Sprintf("process network usage (%d processes)", len(report.Processes)))
+}
+
+func (h *Heartbeat) sendCertificateInventory() {
+	if h.certificateCol == nil {
+		return
+	}
+	certs, err := h.certificateCol.Collect()
+	if err != nil {
+		log.Error("failed to collect machine certificates", "error", err.Error())
+		return
+	}
+
+	h.sendInventoryData("certificates", map[string]any{"certificates": certs}, fmt.Sprintf("certificates (%d)", len(certs)))
+}
+
+func (h *Heartbeat) sendContainerInventory() {
+	if h.containerCol == nil {
+		return
+	}
+	inv, err := h.containerCol.Collect()
+	if err != nil {
+		log.Error("failed to collect container inventory", "error", err.Error())
+		return
+	}
+	if len(inv.Runtimes) == 0 {
+		log.Debug("no container runtime detected")
+		return
+	}
+
+	containers := 0
+	for _, rt := range inv.Runtimes {
+		containers += len(rt.Containers)
+	}
+	h.sendInventoryData("containers", inv, fmt.Sprintf("containers (%d runtimes, %d containers)", len(inv.Runtimes), containers))
+}
+
+func (h *Heartbeat) sendBrowserExtensionInventory() {
+	if h.browserExtCol == nil {
+		return
+	}
+	exts, err := h.browserExtCol.Collect()
+	if err != nil {
+		log.Error("failed to collect browser extensions", "error", err.Error())
+		return
+	}
+
+	h.sendInventoryData("browser-extensions", map[string]any{"extensions": exts}, fmt.Sprintf("browser extensions (%d)", len(exts)))
+}
+
+func (h *Heartbeat) sendUSBDeviceInventory() {
+	if h.usbDeviceCol == nil {
+		return
+	}
+	devices, err := h.usbDeviceCol.Collect()
+	if err != nil {
+		log.Error("failed to collect USB devices", "error", err.Error())
+		return
+	}
+
+	h.sendInventoryData("usb-devices", map[string]any{"devices": devices}, fmt.Sprintf("USB devices (%d)", len(devices)))
+}
+
+func (h *Heartbeat) sendLocalUserInventory() {
+	if h.localUserCol == nil {
+		return
+	}
+	inv, err := h.localUserCol.Collect()
+	if err != nil {
+		log.Error("failed to collect local users", "error", err.Error())
+		return
+	}
+
+	h.sendInventoryData("local-users", inv, fmt.Sprintf("local users (%d users, %d groups)", len(inv.Users), len(inv.Groups)))
+}
+
+func (h *Heartbeat) sendBluetoothInventory() {
+	if h.bluetoothCol == nil {
+		return
+	}
+	devices, err := h.bluetoothCol.Collect()
+	if err != nil {
+		log.Error("failed to collect bluetooth devices", "error", err.Error())
+		return
+	}
+
+	h.sendInventoryData("bluetooth-devices", map[string]any{"devices": devices}, fmt.Sprintf("bluetooth devices (%d)", len(devices)))
+}
+
+func (h *Heartbeat) sendLicensingInventory() {
+	if h.licensingCol == nil || runtime.GOOS != "windows" {
+		return
+	}
+	info, err := h.licensingCol.Collect()
+	if err != nil {
+		log.Warn("failed to collect licensing status", "error", err.Error())
+		return
+	}
+	if info == nil {
+		return
+	}
+
+	h.sendInventoryData("licensing", info, fmt.Sprintf("licensing (%s, %d products)", info.Status, len(info.Products)))
+}
+
+func (h *Heartbeat) sendWiFiInventory() {
+	if h.wifiCol == nil {
+		return
+	}
+	status, err := h.wifiCol.Collect()
+	if err != nil {
+		log.Error("failed to collect wifi status", "error", err.Error())
+		return
+	}
+
+	h.sendInventoryData("wifi", status, fmt.Sprintf("wifi (%d connected, %d nearby)", len(status.Connected), len(status.Nearby)))
+}
+
+// sendAgentChangelog reports the local changelog when it has grown since the
+// last successful send. The full (bounded) history is sent each time so the
+// server can replace its copy rather than merge.
+func (h *Heartbeat) sendAgentChangelog() {
+	if h.changelog == nil {
+		return
+	}
+	entries, rev := h.changelog.Entries()
+	if rev != 0 && rev == h.changelogSentRev.Load() {
+		return
+	}
+	if err := h.sendInventoryData("changelog", map[string]any{"entries": entries}, fmt.Sprintf("agent changelog (%d)", len(entries))); err != nil {
+		return
+	}
+	h.changelogSentRev.Store(rev)
+}
+
 func (h *Heartbeat) sendEventLogs() {
 	events, err := h.eventLogCol.Collect()
 	if err != nil {
@@ -2625,6 +3317,8 @@ func (h *Heartbeat) sendSecurityStatus() {
 // gate) — recovery keys should not transit the wire every 5 minutes. Also
 // drains rotation results whose upload previously failed.
 func (h *Heartbeat) sendRecoveryKeys() {
+	h.escrowDeferredFileVaultKey()
+
 	h.mu.Lock()
 	pending := h.pendingRecoveryKeys
 	h.pendingRecoveryKeys = nil
@@ -3171,6 +3865,16 @@ func (h *Heartbeat) sendHeartbeat() {
 	// Current power/battery state (#2142). Nil on platforms that can't report
 	// it or when the query failed — omitempty then drops the field.
 	payload.Battery = h.hardwareCol.CollectBattery()
+	if h.geoCol != nil {
+		payload.Location = h.geoCol.Current()
+	}
+
+	if status := h.provisioning.Status(); status.Active {
+		payload.Provisioning = &status
+	}
+
+	resourceStats := throttle.Snapshot()
+	payload.ResourceLimits = &resourceStats
 
 	// Agent's own runtime memory gauges (#2389), plus worker-pool wedge
 	// gauges (#2400) so in-flight/overdue commands are visible fleet-wide.
@@ -3362,6 +4066,8 @@ func (h *Heartbeat) doHeartbeatPost(baseURL string, payload *HeartbeatPayload) (
 func (h *Heartbeat) processHeartbeatResponse(response *HeartbeatResponse) {
 	if len(response.ConfigUpdate) > 0 {
 		h.applyConfigUpdate(response.ConfigUpdate)
+		version, detail := changelog.ConfigVersion(response.ConfigUpdate)
+		h.changelog.RecordConfig(version, detail)
 	}
 
 	// Pin per-deployment manifest trust keys delivered by the server (#625).
@@ -3492,6 +4198,7 @@ func (h *Heartbeat) processHeartbeatResponse(response *HeartbeatResponse) {
 
 	// Update tunnel manager policy flag
 	h.tunnelMgr.SetManagedByPolicy(response.ManageRemoteManagement)
+	h.changelog.RecordFlag("manage_remote_management", strconv.FormatBool(response.ManageRemoteManagement))
 
 	// Update helper enabled state and apply full settings
 	h.handleHelperEnabled(response.HelperEnabled)
@@ -3514,6 +4221,7 @@ func (h *Heartbeat) IsHelperEnabled() bool {
 
 // handleHelperEnabled updates the helper enabled flag and logs state transitions.
 func (h *Heartbeat) handleHelperEnabled(enabled bool) {
+	h.changelog.RecordFlag("helper_enabled", strconv.FormatBool(enabled))
 	prev := h.helperEnabled.Swap(enabled)
 	if prev != enabled {
 		if enabled {
@@ -3537,6 +4245,7 @@ func (h *Heartbeat) IsUACInterceptionEnabled() bool {
 // true.
 func (h *Heartbeat) handleUACInterception(enabled *bool) {
 	on := enabled != nil && *enabled
+	h.changelog.RecordFlag("uac_interception_enabled", strconv.FormatBool(on))
 	prev := h.uacInterceptionEnabled.Swap(on)
 	if prev != on {
 		if on {
@@ -3597,6 +4306,7 @@ func (h *Heartbeat) handleCertRenewal() {
 
 	// Update config in memory (hold mutex to prevent races with heartbeat reads)
 	h.mu.Lock()
+	previousExpiry := h.config.MtlsCertExpires
 	h.config.MtlsCertPEM = renewResp.Mtls.Certificate
 	h.config.MtlsKeyPEM = renewResp.Mtls.PrivateKey
 	h.config.MtlsCertExpires = renewResp.Mtls.ExpiresAt
@@ -3621,6 +4331,7 @@ func (h *Heartbeat) handleCertRenewal() {
 		h.wsClient.ForceReconnect()
 	}
 
+	h.changelog.RecordCertRenewal(previousExpiry, renewResp.Mtls.ExpiresAt)
 	log.Info("mTLS certificate renewed", "expires", renewResp.Mtls.ExpiresAt)
 	log.Info("mTLS clients refreshed with renewed certificate")
 }
@@ -4230,10 +4941,11 @@ func (h *Heartbeat) inFlightCommandStats(now time.Time) (inFlight, overdue int)
 
 func isEphemeralCommand(cmdType string) bool {
 	switch cmdType {
-	case tools.CmdTerminalStart, tools.CmdTerminalData, tools.CmdTerminalResize, tools.CmdTerminalStop,
-		tools.CmdStartDesktop, tools.CmdStopDesktop,
+	case tools.CmdTerminalStart, tools.CmdTerminalData, tools.CmdTerminalResize, tools.CmdTerminalStop, tools.CmdTerminalAttach,
+		tools.CmdStartDesktop, tools.CmdStopDesktop, tools.CmdJoinDesktop, tools.CmdLeaveDesktop,
 		tools.CmdDesktopStreamStart, tools.CmdDesktopStreamStop, tools.CmdDesktopInput, tools.CmdDesktopConfig,
-		tools.CmdTunnelOpen, tools.CmdTunnelData, tools.CmdTunnelClose:
+		tools.CmdTunnelOpen, tools.CmdTunnelData, tools.CmdTunnelClose,
+		tools.CmdProcessStreamStart, tools.CmdProcessStreamStop:
 		return true
 	}
 	return false
@@ -4301,9 +5013,7 @@ func (h *Heartbeat) executeCommand(cmd Command) tools.CommandResult {
 
 	// Audit: command received
 	if h.auditLog != nil {
-		h.auditLog.Log(audit.EventCommandReceived, cmd.ID, map[string]any{
-			"type": cmd.Type,
-		})
+		h.auditLog.Log(audit.EventCommandReceived, cmd.ID, commandAuditDetails(cmd))
 	}
 
 	// Privilege check (warn-only for now)
@@ -4311,8 +5021,16 @@ func (h *Heartbeat) executeCommand(cmd Command) tools.CommandResult {
 		cmdLog.Warn("command requires elevated privileges but agent is not running as root")
 	}
 
-	// Dispatch via handler registry
-	result, handled := h.dispatchCommand(cmd)
+	// Dispatch via handler registry. Patching and reboots are refused while
+	// the device is being provisioned (sysprep/OOBE/Autopilot ESP/imaging).
+	var result tools.CommandResult
+	handled := true
+	if reason := h.provisioningDeferral(cmd.Type); reason != "" {
+		cmdLog.Info("deferring command during provisioning", "reason", reason)
+		result = tools.NewErrorResult(errors.New(reason), 0)
+	} else {
+		result, handled = h.dispatchCommand(cmd)
+	}
 	if !handled {
 		result = tools.CommandResult{
 			Status: "failed",
@@ -4340,7 +5058,9 @@ type patchCommandRef struct {
 	Title      string
 }
 
-func (h *Heartbeat) executePatchInstallCommand(payload map[string]any, rollback bool) tools.CommandResult {
+// executePatchInstallCommand installs or rolls back the payload's patches in
+// turn, streaming progress to the console through progress when it is set.
+func (h *Heartbeat) executePatchInstallCommand(payload map[string]any, rollback bool, progress patching.ProgressCallback) tools.CommandResult {
 	start := time.Now()
 	if h.patchMgr == nil || len(h.patchMgr.ProviderIDs()) == 0 {
 		return tools.NewErrorResult(fmt.Errorf("no patch providers available"), time.Since(start).Milliseconds())
@@ -4354,11 +5074,27 @@ func (h *Heartbeat) executePatchInstallCommand(payload map[string]any, rollback
 	results := make([]map[string]any, 0, len(refs))
 	successCount := 0
 	failedCount := 0
+	skippedCount := 0
 	rebootRequired := false
+	var awaitingReboot []patching.RebootPendingPatch
+
+	var snapshot *patching.Snapshot
+	var snapshotErr error
+	if !rollback && h.patchSnapshotRequested(payload) {
+		snap, err := createPatchSnapshot(fmt.Sprintf("Breeze: before installing %d patch(es)", len(refs)))
+		if err != nil {
+			snapshotErr = err
+			log.Warn("pre-patch snapshot failed, installing without one", "error", err.Error())
+		} else {
+			snapshot = &snap
+		}
+	}
 
-	for _, ref := range refs {
+	reporter := newPatchInstallReporter(progress, rollback, len(refs))
+	for i, ref := range refs {
 		installID, resolveErr := h.resolvePatchInstallID(ref)
 		if resolveErr != nil {
+			reporter.end(i, ref, "", fmt.Sprintf("Failed: %v", resolveErr))
 			failedCount++
 			result := patchCommandResultFields(ref, "")
 			result["status"] = "failed"
@@ -4368,7 +5104,9 @@ func (h *Heartbeat) executePatchInstallCommand(payload map[string]any, rollback
 		}
 
 		if rollback {
+			reporter.begin(i, ref, installID)
 			if err := h.patchMgr.Uninstall(installID); err != nil {
+				reporter.end(i, ref, installID, fmt.Sprintf("Rollback failed: %v", err))
 				failedCount++
 				result := patchCommandResultFields(ref, installID)
 				result["status"] = "failed"
@@ -4376,6 +5114,7 @@ func (h *Heartbeat) executePatchInstallCommand(payload map[string]any, rollback
 				results = append(results, result)
 				continue
 			}
+			reporter.end(i, ref, installID, "Rolled back")
 			successCount++
 			result := patchCommandResultFields(ref, installID)
 			result["status"] = "rolled_back"
@@ -4383,8 +5122,23 @@ func (h *Heartbeat) executePatchInstallCommand(payload map[string]any, rollback
 			continue
 		}
 
-		installResult, err := h.patchMgr.Install(installID)
+		// App update rings: pinned, blocked, n-1 and ring-deferred versions
+		// are skipped (not failed) so automatic patch cycles stay green.
+		decision := h.appUpdateDecision(installID)
+		if !decision.Allowed {
+			reporter.end(i, ref, installID, fmt.Sprintf("Skipped: %s", decision.Reason))
+			skippedCount++
+			result := patchCommandResultFields(ref, installID)
+			result["status"] = "skipped"
+			result["reason"] = decision.Reason
+			results = append(results, result)
+			continue
+		}
+
+		reporter.begin(i, ref, installID)
+		installResult, err := h.installWithAppPolicy(installID, decision, reporter.providerCallback(i, ref, installID))
 		if err != nil {
+			reporter.end(i, ref, installID, fmt.Sprintf("Install failed: %v", err))
 			failedCount++
 			result := patchCommandResultFields(ref, installID)
 			result["status"] = "failed"
@@ -4393,25 +5147,43 @@ func (h *Heartbeat) executePatchInstallCommand(payload map[string]any, rollback
 			continue
 		}
 
+		reporter.end(i, ref, installID, "Installed")
 		successCount++
 		rebootRequired = rebootRequired || installResult.RebootRequired
+		if installResult.RebootRequired && !rollback {
+			awaitingReboot = append(awaitingReboot, rebootPendingPatchFor(ref, installID, time.Now()))
+		}
 		result := patchCommandResultFields(ref, installID)
 		result["status"] = "installed"
+		if decision.Version != "" {
+			result["version"] = decision.Version
+		}
 		result["rebootRequired"] = installResult.RebootRequired
 		result["message"] = installResult.Message
+		if snapshot != nil {
+			result["snapshotId"] = snapshot.ID
+		}
 		results = append(results, result)
 	}
 
+	h.recordPatchesAwaitingReboot(awaitingReboot)
+
 	summary := map[string]any{
 		"success":        failedCount == 0,
 		"installedCount": successCount,
 		"failedCount":    failedCount,
+		"skippedCount":   skippedCount,
 		"rebootRequired": rebootRequired,
 		"results":        results,
 	}
 	if rollback {
 		summary["rolledBackCount"] = successCount
 	}
+	if snapshot != nil {
+		summary["snapshot"] = snapshot
+	} else if snapshotErr != nil {
+		summary["snapshotError"] = snapshotErr.Error()
+	}
 
 	// Post-install rescan: trigger an immediate patch inventory so the
 	// dashboard reflects the new state without waiting up to 15 minutes.
@@ -4531,13 +5303,19 @@ func (h *Heartbeat) resolvePatchInstallID(ref patchCommandRef) (string, error) {
 	if provider, local, ok := splitPatchID(ref.ExternalID); ok {
 		switch provider {
 		case "microsoft", "apple", "linux", "third_party", "custom":
-		case "dnf":
-			if h.patchMgr.HasProvider("yum") {
-				return "yum:" + local, nil
-			}
 		default:
+			// Agents before the dnf provider reported dnf hosts as "yum", so
+			// either name resolves to whichever rpm provider this host has.
+			if provider == "dnf" || provider == "yum" {
+				for _, candidate := range []string{provider, "dnf", "yum"} {
+					if h.patchMgr.HasProvider(candidate) {
+						provider = candidate
+						break
+					}
+				}
+			}
 			if h.patchMgr.HasProvider(provider) {
-				if (provider == "apt" || provider == "yum") && strings.Contains(local, "@") {
+				if isLinuxPatchProvider(provider) && strings.Contains(local, "@") {
 					return provider + ":" + strings.SplitN(local, "@", 2)[0], nil
 				}
 				return provider + ":" + local, nil
@@ -4565,6 +5343,11 @@ func (h *Heartbeat) providerForPatchRef(ref patchCommandRef) string {
 	source := strings.ToLower(strings.TrimSpace(ref.Source))
 	switch source {
 	case "microsoft":
+		// Drivers with a KB number report it as their externalId, so only
+		// the packageId says they came from the driver provider.
+		if provider, _, ok := splitPatchID(ref.PackageID); ok && provider == "windows-drivers" && h.patchMgr.HasProvider(provider) {
+			return provider
+		}
 		if h.patchMgr.HasProvider("windows-update") {
 			return "windows-update"
 		}
@@ -4582,14 +5365,13 @@ func (h *Heartbeat) providerForPatchRef(ref patchCommandRef) string {
 			return "homebrew"
 		}
 	case "linux":
-		if h.patchMgr.HasProvider("apt") {
-			return "apt"
-		}
-		if h.patchMgr.HasProvider("yum") {
-			return "yum"
+		for _, providerID := range linuxPatchProviders {
+			if h.patchMgr.HasProvider(providerID) {
+				return providerID
+			}
 		}
 	case "third_party":
-		for _, providerID := range []string{"homebrew", "chocolatey", "apt", "yum"} {
+		for _, providerID := range append([]string{"homebrew", "chocolatey", "mas", "snap", "flatpak"}, linuxPatchProviders...) {
 			if h.patchMgr.HasProvider(providerID) {
 				return providerID
 			}
@@ -4606,6 +5388,19 @@ func (h *Heartbeat) providerForPatchRef(ref patchCommandRef) string {
 	return ""
 }
 
+// linuxPatchProviders are the Linux package-manager providers, in the order
+// a "linux" patch without a provider prefix is resolved.
+var linuxPatchProviders = []string{"apt", "dnf", "yum", "zypper", "pacman"}
+
+func isLinuxPatchProvider(provider string) bool {
+	for _, id := range linuxPatchProviders {
+		if id == provider {
+			return true
+		}
+	}
+	return false
+}
+
 func splitPatchID(value string) (string, string, bool) {
 	parts := strings.SplitN(strings.TrimSpace(value), ":", 2)
 	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
@@ -5080,6 +5875,7 @@ func (h *Heartbeat) doUpgrade(targetVersion string) {
 	userHelperPair := h.prefetchUserHelper(targetVersion, binaryPath)
 
 	u := updater.New(updaterCfg)
+	h.changelog.MarkUpgradePending(h.agentVersion, targetVersion, changelog.InitiatorAutoUpdate)
 	if err := u.UpdateToWithOptions(targetVersion, updater.UpdateOptions{UserHelper: userHelperPair}); err != nil {
 		// If the filesystem is read-only, stop retrying — this is permanent
 		// until the service unit is fixed or the filesystem is remounted.
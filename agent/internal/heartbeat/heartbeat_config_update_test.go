@@ -2,8 +2,11 @@ package heartbeat
 
 import (
 	"testing"
+	"time"
 
+	"github.com/breeze-rmm/agent/internal/collectors"
 	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
 )
 
 func TestApplyConfigUpdateParsesAndClearsPolicyProbeLists(t *testing.T) {
@@ -88,3 +91,155 @@ func TestApplyConfigUpdateIgnoresInvalidProbePayloads(t *testing.T) {
 		t.Fatalf("expected existing registry probes to remain unchanged, got %d", len(h.config.PolicyRegistryStateProbes))
 	}
 }
+
+func TestApplyConfigUpdateRegistryWatchKeys(t *testing.T) {
+	h := &Heartbeat{config: config.Default()}
+	h.regWatcher = collectors.NewRegistryWatcher(nil, nil)
+
+	h.applyConfigUpdate(map[string]any{"registryWatchKeys": []any{`HKLM\SOFTWARE\Contoso\*`, " "}})
+	if keys := h.regWatcher.Keys(); len(keys) != 1 || keys[0] != `HKLM\SOFTWARE\Contoso\*` {
+		t.Fatalf("unexpected watch list %v", keys)
+	}
+
+	h.applyConfigUpdate(map[string]any{"registry_watch_keys": []any{42}})
+	if keys := h.regWatcher.Keys(); len(keys) != 1 {
+		t.Fatalf("invalid payload should be ignored, got %v", keys)
+	}
+
+	h.applyConfigUpdate(map[string]any{"registry_watch_keys": nil})
+	if keys := h.regWatcher.Keys(); len(keys) != len(collectors.DefaultRegistryWatchKeys) {
+		t.Fatalf("null list should restore defaults, got %v", keys)
+	}
+}
+
+func TestApplyConfigUpdateEventLogQueries(t *testing.T) {
+	h := &Heartbeat{config: config.Default(), eventLogCol: collectors.NewEventLogCollector()}
+
+	h.applyConfigUpdate(map[string]any{"eventLogSettings": map[string]any{
+		"queries": []any{
+			map[string]any{"name": "rdp-logons", "channel": "Security", "eventIds": []any{float64(4624)}, "maxPerHour": float64(10)},
+			map[string]any{"name": "bad name", "channel": "System"},
+		},
+	}})
+	queries := h.eventLogCol.Queries()
+	if len(queries) != 1 || queries[0].Name != "rdp-logons" || queries[0].MaxPerHour != 10 {
+		t.Fatalf("unexpected queries %+v", queries)
+	}
+
+	h.applyConfigUpdate(map[string]any{"event_log_settings": map[string]any{"queries": "nope"}})
+	if len(h.eventLogCol.Queries()) != 1 {
+		t.Fatal("invalid payload should be ignored")
+	}
+
+	h.applyConfigUpdate(map[string]any{"event_log_settings": map[string]any{"queries": []any{}}})
+	if len(h.eventLogCol.Queries()) != 0 {
+		t.Fatal("empty list should clear queries")
+	}
+}
+
+func TestApplyConfigUpdateSyslogFilter(t *testing.T) {
+	h := &Heartbeat{config: config.Default(), eventLogCol: collectors.NewEventLogCollector()}
+
+	h.applyConfigUpdate(map[string]any{"event_log_settings": map[string]any{
+		"syslog_units":         []any{"nginx", "postgresql.service"},
+		"syslog_minimum_level": "error",
+	}})
+	units, level := h.eventLogCol.SyslogFilter()
+	if len(units) != 2 || units[0] != "nginx.service" || level != "error" {
+		t.Fatalf("unexpected filter %v %q", units, level)
+	}
+
+	// A level-only update keeps the units; an empty list clears them.
+	h.applyConfigUpdate(map[string]any{"eventLogSettings": map[string]any{"syslogMinimumLevel": "warning"}})
+	if units, level := h.eventLogCol.SyslogFilter(); len(units) != 2 || level != "warning" {
+		t.Fatalf("unexpected filter %v %q", units, level)
+	}
+	h.applyConfigUpdate(map[string]any{"eventLogSettings": map[string]any{"syslogUnits": []any{}}})
+	if units, _ := h.eventLogCol.SyslogFilter(); len(units) != 0 {
+		t.Fatalf("expected units cleared, got %v", units)
+	}
+}
+
+func TestApplyConfigUpdateSoftwareUsageMetering(t *testing.T) {
+	h := &Heartbeat{config: config.Default(), usageCol: collectors.NewSoftwareUsageCollector("", false)}
+
+	h.applyConfigUpdate(map[string]any{"software_usage_metering": true})
+	if !h.usageCol.Enabled() {
+		t.Fatal("expected metering enabled")
+	}
+	h.usageCol.Record("alice", []collectors.SoftwareUsage{{Date: time.Now().Format("2006-01-02"), Name: "code", Seconds: 30}})
+
+	h.applyConfigUpdate(map[string]any{"softwareUsageMetering": false})
+	if h.usageCol.Enabled() || len(h.usageCol.Collect()) != 0 {
+		t.Fatal("expected metering disabled and pending usage discarded")
+	}
+
+	// Non-boolean values are ignored.
+	h.applyConfigUpdate(map[string]any{"software_usage_metering": "yes"})
+	if h.usageCol.Enabled() {
+		t.Fatal("expected invalid value to be ignored")
+	}
+}
+
+func TestApplyConfigUpdateGeolocation(t *testing.T) {
+	h := &Heartbeat{config: config.Default(), geoCol: collectors.NewGeolocationCollector(false, "", nil)}
+
+	h.applyConfigUpdate(map[string]any{"geolocation_enabled": true})
+	if !h.geoCol.Enabled() {
+		t.Fatal("expected geolocation to be enabled")
+	}
+
+	h.applyConfigUpdate(map[string]any{"geolocationEnabled": "yes"})
+	if !h.geoCol.Enabled() {
+		t.Fatal("non-bool value should be ignored")
+	}
+
+	h.applyConfigUpdate(map[string]any{"geolocationEnabled": false})
+	if h.geoCol.Enabled() {
+		t.Fatal("expected geolocation to be disabled")
+	}
+	if loc := h.geoCol.Current(); loc != nil {
+		t.Fatalf("disabled collector reported %+v", loc)
+	}
+}
+
+func TestApplyConfigUpdateReplacesFilePathPolicy(t *testing.T) {
+	h := &Heartbeat{config: config.Default()}
+	t.Cleanup(func() { tools.SetFilePathPolicy(tools.FilePathPolicy{}) })
+
+	h.applyConfigUpdate(map[string]any{
+		"filePathPolicy": map[string]any{"allow": []any{"/srv/share"}, "deny": []any{"/srv/share/private", "relative"}},
+	})
+	got := tools.CurrentFilePathPolicy()
+	if len(got.Allow) != 1 || got.Allow[0] != "/srv/share" || len(got.Deny) != 1 {
+		t.Fatalf("policy = %+v", got)
+	}
+
+	// A malformed update keeps the current policy; null clears it.
+	h.applyConfigUpdate(map[string]any{"file_path_policy": "nope"})
+	if len(tools.CurrentFilePathPolicy().Allow) != 1 {
+		t.Fatal("invalid update should not replace the policy")
+	}
+	h.applyConfigUpdate(map[string]any{"file_path_policy": nil})
+	if got := tools.CurrentFilePathPolicy(); len(got.Allow) != 0 || len(got.Deny) != 0 {
+		t.Fatalf("null update should clear the policy, got %+v", got)
+	}
+}
+
+func TestApplyConfigUpdateTogglesRegistryWrites(t *testing.T) {
+	h := &Heartbeat{config: config.Default()}
+	t.Cleanup(func() { tools.SetRegistryWriteEnabled(false) })
+
+	h.applyConfigUpdate(map[string]any{"registryWriteEnabled": true})
+	if !tools.RegistryWriteEnabled() {
+		t.Fatal("registryWriteEnabled=true should enable registry writes")
+	}
+	h.applyConfigUpdate(map[string]any{"registry_write_enabled": "no"})
+	if !tools.RegistryWriteEnabled() {
+		t.Fatal("a non-boolean update should be ignored")
+	}
+	h.applyConfigUpdate(map[string]any{"registry_write_enabled": false})
+	if tools.RegistryWriteEnabled() {
+		t.Fatal("registry_write_enabled=false should disable registry writes")
+	}
+}
@@ -110,6 +110,74 @@ func TestResolvePatchInstallIDUsesPackageIDForVersionedLinuxExternalID(t *testin
 	}
 }
 
+func TestResolvePatchInstallIDRoutesKBDriverToDriverProvider(t *testing.T) {
+	h := &Heartbeat{patchMgr: patching.NewPatchManager(
+		&heartbeatMockProvider{id: "windows-update"},
+		&heartbeatMockProvider{id: "windows-drivers"},
+	)}
+
+	installID, err := h.resolvePatchInstallID(patchCommandRef{
+		ID:         "platform-patch-id",
+		Source:     "microsoft",
+		ExternalID: "KB5031455",
+		PackageID:  "windows-drivers:6b1a3c44-0d2e-4f7a-9a51-7c2f0e8d9b11",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if installID != "windows-drivers:6b1a3c44-0d2e-4f7a-9a51-7c2f0e8d9b11" {
+		t.Fatalf("expected the driver provider, got %s", installID)
+	}
+}
+
+func TestResolvePatchInstallIDMapsYumExternalIDToDnf(t *testing.T) {
+	h := &Heartbeat{patchMgr: patching.NewPatchManager(&heartbeatMockProvider{id: "dnf"})}
+
+	installID, err := h.resolvePatchInstallID(patchCommandRef{
+		ID:         "platform-patch-id",
+		Source:     "linux",
+		ExternalID: "yum:openssl@3.0.7-27.el9",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if installID != "dnf:openssl" {
+		t.Fatalf("expected dnf:openssl, got %s", installID)
+	}
+}
+
+func TestResolvePatchInstallIDKeepsZypperPatchName(t *testing.T) {
+	h := &Heartbeat{patchMgr: patching.NewPatchManager(&heartbeatMockProvider{id: "zypper"})}
+
+	installID, err := h.resolvePatchInstallID(patchCommandRef{
+		ID:         "platform-patch-id",
+		Source:     "linux",
+		ExternalID: "zypper:patch:SUSE-SLE-Module-Basesystem-15-SP5-2024-1234@1",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if installID != "zypper:patch:SUSE-SLE-Module-Basesystem-15-SP5-2024-1234" {
+		t.Fatalf("unexpected install ID %s", installID)
+	}
+}
+
+func TestResolvePatchInstallIDMapsLinuxSourceToPacman(t *testing.T) {
+	h := &Heartbeat{patchMgr: patching.NewPatchManager(&heartbeatMockProvider{id: "pacman"})}
+
+	installID, err := h.resolvePatchInstallID(patchCommandRef{
+		ID:         "platform-patch-id",
+		Source:     "linux",
+		ExternalID: "openssl",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if installID != "pacman:openssl" {
+		t.Fatalf("expected pacman:openssl, got %s", installID)
+	}
+}
+
 func TestExecutePatchInstallCommandReportsPartialFailures(t *testing.T) {
 	provider := &heartbeatMockProvider{id: "apt"}
 	h := &Heartbeat{patchMgr: patching.NewPatchManager(provider)}
@@ -117,7 +185,7 @@ func TestExecutePatchInstallCommandReportsPartialFailures(t *testing.T) {
 	provider.installErr = errors.New("install failed")
 	result := h.executePatchInstallCommand(map[string]any{
 		"patchIds": []any{"openssl"},
-	}, false)
+	}, false, nil)
 
 	if result.Status != "failed" {
 		t.Fatalf("expected failed status, got %s", result.Status)
@@ -144,7 +212,7 @@ func TestExecutePatchRollbackCommandCallsProviderUninstall(t *testing.T) {
 
 	result := h.executePatchInstallCommand(map[string]any{
 		"patchIds": []any{"openssl"},
-	}, true)
+	}, true, nil)
 
 	if result.Status != "completed" {
 		t.Fatalf("expected completed status, got %s", result.Status)
@@ -213,3 +281,79 @@ func TestAvailablePatchesToMapsDerivesHomebrewCaskCategory(t *testing.T) {
 		t.Fatalf("expected second category homebrew, got %#v", got)
 	}
 }
+
+func stubPatchSnapshot(t *testing.T, snap patching.Snapshot, err error) *[]string {
+	t.Helper()
+	orig := createPatchSnapshot
+	t.Cleanup(func() { createPatchSnapshot = orig })
+	var calls []string
+	createPatchSnapshot = func(description string) (patching.Snapshot, error) {
+		calls = append(calls, description)
+		return snap, err
+	}
+	return &calls
+}
+
+func TestExecutePatchInstallCommandRecordsSnapshot(t *testing.T) {
+	calls := stubPatchSnapshot(t, patching.Snapshot{ID: "42", Kind: patching.SnapshotRestorePoint}, nil)
+	h := &Heartbeat{patchMgr: patching.NewPatchManager(&heartbeatMockProvider{id: "apt"})}
+
+	result := h.executePatchInstallCommand(map[string]any{
+		"patchIds":       []any{"openssl"},
+		"createSnapshot": true,
+	}, false, nil)
+
+	if result.Status != "completed" {
+		t.Fatalf("expected completed status, got %s (%s)", result.Status, result.Error)
+	}
+	if len(*calls) != 1 {
+		t.Fatalf("expected one snapshot, got %d", len(*calls))
+	}
+	var summary struct {
+		Snapshot patching.Snapshot `json:"snapshot"`
+		Results  []map[string]any  `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(result.Stdout), &summary); err != nil {
+		t.Fatalf("expected JSON stdout, got parse error: %v", err)
+	}
+	if summary.Snapshot.ID != "42" || summary.Snapshot.Kind != patching.SnapshotRestorePoint {
+		t.Fatalf("unexpected snapshot %+v", summary.Snapshot)
+	}
+	if len(summary.Results) != 1 || summary.Results[0]["snapshotId"] != "42" {
+		t.Fatalf("expected snapshotId on the install result, got %#v", summary.Results)
+	}
+}
+
+func TestExecutePatchInstallCommandContinuesWhenSnapshotFails(t *testing.T) {
+	stubPatchSnapshot(t, patching.Snapshot{}, patching.ErrSnapshotUnsupported)
+	provider := &heartbeatMockProvider{id: "apt"}
+	h := &Heartbeat{patchMgr: patching.NewPatchManager(provider)}
+
+	result := h.executePatchInstallCommand(map[string]any{
+		"patchIds":       []any{"openssl"},
+		"createSnapshot": true,
+	}, false, nil)
+
+	if result.Status != "completed" || len(provider.installIDs) != 1 {
+		t.Fatalf("expected the install to proceed, got %s with installs %v", result.Status, provider.installIDs)
+	}
+	var summary map[string]any
+	if err := json.Unmarshal([]byte(result.Stdout), &summary); err != nil {
+		t.Fatalf("expected JSON stdout, got parse error: %v", err)
+	}
+	if _, ok := summary["snapshotError"].(string); !ok {
+		t.Fatalf("expected snapshotError in summary, got %#v", summary)
+	}
+}
+
+func TestExecutePatchInstallCommandSnapshotIsOptIn(t *testing.T) {
+	calls := stubPatchSnapshot(t, patching.Snapshot{ID: "1"}, nil)
+	h := &Heartbeat{patchMgr: patching.NewPatchManager(&heartbeatMockProvider{id: "apt"})}
+
+	h.executePatchInstallCommand(map[string]any{"patchIds": []any{"openssl"}}, false, nil)
+	h.executePatchInstallCommand(map[string]any{"patchIds": []any{"openssl"}, "createSnapshot": true}, true, nil)
+
+	if len(*calls) != 0 {
+		t.Fatalf("expected no snapshot without opt-in or on rollback, got %d", len(*calls))
+	}
+}
@@ -13,12 +13,19 @@ func TestMapPatchProviderSource(t *testing.T) {
 		want     string
 	}{
 		{"windows-update", "microsoft"},
+		{"windows-drivers", "microsoft"},
 		{"apple-softwareupdate", "apple"},
 		{"homebrew", "third_party"},
 		{"chocolatey", "third_party"},
 		{"winget", "third_party"},
+		{"snap", "third_party"},
+		{"flatpak", "third_party"},
+		{"mas", "third_party"},
 		{"apt", "linux"},
 		{"yum", "linux"},
+		{"dnf", "linux"},
+		{"zypper", "linux"},
+		{"pacman", "linux"},
 		{"unknown", "custom"},
 	}
 	for _, c := range cases {
@@ -37,12 +44,19 @@ func TestMapPatchProviderCategory(t *testing.T) {
 		want     string
 	}{
 		{"windows-update", "system"},
+		{"windows-drivers", "driver"},
 		{"apple-softwareupdate", "system"},
 		{"homebrew", "application"},
 		{"chocolatey", "application"},
 		{"winget", "application"},
+		{"snap", "application"},
+		{"flatpak", "application"},
+		{"mas", "application"},
 		{"apt", "system"},
 		{"yum", "system"},
+		{"dnf", "system"},
+		{"zypper", "system"},
+		{"pacman", "system"},
 		{"unknown", "application"},
 	}
 	for _, c := range cases {
@@ -0,0 +1,49 @@
+package heartbeat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/patching"
+)
+
+// applyPatchCompliancePolicyConfig handles the patch_compliance_policy config
+// update. A null block clears the policy and stops compliance reports.
+func (h *Heartbeat) applyPatchCompliancePolicyConfig(raw any) {
+	if h.patchCompliance == nil {
+		return
+	}
+	policy, ok := patching.ParsePatchCompliancePolicy(raw)
+	if !ok {
+		log.Warn("ignoring invalid patch_compliance_policy payload: not an object")
+		return
+	}
+
+	changed, err := h.patchCompliance.SetPolicy(policy)
+	if err != nil {
+		log.Warn("failed to persist patch compliance policy", "error", err.Error())
+	}
+	if changed {
+		log.Info("applied patch compliance policy",
+			"criticalMaxAgeDays", policy.CriticalMaxAgeDays,
+			"importantMaxAgeDays", policy.ImportantMaxAgeDays)
+	}
+}
+
+// reportPatchCompliance evaluates the scan against the compliance policy and
+// uploads the device verdict with its violating patches.
+func (h *Heartbeat) reportPatchCompliance(available []patching.AvailablePatch, coveredProviders []string) {
+	if h.patchCompliance == nil || !h.patchCompliance.Policy().Enabled() || h.patchMgr == nil {
+		return
+	}
+
+	report, err := h.patchCompliance.Evaluate(available, h.patchMgr.ProviderIDs(), coveredProviders, time.Now())
+	if err != nil {
+		log.Warn("failed to persist patch first-seen times", "error", err.Error())
+	}
+	h.sendInventoryData(
+		"patches/compliance",
+		report,
+		fmt.Sprintf("patch compliance (%s, %d violations)", report.Status, report.ViolationCount),
+	)
+}
@@ -0,0 +1,58 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/httputil"
+	"github.com/breeze-rmm/agent/internal/patching"
+)
+
+func TestReportPatchComplianceUploadsVerdict(t *testing.T) {
+	var requests []patchInventoryRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, patchInventoryRequest{path: r.URL.Path, body: body})
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	h := New(&config.Config{AgentID: "agent-1", ServerURL: ts.URL, AuthToken: "token"})
+	h.retryCfg = httputil.RetryConfig{MaxRetries: 0}
+	h.patchMgr = patching.NewPatchManager(&heartbeatMockProvider{id: "windows-update"})
+	h.patchCompliance = patching.NewPatchComplianceStore("")
+
+	available := []patching.AvailablePatch{{
+		ID: "windows-update:kb1", Provider: "windows-update", Title: "KB1",
+		Severity: "critical", ReleaseDate: "2020-01-01",
+	}}
+
+	// No policy: nothing is evaluated or sent.
+	h.reportPatchCompliance(available, []string{"windows-update"})
+	if len(requests) != 0 {
+		t.Fatalf("expected no upload without a policy, got %d", len(requests))
+	}
+
+	h.applyPatchCompliancePolicyConfig(map[string]any{"criticalMaxAgeDays": float64(14)})
+	h.reportPatchCompliance(available, []string{"windows-update"})
+	if len(requests) != 1 || requests[0].path != "/api/v1/agents/agent-1/patches/compliance" {
+		t.Fatalf("unexpected requests %#v", requests)
+	}
+	var report patching.PatchComplianceReport
+	if err := json.Unmarshal(requests[0].body, &report); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if report.Status != patching.PatchNonCompliant || report.ViolationCount != 1 || report.Violations[0].PatchID != "windows-update:kb1" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	h.applyPatchCompliancePolicyConfig(nil)
+	h.reportPatchCompliance(available, []string{"windows-update"})
+	if len(requests) != 1 {
+		t.Fatal("expected a cleared policy to stop compliance uploads")
+	}
+}
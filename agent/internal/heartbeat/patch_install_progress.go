@@ -0,0 +1,161 @@
+package heartbeat
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/patching"
+)
+
+// patchProgressKeepalive is how often a patch that is still installing is
+// re-reported. Windows Update gives no progress while a cumulative update
+// installs, so without it the console shows nothing for 30+ minutes.
+var patchProgressKeepalive = 30 * time.Second
+
+// patchProgressSender returns a callback that streams progress events for a
+// command over the WebSocket, or nil when there is no connection.
+func (h *Heartbeat) patchProgressSender(commandID string) patching.ProgressCallback {
+	if h.wsClient == nil {
+		return nil
+	}
+	return func(event patching.ProgressEvent) {
+		_ = h.wsClient.SendPatchProgress(commandID, event)
+	}
+}
+
+// patchInstallReporter turns one install or rollback batch into progress
+// events: one when each patch starts and finishes, the provider's own events
+// scaled to the batch, and a keepalive while a single patch runs long. A nil
+// reporter (no progress callback) does nothing.
+type patchInstallReporter struct {
+	send  patching.ProgressCallback
+	phase string
+	total int
+
+	mu   sync.Mutex
+	last patching.ProgressEvent
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newPatchInstallReporter(send patching.ProgressCallback, rollback bool, total int) *patchInstallReporter {
+	if send == nil {
+		return nil
+	}
+	phase := "installing"
+	if rollback {
+		phase = "rolling_back"
+	}
+	return &patchInstallReporter{send: send, phase: phase, total: total}
+}
+
+// batchPercent places a patch's own percent within the whole batch.
+func (r *patchInstallReporter) batchPercent(index int, percent float64) float64 {
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	return (float64(index) + percent/100) * 100 / float64(r.total)
+}
+
+func (r *patchInstallReporter) emit(event patching.ProgressEvent) {
+	r.mu.Lock()
+	r.last = event
+	r.mu.Unlock()
+	r.send(event)
+}
+
+// begin reports that the patch at index (0-based) has started and keeps
+// re-reporting it until end is called.
+func (r *patchInstallReporter) begin(index int, ref patchCommandRef, installID string) {
+	if r == nil {
+		return
+	}
+	title := ref.Title
+	if title == "" {
+		title = installID
+	}
+	verb := "Installing"
+	if r.phase == "rolling_back" {
+		verb = "Rolling back"
+	}
+	r.emit(patching.ProgressEvent{
+		Phase:       r.phase,
+		PatchID:     installID,
+		PatchTitle:  ref.Title,
+		Percent:     r.batchPercent(index, 0),
+		CurrentItem: index + 1,
+		TotalItems:  r.total,
+		Message:     fmt.Sprintf("%s %s", verb, title),
+	})
+
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	go r.keepalive(time.Now(), r.stop, r.done)
+}
+
+func (r *patchInstallReporter) keepalive(started time.Time, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(patchProgressKeepalive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			event := r.last
+			r.mu.Unlock()
+			name := event.PatchTitle
+			if name == "" {
+				name = event.PatchID
+			}
+			event.Message = fmt.Sprintf("Still working on %s (%s elapsed)", name, time.Since(started).Round(time.Second))
+			r.send(event)
+		}
+	}
+}
+
+// providerCallback scales the provider's events for the patch at index into
+// the batch, so a 50% Windows Update event on the second of four patches
+// reports 37.5%.
+func (r *patchInstallReporter) providerCallback(index int, ref patchCommandRef, installID string) patching.ProgressCallback {
+	if r == nil {
+		return nil
+	}
+	return func(event patching.ProgressEvent) {
+		event.Phase = r.phase
+		event.PatchID = installID
+		if ref.Title != "" {
+			event.PatchTitle = ref.Title
+		}
+		event.Percent = r.batchPercent(index, event.Percent)
+		event.CurrentItem = index + 1
+		event.TotalItems = r.total
+		r.emit(event)
+	}
+}
+
+// end stops the keepalive, if begin started one, and reports the patch's
+// outcome.
+func (r *patchInstallReporter) end(index int, ref patchCommandRef, installID, message string) {
+	if r == nil {
+		return
+	}
+	if r.stop != nil {
+		close(r.stop)
+		<-r.done
+		r.stop, r.done = nil, nil
+	}
+	r.emit(patching.ProgressEvent{
+		Phase:       r.phase,
+		PatchID:     installID,
+		PatchTitle:  ref.Title,
+		Percent:     r.batchPercent(index, 100),
+		CurrentItem: index + 1,
+		TotalItems:  r.total,
+		Message:     message,
+	})
+}
@@ -0,0 +1,139 @@
+package heartbeat
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/patching"
+)
+
+// progressMockProvider reports its own install progress like Windows Update.
+type progressMockProvider struct {
+	heartbeatMockProvider
+	delay time.Duration
+}
+
+func (p *progressMockProvider) Download(patchIDs []string, progress patching.ProgressCallback) ([]patching.DownloadResult, error) {
+	return nil, nil
+}
+
+func (p *progressMockProvider) InstallWithProgress(patchID string, progress patching.ProgressCallback) (patching.InstallResult, error) {
+	progress(patching.ProgressEvent{Phase: "installing", PatchID: patchID, Percent: 50, CurrentItem: 1, TotalItems: 1})
+	time.Sleep(p.delay)
+	return p.Install(patchID)
+}
+
+type progressRecorder struct {
+	mu     sync.Mutex
+	events []patching.ProgressEvent
+}
+
+func (r *progressRecorder) record(event patching.ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *progressRecorder) snapshot() []patching.ProgressEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]patching.ProgressEvent(nil), r.events...)
+}
+
+func TestExecutePatchInstallCommandStreamsPerPatchProgress(t *testing.T) {
+	provider := &heartbeatMockProvider{id: "apt"}
+	h := &Heartbeat{patchMgr: patching.NewPatchManager(provider)}
+	rec := &progressRecorder{}
+
+	result := h.executePatchInstallCommand(map[string]any{
+		"patchIds": []any{"openssl", "curl"},
+	}, false, rec.record)
+	if result.Status != "completed" {
+		t.Fatalf("expected completed, got %s: %s", result.Status, result.Error)
+	}
+
+	events := rec.snapshot()
+	wantPercent := []float64{0, 50, 50, 100}
+	wantItem := []int{1, 1, 2, 2}
+	if len(events) != len(wantPercent) {
+		t.Fatalf("expected %d events, got %+v", len(wantPercent), events)
+	}
+	for i, event := range events {
+		if event.Phase != "installing" || event.Percent != wantPercent[i] || event.CurrentItem != wantItem[i] || event.TotalItems != 2 {
+			t.Fatalf("event %d: unexpected %+v", i, event)
+		}
+	}
+	if events[0].Message != "Installing apt:openssl" || events[3].Message != "Installed" {
+		t.Fatalf("unexpected messages %q / %q", events[0].Message, events[3].Message)
+	}
+}
+
+func TestExecutePatchInstallCommandScalesProviderProgress(t *testing.T) {
+	provider := &progressMockProvider{heartbeatMockProvider: heartbeatMockProvider{id: "windows-update"}}
+	h := &Heartbeat{patchMgr: patching.NewPatchManager(provider)}
+	rec := &progressRecorder{}
+
+	h.executePatchInstallCommand(map[string]any{
+		"patches": []any{
+			map[string]any{"id": "p1", "source": "microsoft", "externalId": "kb1", "title": "KB1"},
+			map[string]any{"id": "p2", "source": "microsoft", "externalId": "kb2", "title": "KB2"},
+		},
+	}, false, rec.record)
+
+	var scaled []patching.ProgressEvent
+	for _, event := range rec.snapshot() {
+		if event.Message == "" {
+			scaled = append(scaled, event)
+		}
+	}
+	if len(scaled) != 2 {
+		t.Fatalf("expected 2 forwarded provider events, got %+v", rec.snapshot())
+	}
+	if scaled[1].Percent != 75 || scaled[1].CurrentItem != 2 || scaled[1].TotalItems != 2 || scaled[1].PatchTitle != "KB2" {
+		t.Fatalf("expected the second patch's 50%% to report 75%% of the batch, got %+v", scaled[1])
+	}
+}
+
+func TestExecutePatchInstallCommandSendsKeepaliveDuringLongInstall(t *testing.T) {
+	orig := patchProgressKeepalive
+	patchProgressKeepalive = 5 * time.Millisecond
+	t.Cleanup(func() { patchProgressKeepalive = orig })
+
+	provider := &progressMockProvider{heartbeatMockProvider: heartbeatMockProvider{id: "windows-update"}, delay: 50 * time.Millisecond}
+	h := &Heartbeat{patchMgr: patching.NewPatchManager(provider)}
+	rec := &progressRecorder{}
+
+	h.executePatchInstallCommand(map[string]any{"patchIds": []any{"kb1"}}, false, rec.record)
+
+	events := rec.snapshot()
+	keepalives := 0
+	for _, event := range events {
+		if strings.HasPrefix(event.Message, "Still working on") {
+			keepalives++
+			if event.Percent != 50 {
+				t.Fatalf("expected keepalive to repeat the last percent, got %+v", event)
+			}
+		}
+	}
+	if keepalives == 0 {
+		t.Fatalf("expected keepalive events, got %+v", events)
+	}
+	if last := events[len(events)-1]; last.Message != "Installed" || last.Percent != 100 {
+		t.Fatalf("expected the final event last, got %+v", last)
+	}
+}
+
+func TestExecutePatchRollbackCommandReportsRollbackPhase(t *testing.T) {
+	provider := &heartbeatMockProvider{id: "apt"}
+	h := &Heartbeat{patchMgr: patching.NewPatchManager(provider)}
+	rec := &progressRecorder{}
+
+	h.executePatchInstallCommand(map[string]any{"patchIds": []any{"openssl"}}, true, rec.record)
+
+	events := rec.snapshot()
+	if len(events) != 2 || events[0].Phase != "rolling_back" || events[1].Message != "Rolled back" {
+		t.Fatalf("unexpected rollback events %+v", events)
+	}
+}
@@ -0,0 +1,152 @@
+package heartbeat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/observability"
+	"github.com/breeze-rmm/agent/internal/patching"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+// deferredPatchCheckInterval is how often queued installs and reboots are
+// checked against the maintenance windows.
+const deferredPatchCheckInterval = time.Minute
+
+// applyPatchMaintenanceConfig handles the patch_maintenance_windows block
+// from the heartbeat config update: a list of windows outside of which
+// patch installs and patch reboots are queued locally. An empty list lifts
+// the restriction.
+func (h *Heartbeat) applyPatchMaintenanceConfig(raw any) {
+	if h.patchMgr == nil {
+		return
+	}
+	windows, err := parsePatchMaintenanceWindows(raw)
+	if err != nil {
+		log.Warn("ignoring invalid patch_maintenance_windows payload", "error", err.Error())
+		return
+	}
+	if err := h.patchMgr.SetMaintenanceWindows(windows); err != nil {
+		log.Warn("ignoring invalid patch_maintenance_windows payload", "error", err.Error())
+		return
+	}
+	log.Info("applied patch maintenance windows", "count", len(windows))
+}
+
+// parsePatchMaintenanceWindows reads the window list. The API may send
+// either snake_case or camelCase field names.
+func parsePatchMaintenanceWindows(raw any) ([]patching.MaintenanceWindow, error) {
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("not an array")
+	}
+	windows := make([]patching.MaintenanceWindow, 0, len(list))
+	for i, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("window %d is not an object", i)
+		}
+		w := patching.MaintenanceWindow{
+			Start:           tools.GetPayloadString(m, "start", ""),
+			DurationMinutes: tools.GetPayloadInt(m, "durationMinutes", tools.GetPayloadInt(m, "duration_minutes", 0)),
+			Timezone:        tools.GetPayloadString(m, "timezone", ""),
+		}
+		if days, ok := m["days"].([]any); ok {
+			for _, d := range days {
+				name, ok := d.(string)
+				if !ok {
+					return nil, fmt.Errorf("window %d has a non-string day", i)
+				}
+				w.Days = append(w.Days, name)
+			}
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// deferOutsideMaintenanceWindow queues an install or patch reboot when
+// maintenance windows are configured and none is open. It reports whether
+// the operation was deferred, with the result to return for the command.
+func (h *Heartbeat) deferOutsideMaintenanceWindow(kind string, cmd Command, payload map[string]any) (tools.CommandResult, bool) {
+	start := time.Now()
+	if h.patchMgr == nil {
+		return tools.CommandResult{}, false
+	}
+	open, next := h.patchMgr.MaintenanceWindowOpen(start)
+	if open {
+		return tools.CommandResult{}, false
+	}
+
+	queued, err := h.patchMgr.DeferOperation(patching.DeferredOperation{
+		Kind:      kind,
+		CommandID: cmd.ID,
+		Payload:   payload,
+	})
+	if err != nil {
+		return tools.NewErrorResult(fmt.Errorf("outside patch maintenance window and %w", err), time.Since(start).Milliseconds()), true
+	}
+	log.Info("deferred patch operation until maintenance window",
+		"kind", kind, "commandId", cmd.ID, "nextWindowStart", next, "queued", queued)
+
+	result := map[string]any{
+		"deferred":    true,
+		"kind":        kind,
+		"queuedCount": queued,
+	}
+	if !next.IsZero() {
+		result["nextWindowStart"] = next.UTC().Format(time.RFC3339)
+	}
+	return tools.NewSuccessResult(result, time.Since(start).Milliseconds()), true
+}
+
+// runDeferredPatchLoop runs queued installs and reboots once a maintenance
+// window opens.
+func (h *Heartbeat) runDeferredPatchLoop() {
+	ticker := time.NewTicker(deferredPatchCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			func() {
+				defer observability.Recoverer("heartbeat.deferredPatches")
+				if h.provisioning.Active() {
+					return
+				}
+				h.runDeferredPatchOperations(time.Now())
+			}()
+		case <-h.stopChan:
+			return
+		}
+	}
+}
+
+// runDeferredPatchOperations runs the queued operations if a window is open
+// at now. Their original commands were already answered as deferred, so the
+// outcome is only logged; the post-install rescan reports the new state.
+func (h *Heartbeat) runDeferredPatchOperations(now time.Time) {
+	if h.patchMgr == nil {
+		return
+	}
+	for _, op := range h.patchMgr.TakeDueOperations(now) {
+		switch op.Kind {
+		case patching.DeferredInstall:
+			result := handleInstallPatches(h, Command{ID: op.CommandID, Type: tools.CmdInstallPatches, Payload: op.Payload})
+			log.Info("ran deferred patch install", "commandId", op.CommandID, "status", result.Status, "error", result.Error)
+		case patching.DeferredReboot:
+			if h.rebootMgr == nil {
+				log.Warn("dropping deferred reboot: reboot manager not available", "commandId", op.CommandID)
+				continue
+			}
+			delay := time.Duration(tools.GetPayloadInt(op.Payload, "delayMinutes", 0)) * time.Minute
+			reason := tools.GetPayloadString(op.Payload, "reason", "Deferred to maintenance window")
+			source := tools.GetPayloadString(op.Payload, "source", "patch_job")
+			if err := h.rebootMgr.Schedule(delay, now.Add(delay), reason, source); err != nil {
+				log.Warn("deferred reboot not scheduled", "commandId", op.CommandID, "error", err.Error())
+				continue
+			}
+			log.Info("scheduled deferred reboot", "commandId", op.CommandID, "delay", delay, "source", source)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/patching"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+// closedWindow is a one-hour window two days from now, so it is never open
+// while the test runs.
+func closedWindow() map[string]any {
+	return map[string]any{
+		"days":            []any{time.Now().Add(48 * time.Hour).Weekday().String()},
+		"start":           "00:00",
+		"durationMinutes": float64(60),
+	}
+}
+
+func TestApplyPatchMaintenanceConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		update      map[string]any
+		wantWindows int
+	}{
+		{
+			name:        "snake_case key",
+			update:      map[string]any{"patch_maintenance_windows": []any{closedWindow()}},
+			wantWindows: 1,
+		},
+		{
+			name: "camelCase key + snake_case field",
+			update: map[string]any{"patchMaintenanceWindows": []any{
+				map[string]any{"start": "02:00", "duration_minutes": float64(120), "timezone": "UTC"},
+			}},
+			wantWindows: 1,
+		},
+		{
+			name:        "empty list clears",
+			update:      map[string]any{"patch_maintenance_windows": []any{}},
+			wantWindows: 0,
+		},
+		{
+			name:        "non-array payload → unchanged",
+			update:      map[string]any{"patch_maintenance_windows": "nightly"},
+			wantWindows: 1,
+		},
+		{
+			name: "invalid window → unchanged",
+			update: map[string]any{"patch_maintenance_windows": []any{
+				map[string]any{"start": "noon", "durationMinutes": float64(60)},
+			}},
+			wantWindows: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Heartbeat{config: config.Default(), patchMgr: patching.NewPatchManager()}
+			if err := h.patchMgr.SetMaintenanceWindows([]patching.MaintenanceWindow{{Start: "01:00", DurationMinutes: 30}}); err != nil {
+				t.Fatalf("seed window: %v", err)
+			}
+
+			h.applyConfigUpdate(tt.update)
+
+			if got := len(h.patchMgr.MaintenanceWindows()); got != tt.wantWindows {
+				t.Errorf("windows = %d, want %d", got, tt.wantWindows)
+			}
+		})
+	}
+}
+
+func TestInstallPatchesDeferredOutsideWindow(t *testing.T) {
+	h := &Heartbeat{config: config.Default(), patchMgr: patching.NewPatchManager()}
+	h.applyPatchMaintenanceConfig([]any{closedWindow()})
+
+	payload := map[string]any{"patchIds": []any{"apt:curl"}}
+	result := handleInstallPatches(h, Command{ID: "cmd-1", Type: tools.CmdInstallPatches, Payload: payload})
+	if result.Status != "completed" {
+		t.Fatalf("result = %+v, want deferred success", result)
+	}
+	var out map[string]any
+	if err := json.Unmarshal([]byte(result.Stdout), &out); err != nil {
+		t.Fatalf("stdout: %v", err)
+	}
+	if out["deferred"] != true || out["nextWindowStart"] == nil {
+		t.Errorf("result = %v, want deferred with nextWindowStart", out)
+	}
+
+	queued := h.patchMgr.DeferredOperations()
+	if len(queued) != 1 || queued[0].Kind != patching.DeferredInstall || queued[0].CommandID != "cmd-1" {
+		t.Fatalf("queue = %+v, want the install", queued)
+	}
+}
+
+func TestPatchRebootDeferredOutsideWindow(t *testing.T) {
+	rebootMgr := patching.NewRebootManager(nil, 3)
+	t.Cleanup(rebootMgr.Stop)
+	h := &Heartbeat{config: config.Default(), patchMgr: patching.NewPatchManager(), rebootMgr: rebootMgr}
+	h.applyPatchMaintenanceConfig([]any{closedWindow()})
+
+	result := handleScheduleReboot(h, Command{ID: "cmd-2", Type: tools.CmdScheduleReboot, Payload: map[string]any{
+		"delayMinutes": float64(60),
+		"source":       "patch_job",
+	}})
+	if result.Status != "completed" {
+		t.Fatalf("result = %+v, want deferred success", result)
+	}
+	if rebootMgr.State().RebootScheduled {
+		t.Fatal("patch reboot scheduled outside the maintenance window")
+	}
+	queued := h.patchMgr.DeferredOperations()
+	if len(queued) != 1 || queued[0].Kind != patching.DeferredReboot {
+		t.Fatalf("queue = %+v, want the reboot", queued)
+	}
+	if got := tools.GetPayloadInt(queued[0].Payload, "delayMinutes", 0); got != 60 {
+		t.Errorf("queued delayMinutes = %d, want 60", got)
+	}
+}
@@ -0,0 +1,64 @@
+package heartbeat
+
+import (
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/security"
+)
+
+// patchDateLayouts are the date formats the patch providers report.
+var patchDateLayouts = []string{time.RFC3339Nano, time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+func parsePatchDate(raw any) (time.Time, bool) {
+	value, _ := raw.(string)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range patchDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// patchPostureFromInventory summarizes a patch scan for the security score:
+// the critical and important backlog, how old it is, and the latest install.
+func patchPostureFromInventory(pending, installed []map[string]any, now time.Time) security.PatchPosture {
+	posture := security.PatchPosture{CollectedAt: now.UTC()}
+	for _, item := range pending {
+		switch item["severity"] {
+		case "critical":
+			posture.PendingCritical++
+		case "important":
+			posture.PendingImportant++
+		default:
+			continue
+		}
+		if released, ok := parsePatchDate(item["releaseDate"]); ok {
+			if posture.OldestPendingReleasedAt == nil || released.Before(*posture.OldestPendingReleasedAt) {
+				posture.OldestPendingReleasedAt = &released
+			}
+		}
+	}
+	for _, item := range installed {
+		if at, ok := parsePatchDate(item["installedAt"]); ok {
+			if posture.LastInstalledAt == nil || at.After(*posture.LastInstalledAt) {
+				posture.LastInstalledAt = &at
+			}
+		}
+	}
+	return posture
+}
+
+// recordPatchPosture keeps a patch scan's result for the patch_age factor of
+// the security score. A scan that failed outright is not recorded, so it
+// does not pass for an empty backlog.
+func recordPatchPosture(pending, installed []map[string]any, scanErr error) {
+	if scanErr != nil && len(pending) == 0 && len(installed) == 0 {
+		return
+	}
+	if err := security.RecordPatchPosture(patchPostureFromInventory(pending, installed, time.Now())); err != nil {
+		log.Warn("failed to persist patch posture", "error", err.Error())
+	}
+}
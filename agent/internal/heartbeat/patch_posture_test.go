@@ -0,0 +1,36 @@
+package heartbeat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPatchPostureFromInventory(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	pending := []map[string]any{
+		{"name": "KB1", "severity": "critical", "releaseDate": "2026-01-10"},
+		{"name": "KB2", "severity": "important", "releaseDate": "2025-12-20T00:00:00Z"},
+		{"name": "KB3", "severity": "moderate", "releaseDate": "2025-06-01"},
+		{"name": "KB4", "severity": "critical"},
+	}
+	installed := []map[string]any{
+		{"name": "KB0", "installedAt": "2026-02-01 10:00:00"},
+		{"name": "KB9", "installedAt": "2026-02-15T08:00:00Z"},
+		{"name": "KB8", "installedAt": "not a date"},
+	}
+
+	got := patchPostureFromInventory(pending, installed, now)
+	if got.PendingCritical != 2 || got.PendingImportant != 1 {
+		t.Fatalf("pending = %d critical, %d important", got.PendingCritical, got.PendingImportant)
+	}
+	// Moderate patches don't count toward the backlog age.
+	if got.OldestPendingReleasedAt == nil || !got.OldestPendingReleasedAt.Equal(time.Date(2025, 12, 20, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("oldest pending = %v", got.OldestPendingReleasedAt)
+	}
+	if got.LastInstalledAt == nil || !got.LastInstalledAt.Equal(time.Date(2026, 2, 15, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("last installed = %v", got.LastInstalledAt)
+	}
+	if !got.CollectedAt.Equal(now) {
+		t.Fatalf("collected at = %v", got.CollectedAt)
+	}
+}
@@ -0,0 +1,89 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/ipc"
+)
+
+// rebootPromptGrace is how long past the prompt timeout the agent waits for
+// the helper's answer before giving up on it.
+const rebootPromptGrace = 30 * time.Second
+
+// promptRebootDeferral shows a patch reboot warning with postpone choices in
+// the preferred user session and returns the choice picked, or "" when none
+// was. Without a helper that can notify, every session gets the plain
+// warning instead.
+func (h *Heartbeat) promptRebootDeferral(title, body string, actions []string, timeout time.Duration) string {
+	if h.sessionBroker == nil {
+		return ""
+	}
+	session := h.sessionBroker.PreferredSessionWithScope("notify")
+	if session == nil {
+		h.sessionBroker.BroadcastNotification(title, body, "critical")
+		return ""
+	}
+
+	req := ipc.NotifyRequest{
+		Title:          title,
+		Body:           body,
+		Urgency:        "critical",
+		Actions:        actions,
+		TimeoutSeconds: int(timeout / time.Second),
+	}
+	resp, err := h.sessionBroker.SendCommandAndWait(session, "reboot-prompt-"+randomNotifyID(), ipc.TypeNotify, req, timeout+rebootPromptGrace)
+	if err != nil {
+		log.Warn("reboot prompt via user helper failed", "uid", session.UID, "error", err.Error())
+		return ""
+	}
+	var result ipc.NotifyResult
+	if resp != nil && resp.Payload != nil {
+		if err := json.Unmarshal(resp.Payload, &result); err != nil {
+			log.Warn("failed to unmarshal reboot prompt result", "error", err.Error())
+			return ""
+		}
+	}
+	if result.ActionClicked != "" {
+		log.Info("user answered patch reboot prompt", "uid", session.UID, "username", session.Username, "choice", result.ActionClicked)
+	}
+	return result.ActionClicked
+}
+
+// applyPatchRebootDeferralConfig handles the patch_reboot_deferral config
+// update. A null or malformed block disables the prompt.
+func (h *Heartbeat) applyPatchRebootDeferralConfig(raw any) {
+	if h.rebootMgr == nil {
+		return
+	}
+	maxDeferrals := 0
+	if m, ok := raw.(map[string]any); ok {
+		for _, key := range []string{"maxDeferrals", "max_deferrals"} {
+			if v, ok := m[key].(float64); ok {
+				maxDeferrals = int(v)
+				break
+			}
+		}
+	}
+	h.rebootMgr.SetMaxDeferrals(maxDeferrals)
+}
+
+// patchRebootDeferralReport is the rebootDeferral block of a full pending
+// patch upload: the user's postpone choices for the scheduled patch reboot,
+// or nil when there are none so the server clears what it last stored.
+func (h *Heartbeat) patchRebootDeferralReport() map[string]any {
+	if h.rebootMgr == nil {
+		return nil
+	}
+	state := h.rebootMgr.State()
+	if len(state.DeferralHistory) == 0 {
+		return nil
+	}
+	return map[string]any{
+		"rebootScheduled": state.RebootScheduled,
+		"scheduledAt":     state.ScheduledAt,
+		"deferrals":       state.Deferrals,
+		"maxDeferrals":    state.MaxDeferrals,
+		"choices":         state.DeferralHistory,
+	}
+}
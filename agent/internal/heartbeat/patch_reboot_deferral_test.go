@@ -0,0 +1,41 @@
+package heartbeat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/patching"
+)
+
+func TestPatchRebootDeferralConfigAndReport(t *testing.T) {
+	h := New(&config.Config{AgentID: "agent-1"})
+	defer h.rebootMgr.Stop()
+
+	h.applyPatchRebootDeferralConfig(map[string]any{"maxDeferrals": float64(2)})
+	if report := h.patchRebootDeferralReport(); report != nil {
+		t.Fatalf("expected no report without deferrals, got %v", report)
+	}
+
+	if err := h.rebootMgr.Schedule(2*time.Hour, time.Now().Add(2*time.Hour), "patches", "patch_job"); err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	if _, err := h.rebootMgr.Postpone(patching.RebootDeferFourHours); err != nil {
+		t.Fatalf("Postpone: %v", err)
+	}
+
+	report := h.patchRebootDeferralReport()
+	if report == nil || report["deferrals"] != 1 || report["maxDeferrals"] != 2 || report["rebootScheduled"] != true {
+		t.Fatalf("unexpected report %v", report)
+	}
+	choices, _ := report["choices"].([]patching.RebootDeferral)
+	if len(choices) != 1 || choices[0].Choice != patching.RebootDeferFourHours {
+		t.Fatalf("unexpected choices %v", report["choices"])
+	}
+
+	// A null block disables further postponing.
+	h.applyPatchRebootDeferralConfig(nil)
+	if got := h.rebootMgr.State().MaxDeferrals; got != 0 {
+		t.Fatalf("MaxDeferrals = %d after a null config, want 0", got)
+	}
+}
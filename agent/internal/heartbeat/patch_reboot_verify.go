@@ -0,0 +1,120 @@
+package heartbeat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/patching"
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// rebootVerifySettle is how long after boot verification waits, so Windows
+// has finished post-reboot servicing before the installed list is trusted.
+var rebootVerifySettle = 10 * time.Minute
+
+// patchBootTime is swapped in tests.
+var patchBootTime = func() (time.Time, error) {
+	sec, err := host.BootTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(sec), 0), nil
+}
+
+// rebootPendingPatchFor builds the verification record for an install that
+// reported it needs a reboot.
+func rebootPendingPatchFor(ref patchCommandRef, installID string, now time.Time) patching.RebootPendingPatch {
+	provider, _, _ := strings.Cut(installID, ":")
+	return patching.RebootPendingPatch{
+		InstallID:   installID,
+		Provider:    provider,
+		ID:          ref.ID,
+		Source:      ref.Source,
+		ExternalID:  ref.ExternalID,
+		Title:       ref.Title,
+		InstalledAt: now,
+	}
+}
+
+// recordPatchesAwaitingReboot remembers reboot-required installs so the first
+// scan after the restart can confirm they stuck.
+func (h *Heartbeat) recordPatchesAwaitingReboot(patches []patching.RebootPendingPatch) {
+	if h.rebootVerify == nil || len(patches) == 0 {
+		return
+	}
+	if err := h.rebootVerify.Record(patches, time.Now()); err != nil {
+		log.Warn("failed to persist patches awaiting reboot", "error", err.Error())
+	}
+}
+
+// verifyPatchesAfterReboot checks the patches installed before the last boot
+// against this scan and reports the ones that rolled back or never finished.
+// It waits until the device has been up for rebootVerifySettle.
+func (h *Heartbeat) verifyPatchesAfterReboot(available []patching.AvailablePatch, installed []patching.InstalledPatch, installedErr error, coveredProviders []string) {
+	if h.rebootVerify == nil || installedErr != nil {
+		return
+	}
+	boot, err := patchBootTime()
+	if err != nil || time.Since(boot) < rebootVerifySettle {
+		return
+	}
+	results := h.rebootVerify.Verify(boot, available, installed, coveredProviders)
+	if len(results) == 0 {
+		return
+	}
+
+	verified, rolledBack, notFinal := 0, 0, 0
+	for _, r := range results {
+		switch r.Status {
+		case patching.RebootVerifyVerified:
+			verified++
+		case patching.RebootVerifyRolledBack:
+			rolledBack++
+		default:
+			notFinal++
+		}
+	}
+	if rolledBack+notFinal > 0 {
+		log.Warn("patches did not stick after reboot", "rolledBack", rolledBack, "failedFinalization", notFinal)
+	}
+
+	report := map[string]any{
+		"bootTime":                boot.UTC(),
+		"verifiedAt":              time.Now().UTC(),
+		"verifiedCount":           verified,
+		"rolledBackCount":         rolledBack,
+		"failedFinalizationCount": notFinal,
+		"results":                 results,
+	}
+	label := fmt.Sprintf("patch reboot verification (%d verified, %d rolled back, %d not finalized)", verified, rolledBack, notFinal)
+	if err := h.sendInventoryData("patches/reboot-verification", report, label); err != nil {
+		return
+	}
+	if err := h.rebootVerify.Remove(results); err != nil {
+		log.Warn("failed to clear verified reboot patches", "error", err.Error())
+	}
+}
+
+// runPostRebootPatchVerification rescans once the device has settled after a
+// reboot that patches were waiting on. The startup scan runs too early to
+// verify them.
+func (h *Heartbeat) runPostRebootPatchVerification() {
+	if h.rebootVerify == nil {
+		return
+	}
+	boot, err := patchBootTime()
+	if err != nil || !h.rebootVerify.DueAt(boot) {
+		return
+	}
+	wait := time.Until(boot.Add(rebootVerifySettle))
+	if wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-h.stopChan:
+			return
+		}
+	}
+	log.Info("post-reboot patch verification scan triggered")
+	h.sendPatchInventory()
+}
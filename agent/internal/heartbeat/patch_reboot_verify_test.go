@@ -0,0 +1,84 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/httputil"
+	"github.com/breeze-rmm/agent/internal/patching"
+)
+
+func TestVerifyPatchesAfterRebootReportsRollbacks(t *testing.T) {
+	var requests []patchInventoryRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, patchInventoryRequest{path: r.URL.Path, body: body})
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	boot := time.Now().Add(-time.Hour)
+	origBoot := patchBootTime
+	patchBootTime = func() (time.Time, error) { return boot, nil }
+	defer func() { patchBootTime = origBoot }()
+
+	h := New(&config.Config{AgentID: "agent-1", ServerURL: ts.URL, AuthToken: "token"})
+	h.retryCfg = httputil.RetryConfig{MaxRetries: 0}
+	h.rebootVerify = patching.NewRebootVerificationStore("")
+
+	installedAt := boot.Add(-time.Hour)
+	h.recordPatchesAwaitingReboot([]patching.RebootPendingPatch{
+		rebootPendingPatchFor(patchCommandRef{ID: "p-1"}, "windows-update:a", installedAt),
+		rebootPendingPatchFor(patchCommandRef{ID: "p-2"}, "windows-update:b", installedAt),
+	})
+
+	available := []patching.AvailablePatch{{ID: "windows-update:b", Provider: "windows-update"}}
+	installed := []patching.InstalledPatch{{ID: "windows-update:a", Provider: "windows-update"}}
+	h.verifyPatchesAfterReboot(available, installed, nil, []string{"windows-update"})
+
+	if len(requests) != 1 || requests[0].path != "/api/v1/agents/agent-1/patches/reboot-verification" {
+		t.Fatalf("unexpected requests %#v", requests)
+	}
+	var report struct {
+		VerifiedCount   int                           `json:"verifiedCount"`
+		RolledBackCount int                           `json:"rolledBackCount"`
+		Results         []patching.RebootVerification `json:"results"`
+	}
+	if err := json.Unmarshal(requests[0].body, &report); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if report.VerifiedCount != 1 || report.RolledBackCount != 1 || len(report.Results) != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if report.Results[1].ID != "p-2" || report.Results[1].Status != patching.RebootVerifyRolledBack {
+		t.Fatalf("unexpected result %+v", report.Results[1])
+	}
+
+	// Reported records are cleared.
+	h.verifyPatchesAfterReboot(available, installed, nil, []string{"windows-update"})
+	if len(requests) != 1 {
+		t.Fatal("expected verified records to be reported once")
+	}
+}
+
+func TestVerifyPatchesAfterRebootWaitsForSettle(t *testing.T) {
+	origBoot := patchBootTime
+	patchBootTime = func() (time.Time, error) { return time.Now().Add(-time.Minute), nil }
+	defer func() { patchBootTime = origBoot }()
+
+	h := New(&config.Config{AgentID: "agent-1", ServerURL: "http://127.0.0.1:1", AuthToken: "token"})
+	h.rebootVerify = patching.NewRebootVerificationStore("")
+	h.recordPatchesAwaitingReboot([]patching.RebootPendingPatch{
+		rebootPendingPatchFor(patchCommandRef{ID: "p-1"}, "windows-update:a", time.Now().Add(-time.Hour)),
+	})
+
+	h.verifyPatchesAfterReboot(nil, nil, nil, []string{"windows-update"})
+	if !h.rebootVerify.DueAt(time.Now()) {
+		t.Fatal("expected the record to be kept until the device settles")
+	}
+}
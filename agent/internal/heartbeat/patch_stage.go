@@ -0,0 +1,124 @@
+package heartbeat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/patching"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+// handlePatchStage downloads patches into the package-manager caches ahead
+// of time and, unless installInWindow is false, queues their install for the
+// next patch maintenance window, so the window is spent installing rather
+// than downloading. Staging itself ignores the maintenance windows: it is
+// meant to run during the day.
+func handlePatchStage(h *Heartbeat, cmd Command) tools.CommandResult {
+	start := time.Now()
+	if h.patchMgr == nil || len(h.patchMgr.ProviderIDs()) == 0 {
+		return tools.NewErrorResult(fmt.Errorf("no patch providers available"), time.Since(start).Milliseconds())
+	}
+
+	refs := h.patchRefsFromPayload(cmd.Payload)
+	if len(refs) == 0 {
+		return tools.NewErrorResult(fmt.Errorf("no patches provided"), time.Since(start).Milliseconds())
+	}
+
+	results := make([]map[string]any, 0, len(refs))
+	refByInstallID := make(map[string]patchCommandRef, len(refs))
+	installIDs := make([]string, 0, len(refs))
+	failedCount := 0
+	for _, ref := range refs {
+		installID, err := h.resolvePatchInstallID(ref)
+		if err != nil {
+			failedCount++
+			result := patchCommandResultFields(ref, "")
+			result["status"] = "failed"
+			result["error"] = err.Error()
+			results = append(results, result)
+			continue
+		}
+		if _, dup := refByInstallID[installID]; dup {
+			continue
+		}
+		refByInstallID[installID] = ref
+		installIDs = append(installIDs, installID)
+	}
+
+	opts := h.patchStageOptions(cmd.Payload)
+	staged, err := h.patchMgr.StagePatches(installIDs, opts, h.patchProgressSender(cmd.ID))
+	if err != nil {
+		return tools.NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	stagedCount := 0
+	for _, r := range staged {
+		result := patchCommandResultFields(refByInstallID[r.PatchID], r.PatchID)
+		if r.Success {
+			stagedCount++
+			result["status"] = "staged"
+		} else {
+			failedCount++
+			result["status"] = "failed"
+			result["error"] = r.Message
+		}
+		results = append(results, result)
+	}
+
+	summary := map[string]any{
+		"stagedCount":        stagedCount,
+		"failedCount":        failedCount,
+		"bandwidthLimitKbps": opts.BandwidthLimitKBps,
+		"installQueued":      false,
+		"results":            results,
+	}
+	if stagedCount > 0 && tools.GetPayloadBool(cmd.Payload, "installInWindow", true) {
+		h.queueStagedInstall(cmd, summary)
+	}
+
+	log.Info("staged patches",
+		"commandId", cmd.ID, "staged", stagedCount, "failed", failedCount,
+		"bandwidthLimitKbps", opts.BandwidthLimitKBps, "installQueued", summary["installQueued"])
+	return tools.NewSuccessResult(summary, time.Since(start).Milliseconds())
+}
+
+// patchStageOptions reads the bandwidth limit from the command, falling back
+// to patch_stage_bandwidth_limit_kbps, and the disk floor from
+// patch_min_disk_space_gb.
+func (h *Heartbeat) patchStageOptions(payload map[string]any) patching.StageOptions {
+	var opts patching.StageOptions
+	if h.config != nil {
+		opts.BandwidthLimitKBps = h.config.PatchStageBandwidthLimitKbps
+		opts.MinFreeDiskGB = h.config.PatchMinDiskSpaceGB
+	}
+	if limit := tools.GetPayloadInt(payload, "bandwidthLimitKbps", -1); limit >= 0 {
+		opts.BandwidthLimitKBps = limit
+	}
+	return opts
+}
+
+// queueStagedInstall queues the command's patches as a deferred install that
+// the maintenance-window loop runs once a window is open. Without configured
+// windows nothing is queued: the install would run within a minute, which
+// defeats staging during working hours.
+func (h *Heartbeat) queueStagedInstall(cmd Command, summary map[string]any) {
+	if len(h.patchMgr.MaintenanceWindows()) == 0 {
+		summary["installNote"] = "no patch maintenance window configured; install the staged patches with install_patches"
+		return
+	}
+
+	queued, err := h.patchMgr.DeferOperation(patching.DeferredOperation{
+		Kind:      patching.DeferredInstall,
+		CommandID: cmd.ID,
+		Payload:   cmd.Payload,
+	})
+	if err != nil {
+		summary["installNote"] = fmt.Sprintf("install not queued: %v", err)
+		return
+	}
+	summary["installQueued"] = true
+	summary["queuedCount"] = queued
+	if open, next := h.patchMgr.MaintenanceWindowOpen(time.Now()); !open && !next.IsZero() {
+		summary["nextWindowStart"] = next.UTC().Format(time.RFC3339)
+	}
+}
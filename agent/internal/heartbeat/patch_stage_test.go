@@ -0,0 +1,103 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/patching"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+type stageMockProvider struct {
+	heartbeatMockProvider
+	limits []int
+}
+
+func (p *stageMockProvider) Stage(patchIDs []string, limitKBps int, _ patching.ProgressCallback) ([]patching.DownloadResult, error) {
+	p.limits = append(p.limits, limitKBps)
+	results := make([]patching.DownloadResult, len(patchIDs))
+	for i, id := range patchIDs {
+		results[i] = patching.DownloadResult{PatchID: id, Success: true}
+	}
+	return results, nil
+}
+
+func runPatchStage(t *testing.T, h *Heartbeat, payload map[string]any) map[string]any {
+	t.Helper()
+	result := handlePatchStage(h, Command{ID: "stage-1", Type: tools.CmdPatchStage, Payload: payload})
+	if result.Status != "completed" {
+		t.Fatalf("result = %+v, want success", result)
+	}
+	var out map[string]any
+	if err := json.Unmarshal([]byte(result.Stdout), &out); err != nil {
+		t.Fatalf("stdout: %v", err)
+	}
+	return out
+}
+
+func TestPatchStageQueuesInstallForMaintenanceWindow(t *testing.T) {
+	provider := &stageMockProvider{heartbeatMockProvider: heartbeatMockProvider{id: "apt"}}
+	cfg := config.Default()
+	cfg.PatchMinDiskSpaceGB = 0
+	cfg.PatchStageBandwidthLimitKbps = 256
+	h := &Heartbeat{config: cfg, patchMgr: patching.NewPatchManager(provider)}
+	h.applyPatchMaintenanceConfig([]any{closedWindow()})
+
+	out := runPatchStage(t, h, map[string]any{"patchIds": []any{"apt:curl", "apt:openssl"}})
+
+	if out["stagedCount"] != float64(2) || out["installQueued"] != true || out["nextWindowStart"] == nil {
+		t.Fatalf("result = %v, want 2 staged and an install queued", out)
+	}
+	if len(provider.limits) != 1 || provider.limits[0] != 256 {
+		t.Errorf("limits = %v, want the configured 256", provider.limits)
+	}
+	if len(provider.installIDs) != 0 {
+		t.Errorf("installed %v while staging", provider.installIDs)
+	}
+	queued := h.patchMgr.DeferredOperations()
+	if len(queued) != 1 || queued[0].Kind != patching.DeferredInstall || queued[0].CommandID != "stage-1" {
+		t.Fatalf("queue = %+v, want the staged install", queued)
+	}
+}
+
+func TestPatchStagePayloadOverridesBandwidthAndSkipsInstall(t *testing.T) {
+	provider := &stageMockProvider{heartbeatMockProvider: heartbeatMockProvider{id: "apt"}}
+	cfg := config.Default()
+	cfg.PatchMinDiskSpaceGB = 0
+	cfg.PatchStageBandwidthLimitKbps = 256
+	h := &Heartbeat{config: cfg, patchMgr: patching.NewPatchManager(provider)}
+	h.applyPatchMaintenanceConfig([]any{closedWindow()})
+
+	out := runPatchStage(t, h, map[string]any{
+		"patchIds":           []any{"apt:curl"},
+		"bandwidthLimitKbps": float64(0),
+		"installInWindow":    false,
+	})
+
+	if out["installQueued"] != false {
+		t.Errorf("installQueued = %v, want false", out["installQueued"])
+	}
+	if len(provider.limits) != 1 || provider.limits[0] != 0 {
+		t.Errorf("limits = %v, want the payload's 0", provider.limits)
+	}
+	if n := len(h.patchMgr.DeferredOperations()); n != 0 {
+		t.Errorf("queued %d operations, want none", n)
+	}
+}
+
+func TestPatchStageWithoutWindowDoesNotQueueInstall(t *testing.T) {
+	provider := &stageMockProvider{heartbeatMockProvider: heartbeatMockProvider{id: "apt"}}
+	cfg := config.Default()
+	cfg.PatchMinDiskSpaceGB = 0
+	h := &Heartbeat{config: cfg, patchMgr: patching.NewPatchManager(provider)}
+
+	out := runPatchStage(t, h, map[string]any{"patchIds": []any{"apt:curl"}})
+
+	if out["installQueued"] != false || out["installNote"] == nil {
+		t.Fatalf("result = %v, want no install queued with a note", out)
+	}
+	if n := len(h.patchMgr.DeferredOperations()); n != 0 {
+		t.Errorf("queued %d operations, want none", n)
+	}
+}
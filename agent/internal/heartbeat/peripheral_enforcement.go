@@ -0,0 +1,128 @@
+package heartbeat
+
+import (
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/observability"
+	"github.com/breeze-rmm/agent/internal/peripheral"
+)
+
+// peripheralEnforceInterval is how often the stored peripheral policies are
+// re-applied between syncs. macOS has no durable gate, and a device plugged
+// in after a sync is only blocked or made read-only by the next pass.
+const peripheralEnforceInterval = time.Minute
+
+// peripheralEnforcement serializes OS enforcement between the policy sync
+// command and the periodic pass, and remembers which violations were already
+// reported so a device that stays attached is reported once.
+var peripheralEnforcement = struct {
+	sync.Mutex
+	reported map[string]bool
+}{reported: map[string]bool{}}
+
+// applyPeripheralPolicies evaluates detected devices against policies and
+// converges OS enforcement to the result.
+func applyPeripheralPolicies(detected []peripheral.DetectedPeripheral, policies []peripheral.Policy) ([]peripheral.EvaluationResult, peripheral.EnforcementPlan, peripheral.EnforcementOutcome) {
+	results := peripheral.Evaluate(detected, policies)
+	plan := peripheral.Plan(results, policies)
+	outcome := peripheral.Enforce(peripheral.NewEnforcer(), plan, peripheral.EnforceableClasses())
+	return results, plan, outcome
+}
+
+// selectNewViolations returns the events for devices that matched a block or
+// read_only policy and have not been reported while attached, with their keys,
+// recording them in reported. Entries for devices no longer attached are
+// dropped so a reinsertion is reported again. events must be
+// ToEvents(results, ...).
+func selectNewViolations(results []peripheral.EvaluationResult, events []peripheral.PeripheralEvent, reported map[string]bool) ([]peripheral.PeripheralEvent, []string) {
+	attached := map[string]bool{}
+	var fresh []peripheral.PeripheralEvent
+	var keys []string
+	for i, r := range results {
+		if r.Policy == nil || (r.Action != "block" && r.Action != "read_only") || i >= len(events) {
+			continue
+		}
+		key := r.Peripheral.DeviceID + "|" + r.Policy.ID + "|" + events[i].EventType
+		attached[key] = true
+		if !reported[key] {
+			fresh = append(fresh, events[i])
+			keys = append(keys, key)
+		}
+	}
+	for key := range reported {
+		if !attached[key] {
+			delete(reported, key)
+		}
+	}
+	for key := range attached {
+		reported[key] = true
+	}
+	return fresh, keys
+}
+
+// forgetViolations un-records violations whose submission failed so the next
+// pass reports them again.
+func forgetViolations(keys []string) {
+	peripheralEnforcement.Lock()
+	defer peripheralEnforcement.Unlock()
+	for _, key := range keys {
+		delete(peripheralEnforcement.reported, key)
+	}
+}
+
+func (h *Heartbeat) runPeripheralEnforcementLoop() {
+	ticker := time.NewTicker(peripheralEnforceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			func() {
+				defer observability.Recoverer("heartbeat.peripheralEnforcement")
+				if h.provisioning.Active() {
+					return
+				}
+				h.enforceStoredPeripheralPolicies()
+			}()
+		case <-h.stopChan:
+			return
+		}
+	}
+}
+
+// enforceStoredPeripheralPolicies re-applies the last synced policies and
+// submits violations not yet reported.
+func (h *Heartbeat) enforceStoredPeripheralPolicies() {
+	policies, err := peripheral.NewStore().Load()
+	if err != nil {
+		log.Warn("failed to load peripheral policies", "error", err.Error())
+		return
+	}
+	if !peripheral.NeedsEnforcement(policies) {
+		return
+	}
+	detected, err := peripheral.DetectPeripherals()
+	if err != nil {
+		log.Warn("peripheral detection failed", "error", err.Error())
+		return
+	}
+
+	peripheralEnforcement.Lock()
+	results, _, outcome := applyPeripheralPolicies(detected, policies)
+	events, keys := selectNewViolations(results, peripheral.ToEvents(results, outcome), peripheralEnforcement.reported)
+	peripheralEnforcement.Unlock()
+
+	if unverified := peripheral.CountUnverified(outcome); unverified > 0 {
+		log.Warn("peripheral enforcement had unverified outcomes", "unverified", unverified)
+	}
+	if len(events) == 0 {
+		return
+	}
+	if err := h.submitPeripheralEvents(events); err != nil {
+		log.Error("failed to submit peripheral violations", "error", err.Error())
+		forgetViolations(keys)
+		return
+	}
+	log.Info("peripheral violations submitted", "count", len(events))
+}
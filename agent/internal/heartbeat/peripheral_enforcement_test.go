@@ -0,0 +1,50 @@
+package heartbeat
+
+import (
+	"testing"
+
+	"github.com/breeze-rmm/agent/internal/peripheral"
+)
+
+func TestSelectNewViolationsReportsOncePerAttachment(t *testing.T) {
+	pol := &peripheral.Policy{ID: "p1", DeviceClass: "storage", Action: "block", IsActive: true}
+	drive := peripheral.EvaluationResult{
+		Peripheral: peripheral.DetectedPeripheral{DeviceClass: "storage", DeviceID: "1-2"},
+		Policy:     pol,
+		Action:     "block",
+	}
+	mouse := peripheral.EvaluationResult{Peripheral: peripheral.DetectedPeripheral{DeviceClass: "all_usb", DeviceID: "1-3"}}
+	results := []peripheral.EvaluationResult{drive, mouse}
+	events := []peripheral.PeripheralEvent{{EventType: "blocked"}, {EventType: "connected"}}
+	reported := map[string]bool{}
+
+	fresh, keys := selectNewViolations(results, events, reported)
+	if len(fresh) != 1 || fresh[0].EventType != "blocked" || len(keys) != 1 {
+		t.Fatalf("first pass should report the blocked drive only, got %+v", fresh)
+	}
+	if fresh, _ := selectNewViolations(results, events, reported); len(fresh) != 0 {
+		t.Fatalf("a device still attached must not be reported again, got %+v", fresh)
+	}
+
+	// Unplugged, then reinserted: reported again.
+	selectNewViolations(nil, nil, reported)
+	if fresh, _ := selectNewViolations(results, events, reported); len(fresh) != 1 {
+		t.Fatalf("a reinsertion should be reported, got %+v", fresh)
+	}
+}
+
+func TestSelectNewViolationsReportsOutcomeChange(t *testing.T) {
+	pol := &peripheral.Policy{ID: "p1", DeviceClass: "storage", Action: "block", IsActive: true}
+	results := []peripheral.EvaluationResult{{
+		Peripheral: peripheral.DetectedPeripheral{DeviceClass: "storage", DeviceID: "1-2"},
+		Policy:     pol,
+		Action:     "block",
+	}}
+	reported := map[string]bool{}
+
+	selectNewViolations(results, []peripheral.PeripheralEvent{{EventType: "connected"}}, reported)
+	fresh, _ := selectNewViolations(results, []peripheral.PeripheralEvent{{EventType: "blocked"}}, reported)
+	if len(fresh) != 1 {
+		t.Fatalf("an alert-only device later blocked should be reported, got %+v", fresh)
+	}
+}
@@ -0,0 +1,55 @@
+package heartbeat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/collectors"
+	"github.com/breeze-rmm/agent/internal/config"
+)
+
+// clampProcessInventoryIntervalMinutes bounds the configured full process
+// inventory cadence to [15, 1440] minutes. A value ≤0 (unset) returns the
+// default. Pure so the cadence math is unit-testable.
+func clampProcessInventoryIntervalMinutes(minutes int) int {
+	if minutes <= 0 {
+		return config.DefaultProcessInventoryIntervalMinutes
+	}
+	if minutes < 15 {
+		return 15
+	}
+	if minutes > 1440 {
+		return 1440
+	}
+	return minutes
+}
+
+// processInventoryInterval is the effective cadence for sendProcessInventory.
+func (h *Heartbeat) processInventoryInterval() time.Duration {
+	return time.Duration(clampProcessInventoryIntervalMinutes(h.config.ProcessInventoryIntervalMinutes)) * time.Minute
+}
+
+// sendProcessInventory ships the full running-process table (path, SHA-256,
+// user, CPU/RSS, start time, signature status) to the processes endpoint.
+// Unlike the top-N process sampler this is the complete list, so it runs on
+// its own slower cadence.
+func (h *Heartbeat) sendProcessInventory() {
+	if h.processCol == nil {
+		return
+	}
+
+	procs, err := h.processCol.Collect()
+	if err != nil {
+		log.Error("failed to collect process inventory", "error", err.Error())
+		return
+	}
+	if procs == nil {
+		procs = []collectors.ProcessInventoryItem{}
+	}
+
+	payload := map[string]any{
+		"processes":   procs,
+		"collectedAt": time.Now().UTC(),
+	}
+	h.sendInventoryData("processes", payload, fmt.Sprintf("processes (%d)", len(procs)))
+}
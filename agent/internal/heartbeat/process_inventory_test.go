@@ -0,0 +1,23 @@
+package heartbeat
+
+import "testing"
+
+func TestClampProcessInventoryIntervalMinutes(t *testing.T) {
+	cases := []struct {
+		in   int
+		want int
+	}{
+		{in: 0, want: 60},      // unset/zero → default
+		{in: -10, want: 60},    // negative → default
+		{in: 5, want: 15},      // below min → floor
+		{in: 15, want: 15},     // min boundary
+		{in: 60, want: 60},     // default, in range
+		{in: 1440, want: 1440}, // max boundary (1 day)
+		{in: 5000, want: 1440}, // above max → ceiling
+	}
+	for _, c := range cases {
+		if got := clampProcessInventoryIntervalMinutes(c.in); got != c.want {
+			t.Errorf("clampProcessInventoryIntervalMinutes(%d) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package heartbeat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+// Provisioning quiet mode. While a device is mid-sysprep/OOBE/Autopilot ESP
+// or being imaged — and until the first real user logon afterwards — the
+// agent keeps heartbeating but sends no inventory, runs no patching and
+// performs no reboots, so it cannot break the provisioning flow.
+
+// provisioningDeferredCommands are refused in quiet mode: anything that
+// installs updates or restarts, powers off or suspends the machine.
+var provisioningDeferredCommands = map[string]bool{
+	tools.CmdPatchScan:       true,
+	tools.CmdInstallPatches:  true,
+	tools.CmdRollbackPatches: true,
+	tools.CmdDownloadPatches: true,
+	tools.CmdPatchStage:      true,
+	tools.CmdScheduleReboot:  true,
+	tools.CmdReboot:          true,
+	tools.CmdShutdown:        true,
+	tools.CmdSleep:           true,
+	tools.CmdHibernate:       true,
+	tools.CmdRebootSafeMode:  true,
+}
+
+// refreshProvisioningState re-runs provisioning detection, logs quiet-mode
+// transitions and returns whether quiet mode is active.
+func (h *Heartbeat) refreshProvisioningState() bool {
+	if h.provisioning == nil {
+		return false
+	}
+	status, changed, err := h.provisioning.Update(time.Now())
+	if err != nil {
+		log.Warn("failed to persist provisioning quiet mode", "error", err.Error())
+	}
+	if changed {
+		if status.Active {
+			log.Info("device is being provisioned, entering quiet mode", "phase", string(status.Phase), "reason", status.Reason)
+		} else {
+			log.Info("provisioning complete, leaving quiet mode")
+		}
+	}
+	return status.Active
+}
+
+// provisioningDeferral returns why cmdType must not run now, or "" when it may.
+func (h *Heartbeat) provisioningDeferral(cmdType string) string {
+	if !provisioningDeferredCommands[cmdType] {
+		return ""
+	}
+	status := h.provisioning.Status()
+	if !status.Active {
+		return ""
+	}
+	return fmt.Sprintf("deferred: device is being provisioned (%s: %s)", status.Phase, status.Reason)
+}
@@ -0,0 +1,34 @@
+package heartbeat
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/breeze-rmm/agent/internal/provisioning"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+)
+
+func TestProvisioningDeferral(t *testing.T) {
+	h := &Heartbeat{}
+	if reason := h.provisioningDeferral(tools.CmdInstallPatches); reason != "" {
+		t.Fatalf("no tracker should never defer, got %q", reason)
+	}
+
+	path := filepath.Join(t.TempDir(), "provisioning_quiet.json")
+	latch := `{"active":true,"phase":"autopilot_esp","reason":"Enrollment Status Page device phase in progress","since":"2026-05-01T09:00:00Z"}`
+	if err := os.WriteFile(path, []byte(latch), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	h.provisioning = provisioning.NewTracker(path)
+
+	for _, cmdType := range []string{tools.CmdInstallPatches, tools.CmdReboot, tools.CmdScheduleReboot} {
+		if reason := h.provisioningDeferral(cmdType); !strings.Contains(reason, "autopilot_esp") {
+			t.Errorf("%s should be deferred during provisioning, got %q", cmdType, reason)
+		}
+	}
+	if reason := h.provisioningDeferral(tools.CmdRunScript); reason != "" {
+		t.Errorf("scripts should still run during provisioning, got %q", reason)
+	}
+}
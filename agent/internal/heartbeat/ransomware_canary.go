@@ -0,0 +1,364 @@
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/audit"
+	"github.com/breeze-rmm/agent/internal/canary"
+	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/security"
+)
+
+const networkIsolationFile = "network_isolation.json"
+
+// Security alert types sent over the WebSocket.
+const (
+	securityAlertRansomwareCanary = "ransomware_canary"
+	securityAlertNetworkIsolation = "network_isolation"
+)
+
+// canaryPolicy is the ransomware_canary config update block.
+type canaryPolicy struct {
+	Enabled          bool `json:"enabled"`
+	IsolateOnTrigger bool `json:"isolateOnTrigger"`
+}
+
+// securityAlert is the body of a security_alert WebSocket message.
+type securityAlert struct {
+	AlertType  string    `json:"alertType"`
+	Severity   string    `json:"severity"`
+	OccurredAt time.Time `json:"occurredAt"`
+	// ransomware_canary
+	Path               string `json:"path,omitempty"`
+	Change             string `json:"change,omitempty"`
+	IsolationRequested bool   `json:"isolationRequested,omitempty"`
+	// network_isolation
+	Isolated *bool    `json:"isolated,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+	Allowed  []string `json:"allowed,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// ransomwareCanary holds the running canary monitor and the alerts that
+// could not be sent yet; they go out on the next WebSocket connect.
+var ransomwareCanary = struct {
+	sync.Mutex
+	monitor *canary.Monitor
+	policy  canaryPolicy
+	pending []securityAlert
+}{}
+
+// isolationMu serializes isolating and releasing the host.
+var isolationMu sync.Mutex
+
+func parseCanaryPolicy(raw any) (canaryPolicy, bool) {
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return canaryPolicy{}, false
+	}
+	var p canaryPolicy
+	p.Enabled, _ = m["enabled"].(bool)
+	p.IsolateOnTrigger, _ = m["isolateOnTrigger"].(bool)
+	return p, true
+}
+
+// applyRansomwareCanaryConfig starts or stops the canary monitor. Disabling
+// it removes the planted canaries.
+func (h *Heartbeat) applyRansomwareCanaryConfig(raw any) {
+	policy, ok := parseCanaryPolicy(raw)
+	if !ok {
+		log.Warn("ignoring invalid ransomware_canary payload: not an object")
+		return
+	}
+	ransomwareCanary.Lock()
+	defer ransomwareCanary.Unlock()
+	ransomwareCanary.policy = policy
+
+	if !policy.Enabled {
+		if ransomwareCanary.monitor != nil {
+			ransomwareCanary.monitor.Remove()
+			ransomwareCanary.monitor = nil
+			log.Info("ransomware canaries disabled")
+		}
+		return
+	}
+	if ransomwareCanary.monitor != nil {
+		return
+	}
+	m := canary.New(config.GetDataDir(), h.onCanaryTriggered)
+	if err := m.Start(); err != nil {
+		log.Warn("failed to start ransomware canaries", "error", err.Error())
+		return
+	}
+	ransomwareCanary.monitor = m
+}
+
+// onCanaryTriggered alerts the server at once and, when the policy says so,
+// isolates the host before the encryption can spread to network shares.
+func (h *Heartbeat) onCanaryTriggered(t canary.Trigger) {
+	ransomwareCanary.Lock()
+	isolate := ransomwareCanary.policy.IsolateOnTrigger
+	ransomwareCanary.Unlock()
+
+	h.sendSecurityAlert(securityAlert{
+		AlertType:          securityAlertRansomwareCanary,
+		Severity:           "critical",
+		OccurredAt:         t.DetectedAt,
+		Path:               t.Path,
+		Change:             t.Change,
+		IsolationRequested: isolate,
+	})
+	if !isolate {
+		return
+	}
+	if _, err := h.isolateHost("", "ransomware_canary"); err != nil {
+		log.Error("failed to isolate host after canary trigger", "error", err.Error())
+	}
+}
+
+// sendSecurityAlert sends the alert now or queues it for the next connect.
+func (h *Heartbeat) sendSecurityAlert(alert securityAlert) {
+	if h.wsClient != nil {
+		err := h.wsClient.SendSecurityAlert(alert)
+		if err == nil {
+			return
+		}
+		log.Warn("failed to send security alert, will retry on reconnect", "alertType", alert.AlertType, "error", err.Error())
+	}
+	ransomwareCanary.Lock()
+	ransomwareCanary.pending = append(ransomwareCanary.pending, alert)
+	ransomwareCanary.Unlock()
+}
+
+// flushSecurityAlerts resends queued alerts. Called on every WebSocket
+// (re)connect.
+func (h *Heartbeat) flushSecurityAlerts() {
+	if h.wsClient == nil {
+		return
+	}
+	ransomwareCanary.Lock()
+	defer ransomwareCanary.Unlock()
+	for len(ransomwareCanary.pending) > 0 {
+		if err := h.wsClient.SendSecurityAlert(ransomwareCanary.pending[0]); err != nil {
+			return
+		}
+		ransomwareCanary.pending = ransomwareCanary.pending[1:]
+	}
+}
+
+// isolationAllowList resolves the Breeze server (and backup server) hosts
+// to the addresses isolation keeps reachable.
+func (h *Heartbeat) isolationAllowList() ([]net.IP, error) {
+	h.mu.Lock()
+	urls := []string{h.config.ServerURL, h.config.BackupServerURL}
+	h.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	seen := map[string]bool{}
+	var allowed []net.IP
+	for _, raw := range urls {
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		ips, err := net.DefaultResolver.LookupIP(ctx, "ip", u.Hostname())
+		if err != nil {
+			log.Warn("failed to resolve server host for isolation", "host", u.Hostname(), "error", err.Error())
+			continue
+		}
+		for _, ip := range ips {
+			if !seen[ip.String()] {
+				seen[ip.String()] = true
+				allowed = append(allowed, ip)
+			}
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, errors.New("could not resolve the server address; refusing to isolate the host from it")
+	}
+	return allowed, nil
+}
+
+// isolateHost cuts the host off from everything but the Breeze server and
+// records the change so it is re-applied after a restart and can be
+// released later. The server is told either way.
+func (h *Heartbeat) isolateHost(commandID, reason string) (*security.NetworkIsolation, error) {
+	isolationMu.Lock()
+	defer isolationMu.Unlock()
+
+	state, err := h.applyIsolation()
+	h.auditIsolation(commandID, "isolate", reason, state, err)
+	if err != nil {
+		isolated := false
+		h.sendSecurityAlert(securityAlert{
+			AlertType: securityAlertNetworkIsolation, Severity: "critical", OccurredAt: time.Now().UTC(),
+			Isolated: &isolated, Reason: reason, Error: err.Error(),
+		})
+		return nil, err
+	}
+	isolated := true
+	h.sendSecurityAlert(securityAlert{
+		AlertType: securityAlertNetworkIsolation, Severity: "critical", OccurredAt: time.Now().UTC(),
+		Isolated: &isolated, Reason: reason, Allowed: state.Allowed,
+	})
+	log.Warn("host network isolated", "reason", reason, "allowed", state.Allowed)
+	return state, nil
+}
+
+func (h *Heartbeat) applyIsolation() (*security.NetworkIsolation, error) {
+	previous, err := loadNetworkIsolation()
+	if err != nil {
+		log.Warn("ignoring unreadable network isolation state", "error", err.Error())
+	}
+	allowed, err := h.isolationAllowList()
+	if err != nil && previous != nil {
+		// DNS is often not up yet when the isolation is restored at boot;
+		// the addresses allowed last time keep the server reachable.
+		for _, addr := range previous.Allowed {
+			if ip := net.ParseIP(addr); ip != nil {
+				allowed = append(allowed, ip)
+			}
+		}
+		if len(allowed) > 0 {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	state, err := isolateNetwork(allowed, previous)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveNetworkIsolation(state); err != nil {
+		// Without the state file the isolation could neither be released
+		// nor re-applied after a restart, so undo it and fail.
+		if relErr := releaseNetworkIsolation(state); relErr != nil {
+			log.Error("failed to undo unrecorded network isolation", "error", relErr.Error())
+		}
+		return nil, fmt.Errorf("persist network isolation state: %w", err)
+	}
+	return state, nil
+}
+
+// releaseHostIsolation undoes isolateHost. Releasing a host that is not
+// isolated is not an error.
+func (h *Heartbeat) releaseHostIsolation(commandID, reason string) (released bool, err error) {
+	isolationMu.Lock()
+	defer isolationMu.Unlock()
+
+	state, err := loadNetworkIsolation()
+	if err != nil {
+		return false, err
+	}
+	if state == nil {
+		return false, nil
+	}
+	err = releaseNetworkIsolation(state)
+	h.auditIsolation(commandID, "release", reason, state, err)
+	if err != nil {
+		return false, err
+	}
+	if err := os.Remove(networkIsolationPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn("failed to remove network isolation state", "error", err.Error())
+	}
+	isolated := false
+	h.sendSecurityAlert(securityAlert{
+		AlertType: securityAlertNetworkIsolation, Severity: "info", OccurredAt: time.Now().UTC(),
+		Isolated: &isolated, Reason: reason,
+	})
+	log.Info("host network isolation released", "reason", reason)
+	return true, nil
+}
+
+// auditIsolation records an isolate or release attempt in the local audit
+// log, successful or not.
+func (h *Heartbeat) auditIsolation(commandID, operation, reason string, state *security.NetworkIsolation, opErr error) {
+	if h.auditLog == nil {
+		return
+	}
+	details := map[string]any{"operation": operation, "reason": reason, "status": "completed"}
+	if state != nil {
+		details["backend"] = state.Backend
+		details["allowed"] = state.Allowed
+	}
+	if opErr != nil {
+		details["status"] = "failed"
+		details["error"] = opErr.Error()
+	}
+	h.auditLog.Log(audit.EventNetworkIsolation, commandID, details)
+}
+
+// restoreNetworkIsolation re-applies a recorded isolation at startup: the
+// nftables and pf rules do not survive a reboot, and an isolated host must
+// stay isolated until it is released.
+func (h *Heartbeat) restoreNetworkIsolation() {
+	state, err := loadNetworkIsolation()
+	if err != nil {
+		log.Warn("failed to read network isolation state", "error", err.Error())
+		return
+	}
+	if state == nil {
+		return
+	}
+	if _, err := h.isolateHost("", "restored"); err != nil {
+		log.Error("failed to restore network isolation", "error", err.Error())
+	}
+}
+
+// isolateNetwork and releaseNetworkIsolation are variables so tests can
+// apply isolation without touching the host firewall.
+var (
+	isolateNetwork          = security.IsolateNetwork
+	releaseNetworkIsolation = security.ReleaseNetworkIsolation
+)
+
+// networkIsolationPath is a variable so tests can keep the state file out of
+// the real data directory.
+var networkIsolationPath = func() string {
+	return filepath.Join(config.GetDataDir(), networkIsolationFile)
+}
+
+func loadNetworkIsolation() (*security.NetworkIsolation, error) {
+	data, err := os.ReadFile(networkIsolationPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state security.NetworkIsolation
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", networkIsolationFile, err)
+	}
+	return &state, nil
+}
+
+func saveNetworkIsolation(state *security.NetworkIsolation) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := networkIsolationPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
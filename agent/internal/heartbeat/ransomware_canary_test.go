@@ -0,0 +1,38 @@
+package heartbeat
+
+import "testing"
+
+func TestParseCanaryPolicy(t *testing.T) {
+	p, ok := parseCanaryPolicy(map[string]any{"enabled": true, "isolateOnTrigger": true})
+	if !ok || !p.Enabled || !p.IsolateOnTrigger {
+		t.Fatalf("parseCanaryPolicy = %+v, %v", p, ok)
+	}
+	p, ok = parseCanaryPolicy(map[string]any{"enabled": "yes"})
+	if !ok || p.Enabled || p.IsolateOnTrigger {
+		t.Fatalf("non-boolean values must read as off, got %+v", p)
+	}
+	if _, ok := parseCanaryPolicy(true); ok {
+		t.Fatal("a non-object payload must be rejected")
+	}
+}
+
+func TestSecurityAlertQueuedWithoutWebSocket(t *testing.T) {
+	ransomwareCanary.Lock()
+	ransomwareCanary.pending = nil
+	ransomwareCanary.Unlock()
+	t.Cleanup(func() {
+		ransomwareCanary.Lock()
+		ransomwareCanary.pending = nil
+		ransomwareCanary.Unlock()
+	})
+
+	h := &Heartbeat{}
+	h.sendSecurityAlert(securityAlert{AlertType: securityAlertRansomwareCanary, Severity: "critical"})
+	h.flushSecurityAlerts()
+
+	ransomwareCanary.Lock()
+	defer ransomwareCanary.Unlock()
+	if len(ransomwareCanary.pending) != 1 {
+		t.Fatalf("alert should wait for a connection, pending = %d", len(ransomwareCanary.pending))
+	}
+}
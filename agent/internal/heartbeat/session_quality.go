@@ -0,0 +1,159 @@
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/httputil"
+	"github.com/breeze-rmm/agent/internal/ipc"
+	"github.com/breeze-rmm/agent/internal/sessionbroker"
+)
+
+const (
+	// qualityFlushBatch is how many samples (10s each) are uploaded together,
+	// about a minute of session history per request.
+	qualityFlushBatch = 6
+	// qualityMaxBuffered bounds a session's backlog while uploads fail; the
+	// oldest samples are dropped past it.
+	qualityMaxBuffered   = 120
+	qualityUploadTimeout = 30 * time.Second
+)
+
+// qualityBuffer holds a desktop session's samples not yet uploaded.
+type qualityBuffer struct {
+	samples   []ipc.DesktopQualitySample
+	uploading bool
+}
+
+// qualityBuffers holds pending quality samples keyed by desktop session ID.
+var (
+	qualityBuffersMu sync.Mutex
+	qualityBuffers   = map[string]*qualityBuffer{}
+)
+
+// handleQualityFromHelper accepts a quality sample from the helper that owns
+// the session.
+func (h *Heartbeat) handleQualityFromHelper(session *sessionbroker.Session, env *ipc.Envelope) {
+	var sample ipc.DesktopQualitySample
+	if err := json.Unmarshal(env.Payload, &sample); err != nil {
+		log.Warn("invalid desktop quality payload", "error", err.Error())
+		return
+	}
+	if !desktopSessionIDPattern.MatchString(sample.SessionID) {
+		log.Warn("dropping desktop quality sample with invalid session ID",
+			"sessionId", sample.SessionID, "helperSession", session.SessionID)
+		return
+	}
+	if owner := h.desktopOwnerSession(sample.SessionID); owner == nil || owner.SessionID != session.SessionID {
+		log.Warn("dropping desktop quality sample for non-owned session",
+			"sessionId", sample.SessionID, "helperSession", session.SessionID)
+		return
+	}
+	h.recordQualitySample(sample)
+}
+
+// recordQualitySample buffers a sample and starts an upload once a batch is
+// ready. Also the desktopMgr.OnQualitySample hook for direct sessions.
+func (h *Heartbeat) recordQualitySample(sample ipc.DesktopQualitySample) {
+	qualityBuffersMu.Lock()
+	buf := qualityBuffers[sample.SessionID]
+	if buf == nil {
+		buf = &qualityBuffer{}
+		qualityBuffers[sample.SessionID] = buf
+	}
+	buf.samples = appendQualitySamples(buf.samples, sample)
+	ready := len(buf.samples) >= qualityFlushBatch && !buf.uploading
+	qualityBuffersMu.Unlock()
+
+	if ready {
+		go h.flushQualitySamples(sample.SessionID, false)
+	}
+}
+
+// appendQualitySamples appends to a backlog, dropping the oldest samples
+// past qualityMaxBuffered.
+func appendQualitySamples(backlog []ipc.DesktopQualitySample, samples ...ipc.DesktopQualitySample) []ipc.DesktopQualitySample {
+	backlog = append(backlog, samples...)
+	if over := len(backlog) - qualityMaxBuffered; over > 0 {
+		backlog = append(backlog[:0:0], backlog[over:]...)
+	}
+	return backlog
+}
+
+// flushQualitySamples uploads a session's buffered samples. Failed uploads
+// are kept for the next flush, except the final one at session end.
+func (h *Heartbeat) flushQualitySamples(sessionID string, final bool) {
+	qualityBuffersMu.Lock()
+	buf := qualityBuffers[sessionID]
+	if buf == nil || (buf.uploading && !final) {
+		qualityBuffersMu.Unlock()
+		return
+	}
+	samples := buf.samples
+	buf.samples = nil
+	buf.uploading = true
+	if final {
+		delete(qualityBuffers, sessionID)
+	}
+	qualityBuffersMu.Unlock()
+
+	if len(samples) == 0 {
+		return
+	}
+	if final {
+		// The disconnect path must not wait on the API.
+		go func() {
+			if err := h.uploadQualitySamples(sessionID, samples); err != nil {
+				log.Warn("dropping desktop quality samples at session end", "sessionId", sessionID, "count", len(samples), "error", err.Error())
+			}
+		}()
+		return
+	}
+
+	err := h.uploadQualitySamples(sessionID, samples)
+	qualityBuffersMu.Lock()
+	defer qualityBuffersMu.Unlock()
+	if qualityBuffers[sessionID] != buf {
+		// The session ended during the upload; its final flush took the rest.
+		return
+	}
+	buf.uploading = false
+	if err != nil {
+		log.Warn("desktop quality upload failed", "sessionId", sessionID, "count", len(samples), "error", err.Error())
+		buf.samples = appendQualitySamples(samples, buf.samples...)
+	}
+}
+
+// uploadQualitySamples posts samples to the session's metrics endpoint.
+func (h *Heartbeat) uploadQualitySamples(sessionID string, samples []ipc.DesktopQualitySample) error {
+	if h.config == nil || h.serverURL() == "" {
+		return errors.New("no server configured")
+	}
+	body, err := json.Marshal(map[string]any{"samples": samples})
+	if err != nil {
+		return fmt.Errorf("marshal quality samples: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), qualityUploadTimeout)
+	defer cancel()
+	url := fmt.Sprintf("%s/api/v1/agents/%s/desktop-sessions/%s/metrics", h.serverURL(), h.config.AgentID, sessionID)
+	headers := http.Header{
+		"Content-Type":  {"application/json"},
+		"Authorization": {h.authHeader()},
+	}
+	resp, err := httputil.Do(ctx, h.httpClient(), http.MethodPost, url, body, headers, h.retryCfg)
+	if err != nil {
+		return fmt.Errorf("post quality samples: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("metrics returned status %d: %s", resp.StatusCode, string(errBody))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/ipc"
+)
+
+func TestUploadQualitySamples(t *testing.T) {
+	var got struct {
+		Samples []ipc.DesktopQualitySample `json:"samples"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/agents/agent-1/desktop-sessions/desk-1/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	h := NewWithVersion(&config.Config{
+		AgentID:   "agent-1",
+		ServerURL: ts.URL,
+		AuthToken: "token",
+	}, "test", nil, nil)
+
+	samples := []ipc.DesktopQualitySample{{SessionID: "desk-1", FPS: 30, RTTMs: 42, Encoder: "mft", HardwareEncode: true}}
+	if err := h.uploadQualitySamples("desk-1", samples); err != nil {
+		t.Fatalf("uploadQualitySamples: %v", err)
+	}
+	if len(got.Samples) != 1 || got.Samples[0].RTTMs != 42 || !got.Samples[0].HardwareEncode {
+		t.Fatalf("uploaded samples = %+v", got.Samples)
+	}
+	if err := h.uploadQualitySamples("desk-2", samples); err == nil {
+		t.Fatal("expected an error for a non-2xx response")
+	}
+}
+
+func TestQualitySamplesBatchedAndKeptOnFailure(t *testing.T) {
+	var fail atomic.Bool
+	var uploaded atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var body struct {
+			Samples []ipc.DesktopQualitySample `json:"samples"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		uploaded.Add(int64(len(body.Samples)))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	h := NewWithVersion(&config.Config{
+		AgentID:   "agent-1",
+		ServerURL: ts.URL,
+		AuthToken: "token",
+	}, "test", nil, nil)
+	const sid = "desk-batch"
+	defer func() {
+		qualityBuffersMu.Lock()
+		delete(qualityBuffers, sid)
+		qualityBuffersMu.Unlock()
+	}()
+
+	fail.Store(true)
+	for i := 0; i < qualityFlushBatch-1; i++ {
+		h.recordQualitySample(ipc.DesktopQualitySample{SessionID: sid})
+	}
+	h.flushQualitySamples(sid, false)
+	qualityBuffersMu.Lock()
+	kept := len(qualityBuffers[sid].samples)
+	qualityBuffersMu.Unlock()
+	if kept != qualityFlushBatch-1 {
+		t.Fatalf("kept %d samples after a failed upload, want %d", kept, qualityFlushBatch-1)
+	}
+
+	fail.Store(false)
+	h.flushQualitySamples(sid, true)
+	deadline := time.Now().Add(5 * time.Second)
+	for uploaded.Load() != qualityFlushBatch-1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := uploaded.Load(); n != qualityFlushBatch-1 {
+		t.Fatalf("final flush uploaded %d samples, want %d", n, qualityFlushBatch-1)
+	}
+	qualityBuffersMu.Lock()
+	_, still := qualityBuffers[sid]
+	qualityBuffersMu.Unlock()
+	if still {
+		t.Fatal("final flush should forget the session")
+	}
+}
+
+func TestAppendQualitySamplesDropsOldest(t *testing.T) {
+	var backlog []ipc.DesktopQualitySample
+	for i := 0; i < qualityMaxBuffered+5; i++ {
+		backlog = appendQualitySamples(backlog, ipc.DesktopQualitySample{AtUnixMs: int64(i)})
+	}
+	if len(backlog) != qualityMaxBuffered || backlog[0].AtUnixMs != 5 {
+		t.Fatalf("backlog len=%d first=%d, want %d starting at 5", len(backlog), backlog[0].AtUnixMs, qualityMaxBuffered)
+	}
+}
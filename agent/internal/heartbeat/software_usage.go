@@ -0,0 +1,91 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/breeze-rmm/agent/internal/collectors"
+	"github.com/breeze-rmm/agent/internal/ipc"
+	"github.com/breeze-rmm/agent/internal/sessionbroker"
+)
+
+// Software usage metering is opt-in per device (software_usage_metering).
+// User helpers do the sampling, since only they run in the user's session;
+// the agent switches them on and off, attributes their reports to the
+// helper session's user and uploads the daily totals.
+
+// usageMeteringConfig is what helpers are sent; zero intervals leave the
+// helper defaults in place.
+func (h *Heartbeat) usageMeteringConfig() ipc.UsageMeteringConfig {
+	return ipc.UsageMeteringConfig{Enabled: h.usageCol != nil && h.usageCol.Enabled()}
+}
+
+// pushUsageMeteringConfig is a broker lifecycle observer: a newly
+// authenticated user helper starts metering when it is enabled. Helpers
+// default to off, so nothing is sent otherwise.
+func (h *Heartbeat) pushUsageMeteringConfig(session *sessionbroker.Session) {
+	if session == nil || !session.HasScope(ipc.ScopeUsageMetering) {
+		return
+	}
+	cfg := h.usageMeteringConfig()
+	if !cfg.Enabled {
+		return
+	}
+	if err := session.SendNotify("", ipc.TypeUsageMeteringConfig, cfg); err != nil {
+		log.Warn("failed to push usage metering config", "sessionId", session.SessionID, "error", err.Error())
+	}
+}
+
+// broadcastUsageMeteringConfig tells every connected user helper about a
+// metering toggle.
+func (h *Heartbeat) broadcastUsageMeteringConfig() {
+	if h.sessionBroker == nil {
+		return
+	}
+	cfg := h.usageMeteringConfig()
+	for _, sess := range h.sessionBroker.SessionsWithScope(ipc.ScopeUsageMetering) {
+		if err := sess.SendNotify("", ipc.TypeUsageMeteringConfig, cfg); err != nil {
+			log.Warn("failed to send usage metering config", "sessionId", sess.SessionID, "error", err.Error())
+		}
+	}
+}
+
+// handleUsageReport records a helper's usage report under the session's
+// verified user, never a name from the payload.
+func (h *Heartbeat) handleUsageReport(session *sessionbroker.Session, env *ipc.Envelope) {
+	if h.usageCol == nil || !h.usageCol.Enabled() {
+		return
+	}
+	var report ipc.UsageReport
+	if err := json.Unmarshal(env.Payload, &report); err != nil {
+		log.Warn("invalid usage report payload", "sessionId", session.SessionID, "error", err.Error())
+		return
+	}
+	usage := make([]collectors.SoftwareUsage, 0, len(report.Apps))
+	for _, app := range report.Apps {
+		usage = append(usage, collectors.SoftwareUsage{
+			Date:    app.Date,
+			Name:    app.Name,
+			Path:    app.Path,
+			Seconds: app.Seconds,
+		})
+	}
+	h.usageCol.Record(session.Username, usage)
+}
+
+// sendSoftwareUsageInventory uploads the pending daily totals. Completed
+// days are dropped once the server has them; today's running total is sent
+// again until the day ends.
+func (h *Heartbeat) sendSoftwareUsageInventory() {
+	if h.usageCol == nil || !h.usageCol.Enabled() {
+		return
+	}
+	usage := h.usageCol.Collect()
+	if len(usage) == 0 {
+		return
+	}
+	if err := h.sendInventoryData("software-usage", map[string]any{"usage": usage}, fmt.Sprintf("software usage (%d)", len(usage))); err != nil {
+		return
+	}
+	h.usageCol.Acknowledge()
+}
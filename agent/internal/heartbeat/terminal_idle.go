@@ -0,0 +1,91 @@
+package heartbeat
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// terminalIdleCheckInterval is how often terminal sessions are checked
+// against remote_idle_timeout_minutes. Matches the desktop lifetime ticker.
+const terminalIdleCheckInterval = 15 * time.Second
+
+// terminalIdleLoop stops terminal sessions that have gone without viewer
+// input for longer than the configured idle timeout, warning in the terminal
+// stream shortly before. Output does not count as activity, so a forgotten
+// `tail -f` still times out.
+func (h *Heartbeat) terminalIdleLoop(stopChan <-chan struct{}) {
+	ticker := time.NewTicker(terminalIdleCheckInterval)
+	defer ticker.Stop()
+	warned := make(map[string]bool)
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			timeout := time.Duration(h.config.RemoteIdleTimeoutMinutes) * time.Minute
+			if timeout <= 0 {
+				continue
+			}
+			h.reapIdleTerminals(time.Now(), timeout, warned)
+		}
+	}
+}
+
+// reapIdleTerminals runs one idle check over the terminal manager.
+func (h *Heartbeat) reapIdleTerminals(now time.Time, timeout time.Duration, warned map[string]bool) {
+	warn, stop := terminalIdleActions(h.terminalMgr.IdleDurations(now), timeout, warned)
+	for _, id := range warn {
+		h.sendTerminalOutput(id, []byte(fmt.Sprintf(
+			"\r\n[breeze] Session idle; it will be closed in %s unless input is received.\r\n",
+			terminalIdleWarningLead(timeout))))
+	}
+	for _, id := range stop {
+		log.Info("terminal session idle timeout, stopping", "sessionId", id, "timeout", timeout)
+		h.sendTerminalOutput(id, []byte("\r\n[breeze] Session closed after being idle.\r\n"))
+		if err := h.terminalMgr.StopSession(id); err != nil {
+			log.Debug("idle terminal already stopped", "sessionId", id, "error", err.Error())
+		}
+	}
+}
+
+// terminalIdleActions decides which sessions to warn and which to stop, given
+// each session's idle duration. warned tracks sessions already warned during
+// the current idle stretch; it is updated in place so a session warns once,
+// warns again after input resumes and idles out a second time, and entries
+// for sessions that have gone away are dropped. Results are sorted for
+// stable output.
+func terminalIdleActions(idle map[string]time.Duration, timeout time.Duration, warned map[string]bool) (warn, stop []string) {
+	lead := terminalIdleWarningLead(timeout)
+	for id := range warned {
+		if _, ok := idle[id]; !ok {
+			delete(warned, id)
+		}
+	}
+	for id, d := range idle {
+		switch {
+		case d >= timeout:
+			stop = append(stop, id)
+			delete(warned, id)
+		case d >= timeout-lead:
+			if !warned[id] {
+				warn = append(warn, id)
+				warned[id] = true
+			}
+		default:
+			delete(warned, id)
+		}
+	}
+	sort.Strings(warn)
+	sort.Strings(stop)
+	return warn, stop
+}
+
+// terminalIdleWarningLead is how long before the idle stop the warning is
+// written: a minute, or a quarter of the timeout for very short limits.
+func terminalIdleWarningLead(timeout time.Duration) time.Duration {
+	if lead := timeout / 4; lead < time.Minute {
+		return lead
+	}
+	return time.Minute
+}
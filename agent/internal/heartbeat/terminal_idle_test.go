@@ -0,0 +1,49 @@
+package heartbeat
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTerminalIdleActions(t *testing.T) {
+	timeout := 10 * time.Minute
+	warned := map[string]bool{"gone": true}
+
+	warn, stop := terminalIdleActions(map[string]time.Duration{
+		"active":  time.Minute,
+		"warning": 9*time.Minute + 30*time.Second,
+		"idle":    11 * time.Minute,
+	}, timeout, warned)
+	if !reflect.DeepEqual(warn, []string{"warning"}) {
+		t.Fatalf("warn = %v, want [warning]", warn)
+	}
+	if !reflect.DeepEqual(stop, []string{"idle"}) {
+		t.Fatalf("stop = %v, want [idle]", stop)
+	}
+	if !reflect.DeepEqual(warned, map[string]bool{"warning": true}) {
+		t.Fatalf("warned = %v, want only the warned session", warned)
+	}
+
+	// Still idle: no second warning.
+	warn, _ = terminalIdleActions(map[string]time.Duration{"warning": 9*time.Minute + 45*time.Second}, timeout, warned)
+	if len(warn) != 0 {
+		t.Fatalf("expected no repeat warning, got %v", warn)
+	}
+
+	// Input resumed, then idle again: warns again.
+	terminalIdleActions(map[string]time.Duration{"warning": time.Second}, timeout, warned)
+	warn, _ = terminalIdleActions(map[string]time.Duration{"warning": 9*time.Minute + 30*time.Second}, timeout, warned)
+	if !reflect.DeepEqual(warn, []string{"warning"}) {
+		t.Fatalf("expected a fresh warning after activity, got %v", warn)
+	}
+}
+
+func TestTerminalIdleWarningLead(t *testing.T) {
+	if got := terminalIdleWarningLead(time.Hour); got != time.Minute {
+		t.Fatalf("lead(1h) = %v, want 1m", got)
+	}
+	if got := terminalIdleWarningLead(2 * time.Minute); got != 30*time.Second {
+		t.Fatalf("lead(2m) = %v, want 30s", got)
+	}
+}
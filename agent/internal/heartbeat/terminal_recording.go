@@ -0,0 +1,189 @@
+package heartbeat
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/audit"
+	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/httputil"
+	"github.com/breeze-rmm/agent/internal/terminal"
+)
+
+// Terminal sessions are recorded as asciinema cast files (terminal package)
+// when terminal_recording is on. At session end the recording's SHA-256 goes
+// into the audit log and the file is uploaded; it is deleted once the API has
+// it. Uploads that fail stay in the spool directory and are retried at the
+// next agent start, within the spool's age and size caps.
+
+const (
+	recordingUploadTimeout = 5 * time.Minute
+
+	// Recordings that could not be uploaded are kept for at most
+	// recordingMaxAge, and the spool for at most recordingSpoolMaxBytes;
+	// past either the oldest are deleted first.
+	recordingMaxAge = 7 * 24 * time.Hour
+)
+
+// recordingSpoolMaxBytes is a var so tests can shrink it.
+var recordingSpoolMaxBytes int64 = 512 << 20
+
+func terminalRecordingDir() string {
+	return filepath.Join(config.GetDataDir(), "recordings")
+}
+
+// handleTerminalRecording is the terminalMgr.OnRecordingClosed hook.
+func (h *Heartbeat) handleTerminalRecording(info terminal.RecordingInfo) {
+	if h.auditLog != nil {
+		h.auditLog.Log(audit.EventRemoteSessionRecording, "", recordingAuditDetails(info))
+	}
+	go func() {
+		if err := h.uploadTerminalRecording(info.SessionID, info.Path, info.SHA256); err != nil {
+			log.Warn("terminal recording upload failed, kept for retry", "sessionId", info.SessionID, "path", info.Path, "error", err.Error())
+			pruneTerminalRecordings(filepath.Dir(info.Path), time.Now())
+		}
+	}()
+}
+
+func recordingAuditDetails(info terminal.RecordingInfo) map[string]any {
+	details := map[string]any{
+		"sessionId":       info.SessionID,
+		"format":          "asciicast-v2",
+		"file":            filepath.Base(info.Path),
+		"recordingSha256": info.SHA256,
+		"sizeBytes":       info.SizeBytes,
+		"startedAt":       info.StartedAt.UTC().Format(time.RFC3339),
+		"durationSeconds": int(info.Duration.Seconds()),
+	}
+	if info.Truncated {
+		details["truncated"] = true
+	}
+	if info.User != "" {
+		details["user"] = info.User
+	}
+	return details
+}
+
+// uploadTerminalRecording POSTs a cast file to the API and deletes it once
+// accepted. sha256 is computed from the file when empty.
+func (h *Heartbeat) uploadTerminalRecording(sessionID, path, sha string) error {
+	if h.config == nil || h.serverURL() == "" {
+		return fmt.Errorf("no server configured")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read recording: %w", err)
+	}
+	if sha == "" {
+		sum := sha256.Sum256(data)
+		sha = hex.EncodeToString(sum[:])
+	}
+
+	url := fmt.Sprintf("%s/api/v1/agents/%s/terminal-sessions/%s/recording", h.serverURL(), h.config.AgentID, sessionID)
+	headers := http.Header{
+		"Content-Type":       {"application/x-asciicast"},
+		"Authorization":      {h.authHeader()},
+		"X-Recording-Sha256": {sha},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), recordingUploadTimeout)
+	defer cancel()
+
+	resp, err := httputil.Do(ctx, h.httpClient(), http.MethodPost, url, data, headers, h.retryCfg)
+	if err != nil {
+		return fmt.Errorf("upload recording: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("upload recording: status %d", resp.StatusCode)
+	}
+	if err := os.Remove(path); err != nil {
+		log.Warn("failed to remove uploaded terminal recording", "path", path, "error", err.Error())
+	}
+	log.Info("terminal recording uploaded", "sessionId", sessionID, "sizeBytes", len(data))
+	return nil
+}
+
+// uploadPendingTerminalRecordings retries recordings left behind by failed
+// uploads or an agent restart mid-session.
+func (h *Heartbeat) uploadPendingTerminalRecordings() {
+	pruneTerminalRecordings(h.terminalMgr.RecordDir, time.Now())
+	paths, err := filepath.Glob(filepath.Join(h.terminalMgr.RecordDir, "*.cast"))
+	if err != nil || len(paths) == 0 {
+		return
+	}
+	for _, path := range paths {
+		sessionID, ok := recordingSessionID(filepath.Base(path))
+		if !ok {
+			continue
+		}
+		if err := h.uploadTerminalRecording(sessionID, path, ""); err != nil {
+			log.Warn("pending terminal recording upload failed", "path", path, "error", err.Error())
+		}
+	}
+}
+
+// pruneTerminalRecordings enforces the spool caps: it deletes recordings
+// older than recordingMaxAge, then the oldest until the rest fit in
+// recordingSpoolMaxBytes.
+func pruneTerminalRecordings(dir string, now time.Time) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.cast"))
+	if err != nil || len(paths) == 0 {
+		return
+	}
+	type spooled struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	files := make([]spooled, 0, len(paths))
+	for _, path := range paths {
+		st, err := os.Stat(path)
+		if err != nil || !st.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, spooled{path, st.Size(), st.ModTime()})
+	}
+	// Newest first, so the size budget goes to the most recent sessions.
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+
+	var kept int64
+	for _, f := range files {
+		reason := ""
+		switch {
+		case now.Sub(f.modTime) > recordingMaxAge:
+			reason = "age"
+		case kept+f.size > recordingSpoolMaxBytes:
+			reason = "spool size"
+		default:
+			kept += f.size
+			continue
+		}
+		if err := os.Remove(f.path); err != nil {
+			log.Warn("failed to prune terminal recording", "path", f.path, "error", err.Error())
+			continue
+		}
+		log.Warn("pruned un-uploaded terminal recording", "path", f.path, "reason", reason)
+	}
+}
+
+// recordingSessionID extracts the session ID from a "<sessionId>-<unix>.cast"
+// file name.
+func recordingSessionID(name string) (string, bool) {
+	base, ok := strings.CutSuffix(name, ".cast")
+	if !ok {
+		return "", false
+	}
+	i := strings.LastIndexByte(base, '-')
+	if i <= 0 {
+		return "", false
+	}
+	return base[:i], true
+}
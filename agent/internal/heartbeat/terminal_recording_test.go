@@ -0,0 +1,116 @@
+package heartbeat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/config"
+)
+
+func TestUploadTerminalRecording(t *testing.T) {
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/agents/agent-1/terminal-sessions/term-1/recording" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("X-Recording-Sha256") == "" || r.Header.Get("Content-Type") != "application/x-asciicast" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	h := NewWithVersion(&config.Config{
+		AgentID:   "agent-1",
+		ServerURL: ts.URL,
+		AuthToken: "token",
+	}, "test", nil, nil)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "term-1-1700000000.cast")
+	if err := os.WriteFile(path, []byte("{\"version\":2}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.uploadTerminalRecording("term-1", path, ""); err != nil {
+		t.Fatalf("uploadTerminalRecording: %v", err)
+	}
+	if body != "{\"version\":2}\n" {
+		t.Fatalf("uploaded body = %q", body)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("uploaded recording should be removed from the spool")
+	}
+
+	kept := filepath.Join(dir, "term-2-1700000000.cast")
+	if err := os.WriteFile(kept, []byte("{}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.uploadTerminalRecording("term-2", kept, ""); err == nil {
+		t.Fatal("expected an error for a rejected upload")
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Fatal("a failed upload must keep the recording for retry")
+	}
+}
+
+func TestRecordingSessionID(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"term-1-1700000000.cast", "term-1", true},
+		{"abc-1700000000.cast", "abc", true},
+		{"abc.cast", "", false},
+		{"abc-1700000000.txt", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := recordingSessionID(tt.name)
+		if got != tt.want || ok != tt.ok {
+			t.Fatalf("recordingSessionID(%q) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPruneTerminalRecordings(t *testing.T) {
+	prev := recordingSpoolMaxBytes
+	recordingSpoolMaxBytes = 1000
+	t.Cleanup(func() { recordingSpoolMaxBytes = prev })
+
+	dir := t.TempDir()
+	now := time.Now()
+	write := func(name string, size int64, age time.Duration) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+		mtime := now.Add(-age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	stale := write("old-1.cast", 10, recordingMaxAge+time.Hour)
+	newest := write("new-1.cast", recordingSpoolMaxBytes/2, time.Minute)
+	middle := write("mid-1.cast", recordingSpoolMaxBytes/2, time.Hour)
+	oldest := write("big-1.cast", 10, 2*time.Hour)
+	other := write("notes.txt", 10, recordingMaxAge+time.Hour)
+
+	pruneTerminalRecordings(dir, now)
+
+	for path, want := range map[string]bool{stale: false, newest: true, middle: true, oldest: false, other: true} {
+		_, err := os.Stat(path)
+		if got := err == nil; got != want {
+			t.Errorf("%s kept = %v, want %v", filepath.Base(path), got, want)
+		}
+	}
+}
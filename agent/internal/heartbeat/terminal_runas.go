@@ -0,0 +1,58 @@
+package heartbeat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/breeze-rmm/agent/internal/remote/tools"
+	"github.com/breeze-rmm/agent/internal/terminal"
+)
+
+// Terminal run modes selected by the "mode" field of terminal_start.
+const (
+	terminalModeSystem    = "system"     // the agent's own account (SYSTEM/root)
+	terminalModeUser      = "user"       // the logged-in user, via the session broker
+	terminalModeNamedUser = "named_user" // a named local account with its credentials
+)
+
+// terminalRunMode returns the normalized run mode of a terminal_start
+// payload. An absent mode keeps the historical SYSTEM/root behavior.
+func terminalRunMode(payload map[string]any) (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(tools.GetPayloadString(payload, "mode", "")))
+	switch mode {
+	case "":
+		return terminalModeSystem, nil
+	case terminalModeSystem, terminalModeUser, terminalModeNamedUser:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unsupported terminal mode %q", mode)
+}
+
+// terminalCredential resolves the account a terminal_start runs its shell
+// as; nil means the agent's own account. Never falls back to SYSTEM/root
+// when a user context was requested.
+func (h *Heartbeat) terminalCredential(payload map[string]any) (*terminal.Credential, error) {
+	mode, err := terminalRunMode(payload)
+	if err != nil {
+		return nil, err
+	}
+	switch mode {
+	case terminalModeUser:
+		if h.sessionBroker == nil {
+			return nil, errors.New("no session broker for user terminal")
+		}
+		session := h.sessionBroker.PreferredRunAsUserSession()
+		if session == nil {
+			return nil, errors.New("no logged-in user session for user terminal")
+		}
+		return loggedInUserCredential(session)
+	case terminalModeNamedUser:
+		username := strings.TrimSpace(tools.GetPayloadString(payload, "username", ""))
+		if username == "" {
+			return nil, errors.New("username is required for named_user terminal")
+		}
+		return namedUserCredential(username, tools.GetPayloadString(payload, "password", ""))
+	}
+	return nil, nil
+}
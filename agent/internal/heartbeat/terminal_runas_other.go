@@ -0,0 +1,30 @@
+//go:build !windows
+
+package heartbeat
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/breeze-rmm/agent/internal/sessionbroker"
+	"github.com/breeze-rmm/agent/internal/terminal"
+)
+
+func loggedInUserCredential(session *sessionbroker.Session) (*terminal.Credential, error) {
+	uid := session.IdentityKey
+	if uid == "" {
+		uid = strconv.FormatUint(uint64(session.UID), 10)
+	}
+	return terminal.LookupUserCredentialByID(uid)
+}
+
+// namedUserCredential switches to the account directly. This is an
+// unauthenticated setuid by the root agent (as with runAs scripts via
+// sudo -n), so a password is never checked here; one is rejected rather than
+// accepted and ignored, so callers can't mistake it for verified logon.
+func namedUserCredential(username, password string) (*terminal.Credential, error) {
+	if password != "" {
+		return nil, errors.New("named_user terminal takes no password on this platform: the agent switches to the account as root without authenticating")
+	}
+	return terminal.LookupUserCredential(username)
+}
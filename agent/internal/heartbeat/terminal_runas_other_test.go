@@ -0,0 +1,20 @@
+//go:build !windows
+
+package heartbeat
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNamedUserCredentialRejectsPassword(t *testing.T) {
+	h := &Heartbeat{}
+	_, err := h.terminalCredential(map[string]any{"mode": "named_user", "username": "root", "password": "x"})
+	if err == nil || !strings.Contains(err.Error(), "no password") {
+		t.Fatalf("named_user with a password: err = %v; want a rejection", err)
+	}
+	c, err := h.terminalCredential(map[string]any{"mode": "named_user", "username": "root"})
+	if err != nil || c == nil {
+		t.Fatalf("named_user without a password = %v, %v; want a credential", c, err)
+	}
+}
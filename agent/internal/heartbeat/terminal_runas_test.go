@@ -0,0 +1,32 @@
+package heartbeat
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTerminalRunMode(t *testing.T) {
+	for in, want := range map[string]string{"": terminalModeSystem, "SYSTEM": terminalModeSystem, " user ": terminalModeUser, "named_user": terminalModeNamedUser} {
+		got, err := terminalRunMode(map[string]any{"mode": in})
+		if err != nil || got != want {
+			t.Fatalf("terminalRunMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := terminalRunMode(map[string]any{"mode": "root"}); err == nil {
+		t.Fatal("unknown mode should be rejected")
+	}
+}
+
+func TestTerminalCredentialNeverFallsBackToSystem(t *testing.T) {
+	h := &Heartbeat{}
+	if c, err := h.terminalCredential(map[string]any{}); err != nil || c != nil {
+		t.Fatalf("system mode = %v, %v; want nil credential", c, err)
+	}
+	if c, err := h.terminalCredential(map[string]any{"mode": "user"}); err == nil || c != nil {
+		t.Fatalf("user mode without a broker = %v, %v; want error", c, err)
+	}
+	_, err := h.terminalCredential(map[string]any{"mode": "named_user", "password": "x"})
+	if err == nil || !strings.Contains(err.Error(), "username") {
+		t.Fatalf("named_user without username: err = %v", err)
+	}
+}
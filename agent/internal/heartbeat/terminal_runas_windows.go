@@ -0,0 +1,32 @@
+//go:build windows
+
+package heartbeat
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/breeze-rmm/agent/internal/sessionbroker"
+	"github.com/breeze-rmm/agent/internal/terminal"
+)
+
+func loggedInUserCredential(session *sessionbroker.Session) (*terminal.Credential, error) {
+	sid, err := strconv.ParseUint(session.WinSessionID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Windows session id %q", session.WinSessionID)
+	}
+	token, err := sessionbroker.UserTokenForSession(uint32(sid))
+	if err != nil {
+		return nil, err
+	}
+	return terminal.NewTokenCredential(token, session.Username)
+}
+
+// namedUserCredential logs the account on; Windows needs its password.
+func namedUserCredential(username, password string) (*terminal.Credential, error) {
+	if password == "" {
+		return nil, errors.New("password is required for named_user terminal")
+	}
+	return terminal.LogonUserCredential(username, password)
+}
@@ -0,0 +1,226 @@
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/httputil"
+	"github.com/breeze-rmm/agent/internal/ipc"
+	"github.com/breeze-rmm/agent/internal/remote/desktop"
+)
+
+const (
+	// turnFetchStartTimeout bounds the credential fetch on the session start
+	// path; past it the session starts with the servers from the payload.
+	turnFetchStartTimeout = 5 * time.Second
+	turnFetchTimeout      = 30 * time.Second
+
+	// Credentials are refreshed a fifth of their lifetime before expiry,
+	// clamped to [turnRefreshMinLead, turnRefreshMaxLead]. The API issues
+	// credentials valid for 1-15 minutes.
+	turnRefreshMinLead = 30 * time.Second
+	turnRefreshMaxLead = 2 * time.Minute
+	// turnRefreshRetry is the delay after a failed refresh.
+	turnRefreshRetry = 30 * time.Second
+)
+
+// turnCredentialsResponse is the body of the agent ICE servers endpoint.
+// ExpiresAt is the Unix time (seconds) the TURN credentials stop working.
+type turnCredentialsResponse struct {
+	ICEServers []desktop.ICEServerConfig `json:"iceServers"`
+	ExpiresAt  int64                     `json:"expiresAt"`
+}
+
+// errTURNSessionGone means the API no longer issues credentials for the
+// session (it ended or was never a desktop session).
+var errTURNSessionGone = errors.New("desktop session not found")
+
+// turnRefreshers holds the stop channel of each desktop session's refresh
+// loop, keyed by desktop session ID.
+var (
+	turnRefreshersMu sync.Mutex
+	turnRefreshers   = map[string]chan struct{}{}
+)
+
+// fetchDesktopICEServers asks the API for fresh ICE servers, including
+// short-lived TURN credentials, for a desktop session. The returned time is
+// when the credentials expire (zero when unknown).
+func (h *Heartbeat) fetchDesktopICEServers(ctx context.Context, sessionID string) ([]desktop.ICEServerConfig, time.Time, error) {
+	if h.config == nil || h.serverURL() == "" {
+		return nil, time.Time{}, errors.New("no server configured")
+	}
+	url := fmt.Sprintf("%s/api/v1/agents/%s/desktop-sessions/%s/ice-servers", h.serverURL(), h.config.AgentID, sessionID)
+	headers := http.Header{
+		"Authorization": {h.authHeader()},
+	}
+	resp, err := httputil.Do(ctx, h.httpClient(), http.MethodGet, url, nil, headers, h.retryCfg)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("fetch ICE servers: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusGone, http.StatusConflict:
+		return nil, time.Time{}, fmt.Errorf("ice-servers returned status %d: %w", resp.StatusCode, errTURNSessionGone)
+	}
+	if resp.StatusCode != http.StatusOK {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, time.Time{}, fmt.Errorf("ice-servers returned status %d: %s", resp.StatusCode, string(errBody))
+	}
+	var decoded turnCredentialsResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&decoded); err != nil {
+		return nil, time.Time{}, fmt.Errorf("decode ICE servers: %w", err)
+	}
+	if len(decoded.ICEServers) == 0 {
+		return nil, time.Time{}, errors.New("ice-servers returned no servers")
+	}
+	expiresAt := turnCredentialExpiry(decoded.ICEServers)
+	if decoded.ExpiresAt > 0 {
+		expiresAt = time.Unix(decoded.ExpiresAt, 0)
+	}
+	return decoded.ICEServers, expiresAt, nil
+}
+
+// desktopICEServers picks the ICE servers a new desktop session starts with:
+// freshly fetched ones when the API answers in time, otherwise those from
+// the command payload. Also returns when their TURN credentials expire.
+func (h *Heartbeat) desktopICEServers(sessionID string, fromPayload []desktop.ICEServerConfig) ([]desktop.ICEServerConfig, time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), turnFetchStartTimeout)
+	defer cancel()
+	servers, expiresAt, err := h.fetchDesktopICEServers(ctx, sessionID)
+	if err != nil {
+		log.Warn("using ICE servers from the start payload", "sessionId", sessionID, "error", err.Error())
+		return fromPayload, turnCredentialExpiry(fromPayload)
+	}
+	return servers, expiresAt
+}
+
+// turnCredentialExpiry returns the earliest expiry encoded in TURN REST API
+// usernames ("<unix expiry>:<user>"), or zero when no server carries one.
+func turnCredentialExpiry(servers []desktop.ICEServerConfig) time.Time {
+	var earliest time.Time
+	for _, s := range servers {
+		prefix, _, found := strings.Cut(s.Username, ":")
+		if !found {
+			continue
+		}
+		unix, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil || unix <= 0 {
+			continue
+		}
+		if t := time.Unix(unix, 0); earliest.IsZero() || t.Before(earliest) {
+			earliest = t
+		}
+	}
+	return earliest
+}
+
+// turnRefreshDelay is how long to wait before refreshing credentials that
+// expire at expiresAt and were issued at now.
+func turnRefreshDelay(now, expiresAt time.Time) time.Duration {
+	remaining := expiresAt.Sub(now)
+	lead := min(max(remaining/5, turnRefreshMinLead), turnRefreshMaxLead)
+	return max(remaining-lead, 0)
+}
+
+// startTURNRefresh keeps a desktop session's TURN credentials fresh until the
+// session ends. No-op when the credentials carry no expiry (plain STUN, or
+// long-lived static credentials).
+func (h *Heartbeat) startTURNRefresh(sessionID string, expiresAt time.Time) {
+	if expiresAt.IsZero() {
+		return
+	}
+	stop := make(chan struct{})
+	turnRefreshersMu.Lock()
+	if old := turnRefreshers[sessionID]; old != nil {
+		close(old)
+	}
+	turnRefreshers[sessionID] = stop
+	turnRefreshersMu.Unlock()
+	go h.turnRefreshLoop(sessionID, expiresAt, stop)
+}
+
+// stopTURNRefresh ends a session's refresh loop, if any.
+func stopTURNRefresh(sessionID string) {
+	turnRefreshersMu.Lock()
+	defer turnRefreshersMu.Unlock()
+	if stop := turnRefreshers[sessionID]; stop != nil {
+		close(stop)
+		delete(turnRefreshers, sessionID)
+	}
+}
+
+func (h *Heartbeat) turnRefreshLoop(sessionID string, expiresAt time.Time, stop chan struct{}) {
+	defer func() {
+		turnRefreshersMu.Lock()
+		if turnRefreshers[sessionID] == stop {
+			delete(turnRefreshers, sessionID)
+		}
+		turnRefreshersMu.Unlock()
+	}()
+
+	timer := time.NewTimer(turnRefreshDelay(time.Now(), expiresAt))
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), turnFetchTimeout)
+		servers, next, err := h.fetchDesktopICEServers(ctx, sessionID)
+		cancel()
+		if errors.Is(err, errTURNSessionGone) {
+			return
+		}
+		if err != nil {
+			log.Warn("TURN credential refresh failed", "sessionId", sessionID, "error", err.Error())
+			timer.Reset(turnRefreshRetry)
+			continue
+		}
+		if alive := h.applyDesktopICEServers(sessionID, servers); !alive {
+			return
+		}
+		if next.IsZero() {
+			return
+		}
+		log.Info("TURN credentials refreshed", "sessionId", sessionID, "expiresAt", next.UTC().Format(time.RFC3339))
+		timer.Reset(turnRefreshDelay(time.Now(), next))
+	}
+}
+
+// applyDesktopICEServers hands refreshed servers to whoever runs the session:
+// the owning helper over IPC, or the service's own session manager. Returns
+// false once the session is gone.
+func (h *Heartbeat) applyDesktopICEServers(sessionID string, servers []desktop.ICEServerConfig) bool {
+	if owner := h.desktopOwnerSession(sessionID); owner != nil {
+		data, err := json.Marshal(servers)
+		if err != nil {
+			log.Warn("failed to marshal ICE servers", "sessionId", sessionID, "error", err.Error())
+			return true
+		}
+		update := ipc.DesktopICEServersUpdate{SessionID: sessionID, ICEServers: data}
+		if err := owner.SendNotify("desk-ice-"+sessionID, ipc.TypeDesktopICE, update); err != nil {
+			log.Warn("failed to send ICE servers to helper", "sessionId", sessionID, "error", err.Error())
+		}
+		return true
+	}
+	if h.desktopMgr == nil {
+		return false
+	}
+	if err := h.desktopMgr.UpdateICEServers(sessionID, servers); err != nil {
+		if errors.Is(err, desktop.ErrNoActiveSession) {
+			return false
+		}
+		log.Warn("failed to update ICE servers", "sessionId", sessionID, "error", err.Error())
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/config"
+	"github.com/breeze-rmm/agent/internal/remote/desktop"
+)
+
+func TestFetchDesktopICEServers(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/agents/agent-1/desktop-sessions/desk-1/ice-servers" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"iceServers":[{"urls":"stun:stun.example.com:3478"},` +
+			`{"urls":["turn:turn.example.com:3478?transport=udp"],"username":"1700000600:breeze:x","credential":"c"}],` +
+			`"expiresAt":1700000500}`))
+	}))
+	defer ts.Close()
+
+	h := NewWithVersion(&config.Config{
+		AgentID:   "agent-1",
+		ServerURL: ts.URL,
+		AuthToken: "token",
+	}, "test", nil, nil)
+
+	servers, expiresAt, err := h.fetchDesktopICEServers(context.Background(), "desk-1")
+	if err != nil {
+		t.Fatalf("fetchDesktopICEServers returned error: %v", err)
+	}
+	if len(servers) != 2 || servers[1].Credential != "c" {
+		t.Fatalf("servers = %+v", servers)
+	}
+	if !expiresAt.Equal(time.Unix(1700000500, 0)) {
+		t.Fatalf("expiresAt = %v, want the explicit expiresAt", expiresAt)
+	}
+
+	if _, _, err := h.fetchDesktopICEServers(context.Background(), "desk-2"); !errors.Is(err, errTURNSessionGone) {
+		t.Fatalf("unknown session error = %v, want errTURNSessionGone", err)
+	}
+}
+
+func TestDesktopICEServersFallsBackToPayload(t *testing.T) {
+	h := &Heartbeat{}
+	payload := []desktop.ICEServerConfig{{URLs: "turn:turn.example.com", Username: "1700000600:breeze", Credential: "c"}}
+	servers, expiresAt := h.desktopICEServers("desk-1", payload)
+	if len(servers) != 1 || servers[0].Credential != "c" {
+		t.Fatalf("servers = %+v, want the payload servers", servers)
+	}
+	if !expiresAt.Equal(time.Unix(1700000600, 0)) {
+		t.Fatalf("expiresAt = %v, want the expiry from the payload username", expiresAt)
+	}
+}
+
+func TestTURNCredentialExpiry(t *testing.T) {
+	servers := []desktop.ICEServerConfig{
+		{URLs: "stun:stun.example.com"},
+		{URLs: "turn:a.example.com", Username: "1700000900:breeze:a"},
+		{URLs: "turn:b.example.com", Username: "1700000300:breeze:b"},
+		{URLs: "turn:c.example.com", Username: "static-user"},
+	}
+	if got := turnCredentialExpiry(servers); !got.Equal(time.Unix(1700000300, 0)) {
+		t.Fatalf("turnCredentialExpiry = %v, want the earliest expiry", got)
+	}
+	if got := turnCredentialExpiry(servers[3:]); !got.IsZero() {
+		t.Fatalf("static credentials should have no expiry, got %v", got)
+	}
+}
+
+func TestTURNRefreshDelay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{10 * time.Minute, 8 * time.Minute},  // lead = ttl/5
+		{15 * time.Minute, 13 * time.Minute}, // lead capped at 2m
+		{time.Minute, 30 * time.Second},      // lead floored at 30s
+		{10 * time.Second, 0},                // already inside the lead
+		{-time.Minute, 0},                    // already expired
+	}
+	for _, tt := range tests {
+		if got := turnRefreshDelay(now, now.Add(tt.ttl)); got != tt.want {
+			t.Fatalf("turnRefreshDelay(ttl=%v) = %v, want %v", tt.ttl, got, tt.want)
+		}
+	}
+}
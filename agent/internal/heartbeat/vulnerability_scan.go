@@ -0,0 +1,115 @@
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/httputil"
+	"github.com/breeze-rmm/agent/internal/vulnscan"
+)
+
+// vulnSnapshotMaxBytes bounds the snapshot download. The API scopes the
+// snapshot to software the device has reported, so real snapshots are far
+// smaller.
+const vulnSnapshotMaxBytes = 32 << 20
+
+// vulnScanState keeps the last snapshot and its ETag, so an unchanged
+// snapshot is not downloaded again on every inventory cycle, and whether
+// the last report was empty, so a device with scanning off reports that
+// once rather than every cycle.
+var vulnScanState struct {
+	sync.Mutex
+	etag          string
+	snapshot      *vulnscan.Snapshot
+	reportedEmpty bool
+}
+
+// fetchVulnerabilitySnapshot downloads the device's vulnerability snapshot,
+// or returns the cached one when the API answers 304 Not Modified.
+func (h *Heartbeat) fetchVulnerabilitySnapshot(ctx context.Context) (*vulnscan.Snapshot, error) {
+	url := fmt.Sprintf("%s/api/v1/agents/%s/vulnerability-snapshot", h.serverURL(), h.config.AgentID)
+	headers := http.Header{
+		"Authorization": {h.authHeader()},
+	}
+	vulnScanState.Lock()
+	cached, etag := vulnScanState.snapshot, vulnScanState.etag
+	vulnScanState.Unlock()
+	if cached != nil && etag != "" {
+		headers.Set("If-None-Match", etag)
+	}
+
+	resp, err := httputil.Do(ctx, h.httpClient(), http.MethodGet, url, nil, headers, h.retryCfg)
+	if err != nil {
+		return nil, fmt.Errorf("fetch vulnerability snapshot: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return cached, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("vulnerability-snapshot returned status %d: %s", resp.StatusCode, string(errBody))
+	}
+	var snapshot vulnscan.Snapshot
+	if err := json.NewDecoder(io.LimitReader(resp.Body, vulnSnapshotMaxBytes)).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("decode vulnerability snapshot: %w", err)
+	}
+
+	vulnScanState.Lock()
+	vulnScanState.snapshot = &snapshot
+	vulnScanState.etag = resp.Header.Get("ETag")
+	vulnScanState.Unlock()
+	return &snapshot, nil
+}
+
+// sendVulnerabilityScan matches the software inventory against the
+// server's vulnerability snapshot and reports the vulnerable
+// package/version/CVE tuples.
+func (h *Heartbeat) sendVulnerabilityScan() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	snapshot, err := h.fetchVulnerabilitySnapshot(ctx)
+	if err != nil {
+		log.Warn("skipping vulnerability scan", "error", err.Error())
+		return
+	}
+
+	vulnScanState.Lock()
+	reportedEmpty := vulnScanState.reportedEmpty
+	vulnScanState.Unlock()
+	if len(snapshot.Entries) == 0 && reportedEmpty {
+		return
+	}
+
+	var scanned int
+	findings := []vulnscan.Finding{}
+	if len(snapshot.Entries) > 0 {
+		software, err := h.softwareCol.Collect()
+		if err != nil {
+			log.Error("failed to collect software for vulnerability scan", "error", err.Error())
+			return
+		}
+		scanned = len(software)
+		if matched := vulnscan.Scan(snapshot, software); matched != nil {
+			findings = matched
+		}
+	}
+
+	payload := map[string]any{
+		"scannedAt":           time.Now().UTC().Format(time.RFC3339),
+		"snapshotGeneratedAt": snapshot.GeneratedAt,
+		"softwareScanned":     scanned,
+		"findings":            findings,
+	}
+	if err := h.sendInventoryData("vulnerabilities", payload, fmt.Sprintf("vulnerabilities (%d findings)", len(findings))); err != nil {
+		return
+	}
+	vulnScanState.Lock()
+	vulnScanState.reportedEmpty = len(snapshot.Entries) == 0
+	vulnScanState.Unlock()
+}
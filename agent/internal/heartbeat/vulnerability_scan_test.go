@@ -0,0 +1,54 @@
+package heartbeat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/breeze-rmm/agent/internal/config"
+)
+
+func TestFetchVulnerabilitySnapshotUsesETag(t *testing.T) {
+	var requests, notModified int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/agents/agent-1/vulnerability-snapshot" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"enabled":true,"generatedAt":"2026-10-18T13:00:00Z","entries":[{"name":"7-zip","cveId":"CVE-2024-11477","endExcluding":"24.07"}]}`))
+	}))
+	defer ts.Close()
+
+	vulnScanState.Lock()
+	vulnScanState.snapshot, vulnScanState.etag = nil, ""
+	vulnScanState.Unlock()
+
+	h := NewWithVersion(&config.Config{
+		AgentID:   "agent-1",
+		ServerURL: ts.URL,
+		AuthToken: "token",
+	}, "test", nil, nil)
+
+	first, err := h.fetchVulnerabilitySnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("fetchVulnerabilitySnapshot returned error: %v", err)
+	}
+	if !first.Enabled || len(first.Entries) != 1 || first.Entries[0].EndExcluding != "24.07" {
+		t.Fatalf("unexpected snapshot %+v", first)
+	}
+	second, err := h.fetchVulnerabilitySnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("second fetch returned error: %v", err)
+	}
+	if requests != 2 || notModified != 1 || second != first {
+		t.Fatalf("second fetch should revalidate and reuse the cached snapshot (requests=%d, 304s=%d)", requests, notModified)
+	}
+}
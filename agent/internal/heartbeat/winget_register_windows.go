@@ -9,7 +9,8 @@ import "github.com/breeze-rmm/agent/internal/patching"
 // SystemWingetProvider that runs winget directly from this SYSTEM agent
 // process at machine scope — no logged-in user or user-helper IPC required.
 func (h *Heartbeat) registerSystemWinget() {
-	res := patching.EnsureWinget(patching.NewEnsureDeps(h.config))
+	deps := patching.NewEnsureDeps(h.config)
+	res := patching.EnsureWinget(deps)
 	if patching.RegisterSystemWinget(h.patchMgr, res, patching.DefaultRunner) {
 		if res.Reason != "" {
 			// Registered, but on a degraded fallback: an older winget is in use
@@ -22,6 +23,13 @@ func (h *Heartbeat) registerSystemWinget() {
 			log.Info("winget provider registered (SYSTEM, machine scope)", "version", res.Version)
 		}
 	} else {
-		log.Info("winget provider not registered", "reason", res.Reason)
+		// Headless and freshly imaged machines often have no resolvable winget
+		// when the service starts. Register the provider anyway and let it
+		// resolve winget on a later scan instead of skipping winget patching
+		// until the next agent restart.
+		patching.RegisterDeferredSystemWinget(h.patchMgr, func() patching.EnsureResult {
+			return patching.EnsureWinget(deps)
+		}, res.Reason, patching.DefaultRunner)
+		log.Info("winget provider deferred; winget unavailable at startup", "reason", res.Reason)
 	}
 }
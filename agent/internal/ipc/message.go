@@ -33,6 +33,9 @@ const (
 	TypeDesktopFrame  = "desktop_frame"
 	TypeDesktopInput  = "desktop_input"
 	TypeDesktopStop   = "desktop_stop"
+	TypeDesktopJoin   = "desktop_join"
+	TypeDesktopLeave  = "desktop_leave"
+	TypeDesktopICE    = "desktop_ice_servers"
 	TypeClipboardGet  = "clipboard_get"
 	TypeClipboardData = "clipboard_data"
 	TypeClipboardSet  = "clipboard_set"
@@ -44,6 +47,9 @@ const (
 	// Desktop peer disconnected — helper notifies service when WebRTC drops
 	TypeDesktopPeerDisconnected = "desktop_peer_disconnected"
 
+	// Desktop stream quality — helper reports one sample per metrics interval
+	TypeDesktopQuality = "desktop_quality"
+
 	// Console user changed — agent notifies helpers to switch input mode
 	TypeConsoleUserChanged = "console_user_changed"
 
@@ -54,6 +60,11 @@ const (
 	// TCC (Transparency, Consent, Control) permission status from macOS helpers
 	TypeTCCStatus = "tcc_status"
 
+	// Software usage metering — the agent turns foreground-app sampling on or
+	// off in user helpers; helpers report the accumulated usage back.
+	TypeUsageMeteringConfig = "usage_metering_config"
+	TypeUsageReport         = "usage_report"
+
 	// Watchdog
 	TypeWatchdogPing          = "watchdog_ping"
 	TypeWatchdogPong          = "watchdog_pong"
@@ -81,6 +92,9 @@ const (
 	TypeConsentResult  = "consent_result"
 	TypeBannerShow     = "banner_show"
 	TypeBannerHide     = "banner_hide"
+
+	// In-session chat between the technician and the end user
+	TypeChatMessage = "chat_message"
 )
 
 // PreAuthReject codes identify why the broker rejected a connection.
@@ -148,6 +162,10 @@ const (
 	// (ScopeConsentUI) is connected. Granted only on explicit advertisement so
 	// older helpers keep helper_absent semantics instead of timing out.
 	ScopeConsentUIFallback = "consent_ui_fallback"
+
+	// ScopeUsageMetering lets a user-role helper sample the foreground
+	// application for software usage metering and report it to the agent.
+	ScopeUsageMetering = "usage_metering"
 )
 
 const (
@@ -237,6 +255,9 @@ type NotifyRequest struct {
 	Icon    string   `json:"icon,omitempty"`
 	Urgency string   `json:"urgency,omitempty"`
 	Actions []string `json:"actions,omitempty"`
+	// TimeoutSeconds bounds how long the helper waits for the user to pick
+	// one of Actions. Ignored for notifications without actions.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 }
 
 // NotifyResult is the user helper's response after showing a notification.
@@ -313,9 +334,23 @@ type DesktopStartRequest struct {
 	ClipboardViewerToHost   *bool `json:"clipboardViewerToHost,omitempty"`
 	IdleTimeoutMinutes      int   `json:"idleTimeoutMinutes,omitempty"`
 	MaxSessionDurationHours int   `json:"maxSessionDurationHours,omitempty"`
+	// ViewOnly tells the helper not to create an input handler for the
+	// session. Absent (older service) means full control.
+	ViewOnly bool `json:"viewOnly,omitempty"`
+	// ExtraDisplays are display indexes to stream alongside DisplayIndex,
+	// each on its own video track.
+	ExtraDisplays []int `json:"extraDisplays,omitempty"`
+	// MaxBitrateKbps is the session's hard bandwidth cap (0 = none) and
+	// AudioDisabled keeps the viewer from unmuting audio.
+	MaxBitrateKbps int  `json:"maxBitrateKbps,omitempty"`
+	AudioDisabled  bool `json:"audioDisabled,omitempty"`
 	// Prompt carries the consent/notification configuration for the session.
 	// Nil means no prompt or banner is requested (legacy behaviour).
 	Prompt *DesktopPrompt `json:"prompt,omitempty"`
+	// FilePathAllow and FilePathDeny carry the service's file path policy so
+	// file drop downloads from the helper honour it.
+	FilePathAllow []string `json:"filePathAllow,omitempty"`
+	FilePathDeny  []string `json:"filePathDeny,omitempty"`
 }
 
 // DesktopStartResponse is returned by the user helper after creating the
@@ -330,6 +365,61 @@ type DesktopStopRequest struct {
 	SessionID string `json:"sessionId"`
 }
 
+// DesktopJoinRequest attaches an additional viewer to a desktop session the
+// helper is already running.
+type DesktopJoinRequest struct {
+	SessionID  string          `json:"sessionId"`
+	ViewerID   string          `json:"viewerId"`
+	Offer      string          `json:"offer"`
+	ICEServers json.RawMessage `json:"iceServers,omitempty"`
+	ViewOnly   bool            `json:"viewOnly"`
+}
+
+// DesktopICEServersUpdate pushes refreshed ICE servers (short-lived TURN
+// credentials) to the helper running a desktop session.
+type DesktopICEServersUpdate struct {
+	SessionID  string          `json:"sessionId"`
+	ICEServers json.RawMessage `json:"iceServers"`
+}
+
+// DesktopQualitySample is one metrics interval of a desktop session's stream
+// quality. The service uploads them so the console can show a session's
+// quality history. Rates and averages cover the interval; the rest is the
+// state at its end.
+type DesktopQualitySample struct {
+	SessionID      string  `json:"sessionId"`
+	AtUnixMs       int64   `json:"atUnixMs"`
+	IntervalMs     int64   `json:"intervalMs"`
+	FPS            float64 `json:"fps"`
+	EncodeMs       float64 `json:"encodeMs"`
+	RTTMs          float64 `json:"rttMs"`
+	LossPct        float64 `json:"lossPct"`
+	BitrateKbps    int     `json:"bitrateKbps"`
+	SentKbps       float64 `json:"sentKbps"`
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	DownscaleLevel int     `json:"downscaleLevel"`
+	Codec          string  `json:"codec"`
+	Encoder        string  `json:"encoder"`
+	HardwareEncode bool    `json:"hardwareEncode"`
+	GPUCapture     bool    `json:"gpuCapture"`
+	FramesDropped  uint64  `json:"framesDropped"`
+}
+
+// DesktopJoinResponse is returned by the user helper after attaching the
+// viewer's peer connection.
+type DesktopJoinResponse struct {
+	SessionID string `json:"sessionId"`
+	ViewerID  string `json:"viewerId"`
+	Answer    string `json:"answer"`
+}
+
+// DesktopLeaveRequest detaches an additional viewer from a desktop session.
+type DesktopLeaveRequest struct {
+	SessionID string `json:"sessionId"`
+	ViewerID  string `json:"viewerId"`
+}
+
 // SASRequest is sent by the user helper to the service when it needs to
 // trigger the Secure Attention Sequence (Ctrl+Alt+Del). The service is the
 // SCM-registered process with the highest chance of SendSAS(FALSE) succeeding.
@@ -381,6 +471,30 @@ type TCCStatus struct {
 	CheckedAt       time.Time `json:"checkedAt"`
 }
 
+// UsageMeteringConfig turns software usage metering on or off in a user
+// helper. Zero intervals use the helper's defaults.
+type UsageMeteringConfig struct {
+	Enabled               bool `json:"enabled"`
+	SampleIntervalSeconds int  `json:"sampleIntervalSeconds,omitempty"`
+	ReportIntervalSeconds int  `json:"reportIntervalSeconds,omitempty"`
+	IdleThresholdSeconds  int  `json:"idleThresholdSeconds,omitempty"`
+}
+
+// UsageReport carries the foreground time a user helper accumulated since
+// its last report. The agent attributes it to the helper session's user.
+type UsageReport struct {
+	Apps []AppUsage `json:"apps"`
+}
+
+// AppUsage is the foreground time of one application on one local day
+// (YYYY-MM-DD). Path is the executable or bundle path when known.
+type AppUsage struct {
+	Date    string `json:"date"`
+	Name    string `json:"name"`
+	Path    string `json:"path,omitempty"`
+	Seconds int64  `json:"seconds"`
+}
+
 // SessionInfoItem describes one interactive Windows session for the
 // list_sessions command response.
 type SessionInfoItem struct {
@@ -509,3 +623,24 @@ type BannerShowRequest struct {
 	Label           string `json:"label"`
 	StartedAtUnixMs int64  `json:"startedAtUnixMs"`
 }
+
+// Chat message senders.
+const (
+	ChatFromTechnician = "technician"
+	ChatFromUser       = "user"
+)
+
+// MaxChatMessageBytes bounds the text of one chat line.
+const MaxChatMessageBytes = 2000
+
+// ChatMessage is one line of the in-session chat. The desktop helper sends
+// technician lines (from the viewer's chat data channel) to the service,
+// which relays them to the consent-UI helper for display. That helper sends
+// the user's replies back the same way, and the service relays them to the
+// helper owning SessionID.
+type ChatMessage struct {
+	SessionID    string `json:"sessionId"`
+	From         string `json:"from"`
+	Text         string `json:"text"`
+	SentAtUnixMs int64  `json:"sentAtUnixMs"`
+}
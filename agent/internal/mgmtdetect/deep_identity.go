@@ -1,6 +1,9 @@
 package mgmtdetect
 
-import "strings"
+import (
+	"net/url"
+	"strings"
+)
 
 // deriveJoinType computes the join type from identity flags.
 func deriveJoinType(id IdentityStatus) JoinType {
@@ -40,6 +43,10 @@ func parseDsregcmdOutput(output string) IdentityStatus {
 			id.DomainName = val
 		case "TenantId":
 			id.TenantId = val
+		case "TenantName":
+			id.TenantName = val
+		case "DeviceId":
+			id.DeviceId = val
 		case "MdmUrl":
 			id.MdmUrl = val
 		}
@@ -47,3 +54,149 @@ func parseDsregcmdOutput(output string) IdentityStatus {
 	id.JoinType = deriveJoinType(id)
 	return id
 }
+
+// mdmAuthorities maps MDM server host suffixes to the service name.
+var mdmAuthorities = []struct{ suffix, name string }{
+	{"manage.microsoft.com", "Microsoft Intune"},
+	{"manage.microsoft.us", "Microsoft Intune"},
+	{"jamfcloud.com", "Jamf Pro"},
+	{"kandji.io", "Kandji"},
+	{"mosyle.com", "Mosyle"},
+	{"addigy.com", "Addigy"},
+	{"awmdm.com", "Workspace ONE"},
+	{"simplemdm.com", "SimpleMDM"},
+	{"jumpcloud.com", "JumpCloud"},
+	{"hexnodemdm.com", "Hexnode"},
+	{"meraki.com", "Cisco Meraki"},
+	{"manageengine.com", "ManageEngine"},
+	{"axm-adm-mdm.apple.com", "Apple Business Essentials"},
+}
+
+// mdmAuthorityFromURL names the MDM service behind an enrollment or check-in
+// URL, falling back to its host.
+func mdmAuthorityFromURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, a := range mdmAuthorities {
+		if host == a.suffix || strings.HasSuffix(host, "."+a.suffix) {
+			return a.name
+		}
+	}
+	return host
+}
+
+// parseProfilesEnrollmentStatus parses `profiles status -type enrollment`:
+//
+//	Enrolled via DEP: Yes
+//	MDM enrollment: Yes (User Approved)
+//	MDM server: https://acme.jamfcloud.com/mdm/ServerURL
+//
+// The labels are English on every macOS locale; the server line only exists
+// on macOS 13 and later.
+func parseProfilesEnrollmentStatus(output string) (enrolled bool, enrollmentType, server string) {
+	var ade, userApproved bool
+	for _, line := range strings.Split(output, "\n") {
+		key, val, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		yes := strings.HasPrefix(strings.ToLower(val), "yes")
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "enrolled via dep":
+			ade = yes
+		case "mdm enrollment":
+			enrolled = yes
+			userApproved = strings.Contains(strings.ToLower(val), "user approved")
+		case "mdm server":
+			server = val
+		}
+	}
+	switch {
+	case !enrolled:
+		return false, "", server
+	case ade:
+		enrollmentType = MdmEnrollmentAutomated
+	case userApproved:
+		enrollmentType = MdmEnrollmentUserApproved
+	default:
+		enrollmentType = MdmEnrollmentManual
+	}
+	return enrolled, enrollmentType, server
+}
+
+// parseRealmList returns the first realm `realm list` reports as joined
+// (configured: kerberos-member), or "" when the host is not joined.
+func parseRealmList(output string) string {
+	var domain, realmName string
+	joined := false
+	flush := func() string {
+		if !joined {
+			return ""
+		}
+		if domain != "" {
+			return domain
+		}
+		return realmName
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			// A new realm block starts with its unindented name.
+			if d := flush(); d != "" {
+				return d
+			}
+			domain, realmName, joined = "", strings.TrimSpace(line), false
+			continue
+		}
+		key, val, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		switch key {
+		case "domain-name":
+			domain = val
+		case "configured":
+			joined = val != "" && val != "no"
+		}
+	}
+	return flush()
+}
+
+// windowsEnrollment is one HKLM\SOFTWARE\Microsoft\Enrollments\{GUID} key.
+type windowsEnrollment struct {
+	ProviderID   string
+	State        uint64 // EnrollmentState; 1 = enrolled
+	DiscoveryURL string // DiscoveryServiceFullURL
+}
+
+// applyWindowsMdmEnrollment records the active MDM enrollment, if any.
+// Intune registers as provider "MS DM Server"; other MDMs are named from
+// their discovery URL. Enrollments without a provider (leftover or
+// provisioning-only keys) are ignored.
+func applyWindowsMdmEnrollment(id *IdentityStatus, enrollments []windowsEnrollment) {
+	for _, e := range enrollments {
+		if e.ProviderID == "" || e.State != 1 {
+			continue
+		}
+		if e.ProviderID != "MS DM Server" && e.DiscoveryURL == "" {
+			continue
+		}
+		id.MdmEnrolled = true
+		if e.ProviderID == "MS DM Server" {
+			id.MdmAuthority = "Microsoft Intune"
+		} else if id.MdmAuthority = mdmAuthorityFromURL(e.DiscoveryURL); id.MdmAuthority == "" {
+			id.MdmAuthority = e.ProviderID
+		}
+		if e.DiscoveryURL != "" {
+			id.MdmUrl = e.DiscoveryURL
+		}
+		return
+	}
+}
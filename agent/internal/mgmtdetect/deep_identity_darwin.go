@@ -36,39 +36,46 @@ func collectIdentityStatus() IdentityStatus {
 		}
 	}
 
-	// Detect MDM enrollment using locale-invariant methods first, then fall
-	// back to English text matching on older macOS versions.
-
-	// Method 1: Check for MDM client preferences (locale-invariant).
-	ctx2, cancel2 := context.WithTimeout(context.Background(), 3*time.Second)
+	// profiles status reports how the Mac was enrolled and (macOS 13+) the
+	// MDM server, so it runs first; its labels are English on every locale.
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel2()
-	mdmPref, err := exec.CommandContext(ctx2, "defaults", "read", "/Library/Preferences/com.apple.mdmclient").CombinedOutput()
-	if err == nil && len(mdmPref) > 0 && !strings.Contains(string(mdmPref), "does not exist") {
-		id.MdmUrl = "enrolled"
+	if profOutput, err := exec.CommandContext(ctx2, "profiles", "status", "-type", "enrollment").CombinedOutput(); err == nil {
+		enrolled, enrollmentType, server := parseProfilesEnrollmentStatus(string(profOutput))
+		if enrolled {
+			id.MdmEnrolled = true
+			id.MdmEnrollmentType = enrollmentType
+			id.MdmUrl = "enrolled"
+			if server != "" {
+				id.MdmUrl = server
+				id.MdmAuthority = mdmAuthorityFromURL(server)
+			}
+		}
 	}
 
-	// Method 2: Check profiles list XML output for com.apple.mdm payload (locale-invariant).
+	// Fall back to locale-invariant enrollment evidence when profiles status
+	// is unavailable or unparseable.
+
+	// Method 1: Check for MDM client preferences (locale-invariant).
 	if id.MdmUrl == "" {
-		ctx3, cancel3 := context.WithTimeout(context.Background(), 5*time.Second)
+		ctx3, cancel3 := context.WithTimeout(context.Background(), 3*time.Second)
 		defer cancel3()
-		profXml, err := exec.CommandContext(ctx3, "profiles", "list", "-output", "stdout-xml").CombinedOutput()
-		if err == nil && strings.Contains(string(profXml), "com.apple.mdm") {
+		mdmPref, err := exec.CommandContext(ctx3, "defaults", "read", "/Library/Preferences/com.apple.mdmclient").CombinedOutput()
+		if err == nil && len(mdmPref) > 0 && !strings.Contains(string(mdmPref), "does not exist") {
 			id.MdmUrl = "enrolled"
 		}
 	}
 
-	// Method 3: Fallback to profiles status text (English-only, for older macOS).
+	// Method 2: Check profiles list XML output for com.apple.mdm payload (locale-invariant).
 	if id.MdmUrl == "" {
 		ctx4, cancel4 := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel4()
-		profOutput, err := exec.CommandContext(ctx4, "profiles", "status", "-type", "enrollment").CombinedOutput()
-		if err == nil {
-			profText := strings.ToLower(string(profOutput))
-			if strings.Contains(profText, "enrolled to an mdm server") || strings.Contains(profText, "mdm enrollment: yes") {
-				id.MdmUrl = "enrolled"
-			}
+		profXml, err := exec.CommandContext(ctx4, "profiles", "list", "-output", "stdout-xml").CombinedOutput()
+		if err == nil && strings.Contains(string(profXml), "com.apple.mdm") {
+			id.MdmUrl = "enrolled"
 		}
 	}
+	id.MdmEnrolled = id.MdmUrl != ""
 
 	id.JoinType = deriveJoinType(id)
 	return id
@@ -0,0 +1,35 @@
+//go:build linux
+
+package mgmtdetect
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"time"
+)
+
+// collectIdentityStatus reports Active Directory / FreeIPA membership as
+// configured through realmd (sssd or winbind). Hosts without realmd report
+// no join.
+func collectIdentityStatus() IdentityStatus {
+	id := IdentityStatus{Source: "realm", JoinType: JoinTypeNone}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "realm", "list").Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			id.Source = "unsupported"
+		} else {
+			log.Debug("realm list failed", "error", err)
+		}
+		return id
+	}
+	if domain := parseRealmList(string(output)); domain != "" {
+		id.DomainJoined = true
+		id.DomainName = domain
+	}
+	id.JoinType = deriveJoinType(id)
+	return id
+}
@@ -115,3 +115,115 @@ NoSpace:AtAll
 		t.Errorf("expected azure_ad, got %s", id.JoinType)
 	}
 }
+
+func TestParseDsregcmdTenantAndDevice(t *testing.T) {
+	sample := `             AzureAdJoined : YES
+                  DeviceId : 0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0
+                TenantName : Contoso Ltd
+                  TenantId : 12345678-1234-1234-1234-123456789abc
+`
+	id := parseDsregcmdOutput(sample)
+	if id.TenantName != "Contoso Ltd" || id.DeviceId != "0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0" {
+		t.Errorf("unexpected tenant/device: %+v", id)
+	}
+}
+
+func TestMdmAuthorityFromURL(t *testing.T) {
+	tests := map[string]string{
+		"https://enrollment.manage.microsoft.com/enrollmentserver/discovery.svc": "Microsoft Intune",
+		"https://acme.jamfcloud.com/mdm/ServerURL":                               "Jamf Pro",
+		"https://acme.kandji.io/mdm/checkin":                                     "Kandji",
+		"https://mdm.example.com:8443/mdm":                                       "mdm.example.com",
+		"enrolled":                                                               "",
+		"":                                                                       "",
+	}
+	for in, want := range tests {
+		if got := mdmAuthorityFromURL(in); got != want {
+			t.Errorf("%q: got %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseProfilesEnrollmentStatus(t *testing.T) {
+	tests := []struct {
+		name, output, wantType, wantServer string
+		wantEnrolled                       bool
+	}{
+		{
+			name:         "ade",
+			output:       "Enrolled via DEP: Yes\nMDM enrollment: Yes (User Approved)\nMDM server: https://acme.jamfcloud.com/mdm/ServerURL\n",
+			wantEnrolled: true, wantType: MdmEnrollmentAutomated, wantServer: "https://acme.jamfcloud.com/mdm/ServerURL",
+		},
+		{
+			name:         "user approved",
+			output:       "Enrolled via DEP: No\nMDM enrollment: Yes (User Approved)\n",
+			wantEnrolled: true, wantType: MdmEnrollmentUserApproved,
+		},
+		{
+			name:         "manual",
+			output:       "Enrolled via DEP: No\nMDM enrollment: Yes\n",
+			wantEnrolled: true, wantType: MdmEnrollmentManual,
+		},
+		{
+			name:   "not enrolled",
+			output: "Enrolled via DEP: No\nMDM enrollment: No\n",
+		},
+	}
+	for _, tt := range tests {
+		enrolled, typ, server := parseProfilesEnrollmentStatus(tt.output)
+		if enrolled != tt.wantEnrolled || typ != tt.wantType || server != tt.wantServer {
+			t.Errorf("%s: got %v/%q/%q", tt.name, enrolled, typ, server)
+		}
+	}
+}
+
+func TestParseRealmList(t *testing.T) {
+	joined := `example.org
+  type: kerberos
+  realm-name: EXAMPLE.ORG
+  domain-name: example.org
+  configured: no
+contoso.com
+  type: kerberos
+  realm-name: CONTOSO.COM
+  domain-name: contoso.com
+  configured: kerberos-member
+  server-software: active-directory
+  client-software: sssd
+`
+	if got := parseRealmList(joined); got != "contoso.com" {
+		t.Errorf("got %q, want contoso.com", got)
+	}
+	if got := parseRealmList("example.org\n  configured: no\n"); got != "" {
+		t.Errorf("discovered-only realm should not count as joined, got %q", got)
+	}
+	if got := parseRealmList(""); got != "" {
+		t.Errorf("got %q for empty output", got)
+	}
+}
+
+func TestApplyWindowsMdmEnrollment(t *testing.T) {
+	var id IdentityStatus
+	applyWindowsMdmEnrollment(&id, []windowsEnrollment{
+		{ProviderID: "", State: 1},
+		{ProviderID: "WMI_Bridge_Server", State: 1},
+		{ProviderID: "MS DM Server", State: 1, DiscoveryURL: "https://enrollment.manage.microsoft.com/enrollmentserver/discovery.svc"},
+	})
+	if !id.MdmEnrolled || id.MdmAuthority != "Microsoft Intune" || id.MdmUrl == "" {
+		t.Errorf("unexpected intune enrollment: %+v", id)
+	}
+
+	id = IdentityStatus{}
+	applyWindowsMdmEnrollment(&id, []windowsEnrollment{
+		{ProviderID: "AirWatchMDM", State: 1, DiscoveryURL: "https://ds1234.awmdm.com/DeviceServices/Discovery.aws"},
+	})
+	if id.MdmAuthority != "Workspace ONE" {
+		t.Errorf("unexpected authority: %+v", id)
+	}
+
+	id = IdentityStatus{}
+	applyWindowsMdmEnrollment(&id, []windowsEnrollment{{ProviderID: "MS DM Server", State: 0}})
+	if id.MdmEnrolled {
+		t.Error("unenrolled key should be ignored")
+	}
+}
@@ -23,6 +23,7 @@ func collectIdentityStatus() IdentityStatus {
 		if fb.JoinType == JoinTypeNone {
 			fb.Source = "dsregcmd_error_no_fallback"
 		}
+		applyWindowsMdmEnrollment(&fb, readWindowsEnrollments())
 		return fb
 	}
 
@@ -35,12 +36,44 @@ func collectIdentityStatus() IdentityStatus {
 		fb := collectIdentityStatusFromRegistry()
 		if fb.JoinType != JoinTypeNone {
 			fb.Source = "registry_fallback_after_dsregcmd_none"
-			return fb
+			id = fb
 		}
 	}
+	// dsregcmd's MdmUrl is the tenant's auto-enrollment URL, not proof of
+	// enrollment; the Enrollments key is authoritative.
+	applyWindowsMdmEnrollment(&id, readWindowsEnrollments())
 	return id
 }
 
+// readWindowsEnrollments lists the MDM enrollment keys under
+// HKLM\SOFTWARE\Microsoft\Enrollments.
+func readWindowsEnrollments() []windowsEnrollment {
+	root, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Enrollments`, registry.READ)
+	if err != nil {
+		return nil
+	}
+	defer root.Close()
+	names, err := root.ReadSubKeyNames(-1)
+	if err != nil {
+		return nil
+	}
+
+	var enrollments []windowsEnrollment
+	for _, name := range names {
+		k, err := registry.OpenKey(root, name, registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+		var e windowsEnrollment
+		e.ProviderID, _, _ = k.GetStringValue("ProviderID")
+		e.State, _, _ = k.GetIntegerValue("EnrollmentState")
+		e.DiscoveryURL, _, _ = k.GetStringValue("DiscoveryServiceFullURL")
+		k.Close()
+		enrollments = append(enrollments, e)
+	}
+	return enrollments
+}
+
 // collectIdentityStatusFromRegistry reads canonical Win32 registry locations
 // for classic AD domain membership. Used when dsregcmd is unavailable, errors,
 // or returns no join info.
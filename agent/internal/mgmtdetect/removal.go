@@ -0,0 +1,480 @@
+package mgmtdetect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/svcquery"
+)
+
+// RemovalStepType identifies one action in a guided removal plan.
+type RemovalStepType string
+
+const (
+	// StepRun executes a vendor uninstaller at a fixed path with its
+	// documented silent arguments. Skipped when the executable is absent.
+	StepRun RemovalStepType = "run"
+	// StepUninstallProduct runs the registered uninstaller of every installed
+	// product whose display name starts with Value (Windows only). MSI
+	// products are removed with msiexec /x /qn; others only when they
+	// register a QuietUninstallString.
+	StepUninstallProduct RemovalStepType = "uninstall_product"
+	// StepStopService stops a service that the uninstaller left running.
+	StepStopService RemovalStepType = "stop_service"
+	// StepDeleteService removes a leftover service registration.
+	StepDeleteService RemovalStepType = "delete_service"
+	// StepUnloadLaunchDaemon boots out a launchd job and deletes its plist.
+	StepUnloadLaunchDaemon RemovalStepType = "unload_launch_daemon"
+	// StepRemovePath deletes a leftover install directory or file.
+	StepRemovePath RemovalStepType = "remove_path"
+)
+
+// RemovalStep is a single action in a removal plan.
+type RemovalStep struct {
+	Type  RemovalStepType `json:"type"`
+	Value string          `json:"value"`
+	Args  []string        `json:"args,omitempty"`
+	// Publisher narrows StepUninstallProduct to products from this
+	// publisher (case-insensitive substring), for generic display names.
+	Publisher string `json:"publisher,omitempty"`
+	OS        string `json:"os,omitempty"`
+}
+
+// RemovalPlan is the vendor-documented silent removal for one tool. Name
+// matches the tool's Signature; the signature's checks double as the
+// post-removal verification.
+type RemovalPlan struct {
+	Name  string        `json:"name"`
+	Steps []RemovalStep `json:"steps"`
+}
+
+// CleanupStatus is the per-tool outcome of a cleanup run.
+type CleanupStatus string
+
+const (
+	CleanupRemoved      CleanupStatus = "removed"
+	CleanupIncomplete   CleanupStatus = "incomplete"
+	CleanupNotInstalled CleanupStatus = "not_installed"
+	CleanupPlanned      CleanupStatus = "planned"
+	CleanupUnsupported  CleanupStatus = "unsupported"
+)
+
+// CleanupOptions controls a cleanup run.
+type CleanupOptions struct {
+	// DryRun detects and reports the steps that would run without
+	// changing anything.
+	DryRun bool
+}
+
+// RemovalStepResult records what one step did.
+type RemovalStepResult struct {
+	Type    RemovalStepType `json:"type"`
+	Target  string          `json:"target"`
+	Skipped bool            `json:"skipped,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Output  string          `json:"output,omitempty"`
+}
+
+// CleanupResult is the migration-cleanup status for one tool.
+type CleanupResult struct {
+	Name   string              `json:"name"`
+	Status CleanupStatus       `json:"status"`
+	Steps  []RemovalStepResult `json:"steps,omitempty"`
+	// Remaining lists the detection probes that still match after removal
+	// (or before it, for a dry run): services, files, processes, launch
+	// daemons and registry keys.
+	Remaining  []string  `json:"remaining,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+const (
+	// removalStepTimeout bounds one uninstaller run. Vendor uninstallers
+	// routinely take minutes (service teardown, MSI rollback scripts).
+	removalStepTimeout = 15 * time.Minute
+	maxStepOutputBytes = 2048
+)
+
+// Seams for tests.
+var (
+	runRemovalCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return exec.CommandContext(ctx, name, args...).CombinedOutput()
+	}
+	serviceRegistered = func(name string) bool {
+		info, err := svcquery.GetStatus(name)
+		return err == nil && info.Status != svcquery.StatusUnknown
+	}
+	// removalSettleDelay gives services and child processes a moment to
+	// exit before verification.
+	removalSettleDelay = 5 * time.Second
+)
+
+// RemovalPlans returns the guided removal plans for supported RMM tools.
+func RemovalPlans() []RemovalPlan {
+	return []RemovalPlan{
+		{
+			Name: "ConnectWise Automate",
+			Steps: []RemovalStep{
+				{Type: StepStopService, Value: "LTService", OS: "windows"},
+				{Type: StepStopService, Value: "LTSvcMon", OS: "windows"},
+				{Type: StepUninstallProduct, Value: "ConnectWise Automate", OS: "windows"},
+				{Type: StepUninstallProduct, Value: "LabTech", OS: "windows"},
+				{Type: StepDeleteService, Value: "LTService", OS: "windows"},
+				{Type: StepDeleteService, Value: "LTSvcMon", OS: "windows"},
+				{Type: StepRemovePath, Value: `C:\Windows\LTSvc`, OS: "windows"},
+			},
+		},
+		{
+			Name: "ScreenConnect",
+			Steps: []RemovalStep{
+				{Type: StepUninstallProduct, Value: "ScreenConnect Client", OS: "windows"},
+				{Type: StepRemovePath, Value: `C:\Program Files (x86)\ScreenConnect Client`, OS: "windows"},
+				{Type: StepRemovePath, Value: "/opt/screenconnect-client", OS: "darwin"},
+			},
+		},
+		{
+			Name: "Datto RMM",
+			Steps: []RemovalStep{
+				{Type: StepRun, Value: `C:\Program Files (x86)\CentraStage\uninst.exe`, Args: []string{"/S"}, OS: "windows"},
+				{Type: StepUninstallProduct, Value: "Datto RMM", OS: "windows"},
+				{Type: StepDeleteService, Value: "CagService", OS: "windows"},
+				{Type: StepRemovePath, Value: `C:\Program Files (x86)\CentraStage`, OS: "windows"},
+				{Type: StepUnloadLaunchDaemon, Value: "com.centrastage.agent", OS: "darwin"},
+				{Type: StepRemovePath, Value: "/Library/Application Support/CentraStage", OS: "darwin"},
+			},
+		},
+		{
+			Name: "NinjaOne",
+			Steps: []RemovalStep{
+				{Type: StepRun, Value: `C:\ProgramData\NinjaRMMAgent\uninstall.exe`, Args: []string{"--mode", "unattended"}, OS: "windows"},
+				{Type: StepUninstallProduct, Value: "NinjaRMMAgent", OS: "windows"},
+				{Type: StepDeleteService, Value: "NinjaRMMAgent", OS: "windows"},
+				{Type: StepRemovePath, Value: `C:\ProgramData\NinjaRMMAgent`, OS: "windows"},
+				{Type: StepRun, Value: "/Applications/NinjaRMMAgent/programfiles/uninstall.sh", OS: "darwin"},
+				{Type: StepUnloadLaunchDaemon, Value: "com.ninjarmm.agent", OS: "darwin"},
+				{Type: StepRemovePath, Value: "/Applications/NinjaRMMAgent", OS: "darwin"},
+			},
+		},
+		{
+			Name: "Atera",
+			Steps: []RemovalStep{
+				{Type: StepUninstallProduct, Value: "AteraAgent", OS: "windows"},
+				{Type: StepDeleteService, Value: "AteraAgent", OS: "windows"},
+				{Type: StepRemovePath, Value: `C:\Program Files\ATERA Networks\AteraAgent`, OS: "windows"},
+				{Type: StepUnloadLaunchDaemon, Value: "com.atera.ateraagent", OS: "darwin"},
+				{Type: StepRemovePath, Value: "/Library/AteraAgent", OS: "darwin"},
+			},
+		},
+		{
+			Name: "SyncroMSP",
+			Steps: []RemovalStep{
+				{Type: StepUninstallProduct, Value: "Syncro", Publisher: "Servably", OS: "windows"},
+				{Type: StepDeleteService, Value: "Syncro", OS: "windows"},
+				{Type: StepRemovePath, Value: `C:\ProgramData\Syncro`, OS: "windows"},
+				{Type: StepUnloadLaunchDaemon, Value: "com.syncromsp.agent", OS: "darwin"},
+			},
+		},
+		{
+			Name: "N-able",
+			Steps: []RemovalStep{
+				{Type: StepUninstallProduct, Value: "Windows Agent", Publisher: "N-able", OS: "windows"},
+				{Type: StepDeleteService, Value: "Windows Agent Service", OS: "windows"},
+				{Type: StepRemovePath, Value: `C:\Program Files (x86)\N-able Technologies\Windows Agent`, OS: "windows"},
+				{Type: StepUnloadLaunchDaemon, Value: "com.n-able.agent", OS: "darwin"},
+			},
+		},
+		{
+			Name: "Kaseya VSA",
+			Steps: []RemovalStep{
+				{Type: StepUninstallProduct, Value: "Kaseya Agent", OS: "windows"},
+				{Type: StepDeleteService, Value: "Kaseya Agent Service", OS: "windows"},
+				{Type: StepRemovePath, Value: "/Library/Application Support/Kaseya", OS: "darwin"},
+			},
+		},
+		{
+			Name: "Pulseway",
+			Steps: []RemovalStep{
+				{Type: StepUninstallProduct, Value: "Pulseway", OS: "windows"},
+				{Type: StepDeleteService, Value: "PulsewayService", OS: "windows"},
+				{Type: StepRemovePath, Value: `C:\Program Files (x86)\Pulseway`, OS: "windows"},
+				{Type: StepUnloadLaunchDaemon, Value: "com.pulseway.agent", OS: "darwin"},
+			},
+		},
+		{
+			Name: "Level",
+			Steps: []RemovalStep{
+				{Type: StepUninstallProduct, Value: "Level", Publisher: "Level", OS: "windows"},
+				{Type: StepDeleteService, Value: "level-agent", OS: "windows"},
+				{Type: StepRemovePath, Value: `C:\ProgramData\Level`, OS: "windows"},
+				{Type: StepUnloadLaunchDaemon, Value: "com.level.agent", OS: "darwin"},
+			},
+		},
+		{
+			Name: "Tactical RMM",
+			Steps: []RemovalStep{
+				{Type: StepRun, Value: `C:\Program Files\TacticalAgent\unins000.exe`, Args: []string{"/VERYSILENT", "/SUPPRESSMSGBOXES", "/NORESTART"}, OS: "windows"},
+				{Type: StepDeleteService, Value: "tacticalrmm", OS: "windows"},
+				{Type: StepRemovePath, Value: `C:\Program Files\TacticalAgent`, OS: "windows"},
+				{Type: StepUnloadLaunchDaemon, Value: "tacticalagent", OS: "darwin"},
+				{Type: StepRemovePath, Value: "/usr/local/bin/tacticalagent", OS: "darwin"},
+			},
+		},
+	}
+}
+
+// CleanupTool removes one superseded RMM tool by name and verifies it is
+// gone. Only tools with both an RMM signature and a removal plan are
+// accepted, so the engine can never be pointed at arbitrary software.
+func CleanupTool(name string, opts CleanupOptions) CleanupResult {
+	start := time.Now()
+	result := CleanupResult{Name: name, StartedAt: start.UTC()}
+	defer func() { result.DurationMs = time.Since(start).Milliseconds() }()
+
+	sig, plan, err := lookupRemoval(name, runtime.GOOS)
+	if err != nil {
+		result.Status = CleanupUnsupported
+		result.Error = err.Error()
+		return result
+	}
+	return runCleanup(sig, plan, opts, result)
+}
+
+func lookupRemoval(name, goos string) (Signature, RemovalPlan, error) {
+	var sig Signature
+	found := false
+	for _, s := range AllSignatures() {
+		if s.Category == CategoryRMM && strings.EqualFold(s.Name, name) {
+			sig, found = s, true
+			break
+		}
+	}
+	if !found {
+		return Signature{}, RemovalPlan{}, fmt.Errorf("no RMM signature named %q", name)
+	}
+	if !sig.MatchesOS(goos) {
+		return Signature{}, RemovalPlan{}, fmt.Errorf("%s is not supported on %s", sig.Name, goos)
+	}
+	for _, p := range RemovalPlans() {
+		if p.Name == sig.Name {
+			return sig, p, nil
+		}
+	}
+	return Signature{}, RemovalPlan{}, fmt.Errorf("no removal plan for %s", sig.Name)
+}
+
+func runCleanup(sig Signature, plan RemovalPlan, opts CleanupOptions, result CleanupResult) CleanupResult {
+	result.Name = sig.Name
+	result.Remaining = verifyRemoved(sig)
+	if len(result.Remaining) == 0 {
+		result.Status = CleanupNotInstalled
+		return result
+	}
+
+	for _, step := range plan.Steps {
+		if step.OS != "" && step.OS != runtime.GOOS {
+			continue
+		}
+		if opts.DryRun {
+			result.Steps = append(result.Steps, RemovalStepResult{Type: step.Type, Target: step.Value, Skipped: true})
+			continue
+		}
+		sr := runRemovalStep(step)
+		if sr.Error != "" {
+			log.Warn("removal step failed", "tool", sig.Name, "step", step.Type, "target", step.Value, "error", sr.Error)
+		}
+		result.Steps = append(result.Steps, sr)
+	}
+	if opts.DryRun {
+		result.Status = CleanupPlanned
+		return result
+	}
+
+	if removalSettleDelay > 0 {
+		time.Sleep(removalSettleDelay)
+	}
+	result.Remaining = verifyRemoved(sig)
+	if len(result.Remaining) == 0 {
+		result.Status = CleanupRemoved
+	} else {
+		result.Status = CleanupIncomplete
+	}
+	log.Info("rmm cleanup finished", "tool", sig.Name, "status", result.Status, "remaining", len(result.Remaining))
+	return result
+}
+
+// verifyRemoved re-runs the signature's probes with a fresh process
+// snapshot and returns every one that still matches. A service probe also
+// counts while the service is merely registered — a stopped but installed
+// agent will start again on the next boot.
+func verifyRemoved(sig Signature) []string {
+	snap, err := newProcessSnapshot()
+	if err != nil {
+		snap = &processSnapshot{names: make(map[string]bool)}
+	}
+	d := newCheckDispatcher(snap)
+
+	var remaining []string
+	for _, c := range sig.Checks {
+		if c.OS != "" && c.OS != runtime.GOOS {
+			continue
+		}
+		if d.evaluate(c) || (c.Type == CheckServiceRunning && serviceRegistered(c.Value)) {
+			remaining = append(remaining, string(c.Type)+":"+c.Value)
+		}
+	}
+	return remaining
+}
+
+func runRemovalStep(step RemovalStep) RemovalStepResult {
+	sr := RemovalStepResult{Type: step.Type, Target: step.Value}
+	var err error
+	switch step.Type {
+	case StepRun:
+		if _, statErr := os.Stat(step.Value); statErr != nil {
+			sr.Skipped = true
+			return sr
+		}
+		sr.Output, err = runStepCommand(step.Value, step.Args...)
+	case StepUninstallProduct:
+		var cmds [][]string
+		cmds, err = productUninstallCommands(step.Value, step.Publisher)
+		if err == nil && len(cmds) == 0 {
+			sr.Skipped = true
+			return sr
+		}
+		var outputs, errs []string
+		for _, argv := range cmds {
+			out, runErr := runStepCommand(argv[0], argv[1:]...)
+			if out != "" {
+				outputs = append(outputs, out)
+			}
+			if runErr != nil {
+				errs = append(errs, runErr.Error())
+			}
+		}
+		sr.Output = truncateStepOutput(strings.Join(outputs, "\n"))
+		if len(errs) > 0 {
+			err = errors.New(strings.Join(errs, "; "))
+		}
+	case StepStopService, StepDeleteService:
+		if !serviceRegistered(step.Value) {
+			sr.Skipped = true
+			return sr
+		}
+		sr.Output, err = serviceCommand(step.Type, step.Value)
+	case StepUnloadLaunchDaemon:
+		err = unloadLaunchDaemon(step.Value)
+	case StepRemovePath:
+		err = removeLeftoverPath(step.Value)
+	default:
+		err = fmt.Errorf("unknown removal step %q", step.Type)
+	}
+	if err != nil {
+		sr.Error = err.Error()
+	}
+	return sr
+}
+
+func runStepCommand(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), removalStepTimeout)
+	defer cancel()
+	out, err := runRemovalCommand(ctx, name, args...)
+	output := truncateStepOutput(strings.TrimSpace(string(out)))
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && uninstallExitOK(exitErr.ExitCode()) {
+			return output, nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return output, fmt.Errorf("%s timed out after %s", filepath.Base(name), removalStepTimeout)
+		}
+		return output, fmt.Errorf("%s: %w", filepath.Base(name), err)
+	}
+	return output, nil
+}
+
+// uninstallExitOK accepts the msiexec codes that mean the product is gone:
+// 1605 (not installed), 1641 and 3010 (removed, reboot initiated/required).
+func uninstallExitOK(code int) bool {
+	return code == 1605 || code == 1641 || code == 3010
+}
+
+func serviceCommand(stepType RemovalStepType, name string) (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		verb := "stop"
+		if stepType == StepDeleteService {
+			verb = "delete"
+		}
+		return runStepCommand("sc.exe", verb, name)
+	case "linux":
+		if stepType == StepDeleteService {
+			return runStepCommand("systemctl", "disable", "--now", name)
+		}
+		return runStepCommand("systemctl", "stop", name)
+	default:
+		return "", fmt.Errorf("service removal not supported on %s", runtime.GOOS)
+	}
+}
+
+func unloadLaunchDaemon(label string) error {
+	if runtime.GOOS != "darwin" {
+		return fmt.Errorf("launch daemons not supported on %s", runtime.GOOS)
+	}
+	// bootout fails when the job is already unloaded; the plist removal
+	// below is what verification checks.
+	_, _ = runStepCommand("launchctl", "bootout", "system/"+label)
+	var errs []string
+	for _, p := range []string{
+		"/Library/LaunchDaemons/" + label + ".plist",
+		"/Library/LaunchAgents/" + label + ".plist",
+	} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// removeLeftoverPath deletes a plan-defined install path. Paths fewer than
+// two levels below the filesystem root are refused as a guard against a
+// bad plan entry wiping a system directory.
+func removeLeftoverPath(path string) error {
+	clean := filepath.Clean(path)
+	if !filepath.IsAbs(clean) || pathDepth(clean) < 2 {
+		return fmt.Errorf("refusing to remove %q", path)
+	}
+	if err := os.RemoveAll(clean); err != nil {
+		return err
+	}
+	return nil
+}
+
+func pathDepth(p string) int {
+	rest := strings.TrimPrefix(p, filepath.VolumeName(p))
+	depth := 0
+	for _, part := range strings.FieldsFunc(rest, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if part != "" {
+			depth++
+		}
+	}
+	return depth
+}
+
+func truncateStepOutput(s string) string {
+	if len(s) <= maxStepOutputBytes {
+		return s
+	}
+	return s[:maxStepOutputBytes] + "...(truncated)"
+}
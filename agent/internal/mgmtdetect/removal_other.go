@@ -0,0 +1,9 @@
+//go:build !windows
+
+package mgmtdetect
+
+// productUninstallCommands is Windows-only: other platforms have no
+// registered-product uninstall database, so plans use explicit steps.
+func productUninstallCommands(_, _ string) ([][]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,111 @@
+package mgmtdetect
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRemovalPlansMatchRMMSignatures(t *testing.T) {
+	sigs := make(map[string]Signature)
+	for _, s := range AllSignatures() {
+		sigs[s.Name] = s
+	}
+	for _, p := range RemovalPlans() {
+		sig, ok := sigs[p.Name]
+		if !ok {
+			t.Errorf("plan %q has no signature", p.Name)
+			continue
+		}
+		if sig.Category != CategoryRMM {
+			t.Errorf("plan %q targets non-RMM category %q", p.Name, sig.Category)
+		}
+		for _, step := range p.Steps {
+			if step.OS != "" && !sig.MatchesOS(step.OS) {
+				t.Errorf("plan %q step %s targets %s, which the signature does not cover", p.Name, step.Type, step.OS)
+			}
+			if step.Type == StepRemovePath && pathDepth(filepath.Clean(step.Value)) < 2 {
+				t.Errorf("plan %q removes shallow path %q", p.Name, step.Value)
+			}
+		}
+	}
+}
+
+func TestLookupRemovalRejectsNonRMM(t *testing.T) {
+	if _, _, err := lookupRemoval("TeamViewer", "windows"); err == nil {
+		t.Error("remote access tool should not be removable")
+	}
+	if _, _, err := lookupRemoval("NinjaOne", "linux"); err == nil {
+		t.Error("tool without a linux signature should be unsupported")
+	}
+	if sig, _, err := lookupRemoval("ninjaone", "windows"); err != nil || sig.Name != "NinjaOne" {
+		t.Errorf("case-insensitive lookup failed: %v", err)
+	}
+}
+
+func TestRunCleanupVerifiesRemoval(t *testing.T) {
+	origSettle, origRun := removalSettleDelay, runRemovalCommand
+	removalSettleDelay = 0
+	t.Cleanup(func() { removalSettleDelay, runRemovalCommand = origSettle, origRun })
+
+	installDir := filepath.Join(t.TempDir(), "vendor", "agent")
+	if err := os.MkdirAll(installDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	marker := filepath.Join(installDir, "agent.bin")
+	if err := os.WriteFile(marker, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	uninstaller := filepath.Join(installDir, "uninstall")
+	if err := os.WriteFile(uninstaller, []byte("x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var ran []string
+	runRemovalCommand = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		ran = append(ran, name)
+		return []byte("ok"), nil
+	}
+
+	sig := Signature{Name: "Fake RMM", Category: CategoryRMM, OS: []string{runtime.GOOS},
+		Checks: []Check{{Type: CheckFileExists, Value: marker}}}
+	plan := RemovalPlan{Name: "Fake RMM", Steps: []RemovalStep{
+		{Type: StepRun, Value: uninstaller, Args: []string{"--silent"}},
+		{Type: StepRun, Value: filepath.Join(installDir, "missing")},
+	}}
+
+	dry := runCleanup(sig, plan, CleanupOptions{DryRun: true}, CleanupResult{})
+	if dry.Status != CleanupPlanned || len(ran) != 0 || len(dry.Remaining) != 1 {
+		t.Fatalf("dry run: status=%s ran=%v remaining=%v", dry.Status, ran, dry.Remaining)
+	}
+
+	// The fake uninstaller leaves files behind, so the run is incomplete.
+	res := runCleanup(sig, plan, CleanupOptions{}, CleanupResult{})
+	if res.Status != CleanupIncomplete || len(ran) != 1 {
+		t.Fatalf("status=%s ran=%v", res.Status, ran)
+	}
+	if !res.Steps[1].Skipped {
+		t.Error("missing uninstaller should be skipped")
+	}
+
+	plan.Steps = append(plan.Steps, RemovalStep{Type: StepRemovePath, Value: installDir})
+	if res := runCleanup(sig, plan, CleanupOptions{}, CleanupResult{}); res.Status != CleanupRemoved {
+		t.Fatalf("status=%s remaining=%v steps=%+v", res.Status, res.Remaining, res.Steps)
+	}
+	if res := runCleanup(sig, plan, CleanupOptions{}, CleanupResult{}); res.Status != CleanupNotInstalled {
+		t.Fatalf("second run status=%s", res.Status)
+	}
+}
+
+func TestRemoveLeftoverPathRefusesShallowPaths(t *testing.T) {
+	for _, p := range []string{"/", "/usr", "relative/dir", `C:\`, `C:\Windows`} {
+		if runtime.GOOS != "windows" && len(p) > 1 && p[1] == ':' {
+			continue
+		}
+		if err := removeLeftoverPath(p); err == nil {
+			t.Errorf("removeLeftoverPath(%q) should refuse", p)
+		}
+	}
+}
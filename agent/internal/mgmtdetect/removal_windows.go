@@ -0,0 +1,88 @@
+//go:build windows
+
+package mgmtdetect
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+var uninstallRoots = []string{
+	`SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall`,
+	`SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall`,
+}
+
+var msiProductCode = regexp.MustCompile(`(?i)\{[0-9A-F]{8}-[0-9A-F]{4}-[0-9A-F]{4}-[0-9A-F]{4}-[0-9A-F]{12}\}`)
+
+// productUninstallCommands returns the silent uninstall command line for
+// every installed product whose DisplayName starts with prefix (and whose
+// Publisher contains publisher, when given). MSI products are removed by
+// product code; non-MSI products only when they register a
+// QuietUninstallString, since their interactive UninstallString would hang
+// waiting for a user.
+func productUninstallCommands(prefix, publisher string) ([][]string, error) {
+	prefix = strings.ToLower(prefix)
+	publisher = strings.ToLower(publisher)
+	seen := make(map[string]bool)
+	var cmds [][]string
+
+	for _, root := range uninstallRoots {
+		key, err := registry.OpenKey(registry.LOCAL_MACHINE, root, registry.ENUMERATE_SUB_KEYS)
+		if err != nil {
+			continue
+		}
+		names, err := key.ReadSubKeyNames(-1)
+		key.Close()
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			sub, err := registry.OpenKey(registry.LOCAL_MACHINE, root+`\`+name, registry.QUERY_VALUE)
+			if err != nil {
+				continue
+			}
+			displayName, _, _ := sub.GetStringValue("DisplayName")
+			pub, _, _ := sub.GetStringValue("Publisher")
+			windowsInstaller, _, _ := sub.GetIntegerValue("WindowsInstaller")
+			uninstall, _, _ := sub.GetStringValue("UninstallString")
+			quiet, _, _ := sub.GetStringValue("QuietUninstallString")
+			sub.Close()
+
+			if !strings.HasPrefix(strings.ToLower(displayName), prefix) {
+				continue
+			}
+			if publisher != "" && !strings.Contains(strings.ToLower(pub), publisher) {
+				continue
+			}
+
+			var argv []string
+			code := msiProductCode.FindString(name)
+			if code == "" {
+				code = msiProductCode.FindString(uninstall)
+			}
+			switch {
+			case code != "" && (windowsInstaller == 1 || strings.Contains(strings.ToLower(uninstall), "msiexec")):
+				argv = []string{"msiexec.exe", "/x", code, "/qn", "/norestart"}
+			case quiet != "":
+				argv, err = windows.DecomposeCommandLine(quiet)
+				if err != nil || len(argv) == 0 {
+					log.Warn("unparseable QuietUninstallString", "product", displayName, "error", err)
+					continue
+				}
+			default:
+				log.Warn("product has no silent uninstaller", "product", displayName)
+				continue
+			}
+			k := strings.ToLower(strings.Join(argv, " "))
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			cmds = append(cmds, argv)
+		}
+	}
+	return cmds, nil
+}
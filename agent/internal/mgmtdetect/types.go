@@ -87,16 +87,30 @@ type Detection struct {
 	Details     any             `json:"details,omitempty"`
 }
 
-// IdentityStatus describes the device's directory/join posture.
+// MDM enrollment types reported in IdentityStatus.MdmEnrollmentType.
+const (
+	MdmEnrollmentAutomated    = "automated"     // Apple ADE (DEP)
+	MdmEnrollmentUserApproved = "user_approved" // macOS user-approved MDM
+	MdmEnrollmentManual       = "manual"
+)
+
+// IdentityStatus describes the device's directory/join posture and the MDM
+// it is enrolled with. MdmAuthority names the MDM service ("Microsoft
+// Intune", "Jamf Pro", ...) or, when unrecognised, the server host.
 type IdentityStatus struct {
-	JoinType        JoinType `json:"joinType"`
-	AzureAdJoined   bool     `json:"azureAdJoined"`
-	DomainJoined    bool     `json:"domainJoined"`
-	WorkplaceJoined bool     `json:"workplaceJoined"`
-	DomainName      string   `json:"domainName,omitempty"`
-	TenantId        string   `json:"tenantId,omitempty"`
-	MdmUrl          string   `json:"mdmUrl,omitempty"`
-	Source          string   `json:"source"`
+	JoinType          JoinType `json:"joinType"`
+	AzureAdJoined     bool     `json:"azureAdJoined"`
+	DomainJoined      bool     `json:"domainJoined"`
+	WorkplaceJoined   bool     `json:"workplaceJoined"`
+	DomainName        string   `json:"domainName,omitempty"`
+	TenantId          string   `json:"tenantId,omitempty"`
+	TenantName        string   `json:"tenantName,omitempty"`
+	DeviceId          string   `json:"deviceId,omitempty"`
+	MdmUrl            string   `json:"mdmUrl,omitempty"`
+	MdmEnrolled       bool     `json:"mdmEnrolled"`
+	MdmAuthority      string   `json:"mdmAuthority,omitempty"`
+	MdmEnrollmentType string   `json:"mdmEnrollmentType,omitempty"`
+	Source            string   `json:"source"`
 }
 
 // ManagementPosture is the top-level result of a posture scan.
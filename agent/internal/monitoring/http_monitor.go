@@ -0,0 +1,346 @@
+package monitoring
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTP(S) availability monitoring for customer-hosted intranet apps. The
+// agent checks the configured URLs from inside the customer network on its
+// own schedule — status code, keyword, certificate expiry and response time —
+// and ships the results to the synthetics endpoint. External uptime monitors
+// cannot reach these URLs at all.
+
+const (
+	minHTTPMonitorIntervalSeconds     = 30
+	defaultHTTPMonitorIntervalSeconds = 300
+	defaultHTTPMonitorTimeoutSeconds  = 10
+	maxHTTPMonitorTimeoutSeconds      = 60
+	defaultCertExpiryWarnDays         = 14
+	maxHTTPMonitors                   = 50
+	// httpMonitorBodyLimit bounds the body read for keyword matching.
+	httpMonitorBodyLimit = 1 << 20
+	// httpMonitorTick is how often the scheduler looks for due checks.
+	httpMonitorTick = 10 * time.Second
+)
+
+// HTTP check statuses, matching the network_http_check command's vocabulary.
+const (
+	HTTPStatusOnline   = "online"
+	HTTPStatusDegraded = "degraded"
+	HTTPStatusOffline  = "offline"
+)
+
+// HTTPMonitorConfig is one configured URL check, delivered via heartbeat
+// configUpdate under "http_monitors" (or "httpMonitors").
+type HTTPMonitorConfig struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name,omitempty"`
+	URL                string `json:"url"`
+	Method             string `json:"method,omitempty"`
+	ExpectedStatus     int    `json:"expected_status,omitempty"`
+	Keyword            string `json:"keyword,omitempty"`
+	IntervalSeconds    int    `json:"interval_seconds,omitempty"`
+	TimeoutSeconds     int    `json:"timeout_seconds,omitempty"`
+	VerifyTLS          *bool  `json:"verify_tls,omitempty"`
+	CertExpiryWarnDays int    `json:"cert_expiry_warn_days,omitempty"`
+	MaxResponseMs      int    `json:"max_response_ms,omitempty"`
+}
+
+// HTTPCheckResult is one check outcome as sent to the synthetics endpoint.
+type HTTPCheckResult struct {
+	MonitorID         string     `json:"monitorId"`
+	Name              string     `json:"name,omitempty"`
+	URL               string     `json:"url"`
+	Status            string     `json:"status"`
+	StatusCode        int        `json:"statusCode,omitempty"`
+	ResponseMs        float64    `json:"responseMs"`
+	KeywordMatched    *bool      `json:"keywordMatched,omitempty"`
+	CertExpiresAt     *time.Time `json:"certExpiresAt,omitempty"`
+	CertDaysRemaining *int       `json:"certDaysRemaining,omitempty"`
+	Error             string     `json:"error,omitempty"`
+	CheckedAt         time.Time  `json:"checkedAt"`
+}
+
+// SendHTTPResultsFunc ships a batch of HTTP check results to the API.
+type SendHTTPResultsFunc func(results []HTTPCheckResult)
+
+// HTTPMonitor schedules the configured URL checks.
+type HTTPMonitor struct {
+	mu          sync.Mutex
+	monitors    []HTTPMonitorConfig
+	nextRun     map[string]time.Time
+	stopCh      chan struct{}
+	running     bool
+	sendResults SendHTTPResultsFunc
+}
+
+// NewHTTPMonitor creates an HTTPMonitor with the given results callback.
+func NewHTTPMonitor(sendResults SendHTTPResultsFunc) *HTTPMonitor {
+	return &HTTPMonitor{
+		nextRun:     make(map[string]time.Time),
+		sendResults: sendResults,
+	}
+}
+
+// ApplyConfig replaces the monitored URLs. Checks that already existed keep
+// their schedule; new ones run on the next scheduler tick. An empty list
+// stops the scheduler.
+func (m *HTTPMonitor) ApplyConfig(monitors []HTTPMonitorConfig) {
+	m.mu.Lock()
+	nextRun := make(map[string]time.Time, len(monitors))
+	for _, mon := range monitors {
+		nextRun[mon.ID] = m.nextRun[mon.ID]
+	}
+	m.monitors = monitors
+	m.nextRun = nextRun
+	m.mu.Unlock()
+
+	if len(monitors) == 0 {
+		m.Stop()
+	} else {
+		m.Start()
+	}
+	log.Info("applied http monitor config", "monitors", len(monitors))
+}
+
+// Start begins the scheduler loop.
+func (m *HTTPMonitor) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running {
+		return
+	}
+	m.stopCh = make(chan struct{})
+	m.running = true
+	go m.loop(m.stopCh)
+}
+
+// Stop halts the scheduler loop.
+func (m *HTTPMonitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.running {
+		return
+	}
+	close(m.stopCh)
+	m.running = false
+}
+
+func (m *HTTPMonitor) loop(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(httpMonitorTick)
+	defer ticker.Stop()
+
+	m.runDue(time.Now())
+	for {
+		select {
+		case <-stopCh:
+			return
+		case now := <-ticker.C:
+			m.runDue(now)
+		}
+	}
+}
+
+// runDue runs every check whose interval has elapsed, concurrently, and
+// sends the results as one batch.
+func (m *HTTPMonitor) runDue(now time.Time) {
+	m.mu.Lock()
+	var due []HTTPMonitorConfig
+	for _, mon := range m.monitors {
+		if now.Before(m.nextRun[mon.ID]) {
+			continue
+		}
+		m.nextRun[mon.ID] = now.Add(time.Duration(mon.IntervalSeconds) * time.Second)
+		due = append(due, mon)
+	}
+	m.mu.Unlock()
+	if len(due) == 0 {
+		return
+	}
+
+	results := make([]HTTPCheckResult, len(due))
+	var wg sync.WaitGroup
+	for i, mon := range due {
+		wg.Add(1)
+		go func(i int, mon HTTPMonitorConfig) {
+			defer wg.Done()
+			results[i] = RunHTTPCheck(context.Background(), mon)
+		}(i, mon)
+	}
+	wg.Wait()
+
+	if m.sendResults != nil {
+		m.sendResults(results)
+	}
+}
+
+// RunHTTPCheck performs one check. Transport failures (DNS, connect, TLS
+// verification, timeout) are offline; a wrong status code, missing keyword,
+// slow response or soon-expiring certificate is degraded.
+func RunHTTPCheck(ctx context.Context, mon HTTPMonitorConfig) HTTPCheckResult {
+	result := HTTPCheckResult{
+		MonitorID: mon.ID,
+		Name:      mon.Name,
+		URL:       mon.URL,
+		Status:    HTTPStatusOnline,
+		CheckedAt: time.Now().UTC(),
+	}
+
+	verifyTLS := mon.VerifyTLS == nil || *mon.VerifyTLS
+	client := &http.Client{
+		Timeout: time.Duration(mon.TimeoutSeconds) * time.Second,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: !verifyTLS},
+			DisableKeepAlives:   true,
+			TLSHandshakeTimeout: time.Duration(mon.TimeoutSeconds) * time.Second,
+		},
+		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("stopped after 5 redirects")
+			}
+			return nil
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, mon.Method, mon.URL, nil)
+	if err != nil {
+		result.Status = HTTPStatusOffline
+		result.Error = fmt.Sprintf("invalid request: %v", err)
+		return result
+	}
+	req.Header.Set("User-Agent", "BreezeRMM-Monitor/1.0")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Status = HTTPStatusOffline
+		result.ResponseMs = elapsedMs(start)
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	var body []byte
+	if mon.Keyword != "" {
+		body, err = io.ReadAll(io.LimitReader(resp.Body, httpMonitorBodyLimit))
+	} else {
+		_, err = io.Copy(io.Discard, io.LimitReader(resp.Body, httpMonitorBodyLimit))
+	}
+	result.ResponseMs = elapsedMs(start)
+	result.StatusCode = resp.StatusCode
+
+	var problems []string
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("failed to read body: %v", err))
+	}
+	if resp.StatusCode != mon.ExpectedStatus {
+		problems = append(problems, fmt.Sprintf("expected status %d, got %d", mon.ExpectedStatus, resp.StatusCode))
+	}
+	if mon.Keyword != "" && err == nil {
+		matched := strings.Contains(string(body), mon.Keyword)
+		result.KeywordMatched = &matched
+		if !matched {
+			problems = append(problems, fmt.Sprintf("keyword %q not found", mon.Keyword))
+		}
+	}
+	if mon.MaxResponseMs > 0 && result.ResponseMs > float64(mon.MaxResponseMs) {
+		problems = append(problems, fmt.Sprintf("response took %.0fms (limit %dms)", result.ResponseMs, mon.MaxResponseMs))
+	}
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		expires := resp.TLS.PeerCertificates[0].NotAfter.UTC()
+		days := int(time.Until(expires).Hours() / 24)
+		result.CertExpiresAt = &expires
+		result.CertDaysRemaining = &days
+		if days < mon.CertExpiryWarnDays {
+			problems = append(problems, fmt.Sprintf("certificate expires in %d days", days))
+		}
+	}
+
+	if len(problems) > 0 {
+		result.Status = HTTPStatusDegraded
+		result.Error = strings.Join(problems, "; ")
+	}
+	return result
+}
+
+func elapsedMs(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000.0
+}
+
+// ParseHTTPMonitorConfigs parses the http_monitors config list, applying
+// defaults and dropping entries without an ID or with a non-HTTP(S) URL. The
+// bool is false only when raw is not a list.
+func ParseHTTPMonitorConfigs(raw any) ([]HTTPMonitorConfig, bool) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, false
+	}
+
+	monitors := make([]HTTPMonitorConfig, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			continue
+		}
+		var mon HTTPMonitorConfig
+		if err := json.Unmarshal(data, &mon); err != nil {
+			log.Warn("skipping invalid http monitor", "error", err.Error())
+			continue
+		}
+		mon.ID = strings.TrimSpace(mon.ID)
+		if mon.ID == "" || seen[mon.ID] {
+			continue
+		}
+		parsed, err := url.Parse(strings.TrimSpace(mon.URL))
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			log.Warn("skipping http monitor with invalid url", "monitorId", mon.ID)
+			continue
+		}
+		mon.URL = parsed.String()
+		normalizeHTTPMonitor(&mon)
+		seen[mon.ID] = true
+		monitors = append(monitors, mon)
+		if len(monitors) >= maxHTTPMonitors {
+			log.Warn("http monitor list truncated", "limit", maxHTTPMonitors)
+			break
+		}
+	}
+	return monitors, true
+}
+
+func normalizeHTTPMonitor(mon *HTTPMonitorConfig) {
+	mon.Method = strings.ToUpper(strings.TrimSpace(mon.Method))
+	if mon.Method != http.MethodHead || mon.Keyword != "" {
+		mon.Method = http.MethodGet
+	}
+	if mon.ExpectedStatus == 0 {
+		mon.ExpectedStatus = http.StatusOK
+	}
+	switch {
+	case mon.IntervalSeconds == 0:
+		mon.IntervalSeconds = defaultHTTPMonitorIntervalSeconds
+	case mon.IntervalSeconds < minHTTPMonitorIntervalSeconds:
+		mon.IntervalSeconds = minHTTPMonitorIntervalSeconds
+	}
+	switch {
+	case mon.TimeoutSeconds <= 0:
+		mon.TimeoutSeconds = defaultHTTPMonitorTimeoutSeconds
+	case mon.TimeoutSeconds > maxHTTPMonitorTimeoutSeconds:
+		mon.TimeoutSeconds = maxHTTPMonitorTimeoutSeconds
+	}
+	if mon.CertExpiryWarnDays == 0 {
+		mon.CertExpiryWarnDays = defaultCertExpiryWarnDays
+	}
+}
@@ -0,0 +1,120 @@
+package monitoring
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseOneHTTPMonitor(t *testing.T, raw map[string]any) HTTPMonitorConfig {
+	t.Helper()
+	monitors, ok := ParseHTTPMonitorConfigs([]any{raw})
+	if !ok || len(monitors) != 1 {
+		t.Fatalf("ParseHTTPMonitorConfigs(%v) = %+v, %v", raw, monitors, ok)
+	}
+	return monitors[0]
+}
+
+func TestParseHTTPMonitorConfigs(t *testing.T) {
+	monitors, ok := ParseHTTPMonitorConfigs([]any{
+		map[string]any{"id": "m1", "url": "https://intranet.local/health", "interval_seconds": float64(5), "method": "head"},
+		map[string]any{"id": "m2", "url": "ftp://files.local/"},      // unsupported scheme
+		map[string]any{"url": "http://no-id.local/"},                 // no id
+		map[string]any{"id": "m1", "url": "http://duplicate.local/"}, // duplicate id
+		map[string]any{"id": "m3", "url": "http://wiki.local/", "method": "HEAD", "keyword": "Welcome"},
+	})
+	if !ok || len(monitors) != 2 {
+		t.Fatalf("got %+v, %v; want 2 monitors", monitors, ok)
+	}
+	m1 := monitors[0]
+	if m1.IntervalSeconds != minHTTPMonitorIntervalSeconds || m1.TimeoutSeconds != defaultHTTPMonitorTimeoutSeconds ||
+		m1.ExpectedStatus != http.StatusOK || m1.Method != http.MethodHead || m1.CertExpiryWarnDays != defaultCertExpiryWarnDays {
+		t.Errorf("defaults not applied: %+v", m1)
+	}
+	if monitors[1].Method != http.MethodGet {
+		t.Errorf("keyword checks need a body, method = %q", monitors[1].Method)
+	}
+
+	if _, ok := ParseHTTPMonitorConfigs(map[string]any{}); ok {
+		t.Error("non-list payload should be rejected")
+	}
+}
+
+func TestRunHTTPCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			_, _ = w.Write([]byte("<h1>Payroll portal</h1>"))
+		default:
+			http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	ok := RunHTTPCheck(context.Background(), parseOneHTTPMonitor(t, map[string]any{"id": "ok", "url": srv.URL + "/ok", "keyword": "Payroll"}))
+	if ok.Status != HTTPStatusOnline || ok.StatusCode != http.StatusOK || ok.KeywordMatched == nil || !*ok.KeywordMatched {
+		t.Errorf("healthy check = %+v", ok)
+	}
+
+	missing := RunHTTPCheck(context.Background(), parseOneHTTPMonitor(t, map[string]any{"id": "kw", "url": srv.URL + "/ok", "keyword": "Timesheets"}))
+	if missing.Status != HTTPStatusDegraded || !strings.Contains(missing.Error, "keyword") {
+		t.Errorf("missing keyword = %+v", missing)
+	}
+
+	bad := RunHTTPCheck(context.Background(), parseOneHTTPMonitor(t, map[string]any{"id": "503", "url": srv.URL + "/down"}))
+	if bad.Status != HTTPStatusDegraded || bad.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("wrong status = %+v", bad)
+	}
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL := closed.URL
+	closed.Close()
+	offline := RunHTTPCheck(context.Background(), parseOneHTTPMonitor(t, map[string]any{"id": "off", "url": closedURL, "timeout_seconds": float64(2)}))
+	if offline.Status != HTTPStatusOffline || offline.Error == "" {
+		t.Errorf("unreachable = %+v", offline)
+	}
+}
+
+func TestRunHTTPCheckCertificate(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// httptest's self-signed certificate fails verification: offline.
+	verified := RunHTTPCheck(context.Background(), parseOneHTTPMonitor(t, map[string]any{"id": "tls", "url": srv.URL}))
+	if verified.Status != HTTPStatusOffline {
+		t.Errorf("untrusted certificate should be offline, got %+v", verified)
+	}
+
+	insecure := parseOneHTTPMonitor(t, map[string]any{"id": "tls", "url": srv.URL, "verify_tls": false})
+	result := RunHTTPCheck(context.Background(), insecure)
+	if result.Status != HTTPStatusOnline || result.CertExpiresAt == nil || result.CertDaysRemaining == nil {
+		t.Fatalf("insecure check = %+v", result)
+	}
+
+	insecure.CertExpiryWarnDays = *result.CertDaysRemaining + 1
+	if warn := RunHTTPCheck(context.Background(), insecure); warn.Status != HTTPStatusDegraded || !strings.Contains(warn.Error, "certificate expires") {
+		t.Errorf("expiring certificate = %+v", warn)
+	}
+}
+
+func TestHTTPMonitorRunDueHonoursInterval(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer srv.Close()
+
+	var batches [][]HTTPCheckResult
+	m := NewHTTPMonitor(func(results []HTTPCheckResult) { batches = append(batches, results) })
+	m.monitors = []HTTPMonitorConfig{parseOneHTTPMonitor(t, map[string]any{"id": "a", "url": srv.URL, "interval_seconds": float64(60)})}
+
+	now := time.Now()
+	m.runDue(now)
+	m.runDue(now.Add(30 * time.Second))
+	m.runDue(now.Add(61 * time.Second))
+	if len(batches) != 2 || batches[0][0].MonitorID != "a" {
+		t.Fatalf("expected 2 batches (t=0, t=61s), got %d: %+v", len(batches), batches)
+	}
+}
@@ -0,0 +1,43 @@
+package patching
+
+import "strings"
+
+// packageAdvisory is the most severe advisory that covers a package. ID is
+// the advisory name, or the CVE list where there is none.
+type packageAdvisory struct {
+	ID       string
+	Security bool
+	Severity string
+}
+
+// normalizeLinuxSeverity maps distro advisory severities onto the patch
+// severities the server knows. Arch's "High"/"Medium" are the equivalents of
+// "important"/"moderate".
+func normalizeLinuxSeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical":
+		return "critical"
+	case "important", "high":
+		return "important"
+	case "moderate", "medium":
+		return "moderate"
+	case "low":
+		return "low"
+	}
+	return "unknown"
+}
+
+// severityRank orders normalized severities so the worst advisory wins.
+func severityRank(severity string) int {
+	switch severity {
+	case "critical":
+		return 4
+	case "important":
+		return 3
+	case "moderate":
+		return 2
+	case "low":
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,632 @@
+package patching
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Third-party application update rings.
+//
+// A policy targets one package from a third-party provider (winget,
+// chocolatey, homebrew) and decides which version, if any, an install may move
+// it to: the newest release ("latest"), a fixed version ("pin"), or the
+// release before the newest one ("n-1"). Blocked versions are never installed,
+// and devices outside the test ring wait RingDelayDays after a version first
+// appears before taking it, so the test ring updates first. Pinned policies are
+// also pushed into the package manager's own pin list so out-of-band upgrades
+// (`winget upgrade --all`, `brew upgrade`) honor them too.
+
+// AppUpdateChannel is the closed channel vocabulary for an app policy.
+type AppUpdateChannel string
+
+const (
+	AppUpdateChannelLatest   AppUpdateChannel = "latest"
+	AppUpdateChannelPin      AppUpdateChannel = "pin"
+	AppUpdateChannelPrevious AppUpdateChannel = "n-1"
+)
+
+// AppUpdateComplianceStatus is the per-app compliance vocabulary reported to
+// the API.
+type AppUpdateComplianceStatus string
+
+const (
+	AppUpdateCompliant    AppUpdateComplianceStatus = "compliant"
+	AppUpdatePending      AppUpdateComplianceStatus = "pending"
+	AppUpdateDeferred     AppUpdateComplianceStatus = "deferred"
+	AppUpdateNonCompliant AppUpdateComplianceStatus = "non_compliant"
+	AppUpdateNotInstalled AppUpdateComplianceStatus = "not_installed"
+)
+
+// validPackageVersion matches version strings safe to pass to a package
+// manager as a --version argument.
+var validPackageVersion = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9.+_\-]{0,63}$`)
+
+// appUpdateMaxObservedVersions bounds the per-app version history kept for
+// n-1 and ring-delay decisions.
+const appUpdateMaxObservedVersions = 10
+
+// AppUpdatePolicy is one per-application update rule delivered by the API.
+type AppUpdatePolicy struct {
+	Provider        string           `json:"provider"`
+	PackageID       string           `json:"packageId"`
+	Channel         AppUpdateChannel `json:"channel"`
+	PinnedVersion   string           `json:"pinnedVersion,omitempty"`
+	BlockedVersions []string         `json:"blockedVersions,omitempty"`
+	TestRing        bool             `json:"testRing,omitempty"`
+	RingDelayDays   int              `json:"ringDelayDays,omitempty"`
+}
+
+func (p AppUpdatePolicy) key() string {
+	return appUpdateKey(p.Provider, p.PackageID)
+}
+
+// isBlocked reports whether version matches a blocked entry. Entries are
+// compared case-insensitively and may use glob wildcards ("1.2.*").
+func (p AppUpdatePolicy) isBlocked(version string) bool {
+	version = strings.ToLower(strings.TrimSpace(version))
+	if version == "" {
+		return false
+	}
+	for _, blocked := range p.BlockedVersions {
+		pattern := strings.ToLower(strings.TrimSpace(blocked))
+		if pattern == version {
+			return true
+		}
+		if ok, err := path.Match(pattern, version); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AppUpdateDecision is the outcome of evaluating a policy for one install.
+// An empty Version with Allowed set means "install whatever the provider
+// offers"; a non-empty Version must be installed explicitly.
+type AppUpdateDecision struct {
+	Allowed bool
+	Version string
+	Reason  string
+	Policy  *AppUpdatePolicy
+}
+
+// AppUpdateCompliance is the per-app compliance row sent to the API.
+type AppUpdateCompliance struct {
+	Provider         string                    `json:"provider"`
+	PackageID        string                    `json:"packageId"`
+	Channel          AppUpdateChannel          `json:"channel"`
+	InstalledVersion string                    `json:"installedVersion,omitempty"`
+	AvailableVersion string                    `json:"availableVersion,omitempty"`
+	TargetVersion    string                    `json:"targetVersion,omitempty"`
+	Status           AppUpdateComplianceStatus `json:"status"`
+	Reason           string                    `json:"reason,omitempty"`
+}
+
+type observedAppVersion struct {
+	Version   string    `json:"version"`
+	FirstSeen time.Time `json:"firstSeen"`
+}
+
+// AppPin is a native package-manager pin applied for a pin-channel policy.
+type AppPin struct {
+	Provider  string `json:"provider"`
+	PackageID string `json:"packageId"`
+	Version   string `json:"version"`
+}
+
+func (p AppPin) key() string {
+	return appUpdateKey(p.Provider, p.PackageID)
+}
+
+type appUpdateState struct {
+	Policies []AppUpdatePolicy               `json:"policies"`
+	Pins     map[string]AppPin               `json:"pins,omitempty"`
+	Versions map[string][]observedAppVersion `json:"versions,omitempty"`
+}
+
+// AppUpdatePolicyStore holds the active app policies plus the version history
+// and native pins they depend on, persisted so ring delays survive restarts.
+type AppUpdatePolicyStore struct {
+	path  string
+	mu    sync.Mutex
+	state appUpdateState
+	// current is the latest scan's available/installed version per governed
+	// app, used to evaluate install commands that carry only a package ID.
+	current map[string]appVersionSnapshot
+}
+
+type appVersionSnapshot struct {
+	available string
+	installed string
+}
+
+// NewAppUpdatePolicyStore loads (or starts) the store backed by path. An
+// empty path keeps the store in memory only.
+func NewAppUpdatePolicyStore(path string) *AppUpdatePolicyStore {
+	s := &AppUpdatePolicyStore{path: path, current: map[string]appVersionSnapshot{}}
+	s.state.Pins = map[string]AppPin{}
+	s.state.Versions = map[string][]observedAppVersion{}
+	if path == "" {
+		return s
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s
+	}
+	var loaded appUpdateState
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return s
+	}
+	s.state.Policies = loaded.Policies
+	for k, v := range loaded.Pins {
+		s.state.Pins[k] = v
+	}
+	for k, v := range loaded.Versions {
+		s.state.Versions[k] = v
+	}
+	return s
+}
+
+// DefaultAppUpdatePolicyPath is the store location under the agent data dir.
+func DefaultAppUpdatePolicyPath(dataDir string) string {
+	if dataDir == "" {
+		return ""
+	}
+	return filepath.Join(dataDir, "app_update_policies.json")
+}
+
+// Policies returns a copy of the active policies.
+func (s *AppUpdatePolicyStore) Policies() []AppUpdatePolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AppUpdatePolicy(nil), s.state.Policies...)
+}
+
+// Replace swaps in a new policy set and drops version history for apps that
+// are no longer governed. Returns true when the set actually changed.
+func (s *AppUpdatePolicyStore) Replace(policies []AppUpdatePolicy) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, _ := json.Marshal(s.state.Policies)
+	after, _ := json.Marshal(policies)
+	if string(before) == string(after) {
+		return false, nil
+	}
+
+	s.state.Policies = append([]AppUpdatePolicy(nil), policies...)
+	governed := make(map[string]bool, len(policies))
+	for _, p := range policies {
+		governed[p.key()] = true
+	}
+	for key := range s.state.Versions {
+		if !governed[key] {
+			delete(s.state.Versions, key)
+		}
+	}
+	return true, s.persistLocked()
+}
+
+// Observe records the available and installed versions of governed apps so
+// n-1 targets and ring delays can be computed from first-seen times.
+func (s *AppUpdatePolicyStore) Observe(available []AvailablePatch, installed []InstalledPatch, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	governed := make(map[string]bool, len(s.state.Policies))
+	for _, p := range s.state.Policies {
+		governed[p.key()] = true
+	}
+	if len(governed) == 0 {
+		return nil
+	}
+
+	current := make(map[string]appVersionSnapshot, len(governed))
+	changed := false
+	record := func(provider, id, version string, isInstalled bool) {
+		key := appUpdateKey(provider, localPatchID(provider, id))
+		version = strings.TrimSpace(version)
+		if !governed[key] || version == "" {
+			return
+		}
+		snap := current[key]
+		if isInstalled {
+			snap.installed = version
+		} else {
+			snap.available = version
+		}
+		current[key] = snap
+		for _, seen := range s.state.Versions[key] {
+			if strings.EqualFold(seen.Version, version) {
+				return
+			}
+		}
+		versions := append(s.state.Versions[key], observedAppVersion{Version: version, FirstSeen: now.UTC()})
+		sort.SliceStable(versions, func(i, j int) bool {
+			return compareVersions(versions[i].Version, versions[j].Version) < 0
+		})
+		if len(versions) > appUpdateMaxObservedVersions {
+			versions = versions[len(versions)-appUpdateMaxObservedVersions:]
+		}
+		s.state.Versions[key] = versions
+		changed = true
+	}
+	for _, patch := range available {
+		record(patch.Provider, patch.ID, patch.Version, false)
+	}
+	for _, patch := range installed {
+		record(patch.Provider, patch.ID, patch.Version, true)
+	}
+	s.current = current
+
+	if !changed {
+		return nil
+	}
+	return s.persistLocked()
+}
+
+// Evaluate decides whether provider/packageID may be updated to
+// availableVersion given what is currently installed.
+func (s *AppUpdatePolicyStore) Evaluate(provider, packageID, availableVersion, installedVersion string, now time.Time) AppUpdateDecision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policy, ok := s.policyLocked(provider, packageID)
+	if !ok {
+		return AppUpdateDecision{Allowed: true}
+	}
+	return evaluateAppUpdatePolicy(policy, s.state.Versions[policy.key()], availableVersion, installedVersion, now)
+}
+
+// EvaluateInstall evaluates an install command for provider/packageID against
+// the versions seen by the most recent Observe.
+func (s *AppUpdatePolicyStore) EvaluateInstall(provider, packageID string, now time.Time) AppUpdateDecision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policy, ok := s.policyLocked(provider, packageID)
+	if !ok {
+		return AppUpdateDecision{Allowed: true}
+	}
+	snap := s.current[policy.key()]
+	return evaluateAppUpdatePolicy(policy, s.state.Versions[policy.key()], snap.available, snap.installed, now)
+}
+
+// IsPinned reports whether provider/packageID currently has a native pin
+// recorded, returning the pin.
+func (s *AppUpdatePolicyStore) IsPinned(provider, packageID string) (AppPin, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pin, ok := s.state.Pins[appUpdateKey(provider, localPatchID(provider, packageID))]
+	return pin, ok
+}
+
+// Compliance reports one row per policy from the latest scan results.
+func (s *AppUpdatePolicyStore) Compliance(available []AvailablePatch, installed []InstalledPatch, now time.Time) []AppUpdateCompliance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows := make([]AppUpdateCompliance, 0, len(s.state.Policies))
+	for _, policy := range s.state.Policies {
+		row := AppUpdateCompliance{
+			Provider:  policy.Provider,
+			PackageID: policy.PackageID,
+			Channel:   policy.Channel,
+		}
+		for _, patch := range installed {
+			if appUpdateKey(patch.Provider, localPatchID(patch.Provider, patch.ID)) == policy.key() {
+				row.InstalledVersion = patch.Version
+				break
+			}
+		}
+		for _, patch := range available {
+			if appUpdateKey(patch.Provider, localPatchID(patch.Provider, patch.ID)) == policy.key() {
+				row.AvailableVersion = patch.Version
+				break
+			}
+		}
+		row.Status, row.TargetVersion, row.Reason = appUpdateComplianceStatus(policy, s.state.Versions[policy.key()], row.AvailableVersion, row.InstalledVersion, now)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// PinChanges returns the native pins to apply and the ones to remove so the
+// package manager's pin list matches the pin-channel policies.
+func (s *AppUpdatePolicyStore) PinChanges() ([]AppPin, []AppPin) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	want := make(map[string]AppPin)
+	for _, p := range s.state.Policies {
+		if p.Channel == AppUpdateChannelPin && p.PinnedVersion != "" {
+			want[p.key()] = AppPin{Provider: p.Provider, PackageID: p.PackageID, Version: p.PinnedVersion}
+		}
+	}
+	var add, remove []AppPin
+	for key, pin := range want {
+		if s.state.Pins[key] != pin {
+			add = append(add, pin)
+		}
+	}
+	for key, pin := range s.state.Pins {
+		if _, ok := want[key]; !ok {
+			remove = append(remove, pin)
+		}
+	}
+	sortPins := func(pins []AppPin) {
+		sort.Slice(pins, func(i, j int) bool { return pins[i].key() < pins[j].key() })
+	}
+	sortPins(add)
+	sortPins(remove)
+	return add, remove
+}
+
+// RecordPin remembers that pin is applied natively; removed forgets it.
+func (s *AppUpdatePolicyStore) RecordPin(pin AppPin, removed bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if removed {
+		delete(s.state.Pins, pin.key())
+	} else {
+		s.state.Pins[pin.key()] = pin
+	}
+	return s.persistLocked()
+}
+
+func (s *AppUpdatePolicyStore) policyLocked(provider, packageID string) (AppUpdatePolicy, bool) {
+	key := appUpdateKey(provider, localPatchID(provider, packageID))
+	for _, p := range s.state.Policies {
+		if p.key() == key {
+			return p, true
+		}
+	}
+	return AppUpdatePolicy{}, false
+}
+
+func (s *AppUpdatePolicyStore) persistLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// evaluateAppUpdatePolicy is the pure decision behind Evaluate.
+func evaluateAppUpdatePolicy(policy AppUpdatePolicy, observed []observedAppVersion, availableVersion, installedVersion string, now time.Time) AppUpdateDecision {
+	decision := AppUpdateDecision{Policy: &policy}
+
+	target := availableVersion
+	switch policy.Channel {
+	case AppUpdateChannelPin:
+		if policy.PinnedVersion == "" {
+			decision.Reason = "app is pinned to its current version"
+			return decision
+		}
+		if strings.EqualFold(installedVersion, policy.PinnedVersion) {
+			decision.Reason = fmt.Sprintf("app is pinned to %s", policy.PinnedVersion)
+			return decision
+		}
+		target = policy.PinnedVersion
+		decision.Version = target
+	case AppUpdateChannelPrevious:
+		target = previousAppVersion(observed, availableVersion)
+		if target == "" {
+			decision.Reason = "n-1 channel: no previous release known yet"
+			return decision
+		}
+		if installedVersion != "" && compareVersions(installedVersion, target) >= 0 {
+			decision.Reason = fmt.Sprintf("n-1 channel: already at %s", installedVersion)
+			return decision
+		}
+		decision.Version = target
+	default:
+		if target == "" {
+			decision.Reason = "no update seen by the last scan"
+			return decision
+		}
+	}
+
+	if policy.isBlocked(target) {
+		decision.Reason = fmt.Sprintf("version %s is blocked", target)
+		return decision
+	}
+
+	if !policy.TestRing && policy.RingDelayDays > 0 {
+		until, ok := ringReleaseTime(policy, observed, target)
+		if !ok {
+			decision.Reason = fmt.Sprintf("deferred: version %s not yet seen by a scan (test ring goes first)", target)
+			return decision
+		}
+		if now.Before(until) {
+			decision.Reason = fmt.Sprintf("deferred until %s (test ring goes first)", until.Format("2006-01-02"))
+			return decision
+		}
+	}
+
+	decision.Allowed = true
+	return decision
+}
+
+// appUpdateComplianceStatus is the pure per-app compliance evaluation.
+func appUpdateComplianceStatus(policy AppUpdatePolicy, observed []observedAppVersion, availableVersion, installedVersion string, now time.Time) (AppUpdateComplianceStatus, string, string) {
+	if installedVersion == "" {
+		return AppUpdateNotInstalled, "", ""
+	}
+	if policy.isBlocked(installedVersion) {
+		return AppUpdateNonCompliant, "", fmt.Sprintf("blocked version %s is installed", installedVersion)
+	}
+
+	switch policy.Channel {
+	case AppUpdateChannelPin:
+		if policy.PinnedVersion == "" || strings.EqualFold(installedVersion, policy.PinnedVersion) {
+			return AppUpdateCompliant, policy.PinnedVersion, ""
+		}
+		return AppUpdateNonCompliant, policy.PinnedVersion, fmt.Sprintf("installed %s, pinned to %s", installedVersion, policy.PinnedVersion)
+	case AppUpdateChannelPrevious:
+		target := previousAppVersion(observed, availableVersion)
+		if target == "" || compareVersions(installedVersion, target) >= 0 {
+			return AppUpdateCompliant, target, ""
+		}
+		return AppUpdatePending, target, fmt.Sprintf("installed %s, n-1 is %s", installedVersion, target)
+	}
+
+	if availableVersion == "" || compareVersions(availableVersion, installedVersion) <= 0 {
+		return AppUpdateCompliant, installedVersion, ""
+	}
+	decision := evaluateAppUpdatePolicy(policy, observed, availableVersion, installedVersion, now)
+	if !decision.Allowed {
+		if policy.isBlocked(availableVersion) {
+			return AppUpdateCompliant, installedVersion, decision.Reason
+		}
+		return AppUpdateDeferred, availableVersion, decision.Reason
+	}
+	return AppUpdatePending, availableVersion, fmt.Sprintf("update to %s available", availableVersion)
+}
+
+// previousAppVersion returns the newest observed version strictly older than
+// the newest known release (the larger of latest and the observed history).
+func previousAppVersion(observed []observedAppVersion, latest string) string {
+	newest := latest
+	for _, v := range observed {
+		if newest == "" || compareVersions(v.Version, newest) > 0 {
+			newest = v.Version
+		}
+	}
+	previous := ""
+	for _, v := range observed {
+		if compareVersions(v.Version, newest) >= 0 {
+			continue
+		}
+		if previous == "" || compareVersions(v.Version, previous) > 0 {
+			previous = v.Version
+		}
+	}
+	return previous
+}
+
+// ringReleaseTime is when a non-test-ring device may take version: its first
+// sighting plus the ring delay. A version no scan has seen yet reports !ok so
+// the caller defers rather than letting production devices go first.
+func ringReleaseTime(policy AppUpdatePolicy, observed []observedAppVersion, version string) (time.Time, bool) {
+	for _, v := range observed {
+		if strings.EqualFold(v.Version, version) {
+			return v.FirstSeen.Add(time.Duration(policy.RingDelayDays) * 24 * time.Hour), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseAppUpdatePolicies converts the app_update_policies config payload into
+// validated policies. Unknown providers, missing package IDs and pin policies
+// without a version are dropped; the bool is false when raw is not a list.
+func ParseAppUpdatePolicies(raw any) ([]AppUpdatePolicy, bool) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, false
+	}
+
+	str := func(m map[string]any, keys ...string) string {
+		for _, k := range keys {
+			if v, ok := m[k].(string); ok {
+				return strings.TrimSpace(v)
+			}
+		}
+		return ""
+	}
+
+	policies := make([]AppUpdatePolicy, 0, len(items))
+	seen := make(map[string]bool)
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		policy := AppUpdatePolicy{
+			Provider:      strings.ToLower(str(m, "provider")),
+			PackageID:     str(m, "packageId", "package_id"),
+			Channel:       normalizeAppUpdateChannel(str(m, "channel")),
+			PinnedVersion: str(m, "pinnedVersion", "pinned_version"),
+		}
+		switch policy.Provider {
+		case "winget", "chocolatey", "homebrew":
+		default:
+			continue
+		}
+		if policy.PackageID == "" || seen[policy.key()] {
+			continue
+		}
+		if policy.Channel == AppUpdateChannelPin && !validPackageVersion.MatchString(policy.PinnedVersion) {
+			continue
+		}
+		if blocked, ok := m["blockedVersions"]; ok {
+			policy.BlockedVersions = stringList(blocked)
+		} else {
+			policy.BlockedVersions = stringList(m["blocked_versions"])
+		}
+		for _, k := range []string{"testRing", "test_ring"} {
+			if v, ok := m[k].(bool); ok {
+				policy.TestRing = v
+			}
+		}
+		for _, k := range []string{"ringDelayDays", "ring_delay_days"} {
+			if v, ok := m[k].(float64); ok && v > 0 {
+				policy.RingDelayDays = int(v)
+			}
+		}
+		seen[policy.key()] = true
+		policies = append(policies, policy)
+	}
+	return policies, true
+}
+
+func normalizeAppUpdateChannel(channel string) AppUpdateChannel {
+	switch strings.ToLower(channel) {
+	case "pin", "pinned":
+		return AppUpdateChannelPin
+	case "n-1", "n1", "n_1", "previous":
+		return AppUpdateChannelPrevious
+	default:
+		return AppUpdateChannelLatest
+	}
+}
+
+func stringList(raw any) []string {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+			out = append(out, strings.TrimSpace(s))
+		}
+	}
+	return out
+}
+
+func appUpdateKey(provider, packageID string) string {
+	return strings.ToLower(provider) + patchIDSeparator + strings.ToLower(packageID)
+}
+
+// localPatchID strips a "provider:" prefix from a decorated patch ID.
+func localPatchID(provider, id string) string {
+	return strings.TrimPrefix(id, provider+patchIDSeparator)
+}
@@ -0,0 +1,160 @@
+package patching
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseAppUpdatePolicies(t *testing.T) {
+	raw := []any{
+		map[string]any{"provider": "winget", "packageId": "Mozilla.Firefox", "channel": "n-1", "blockedVersions": []any{"128.0", " "}, "testRing": true},
+		map[string]any{"provider": "chocolatey", "package_id": "7zip", "channel": "pin", "pinned_version": "23.1.0", "ring_delay_days": float64(3)},
+		map[string]any{"provider": "chocolatey", "packageId": "git", "channel": "pin"},            // pin without version
+		map[string]any{"provider": "apt", "packageId": "curl"},                                    // unsupported provider
+		map[string]any{"provider": "winget", "packageId": "mozilla.firefox", "channel": "latest"}, // duplicate
+		"not-an-object",
+	}
+
+	policies, ok := ParseAppUpdatePolicies(raw)
+	if !ok {
+		t.Fatal("expected list payload to parse")
+	}
+	if len(policies) != 2 {
+		t.Fatalf("got %d policies, want 2: %+v", len(policies), policies)
+	}
+	if p := policies[0]; p.Channel != AppUpdateChannelPrevious || !p.TestRing || len(p.BlockedVersions) != 1 {
+		t.Fatalf("unexpected winget policy: %+v", p)
+	}
+	if p := policies[1]; p.Channel != AppUpdateChannelPin || p.PinnedVersion != "23.1.0" || p.RingDelayDays != 3 {
+		t.Fatalf("unexpected chocolatey policy: %+v", p)
+	}
+
+	if _, ok := ParseAppUpdatePolicies(map[string]any{}); ok {
+		t.Fatal("non-list payload should be rejected")
+	}
+}
+
+func TestEvaluateAppUpdatePolicy(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	observed := []observedAppVersion{
+		{Version: "1.0", FirstSeen: now.Add(-30 * 24 * time.Hour)},
+		{Version: "1.1", FirstSeen: now.Add(-10 * 24 * time.Hour)},
+		{Version: "1.2", FirstSeen: now.Add(-24 * time.Hour)},
+	}
+
+	tests := []struct {
+		name        string
+		policy      AppUpdatePolicy
+		available   string
+		installed   string
+		wantAllowed bool
+		wantVersion string
+	}{
+		{"latest allowed", AppUpdatePolicy{Channel: AppUpdateChannelLatest}, "1.2", "1.0", true, ""},
+		{"latest blocked", AppUpdatePolicy{Channel: AppUpdateChannelLatest, BlockedVersions: []string{"1.2"}}, "1.2", "1.0", false, ""},
+		{"blocked wildcard", AppUpdatePolicy{Channel: AppUpdateChannelLatest, BlockedVersions: []string{"1.*"}}, "1.2", "1.0", false, ""},
+		{"pin installs pinned version", AppUpdatePolicy{Channel: AppUpdateChannelPin, PinnedVersion: "1.1"}, "1.2", "1.0", true, "1.1"},
+		{"pin already satisfied", AppUpdatePolicy{Channel: AppUpdateChannelPin, PinnedVersion: "1.1"}, "1.2", "1.1", false, ""},
+		{"n-1 targets previous release", AppUpdatePolicy{Channel: AppUpdateChannelPrevious}, "1.2", "1.0", true, "1.1"},
+		{"n-1 already there", AppUpdatePolicy{Channel: AppUpdateChannelPrevious}, "1.2", "1.1", false, ""},
+		{"production ring deferred", AppUpdatePolicy{Channel: AppUpdateChannelLatest, RingDelayDays: 7}, "1.2", "1.0", false, ""},
+		{"test ring goes first", AppUpdatePolicy{Channel: AppUpdateChannelLatest, RingDelayDays: 7, TestRing: true}, "1.2", "1.0", true, ""},
+		{"production ring after soak", AppUpdatePolicy{Channel: AppUpdateChannelLatest, RingDelayDays: 7}, "1.1", "1.0", true, ""},
+		{"unseen version deferred", AppUpdatePolicy{Channel: AppUpdateChannelLatest, RingDelayDays: 7}, "2.0", "1.0", false, ""},
+	}
+	for _, tt := range tests {
+		got := evaluateAppUpdatePolicy(tt.policy, observed, tt.available, tt.installed, now)
+		if got.Allowed != tt.wantAllowed || (tt.wantAllowed && got.Version != tt.wantVersion) {
+			t.Errorf("%s: got allowed=%v version=%q reason=%q, want allowed=%v version=%q",
+				tt.name, got.Allowed, got.Version, got.Reason, tt.wantAllowed, tt.wantVersion)
+		}
+		if !got.Allowed && got.Reason == "" {
+			t.Errorf("%s: denied decision has no reason", tt.name)
+		}
+	}
+}
+
+func TestAppUpdatePolicyStoreComplianceAndPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app_update_policies.json")
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	store := NewAppUpdatePolicyStore(path)
+	changed, err := store.Replace([]AppUpdatePolicy{
+		{Provider: "winget", PackageID: "Mozilla.Firefox", Channel: AppUpdateChannelLatest},
+		{Provider: "chocolatey", PackageID: "7zip", Channel: AppUpdateChannelPin, PinnedVersion: "23.1.0"},
+		{Provider: "winget", PackageID: "Git.Git", Channel: AppUpdateChannelLatest, BlockedVersions: []string{"2.40.0"}},
+		{Provider: "homebrew", PackageID: "wget", Channel: AppUpdateChannelLatest},
+	})
+	if err != nil || !changed {
+		t.Fatalf("Replace = %v, %v", changed, err)
+	}
+
+	available := []AvailablePatch{{ID: "winget:Mozilla.Firefox", Provider: "winget", Version: "125.0"}}
+	installed := []InstalledPatch{
+		{ID: "winget:mozilla.firefox", Provider: "winget", Version: "124.0"},
+		{ID: "chocolatey:7zip", Provider: "chocolatey", Version: "24.0.0"},
+		{ID: "winget:Git.Git", Provider: "winget", Version: "2.40.0"},
+	}
+	if err := store.Observe(available, installed, now); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]AppUpdateComplianceStatus{
+		"Mozilla.Firefox": AppUpdatePending,
+		"7zip":            AppUpdateNonCompliant,
+		"Git.Git":         AppUpdateNonCompliant,
+		"wget":            AppUpdateNotInstalled,
+	}
+	rows := store.Compliance(available, installed, now)
+	if len(rows) != len(want) {
+		t.Fatalf("got %d compliance rows, want %d", len(rows), len(want))
+	}
+	for _, row := range rows {
+		if row.Status != want[row.PackageID] {
+			t.Errorf("%s status = %q, want %q (%s)", row.PackageID, row.Status, want[row.PackageID], row.Reason)
+		}
+	}
+
+	if d := store.EvaluateInstall("chocolatey", "chocolatey:7zip", now); !d.Allowed || d.Version != "23.1.0" {
+		t.Fatalf("pin policy should install the pinned version: %+v", d)
+	}
+	if d := store.EvaluateInstall("winget", "Git.Git", now); d.Allowed {
+		t.Fatalf("git has no available update and should not be installable: %+v", d)
+	}
+
+	add, remove := store.PinChanges()
+	if len(add) != 1 || add[0].PackageID != "7zip" || len(remove) != 0 {
+		t.Fatalf("PinChanges = %+v, %+v", add, remove)
+	}
+	if err := store.RecordPin(add[0], false); err != nil {
+		t.Fatal(err)
+	}
+
+	// History and applied pins survive a reload.
+	reloaded := NewAppUpdatePolicyStore(path)
+	if got := len(reloaded.Policies()); got != 4 {
+		t.Fatalf("reloaded %d policies, want 4", got)
+	}
+	if add, _ := reloaded.PinChanges(); len(add) != 0 {
+		t.Fatalf("recorded pin should not be re-applied: %+v", add)
+	}
+	if d := reloaded.Evaluate("winget", "winget:Mozilla.Firefox", "125.0", "124.0", now); !d.Allowed {
+		t.Fatalf("latest policy should allow update: %+v", d)
+	}
+
+	// Dropping the pin policy schedules the native unpin.
+	if _, err := reloaded.Replace(reloaded.Policies()[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, remove := reloaded.PinChanges(); len(remove) != 1 || remove[0].PackageID != "7zip" {
+		t.Fatalf("expected 7zip unpin, got %+v", remove)
+	}
+}
+
+func TestEvaluateWithoutPolicyAllows(t *testing.T) {
+	store := NewAppUpdatePolicyStore("")
+	if d := store.Evaluate("winget", "Some.App", "1.0", "0.9", time.Now()); !d.Allowed || d.Policy != nil {
+		t.Fatalf("ungoverned app should be allowed without a policy: %+v", d)
+	}
+}
@@ -5,12 +5,15 @@ package patching
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/breeze-rmm/agent/internal/throttle"
 )
 
 // AppleSoftwareUpdateProvider integrates with macOS softwareupdate.
@@ -30,8 +33,9 @@ func (p *AppleSoftwareUpdateProvider) Name() string {
 }
 
 func (p *AppleSoftwareUpdateProvider) Scan() ([]AvailablePatch, error) {
+	throttle.Admit(context.Background())
 	cmd := exec.Command("softwareupdate", "-l")
-	output, err := cmd.CombinedOutput()
+	output, err := throttle.CombinedOutput(cmd)
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 2 {
 			return []AvailablePatch{}, nil
@@ -167,8 +171,9 @@ func (p *AppleSoftwareUpdateProvider) Uninstall(patchID string) error {
 }
 
 func (p *AppleSoftwareUpdateProvider) GetInstalled() ([]InstalledPatch, error) {
+	throttle.Admit(context.Background())
 	cmd := exec.Command("system_profiler", "SPInstallHistoryDataType", "-json")
-	output, err := cmd.Output()
+	output, err := throttle.Output(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("system_profiler install history failed: %w", err)
 	}
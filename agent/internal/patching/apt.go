@@ -4,6 +4,7 @@ package patching
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -80,6 +81,26 @@ func (a *AptProvider) Install(patchID string) (InstallResult, error) {
 	}, nil
 }
 
+// Stage downloads a package upgrade into the apt cache without installing
+// it. limitKBps maps to apt's per-connection Dl-Limit.
+func (a *AptProvider) Stage(patchIDs []string, limitKBps int, progress ProgressCallback) ([]DownloadResult, error) {
+	return stageEach(patchIDs, progress, func(patchID string) (string, error) {
+		if err := validateAptPackageName(patchID); err != nil {
+			return "", err
+		}
+		args := []string{"-y", "install", "--only-upgrade", "--download-only"}
+		if limitKBps > 0 {
+			limit := strconv.Itoa(limitKBps)
+			args = append(args, "-o", "Acquire::http::Dl-Limit="+limit, "-o", "Acquire::https::Dl-Limit="+limit)
+		}
+		output, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "apt-get", append(args, patchID)...)
+		if err != nil {
+			return "", fmt.Errorf("apt-get download failed: %w: %s", err, truncatePatchOutput(output))
+		}
+		return truncatePatchOutput(output), nil
+	}), nil
+}
+
 // Uninstall removes a package using apt-get.
 func (a *AptProvider) Uninstall(patchID string) error {
 	if err := validateAptPackageName(patchID); err != nil {
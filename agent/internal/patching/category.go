@@ -50,3 +50,18 @@ func classifyWindowsUpdateCategory(names []string) string {
 
 	return "application"
 }
+
+// classifyWindowsDriverUpdate categorizes a WUA driver update (IUpdate.Type
+// == 2) as "firmware" or "driver". Firmware capsules (UEFI/BIOS, Thunderbolt,
+// dock and SSD firmware) are published as drivers with DriverClass
+// "Firmware", so the category names alone never tell them apart; approving
+// firmware separately matters because a bad flash is far harder to undo.
+func classifyWindowsDriverUpdate(driverClass string, names []string) string {
+	if strings.EqualFold(strings.TrimSpace(driverClass), "firmware") {
+		return "firmware"
+	}
+	if classifyWindowsUpdateCategory(names) == "firmware" {
+		return "firmware"
+	}
+	return "driver"
+}
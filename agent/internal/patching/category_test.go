@@ -37,3 +37,28 @@ func TestClassifyWindowsUpdateCategory(t *testing.T) {
 		})
 	}
 }
+
+func TestClassifyWindowsDriverUpdate(t *testing.T) {
+	tests := []struct {
+		name        string
+		driverClass string
+		names       []string
+		want        string
+	}{
+		{"display driver", "Display", []string{"Drivers", "Intel"}, "driver"},
+		{"firmware driver class", "Firmware", []string{"Drivers", "Dell"}, "firmware"},
+		{"firmware driver class case", "FIRMWARE", nil, "firmware"},
+		{"firmware category name", "", []string{"Drivers", "Firmware"}, "firmware"},
+		// A driver never inherits a quality/security category.
+		{"security category stays driver", "Net", []string{"Security Updates"}, "driver"},
+		{"no metadata", "", nil, "driver"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyWindowsDriverUpdate(tt.driverClass, tt.names); got != tt.want {
+				t.Errorf("classifyWindowsDriverUpdate(%q, %q) = %q, want %q", tt.driverClass, tt.names, got, tt.want)
+			}
+		})
+	}
+}
@@ -84,6 +84,53 @@ func (c *ChocolateyProvider) Install(patchID string) (InstallResult, error) {
 	}, nil
 }
 
+// InstallVersion installs an exact Chocolatey package version (app update pin
+// / n-1 rings), allowing a downgrade when the target is older.
+func (c *ChocolateyProvider) InstallVersion(patchID, version string) (InstallResult, error) {
+	if !validChocoPkgName.MatchString(patchID) {
+		return InstallResult{}, fmt.Errorf("invalid package name: %q", patchID)
+	}
+	if !validPackageVersion.MatchString(version) {
+		return InstallResult{}, fmt.Errorf("invalid package version: %q", version)
+	}
+	output, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "choco", "upgrade", "-y", patchID, "--version="+version, "--allow-downgrade")
+	if err != nil {
+		return InstallResult{}, fmt.Errorf("choco upgrade failed: %w: %s", err, truncatePatchOutput(output))
+	}
+
+	return InstallResult{
+		PatchID: patchID,
+		Message: truncatePatchOutput(output),
+	}, nil
+}
+
+// Pin adds a Chocolatey pin so `choco upgrade all` skips the package.
+func (c *ChocolateyProvider) Pin(packageID, version string) error {
+	if !validChocoPkgName.MatchString(packageID) {
+		return fmt.Errorf("invalid package name: %q", packageID)
+	}
+	if !validPackageVersion.MatchString(version) {
+		return fmt.Errorf("invalid package version: %q", version)
+	}
+	output, err := commandCombinedOutputWithTimeout(patchListTimeout, "choco", "pin", "add", "-n="+packageID, "--version="+version, "-y")
+	if err != nil {
+		return fmt.Errorf("choco pin add failed: %w: %s", err, truncatePatchOutput(output))
+	}
+	return nil
+}
+
+// Unpin removes the Chocolatey pin for packageID.
+func (c *ChocolateyProvider) Unpin(packageID string) error {
+	if !validChocoPkgName.MatchString(packageID) {
+		return fmt.Errorf("invalid package name: %q", packageID)
+	}
+	output, err := commandCombinedOutputWithTimeout(patchListTimeout, "choco", "pin", "remove", "-n="+packageID, "-y")
+	if err != nil {
+		return fmt.Errorf("choco pin remove failed: %w: %s", err, truncatePatchOutput(output))
+	}
+	return nil
+}
+
 // Uninstall removes a Chocolatey package.
 func (c *ChocolateyProvider) Uninstall(patchID string) error {
 	if !validChocoPkgName.MatchString(patchID) {
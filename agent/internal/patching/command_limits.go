@@ -4,11 +4,14 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/breeze-rmm/agent/internal/throttle"
 )
 
 const (
@@ -27,11 +30,12 @@ var validYumPkgName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9+._:@~-]{0,127}$
 var validBrewPkgName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9+._/@-]{0,255}$`)
 
 func commandOutputWithTimeout(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	throttle.Admit(context.Background())
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, name, args...)
-	output, err := cmd.Output()
+	output, err := throttle.Output(cmd)
 	if ctx.Err() != nil {
 		return nil, fmt.Errorf("%s timed out: %w", name, ctx.Err())
 	}
@@ -39,13 +43,14 @@ func commandOutputWithTimeout(timeout time.Duration, name string, args ...string
 }
 
 func runCmdOutputWithTimeout(cmd *exec.Cmd, timeout time.Duration) ([]byte, error) {
+	throttle.Admit(context.Background())
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	timeoutCmd := exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...)
 	timeoutCmd.Env = cmd.Env
 	timeoutCmd.Dir = cmd.Dir
-	output, err := timeoutCmd.Output()
+	output, err := throttle.Output(timeoutCmd)
 	if ctx.Err() != nil {
 		return nil, fmt.Errorf("%s timed out: %w", cmd.Path, ctx.Err())
 	}
@@ -53,11 +58,12 @@ func runCmdOutputWithTimeout(cmd *exec.Cmd, timeout time.Duration) ([]byte, erro
 }
 
 func commandCombinedOutputWithTimeout(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	throttle.Admit(context.Background())
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, name, args...)
-	output, err := cmd.CombinedOutput()
+	output, err := throttle.CombinedOutput(cmd)
 	if ctx.Err() != nil {
 		return nil, fmt.Errorf("%s timed out: %w", name, ctx.Err())
 	}
@@ -65,13 +71,14 @@ func commandCombinedOutputWithTimeout(timeout time.Duration, name string, args .
 }
 
 func runCmdCombinedOutputWithTimeout(cmd *exec.Cmd, timeout time.Duration) ([]byte, error) {
+	throttle.Admit(context.Background())
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	timeoutCmd := exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...)
 	timeoutCmd.Env = cmd.Env
 	timeoutCmd.Dir = cmd.Dir
-	output, err := timeoutCmd.CombinedOutput()
+	output, err := throttle.CombinedOutput(timeoutCmd)
 	if ctx.Err() != nil {
 		return nil, fmt.Errorf("%s timed out: %w", cmd.Path, ctx.Err())
 	}
@@ -84,6 +91,16 @@ func newPatchScanner(output []byte) *bufio.Scanner {
 	return scanner
 }
 
+// commandExitCode returns the exit code of a command that ran and failed,
+// or 0 for nil and for errors that are not an exit status.
+func commandExitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return 0
+}
+
 func truncatePatchOutput(output []byte) string {
 	text := strings.TrimSpace(string(output))
 	if len(text) <= patchOutputLimit {
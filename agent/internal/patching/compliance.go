@@ -0,0 +1,296 @@
+package patching
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Local patch compliance.
+//
+// The API pushes a compliance policy (how many days a critical or important
+// update may stay pending) and the agent evaluates it against every scan, so
+// a device's verdict stays current even when the server-side compliance
+// snapshot runs late. A patch's age counts from its release date when the
+// provider reports one, otherwise from the first scan that found it on this
+// device; first-seen times are persisted so they survive restarts.
+
+// PatchComplianceStatus is the device verdict reported to the API.
+type PatchComplianceStatus string
+
+const (
+	PatchCompliant    PatchComplianceStatus = "compliant"
+	PatchNonCompliant PatchComplianceStatus = "non_compliant"
+)
+
+// patchComplianceMaxViolations bounds the violations listed in one report;
+// the counts still cover every violating patch.
+const patchComplianceMaxViolations = 500
+
+// PatchCompliancePolicy sets the maximum pending age, in days, per severity.
+// Zero leaves a severity unenforced.
+type PatchCompliancePolicy struct {
+	CriticalMaxAgeDays  int `json:"criticalMaxAgeDays,omitempty"`
+	ImportantMaxAgeDays int `json:"importantMaxAgeDays,omitempty"`
+}
+
+// Enabled reports whether the policy enforces any severity.
+func (p PatchCompliancePolicy) Enabled() bool {
+	return p.CriticalMaxAgeDays > 0 || p.ImportantMaxAgeDays > 0
+}
+
+func (p PatchCompliancePolicy) maxAgeDays(severity string) int {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return p.CriticalMaxAgeDays
+	case "important":
+		return p.ImportantMaxAgeDays
+	default:
+		return 0
+	}
+}
+
+// ParsePatchCompliancePolicy reads the patch_compliance_policy config block.
+// A null block clears the policy; anything else that is not an object is
+// rejected.
+func ParsePatchCompliancePolicy(raw any) (PatchCompliancePolicy, bool) {
+	if raw == nil {
+		return PatchCompliancePolicy{}, true
+	}
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return PatchCompliancePolicy{}, false
+	}
+	days := func(keys ...string) int {
+		for _, k := range keys {
+			if v, ok := m[k].(float64); ok && v > 0 && v <= 3650 {
+				return int(v)
+			}
+		}
+		return 0
+	}
+	return PatchCompliancePolicy{
+		CriticalMaxAgeDays:  days("criticalMaxAgeDays", "critical_max_age_days"),
+		ImportantMaxAgeDays: days("importantMaxAgeDays", "important_max_age_days"),
+	}, true
+}
+
+// PatchComplianceViolation is one pending patch older than the policy allows.
+type PatchComplianceViolation struct {
+	PatchID     string    `json:"patchId"`
+	Provider    string    `json:"provider"`
+	Title       string    `json:"title"`
+	Severity    string    `json:"severity"`
+	KBNumber    string    `json:"kbNumber,omitempty"`
+	Since       time.Time `json:"since"`
+	SinceSource string    `json:"sinceSource"` // "release" or "first_seen"
+	AgeDays     int       `json:"ageDays"`
+	MaxAgeDays  int       `json:"maxAgeDays"`
+}
+
+// PatchComplianceReport is the device verdict sent to the API.
+type PatchComplianceReport struct {
+	Status              PatchComplianceStatus      `json:"status"`
+	CriticalMaxAgeDays  int                        `json:"criticalMaxAgeDays"`
+	ImportantMaxAgeDays int                        `json:"importantMaxAgeDays"`
+	PendingCritical     int                        `json:"pendingCritical"`
+	PendingImportant    int                        `json:"pendingImportant"`
+	ViolationCount      int                        `json:"violationCount"`
+	Violations          []PatchComplianceViolation `json:"violations"`
+	// ScanComplete is false when a provider was skipped or failed, so a
+	// "compliant" verdict may be missing that provider's patches.
+	ScanComplete bool      `json:"scanComplete"`
+	EvaluatedAt  time.Time `json:"evaluatedAt"`
+}
+
+type patchComplianceState struct {
+	Policy    PatchCompliancePolicy `json:"policy"`
+	FirstSeen map[string]time.Time  `json:"firstSeen,omitempty"`
+}
+
+// PatchComplianceStore holds the active compliance policy and the first-seen
+// time of every pending patch.
+type PatchComplianceStore struct {
+	path  string
+	mu    sync.Mutex
+	state patchComplianceState
+}
+
+// NewPatchComplianceStore loads (or starts) the store backed by path. An
+// empty path keeps the store in memory only.
+func NewPatchComplianceStore(path string) *PatchComplianceStore {
+	s := &PatchComplianceStore{path: path}
+	s.state.FirstSeen = map[string]time.Time{}
+	if path == "" {
+		return s
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s
+	}
+	var loaded patchComplianceState
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return s
+	}
+	s.state.Policy = loaded.Policy
+	for k, v := range loaded.FirstSeen {
+		s.state.FirstSeen[k] = v
+	}
+	return s
+}
+
+// DefaultPatchCompliancePath is the store location under the agent data dir.
+func DefaultPatchCompliancePath(dataDir string) string {
+	if dataDir == "" {
+		return ""
+	}
+	return filepath.Join(dataDir, "patch_compliance.json")
+}
+
+// Policy returns the active policy.
+func (s *PatchComplianceStore) Policy() PatchCompliancePolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.Policy
+}
+
+// SetPolicy replaces the policy. Returns true when it actually changed.
+func (s *PatchComplianceStore) SetPolicy(policy PatchCompliancePolicy) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state.Policy == policy {
+		return false, nil
+	}
+	s.state.Policy = policy
+	return true, s.persistLocked()
+}
+
+// Evaluate records first-seen times for the scan's pending patches and
+// returns the device verdict. coveredProviders lists the providers that
+// actually scanned: first-seen times are only dropped for patches those
+// providers no longer report, so a skipped provider does not reset its
+// patches' ages.
+func (s *PatchComplianceStore) Evaluate(available []AvailablePatch, providerIDs, coveredProviders []string, now time.Time) (PatchComplianceReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now = now.UTC()
+	covered := make(map[string]bool, len(coveredProviders))
+	for _, id := range coveredProviders {
+		covered[id] = true
+	}
+	report := PatchComplianceReport{
+		Status:              PatchCompliant,
+		CriticalMaxAgeDays:  s.state.Policy.CriticalMaxAgeDays,
+		ImportantMaxAgeDays: s.state.Policy.ImportantMaxAgeDays,
+		Violations:          []PatchComplianceViolation{},
+		ScanComplete:        len(coveredProviders) >= len(providerIDs),
+		EvaluatedAt:         now,
+	}
+
+	changed := false
+	pending := make(map[string]bool, len(available))
+	for _, patch := range available {
+		key := patch.Provider + patchIDSeparator + localPatchID(patch.Provider, patch.ID)
+		pending[key] = true
+		firstSeen, ok := s.state.FirstSeen[key]
+		if !ok {
+			firstSeen = now
+			s.state.FirstSeen[key] = now
+			changed = true
+		}
+
+		severity := strings.ToLower(patch.Severity)
+		switch severity {
+		case "critical":
+			report.PendingCritical++
+		case "important":
+			report.PendingImportant++
+		}
+		maxAge := s.state.Policy.maxAgeDays(severity)
+		if maxAge == 0 {
+			continue
+		}
+
+		since, source := firstSeen, "first_seen"
+		if released, ok := parsePatchReleaseDate(patch.ReleaseDate); ok && released.Before(firstSeen) {
+			since, source = released, "release"
+		}
+		age := int(math.Floor(now.Sub(since).Hours() / 24))
+		if age <= maxAge {
+			continue
+		}
+		report.ViolationCount++
+		if len(report.Violations) < patchComplianceMaxViolations {
+			report.Violations = append(report.Violations, PatchComplianceViolation{
+				PatchID:     key,
+				Provider:    patch.Provider,
+				Title:       patch.Title,
+				Severity:    severity,
+				KBNumber:    patch.KBNumber,
+				Since:       since,
+				SinceSource: source,
+				AgeDays:     age,
+				MaxAgeDays:  maxAge,
+			})
+		}
+	}
+
+	for key := range s.state.FirstSeen {
+		provider, _, _ := strings.Cut(key, patchIDSeparator)
+		if covered[provider] && !pending[key] {
+			delete(s.state.FirstSeen, key)
+			changed = true
+		}
+	}
+
+	if report.ViolationCount > 0 {
+		report.Status = PatchNonCompliant
+	}
+	sort.SliceStable(report.Violations, func(i, j int) bool {
+		return report.Violations[i].AgeDays > report.Violations[j].AgeDays
+	})
+
+	if !changed {
+		return report, nil
+	}
+	return report, s.persistLocked()
+}
+
+// parsePatchReleaseDate accepts the RFC 3339 timestamps and bare dates
+// providers report.
+func parsePatchReleaseDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (s *PatchComplianceStore) persistLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,100 @@
+package patching
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParsePatchCompliancePolicy(t *testing.T) {
+	policy, ok := ParsePatchCompliancePolicy(map[string]any{"criticalMaxAgeDays": float64(14), "important_max_age_days": float64(30)})
+	if !ok || policy != (PatchCompliancePolicy{CriticalMaxAgeDays: 14, ImportantMaxAgeDays: 30}) {
+		t.Fatalf("unexpected policy %+v (%v)", policy, ok)
+	}
+	if policy, ok := ParsePatchCompliancePolicy(nil); !ok || policy.Enabled() {
+		t.Fatalf("expected null to clear the policy, got %+v", policy)
+	}
+	if _, ok := ParsePatchCompliancePolicy([]any{}); ok {
+		t.Fatal("expected a non-object to be rejected")
+	}
+}
+
+func TestPatchComplianceEvaluateFlagsOverdueSeverities(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	store := NewPatchComplianceStore("")
+	if _, err := store.SetPolicy(PatchCompliancePolicy{CriticalMaxAgeDays: 14, ImportantMaxAgeDays: 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	available := []AvailablePatch{
+		{ID: "windows-update:kb1", Provider: "windows-update", Title: "KB1", Severity: "critical", KBNumber: "KB1", ReleaseDate: "2026-09-01"},
+		{ID: "windows-update:kb2", Provider: "windows-update", Title: "KB2", Severity: "important", ReleaseDate: "2026-10-01T00:00:00Z"},
+		{ID: "apt:openssl", Provider: "apt", Title: "openssl", Severity: "moderate", ReleaseDate: "2025-01-01"},
+	}
+	report, err := store.Evaluate(available, []string{"windows-update", "apt"}, []string{"windows-update", "apt"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Status != PatchNonCompliant || report.ViolationCount != 1 || report.PendingCritical != 1 || report.PendingImportant != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	v := report.Violations[0]
+	if v.PatchID != "windows-update:kb1" || v.SinceSource != "release" || v.AgeDays != 47 || v.MaxAgeDays != 14 {
+		t.Fatalf("unexpected violation %+v", v)
+	}
+	if !report.ScanComplete {
+		t.Fatal("expected a complete scan")
+	}
+}
+
+func TestPatchComplianceAgesUndatedPatchesFromFirstSeen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patch_compliance.json")
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	store := NewPatchComplianceStore(path)
+	if _, err := store.SetPolicy(PatchCompliancePolicy{CriticalMaxAgeDays: 7}); err != nil {
+		t.Fatal(err)
+	}
+	undated := []AvailablePatch{{ID: "winget:Vendor.App", Provider: "winget", Severity: "critical"}}
+
+	if report, _ := store.Evaluate(undated, []string{"winget"}, []string{"winget"}, start); report.Status != PatchCompliant {
+		t.Fatalf("expected a new patch to be compliant, got %+v", report)
+	}
+
+	// A restarted agent keeps the first-seen time.
+	reloaded := NewPatchComplianceStore(path)
+	report, err := reloaded.Evaluate(undated, []string{"winget"}, []string{"winget"}, start.Add(8*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != PatchNonCompliant || report.Violations[0].SinceSource != "first_seen" || report.Violations[0].AgeDays != 8 {
+		t.Fatalf("expected the first-seen age to violate, got %+v", report)
+	}
+}
+
+func TestPatchComplianceKeepsFirstSeenForSkippedProviders(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	store := NewPatchComplianceStore("")
+	if _, err := store.SetPolicy(PatchCompliancePolicy{CriticalMaxAgeDays: 7}); err != nil {
+		t.Fatal(err)
+	}
+	patch := []AvailablePatch{{ID: "winget:Vendor.App", Provider: "winget", Severity: "critical"}}
+	store.Evaluate(patch, []string{"winget"}, []string{"winget"}, start)
+
+	// winget skipped: its first-seen time must survive the empty scan.
+	report, _ := store.Evaluate(nil, []string{"winget"}, nil, start.Add(24*time.Hour))
+	if report.ScanComplete {
+		t.Fatal("expected an incomplete scan")
+	}
+	report, _ = store.Evaluate(patch, []string{"winget"}, []string{"winget"}, start.Add(10*24*time.Hour))
+	if report.ViolationCount != 1 {
+		t.Fatalf("expected the age to count from the first scan, got %+v", report)
+	}
+
+	// winget scanned and the patch is gone: the age resets.
+	store.Evaluate(nil, []string{"winget"}, []string{"winget"}, start.Add(11*24*time.Hour))
+	report, _ = store.Evaluate(patch, []string{"winget"}, []string{"winget"}, start.Add(12*24*time.Hour))
+	if report.ViolationCount != 0 {
+		t.Fatalf("expected a reappearing patch to start a new age, got %+v", report)
+	}
+}
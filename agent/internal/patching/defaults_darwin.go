@@ -18,6 +18,9 @@ func NewDefaultManager(_ *config.Config) *PatchManager {
 	if _, err := brewBinaryPath(); err == nil {
 		providers = append(providers, NewHomebrewProvider())
 	}
+	if _, err := masBinaryPath(); err == nil {
+		providers = append(providers, NewMasProvider())
+	}
 
-	return NewPatchManager(providers...)
+	return withMaintenanceState(NewPatchManager(providers...))
 }
@@ -16,10 +16,22 @@ func NewDefaultManager(_ *config.Config) *PatchManager {
 		providers = append(providers, NewAptProvider())
 	}
 	if _, err := exec.LookPath("dnf"); err == nil {
-		providers = append(providers, NewYumProvider())
+		providers = append(providers, NewDnfProvider())
 	} else if _, err := exec.LookPath("yum"); err == nil {
 		providers = append(providers, NewYumProvider())
 	}
+	if _, err := exec.LookPath("zypper"); err == nil {
+		providers = append(providers, NewZypperProvider())
+	}
+	if _, err := exec.LookPath("pacman"); err == nil {
+		providers = append(providers, NewPacmanProvider())
+	}
+	if _, err := exec.LookPath("snap"); err == nil {
+		providers = append(providers, NewSnapProvider())
+	}
+	if _, err := exec.LookPath("flatpak"); err == nil {
+		providers = append(providers, NewFlatpakProvider())
+	}
 
-	return NewPatchManager(providers...)
+	return withMaintenanceState(NewPatchManager(providers...))
 }
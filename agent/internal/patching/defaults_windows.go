@@ -11,10 +11,13 @@ import (
 // NewDefaultManager creates a patch manager with providers available on Windows.
 func NewDefaultManager(cfg *config.Config) *PatchManager {
 	providers := []PatchProvider{NewWindowsUpdateProvider(cfg)}
+	if cfg == nil || !cfg.PatchExcludeDrivers {
+		providers = append(providers, NewWindowsDriverProvider(cfg))
+	}
 
 	if _, err := exec.LookPath("choco"); err == nil {
 		providers = append(providers, NewChocolateyProvider())
 	}
 
-	return NewPatchManager(providers...)
+	return withMaintenanceState(NewPatchManager(providers...))
 }
@@ -0,0 +1,187 @@
+//go:build linux
+
+package patching
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DnfProvider integrates with dnf (Fedora, RHEL 8+ and derivatives) and
+// grades updates from the repositories' updateinfo advisories.
+type DnfProvider struct{}
+
+// NewDnfProvider creates a new DnfProvider.
+func NewDnfProvider() *DnfProvider {
+	return &DnfProvider{}
+}
+
+// ID returns the provider identifier.
+func (d *DnfProvider) ID() string {
+	return "dnf"
+}
+
+// Name returns the human-readable provider name.
+func (d *DnfProvider) Name() string {
+	return "DNF"
+}
+
+// Scan returns available upgrades, marking those covered by a security
+// advisory with its severity.
+func (d *DnfProvider) Scan() ([]AvailablePatch, error) {
+	output, runErr := commandCombinedOutputWithTimeout(patchScanTimeout, "dnf", "check-update", "-q")
+	if runErr != nil {
+		// dnf returns exit code 100 when updates are available.
+		if commandExitCode(runErr) != 100 {
+			return nil, fmt.Errorf("dnf check-update failed: %w", runErr)
+		}
+	}
+
+	patches, err := parseRpmCheckUpdate(output)
+	if err != nil {
+		return nil, fmt.Errorf("dnf check-update parse failed: %w", err)
+	}
+
+	// Advisory metadata only grades the list; a repository without
+	// updateinfo (or a failing query) leaves severities unknown.
+	infoOutput, infoErr := commandOutputWithTimeout(patchScanTimeout, "dnf", "-q", "updateinfo", "list", "--updates")
+	if infoErr != nil {
+		log.Debug("dnf updateinfo unavailable, severities unknown", "error", infoErr)
+		return patches, nil
+	}
+	advisories := parseDnfUpdateInfo(infoOutput)
+	for i := range patches {
+		adv, ok := advisories[patches[i].ID]
+		if !ok {
+			continue
+		}
+		patches[i].Description = truncatePatchDescription(adv.ID)
+		if adv.Security {
+			patches[i].Category = "security"
+			patches[i].Severity = adv.Severity
+		}
+	}
+	return patches, nil
+}
+
+// Install upgrades a package with dnf.
+func (d *DnfProvider) Install(patchID string) (InstallResult, error) {
+	if err := validateYumPackageName(patchID); err != nil {
+		return InstallResult{}, err
+	}
+
+	output, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "dnf", "-y", "upgrade", patchID)
+	if err != nil {
+		return InstallResult{}, fmt.Errorf("dnf upgrade failed: %w: %s", err, truncatePatchOutput(output))
+	}
+
+	return InstallResult{
+		PatchID:        patchID,
+		RebootRequired: dnfNeedsReboot(),
+		Message:        truncatePatchOutput(output),
+	}, nil
+}
+
+// Stage downloads a package upgrade into the dnf cache without installing
+// it. limitKBps maps to dnf's throttle option.
+func (d *DnfProvider) Stage(patchIDs []string, limitKBps int, progress ProgressCallback) ([]DownloadResult, error) {
+	return stageEach(patchIDs, progress, func(patchID string) (string, error) {
+		if err := validateYumPackageName(patchID); err != nil {
+			return "", err
+		}
+		output, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "dnf", rpmStageArgs(patchID, limitKBps)...)
+		if err != nil {
+			return "", fmt.Errorf("dnf download failed: %w: %s", err, truncatePatchOutput(output))
+		}
+		return truncatePatchOutput(output), nil
+	}), nil
+}
+
+// Uninstall removes a package with dnf.
+func (d *DnfProvider) Uninstall(patchID string) error {
+	if err := validateYumPackageName(patchID); err != nil {
+		return err
+	}
+
+	output, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "dnf", "-y", "remove", patchID)
+	if err != nil {
+		return fmt.Errorf("dnf remove failed: %w: %s", err, truncatePatchOutput(output))
+	}
+
+	return nil
+}
+
+// GetInstalled returns installed packages from the rpm database.
+func (d *DnfProvider) GetInstalled() ([]InstalledPatch, error) {
+	return rpmInstalledPackages()
+}
+
+// dnfNeedsReboot asks `dnf needs-restarting -r` (dnf-plugins-core) whether
+// the update touched the kernel or core libraries. Exit 1 means a reboot is
+// needed; the plugin being absent reports no reboot.
+func dnfNeedsReboot() bool {
+	_, err := commandCombinedOutputWithTimeout(patchListTimeout, "dnf", "needs-restarting", "-r")
+	return commandExitCode(err) == 1
+}
+
+// parseDnfUpdateInfo maps package names to their most severe pending
+// advisory. It reads both the dnf4 layout
+//
+//	RHSA-2024:1234 Important/Sec. openssl-1:3.0.7-27.el9.x86_64
+//	FEDORA-2024-0a1b2c bugfix      curl-8.6.0-7.fc40.x86_64
+//
+// and the dnf5 layout, which has separate type and severity columns:
+//
+//	FEDORA-2024-0a1b2c security Moderate openssl-1:3.2.2-3.fc41.x86_64 2024-06-01 12:00:00
+func parseDnfUpdateInfo(output []byte) map[string]packageAdvisory {
+	advisories := map[string]packageAdvisory{}
+	scanner := newPatchScanner(output)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] == "Name" {
+			continue
+		}
+
+		adv := packageAdvisory{ID: fields[0]}
+		nevra := fields[2]
+		switch {
+		case strings.HasSuffix(fields[1], "/Sec."):
+			adv.Security = true
+			adv.Severity = normalizeLinuxSeverity(strings.TrimSuffix(fields[1], "/Sec."))
+		case len(fields) >= 4 && isDnfSeverityColumn(fields[2]):
+			adv.Security = fields[1] == "security"
+			if adv.Security {
+				adv.Severity = normalizeLinuxSeverity(fields[2])
+			}
+			nevra = fields[3]
+		default:
+			adv.Security = fields[1] == "security"
+			if adv.Security {
+				adv.Severity = "unknown"
+			}
+		}
+
+		name := rpmNameFromNEVRA(nevra)
+		if name == "" {
+			continue
+		}
+		if prev, ok := advisories[name]; ok {
+			if prev.Security && !adv.Security {
+				continue
+			}
+			if prev.Security == adv.Security && severityRank(prev.Severity) >= severityRank(adv.Severity) {
+				continue
+			}
+		}
+		advisories[name] = adv
+	}
+	return advisories
+}
+
+func isDnfSeverityColumn(value string) bool {
+	switch strings.ToLower(value) {
+	case "none", "critical", "important", "moderate", "low", "unknown":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+//go:build linux
+
+package patching
+
+import "testing"
+
+func TestParseDnfUpdateInfoDnf4(t *testing.T) {
+	output := []byte(`RHSA-2024:1234 Important/Sec. openssl-1:3.0.7-27.el9.x86_64
+RHSA-2024:1300 Moderate/Sec.  openssl-libs-1:3.0.7-27.el9.x86_64
+RHBA-2024:1111 bugfix         curl-7.76.1-29.el9.x86_64
+RHSA-2024:0999 Low/Sec.       openssl-1:3.0.7-26.el9.x86_64
+`)
+	advisories := parseDnfUpdateInfo(output)
+
+	openssl := advisories["openssl"]
+	if !openssl.Security || openssl.Severity != "important" || openssl.ID != "RHSA-2024:1234" {
+		t.Fatalf("openssl should keep the most severe advisory, got %+v", openssl)
+	}
+	if libs := advisories["openssl-libs"]; libs.Severity != "moderate" {
+		t.Fatalf("expected openssl-libs moderate, got %+v", libs)
+	}
+	if curl := advisories["curl"]; curl.Security || curl.ID != "RHBA-2024:1111" {
+		t.Fatalf("expected curl bugfix advisory, got %+v", curl)
+	}
+}
+
+func TestParseDnfUpdateInfoDnf5(t *testing.T) {
+	output := []byte(`Name               Type     Severity Package                         Issued
+FEDORA-2024-0a1b2c security Moderate openssl-1:3.2.2-3.fc41.x86_64  2024-06-01 12:00:00
+FEDORA-2024-ffee00 bugfix   None     kernel-6.9.7-200.fc41.x86_64   2024-06-02 12:00:00
+`)
+	advisories := parseDnfUpdateInfo(output)
+
+	if openssl := advisories["openssl"]; !openssl.Security || openssl.Severity != "moderate" {
+		t.Fatalf("expected openssl moderate security, got %+v", openssl)
+	}
+	if kernel := advisories["kernel"]; kernel.Security || kernel.Severity != "" {
+		t.Fatalf("expected kernel bugfix without severity, got %+v", kernel)
+	}
+	if len(advisories) != 2 {
+		t.Fatalf("expected header to be skipped, got %d advisories", len(advisories))
+	}
+}
+
+func TestRpmNameFromNEVRA(t *testing.T) {
+	cases := map[string]string{
+		"openssl-1:3.0.7-27.el9.x86_64":      "openssl",
+		"python3-libs-3.9.18-3.el9.x86_64":   "python3-libs",
+		"kernel-core-5.14.0-427.el9.aarch64": "kernel-core",
+		"broken":                             "",
+	}
+	for nevra, want := range cases {
+		if got := rpmNameFromNEVRA(nevra); got != want {
+			t.Errorf("rpmNameFromNEVRA(%q) = %q, want %q", nevra, got, want)
+		}
+	}
+}
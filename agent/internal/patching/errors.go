@@ -6,7 +6,7 @@ import (
 )
 
 // ErrScanSkipped is a sentinel a provider's Scan returns when the provider
-// could not run at all (e.g. winget with no connected user helper session).
+// could not run at all (e.g. winget that could not be resolved yet).
 // Skipped is distinct from "scanned and found nothing": the server must not
 // tombstone a skipped provider's previously reported patches, so skipped
 // providers are excluded from scan coverage (see PatchManager.ScanWithCoverage).
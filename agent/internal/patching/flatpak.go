@@ -0,0 +1,140 @@
+//go:build linux
+
+package patching
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// validFlatpakRef matches a full installed ref, kind/name/arch/branch.
+var validFlatpakRef = regexp.MustCompile(`^(app|runtime)/[A-Za-z][A-Za-z0-9_.-]{0,254}/[A-Za-z0-9_]{1,32}/[A-Za-z0-9][A-Za-z0-9_.-]{0,127}$`)
+
+// FlatpakProvider integrates with the system-wide Flatpak installation.
+// Patch IDs are full refs (for example app/org.mozilla.firefox/x86_64/stable)
+// because one runtime can be installed on several branches. Per-user
+// installations under a user's home directory are not managed.
+type FlatpakProvider struct{}
+
+// NewFlatpakProvider creates a new FlatpakProvider.
+func NewFlatpakProvider() *FlatpakProvider {
+	return &FlatpakProvider{}
+}
+
+// ID returns the provider identifier.
+func (f *FlatpakProvider) ID() string {
+	return "flatpak"
+}
+
+// Name returns the human-readable provider name.
+func (f *FlatpakProvider) Name() string {
+	return "Flatpak"
+}
+
+// Scan returns installed apps and runtimes with an update on their remote.
+func (f *FlatpakProvider) Scan() ([]AvailablePatch, error) {
+	output, err := commandOutputWithTimeout(patchScanTimeout, "flatpak", "remote-ls", "--system", "--updates", "--columns=ref,application,version")
+	if err != nil {
+		return nil, fmt.Errorf("flatpak remote-ls failed: %w", err)
+	}
+
+	patches, err := parseFlatpakRefs(output, func(ref, title, version string) AvailablePatch {
+		return AvailablePatch{
+			ID:      truncatePatchField(ref),
+			Title:   truncatePatchField(title),
+			Version: truncatePatchField(version),
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("flatpak remote-ls parse failed: %w", err)
+	}
+	return patches, nil
+}
+
+// Install updates one app or runtime.
+func (f *FlatpakProvider) Install(patchID string) (InstallResult, error) {
+	if err := validateFlatpakRef(patchID); err != nil {
+		return InstallResult{}, err
+	}
+
+	output, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "flatpak", "update", "--system", "--noninteractive", "-y", patchID)
+	if err != nil {
+		return InstallResult{}, fmt.Errorf("flatpak update failed: %w: %s", err, truncatePatchOutput(output))
+	}
+
+	return InstallResult{
+		PatchID: patchID,
+		Message: truncatePatchOutput(output),
+	}, nil
+}
+
+// Uninstall removes an app or runtime.
+func (f *FlatpakProvider) Uninstall(patchID string) error {
+	if err := validateFlatpakRef(patchID); err != nil {
+		return err
+	}
+
+	output, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "flatpak", "uninstall", "--system", "--noninteractive", "-y", patchID)
+	if err != nil {
+		return fmt.Errorf("flatpak uninstall failed: %w: %s", err, truncatePatchOutput(output))
+	}
+	return nil
+}
+
+// GetInstalled returns installed apps and runtimes using flatpak list.
+func (f *FlatpakProvider) GetInstalled() ([]InstalledPatch, error) {
+	output, err := commandOutputWithTimeout(patchListTimeout, "flatpak", "list", "--system", "--columns=ref,application,version")
+	if err != nil {
+		return nil, fmt.Errorf("flatpak list failed: %w", err)
+	}
+
+	installed, err := parseFlatpakRefs(output, func(ref, title, version string) InstalledPatch {
+		return InstalledPatch{
+			ID:      truncatePatchField(ref),
+			Title:   truncatePatchField(title),
+			Version: truncatePatchField(version),
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("flatpak list parse failed: %w", err)
+	}
+	return installed, nil
+}
+
+// parseFlatpakRefs reads tab-separated "ref application version" rows. Many
+// runtimes publish no version, so the branch from the ref stands in.
+func parseFlatpakRefs[T any](output []byte, build func(ref, title, version string) T) ([]T, error) {
+	scanner := newPatchScanner(output)
+	items := []T{}
+	for scanner.Scan() {
+		cols := strings.Split(strings.TrimRight(scanner.Text(), "\r"), "\t")
+		ref := strings.TrimSpace(cols[0])
+		if validateFlatpakRef(ref) != nil {
+			continue
+		}
+		parts := strings.Split(ref, "/")
+		title, version := parts[1], parts[3]
+		if len(cols) > 1 && strings.TrimSpace(cols[1]) != "" {
+			title = strings.TrimSpace(cols[1])
+		}
+		if len(cols) > 2 && strings.TrimSpace(cols[2]) != "" {
+			version = strings.TrimSpace(cols[2])
+		}
+		items = append(items, build(ref, title, version))
+		if len(items) >= patchResultItemLimit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func validateFlatpakRef(ref string) error {
+	if !validFlatpakRef.MatchString(ref) {
+		return fmt.Errorf("invalid flatpak ref: %q", ref)
+	}
+	return nil
+}
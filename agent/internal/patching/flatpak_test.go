@@ -0,0 +1,37 @@
+//go:build linux
+
+package patching
+
+import "testing"
+
+func TestParseFlatpakRefs(t *testing.T) {
+	output := []byte("app/org.mozilla.firefox/x86_64/stable\torg.mozilla.firefox\t128.0\n" +
+		"runtime/org.freedesktop.Platform/x86_64/23.08\torg.freedesktop.Platform\t\n" +
+		"not a ref\tjunk\t1.0\n")
+	patches, err := parseFlatpakRefs(output, func(ref, title, version string) AvailablePatch {
+		return AvailablePatch{ID: ref, Title: title, Version: version}
+	})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("expected 2 refs, got %d", len(patches))
+	}
+	if p := patches[0]; p.ID != "app/org.mozilla.firefox/x86_64/stable" || p.Title != "org.mozilla.firefox" || p.Version != "128.0" {
+		t.Fatalf("unexpected app %+v", p)
+	}
+	if p := patches[1]; p.Version != "23.08" {
+		t.Fatalf("runtime without a version should fall back to its branch, got %+v", p)
+	}
+}
+
+func TestValidateFlatpakRef(t *testing.T) {
+	if err := validateFlatpakRef("app/org.gimp.GIMP/x86_64/stable"); err != nil {
+		t.Fatalf("expected valid ref: %v", err)
+	}
+	for _, ref := range []string{"", "org.gimp.GIMP", "app/org.gimp.GIMP/x86_64", "app/../x86_64/stable", "extension/a/b/c", "app/a b/x86_64/stable"} {
+		if err := validateFlatpakRef(ref); err == nil {
+			t.Errorf("expected %q to be rejected", ref)
+		}
+	}
+}
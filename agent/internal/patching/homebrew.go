@@ -230,6 +230,33 @@ func (h *HomebrewProvider) Uninstall(patchID string) error {
 	return nil
 }
 
+// Pin holds a Homebrew formula at its installed version. Homebrew pins the
+// current version only and has no cask pins, so version is informational and
+// casks return an error (the agent still enforces their policy on install).
+func (h *HomebrewProvider) Pin(packageID, version string) error {
+	return h.pinCommand("pin", packageID)
+}
+
+// Unpin releases a Homebrew formula pin.
+func (h *HomebrewProvider) Unpin(packageID string) error {
+	return h.pinCommand("unpin", packageID)
+}
+
+func (h *HomebrewProvider) pinCommand(op, packageID string) error {
+	name, isCask := parseBrewID(packageID)
+	if err := validateBrewPackageName(name); err != nil {
+		return err
+	}
+	if isCask {
+		return fmt.Errorf("homebrew cannot %s casks", op)
+	}
+	output, err := h.brewCombinedOutput(patchListTimeout, op, name)
+	if err != nil {
+		return fmt.Errorf("brew %s failed: %w: %s", op, err, truncatePatchOutput(output))
+	}
+	return nil
+}
+
 // GetInstalled returns installed Homebrew formulae and casks.
 func (h *HomebrewProvider) GetInstalled() ([]InstalledPatch, error) {
 	formulae, err := h.brewList("--versions")
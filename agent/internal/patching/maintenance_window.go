@@ -0,0 +1,345 @@
+package patching
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/config"
+)
+
+// ErrOutsideMaintenanceWindow is returned by Install and InstallVersion when
+// maintenance windows are configured and none of them is open.
+var ErrOutsideMaintenanceWindow = errors.New("outside patch maintenance window")
+
+// Deferred operation kinds.
+const (
+	DeferredInstall = "install_patches"
+	DeferredReboot  = "reboot"
+)
+
+// maxDeferredOperations bounds the local queue so a server that keeps
+// sending installs outside the window cannot grow it without limit.
+const maxDeferredOperations = 100
+
+// maxWindowDurationMinutes is one week: a longer window is always open.
+const maxWindowDurationMinutes = 7 * 24 * 60
+
+// MaintenanceWindow is a recurring window in which patch installs and patch
+// reboots may run. It opens at Start ("HH:MM") on each of Days (lowercase
+// English weekday names; empty means every day) in Timezone (IANA name;
+// empty means agent local time) and stays open for DurationMinutes, which
+// may run past midnight.
+type MaintenanceWindow struct {
+	Days            []string `json:"days,omitempty"`
+	Start           string   `json:"start"`
+	DurationMinutes int      `json:"durationMinutes"`
+	Timezone        string   `json:"timezone,omitempty"`
+}
+
+// DeferredOperation is an install or patch reboot received outside the
+// maintenance windows and held until the next one opens.
+type DeferredOperation struct {
+	Kind      string         `json:"kind"`
+	CommandID string         `json:"commandId,omitempty"`
+	Payload   map[string]any `json:"payload,omitempty"`
+	QueuedAt  time.Time      `json:"queuedAt"`
+}
+
+// compiledWindow is a validated MaintenanceWindow.
+type compiledWindow struct {
+	loc         *time.Location
+	days        [7]bool
+	startMinute int
+	duration    time.Duration
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+func compileWindow(w MaintenanceWindow) (compiledWindow, error) {
+	var c compiledWindow
+
+	var hour, minute int
+	if _, err := fmt.Sscanf(w.Start, "%d:%d", &hour, &minute); err != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return c, fmt.Errorf("invalid window start %q: want HH:MM", w.Start)
+	}
+	c.startMinute = hour*60 + minute
+
+	if w.DurationMinutes < 1 || w.DurationMinutes > maxWindowDurationMinutes {
+		return c, fmt.Errorf("invalid window duration %d: must be 1-%d minutes", w.DurationMinutes, maxWindowDurationMinutes)
+	}
+	c.duration = time.Duration(w.DurationMinutes) * time.Minute
+
+	c.loc = time.Local
+	if w.Timezone != "" {
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return c, fmt.Errorf("invalid window timezone %q: %w", w.Timezone, err)
+		}
+		c.loc = loc
+	}
+
+	if len(w.Days) == 0 {
+		for i := range c.days {
+			c.days[i] = true
+		}
+	}
+	for _, name := range w.Days {
+		day, ok := weekdayNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return c, fmt.Errorf("invalid window day %q", name)
+		}
+		c.days[day] = true
+	}
+	return c, nil
+}
+
+// occurrence returns the window start on the calendar day offset days from
+// t's day in the window's timezone.
+func (c compiledWindow) occurrence(t time.Time, offset int) time.Time {
+	local := t.In(c.loc)
+	return time.Date(local.Year(), local.Month(), local.Day()+offset, c.startMinute/60, c.startMinute%60, 0, 0, c.loc)
+}
+
+func (c compiledWindow) contains(t time.Time) bool {
+	// A window can last up to a week, so one that opened up to 7 days ago
+	// may still be open.
+	for offset := 0; offset >= -7; offset-- {
+		opens := c.occurrence(t, offset)
+		if c.days[opens.Weekday()] && !t.Before(opens) && t.Before(opens.Add(c.duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c compiledWindow) nextStart(t time.Time) time.Time {
+	for offset := 0; offset <= 7; offset++ {
+		opens := c.occurrence(t, offset)
+		if c.days[opens.Weekday()] && opens.After(t) {
+			return opens
+		}
+	}
+	return time.Time{}
+}
+
+// maintenanceState is the persisted form of the windows and queue, so a
+// restart neither forgets queued work nor runs it outside the window.
+type maintenanceState struct {
+	Windows  []MaintenanceWindow `json:"windows"`
+	Deferred []DeferredOperation `json:"deferred"`
+}
+
+// maintenanceSchedule holds the server-pushed windows and the operations
+// deferred until the next one.
+type maintenanceSchedule struct {
+	mu        sync.Mutex
+	windows   []MaintenanceWindow
+	compiled  []compiledWindow
+	deferred  []DeferredOperation
+	statePath string
+}
+
+// SetMaintenanceWindows replaces the maintenance windows. An empty list
+// removes the restriction. Invalid windows are rejected as a whole so a bad
+// push never leaves the agent half-configured.
+func (m *PatchManager) SetMaintenanceWindows(windows []MaintenanceWindow) error {
+	compiled := make([]compiledWindow, 0, len(windows))
+	for i, w := range windows {
+		c, err := compileWindow(w)
+		if err != nil {
+			return fmt.Errorf("window %d: %w", i, err)
+		}
+		compiled = append(compiled, c)
+	}
+
+	s := &m.maintenance
+	s.mu.Lock()
+	s.windows = append([]MaintenanceWindow(nil), windows...)
+	s.compiled = compiled
+	s.mu.Unlock()
+	s.save()
+	return nil
+}
+
+// MaintenanceWindows returns the configured maintenance windows.
+func (m *PatchManager) MaintenanceWindows() []MaintenanceWindow {
+	s := &m.maintenance
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]MaintenanceWindow(nil), s.windows...)
+}
+
+// MaintenanceWindowOpen reports whether installs and patch reboots may run
+// at now, and otherwise when the next window opens. With no windows
+// configured it is always open.
+func (m *PatchManager) MaintenanceWindowOpen(now time.Time) (bool, time.Time) {
+	s := &m.maintenance
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.openLocked(now)
+}
+
+func (s *maintenanceSchedule) openLocked(now time.Time) (bool, time.Time) {
+	if len(s.compiled) == 0 {
+		return true, time.Time{}
+	}
+	var next time.Time
+	for _, c := range s.compiled {
+		if c.contains(now) {
+			return true, time.Time{}
+		}
+		if n := c.nextStart(now); !n.IsZero() && (next.IsZero() || n.Before(next)) {
+			next = n
+		}
+	}
+	return false, next
+}
+
+// DeferOperation queues op for the next maintenance window and returns the
+// queue length. A queued reboot replaces any earlier one, and an install
+// with the same command ID as a queued one replaces it.
+func (m *PatchManager) DeferOperation(op DeferredOperation) (int, error) {
+	if op.Kind != DeferredInstall && op.Kind != DeferredReboot {
+		return 0, fmt.Errorf("unknown deferred operation kind %q", op.Kind)
+	}
+	if op.QueuedAt.IsZero() {
+		op.QueuedAt = time.Now()
+	}
+
+	s := &m.maintenance
+	s.mu.Lock()
+	kept := s.deferred[:0]
+	for _, queued := range s.deferred {
+		if queued.Kind == op.Kind && (op.Kind == DeferredReboot || (op.CommandID != "" && queued.CommandID == op.CommandID)) {
+			continue
+		}
+		kept = append(kept, queued)
+	}
+	s.deferred = kept
+	if len(s.deferred) >= maxDeferredOperations {
+		s.mu.Unlock()
+		return 0, fmt.Errorf("deferred operation queue is full (%d)", maxDeferredOperations)
+	}
+	s.deferred = append(s.deferred, op)
+	n := len(s.deferred)
+	s.mu.Unlock()
+
+	s.save()
+	return n, nil
+}
+
+// DeferredOperations returns the queued operations, oldest first.
+func (m *PatchManager) DeferredOperations() []DeferredOperation {
+	s := &m.maintenance
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]DeferredOperation(nil), s.deferred...)
+}
+
+// TakeDueOperations removes and returns the queued operations if a
+// maintenance window is open at now, installs before the reboot.
+func (m *PatchManager) TakeDueOperations(now time.Time) []DeferredOperation {
+	s := &m.maintenance
+	s.mu.Lock()
+	if len(s.deferred) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	if open, _ := s.openLocked(now); !open {
+		s.mu.Unlock()
+		return nil
+	}
+	due := make([]DeferredOperation, 0, len(s.deferred))
+	var reboot []DeferredOperation
+	for _, op := range s.deferred {
+		if op.Kind == DeferredReboot {
+			reboot = append(reboot, op)
+			continue
+		}
+		due = append(due, op)
+	}
+	due = append(due, reboot...)
+	s.deferred = nil
+	s.mu.Unlock()
+
+	s.save()
+	return due
+}
+
+func (m *PatchManager) installAllowed() error {
+	if open, next := m.MaintenanceWindowOpen(time.Now()); !open {
+		return fmt.Errorf("%w (next window opens %s)", ErrOutsideMaintenanceWindow, next.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// withMaintenanceState loads the persisted windows and queue from the data
+// directory into m. NewDefaultManager uses it on every platform.
+func withMaintenanceState(m *PatchManager) *PatchManager {
+	m.loadMaintenanceState(filepath.Join(config.GetDataDir(), "patch_maintenance.json"))
+	return m
+}
+
+// loadMaintenanceState reads the persisted windows and queue. Windows that
+// no longer compile (e.g. an unknown timezone after an OS update) are
+// dropped with a warning.
+func (m *PatchManager) loadMaintenanceState(path string) {
+	s := &m.maintenance
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statePath = path
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var state maintenanceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Warn("ignoring unreadable patch maintenance state", "path", path, "error", err.Error())
+		return
+	}
+	for _, w := range state.Windows {
+		c, err := compileWindow(w)
+		if err != nil {
+			log.Warn("dropping invalid persisted maintenance window", "error", err.Error())
+			continue
+		}
+		s.windows = append(s.windows, w)
+		s.compiled = append(s.compiled, c)
+	}
+	s.deferred = state.Deferred
+}
+
+func (s *maintenanceSchedule) save() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.statePath == "" {
+		return
+	}
+	data, err := json.Marshal(maintenanceState{Windows: s.windows, Deferred: s.deferred})
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.statePath), 0700); err != nil {
+		log.Debug("failed to create patch maintenance state dir", "error", err)
+		return
+	}
+	if err := os.WriteFile(s.statePath, data, 0600); err != nil {
+		log.Warn("failed to write patch maintenance state", "error", err.Error())
+	}
+}
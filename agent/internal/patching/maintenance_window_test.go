@@ -0,0 +1,153 @@
+package patching
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	return loc
+}
+
+func TestMaintenanceWindowOpen(t *testing.T) {
+	ny := mustLocation(t, "America/New_York")
+	m := NewPatchManager()
+	// Saturday 22:00 to Sunday 04:00 New York time.
+	err := m.SetMaintenanceWindows([]MaintenanceWindow{{
+		Days:            []string{"Saturday"},
+		Start:           "22:00",
+		DurationMinutes: 360,
+		Timezone:        "America/New_York",
+	}})
+	if err != nil {
+		t.Fatalf("SetMaintenanceWindows: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		open bool
+	}{
+		{"before start", time.Date(2026, 3, 7, 21, 59, 0, 0, ny), false},
+		{"at start", time.Date(2026, 3, 7, 22, 0, 0, 0, ny), true},
+		{"past midnight", time.Date(2026, 3, 8, 1, 30, 0, 0, ny), true},
+		{"after end", time.Date(2026, 3, 8, 5, 0, 0, 0, ny), false},
+		{"weekday afternoon", time.Date(2026, 3, 4, 14, 0, 0, 0, ny), false},
+	}
+	for _, tt := range tests {
+		open, next := m.MaintenanceWindowOpen(tt.at)
+		if open != tt.open {
+			t.Errorf("%s: open = %v, want %v", tt.name, open, tt.open)
+		}
+		if !open && next.IsZero() {
+			t.Errorf("%s: closed window should report the next start", tt.name)
+		}
+	}
+
+	_, next := m.MaintenanceWindowOpen(time.Date(2026, 3, 4, 14, 0, 0, 0, ny))
+	if want := time.Date(2026, 3, 7, 22, 0, 0, 0, ny); !next.Equal(want) {
+		t.Errorf("next start = %v, want %v", next, want)
+	}
+}
+
+func TestMaintenanceWindowsUnsetAlwaysOpen(t *testing.T) {
+	m := NewPatchManager()
+	if open, _ := m.MaintenanceWindowOpen(time.Now()); !open {
+		t.Error("no windows configured should be open")
+	}
+}
+
+func TestSetMaintenanceWindowsRejectsInvalid(t *testing.T) {
+	valid := MaintenanceWindow{Start: "02:00", DurationMinutes: 60}
+	tests := map[string]MaintenanceWindow{
+		"bad start":    {Start: "25:00", DurationMinutes: 60},
+		"zero length":  {Start: "02:00"},
+		"too long":     {Start: "02:00", DurationMinutes: maxWindowDurationMinutes + 1},
+		"bad day":      {Days: []string{"funday"}, Start: "02:00", DurationMinutes: 60},
+		"bad timezone": {Start: "02:00", DurationMinutes: 60, Timezone: "Mars/Olympus"},
+	}
+	for name, w := range tests {
+		m := NewPatchManager()
+		if err := m.SetMaintenanceWindows([]MaintenanceWindow{valid, w}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		if len(m.MaintenanceWindows()) != 0 {
+			t.Errorf("%s: a rejected push must not apply any window", name)
+		}
+	}
+}
+
+func TestInstallRefusedOutsideMaintenanceWindow(t *testing.T) {
+	m := NewPatchManager()
+	now := time.Now()
+	closed := MaintenanceWindow{
+		Days:            []string{now.Add(48 * time.Hour).Weekday().String()},
+		Start:           "00:00",
+		DurationMinutes: 60,
+	}
+	if err := m.SetMaintenanceWindows([]MaintenanceWindow{closed}); err != nil {
+		t.Fatalf("SetMaintenanceWindows: %v", err)
+	}
+	if _, err := m.Install("apt:curl"); !errors.Is(err, ErrOutsideMaintenanceWindow) {
+		t.Errorf("Install error = %v, want ErrOutsideMaintenanceWindow", err)
+	}
+}
+
+func TestDeferredOperationsQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patch_maintenance.json")
+	m := NewPatchManager()
+	m.loadMaintenanceState(path)
+
+	utc := time.UTC
+	if err := m.SetMaintenanceWindows([]MaintenanceWindow{{Start: "02:00", DurationMinutes: 120, Timezone: "UTC"}}); err != nil {
+		t.Fatalf("SetMaintenanceWindows: %v", err)
+	}
+
+	ops := []DeferredOperation{
+		{Kind: DeferredReboot, CommandID: "r1", Payload: map[string]any{"delayMinutes": 5}},
+		{Kind: DeferredInstall, CommandID: "c1"},
+		{Kind: DeferredInstall, CommandID: "c2"},
+		{Kind: DeferredInstall, CommandID: "c1"}, // redelivery replaces c1
+		{Kind: DeferredReboot, CommandID: "r2"},  // only the latest reboot is kept
+	}
+	for _, op := range ops {
+		if _, err := m.DeferOperation(op); err != nil {
+			t.Fatalf("DeferOperation(%s): %v", op.CommandID, err)
+		}
+	}
+	if _, err := m.DeferOperation(DeferredOperation{Kind: "uninstall"}); err == nil {
+		t.Error("unknown kind should be rejected")
+	}
+
+	// Queue and windows survive a restart.
+	reloaded := NewPatchManager()
+	reloaded.loadMaintenanceState(path)
+	if got := len(reloaded.DeferredOperations()); got != 3 {
+		t.Fatalf("reloaded queue = %d operations, want 3", got)
+	}
+	if len(reloaded.MaintenanceWindows()) != 1 {
+		t.Fatal("reloaded windows missing")
+	}
+
+	if due := reloaded.TakeDueOperations(time.Date(2026, 3, 4, 12, 0, 0, 0, utc)); due != nil {
+		t.Fatalf("took %d operations outside the window", len(due))
+	}
+	due := reloaded.TakeDueOperations(time.Date(2026, 3, 4, 3, 0, 0, 0, utc))
+	var ids []string
+	for _, op := range due {
+		ids = append(ids, op.CommandID)
+	}
+	if len(ids) != 3 || ids[0] != "c2" || ids[1] != "c1" || ids[2] != "r2" {
+		t.Errorf("due operations = %v, want installs then the reboot [c2 c1 r2]", ids)
+	}
+	if len(reloaded.DeferredOperations()) != 0 {
+		t.Error("queue not cleared after taking due operations")
+	}
+}
@@ -4,14 +4,19 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/breeze-rmm/agent/internal/logging"
 )
 
+var log = logging.L("patching")
+
 const patchIDSeparator = ":"
 
 // PatchManager coordinates patch providers.
 type PatchManager struct {
 	providers     []PatchProvider
 	providerIndex map[string]PatchProvider
+	maintenance   maintenanceSchedule
 }
 
 // NewPatchManager creates a PatchManager with the given providers.
@@ -35,7 +40,7 @@ func (m *PatchManager) Scan() ([]AvailablePatch, error) {
 
 // ScanWithCoverage aggregates available patches from all providers and also
 // reports which provider IDs actually scanned. A provider that returned
-// ErrScanSkipped (couldn't run, e.g. winget without a user helper session) or
+// ErrScanSkipped (couldn't run, e.g. winget that is not installed yet) or
 // failed with an error is NOT covered: its previously reported patches must
 // not be treated as gone just because this scan didn't include them (#2217).
 func (m *PatchManager) ScanWithCoverage() ([]AvailablePatch, []string, error) {
@@ -64,8 +69,12 @@ func (m *PatchManager) ScanWithCoverage() ([]AvailablePatch, []string, error) {
 	return patches, covered, errors.Join(errs...)
 }
 
-// Install installs a patch by ID.
+// Install installs a patch by ID. It fails with ErrOutsideMaintenanceWindow
+// while maintenance windows are configured and none is open.
 func (m *PatchManager) Install(patchID string) (InstallResult, error) {
+	if err := m.installAllowed(); err != nil {
+		return InstallResult{}, err
+	}
 	providerID, localID, err := m.splitPatchID(patchID)
 	if err != nil {
 		return InstallResult{}, err
@@ -81,6 +90,99 @@ func (m *PatchManager) Install(patchID string) (InstallResult, error) {
 		return InstallResult{}, err
 	}
 
+	return m.decorateInstallResult(providerID, localID, result), nil
+}
+
+// InstallWithProgress installs a patch by ID like Install, forwarding the
+// provider's own progress events when it implements DownloadableProvider.
+// Other providers report nothing; callers emit their own per-patch events.
+func (m *PatchManager) InstallWithProgress(patchID string, progress ProgressCallback) (InstallResult, error) {
+	if err := m.installAllowed(); err != nil {
+		return InstallResult{}, err
+	}
+	providerID, localID, err := m.splitPatchID(patchID)
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	provider, ok := m.providerIndex[providerID]
+	if !ok {
+		return InstallResult{}, fmt.Errorf("unknown patch provider: %s", providerID)
+	}
+
+	var result InstallResult
+	if dp, ok := provider.(DownloadableProvider); ok && progress != nil {
+		result, err = dp.InstallWithProgress(localID, progress)
+	} else {
+		result, err = provider.Install(localID)
+	}
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	return m.decorateInstallResult(providerID, localID, result), nil
+}
+
+// InstallVersion installs a specific version of a patch by ID. Only providers
+// implementing VersionedInstaller support it.
+func (m *PatchManager) InstallVersion(patchID, version string) (InstallResult, error) {
+	if err := m.installAllowed(); err != nil {
+		return InstallResult{}, err
+	}
+	providerID, localID, err := m.splitPatchID(patchID)
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	provider, ok := m.providerIndex[providerID]
+	if !ok {
+		return InstallResult{}, fmt.Errorf("unknown patch provider: %s", providerID)
+	}
+	versioned, ok := provider.(VersionedInstaller)
+	if !ok {
+		return InstallResult{}, fmt.Errorf("provider %s cannot install a specific version", providerID)
+	}
+
+	result, err := versioned.InstallVersion(localID, version)
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	return m.decorateInstallResult(providerID, localID, result), nil
+}
+
+// PinPackage applies a native package-manager pin for a provider that
+// implements PackagePinner.
+func (m *PatchManager) PinPackage(providerID, packageID, version string) error {
+	pinner, err := m.pinner(providerID)
+	if err != nil {
+		return err
+	}
+	return pinner.Pin(packageID, version)
+}
+
+// UnpinPackage removes a native package-manager pin.
+func (m *PatchManager) UnpinPackage(providerID, packageID string) error {
+	pinner, err := m.pinner(providerID)
+	if err != nil {
+		return err
+	}
+	return pinner.Unpin(packageID)
+}
+
+func (m *PatchManager) pinner(providerID string) (PackagePinner, error) {
+	provider, ok := m.providerIndex[providerID]
+	if !ok {
+		return nil, fmt.Errorf("unknown patch provider: %s", providerID)
+	}
+	pinner, ok := provider.(PackagePinner)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support pinning", providerID)
+	}
+	return pinner, nil
+}
+
+func (m *PatchManager) decorateInstallResult(providerID, localID string, result InstallResult) InstallResult {
 	if result.Provider == "" {
 		result.Provider = providerID
 	}
@@ -90,7 +192,7 @@ func (m *PatchManager) Install(patchID string) (InstallResult, error) {
 		result.PatchID = m.formatPatchID(providerID, result.PatchID)
 	}
 
-	return result, nil
+	return result
 }
 
 // Uninstall removes a patch by ID.
@@ -108,13 +210,17 @@ func (m *PatchManager) Uninstall(patchID string) error {
 	return provider.Uninstall(localID)
 }
 
-// GetInstalled aggregates installed patches from all providers.
+// GetInstalled aggregates installed patches from all providers. Providers
+// that return ErrScanSkipped are left out without an error.
 func (m *PatchManager) GetInstalled() ([]InstalledPatch, error) {
 	var installed []InstalledPatch
 	var errs []error
 
 	for _, provider := range m.providers {
 		providerInstalled, err := provider.GetInstalled()
+		if errors.Is(err, ErrScanSkipped) {
+			continue
+		}
 		if err != nil {
 			errs = append(errs, fmt.Errorf("%s installed scan failed: %w", provider.ID(), err))
 			continue
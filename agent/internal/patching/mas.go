@@ -0,0 +1,161 @@
+//go:build darwin
+
+package patching
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// MasProvider updates Mac App Store apps with the mas CLI. App Store updates
+// are tied to the signed-in user's Apple Account, which softwareupdate and
+// Homebrew never see, so scans and upgrades run as the console user.
+type MasProvider struct{}
+
+// NewMasProvider creates a new MasProvider.
+func NewMasProvider() *MasProvider {
+	return &MasProvider{}
+}
+
+// ID returns the provider identifier.
+func (m *MasProvider) ID() string {
+	return "mas"
+}
+
+// Name returns the human-readable provider name.
+func (m *MasProvider) Name() string {
+	return "Mac App Store"
+}
+
+func masBinaryPath() (string, error) {
+	if path, err := exec.LookPath("mas"); err == nil {
+		return path, nil
+	}
+
+	// mas is normally installed with Homebrew, whose prefix is not on the
+	// launchd PATH the agent starts with.
+	for _, candidate := range []string{
+		"/opt/homebrew/bin/mas",
+		"/usr/local/bin/mas",
+	} {
+		info, err := os.Stat(candidate)
+		if err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("mas binary not found")
+}
+
+// masCommand runs mas as the console user when the agent is root: the App
+// Store session, and so the list of purchases to update, is per user.
+func (m *MasProvider) masCommand(args ...string) (*exec.Cmd, error) {
+	masPath, err := masBinaryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if os.Geteuid() == 0 {
+		account, err := activeConsoleUser()
+		if err != nil {
+			return nil, fmt.Errorf("cannot execute mas without a console user: %w", err)
+		}
+
+		sudoArgs := append([]string{"-n", "-H", "-u", account.Username, masPath}, args...)
+		cmd := exec.Command("/usr/bin/sudo", sudoArgs...)
+		cmd.Env = setEnv(os.Environ(), "HOME", account.HomeDir)
+		return cmd, nil
+	}
+
+	return exec.Command(masPath, args...), nil
+}
+
+// Scan returns App Store apps with a pending update.
+func (m *MasProvider) Scan() ([]AvailablePatch, error) {
+	output, err := m.masOutput(patchScanTimeout, "outdated")
+	if err != nil {
+		return nil, fmt.Errorf("mas outdated failed: %w", err)
+	}
+
+	patches := []AvailablePatch{}
+	for _, app := range parseMasApps(output) {
+		patches = append(patches, AvailablePatch{
+			ID:          truncatePatchField(app.ID),
+			Title:       truncatePatchField(app.Name),
+			Version:     truncatePatchField(app.Version),
+			Description: truncatePatchDescription(app.installedDescription()),
+		})
+	}
+	return patches, nil
+}
+
+// Install upgrades one app by its App Store ID.
+func (m *MasProvider) Install(patchID string) (InstallResult, error) {
+	if err := validateMasAppID(patchID); err != nil {
+		return InstallResult{}, err
+	}
+
+	output, err := m.masCombinedOutput(patchMutateTimeout, "upgrade", patchID)
+	if err != nil {
+		return InstallResult{}, fmt.Errorf("mas upgrade failed: %w: %s", err, truncatePatchOutput(output))
+	}
+
+	return InstallResult{
+		PatchID: patchID,
+		Message: truncatePatchOutput(output),
+	}, nil
+}
+
+// Uninstall removes an app. mas uninstall deletes the bundle from
+// /Applications and must run as root rather than as the console user.
+func (m *MasProvider) Uninstall(patchID string) error {
+	if err := validateMasAppID(patchID); err != nil {
+		return err
+	}
+	masPath, err := masBinaryPath()
+	if err != nil {
+		return err
+	}
+
+	output, err := runCmdCombinedOutputWithTimeout(exec.Command(masPath, "uninstall", patchID), patchMutateTimeout)
+	if err != nil {
+		return fmt.Errorf("mas uninstall failed: %w: %s", err, truncatePatchOutput(output))
+	}
+	return nil
+}
+
+// GetInstalled returns apps installed from the App Store.
+func (m *MasProvider) GetInstalled() ([]InstalledPatch, error) {
+	output, err := m.masOutput(patchListTimeout, "list")
+	if err != nil {
+		return nil, fmt.Errorf("mas list failed: %w", err)
+	}
+
+	installed := []InstalledPatch{}
+	for _, app := range parseMasApps(output) {
+		installed = append(installed, InstalledPatch{
+			ID:      truncatePatchField(app.ID),
+			Title:   truncatePatchField(app.Name),
+			Version: truncatePatchField(app.Version),
+		})
+	}
+	return installed, nil
+}
+
+func (m *MasProvider) masOutput(timeout time.Duration, args ...string) ([]byte, error) {
+	cmd, err := m.masCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+	return runCmdOutputWithTimeout(cmd, timeout)
+}
+
+func (m *MasProvider) masCombinedOutput(timeout time.Duration, args ...string) ([]byte, error) {
+	cmd, err := m.masCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+	return runCmdCombinedOutputWithTimeout(cmd, timeout)
+}
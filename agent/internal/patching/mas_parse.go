@@ -0,0 +1,61 @@
+package patching
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	validMasAppID = regexp.MustCompile(`^[0-9]{1,20}$`)
+	masAppLine    = regexp.MustCompile(`^\s*([0-9]+)\s+(.+?)\s+\(([^)]*)\)\s*$`)
+)
+
+// masApp is one row of mas list or mas outdated.
+type masApp struct {
+	ID               string
+	Name             string
+	Version          string
+	InstalledVersion string
+}
+
+func (a masApp) installedDescription() string {
+	if a.InstalledVersion == "" {
+		return ""
+	}
+	return "installed: " + a.InstalledVersion
+}
+
+// parseMasApps reads "<id> <name> (<version>)" rows. mas outdated writes the
+// version as "installed -> available"; older releases print only the
+// available version.
+func parseMasApps(output []byte) []masApp {
+	scanner := newPatchScanner(output)
+	apps := []masApp{}
+	for scanner.Scan() {
+		m := masAppLine.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		app := masApp{ID: m[1], Name: strings.TrimSpace(m[2]), Version: strings.TrimSpace(m[3])}
+		if installed, available, ok := strings.Cut(app.Version, "->"); ok {
+			app.InstalledVersion = strings.TrimSpace(installed)
+			app.Version = strings.TrimSpace(available)
+		}
+		if validateMasAppID(app.ID) != nil {
+			continue
+		}
+		apps = append(apps, app)
+		if len(apps) >= patchResultItemLimit {
+			break
+		}
+	}
+	return apps
+}
+
+func validateMasAppID(id string) error {
+	if !validMasAppID.MatchString(id) {
+		return fmt.Errorf("invalid App Store app ID: %q", id)
+	}
+	return nil
+}
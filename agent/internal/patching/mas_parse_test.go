@@ -0,0 +1,35 @@
+package patching
+
+import "testing"
+
+func TestParseMasAppsOutdated(t *testing.T) {
+	output := []byte(`497799835  Xcode              (15.3 -> 15.4)
+409183694  Keynote            (14.0 -> 14.1)
+1295203466 Microsoft Remote Desktop (10.9.5)
+Warning: No Apple Account found
+`)
+	apps := parseMasApps(output)
+	if len(apps) != 3 {
+		t.Fatalf("expected 3 apps, got %d: %+v", len(apps), apps)
+	}
+	if a := apps[0]; a.ID != "497799835" || a.Name != "Xcode" || a.Version != "15.4" || a.InstalledVersion != "15.3" {
+		t.Fatalf("unexpected first app %+v", a)
+	}
+	if a := apps[2]; a.Name != "Microsoft Remote Desktop" || a.Version != "10.9.5" || a.InstalledVersion != "" {
+		t.Fatalf("older mas output should keep the version as-is, got %+v", a)
+	}
+	if apps[0].installedDescription() != "installed: 15.3" || apps[2].installedDescription() != "" {
+		t.Fatal("unexpected installed description")
+	}
+}
+
+func TestValidateMasAppID(t *testing.T) {
+	if err := validateMasAppID("497799835"); err != nil {
+		t.Fatalf("expected valid ID: %v", err)
+	}
+	for _, id := range []string{"", "Xcode", "497799835;rm", "-1"} {
+		if err := validateMasAppID(id); err == nil {
+			t.Errorf("expected %q to be rejected", id)
+		}
+	}
+}
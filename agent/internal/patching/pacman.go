@@ -0,0 +1,237 @@
+//go:build linux
+
+package patching
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var validPacmanPkgName = regexp.MustCompile(`^[a-z0-9@_+][a-z0-9@._+-]{0,127}$`)
+
+// pacmanRebootPackages are the packages whose upgrade needs a reboot to take
+// effect: the kernels (whose modules for the running kernel are removed) and
+// the init system and C library every process maps.
+var pacmanRebootPackages = map[string]bool{
+	"linux":          true,
+	"linux-lts":      true,
+	"linux-zen":      true,
+	"linux-hardened": true,
+	"systemd":        true,
+	"glibc":          true,
+}
+
+// PacmanProvider integrates with pacman on Arch Linux and derivatives.
+// Security severities come from arch-audit when it is installed.
+type PacmanProvider struct{}
+
+// NewPacmanProvider creates a new PacmanProvider.
+func NewPacmanProvider() *PacmanProvider {
+	return &PacmanProvider{}
+}
+
+// ID returns the provider identifier.
+func (p *PacmanProvider) ID() string {
+	return "pacman"
+}
+
+// Name returns the human-readable provider name.
+func (p *PacmanProvider) Name() string {
+	return "Pacman"
+}
+
+// Scan returns available upgrades. checkupdates (pacman-contrib) syncs into
+// a temporary database, leaving the system one untouched; without it the
+// scan is against the last synced database.
+func (p *PacmanProvider) Scan() ([]AvailablePatch, error) {
+	var (
+		output []byte
+		err    error
+		tool   string
+	)
+	if _, lookErr := exec.LookPath("checkupdates"); lookErr == nil {
+		tool = "checkupdates"
+		output, err = commandOutputWithTimeout(patchScanTimeout, "checkupdates")
+		// checkupdates exits 2 when there are no updates.
+		if commandExitCode(err) == 2 {
+			err = nil
+		}
+	} else {
+		tool = "pacman -Qu"
+		output, err = commandOutputWithTimeout(patchScanTimeout, "pacman", "-Qu")
+		// pacman -Qu exits 1 when there are no updates.
+		if commandExitCode(err) == 1 && len(strings.TrimSpace(string(output))) == 0 {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", tool, err)
+	}
+
+	patches, err := parsePacmanUpdates(output)
+	if err != nil {
+		return nil, fmt.Errorf("%s parse failed: %w", tool, err)
+	}
+
+	if _, lookErr := exec.LookPath("arch-audit"); lookErr != nil {
+		return patches, nil
+	}
+	auditOutput, err := commandOutputWithTimeout(patchScanTimeout, "arch-audit", "--upgradable", "--format", "%n|%s|%c")
+	if err != nil {
+		log.Debug("arch-audit failed, severities unknown", "error", err)
+		return patches, nil
+	}
+	advisories := parseArchAudit(auditOutput)
+	for i := range patches {
+		if adv, ok := advisories[patches[i].ID]; ok {
+			patches[i].Category = "security"
+			patches[i].Severity = adv.Severity
+			patches[i].Description = truncatePatchDescription(adv.ID)
+		}
+	}
+	return patches, nil
+}
+
+// parsePacmanUpdates parses "name old -> new" lines. Packages held by
+// IgnorePkg are listed with an "[ignored]" suffix and skipped.
+func parsePacmanUpdates(output []byte) ([]AvailablePatch, error) {
+	scanner := newPatchScanner(output)
+	patches := []AvailablePatch{}
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[2] != "->" {
+			continue
+		}
+		if len(fields) > 4 && fields[4] == "[ignored]" {
+			continue
+		}
+		if err := validatePacmanPackageName(fields[0]); err != nil {
+			continue
+		}
+		patches = append(patches, AvailablePatch{
+			ID:             truncatePatchField(fields[0]),
+			Title:          truncatePatchField(fields[0]),
+			Version:        truncatePatchField(fields[3]),
+			RebootRequired: pacmanRebootPackages[fields[0]],
+		})
+		if len(patches) >= patchResultItemLimit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return patches, nil
+}
+
+// parseArchAudit parses arch-audit "%n|%s|%c" lines (package, severity,
+// CVEs) into advisories keyed by package. ID carries the CVE list.
+func parseArchAudit(output []byte) map[string]packageAdvisory {
+	advisories := map[string]packageAdvisory{}
+	scanner := newPatchScanner(output)
+	for scanner.Scan() {
+		parts := strings.SplitN(strings.TrimSpace(scanner.Text()), "|", 3)
+		if len(parts) < 2 || parts[0] == "" {
+			continue
+		}
+		adv := packageAdvisory{Security: true, Severity: normalizeLinuxSeverity(parts[1])}
+		if len(parts) == 3 {
+			adv.ID = parts[2]
+		}
+		if prev, ok := advisories[parts[0]]; ok && severityRank(prev.Severity) >= severityRank(adv.Severity) {
+			continue
+		}
+		advisories[parts[0]] = adv
+	}
+	return advisories
+}
+
+// Install upgrades one package. Arch only supports full system upgrades, so
+// installing a single package against a freshly synced database can leave
+// its reverse dependencies behind; approve every pending update together on
+// Arch devices to keep them consistent.
+func (p *PacmanProvider) Install(patchID string) (InstallResult, error) {
+	if err := validatePacmanPackageName(patchID); err != nil {
+		return InstallResult{}, err
+	}
+
+	output, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "pacman", "-Sy", "--noconfirm", "--needed", patchID)
+	if err != nil {
+		return InstallResult{}, fmt.Errorf("pacman -S failed: %w: %s", err, truncatePatchOutput(output))
+	}
+
+	return InstallResult{
+		PatchID:        patchID,
+		RebootRequired: pacmanRebootPackages[patchID],
+		Message:        truncatePatchOutput(output),
+	}, nil
+}
+
+// Stage downloads a package into the pacman cache without installing it.
+// pacman has no rate-limit option of its own, so limitKBps is not applied.
+func (p *PacmanProvider) Stage(patchIDs []string, _ int, progress ProgressCallback) ([]DownloadResult, error) {
+	return stageEach(patchIDs, progress, func(patchID string) (string, error) {
+		if err := validatePacmanPackageName(patchID); err != nil {
+			return "", err
+		}
+		output, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "pacman", "-Sw", "--noconfirm", patchID)
+		if err != nil {
+			return "", fmt.Errorf("pacman -Sw failed: %w: %s", err, truncatePatchOutput(output))
+		}
+		return truncatePatchOutput(output), nil
+	}), nil
+}
+
+// Uninstall removes a package with pacman.
+func (p *PacmanProvider) Uninstall(patchID string) error {
+	if err := validatePacmanPackageName(patchID); err != nil {
+		return err
+	}
+
+	output, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "pacman", "-R", "--noconfirm", patchID)
+	if err != nil {
+		return fmt.Errorf("pacman -R failed: %w: %s", err, truncatePatchOutput(output))
+	}
+	return nil
+}
+
+// GetInstalled returns installed packages using pacman -Q.
+func (p *PacmanProvider) GetInstalled() ([]InstalledPatch, error) {
+	output, err := commandOutputWithTimeout(patchListTimeout, "pacman", "-Q")
+	if err != nil {
+		return nil, fmt.Errorf("pacman -Q failed: %w", err)
+	}
+
+	scanner := newPatchScanner(output)
+	installed := []InstalledPatch{}
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if err := validatePacmanPackageName(fields[0]); err != nil {
+			continue
+		}
+		installed = append(installed, InstalledPatch{
+			ID:      truncatePatchField(fields[0]),
+			Title:   truncatePatchField(fields[0]),
+			Version: truncatePatchField(fields[1]),
+		})
+		if len(installed) >= patchResultItemLimit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("pacman -Q parse failed: %w", err)
+	}
+	return installed, nil
+}
+
+func validatePacmanPackageName(name string) error {
+	if !validPacmanPkgName.MatchString(name) {
+		return fmt.Errorf("invalid package name: %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+//go:build linux
+
+package patching
+
+import "testing"
+
+func TestParsePacmanUpdates(t *testing.T) {
+	output := []byte(`linux 6.9.7.arch1-1 -> 6.9.8.arch1-1
+openssl 3.3.0-1 -> 3.3.1-1
+firefox 127.0-1 -> 127.0.2-1 [ignored]
+:: Synchronizing package databases...
+`)
+	patches, err := parsePacmanUpdates(output)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("expected 2 patches, got %d: %+v", len(patches), patches)
+	}
+	if p := patches[0]; p.ID != "linux" || p.Version != "6.9.8.arch1-1" || !p.RebootRequired {
+		t.Fatalf("unexpected kernel update %+v", p)
+	}
+	if p := patches[1]; p.ID != "openssl" || p.RebootRequired {
+		t.Fatalf("unexpected openssl update %+v", p)
+	}
+}
+
+func TestParseArchAudit(t *testing.T) {
+	output := []byte(`openssl|Medium|CVE-2024-5535
+openssl|High|CVE-2024-4741,CVE-2024-4603
+curl|Low|CVE-2024-6197
+|Critical|CVE-2024-0000
+`)
+	advisories := parseArchAudit(output)
+
+	if len(advisories) != 2 {
+		t.Fatalf("expected 2 advisories, got %d", len(advisories))
+	}
+	openssl := advisories["openssl"]
+	if openssl.Severity != "important" || openssl.ID != "CVE-2024-4741,CVE-2024-4603" {
+		t.Fatalf("openssl should keep the most severe advisory, got %+v", openssl)
+	}
+	if curl := advisories["curl"]; !curl.Security || curl.Severity != "low" {
+		t.Fatalf("unexpected curl advisory %+v", curl)
+	}
+}
+
+func TestValidatePacmanPackageName(t *testing.T) {
+	for _, name := range []string{"linux-lts", "python-pip", "lib32-glibc", "gtk+3"} {
+		if err := validatePacmanPackageName(name); err != nil {
+			t.Errorf("expected %q to be valid: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "-Syu", "foo bar", "pkg;rm"} {
+		if err := validatePacmanPackageName(name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
@@ -317,13 +317,21 @@ func checkMaintenanceWindow(startStr, endStr string, days []string) PreflightChe
 // CreateRestorePoint creates a Windows System Restore point.
 // Best-effort: returns error but callers should not block on failure.
 func CreateRestorePoint(description string) error {
+	_, err := setRestorePoint(description)
+	return err
+}
+
+// setRestorePoint creates a restore point and returns its sequence number.
+// The change is closed right away: the point captures the system as it is
+// now, before the caller starts installing.
+func setRestorePoint(description string) (uint32, error) {
 	var (
 		srclientDLL           = windows.NewLazySystemDLL("srclient.dll")
 		procSRSetRestorePoint = srclientDLL.NewProc("SRSetRestorePointW")
 	)
 
 	if err := procSRSetRestorePoint.Find(); err != nil {
-		return fmt.Errorf("SRSetRestorePoint not available: %w", err)
+		return 0, fmt.Errorf("SRSetRestorePoint not available: %w", err)
 	}
 
 	// RESTOREPOINTINFOW structure (must match Windows SDK RESTOREPOINTINFOW layout)
@@ -344,6 +352,7 @@ func CreateRestorePoint(description string) error {
 
 	const (
 		beginSystemChange  = 100
+		endSystemChange    = 101
 		applicationInstall = 0
 	)
 
@@ -355,7 +364,7 @@ func CreateRestorePoint(description string) error {
 	// Convert description to UTF-16, truncate to fit the fixed 256-element array
 	descUTF16, err := windows.UTF16FromString(description)
 	if err != nil {
-		return fmt.Errorf("failed to convert description: %w", err)
+		return 0, fmt.Errorf("failed to convert description: %w", err)
 	}
 	if len(descUTF16) > len(rpi.Description) {
 		descUTF16 = descUTF16[:len(rpi.Description)-1]
@@ -369,8 +378,20 @@ func CreateRestorePoint(description string) error {
 		uintptr(unsafe.Pointer(&status)),
 	)
 	if r == 0 {
-		return fmt.Errorf("SRSetRestorePoint failed: status=%d err=%v", status.Status, callErr)
+		return 0, fmt.Errorf("SRSetRestorePoint failed: status=%d err=%v", status.Status, callErr)
 	}
 
-	return nil
+	end := restorePointInfo{
+		EventType:      endSystemChange,
+		SequenceNumber: status.SequenceNumber,
+	}
+	var endStatus statemgrStatus
+	if r, _, callErr := procSRSetRestorePoint.Call(
+		uintptr(unsafe.Pointer(&end)),
+		uintptr(unsafe.Pointer(&endStatus)),
+	); r == 0 {
+		log.Debug("closing restore point failed", "sequence", status.SequenceNumber, "status", endStatus.Status, "error", callErr)
+	}
+
+	return status.SequenceNumber, nil
 }
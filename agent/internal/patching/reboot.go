@@ -0,0 +1,515 @@
+package patching
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/config"
+)
+
+// RebootState tracks the current reboot scheduling state.
+type RebootState struct {
+	PendingReboot    bool      `json:"pendingReboot"`
+	RebootScheduled  bool      `json:"rebootScheduled"`
+	ScheduledAt      time.Time `json:"scheduledAt,omitempty"`
+	Deadline         time.Time `json:"deadline,omitempty"`
+	Reason           string    `json:"reason,omitempty"`
+	NotifiedUser     bool      `json:"notifiedUser"`
+	NotificationSent time.Time `json:"notificationSent,omitempty"`
+	Source           string    `json:"source"` // "patch_job", "maintenance_window", "manual"
+	// Deferrals counts the times the user postponed this reboot; at
+	// MaxDeferrals the prompt stops offering to postpone.
+	Deferrals       int              `json:"deferrals"`
+	MaxDeferrals    int              `json:"maxDeferrals"`
+	DeferralHistory []RebootDeferral `json:"deferralHistory,omitempty"`
+}
+
+// RebootDeferral is one postponement the logged-in user chose.
+type RebootDeferral struct {
+	Choice     string    `json:"choice"`
+	DeferredAt time.Time `json:"deferredAt"`
+	RebootAt   time.Time `json:"rebootAt"`
+}
+
+// Postpone choices offered to the user for a patch reboot.
+const (
+	RebootDeferOneHour   = "Postpone 1 hour"
+	RebootDeferFourHours = "Postpone 4 hours"
+	RebootDeferTonight   = "Postpone until tonight"
+)
+
+// rebootDeferralChoices is the order the choices are offered in.
+var rebootDeferralChoices = []string{RebootDeferOneHour, RebootDeferFourHours, RebootDeferTonight}
+
+// rebootTonightHour is the local hour an "until tonight" reboot runs at.
+const rebootTonightHour = 22
+
+// maxRebootDeferrals bounds the deferral policy pushed by the server.
+const maxRebootDeferrals = 10
+
+// patchRebootSource is the only reboot source the user may postpone;
+// maintenance-window and manual reboots are never offered a deferral.
+const patchRebootSource = "patch_job"
+
+// NotifyFunc is called to send a notification to the logged-in user.
+type NotifyFunc func(title, body, urgency string)
+
+// PromptFunc asks the logged-in user to pick one of actions and returns the
+// pick, or "" when nobody picked one within timeout.
+type PromptFunc func(title, body string, actions []string, timeout time.Duration) string
+
+// RebootManager handles reboot scheduling, notification, and execution.
+// Patch, maintenance-window and ad-hoc reboots all go through it so they
+// share one reboots-per-day limit.
+type RebootManager struct {
+	mu               sync.Mutex
+	state            RebootState
+	scheduledTimer   *time.Timer
+	notifyTimers     []*time.Timer
+	notifyFn         NotifyFunc
+	promptFn         PromptFunc
+	maxDeferrals     int
+	stopChan         chan struct{}
+	stopped          bool
+	maxRebootsPerDay int
+	rebootHistory    []time.Time
+	historyPath      string
+
+	// rebootFn and abortFn are the OS operations (see reboot_windows.go and
+	// reboot_unix.go); tests replace them.
+	rebootFn func(source string) error
+	abortFn  func()
+}
+
+// NewRebootManager creates a new RebootManager with circuit breaker protection.
+func NewRebootManager(notifyFn NotifyFunc, maxRebootsPerDay int) *RebootManager {
+	if maxRebootsPerDay <= 0 {
+		maxRebootsPerDay = 3
+	}
+	rm := &RebootManager{
+		notifyFn:         notifyFn,
+		stopChan:         make(chan struct{}),
+		maxRebootsPerDay: maxRebootsPerDay,
+		historyPath:      filepath.Join(config.GetDataDir(), "reboot_history.json"),
+		rebootFn:         rebootOS,
+		abortFn:          abortRebootOS,
+	}
+	rm.loadRebootHistory()
+	return rm
+}
+
+// SetPromptFunc sets how patch reboot prompts reach the user. Without one,
+// patch reboots are only announced.
+func (r *RebootManager) SetPromptFunc(fn PromptFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.promptFn = fn
+}
+
+// SetMaxDeferrals sets how many times the user may postpone one patch
+// reboot. Zero disables the prompt. A reboot already scheduled picks up the
+// new limit.
+func (r *RebootManager) SetMaxDeferrals(n int) {
+	if n < 0 {
+		n = 0
+	} else if n > maxRebootDeferrals {
+		n = maxRebootDeferrals
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxDeferrals = n
+	if r.state.RebootScheduled {
+		r.state.MaxDeferrals = n
+	}
+}
+
+// State returns the current reboot state.
+func (r *RebootManager) State() RebootState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Refresh pending reboot detection
+	pending, _ := DetectPendingReboot()
+	r.state.PendingReboot = pending
+	return r.state
+}
+
+// Schedule schedules a reboot after the given delay with a hard deadline. A
+// zero delay reboots right away. It fails without scheduling when the
+// reboots-per-day limit is already used up.
+func (r *RebootManager) Schedule(delay time.Duration, deadline time.Time, reason, source string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return fmt.Errorf("reboot manager is stopped")
+	}
+	if recent := r.recentRebootsLocked(time.Now()); recent >= r.maxRebootsPerDay {
+		return &ErrRebootLoopDetected{Count: recent, Window: "24h"}
+	}
+
+	// Cancel any existing schedule
+	r.cancelLocked()
+
+	rebootAt := time.Now().Add(delay)
+	r.state = RebootState{
+		PendingReboot:   true,
+		RebootScheduled: true,
+		ScheduledAt:     rebootAt,
+		Deadline:        deadline,
+		Reason:          reason,
+		Source:          source,
+		MaxDeferrals:    r.maxDeferrals,
+	}
+
+	// Schedule the actual reboot
+	r.scheduledTimer = time.AfterFunc(delay, func() {
+		r.executeReboot()
+	})
+
+	// Schedule user notifications
+	r.scheduleNotifications(delay)
+
+	return nil
+}
+
+// Postpone moves the scheduled patch reboot to the time choice names and
+// records the deferral. It fails when the reboot may not be postponed
+// further or choice would not move it later.
+func (r *RebootManager) Postpone(choice string) (time.Time, error) {
+	r.mu.Lock()
+	scheduledAt := r.state.ScheduledAt
+	r.mu.Unlock()
+	return r.postpone(choice, scheduledAt, time.Now())
+}
+
+// postpone applies choice only if the reboot is still the one scheduled at
+// scheduledAt, so a prompt answered after a reschedule changes nothing.
+func (r *RebootManager) postpone(choice string, scheduledAt, now time.Time) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped || !r.state.RebootScheduled || !r.state.ScheduledAt.Equal(scheduledAt) {
+		return time.Time{}, fmt.Errorf("reboot is no longer scheduled")
+	}
+	if !slices.Contains(r.deferralOptionsLocked(now), choice) {
+		return time.Time{}, fmt.Errorf("reboot cannot be postponed with %q", choice)
+	}
+	rebootAt, _ := rebootDeferralTarget(choice, now)
+
+	r.cancelLocked()
+	r.state.ScheduledAt = rebootAt
+	r.state.Deferrals++
+	r.state.DeferralHistory = append(r.state.DeferralHistory, RebootDeferral{
+		Choice:     choice,
+		DeferredAt: now,
+		RebootAt:   rebootAt,
+	})
+	delay := rebootAt.Sub(now)
+	r.scheduledTimer = time.AfterFunc(delay, func() {
+		r.executeReboot()
+	})
+	r.scheduleNotifications(delay)
+
+	log.Info("patch reboot postponed by user", "choice", choice, "rebootAt", rebootAt,
+		"deferrals", r.state.Deferrals, "maxDeferrals", r.state.MaxDeferrals)
+	if r.notifyFn != nil {
+		go r.notifyFn("Reboot Postponed",
+			fmt.Sprintf("The reboot has been postponed to %s.", rebootAt.Local().Format("Mon 15:04")), "normal")
+	}
+	return rebootAt, nil
+}
+
+// deferralOptionsLocked lists the choices that would postpone the current
+// reboot, or nil when it may not be postponed. An explicit deadline later
+// than the scheduled time caps how far it can move.
+func (r *RebootManager) deferralOptionsLocked(now time.Time) []string {
+	s := r.state
+	if r.promptFn == nil || !s.RebootScheduled || s.Source != patchRebootSource || s.Deferrals >= s.MaxDeferrals {
+		return nil
+	}
+	var options []string
+	for _, choice := range rebootDeferralChoices {
+		target, ok := rebootDeferralTarget(choice, now)
+		if !ok || !target.After(s.ScheduledAt) {
+			continue
+		}
+		if s.Deadline.After(s.ScheduledAt) && target.After(s.Deadline) {
+			continue
+		}
+		options = append(options, choice)
+	}
+	return options
+}
+
+// rebootDeferralTarget returns the reboot time a choice made at now names.
+func rebootDeferralTarget(choice string, now time.Time) (time.Time, bool) {
+	switch choice {
+	case RebootDeferOneHour:
+		return now.Add(time.Hour), true
+	case RebootDeferFourHours:
+		return now.Add(4 * time.Hour), true
+	case RebootDeferTonight:
+		local := now.Local()
+		tonight := time.Date(local.Year(), local.Month(), local.Day(), rebootTonightHour, 0, 0, 0, local.Location())
+		return tonight, tonight.After(now)
+	default:
+		return time.Time{}, false
+	}
+}
+
+// Cancel cancels a scheduled reboot.
+func (r *RebootManager) Cancel() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.state.RebootScheduled {
+		return fmt.Errorf("no reboot scheduled")
+	}
+
+	r.cancelLocked()
+
+	// Abort any pending OS shutdown
+	if r.abortFn != nil {
+		r.abortFn()
+	}
+
+	r.state.RebootScheduled = false
+	r.state.ScheduledAt = time.Time{}
+	r.state.Deadline = time.Time{}
+
+	if r.notifyFn != nil && r.state.NotifiedUser {
+		go r.notifyFn("Reboot Cancelled", "The scheduled reboot has been cancelled.", "normal")
+	}
+
+	return nil
+}
+
+// Stop stops the reboot manager and cancels any pending reboot.
+func (r *RebootManager) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return // already stopped, avoid double-close on stopChan
+	}
+	r.stopped = true
+	r.cancelLocked()
+	close(r.stopChan)
+}
+
+func (r *RebootManager) cancelLocked() {
+	if r.scheduledTimer != nil {
+		r.scheduledTimer.Stop()
+		r.scheduledTimer = nil
+	}
+	for _, t := range r.notifyTimers {
+		t.Stop()
+	}
+	r.notifyTimers = nil
+}
+
+// recentRebootsLocked counts reboots in the 24 hours before now.
+func (r *RebootManager) recentRebootsLocked(now time.Time) int {
+	cutoff := now.Add(-24 * time.Hour)
+	recentCount := 0
+	for _, t := range r.rebootHistory {
+		if t.After(cutoff) {
+			recentCount++
+		}
+	}
+	return recentCount
+}
+
+func (r *RebootManager) scheduleNotifications(totalDelay time.Duration) {
+	type notification struct {
+		before  time.Duration
+		title   string
+		body    string
+		urgency string
+	}
+
+	notifications := []notification{
+		{60 * time.Minute, "Reboot Scheduled", "A system reboot is scheduled in 1 hour. Please save your work.", "normal"},
+		{15 * time.Minute, "Reboot Soon", "A system reboot is scheduled in 15 minutes. Please save your work.", "normal"},
+		{5 * time.Minute, "Reboot Imminent", "System will reboot in 5 minutes. Save all work now.", "critical"},
+	}
+
+	// When the user may postpone, the first warning to fire is a prompt
+	// with the postpone choices instead of a plain notification.
+	prompting := len(r.deferralOptionsLocked(time.Now())) > 0
+	warn := func(delay time.Duration, title, body, urgency string) {
+		fire := func() { r.notify(title, body, urgency) }
+		if prompting {
+			prompting = false
+			fire = func() { r.prompt(title, body, urgency) }
+		}
+		r.notifyTimers = append(r.notifyTimers, time.AfterFunc(delay, fire))
+	}
+
+	for _, n := range notifications {
+		if totalDelay > n.before {
+			warn(totalDelay-n.before, n.title, n.body, n.urgency)
+		}
+	}
+
+	// A reboot closer than the first staged warning that will fire still
+	// gets one warning now, so the user is never restarted unannounced.
+	if totalDelay > 0 && totalDelay <= 5*time.Minute {
+		body := fmt.Sprintf("System will reboot in %s. Save all work now.", formatRebootDelay(totalDelay))
+		warn(0, "Reboot Imminent", body, "critical")
+	}
+}
+
+// prompt shows a warning with the postpone choices and applies the user's
+// pick. It falls back to a plain warning when the reboot can no longer be
+// postponed.
+func (r *RebootManager) prompt(title, body, urgency string) {
+	now := time.Now()
+	r.mu.Lock()
+	options := r.deferralOptionsLocked(now)
+	promptFn := r.promptFn
+	scheduledAt := r.state.ScheduledAt
+	remaining := r.state.MaxDeferrals - r.state.Deferrals
+	r.mu.Unlock()
+
+	timeout := scheduledAt.Sub(now)
+	if len(options) == 0 || timeout <= 0 {
+		r.notify(title, body, urgency)
+		return
+	}
+	r.markNotified(now)
+
+	body += fmt.Sprintf(" You can postpone it %d more time(s).", remaining)
+	choice := promptFn(title, body, options, timeout)
+	if choice == "" {
+		return
+	}
+	if _, err := r.postpone(choice, scheduledAt, time.Now()); err != nil {
+		log.Warn("could not postpone patch reboot", "choice", choice, "error", err.Error())
+	}
+}
+
+func (r *RebootManager) notify(title, body, urgency string) {
+	if r.notifyFn != nil {
+		r.notifyFn(title, body, urgency)
+	}
+	r.markNotified(time.Now())
+}
+
+func (r *RebootManager) markNotified(at time.Time) {
+	r.mu.Lock()
+	r.state.NotifiedUser = true
+	r.state.NotificationSent = at
+	r.mu.Unlock()
+}
+
+// formatRebootDelay renders a short delay for a user notification.
+func formatRebootDelay(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%d seconds", int(d.Round(time.Second).Seconds()))
+	}
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+func (r *RebootManager) executeReboot() {
+	r.mu.Lock()
+
+	if r.stopped {
+		r.mu.Unlock()
+		return
+	}
+
+	// Circuit breaker: check reboot frequency
+	now := time.Now()
+	recentCount := r.recentRebootsLocked(now)
+
+	if recentCount >= r.maxRebootsPerDay {
+		r.state.RebootScheduled = false
+		r.mu.Unlock()
+
+		log.Warn("reboot blocked by circuit breaker",
+			"recentReboots", recentCount, "maxPerDay", r.maxRebootsPerDay)
+
+		if r.notifyFn != nil {
+			r.notifyFn("Reboot Blocked",
+				fmt.Sprintf("Too many reboots detected (%d in 24h, max %d). Reboot cancelled to prevent reboot loop.",
+					recentCount, r.maxRebootsPerDay),
+				"critical")
+		}
+		return
+	}
+
+	// Record this reboot
+	r.rebootHistory = append(r.rebootHistory, now)
+	r.state.RebootScheduled = false
+	source := r.state.Source
+	r.mu.Unlock()
+
+	// Persist history before rebooting
+	r.saveRebootHistory()
+
+	// Notify user of imminent reboot
+	if r.notifyFn != nil {
+		r.notifyFn("Rebooting Now", "System is rebooting now.", "critical")
+	}
+
+	if r.rebootFn != nil {
+		if err := r.rebootFn(source); err != nil {
+			log.Error("reboot command failed", "source", source, "error", err.Error())
+		}
+	}
+}
+
+func (r *RebootManager) loadRebootHistory() {
+	data, err := os.ReadFile(r.historyPath)
+	if err != nil {
+		return
+	}
+
+	var history []time.Time
+	if err := json.Unmarshal(data, &history); err != nil {
+		return
+	}
+
+	// Only keep entries from last 24 hours
+	cutoff := time.Now().Add(-24 * time.Hour)
+	filtered := make([]time.Time, 0, len(history))
+	for _, t := range history {
+		if t.After(cutoff) {
+			filtered = append(filtered, t)
+		}
+	}
+
+	r.rebootHistory = filtered
+}
+
+func (r *RebootManager) saveRebootHistory() {
+	r.mu.Lock()
+	history := make([]time.Time, len(r.rebootHistory))
+	copy(history, r.rebootHistory)
+	r.mu.Unlock()
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		return
+	}
+
+	dir := filepath.Dir(r.historyPath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		log.Debug("failed to create reboot history dir", "error", err)
+		return
+	}
+	if err := os.WriteFile(r.historyPath, data, 0600); err != nil {
+		log.Debug("failed to write reboot history", "error", err)
+	}
+}
@@ -0,0 +1,284 @@
+package patching
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestRebootManager returns a manager whose OS reboot is recorded rather
+// than performed, with its history kept in a temp dir.
+func newTestRebootManager(t *testing.T, maxPerDay int) (*RebootManager, chan string, *notifications) {
+	t.Helper()
+	notes := &notifications{}
+	rebooted := make(chan string, 4)
+	r := &RebootManager{
+		notifyFn:         notes.add,
+		stopChan:         make(chan struct{}),
+		maxRebootsPerDay: maxPerDay,
+		historyPath:      filepath.Join(t.TempDir(), "reboot_history.json"),
+		rebootFn: func(source string) error {
+			rebooted <- source
+			return nil
+		},
+		abortFn: func() {},
+	}
+	t.Cleanup(r.Stop)
+	return r, rebooted, notes
+}
+
+type notifications struct {
+	mu     sync.Mutex
+	titles []string
+}
+
+func (n *notifications) add(title, _, _ string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.titles = append(n.titles, title)
+}
+
+func (n *notifications) list() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.titles...)
+}
+
+func TestRebootManagerImmediateRebootRecordsHistory(t *testing.T) {
+	r, rebooted, _ := newTestRebootManager(t, 3)
+
+	if err := r.Schedule(0, time.Now(), "test", "manual"); err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	select {
+	case source := <-rebooted:
+		if source != "manual" {
+			t.Errorf("reboot source = %q, want manual", source)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("reboot did not run")
+	}
+
+	// The history is persisted and reloaded by the next manager.
+	reloaded := &RebootManager{historyPath: r.historyPath}
+	reloaded.loadRebootHistory()
+	if len(reloaded.rebootHistory) != 1 {
+		t.Errorf("persisted history = %d entries, want 1", len(reloaded.rebootHistory))
+	}
+}
+
+func TestRebootManagerScheduleRefusedAtDailyLimit(t *testing.T) {
+	r, _, _ := newTestRebootManager(t, 2)
+	r.rebootHistory = []time.Time{time.Now().Add(-time.Hour), time.Now().Add(-2 * time.Hour)}
+
+	err := r.Schedule(time.Hour, time.Now().Add(time.Hour), "test", "patch_job")
+	var loop *ErrRebootLoopDetected
+	if !errors.As(err, &loop) || loop.Count != 2 {
+		t.Fatalf("Schedule error = %v, want ErrRebootLoopDetected with count 2", err)
+	}
+	if r.State().RebootScheduled {
+		t.Error("refused reboot should not be scheduled")
+	}
+
+	// Reboots older than 24h no longer count.
+	r.rebootHistory = []time.Time{time.Now().Add(-25 * time.Hour)}
+	if err := r.Schedule(time.Hour, time.Now().Add(time.Hour), "test", "patch_job"); err != nil {
+		t.Fatalf("Schedule after window: %v", err)
+	}
+}
+
+func TestRebootManagerCancel(t *testing.T) {
+	r, rebooted, notes := newTestRebootManager(t, 3)
+	aborted := false
+	r.abortFn = func() { aborted = true }
+
+	if err := r.Cancel(); err == nil {
+		t.Error("Cancel with nothing scheduled should fail")
+	}
+
+	if err := r.Schedule(2*time.Minute, time.Now().Add(2*time.Minute), "test", "manual"); err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	// A reboot inside 5 minutes is announced right away.
+	deadline := time.Now().Add(2 * time.Second)
+	for len(notes.list()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := notes.list(); len(got) != 1 || got[0] != "Reboot Imminent" {
+		t.Fatalf("notifications = %v, want one immediate warning", got)
+	}
+
+	if err := r.Cancel(); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if !aborted {
+		t.Error("Cancel should abort a pending OS shutdown")
+	}
+	if r.State().RebootScheduled {
+		t.Error("reboot still scheduled after Cancel")
+	}
+	select {
+	case <-rebooted:
+		t.Error("cancelled reboot ran")
+	default:
+	}
+}
+
+func TestFormatRebootDelay(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Second: "30 seconds",
+		time.Minute:      "1 minute",
+		5 * time.Minute:  "5 minutes",
+	}
+	for d, want := range tests {
+		if got := formatRebootDelay(d); got != want {
+			t.Errorf("formatRebootDelay(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+// promptRecorder answers reboot prompts with queued choices and records the
+// actions each prompt offered.
+type promptRecorder struct {
+	mu      sync.Mutex
+	answers []string
+	offered [][]string
+	asked   chan struct{}
+}
+
+func newPromptRecorder(answers ...string) *promptRecorder {
+	return &promptRecorder{answers: answers, asked: make(chan struct{}, 8)}
+}
+
+func (p *promptRecorder) prompt(_, _ string, actions []string, _ time.Duration) string {
+	p.mu.Lock()
+	p.offered = append(p.offered, actions)
+	answer := ""
+	if len(p.answers) > 0 {
+		answer, p.answers = p.answers[0], p.answers[1:]
+	}
+	p.mu.Unlock()
+	p.asked <- struct{}{}
+	return answer
+}
+
+func (p *promptRecorder) offers() [][]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([][]string(nil), p.offered...)
+}
+
+func (p *promptRecorder) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-p.asked:
+	case <-time.After(2 * time.Second):
+		t.Fatal("user was not prompted")
+	}
+}
+
+func TestRebootManagerPatchRebootPostponedByUser(t *testing.T) {
+	r, rebooted, notes := newTestRebootManager(t, 3)
+	prompts := newPromptRecorder(RebootDeferOneHour)
+	r.SetPromptFunc(prompts.prompt)
+	r.SetMaxDeferrals(1)
+
+	if err := r.Schedule(2*time.Minute, time.Now().Add(2*time.Minute), "patches", "patch_job"); err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	prompts.wait(t)
+
+	// Wait for the postpone to land (the prompt returns before it is applied).
+	deadline := time.Now().Add(2 * time.Second)
+	for r.State().Deferrals == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	state := r.State()
+	if state.Deferrals != 1 || len(state.DeferralHistory) != 1 || state.DeferralHistory[0].Choice != RebootDeferOneHour {
+		t.Fatalf("unexpected deferral state %+v", state)
+	}
+	if until := time.Until(state.ScheduledAt); until < 55*time.Minute || until > time.Hour {
+		t.Fatalf("reboot moved to %v from now, want about an hour", until)
+	}
+	if got := prompts.offers()[0]; len(got) == 0 || got[0] != RebootDeferOneHour {
+		t.Fatalf("offered %v, want the one-hour choice first", got)
+	}
+
+	// The limit is used up: the next warning is not a prompt.
+	if _, err := r.Postpone(RebootDeferFourHours); err == nil {
+		t.Fatal("expected a postpone past the limit to fail")
+	}
+	for len(notes.list()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := notes.list(); len(got) != 1 || got[0] != "Reboot Postponed" {
+		t.Fatalf("notifications = %v, want a postpone confirmation", got)
+	}
+	select {
+	case <-rebooted:
+		t.Fatal("postponed reboot ran")
+	default:
+	}
+}
+
+func TestRebootManagerOnlyPromptsForPatchReboots(t *testing.T) {
+	r, _, notes := newTestRebootManager(t, 3)
+	prompts := newPromptRecorder()
+	r.SetPromptFunc(prompts.prompt)
+	r.SetMaxDeferrals(3)
+
+	if err := r.Schedule(2*time.Minute, time.Now().Add(2*time.Minute), "admin", "manual"); err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(notes.list()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := notes.list(); len(got) != 1 || got[0] != "Reboot Imminent" {
+		t.Fatalf("notifications = %v, want a plain warning", got)
+	}
+	if got := prompts.offers(); len(got) != 0 {
+		t.Fatalf("manual reboot prompted with %v", got)
+	}
+}
+
+func TestRebootDeferralOptionsRespectDeadline(t *testing.T) {
+	r, _, _ := newTestRebootManager(t, 3)
+	r.promptFn = newPromptRecorder().prompt
+	now := time.Now()
+	r.state = RebootState{
+		RebootScheduled: true,
+		ScheduledAt:     now.Add(5 * time.Minute),
+		Deadline:        now.Add(2 * time.Hour),
+		Source:          "patch_job",
+		MaxDeferrals:    2,
+	}
+
+	options := r.deferralOptionsLocked(now)
+	if len(options) == 0 || options[0] != RebootDeferOneHour {
+		t.Fatalf("options = %v, want the one-hour choice", options)
+	}
+	for _, option := range options {
+		if option == RebootDeferFourHours {
+			t.Fatalf("options = %v, four hours is past the deadline", options)
+		}
+	}
+
+	r.state.Deferrals = 2
+	if options := r.deferralOptionsLocked(now); options != nil {
+		t.Fatalf("options = %v, want none at the limit", options)
+	}
+}
+
+func TestRebootDeferralTargetTonight(t *testing.T) {
+	evening := time.Date(2026, 10, 18, 15, 0, 0, 0, time.Local)
+	target, ok := rebootDeferralTarget(RebootDeferTonight, evening)
+	if !ok || target.Hour() != rebootTonightHour || target.Day() != 18 {
+		t.Fatalf("tonight from 15:00 = %v (%v)", target, ok)
+	}
+	if _, ok := rebootDeferralTarget(RebootDeferTonight, evening.Add(8*time.Hour)); ok {
+		t.Fatal("tonight from 23:00 should not be offered")
+	}
+}
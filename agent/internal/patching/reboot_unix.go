@@ -0,0 +1,21 @@
+//go:build !windows
+
+package patching
+
+import (
+	"os/exec"
+	"runtime"
+)
+
+// rebootOS restarts the machine now.
+func rebootOS(_ string) error {
+	return exec.Command("shutdown", "-r", "now").Run()
+}
+
+// abortRebootOS cancels a shutdown scheduled with shutdown(8). macOS has
+// no equivalent; the manager's own timer is what it cancels there.
+func abortRebootOS() {
+	if runtime.GOOS == "linux" {
+		exec.Command("shutdown", "-c").Run()
+	}
+}
@@ -0,0 +1,205 @@
+package patching
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Post-reboot patch verification.
+//
+// An install that needs a reboot is reported "installed" as soon as the
+// installer returns, but Windows only commits it while servicing during the
+// restart and may roll it back there. Such patches are recorded here when
+// they install; after the device has rebooted, the next patch scan checks
+// each one against the installed and available lists and reports whether it
+// stuck.
+
+// Post-reboot verification outcomes.
+const (
+	RebootVerifyVerified   = "verified"
+	RebootVerifyRolledBack = "rolled_back"
+	RebootVerifyNotFinal   = "failed_finalization"
+)
+
+// rebootVerifyMaxAge drops records whose reboot never came, so a device
+// that is not restarted does not carry them forever.
+const rebootVerifyMaxAge = 30 * 24 * time.Hour
+
+// RebootPendingPatch is a patch installed successfully that needed a reboot
+// to finish.
+type RebootPendingPatch struct {
+	// InstallID is the provider-prefixed patch ID the install ran with.
+	InstallID string `json:"installId"`
+	Provider  string `json:"provider"`
+	// ID, Source and ExternalID echo the server's reference for the patch.
+	ID          string    `json:"id,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	ExternalID  string    `json:"externalId,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	KBNumber    string    `json:"kbNumber,omitempty"`
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+// RebootVerification is the post-reboot outcome for one recorded patch.
+type RebootVerification struct {
+	RebootPendingPatch
+	Status string `json:"status"`
+}
+
+// RebootVerificationStore persists the patches awaiting post-reboot
+// verification.
+type RebootVerificationStore struct {
+	path    string
+	mu      sync.Mutex
+	pending []RebootPendingPatch
+}
+
+// NewRebootVerificationStore loads (or starts) the store backed by path.
+// An empty path keeps the store in memory only.
+func NewRebootVerificationStore(path string) *RebootVerificationStore {
+	s := &RebootVerificationStore{path: path}
+	if path == "" {
+		return s
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s
+	}
+	if err := json.Unmarshal(data, &s.pending); err != nil {
+		log.Warn("ignoring unreadable reboot verification state", "path", path, "error", err.Error())
+		s.pending = nil
+	}
+	return s
+}
+
+// DefaultRebootVerificationPath is the store location under the agent data dir.
+func DefaultRebootVerificationPath(dataDir string) string {
+	if dataDir == "" {
+		return ""
+	}
+	return filepath.Join(dataDir, "patch_reboot_verify.json")
+}
+
+// Record adds patches awaiting a reboot, replacing older records of the same
+// install ID, and drops records older than rebootVerifyMaxAge.
+func (s *RebootVerificationStore) Record(patches []RebootPendingPatch, now time.Time) error {
+	if len(patches) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replaced := make(map[string]bool, len(patches))
+	for _, p := range patches {
+		replaced[p.InstallID] = true
+	}
+	kept := make([]RebootPendingPatch, 0, len(s.pending)+len(patches))
+	for _, p := range s.pending {
+		if replaced[p.InstallID] || now.Sub(p.InstalledAt) > rebootVerifyMaxAge {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	s.pending = append(kept, patches...)
+	return s.persistLocked()
+}
+
+// DueAt reports whether any record was installed before boot, i.e. its
+// reboot has happened and it can be verified.
+func (s *RebootVerificationStore) DueAt(boot time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.pending {
+		if p.InstalledAt.Before(boot) {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify classifies every record installed before boot whose provider
+// scanned (coveredProviders) against the scan's available and installed
+// lists. A patch offered again did not stick; one neither offered nor
+// installed did not finish. Records are kept until Remove.
+func (s *RebootVerificationStore) Verify(boot time.Time, available []AvailablePatch, installed []InstalledPatch, coveredProviders []string) []RebootVerification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	covered := make(map[string]bool, len(coveredProviders))
+	for _, id := range coveredProviders {
+		covered[id] = true
+	}
+	var results []RebootVerification
+	for _, p := range s.pending {
+		if !p.InstalledAt.Before(boot) || !covered[p.Provider] {
+			continue
+		}
+		status := RebootVerifyNotFinal
+		switch {
+		case containsRebootPatch(available, p, func(a AvailablePatch) (string, string, string) { return a.Provider, a.ID, a.KBNumber }):
+			status = RebootVerifyRolledBack
+		case containsRebootPatch(installed, p, func(i InstalledPatch) (string, string, string) { return i.Provider, i.ID, i.KBNumber }):
+			status = RebootVerifyVerified
+		}
+		results = append(results, RebootVerification{RebootPendingPatch: p, Status: status})
+	}
+	return results
+}
+
+// Remove drops the verified records once their outcome was reported.
+func (s *RebootVerificationStore) Remove(results []RebootVerification) error {
+	if len(results) == 0 {
+		return nil
+	}
+	done := make(map[string]bool, len(results))
+	for _, r := range results {
+		done[r.InstallID] = true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.pending[:0]
+	for _, p := range s.pending {
+		if !done[p.InstallID] {
+			kept = append(kept, p)
+		}
+	}
+	s.pending = kept
+	return s.persistLocked()
+}
+
+// containsRebootPatch matches a record by install ID, or by KB number within
+// the same provider when the provider reports one.
+func containsRebootPatch[T any](items []T, p RebootPendingPatch, key func(T) (provider, id, kb string)) bool {
+	for _, item := range items {
+		provider, id, kb := key(item)
+		if provider != p.Provider {
+			continue
+		}
+		if id == p.InstallID || (p.KBNumber != "" && strings.EqualFold(kb, p.KBNumber)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *RebootVerificationStore) persistLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.pending)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
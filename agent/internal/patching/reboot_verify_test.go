@@ -0,0 +1,73 @@
+package patching
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRebootVerificationStoreClassifiesAfterBoot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "verify.json")
+	store := NewRebootVerificationStore(path)
+
+	boot := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	before := boot.Add(-time.Hour)
+	if err := store.Record([]RebootPendingPatch{
+		{InstallID: "windows-update:a", Provider: "windows-update", InstalledAt: before},
+		{InstallID: "windows-update:b", Provider: "windows-update", InstalledAt: before},
+		{InstallID: "windows-update:c", Provider: "windows-update", KBNumber: "KB5001", InstalledAt: before},
+		{InstallID: "windows-update:d", Provider: "windows-update", InstalledAt: before},
+		{InstallID: "windows-update:e", Provider: "windows-update", InstalledAt: boot.Add(time.Minute)},
+		{InstallID: "homebrew:f", Provider: "homebrew", InstalledAt: before},
+	}, boot); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	// Reloading keeps the records across an agent restart.
+	store = NewRebootVerificationStore(path)
+	available := []AvailablePatch{{ID: "windows-update:b", Provider: "windows-update"}}
+	installed := []InstalledPatch{
+		{ID: "windows-update:a", Provider: "windows-update"},
+		{ID: "windows-update:other", Provider: "windows-update", KBNumber: "kb5001"},
+	}
+	results := store.Verify(boot, available, installed, []string{"windows-update"})
+
+	got := map[string]string{}
+	for _, r := range results {
+		got[r.InstallID] = r.Status
+	}
+	want := map[string]string{
+		"windows-update:a": RebootVerifyVerified,
+		"windows-update:b": RebootVerifyRolledBack,
+		"windows-update:c": RebootVerifyVerified,
+		"windows-update:d": RebootVerifyNotFinal,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d results, got %#v", len(want), got)
+	}
+	for id, status := range want {
+		if got[id] != status {
+			t.Errorf("%s: expected %s, got %q", id, status, got[id])
+		}
+	}
+
+	if err := store.Remove(results); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if !store.DueAt(boot) {
+		t.Fatal("expected the uncovered homebrew record to remain due")
+	}
+	if NewRebootVerificationStore(path).Verify(boot, available, installed, []string{"windows-update"}) != nil {
+		t.Fatal("expected removed records to stay removed")
+	}
+}
+
+func TestRebootVerificationStoreDropsStaleRecords(t *testing.T) {
+	store := NewRebootVerificationStore("")
+	now := time.Now()
+	_ = store.Record([]RebootPendingPatch{{InstallID: "a", Provider: "x", InstalledAt: now.Add(-40 * 24 * time.Hour)}}, now)
+	_ = store.Record([]RebootPendingPatch{{InstallID: "b", Provider: "x", InstalledAt: now}}, now)
+	if store.DueAt(now) {
+		t.Fatal("expected the stale record to be dropped")
+	}
+}
@@ -0,0 +1,111 @@
+//go:build linux
+
+package patching
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseRpmCheckUpdate parses `dnf|yum check-update -q` output: one
+// "name.arch version repo" line per upgradable package.
+func parseRpmCheckUpdate(output []byte) ([]AvailablePatch, error) {
+	scanner := newPatchScanner(output)
+	patches := []AvailablePatch{}
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "Last metadata") || strings.HasPrefix(line, "Obsoleting") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		pkgArch := fields[0]
+		name := pkgArch
+		if idx := strings.LastIndex(pkgArch, "."); idx > 0 {
+			name = pkgArch[:idx]
+		}
+		if err := validateYumPackageName(name); err != nil {
+			continue
+		}
+
+		patches = append(patches, AvailablePatch{
+			ID:      truncatePatchField(name),
+			Title:   truncatePatchField(name),
+			Version: truncatePatchField(fields[1]),
+		})
+		if len(patches) >= patchResultItemLimit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return patches, nil
+}
+
+// rpmInstalledPackages lists installed packages from the rpm database, shared
+// by the yum, dnf and zypper providers.
+func rpmInstalledPackages() ([]InstalledPatch, error) {
+	output, err := commandOutputWithTimeout(patchListTimeout, "rpm", "-qa", "--queryformat", "%{NAME}\t%{VERSION}-%{RELEASE}\n")
+	if err != nil {
+		return nil, fmt.Errorf("rpm query failed: %w", err)
+	}
+
+	scanner := newPatchScanner(output)
+	installed := []InstalledPatch{}
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if err := validateYumPackageName(parts[0]); err != nil {
+			continue
+		}
+		installed = append(installed, InstalledPatch{
+			ID:      truncatePatchField(parts[0]),
+			Title:   truncatePatchField(parts[0]),
+			Version: truncatePatchField(parts[1]),
+		})
+		if len(installed) >= patchResultItemLimit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("rpm query parse failed: %w", err)
+	}
+
+	return installed, nil
+}
+
+// rpmNameFromNEVRA returns the package name of "name-[epoch:]version-release.arch".
+func rpmNameFromNEVRA(nevra string) string {
+	if idx := strings.LastIndex(nevra, "."); idx > 0 {
+		nevra = nevra[:idx]
+	}
+	for i := 0; i < 2; i++ {
+		idx := strings.LastIndex(nevra, "-")
+		if idx <= 0 {
+			return ""
+		}
+		nevra = nevra[:idx]
+	}
+	return nevra
+}
+
+// rpmStageArgs builds the dnf/yum arguments that download an upgrade
+// without installing it, throttled to limitKBps when set.
+func rpmStageArgs(patchID string, limitKBps int) []string {
+	args := []string{"-y", "upgrade", "--downloadonly"}
+	if limitKBps > 0 {
+		args = append(args, fmt.Sprintf("--setopt=throttle=%dk", limitKBps))
+	}
+	return append(args, patchID)
+}
@@ -0,0 +1,133 @@
+//go:build linux
+
+package patching
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var validSnapName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,39}$`)
+
+// SnapProvider integrates with snapd. snapd refreshes snaps on its own
+// schedule; the provider reports refreshes that are still pending and applies
+// them on demand so they follow the same approval flow as other patches.
+type SnapProvider struct{}
+
+// NewSnapProvider creates a new SnapProvider.
+func NewSnapProvider() *SnapProvider {
+	return &SnapProvider{}
+}
+
+// ID returns the provider identifier.
+func (s *SnapProvider) ID() string {
+	return "snap"
+}
+
+// Name returns the human-readable provider name.
+func (s *SnapProvider) Name() string {
+	return "Snap"
+}
+
+// Scan returns snaps with a pending refresh.
+func (s *SnapProvider) Scan() ([]AvailablePatch, error) {
+	// "All snaps up to date." goes to stderr, so stdout alone is the table.
+	output, err := commandOutputWithTimeout(patchScanTimeout, "snap", "refresh", "--list")
+	if err != nil {
+		return nil, fmt.Errorf("snap refresh --list failed: %w", err)
+	}
+
+	patches, err := parseSnapTable(output, func(name, version string) AvailablePatch {
+		return AvailablePatch{
+			ID:      truncatePatchField(name),
+			Title:   truncatePatchField(name),
+			Version: truncatePatchField(version),
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("snap refresh --list parse failed: %w", err)
+	}
+	return patches, nil
+}
+
+// Install refreshes a snap to the latest revision in its tracked channel.
+func (s *SnapProvider) Install(patchID string) (InstallResult, error) {
+	if err := validateSnapName(patchID); err != nil {
+		return InstallResult{}, err
+	}
+
+	output, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "snap", "refresh", patchID)
+	if err != nil {
+		return InstallResult{}, fmt.Errorf("snap refresh failed: %w: %s", err, truncatePatchOutput(output))
+	}
+
+	return InstallResult{
+		PatchID: patchID,
+		Message: truncatePatchOutput(output),
+	}, nil
+}
+
+// Uninstall removes a snap.
+func (s *SnapProvider) Uninstall(patchID string) error {
+	if err := validateSnapName(patchID); err != nil {
+		return err
+	}
+
+	output, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "snap", "remove", patchID)
+	if err != nil {
+		return fmt.Errorf("snap remove failed: %w: %s", err, truncatePatchOutput(output))
+	}
+	return nil
+}
+
+// GetInstalled returns installed snaps using snap list.
+func (s *SnapProvider) GetInstalled() ([]InstalledPatch, error) {
+	output, err := commandOutputWithTimeout(patchListTimeout, "snap", "list")
+	if err != nil {
+		return nil, fmt.Errorf("snap list failed: %w", err)
+	}
+
+	installed, err := parseSnapTable(output, func(name, version string) InstalledPatch {
+		return InstalledPatch{
+			ID:      truncatePatchField(name),
+			Title:   truncatePatchField(name),
+			Version: truncatePatchField(version),
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("snap list parse failed: %w", err)
+	}
+	return installed, nil
+}
+
+// parseSnapTable reads the Name and Version columns of snap list and snap
+// refresh --list, which share a "Name Version Rev ..." header.
+func parseSnapTable[T any](output []byte, build func(name, version string) T) ([]T, error) {
+	scanner := newPatchScanner(output)
+	items := []T{}
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] == "Name" {
+			continue
+		}
+		if err := validateSnapName(fields[0]); err != nil {
+			continue
+		}
+		items = append(items, build(fields[0], fields[1]))
+		if len(items) >= patchResultItemLimit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func validateSnapName(name string) error {
+	if !validSnapName.MatchString(name) {
+		return fmt.Errorf("invalid snap name: %q", name)
+	}
+	return nil
+}
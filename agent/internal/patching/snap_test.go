@@ -0,0 +1,49 @@
+//go:build linux
+
+package patching
+
+import "testing"
+
+func TestParseSnapTableRefreshList(t *testing.T) {
+	output := []byte(`Name     Version        Rev   Size   Publisher     Notes
+firefox  128.0-2        4539  270MB  mozilla✓      -
+core22   20240731       1586  77MB   canonical✓    base
+`)
+	patches, err := parseSnapTable(output, func(name, version string) AvailablePatch {
+		return AvailablePatch{ID: name, Version: version}
+	})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("expected 2 snaps, got %d", len(patches))
+	}
+	if patches[0].ID != "firefox" || patches[0].Version != "128.0-2" {
+		t.Fatalf("unexpected first snap %+v", patches[0])
+	}
+}
+
+func TestParseSnapTableEmpty(t *testing.T) {
+	patches, err := parseSnapTable([]byte(""), func(name, version string) AvailablePatch {
+		return AvailablePatch{ID: name}
+	})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(patches) != 0 {
+		t.Fatalf("expected no snaps, got %d", len(patches))
+	}
+}
+
+func TestValidateSnapName(t *testing.T) {
+	for _, name := range []string{"firefox", "core22", "gnome-42-2204"} {
+		if err := validateSnapName(name); err != nil {
+			t.Errorf("expected %q to be valid: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "-firefox", "Firefox", "fire fox", "a;b"} {
+		if err := validateSnapName(name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
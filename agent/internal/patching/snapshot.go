@@ -0,0 +1,103 @@
+package patching
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrSnapshotUnsupported is returned by CreateSnapshot when the device has no
+// snapshot mechanism the agent knows how to drive.
+var ErrSnapshotUnsupported = errors.New("no supported snapshot mechanism on this system")
+
+// Snapshot kinds.
+const (
+	SnapshotRestorePoint = "restore_point" // Windows System Restore
+	SnapshotTimeshift    = "timeshift"
+	SnapshotBtrfs        = "btrfs"
+	SnapshotLVM          = "lvm"
+	SnapshotAPFS         = "apfs" // Time Machine local snapshot
+)
+
+// Snapshot identifies a system snapshot taken before patching. ID is what the
+// native tool needs to restore or delete it: the restore point sequence
+// number, the Timeshift snapshot name, the btrfs snapshot path, the LVM
+// volume (vg/lv) or the APFS snapshot name.
+type Snapshot struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// createSnapshotOS is the platform implementation; tests replace it.
+var createSnapshotOS = createSnapshot
+
+// CreateSnapshot takes a system snapshot labelled with description so a
+// patch install can be rolled back beyond uninstalling the package.
+func CreateSnapshot(description string) (Snapshot, error) {
+	snap, err := createSnapshotOS(description)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if snap.CreatedAt.IsZero() {
+		snap.CreatedAt = time.Now().UTC()
+	}
+	log.Info("created pre-patch snapshot", "kind", snap.Kind, "id", snap.ID)
+	return snap, nil
+}
+
+// snapshotName is the name used for snapshots the agent creates itself.
+func snapshotName(now time.Time) string {
+	return "breeze-prepatch-" + now.UTC().Format("20060102T150405Z")
+}
+
+var timeshiftTagged = regexp.MustCompile(`Tagged snapshot '([^']+)'`)
+
+// parseTimeshiftSnapshotName reads the snapshot name from the output of
+// timeshift --create.
+func parseTimeshiftSnapshotName(output string) string {
+	if m := timeshiftTagged.FindStringSubmatch(output); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+var tmutilCreated = regexp.MustCompile(`Created local snapshot with date: (\S+)`)
+
+// parseTmutilSnapshotName converts the output of tmutil localsnapshot to
+// the APFS snapshot name tmutil deletelocalsnapshots and the Finder use.
+func parseTmutilSnapshotName(output string) string {
+	if m := tmutilCreated.FindStringSubmatch(output); m != nil {
+		return "com.apple.TimeMachine." + m[1] + ".local"
+	}
+	return ""
+}
+
+// parseFindmntRoot reads "FSTYPE SOURCE" from findmnt -n -o FSTYPE,SOURCE /.
+// A btrfs source carries the subvolume in brackets, which is dropped.
+func parseFindmntRoot(output string) (fstype, source string) {
+	fields := strings.Fields(output)
+	if len(fields) < 2 {
+		return "", ""
+	}
+	source = fields[1]
+	if i := strings.IndexByte(source, '['); i > 0 {
+		source = source[:i]
+	}
+	return fields[0], source
+}
+
+// parseLVMVolume reads "vg/lv" from lvs --noheadings --separator / -o
+// vg_name,lv_name.
+func parseLVMVolume(output string) (vg, lv string) {
+	line := strings.TrimSpace(output)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+	vg, lv, ok := strings.Cut(line, "/")
+	if !ok || vg == "" || lv == "" {
+		return "", ""
+	}
+	return vg, lv
+}
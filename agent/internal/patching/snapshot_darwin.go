@@ -0,0 +1,19 @@
+//go:build darwin
+
+package patching
+
+import "fmt"
+
+// createSnapshot takes an APFS local snapshot of the boot volume through
+// Time Machine. It works without a Time Machine destination configured.
+func createSnapshot(_ string) (Snapshot, error) {
+	out, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "tmutil", "localsnapshot")
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("tmutil localsnapshot failed: %w: %s", err, truncatePatchOutput(out))
+	}
+	name := parseTmutilSnapshotName(string(out))
+	if name == "" {
+		return Snapshot{}, fmt.Errorf("tmutil did not report a snapshot: %s", truncatePatchOutput(out))
+	}
+	return Snapshot{ID: name, Kind: SnapshotAPFS}, nil
+}
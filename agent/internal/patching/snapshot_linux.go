@@ -0,0 +1,89 @@
+//go:build linux
+
+package patching
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// btrfsSnapshotDir holds the read-only root snapshots the agent creates on
+// btrfs systems without Timeshift.
+const btrfsSnapshotDir = "/.breeze-snapshots"
+
+// createSnapshot prefers Timeshift, which users already know how to restore
+// from, then falls back to a btrfs or LVM snapshot of the root filesystem.
+func createSnapshot(description string) (Snapshot, error) {
+	var errs []error
+	if _, err := exec.LookPath("timeshift"); err == nil {
+		snap, err := createTimeshiftSnapshot(description)
+		if err == nil {
+			return snap, nil
+		}
+		errs = append(errs, err)
+	}
+
+	out, err := commandOutputWithTimeout(patchListTimeout, "findmnt", "-n", "-o", "FSTYPE,SOURCE", "/")
+	if err != nil {
+		errs = append(errs, fmt.Errorf("findmnt failed: %w", err))
+		return Snapshot{}, errors.Join(append([]error{ErrSnapshotUnsupported}, errs...)...)
+	}
+	fstype, source := parseFindmntRoot(string(out))
+
+	switch {
+	case fstype == "btrfs":
+		return createBtrfsSnapshot()
+	case strings.HasPrefix(source, "/dev/mapper/") || strings.HasPrefix(source, "/dev/dm-"):
+		return createLVMSnapshot(source)
+	}
+	return Snapshot{}, errors.Join(append([]error{ErrSnapshotUnsupported}, errs...)...)
+}
+
+func createTimeshiftSnapshot(description string) (Snapshot, error) {
+	out, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "timeshift", "--create", "--scripted", "--comments", description)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("timeshift --create failed: %w: %s", err, truncatePatchOutput(out))
+	}
+	name := parseTimeshiftSnapshotName(string(out))
+	if name == "" {
+		return Snapshot{}, fmt.Errorf("timeshift did not report a snapshot name: %s", truncatePatchOutput(out))
+	}
+	return Snapshot{ID: name, Kind: SnapshotTimeshift}, nil
+}
+
+func createBtrfsSnapshot() (Snapshot, error) {
+	if err := os.MkdirAll(btrfsSnapshotDir, 0700); err != nil {
+		return Snapshot{}, fmt.Errorf("create %s: %w", btrfsSnapshotDir, err)
+	}
+	path := filepath.Join(btrfsSnapshotDir, snapshotName(time.Now()))
+	out, err := commandCombinedOutputWithTimeout(patchMutateTimeout, "btrfs", "subvolume", "snapshot", "-r", "/", path)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("btrfs subvolume snapshot failed: %w: %s", err, truncatePatchOutput(out))
+	}
+	return Snapshot{ID: path, Kind: SnapshotBtrfs}, nil
+}
+
+func createLVMSnapshot(source string) (Snapshot, error) {
+	out, err := commandOutputWithTimeout(patchListTimeout, "lvs", "--noheadings", "--separator", "/", "-o", "vg_name,lv_name", source)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("lvs failed: %w", err)
+	}
+	vg, lv := parseLVMVolume(string(out))
+	if vg == "" {
+		return Snapshot{}, fmt.Errorf("%w: root device %s is not a logical volume", ErrSnapshotUnsupported, source)
+	}
+
+	name := snapshotName(time.Now())
+	// 20% of the origin holds the blocks the patch rewrites; the snapshot is
+	// invalidated, not the origin, if that runs out.
+	out, err = commandCombinedOutputWithTimeout(patchMutateTimeout, "lvcreate", "--snapshot", "--name", name, "--extents", "20%ORIGIN", vg+"/"+lv)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("lvcreate --snapshot failed: %w: %s", err, truncatePatchOutput(out))
+	}
+	return Snapshot{ID: vg + "/" + name, Kind: SnapshotLVM}, nil
+}
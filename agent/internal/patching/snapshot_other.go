@@ -0,0 +1,7 @@
+//go:build !windows && !linux && !darwin
+
+package patching
+
+func createSnapshot(_ string) (Snapshot, error) {
+	return Snapshot{}, ErrSnapshotUnsupported
+}
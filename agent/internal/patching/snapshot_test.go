@@ -0,0 +1,73 @@
+package patching
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeshiftSnapshotName(t *testing.T) {
+	out := "Mounted '/dev/sda2' at '/run/timeshift/backup'\n" +
+		"Creating new snapshot...(RSYNC)\n" +
+		"Saving to device: /dev/sda2, mounted at path: /run/timeshift/backup\n" +
+		"Created control file: /run/timeshift/backup/timeshift/snapshots/2026-03-01_10-15-00/info.json\n" +
+		"RSYNC Snapshot saved successfully (41s)\n" +
+		"Tagged snapshot '2026-03-01_10-15-00': ondemand\n"
+	if got := parseTimeshiftSnapshotName(out); got != "2026-03-01_10-15-00" {
+		t.Errorf("parseTimeshiftSnapshotName = %q", got)
+	}
+	if got := parseTimeshiftSnapshotName("E: Snapshot device not selected"); got != "" {
+		t.Errorf("expected no name from an error, got %q", got)
+	}
+}
+
+func TestParseTmutilSnapshotName(t *testing.T) {
+	out := "NOTE: local snapshots are considered purgeable and may be removed at any time by deleted(8).\n" +
+		"Created local snapshot with date: 2026-03-01-101500\n"
+	if got := parseTmutilSnapshotName(out); got != "com.apple.TimeMachine.2026-03-01-101500.local" {
+		t.Errorf("parseTmutilSnapshotName = %q", got)
+	}
+}
+
+func TestParseFindmntRoot(t *testing.T) {
+	tests := []struct {
+		out, fstype, source string
+	}{
+		{"btrfs  /dev/nvme0n1p2[/@]\n", "btrfs", "/dev/nvme0n1p2"},
+		{"ext4 /dev/mapper/vg0-root\n", "ext4", "/dev/mapper/vg0-root"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		fstype, source := parseFindmntRoot(tt.out)
+		if fstype != tt.fstype || source != tt.source {
+			t.Errorf("parseFindmntRoot(%q) = %q, %q", tt.out, fstype, source)
+		}
+	}
+}
+
+func TestParseLVMVolume(t *testing.T) {
+	if vg, lv := parseLVMVolume("  vg0/root\n"); vg != "vg0" || lv != "root" {
+		t.Errorf("parseLVMVolume = %q, %q", vg, lv)
+	}
+	if vg, _ := parseLVMVolume("  \n"); vg != "" {
+		t.Errorf("expected no volume, got %q", vg)
+	}
+}
+
+func TestCreateSnapshotStampsTime(t *testing.T) {
+	orig := createSnapshotOS
+	t.Cleanup(func() { createSnapshotOS = orig })
+	createSnapshotOS = func(string) (Snapshot, error) {
+		return Snapshot{ID: "vg0/breeze-prepatch", Kind: SnapshotLVM}, nil
+	}
+
+	snap, err := CreateSnapshot("test")
+	if err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	if snap.CreatedAt.IsZero() || time.Since(snap.CreatedAt) > time.Minute {
+		t.Errorf("CreatedAt = %v, want now", snap.CreatedAt)
+	}
+	if got := snapshotName(time.Date(2026, 3, 1, 10, 15, 0, 0, time.UTC)); got != "breeze-prepatch-20260301T101500Z" {
+		t.Errorf("snapshotName = %q", got)
+	}
+}
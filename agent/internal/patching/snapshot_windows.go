@@ -0,0 +1,15 @@
+//go:build windows
+
+package patching
+
+import "strconv"
+
+// createSnapshot creates a System Restore point. System Restore must be
+// enabled for the system drive.
+func createSnapshot(description string) (Snapshot, error) {
+	seq, err := setRestorePoint(description)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{ID: strconv.FormatUint(uint64(seq), 10), Kind: SnapshotRestorePoint}, nil
+}
@@ -0,0 +1,162 @@
+package patching
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// ErrInsufficientDiskSpace is returned by StagePatches when the volume that
+// holds the package caches is below the configured free-space floor.
+var ErrInsufficientDiskSpace = errors.New("insufficient disk space to stage patches")
+
+// StageOptions controls a patch_stage download.
+type StageOptions struct {
+	// BandwidthLimitKBps caps the download rate in KiB/s for providers that
+	// support it. Zero means unlimited.
+	BandwidthLimitKBps int
+	// MinFreeDiskGB is the free space that must remain on the cache volume
+	// before each provider starts downloading. Zero skips the check.
+	MinFreeDiskGB float64
+}
+
+// StageableProvider downloads updates into the package manager's own cache
+// without installing them, so a later Install only has to apply them.
+type StageableProvider interface {
+	PatchProvider
+	Stage(patchIDs []string, limitKBps int, progress ProgressCallback) ([]DownloadResult, error)
+}
+
+// freeDiskGB reports free space on the volume at path; tests replace it.
+var freeDiskGB = func(path string) (float64, error) {
+	usage, err := disk.Usage(path)
+	if err != nil {
+		return 0, err
+	}
+	return float64(usage.Free) / (1024 * 1024 * 1024), nil
+}
+
+// stageCacheDir is the volume the package managers download into: apt, dnf,
+// zypper and pacman all cache under /var/cache, Windows Update under the
+// system drive and softwareupdate under /Library/Updates on the root volume.
+func stageCacheDir() string {
+	switch runtime.GOOS {
+	case "windows":
+		systemDrive := os.Getenv("SystemDrive")
+		if systemDrive == "" {
+			systemDrive = "C:"
+		}
+		return systemDrive + "\\"
+	case "linux":
+		return "/var/cache"
+	default:
+		return "/"
+	}
+}
+
+// StagePatches downloads patches by their composite IDs without installing
+// them. Providers implementing StageableProvider honour the bandwidth limit;
+// Windows Update downloads through its DownloadableProvider support, where
+// BITS already yields to foreground traffic. Disk space is checked before each
+// provider so one large batch cannot fill the volume for the next.
+func (m *PatchManager) StagePatches(patchIDs []string, opts StageOptions, progress ProgressCallback) ([]DownloadResult, error) {
+	groups := make(map[string][]string)
+	for _, patchID := range patchIDs {
+		providerID, localID, err := m.splitPatchID(patchID)
+		if err != nil {
+			return nil, err
+		}
+		groups[providerID] = append(groups[providerID], localID)
+	}
+
+	providerIDs := make([]string, 0, len(groups))
+	for providerID := range groups {
+		providerIDs = append(providerIDs, providerID)
+	}
+	sort.Strings(providerIDs)
+
+	var results []DownloadResult
+	fail := func(providerID string, localIDs []string, message string) {
+		for _, id := range localIDs {
+			results = append(results, DownloadResult{
+				PatchID: m.formatPatchID(providerID, id),
+				Success: false,
+				Message: message,
+			})
+		}
+	}
+
+	for _, providerID := range providerIDs {
+		localIDs := groups[providerID]
+		if opts.MinFreeDiskGB > 0 {
+			dir := stageCacheDir()
+			free, err := freeDiskGB(dir)
+			if err != nil {
+				return results, fmt.Errorf("check free space on %s: %w", dir, err)
+			}
+			if free < opts.MinFreeDiskGB {
+				return results, fmt.Errorf("%w: %.1f GB free on %s, minimum %.1f GB", ErrInsufficientDiskSpace, free, dir, opts.MinFreeDiskGB)
+			}
+		}
+
+		provider, ok := m.providerIndex[providerID]
+		if !ok {
+			fail(providerID, localIDs, fmt.Sprintf("unknown provider: %s", providerID))
+			continue
+		}
+
+		var (
+			providerResults []DownloadResult
+			err             error
+		)
+		switch p := provider.(type) {
+		case StageableProvider:
+			providerResults, err = p.Stage(localIDs, opts.BandwidthLimitKBps, progress)
+		case DownloadableProvider:
+			providerResults, err = p.Download(localIDs, progress)
+		default:
+			fail(providerID, localIDs, fmt.Sprintf("provider %s does not support staging", providerID))
+			continue
+		}
+		if err != nil {
+			fail(providerID, localIDs, err.Error())
+			continue
+		}
+
+		for _, r := range providerResults {
+			r.PatchID = m.formatPatchID(providerID, r.PatchID)
+			results = append(results, r)
+		}
+	}
+
+	return results, nil
+}
+
+// stageEach runs download for each package in turn, reporting progress and
+// one result per package. Package-manager providers stage one package per
+// call so a single unavailable package does not fail the rest.
+func stageEach(patchIDs []string, progress ProgressCallback, download func(patchID string) (string, error)) []DownloadResult {
+	results := make([]DownloadResult, 0, len(patchIDs))
+	for i, patchID := range patchIDs {
+		if progress != nil {
+			progress(ProgressEvent{
+				Phase:       "downloading",
+				PatchID:     patchID,
+				Percent:     float64(i) * 100 / float64(len(patchIDs)),
+				CurrentItem: i + 1,
+				TotalItems:  len(patchIDs),
+			})
+		}
+		message, err := download(patchID)
+		if err != nil {
+			results = append(results, DownloadResult{PatchID: patchID, Message: err.Error()})
+			continue
+		}
+		results = append(results, DownloadResult{PatchID: patchID, Success: true, Message: message})
+	}
+	return results
+}
@@ -0,0 +1,95 @@
+package patching
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeStageProvider struct {
+	fakeProvider
+	limits  []int
+	staged  []string
+	failIDs map[string]bool
+}
+
+func (p *fakeStageProvider) Stage(patchIDs []string, limitKBps int, progress ProgressCallback) ([]DownloadResult, error) {
+	p.limits = append(p.limits, limitKBps)
+	return stageEach(patchIDs, progress, func(patchID string) (string, error) {
+		if p.failIDs[patchID] {
+			return "", errors.New("not found")
+		}
+		p.staged = append(p.staged, patchID)
+		return "downloaded", nil
+	}), nil
+}
+
+func stubFreeDisk(t *testing.T, gb float64, err error) {
+	t.Helper()
+	orig := freeDiskGB
+	freeDiskGB = func(string) (float64, error) { return gb, err }
+	t.Cleanup(func() { freeDiskGB = orig })
+}
+
+func TestStagePatchesPassesBandwidthLimitAndDecoratesIDs(t *testing.T) {
+	stubFreeDisk(t, 100, nil)
+	apt := &fakeStageProvider{fakeProvider: fakeProvider{id: "apt"}, failIDs: map[string]bool{"missing": true}}
+	mgr := NewPatchManager(apt)
+
+	var events []ProgressEvent
+	results, err := mgr.StagePatches([]string{"apt:curl", "apt:missing"}, StageOptions{BandwidthLimitKBps: 512, MinFreeDiskGB: 2}, func(e ProgressEvent) {
+		events = append(events, e)
+	})
+	if err != nil {
+		t.Fatalf("StagePatches: %v", err)
+	}
+	if len(apt.limits) != 1 || apt.limits[0] != 512 {
+		t.Fatalf("limits = %v, want [512]", apt.limits)
+	}
+	if len(results) != 2 || results[0].PatchID != "apt:curl" || !results[0].Success {
+		t.Fatalf("results = %+v", results)
+	}
+	if results[1].Success || results[1].Message != "not found" {
+		t.Fatalf("expected apt:missing to fail, got %+v", results[1])
+	}
+	if len(events) != 2 || events[1].CurrentItem != 2 || events[1].TotalItems != 2 {
+		t.Fatalf("events = %+v", events)
+	}
+}
+
+func TestStagePatchesStopsBelowDiskFloor(t *testing.T) {
+	stubFreeDisk(t, 1.5, nil)
+	apt := &fakeStageProvider{fakeProvider: fakeProvider{id: "apt"}}
+	mgr := NewPatchManager(apt)
+
+	_, err := mgr.StagePatches([]string{"apt:curl"}, StageOptions{MinFreeDiskGB: 2}, nil)
+	if !errors.Is(err, ErrInsufficientDiskSpace) {
+		t.Fatalf("err = %v, want ErrInsufficientDiskSpace", err)
+	}
+	if len(apt.staged) != 0 {
+		t.Fatalf("staged %v despite the disk floor", apt.staged)
+	}
+}
+
+func TestStagePatchesSkipsDiskCheckWithoutFloor(t *testing.T) {
+	stubFreeDisk(t, 0, errors.New("statfs failed"))
+	apt := &fakeStageProvider{fakeProvider: fakeProvider{id: "apt"}}
+	mgr := NewPatchManager(apt)
+
+	results, err := mgr.StagePatches([]string{"apt:curl"}, StageOptions{}, nil)
+	if err != nil || len(results) != 1 || !results[0].Success {
+		t.Fatalf("results = %+v, err = %v", results, err)
+	}
+}
+
+func TestStagePatchesReportsUnsupportedProvider(t *testing.T) {
+	stubFreeDisk(t, 100, nil)
+	mgr := NewPatchManager(&fakeProvider{id: "homebrew"})
+
+	results, err := mgr.StagePatches([]string{"homebrew:wget"}, StageOptions{}, nil)
+	if err != nil {
+		t.Fatalf("StagePatches: %v", err)
+	}
+	if len(results) != 1 || results[0].Success || results[0].PatchID != "homebrew:wget" {
+		t.Fatalf("results = %+v, want one unsupported failure", results)
+	}
+}
@@ -48,3 +48,16 @@ type PatchProvider interface {
 	Uninstall(patchID string) error
 	GetInstalled() ([]InstalledPatch, error)
 }
+
+// VersionedInstaller is implemented by providers that can install a specific
+// package version rather than the newest one (app update pin and n-1 rings).
+type VersionedInstaller interface {
+	InstallVersion(patchID, version string) (InstallResult, error)
+}
+
+// PackagePinner is implemented by providers whose package manager has a
+// native pin list, so pinned apps are also held back from out-of-band upgrades.
+type PackagePinner interface {
+	Pin(packageID, version string) error
+	Unpin(packageID string) error
+}
@@ -12,11 +12,8 @@ import (
 	"github.com/go-ole/go-ole/oleutil"
 
 	"github.com/breeze-rmm/agent/internal/config"
-	"github.com/breeze-rmm/agent/internal/logging"
 )
 
-var log = logging.L("patching")
-
 // WUA OperationResultCode constants
 const (
 	wuaResultNotStarted      = 0
@@ -47,11 +44,12 @@ func (w *WindowsUpdateProvider) Name() string {
 	return "Windows Update"
 }
 
-// Scan returns available Windows Updates.
+// Scan returns available Windows Updates. Driver and firmware updates are
+// left to WindowsDriverProvider so they are approved on their own.
 func (w *WindowsUpdateProvider) Scan() ([]AvailablePatch, error) {
 	var patches []AvailablePatch
 	return patches, w.withSession(func(session *ole.IDispatch) error {
-		updates, err := w.searchUpdates(session, "IsInstalled=0")
+		updates, err := w.searchUpdates(session, "IsInstalled=0 and Type='Software'")
 		if err != nil {
 			return err
 		}
@@ -204,11 +202,15 @@ func (w *WindowsUpdateProvider) Uninstall(patchID string) error {
 	})
 }
 
-// GetInstalled returns installed Windows Updates.
+// GetInstalled returns installed Windows Updates, excluding drivers.
 func (w *WindowsUpdateProvider) GetInstalled() ([]InstalledPatch, error) {
+	return w.installedMatching("IsInstalled=1 and Type='Software'")
+}
+
+func (w *WindowsUpdateProvider) installedMatching(criteria string) ([]InstalledPatch, error) {
 	var patches []InstalledPatch
 	return patches, w.withSession(func(session *ole.IDispatch) error {
-		updates, err := w.searchUpdates(session, "IsInstalled=1")
+		updates, err := w.searchUpdates(session, criteria)
 		if err != nil {
 			return err
 		}
@@ -362,7 +364,7 @@ func (w *WindowsUpdateProvider) updateToPatch(update *ole.IDispatch) (AvailableP
 	rebootBehavior, _ := w.getIntProperty(update, "RebootBehavior")
 
 	kbNumber := w.getKBNumber(update)
-	category := w.mapCategory(update)
+	categoryNames := w.categoryNames(update)
 	eulaAccepted, _ := w.getBoolProperty(update, "EulaAccepted")
 
 	// IUpdate exposes no true "release date"; LastDeploymentChangeTime is the
@@ -370,14 +372,26 @@ func (w *WindowsUpdateProvider) updateToPatch(update *ole.IDispatch) (AvailableP
 	// behaviour (collectors/patches_windows.go). Empty when unavailable.
 	releaseDate := w.getDateProperty(update, "LastDeploymentChangeTime")
 
-	// Determine update type: software, driver, or feature
+	// Determine update type: software, driver, or feature. Optional drivers
+	// are BrowseOnly too, so BrowseOnly only marks software as a feature.
 	updateType := "software"
+	category := classifyWindowsUpdateCategory(categoryNames)
+	version := ""
 	typeVal, _ := w.getIntProperty(update, "Type")
+	browseOnly, _ := w.getBoolProperty(update, "BrowseOnly")
 	if typeVal == 2 {
 		updateType = "driver"
-	}
-	browseOnly, _ := w.getBoolProperty(update, "BrowseOnly")
-	if browseOnly {
+		// IWindowsDriverUpdate properties; the driver date is the closest
+		// thing to a version WUA exposes for every driver.
+		driverClass, _ := w.getStringProperty(update, "DriverClass")
+		category = classifyWindowsDriverUpdate(driverClass, categoryNames)
+		version = w.getDateProperty(update, "DriverVerDate")
+		if description == "" {
+			manufacturer, _ := w.getStringProperty(update, "DriverManufacturer")
+			model, _ := w.getStringProperty(update, "DriverModel")
+			description = strings.TrimSpace(manufacturer + " " + model)
+		}
+	} else if browseOnly {
 		updateType = "feature"
 	}
 
@@ -389,6 +403,7 @@ func (w *WindowsUpdateProvider) updateToPatch(update *ole.IDispatch) (AvailableP
 		ID:             updateID,
 		Title:          title,
 		Description:    description,
+		Version:        version,
 		Severity:       strings.ToLower(severity),
 		Category:       category,
 		KBNumber:       kbNumber,
@@ -437,27 +452,32 @@ func (w *WindowsUpdateProvider) getKBNumber(update *ole.IDispatch) string {
 	return kb
 }
 
-// mapCategory extracts and maps the first WUA category to a normalized name.
+// mapCategory maps the update's WUA categories to a normalized name.
 func (w *WindowsUpdateProvider) mapCategory(update *ole.IDispatch) string {
+	return classifyWindowsUpdateCategory(w.categoryNames(update))
+}
+
+// categoryNames returns the names of every WUA category on the update.
+func (w *WindowsUpdateProvider) categoryNames(update *ole.IDispatch) []string {
 	catsVar, err := oleutil.GetProperty(update, "Categories")
 	if err != nil {
-		return "application"
+		return nil
 	}
 
 	cats := catsVar.ToIDispatch()
 	if cats == nil {
-		return "application"
+		return nil
 	}
 	defer cats.Release()
 
 	countVar, err := oleutil.GetProperty(cats, "Count")
 	if err != nil {
-		return "application"
+		return nil
 	}
 	catCount := int(countVar.Val)
 	countVar.Clear()
 	if catCount == 0 {
-		return "application"
+		return nil
 	}
 
 	// Collect ALL category names, not just index 0. A single update carries
@@ -481,7 +501,7 @@ func (w *WindowsUpdateProvider) mapCategory(update *ole.IDispatch) string {
 		}
 	}
 
-	return classifyWindowsUpdateCategory(names)
+	return names
 }
 
 func (w *WindowsUpdateProvider) findUpdate(session *ole.IDispatch, criteria, patchID string) (*ole.IDispatch, error) {
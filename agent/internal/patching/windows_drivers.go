@@ -0,0 +1,65 @@
+//go:build windows
+
+package patching
+
+import (
+	"fmt"
+
+	"github.com/go-ole/go-ole"
+
+	"github.com/breeze-rmm/agent/internal/config"
+)
+
+// WindowsDriverProvider offers the driver and firmware updates Windows Update
+// publishes for this machine's hardware, including the optional ones Settings
+// lists under "Optional updates". OEMs ship drivers, UEFI and dock firmware
+// through this catalog, so it also covers the OEM updates available without a
+// vendor tool. Keeping them in their own provider lets them be approved
+// separately from quality and security updates.
+//
+// Installs, downloads and progress reuse the Windows Update session: the
+// update IDs come from the same catalog.
+type WindowsDriverProvider struct {
+	*WindowsUpdateProvider
+}
+
+// NewWindowsDriverProvider creates a new WindowsDriverProvider.
+func NewWindowsDriverProvider(cfg *config.Config) *WindowsDriverProvider {
+	return &WindowsDriverProvider{WindowsUpdateProvider: NewWindowsUpdateProvider(cfg)}
+}
+
+// ID returns the provider identifier.
+func (d *WindowsDriverProvider) ID() string {
+	return "windows-drivers"
+}
+
+// Name returns the human-readable provider name.
+func (d *WindowsDriverProvider) Name() string {
+	return "Windows Driver Updates"
+}
+
+// Scan returns driver and firmware updates that are not installed.
+func (d *WindowsDriverProvider) Scan() ([]AvailablePatch, error) {
+	var patches []AvailablePatch
+	return patches, d.withSession(func(session *ole.IDispatch) error {
+		updates, err := d.searchUpdates(session, "IsInstalled=0 and Type='Driver'")
+		if err != nil {
+			return err
+		}
+
+		patches = updates
+		return nil
+	})
+}
+
+// GetInstalled returns driver and firmware updates installed through Windows
+// Update.
+func (d *WindowsDriverProvider) GetInstalled() ([]InstalledPatch, error) {
+	return d.installedMatching("IsInstalled=1 and Type='Driver'")
+}
+
+// Uninstall is unsupported: WUA cannot remove a driver update, and rolling a
+// driver back is a per-device action in Device Manager.
+func (d *WindowsDriverProvider) Uninstall(patchID string) error {
+	return fmt.Errorf("driver update %s cannot be rolled back through Windows Update", patchID)
+}
@@ -12,3 +12,12 @@ func RegisterSystemWinget(m *PatchManager, res EnsureResult, run cmdRunner) bool
 	m.RegisterProvider(NewSystemWingetProvider(res.WingetPath, run))
 	return true
 }
+
+// RegisterDeferredSystemWinget registers a SystemWingetProvider that keeps
+// trying to resolve winget through resolve. Used when RegisterSystemWinget
+// could not register at startup, so a machine whose winget appears later
+// (App Installer registered after OOBE or the first sign-in) still gets
+// machine-scope winget patching without an agent restart.
+func RegisterDeferredSystemWinget(m *PatchManager, resolve func() EnsureResult, reason string, run cmdRunner) {
+	m.RegisterProvider(NewDeferredSystemWingetProvider(resolve, reason, run))
+}
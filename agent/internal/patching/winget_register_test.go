@@ -24,3 +24,16 @@ func TestRegisterSystemWinget(t *testing.T) {
 		t.Fatal("winget provider expected")
 	}
 }
+
+func TestRegisterDeferredSystemWinget(t *testing.T) {
+	m := NewPatchManager()
+	RegisterDeferredSystemWinget(m, func() EnsureResult { return EnsureResult{Reason: "x"} }, "x",
+		func(string, []string, time.Duration) (string, string, int, error) { return "", "", 0, nil })
+	if !m.HasProvider("winget") {
+		t.Fatal("deferred winget provider expected")
+	}
+	_, covered, err := m.ScanWithCoverage()
+	if err != nil || len(covered) != 0 {
+		t.Fatalf("expected winget to be skipped, not covered: covered=%v err=%v", covered, err)
+	}
+}
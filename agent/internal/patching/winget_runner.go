@@ -6,6 +6,8 @@ import (
 	"errors"
 	"os/exec"
 	"time"
+
+	"github.com/breeze-rmm/agent/internal/throttle"
 )
 
 // DefaultRunner is the production cmdRunner: it runs name/args via os/exec
@@ -14,8 +16,10 @@ import (
 // reserved for cases where the command could not be started/waited on at
 // all (e.g. binary not found, killed by context deadline). On Windows it
 // hides the console window via hideWindowCmd so SYSTEM-context invocations
-// don't flash a console.
+// don't flash a console. Like every patch provider command it runs as
+// throttled background work.
 func DefaultRunner(name string, args []string, timeout time.Duration) (string, string, int, error) {
+	throttle.Admit(context.Background())
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
@@ -26,7 +30,7 @@ func DefaultRunner(name string, args []string, timeout time.Duration) (string, s
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err := throttle.Run(cmd)
 	if err == nil {
 		return stdout.String(), stderr.String(), 0, nil
 	}
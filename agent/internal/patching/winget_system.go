@@ -3,19 +3,31 @@ package patching
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	systemWingetScanTimeout    = 120 * time.Second
 	systemWingetInstallTimeout = 600 * time.Second
+
+	// systemWingetResolveRetry bounds how often a deferred provider probes
+	// for winget again; EnsureWinget walks WindowsApps and may spawn
+	// PowerShell, so it must not run on every scan.
+	systemWingetResolveRetry = time.Hour
 )
 
 // SystemWingetProvider implements PatchProvider by running the resolved
 // winget.exe directly from the SYSTEM agent process against MACHINE scope.
 type SystemWingetProvider struct {
-	wingetPath string
-	run        cmdRunner
+	run cmdRunner
+
+	mu          sync.Mutex
+	wingetPath  string
+	resolve     func() EnsureResult
+	retry       time.Duration
+	nextResolve time.Time
+	reason      string
 }
 
 // NewSystemWingetProvider constructs a SystemWingetProvider that invokes the
@@ -24,7 +36,49 @@ func NewSystemWingetProvider(wingetPath string, run cmdRunner) *SystemWingetProv
 	return &SystemWingetProvider{wingetPath: wingetPath, run: run}
 }
 
-var _ PatchProvider = (*SystemWingetProvider)(nil)
+// NewDeferredSystemWingetProvider constructs a SystemWingetProvider for a
+// machine where winget could not be resolved at startup — typically a
+// headless server or a freshly imaged machine whose App Installer package is
+// registered only after the first user signs in. Each scan retries resolve,
+// at most once per systemWingetResolveRetry. Scans report ErrScanSkipped until
+// winget turns up, so the server keeps the last reported winget patches;
+// installs fail with the unavailability reason.
+func NewDeferredSystemWingetProvider(resolve func() EnsureResult, reason string, run cmdRunner) *SystemWingetProvider {
+	return &SystemWingetProvider{
+		run:         run,
+		resolve:     resolve,
+		retry:       systemWingetResolveRetry,
+		nextResolve: time.Now().Add(systemWingetResolveRetry),
+		reason:      reason,
+	}
+}
+
+// binary returns the winget executable, resolving it again when the provider
+// was deferred and the retry interval has passed.
+func (p *SystemWingetProvider) binary() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.wingetPath != "" {
+		return p.wingetPath, nil
+	}
+	if p.resolve != nil && !time.Now().Before(p.nextResolve) {
+		p.nextResolve = time.Now().Add(p.retry)
+		res := p.resolve()
+		if res.Available {
+			log.Info("winget resolved after startup", "path", res.WingetPath, "version", res.Version)
+			p.wingetPath, p.reason = res.WingetPath, ""
+			return p.wingetPath, nil
+		}
+		p.reason = res.Reason
+	}
+	return "", fmt.Errorf("winget unavailable: %s", p.reason)
+}
+
+var (
+	_ PatchProvider      = (*SystemWingetProvider)(nil)
+	_ VersionedInstaller = (*SystemWingetProvider)(nil)
+	_ PackagePinner      = (*SystemWingetProvider)(nil)
+)
 
 func (p *SystemWingetProvider) ID() string   { return "winget" }
 func (p *SystemWingetProvider) Name() string { return "winget (Windows Package Manager, machine scope)" }
@@ -39,6 +93,19 @@ func systemInstallArgs(id string) []string {
 		"--accept-package-agreements", "--accept-source-agreements", "--source", "winget", "--disable-interactivity"}
 }
 
+func systemInstallVersionArgs(id, version string) []string {
+	return append(systemInstallArgs(id), "--version", version, "--force")
+}
+
+func systemPinAddArgs(id, version string) []string {
+	return []string{"pin", "add", "--exact", "--id", id, "--version", version, "--force",
+		"--source", "winget", "--accept-source-agreements", "--disable-interactivity"}
+}
+
+func systemPinRemoveArgs(id string) []string {
+	return []string{"pin", "remove", "--exact", "--id", id, "--source", "winget", "--disable-interactivity"}
+}
+
 func systemUninstallArgs(id string) []string {
 	return []string{"uninstall", "--exact", "--id", id, "--scope", "machine", "--silent", "--disable-interactivity"}
 }
@@ -49,7 +116,11 @@ func systemListArgs() []string {
 }
 
 func (p *SystemWingetProvider) Scan() ([]AvailablePatch, error) {
-	stdout, stderr, code, err := p.run(p.wingetPath, systemScanArgs(), systemWingetScanTimeout)
+	wingetPath, err := p.binary()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScanSkipped, err)
+	}
+	stdout, stderr, code, err := p.run(wingetPath, systemScanArgs(), systemWingetScanTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("winget upgrade failed: %w", err)
 	}
@@ -63,7 +134,27 @@ func (p *SystemWingetProvider) Install(patchID string) (InstallResult, error) {
 	if !validWingetPkgID.MatchString(patchID) {
 		return InstallResult{}, fmt.Errorf("invalid winget package ID: %q", patchID)
 	}
-	stdout, stderr, code, err := p.run(p.wingetPath, systemInstallArgs(patchID), systemWingetInstallTimeout)
+	return p.install(patchID, systemInstallArgs(patchID))
+}
+
+// InstallVersion installs an exact package version (app update pin / n-1
+// rings). --force lets winget move to an older version than installed.
+func (p *SystemWingetProvider) InstallVersion(patchID, version string) (InstallResult, error) {
+	if !validWingetPkgID.MatchString(patchID) {
+		return InstallResult{}, fmt.Errorf("invalid winget package ID: %q", patchID)
+	}
+	if !validPackageVersion.MatchString(version) {
+		return InstallResult{}, fmt.Errorf("invalid package version: %q", version)
+	}
+	return p.install(patchID, systemInstallVersionArgs(patchID, version))
+}
+
+func (p *SystemWingetProvider) install(patchID string, args []string) (InstallResult, error) {
+	wingetPath, err := p.binary()
+	if err != nil {
+		return InstallResult{}, err
+	}
+	stdout, stderr, code, err := p.run(wingetPath, args, systemWingetInstallTimeout)
 	if err != nil {
 		return InstallResult{}, fmt.Errorf("winget install failed: %w", err)
 	}
@@ -83,7 +174,11 @@ func (p *SystemWingetProvider) Uninstall(patchID string) error {
 	if !validWingetPkgID.MatchString(patchID) {
 		return fmt.Errorf("invalid winget package ID: %q", patchID)
 	}
-	_, stderr, code, err := p.run(p.wingetPath, systemUninstallArgs(patchID), systemWingetInstallTimeout)
+	wingetPath, err := p.binary()
+	if err != nil {
+		return err
+	}
+	_, stderr, code, err := p.run(wingetPath, systemUninstallArgs(patchID), systemWingetInstallTimeout)
 	if err != nil {
 		return fmt.Errorf("winget uninstall failed: %w", err)
 	}
@@ -93,8 +188,46 @@ func (p *SystemWingetProvider) Uninstall(patchID string) error {
 	return nil
 }
 
+// Pin adds a winget pin so `winget upgrade` holds the package at version.
+func (p *SystemWingetProvider) Pin(packageID, version string) error {
+	if !validWingetPkgID.MatchString(packageID) {
+		return fmt.Errorf("invalid winget package ID: %q", packageID)
+	}
+	if !validPackageVersion.MatchString(version) {
+		return fmt.Errorf("invalid package version: %q", version)
+	}
+	return p.runPin(systemPinAddArgs(packageID, version), "pin add")
+}
+
+// Unpin removes the winget pin for packageID.
+func (p *SystemWingetProvider) Unpin(packageID string) error {
+	if !validWingetPkgID.MatchString(packageID) {
+		return fmt.Errorf("invalid winget package ID: %q", packageID)
+	}
+	return p.runPin(systemPinRemoveArgs(packageID), "pin remove")
+}
+
+func (p *SystemWingetProvider) runPin(args []string, op string) error {
+	wingetPath, err := p.binary()
+	if err != nil {
+		return err
+	}
+	stdout, stderr, code, err := p.run(wingetPath, args, systemWingetScanTimeout)
+	if err != nil {
+		return fmt.Errorf("winget %s failed: %w", op, err)
+	}
+	if code != 0 {
+		return fmt.Errorf("winget %s failed (exit %d): %s", op, code, strings.TrimSpace(stdout+"\n"+stderr))
+	}
+	return nil
+}
+
 func (p *SystemWingetProvider) GetInstalled() ([]InstalledPatch, error) {
-	stdout, stderr, code, err := p.run(p.wingetPath, systemListArgs(), systemWingetScanTimeout)
+	wingetPath, err := p.binary()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScanSkipped, err)
+	}
+	stdout, stderr, code, err := p.run(wingetPath, systemListArgs(), systemWingetScanTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("winget list failed: %w", err)
 	}
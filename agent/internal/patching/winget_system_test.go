@@ -1,6 +1,7 @@
 package patching
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -59,3 +60,65 @@ func TestSystemInstallSuccess(t *testing.T) {
 		t.Fatalf("got %+v", res)
 	}
 }
+
+func TestDeferredSystemWingetSkipsUntilResolved(t *testing.T) {
+	out := "Name    Id               Version  Available Source\n" +
+		"-----------------------------------------------------\n" +
+		"Firefox Mozilla.Firefox   1.0      2.0       winget\n"
+	available := false
+	resolves := 0
+	p := NewDeferredSystemWingetProvider(func() EnsureResult {
+		resolves++
+		if !available {
+			return EnsureResult{Reason: "winget absent and Appx provisioning unavailable"}
+		}
+		return EnsureResult{Available: true, WingetPath: `C:\wg\winget.exe`, Version: "1.8.0"}
+	}, "winget absent at startup", func(name string, _ []string, _ time.Duration) (string, string, int, error) {
+		if name != `C:\wg\winget.exe` {
+			t.Fatalf("unexpected winget path %q", name)
+		}
+		return out, "", 0, nil
+	})
+
+	// Within the retry interval the startup failure stands without re-probing.
+	if _, err := p.Scan(); !errors.Is(err, ErrScanSkipped) || !strings.Contains(err.Error(), "at startup") {
+		t.Fatalf("expected a skipped scan with the startup reason, got %v", err)
+	}
+	if _, err := p.Install("Mozilla.Firefox"); err == nil || errors.Is(err, ErrScanSkipped) {
+		t.Fatalf("expected a plain install error, got %v", err)
+	}
+	if resolves != 0 {
+		t.Fatalf("expected no re-resolve inside the retry interval, got %d", resolves)
+	}
+
+	p.nextResolve = time.Time{}
+	if _, err := p.GetInstalled(); !errors.Is(err, ErrScanSkipped) {
+		t.Fatalf("expected a skipped installed scan, got %v", err)
+	}
+	if resolves != 1 {
+		t.Fatalf("expected one re-resolve, got %d", resolves)
+	}
+
+	available = true
+	p.nextResolve = time.Time{}
+	patches, err := p.Scan()
+	if err != nil || len(patches) != 1 {
+		t.Fatalf("expected the scan to run once winget resolved, got %+v (%v)", patches, err)
+	}
+	if _, err := p.Scan(); err != nil || resolves != 2 {
+		t.Fatalf("expected the resolved path to be kept, got %v after %d resolves", err, resolves)
+	}
+}
+
+func TestManagerGetInstalledIgnoresSkippedProvider(t *testing.T) {
+	m := NewPatchManager(NewDeferredSystemWingetProvider(func() EnsureResult {
+		return EnsureResult{Reason: "absent"}
+	}, "absent", func(string, []string, time.Duration) (string, string, int, error) {
+		t.Fatal("must not exec without winget")
+		return "", "", 0, nil
+	}))
+	installed, err := m.GetInstalled()
+	if err != nil || len(installed) != 0 {
+		t.Fatalf("expected no installed patches and no error, got %+v (%v)", installed, err)
+	}
+}
@@ -37,41 +37,10 @@ func (y *YumProvider) Scan() ([]AvailablePatch, error) {
 		}
 	}
 
-	scanner := newPatchScanner(output)
-	patches := []AvailablePatch{}
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "Last metadata") || strings.HasPrefix(line, "Obsoleting") {
-			continue
-		}
-
-		fields := strings.Fields(line)
-		if len(fields) < 2 {
-			continue
-		}
-
-		pkgArch := fields[0]
-		name := pkgArch
-		if idx := strings.LastIndex(pkgArch, "."); idx > 0 {
-			name = pkgArch[:idx]
-		}
-		if err := validateYumPackageName(name); err != nil {
-			continue
-		}
-
-		patches = append(patches, AvailablePatch{
-			ID:      truncatePatchField(name),
-			Title:   truncatePatchField(name),
-			Version: truncatePatchField(fields[1]),
-		})
-		if len(patches) >= patchResultItemLimit {
-			break
-		}
-	}
-	if err := scanner.Err(); err != nil {
+	patches, err := parseRpmCheckUpdate(output)
+	if err != nil {
 		return nil, fmt.Errorf("%s check-update parse failed: %w", mgr, err)
 	}
-
 	return patches, nil
 }
 
@@ -100,6 +69,25 @@ func (y *YumProvider) Install(patchID string) (InstallResult, error) {
 	}, nil
 }
 
+// Stage downloads a package upgrade into the dnf/yum cache without
+// installing it. limitKBps maps to the throttle option.
+func (y *YumProvider) Stage(patchIDs []string, limitKBps int, progress ProgressCallback) ([]DownloadResult, error) {
+	mgr, err := detectYumManager()
+	if err != nil {
+		return nil, err
+	}
+	return stageEach(patchIDs, progress, func(patchID string) (string, error) {
+		if err := validateYumPackageName(patchID); err != nil {
+			return "", err
+		}
+		output, err := commandCombinedOutputWithTimeout(patchMutateTimeout, mgr, rpmStageArgs(patchID, limitKBps)...)
+		if err != nil {
+			return "", fmt.Errorf("%s download failed: %w: %s", mgr, err, truncatePatchOutput(output))
+		}
+		return truncatePatchOutput(output), nil
+	}), nil
+}
+
 func (y *YumProvider) Uninstall(patchID string) error {
 	mgr, err := detectYumManager()
 	if err != nil {
@@ -118,39 +106,7 @@ func (y *YumProvider) Uninstall(patchID string) error {
 }
 
 func (y *YumProvider) GetInstalled() ([]InstalledPatch, error) {
-	output, err := commandOutputWithTimeout(patchListTimeout, "rpm", "-qa", "--queryformat", "%{NAME}\t%{VERSION}-%{RELEASE}\n")
-	if err != nil {
-		return nil, fmt.Errorf("rpm query failed: %w", err)
-	}
-
-	scanner := newPatchScanner(output)
-	installed := []InstalledPatch{}
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-		parts := strings.SplitN(line, "\t", 2)
-		if len(parts) != 2 {
-			continue
-		}
-		if err := validateYumPackageName(parts[0]); err != nil {
-			continue
-		}
-		installed = append(installed, InstalledPatch{
-			ID:      truncatePatchField(parts[0]),
-			Title:   truncatePatchField(parts[0]),
-			Version: truncatePatchField(parts[1]),
-		})
-		if len(installed) >= patchResultItemLimit {
-			break
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("rpm query parse failed: %w", err)
-	}
-
-	return installed, nil
+	return rpmInstalledPackages()
 }
 
 func detectYumManager() (string, error) {
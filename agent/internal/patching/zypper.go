@@ -0,0 +1,232 @@
+//go:build linux
+
+package patching
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// zypperPatchPrefix marks a SUSE patch (an advisory that updates a set of
+// packages) as opposed to a single package update.
+const zypperPatchPrefix = "patch:"
+
+// zypper informational exit codes, which are not failures.
+const (
+	zypperExitRebootNeeded = 102
+	zypperExitRestartSelf  = 103
+)
+
+// ZypperProvider integrates with zypper on SLES and openSUSE. It reports
+// package updates and, where the repositories publish them, SUSE patches
+// carrying the advisory category and severity.
+type ZypperProvider struct{}
+
+// NewZypperProvider creates a new ZypperProvider.
+func NewZypperProvider() *ZypperProvider {
+	return &ZypperProvider{}
+}
+
+// ID returns the provider identifier.
+func (z *ZypperProvider) ID() string {
+	return "zypper"
+}
+
+// Name returns the human-readable provider name.
+func (z *ZypperProvider) Name() string {
+	return "Zypper"
+}
+
+// zypperStream is the root of zypper --xmlout output.
+type zypperStream struct {
+	XMLName xml.Name       `xml:"stream"`
+	Updates []zypperUpdate `xml:"update-status>update-list>update"`
+}
+
+type zypperUpdate struct {
+	Kind     string `xml:"kind,attr"`
+	Name     string `xml:"name,attr"`
+	Edition  string `xml:"edition,attr"`
+	Category string `xml:"category,attr"`
+	Severity string `xml:"severity,attr"`
+	Restart  string `xml:"restart,attr"`
+	Summary  string `xml:"summary"`
+}
+
+// Scan returns pending package updates and needed patches.
+func (z *ZypperProvider) Scan() ([]AvailablePatch, error) {
+	output, err := z.query("list-updates")
+	if err != nil {
+		return nil, fmt.Errorf("zypper list-updates failed: %w", err)
+	}
+	patches, err := parseZypperUpdates(output)
+	if err != nil {
+		return nil, fmt.Errorf("zypper list-updates parse failed: %w", err)
+	}
+
+	// openSUSE Tumbleweed and third-party repositories publish no patches;
+	// a failing list-patches still leaves the package list.
+	patchOutput, err := z.query("list-patches")
+	if err != nil {
+		log.Debug("zypper list-patches failed, reporting packages only", "error", err)
+		return patches, nil
+	}
+	advisories, err := parseZypperUpdates(patchOutput)
+	if err != nil {
+		log.Debug("zypper list-patches parse failed, reporting packages only", "error", err)
+		return patches, nil
+	}
+	for _, p := range advisories {
+		if len(patches) >= patchResultItemLimit {
+			break
+		}
+		patches = append(patches, p)
+	}
+	return patches, nil
+}
+
+// parseZypperUpdates converts list-updates or list-patches XML to patches.
+func parseZypperUpdates(output []byte) ([]AvailablePatch, error) {
+	var stream zypperStream
+	if err := xml.Unmarshal(output, &stream); err != nil {
+		return nil, err
+	}
+
+	patches := []AvailablePatch{}
+	for _, u := range stream.Updates {
+		if err := validateYumPackageName(u.Name); err != nil {
+			continue
+		}
+		switch u.Kind {
+		case "package":
+			patches = append(patches, AvailablePatch{
+				ID:          truncatePatchField(u.Name),
+				Title:       truncatePatchField(u.Name),
+				Description: truncatePatchDescription(u.Summary),
+				Version:     truncatePatchField(u.Edition),
+			})
+		case "patch":
+			title := u.Summary
+			if title == "" {
+				title = u.Name
+			}
+			p := AvailablePatch{
+				ID:             truncatePatchField(zypperPatchPrefix + u.Name),
+				Title:          truncatePatchField(title),
+				Description:    truncatePatchDescription(u.Name),
+				Version:        truncatePatchField(u.Edition),
+				Category:       zypperCategory(u.Category),
+				RebootRequired: u.Restart == "true",
+			}
+			if p.Category == "security" {
+				p.Severity = normalizeLinuxSeverity(u.Severity)
+			}
+			patches = append(patches, p)
+		default:
+			continue
+		}
+		if len(patches) >= patchResultItemLimit {
+			break
+		}
+	}
+	return patches, nil
+}
+
+func zypperCategory(category string) string {
+	switch strings.ToLower(category) {
+	case "security":
+		return "security"
+	case "feature":
+		return "feature"
+	}
+	return "system"
+}
+
+// Install updates a package, or installs a patch for "patch:<name>" IDs.
+func (z *ZypperProvider) Install(patchID string) (InstallResult, error) {
+	args := []string{"update"}
+	name := patchID
+	if strings.HasPrefix(patchID, zypperPatchPrefix) {
+		name = strings.TrimPrefix(patchID, zypperPatchPrefix)
+		args = []string{"install", "--type", "patch"}
+	}
+	if err := validateYumPackageName(name); err != nil {
+		return InstallResult{}, err
+	}
+
+	output, err := z.mutate(append(args, name)...)
+	rebootRequired := commandExitCode(err) == zypperExitRebootNeeded
+	if err != nil && !rebootRequired {
+		return InstallResult{}, fmt.Errorf("zypper %s failed: %w: %s", args[0], err, truncatePatchOutput(output))
+	}
+
+	return InstallResult{
+		PatchID:        patchID,
+		RebootRequired: rebootRequired,
+		Message:        truncatePatchOutput(output),
+	}, nil
+}
+
+// Stage downloads a package or patch into the zypper cache without
+// installing it. zypper has no rate-limit option, so limitKBps is not applied.
+func (z *ZypperProvider) Stage(patchIDs []string, _ int, progress ProgressCallback) ([]DownloadResult, error) {
+	return stageEach(patchIDs, progress, func(patchID string) (string, error) {
+		args := []string{"update", "--download-only"}
+		name := patchID
+		if strings.HasPrefix(patchID, zypperPatchPrefix) {
+			name = strings.TrimPrefix(patchID, zypperPatchPrefix)
+			args = []string{"install", "--download-only", "--type", "patch"}
+		}
+		if err := validateYumPackageName(name); err != nil {
+			return "", err
+		}
+		output, err := z.mutate(append(args, name)...)
+		if err != nil {
+			return "", fmt.Errorf("zypper download failed: %w: %s", err, truncatePatchOutput(output))
+		}
+		return truncatePatchOutput(output), nil
+	}), nil
+}
+
+// Uninstall removes a package. Patches cannot be removed as a unit.
+func (z *ZypperProvider) Uninstall(patchID string) error {
+	if strings.HasPrefix(patchID, zypperPatchPrefix) {
+		return fmt.Errorf("zypper patches cannot be uninstalled; roll back the packages they updated instead")
+	}
+	if err := validateYumPackageName(patchID); err != nil {
+		return err
+	}
+
+	output, err := z.mutate("remove", patchID)
+	if err != nil {
+		return fmt.Errorf("zypper remove failed: %w: %s", err, truncatePatchOutput(output))
+	}
+	return nil
+}
+
+// GetInstalled returns installed packages from the rpm database.
+func (z *ZypperProvider) GetInstalled() ([]InstalledPatch, error) {
+	return rpmInstalledPackages()
+}
+
+// query runs a zypper listing with XML output. Only stdout is kept so
+// warnings on stderr cannot corrupt the XML.
+func (z *ZypperProvider) query(command string) ([]byte, error) {
+	return zypperResult(commandOutputWithTimeout(patchScanTimeout, "zypper", "--non-interactive", "--xmlout", command))
+}
+
+// mutate runs a zypper change non-interactively. "Reboot needed" is left to
+// the caller.
+func (z *ZypperProvider) mutate(args ...string) ([]byte, error) {
+	return zypperResult(commandCombinedOutputWithTimeout(patchMutateTimeout, "zypper", append([]string{"--non-interactive"}, args...)...))
+}
+
+// zypperResult treats "restart zypper" as success: the operation completed
+// and only zypper itself was updated.
+func zypperResult(output []byte, err error) ([]byte, error) {
+	if commandExitCode(err) == zypperExitRestartSelf {
+		return output, nil
+	}
+	return output, err
+}
@@ -0,0 +1,69 @@
+//go:build linux
+
+package patching
+
+import "testing"
+
+func TestParseZypperUpdatesPackages(t *testing.T) {
+	output := []byte(`<?xml version='1.0'?>
+<stream>
+<message type="info">Loading repository data...</message>
+<update-status version="0.6">
+<update-list>
+<update kind="package" name="curl" edition="8.6.0-4.1" arch="x86_64"><summary>A Tool for Transferring Data from URLs</summary></update>
+<update kind="package" name="bad;name" edition="1.0" arch="x86_64"/>
+</update-list>
+</update-status>
+</stream>`)
+	patches, err := parseZypperUpdates(output)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(patches) != 1 {
+		t.Fatalf("expected 1 patch, got %d", len(patches))
+	}
+	if p := patches[0]; p.ID != "curl" || p.Version != "8.6.0-4.1" || p.Category != "" {
+		t.Fatalf("unexpected package update %+v", p)
+	}
+}
+
+func TestParseZypperUpdatesSecurityPatch(t *testing.T) {
+	output := []byte(`<?xml version='1.0'?>
+<stream>
+<update-status version="0.6">
+<update-list>
+<update kind="patch" name="SUSE-SLE-Module-Basesystem-15-SP5-2024-1234" edition="1" category="security" severity="important" restart="true"><summary>Security update for openssl-3</summary></update>
+<update kind="patch" name="openSUSE-2024-77" edition="1" category="recommended" severity="moderate" restart="false"><summary>Recommended update for zypper</summary></update>
+</update-list>
+</update-status>
+</stream>`)
+	patches, err := parseZypperUpdates(output)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("expected 2 patches, got %d", len(patches))
+	}
+
+	sec := patches[0]
+	if sec.ID != "patch:SUSE-SLE-Module-Basesystem-15-SP5-2024-1234" {
+		t.Fatalf("unexpected patch ID %q", sec.ID)
+	}
+	if sec.Category != "security" || sec.Severity != "important" || !sec.RebootRequired {
+		t.Fatalf("unexpected security patch %+v", sec)
+	}
+	if sec.Title != "Security update for openssl-3" {
+		t.Fatalf("unexpected title %q", sec.Title)
+	}
+
+	rec := patches[1]
+	if rec.Category != "system" || rec.Severity != "" || rec.RebootRequired {
+		t.Fatalf("recommended patch should not carry a severity, got %+v", rec)
+	}
+}
+
+func TestParseZypperUpdatesRejectsInvalidXML(t *testing.T) {
+	if _, err := parseZypperUpdates([]byte("Repository 'x' is invalid.")); err == nil {
+		t.Fatal("expected an error for non-XML output")
+	}
+}
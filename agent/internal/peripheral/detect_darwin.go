@@ -24,7 +24,15 @@ type spUSBItem struct {
 }
 
 type spMedia struct {
-	Name string `json:"_name"`
+	Name    string     `json:"_name"`
+	BSDName string     `json:"bsd_name,omitempty"`
+	Volumes []spVolume `json:"volumes,omitempty"`
+}
+
+type spVolume struct {
+	Name       string `json:"_name"`
+	BSDName    string `json:"bsd_name,omitempty"`
+	MountPoint string `json:"mount_point,omitempty"`
 }
 
 // DetectPeripherals enumerates USB devices via system_profiler on macOS.
@@ -35,8 +43,8 @@ func DetectPeripherals() ([]DetectedPeripheral, error) {
 		return nil, err
 	}
 
-	var data spUSBDataType
-	if err := json.Unmarshal(output, &data); err != nil {
+	data, err := parseUSBProfile(output)
+	if err != nil {
 		return nil, err
 	}
 
@@ -47,6 +55,18 @@ func DetectPeripherals() ([]DetectedPeripheral, error) {
 	return result, nil
 }
 
+func parseUSBProfile(output []byte) (spUSBDataType, error) {
+	var data spUSBDataType
+	err := json.Unmarshal(output, &data)
+	return data, err
+}
+
+// darwinDeviceID is the DeviceID reported for a USB item ("vid:pid"), which
+// the macOS enforcer resolves back to the item's media.
+func darwinDeviceID(item spUSBItem) string {
+	return strings.TrimSpace(item.VendorID + ":" + item.ProductID)
+}
+
 func collectUSBItems(item spUSBItem, out *[]DetectedPeripheral) {
 	// Skip hub entries (they have sub-items but no useful info)
 	if item.Name != "" && !isHub(item.Name) {
@@ -66,7 +86,7 @@ func collectUSBItems(item spUSBItem, out *[]DetectedPeripheral) {
 			Product:        item.Name,
 			SerialNumber:   item.SerialNum,
 			DeviceClass:    devClass,
-			DeviceID:       strings.TrimSpace(item.VendorID + ":" + item.ProductID),
+			DeviceID:       darwinDeviceID(item),
 		})
 	}
 
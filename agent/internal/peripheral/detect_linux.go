@@ -0,0 +1,87 @@
+//go:build linux
+
+package peripheral
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const linuxUSBSysfsRoot = "/sys/bus/usb/devices"
+
+// sysfsUSBDeviceName matches USB device directories ("1-2", "3-1.4.2").
+// Root hubs ("usb1") and interfaces ("1-2:1.0") do not match.
+var sysfsUSBDeviceName = regexp.MustCompile(`^[0-9]+-[0-9]+(\.[0-9]+)*$`)
+
+// DetectPeripherals enumerates USB devices from sysfs on Linux.
+func DetectPeripherals() ([]DetectedPeripheral, error) {
+	return detectSysfsPeripherals(linuxUSBSysfsRoot)
+}
+
+// detectSysfsPeripherals reads USB devices under root. DeviceID is the sysfs
+// device name, which is what the Linux enforcer deauthorizes. Hubs and devices
+// already deauthorized (authorized=0, i.e. blocked) are skipped.
+func detectSysfsPeripherals(root string) ([]DetectedPeripheral, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var result []DetectedPeripheral
+	for _, entry := range entries {
+		name := entry.Name()
+		if !sysfsUSBDeviceName.MatchString(name) {
+			continue
+		}
+		dir := filepath.Join(root, name)
+		if readSysfs(dir, "bDeviceClass") == "09" || readSysfs(dir, "authorized") == "0" {
+			continue
+		}
+		vid := readSysfs(dir, "idVendor")
+		pid := readSysfs(dir, "idProduct")
+		if vid == "" || pid == "" {
+			continue
+		}
+
+		devClass := "all_usb"
+		ifaces, _ := filepath.Glob(filepath.Join(dir, name+":*"))
+		for _, iface := range ifaces {
+			if readSysfs(iface, "bInterfaceClass") == "08" {
+				devClass = "storage"
+				break
+			}
+		}
+
+		vendor := readSysfs(dir, "manufacturer")
+		if vendor == "" {
+			vendor = vid
+		}
+		product := readSysfs(dir, "product")
+		if product == "" {
+			product = vid + ":" + pid
+		}
+
+		result = append(result, DetectedPeripheral{
+			PeripheralType: "usb",
+			Vendor:         vendor,
+			Product:        product,
+			SerialNumber:   readSysfs(dir, "serial"),
+			DeviceClass:    devClass,
+			DeviceID:       name,
+		})
+	}
+	return result, nil
+}
+
+func readSysfs(dir, attr string) string {
+	data, err := os.ReadFile(filepath.Join(dir, attr))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
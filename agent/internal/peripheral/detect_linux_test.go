@@ -0,0 +1,71 @@
+//go:build linux
+
+package peripheral
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSysfsAttrs(t *testing.T, dir string, attrs map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range attrs {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(value+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetectSysfsPeripherals(t *testing.T) {
+	root := t.TempDir()
+	// Flash drive: mass-storage interface.
+	writeSysfsAttrs(t, filepath.Join(root, "1-2"), map[string]string{
+		"idVendor": "0781", "idProduct": "5583", "manufacturer": "SanDisk",
+		"product": "Ultra", "serial": "4C530001", "bDeviceClass": "00", "authorized": "1",
+	})
+	writeSysfsAttrs(t, filepath.Join(root, "1-2", "1-2:1.0"), map[string]string{"bInterfaceClass": "08"})
+	// Keyboard behind a hub, no strings.
+	writeSysfsAttrs(t, filepath.Join(root, "1-3.1"), map[string]string{
+		"idVendor": "046d", "idProduct": "c31c", "bDeviceClass": "00", "authorized": "1",
+	})
+	writeSysfsAttrs(t, filepath.Join(root, "1-3.1", "1-3.1:1.0"), map[string]string{"bInterfaceClass": "03"})
+	// Hub, root hub, interface entry and an already-blocked device are skipped.
+	writeSysfsAttrs(t, filepath.Join(root, "1-3"), map[string]string{"idVendor": "05e3", "idProduct": "0610", "bDeviceClass": "09"})
+	writeSysfsAttrs(t, filepath.Join(root, "usb1"), map[string]string{"idVendor": "1d6b", "idProduct": "0002", "bDeviceClass": "09"})
+	writeSysfsAttrs(t, filepath.Join(root, "1-2:1.0"), map[string]string{"bInterfaceClass": "08"})
+	writeSysfsAttrs(t, filepath.Join(root, "2-1"), map[string]string{
+		"idVendor": "0951", "idProduct": "1666", "bDeviceClass": "00", "authorized": "0",
+	})
+
+	got, err := detectSysfsPeripherals(root)
+	if err != nil {
+		t.Fatalf("detectSysfsPeripherals: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 devices, got %+v", got)
+	}
+	byID := map[string]DetectedPeripheral{}
+	for _, dev := range got {
+		byID[dev.DeviceID] = dev
+	}
+
+	drive := byID["1-2"]
+	if drive.DeviceClass != "storage" || drive.Vendor != "SanDisk" || drive.Product != "Ultra" || drive.SerialNumber != "4C530001" {
+		t.Fatalf("unexpected flash drive %+v", drive)
+	}
+	kbd := byID["1-3.1"]
+	if kbd.DeviceClass != "all_usb" || kbd.Vendor != "046d" || kbd.Product != "046d:c31c" {
+		t.Fatalf("unexpected keyboard %+v", kbd)
+	}
+}
+
+func TestDetectSysfsPeripheralsMissingRoot(t *testing.T) {
+	got, err := detectSysfsPeripherals(filepath.Join(t.TempDir(), "absent"))
+	if err != nil || got != nil {
+		t.Fatalf("missing sysfs root should yield no devices, got %+v, %v", got, err)
+	}
+}
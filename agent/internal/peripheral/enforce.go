@@ -1,6 +1,6 @@
 package peripheral
 
-// enforceableClasses are the only device classes Tier 1 enforces on Windows.
+// enforceableClasses are the only device classes Tier 1 enforces.
 // Bluetooth and Thunderbolt block/read_only actions remain alert-only. The
 // Windows durable gate (USBSTOR) covers removable storage only; for all_usb,
 // non-storage USB is handled by per-device disable of connected devices, not a
@@ -66,6 +66,19 @@ func planEnforcement(results []EvaluationResult, policies []Policy) EnforcementP
 	return plan
 }
 
+// NeedsEnforcement reports whether any active policy blocks or makes read-only
+// an enforceable class, i.e. whether re-applying the policy set can change
+// anything on the OS.
+func NeedsEnforcement(policies []Policy) bool {
+	for i := range policies {
+		p := &policies[i]
+		if p.IsActive && enforceableClasses[p.DeviceClass] && (p.Action == "block" || p.Action == "read_only") {
+			return true
+		}
+	}
+	return false
+}
+
 // Plan exposes planEnforcement to other packages (the heartbeat handler).
 func Plan(results []EvaluationResult, policies []Policy) EnforcementPlan {
 	return planEnforcement(results, policies)
@@ -136,6 +149,15 @@ func CountUnverified(o EnforcementOutcome) int {
 	return n
 }
 
+// probeDetail is the outcome Detail for a post-write probe: empty when it
+// confirmed the change, failMsg otherwise.
+func probeDetail(verified bool, failMsg string) string {
+	if verified {
+		return ""
+	}
+	return failMsg
+}
+
 // Enforcer abstracts all OS-touching enforcement so the orchestrator is testable.
 type Enforcer interface {
 	ApplyGate(class string, hasExceptions bool) EnforceOutcome
@@ -0,0 +1,213 @@
+//go:build darwin
+
+package peripheral
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// darwinEnforcer enforces without a kernel or system extension: block ejects
+// USB storage media with diskutil, read-only remounts its volumes read-only.
+// Neither survives a replug, so nothing durable is armed; the agent's periodic
+// enforcement pass re-applies the policy to newly attached media.
+type darwinEnforcer struct {
+	run func(name string, args ...string) ([]byte, error)
+}
+
+func NewEnforcer() Enforcer {
+	return darwinEnforcer{run: func(name string, args ...string) ([]byte, error) {
+		return exec.Command(name, args...).CombinedOutput()
+	}}
+}
+
+// usbMedia is one USB storage medium (whole disk) and the device it belongs to.
+type usbMedia struct {
+	deviceID string
+	disk     string
+	volumes  []spVolume
+}
+
+func collectUSBMedia(item spUSBItem, out *[]usbMedia) {
+	for _, m := range item.Media {
+		if m.BSDName != "" {
+			*out = append(*out, usbMedia{deviceID: darwinDeviceID(item), disk: m.BSDName, volumes: m.Volumes})
+		}
+	}
+	for _, child := range item.Items {
+		collectUSBMedia(child, out)
+	}
+}
+
+func (e darwinEnforcer) listUSBMedia() ([]usbMedia, error) {
+	output, err := e.run("system_profiler", "SPUSBDataType", "-json")
+	if err != nil {
+		return nil, fmt.Errorf("system_profiler: %w", err)
+	}
+	data, err := parseUSBProfile(output)
+	if err != nil {
+		return nil, fmt.Errorf("parse system_profiler: %w", err)
+	}
+	var media []usbMedia
+	for _, item := range data.SPUSBDataType {
+		collectUSBMedia(item, &media)
+	}
+	return media, nil
+}
+
+func (e darwinEnforcer) ApplyGate(class string, hasExceptions bool) EnforceOutcome {
+	if hasExceptions {
+		return EnforceOutcome{Mechanism: "per-device-only", Applied: true, Verified: true,
+			Detail: "machine-wide eject skipped: policy has allow-exceptions"}
+	}
+	media, err := e.listUSBMedia()
+	if err != nil {
+		return EnforceOutcome{Mechanism: "diskutil-eject", Detail: err.Error()}
+	}
+	return e.eject(media, func(usbMedia) bool { return true })
+}
+
+// RevertGate has nothing to undo: ejected media come back on the next replug.
+func (e darwinEnforcer) RevertGate(class string) EnforceOutcome {
+	return EnforceOutcome{Mechanism: "diskutil-eject", Applied: false, Verified: true}
+}
+
+func (e darwinEnforcer) DisableDevice(instanceID string) EnforceOutcome {
+	media, err := e.listUSBMedia()
+	if err != nil {
+		return EnforceOutcome{Mechanism: "diskutil-eject", Detail: err.Error()}
+	}
+	if !containsMediaFor(media, instanceID) {
+		return EnforceOutcome{Mechanism: "unsupported",
+			Detail: "no storage media to eject; non-storage USB devices cannot be blocked without a system extension"}
+	}
+	return e.eject(media, func(m usbMedia) bool { return m.deviceID == instanceID })
+}
+
+// eject ejects every medium in media selected by match and verifies none is
+// still attached afterwards.
+func (e darwinEnforcer) eject(media []usbMedia, match func(usbMedia) bool) EnforceOutcome {
+	targets := map[string]bool{}
+	var failures []string
+	for _, m := range media {
+		if !match(m) {
+			continue
+		}
+		targets[m.disk] = true
+		if out, err := e.run("diskutil", "eject", m.disk); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v: %s", m.disk, err, strings.TrimSpace(string(out))))
+		}
+	}
+	if len(failures) > 0 {
+		return EnforceOutcome{Mechanism: "diskutil-eject", Applied: true, Verified: false,
+			Detail: "eject failed: " + strings.Join(failures, "; ")}
+	}
+
+	after, err := e.listUSBMedia()
+	if err != nil {
+		return EnforceOutcome{Mechanism: "diskutil-eject", Applied: true, Verified: false,
+			Detail: "post-eject probe could not run (cannot confirm block): " + err.Error()}
+	}
+	verified := true
+	for _, m := range after {
+		if targets[m.disk] {
+			verified = false
+		}
+	}
+	return EnforceOutcome{Mechanism: "diskutil-eject", Applied: true, Verified: verified,
+		Detail: probeDetail(verified, "media still attached after eject")}
+}
+
+func (e darwinEnforcer) ApplyReadOnly(class string) EnforceOutcome {
+	media, err := e.listUSBMedia()
+	if err != nil {
+		return EnforceOutcome{Mechanism: "diskutil-readonly-mount", Detail: err.Error()}
+	}
+	readOnly, err := e.readOnlyMounts()
+	if err != nil {
+		return EnforceOutcome{Mechanism: "diskutil-readonly-mount", Detail: err.Error()}
+	}
+
+	var remounted []string
+	var failures []string
+	for _, m := range media {
+		for _, v := range m.volumes {
+			if v.BSDName == "" || v.MountPoint == "" || readOnly[v.BSDName] {
+				continue
+			}
+			if out, err := e.run("diskutil", "unmount", v.BSDName); err != nil {
+				failures = append(failures, fmt.Sprintf("%s unmount: %v: %s", v.BSDName, err, strings.TrimSpace(string(out))))
+				continue
+			}
+			if out, err := e.run("diskutil", "mount", "readOnly", v.BSDName); err != nil {
+				failures = append(failures, fmt.Sprintf("%s mount: %v: %s", v.BSDName, err, strings.TrimSpace(string(out))))
+				continue
+			}
+			remounted = append(remounted, v.BSDName)
+		}
+	}
+	if len(failures) > 0 {
+		return EnforceOutcome{Mechanism: "diskutil-readonly-mount", Applied: true, Verified: false,
+			Detail: "remount failed: " + strings.Join(failures, "; ")}
+	}
+
+	readOnly, err = e.readOnlyMounts()
+	if err != nil {
+		return EnforceOutcome{Mechanism: "diskutil-readonly-mount", Applied: true, Verified: false,
+			Detail: "post-remount probe could not run: " + err.Error()}
+	}
+	verified := true
+	for _, bsd := range remounted {
+		if !readOnly[bsd] {
+			verified = false
+		}
+	}
+	return EnforceOutcome{Mechanism: "diskutil-readonly-mount", Applied: true, Verified: verified,
+		Detail: probeDetail(verified, "volume not mounted read-only after remount")}
+}
+
+// RevertReadOnly leaves mounted volumes alone: they mount read-write again on
+// the next replug once the periodic pass stops remounting them.
+func (e darwinEnforcer) RevertReadOnly(class string) EnforceOutcome {
+	return EnforceOutcome{Mechanism: "diskutil-readonly-mount", Applied: false, Verified: true}
+}
+
+func (e darwinEnforcer) readOnlyMounts() (map[string]bool, error) {
+	out, err := e.run("mount")
+	if err != nil {
+		return nil, fmt.Errorf("mount: %w", err)
+	}
+	return parseReadOnlyMounts(string(out)), nil
+}
+
+// parseReadOnlyMounts returns the BSD names of read-only mounts in `mount`
+// output ("/dev/disk4s1 on /Volumes/X (msdos, local, read-only, ...)").
+func parseReadOnlyMounts(output string) map[string]bool {
+	readOnly := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "/dev/") {
+			continue
+		}
+		open := strings.LastIndex(line, "(")
+		if open < 0 {
+			continue
+		}
+		for _, opt := range strings.Split(strings.TrimSuffix(line[open+1:], ")"), ",") {
+			if strings.TrimSpace(opt) == "read-only" {
+				readOnly[strings.TrimPrefix(strings.Fields(line)[0], "/dev/")] = true
+				break
+			}
+		}
+	}
+	return readOnly
+}
+
+func containsMediaFor(media []usbMedia, deviceID string) bool {
+	for _, m := range media {
+		if m.deviceID == deviceID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+//go:build darwin
+
+package peripheral
+
+import (
+	"strings"
+	"testing"
+)
+
+const profileWithDrive = `{"SPUSBDataType":[{"_name":"USB31Bus","_items":[
+  {"_name":"Ultra","vendor_id":"0x0781","product_id":"0x5583","Media":[
+    {"_name":"Ultra","bsd_name":"disk4","volumes":[{"_name":"UNTITLED","bsd_name":"disk4s1","mount_point":"/Volumes/UNTITLED"}]}]},
+  {"_name":"Keyboard","vendor_id":"0x046d","product_id":"0xc31c"}]}]}`
+
+const profileWithoutDrive = `{"SPUSBDataType":[{"_name":"USB31Bus","_items":[
+  {"_name":"Ultra","vendor_id":"0x0781","product_id":"0x5583"},
+  {"_name":"Keyboard","vendor_id":"0x046d","product_id":"0xc31c"}]}]}`
+
+// fakeMac emulates system_profiler, diskutil and mount for the enforcer.
+type fakeMac struct {
+	ejected  bool
+	readOnly bool
+	calls    []string
+}
+
+func (f *fakeMac) run(name string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, name+" "+strings.Join(args, " "))
+	switch {
+	case name == "system_profiler":
+		if f.ejected {
+			return []byte(profileWithoutDrive), nil
+		}
+		return []byte(profileWithDrive), nil
+	case name == "diskutil" && args[0] == "eject":
+		f.ejected = true
+	case name == "diskutil" && args[0] == "mount":
+		f.readOnly = len(args) > 1 && args[1] == "readOnly"
+	case name == "mount":
+		if f.readOnly {
+			return []byte("/dev/disk4s1 on /Volumes/UNTITLED (msdos, local, nodev, nosuid, read-only, noowners)\n"), nil
+		}
+		return []byte("/dev/disk4s1 on /Volumes/UNTITLED (msdos, local, nodev, nosuid, noowners)\n"), nil
+	}
+	return nil, nil
+}
+
+func TestDarwinEnforcer_GateEjectsUSBMedia(t *testing.T) {
+	f := &fakeMac{}
+	out := darwinEnforcer{run: f.run}.ApplyGate("storage", false)
+	if !out.Applied || !out.Verified || out.Mechanism != "diskutil-eject" {
+		t.Fatalf("ApplyGate = %+v", out)
+	}
+	if !f.ejected {
+		t.Fatalf("expected disk4 ejected, calls = %v", f.calls)
+	}
+}
+
+func TestDarwinEnforcer_DisableDeviceWithoutMediaIsUnsupported(t *testing.T) {
+	f := &fakeMac{}
+	out := darwinEnforcer{run: f.run}.DisableDevice("0x046d:0xc31c")
+	if out.Applied || out.Mechanism != "unsupported" {
+		t.Fatalf("keyboard block should be alert-only, got %+v", out)
+	}
+	if f.ejected {
+		t.Fatal("no media should be ejected for a non-storage device")
+	}
+}
+
+func TestDarwinEnforcer_DisableDeviceEjectsItsMedia(t *testing.T) {
+	f := &fakeMac{}
+	out := darwinEnforcer{run: f.run}.DisableDevice("0x0781:0x5583")
+	if !out.Applied || !out.Verified {
+		t.Fatalf("DisableDevice = %+v", out)
+	}
+}
+
+func TestDarwinEnforcer_ReadOnlyRemountsVolumes(t *testing.T) {
+	f := &fakeMac{}
+	out := darwinEnforcer{run: f.run}.ApplyReadOnly("storage")
+	if !out.Applied || !out.Verified {
+		t.Fatalf("ApplyReadOnly = %+v, calls = %v", out, f.calls)
+	}
+	if !f.readOnly {
+		t.Fatalf("expected a readOnly remount, calls = %v", f.calls)
+	}
+}
+
+func TestParseReadOnlyMounts(t *testing.T) {
+	got := parseReadOnlyMounts(`/dev/disk3s1s1 on / (apfs, sealed, local, read-only, journaled)
+/dev/disk3s5 on /System/Volumes/Data (apfs, local, journaled, nobrowse, protect)
+map auto_home on /System/Volumes/Data/home (autofs, automounted, nobrowse)
+/dev/disk4s1 on /Volumes/My Drive (read-only) (msdos, local, read-only, noowners)
+`)
+	if !got["disk3s1s1"] || got["disk3s5"] || !got["disk4s1"] || len(got) != 2 {
+		t.Fatalf("parseReadOnlyMounts = %v", got)
+	}
+}
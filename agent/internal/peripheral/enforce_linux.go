@@ -0,0 +1,211 @@
+//go:build linux
+
+package peripheral
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	udevRulesDir      = "/etc/udev/rules.d"
+	udevBlockRules    = "99-breeze-usb-storage-block.rules"
+	udevReadOnlyRules = "99-breeze-usb-storage-readonly.rules"
+	linuxBlockRoot    = "/sys/block"
+
+	// udevManagedHeader is the sentinel first line: revert only removes rule
+	// files that carry it, so an admin's file of the same name is left alone.
+	udevManagedHeader = "# Managed by Breeze peripheral control; removed when the policy is lifted. Do not edit."
+
+	// Deauthorizing the mass-storage interface unbinds usb-storage/uas before
+	// any block device appears. Other interfaces of a composite device keep
+	// working.
+	udevBlockRule = `ACTION=="add", SUBSYSTEM=="usb", ENV{DEVTYPE}=="usb_interface", ATTR{bInterfaceClass}=="08", ATTR{authorized}="0"`
+
+	// Marks every USB disk and partition read-only at the block layer as it
+	// appears, so writes fail regardless of filesystem or mount options.
+	udevReadOnlyRule = `ACTION=="add|change", SUBSYSTEM=="block", ENV{ID_BUS}=="usb", RUN+="/sbin/blockdev --setro $devnode"`
+)
+
+// linuxEnforcer enforces through udev rules (durable, future insertions) and
+// sysfs (connected devices). Paths and the command runner are fields so tests
+// can point them at a temp tree.
+type linuxEnforcer struct {
+	rulesDir  string
+	usbRoot   string
+	blockRoot string
+	run       func(name string, args ...string) ([]byte, error)
+}
+
+func NewEnforcer() Enforcer {
+	return linuxEnforcer{
+		rulesDir:  udevRulesDir,
+		usbRoot:   linuxUSBSysfsRoot,
+		blockRoot: linuxBlockRoot,
+		run:       runCommand,
+	}
+}
+
+func runCommand(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+func renderUdevRules(rule string) string {
+	return udevManagedHeader + "\n" + rule + "\n"
+}
+
+func (e linuxEnforcer) ApplyGate(class string, hasExceptions bool) EnforceOutcome {
+	if hasExceptions {
+		return EnforceOutcome{Mechanism: "per-device-only", Applied: true, Verified: true,
+			Detail: "machine-wide gate skipped: policy has allow-exceptions"}
+	}
+	return e.applyRules("udev-usb-storage-block", udevBlockRules, udevBlockRule)
+}
+
+func (e linuxEnforcer) RevertGate(class string) EnforceOutcome {
+	out, _ := e.revertRules("udev-usb-storage-block", udevBlockRules)
+	return out
+}
+
+func (e linuxEnforcer) DisableDevice(instanceID string) EnforceOutcome {
+	if !sysfsUSBDeviceName.MatchString(instanceID) {
+		return EnforceOutcome{Mechanism: "usb-authorized", Detail: "not a sysfs USB device name: " + instanceID}
+	}
+	path := filepath.Join(e.usbRoot, instanceID, "authorized")
+	if err := os.WriteFile(path, []byte("0"), 0o644); err != nil {
+		return EnforceOutcome{Mechanism: "usb-authorized", Detail: "deauthorize: " + err.Error()}
+	}
+	verified := readSysfs(filepath.Join(e.usbRoot, instanceID), "authorized") == "0"
+	return EnforceOutcome{Mechanism: "usb-authorized", Applied: true, Verified: verified,
+		Detail: probeDetail(verified, "authorized read-back mismatch")}
+}
+
+func (e linuxEnforcer) ApplyReadOnly(class string) EnforceOutcome {
+	out := e.applyRules("udev-usb-storage-readonly", udevReadOnlyRules, udevReadOnlyRule)
+	if !out.Applied {
+		return out
+	}
+
+	// udev only acts on add/change; mark the USB disks already attached.
+	var failed []string
+	for _, dev := range e.usbBlockDevices() {
+		if _, err := e.run("blockdev", "--setro", "/dev/"+dev.name); err != nil || readSysfs(dev.dir, "ro") != "1" {
+			failed = append(failed, dev.name)
+		}
+	}
+	if len(failed) > 0 {
+		out.Verified = false
+		out.Detail = "could not mark connected USB disks read-only: " + strings.Join(failed, ", ")
+	}
+	return out
+}
+
+func (e linuxEnforcer) RevertReadOnly(class string) EnforceOutcome {
+	out, removed := e.revertRules("udev-usb-storage-readonly", udevReadOnlyRules)
+	if !removed {
+		return out
+	}
+	// We marked connected disks read-only when applying; clear it so lifting
+	// the policy takes effect without a replug.
+	for _, dev := range e.usbBlockDevices() {
+		if readSysfs(dev.dir, "ro") == "1" {
+			_, _ = e.run("blockdev", "--setrw", "/dev/"+dev.name)
+		}
+	}
+	return out
+}
+
+// applyRules writes a managed rules file, reloads udev and verifies the file
+// on disk.
+func (e linuxEnforcer) applyRules(mechanism, file, rule string) EnforceOutcome {
+	path := filepath.Join(e.rulesDir, file)
+	content := renderUdevRules(rule)
+	if err := writeFileAtomic(path, content); err != nil {
+		return EnforceOutcome{Mechanism: mechanism, Detail: "write rules: " + err.Error()}
+	}
+	if out, err := e.run("udevadm", "control", "--reload-rules"); err != nil {
+		return EnforceOutcome{Mechanism: mechanism, Applied: true, Verified: false,
+			Detail: fmt.Sprintf("rules written but udev reload failed: %v: %s", err, strings.TrimSpace(string(out)))}
+	}
+	got, err := os.ReadFile(path)
+	verified := err == nil && string(got) == content
+	return EnforceOutcome{Mechanism: mechanism, Applied: true, Verified: verified,
+		Detail: probeDetail(verified, "rules file read-back mismatch")}
+}
+
+// revertRules removes a rules file only if Breeze wrote it. removed reports
+// whether a managed file was actually deleted.
+func (e linuxEnforcer) revertRules(mechanism, file string) (out EnforceOutcome, removed bool) {
+	path := filepath.Join(e.rulesDir, file)
+	got, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return EnforceOutcome{Mechanism: mechanism, Applied: false, Verified: true}, false
+	}
+	if err != nil {
+		return EnforceOutcome{Mechanism: mechanism, Detail: "read rules: " + err.Error()}, false
+	}
+	if !strings.HasPrefix(string(got), udevManagedHeader) {
+		return EnforceOutcome{Mechanism: mechanism, Applied: false, Verified: true,
+			Detail: "not Breeze-managed; left untouched"}, false
+	}
+	if err := os.Remove(path); err != nil {
+		return EnforceOutcome{Mechanism: mechanism, Detail: "remove rules: " + err.Error()}, false
+	}
+	if out, err := e.run("udevadm", "control", "--reload-rules"); err != nil {
+		return EnforceOutcome{Mechanism: mechanism, Applied: false, Verified: false,
+			Detail: fmt.Sprintf("rules removed but udev reload failed: %v: %s", err, strings.TrimSpace(string(out)))}, true
+	}
+	_, statErr := os.Stat(path)
+	verified := errors.Is(statErr, os.ErrNotExist)
+	return EnforceOutcome{Mechanism: mechanism, Applied: false, Verified: verified,
+		Detail: probeDetail(verified, "rules file still present after remove")}, true
+}
+
+type blockDevice struct {
+	name string
+	dir  string
+}
+
+// usbBlockDevices lists USB-attached disks and their partitions. A disk is
+// USB-attached when its sysfs path runs through a USB host controller.
+func (e linuxEnforcer) usbBlockDevices() []blockDevice {
+	entries, err := os.ReadDir(e.blockRoot)
+	if err != nil {
+		return nil
+	}
+	var devs []blockDevice
+	for _, entry := range entries {
+		dir := filepath.Join(e.blockRoot, entry.Name())
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err != nil || !strings.Contains(resolved, "/usb") {
+			continue
+		}
+		devs = append(devs, blockDevice{name: entry.Name(), dir: dir})
+		parts, _ := filepath.Glob(filepath.Join(dir, entry.Name()+"*"))
+		for _, part := range parts {
+			if _, err := os.Stat(filepath.Join(part, "partition")); err == nil {
+				devs = append(devs, blockDevice{name: filepath.Base(part), dir: part})
+			}
+		}
+	}
+	return devs
+}
+
+func writeFileAtomic(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+//go:build linux
+
+package peripheral
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestLinuxEnforcer returns an enforcer over a temp tree. Its fake runner
+// records commands and emulates blockdev by writing the device's ro flag.
+func newTestLinuxEnforcer(t *testing.T) (linuxEnforcer, *[]string) {
+	t.Helper()
+	root := t.TempDir()
+	var calls []string
+	e := linuxEnforcer{
+		rulesDir:  filepath.Join(root, "rules.d"),
+		usbRoot:   filepath.Join(root, "usb"),
+		blockRoot: filepath.Join(root, "block"),
+	}
+	e.run = func(name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		if name == "blockdev" && len(args) == 2 {
+			flag := map[string]string{"--setro": "1", "--setrw": "0"}[args[0]]
+			for _, dev := range e.usbBlockDevices() {
+				if "/dev/"+dev.name == args[1] {
+					return nil, os.WriteFile(filepath.Join(dev.dir, "ro"), []byte(flag), 0o644)
+				}
+			}
+		}
+		return nil, nil
+	}
+	return e, &calls
+}
+
+// addBlockDevice creates /sys/block/<name> as a symlink into a devices tree,
+// through a USB controller when usb is set.
+func addBlockDevice(t *testing.T, e linuxEnforcer, name string, usb bool, partitions ...string) {
+	t.Helper()
+	parent := filepath.Join(filepath.Dir(e.blockRoot), "devices", "pci0000:00", "ata1")
+	if usb {
+		parent = filepath.Join(filepath.Dir(e.blockRoot), "devices", "pci0000:00", "usb2", "2-1")
+	}
+	dir := filepath.Join(parent, "block", name)
+	writeSysfsAttrs(t, dir, map[string]string{"ro": "0"})
+	for _, part := range partitions {
+		writeSysfsAttrs(t, filepath.Join(dir, part), map[string]string{"ro": "0", "partition": "1"})
+	}
+	if err := os.MkdirAll(e.blockRoot, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(dir, filepath.Join(e.blockRoot, name)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLinuxEnforcer_GateWritesAndRevertsManagedRules(t *testing.T) {
+	e, calls := newTestLinuxEnforcer(t)
+	path := filepath.Join(e.rulesDir, udevBlockRules)
+
+	out := e.ApplyGate("storage", false)
+	if !out.Applied || !out.Verified {
+		t.Fatalf("ApplyGate = %+v, want applied+verified", out)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(got), `ATTR{bInterfaceClass}=="08", ATTR{authorized}="0"`) {
+		t.Fatalf("rules file = %q, %v", got, err)
+	}
+	if len(*calls) != 1 || (*calls)[0] != "udevadm control --reload-rules" {
+		t.Fatalf("expected a udev reload, got %v", *calls)
+	}
+
+	out = e.RevertGate("storage")
+	if out.Applied || !out.Verified {
+		t.Fatalf("RevertGate = %+v, want verified revert", out)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("rules file should be removed, stat err = %v", err)
+	}
+}
+
+func TestLinuxEnforcer_GateSkippedWithExceptions(t *testing.T) {
+	e, calls := newTestLinuxEnforcer(t)
+	out := e.ApplyGate("storage", true)
+	if out.Mechanism != "per-device-only" || !out.Verified {
+		t.Fatalf("ApplyGate with exceptions = %+v", out)
+	}
+	if _, err := os.Stat(filepath.Join(e.rulesDir, udevBlockRules)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("no rules file should be written when the policy has exceptions")
+	}
+	if len(*calls) != 0 {
+		t.Fatalf("unexpected commands %v", *calls)
+	}
+}
+
+func TestLinuxEnforcer_RevertLeavesUnmanagedRules(t *testing.T) {
+	e, _ := newTestLinuxEnforcer(t)
+	path := filepath.Join(e.rulesDir, udevBlockRules)
+	if err := writeFileAtomic(path, "# admin rule\n"); err != nil {
+		t.Fatal(err)
+	}
+	out := e.RevertGate("storage")
+	if !out.Verified || !strings.Contains(out.Detail, "not Breeze-managed") {
+		t.Fatalf("RevertGate = %+v", out)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("admin rules file must be kept: %v", err)
+	}
+}
+
+func TestLinuxEnforcer_ReloadFailureIsUnverified(t *testing.T) {
+	e, _ := newTestLinuxEnforcer(t)
+	e.run = func(string, ...string) ([]byte, error) { return []byte("no udevd"), errors.New("exit status 1") }
+	out := e.ApplyGate("storage", false)
+	if !out.Applied || out.Verified || !strings.Contains(out.Detail, "udev reload failed") {
+		t.Fatalf("ApplyGate = %+v, want applied but unverified", out)
+	}
+}
+
+func TestLinuxEnforcer_DisableDevice(t *testing.T) {
+	e, _ := newTestLinuxEnforcer(t)
+	writeSysfsAttrs(t, filepath.Join(e.usbRoot, "1-2"), map[string]string{"authorized": "1"})
+
+	out := e.DisableDevice("1-2")
+	if !out.Applied || !out.Verified {
+		t.Fatalf("DisableDevice = %+v", out)
+	}
+	if readSysfs(filepath.Join(e.usbRoot, "1-2"), "authorized") != "0" {
+		t.Fatal("device should be deauthorized")
+	}
+
+	if out := e.DisableDevice("../../etc"); out.Applied {
+		t.Fatalf("path-like id must be rejected, got %+v", out)
+	}
+}
+
+func TestLinuxEnforcer_ReadOnlyMarksConnectedUSBDisks(t *testing.T) {
+	e, _ := newTestLinuxEnforcer(t)
+	addBlockDevice(t, e, "sdb", true, "sdb1")
+	addBlockDevice(t, e, "sda", false, "sda1")
+
+	out := e.ApplyReadOnly("storage")
+	if !out.Applied || !out.Verified {
+		t.Fatalf("ApplyReadOnly = %+v", out)
+	}
+	for _, dir := range []string{"sdb", "sdb/sdb1"} {
+		if got := readSysfs(filepath.Join(e.blockRoot, dir), "ro"); got != "1" {
+			t.Fatalf("%s ro = %q, want 1", dir, got)
+		}
+	}
+	if got := readSysfs(filepath.Join(e.blockRoot, "sda"), "ro"); got != "0" {
+		t.Fatalf("internal disk must stay writable, ro = %q", got)
+	}
+
+	out = e.RevertReadOnly("storage")
+	if !out.Verified {
+		t.Fatalf("RevertReadOnly = %+v", out)
+	}
+	if got := readSysfs(filepath.Join(e.blockRoot, "sdb"), "ro"); got != "0" {
+		t.Fatalf("revert should clear ro on USB disks, ro = %q", got)
+	}
+}
+
+func TestLinuxEnforcer_ReadOnlyUnverifiedWhenDiskStaysWritable(t *testing.T) {
+	e, _ := newTestLinuxEnforcer(t)
+	addBlockDevice(t, e, "sdc", true)
+	e.run = func(string, ...string) ([]byte, error) { return nil, nil }
+
+	out := e.ApplyReadOnly("storage")
+	if !out.Applied || out.Verified || !strings.Contains(out.Detail, "sdc") {
+		t.Fatalf("ApplyReadOnly = %+v, want unverified naming sdc", out)
+	}
+}
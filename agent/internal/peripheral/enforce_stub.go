@@ -1,9 +1,10 @@
-//go:build !windows
+//go:build !windows && !linux && !darwin
 
 package peripheral
 
-// stubEnforcer is the non-Windows enforcer: detection/eval still run, but no OS
-// enforcement is applied. Every action reports alert_only via Applied=false.
+// stubEnforcer is the enforcer for platforms without an implementation:
+// detection/eval still run, but no OS enforcement is applied. Every action
+// reports alert_only via Applied=false.
 type stubEnforcer struct{}
 
 func NewEnforcer() Enforcer { return stubEnforcer{} }
@@ -152,3 +152,23 @@ func TestEnforce_AppliesAndReverts(t *testing.T) {
 		t.Fatalf("outcome not recorded: %+v", out.GateOutcomes)
 	}
 }
+
+func TestNeedsEnforcement(t *testing.T) {
+	cases := []struct {
+		name     string
+		policies []Policy
+		want     bool
+	}{
+		{"none", nil, false},
+		{"storage block", []Policy{{DeviceClass: "storage", Action: "block", IsActive: true}}, true},
+		{"all_usb read_only", []Policy{{DeviceClass: "all_usb", Action: "read_only", IsActive: true}}, true},
+		{"inactive", []Policy{{DeviceClass: "storage", Action: "block"}}, false},
+		{"alert only", []Policy{{DeviceClass: "storage", Action: "alert", IsActive: true}}, false},
+		{"bluetooth block", []Policy{{DeviceClass: "bluetooth", Action: "block", IsActive: true}}, false},
+	}
+	for _, tc := range cases {
+		if got := NeedsEnforcement(tc.policies); got != tc.want {
+			t.Errorf("%s: NeedsEnforcement = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
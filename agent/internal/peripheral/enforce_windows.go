@@ -138,10 +138,3 @@ func (winEnforcer) RevertReadOnly(class string) EnforceOutcome {
 	}
 	return EnforceOutcome{Mechanism: "removable-storage-deny-write", Applied: false, Verified: true}
 }
-
-func probeDetail(verified bool, failMsg string) string {
-	if verified {
-		return ""
-	}
-	return failMsg
-}
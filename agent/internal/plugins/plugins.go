@@ -0,0 +1,407 @@
+// Package plugins runs MSP-supplied collector plugins: executables under the
+// agent's plugins directory that print a JSON document on stdout. Each plugin
+// lives in its own subdirectory next to a plugin.json manifest describing how
+// often it runs, how long it may take and which endpoint its output is
+// uploaded to:
+//
+//	<plugins dir>/disk-smart/plugin.json
+//	<plugins dir>/disk-smart/collect.sh
+//
+//	{"name": "disk-smart", "executable": "collect.sh", "intervalMinutes": 60,
+//	 "timeoutSeconds": 30, "endpoint": "smart-health"}
+//
+// The plugin files are only trusted when an administrator could have put
+// them there: on Unix the plugins directory, each plugin directory, the
+// manifest and the executable must be owned by root (or the agent's own user)
+// and not writable by group/other; on Windows the plugins
+// directory inherits the config directory's SYSTEM/Administrators-only ACL.
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/procoutput"
+)
+
+// ManifestFile is the manifest name expected in each plugin directory.
+const ManifestFile = "plugin.json"
+
+const (
+	DefaultIntervalMinutes = 60
+	MinIntervalMinutes     = 5
+	MaxIntervalMinutes     = 24 * 60
+
+	DefaultTimeoutSeconds = 60
+	MaxTimeoutSeconds     = 300
+
+	DefaultMaxOutputBytes = 1 << 20
+	MaxOutputBytes        = 8 << 20
+
+	// maxManifestBytes bounds plugin.json reads.
+	maxManifestBytes = 64 << 10
+	// maxStderrBytes is how much trailing stderr is kept for error reports.
+	maxStderrBytes = 4 << 10
+	// killGrace is how long a timed-out plugin's pipes may stay open after
+	// the process tree is killed.
+	killGrace = 5 * time.Second
+)
+
+// namePattern constrains plugin names and endpoints to a single URL path
+// segment.
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Manifest is the parsed plugin.json.
+type Manifest struct {
+	Name            string   `json:"name"`
+	Version         string   `json:"version,omitempty"`
+	Executable      string   `json:"executable"`
+	Args            []string `json:"args,omitempty"`
+	IntervalMinutes int      `json:"intervalMinutes,omitempty"`
+	TimeoutSeconds  int      `json:"timeoutSeconds,omitempty"`
+	Endpoint        string   `json:"endpoint"`
+	MaxOutputBytes  int      `json:"maxOutputBytes,omitempty"`
+}
+
+// Plugin is a validated manifest bound to its directory.
+type Plugin struct {
+	Manifest
+	Dir  string
+	Path string // absolute executable path inside Dir
+}
+
+// Interval returns the plugin's run cadence.
+func (p Plugin) Interval() time.Duration {
+	return time.Duration(p.IntervalMinutes) * time.Minute
+}
+
+// Timeout returns the plugin's per-run deadline.
+func (p Plugin) Timeout() time.Duration {
+	return time.Duration(p.TimeoutSeconds) * time.Second
+}
+
+// Result is one plugin run. Exactly one of Data and Error is set.
+type Result struct {
+	Plugin      string          `json:"plugin"`
+	Version     string          `json:"version,omitempty"`
+	CollectedAt time.Time       `json:"collectedAt"`
+	DurationMs  int64           `json:"durationMs"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// parseManifest decodes and validates a manifest, applying defaults and
+// clamping limits.
+func parseManifest(data []byte) (Manifest, error) {
+	var m Manifest
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return m, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if !namePattern.MatchString(m.Name) {
+		return m, fmt.Errorf("invalid plugin name %q", m.Name)
+	}
+	if !namePattern.MatchString(m.Endpoint) {
+		return m, fmt.Errorf("invalid endpoint %q", m.Endpoint)
+	}
+	exe := filepath.Clean(filepath.FromSlash(m.Executable))
+	if m.Executable == "" || filepath.IsAbs(exe) || filepath.VolumeName(exe) != "" ||
+		exe == "." || exe == ".." || strings.HasPrefix(exe, ".."+string(filepath.Separator)) {
+		return m, fmt.Errorf("executable %q must be a path inside the plugin directory", m.Executable)
+	}
+	m.Executable = exe
+
+	switch {
+	case m.IntervalMinutes <= 0:
+		m.IntervalMinutes = DefaultIntervalMinutes
+	case m.IntervalMinutes < MinIntervalMinutes:
+		m.IntervalMinutes = MinIntervalMinutes
+	case m.IntervalMinutes > MaxIntervalMinutes:
+		m.IntervalMinutes = MaxIntervalMinutes
+	}
+	switch {
+	case m.TimeoutSeconds <= 0:
+		m.TimeoutSeconds = DefaultTimeoutSeconds
+	case m.TimeoutSeconds > MaxTimeoutSeconds:
+		m.TimeoutSeconds = MaxTimeoutSeconds
+	}
+	switch {
+	case m.MaxOutputBytes <= 0:
+		m.MaxOutputBytes = DefaultMaxOutputBytes
+	case m.MaxOutputBytes > MaxOutputBytes:
+		m.MaxOutputBytes = MaxOutputBytes
+	}
+	return m, nil
+}
+
+// loadPlugin reads and trust-checks the plugin in dir.
+func loadPlugin(dir string) (Plugin, error) {
+	manifestPath := filepath.Join(dir, ManifestFile)
+	if err := checkTrusted(manifestPath); err != nil {
+		return Plugin{}, err
+	}
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		return Plugin{}, err
+	}
+	defer f.Close()
+	data, err := readLimited(f, maxManifestBytes)
+	if err != nil {
+		return Plugin{}, fmt.Errorf("read manifest: %w", err)
+	}
+	m, err := parseManifest(data)
+	if err != nil {
+		return Plugin{}, err
+	}
+	if m.Name != filepath.Base(dir) {
+		return Plugin{}, fmt.Errorf("manifest name %q does not match directory %q", m.Name, filepath.Base(dir))
+	}
+	p := Plugin{Manifest: m, Dir: dir, Path: filepath.Join(dir, m.Executable)}
+	if err := checkTrusted(p.Path); err != nil {
+		return Plugin{}, err
+	}
+	if err := checkExecutable(p.Path); err != nil {
+		return Plugin{}, err
+	}
+	return p, nil
+}
+
+func readLimited(f *os.File, limit int64) ([]byte, error) {
+	var buf bytes.Buffer
+	n, err := buf.ReadFrom(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > limit {
+		return nil, fmt.Errorf("larger than %d bytes", limit)
+	}
+	return buf.Bytes(), nil
+}
+
+// Manager discovers plugins and tracks when each last ran.
+type Manager struct {
+	dir string
+	now func() time.Time
+
+	mu       sync.Mutex
+	lastRun  map[string]time.Time
+	running  map[string]bool
+	rejected map[string]string
+}
+
+// NewManager returns a manager for the plugins under dir. The directory does
+// not need to exist; an absent directory simply has no plugins.
+func NewManager(dir string) *Manager {
+	return &Manager{
+		dir:      dir,
+		now:      time.Now,
+		lastRun:  make(map[string]time.Time),
+		running:  make(map[string]bool),
+		rejected: make(map[string]string),
+	}
+}
+
+// Dir returns the plugins directory.
+func (m *Manager) Dir() string { return m.dir }
+
+// Discover scans the plugins directory. Plugins that fail validation are
+// skipped; their errors are returned only when newly seen (or changed) so a
+// caller polling every tick logs each problem once.
+func (m *Manager) Discover() ([]Plugin, []error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, m.reject(nil)
+		}
+		return nil, m.reject(map[string]string{m.dir: err.Error()})
+	}
+	if err := checkTrustedDir(m.dir); err != nil {
+		return nil, m.reject(map[string]string{m.dir: err.Error()})
+	}
+
+	var plugins []Plugin
+	failures := make(map[string]string)
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(m.dir, e.Name())
+		if err := checkTrustedDir(dir); err != nil {
+			failures[e.Name()] = err.Error()
+			continue
+		}
+		p, err := loadPlugin(dir)
+		if err != nil {
+			failures[e.Name()] = err.Error()
+			continue
+		}
+		plugins = append(plugins, p)
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins, m.reject(failures)
+}
+
+// reject records the current failure set and returns errors for entries that
+// are new or whose reason changed.
+func (m *Manager) reject(failures map[string]string) []error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var errs []error
+	for name, reason := range failures {
+		if m.rejected[name] != reason {
+			errs = append(errs, fmt.Errorf("plugin %s skipped: %s", name, reason))
+		}
+	}
+	m.rejected = failures
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errs
+}
+
+// Due returns the discovered plugins whose interval has elapsed and that are
+// not already running, and marks them running. Each returned plugin must be
+// passed to Run (which clears the mark).
+func (m *Manager) Due(plugins []Plugin) []Plugin {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []Plugin
+	for _, p := range plugins {
+		if m.running[p.Name] {
+			continue
+		}
+		if last, ok := m.lastRun[p.Name]; ok && now.Sub(last) < p.Interval() {
+			continue
+		}
+		m.running[p.Name] = true
+		m.lastRun[p.Name] = now
+		due = append(due, p)
+	}
+	return due
+}
+
+// Run executes the plugin under its timeout and returns the result. A
+// failed run (non-zero exit, timeout, oversized or non-JSON output) is
+// reported in Result.Error rather than as a Go error so the server sees it.
+func (m *Manager) Run(ctx context.Context, p Plugin) *Result {
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, p.Name)
+		m.mu.Unlock()
+	}()
+
+	start := m.now()
+	data, err := execute(ctx, p)
+	result := &Result{
+		Plugin:      p.Name,
+		Version:     p.Version,
+		CollectedAt: start.UTC(),
+		DurationMs:  m.now().Sub(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Data = data
+	}
+	return result
+}
+
+func execute(ctx context.Context, p Plugin) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.Path, p.Args...)
+	cmd.Dir = p.Dir
+	cmd.Env = pluginEnv(os.Environ(), p)
+	stdout := &limitedBuffer{limit: p.MaxOutputBytes}
+	stderr := &tailBuffer{limit: maxStderrBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	configureProcess(cmd)
+	cmd.WaitDelay = killGrace
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out after %s", p.Timeout())
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	if stdout.overflow {
+		return nil, fmt.Errorf("output exceeded %d bytes", p.MaxOutputBytes)
+	}
+	out := bytes.TrimSpace(stdout.Bytes())
+	if !json.Valid(out) {
+		return nil, errors.New("output is not valid JSON")
+	}
+	return json.RawMessage(out), nil
+}
+
+// pluginEnv strips the agent's own BREEZE_* variables (which may carry
+// config overrides or secrets), forces a UTF-8 locale and tells the plugin
+// who it is.
+func pluginEnv(base []string, p Plugin) []string {
+	env := make([]string, 0, len(base)+2)
+	for _, kv := range base {
+		if strings.HasPrefix(strings.ToUpper(kv), "BREEZE_") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	env = append(env, "BREEZE_PLUGIN_NAME="+p.Name, "BREEZE_PLUGIN_DIR="+p.Dir)
+	return procoutput.ApplyEnv(env)
+}
+
+// limitedBuffer keeps at most limit bytes and notes whether more arrived.
+// It never returns an error so a chatty plugin is not killed by SIGPIPE
+// before its exit status is known. The buffer is a named field, not
+// embedded, so exec's io.Copy cannot bypass Write via Buffer.ReadFrom.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.overflow = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) Bytes() []byte { return b.buf.Bytes() }
+
+// tailBuffer keeps the last limit bytes written.
+type tailBuffer struct {
+	buf   []byte
+	limit int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.limit; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string { return string(b.buf) }
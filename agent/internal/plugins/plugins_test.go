@@ -0,0 +1,85 @@
+package plugins
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseManifest(t *testing.T) {
+	m, err := parseManifest([]byte(`{"name":"disk-smart","executable":"bin/collect","endpoint":"smart-health","intervalMinutes":1,"timeoutSeconds":9999}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.IntervalMinutes != MinIntervalMinutes || m.TimeoutSeconds != MaxTimeoutSeconds || m.MaxOutputBytes != DefaultMaxOutputBytes {
+		t.Errorf("limits not clamped/defaulted: %+v", m)
+	}
+
+	bad := map[string]string{
+		"unknown field":   `{"name":"a","executable":"x","endpoint":"e","runAs":"root"}`,
+		"bad name":        `{"name":"../a","executable":"x","endpoint":"e"}`,
+		"endpoint path":   `{"name":"a","executable":"x","endpoint":"software/../x"}`,
+		"escaping exe":    `{"name":"a","executable":"../../bin/sh","endpoint":"e"}`,
+		"absolute exe":    `{"name":"a","executable":"/bin/sh","endpoint":"e"}`,
+		"missing exe":     `{"name":"a","endpoint":"e"}`,
+		"uppercase name":  `{"name":"Disk","executable":"x","endpoint":"e"}`,
+		"trailing object": `{"name":"a","executable":"x","endpoint":"e"`,
+	}
+	for label, data := range bad {
+		if _, err := parseManifest([]byte(data)); err == nil {
+			t.Errorf("%s: expected manifest to be rejected", label)
+		}
+	}
+}
+
+func TestManagerDueRespectsIntervalAndInFlight(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewManager(t.TempDir())
+	m.now = func() time.Time { return now }
+	p := Plugin{Manifest: Manifest{Name: "a", IntervalMinutes: 10}}
+
+	if due := m.Due([]Plugin{p}); len(due) != 1 {
+		t.Fatalf("first pass should run the plugin, got %d", len(due))
+	}
+	now = now.Add(time.Hour)
+	if due := m.Due([]Plugin{p}); len(due) != 0 {
+		t.Fatal("a plugin still running must not be scheduled again")
+	}
+	m.mu.Lock()
+	delete(m.running, "a")
+	m.lastRun["a"] = now
+	m.mu.Unlock()
+	now = now.Add(5 * time.Minute)
+	if due := m.Due([]Plugin{p}); len(due) != 0 {
+		t.Fatal("plugin ran before its interval elapsed")
+	}
+	now = now.Add(5 * time.Minute)
+	if due := m.Due([]Plugin{p}); len(due) != 1 {
+		t.Fatal("plugin should run once its interval elapsed")
+	}
+}
+
+func TestLimitedAndTailBuffers(t *testing.T) {
+	lb := &limitedBuffer{limit: 4}
+	n, err := lb.Write([]byte("abcdef"))
+	if err != nil || n != 6 || !lb.overflow || string(lb.Bytes()) != "abcd" {
+		t.Errorf("limitedBuffer: n=%d err=%v overflow=%v %q", n, err, lb.overflow, lb.Bytes())
+	}
+	tb := &tailBuffer{limit: 4}
+	tb.Write([]byte("abc"))
+	tb.Write([]byte("defg"))
+	if tb.String() != "defg" {
+		t.Errorf("tailBuffer kept %q", tb.String())
+	}
+}
+
+func TestPluginEnvStripsAgentVariables(t *testing.T) {
+	env := pluginEnv([]string{"PATH=/usr/bin", "BREEZE_AUTH_TOKEN=secret", "breeze_server=x"}, Plugin{Manifest: Manifest{Name: "a"}, Dir: "/p/a"})
+	joined := strings.Join(env, "\n")
+	if strings.Contains(joined, "secret") || strings.Contains(joined, "breeze_server") {
+		t.Errorf("agent variables leaked: %v", env)
+	}
+	if !strings.Contains(joined, "BREEZE_PLUGIN_NAME=a") || !strings.Contains(joined, "PATH=/usr/bin") {
+		t.Errorf("expected plugin variables and PATH: %v", env)
+	}
+}
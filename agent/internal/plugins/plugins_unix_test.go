@@ -0,0 +1,100 @@
+//go:build !windows
+
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePlugin(t *testing.T, root, name, manifest, script string) string {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func newTestManager(t *testing.T) (*Manager, string) {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), "plugins")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return NewManager(root), root
+}
+
+func TestDiscoverAndRun(t *testing.T) {
+	m, root := newTestManager(t)
+	writePlugin(t, root, "ok", `{"name":"ok","version":"1.2","executable":"run.sh","endpoint":"custom-ok"}`,
+		`echo '{"hello":"'"$BREEZE_PLUGIN_NAME"'"}'`)
+	writePlugin(t, root, "text", `{"name":"text","executable":"run.sh","endpoint":"custom-text"}`, `echo not json`)
+	writePlugin(t, root, "fails", `{"name":"fails","executable":"run.sh","endpoint":"custom-fails"}`, `echo boom >&2; exit 3`)
+	writePlugin(t, root, "slow", `{"name":"slow","executable":"run.sh","endpoint":"custom-slow","timeoutSeconds":1}`, `sleep 30`)
+	writePlugin(t, root, "big", `{"name":"big","executable":"run.sh","endpoint":"custom-big","maxOutputBytes":8}`, `echo '["aaaaaaaaaaaaaaaa"]'`)
+	writePlugin(t, root, "mismatch", `{"name":"other","executable":"run.sh","endpoint":"e"}`, `true`)
+
+	plugins, errs := m.Discover()
+	if len(plugins) != 5 || len(errs) != 1 || !strings.Contains(errs[0].Error(), "does not match") {
+		t.Fatalf("expected 5 plugins and the mismatched one rejected, got %d plugins, errs %v", len(plugins), errs)
+	}
+	if _, errs := m.Discover(); len(errs) != 0 {
+		t.Errorf("unchanged rejection should not be reported again: %v", errs)
+	}
+
+	results := map[string]*Result{}
+	for _, p := range m.Due(plugins) {
+		results[p.Name] = m.Run(context.Background(), p)
+	}
+	if r := results["ok"]; r.Error != "" || string(r.Data) != `{"hello":"ok"}` || r.Version != "1.2" {
+		t.Errorf("unexpected ok result %+v (data %s)", r, r.Data)
+	}
+	if r := results["text"]; r.Data != nil || !strings.Contains(r.Error, "not valid JSON") {
+		t.Errorf("unexpected text result %+v", r)
+	}
+	if r := results["fails"]; !strings.Contains(r.Error, "exit status 3") || !strings.Contains(r.Error, "boom") {
+		t.Errorf("unexpected fails result %+v", r)
+	}
+	if r := results["slow"]; !strings.Contains(r.Error, "timed out") {
+		t.Errorf("unexpected slow result %+v", r)
+	}
+	if r := results["big"]; !strings.Contains(r.Error, "exceeded 8 bytes") {
+		t.Errorf("unexpected big result %+v", r)
+	}
+}
+
+func TestDiscoverRejectsWritableFiles(t *testing.T) {
+	m, root := newTestManager(t)
+	dir := writePlugin(t, root, "loose", `{"name":"loose","executable":"run.sh","endpoint":"e"}`, `echo '{}'`)
+	if err := os.Chmod(filepath.Join(dir, "run.sh"), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	plugins, errs := m.Discover()
+	if len(plugins) != 0 || len(errs) != 1 || !strings.Contains(errs[0].Error(), "writable") {
+		t.Fatalf("world-writable executable should be rejected, got %v, %v", plugins, errs)
+	}
+
+	if err := os.Chmod(root, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if plugins, errs := m.Discover(); len(plugins) != 0 || len(errs) != 1 {
+		t.Fatalf("world-writable plugins directory should be rejected, got %v, %v", plugins, errs)
+	}
+}
+
+func TestDiscoverMissingDirectory(t *testing.T) {
+	m := NewManager(filepath.Join(t.TempDir(), "absent"))
+	if plugins, errs := m.Discover(); plugins != nil || errs != nil {
+		t.Fatalf("absent directory should yield nothing, got %v, %v", plugins, errs)
+	}
+}
@@ -0,0 +1,67 @@
+//go:build windows
+
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// checkTrusted rejects symlinks and other reparse points. Ownership is not
+// checked per file: the plugins directory lives under the config directory,
+// whose SYSTEM/Administrators-only DACL is inherited by everything below it.
+func checkTrusted(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 || info.Mode()&os.ModeIrregular != 0 {
+		return fmt.Errorf("%s is a reparse point", path)
+	}
+	if d, ok := info.Sys().(*syscall.Win32FileAttributeData); ok && d.FileAttributes&windows.FILE_ATTRIBUTE_REPARSE_POINT != 0 {
+		return fmt.Errorf("%s is a reparse point", path)
+	}
+	return nil
+}
+
+func checkTrustedDir(path string) error {
+	if err := checkTrusted(path); err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", path)
+	}
+	return nil
+}
+
+// checkExecutable only allows file types Windows can start directly.
+func checkExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".exe", ".com", ".bat", ".cmd":
+		return nil
+	}
+	return fmt.Errorf("%s is not an executable (.exe, .com, .bat or .cmd)", path)
+}
+
+// configureProcess keeps the plugin from opening a console window. A timeout
+// kills the plugin process itself; children it spawned are not tracked.
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true, CreationFlags: windows.CREATE_NO_WINDOW}
+}
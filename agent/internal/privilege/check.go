@@ -7,10 +7,16 @@ import "github.com/breeze-rmm/agent/internal/remote/tools"
 var elevatedCommandTypes = map[string]bool{
 	tools.CmdReboot:                   true,
 	tools.CmdShutdown:                 true,
+	tools.CmdSleep:                    true,
+	tools.CmdHibernate:                true,
 	tools.CmdLock:                     true,
 	tools.CmdStartService:             true,
 	tools.CmdStopService:              true,
 	tools.CmdRestartService:           true,
+	tools.CmdServiceStart:             true,
+	tools.CmdServiceStop:              true,
+	tools.CmdServiceRestart:           true,
+	tools.CmdServiceSetStartup:        true,
 	tools.CmdInstallPatches:           true,
 	tools.CmdRollbackPatches:          true,
 	tools.CmdRegistrySet:              true,
@@ -20,6 +26,7 @@ var elevatedCommandTypes = map[string]bool{
 	tools.CmdTaskEnable:               true,
 	tools.CmdTaskDisable:              true,
 	tools.CmdDownloadPatches:          true,
+	tools.CmdPatchStage:               true,
 	tools.CmdScheduleReboot:           true,
 	tools.CmdCancelReboot:             true,
 	tools.CmdApplyAuditPolicyBaseline: true,
@@ -29,6 +36,7 @@ var elevatedCommandTypes = map[string]bool{
 	tools.CmdEncryptionCollectKeys:    true,
 	tools.CmdEncryptionRotateKey:      true,
 	tools.CmdSelfUninstall:            true,
+	tools.CmdRMMCleanup:               true,
 }
 
 // RequiresElevation returns true if the command type needs root/admin privileges.
@@ -15,10 +15,16 @@ func TestRequiresElevationTrueForElevatedCommands(t *testing.T) {
 	elevated := []string{
 		tools.CmdReboot,
 		tools.CmdShutdown,
+		tools.CmdSleep,
+		tools.CmdHibernate,
 		tools.CmdLock,
 		tools.CmdStartService,
 		tools.CmdStopService,
 		tools.CmdRestartService,
+		tools.CmdServiceStart,
+		tools.CmdServiceStop,
+		tools.CmdServiceRestart,
+		tools.CmdServiceSetStartup,
 		tools.CmdInstallPatches,
 		tools.CmdRollbackPatches,
 		tools.CmdRegistrySet,
@@ -28,6 +34,7 @@ func TestRequiresElevationTrueForElevatedCommands(t *testing.T) {
 		tools.CmdTaskEnable,
 		tools.CmdTaskDisable,
 		tools.CmdDownloadPatches,
+		tools.CmdPatchStage,
 		tools.CmdScheduleReboot,
 		tools.CmdCancelReboot,
 		tools.CmdApplyAuditPolicyBaseline,
@@ -52,6 +59,7 @@ func TestRequiresElevationFalseForNonElevatedCommands(t *testing.T) {
 		tools.CmdListProcesses,
 		tools.CmdGetProcess,
 		tools.CmdListServices,
+		tools.CmdServiceList,
 		tools.CmdGetService,
 		tools.CmdEventLogsList,
 		tools.CmdEventLogsQuery,
@@ -0,0 +1,208 @@
+// Package provisioning detects when a device is still being provisioned —
+// Windows sysprep/OOBE/Autopilot ESP or WinPE/task-sequence imaging, macOS
+// Setup Assistant, Linux first-boot cloud-init — so the agent can hold off on
+// patching, reboots and bulk telemetry that would break the provisioning flow.
+package provisioning
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phase names the provisioning stage a device is in.
+type Phase string
+
+const (
+	PhaseNone         Phase = ""
+	PhaseImaging      Phase = "imaging"
+	PhaseSysprep      Phase = "sysprep"
+	PhaseOOBE         Phase = "oobe"
+	PhaseAutopilotESP Phase = "autopilot_esp"
+)
+
+// State is one detection pass.
+type State struct {
+	Phase  Phase
+	Reason string
+	// UserLoggedOn reports that a real user — not a setup account such as
+	// defaultuser0 or _mbsetupuser — has logged on interactively. Platforms
+	// without an interactive first login (Linux servers) always report true.
+	UserLoggedOn bool
+}
+
+// Provisioning reports whether any provisioning indicator was found.
+func (s State) Provisioning() bool { return s.Phase != PhaseNone }
+
+// maxLogonWait bounds how long quiet mode outlives the provisioning
+// indicators while waiting for a first logon, so a server that is never
+// logged on to interactively does not stay quiet forever.
+const maxLogonWait = 72 * time.Hour
+
+// Status is the quiet-mode state reported in the heartbeat.
+type Status struct {
+	Active bool      `json:"active"`
+	Phase  Phase     `json:"phase,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since,omitempty"`
+}
+
+// Detect runs the platform's provisioning checks.
+func Detect() State { return detect() }
+
+// setupAccounts are the transient accounts provisioning flows log on as. A
+// logon by one of them is not the "first real login" that ends quiet mode.
+var setupAccounts = map[string]bool{
+	"defaultuser0":      true,
+	"defaultuser1":      true,
+	"defaultuser100000": true,
+	"_mbsetupuser":      true,
+	"root":              true,
+}
+
+// IsSetupAccount reports whether name (optionally DOMAIN\user or user@domain)
+// is a provisioning-only account.
+func IsSetupAccount(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.LastIndex(name, `\`); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	return name == "" || setupAccounts[name]
+}
+
+// classifyImageState maps the Windows Setup ImageState value to a phase.
+// IMAGE_STATE_COMPLETE (or no value at all) means setup has finished.
+func classifyImageState(state string) Phase {
+	state = strings.ToUpper(strings.TrimSpace(state))
+	switch {
+	case state == "" || state == "IMAGE_STATE_COMPLETE":
+		return PhaseNone
+	case strings.Contains(state, "GENERALIZE"), state == "IMAGE_STATE_UNDEPLOYABLE":
+		return PhaseSysprep
+	default:
+		// IMAGE_STATE_SPECIALIZE_RESEAL_TO_OOBE / _TO_AUDIT and friends.
+		return PhaseOOBE
+	}
+}
+
+// detectCloudInit reports a Linux first boot whose cloud-init run has started
+// but not finished. root is "/" in production and a temp dir in tests.
+func detectCloudInit(root string) State {
+	if _, err := os.Stat(filepath.Join(root, "run", "cloud-init")); err != nil {
+		return State{UserLoggedOn: true}
+	}
+	if _, err := os.Stat(filepath.Join(root, "var", "lib", "cloud", "instance", "boot-finished")); err == nil {
+		return State{UserLoggedOn: true}
+	}
+	return State{Phase: PhaseImaging, Reason: "cloud-init has not finished", UserLoggedOn: true}
+}
+
+// Tracker latches quiet mode: it is entered as soon as a provisioning
+// indicator is seen and left only once the indicators have cleared AND a real
+// user has logged on, so the gap between the end of OOBE and the first login
+// stays quiet too. The latch is persisted so it survives the reboots that
+// provisioning flows are full of.
+type Tracker struct {
+	mu     sync.Mutex
+	path   string
+	detect func() State
+	status Status
+}
+
+// NewTracker returns a Tracker persisting its latch at path ("" disables
+// persistence).
+func NewTracker(path string) *Tracker {
+	t := &Tracker{path: path, detect: Detect}
+	t.load()
+	return t
+}
+
+// Status returns the last evaluated quiet-mode state without re-detecting.
+func (t *Tracker) Status() Status {
+	if t == nil {
+		return Status{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
+// Active reports whether quiet mode is on.
+func (t *Tracker) Active() bool { return t.Status().Active }
+
+// Update re-runs detection and returns the new state plus whether quiet mode
+// was entered or left by this call.
+func (t *Tracker) Update(now time.Time) (Status, bool, error) {
+	if t == nil {
+		return Status{}, false, nil
+	}
+	state := t.detect()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	next := nextStatus(t.status, state, now)
+	changed := next.Active != t.status.Active
+	if next == t.status {
+		return next, false, nil
+	}
+	t.status = next
+	return next, changed, t.save()
+}
+
+// nextStatus applies one detection pass to the latched status.
+func nextStatus(cur Status, state State, now time.Time) Status {
+	if state.Provisioning() {
+		next := Status{Active: true, Phase: state.Phase, Reason: state.Reason, Since: cur.Since}
+		if !cur.Active {
+			next.Since = now.UTC()
+		}
+		return next
+	}
+	if cur.Active && !state.UserLoggedOn && now.Sub(cur.Since) < maxLogonWait {
+		// Setup finished but nobody has logged on yet: stay quiet.
+		cur.Reason = "waiting for first user logon"
+		return cur
+	}
+	return Status{}
+}
+
+func (t *Tracker) load() {
+	if t.path == "" {
+		return
+	}
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return
+	}
+	var status Status
+	if json.Unmarshal(data, &status) == nil && status.Active {
+		t.status = status
+	}
+}
+
+func (t *Tracker) save() error {
+	if t.path == "" {
+		return nil
+	}
+	if !t.status.Active {
+		if err := os.Remove(t.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(t.status)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(t.path, data, 0o600)
+}
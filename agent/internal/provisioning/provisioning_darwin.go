@@ -0,0 +1,48 @@
+//go:build darwin
+
+package provisioning
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// detect treats a Mac that has not completed Setup Assistant (no
+// .AppleSetupDone, or the console owned by _mbsetupuser during automated
+// device enrollment) as in OOBE. A real login is any console owner other
+// than root or a setup account.
+func detect() State {
+	console := consoleUser()
+	state := State{UserLoggedOn: console != "" && !IsSetupAccount(console)}
+
+	if console == "_mbsetupuser" {
+		state.Phase = PhaseOOBE
+		state.Reason = "Setup Assistant is running"
+		return state
+	}
+	if _, err := os.Stat("/var/db/.AppleSetupDone"); os.IsNotExist(err) {
+		state.Phase = PhaseOOBE
+		state.Reason = "Setup Assistant has not completed"
+	}
+	return state
+}
+
+// consoleUser returns the owner of /dev/console: root at the login window,
+// _mbsetupuser during Setup Assistant, otherwise the logged-on user.
+func consoleUser() string {
+	info, err := os.Stat("/dev/console")
+	if err != nil {
+		return ""
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	u, err := user.LookupId(strconv.FormatUint(uint64(st.Uid), 10))
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
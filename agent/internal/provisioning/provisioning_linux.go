@@ -0,0 +1,5 @@
+//go:build linux
+
+package provisioning
+
+func detect() State { return detectCloudInit("/") }
@@ -0,0 +1,104 @@
+package provisioning
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClassifyImageState(t *testing.T) {
+	tests := map[string]Phase{
+		"":                                       PhaseNone,
+		"IMAGE_STATE_COMPLETE":                   PhaseNone,
+		"IMAGE_STATE_GENERALIZE_RESEAL_TO_OOBE":  PhaseSysprep,
+		"IMAGE_STATE_UNDEPLOYABLE":               PhaseSysprep,
+		"IMAGE_STATE_SPECIALIZE_RESEAL_TO_OOBE":  PhaseOOBE,
+		"image_state_specialize_reseal_to_audit": PhaseOOBE,
+	}
+	for in, want := range tests {
+		if got := classifyImageState(in); got != want {
+			t.Errorf("classifyImageState(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestIsSetupAccount(t *testing.T) {
+	for _, name := range []string{"defaultuser0", `WORKGROUP\defaultUser0`, "_mbsetupuser", "root", ""} {
+		if !IsSetupAccount(name) {
+			t.Errorf("%q should be a setup account", name)
+		}
+	}
+	for _, name := range []string{`CONTOSO\alice`, "alice@contoso.com", "bob"} {
+		if IsSetupAccount(name) {
+			t.Errorf("%q should be a real account", name)
+		}
+	}
+}
+
+func TestDetectCloudInit(t *testing.T) {
+	root := t.TempDir()
+	if s := detectCloudInit(root); s.Provisioning() {
+		t.Fatalf("no cloud-init should not be provisioning: %+v", s)
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, "run", "cloud-init"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if s := detectCloudInit(root); s.Phase != PhaseImaging {
+		t.Fatalf("running cloud-init should be imaging, got %+v", s)
+	}
+
+	finished := filepath.Join(root, "var", "lib", "cloud", "instance")
+	if err := os.MkdirAll(finished, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(finished, "boot-finished"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if s := detectCloudInit(root); s.Provisioning() {
+		t.Fatalf("finished cloud-init should not be provisioning: %+v", s)
+	}
+}
+
+func TestTrackerLatchesUntilFirstLogon(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "provisioning_quiet.json")
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	state := State{Phase: PhaseOOBE, Reason: "OOBE in progress"}
+
+	tracker := NewTracker(path)
+	tracker.detect = func() State { return state }
+
+	status, changed, err := tracker.Update(now)
+	if err != nil || !changed || !status.Active || status.Phase != PhaseOOBE {
+		t.Fatalf("Update = %+v, %v, %v; want active oobe", status, changed, err)
+	}
+
+	// OOBE finished, nobody logged on yet: still quiet, and the latch
+	// survives an agent restart.
+	state = State{}
+	if status, changed, _ := tracker.Update(now.Add(time.Hour)); !status.Active || changed {
+		t.Fatalf("quiet mode should hold until first logon: %+v changed=%v", status, changed)
+	}
+	reloaded := NewTracker(path)
+	reloaded.detect = func() State { return state }
+	if got := reloaded.Status(); !got.Active || !got.Since.Equal(now) {
+		t.Fatalf("reloaded status = %+v, want active since %v", got, now)
+	}
+
+	state = State{UserLoggedOn: true}
+	if status, changed, err := reloaded.Update(now.Add(2 * time.Hour)); status.Active || !changed || err != nil {
+		t.Fatalf("first logon should end quiet mode: %+v changed=%v err=%v", status, changed, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("latch file should be removed, stat err = %v", err)
+	}
+}
+
+func TestTrackerGivesUpWaitingForLogon(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	status := nextStatus(Status{Active: true, Phase: PhaseSysprep, Since: now}, State{}, now.Add(maxLogonWait+time.Minute))
+	if status.Active {
+		t.Fatalf("quiet mode should expire without a logon: %+v", status)
+	}
+}
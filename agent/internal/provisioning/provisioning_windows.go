@@ -0,0 +1,95 @@
+//go:build windows
+
+package provisioning
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// detect checks, in order: WinPE (MiniNT), an SCCM/MDT task sequence, the
+// setup-in-progress flags and ImageState written by sysprep/OOBE, and the
+// Autopilot Enrollment Status Page device phase.
+func detect() State {
+	state := State{UserLoggedOn: lastLoggedOnIsReal()}
+
+	if keyExists(`SYSTEM\CurrentControlSet\Control\MiniNT`) {
+		state.Phase, state.Reason = PhaseImaging, "running in WinPE"
+		return state
+	}
+	drive := os.Getenv("SystemDrive")
+	if drive == "" {
+		drive = `C:`
+	}
+	if _, err := os.Stat(filepath.Join(drive+`\`, "_SMSTaskSequence")); err == nil {
+		state.Phase, state.Reason = PhaseImaging, "task sequence in progress"
+		return state
+	}
+
+	if dwordValue(`SYSTEM\Setup`, "SystemSetupInProgress") == 1 {
+		state.Phase, state.Reason = PhaseSysprep, "Windows setup in progress"
+		if dwordValue(`SYSTEM\Setup`, "OOBEInProgress") == 1 {
+			state.Phase, state.Reason = PhaseOOBE, "OOBE in progress"
+		}
+		return state
+	}
+	if imageState := stringValue(`SOFTWARE\Microsoft\Windows\CurrentVersion\Setup\State`, "ImageState"); classifyImageState(imageState) != PhaseNone {
+		state.Phase, state.Reason = classifyImageState(imageState), imageState
+		return state
+	}
+
+	const espSetup = `SOFTWARE\Microsoft\Windows\Autopilot\EnrollmentStatusTracking\Device\Setup`
+	if keyExists(espSetup) && dwordValue(espSetup, "HasProvisioningCompleted") == 0 {
+		state.Phase, state.Reason = PhaseAutopilotESP, "Enrollment Status Page device phase in progress"
+	}
+	return state
+}
+
+// lastLoggedOnIsReal reports whether LogonUI records a last logon by a
+// non-setup account.
+func lastLoggedOnIsReal() bool {
+	const logonUI = `SOFTWARE\Microsoft\Windows\CurrentVersion\Authentication\LogonUI`
+	name := stringValue(logonUI, "LastLoggedOnSAMUser")
+	if name == "" {
+		name = stringValue(logonUI, "LastLoggedOnUser")
+	}
+	return !IsSetupAccount(name)
+}
+
+func keyExists(path string) bool {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, path, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	key.Close()
+	return true
+}
+
+// dwordValue returns the DWORD, or -1 when the key or value is missing.
+func dwordValue(path, name string) int64 {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, path, registry.QUERY_VALUE)
+	if err != nil {
+		return -1
+	}
+	defer key.Close()
+	v, _, err := key.GetIntegerValue(name)
+	if err != nil {
+		return -1
+	}
+	return int64(v)
+}
+
+func stringValue(path, name string) string {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, path, registry.QUERY_VALUE)
+	if err != nil {
+		return ""
+	}
+	defer key.Close()
+	v, _, err := key.GetStringValue(name)
+	if err != nil {
+		return ""
+	}
+	return v
+}
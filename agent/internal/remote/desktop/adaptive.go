@@ -17,6 +17,11 @@ type AdaptiveConfig struct {
 	Cooldown       time.Duration
 	MaxFPS         int       // maximum FPS ceiling (from encoder/session)
 	OnFPSChange    func(int) // called when adaptive FPS changes
+	// OnBitrateChange is called with the new target video bitrate.
+	OnBitrateChange func(int)
+	// OnResolutionChange is called with the new downscale level (0 = native,
+	// see downscaledDimensions) when the controller steps resolution.
+	OnResolutionChange func(int)
 }
 
 // minBitsPerFrame is the minimum bits each frame should receive to maintain
@@ -25,6 +30,18 @@ type AdaptiveConfig struct {
 // from pushing too many low-quality frames.
 const minBitsPerFrame = 40000 // 5KB per frame
 
+const (
+	// floorLossSamplesToDownscale is how many consecutive degrade decisions
+	// with bitrate already at the floor step the encode resolution down one
+	// level. At that point cutting bits further isn't possible and FPS has
+	// already bottomed out, so fewer pixels is the only lever left.
+	floorLossSamplesToDownscale = 5
+
+	// upscaleBitrateFraction: once the controller has recovered to this
+	// share of maxBitrate at a reduced resolution, it steps back up a level.
+	upscaleBitrateFraction = 0.5
+)
+
 type AdaptiveBitrate struct {
 	mu            sync.Mutex
 	encoder       *VideoEncoder
@@ -37,11 +54,20 @@ type AdaptiveBitrate struct {
 	targetBitrate int
 	targetQuality QualityPreset
 
+	// maxBitrate is requestedMaxBitrate (resolution ceiling, viewer slider)
+	// limited by bitrateCap, the session's bandwidth policy; minBitrate is
+	// floorBitrate limited by maxBitrate.
+	requestedMaxBitrate int
+	bitrateCap          int
+	floorBitrate        int
+
 	// Adaptive FPS: scaled with bitrate to maintain per-frame quality.
 	maxFPS      int
 	currentFPS  int
 	onFPSChange func(int)
 
+	onBitrateChange func(int)
+
 	// EWMA-smoothed metrics to avoid reacting to single transient spikes.
 	// Alpha = 0.3 gives ~70% weight to history, 30% to new sample.
 	smoothedLoss float64
@@ -66,6 +92,13 @@ type AdaptiveBitrate struct {
 	// pinned by TestAdaptive_DeepDipRecoveryTime) instead of ~60, without
 	// reintroducing oscillation.
 	upgradeStreak int
+
+	// Resolution downscaling: floorLossCount counts consecutive degrade
+	// decisions made while pinned at minBitrate; resolutionLevel is the
+	// current downscale level (0 = native, up to maxDownscaleLevel).
+	floorLossCount     int
+	resolutionLevel    int
+	onResolutionChange func(int)
 }
 
 func NewAdaptiveBitrate(cfg AdaptiveConfig) (*AdaptiveBitrate, error) {
@@ -120,9 +153,35 @@ func NewAdaptiveBitrate(cfg AdaptiveConfig) (*AdaptiveBitrate, error) {
 		maxFPS:        maxFPS,
 		currentFPS:    initialFPS,
 		onFPSChange:   cfg.OnFPSChange,
+
+		onBitrateChange:    cfg.OnBitrateChange,
+		onResolutionChange: cfg.OnResolutionChange,
+
+		requestedMaxBitrate: cfg.MaxBitrate,
+		floorBitrate:        cfg.MinBitrate,
 	}, nil
 }
 
+// TargetBitrate returns the bitrate the controller currently targets.
+func (a *AdaptiveBitrate) TargetBitrate() int {
+	if a == nil {
+		return 0
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.targetBitrate
+}
+
+// ResolutionLevel returns the current downscale level (0 = native).
+func (a *AdaptiveBitrate) ResolutionLevel() int {
+	if a == nil {
+		return 0
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.resolutionLevel
+}
+
 // SetEncoder updates the encoder pointer after a mid-session encoder swap.
 func (a *AdaptiveBitrate) SetEncoder(enc *VideoEncoder) {
 	if a == nil {
@@ -145,14 +204,40 @@ func (a *AdaptiveBitrate) SetMaxFPS(max int) {
 }
 
 // SetMaxBitrate updates the ceiling the adaptive controller will ramp up to.
-// Called when the viewer adjusts the bitrate slider.
+// Called when the viewer adjusts the bitrate slider. The ceiling never
+// exceeds the bitrate cap.
 func (a *AdaptiveBitrate) SetMaxBitrate(max int) {
 	if a == nil || max <= 0 {
 		return
 	}
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	a.requestedMaxBitrate = max
+	a.applyMaxBitrateLocked()
+}
+
+// SetBitrateCap sets a hard ceiling that later SetMaxBitrate calls cannot
+// lift: the session's bandwidth policy. 0 removes the cap. A cap below the
+// configured floor lowers the floor with it.
+func (a *AdaptiveBitrate) SetBitrateCap(cap int) {
+	if a == nil || cap < 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.bitrateCap = cap
+	a.applyMaxBitrateLocked()
+}
+
+// applyMaxBitrateLocked recomputes the bounds from the requested ceiling and
+// the cap. Caller holds a.mu.
+func (a *AdaptiveBitrate) applyMaxBitrateLocked() {
+	max := a.requestedMaxBitrate
+	if a.bitrateCap > 0 && max > a.bitrateCap {
+		max = a.bitrateCap
+	}
 	a.maxBitrate = max
+	a.minBitrate = min(a.floorBitrate, max)
 	// If current target exceeds the new ceiling, clamp immediately.
 	if a.targetBitrate > max {
 		a.targetBitrate = max
@@ -161,6 +246,9 @@ func (a *AdaptiveBitrate) SetMaxBitrate(max int) {
 				slog.Warn("Failed to clamp bitrate", "targetBitrate", max, "error", err.Error())
 			}
 		}
+		if a.onBitrateChange != nil {
+			a.onBitrateChange(max)
+		}
 	}
 }
 
@@ -182,6 +270,7 @@ func (a *AdaptiveBitrate) SoftResetForActivity() {
 	a.stableCount = 0
 	a.degradeBackoff = 0
 	a.upgradeStreak = 0
+	a.floorLossCount = 0
 	a.samplesCount = 0 // reset network EWMA warmup for fresh conditions
 
 	newFPS := clampInt(moderate/minBitsPerFrame, 10, a.maxFPS)
@@ -199,6 +288,9 @@ func (a *AdaptiveBitrate) SoftResetForActivity() {
 	if encoder != nil {
 		_ = encoder.SetBitrate(moderate)
 	}
+	if a.onBitrateChange != nil {
+		a.onBitrateChange(moderate)
+	}
 	if newFPS != prevFPS && fpsCallback != nil {
 		fpsCallback(newFPS)
 	}
@@ -218,6 +310,9 @@ func (a *AdaptiveBitrate) CapForSoftwareEncoder() {
 
 	const swMaxBitrate = 4_000_000 // 4 Mbps
 
+	if a.requestedMaxBitrate > swMaxBitrate {
+		a.requestedMaxBitrate = swMaxBitrate
+	}
 	if a.maxBitrate > swMaxBitrate {
 		a.maxBitrate = swMaxBitrate
 	}
@@ -226,6 +321,7 @@ func (a *AdaptiveBitrate) CapForSoftwareEncoder() {
 	}
 	encoder := a.encoder
 	targetBitrate := a.targetBitrate
+	bitrateCallback := a.onBitrateChange
 
 	slog.Info("Adaptive: capped for software encoder",
 		"maxBitrate", a.maxBitrate,
@@ -238,6 +334,9 @@ func (a *AdaptiveBitrate) CapForSoftwareEncoder() {
 			slog.Warn("failed to apply software encoder bitrate cap", "bitrate", targetBitrate, "error", err.Error())
 		}
 	}
+	if bitrateCallback != nil {
+		bitrateCallback(targetBitrate)
+	}
 }
 
 // Update feeds a new RTT/loss sample from RTCP and adjusts bitrate.
@@ -294,12 +393,18 @@ func (a *AdaptiveBitrate) Update(rtt time.Duration, packetLoss float64) {
 	if degrade {
 		a.stableCount = 0
 		a.upgradeStreak = 0
+		if a.targetBitrate <= a.minBitrate {
+			a.floorLossCount++
+		} else {
+			a.floorLossCount = 0
+		}
 		// After degrading, require extra stable samples before upgrading again.
 		// This prevents the boom-bust cycle: degrade→recover→immediately ramp
 		// back to the same bitrate that caused congestion. At 1s viewer stats
 		// intervals, backoff=4 means ~4s of stable conditions before upgrading.
 		a.degradeBackoff = 4
 	} else if upgrade {
+		a.floorLossCount = 0
 		a.stableCount++
 		if a.degradeBackoff > 0 {
 			a.degradeBackoff--
@@ -364,22 +469,36 @@ func (a *AdaptiveBitrate) Update(rtt time.Duration, packetLoss float64) {
 		a.upgradeStreak++
 	}
 
+	// Resolution ladder: step down after sustained loss at the bitrate floor,
+	// step back up once the recovered bitrate could carry more pixels.
+	newLevel := a.resolutionLevel
+	if a.floorLossCount >= floorLossSamplesToDownscale && newLevel < maxDownscaleLevel {
+		newLevel++
+		a.floorLossCount = 0
+	} else if action == "upgrade" && newLevel > 0 && float64(newBitrate) >= float64(a.maxBitrate)*upscaleBitrateFraction {
+		newLevel--
+	}
+
 	// Scale FPS with bitrate: ensure each frame gets enough bits for quality.
 	newFPS := clampInt(newBitrate/minBitsPerFrame, 10, a.maxFPS)
 
-	if newBitrate == a.targetBitrate && newQuality == a.targetQuality && newFPS == a.currentFPS {
+	if newBitrate == a.targetBitrate && newQuality == a.targetQuality && newFPS == a.currentFPS && newLevel == a.resolutionLevel {
 		a.mu.Unlock()
 		return
 	}
 
 	prevBitrate := a.targetBitrate
 	prevFPS := a.currentFPS
+	prevLevel := a.resolutionLevel
 	a.targetBitrate = newBitrate
 	a.targetQuality = newQuality
 	a.currentFPS = newFPS
+	a.resolutionLevel = newLevel
 	a.lastAdjust = now
 	encoder := a.encoder
 	fpsCallback := a.onFPSChange
+	bitrateCallback := a.onBitrateChange
+	resolutionCallback := a.onResolutionChange
 	a.mu.Unlock()
 
 	slog.Info("Adaptive bitrate adjustment",
@@ -389,6 +508,7 @@ func (a *AdaptiveBitrate) Update(rtt time.Duration, packetLoss float64) {
 		"fps", newFPS,
 		"prevFPS", prevFPS,
 		"quality", newQuality,
+		"resolutionLevel", newLevel,
 		"smoothedLoss", loss,
 		"smoothedRTT", smoothRTT.Round(time.Millisecond),
 	)
@@ -396,6 +516,12 @@ func (a *AdaptiveBitrate) Update(rtt time.Duration, packetLoss float64) {
 	if newFPS != prevFPS && fpsCallback != nil {
 		fpsCallback(newFPS)
 	}
+	if newLevel != prevLevel && resolutionCallback != nil {
+		resolutionCallback(newLevel)
+	}
+	if newBitrate != prevBitrate && bitrateCallback != nil {
+		bitrateCallback(newBitrate)
+	}
 
 	if encoder != nil {
 		if err := encoder.SetBitrate(newBitrate); err != nil {
@@ -461,3 +461,72 @@ func abs(x int) int {
 	}
 	return x
 }
+
+func TestAdaptive_OnBitrateChangeFollowsTarget(t *testing.T) {
+	a, stub := newTestAdaptive(2_000_000, 500_000, 8_000_000)
+	var got []int
+	a.onBitrateChange = func(bps int) { got = append(got, bps) }
+
+	warmup(a, 50*time.Millisecond, 0.10)
+	if len(got) != 1 || got[0] != stub.bitrate {
+		t.Fatalf("expected one callback with %d after degrade, got %v", stub.bitrate, got)
+	}
+
+	a.SetMaxBitrate(1_000_000)
+	if got[len(got)-1] != 1_000_000 {
+		t.Fatalf("expected callback with clamped 1M, got %v", got)
+	}
+}
+
+func TestAdaptive_DownscalesAfterSustainedLossAtFloor(t *testing.T) {
+	a, _ := newTestAdaptive(500_000, 500_000, 2_000_000)
+	var levels []int
+	a.onResolutionChange = func(level int) { levels = append(levels, level) }
+
+	// The 5th warmup sample is the first degrade at the floor.
+	warmup(a, 50*time.Millisecond, 0.10)
+	for i := 0; i < floorLossSamplesToDownscale-2; i++ {
+		a.Update(50*time.Millisecond, 0.10)
+	}
+	if a.ResolutionLevel() != 0 {
+		t.Fatalf("stepped down after only %d floor samples", floorLossSamplesToDownscale-1)
+	}
+	a.Update(50*time.Millisecond, 0.10)
+	if a.ResolutionLevel() != 1 || len(levels) != 1 || levels[0] != 1 {
+		t.Fatalf("expected one step to level 1, got level=%d callbacks=%v", a.ResolutionLevel(), levels)
+	}
+
+	// Never past the bottom of the ladder.
+	for i := 0; i < floorLossSamplesToDownscale*(maxDownscaleLevel+2); i++ {
+		a.Update(50*time.Millisecond, 0.10)
+	}
+	if a.ResolutionLevel() != maxDownscaleLevel {
+		t.Fatalf("expected level capped at %d, got %d", maxDownscaleLevel, a.ResolutionLevel())
+	}
+
+	// Clean conditions recover bitrate first, then resolution.
+	for i := 0; i < 200 && a.ResolutionLevel() > 0; i++ {
+		a.Update(20*time.Millisecond, 0.0)
+	}
+	if a.ResolutionLevel() != 0 {
+		t.Fatalf("expected native resolution after recovery, got level %d", a.ResolutionLevel())
+	}
+	if a.targetBitrate < int(float64(a.maxBitrate)*upscaleBitrateFraction) {
+		t.Fatalf("restored resolution at bitrate %d, below %v of max", a.targetBitrate, upscaleBitrateFraction)
+	}
+}
+
+func TestAdaptive_LossAboveFloorDoesNotDownscale(t *testing.T) {
+	a, _ := newTestAdaptive(8_000_000, 500_000, 8_000_000)
+	// 0.85x per degrade from 8M takes ~13 degrades to reach the floor; a
+	// handful of degrades above it must not touch resolution.
+	for i := 0; i < 8; i++ {
+		a.Update(50*time.Millisecond, 0.10)
+	}
+	if a.targetBitrate <= a.minBitrate {
+		t.Fatalf("test precondition: bitrate reached floor (%d)", a.targetBitrate)
+	}
+	if a.ResolutionLevel() != 0 {
+		t.Fatalf("expected no downscale above the floor, got level %d", a.ResolutionLevel())
+	}
+}
@@ -0,0 +1,468 @@
+package desktop
+
+// Annotations ("click here" guidance).
+//
+// The viewer draws on an "annotations" data channel:
+//
+//	{"type":"pointer","x":0.42,"y":0.61}
+//	{"type":"stroke","id":"s1","points":[[0.1,0.2],[0.15,0.22]],"color":"#ff3b30","width":4}
+//	{"type":"clear"}
+//
+// Coordinates are fractions of the streamed display. Points sent for an
+// existing stroke ID are appended, so the viewer can stream a stroke while it
+// is being drawn. Nothing is permanent: the laser pointer fades
+// annotationPointerHold after it last moved, strokes annotationStrokeHold
+// after their last point, each over annotationFade.
+//
+// Where the platform has an on-screen surface (annotationSurfaceSupported;
+// Windows uses a click-through layered window), the user sees the overlay on
+// their screen and capture picks it up like any other window. Elsewhere the
+// layer is composited into captured frames next to the cursor overlay, so
+// only viewers see it. The agent's first message on the channel,
+// {"type":"ready","onScreen":bool}, tells the viewer which applies.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	annotationPointerHold = time.Second
+	annotationStrokeHold  = 3 * time.Second
+	annotationFade        = time.Second
+
+	maxAnnotationMessageBytes = 64 << 10
+	maxAnnotationStrokes      = 32
+	maxAnnotationStrokePoints = 4096
+
+	minAnnotationWidth     = 2
+	maxAnnotationWidth     = 24
+	defaultAnnotationWidth = 4
+
+	annotationPointerRadius = 7
+	annotationPointerRing   = 10
+)
+
+var (
+	defaultAnnotationColor = color.RGBA{R: 0xff, G: 0x3b, B: 0x30, A: 0xff}
+	annotationRingColor    = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xe0}
+)
+
+// annotationMessage is the JSON shape on the annotations data channel.
+type annotationMessage struct {
+	Type   string       `json:"type"`
+	X      float64      `json:"x"`
+	Y      float64      `json:"y"`
+	ID     string       `json:"id"`
+	Points [][2]float64 `json:"points"`
+	Color  string       `json:"color"`
+	Width  float64      `json:"width"`
+}
+
+type annotationPoint struct{ x, y float64 }
+
+type annotationStroke struct {
+	id      string
+	points  []annotationPoint
+	color   color.RGBA
+	width   float64
+	updated time.Time
+}
+
+// annotationLayer holds the viewer's live annotations. Safe for concurrent
+// use: the data channel writes, the capture loop or on-screen surface reads.
+type annotationLayer struct {
+	mu        sync.Mutex
+	pointer   annotationPoint
+	pointerAt time.Time // zero when there is no pointer
+	strokes   []*annotationStroke
+	mask      []uint8 // scratch coverage buffer for render
+}
+
+func newAnnotationLayer() *annotationLayer {
+	return &annotationLayer{}
+}
+
+// parseAnnotationColor accepts "#rrggbb"; anything else is the default red.
+func parseAnnotationColor(s string) color.RGBA {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) != 6 {
+		return defaultAnnotationColor
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return defaultAnnotationColor
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+}
+
+// validAnnotationCoord reports whether v is a usable display fraction.
+func validAnnotationCoord(v float64) bool {
+	return !math.IsNaN(v) && v >= 0 && v <= 1
+}
+
+// apply records one viewer message.
+func (l *annotationLayer) apply(msg annotationMessage, now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch msg.Type {
+	case "pointer":
+		if !validAnnotationCoord(msg.X) || !validAnnotationCoord(msg.Y) {
+			return errors.New("pointer outside the display")
+		}
+		l.pointer = annotationPoint{msg.X, msg.Y}
+		l.pointerAt = now
+	case "stroke":
+		if msg.ID == "" || len(msg.ID) > 64 {
+			return errors.New("stroke needs a short id")
+		}
+		s := l.strokeLocked(msg, now)
+		for _, p := range msg.Points {
+			if len(s.points) >= maxAnnotationStrokePoints {
+				break
+			}
+			if validAnnotationCoord(p[0]) && validAnnotationCoord(p[1]) {
+				s.points = append(s.points, annotationPoint{p[0], p[1]})
+			}
+		}
+		s.updated = now
+	case "clear":
+		l.pointerAt = time.Time{}
+		l.strokes = nil
+	default:
+		return fmt.Errorf("unknown annotation type %q", msg.Type)
+	}
+	return nil
+}
+
+// strokeLocked returns the stroke with msg.ID, starting a new one (and
+// evicting the oldest past maxAnnotationStrokes) when there is none.
+func (l *annotationLayer) strokeLocked(msg annotationMessage, now time.Time) *annotationStroke {
+	for _, s := range l.strokes {
+		if s.id == msg.ID {
+			return s
+		}
+	}
+	width := msg.Width
+	if width == 0 || math.IsNaN(width) {
+		width = defaultAnnotationWidth
+	}
+	s := &annotationStroke{
+		id:      msg.ID,
+		color:   parseAnnotationColor(msg.Color),
+		width:   min(max(width, minAnnotationWidth), maxAnnotationWidth),
+		updated: now,
+	}
+	if len(l.strokes) >= maxAnnotationStrokes {
+		l.strokes = l.strokes[1:]
+	}
+	l.strokes = append(l.strokes, s)
+	return s
+}
+
+// clear drops every annotation, e.g. after a monitor switch.
+func (l *annotationLayer) clear() {
+	l.mu.Lock()
+	l.pointerAt = time.Time{}
+	l.strokes = nil
+	l.mu.Unlock()
+}
+
+// annotationOpacity is the fade factor for something last updated at
+// updated: 1 while held, falling linearly to 0 over annotationFade.
+func annotationOpacity(updated time.Time, hold time.Duration, now time.Time) float64 {
+	if updated.IsZero() {
+		return 0
+	}
+	age := now.Sub(updated)
+	switch {
+	case age < hold:
+		return 1
+	case age >= hold+annotationFade:
+		return 0
+	}
+	return 1 - float64(age-hold)/float64(annotationFade)
+}
+
+// visible reports whether anything is still on screen, pruning what has
+// fully faded.
+func (l *annotationLayer) visible(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pruneLocked(now)
+}
+
+func (l *annotationLayer) pruneLocked(now time.Time) bool {
+	if annotationOpacity(l.pointerAt, annotationPointerHold, now) == 0 {
+		l.pointerAt = time.Time{}
+	}
+	kept := l.strokes[:0]
+	for _, s := range l.strokes {
+		if annotationOpacity(s.updated, annotationStrokeHold, now) > 0 {
+			kept = append(kept, s)
+		}
+	}
+	clear(l.strokes[len(kept):])
+	l.strokes = kept
+	return !l.pointerAt.IsZero() || len(l.strokes) > 0
+}
+
+// render draws the layer over a w×h 32bpp image (RGBA, or BGRA when bgra is
+// set) and returns the bounds it touched. Pixels are blended premultiplied
+// "over", which leaves opaque frames opaque and also suits a transparent
+// premultiplied canvas such as a layered window's bitmap.
+func (l *annotationLayer) render(pix []byte, stride, w, h int, bgra bool, now time.Time) image.Rectangle {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var touched image.Rectangle
+	if !l.pruneLocked(now) {
+		return touched
+	}
+	c := annotationCanvas{pix: pix, stride: stride, w: w, h: h, bgra: bgra}
+	for _, s := range l.strokes {
+		op := annotationOpacity(s.updated, annotationStrokeHold, now)
+		touched = touched.Union(c.stroke(s, op, &l.mask))
+	}
+	if op := annotationOpacity(l.pointerAt, annotationPointerHold, now); op > 0 {
+		x, y := l.pointer.x*float64(w), l.pointer.y*float64(h)
+		touched = touched.Union(c.disc(x, y, annotationPointerRing, annotationRingColor, op))
+		touched = touched.Union(c.disc(x, y, annotationPointerRadius, defaultAnnotationColor, op))
+	}
+	return touched
+}
+
+// annotationCanvas rasterizes onto a 32bpp pixel buffer.
+type annotationCanvas struct {
+	pix    []byte
+	stride int
+	w, h   int
+	bgra   bool
+}
+
+// blend composites c at coverage (0..1) over the pixel at (x, y).
+func (c *annotationCanvas) blend(x, y int, col color.RGBA, coverage float64) {
+	a := coverage * float64(col.A) / 255
+	if a <= 0 {
+		return
+	}
+	off := y*c.stride + x*4
+	if off < 0 || off+3 >= len(c.pix) {
+		return
+	}
+	r, b := col.R, col.B
+	if c.bgra {
+		r, b = b, r
+	}
+	p := c.pix[off : off+4 : off+4]
+	inv := 1 - a
+	p[0] = uint8(float64(r)*a + float64(p[0])*inv + 0.5)
+	p[1] = uint8(float64(col.G)*a + float64(p[1])*inv + 0.5)
+	p[2] = uint8(float64(b)*a + float64(p[2])*inv + 0.5)
+	p[3] = uint8(255*a + float64(p[3])*inv + 0.5)
+}
+
+// clip bounds a rectangle to the canvas.
+func (c *annotationCanvas) clip(r image.Rectangle) image.Rectangle {
+	return r.Intersect(image.Rect(0, 0, c.w, c.h))
+}
+
+// disc draws an antialiased filled circle and returns the bounds touched.
+func (c *annotationCanvas) disc(cx, cy, radius float64, col color.RGBA, opacity float64) image.Rectangle {
+	r := c.clip(image.Rect(int(cx-radius-1), int(cy-radius-1), int(cx+radius+2), int(cy+radius+2)))
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			d := math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+			if cov := min(radius+0.5-d, 1); cov > 0 {
+				c.blend(x, y, col, cov*opacity)
+			}
+		}
+	}
+	return r
+}
+
+// stroke draws a polyline of round-capped segments. Coverage is first
+// accumulated (max, not sum) into mask so overlapping stamps along the line
+// don't darken it, then blended once.
+func (c *annotationCanvas) stroke(s *annotationStroke, opacity float64, mask *[]uint8) image.Rectangle {
+	if len(s.points) == 0 || opacity <= 0 {
+		return image.Rectangle{}
+	}
+	radius := s.width / 2
+	pts := make([]annotationPoint, len(s.points))
+	var bounds image.Rectangle
+	for i, p := range s.points {
+		pts[i] = annotationPoint{p.x * float64(c.w), p.y * float64(c.h)}
+		pr := image.Rect(int(pts[i].x-radius-1), int(pts[i].y-radius-1), int(pts[i].x+radius+2), int(pts[i].y+radius+2))
+		if i == 0 {
+			bounds = pr
+		} else {
+			bounds = bounds.Union(pr)
+		}
+	}
+	bounds = c.clip(bounds)
+	bw, bh := bounds.Dx(), bounds.Dy()
+	if bw <= 0 || bh <= 0 {
+		return image.Rectangle{}
+	}
+	if cap(*mask) < bw*bh {
+		*mask = make([]uint8, bw*bh)
+	}
+	m := (*mask)[:bw*bh]
+	clear(m)
+
+	stamp := func(cx, cy float64) {
+		r := image.Rect(int(cx-radius-1), int(cy-radius-1), int(cx+radius+2), int(cy+radius+2)).Intersect(bounds)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				d := math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+				cov := min(radius+0.5-d, 1)
+				if cov <= 0 {
+					continue
+				}
+				i := (y-bounds.Min.Y)*bw + (x - bounds.Min.X)
+				if v := uint8(cov * 255); v > m[i] {
+					m[i] = v
+				}
+			}
+		}
+	}
+	step := max(radius/2, 0.5)
+	stamp(pts[0].x, pts[0].y)
+	for i := 1; i < len(pts); i++ {
+		dx, dy := pts[i].x-pts[i-1].x, pts[i].y-pts[i-1].y
+		n := int(math.Ceil(math.Hypot(dx, dy) / step))
+		for j := 1; j <= n; j++ {
+			t := float64(j) / float64(n)
+			stamp(pts[i-1].x+dx*t, pts[i-1].y+dy*t)
+		}
+	}
+
+	for y := 0; y < bh; y++ {
+		for x := 0; x < bw; x++ {
+			if v := m[y*bw+x]; v > 0 {
+				c.blend(bounds.Min.X+x, bounds.Min.Y+y, s.color, float64(v)/255*opacity)
+			}
+		}
+	}
+	return bounds
+}
+
+// annotationSurface shows the annotation layer on the target's physical
+// screen; see annotation_windows.go.
+type annotationSurface interface {
+	Close()
+}
+
+// handleAnnotationMessage applies one message from the viewer's annotations
+// channel and, where supported, makes sure the on-screen surface is up.
+func (s *Session) handleAnnotationMessage(data []byte) {
+	if s.annotations == nil {
+		return
+	}
+	if len(data) > maxAnnotationMessageBytes {
+		slog.Warn("Rejected oversized annotation message", "session", s.id, "size", len(data))
+		return
+	}
+	var msg annotationMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		slog.Warn("Failed to parse annotation message", "session", s.id, "error", err.Error())
+		return
+	}
+	if err := s.annotations.apply(msg, time.Now()); err != nil {
+		slog.Debug("Ignoring annotation message", "session", s.id, "error", err.Error())
+		return
+	}
+	if msg.Type != "clear" {
+		s.ensureAnnotationSurface()
+	}
+}
+
+// ensureAnnotationSurface opens the on-screen surface over the streamed
+// display on first use. A failure is logged once; the capture loop then
+// composites the layer into frames instead.
+func (s *Session) ensureAnnotationSurface() {
+	if !annotationSurfaceSupported {
+		return
+	}
+	s.mu.RLock()
+	skip := s.annotationSurface != nil || s.annotationSurfaceFailed || !s.isActive
+	displayIndex := s.displayIndex
+	s.mu.RUnlock()
+	if skip {
+		return
+	}
+
+	surface, err := newAnnotationSurface(s.annotations, displayIndex)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.annotationSurfaceFailed = true
+		slog.Warn("Annotation overlay unavailable on screen, compositing into the stream",
+			"session", s.id, "display", displayIndex, "error", err.Error())
+		return
+	}
+	if !s.isActive || s.annotationSurface != nil || s.displayIndex != displayIndex {
+		surface.Close()
+		return
+	}
+	s.annotationSurface = surface
+	slog.Info("Annotation overlay shown on screen", "session", s.id, "display", displayIndex)
+}
+
+// resetAnnotations drops every annotation and the on-screen surface; called
+// on monitor switch (coordinates refer to the old display) and cleanup.
+func (s *Session) resetAnnotations() {
+	if s.annotations == nil {
+		return
+	}
+	s.annotations.clear()
+	s.mu.Lock()
+	surface := s.annotationSurface
+	s.annotationSurface = nil
+	s.annotationSurfaceFailed = false
+	s.mu.Unlock()
+	if surface != nil {
+		surface.Close()
+	}
+}
+
+// compositeAnnotations reports whether the capture loop must draw the
+// annotation layer into the frame: something is visible and no on-screen
+// surface shows it already.
+func (s *Session) compositeAnnotations(now time.Time) bool {
+	if s.annotations == nil || !s.annotations.visible(now) {
+		return false
+	}
+	s.mu.RLock()
+	onScreen := s.annotationSurface != nil
+	s.mu.RUnlock()
+	return !onScreen
+}
+
+// sendAnnotationsReady tells the viewer whether annotations will appear on
+// the user's screen or only in the stream.
+func (s *Session) sendAnnotationsReady() {
+	resp, err := json.Marshal(map[string]any{
+		"type":     "ready",
+		"onScreen": annotationSurfaceSupported,
+	})
+	if err != nil {
+		return
+	}
+	s.mu.RLock()
+	dc := s.annotationsDC
+	s.mu.RUnlock()
+	if dc != nil {
+		dc.SendText(string(resp))
+	}
+}
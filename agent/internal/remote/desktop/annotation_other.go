@@ -0,0 +1,13 @@
+//go:build !windows
+
+package desktop
+
+import "errors"
+
+// annotationSurfaceSupported is false here: without an on-screen surface
+// the capture loop composites annotations into the stream.
+const annotationSurfaceSupported = false
+
+func newAnnotationSurface(*annotationLayer, int) (annotationSurface, error) {
+	return nil, errors.New("annotation overlay not supported on this platform")
+}
@@ -0,0 +1,121 @@
+package desktop
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+func TestAnnotationOpacityFades(t *testing.T) {
+	start := time.Unix(1000, 0)
+	tests := []struct {
+		age  time.Duration
+		want float64
+	}{
+		{0, 1},
+		{annotationStrokeHold - time.Millisecond, 1},
+		{annotationStrokeHold + annotationFade/2, 0.5},
+		{annotationStrokeHold + annotationFade, 0},
+	}
+	for _, tt := range tests {
+		if got := annotationOpacity(start, annotationStrokeHold, start.Add(tt.age)); got != tt.want {
+			t.Errorf("opacity after %v = %v, want %v", tt.age, got, tt.want)
+		}
+	}
+	if annotationOpacity(time.Time{}, annotationStrokeHold, start) != 0 {
+		t.Error("zero update time should be invisible")
+	}
+}
+
+func TestAnnotationLayerApply(t *testing.T) {
+	l := newAnnotationLayer()
+	now := time.Unix(1000, 0)
+
+	for _, msg := range []annotationMessage{
+		{Type: "pointer", X: 1.5, Y: 0.5},
+		{Type: "stroke", Points: [][2]float64{{0.1, 0.1}}},
+		{Type: "scribble"},
+	} {
+		if err := l.apply(msg, now); err == nil {
+			t.Errorf("apply(%+v) accepted an invalid message", msg)
+		}
+	}
+	if l.visible(now) {
+		t.Fatal("rejected messages left something visible")
+	}
+
+	// Points for an existing stroke are appended; invalid points dropped.
+	_ = l.apply(annotationMessage{Type: "stroke", ID: "s1", Points: [][2]float64{{0.1, 0.1}}, Width: 100}, now)
+	_ = l.apply(annotationMessage{Type: "stroke", ID: "s1", Points: [][2]float64{{0.2, 0.2}, {-1, 0}}}, now)
+	if len(l.strokes) != 1 || len(l.strokes[0].points) != 2 || l.strokes[0].width != maxAnnotationWidth {
+		t.Fatalf("strokes = %+v", l.strokes)
+	}
+
+	for i := 0; i < maxAnnotationStrokes+5; i++ {
+		_ = l.apply(annotationMessage{Type: "stroke", ID: string(rune('a' + i)), Points: [][2]float64{{0.5, 0.5}}}, now)
+	}
+	if len(l.strokes) != maxAnnotationStrokes {
+		t.Fatalf("kept %d strokes, want %d", len(l.strokes), maxAnnotationStrokes)
+	}
+
+	if !l.visible(now.Add(annotationStrokeHold)) {
+		t.Fatal("strokes gone before fading out")
+	}
+	if l.visible(now.Add(annotationStrokeHold + annotationFade)) {
+		t.Fatal("strokes still visible after fading out")
+	}
+
+	_ = l.apply(annotationMessage{Type: "pointer", X: 0.5, Y: 0.5}, now)
+	_ = l.apply(annotationMessage{Type: "clear"}, now)
+	if l.visible(now) {
+		t.Fatal("clear left something visible")
+	}
+}
+
+func TestAnnotationLayerRender(t *testing.T) {
+	l := newAnnotationLayer()
+	now := time.Unix(1000, 0)
+	_ = l.apply(annotationMessage{Type: "pointer", X: 0.5, Y: 0.5}, now)
+	_ = l.apply(annotationMessage{Type: "stroke", ID: "s1", Color: "#0000ff", Width: 4,
+		Points: [][2]float64{{0.1, 0.9}, {0.9, 0.9}}}, now)
+
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	touched := l.render(img.Pix, img.Stride, 100, 100, false, now)
+	if !touched.In(img.Rect) || touched.Empty() {
+		t.Fatalf("touched = %v", touched)
+	}
+	if c := img.RGBAAt(50, 50); c.R != 0xff || c.G != 0x3b || c.A != 0xff {
+		t.Errorf("pointer center = %+v, want the pointer red", c)
+	}
+	if c := img.RGBAAt(50, 90); c.B != 0xff || c.R != 0 {
+		t.Errorf("stroke pixel = %+v, want blue", c)
+	}
+	if c := img.RGBAAt(50, 20); c != (img.RGBAAt(0, 0)) || c.A != 0 {
+		t.Errorf("untouched pixel = %+v, want transparent", c)
+	}
+
+	// BGRA swaps red and blue.
+	bgra := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	l.render(bgra.Pix, bgra.Stride, 100, 100, true, now)
+	if c := bgra.RGBAAt(50, 90); c.R != 0xff || c.B != 0 {
+		t.Errorf("BGRA stroke pixel = %+v, want blue in the first byte", c)
+	}
+
+	faded := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	if r := l.render(faded.Pix, faded.Stride, 100, 100, false, now.Add(time.Hour)); !r.Empty() {
+		t.Fatalf("faded layer touched %v", r)
+	}
+}
+
+func TestSessionAnnotationsCompositeWithoutSurface(t *testing.T) {
+	s := &Session{id: "s1", isActive: true, annotations: newAnnotationLayer()}
+	s.handleAnnotationMessage([]byte(`{"type":"pointer","x":0.25,"y":0.75}`))
+	onScreen := s.annotationSurface != nil
+	if got := s.compositeAnnotations(time.Now()); got == onScreen {
+		t.Fatalf("compositeAnnotations = %v with on-screen surface %v", got, onScreen)
+	}
+	s.resetAnnotations()
+	if s.compositeAnnotations(time.Now()) || s.annotationSurface != nil {
+		t.Fatal("resetAnnotations left annotations or a surface behind")
+	}
+}
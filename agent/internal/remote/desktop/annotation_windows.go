@@ -0,0 +1,316 @@
+//go:build windows
+
+package desktop
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// The annotation surface is a topmost, click-through, non-activating layered
+// window over the streamed monitor. The window thread renders the layer into
+// a premultiplied BGRA DIB on a 30 Hz timer and presents it with
+// UpdateLayeredWindow, so transparent pixels stay truly transparent and the
+// user's clicks go through to the windows below. Unlike the privacy overlay
+// it is NOT excluded from capture: the stream shows exactly what the user
+// sees. Like the privacy overlay it lives on the default desktop, so UAC and
+// the lock screen are never annotated, and it dies with the helper.
+const annotationSurfaceSupported = true
+
+const (
+	annotationClassName     = "BreezeAnnotationOverlay"
+	annotationTimerID       = 1
+	annotationTickMs        = 33
+	annotationTopmostTicks  = 30 // re-assert topmost about once a second
+	annotationCreateTimeout = 5 * time.Second
+
+	ulwAlpha   = 0x00000002
+	acSrcAlpha = 0x01
+)
+
+var (
+	procAnnotationUpdateLayeredWindow = user32.NewProc("UpdateLayeredWindow")
+	procAnnotationCreateCompatibleDC  = privacyGdi32.NewProc("CreateCompatibleDC")
+	procAnnotationCreateDIBSection    = privacyGdi32.NewProc("CreateDIBSection")
+	procAnnotationDeleteDC            = privacyGdi32.NewProc("DeleteDC")
+)
+
+type annotationBitmapInfoHeader struct {
+	biSize          uint32
+	biWidth         int32
+	biHeight        int32
+	biPlanes        uint16
+	biBitCount      uint16
+	biCompression   uint32
+	biSizeImage     uint32
+	biXPelsPerMeter int32
+	biYPelsPerMeter int32
+	biClrUsed       uint32
+	biClrImportant  uint32
+}
+
+type annotationWinPoint struct{ x, y int32 }
+
+type annotationBlendFunction struct {
+	blendOp             byte
+	blendFlags          byte
+	sourceConstantAlpha byte
+	alphaFormat         byte
+}
+
+// annotationWindow is the per-window state the window procedure renders
+// from. Owned by the window's thread.
+type annotationWindow struct {
+	layer   *annotationLayer
+	bounds  image.Rectangle // monitor rect in virtual-screen coordinates
+	memDC   uintptr
+	bitmap  uintptr
+	oldBmp  uintptr
+	pix     []byte
+	drawn   image.Rectangle // area of pix painted by the last render
+	ticks   int
+	present bool // last present showed something
+}
+
+var (
+	annotationWindowsMu sync.Mutex
+	annotationWindows   = map[uintptr]*annotationWindow{}
+	annotationClassReg  sync.Once
+)
+
+type windowsAnnotationSurface struct {
+	hwnd uintptr
+}
+
+func (s *windowsAnnotationSurface) Close() {
+	procPrivacyPostMessageW.Call(s.hwnd, pwmClose, 0, 0)
+}
+
+// newAnnotationSurface opens the overlay window over the given display.
+func newAnnotationSurface(layer *annotationLayer, displayIndex int) (annotationSurface, error) {
+	monitors, err := ListMonitors()
+	if err != nil {
+		return nil, fmt.Errorf("list monitors: %w", err)
+	}
+	var bounds image.Rectangle
+	for _, m := range monitors {
+		if m.Index == displayIndex {
+			bounds = image.Rect(m.X, m.Y, m.X+m.Width, m.Y+m.Height)
+		}
+	}
+	if bounds.Empty() {
+		return nil, fmt.Errorf("display %d not found", displayIndex)
+	}
+
+	// Same handoff as the privacy overlay: a window created after the caller
+	// gave up is destroyed, never shown.
+	type created struct {
+		hwnd uintptr
+		err  error
+	}
+	ready := make(chan created)
+	abandoned := make(chan struct{})
+	go annotationWindowLoop(layer, bounds, func(hwnd uintptr, err error) bool {
+		select {
+		case ready <- created{hwnd, err}:
+			return true
+		case <-abandoned:
+			return false
+		}
+	})
+	select {
+	case c := <-ready:
+		if c.err != nil {
+			return nil, c.err
+		}
+		return &windowsAnnotationSurface{hwnd: c.hwnd}, nil
+	case <-time.After(annotationCreateTimeout):
+		close(abandoned)
+		return nil, errors.New("annotation overlay creation timed out")
+	}
+}
+
+func registerAnnotationClass() {
+	annotationClassReg.Do(func() {
+		hInst, _, _ := procPrivacyGetModuleHandleW.Call(0)
+		className, _ := syscall.UTF16PtrFromString(annotationClassName)
+		wc := privacyWndClassEx{
+			cbSize:        uint32(unsafe.Sizeof(privacyWndClassEx{})),
+			lpfnWndProc:   syscall.NewCallback(annotationWndProc),
+			hInstance:     hInst,
+			lpszClassName: className,
+		}
+		procPrivacyRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc)))
+	})
+}
+
+func annotationWndProc(hwnd uintptr, msg uint32, wParam, lParam uintptr) uintptr {
+	switch msg {
+	case pwmTimer:
+		annotationWindowsMu.Lock()
+		w := annotationWindows[hwnd]
+		annotationWindowsMu.Unlock()
+		if w != nil {
+			w.tick(hwnd)
+		}
+		return 0
+	case pwmClose:
+		procPrivacyDestroyWindow.Call(hwnd)
+		return 0
+	case pwmDestroy:
+		annotationWindowsMu.Lock()
+		w := annotationWindows[hwnd]
+		delete(annotationWindows, hwnd)
+		annotationWindowsMu.Unlock()
+		if w != nil {
+			w.release()
+		}
+		procPrivacyPostQuitMessage.Call(0)
+		return 0
+	}
+	ret, _, _ := procPrivacyDefWindowProcW.Call(hwnd, uintptr(msg), wParam, lParam)
+	return ret
+}
+
+// tick re-renders the layer when something is visible or was visible on the
+// last present, and periodically keeps the window on top.
+func (w *annotationWindow) tick(hwnd uintptr) {
+	w.ticks++
+	if w.ticks%annotationTopmostTicks == 0 {
+		procPrivacySetWindowPos.Call(hwnd, pHwndTopmost,
+			uintptr(w.bounds.Min.X), uintptr(w.bounds.Min.Y), uintptr(w.bounds.Dx()), uintptr(w.bounds.Dy()), pSwpNoactivate)
+	}
+	now := time.Now()
+	if !w.present && !w.layer.visible(now) {
+		return
+	}
+	stride := w.bounds.Dx() * 4
+	for y := w.drawn.Min.Y; y < w.drawn.Max.Y; y++ {
+		clear(w.pix[y*stride+w.drawn.Min.X*4 : y*stride+w.drawn.Max.X*4])
+	}
+	w.drawn = w.layer.render(w.pix, stride, w.bounds.Dx(), w.bounds.Dy(), true, now)
+	w.present = !w.drawn.Empty()
+	w.update(hwnd)
+}
+
+// update presents the DIB with per-pixel alpha.
+func (w *annotationWindow) update(hwnd uintptr) {
+	dst := annotationWinPoint{x: int32(w.bounds.Min.X), y: int32(w.bounds.Min.Y)}
+	size := annotationWinPoint{x: int32(w.bounds.Dx()), y: int32(w.bounds.Dy())}
+	var src annotationWinPoint
+	blend := annotationBlendFunction{sourceConstantAlpha: 255, alphaFormat: acSrcAlpha}
+	procAnnotationUpdateLayeredWindow.Call(hwnd, 0,
+		uintptr(unsafe.Pointer(&dst)), uintptr(unsafe.Pointer(&size)),
+		w.memDC, uintptr(unsafe.Pointer(&src)), 0,
+		uintptr(unsafe.Pointer(&blend)), ulwAlpha)
+}
+
+func (w *annotationWindow) release() {
+	if w.memDC != 0 {
+		procPrivacySelectObject.Call(w.memDC, w.oldBmp)
+		procAnnotationDeleteDC.Call(w.memDC)
+		w.memDC = 0
+	}
+	if w.bitmap != 0 {
+		procPrivacyDeleteObject.Call(w.bitmap)
+		w.bitmap = 0
+	}
+	w.pix = nil
+}
+
+// newAnnotationWindow allocates the DIB the layer renders into.
+func newAnnotationWindow(layer *annotationLayer, bounds image.Rectangle) (*annotationWindow, error) {
+	memDC, _, _ := procAnnotationCreateCompatibleDC.Call(0)
+	if memDC == 0 {
+		return nil, errors.New("CreateCompatibleDC failed")
+	}
+	bmi := annotationBitmapInfoHeader{
+		biWidth:    int32(bounds.Dx()),
+		biHeight:   -int32(bounds.Dy()), // top-down
+		biPlanes:   1,
+		biBitCount: 32,
+	}
+	bmi.biSize = uint32(unsafe.Sizeof(bmi))
+	var bits unsafe.Pointer
+	bitmap, _, err := procAnnotationCreateDIBSection.Call(memDC, uintptr(unsafe.Pointer(&bmi)), 0,
+		uintptr(unsafe.Pointer(&bits)), 0, 0)
+	if bitmap == 0 || bits == nil {
+		procAnnotationDeleteDC.Call(memDC)
+		return nil, fmt.Errorf("CreateDIBSection: %w", err)
+	}
+	oldBmp, _, _ := procPrivacySelectObject.Call(memDC, bitmap)
+	return &annotationWindow{
+		layer:  layer,
+		bounds: bounds,
+		memDC:  memDC,
+		bitmap: bitmap,
+		oldBmp: oldBmp,
+		pix:    unsafe.Slice((*byte)(bits), bounds.Dx()*bounds.Dy()*4),
+	}, nil
+}
+
+// annotationWindowLoop creates the overlay on the input desktop and pumps
+// its message loop on a dedicated locked OS thread; see privacyWindowLoop.
+func annotationWindowLoop(layer *annotationLayer, bounds image.Rectangle, handoff func(hwnd uintptr, err error) bool) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if hDesk, _, _ := procOpenInputDesktop.Call(0, 0, uintptr(desktopGenericAll)); hDesk != 0 {
+		if ret, _, _ := procSetThreadDesktop.Call(hDesk); ret == 0 {
+			procCloseDesktop.Call(hDesk)
+		} else {
+			defer procCloseDesktop.Call(hDesk)
+		}
+	}
+
+	w, err := newAnnotationWindow(layer, bounds)
+	if err != nil {
+		handoff(0, err)
+		return
+	}
+	registerAnnotationClass()
+	className, _ := syscall.UTF16PtrFromString(annotationClassName)
+	title, _ := syscall.UTF16PtrFromString("Annotations")
+	hInst, _, _ := procPrivacyGetModuleHandleW.Call(0)
+	hwnd, _, err := procPrivacyCreateWindowExW.Call(
+		pwsExTopmost|pwsExToolwindow|pwsExNoactivate|pwsExLayered|pwsExTransparent,
+		uintptr(unsafe.Pointer(className)),
+		uintptr(unsafe.Pointer(title)),
+		pwsPopup,
+		uintptr(bounds.Min.X), uintptr(bounds.Min.Y), uintptr(bounds.Dx()), uintptr(bounds.Dy()),
+		0, 0, hInst, 0,
+	)
+	if hwnd == 0 {
+		w.release()
+		handoff(0, fmt.Errorf("CreateWindowEx: %w", err))
+		return
+	}
+	annotationWindowsMu.Lock()
+	annotationWindows[hwnd] = w
+	annotationWindowsMu.Unlock()
+	if !handoff(hwnd, nil) {
+		procPrivacyDestroyWindow.Call(hwnd)
+		return
+	}
+	// A layered window shows nothing until its first UpdateLayeredWindow;
+	// present the empty (fully transparent) bitmap before showing it.
+	w.update(hwnd)
+	procPrivacyShowWindow.Call(hwnd, pswShowNoactive)
+	procPrivacySetTimer.Call(hwnd, annotationTimerID, annotationTickMs, 0)
+
+	var msg privacyMsg
+	for {
+		ret, _, _ := procPrivacyGetMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+		if ret == 0 || int32(ret) == -1 { // WM_QUIT or error
+			return
+		}
+		procPrivacyTranslateMessage.Call(uintptr(unsafe.Pointer(&msg)))
+		procPrivacyDispatchMessageW.Call(uintptr(unsafe.Pointer(&msg)))
+	}
+}
@@ -1,10 +1,22 @@
 package desktop
 
+import "time"
+
+// Captured audio is delivered as 20ms frames of 48kHz stereo PCM: the Opus
+// native rate, and easy to downsample for the PCMU fallback.
+const (
+	audioSampleRate    = 48000
+	audioChannels      = 2
+	audioFrameSamples  = audioSampleRate / 50 // per channel
+	audioFrameDuration = 20 * time.Millisecond
+)
+
 // AudioCapturer captures system audio for streaming to the viewer.
 type AudioCapturer interface {
-	// Start begins capturing audio. Calls the callback with μ-law encoded
-	// 8kHz mono frames (160 bytes = 20ms at 8000Hz).
-	Start(callback func([]byte)) error
+	// Start begins capturing audio. Calls the callback with 20ms frames of
+	// interleaved 48kHz stereo float32 PCM (audioFrameSamples*audioChannels
+	// samples in [-1, 1]). The slice is only valid during the call.
+	Start(callback func([]float32)) error
 	// Stop stops the audio capture.
 	Stop()
 }
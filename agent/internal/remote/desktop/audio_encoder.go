@@ -0,0 +1,193 @@
+package desktop
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// audioEncoder compresses captured 20ms PCM frames for the audio track.
+type audioEncoder interface {
+	// Encode compresses one frame of interleaved 48kHz stereo PCM.
+	Encode(pcm []float32) ([]byte, error)
+	// SetBitrate sets the target bitrate in bps; a no-op for fixed-rate codecs.
+	SetBitrate(bps int)
+	// Capability is the RTP codec the audio track is created with.
+	Capability() webrtc.RTPCodecCapability
+	Name() string
+	Close()
+}
+
+// newAudioEncoder returns an Opus encoder when libopus can be loaded, and
+// the 8kHz mono PCMU fallback otherwise. Every viewer can decode both.
+func newAudioEncoder() audioEncoder {
+	enc, err := newOpusEncoder()
+	if err == nil {
+		return enc
+	}
+	slog.Info("Opus unavailable, using PCMU for desktop audio", "error", err.Error())
+	return &pcmuEncoder{}
+}
+
+// Opus bitrate bounds. 32 kbps stereo is still clear for speech and system
+// sounds; 128 kbps is transparent for music.
+const (
+	minOpusBitrate = 32_000
+	maxOpusBitrate = 128_000
+)
+
+// audioBitrateForVideo scales the Opus bitrate with the adaptive video
+// bitrate, so audio backs off with the video under congestion while taking
+// a small share (~1/40) of the link: 32 kbps at 1.3 Mbps and below, the
+// 128 kbps cap from ~5 Mbps up.
+func audioBitrateForVideo(videoBps int) int {
+	return clampInt(videoBps/40, minOpusBitrate, maxOpusBitrate)
+}
+
+// pcmuEncoder downmixes to mono, decimates 48kHz to 8kHz and μ-law encodes:
+// 160 bytes per 20ms frame.
+type pcmuEncoder struct{}
+
+const pcmuDecimation = audioSampleRate / 8000
+
+func (pcmuEncoder) Encode(pcm []float32) ([]byte, error) {
+	frames := len(pcm) / audioChannels
+	out := make([]byte, 0, frames/pcmuDecimation)
+	for i := 0; i+pcmuDecimation <= frames; i += pcmuDecimation {
+		var sum float32
+		for j := i; j < i+pcmuDecimation; j++ {
+			sum += pcm[j*audioChannels] + pcm[j*audioChannels+1]
+		}
+		mono := sum / (2 * pcmuDecimation)
+		if mono > 1 {
+			mono = 1
+		} else if mono < -1 {
+			mono = -1
+		}
+		out = append(out, linearToMulaw(int16(mono*32767)))
+	}
+	return out, nil
+}
+
+func (pcmuEncoder) SetBitrate(int) {}
+
+func (pcmuEncoder) Capability() webrtc.RTPCodecCapability {
+	return webrtc.RTPCodecCapability{
+		MimeType:  webrtc.MimeTypePCMU,
+		ClockRate: 8000,
+		Channels:  1,
+	}
+}
+
+func (pcmuEncoder) Name() string { return "pcmu" }
+func (pcmuEncoder) Close()       {}
+
+// linearToMulaw converts a 16-bit signed PCM sample to μ-law encoding.
+func linearToMulaw(sample int16) byte {
+	const bias = 0x84
+	const clip = 32635
+
+	sign := byte(0)
+	if sample < 0 {
+		sign = 0x80
+		sample = -sample
+	}
+	if sample > clip {
+		sample = clip
+	}
+	sample += bias
+
+	exp := 7
+	for mask := int16(0x4000); exp > 0; exp-- {
+		if sample&mask != 0 {
+			break
+		}
+		mask >>= 1
+	}
+	mantissa := (sample >> (uint(exp) + 3)) & 0x0F
+	return ^(sign | byte(exp<<4) | byte(mantissa))
+}
+
+// audioFramer turns captured samples at the device rate into 20ms frames of
+// 48kHz stereo, resampling by linear interpolation when the device mix
+// format runs at another rate (44.1kHz, 96kHz).
+type audioFramer struct {
+	step        float64 // input samples per output sample
+	t           float64 // next output position, relative to prev
+	prevL       float32
+	prevR       float32
+	started     bool
+	frame       []float32
+	emit        func([]float32)
+	passthrough bool
+}
+
+func newAudioFramer(inputRate int, emit func([]float32)) *audioFramer {
+	if inputRate <= 0 {
+		inputRate = audioSampleRate
+	}
+	return &audioFramer{
+		step:        float64(inputRate) / audioSampleRate,
+		frame:       make([]float32, 0, audioFrameSamples*audioChannels),
+		emit:        emit,
+		passthrough: inputRate == audioSampleRate,
+	}
+}
+
+// push adds one input sample pair.
+func (f *audioFramer) push(l, r float32) {
+	if f.passthrough {
+		f.out(l, r)
+		return
+	}
+	if !f.started {
+		f.prevL, f.prevR = l, r
+		f.started = true
+		return
+	}
+	for f.t <= 1 {
+		t := float32(f.t)
+		f.out(f.prevL+(l-f.prevL)*t, f.prevR+(r-f.prevR)*t)
+		f.t += f.step
+	}
+	f.t--
+	f.prevL, f.prevR = l, r
+}
+
+func (f *audioFramer) out(l, r float32) {
+	f.frame = append(f.frame, l, r)
+	if len(f.frame) == cap(f.frame) {
+		f.emit(f.frame)
+		f.frame = f.frame[:0]
+	}
+}
+
+// withOpusStereo adds stereo=1;sprop-stereo=1 to the Opus fmtp lines of an
+// SDP. Browsers decode Opus as mono unless the remote description signals
+// stereo.
+func withOpusStereo(sdp string) string {
+	lines := strings.Split(sdp, "\n")
+	opusPT := make(map[string]bool)
+	for _, line := range lines {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "a=rtpmap:"); ok {
+			pt, enc, found := strings.Cut(rest, " ")
+			if found && strings.HasPrefix(strings.ToLower(enc), "opus/") {
+				opusPT[pt] = true
+			}
+		}
+	}
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, "\r")
+		rest, ok := strings.CutPrefix(trimmed, "a=fmtp:")
+		if !ok {
+			continue
+		}
+		pt, params, _ := strings.Cut(rest, " ")
+		if !opusPT[pt] || strings.Contains(params, "stereo=") {
+			continue
+		}
+		lines[i] = trimmed + ";stereo=1;sprop-stereo=1" + line[len(trimmed):]
+	}
+	return strings.Join(lines, "\n")
+}
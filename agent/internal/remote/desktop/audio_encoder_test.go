@@ -0,0 +1,110 @@
+package desktop
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPCMUEncoderFrameSize(t *testing.T) {
+	pcm := make([]float32, audioFrameSamples*audioChannels)
+	out, err := pcmuEncoder{}.Encode(pcm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 20ms at 8kHz mono.
+	if len(out) != 160 {
+		t.Fatalf("got %d bytes, want 160", len(out))
+	}
+	for i, b := range out {
+		if b != 0xFF {
+			t.Fatalf("byte %d = 0x%02X, want μ-law silence 0xFF", i, b)
+		}
+	}
+}
+
+func TestPCMUEncoderDownmixes(t *testing.T) {
+	// Opposite channels cancel out to silence; equal channels do not.
+	pcm := make([]float32, audioFrameSamples*audioChannels)
+	for i := 0; i < len(pcm); i += 2 {
+		pcm[i], pcm[i+1] = 0.5, -0.5
+	}
+	out, _ := pcmuEncoder{}.Encode(pcm)
+	if out[0] != 0xFF {
+		t.Fatalf("cancelled channels encoded as 0x%02X, want 0xFF", out[0])
+	}
+	for i := 0; i < len(pcm); i += 2 {
+		pcm[i], pcm[i+1] = 0.5, 0.5
+	}
+	out, _ = pcmuEncoder{}.Encode(pcm)
+	if want := linearToMulaw(16383); out[0] != want {
+		t.Fatalf("mono sample 0x%02X, want 0x%02X", out[0], want)
+	}
+}
+
+func TestAudioBitrateForVideo(t *testing.T) {
+	tests := []struct{ video, want int }{
+		{500_000, minOpusBitrate},
+		{2_500_000, 62_500},
+		{8_000_000, maxOpusBitrate},
+	}
+	for _, tt := range tests {
+		if got := audioBitrateForVideo(tt.video); got != tt.want {
+			t.Errorf("audioBitrateForVideo(%d) = %d, want %d", tt.video, got, tt.want)
+		}
+	}
+}
+
+func TestAudioFramer(t *testing.T) {
+	tests := []struct {
+		name      string
+		inputRate int
+	}{
+		{"48kHz passthrough", 48000},
+		{"44.1kHz upsampled", 44100},
+		{"96kHz downsampled", 96000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var frames int
+			f := newAudioFramer(tt.inputRate, func(frame []float32) {
+				if len(frame) != audioFrameSamples*audioChannels {
+					t.Fatalf("frame has %d samples", len(frame))
+				}
+				for _, s := range frame {
+					if s != 0.25 {
+						t.Fatalf("sample %v, want 0.25", s)
+					}
+				}
+				frames++
+			})
+			// One second of a constant signal is 50 frames at any rate
+			// (give or take the interpolation edge).
+			for i := 0; i < tt.inputRate; i++ {
+				f.push(0.25, 0.25)
+			}
+			if frames < 49 || frames > 50 {
+				t.Fatalf("got %d frames for 1s of input, want 50", frames)
+			}
+		})
+	}
+}
+
+func TestWithOpusStereo(t *testing.T) {
+	sdp := "v=0\r\n" +
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111 0\r\n" +
+		"a=rtpmap:111 opus/48000/2\r\n" +
+		"a=fmtp:111 minptime=10;useinbandfec=1\r\n" +
+		"a=rtpmap:0 PCMU/8000\r\n" +
+		"m=video 9 UDP/TLS/RTP/SAVPF 96\r\n" +
+		"a=fmtp:96 profile-id=0\r\n"
+	got := withOpusStereo(sdp)
+	if !strings.Contains(got, "a=fmtp:111 minptime=10;useinbandfec=1;stereo=1;sprop-stereo=1\r\n") {
+		t.Fatalf("opus fmtp not updated:\n%s", got)
+	}
+	if !strings.Contains(got, "a=fmtp:96 profile-id=0\r\n") {
+		t.Fatalf("non-opus fmtp changed:\n%s", got)
+	}
+	if again := withOpusStereo(got); again != got {
+		t.Fatalf("not idempotent:\n%s", again)
+	}
+}
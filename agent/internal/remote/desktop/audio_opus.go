@@ -0,0 +1,153 @@
+//go:build linux || darwin || windows
+
+package desktop
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// Opus through libopus, loaded at runtime like libvpx/libaom. Without it
+// the audio track stays on PCMU.
+
+var libopusNames = []string{"libopus.so.0", "libopus.0.dylib", "libopus.dylib", "opus.dll", "libopus-0.dll"}
+
+const (
+	opusOK               = 0
+	opusApplicationAudio = 2049
+
+	opusSetBitrateRequest   = 4002
+	opusSetInbandFECRequest = 4012
+	opusSetPacketLossPerc   = 4014
+
+	// maxOpusPacket is the recommended output buffer for one packet.
+	maxOpusPacket = 4000
+)
+
+type opusAPI struct {
+	path        string
+	create      func(fs int32, channels int32, application int32, errOut *int32) uintptr
+	encodeFloat func(st uintptr, pcm *float32, frameSize int32, data *byte, maxBytes int32) int32
+	ctl         func(st uintptr, request int32, value int32) int32
+	destroy     func(st uintptr)
+	strerror    func(code int32) string
+}
+
+var (
+	opusLoadOnce sync.Once
+	opusLib      *opusAPI
+	opusLoadErr  error
+)
+
+func loadLibopus() (*opusAPI, error) {
+	opusLoadOnce.Do(func() {
+		lib, path, err := openCodecLibrary(libopusNames)
+		if err != nil {
+			opusLoadErr = fmt.Errorf("libopus: %w", err)
+			return
+		}
+		api := &opusAPI{path: path}
+		bindings := []struct {
+			fptr any
+			name string
+		}{
+			{&api.create, "opus_encoder_create"},
+			{&api.encodeFloat, "opus_encode_float"},
+			{&api.ctl, "opus_encoder_ctl"},
+			{&api.destroy, "opus_encoder_destroy"},
+			{&api.strerror, "opus_strerror"},
+		}
+		for _, b := range bindings {
+			if err := registerCodecLibFunc(b.fptr, lib, b.name); err != nil {
+				opusLoadErr = fmt.Errorf("libopus %s: %w", path, err)
+				return
+			}
+		}
+		opusLib = api
+	})
+	return opusLib, opusLoadErr
+}
+
+type opusEncoder struct {
+	mu      sync.Mutex
+	api     *opusAPI
+	st      uintptr
+	bitrate int
+	out     [maxOpusPacket]byte
+}
+
+func newOpusEncoder() (audioEncoder, error) {
+	api, err := loadLibopus()
+	if err != nil {
+		return nil, err
+	}
+	var code int32
+	st := api.create(audioSampleRate, audioChannels, opusApplicationAudio, &code)
+	if st == 0 || code != opusOK {
+		return nil, fmt.Errorf("opus_encoder_create: %s", api.strerror(code))
+	}
+	e := &opusEncoder{api: api, st: st}
+	// opus_encoder_ctl is variadic; see codecLibVariadicSafe. Without it
+	// the encoder keeps libopus's automatic bitrate.
+	if codecLibVariadicSafe() {
+		api.ctl(st, opusSetInbandFECRequest, 1)
+		api.ctl(st, opusSetPacketLossPerc, 5)
+	}
+	e.SetBitrate(audioBitrateForVideo(2_500_000))
+	slog.Info("Opus audio encoder initialized", "library", api.path, "bitrate", e.bitrate)
+	return e, nil
+}
+
+func (e *opusEncoder) Encode(pcm []float32) ([]byte, error) {
+	if len(pcm) != audioFrameSamples*audioChannels {
+		return nil, fmt.Errorf("opus: frame has %d samples, want %d", len(pcm), audioFrameSamples*audioChannels)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.st == 0 {
+		return nil, errors.New("opus encoder closed")
+	}
+	n := e.api.encodeFloat(e.st, &pcm[0], audioFrameSamples, &e.out[0], int32(len(e.out)))
+	if n < 0 {
+		return nil, fmt.Errorf("opus_encode_float: %s", e.api.strerror(n))
+	}
+	return append([]byte(nil), e.out[:n]...), nil
+}
+
+func (e *opusEncoder) SetBitrate(bps int) {
+	bps = clampInt(bps, minOpusBitrate, maxOpusBitrate)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.st == 0 || bps == e.bitrate || !codecLibVariadicSafe() {
+		return
+	}
+	if res := e.api.ctl(e.st, opusSetBitrateRequest, int32(bps)); res != opusOK {
+		slog.Warn("Opus bitrate change rejected", "bitrate", bps, "error", e.api.strerror(res))
+		return
+	}
+	e.bitrate = bps
+}
+
+func (e *opusEncoder) Capability() webrtc.RTPCodecCapability {
+	return webrtc.RTPCodecCapability{
+		MimeType:    webrtc.MimeTypeOpus,
+		ClockRate:   audioSampleRate,
+		Channels:    audioChannels,
+		SDPFmtpLine: "minptime=10;useinbandfec=1",
+	}
+}
+
+func (e *opusEncoder) Name() string { return "opus" }
+
+func (e *opusEncoder) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.st != 0 {
+		e.api.destroy(e.st)
+		e.st = 0
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+
+package desktop
+
+import "errors"
+
+func newOpusEncoder() (audioEncoder, error) {
+	return nil, errors.New("opus is not supported on this platform")
+}
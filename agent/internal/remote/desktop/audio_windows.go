@@ -74,7 +74,7 @@ func NewAudioCapturer() AudioCapturer {
 	return &wasapiCapturer{done: make(chan struct{})}
 }
 
-func (w *wasapiCapturer) Start(callback func([]byte)) error {
+func (w *wasapiCapturer) Start(callback func([]float32)) error {
 	w.mu.Lock()
 	if w.started {
 		w.mu.Unlock()
@@ -207,18 +207,9 @@ func (w *wasapiCapturer) Start(callback func([]byte)) error {
 	return nil
 }
 
-func (w *wasapiCapturer) captureLoop(callback func([]byte), channels, sampleRate, bitsPerSample int, isFloat bool) {
-	// We'll downsample to 8kHz mono μ-law (PCMU).
-	// Accumulate raw samples, then downsample + encode in 20ms chunks (160 samples at 8kHz).
-	const targetRate = 8000
-	const frameSize = 160 // 20ms at 8kHz
-
-	// Resampling: simple decimation with averaging
-	ratio := float64(sampleRate) / float64(targetRate)
-	var accum float64
-	var accumCount int
-	var outBuf [frameSize]byte
-	outIdx := 0
+func (w *wasapiCapturer) captureLoop(callback func([]float32), channels, sampleRate, bitsPerSample int, isFloat bool) {
+	// Convert the mix format to 48kHz stereo and hand it on in 20ms frames.
+	framer := newAudioFramer(sampleRate, callback)
 
 	ticker := time.NewTicker(10 * time.Millisecond)
 	defer ticker.Stop()
@@ -264,62 +255,27 @@ func (w *wasapiCapturer) captureLoop(callback func([]byte), channels, sampleRate
 
 			if !silent && dataPtr != 0 {
 				raw := unsafe.Slice((*byte)(unsafe.Pointer(dataPtr)), totalBytes)
-				for i := 0; i < int(numFrames); i++ {
-					// Mix down to mono: average all channels
-					var mono float64
-					for ch := 0; ch < channels; ch++ {
-						offset := i*bytesPerFrame + ch*bytesPerSample
-						if isFloat && bytesPerSample == 4 {
-							mono += float64(math.Float32frombits(binary.LittleEndian.Uint32(raw[offset:])))
-						} else if bytesPerSample == 2 {
-							s16 := int16(binary.LittleEndian.Uint16(raw[offset:]))
-							mono += float64(s16) / 32768.0
-						}
+				sample := func(i, ch int) float32 {
+					offset := i*bytesPerFrame + ch*bytesPerSample
+					if isFloat && bytesPerSample == 4 {
+						return math.Float32frombits(binary.LittleEndian.Uint32(raw[offset:]))
+					} else if bytesPerSample == 2 {
+						return float32(int16(binary.LittleEndian.Uint16(raw[offset:]))) / 32768.0
 					}
-					mono /= float64(channels)
-
-					// Accumulate for downsampling
-					accum += mono
-					accumCount++
-
-					if float64(accumCount) >= ratio {
-						avg := accum / float64(accumCount)
-						// Clamp to [-1, 1]
-						if avg > 1.0 {
-							avg = 1.0
-						} else if avg < -1.0 {
-							avg = -1.0
-						}
-						// Convert to 16-bit PCM then to μ-law
-						pcm16 := int16(avg * 32767.0)
-						outBuf[outIdx] = linearToMulaw(pcm16)
-						outIdx++
-						accum = 0
-						accumCount = 0
-
-						if outIdx >= frameSize {
-							frame := make([]byte, frameSize)
-							copy(frame, outBuf[:])
-							callback(frame)
-							outIdx = 0
-						}
+					return 0
+				}
+				for i := 0; i < int(numFrames); i++ {
+					// Front left/right; a mono mix is duplicated to both.
+					l := sample(i, 0)
+					r := l
+					if channels > 1 {
+						r = sample(i, 1)
 					}
+					framer.push(l, r)
 				}
 			} else if silent {
-				// Silent: generate silence frames
 				for i := 0; i < int(numFrames); i++ {
-					accumCount++
-					if float64(accumCount) >= ratio {
-						outBuf[outIdx] = 0xFF // μ-law silence
-						outIdx++
-						accumCount = 0
-						if outIdx >= frameSize {
-							frame := make([]byte, frameSize)
-							copy(frame, outBuf[:])
-							callback(frame)
-							outIdx = 0
-						}
-					}
+					framer.push(0, 0)
 				}
 			}
 
@@ -364,29 +320,3 @@ func (w *wasapiCapturer) Stop() {
 }
 
 var procCoCreateInstance = ole32DLL.NewProc("CoCreateInstance")
-
-// linearToMulaw converts a 16-bit signed PCM sample to μ-law encoding.
-func linearToMulaw(sample int16) byte {
-	const bias = 0x84
-	const clip = 32635
-
-	sign := byte(0)
-	if sample < 0 {
-		sign = 0x80
-		sample = -sample
-	}
-	if sample > clip {
-		sample = clip
-	}
-	sample += bias
-
-	exp := 7
-	for mask := int16(0x4000); exp > 0; exp-- {
-		if sample&mask != 0 {
-			break
-		}
-		mask >>= 1
-	}
-	mantissa := (sample >> (uint(exp) + 3)) & 0x0F
-	return ^(sign | byte(exp<<4) | byte(mantissa))
-}
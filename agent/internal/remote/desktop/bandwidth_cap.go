@@ -0,0 +1,97 @@
+package desktop
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// Per-session bandwidth ceiling (SessionPolicy.MaxBitrate). The server sets
+// it for sites on metered or thin links; the viewer cannot raise it. Video is
+// held under the cap by the adaptive controller's hard ceiling, which outlives
+// the resolution-derived ceilings and slider requests that reset its max.
+// Unmuted audio is budgeted out of the same cap, so toggling audio moves the
+// video ceiling, and under a low cap audio stays muted altogether.
+
+const (
+	// MinSessionMaxBitrateKbps and MaxSessionMaxBitrateKbps bound the cap the
+	// server may set. Below the minimum no usable video fits.
+	MinSessionMaxBitrateKbps = 250
+	MaxSessionMaxBitrateKbps = 1_000_000
+
+	// audioBandwidthReserve is unmuted audio's share of the cap: the top
+	// Opus rate, which also covers 64 kbps PCMU.
+	audioBandwidthReserve = maxOpusBitrate
+
+	// minBandwidthCapForAudio is the smallest cap that still allows audio.
+	minBandwidthCapForAudio = 1_000_000
+)
+
+// bandwidthCap is a session's bandwidth policy.
+type bandwidthCap struct {
+	maxBitrate    int // bits/s; 0 = uncapped
+	audioDisabled bool
+}
+
+func newBandwidthCap(p SessionPolicy) bandwidthCap {
+	return bandwidthCap{maxBitrate: max(p.MaxBitrate, 0), audioDisabled: p.AudioDisabled}
+}
+
+// audioAllowed reports whether the viewer may unmute audio.
+func (b bandwidthCap) audioAllowed() bool {
+	if b.audioDisabled {
+		return false
+	}
+	return b.maxBitrate == 0 || b.maxBitrate >= minBandwidthCapForAudio
+}
+
+// videoCap is the video bitrate ceiling with audio on or off; 0 = uncapped.
+func (b bandwidthCap) videoCap(audioOn bool) int {
+	if b.maxBitrate == 0 {
+		return 0
+	}
+	if audioOn {
+		return b.maxBitrate - audioBandwidthReserve
+	}
+	return b.maxBitrate
+}
+
+// clamp limits a video bitrate to the cap.
+func (b bandwidthCap) clamp(bps int, audioOn bool) int {
+	if c := b.videoCap(audioOn); c > 0 && bps > c {
+		return c
+	}
+	return bps
+}
+
+// applyBandwidthCap pushes the current video ceiling to the adaptive
+// controller. Called at start and whenever audio is toggled.
+func (s *Session) applyBandwidthCap() {
+	c := s.bandwidth.videoCap(s.audioEnabled.Load())
+	if c == 0 || s.adaptive == nil {
+		return
+	}
+	s.adaptive.SetBitrateCap(c)
+	slog.Debug("Applied session bandwidth cap", "session", s.id, "videoCap", c, "audio", s.audioEnabled.Load())
+}
+
+// refuseAudioForBandwidth answers a toggle_audio the bandwidth policy does
+// not allow, so the viewer can reset its audio control.
+func (s *Session) refuseAudioForBandwidth() {
+	slog.Info("Refused audio under session bandwidth policy", "session", s.id, "maxBitrate", s.bandwidth.maxBitrate)
+	resp, err := json.Marshal(map[string]any{
+		"type":  "toggle_audio_result",
+		"ok":    false,
+		"error": "audio is disabled by the session's bandwidth policy",
+	})
+	if err != nil {
+		return
+	}
+	s.mu.RLock()
+	dc := s.controlDC
+	s.mu.RUnlock()
+	if dc != nil {
+		if err := dc.SendText(string(resp)); err != nil {
+			slog.Debug("Failed to send audio refusal", "session", s.id, "error", err.Error())
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package desktop
+
+import "testing"
+
+func TestAdaptive_BitrateCapSurvivesSetMaxBitrate(t *testing.T) {
+	a, stub := newTestAdaptive(2_500_000, 500_000, 8_000_000)
+
+	a.SetBitrateCap(2_000_000)
+	if a.maxBitrate != 2_000_000 || stub.bitrate != 2_000_000 {
+		t.Fatalf("cap not applied: max=%d encoder=%d", a.maxBitrate, stub.bitrate)
+	}
+	// Resolution ceilings and the viewer slider must not lift the cap.
+	a.SetMaxBitrate(20_000_000)
+	if a.maxBitrate != 2_000_000 {
+		t.Fatalf("SetMaxBitrate lifted the cap: max=%d", a.maxBitrate)
+	}
+	// A cap below the floor lowers the floor with it.
+	a.SetBitrateCap(300_000)
+	if a.minBitrate != 300_000 || a.maxBitrate != 300_000 {
+		t.Fatalf("bounds = [%d, %d], want [300000, 300000]", a.minBitrate, a.maxBitrate)
+	}
+	// Raising the cap restores the requested ceiling and floor.
+	a.SetBitrateCap(0)
+	if a.maxBitrate != 20_000_000 || a.minBitrate != 500_000 {
+		t.Fatalf("bounds = [%d, %d], want [500000, 20000000]", a.minBitrate, a.maxBitrate)
+	}
+}
+
+func TestBandwidthCap(t *testing.T) {
+	uncapped := newBandwidthCap(SessionPolicy{})
+	if !uncapped.audioAllowed() || uncapped.videoCap(true) != 0 || uncapped.clamp(9_000_000, true) != 9_000_000 {
+		t.Fatal("a session without a cap must not be limited")
+	}
+
+	metered := newBandwidthCap(SessionPolicy{MaxBitrate: 3_000_000})
+	if !metered.audioAllowed() {
+		t.Fatal("a 3 Mbps cap leaves room for audio")
+	}
+	if got := metered.clamp(8_000_000, false); got != 3_000_000 {
+		t.Fatalf("clamp without audio = %d, want 3000000", got)
+	}
+	if got := metered.clamp(8_000_000, true); got != 3_000_000-audioBandwidthReserve {
+		t.Fatalf("clamp with audio = %d, want the cap minus the audio reserve", got)
+	}
+	if got := metered.clamp(1_000_000, true); got != 1_000_000 {
+		t.Fatalf("clamp below the cap = %d, want it unchanged", got)
+	}
+
+	if newBandwidthCap(SessionPolicy{MaxBitrate: 500_000}).audioAllowed() {
+		t.Fatal("a cap below minBandwidthCapForAudio must keep audio muted")
+	}
+	if newBandwidthCap(SessionPolicy{MaxBitrate: 3_000_000, AudioDisabled: true}).audioAllowed() {
+		t.Fatal("AudioDisabled must keep audio muted")
+	}
+}
+
+func TestToggleAudioRefusedUnderBandwidthPolicy(t *testing.T) {
+	s := &Session{id: "metered", bandwidth: newBandwidthCap(SessionPolicy{MaxBitrate: 500_000})}
+	s.handleControlMessage([]byte(`{"type":"toggle_audio","value":1}`))
+	if s.audioEnabled.Load() {
+		t.Fatal("toggle_audio must be refused under a cap too low for audio")
+	}
+
+	a, _ := newTestAdaptive(2_500_000, 500_000, 8_000_000)
+	s = &Session{id: "capped", adaptive: a, bandwidth: newBandwidthCap(SessionPolicy{MaxBitrate: 3_000_000})}
+	s.applyBandwidthCap()
+	s.handleControlMessage([]byte(`{"type":"toggle_audio","value":1}`))
+	if !s.audioEnabled.Load() {
+		t.Fatal("toggle_audio must be honored when the cap allows audio")
+	}
+	if a.maxBitrate != 3_000_000-audioBandwidthReserve {
+		t.Fatalf("video ceiling with audio = %d, want the cap minus the audio reserve", a.maxBitrate)
+	}
+	s.handleControlMessage([]byte(`{"type":"toggle_audio","value":0}`))
+	if a.maxBitrate != 3_000_000 {
+		t.Fatalf("video ceiling after muting = %d, want the full cap", a.maxBitrate)
+	}
+}
@@ -111,10 +111,14 @@ type TextureProvider interface {
 	GetD3D11Context() uintptr
 }
 
-// NOTE: a DirtyRectProvider interface (DirtyRects() []image.Rectangle) used to
-// live here, but nothing ever consumed it and the DXGI capturer paid a per-frame
-// COM call + allocations to populate it. Removed until region-based encoding
-// actually lands; the fetch helper survives in dxgi_dirty_rects_windows.go.
+// DirtyRectProvider is implemented by capturers that know which regions of the
+// last frame returned by Capture() changed since the previous one (e.g. DXGI
+// dirty and move rects). DirtyRects returns nil when the damage is unknown —
+// the whole frame must then be treated as changed — and an empty non-nil slice
+// when nothing changed. The result feeds VideoEncoder.EncodeDamaged.
+type DirtyRectProvider interface {
+	DirtyRects() []image.Rectangle
+}
 
 // CursorProvider is implemented by capturers that can report the system cursor
 // position for real-time cursor streaming to the viewer. This enables the viewer
@@ -25,10 +25,15 @@ type linuxCapturer struct {
 	cursorShape atomic.Value // string (CSS cursor)
 }
 
-// newPlatformCapturer creates a new Linux screen capturer. It resolves the
-// display target itself so standalone tool/probe paths (which never call
-// through a session that has already resolved a target) work unmodified.
+// newPlatformCapturer creates a new Linux screen capturer. Inside a Wayland
+// session (the user helper) it uses the xdg-desktop-portal backend. Otherwise
+// it resolves the X11 display target itself so standalone tool/probe paths
+// (which never call through a session that has already resolved a target)
+// work unmodified.
 func newPlatformCapturer(config CaptureConfig) (ScreenCapturer, error) {
+	if waylandPortalSession() {
+		return newPortalCapturer(config)
+	}
 	target, err := x11.SelectX11Target()
 	if err != nil {
 		return nil, mapResolveErr(err)
@@ -0,0 +1,465 @@
+//go:build linux
+
+package desktop
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Wayland capture through xdg-desktop-portal's ScreenCast interface. The
+// portal only answers on the user's own session bus, so this runs in the user
+// helper (see newPlatformCapturer), never in the root agent. Starting a
+// session shows the compositor's share-screen dialog in the user's session —
+// that dialog is the consent step. The restore token the portal hands back is
+// persisted so later sessions start without prompting again.
+//
+// Frames come from the PipeWire stream the portal opens, decoded by a
+// gst-launch-1.0 pipewiresrc pipeline into raw BGRx on stdout. This keeps the
+// agent CGO-free like the X11 backend. Input stays on the X11 injector, which
+// only reaches Xwayland clients.
+
+const (
+	portalBusName    = "org.freedesktop.portal.Desktop"
+	portalObjectPath = dbus.ObjectPath("/org/freedesktop/portal/desktop")
+	portalScreenCast = "org.freedesktop.portal.ScreenCast"
+	portalRequest    = "org.freedesktop.portal.Request"
+	portalSession    = "org.freedesktop.portal.Session"
+
+	portalSourceMonitor       = 1
+	portalCursorEmbedded      = 2
+	portalPersistUntilRevoked = 2
+
+	// portalConsentTimeout bounds how long Start waits for the user to answer
+	// the share-screen dialog.
+	portalConsentTimeout = 2 * time.Minute
+	portalCallTimeout    = 15 * time.Second
+	portalFirstFrameWait = 5 * time.Second
+
+	restoreTokenFile = "screencast-restore-token"
+)
+
+// errPortalDenied is returned when the user dismisses the share-screen dialog.
+var errPortalDenied = errors.New("screen sharing was not allowed by the user")
+
+// waylandPortalSession reports whether this process runs inside a Wayland
+// session with a session bus, i.e. it is the user helper (or an interactive
+// agent) and can reach xdg-desktop-portal.
+func waylandPortalSession() bool {
+	return os.Getenv("WAYLAND_DISPLAY") != "" && os.Getenv("DBUS_SESSION_BUS_ADDRESS") != ""
+}
+
+// portalStream is one entry of the Start response's "streams" result.
+type portalStream struct {
+	NodeID uint32
+	Width  int
+	Height int
+}
+
+// portalCapturer streams the monitor the user picked in the portal dialog.
+// config.DisplayIndex is not honored: the portal lets the user choose.
+type portalCapturer struct {
+	config CaptureConfig
+
+	conn    *dbus.Conn
+	session dbus.ObjectPath
+	cmd     *exec.Cmd
+	stdout  io.ReadCloser
+	width   int
+	height  int
+
+	mu       sync.Mutex
+	frame    []byte // latest complete BGRx frame
+	readErr  error
+	firstCh  chan struct{}
+	closed   atomic.Bool
+	doneOnce sync.Once
+}
+
+func newPortalCapturer(config CaptureConfig) (ScreenCapturer, error) {
+	gst, err := exec.LookPath("gst-launch-1.0")
+	if err != nil {
+		return nil, fmt.Errorf("%w: wayland capture needs gst-launch-1.0 with the pipewire plugin", ErrNotSupported)
+	}
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("%w: session bus: %v", ErrNotSupported, err)
+	}
+	c := &portalCapturer{config: config, conn: conn, firstCh: make(chan struct{})}
+	stream, fd, err := c.startScreenCast()
+	if err != nil {
+		c.closeSession()
+		return nil, err
+	}
+	c.width, c.height = stream.Width, stream.Height
+	if err := c.startPipeline(gst, stream, fd); err != nil {
+		c.closeSession()
+		return nil, err
+	}
+	slog.Info("Wayland portal capture started",
+		"node", stream.NodeID, "width", c.width, "height", c.height)
+	return c, nil
+}
+
+// startScreenCast runs CreateSession → SelectSources → Start and opens the
+// PipeWire remote for the selected stream.
+func (c *portalCapturer) startScreenCast() (portalStream, *os.File, error) {
+	portal := c.conn.Object(portalBusName, portalObjectPath)
+
+	results, err := c.portalRequest(portal, portalScreenCast+".CreateSession", portalCallTimeout,
+		map[string]dbus.Variant{"session_handle_token": dbus.MakeVariant(portalToken())})
+	if err != nil {
+		return portalStream{}, nil, fmt.Errorf("portal CreateSession: %w", err)
+	}
+	handle, _ := results["session_handle"].Value().(string)
+	if handle == "" {
+		return portalStream{}, nil, errors.New("portal CreateSession: no session handle")
+	}
+	c.session = dbus.ObjectPath(handle)
+
+	opts := map[string]dbus.Variant{
+		"types":    dbus.MakeVariant(uint32(portalSourceMonitor)),
+		"multiple": dbus.MakeVariant(false),
+	}
+	if modes, err := portal.GetProperty(portalScreenCast + ".AvailableCursorModes"); err == nil {
+		if m, ok := modes.Value().(uint32); ok && m&portalCursorEmbedded != 0 {
+			opts["cursor_mode"] = dbus.MakeVariant(uint32(portalCursorEmbedded))
+		}
+	}
+	// persist_mode and restore_token arrived in ScreenCast version 4.
+	if v, err := portal.GetProperty(portalScreenCast + ".version"); err == nil {
+		if version, ok := v.Value().(uint32); ok && version >= 4 {
+			opts["persist_mode"] = dbus.MakeVariant(uint32(portalPersistUntilRevoked))
+			if token := loadRestoreToken(); token != "" {
+				opts["restore_token"] = dbus.MakeVariant(token)
+			}
+		}
+	}
+	if _, err := c.portalRequest(portal, portalScreenCast+".SelectSources", portalCallTimeout, opts, c.session); err != nil {
+		return portalStream{}, nil, fmt.Errorf("portal SelectSources: %w", err)
+	}
+
+	results, err = c.portalRequest(portal, portalScreenCast+".Start", portalConsentTimeout,
+		map[string]dbus.Variant{}, c.session, "")
+	if err != nil {
+		return portalStream{}, nil, fmt.Errorf("portal Start: %w", err)
+	}
+	if token, ok := results["restore_token"].Value().(string); ok && token != "" {
+		saveRestoreToken(token)
+	}
+	streams, err := parsePortalStreams(results["streams"])
+	if err != nil {
+		return portalStream{}, nil, fmt.Errorf("portal Start: %w", err)
+	}
+	stream := streams[0]
+	if stream.Width <= 0 || stream.Height <= 0 {
+		return portalStream{}, nil, errors.New("portal Start: stream has no size")
+	}
+
+	var fd dbus.UnixFD
+	if err := portal.Call(portalScreenCast+".OpenPipeWireRemote", 0, c.session, map[string]dbus.Variant{}).Store(&fd); err != nil {
+		return portalStream{}, nil, fmt.Errorf("portal OpenPipeWireRemote: %w", err)
+	}
+	return stream, os.NewFile(uintptr(fd), "pipewire-remote"), nil
+}
+
+// portalRequest calls a portal method that answers through a Request object's
+// Response signal, and returns the response results. options gets the
+// handle_token that fixes the request path; args precede it in the call.
+func (c *portalCapturer) portalRequest(portal dbus.BusObject, method string, timeout time.Duration, options map[string]dbus.Variant, args ...any) (map[string]dbus.Variant, error) {
+	token := portalToken()
+	options["handle_token"] = dbus.MakeVariant(token)
+	path := portalRequestPath(c.conn.Names()[0], token)
+
+	// Subscribe before calling so a fast Response is not missed.
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface(portalRequest),
+		dbus.WithMatchMember("Response"),
+	}
+	if err := c.conn.AddMatchSignal(match...); err != nil {
+		return nil, err
+	}
+	defer func() { _ = c.conn.RemoveMatchSignal(match...) }()
+	signals := make(chan *dbus.Signal, 4)
+	c.conn.Signal(signals)
+	defer c.conn.RemoveSignal(signals)
+
+	if err := portal.Call(method, 0, append(args, options)...).Err; err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case sig := <-signals:
+			if sig.Path != path || sig.Name != portalRequest+".Response" {
+				continue
+			}
+			return parsePortalResponse(sig.Body)
+		case <-timer.C:
+			return nil, fmt.Errorf("no response within %s", timeout)
+		}
+	}
+}
+
+// startPipeline launches pipewiresrc on the portal's PipeWire fd (passed as
+// fd 3) and starts reading frames.
+func (c *portalCapturer) startPipeline(gst string, stream portalStream, remote *os.File) error {
+	defer remote.Close() // the child holds its own copy
+	cmd := exec.Command(gst, pipewireFramePipeline(stream.NodeID, stream.Width, stream.Height)...)
+	cmd.ExtraFiles = []*os.File{remote}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start pipewire pipeline: %w", err)
+	}
+	c.cmd, c.stdout = cmd, stdout
+	go c.readFrames()
+
+	select {
+	case <-c.firstCh:
+	case <-time.After(portalFirstFrameWait):
+		c.stopPipeline()
+		return errors.New("pipewire pipeline produced no frames")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.frame == nil {
+		return fmt.Errorf("pipewire pipeline: %w", c.readErr)
+	}
+	return nil
+}
+
+// pipewireFramePipeline is the gst-launch-1.0 argv that decodes a PipeWire
+// node into fixed-size BGRx frames on stdout. videoscale keeps the frame size
+// constant if the monitor mode changes mid-session.
+func pipewireFramePipeline(nodeID uint32, width, height int) []string {
+	return []string{
+		"-q",
+		"pipewiresrc", "fd=3", "path=" + strconv.FormatUint(uint64(nodeID), 10), "do-timestamp=true",
+		"!", "videoconvert",
+		"!", "videoscale",
+		"!", fmt.Sprintf("video/x-raw,format=BGRx,width=%d,height=%d", width, height),
+		"!", "fdsink", "fd=1", "sync=false",
+	}
+}
+
+func (c *portalCapturer) readFrames() {
+	defer c.doneOnce.Do(func() { close(c.firstCh) })
+	size := c.width * c.height * 4
+	buf := make([]byte, size)
+	for {
+		if _, err := io.ReadFull(c.stdout, buf); err != nil {
+			c.mu.Lock()
+			if c.readErr == nil {
+				c.readErr = fmt.Errorf("pipewire stream ended: %w", err)
+			}
+			c.mu.Unlock()
+			if !c.closed.Load() {
+				slog.Warn("Wayland portal capture stream ended", "error", err.Error())
+			}
+			return
+		}
+		c.mu.Lock()
+		// Swap buffers: Capture copies out of c.frame under the lock, so the
+		// previous frame can be reused for the next read.
+		prev := c.frame
+		c.frame = buf
+		c.mu.Unlock()
+		if prev == nil {
+			prev = make([]byte, size)
+			c.doneOnce.Do(func() { close(c.firstCh) })
+		}
+		buf = prev
+	}
+}
+
+// Capture returns the latest frame as image.RGBA whose Pix is BGRX (see IsBGRA).
+func (c *portalCapturer) Capture() (*image.RGBA, error) {
+	return c.CaptureRegion(0, 0, c.width, c.height)
+}
+
+// CaptureRegion crops the latest frame.
+func (c *portalCapturer) CaptureRegion(x, y, width, height int) (*image.RGBA, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed.Load() {
+		return nil, ErrNoActiveSession
+	}
+	if c.readErr != nil {
+		return nil, c.readErr
+	}
+	r := image.Rect(x, y, x+width, y+height).Intersect(image.Rect(0, 0, c.width, c.height))
+	if r.Empty() {
+		return nil, fmt.Errorf("region %dx%d+%d+%d outside %dx%d screen", width, height, x, y, c.width, c.height)
+	}
+	img := &image.RGBA{
+		Pix:    make([]byte, r.Dx()*r.Dy()*4),
+		Stride: r.Dx() * 4,
+		Rect:   image.Rect(0, 0, r.Dx(), r.Dy()),
+	}
+	srcStride := c.width * 4
+	for row := 0; row < r.Dy(); row++ {
+		src := (r.Min.Y+row)*srcStride + r.Min.X*4
+		copy(img.Pix[row*img.Stride:(row+1)*img.Stride], c.frame[src:src+img.Stride])
+	}
+	return img, nil
+}
+
+// GetScreenBounds returns the stream dimensions.
+func (c *portalCapturer) GetScreenBounds() (int, int, error) {
+	if c.closed.Load() {
+		return 0, 0, ErrNoActiveSession
+	}
+	return c.width, c.height, nil
+}
+
+// Close stops the pipeline and ends the portal session, which removes the
+// compositor's screen-sharing indicator.
+func (c *portalCapturer) Close() error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	c.stopPipeline()
+	c.closeSession()
+	return nil
+}
+
+func (c *portalCapturer) stopPipeline() {
+	if c.cmd == nil || c.cmd.Process == nil {
+		return
+	}
+	_ = c.cmd.Process.Kill()
+	_ = c.cmd.Wait()
+}
+
+func (c *portalCapturer) closeSession() {
+	if c.session != "" {
+		_ = c.conn.Object(portalBusName, c.session).Call(portalSession+".Close", 0).Err
+		c.session = ""
+	}
+	_ = c.conn.Close()
+}
+
+// IsBGRA reports that Capture()'s Pix holds BGRX, as the pipeline requests.
+func (c *portalCapturer) IsBGRA() bool { return true }
+
+// portalRequestPath is the Request object path a portal call with the given
+// handle_token will use: the sender's unique name without the leading ':'
+// and with '.' replaced by '_'.
+func portalRequestPath(uniqueName, token string) dbus.ObjectPath {
+	sender := strings.ReplaceAll(strings.TrimPrefix(uniqueName, ":"), ".", "_")
+	return dbus.ObjectPath("/org/freedesktop/portal/desktop/request/" + sender + "/" + token)
+}
+
+var portalTokenSeq atomic.Uint64
+
+// portalToken returns a handle token unique within this process.
+func portalToken() string {
+	return fmt.Sprintf("breeze%d_%d", os.Getpid(), portalTokenSeq.Add(1))
+}
+
+// parsePortalResponse decodes a Request.Response signal body (u response,
+// a{sv} results). Response 1 means the user cancelled the dialog.
+func parsePortalResponse(body []any) (map[string]dbus.Variant, error) {
+	if len(body) != 2 {
+		return nil, fmt.Errorf("malformed portal response (%d values)", len(body))
+	}
+	code, ok := body[0].(uint32)
+	if !ok {
+		return nil, errors.New("malformed portal response code")
+	}
+	results, _ := body[1].(map[string]dbus.Variant)
+	switch code {
+	case 0:
+		return results, nil
+	case 1:
+		return nil, errPortalDenied
+	default:
+		return nil, fmt.Errorf("portal request failed (response %d)", code)
+	}
+}
+
+// parsePortalStreams decodes the a(ua{sv}) "streams" result of Start.
+func parsePortalStreams(v dbus.Variant) ([]portalStream, error) {
+	var raw []struct {
+		NodeID uint32
+		Props  map[string]dbus.Variant
+	}
+	if v.Value() == nil {
+		return nil, errors.New("no streams in response")
+	}
+	if err := dbus.Store([]any{v.Value()}, &raw); err != nil {
+		return nil, fmt.Errorf("decode streams: %w", err)
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("no streams in response")
+	}
+	streams := make([]portalStream, 0, len(raw))
+	for _, r := range raw {
+		s := portalStream{NodeID: r.NodeID}
+		var size struct{ W, H int32 }
+		if sv, ok := r.Props["size"]; ok && dbus.Store([]any{sv.Value()}, &size) == nil {
+			s.Width, s.Height = int(size.W), int(size.H)
+		}
+		streams = append(streams, s)
+	}
+	return streams, nil
+}
+
+func restoreTokenPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "breeze", restoreTokenFile)
+}
+
+// loadRestoreToken returns the token from the last granted session, if any.
+func loadRestoreToken() string {
+	path := restoreTokenPath()
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// saveRestoreToken persists the token for the next session. Tokens are
+// single-use, so every Start replaces it.
+func saveRestoreToken(token string) {
+	path := restoreTokenPath()
+	if path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		slog.Warn("Failed to store screencast restore token", "error", err.Error())
+		return
+	}
+	if err := os.WriteFile(path, []byte(token), 0o600); err != nil {
+		slog.Warn("Failed to store screencast restore token", "error", err.Error())
+	}
+}
+
+var _ ScreenCapturer = (*portalCapturer)(nil)
+var _ BGRAProvider = (*portalCapturer)(nil)
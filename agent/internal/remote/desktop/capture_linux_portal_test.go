@@ -0,0 +1,112 @@
+//go:build linux
+
+package desktop
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestPortalCapturerImplementsInterfaces(t *testing.T) {
+	var c ScreenCapturer = &portalCapturer{}
+	if bgra, ok := c.(BGRAProvider); !ok || !bgra.IsBGRA() {
+		t.Error("portalCapturer must report BGRA")
+	}
+}
+
+func TestPortalRequestPath(t *testing.T) {
+	got := portalRequestPath(":1.42", "breeze7_1")
+	want := dbus.ObjectPath("/org/freedesktop/portal/desktop/request/1_42/breeze7_1")
+	if got != want {
+		t.Fatalf("portalRequestPath = %q, want %q", got, want)
+	}
+	if !got.IsValid() {
+		t.Fatalf("portalRequestPath %q is not a valid object path", got)
+	}
+}
+
+func TestParsePortalResponse(t *testing.T) {
+	results := map[string]dbus.Variant{"session_handle": dbus.MakeVariant("/org/freedesktop/portal/desktop/session/1_42/s1")}
+	got, err := parsePortalResponse([]any{uint32(0), results})
+	if err != nil {
+		t.Fatalf("success response: %v", err)
+	}
+	if got["session_handle"].Value() != "/org/freedesktop/portal/desktop/session/1_42/s1" {
+		t.Fatalf("results = %v", got)
+	}
+
+	if _, err := parsePortalResponse([]any{uint32(1), map[string]dbus.Variant{}}); !errors.Is(err, errPortalDenied) {
+		t.Fatalf("cancelled response err = %v, want errPortalDenied", err)
+	}
+	if _, err := parsePortalResponse([]any{uint32(2), map[string]dbus.Variant{}}); err == nil {
+		t.Fatal("expected error for response 2")
+	}
+	if _, err := parsePortalResponse([]any{uint32(0)}); err == nil {
+		t.Fatal("expected error for malformed body")
+	}
+}
+
+func TestParsePortalStreams(t *testing.T) {
+	// a(ua{sv}) as godbus delivers it inside a variant.
+	raw := [][]any{
+		{uint32(57), map[string]dbus.Variant{
+			"size":     dbus.MakeVariant([]any{int32(1920), int32(1080)}),
+			"position": dbus.MakeVariant([]any{int32(0), int32(0)}),
+		}},
+		{uint32(58), map[string]dbus.Variant{}},
+	}
+	streams, err := parsePortalStreams(dbus.MakeVariant(raw))
+	if err != nil {
+		t.Fatalf("parsePortalStreams: %v", err)
+	}
+	want := []portalStream{{NodeID: 57, Width: 1920, Height: 1080}, {NodeID: 58}}
+	if !reflect.DeepEqual(streams, want) {
+		t.Fatalf("streams = %+v, want %+v", streams, want)
+	}
+
+	if _, err := parsePortalStreams(dbus.Variant{}); err == nil {
+		t.Fatal("expected error for missing streams")
+	}
+	if _, err := parsePortalStreams(dbus.MakeVariant([][]any{})); err == nil {
+		t.Fatal("expected error for empty streams")
+	}
+}
+
+func TestPipewireFramePipeline(t *testing.T) {
+	args := strings.Join(pipewireFramePipeline(57, 1280, 720), " ")
+	for _, want := range []string{
+		"-q pipewiresrc fd=3 path=57",
+		"video/x-raw,format=BGRx,width=1280,height=720",
+		"fdsink fd=1",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("pipeline %q missing %q", args, want)
+		}
+	}
+}
+
+func TestPortalCapturerCaptureRegion(t *testing.T) {
+	const w, h = 4, 3
+	c := &portalCapturer{width: w, height: h, frame: make([]byte, w*h*4)}
+	for i := range c.frame {
+		c.frame[i] = byte(i)
+	}
+	img, err := c.CaptureRegion(1, 1, 2, 2)
+	if err != nil {
+		t.Fatalf("CaptureRegion: %v", err)
+	}
+	if img.Rect.Dx() != 2 || img.Rect.Dy() != 2 {
+		t.Fatalf("region size = %v", img.Rect)
+	}
+	// Row 1, column 1 of the source frame.
+	if img.Pix[0] != byte((1*w+1)*4) {
+		t.Fatalf("first pixel byte = %d, want %d", img.Pix[0], (1*w+1)*4)
+	}
+	if _, err := c.CaptureRegion(10, 10, 2, 2); err == nil {
+		t.Fatal("expected error for region outside the screen")
+	}
+}
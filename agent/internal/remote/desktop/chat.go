@@ -0,0 +1,106 @@
+package desktop
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// In-session chat.
+//
+// The agent opens a "chat" data channel next to clipboard and filedrop so the
+// technician can talk to the seated user. The viewer sends
+//
+//	{"type":"message","text":"..."}
+//
+// and each line is handed to SessionManager.OnChatMessage, which routes it
+// to the end user: the service relays it to the consent-UI helper for
+// display. The user's replies come back through SendChatMessage and reach
+// the viewer as
+//
+//	{"type":"message","from":"user","text":"...","sentAt":<unix ms>}
+//
+// The channel is only created when OnChatMessage is set; a viewer that sees
+// no chat channel should hide its chat UI.
+
+// maxChatMessageBytes bounds one chat line in either direction.
+const maxChatMessageBytes = 2000
+
+// chatWireMessage is the JSON shape on the chat data channel.
+type chatWireMessage struct {
+	Type   string `json:"type"`
+	From   string `json:"from,omitempty"`
+	Text   string `json:"text"`
+	SentAt int64  `json:"sentAt,omitempty"`
+}
+
+// sanitizeChatText trims a chat line and rejects empty, oversized or
+// non-UTF-8 text.
+func sanitizeChatText(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" || len(text) > maxChatMessageBytes || !utf8.ValidString(text) {
+		return "", false
+	}
+	return text, true
+}
+
+// handleChatMessage processes one message from the viewer's chat channel.
+func (s *Session) handleChatMessage(data []byte) {
+	if s.chatHandler == nil {
+		return
+	}
+	var msg chatWireMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		slog.Warn("Failed to parse chat message", "session", s.id, "error", err.Error())
+		return
+	}
+	if msg.Type != "message" {
+		slog.Debug("Ignoring chat message", "session", s.id, "type", msg.Type)
+		return
+	}
+	text, ok := sanitizeChatText(msg.Text)
+	if !ok {
+		slog.Warn("Rejected chat message", "session", s.id, "size", len(msg.Text))
+		return
+	}
+	s.chatHandler(s.id, text)
+}
+
+// sendChat delivers a line from the end user to the viewer.
+func (s *Session) sendChat(from, text string, sentAt time.Time) error {
+	s.mu.RLock()
+	dc := s.chatDC
+	s.mu.RUnlock()
+	if dc == nil {
+		return fmt.Errorf("session %s has no chat channel", s.id)
+	}
+	resp, err := json.Marshal(chatWireMessage{
+		Type:   "message",
+		From:   from,
+		Text:   text,
+		SentAt: sentAt.UnixMilli(),
+	})
+	if err != nil {
+		return err
+	}
+	return dc.SendText(string(resp))
+}
+
+// SendChatMessage delivers a chat line from the end user to the viewer of
+// the given session.
+func (m *SessionManager) SendChatMessage(sessionID, from, text string, sentAt time.Time) error {
+	text, ok := sanitizeChatText(text)
+	if !ok {
+		return fmt.Errorf("invalid chat message")
+	}
+	m.mu.RLock()
+	session := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if session == nil {
+		return fmt.Errorf("session %s not found", sessionID)
+	}
+	return session.sendChat(from, text, sentAt)
+}
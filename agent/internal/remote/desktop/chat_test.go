@@ -0,0 +1,37 @@
+package desktop
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleChatMessage(t *testing.T) {
+	var got []string
+	s := &Session{id: "s1", chatHandler: func(sessionID, text string) {
+		if sessionID != "s1" {
+			t.Errorf("handler got session %q", sessionID)
+		}
+		got = append(got, text)
+	}}
+
+	s.handleChatMessage([]byte(`{"type":"message","text":"  hello there  "}`))
+	s.handleChatMessage([]byte(`{"type":"typing"}`))
+	s.handleChatMessage([]byte(`{"type":"message","text":"   "}`))
+	s.handleChatMessage([]byte(`{"type":"message","text":"` + strings.Repeat("x", maxChatMessageBytes+1) + `"}`))
+	s.handleChatMessage([]byte(`not json`))
+
+	if len(got) != 1 || got[0] != "hello there" {
+		t.Fatalf("handler calls = %q, want [\"hello there\"]", got)
+	}
+}
+
+func TestSendChatMessageUnknownSession(t *testing.T) {
+	m := &SessionManager{sessions: map[string]*Session{}}
+	if err := m.SendChatMessage("missing", "user", "hi", time.Now()); err == nil {
+		t.Fatal("expected an error for an unknown session")
+	}
+	if err := m.SendChatMessage("missing", "user", "", time.Now()); err == nil {
+		t.Fatal("expected an error for an empty message")
+	}
+}
@@ -0,0 +1,339 @@
+package desktop
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// Video codec negotiation for the WebRTC stream.
+//
+// VP9 and AV1 keep text sharper than H264 at the same bitrate, which matters
+// most for screen content. Both are software encoders here (libvpx / libaom,
+// loaded at runtime), so they are only chosen when the viewer's offer
+// includes them, the library is installed, and the stream is small enough
+// for a CPU encoder to keep up. Anything else falls back to H264, which
+// keeps the hardware encoder path.
+//
+// HEVC is the opposite case: hardware-only (MFT / VideoToolbox), so it has
+// no resolution cap and is preferred first — at 4K it roughly halves the
+// bitrate H264 needs. It is used only when the viewer offers H265 and a
+// hardware HEVC encoder initializes; otherwise negotiation moves on.
+
+const (
+	// envVideoCodecs lets an operator reorder or restrict the codecs the
+	// agent will use, e.g. "h264" to disable HEVC/VP9/AV1 or "vp9,h264" to
+	// skip HEVC and AV1. H264 is always kept as the final fallback.
+	envVideoCodecs = "BREEZE_REMOTE_VIDEO_CODECS"
+
+	// maxSoftwareCodecPixels bounds the resolution VP9/AV1 are used at;
+	// above it the CPU cost outweighs the quality gain and H264 (usually
+	// hardware) is used instead.
+	maxSoftwareCodecPixels = pixels1080p
+)
+
+var defaultVideoCodecPreference = []Codec{CodecH265, CodecAV1, CodecVP9, CodecH264}
+
+var (
+	videoCodecPreferenceOnce sync.Once
+	videoCodecPreference     []Codec
+)
+
+// loadVideoCodecPreference parses BREEZE_REMOTE_VIDEO_CODECS once and caches
+// the result (the env value is fixed for the process lifetime).
+func loadVideoCodecPreference() []Codec {
+	videoCodecPreferenceOnce.Do(func() {
+		videoCodecPreference = parseVideoCodecPreference(os.Getenv(envVideoCodecs))
+	})
+	return videoCodecPreference
+}
+
+// parseVideoCodecPreference reads a comma-separated codec list. Unknown
+// names are ignored (logged); an empty or entirely invalid value yields the
+// default order. H264 is appended when missing so there is always a codec
+// every viewer can decode.
+func parseVideoCodecPreference(raw string) []Codec {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return defaultVideoCodecPreference
+	}
+	var prefs []Codec
+	seen := make(map[Codec]bool)
+	for _, part := range strings.Split(raw, ",") {
+		codec := Codec(strings.ToLower(strings.TrimSpace(part)))
+		if codec == "hevc" {
+			codec = CodecH265
+		}
+		switch codec {
+		case CodecH264, CodecH265, CodecVP9, CodecAV1:
+		default:
+			slog.Warn("Ignoring unsupported codec in "+envVideoCodecs, "codec", string(codec))
+			continue
+		}
+		if !seen[codec] {
+			seen[codec] = true
+			prefs = append(prefs, codec)
+		}
+	}
+	if len(prefs) == 0 {
+		return defaultVideoCodecPreference
+	}
+	if !seen[CodecH264] {
+		prefs = append(prefs, CodecH264)
+	}
+	return prefs
+}
+
+// offeredVideoCodecs returns the codecs in the viewer's offer that the agent
+// can send. VP9 is only taken in profile 0, AV1 in the main profile and H265
+// in Main (profile-id 1) — 8-bit 4:2:0, which is what the encoders produce.
+func offeredVideoCodecs(offerSDP string) map[Codec]bool {
+	offered := make(map[Codec]bool)
+	names := make(map[string]string) // payload type → encoding name
+	fmtps := make(map[string]string) // payload type → fmtp parameters
+	inVideo := false
+	flush := func() {
+		for pt, name := range names {
+			params := fmtps[pt]
+			switch name {
+			case "h264":
+				offered[CodecH264] = true
+			case "h265":
+				if p, ok := fmtpParam(params, "profile-id"); !ok || p == "1" {
+					offered[CodecH265] = true
+				}
+			case "vp9":
+				if p, ok := fmtpParam(params, "profile-id"); !ok || p == "0" {
+					offered[CodecVP9] = true
+				}
+			case "av1":
+				if p, ok := fmtpParam(params, "profile"); !ok || p == "0" {
+					offered[CodecAV1] = true
+				}
+			}
+		}
+		names = make(map[string]string)
+		fmtps = make(map[string]string)
+	}
+	for _, line := range strings.Split(offerSDP, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "m=") {
+			if inVideo {
+				flush()
+			}
+			inVideo = strings.HasPrefix(line, "m=video ")
+			continue
+		}
+		if !inVideo {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "a=rtpmap:"); ok {
+			pt, enc, found := strings.Cut(rest, " ")
+			if found {
+				name, _, _ := strings.Cut(enc, "/")
+				names[pt] = strings.ToLower(name)
+			}
+		} else if rest, ok := strings.CutPrefix(line, "a=fmtp:"); ok {
+			if pt, params, found := strings.Cut(rest, " "); found {
+				fmtps[pt] = params
+			}
+		}
+	}
+	if inVideo {
+		flush()
+	}
+	return offered
+}
+
+// fmtpParam looks up one key in an fmtp parameter list ("a=1;b=2").
+func fmtpParam(params, key string) (string, bool) {
+	for _, kv := range strings.Split(params, ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(kv), "=")
+		if strings.EqualFold(k, key) {
+			return strings.TrimSpace(v), true
+		}
+	}
+	return "", false
+}
+
+// offeredVideoSections counts the video m-lines in the viewer's offer that
+// are not rejected (port 0). As the answerer the agent can only send one
+// video track per offered section, so this bounds how many displays a viewer
+// can receive at once.
+func offeredVideoSections(offerSDP string) int {
+	n := 0
+	for _, line := range strings.Split(offerSDP, "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "m=video ")
+		if !ok {
+			continue
+		}
+		if port, _, _ := strings.Cut(rest, " "); port != "0" {
+			n++
+		}
+	}
+	return n
+}
+
+// videoCodecCandidates lists the codecs to try, in order, for a stream of the
+// given size. H264 is always last, even when the offer is unparseable, so
+// session setup behaves exactly as before when nothing better is available.
+func videoCodecCandidates(offered map[Codec]bool, preference []Codec, width, height int) []Codec {
+	var out []Codec
+	for _, codec := range preference {
+		if codec == CodecH264 || !offered[codec] {
+			continue
+		}
+		if codec != CodecH265 && width*height > maxSoftwareCodecPixels {
+			continue
+		}
+		out = append(out, codec)
+	}
+	return append(out, CodecH264)
+}
+
+// videoTrackCapability is the RTP codec the video track is created with.
+func videoTrackCapability(codec Codec) webrtc.RTPCodecCapability {
+	switch codec {
+	case CodecVP9:
+		return webrtc.RTPCodecCapability{
+			MimeType:    webrtc.MimeTypeVP9,
+			ClockRate:   90000,
+			SDPFmtpLine: "profile-id=0",
+		}
+	case CodecAV1:
+		return webrtc.RTPCodecCapability{
+			MimeType:  webrtc.MimeTypeAV1,
+			ClockRate: 90000,
+		}
+	case CodecH265:
+		return webrtc.RTPCodecCapability{
+			MimeType:  webrtc.MimeTypeH265,
+			ClockRate: 90000,
+		}
+	default:
+		return webrtc.RTPCodecCapability{
+			MimeType:  webrtc.MimeTypeH264,
+			ClockRate: 90000,
+			// Main profile Level 3.1 — matches MFT encoder's CABAC configuration.
+			// VideoToolbox uses Baseline; browser decoders accept both transparently.
+			SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=4d001f",
+		}
+	}
+}
+
+// keyframeIntervalFrames is the periodic keyframe distance for the software
+// encoders: every 4 seconds, as with OpenH264.
+func keyframeIntervalFrames(fps int) int {
+	if fps*4 < 30 {
+		return 30
+	}
+	return fps * 4
+}
+
+// containsKeyframe reports whether an encoded frame is a keyframe, so the
+// oversized-frame guard in the capture loop never drops one.
+func containsKeyframe(codec Codec, data []byte) bool {
+	switch codec {
+	case CodecVP9:
+		return vp9IsKeyframe(data)
+	case CodecAV1:
+		return av1HasSequenceHeader(data)
+	case CodecH265:
+		return h265ContainsIRAP(data)
+	default:
+		return h264ContainsIDR(data)
+	}
+}
+
+// h265ContainsIRAP reports whether HEVC Annex B data contains an IRAP
+// picture (BLA/IDR/CRA, NAL types 16-21), the HEVC equivalent of an IDR.
+func h265ContainsIRAP(data []byte) bool {
+	for i := 0; i+2 < len(data); {
+		startLen := 0
+		if data[i] == 0 && data[i+1] == 0 {
+			if data[i+2] == 1 {
+				startLen = 3
+			} else if i+3 < len(data) && data[i+2] == 0 && data[i+3] == 1 {
+				startLen = 4
+			}
+		}
+		if startLen == 0 {
+			i++
+			continue
+		}
+		if i+startLen < len(data) {
+			if nalType := (data[i+startLen] >> 1) & 0x3f; nalType >= 16 && nalType <= 21 {
+				return true
+			}
+		}
+		i += startLen + 1
+	}
+	return false
+}
+
+// vp9IsKeyframe reads the start of the uncompressed frame header:
+// frame_marker(2) profile(2) [reserved(1) for profile 3]
+// show_existing_frame(1) frame_type(1), where frame_type 0 is KEY_FRAME.
+func vp9IsKeyframe(data []byte) bool {
+	if len(data) == 0 || data[0]>>6 != 0x2 {
+		return false
+	}
+	bit := 2
+	profile := (data[0]>>5)&1 | ((data[0]>>4)&1)<<1
+	bit += 2
+	if profile == 3 {
+		bit++
+	}
+	showExisting := (data[0] >> (7 - bit)) & 1
+	bit++
+	if showExisting == 1 {
+		return false
+	}
+	return (data[0]>>(7-bit))&1 == 0
+}
+
+// av1HasSequenceHeader walks the OBUs of a temporal unit looking for a
+// sequence header, which libaom emits with every keyframe.
+func av1HasSequenceHeader(data []byte) bool {
+	const obuSequenceHeader = 1
+	for len(data) > 0 {
+		header := data[0]
+		obuType := (header >> 3) & 0xf
+		if obuType == obuSequenceHeader {
+			return true
+		}
+		pos := 1
+		if header&0x04 != 0 { // extension header
+			pos++
+		}
+		if header&0x02 == 0 { // no size field: the OBU runs to the end
+			return false
+		}
+		size, n := readLEB128(data[pos:])
+		if n == 0 {
+			return false
+		}
+		pos += n
+		if size > uint64(len(data)-pos) {
+			return false
+		}
+		data = data[pos+int(size):]
+	}
+	return false
+}
+
+// readLEB128 decodes an AV1 leb128 value, returning the number of bytes
+// read (0 when truncated or over-long).
+func readLEB128(data []byte) (uint64, int) {
+	var value uint64
+	for i := 0; i < 8 && i < len(data); i++ {
+		value |= uint64(data[i]&0x7f) << (7 * i)
+		if data[i]&0x80 == 0 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
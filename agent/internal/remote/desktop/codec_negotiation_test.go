@@ -0,0 +1,171 @@
+package desktop
+
+import (
+	"reflect"
+	"testing"
+)
+
+const chromeLikeOffer = "v=0\r\n" +
+	"o=- 1 2 IN IP4 127.0.0.1\r\n" +
+	"s=-\r\n" +
+	"t=0 0\r\n" +
+	"m=video 9 UDP/TLS/RTP/SAVPF 96 98 100 45 102\r\n" +
+	"a=rtpmap:96 VP8/90000\r\n" +
+	"a=rtpmap:98 VP9/90000\r\n" +
+	"a=fmtp:98 profile-id=0\r\n" +
+	"a=rtpmap:100 VP9/90000\r\n" +
+	"a=fmtp:100 profile-id=2\r\n" +
+	"a=rtpmap:45 AV1/90000\r\n" +
+	"a=fmtp:45 level-idx=5;profile=0;tier=0\r\n" +
+	"a=rtpmap:102 H264/90000\r\n" +
+	"a=fmtp:102 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f\r\n" +
+	"m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\n"
+
+func TestOfferedVideoCodecs(t *testing.T) {
+	got := offeredVideoCodecs(chromeLikeOffer)
+	want := map[Codec]bool{CodecH264: true, CodecVP9: true, CodecAV1: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("offeredVideoCodecs = %v, want %v", got, want)
+	}
+}
+
+func TestOfferedVideoCodecsSkipsUnsupportedProfiles(t *testing.T) {
+	offer := "m=video 9 UDP/TLS/RTP/SAVPF 100 35 49 102\n" +
+		"a=rtpmap:100 VP9/90000\n" +
+		"a=fmtp:100 profile-id=2\n" +
+		"a=rtpmap:49 H265/90000\n" +
+		"a=fmtp:49 level-id=93;profile-id=2;tier-flag=0;tx-mode=SRST\n" +
+		"a=rtpmap:35 AV1/90000\n" +
+		"a=fmtp:35 profile=1\n" +
+		"a=rtpmap:102 H264/90000\n" +
+		// Codecs outside the video section are ignored.
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111\n" +
+		"a=rtpmap:111 AV1/90000\n"
+	got := offeredVideoCodecs(offer)
+	want := map[Codec]bool{CodecH264: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("offeredVideoCodecs = %v, want %v", got, want)
+	}
+}
+
+func TestOfferedVideoCodecsH265(t *testing.T) {
+	offer := "m=video 9 UDP/TLS/RTP/SAVPF 49 102\n" +
+		"a=rtpmap:49 H265/90000\n" +
+		"a=fmtp:49 level-id=180;profile-id=1;tier-flag=0;tx-mode=SRST\n" +
+		"a=rtpmap:102 H264/90000\n"
+	got := offeredVideoCodecs(offer)
+	want := map[Codec]bool{CodecH265: true, CodecH264: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("offeredVideoCodecs = %v, want %v", got, want)
+	}
+}
+
+func TestOfferedVideoSections(t *testing.T) {
+	if got := offeredVideoSections(chromeLikeOffer); got != 1 {
+		t.Fatalf("offeredVideoSections(chrome) = %d, want 1", got)
+	}
+	offer := "m=video 9 UDP/TLS/RTP/SAVPF 102\r\n" +
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\n" +
+		"m=video 9 UDP/TLS/RTP/SAVPF 102\r\n" +
+		// A rejected section can't carry a track.
+		"m=video 0 UDP/TLS/RTP/SAVPF 102\r\n" +
+		"m=video 9 UDP/TLS/RTP/SAVPF 102\r\n"
+	if got := offeredVideoSections(offer); got != 3 {
+		t.Fatalf("offeredVideoSections = %d, want 3", got)
+	}
+	if got := offeredVideoSections(""); got != 0 {
+		t.Fatalf("offeredVideoSections(empty) = %d, want 0", got)
+	}
+}
+
+func TestParseVideoCodecPreference(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []Codec
+	}{
+		{"", defaultVideoCodecPreference},
+		{"h264", []Codec{CodecH264}},
+		{"VP9, h264", []Codec{CodecVP9, CodecH264}},
+		{"vp9,vp9", []Codec{CodecVP9, CodecH264}},
+		{"av1,bogus", []Codec{CodecAV1, CodecH264}},
+		{"HEVC,vp9", []Codec{CodecH265, CodecVP9, CodecH264}},
+		{"bogus", defaultVideoCodecPreference},
+	}
+	for _, tt := range tests {
+		if got := parseVideoCodecPreference(tt.raw); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseVideoCodecPreference(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestVideoCodecCandidates(t *testing.T) {
+	all := map[Codec]bool{CodecH264: true, CodecVP9: true, CodecAV1: true}
+	withH265 := map[Codec]bool{CodecH264: true, CodecH265: true, CodecVP9: true, CodecAV1: true}
+	tests := []struct {
+		name    string
+		offered map[Codec]bool
+		w, h    int
+		want    []Codec
+	}{
+		{"preference order", all, 1920, 1080, []Codec{CodecAV1, CodecVP9, CodecH264}},
+		{"above 1080p uses H264 only", all, 2560, 1440, []Codec{CodecH264}},
+		{"viewer without AV1", map[Codec]bool{CodecVP9: true, CodecH264: true}, 1280, 720, []Codec{CodecVP9, CodecH264}},
+		{"unparseable offer", map[Codec]bool{}, 1280, 720, []Codec{CodecH264}},
+		{"hevc first", withH265, 1920, 1080, []Codec{CodecH265, CodecAV1, CodecVP9, CodecH264}},
+		// HEVC is hardware-encoded, so it is not capped like VP9/AV1.
+		{"hevc at 4k", withH265, 3840, 2160, []Codec{CodecH265, CodecH264}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := videoCodecCandidates(tt.offered, defaultVideoCodecPreference, tt.w, tt.h)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("videoCodecCandidates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainsKeyframe(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+		data  []byte
+		want  bool
+	}{
+		{"h264 idr", CodecH264, []byte{0, 0, 0, 1, 0x67, 0x42, 0, 0, 0, 1, 0x65, 0x88}, true},
+		{"h264 p-frame", CodecH264, []byte{0, 0, 0, 1, 0x41, 0x9a}, false},
+		// frame_marker=10, profile 0, show_existing=0, frame_type=0.
+		{"vp9 keyframe", CodecVP9, []byte{0x82, 0x49, 0x83, 0x42}, true},
+		// frame_type=1.
+		{"vp9 inter frame", CodecVP9, []byte{0x86, 0x00}, false},
+		{"vp9 show existing", CodecVP9, []byte{0x88}, false},
+		{"vp9 bad marker", CodecVP9, []byte{0x02}, false},
+		// Temporal delimiter then a sequence header, as libaom emits on keyframes.
+		{"av1 keyframe", CodecAV1, []byte{0x12, 0x00, 0x0a, 0x02, 0x00, 0x00, 0x32, 0x01, 0x10}, true},
+		// Temporal delimiter then a frame OBU only.
+		{"av1 inter frame", CodecAV1, []byte{0x12, 0x00, 0x32, 0x02, 0x30, 0x00}, false},
+		{"av1 truncated", CodecAV1, []byte{0x12, 0x05, 0x00}, false},
+		// VPS (32) then IDR_W_RADL (19).
+		{"h265 idr", CodecH265, []byte{0, 0, 0, 1, 0x40, 0x01, 0x0c, 0, 0, 0, 1, 0x26, 0x01, 0xaf}, true},
+		// CRA (21).
+		{"h265 cra", CodecH265, []byte{0, 0, 1, 0x2a, 0x01, 0xaf}, true},
+		// TRAIL_R (1).
+		{"h265 p-frame", CodecH265, []byte{0, 0, 0, 1, 0x02, 0x01, 0xd0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containsKeyframe(tt.codec, tt.data); got != tt.want {
+				t.Errorf("containsKeyframe(%s) = %v, want %v", tt.codec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyframeIntervalFrames(t *testing.T) {
+	if got := keyframeIntervalFrames(60); got != 240 {
+		t.Errorf("keyframeIntervalFrames(60) = %d, want 240", got)
+	}
+	if got := keyframeIntervalFrames(5); got != 30 {
+		t.Errorf("keyframeIntervalFrames(5) = %d, want 30", got)
+	}
+}
@@ -0,0 +1,57 @@
+//go:build linux || darwin
+
+package desktop
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ebitengine/purego"
+
+	"github.com/breeze-rmm/agent/internal/config"
+)
+
+// openCodecLibrary loads the first of names found next to the agent
+// executable, in the agent data dir, then through the system loader
+// (which also covers Homebrew's prefixes on macOS).
+func openCodecLibrary(names []string) (uintptr, string, error) {
+	var dirs []string
+	if exePath, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exePath))
+	}
+	dirs = append(dirs, config.GetDataDir())
+	if runtime.GOOS == "darwin" {
+		dirs = append(dirs, "/opt/homebrew/lib", "/usr/local/lib")
+	}
+
+	var errs []string
+	for _, name := range names {
+		candidates := []string{name}
+		for _, dir := range dirs {
+			candidates = append([]string{filepath.Join(dir, name)}, candidates...)
+		}
+		for _, path := range candidates {
+			if filepath.IsAbs(path) {
+				if _, err := os.Stat(path); err != nil {
+					continue
+				}
+			}
+			h, err := purego.Dlopen(path, purego.RTLD_NOW|purego.RTLD_LOCAL)
+			if err == nil {
+				return h, path, nil
+			}
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) == 0 {
+		return 0, "", fmt.Errorf("none of %s found", strings.Join(names, ", "))
+	}
+	return 0, "", fmt.Errorf("load %s: %s", strings.Join(names, ", "), strings.Join(errs, "; "))
+}
+
+func codecLibrarySymbol(lib uintptr, name string) (uintptr, error) {
+	return purego.Dlsym(lib, name)
+}
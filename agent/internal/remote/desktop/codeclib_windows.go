@@ -0,0 +1,48 @@
+//go:build windows
+
+package desktop
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/breeze-rmm/agent/internal/config"
+)
+
+// openCodecLibrary loads the first of names found next to the agent
+// executable or in the agent data dir. The system search path is not used,
+// so a DLL dropped into a PATH directory is never picked up.
+func openCodecLibrary(names []string) (uintptr, string, error) {
+	var dirs []string
+	if exePath, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exePath))
+	}
+	dirs = append(dirs, config.GetDataDir())
+
+	var errs []string
+	for _, name := range names {
+		for _, dir := range dirs {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			h, err := windows.LoadLibraryEx(path, 0, windows.LOAD_WITH_ALTERED_SEARCH_PATH)
+			if err == nil {
+				return uintptr(h), path, nil
+			}
+			errs = append(errs, fmt.Sprintf("%s: %v", path, err))
+		}
+	}
+	if len(errs) == 0 {
+		return 0, "", fmt.Errorf("none of %s found", strings.Join(names, ", "))
+	}
+	return 0, "", fmt.Errorf("load %s: %s", strings.Join(names, ", "), strings.Join(errs, "; "))
+}
+
+func codecLibrarySymbol(lib uintptr, name string) (uintptr, error) {
+	return windows.GetProcAddress(windows.Handle(lib), name)
+}
@@ -167,6 +167,7 @@ var (
 
 	mfMediaTypeVideo  = comGUID{0x73646976, 0x0000, 0x0010, [8]byte{0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}}
 	mfVideoFormatH264 = comGUID{0x34363248, 0x0000, 0x0010, [8]byte{0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}}
+	mfVideoFormatHEVC = comGUID{0x43564548, 0x0000, 0x0010, [8]byte{0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}}
 	mfVideoFormatNV12 = comGUID{0x3231564E, 0x0000, 0x0010, [8]byte{0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}}
 
 	mfMTMajorType        = comGUID{0x48eba18e, 0xf8c9, 0x4687, [8]byte{0xbf, 0x11, 0x0a, 0x74, 0xc9, 0xf9, 0x6a, 0x8f}}
@@ -209,6 +210,7 @@ var (
 	codecAPIAVLowLatencyMode          = comGUID{0x9c27891a, 0xed7a, 0x40e1, [8]byte{0x88, 0xe8, 0xb2, 0x27, 0x27, 0xa0, 0x24, 0xee}}
 	codecAPIAVEncCommonQualityVsSpeed = comGUID{0x98332df8, 0x03cd, 0x476b, [8]byte{0x89, 0xfa, 0x3f, 0x9e, 0x44, 0x2d, 0xec, 0x9f}}
 	codecAPIAVEncVideoEncodeQP        = comGUID{0x2cb5696b, 0x23fb, 0x4ce1, [8]byte{0xa0, 0xf9, 0xef, 0x5b, 0x90, 0xfd, 0x55, 0xca}}
+	codecAPIAVEncVideoMaxQP           = comGUID{0x3daf6f66, 0xa6a7, 0x45e0, [8]byte{0xa8, 0xe5, 0xf2, 0x74, 0x3f, 0x46, 0xa3, 0xa2}}
 
 	// Rate control modes
 	eAVEncCommonRateControlMode_CBR     uint32 = 1
@@ -218,6 +220,9 @@ var (
 	eAVEncH264VProfileBaseline uint32 = 66
 	eAVEncH264VProfileMain     uint32 = 77
 
+	// HEVC profile constants
+	eAVEncH265VProfileMain420_8 uint32 = 1
+
 	// HRESULT for async locked
 	mfETransformAsyncLocked = 0xC00D6D77
 )
@@ -0,0 +1,177 @@
+package desktop
+
+import "image"
+
+// Damage-region aware colour conversion.
+//
+// Capturers that know which parts of the screen changed (DXGI dirty and move
+// rects, see DirtyRectProvider) pass them down through
+// VideoEncoder.EncodeDamaged. CPU-input backends keep the previous frame's
+// converted YUV buffer and re-convert only the damaged macroblocks, so an idle
+// desktop with a blinking caret costs a few 16×16 blocks of conversion per
+// frame instead of the whole screen. Unchanged macroblocks are then
+// bit-identical to the reference, which the encoder codes as skips.
+
+// damageFullFrameFraction is the damaged-area share above which a full-frame
+// conversion (with its unrolled inner loops) is no slower than the per-region
+// pass.
+const damageFullFrameFraction = 0.5
+
+// damageAlign is the block size damage is expanded to. 16 matches the H264
+// macroblock and keeps every region even for 4:2:0 chroma subsampling.
+const damageAlign = 16
+
+// mergeDirtyRects combines dirty rects into a single bounding box.
+func mergeDirtyRects(rects []image.Rectangle) image.Rectangle {
+	if len(rects) == 0 {
+		return image.Rectangle{}
+	}
+	bounds := rects[0]
+	for _, r := range rects[1:] {
+		bounds = bounds.Union(r)
+	}
+	return bounds
+}
+
+// dirtyRectCoversFraction returns the fraction of the screen covered by
+// the dirty region (0.0 to 1.0).
+func dirtyRectCoversFraction(dirty image.Rectangle, screenW, screenH int) float64 {
+	if screenW <= 0 || screenH <= 0 {
+		return 1.0
+	}
+	area := dirty.Dx() * dirty.Dy()
+	return float64(area) / float64(screenW*screenH)
+}
+
+// alignDamage expands rects to the macroblock grid, clips them to the frame
+// and drops empty ones. Overlapping blocks may be converted twice; that is
+// cheaper than computing an exact union for the handful of rects DXGI
+// reports.
+func alignDamage(rects []image.Rectangle, width, height int) []image.Rectangle {
+	bounds := image.Rect(0, 0, width, height)
+	out := make([]image.Rectangle, 0, len(rects))
+	for _, r := range rects {
+		r = image.Rect(
+			r.Min.X&^(damageAlign-1), r.Min.Y&^(damageAlign-1),
+			(r.Max.X+damageAlign-1)&^(damageAlign-1), (r.Max.Y+damageAlign-1)&^(damageAlign-1),
+		).Intersect(bounds)
+		if !r.Empty() {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// damageArea sums the area of rects (overlaps counted twice).
+func damageArea(rects []image.Rectangle) int {
+	area := 0
+	for _, r := range rects {
+		area += r.Dx() * r.Dy()
+	}
+	return area
+}
+
+// yuvLayout selects the planar format a yuvFrameCache produces.
+type yuvLayout int
+
+const (
+	yuvNV12 yuvLayout = iota // MFT, VA-API
+	yuvI420                  // OpenH264, libvpx, libaom
+)
+
+// yuvFrameCache holds the last converted frame of one encoder so the next
+// frame only needs its damaged regions re-converted. Not safe for concurrent
+// use; backends call it under their own mutex.
+type yuvFrameCache struct {
+	layout yuvLayout
+	buf    []byte
+	width  int
+	height int
+	pf     PixelFormat
+}
+
+// convert returns the YUV conversion of frame. With damage == nil (unknown),
+// on the first frame, after a size or pixel-format change, or when most of
+// the frame is damaged, it does a full conversion; otherwise only the damaged
+// regions of the previous result are rewritten. The returned buffer is owned
+// by the cache and valid until the next call.
+func (c *yuvFrameCache) convert(frame []byte, width, height, stride int, pf PixelFormat, damage []image.Rectangle) []byte {
+	if damage != nil && c.buf != nil && c.width == width && c.height == height && c.pf == pf &&
+		len(frame) >= height*stride {
+		regions := alignDamage(damage, width, height)
+		if float64(damageArea(regions)) <= damageFullFrameFraction*float64(width*height) {
+			for _, r := range regions {
+				convertRegion(c.layout, c.buf, frame, width, height, stride, pf == PixelFormatBGRA, r)
+			}
+			return c.buf
+		}
+	}
+
+	c.reset()
+	switch {
+	case c.layout == yuvI420 && pf == PixelFormatBGRA:
+		c.buf = bgraToI420(frame, width, height, stride)
+	case c.layout == yuvI420:
+		c.buf = rgbaToI420(frame, width, height, stride)
+	case pf == PixelFormatBGRA:
+		c.buf = bgraToNV12(frame, width, height, stride)
+	default:
+		c.buf = rgbaToNV12(frame, width, height, stride)
+	}
+	c.width, c.height, c.pf = width, height, pf
+	return c.buf
+}
+
+// reset drops the cached frame so the next convert is a full conversion.
+// Call it whenever a frame passed to the encoder was not converted (early
+// error returns), since its damage would otherwise be lost.
+func (c *yuvFrameCache) reset() {
+	if c.buf == nil {
+		return
+	}
+	if c.layout == yuvI420 {
+		putI420Buffer(c.buf)
+	} else {
+		putNV12Buffer(c.buf)
+	}
+	c.buf = nil
+}
+
+// convertRegion converts the pixels of r (even-aligned, inside the frame)
+// into dst with the same BT.601 math as the full-frame converters, so a
+// region-updated buffer is byte-identical to a full conversion.
+func convertRegion(layout yuvLayout, dst, src []byte, width, height, stride int, bgra bool, r image.Rectangle) {
+	ri, bi := 0, 2
+	if bgra {
+		ri, bi = 2, 0
+	}
+	ySize := width * height
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := src[y*stride+r.Min.X*4 : y*stride+r.Max.X*4]
+		yRow := dst[y*width+r.Min.X : y*width+r.Max.X]
+		for x := range yRow {
+			pi := x * 4
+			yRow[x] = byte((66*int(row[pi+ri])+129*int(row[pi+1])+25*int(row[pi+bi])+128)>>8 + 16)
+		}
+	}
+
+	for y := r.Min.Y; y < r.Max.Y; y += 2 {
+		row := src[y*stride : y*stride+width*4]
+		for x := r.Min.X; x < r.Max.X; x += 2 {
+			pi := x * 4
+			rr, g, b := int(row[pi+ri]), int(row[pi+1]), int(row[pi+bi])
+			u := byte((-38*rr-74*g+112*b+128)>>8 + 128)
+			v := byte((112*rr-94*g-18*b+128)>>8 + 128)
+			if layout == yuvI420 {
+				off := (y/2)*(width/2) + x/2
+				dst[ySize+off] = u
+				dst[ySize+ySize/4+off] = v
+			} else {
+				off := ySize + (y/2)*width + x
+				dst[off] = u
+				dst[off+1] = v
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package desktop
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func TestMergeDirtyRects_Empty(t *testing.T) {
+	result := mergeDirtyRects(nil)
+	if !result.Empty() {
+		t.Errorf("expected empty rect, got %v", result)
+	}
+}
+
+func TestMergeDirtyRects_Single(t *testing.T) {
+	rects := []image.Rectangle{image.Rect(10, 20, 100, 200)}
+	result := mergeDirtyRects(rects)
+	expected := image.Rect(10, 20, 100, 200)
+	if result != expected {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
+
+func TestMergeDirtyRects_Multiple(t *testing.T) {
+	rects := []image.Rectangle{
+		image.Rect(10, 20, 100, 200),
+		image.Rect(500, 300, 600, 400),
+	}
+	result := mergeDirtyRects(rects)
+	expected := image.Rect(10, 20, 600, 400)
+	if result != expected {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
+
+func TestDirtyRectCoversFraction(t *testing.T) {
+	tests := []struct {
+		name     string
+		dirty    image.Rectangle
+		w, h     int
+		expected float64
+	}{
+		{"full screen", image.Rect(0, 0, 1920, 1080), 1920, 1080, 1.0},
+		{"quarter", image.Rect(0, 0, 960, 540), 1920, 1080, 0.25},
+		{"small region", image.Rect(100, 100, 132, 132), 1920, 1080, 0.000494},
+		{"zero screen", image.Rect(0, 0, 100, 100), 0, 0, 1.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dirtyRectCoversFraction(tt.dirty, tt.w, tt.h)
+			if got < tt.expected-0.001 || got > tt.expected+0.001 {
+				t.Errorf("expected ~%f, got %f", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAlignDamage(t *testing.T) {
+	got := alignDamage([]image.Rectangle{
+		image.Rect(5, 17, 20, 33),      // grows to the macroblock grid
+		image.Rect(90, 50, 120, 70),    // clipped to the frame
+		image.Rect(200, 200, 210, 210), // entirely outside
+	}, 100, 60)
+	want := []image.Rectangle{
+		image.Rect(0, 16, 32, 48),
+		image.Rect(80, 48, 100, 60),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("alignDamage = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("rect %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestYUVFrameCacheDamagedMatchesFull(t *testing.T) {
+	const w, h = 96, 64
+	stride := w * 4
+	frame := func(seed int) []byte {
+		pix := make([]byte, h*stride)
+		for i := range pix {
+			pix[i] = byte(i*7 + seed)
+		}
+		return pix
+	}
+	damage := []image.Rectangle{image.Rect(20, 10, 30, 14), image.Rect(64, 48, 70, 60)}
+
+	for _, layout := range []yuvLayout{yuvNV12, yuvI420} {
+		for _, pf := range []PixelFormat{PixelFormatRGBA, PixelFormatBGRA} {
+			first, second := frame(0), frame(0)
+			for _, r := range alignDamage(damage, w, h) {
+				for y := r.Min.Y; y < r.Max.Y; y++ {
+					for x := r.Min.X * 4; x < r.Max.X*4; x++ {
+						second[y*stride+x] ^= 0x5A
+					}
+				}
+			}
+
+			c := yuvFrameCache{layout: layout}
+			c.convert(first, w, h, stride, pf, nil)
+			got := append([]byte(nil), c.convert(second, w, h, stride, pf, damage)...)
+
+			full := yuvFrameCache{layout: layout}
+			want := full.convert(second, w, h, stride, pf, nil)
+			if !bytes.Equal(got, want) {
+				t.Errorf("layout %d pf %d: damaged conversion differs from full conversion", layout, pf)
+			}
+			c.reset()
+			full.reset()
+		}
+	}
+}
+
+func TestYUVFrameCacheUnknownDamageConvertsFully(t *testing.T) {
+	const w, h = 32, 32
+	c := yuvFrameCache{layout: yuvNV12}
+	defer c.reset()
+	c.convert(make([]byte, w*h*4), w, h, w*4, PixelFormatRGBA, nil)
+
+	white := bytes.Repeat([]byte{0xFF}, w*h*4)
+	out := c.convert(white, w, h, w*4, PixelFormatRGBA, nil)
+	if out[w*h-1] != 235 {
+		t.Fatalf("last luma sample = %d, want 235 after full conversion", out[w*h-1])
+	}
+	out = c.convert(make([]byte, w*h*4), w, h, w*4, PixelFormatRGBA, []image.Rectangle{})
+	if out[w*h-1] != 235 {
+		t.Fatalf("empty damage rewrote the frame: last luma sample = %d", out[w*h-1])
+	}
+}
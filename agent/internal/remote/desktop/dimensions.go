@@ -48,3 +48,37 @@ func FitRGBAFrame(input []byte, w, h int) ([]byte, error) {
 			len(input), w, h, expected)
 	}
 }
+
+// downscaleHeights is the encode-resolution ladder the adaptive controller
+// steps down under sustained loss at the bitrate floor (4K → 1440p → 1080p →
+// 720p). maxDownscaleLevel is its length.
+var downscaleHeights = []int{1440, 1080, 720}
+
+const maxDownscaleLevel = 3
+
+// downscaledDimensions returns the encode size for a w×h source at the given
+// downscale level: the level-th ladder height strictly below h (the smallest
+// one when the source has fewer rungs below it), with the width following the
+// source aspect ratio. Level 0, or a source already at or below every rung,
+// returns the source size unchanged. Results are [AlignEven]ed.
+func downscaledDimensions(w, h, level int) (int, int) {
+	if level <= 0 || w <= 0 || h <= 0 {
+		return w, h
+	}
+	target := 0
+	rung := 0
+	for _, height := range downscaleHeights {
+		if height >= h {
+			continue
+		}
+		target = height
+		rung++
+		if rung == level {
+			break
+		}
+	}
+	if target == 0 {
+		return w, h
+	}
+	return AlignEven(w*target/h, target)
+}
@@ -101,3 +101,29 @@ func TestFitRGBAFrame(t *testing.T) {
 		}
 	})
 }
+
+func TestDownscaledDimensions(t *testing.T) {
+	cases := []struct {
+		w, h, level  int
+		wantW, wantH int
+	}{
+		{3840, 2160, 0, 3840, 2160},
+		{3840, 2160, 1, 2560, 1440},
+		{3840, 2160, 2, 1920, 1080},
+		{3840, 2160, 3, 1280, 720},
+		{3840, 2160, 9, 1280, 720},
+		{2560, 1440, 1, 1920, 1080},
+		{1920, 1080, 1, 1280, 720},
+		{1920, 1080, 3, 1280, 720},
+		{1366, 768, 1, 1280, 720},
+		{1280, 720, 2, 1280, 720},
+		{1512, 949, 1, 1146, 720},
+	}
+	for _, c := range cases {
+		gotW, gotH := downscaledDimensions(c.w, c.h, c.level)
+		if gotW != c.wantW || gotH != c.wantH {
+			t.Errorf("downscaledDimensions(%d, %d, %d) = (%d, %d), want (%d, %d)",
+				c.w, c.h, c.level, gotW, gotH, c.wantW, c.wantH)
+		}
+	}
+}
@@ -84,6 +84,7 @@ func (c *dxgiCapturer) Capture() (*image.RGBA, error) {
 
 	// If we've fallen back to GDI, delegate
 	if c.gdiFallback != nil {
+		c.damageChain = false
 		return c.captureFromGDIFallbackLocked()
 	}
 
@@ -185,10 +186,6 @@ func (c *dxgiCapturer) Capture() (*image.RGBA, error) {
 	// Success — reset failure counter
 	c.consecutiveFailures = 0
 	c.lastAccumulatedFrames = frameInfo.AccumulatedFrames
-	// NOTE: dirty rects (frameInfo.TotalMetadataBufferSize) are intentionally
-	// NOT fetched — nothing consumes them yet, and the per-frame COM call +
-	// allocations were pure overhead. Re-add via getDirtyRects (still in
-	// dxgi_dirty_rects_windows.go) when region-based encoding lands.
 
 	// No new frames accumulated — skip
 	if frameInfo.AccumulatedFrames == 0 {
@@ -199,6 +196,9 @@ func (c *dxgiCapturer) Capture() (*image.RGBA, error) {
 	}
 
 	c.diagSuccessFrames++
+	// Dirty + move rects let the encoder re-convert only what changed.
+	// Fetched before ReleaseFrame, while the frame's metadata is valid.
+	c.updateFrameDamageLocked(&frameInfo)
 	// QueryInterface → ID3D11Texture2D
 	var texture uintptr
 	_, err := comCall(resource, vtblQueryInterface,
@@ -264,6 +264,7 @@ func (c *dxgiCapturer) Capture() (*image.RGBA, error) {
 	syscall.SyscallN(comVtblFn(c.context, d3d11CtxUnmap), c.context, c.staging, 0)
 	syscall.SyscallN(comVtblFn(c.duplication, dxgiDuplReleaseFrame), c.duplication)
 
+	c.damageChain = true
 	return img, nil
 }
 
@@ -482,7 +483,9 @@ func (c *dxgiCapturer) CaptureTexture() (uintptr, error) {
 
 	c.consecutiveFailures = 0
 	c.lastAccumulatedFrames = frameInfo.AccumulatedFrames
-	// Dirty rects intentionally not fetched — see CaptureFrame.
+	// The GPU path encodes textures whole; this frame's damage never
+	// reaches a CPU encoder, so the next Capture() reports it unknown.
+	c.damageChain = false
 
 	if frameInfo.AccumulatedFrames == 0 {
 		c.diagZeroFrames++
@@ -1,4 +1,4 @@
-//go:build windows
+//go:build windows && !cgo
 
 package desktop
 
@@ -8,64 +8,94 @@ import (
 	"unsafe"
 )
 
-// getDirtyRects calls IDXGIOutputDuplication::GetFrameDirtyRects to retrieve
-// the list of screen regions that changed since the last AcquireNextFrame.
-// Returns nil if the call fails or no metadata is available (non-fatal).
-func getDirtyRects(duplication uintptr, metadataSize uint32) []image.Rectangle {
+// dxgiMoveRect matches DXGI_OUTDUPL_MOVE_RECT.
+type dxgiMoveRect struct {
+	SourceX, SourceY int32 // POINT SourcePoint
+	Destination      dxgiRECT
+}
+
+// getFrameDamage calls IDXGIOutputDuplication::GetFrameMoveRects and
+// GetFrameDirtyRects to retrieve the screen regions that changed since the
+// last AcquireNextFrame: the destination of every move rect, then the dirty
+// rects. buf and rects are reused across frames to keep the per-frame cost at
+// two COM calls. ok is false if either call fails (damage unknown).
+func getFrameDamage(duplication uintptr, metadataSize uint32, buf []byte, rects []image.Rectangle) (_ []byte, _ []image.Rectangle, ok bool) {
+	rects = rects[:0]
 	if duplication == 0 || metadataSize == 0 {
-		return nil
+		return buf, rects, false
 	}
+	if uint32(cap(buf)) < metadataSize {
+		buf = make([]byte, metadataSize)
+	}
+	buf = buf[:metadataSize]
 
-	// Allocate buffer for dirty rects (each RECT is 16 bytes)
-	buf := make([]byte, metadataSize)
-	var bytesReturned uint32
-
+	// Move rects come first in the metadata buffer; DXGI does not repeat
+	// their destinations in the dirty rect list.
+	var moveBytes uint32
 	hr, _, _ := syscall.SyscallN(
-		comVtblFn(duplication, dxgiDuplGetFrameDirtyRects),
+		comVtblFn(duplication, dxgiDuplGetFrameMoveRects),
 		duplication,
 		uintptr(len(buf)),
 		uintptr(unsafe.Pointer(&buf[0])),
-		uintptr(unsafe.Pointer(&bytesReturned)),
+		uintptr(unsafe.Pointer(&moveBytes)),
 	)
 	if int32(hr) < 0 {
-		return nil
+		return buf, rects, false
 	}
-
-	rectSize := uint32(unsafe.Sizeof(dxgiRECT{}))
-	count := bytesReturned / rectSize
-	if count == 0 {
-		return nil
+	moveSize := uint32(unsafe.Sizeof(dxgiMoveRect{}))
+	for i := uint32(0); i < moveBytes/moveSize; i++ {
+		m := (*dxgiMoveRect)(unsafe.Pointer(&buf[i*moveSize]))
+		rects = append(rects, image.Rect(
+			int(m.Destination.Left), int(m.Destination.Top),
+			int(m.Destination.Right), int(m.Destination.Bottom),
+		))
 	}
 
-	rects := make([]image.Rectangle, 0, count)
-	for i := uint32(0); i < count; i++ {
+	var dirtyBytes uint32
+	hr, _, _ = syscall.SyscallN(
+		comVtblFn(duplication, dxgiDuplGetFrameDirtyRects),
+		duplication,
+		uintptr(len(buf)),
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(unsafe.Pointer(&dirtyBytes)),
+	)
+	if int32(hr) < 0 {
+		return buf, rects, false
+	}
+	rectSize := uint32(unsafe.Sizeof(dxgiRECT{}))
+	for i := uint32(0); i < dirtyBytes/rectSize; i++ {
 		r := (*dxgiRECT)(unsafe.Pointer(&buf[i*rectSize]))
 		rects = append(rects, image.Rect(
 			int(r.Left), int(r.Top),
 			int(r.Right), int(r.Bottom),
 		))
 	}
-	return rects
+	return buf, rects, true
 }
 
-// mergeDirtyRects combines dirty rects into a single bounding box.
-func mergeDirtyRects(rects []image.Rectangle) image.Rectangle {
-	if len(rects) == 0 {
-		return image.Rectangle{}
+// updateFrameDamageLocked records the damage of a frame just acquired by
+// Capture(). Damage is only known when the previous acquired frame also went
+// through Capture() — frames consumed by CaptureTexture or the GDI fallback
+// never reached the CPU encoder, so their changes would be missing — and
+// only for identity rotation, where the rects map directly onto the
+// captured frame. Caller must hold c.mu.
+func (c *dxgiCapturer) updateFrameDamageLocked(frameInfo *dxgiOutDuplFrameInfo) {
+	known := c.damageChain &&
+		(c.rotation == 0 || c.rotation == 1) &&
+		frameInfo.ProtectedContentMaskedOut == 0
+	if known {
+		c.damageBuf, c.damage, known = getFrameDamage(c.duplication, frameInfo.TotalMetadataBufferSize, c.damageBuf, c.damage)
 	}
-	bounds := rects[0]
-	for _, r := range rects[1:] {
-		bounds = bounds.Union(r)
-	}
-	return bounds
+	c.damageKnown = known
+	c.damageChain = false // re-armed once Capture() hands the frame out
 }
 
-// dirtyRectCoversFraction returns the fraction of the screen covered by
-// the dirty region (0.0 to 1.0).
-func dirtyRectCoversFraction(dirty image.Rectangle, screenW, screenH int) float64 {
-	if screenW <= 0 || screenH <= 0 {
-		return 1.0
+// DirtyRects implements DirtyRectProvider.
+func (c *dxgiCapturer) DirtyRects() []image.Rectangle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.damageKnown || c.gdiFallback != nil {
+		return nil
 	}
-	area := dirty.Dx() * dirty.Dy()
-	return float64(area) / float64(screenW*screenH)
+	return append([]image.Rectangle{}, c.damage...)
 }
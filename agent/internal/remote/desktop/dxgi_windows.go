@@ -4,6 +4,7 @@ package desktop
 
 import (
 	"fmt"
+	"image"
 	"log/slog"
 	"runtime"
 	"sync"
@@ -177,6 +178,14 @@ type dxgiCapturer struct {
 	// Last AcquireNextFrame accumulated count
 	lastAccumulatedFrames uint32
 
+	// Damage of the last frame returned by Capture() (see DirtyRects).
+	// damageChain is true while consecutive acquired frames all went through
+	// Capture(); anything else in between makes the next damage unknown.
+	damageBuf   []byte
+	damage      []image.Rectangle
+	damageKnown bool
+	damageChain bool
+
 	// Failure tracking for GDI fallback
 	consecutiveFailures int
 	gdiFallback         *gdiCapturer
@@ -489,6 +498,7 @@ func (c *dxgiCapturer) releaseDXGI() {
 		syscall.SyscallN(comVtblFn(c.duplication, dxgiDuplReleaseFrame), c.duplication)
 	}
 	c.textureFrameAcquired = false
+	c.damageChain = false
 	if c.gpuTexture != 0 {
 		comRelease(c.gpuTexture)
 		c.gpuTexture = 0
@@ -513,9 +523,10 @@ func (c *dxgiCapturer) releaseDXGI() {
 }
 
 var (
-	_ ScreenCapturer  = (*dxgiCapturer)(nil)
-	_ BGRAProvider    = (*dxgiCapturer)(nil)
-	_ TightLoopHint   = (*dxgiCapturer)(nil)
-	_ FrameChangeHint = (*dxgiCapturer)(nil)
-	_ TextureProvider = (*dxgiCapturer)(nil)
+	_ ScreenCapturer    = (*dxgiCapturer)(nil)
+	_ BGRAProvider      = (*dxgiCapturer)(nil)
+	_ TightLoopHint     = (*dxgiCapturer)(nil)
+	_ FrameChangeHint   = (*dxgiCapturer)(nil)
+	_ TextureProvider   = (*dxgiCapturer)(nil)
+	_ DirtyRectProvider = (*dxgiCapturer)(nil)
 )
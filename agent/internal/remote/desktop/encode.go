@@ -111,6 +111,16 @@ func ScaleImageFast(img *image.RGBA, factor float64) *image.RGBA {
 	if dstH < 1 {
 		dstH = 1
 	}
+	return scaleImageTo(img, dstW, dstH)
+}
+
+// scaleImageTo is the nearest-neighbor resampler behind ScaleImageFast,
+// producing exactly dstW×dstH. The result comes from scaledImagePool. Byte
+// order is preserved, so it works on RGBA and BGRA frames alike.
+func scaleImageTo(img *image.RGBA, dstW, dstH int) *image.RGBA {
+	srcBounds := img.Bounds()
+	srcW := srcBounds.Dx()
+	srcH := srcBounds.Dy()
 
 	scaled := scaledImagePool.Get(dstW, dstH)
 
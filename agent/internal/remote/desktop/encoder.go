@@ -3,6 +3,7 @@ package desktop
 import (
 	"errors"
 	"fmt"
+	"image"
 	"log/slog"
 	"sync"
 )
@@ -11,6 +12,7 @@ type Codec string
 
 const (
 	CodecH264 Codec = "h264"
+	CodecH265 Codec = "h265"
 	CodecVP9  Codec = "vp9"
 	CodecVP8  Codec = "vp8"
 	CodecAV1  Codec = "av1"
@@ -64,6 +66,9 @@ type VideoEncoder struct {
 	mu      sync.Mutex
 	cfg     EncoderConfig
 	backend encoderBackend
+
+	// width and height are the last dimensions the backend accepted.
+	width, height int
 }
 
 // optionalKeyframeForcer is implemented by encoder backends that can force the
@@ -91,6 +96,19 @@ type optionalGPUOnlyMarker interface {
 	IsGPUOnly() bool
 }
 
+// optionalDamageEncoder is implemented by CPU-input backends that convert
+// frames incrementally (see yuvFrameCache): only the damaged regions of the
+// previous frame's YUV buffer are re-converted.
+type optionalDamageEncoder interface {
+	EncodeDamaged(frame []byte, damage []image.Rectangle) ([]byte, error)
+}
+
+// optionalTextModeEncoder is implemented by H264 backends that can cap their
+// quantizer for text content (see text_mode.go).
+type optionalTextModeEncoder interface {
+	SetTextMode(enabled bool) error
+}
+
 type encoderBackend interface {
 	Encode(frame []byte) ([]byte, error)
 	SetCodec(codec Codec) error
@@ -158,6 +176,22 @@ func (v *VideoEncoder) Encode(frame []byte) ([]byte, error) {
 	return v.backend.Encode(frame)
 }
 
+// EncodeDamaged is Encode with the regions that changed since the previous
+// frame passed to this encoder (see DirtyRectProvider). A nil damage means
+// unknown and converts the whole frame; backends without incremental
+// conversion ignore the hint.
+func (v *VideoEncoder) EncodeDamaged(frame []byte, damage []image.Rectangle) ([]byte, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.backend == nil {
+		return nil, errors.New("encoder not initialized")
+	}
+	if de, ok := v.backend.(optionalDamageEncoder); ok {
+		return de.EncodeDamaged(frame, damage)
+	}
+	return v.backend.Encode(frame)
+}
+
 func (v *VideoEncoder) SetCodec(codec Codec) error {
 	if !codec.valid() {
 		return fmt.Errorf("%w: %s", ErrInvalidCodec, codec)
@@ -213,7 +247,19 @@ func (v *VideoEncoder) SetFPS(fps int) error {
 func (v *VideoEncoder) SetDimensions(width, height int) error {
 	v.mu.Lock()
 	defer v.mu.Unlock()
-	return v.backend.SetDimensions(width, height)
+	if err := v.backend.SetDimensions(width, height); err != nil {
+		return err
+	}
+	v.width, v.height = width, height
+	return nil
+}
+
+// Dimensions returns the encode resolution, 0x0 before the first
+// SetDimensions.
+func (v *VideoEncoder) Dimensions() (width, height int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.width, v.height
 }
 
 func (v *VideoEncoder) SetPixelFormat(pf PixelFormat) {
@@ -267,6 +313,20 @@ func (v *VideoEncoder) ForceKeyframe() error {
 	return nil
 }
 
+// SetTextMode caps the quantizer for sharp text when enabled. Backends
+// without quantizer control ignore it.
+func (v *VideoEncoder) SetTextMode(enabled bool) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.backend == nil {
+		return errors.New("encoder not initialized")
+	}
+	if tm, ok := v.backend.(optionalTextModeEncoder); ok {
+		return tm.SetTextMode(enabled)
+	}
+	return nil
+}
+
 // IsPermanentlyStalled returns true if the encoder backend has detected an
 // unrecoverable stall (e.g., MFT flush recovery repeatedly failed).
 func (v *VideoEncoder) IsPermanentlyStalled() bool {
@@ -359,7 +419,7 @@ func (v *VideoEncoder) EncodeTexture(bgraTexture uintptr) ([]byte, error) {
 
 func (c Codec) valid() bool {
 	switch c {
-	case CodecH264, CodecVP9, CodecVP8, CodecAV1:
+	case CodecH264, CodecH265, CodecVP9, CodecVP8, CodecAV1:
 		return true
 	default:
 		return false
@@ -409,6 +469,27 @@ func validateConfig(cfg EncoderConfig) error {
 }
 
 func newBackend(cfg EncoderConfig) (encoderBackend, error) {
+	// VP9 and AV1 are software-only (libvpx / libaom loaded at runtime) and
+	// have no placeholder: an error here makes the session fall back to H264.
+	switch cfg.Codec {
+	case CodecVP9:
+		return newVP9Encoder(cfg)
+	case CodecAV1:
+		return newAV1Encoder(cfg)
+	case CodecH265:
+		// HEVC is hardware-only (MFT / VideoToolbox); without one the session
+		// falls back to H264.
+		if !cfg.PreferHardware {
+			return nil, errors.New("HEVC requires a hardware encoder")
+		}
+		backend := tryHardware(cfg)
+		if backend == nil {
+			return nil, errors.New("no hardware HEVC encoder available")
+		}
+		slog.Info("Selected hardware HEVC encoder",
+			"backend", backend.Name(), "gpuVendor", cfg.GPUVendor)
+		return backend, nil
+	}
 	if cfg.PreferHardware {
 		if backend := tryHardware(cfg); backend != nil {
 			slog.Info("Selected hardware H264 encoder",
@@ -0,0 +1,429 @@
+//go:build linux || darwin || windows
+
+package desktop
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+// libvpx (VP9) and libaom (AV1) share one encoder API shape — libaom forked
+// libvpx and kept vpx_codec_* as aom_codec_* — so both backends are the same
+// codecLibEncoder driven by a per-library codecLibAPI. The libraries are
+// loaded through purego at first use (no cgo), like OpenH264; a missing
+// library just means the codec is not offered and the session stays on H264.
+
+const (
+	codecLibOK          = 0
+	codecLibABIMismatch = 3
+
+	// codecLibImgFmtI420 is VPX_IMG_FMT_I420 / AOM_IMG_FMT_I420.
+	codecLibImgFmtI420 = 0x102
+	// codecLibForceKF is VPX_EFLAG_FORCE_KF / AOM_EFLAG_FORCE_KF.
+	codecLibForceKF = 1
+	// codecLibCxFramePkt is VPX_CODEC_CX_FRAME_PKT / AOM_CODEC_CX_FRAME_PKT.
+	codecLibCxFramePkt = 0
+
+	// rcCBR is VPX_CBR / AOM_CBR; kfAuto is VPX_KF_AUTO / AOM_KF_AUTO.
+	rcCBR  = 1
+	kfAuto = 1
+
+	// maxEncoderABIVersion bounds the *_ENCODER_ABI_VERSION probe below.
+	maxEncoderABIVersion = 64
+)
+
+// codecLibCtx and codecLibImage are opaque, over-sized stand-ins for
+// vpx_codec_ctx_t / vpx_image_t (and the aom equivalents); only the
+// library reads or writes them.
+type codecLibCtx [16]uintptr
+type codecLibImage [64]uintptr
+
+// codecLibConfig is a Go mirror of the library's *_codec_enc_cfg_t.
+type codecLibConfig interface {
+	pointer() unsafe.Pointer
+	apply(s codecLibSettings)
+}
+
+// codecLibSettings are the encoder parameters derived from EncoderConfig.
+type codecLibSettings struct {
+	width, height int
+	threads       int
+	fps           int
+	bitrateKbps   int
+}
+
+// codecLibControl is a codec control (vpx_codec_control / aom_codec_control)
+// applied after init.
+type codecLibControl struct {
+	name  string
+	id    int32
+	value int32
+}
+
+type codecLibAPI struct {
+	name     string // library name for logs and Name()
+	codec    Codec
+	iface    uintptr
+	abiVer   int32
+	usage    uint32
+	newCfg   func() codecLibConfig
+	controls []codecLibControl
+
+	configDefault func(iface uintptr, cfg unsafe.Pointer, usage uint32) int32
+	encInitVer    func(ctx unsafe.Pointer, iface uintptr, cfg unsafe.Pointer, flags int64, ver int32) int32
+	configSet     func(ctx unsafe.Pointer, cfg unsafe.Pointer) int32
+	encode        func(ctx unsafe.Pointer, img unsafe.Pointer, pts int64, duration uint64, flags int64) int32
+	getCxData     func(ctx unsafe.Pointer, iter *uintptr) unsafe.Pointer
+	control       func(ctx unsafe.Pointer, id int32, value int32) int32
+	imgWrap       func(img unsafe.Pointer, fmt int32, w, h, align uint32, data unsafe.Pointer) uintptr
+	destroy       func(ctx unsafe.Pointer) int32
+	errToString   func(err int32) string
+}
+
+// codecLibLoader loads a library once per process; a failed load is not
+// retried (the library will not appear mid-run).
+type codecLibLoader struct {
+	once sync.Once
+	api  *codecLibAPI
+	err  error
+	load func() (*codecLibAPI, error)
+}
+
+func (l *codecLibLoader) get() (*codecLibAPI, error) {
+	l.once.Do(func() {
+		l.api, l.err = l.load()
+		if l.err != nil {
+			slog.Info("Video codec library unavailable", "error", l.err.Error())
+		}
+	})
+	return l.api, l.err
+}
+
+// registerCodecLibFunc binds a C symbol to a Go function variable.
+func registerCodecLibFunc(fptr any, lib uintptr, name string) error {
+	sym, err := codecLibrarySymbol(lib, name)
+	if err != nil || sym == 0 {
+		return fmt.Errorf("symbol %s: %v", name, err)
+	}
+	purego.RegisterFunc(fptr, sym)
+	return nil
+}
+
+// codecLibVariadicSafe reports whether a variadic C function (the control
+// call) can be called through a fixed-argument binding. Apple arm64 passes
+// variadic arguments on the stack, so controls are skipped there and the
+// encoder runs with the config-level settings only.
+func codecLibVariadicSafe() bool {
+	return !(runtime.GOOS == "darwin" && runtime.GOARCH == "arm64")
+}
+
+// probeEncoderABIVersion finds the *_ENCODER_ABI_VERSION the library was
+// built with. It differs between releases and the check happens before
+// anything else in *_codec_enc_init_ver, so a wrong guess is harmless.
+func probeEncoderABIVersion(api *codecLibAPI) (int32, error) {
+	cfg := api.newCfg()
+	if res := api.configDefault(api.iface, cfg.pointer(), api.usage); res != codecLibOK {
+		return 0, fmt.Errorf("%s: default config: %s", api.name, api.errToString(res))
+	}
+	cfg.apply(codecLibSettings{width: 64, height: 64, threads: 1, fps: 30, bitrateKbps: 100})
+	for ver := int32(1); ver <= maxEncoderABIVersion; ver++ {
+		var ctx codecLibCtx
+		res := api.encInitVer(unsafe.Pointer(&ctx), api.iface, cfg.pointer(), 0, ver)
+		if res == codecLibABIMismatch {
+			continue
+		}
+		if res != codecLibOK {
+			return 0, fmt.Errorf("%s: encoder init: %s", api.name, api.errToString(res))
+		}
+		api.destroy(unsafe.Pointer(&ctx))
+		return ver, nil
+	}
+	return 0, fmt.Errorf("%s: no supported encoder ABI version", api.name)
+}
+
+// codecLibEncoder implements encoderBackend on top of libvpx or libaom.
+// Like OpenH264 it is lazily initialized on the first Encode() after
+// SetDimensions, and re-initialized when the dimensions change.
+type codecLibEncoder struct {
+	mu          sync.Mutex
+	api         *codecLibAPI
+	cfg         EncoderConfig
+	width       int
+	height      int
+	pixelFormat PixelFormat
+	encCfg      codecLibConfig
+	ctx         codecLibCtx
+	img         codecLibImage
+	yuv         yuvFrameCache // I420 of the previous frame, for damage-only conversion
+	inited      bool
+	forceKF     bool
+	pts         int64
+	pinner      runtime.Pinner
+}
+
+func newCodecLibEncoder(loader *codecLibLoader, cfg EncoderConfig) (encoderBackend, error) {
+	api, err := loader.get()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Codec != api.codec {
+		return nil, fmt.Errorf("%s only supports %s, got %s", api.name, api.codec, cfg.Codec)
+	}
+	return &codecLibEncoder{api: api, cfg: cfg, yuv: yuvFrameCache{layout: yuvI420}}, nil
+}
+
+func (e *codecLibEncoder) settings() codecLibSettings {
+	fps := e.cfg.FPS
+	if fps <= 0 {
+		fps = 30
+	}
+	bitrate := e.cfg.Bitrate
+	if bitrate <= 0 {
+		bitrate = 2_500_000
+	}
+	return codecLibSettings{
+		width:       e.width,
+		height:      e.height,
+		threads:     clampThreads(runtime.NumCPU()),
+		fps:         fps,
+		bitrateKbps: bitrate / 1000,
+	}
+}
+
+func (e *codecLibEncoder) initEncoder() error {
+	if e.width == 0 || e.height == 0 {
+		return fmt.Errorf("%s: call SetDimensions before Encode", e.api.name)
+	}
+	cfg := e.api.newCfg()
+	if res := e.api.configDefault(e.api.iface, cfg.pointer(), e.api.usage); res != codecLibOK {
+		return fmt.Errorf("%s: default config: %s", e.api.name, e.api.errToString(res))
+	}
+	s := e.settings()
+	cfg.apply(s)
+
+	e.ctx = codecLibCtx{}
+	if res := e.api.encInitVer(unsafe.Pointer(&e.ctx), e.api.iface, cfg.pointer(), 0, e.api.abiVer); res != codecLibOK {
+		return fmt.Errorf("%s: encoder init: %s", e.api.name, e.api.errToString(res))
+	}
+	if codecLibVariadicSafe() {
+		for _, c := range e.api.controls {
+			if res := e.api.control(unsafe.Pointer(&e.ctx), c.id, c.value); res != codecLibOK {
+				slog.Warn("Video encoder control rejected", "backend", e.api.name, "control", c.name, "error", e.api.errToString(res))
+			}
+		}
+	}
+	e.encCfg = cfg
+	e.inited = true
+	e.forceKF = true
+	e.pts = 0
+
+	slog.Info("Video encoder initialized",
+		"backend", e.api.name,
+		"codec", string(e.api.codec),
+		"width", e.width,
+		"height", e.height,
+		"bitrateKbps", s.bitrateKbps,
+		"fps", s.fps,
+		"threads", s.threads,
+	)
+	return nil
+}
+
+func (e *codecLibEncoder) destroyLocked() {
+	if !e.inited {
+		return
+	}
+	e.pinner.Unpin()
+	e.api.destroy(unsafe.Pointer(&e.ctx))
+	e.inited = false
+	e.encCfg = nil
+}
+
+func (e *codecLibEncoder) Encode(frame []byte) ([]byte, error) {
+	return e.EncodeDamaged(frame, nil)
+}
+
+// EncodeDamaged implements optionalDamageEncoder.
+func (e *codecLibEncoder) EncodeDamaged(frame []byte, damage []image.Rectangle) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(frame) == 0 {
+		return nil, errors.New("empty frame")
+	}
+	if e.width == 0 || e.height == 0 {
+		return nil, fmt.Errorf("%s: call SetDimensions before Encode", e.api.name)
+	}
+	var err error
+	frame, err = FitRGBAFrame(frame, e.width, e.height)
+	if err != nil {
+		e.yuv.reset()
+		return nil, err
+	}
+	if !e.inited {
+		if err := e.initEncoder(); err != nil {
+			e.yuv.reset()
+			return nil, err
+		}
+	}
+
+	i420 := e.yuv.convert(frame, e.width, e.height, e.width*4, e.pixelFormat, damage)
+
+	e.pinner.Unpin()
+	e.pinner.Pin(&i420[0])
+	// Alignment 1 lays the planes out back to back — exactly the I420
+	// buffer's [Y][U][V] layout, with strides w and w/2.
+	if e.api.imgWrap(unsafe.Pointer(&e.img), codecLibImgFmtI420, uint32(e.width), uint32(e.height), 1, unsafe.Pointer(&i420[0])) == 0 {
+		return nil, fmt.Errorf("%s: image wrap failed", e.api.name)
+	}
+
+	fps := e.cfg.FPS
+	if fps <= 0 {
+		fps = 30
+	}
+	duration := int64(1000 / fps)
+	var flags int64
+	if e.forceKF {
+		flags = codecLibForceKF
+		e.forceKF = false
+	}
+	res := e.api.encode(unsafe.Pointer(&e.ctx), unsafe.Pointer(&e.img), e.pts, uint64(duration), flags)
+	e.pts += duration
+	if res != codecLibOK {
+		return nil, fmt.Errorf("%s: encode: %s", e.api.name, e.api.errToString(res))
+	}
+
+	var out []byte
+	var iter uintptr
+	for {
+		pkt := e.api.getCxData(unsafe.Pointer(&e.ctx), &iter)
+		if pkt == nil {
+			break
+		}
+		if kind, buf, size := codecLibPacket(pkt); kind == codecLibCxFramePkt && buf != nil && size > 0 {
+			out = append(out, unsafe.Slice((*byte)(buf), size)...)
+		}
+	}
+	// Rate control dropped the frame.
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// codecLibPacket reads kind and the frame buffer from a *_codec_cx_pkt_t:
+// an enum followed by a union whose frame member starts with buf and sz.
+func codecLibPacket(p unsafe.Pointer) (kind int32, buf unsafe.Pointer, size int) {
+	kind = *(*int32)(p)
+	buf = *(*unsafe.Pointer)(unsafe.Add(p, unsafe.Sizeof(uintptr(0))))
+	size = int(*(*uintptr)(unsafe.Add(p, 2*unsafe.Sizeof(uintptr(0)))))
+	return kind, buf, size
+}
+
+func (e *codecLibEncoder) ForceKeyframe() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.forceKF = true
+	return nil
+}
+
+func (e *codecLibEncoder) Flush() error {
+	// No lookahead (lag 0), so nothing is buffered — flush is a keyframe request.
+	return e.ForceKeyframe()
+}
+
+func (e *codecLibEncoder) reconfigureLocked() {
+	if !e.inited || e.encCfg == nil {
+		return
+	}
+	e.encCfg.apply(e.settings())
+	if res := e.api.configSet(unsafe.Pointer(&e.ctx), e.encCfg.pointer()); res != codecLibOK {
+		slog.Warn("Video encoder reconfigure failed", "backend", e.api.name, "error", e.api.errToString(res))
+	}
+}
+
+func (e *codecLibEncoder) SetBitrate(bitrate int) error {
+	if bitrate <= 0 {
+		return ErrInvalidBitrate
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cfg.Bitrate = bitrate
+	e.reconfigureLocked()
+	return nil
+}
+
+func (e *codecLibEncoder) SetFPS(fps int) error {
+	if fps <= 0 {
+		return ErrInvalidFPS
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cfg.FPS = fps
+	e.reconfigureLocked()
+	return nil
+}
+
+func (e *codecLibEncoder) SetDimensions(width, height int) error {
+	// 4:2:0 chroma subsampling needs even dimensions.
+	width = width &^ 1
+	height = height &^ 1
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.width == width && e.height == height {
+		return nil
+	}
+	e.destroyLocked()
+	e.width = width
+	e.height = height
+	// Initialize eagerly so a library that rejects the configuration fails
+	// at session setup, where the caller can still fall back to H264.
+	return e.initEncoder()
+}
+
+func (e *codecLibEncoder) SetCodec(codec Codec) error {
+	if codec != e.api.codec {
+		return fmt.Errorf("%w: %s only supports %s, got %s", ErrInvalidCodec, e.api.name, e.api.codec, codec)
+	}
+	return nil
+}
+
+func (e *codecLibEncoder) SetQuality(quality QualityPreset) error {
+	e.mu.Lock()
+	e.cfg.Quality = quality
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *codecLibEncoder) SetPixelFormat(pf PixelFormat) {
+	e.mu.Lock()
+	e.pixelFormat = pf
+	e.mu.Unlock()
+}
+
+func (e *codecLibEncoder) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.inited {
+		e.destroyLocked()
+		slog.Info("Video encoder shut down", "backend", e.api.name)
+	}
+	e.yuv.reset()
+	return nil
+}
+
+func (e *codecLibEncoder) Name() string                { return e.api.name }
+func (e *codecLibEncoder) IsHardware() bool            { return false }
+func (e *codecLibEncoder) IsPlaceholder() bool         { return false }
+func (e *codecLibEncoder) SetD3D11Device(_, _ uintptr) {}
+func (e *codecLibEncoder) SupportsGPUInput() bool      { return false }
+func (e *codecLibEncoder) EncodeTexture(_ uintptr) ([]byte, error) {
+	return nil, fmt.Errorf("GPU input not supported by %s encoder", e.api.name)
+}
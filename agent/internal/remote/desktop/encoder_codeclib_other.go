@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !windows
+
+package desktop
+
+import "errors"
+
+var errCodecLibUnsupported = errors.New("VP9/AV1 encoding is not supported on this platform")
+
+func newVP9Encoder(cfg EncoderConfig) (encoderBackend, error) {
+	return nil, errCodecLibUnsupported
+}
+
+func newAV1Encoder(cfg EncoderConfig) (encoderBackend, error) {
+	return nil, errCodecLibUnsupported
+}
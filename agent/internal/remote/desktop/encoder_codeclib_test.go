@@ -0,0 +1,51 @@
+//go:build linux || darwin || windows
+
+package desktop
+
+import "testing"
+
+// TestAV1EncoderProducesKeyframe runs a real libaom encode when the library
+// is installed: the first frame must be a keyframe the capture loop
+// recognizes, and later frames must not be.
+func TestAV1EncoderProducesKeyframe(t *testing.T) {
+	if _, err := libaomLoader.get(); err != nil {
+		t.Skipf("libaom not available: %v", err)
+	}
+	enc, err := NewVideoEncoder(EncoderConfig{Codec: CodecAV1, Bitrate: 1_000_000, FPS: 30})
+	if err != nil {
+		t.Fatalf("NewVideoEncoder: %v", err)
+	}
+	defer enc.Close()
+	const w, h = 320, 240
+	if err := enc.SetDimensions(w, h); err != nil {
+		t.Fatalf("SetDimensions: %v", err)
+	}
+
+	frame := make([]byte, w*h*4)
+	for i := range frame {
+		frame[i] = byte(i)
+	}
+	first, err := enc.Encode(frame)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if !containsKeyframe(CodecAV1, first) {
+		t.Fatalf("first frame (%d bytes) is not a keyframe", len(first))
+	}
+	second, err := enc.Encode(frame)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if second != nil && containsKeyframe(CodecAV1, second) {
+		t.Errorf("second frame unexpectedly a keyframe")
+	}
+}
+
+func TestCodecLibEncoderRejectsOtherCodecs(t *testing.T) {
+	if _, err := libaomLoader.get(); err != nil {
+		t.Skipf("libaom not available: %v", err)
+	}
+	if _, err := newAV1Encoder(EncoderConfig{Codec: CodecVP9}); err == nil {
+		t.Fatal("expected libaom to reject VP9")
+	}
+}
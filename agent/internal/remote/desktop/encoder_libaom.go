@@ -0,0 +1,149 @@
+//go:build linux || darwin || windows
+
+package desktop
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// AV1 via libaom's realtime mode with screen-content tools (palette,
+// intra block copy), which is where AV1 gains most over H264 on text.
+
+var libaomNames = []string{"libaom.so.3", "libaom.3.dylib", "libaom.dylib", "aom.dll", "libaom.dll"}
+
+const (
+	aomUsageRealtime = 1
+
+	aomeSetCPUUsed     = 13
+	av1eSetTuneContent = 43
+	aomContentScreen   = 1
+	// aomRealtimeSpeed is cpu-used 9, the fastest preset every libaom 3.x
+	// accepts in realtime mode.
+	aomRealtimeSpeed = 9
+)
+
+// aomEncCfg mirrors the leading fields of aom_codec_enc_cfg_t (libaom 3.x);
+// the tail covers the rest of the struct, which is left at the library
+// defaults.
+type aomEncCfg struct {
+	GUsage                  uint32
+	GThreads                uint32
+	GProfile                uint32
+	GW                      uint32
+	GH                      uint32
+	GLimit                  uint32
+	GForcedMaxFrameWidth    uint32
+	GForcedMaxFrameHeight   uint32
+	GBitDepth               int32
+	GInputBitDepth          uint32
+	GTimebaseNum            int32
+	GTimebaseDen            int32
+	GErrorResilient         uint32
+	GPass                   int32
+	GLagInFrames            uint32
+	RcDropframeThresh       uint32
+	RcResizeMode            uint32
+	RcResizeDenominator     uint32
+	RcResizeKfDenominator   uint32
+	RcSuperresMode          uint32
+	RcSuperresDenominator   uint32
+	RcSuperresKfDenominator uint32
+	RcSuperresQthresh       uint32
+	RcSuperresKfQthresh     uint32
+	RcEndUsage              int32
+	RcTwopassStatsIn        [2]uintptr
+	RcFirstpassMbStatsIn    [2]uintptr
+	RcTargetBitrate         uint32
+	RcMinQuantizer          uint32
+	RcMaxQuantizer          uint32
+	RcUndershootPct         uint32
+	RcOvershootPct          uint32
+	RcBufSz                 uint32
+	RcBufInitialSz          uint32
+	RcBufOptimalSz          uint32
+	Rc2passVbrBiasPct       uint32
+	Rc2passVbrMinsectionPct uint32
+	Rc2passVbrMaxsectionPct uint32
+	FwdKfEnabled            uint32
+	KfMode                  int32
+	KfMinDist               uint32
+	KfMaxDist               uint32
+	_                       [4096]byte
+}
+
+func (c *aomEncCfg) pointer() unsafe.Pointer { return unsafe.Pointer(c) }
+
+func (c *aomEncCfg) apply(s codecLibSettings) {
+	c.GW, c.GH = uint32(s.width), uint32(s.height)
+	c.GThreads = uint32(s.threads)
+	c.GTimebaseNum, c.GTimebaseDen = 1, 1000
+	c.GPass = 0
+	c.GLagInFrames = 0
+	c.GErrorResilient = 0
+	c.RcDropframeThresh = 0
+	c.RcEndUsage = rcCBR
+	c.RcTargetBitrate = uint32(s.bitrateKbps)
+	c.RcMinQuantizer = 10
+	c.RcMaxQuantizer = 56
+	c.RcUndershootPct = 50
+	c.RcOvershootPct = 50
+	c.RcBufSz = 1000
+	c.RcBufInitialSz = 600
+	c.RcBufOptimalSz = 600
+	c.KfMode = kfAuto
+	c.KfMinDist = 0
+	c.KfMaxDist = uint32(keyframeIntervalFrames(s.fps))
+}
+
+var libaomLoader = codecLibLoader{load: loadLibaom}
+
+func loadLibaom() (*codecLibAPI, error) {
+	lib, path, err := openCodecLibrary(libaomNames)
+	if err != nil {
+		return nil, fmt.Errorf("libaom: %w", err)
+	}
+	api := &codecLibAPI{
+		name:   "libaom",
+		codec:  CodecAV1,
+		usage:  aomUsageRealtime,
+		newCfg: func() codecLibConfig { return &aomEncCfg{} },
+		controls: []codecLibControl{
+			{name: "cpu-used", id: aomeSetCPUUsed, value: aomRealtimeSpeed},
+			{name: "tune-content", id: av1eSetTuneContent, value: aomContentScreen},
+		},
+	}
+	var ifaceFn func() uintptr
+	bindings := []struct {
+		fptr any
+		name string
+	}{
+		{&ifaceFn, "aom_codec_av1_cx"},
+		{&api.configDefault, "aom_codec_enc_config_default"},
+		{&api.encInitVer, "aom_codec_enc_init_ver"},
+		{&api.configSet, "aom_codec_enc_config_set"},
+		{&api.encode, "aom_codec_encode"},
+		{&api.getCxData, "aom_codec_get_cx_data"},
+		{&api.control, "aom_codec_control"},
+		{&api.imgWrap, "aom_img_wrap"},
+		{&api.destroy, "aom_codec_destroy"},
+		{&api.errToString, "aom_codec_err_to_string"},
+	}
+	for _, b := range bindings {
+		if err := registerCodecLibFunc(b.fptr, lib, b.name); err != nil {
+			return nil, fmt.Errorf("libaom %s: %w", path, err)
+		}
+	}
+	api.iface = ifaceFn()
+	if api.iface == 0 {
+		return nil, fmt.Errorf("libaom %s: no AV1 encoder interface", path)
+	}
+	if api.abiVer, err = probeEncoderABIVersion(api); err != nil {
+		return nil, err
+	}
+	return api, nil
+}
+
+func newAV1Encoder(cfg EncoderConfig) (encoderBackend, error) {
+	return newCodecLibEncoder(&libaomLoader, cfg)
+}
@@ -0,0 +1,544 @@
+//go:build linux && (amd64 || arm64)
+
+package desktop
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+// =============================================================================
+// NVENC Encoder (Linux) — NVIDIA GPU encoding via libnvidia-encode.so.1
+//
+// The Linux capturers (X11, PipeWire portal) produce CPU frames, so unlike the
+// Windows backend there is no texture to register: the session is opened on a
+// CUDA context and each BGRX frame is copied into an NVENC-allocated input
+// buffer as ARGB. The GPU does the colour conversion, so the CPU cost is one
+// memcpy per frame instead of a full software encode.
+//
+// Registered as a vendor-specific hardware factory for "nvidia". Both driver
+// libraries ship with the proprietary NVIDIA driver; without them the factory
+// fails and the encoder falls through to VA-API → OpenH264.
+// =============================================================================
+
+var (
+	nvencLinuxOnce sync.Once
+	nvencLinuxAPI  *nvencLinuxLibs
+	nvencLinuxErr  error
+)
+
+// nvencLinuxLibs holds the CUDA driver API entry points needed to create a
+// context, plus NvEncodeAPICreateInstance.
+type nvencLinuxLibs struct {
+	cuInit          func(flags uint32) int32
+	cuDeviceGet     func(dev *int32, ordinal int32) int32
+	cuCtxCreate     func(ctx *uintptr, flags uint32, dev int32) int32
+	cuCtxPopCurrent func(ctx *uintptr) int32
+	cuCtxDestroy    func(ctx uintptr) int32
+	createInstance  func(funcs *nvencFuncList) uint32
+}
+
+func loadNVENCLinux() (*nvencLinuxLibs, error) {
+	nvencLinuxOnce.Do(func() {
+		nvencLinuxAPI, nvencLinuxErr = openNVENCLinux()
+		if nvencLinuxErr != nil {
+			slog.Debug("NVENC not available", "error", nvencLinuxErr.Error())
+			return
+		}
+		slog.Info("NVENC library loaded (libnvidia-encode.so.1)")
+	})
+	return nvencLinuxAPI, nvencLinuxErr
+}
+
+func openNVENCLinux() (*nvencLinuxLibs, error) {
+	cuda, err := purego.Dlopen("libcuda.so.1", purego.RTLD_NOW|purego.RTLD_GLOBAL)
+	if err != nil {
+		return nil, fmt.Errorf("load libcuda.so.1: %w", err)
+	}
+	nvenc, err := purego.Dlopen("libnvidia-encode.so.1", purego.RTLD_NOW|purego.RTLD_LOCAL)
+	if err != nil {
+		return nil, fmt.Errorf("load libnvidia-encode.so.1: %w", err)
+	}
+	libs := &nvencLinuxLibs{}
+	for _, b := range []struct {
+		fptr any
+		lib  uintptr
+		name string
+	}{
+		{&libs.cuInit, cuda, "cuInit"},
+		{&libs.cuDeviceGet, cuda, "cuDeviceGet"},
+		{&libs.cuCtxCreate, cuda, "cuCtxCreate_v2"},
+		{&libs.cuCtxPopCurrent, cuda, "cuCtxPopCurrent_v2"},
+		{&libs.cuCtxDestroy, cuda, "cuCtxDestroy_v2"},
+		{&libs.createInstance, nvenc, "NvEncodeAPICreateInstance"},
+	} {
+		if err := registerCodecLibFunc(b.fptr, b.lib, b.name); err != nil {
+			return nil, err
+		}
+	}
+	if r := libs.cuInit(0); r != 0 {
+		return nil, fmt.Errorf("cuInit failed: %d", r)
+	}
+	var dev int32
+	if r := libs.cuDeviceGet(&dev, 0); r != 0 {
+		return nil, fmt.Errorf("cuDeviceGet failed: %d", r)
+	}
+	return libs, nil
+}
+
+func init() {
+	registerHardwareFactoryForVendor("nvidia", newNVENCEncoder)
+}
+
+func newNVENCEncoder(cfg EncoderConfig) (encoderBackend, error) {
+	if cfg.Codec != CodecH264 {
+		return nil, fmt.Errorf("nvenc: only H264 supported, got %s", cfg.Codec)
+	}
+	libs, err := loadNVENCLinux()
+	if err != nil {
+		return nil, err
+	}
+	return &nvencEncoder{cfg: cfg, libs: libs}, nil
+}
+
+// nvencEncoder implements encoderBackend using NVIDIA's NVENC hardware encoder
+// with host-memory input.
+type nvencEncoder struct {
+	mu          sync.Mutex
+	cfg         EncoderConfig
+	libs        *nvencLinuxLibs
+	width       int
+	height      int
+	pixelFormat PixelFormat
+	forceIDR    bool
+	frameIdx    uint64
+
+	// NVENC API state
+	funcs   nvencFuncList
+	encoder uintptr // NV_ENC_ENCODE_SESSION handle
+	cuCtx   uintptr // CUcontext the session is opened on
+
+	// Config kept alive for encoder lifetime (passed by pointer during init)
+	config nvencConfig
+
+	inputBuf     uintptr // NV_ENC_INPUT_PTR from CreateInputBuffer
+	inputFmt     uint32  // buffer format inputBuf was created with
+	bitstreamBuf uintptr // output bitstream buffer handle
+
+	inited bool
+}
+
+// --- encoderBackend interface ---
+
+func (e *nvencEncoder) Name() string {
+	if e.inited {
+		return "nvenc-hardware"
+	}
+	return "nvenc"
+}
+
+func (e *nvencEncoder) IsHardware() bool    { return true }
+func (e *nvencEncoder) IsPlaceholder() bool { return false }
+
+func (e *nvencEncoder) SetCodec(c Codec) error {
+	if c != CodecH264 {
+		return fmt.Errorf("%w: nvenc only supports H264, got %s", ErrInvalidCodec, c)
+	}
+	return nil
+}
+
+func (e *nvencEncoder) SetQuality(_ QualityPreset) error { return nil }
+
+func (e *nvencEncoder) SetPixelFormat(pf PixelFormat) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pixelFormat = pf
+}
+
+func (e *nvencEncoder) SetBitrate(bitrate int) error {
+	if bitrate <= 0 {
+		return ErrInvalidBitrate
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cfg.Bitrate = bitrate
+	// TODO: dynamic reconfigure via NvEncReconfigureEncoder (as on Windows)
+	return nil
+}
+
+func (e *nvencEncoder) SetFPS(fps int) error {
+	if fps <= 0 {
+		return ErrInvalidFPS
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cfg.FPS = fps
+	return nil
+}
+
+func (e *nvencEncoder) SetDimensions(w, h int) error {
+	w = w &^ 1 // H264 requires even dimensions
+	h = h &^ 1
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.inited && (e.width != w || e.height != h) {
+		e.shutdown()
+	}
+	e.width = w
+	e.height = h
+	return nil
+}
+
+func (e *nvencEncoder) SetD3D11Device(_, _ uintptr) {}
+func (e *nvencEncoder) SupportsGPUInput() bool      { return false }
+func (e *nvencEncoder) EncodeTexture(_ uintptr) ([]byte, error) {
+	return nil, errors.New("GPU input not supported by Linux nvenc encoder")
+}
+
+func (e *nvencEncoder) ForceKeyframe() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.forceIDR = true
+	return nil
+}
+
+func (e *nvencEncoder) Flush() error {
+	return e.ForceKeyframe()
+}
+
+func (e *nvencEncoder) Encode(frame []byte) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(frame) == 0 {
+		return nil, errors.New("empty frame")
+	}
+	if e.width == 0 || e.height == 0 {
+		return nil, fmt.Errorf("nvenc: call SetDimensions before Encode")
+	}
+	var err error
+	frame, err = FitRGBAFrame(frame, e.width, e.height)
+	if err != nil {
+		return nil, fmt.Errorf("nvenc: %w", err)
+	}
+
+	// The input buffer's format is fixed at creation; a pixel format change
+	// after init needs a fresh session.
+	if e.inited && e.inputFmt != e.bufferFormat() {
+		e.shutdown()
+	}
+	if !e.inited {
+		if err := e.initialize(); err != nil {
+			return nil, fmt.Errorf("nvenc init: %w", err)
+		}
+	}
+
+	if err := e.uploadFrame(frame); err != nil {
+		return nil, err
+	}
+	return e.encodeFrame()
+}
+
+func (e *nvencEncoder) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.shutdown()
+	return nil
+}
+
+// bufferFormat maps the capture byte order to the NVENC input format. NVENC's
+// ARGB is word-ordered, i.e. B,G,R,A in memory — exactly the BGRX the Linux
+// capturers produce; ABGR is R,G,B,A in memory.
+func (e *nvencEncoder) bufferFormat() uint32 {
+	if e.pixelFormat == PixelFormatBGRA {
+		return nvencBufFmtARGB
+	}
+	return nvencBufFmtABGR
+}
+
+// =============================================================================
+// Initialization
+// =============================================================================
+
+func (e *nvencEncoder) initialize() error {
+	// Step 1: CUDA context on the first GPU. cuCtxCreate makes it current on
+	// this OS thread; pop it straight away — NVENC pushes the context itself
+	// for every call, and the capture goroutine is not thread-locked.
+	var dev int32
+	if r := e.libs.cuDeviceGet(&dev, 0); r != 0 {
+		return fmt.Errorf("cuDeviceGet failed: %d", r)
+	}
+	runtime.LockOSThread()
+	r := e.libs.cuCtxCreate(&e.cuCtx, 0, dev)
+	if r == 0 {
+		var popped uintptr
+		e.libs.cuCtxPopCurrent(&popped)
+	}
+	runtime.UnlockOSThread()
+	if r != 0 {
+		e.cuCtx = 0
+		return fmt.Errorf("cuCtxCreate failed: %d", r)
+	}
+
+	// Step 2: Get the NVENC function pointer table
+	e.funcs = nvencFuncList{}
+	e.funcs.Version = nvencStructVer(2)
+	if st := e.libs.createInstance(&e.funcs); st != nvencSuccess {
+		e.destroyContext()
+		return fmt.Errorf("NvEncodeAPICreateInstance failed: %s (0x%X)", nvencStatusStr(uintptr(st)), st)
+	}
+
+	// Step 3: Open encode session on the CUDA context
+	var sessionParams nvencOpenSessionParams
+	sessionParams.Version = nvencStructVer(1)
+	sessionParams.DeviceType = nvencDeviceTypeCUDA
+	sessionParams.Device = e.cuCtx
+	sessionParams.APIVersion = nvencAPIVersion
+	if st, _, _ := purego.SyscallN(
+		e.funcs.OpenEncodeSessionEx,
+		uintptr(unsafe.Pointer(&sessionParams)),
+		uintptr(unsafe.Pointer(&e.encoder)),
+	); st != nvencSuccess {
+		e.encoder = 0
+		e.destroyContext()
+		return fmt.Errorf("NvEncOpenEncodeSessionEx failed: %s (0x%X)", nvencStatusStr(st), st)
+	}
+
+	// Step 4: Preset config defaults for ultra-low-latency, customized as on
+	// Windows (CBR, IP-only, repeated SPS/PPS).
+	var presetCfg nvencPresetConfig
+	*(*uint32)(unsafe.Pointer(&presetCfg[0])) = nvencStructVerExt(5)            // preset config version
+	*(*uint32)(unsafe.Pointer(&presetCfg[8])) = nvencStructVerExt(9)            // embedded NV_ENC_CONFIG version
+	*(*uint32)(unsafe.Pointer(&presetCfg[8+ncfgRCVersion])) = nvencStructVer(1) // embedded RC_PARAMS version
+	if st, _, _ := purego.SyscallN(
+		e.funcs.GetPresetConfigEx,
+		e.encoder,
+		uintptr(unsafe.Pointer(&nvencCodecH264GUID)),
+		uintptr(unsafe.Pointer(&nvencPresetP4GUID)),
+		uintptr(nvencTuningUltraLowLat),
+		uintptr(unsafe.Pointer(&presetCfg)),
+	); st != nvencSuccess {
+		e.destroySession()
+		return fmt.Errorf("NvEncGetEncodePresetConfigEx failed: %s (0x%X)", nvencStatusStr(st), st)
+	}
+	copy(e.config[:], presetCfg[8:8+3584])
+
+	fps := e.cfg.FPS
+	if fps <= 0 {
+		fps = 30
+	}
+	bitrate := e.cfg.Bitrate
+	if bitrate <= 0 {
+		bitrate = 2_500_000
+	}
+
+	ncfgPutGUID(&e.config, ncfgProfileGUID, nvencProfileAutoGUID)
+
+	// GOP: IDR every 10 seconds, no B-frames
+	idrPeriod := uint32(fps * 10)
+	if idrPeriod < 30 {
+		idrPeriod = 30
+	}
+	ncfgPutU32(&e.config, ncfgGOPLength, idrPeriod)
+	ncfgPutU32(&e.config, ncfgFrameIntervalP, 1)
+
+	ncfgPutU32(&e.config, ncfgRCMode, nvencRCCBR)
+	ncfgPutU32(&e.config, ncfgRCAvgBR, uint32(bitrate))
+	ncfgPutU32(&e.config, ncfgRCMaxBR, uint32(bitrate))
+	ncfgPutU32(&e.config, ncfgRCVBVBuf, uint32(bitrate/fps))
+	ncfgPutU32(&e.config, ncfgRCVBVInit, uint32(bitrate/fps))
+
+	ncfgPutU32(&e.config, ncfgH264IDRPeriod, idrPeriod)
+	ncfgPutU32(&e.config, ncfgH264Bitfields, ncfgGetU32(&e.config, ncfgH264Bitfields)|ncfgH264RepeatSPSPPS)
+
+	// Step 5: Initialize the encoder
+	var initParams nvencInitParams
+	initParams.Version = nvencStructVerExt(7)
+	initParams.EncodeGUID = nvencCodecH264GUID
+	initParams.PresetGUID = nvencPresetP4GUID
+	initParams.EncodeWidth = uint32(e.width)
+	initParams.EncodeHeight = uint32(e.height)
+	initParams.DarWidth = uint32(e.width)
+	initParams.DarHeight = uint32(e.height)
+	initParams.FrameRateNum = uint32(fps)
+	initParams.FrameRateDen = 1
+	initParams.EnablePTD = 1
+	initParams.EncodeConfig = uintptr(unsafe.Pointer(&e.config))
+	initParams.TuningInfo = nvencTuningUltraLowLat
+	st, _, _ := purego.SyscallN(
+		e.funcs.InitializeEncoder,
+		e.encoder,
+		uintptr(unsafe.Pointer(&initParams)),
+	)
+	runtime.KeepAlive(e.config)
+	if st != nvencSuccess {
+		e.destroySession()
+		return fmt.Errorf("NvEncInitializeEncoder failed: %s (0x%X)", nvencStatusStr(st), st)
+	}
+
+	// Step 6: Host input buffer in the capture byte order
+	var createIn nvencCreateInputBuffer
+	createIn.Version = nvencStructVer(1)
+	createIn.Width = uint32(e.width)
+	createIn.Height = uint32(e.height)
+	createIn.BufferFmt = e.bufferFormat()
+	if st, _, _ := purego.SyscallN(
+		e.funcs.CreateInputBuffer,
+		e.encoder,
+		uintptr(unsafe.Pointer(&createIn)),
+	); st != nvencSuccess {
+		e.destroySession()
+		return fmt.Errorf("NvEncCreateInputBuffer failed: %s (0x%X)", nvencStatusStr(st), st)
+	}
+	e.inputBuf = createIn.InputBuffer
+	e.inputFmt = createIn.BufferFmt
+
+	// Step 7: Output bitstream buffer
+	var createBuf nvencCreateBitstreamBuffer
+	createBuf.Version = nvencStructVer(1)
+	if st, _, _ := purego.SyscallN(
+		e.funcs.CreateBitstreamBuffer,
+		e.encoder,
+		uintptr(unsafe.Pointer(&createBuf)),
+	); st != nvencSuccess {
+		e.destroySession()
+		return fmt.Errorf("NvEncCreateBitstreamBuffer failed: %s (0x%X)", nvencStatusStr(st), st)
+	}
+	e.bitstreamBuf = createBuf.Buffer
+
+	e.inited = true
+	e.frameIdx = 0
+	slog.Info("NVENC encoder initialized",
+		"width", e.width, "height", e.height,
+		"bitrate", bitrate, "fps", fps,
+		"preset", "P4", "tuning", "ultra-low-latency",
+		"input", "cuda-host",
+	)
+	return nil
+}
+
+// =============================================================================
+// Per-frame encoding
+// =============================================================================
+
+// uploadFrame copies one capture frame into the locked input buffer, row by
+// row since NVENC may pad the pitch.
+func (e *nvencEncoder) uploadFrame(frame []byte) error {
+	var lock nvencLockInputBuffer
+	lock.Version = nvencStructVer(1)
+	lock.InputBuffer = e.inputBuf
+	if st, _, _ := purego.SyscallN(
+		e.funcs.LockInputBuffer,
+		e.encoder,
+		uintptr(unsafe.Pointer(&lock)),
+	); st != nvencSuccess {
+		return fmt.Errorf("NvEncLockInputBuffer failed: %s (0x%X)", nvencStatusStr(st), st)
+	}
+	defer purego.SyscallN(e.funcs.UnlockInputBuffer, e.encoder, e.inputBuf)
+
+	rowBytes := e.width * 4
+	pitch := int(lock.Pitch)
+	data := *(*unsafe.Pointer)(unsafe.Pointer(&lock.BufferDataPtr))
+	if data == nil || pitch < rowBytes {
+		return fmt.Errorf("nvenc: bad input buffer lock (pitch %d)", pitch)
+	}
+	dst := unsafe.Slice((*byte)(data), pitch*e.height)
+	for y := 0; y < e.height; y++ {
+		copy(dst[y*pitch:y*pitch+rowBytes], frame[y*rowBytes:(y+1)*rowBytes])
+	}
+	return nil
+}
+
+func (e *nvencEncoder) encodeFrame() ([]byte, error) {
+	var picParams nvencPicParams
+	picParams.Version = nvencStructVerExt(7)
+	picParams.InputWidth = uint32(e.width)
+	picParams.InputHeight = uint32(e.height)
+	picParams.InputPitch = uint32(e.width)
+	picParams.InputBuffer = e.inputBuf
+	picParams.OutputBitstream = e.bitstreamBuf
+	picParams.BufferFmt = e.inputFmt
+	picParams.PictureStruct = nvencPicStructFrame
+	picParams.FrameIdx = uint32(e.frameIdx)
+
+	if e.forceIDR || e.frameIdx == 0 {
+		picParams.EncodePicFlags = nvencPicFlagForceIDR | nvencPicFlagSPSPPS
+		e.forceIDR = false
+	}
+	e.frameIdx++
+
+	if st, _, _ := purego.SyscallN(
+		e.funcs.EncodePicture,
+		e.encoder,
+		uintptr(unsafe.Pointer(&picParams)),
+	); st != nvencSuccess {
+		return nil, fmt.Errorf("NvEncEncodePicture failed: %s (0x%X)", nvencStatusStr(st), st)
+	}
+
+	var lockBS nvencLockBitstream
+	lockBS.Version = nvencStructVerExt(2)
+	lockBS.OutputBitstream = e.bitstreamBuf
+	if st, _, _ := purego.SyscallN(
+		e.funcs.LockBitstream,
+		e.encoder,
+		uintptr(unsafe.Pointer(&lockBS)),
+	); st != nvencSuccess {
+		return nil, fmt.Errorf("NvEncLockBitstream failed: %s (0x%X)", nvencStatusStr(st), st)
+	}
+
+	var out []byte
+	if data := *(*unsafe.Pointer)(unsafe.Pointer(&lockBS.DataPtr)); lockBS.BitstreamSize > 0 && data != nil {
+		out = make([]byte, lockBS.BitstreamSize)
+		copy(out, unsafe.Slice((*byte)(data), lockBS.BitstreamSize))
+	}
+
+	purego.SyscallN(e.funcs.UnlockBitstream, e.encoder, e.bitstreamBuf)
+
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// =============================================================================
+// Shutdown
+// =============================================================================
+
+// destroySession tears down a partially initialized session.
+func (e *nvencEncoder) destroySession() {
+	if e.encoder != 0 {
+		purego.SyscallN(e.funcs.DestroyEncoder, e.encoder)
+		e.encoder = 0
+	}
+	e.destroyContext()
+}
+
+func (e *nvencEncoder) destroyContext() {
+	if e.cuCtx != 0 {
+		e.libs.cuCtxDestroy(e.cuCtx)
+		e.cuCtx = 0
+	}
+}
+
+func (e *nvencEncoder) shutdown() {
+	if !e.inited {
+		return
+	}
+	if e.inputBuf != 0 {
+		purego.SyscallN(e.funcs.DestroyInputBuffer, e.encoder, e.inputBuf)
+		e.inputBuf = 0
+	}
+	if e.bitstreamBuf != 0 {
+		purego.SyscallN(e.funcs.DestroyBitstreamBuffer, e.encoder, e.bitstreamBuf)
+		e.bitstreamBuf = 0
+	}
+	e.destroySession()
+	e.inited = false
+	slog.Info("NVENC encoder shut down")
+}
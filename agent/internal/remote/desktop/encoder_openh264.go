@@ -3,6 +3,7 @@ package desktop
 import (
 	"errors"
 	"fmt"
+	"image"
 	"log/slog"
 	"runtime"
 	"sync"
@@ -21,8 +22,10 @@ type openH264Encoder struct {
 	height      int
 	pixelFormat PixelFormat
 	enc         *openh264.ISVCEncoder
+	yuv         yuvFrameCache // I420 of the previous frame, for damage-only conversion
 	pinner      runtime.Pinner
 	forceIDR    bool
+	textMode    bool
 	frameIdx    uint64
 	inited      bool
 }
@@ -80,7 +83,7 @@ func newOpenH264Encoder(cfg EncoderConfig) (encoderBackend, error) {
 	if err := loadOpenH264(); err != nil {
 		return nil, err
 	}
-	return &openH264Encoder{cfg: cfg}, nil
+	return &openH264Encoder{cfg: cfg, yuv: yuvFrameCache{layout: yuvI420}}, nil
 }
 
 // clampThreads returns a thread count suitable for OpenH264's
@@ -150,6 +153,13 @@ func (e *openH264Encoder) initEncoder() error {
 	params.IComplexityMode = openh264.MEDIUM_COMPLEXITY
 	params.IMinQp = 18
 	params.IMaxQp = 42
+	if e.textMode {
+		// Text mode: keep glyph edges sharp; adaptive quant would spend the
+		// budget on busy regions at the expense of flat text backgrounds.
+		params.IMinQp = textModeMinQP
+		params.IMaxQp = textModeMaxQP
+		params.BEnableAdaptiveQuant = false
+	}
 
 	// IDR every 4 seconds — tighter than 10s so packet-loss recovery doesn't
 	// rely solely on PLI, still sparse enough to avoid scene-change-free
@@ -190,11 +200,18 @@ func (e *openH264Encoder) initEncoder() error {
 		"fps", fps,
 		"profile", "baseline",
 		"complexity", "medium",
+		"textMode", e.textMode,
 	)
 	return nil
 }
 
 func (e *openH264Encoder) Encode(frame []byte) ([]byte, error) {
+	return e.EncodeDamaged(frame, nil)
+}
+
+// EncodeDamaged implements optionalDamageEncoder: only the damaged regions
+// of the previous frame's I420 buffer are re-converted.
+func (e *openH264Encoder) EncodeDamaged(frame []byte, damage []image.Rectangle) ([]byte, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -212,24 +229,19 @@ func (e *openH264Encoder) Encode(frame []byte) ([]byte, error) {
 	var err error
 	frame, err = FitRGBAFrame(frame, e.width, e.height)
 	if err != nil {
+		e.yuv.reset()
 		return nil, err
 	}
 
 	if !e.inited {
 		if err := e.initEncoder(); err != nil {
+			e.yuv.reset()
 			return nil, err
 		}
 	}
 
-	// Convert RGBA/BGRA to I420
-	stride := e.width * 4
-	var i420 []byte
-	if e.pixelFormat == PixelFormatBGRA {
-		i420 = bgraToI420(frame, e.width, e.height, stride)
-	} else {
-		i420 = rgbaToI420(frame, e.width, e.height, stride)
-	}
-	defer putI420Buffer(i420)
+	// Convert RGBA/BGRA to I420 (damaged regions only when known)
+	i420 := e.yuv.convert(frame, e.width, e.height, e.width*4, e.pixelFormat, damage)
 
 	// Force IDR if requested
 	if e.forceIDR {
@@ -379,6 +391,25 @@ func (e *openH264Encoder) SetQuality(quality QualityPreset) error {
 	return nil
 }
 
+// SetTextMode implements optionalTextModeEncoder. OpenH264 cannot change its
+// QP range on a live encoder, so the next Encode reinitializes (with an IDR).
+func (e *openH264Encoder) SetTextMode(enabled bool) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.textMode == enabled {
+		return nil
+	}
+	e.textMode = enabled
+	if e.enc != nil {
+		e.pinner.Unpin()
+		e.enc.Uninitialize()
+		openh264.WelsDestroySVCEncoder(e.enc)
+		e.enc = nil
+		e.inited = false
+	}
+	return nil
+}
+
 func (e *openH264Encoder) SetPixelFormat(pf PixelFormat) {
 	e.mu.Lock()
 	e.pixelFormat = pf
@@ -396,6 +427,7 @@ func (e *openH264Encoder) Close() error {
 		e.inited = false
 		slog.Info("OpenH264 encoder shut down")
 	}
+	e.yuv.reset()
 	return nil
 }
 
@@ -28,13 +28,16 @@ static void vtOutputCallback(void *outputCallbackRefCon,
                                   sampleBuffer);
 }
 
-static OSStatus vtCreateH264Session(int width, int height, VTCompressionSessionRef *sessionOut) {
+static OSStatus vtCreateSession(int width, int height, int hevc, VTCompressionSessionRef *sessionOut) {
     if (sessionOut == NULL) return -1;
 
-    // Prefer hardware if available, but don't require it.
+    // H264: prefer hardware if available, but don't require it. HEVC is only
+    // used when hardware can encode it, so require it there — a software
+    // HEVC session would not keep up and the caller falls back to H264.
     CFTypeRef hwTrue = kCFBooleanTrue;
     const void *specKeys[] = {
-        kVTVideoEncoderSpecification_EnableHardwareAcceleratedVideoEncoder,
+        hevc ? kVTVideoEncoderSpecification_RequireHardwareAcceleratedVideoEncoder
+             : kVTVideoEncoderSpecification_EnableHardwareAcceleratedVideoEncoder,
     };
     const void *specVals[] = {
         hwTrue,
@@ -81,7 +84,7 @@ static OSStatus vtCreateH264Session(int width, int height, VTCompressionSessionR
     OSStatus status = VTCompressionSessionCreate(kCFAllocatorDefault,
                                                  width,
                                                  height,
-                                                 kCMVideoCodecType_H264,
+                                                 hevc ? kCMVideoCodecType_HEVC : kCMVideoCodecType_H264,
                                                  encoderSpec,
                                                  attrs,
                                                  NULL,
@@ -285,45 +288,58 @@ static OSStatus vtCopyAnnexBFromSample(CMSampleBufferRef sample, uint8_t **outBy
     return noErr;
 }
 
-static OSStatus vtCopySPSPPSFromSample(CMSampleBufferRef sample,
-                                      uint8_t **spsOut, size_t *spsLen,
-                                      uint8_t **ppsOut, size_t *ppsLen) {
-    if (spsOut == NULL || spsLen == NULL || ppsOut == NULL || ppsLen == NULL) return -1;
-    *spsOut = NULL;
-    *ppsOut = NULL;
-    *spsLen = 0;
-    *ppsLen = 0;
+// vtCopyParameterSetsFromSample copies the stream's parameter sets (H264
+// SPS/PPS, HEVC VPS/SPS/PPS) out of the sample's format description as one
+// Annex-B buffer, so keyframes can carry them in-band.
+static OSStatus vtCopyParameterSetsFromSample(CMSampleBufferRef sample, uint8_t **outBytes, size_t *outLen) {
+    if (outBytes == NULL || outLen == NULL) return -1;
+    *outBytes = NULL;
+    *outLen = 0;
     if (sample == NULL) return -1;
 
     CMFormatDescriptionRef fmt = CMSampleBufferGetFormatDescription(sample);
     if (fmt == NULL) return -1;
+    int hevc = CMFormatDescriptionGetMediaSubType(fmt) == kCMVideoCodecType_HEVC;
 
-    const uint8_t *spsPtr = NULL;
-    const uint8_t *ppsPtr = NULL;
-    size_t spsSize = 0;
-    size_t ppsSize = 0;
+    const uint8_t *psPtr = NULL;
+    size_t psSize = 0;
     size_t psCount = 0;
     int nalHeaderLen = 0;
+    OSStatus st = hevc
+        ? CMVideoFormatDescriptionGetHEVCParameterSetAtIndex(fmt, 0, &psPtr, &psSize, &psCount, &nalHeaderLen)
+        : CMVideoFormatDescriptionGetH264ParameterSetAtIndex(fmt, 0, &psPtr, &psSize, &psCount, &nalHeaderLen);
+    if (st != noErr || psCount == 0) return st != noErr ? st : -1;
+
+    size_t total = 0;
+    for (size_t i = 0; i < psCount; i++) {
+        st = hevc
+            ? CMVideoFormatDescriptionGetHEVCParameterSetAtIndex(fmt, i, &psPtr, &psSize, NULL, NULL)
+            : CMVideoFormatDescriptionGetH264ParameterSetAtIndex(fmt, i, &psPtr, &psSize, NULL, NULL);
+        if (st != noErr || psPtr == NULL || psSize == 0) return st != noErr ? st : -1;
+        total += 4 + psSize;
+    }
 
-    OSStatus st = CMVideoFormatDescriptionGetH264ParameterSetAtIndex(fmt, 0, &spsPtr, &spsSize, &psCount, &nalHeaderLen);
-    if (st != noErr || spsPtr == NULL || spsSize == 0) return st != noErr ? st : -1;
-    st = CMVideoFormatDescriptionGetH264ParameterSetAtIndex(fmt, 1, &ppsPtr, &ppsSize, &psCount, &nalHeaderLen);
-    if (st != noErr || ppsPtr == NULL || ppsSize == 0) return st != noErr ? st : -1;
-
-    uint8_t *sps = (uint8_t *)malloc(spsSize);
-    uint8_t *pps = (uint8_t *)malloc(ppsSize);
-    if (sps == NULL || pps == NULL) {
-        if (sps) free(sps);
-        if (pps) free(pps);
-        return -1;
+    uint8_t *buf = (uint8_t *)malloc(total);
+    if (buf == NULL) return -1;
+    size_t off = 0;
+    for (size_t i = 0; i < psCount; i++) {
+        st = hevc
+            ? CMVideoFormatDescriptionGetHEVCParameterSetAtIndex(fmt, i, &psPtr, &psSize, NULL, NULL)
+            : CMVideoFormatDescriptionGetH264ParameterSetAtIndex(fmt, i, &psPtr, &psSize, NULL, NULL);
+        if (st != noErr || psPtr == NULL || off + 4 + psSize > total) {
+            free(buf);
+            return st != noErr ? st : -1;
+        }
+        buf[off] = 0;
+        buf[off+1] = 0;
+        buf[off+2] = 0;
+        buf[off+3] = 1;
+        memcpy(buf + off + 4, psPtr, psSize);
+        off += 4 + psSize;
     }
-    memcpy(sps, spsPtr, spsSize);
-    memcpy(pps, ppsPtr, ppsSize);
 
-    *spsOut = sps;
-    *ppsOut = pps;
-    *spsLen = spsSize;
-    *ppsLen = ppsSize;
+    *outBytes = buf;
+    *outLen = off;
     return noErr;
 }
 
@@ -366,8 +382,12 @@ func init() {
 	registerHardwareFactory(newVideoToolboxEncoder)
 }
 
+// newVideoToolboxEncoder builds an H264 or HEVC encoder. HEVC sessions
+// require a hardware encoder (Apple silicon, or Intel Macs from 6th-gen
+// Core); where there is none, SetDimensions fails and the WebRTC session
+// falls back to H264.
 func newVideoToolboxEncoder(cfg EncoderConfig) (encoderBackend, error) {
-	if cfg.Codec != CodecH264 {
+	if cfg.Codec != CodecH264 && cfg.Codec != CodecH265 {
 		return nil, fmt.Errorf("videotoolbox unsupported codec: %s", cfg.Codec)
 	}
 	return &videotoolboxEncoder{cfg: cfg}, nil
@@ -443,12 +463,13 @@ func (v *videotoolboxEncoder) SetCodec(codec Codec) error {
 	if !codec.valid() {
 		return fmt.Errorf("%w: %s", ErrInvalidCodec, codec)
 	}
-	if codec != CodecH264 {
-		return fmt.Errorf("videotoolbox unsupported codec: %s", codec)
-	}
 	v.mu.Lock()
-	v.cfg.Codec = codec
-	v.mu.Unlock()
+	defer v.mu.Unlock()
+	// The codec is fixed for the session lifetime (it matches the
+	// negotiated video track), so only a no-op change is accepted.
+	if codec != v.cfg.Codec {
+		return fmt.Errorf("videotoolbox encoder is configured for %s, got %s", v.cfg.Codec, codec)
+	}
 	return nil
 }
 
@@ -540,9 +561,14 @@ func (v *videotoolboxEncoder) SetDimensions(width, height int) error {
 		return nil
 	}
 
+	hevc := v.cfg.Codec == CodecH265
+	var hevcC C.int
+	if hevc {
+		hevcC = 1
+	}
 	var session C.VTCompressionSessionRef
-	if st := C.vtCreateH264Session(C.int(width), C.int(height), &session); st != 0 || session == 0 {
-		return fmt.Errorf("videotoolbox create session failed: OSStatus=%d", int32(st))
+	if st := C.vtCreateSession(C.int(width), C.int(height), hevcC, &session); st != 0 || session == 0 {
+		return fmt.Errorf("videotoolbox create %s session failed: OSStatus=%d", v.cfg.Codec, int32(st))
 	}
 
 	// Apply baseline realtime config.
@@ -550,8 +576,12 @@ func (v *videotoolboxEncoder) SetDimensions(width, height int) error {
 		slog.Warn("VideoToolbox: failed to set RealTime", "OSStatus", int32(st))
 	}
 	_ = C.vtSetPropertyBool(session, C.kVTCompressionPropertyKey_AllowFrameReordering, 0)
-	_ = C.vtSetPropertyString(session, C.kVTCompressionPropertyKey_ProfileLevel, C.kVTProfileLevel_H264_Baseline_AutoLevel)
-	_ = C.vtSetPropertyString(session, C.kVTCompressionPropertyKey_H264EntropyMode, C.kVTH264EntropyMode_CAVLC)
+	if hevc {
+		_ = C.vtSetPropertyString(session, C.kVTCompressionPropertyKey_ProfileLevel, C.kVTProfileLevel_HEVC_Main_AutoLevel)
+	} else {
+		_ = C.vtSetPropertyString(session, C.kVTCompressionPropertyKey_ProfileLevel, C.kVTProfileLevel_H264_Baseline_AutoLevel)
+		_ = C.vtSetPropertyString(session, C.kVTCompressionPropertyKey_H264EntropyMode, C.kVTH264EntropyMode_CAVLC)
+	}
 
 	// Bitrate + FPS.
 	if st := C.vtSetPropertyInt(session, C.kVTCompressionPropertyKey_AverageBitRate, C.int32_t(v.cfg.Bitrate)); st != 0 {
@@ -658,8 +688,6 @@ func vtQualityFloat(q QualityPreset) (float32, bool) {
 	}
 }
 
-var h264StartCode = []byte{0, 0, 0, 1}
-
 //export goVTCompressionOutputCallback
 func goVTCompressionOutputCallback(outputCallbackRefCon C.uintptr_t, sourceFrameRefCon C.uintptr_t, status C.int32_t, infoFlags C.uint32_t, sampleBuffer C.CMSampleBufferRef) {
 	defer func() {
@@ -713,34 +741,23 @@ func goVTCompressionOutputCallback(outputCallbackRefCon C.uintptr_t, sourceFrame
 		return
 	}
 
-	var spsPtr *C.uint8_t
-	var ppsPtr *C.uint8_t
-	var spsLen C.size_t
-	var ppsLen C.size_t
-	st := C.vtCopySPSPPSFromSample(sampleBuffer, &spsPtr, &spsLen, &ppsPtr, &ppsLen)
-	if st != 0 || spsPtr == nil || ppsPtr == nil || spsLen == 0 || ppsLen == 0 {
-		if spsPtr != nil {
-			C.vtFree(unsafe.Pointer(spsPtr))
+	var psPtr *C.uint8_t
+	var psLen C.size_t
+	if st := C.vtCopyParameterSetsFromSample(sampleBuffer, &psPtr, &psLen); st != 0 || psPtr == nil || psLen == 0 {
+		if psPtr != nil {
+			C.vtFree(unsafe.Pointer(psPtr))
 		}
-		if ppsPtr != nil {
-			C.vtFree(unsafe.Pointer(ppsPtr))
-		}
-		// Even if we can't read SPS/PPS, return the frame data.
+		// Even if we can't read the parameter sets, return the frame data.
 		req.ch <- vtEncodeResult{data: annex}
 		return
 	}
-	defer C.vtFree(unsafe.Pointer(spsPtr))
-	defer C.vtFree(unsafe.Pointer(ppsPtr))
-
-	sps := C.GoBytes(unsafe.Pointer(spsPtr), C.int(spsLen))
-	pps := C.GoBytes(unsafe.Pointer(ppsPtr), C.int(ppsLen))
+	defer C.vtFree(unsafe.Pointer(psPtr))
+	ps := C.GoBytes(unsafe.Pointer(psPtr), C.int(psLen))
 
-	// Prefix SPS/PPS for keyframes so decoders can start mid-stream.
-	out := make([]byte, 0, len(sps)+len(pps)+len(annex)+12)
-	out = append(out, h264StartCode...)
-	out = append(out, sps...)
-	out = append(out, h264StartCode...)
-	out = append(out, pps...)
+	// Prefix SPS/PPS (and VPS for HEVC) for keyframes so decoders can start
+	// mid-stream. ps is already start-code prefixed.
+	out := make([]byte, 0, len(ps)+len(annex))
+	out = append(out, ps...)
 	out = append(out, annex...)
 
 	req.ch <- vtEncodeResult{data: out}
@@ -0,0 +1,66 @@
+package desktop
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// UpdateICEServers swaps the ICE servers of a running session's peer
+// connection and of every extra viewer. The server hands out TURN credentials
+// that expire after a few minutes; refreshing them before expiry keeps the
+// ICE gatherer able to allocate relays for the rest of the session (ICE
+// restarts, new candidate pairs) instead of failing with stale credentials.
+// An empty list leaves the session untouched rather than falling back to the
+// public STUN server.
+func (m *SessionManager) UpdateICEServers(sessionID string, iceServers []ICEServerConfig) error {
+	if len(iceServers) == 0 {
+		return fmt.Errorf("no ICE servers")
+	}
+	m.mu.RLock()
+	session := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if session == nil || !session.active() {
+		return fmt.Errorf("%w: %s", ErrNoActiveSession, sessionID)
+	}
+	parsed := parseICEServers(iceServers)
+
+	session.mu.RLock()
+	pc := session.peerConn
+	session.mu.RUnlock()
+	if pc == nil {
+		return fmt.Errorf("session %s has no peer connection", sessionID)
+	}
+	if err := setPeerICEServers(pc, parsed); err != nil {
+		return err
+	}
+
+	session.viewersMu.RLock()
+	viewers := make([]*viewerPeer, 0, len(session.viewers))
+	for _, v := range session.viewers {
+		viewers = append(viewers, v)
+	}
+	session.viewersMu.RUnlock()
+	for _, v := range viewers {
+		if err := setPeerICEServers(v.peerConn, parsed); err != nil {
+			slog.Warn("Failed to update viewer ICE servers", "session", sessionID, "viewer", v.id, "error", err.Error())
+		}
+	}
+	slog.Info("ICE servers updated", "session", sessionID, "servers", len(parsed), "viewers", len(viewers))
+	return nil
+}
+
+// setPeerICEServers replaces only the ICE servers of pc's configuration;
+// pion rejects changes to certificates, bundle or mux policy mid-session.
+func setPeerICEServers(pc *webrtc.PeerConnection, servers []webrtc.ICEServer) error {
+	if pc == nil {
+		return nil
+	}
+	cfg := pc.GetConfiguration()
+	cfg.ICEServers = servers
+	if err := pc.SetConfiguration(cfg); err != nil {
+		return fmt.Errorf("set ICE servers: %w", err)
+	}
+	return nil
+}
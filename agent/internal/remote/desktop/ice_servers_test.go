@@ -0,0 +1,53 @@
+package desktop
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pion/webrtc/v4"
+)
+
+func TestUpdateICEServers_RequiresRunningSession(t *testing.T) {
+	m := NewSessionManager()
+	servers := []ICEServerConfig{{URLs: "stun:stun.example.com:3478"}}
+	if err := m.UpdateICEServers("missing", servers); !errors.Is(err, ErrNoActiveSession) {
+		t.Fatalf("expected ErrNoActiveSession, got %v", err)
+	}
+}
+
+func TestUpdateICEServers_SwapsSessionAndViewerServers(t *testing.T) {
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatalf("NewPeerConnection: %v", err)
+	}
+	defer pc.Close()
+	viewerPC, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatalf("NewPeerConnection: %v", err)
+	}
+	defer viewerPC.Close()
+
+	m := NewSessionManager()
+	s := &Session{id: "turn", isActive: true, peerConn: pc, done: make(chan struct{})}
+	s.viewers = map[string]*viewerPeer{"senior": {id: "senior", peerConn: viewerPC}}
+	m.sessions["turn"] = s
+
+	if err := m.UpdateICEServers("turn", nil); err == nil {
+		t.Fatal("an empty server list must be rejected, not replaced by the STUN fallback")
+	}
+
+	servers := []ICEServerConfig{{
+		URLs:       []interface{}{"turn:turn.example.com:3478?transport=udp"},
+		Username:   "1700000600:breeze:x",
+		Credential: "fresh",
+	}}
+	if err := m.UpdateICEServers("turn", servers); err != nil {
+		t.Fatalf("UpdateICEServers: %v", err)
+	}
+	for name, peer := range map[string]*webrtc.PeerConnection{"session": pc, "viewer": viewerPC} {
+		got := peer.GetConfiguration().ICEServers
+		if len(got) != 1 || got[0].Credential != "fresh" {
+			t.Fatalf("%s ICE servers = %+v, want the refreshed TURN server", name, got)
+		}
+	}
+}
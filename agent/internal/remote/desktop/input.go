@@ -1,14 +1,17 @@
 package desktop
 
+import "fmt"
+
 // InputEvent represents a mouse or keyboard input event
 type InputEvent struct {
-	Type      string   `json:"type"` // "mouse_move", "mouse_click", "mouse_scroll", "key_press", "key_release"
+	Type      string   `json:"type"` // "mouse_move", "mouse_click", "mouse_scroll", "key_press", "key_release", "text"
 	X         int      `json:"x,omitempty"`
 	Y         int      `json:"y,omitempty"`
 	Button    string   `json:"button,omitempty"`    // "left", "right", "middle"
 	Key       string   `json:"key,omitempty"`       // Key code or character
 	Modifiers []string `json:"modifiers,omitempty"` // "ctrl", "alt", "shift", "meta"
 	Delta     int      `json:"delta,omitempty"`     // Scroll delta
+	Text      string   `json:"text,omitempty"`      // Committed string for "text" events
 }
 
 // InputHandler processes input events
@@ -65,6 +68,45 @@ type TypeCharHandler interface {
 	TypeChar(ch rune) error
 }
 
+// TextInputHandler is an optional interface for input handlers that can
+// inject a committed string as-is, independent of the target's keyboard
+// layout. The viewer sends "text" events for IME commits, dead-key
+// compositions and, when layouts differ (see keyboard_layout.go), every
+// printable character.
+type TextInputHandler interface {
+	TypeText(text string) error
+}
+
+// TypeText types text through the best mechanism h supports: whole-string
+// injection, per-character Unicode input, or key presses as a last resort
+// (which only work for characters on the target's layout).
+func TypeText(h InputHandler, text string) error {
+	if th, ok := h.(TextInputHandler); ok {
+		return th.TypeText(text)
+	}
+	typer, hasTyper := h.(TypeCharHandler)
+	for _, ch := range text {
+		var err error
+		if hasTyper {
+			err = typer.TypeChar(ch)
+		} else {
+			err = h.SendKeyPress(string(ch), nil)
+		}
+		if err != nil {
+			return fmt.Errorf("failed typing character %q: %w", string(ch), err)
+		}
+	}
+	return nil
+}
+
+// InputDesktopResyncer is an optional interface for input handlers that
+// cache which desktop their thread is attached to (Windows). The capture loop
+// calls ResyncInputDesktop on every Default <-> Winlogon switch so the next
+// event re-attaches immediately.
+type InputDesktopResyncer interface {
+	ResyncInputDesktop()
+}
+
 // NewInputHandler creates a platform-specific input handler.
 // desktopContext is "user_session" or "login_window" — on macOS, login_window
 // uses IOHIDPostEvent instead of CGEvent for input at the login screen.
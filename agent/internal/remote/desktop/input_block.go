@@ -12,8 +12,9 @@ package desktop
 // permanently locked out of their own machine. Three independent release paths
 // guarantee that:
 //
-//  1. Explicit release — the viewer toggles it off, or the session ends
-//     (doCleanup() calls GetInputBlockManager().Release()).
+//  1. Explicit release — the viewer toggles it off, the session ends
+//     (doCleanup()), or the viewer's input/control data channel closes
+//     (Session.releaseLocalInputBlock).
 //  2. Process-death release — on every supported platform the OS-level block is
 //     tied to the agent process/thread. If the agent crashes or is killed
 //     (watchdog, OOM, SIGKILL), the OS automatically tears the block down. This
@@ -1,52 +1,175 @@
-//go:build darwin
+//go:build darwin && cgo
 
 package desktop
 
-import "errors"
+/*
+#cgo LDFLAGS: -framework CoreGraphics -framework CoreFoundation
 
-// darwinInputBlockBackend is a deliberate stub for macOS.
-//
-// macOS local-input blocking is feasible via a CGEventTap created at
-// kCGHIDEventTap / kCGSessionEventTap that returns NULL from its callback to
-// swallow physical key/mouse events, but a correct, safe implementation needs:
-//
-//   - Accessibility (and, for some event types, Input Monitoring) permission —
-//     the agent must already hold these (see project_macos_permissions); a tap
-//     created without them silently fails or is auto-disabled.
-//   - A dedicated CFRunLoop on a locked OS thread to service the tap. The Go
-//     agent has no such runloop in this package today; the existing CGEvent
-//     INJECTION path (input_darwin.go) does not need one, but a TAP does.
-//   - Robust handling of kCGEventTapDisabledByTimeout /
-//     kCGEventTapDisabledByUserInput, which macOS fires under load and which
-//     would otherwise leave the tap silently dead (the "finicky under load"
-//     caveat called out in issue #966).
-//   - A guarantee that the operator's INJECTED CGEvents are not also swallowed
-//     by our own tap. CGEventTap sees synthetic events too, so injected input
-//     must be tagged (e.g. CGEventSetIntegerValueField with a sentinel in
-//     kCGEventSourceUserData / a custom field) and the tap callback must let
-//     tagged events through. Getting this wrong blocks the remote operator too.
+#include <CoreGraphics/CoreGraphics.h>
+#include <CoreFoundation/CoreFoundation.h>
+#include <unistd.h>
+
+static CFMachPortRef g_blockTap = NULL;
+static CFRunLoopSourceRef g_blockSource = NULL;
+static CFRunLoopRef g_blockRunLoop = NULL;
+static volatile int g_blockPassthrough = 0;
+
+// blockTapCallback swallows physical input. Events this process injected
+// (the remote operator's input, posted with CGEventPost) carry our pid in
+// kCGEventSourceUnixProcessID and pass through; hardware events carry 0.
+static CGEventRef blockTapCallback(CGEventTapProxy proxy, CGEventType type, CGEventRef event, void *refcon) {
+    if (type == kCGEventTapDisabledByTimeout || type == kCGEventTapDisabledByUserInput) {
+        // macOS disables a tap that is slow or when the user hits the
+        // secure-input escape; re-arm it or the block silently lapses.
+        if (g_blockTap != NULL) {
+            CGEventTapEnable(g_blockTap, true);
+        }
+        return event;
+    }
+    if (g_blockPassthrough) {
+        return event;
+    }
+    if (CGEventGetIntegerValueField(event, kCGEventSourceUnixProcessID) == (int64_t)getpid()) {
+        return event;
+    }
+    return NULL;
+}
+
+// createBlockTap creates the HID-level tap and attaches it to the calling
+// thread's run loop. Returns 0 on success, -1 when the tap cannot be created
+// (missing Accessibility permission).
+static int createBlockTap(void) {
+    CGEventMask mask =
+        CGEventMaskBit(kCGEventKeyDown) | CGEventMaskBit(kCGEventKeyUp) |
+        CGEventMaskBit(kCGEventFlagsChanged) |
+        CGEventMaskBit(kCGEventMouseMoved) |
+        CGEventMaskBit(kCGEventLeftMouseDown) | CGEventMaskBit(kCGEventLeftMouseUp) |
+        CGEventMaskBit(kCGEventLeftMouseDragged) |
+        CGEventMaskBit(kCGEventRightMouseDown) | CGEventMaskBit(kCGEventRightMouseUp) |
+        CGEventMaskBit(kCGEventRightMouseDragged) |
+        CGEventMaskBit(kCGEventOtherMouseDown) | CGEventMaskBit(kCGEventOtherMouseUp) |
+        CGEventMaskBit(kCGEventOtherMouseDragged) |
+        CGEventMaskBit(kCGEventScrollWheel);
+    g_blockTap = CGEventTapCreate(kCGHIDEventTap, kCGHeadInsertEventTap,
+                                  kCGEventTapOptionDefault, mask, blockTapCallback, NULL);
+    if (g_blockTap == NULL) {
+        return -1;
+    }
+    g_blockSource = CFMachPortCreateRunLoopSource(kCFAllocatorDefault, g_blockTap, 0);
+    g_blockRunLoop = CFRunLoopGetCurrent();
+    CFRetain(g_blockRunLoop);
+    CFRunLoopAddSource(g_blockRunLoop, g_blockSource, kCFRunLoopCommonModes);
+    CGEventTapEnable(g_blockTap, true);
+    return 0;
+}
+
+// runBlockTap services the tap until stopBlockTap, then tears it down.
+static void runBlockTap(void) {
+    CFRunLoopRun();
+    CGEventTapEnable(g_blockTap, false);
+    CFRunLoopRemoveSource(g_blockRunLoop, g_blockSource, kCFRunLoopCommonModes);
+    CFMachPortInvalidate(g_blockTap);
+    CFRelease(g_blockSource);
+    CFRelease(g_blockTap);
+    CFRelease(g_blockRunLoop);
+    g_blockSource = NULL;
+    g_blockTap = NULL;
+    g_blockRunLoop = NULL;
+}
+
+static void stopBlockTap(void) {
+    if (g_blockRunLoop != NULL) {
+        CFRunLoopStop(g_blockRunLoop);
+    }
+}
+
+static void setBlockPassthrough(int on) {
+    g_blockPassthrough = on;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// darwinInputBlockBackend blocks local physical input with a CGEventTap at
+// kCGHIDEventTap whose callback drops hardware events (issue #966). The
+// pieces the original stub called out:
 //
-// That is a self-contained subsystem with its own runloop lifecycle and crash
-// semantics, so per the issue ("worth scoping separately ... may want to land
-// Windows first") it is intentionally deferred. Reporting Supported()==false
-// here means the control-channel handler replies block_local_input_result with
-// supported:false, and the viewer surfaces "not available on macOS" rather than
-// a false "blocked".
+//   - Permission: an active (filtering) tap needs the Accessibility grant the
+//     helper already holds for injection; without it CGEventTapCreate returns
+//     NULL and Block reports the failure.
+//   - Run loop: the tap is serviced by its own CFRunLoop on a dedicated locked
+//     OS thread, started on Block and stopped on Unblock.
+//   - Timeouts: kCGEventTapDisabledByTimeout / ByUserInput re-enable the tap.
+//   - Operator input: injected CGEvents carry this process's pid in
+//     kCGEventSourceUnixProcessID and pass through untouched.
 //
-// TODO(#966 follow-up): implement CGEventTap-based blocking on a dedicated
-// CFRunLoop thread, gate on Accessibility/Input-Monitoring permission, tag and
-// pass through injected events, and re-enable the tap on
-// kCGEventTapDisabledBy* notifications.
-type darwinInputBlockBackend struct{}
+// At the login window the operator's input may go through IOHIDPostEvent,
+// which is indistinguishable from hardware, so the tap passes everything
+// while the input handler is in login-window mode (setInputBlockLoginWindow).
+// The tap dies with the process, so a crashed helper never leaves input
+// blocked.
+type darwinInputBlockBackend struct {
+	mu   sync.Mutex
+	done chan struct{}
+}
 
 func newInputBlockBackend() inputBlockBackend {
 	return &darwinInputBlockBackend{}
 }
 
-func (b *darwinInputBlockBackend) Supported() bool { return false }
+func (b *darwinInputBlockBackend) Supported() bool { return true }
 
 func (b *darwinInputBlockBackend) Block() error {
-	return errors.New("local input blocking not yet implemented on macOS")
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done != nil {
+		return nil
+	}
+	created := make(chan bool, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		if C.createBlockTap() != 0 {
+			created <- false
+			return
+		}
+		created <- true
+		C.runBlockTap()
+	}()
+	if !<-created {
+		<-done
+		return errors.New("CGEventTapCreate failed (Accessibility permission required)")
+	}
+	b.done = done
+	return nil
+}
+
+func (b *darwinInputBlockBackend) Unblock() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done == nil {
+		return nil
+	}
+	C.stopBlockTap()
+	<-b.done
+	b.done = nil
+	return nil
 }
 
-func (b *darwinInputBlockBackend) Unblock() error { return nil }
+// setInputBlockLoginWindow suspends blocking while input is injected at the
+// login window, where the operator's events cannot be told apart from local
+// hardware input.
+func setInputBlockLoginWindow(atLoginWindow bool) {
+	on := C.int(0)
+	if atLoginWindow {
+		on = 1
+	}
+	C.setBlockPassthrough(on)
+}
@@ -0,0 +1,15 @@
+//go:build darwin && !cgo
+
+package desktop
+
+// darwinInputBlockBackend is unavailable without cgo: the CGEventTap backend
+// (input_block_darwin.go) needs CoreGraphics.
+type darwinInputBlockBackend struct{}
+
+func newInputBlockBackend() inputBlockBackend {
+	return &darwinInputBlockBackend{}
+}
+
+func (b *darwinInputBlockBackend) Supported() bool { return false }
+func (b *darwinInputBlockBackend) Block() error    { return nil }
+func (b *darwinInputBlockBackend) Unblock() error  { return nil }
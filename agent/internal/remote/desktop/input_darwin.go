@@ -302,6 +302,34 @@ static void sessionKeyUp(int keycode, int flags) {
         CFRelease(event);
     }
 }
+
+// ---- Unicode text injection ----
+// Posts UTF-16 text as keyboard events carrying a Unicode string, so the
+// result does not depend on the active input source. The string attached to
+// one event is limited (20 UniChars in practice), so long text is split
+// without separating surrogate pairs.
+static void typeUnicode(const UniChar *chars, int len, int sessionTap) {
+    CGEventTapLocation tap = sessionTap ? kCGSessionEventTap : kCGHIDEventTap;
+    int off = 0;
+    while (off < len) {
+        int n = len - off;
+        if (n > 20) {
+            n = 20;
+            if (CFStringIsSurrogateHighCharacter(chars[off + n - 1])) n--;
+        }
+        CGEventRef down = CGEventCreateKeyboardEvent(NULL, 0, true);
+        CGEventRef up = CGEventCreateKeyboardEvent(NULL, 0, false);
+        if (down && up) {
+            CGEventKeyboardSetUnicodeString(down, n, chars + off);
+            CGEventKeyboardSetUnicodeString(up, n, chars + off);
+            CGEventPost(tap, down);
+            CGEventPost(tap, up);
+        }
+        if (down) CFRelease(down);
+        if (up) CFRelease(up);
+        off += n;
+    }
+}
 */
 // #cgo LDFLAGS: -framework IOKit
 import "C"
@@ -311,6 +339,8 @@ import (
 	"log/slog"
 	"strings"
 	"sync/atomic"
+	"unicode/utf16"
+	"unsafe"
 )
 
 // macOS virtual keycodes (from Carbon HIToolbox/Events.h)
@@ -394,6 +424,7 @@ func NewInputHandler(desktopContext string) InputHandler {
 	// If launched in login_window context, start in login window mode.
 	if desktopContext == "login_window" {
 		h.atLoginWindow.Store(true)
+		setInputBlockLoginWindow(true)
 	}
 
 	return h
@@ -412,6 +443,7 @@ func (h *DarwinInputHandler) InputAvailable() bool {
 
 func (h *DarwinInputHandler) SetAtLoginWindow(atLoginWindow bool) {
 	prev := h.atLoginWindow.Swap(atLoginWindow)
+	setInputBlockLoginWindow(atLoginWindow)
 	if prev != atLoginWindow {
 		if atLoginWindow {
 			slog.Info("input switching to IOHIDPostEvent mode (login window)")
@@ -640,6 +672,67 @@ func (h *DarwinInputHandler) SendKeyUp(key string) error {
 	return nil
 }
 
+// TypeText implements TextInputHandler with Unicode-carrying CGEvents. Line
+// breaks and tabs are sent as their keys. IOHIDPostEvent (login window via
+// IOHIDSystem) cannot carry text, so that path types key by key and only
+// covers characters in keyNameToKeycode.
+func (h *DarwinInputHandler) TypeText(text string) error {
+	if !h.inputAvailable {
+		return errInputUnavailable
+	}
+	if h.shouldUseHID() {
+		for _, r := range text {
+			var mods []string
+			if r >= 'A' && r <= 'Z' {
+				mods = []string{"shift"}
+			}
+			if err := h.SendKeyPress(darwinTextKeyName(r), mods); err != nil {
+				return fmt.Errorf("failed typing character %q: %w", string(r), err)
+			}
+		}
+		return nil
+	}
+	sessionTap := C.int(0)
+	if h.shouldUseSessionTap() {
+		sessionTap = 1
+	}
+	var units []uint16
+	flush := func() {
+		if len(units) > 0 {
+			C.typeUnicode((*C.UniChar)(unsafe.Pointer(&units[0])), C.int(len(units)), sessionTap)
+			units = units[:0]
+		}
+	}
+	prev := rune(0)
+	for _, r := range text {
+		switch {
+		case r == '\n' && prev == '\r':
+			// CRLF is one Return.
+		case r == '\r' || r == '\n' || r == '\t':
+			flush()
+			if err := h.SendKeyPress(darwinTextKeyName(r), nil); err != nil {
+				return err
+			}
+		default:
+			units = utf16.AppendRune(units, r)
+		}
+		prev = r
+	}
+	flush()
+	return nil
+}
+
+// darwinTextKeyName maps a typed character onto its keyNameToKeycode name.
+func darwinTextKeyName(r rune) string {
+	switch r {
+	case '\r', '\n':
+		return "return"
+	case '\t':
+		return "tab"
+	}
+	return strings.ToLower(string(r))
+}
+
 func (h *DarwinInputHandler) HandleEvent(event InputEvent) error {
 	if !h.inputAvailable {
 		return errInputUnavailable
@@ -23,7 +23,11 @@ type LinuxInputHandler struct {
 	offY  int
 }
 
-var _ InputHandler = (*LinuxInputHandler)(nil)
+var (
+	_ InputHandler           = (*LinuxInputHandler)(nil)
+	_ TextInputHandler       = (*LinuxInputHandler)(nil)
+	_ KeyboardLayoutProvider = (*LinuxInputHandler)(nil)
+)
 
 // NewInputHandler creates a Linux input handler backed by XTEST.
 func NewInputHandler(_ string) InputHandler {
@@ -188,6 +192,42 @@ func (h *LinuxInputHandler) SendKeyUp(key string) error {
 	return inj.KeyUpByName(canonicalModifierName(key))
 }
 
+// TypeText implements TextInputHandler: each character is typed through the
+// current layout when it has the character, otherwise through a temporarily
+// rebound spare keycode.
+func (h *LinuxInputHandler) TypeText(text string) error {
+	inj := h.injector()
+	if inj == nil {
+		return ErrNoActiveSession
+	}
+	prev := rune(0)
+	for _, r := range text {
+		if r == '\n' && prev == '\r' {
+			prev = r
+			continue // CRLF is one Enter
+		}
+		if err := inj.TypeRune(r); err != nil {
+			return fmt.Errorf("failed typing character %q: %w", string(r), err)
+		}
+		prev = r
+	}
+	return nil
+}
+
+// KeyboardLayout implements KeyboardLayoutProvider from the XKB layout the
+// X server reports, or "" when it has none.
+func (h *LinuxInputHandler) KeyboardLayout() string {
+	inj := h.injector()
+	if inj == nil {
+		return ""
+	}
+	layouts, variants, err := inj.XKBLayout()
+	if err != nil {
+		return ""
+	}
+	return xkbLayoutTag(layouts, variants)
+}
+
 // HandleEvent dispatches an InputEvent (mirrors the Windows/macOS handlers).
 func (h *LinuxInputHandler) HandleEvent(event InputEvent) error {
 	switch event.Type {
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"syscall"
 	"time"
+	"unicode/utf16"
 	"unsafe"
 )
 
@@ -20,6 +21,11 @@ var (
 	mapvirtualkey    = user32.NewProc("MapVirtualKeyW")
 	getSystemMetrics = user32.NewProc("GetSystemMetrics")
 	vkKeyScanW       = user32.NewProc("VkKeyScanW")
+
+	procGetForegroundWindow      = user32.NewProc("GetForegroundWindow")
+	procGetWindowThreadProcessId = user32.NewProc("GetWindowThreadProcessId")
+	procGetKeyboardLayout        = user32.NewProc("GetKeyboardLayout")
+	procLCIDToLocaleName         = syscall.NewLazyDLL("kernel32.dll").NewProc("LCIDToLocaleName")
 )
 
 const (
@@ -49,6 +55,8 @@ const (
 
 	MAPVK_VK_TO_VSC = 0
 
+	VK_TAB     = 0x09
+	VK_RETURN  = 0x0D
 	VK_SHIFT   = 0x10
 	VK_CONTROL = 0x11
 	VK_MENU    = 0x12 // Alt
@@ -441,25 +449,65 @@ func (h *WindowsInputHandler) SendKeyDown(key string) error {
 // This bypasses VK code mapping entirely and works for any character
 // including ":", "!", "@", non-ASCII, emoji, etc.
 func (h *WindowsInputHandler) TypeChar(ch rune) error {
-	down := input{inputType: INPUT_KEYBOARD}
-	ki := (*keybdInput)(unsafe.Pointer(&down.mi))
-	ki.wVk = 0
-	ki.wScan = uint16(ch)
-	ki.dwFlags = KEYEVENTF_UNICODE
-
-	up := input{inputType: INPUT_KEYBOARD}
-	kiUp := (*keybdInput)(unsafe.Pointer(&up.mi))
-	kiUp.wVk = 0
-	kiUp.wScan = uint16(ch)
-	kiUp.dwFlags = KEYEVENTF_UNICODE | KEYEVENTF_KEYUP
-
-	ret, _, _ := sendInput.Call(2, uintptr(unsafe.Pointer(&down)), unsafe.Sizeof(down))
-	if ret == 0 {
-		return fmt.Errorf("SendInput UNICODE failed for char U+%04X", ch)
+	if err := h.TypeText(string(ch)); err != nil {
+		return fmt.Errorf("SendInput UNICODE failed for char U+%04X: %w", ch, err)
 	}
 	return nil
 }
 
+// TypeText injects a committed string (IME result, dead-key composition) in
+// a single SendInput batch so it cannot interleave with the local user's
+// keystrokes. Independent of the active keyboard layout.
+func (h *WindowsInputHandler) TypeText(text string) error {
+	h.ensureInputDesktop()
+	inputs := unicodeKeyInputs(text)
+	if len(inputs) == 0 {
+		return nil
+	}
+	ret, _, _ := sendInput.Call(uintptr(len(inputs)), uintptr(unsafe.Pointer(&inputs[0])), unsafe.Sizeof(inputs[0]))
+	if int(ret) != len(inputs) {
+		return fmt.Errorf("SendInput UNICODE injected %d of %d events", ret, len(inputs))
+	}
+	return nil
+}
+
+// unicodeKeyInputs builds the SendInput batch for text: a KEYEVENTF_UNICODE
+// down/up pair per UTF-16 code unit (characters outside the BMP go as their
+// surrogate pair, which Windows recombines), and real VK_RETURN / VK_TAB
+// presses for line breaks and tabs, which consoles ignore as Unicode input.
+func unicodeKeyInputs(text string) []input {
+	inputs := make([]input, 0, 2*len(text))
+	key := func(vk, scan uint16, flags uint32) {
+		inp := input{inputType: INPUT_KEYBOARD}
+		ki := (*keybdInput)(unsafe.Pointer(&inp.mi))
+		ki.wVk = vk
+		ki.wScan = scan
+		ki.dwFlags = flags
+		inputs = append(inputs, inp)
+	}
+	prev := rune(0)
+	for _, ch := range text {
+		switch {
+		case ch == '\n' && prev == '\r':
+			// CRLF is one Enter.
+		case ch == '\r' || ch == '\n' || ch == '\t':
+			vk := uint16(VK_RETURN)
+			if ch == '\t' {
+				vk = VK_TAB
+			}
+			key(vk, vkToScanCode(vk), 0)
+			key(vk, vkToScanCode(vk), KEYEVENTF_KEYUP)
+		default:
+			for _, unit := range utf16.Encode([]rune{ch}) {
+				key(0, unit, KEYEVENTF_UNICODE)
+				key(0, unit, KEYEVENTF_UNICODE|KEYEVENTF_KEYUP)
+			}
+		}
+		prev = ch
+	}
+	return inputs
+}
+
 func (h *WindowsInputHandler) SendKeyUp(key string) error {
 	vk := charToVK(key)
 	if vk == 0 {
@@ -482,6 +530,44 @@ func (h *WindowsInputHandler) SendKeyUp(key string) error {
 	return nil
 }
 
+// KeyboardLayout implements KeyboardLayoutProvider with the input locale of
+// the foreground window's thread — Windows keeps a layout per thread, and
+// that is the one typed keys land in. Non-default layouts for a language
+// (Dvorak, "US-International", ...) carry the HKL device word as a
+// private-use suffix so they don't match the plain layout.
+func (h *WindowsInputHandler) KeyboardLayout() string {
+	var tid uintptr
+	if hwnd, _, _ := procGetForegroundWindow.Call(); hwnd != 0 {
+		tid, _, _ = procGetWindowThreadProcessId.Call(hwnd, 0)
+	}
+	hkl, _, _ := procGetKeyboardLayout.Call(tid)
+	if hkl == 0 {
+		return ""
+	}
+	langID := uint16(hkl)
+	device := uint16(hkl >> 16)
+
+	var buf [85]uint16 // LOCALE_NAME_MAX_LENGTH
+	n, _, _ := procLCIDToLocaleName.Call(uintptr(langID), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), 0)
+	if n == 0 {
+		return ""
+	}
+	tag := syscall.UTF16ToString(buf[:])
+	if device != langID {
+		tag += fmt.Sprintf("-x-%04x", device)
+	}
+	return tag
+}
+
+// ResyncInputDesktop makes the next input event re-attach to the active input
+// desktop instead of waiting out the 500ms recheck interval, so the first
+// click on a UAC prompt or the lock screen lands on the secure desktop.
+func (h *WindowsInputHandler) ResyncInputDesktop() {
+	h.mu.Lock()
+	h.lastDesktopSync = time.Time{}
+	h.mu.Unlock()
+}
+
 // ensureInputDesktop switches the input handler's thread to the active input
 // desktop so that SendInput works on the Winlogon/UAC secure desktop.
 // Only re-checks every 500ms to avoid overhead on every input event.
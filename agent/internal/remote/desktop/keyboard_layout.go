@@ -0,0 +1,119 @@
+package desktop
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+)
+
+// Keyboard layout negotiation.
+//
+// Key events carry key names ("a", "enter", "-"), which the target resolves
+// through its own keyboard layout. That only reproduces what the viewer's
+// user typed when both ends use the same layout; dead keys and IME
+// compositions never map onto a single key at all. The viewer therefore
+// reports its layout in a keyboard_layout control message and the agent
+// answers with the host layout and the input mode to use:
+//
+//   - "keys": layouts match. The viewer sends key events for everything, so
+//     shortcuts and key repeat behave natively.
+//   - "text": layouts differ, the host layout is unknown, or the viewer is
+//     composing with an IME. The viewer sends printable characters as "text"
+//     events (committed strings, see TextInputHandler) and keeps key events
+//     for non-printing keys and modifier chords.
+
+const (
+	inputModeKeys = "keys"
+	inputModeText = "text"
+)
+
+// KeyboardLayoutProvider is an optional interface for input handlers that can
+// report the target's active keyboard layout as a BCP 47 style tag
+// ("de-DE", "en-US"), or "" when it cannot be determined.
+type KeyboardLayoutProvider interface {
+	KeyboardLayout() string
+}
+
+// normalizeLayoutTag lower-cases a layout tag and accepts "_" separators, so
+// "de_DE" and "de-de" compare equal to "de-DE".
+func normalizeLayoutTag(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+// negotiateInputMode picks the input mode for a viewer/host layout pair.
+// Layouts only match when the full tag (region and variant included) is
+// equal — en-US and en-GB differ in their symbol keys.
+func negotiateInputMode(viewerLayout, hostLayout string, viewerIME bool) string {
+	v, h := normalizeLayoutTag(viewerLayout), normalizeLayoutTag(hostLayout)
+	if viewerIME || v == "" || h == "" || v != h {
+		return inputModeText
+	}
+	return inputModeKeys
+}
+
+// xkbLayoutTags maps common XKB layout names (country codes) to the tags
+// the other platforms report.
+var xkbLayoutTags = map[string]string{
+	"us": "en-US", "gb": "en-GB", "ie": "en-IE", "de": "de-DE", "at": "de-AT",
+	"ch": "de-CH", "fr": "fr-FR", "be": "fr-BE", "ca": "fr-CA", "es": "es-ES",
+	"latam": "es-419", "it": "it-IT", "pt": "pt-PT", "br": "pt-BR",
+	"nl": "nl-NL", "se": "sv-SE", "no": "nb-NO", "dk": "da-DK", "fi": "fi-FI",
+	"is": "is-IS", "pl": "pl-PL", "cz": "cs-CZ", "sk": "sk-SK", "hu": "hu-HU",
+	"ro": "ro-RO", "tr": "tr-TR", "gr": "el-GR", "ru": "ru-RU", "ua": "uk-UA",
+	"il": "he-IL", "ara": "ar", "jp": "ja-JP", "kr": "ko-KR", "cn": "zh-CN",
+	"tw": "zh-TW", "in": "hi-IN", "th": "th-TH", "vn": "vi-VN",
+}
+
+// xkbLayoutTag converts the first XKB layout and variant (as found in the
+// _XKB_RULES_NAMES root window property) to a layout tag. Variants (dvorak,
+// nodeadkeys, ...) are kept as a private-use suffix so they never match a
+// plain layout.
+func xkbLayoutTag(layouts, variants string) string {
+	layout, _, _ := strings.Cut(layouts, ",")
+	variant, _, _ := strings.Cut(variants, ",")
+	layout = strings.TrimSpace(layout)
+	if layout == "" {
+		return ""
+	}
+	tag, ok := xkbLayoutTags[layout]
+	if !ok {
+		tag = "x-xkb-" + layout
+	}
+	if variant = strings.TrimSpace(variant); variant != "" {
+		tag += "-x-" + variant
+	}
+	return tag
+}
+
+// hostKeyboardLayout returns the target's layout tag, or "" if the input
+// handler cannot tell.
+func (s *Session) hostKeyboardLayout() string {
+	if kp, ok := s.inputHandler.(KeyboardLayoutProvider); ok {
+		return kp.KeyboardLayout()
+	}
+	return ""
+}
+
+// handleKeyboardLayout answers a keyboard_layout control message with the
+// host layout and the negotiated input mode.
+func (s *Session) handleKeyboardLayout(viewerLayout string, viewerIME bool) {
+	host := s.hostKeyboardLayout()
+	mode := negotiateInputMode(viewerLayout, host, viewerIME)
+	slog.Info("Keyboard layout negotiated", "session", s.id,
+		"viewerLayout", viewerLayout, "hostLayout", host, "ime", viewerIME, "inputMode", mode)
+
+	resp, err := json.Marshal(map[string]any{
+		"type":       "keyboard_layout",
+		"hostLayout": host,
+		"inputMode":  mode,
+	})
+	if err != nil {
+		return
+	}
+	s.mu.RLock()
+	dc := s.controlDC
+	s.mu.RUnlock()
+	if dc != nil {
+		dc.SendText(string(resp))
+	}
+}
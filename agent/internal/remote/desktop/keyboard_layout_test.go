@@ -0,0 +1,45 @@
+package desktop
+
+import "testing"
+
+func TestNegotiateInputMode(t *testing.T) {
+	tests := []struct {
+		name         string
+		viewer, host string
+		ime          bool
+		want         string
+	}{
+		{"same layout", "de-DE", "de-DE", false, inputModeKeys},
+		{"case and separator", "de_de", "de-DE", false, inputModeKeys},
+		{"same language other region", "en-US", "en-GB", false, inputModeText},
+		{"different layout", "fr-FR", "en-US", false, inputModeText},
+		{"host unknown", "en-US", "", false, inputModeText},
+		{"viewer unknown", "", "en-US", false, inputModeText},
+		{"ime composing", "ja-JP", "ja-JP", true, inputModeText},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiateInputMode(tt.viewer, tt.host, tt.ime); got != tt.want {
+				t.Errorf("negotiateInputMode(%q, %q, %v) = %q, want %q", tt.viewer, tt.host, tt.ime, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXKBLayoutTag(t *testing.T) {
+	tests := []struct {
+		layouts, variants string
+		want              string
+	}{
+		{"us", "", "en-US"},
+		{"de,us", ",", "de-DE"},
+		{"us", "dvorak", "en-US-x-dvorak"},
+		{"epo", "", "x-xkb-epo"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if got := xkbLayoutTag(tt.layouts, tt.variants); got != tt.want {
+			t.Errorf("xkbLayoutTag(%q, %q) = %q, want %q", tt.layouts, tt.variants, got, tt.want)
+		}
+	}
+}
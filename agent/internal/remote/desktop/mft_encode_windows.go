@@ -4,6 +4,7 @@ package desktop
 
 import (
 	"fmt"
+	"image"
 	"log/slog"
 	"runtime"
 	"syscall"
@@ -38,6 +39,12 @@ const mftAsyncInputWait = 3 * time.Millisecond
 // Encode takes RGBA or BGRA pixel data (per SetPixelFormat), converts to NV12, and encodes to H264.
 // Returns nil, nil when the MFT is buffering (no output yet).
 func (m *mftEncoder) Encode(frame []byte) ([]byte, error) {
+	return m.EncodeDamaged(frame, nil)
+}
+
+// EncodeDamaged implements optionalDamageEncoder: only the damaged regions
+// of the previous frame's NV12 buffer are re-converted.
+func (m *mftEncoder) EncodeDamaged(frame []byte, damage []image.Rectangle) ([]byte, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -55,12 +62,14 @@ func (m *mftEncoder) Encode(frame []byte) ([]byte, error) {
 	var err error
 	frame, err = FitRGBAFrame(frame, m.width, m.height)
 	if err != nil {
+		m.yuv.reset()
 		return nil, err
 	}
 
 	// Lazy init: need dimensions to configure MFT
 	if !m.inited {
 		if err := m.initialize(m.width, m.height, m.width*4); err != nil {
+			m.yuv.reset()
 			return nil, err
 		}
 	}
@@ -73,14 +82,8 @@ func (m *mftEncoder) Encode(frame []byte) ([]byte, error) {
 		m.teardownDXGIManager()
 	}
 
-	// Convert pixels → NV12
-	var nv12 []byte
-	if m.pixelFormat == PixelFormatBGRA {
-		nv12 = bgraToNV12(frame, m.width, m.height, m.stride)
-	} else {
-		nv12 = rgbaToNV12(frame, m.width, m.height, m.stride)
-	}
-	defer putNV12Buffer(nv12)
+	// Convert pixels → NV12, re-converting only damaged regions when known
+	nv12 := m.yuv.convert(frame, m.width, m.height, m.stride, m.pixelFormat, damage)
 
 	// Create MF sample with NV12 data
 	sample, err := m.createSample(nv12)
@@ -26,7 +26,8 @@ func vbvSizeForBitrate(bitrate int) uint32 {
 
 // mftEncoder implements encoderBackend using Windows Media Foundation Transform.
 // It discovers and uses hardware H264 encoders (NVENC, QuickSync, AMD VCE)
-// via the MFT enumeration API, falling back to the software H264 MFT.
+// via the MFT enumeration API, falling back to the software H264 MFT. The
+// same hardware MFTs also provide HEVC output when the session negotiates it.
 type mftEncoder struct {
 	mu sync.Mutex
 
@@ -34,6 +35,7 @@ type mftEncoder struct {
 	width  int
 	height int
 	stride int
+	yuv    yuvFrameCache // NV12 of the previous CPU frame, for damage-only conversion
 
 	// COM handles (persistent across frames)
 	transform       uintptr // IMFTransform
@@ -86,6 +88,9 @@ type mftEncoder struct {
 	// Keyframe forcing: set when we want the next output to be an IDR.
 	forceKeyframePending bool
 
+	// textMode caps AVEncVideoMaxQP; applied at init and on change.
+	textMode bool
+
 	// Diagnostic: consecutive Encode() calls that returned nil (MFT buffering).
 	consecutiveNilOutputs int
 	// lastStallFlush prevents rapid flush loops when the MFT is fundamentally
@@ -103,14 +108,15 @@ func init() {
 }
 
 func newMFTEncoder(cfg EncoderConfig) (encoderBackend, error) {
-	if cfg.Codec != CodecH264 {
-		return nil, fmt.Errorf("MFT encoder only supports H264, got %s", cfg.Codec)
+	if cfg.Codec != CodecH264 && cfg.Codec != CodecH265 {
+		return nil, fmt.Errorf("MFT encoder only supports H264 and HEVC, got %s", cfg.Codec)
 	}
 	// Probe for hardware MFTs at creation time so the factory fails fast
 	// when no GPU encoder is available. This lets newBackend() fall through
-	// to OpenH264 instead of returning a struct that fails lazily on Encode().
-	if !probeHardwareMFT() {
-		return nil, fmt.Errorf("no hardware H264 MFT available")
+	// to OpenH264 (or, for HEVC, the session fall back to H264) instead of
+	// returning a struct that fails lazily on Encode().
+	if !probeHardwareMFT(mftOutputSubtype(cfg.Codec)) {
+		return nil, fmt.Errorf("no hardware %s MFT available", mftCodecName(cfg.Codec))
 	}
 	return &mftEncoder{
 		cfg:       cfg,
@@ -118,10 +124,27 @@ func newMFTEncoder(cfg EncoderConfig) (encoderBackend, error) {
 	}, nil
 }
 
-// probeHardwareMFT checks if a hardware H264 encoder MFT exists without
-// fully initializing it. Returns false on headless servers / basic GPUs
-// (e.g. Matrox G200) that lack hardware H264 encoding.
-func probeHardwareMFT() bool {
+// mftOutputSubtype is the MF output subtype for codec (H264 unless HEVC).
+func mftOutputSubtype(codec Codec) comGUID {
+	if codec == CodecH265 {
+		return mfVideoFormatHEVC
+	}
+	return mfVideoFormatH264
+}
+
+// mftCodecName is codec's display name for logs and errors.
+func mftCodecName(codec Codec) string {
+	if codec == CodecH265 {
+		return "HEVC"
+	}
+	return "H264"
+}
+
+// probeHardwareMFT checks if a hardware encoder MFT producing outputSubtype
+// exists without fully initializing it. Returns false on headless servers /
+// basic GPUs (e.g. Matrox G200) that lack hardware H264 encoding, and on
+// GPUs predating HEVC encode when probing for HEVC.
+func probeHardwareMFT(outputSubtype comGUID) bool {
 	// COM init (best-effort, may already be initialized)
 	hr, _, _ := procCoInitializeEx.Call(0, coinitMultithreaded)
 	if int32(hr) < 0 && uint32(hr) != 0x80010106 {
@@ -130,7 +153,7 @@ func probeHardwareMFT() bool {
 	procMFStartup.Call(mfVersion, mfStartupFull)
 
 	inputType := mftRegisterTypeInfo{mfMediaTypeVideo, mfVideoFormatNV12}
-	outputType := mftRegisterTypeInfo{mfMediaTypeVideo, mfVideoFormatH264}
+	outputType := mftRegisterTypeInfo{mfMediaTypeVideo, outputSubtype}
 
 	var ppActivate uintptr
 	var count uint32
@@ -154,8 +177,11 @@ func probeHardwareMFT() bool {
 	return true
 }
 
-// initialize sets up COM, finds an MFT H264 encoder, and configures it.
-// Called lazily on the first Encode with known dimensions.
+// initialize sets up COM, finds an MFT H264 (or HEVC) encoder, and
+// configures it. Called lazily on the first Encode with known dimensions.
+// HEVC has no software MFT fallback: the Windows software HEVC encoder is an
+// optional Store extension and far too slow for 4K, so hardware failures are
+// returned and the session stays on H264.
 func (m *mftEncoder) initialize(width, height, stride int) error {
 	// Lock this goroutine to an OS thread for COM thread affinity
 	if !m.threadLocked {
@@ -175,23 +201,28 @@ func (m *mftEncoder) initialize(width, height, stride int) error {
 		return fmt.Errorf("MFStartup failed: 0x%08X", uint32(hr))
 	}
 
-	// Find H264 encoder — try hardware first
+	// Find encoder — try hardware first
 	transform, isHW, err := m.findEncoder(width, height)
 	if err != nil {
 		procMFShutdown.Call()
-		return fmt.Errorf("no H264 encoder found: %w", err)
+		return fmt.Errorf("no %s encoder found: %w", mftCodecName(m.cfg.Codec), err)
 	}
+	outputSubtype := mftOutputSubtype(m.cfg.Codec)
 
 	// Hardware MFTs are async and must be unlocked before configuration.
 	// Without this, SetOutputType/SetInputType return MF_E_TRANSFORM_ASYNC_LOCKED.
 	if isHW {
 		if err := m.unlockAsyncMFT(transform); err != nil {
-			slog.Warn("Failed to unlock async MFT, falling back to software", "error", err.Error())
 			comRelease(transform)
+			if m.cfg.Codec == CodecH265 {
+				procMFShutdown.Call()
+				return fmt.Errorf("unlock async HEVC MFT: %w", err)
+			}
+			slog.Warn("Failed to unlock async MFT, falling back to software", "error", err.Error())
 			transform, err = m.enumAndActivate(
 				mftEnumFlagSyncMFT|mftEnumFlagSortAndFilter,
 				&mftRegisterTypeInfo{mfMediaTypeVideo, mfVideoFormatNV12},
-				&mftRegisterTypeInfo{mfMediaTypeVideo, mfVideoFormatH264},
+				&mftRegisterTypeInfo{mfMediaTypeVideo, outputSubtype},
 			)
 			if err != nil {
 				procMFShutdown.Call()
@@ -212,7 +243,7 @@ func (m *mftEncoder) initialize(width, height, stride int) error {
 		m.tryInitGPUPipeline(transform)
 	}
 
-	// Configure output type (H264) — must be set BEFORE input
+	// Configure output type (H264/HEVC) — must be set BEFORE input
 	if err := m.setOutputType(transform, width, height); err != nil {
 		comRelease(transform)
 		procMFShutdown.Call()
@@ -222,7 +253,8 @@ func (m *mftEncoder) initialize(width, height, stride int) error {
 	// Configure input type (NV12)
 	if err := m.setInputType(transform, width, height); err != nil {
 		// Hardware encoder may reject this format — fall back to software MFT
-		if isHW {
+		// (H264 only; see above)
+		if isHW && m.cfg.Codec != CodecH265 {
 			// The DXGI manager was installed on the hardware transform being
 			// discarded; the software MFT must not inherit zero-copy state.
 			if m.dxgiManager != 0 {
@@ -232,7 +264,7 @@ func (m *mftEncoder) initialize(width, height, stride int) error {
 			m.useDXGISamples = false
 			comRelease(transform)
 			slog.Warn("Hardware MFT rejected input type, falling back to software", "error", err.Error())
-			transform, err = m.enumAndActivate(mftEnumFlagSyncMFT|mftEnumFlagSortAndFilter, &mftRegisterTypeInfo{mfMediaTypeVideo, mfVideoFormatNV12}, &mftRegisterTypeInfo{mfMediaTypeVideo, mfVideoFormatH264})
+			transform, err = m.enumAndActivate(mftEnumFlagSyncMFT|mftEnumFlagSortAndFilter, &mftRegisterTypeInfo{mfMediaTypeVideo, mfVideoFormatNV12}, &mftRegisterTypeInfo{mfMediaTypeVideo, outputSubtype})
 			if err != nil {
 				procMFShutdown.Call()
 				return fmt.Errorf("software MFT fallback failed: %w", err)
@@ -416,6 +448,9 @@ func (m *mftEncoder) initialize(width, height, stride int) error {
 	if m.forceKeyframePending {
 		_ = m.forceKeyframeLocked()
 	}
+	if m.textMode {
+		m.applyTextModeLocked()
+	}
 
 	// NOTE: the DXGI device manager is installed earlier in this function
 	// (tryInitGPUPipeline, before media-type negotiation) when zero-copy
@@ -429,7 +464,8 @@ func (m *mftEncoder) initialize(width, height, stride int) error {
 	if isHW {
 		hwStr = "hardware"
 	}
-	slog.Info("MFT H264 encoder initialized",
+	slog.Info("MFT encoder initialized",
+		"codec", mftCodecName(m.cfg.Codec),
 		"type", hwStr,
 		"width", width,
 		"height", height,
@@ -452,7 +488,7 @@ func (m *mftEncoder) findEncoder(width, height int) (uintptr, bool, error) {
 	}
 	outputType := mftRegisterTypeInfo{
 		guidMajorType: mfMediaTypeVideo,
-		guidSubtype:   mfVideoFormatH264,
+		guidSubtype:   mftOutputSubtype(m.cfg.Codec),
 	}
 
 	// Hardware only — software H264 encoding is handled by OpenH264 which
@@ -467,6 +503,9 @@ func (m *mftEncoder) findEncoder(width, height int) (uintptr, bool, error) {
 		return transform, true, nil
 	}
 
+	if m.cfg.Codec == CodecH265 {
+		return 0, false, fmt.Errorf("no hardware HEVC encoder available")
+	}
 	return 0, false, fmt.Errorf("no hardware H264 encoder available (software encoding handled by OpenH264)")
 }
 
@@ -526,10 +565,11 @@ func (m *mftEncoder) setOutputType(transform uintptr, width, height int) error {
 		return err
 	}
 
-	// Subtype = H264
+	// Subtype = H264 / HEVC
+	subtype := mftOutputSubtype(m.cfg.Codec)
 	if _, err := comCall(mediaType, vtblSetGUID,
 		uintptr(unsafe.Pointer(&mfMTSubtype)),
-		uintptr(unsafe.Pointer(&mfVideoFormatH264)),
+		uintptr(unsafe.Pointer(&subtype)),
 	); err != nil {
 		return err
 	}
@@ -575,9 +615,14 @@ func (m *mftEncoder) setOutputType(transform uintptr, width, height int) error {
 	// H264 profile = Main (CABAC entropy coding = 10-15% better compression than
 	// Baseline's CAVLC, critical for text clarity in screen sharing).
 	// No B-frames needed — Main profile without B-frames still enables CABAC.
+	// HEVC uses Main (8-bit 4:2:0), the profile-id=1 the viewer negotiated.
+	profile := eAVEncH264VProfileMain
+	if m.cfg.Codec == CodecH265 {
+		profile = eAVEncH265VProfileMain420_8
+	}
 	if _, err := comCall(mediaType, vtblSetUINT32,
 		uintptr(unsafe.Pointer(&mfMTMpeg2Profile)),
-		uintptr(eAVEncH264VProfileMain),
+		uintptr(profile),
 	); err != nil {
 		// Non-fatal: encoder will use default profile
 		slog.Debug("Failed to set Main profile", "error", err.Error())
@@ -730,8 +775,8 @@ func (m *mftEncoder) unlockAsyncMFT(transform uintptr) error {
 // --- encoderBackend interface ---
 
 func (m *mftEncoder) SetCodec(codec Codec) error {
-	if codec != CodecH264 {
-		return fmt.Errorf("%w: MFT encoder only supports H264, got %s", ErrInvalidCodec, codec)
+	if codec != m.cfg.Codec {
+		return fmt.Errorf("%w: MFT encoder is configured for %s, got %s", ErrInvalidCodec, m.cfg.Codec, codec)
 	}
 	return nil
 }
@@ -743,6 +788,39 @@ func (m *mftEncoder) SetQuality(quality QualityPreset) error {
 	return nil
 }
 
+// SetTextMode implements optionalTextModeEncoder.
+func (m *mftEncoder) SetTextMode(enabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.textMode == enabled {
+		return nil
+	}
+	m.textMode = enabled
+	if m.inited {
+		m.applyTextModeLocked()
+	}
+	return nil
+}
+
+// applyTextModeLocked sets CODECAPI_AVEncVideoMaxQP for the current text
+// mode. Best-effort: MFTs that don't expose it keep their own range.
+func (m *mftEncoder) applyTextModeLocked() {
+	if m.codecAPI == 0 {
+		return
+	}
+	maxQP := uint32(51)
+	if m.textMode {
+		maxQP = textModeMaxQP
+	}
+	v := comVariant{vt: vtUI4, val: uint64(maxQP)}
+	if _, err := comCall(m.codecAPI, vtblCodecAPISetValue,
+		uintptr(unsafe.Pointer(&codecAPIAVEncVideoMaxQP)),
+		uintptr(unsafe.Pointer(&v)),
+	); err != nil {
+		slog.Debug("ICodecAPI SetValue(AVEncVideoMaxQP) failed (non-fatal)", "maxQP", maxQP, "error", err.Error())
+	}
+}
+
 func (m *mftEncoder) SetBitrate(bitrate int) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -815,6 +893,11 @@ func (m *mftEncoder) SetDimensions(w, h int) error {
 	// lazy init hasn't run yet.
 	if !m.inited && m.width > 0 && m.height > 0 {
 		if err := m.initialize(m.width, m.height, m.stride); err != nil {
+			// HEVC is only chosen if it initializes; report the failure so
+			// session setup falls back to H264.
+			if m.cfg.Codec == CodecH265 {
+				return err
+			}
 			slog.Warn("Eager MFT initialization failed, will retry on first encode",
 				"error", err.Error(), "width", m.width, "height", m.height)
 			// Non-fatal: lazy init on first Encode() will retry
@@ -827,6 +910,7 @@ func (m *mftEncoder) Close() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.shutdown()
+	m.yuv.reset()
 	return nil
 }
 
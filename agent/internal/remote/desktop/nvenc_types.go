@@ -1,4 +1,4 @@
-//go:build windows
+//go:build windows || (linux && (amd64 || arm64))
 
 package desktop
 
@@ -14,6 +14,8 @@ import (
 // (tag n12.2.72.0). All reserved field sizes are verified against the SDK
 // header sizeof values. Wrong sizes cause NV_ENC_ERR_INVALID_VERSION at
 // runtime or memory corruption — the init() assertions catch mismatches.
+// Shared by the Windows (D3D11) and Linux (CUDA) backends; the layouts
+// assume 64-bit pointers, hence the amd64/arm64 constraint on Linux.
 // =============================================================================
 
 // --- Version macros ---
@@ -73,11 +75,13 @@ var (
 
 const (
 	// Device and resource types
-	nvencDeviceTypeDX  uint32 = 0 // NV_ENC_DEVICE_TYPE_DIRECTX
-	nvencInputResDX    uint32 = 0 // NV_ENC_INPUT_RESOURCE_TYPE_DIRECTX
-	nvencBufFmtARGB    uint32 = 0x01000000
-	nvencBufFmtNV12    uint32 = 0x00000001
-	nvencBufUsageInput uint32 = 0 // NV_ENC_INPUT_IMAGE
+	nvencDeviceTypeDX   uint32 = 0 // NV_ENC_DEVICE_TYPE_DIRECTX
+	nvencDeviceTypeCUDA uint32 = 1 // NV_ENC_DEVICE_TYPE_CUDA
+	nvencInputResDX     uint32 = 0 // NV_ENC_INPUT_RESOURCE_TYPE_DIRECTX
+	nvencBufFmtARGB     uint32 = 0x01000000
+	nvencBufFmtABGR     uint32 = 0x10000000
+	nvencBufFmtNV12     uint32 = 0x00000001
+	nvencBufUsageInput  uint32 = 0 // NV_ENC_INPUT_IMAGE
 
 	// Rate control
 	nvencRCConstQP uint32 = 0
@@ -222,6 +226,31 @@ type nvencCreateBitstreamBuffer struct {
 	_res2    [64]uintptr
 }
 
+// NV_ENC_CREATE_INPUT_BUFFER — 776 bytes
+type nvencCreateInputBuffer struct {
+	Version     uint32
+	Width       uint32
+	Height      uint32
+	_memHeap    uint32 // deprecated
+	BufferFmt   uint32
+	_res0       uint32
+	InputBuffer uintptr // out: handle for Lock/UnlockInputBuffer and EncodePicture
+	_sysMemBuf  uintptr // reserved
+	_res1       [58]uint32
+	_res2       [63]uintptr
+}
+
+// NV_ENC_LOCK_INPUT_BUFFER — 1544 bytes
+type nvencLockInputBuffer struct {
+	Version       uint32
+	Flags         uint32  // bitfield: doNotWait(0)
+	InputBuffer   uintptr // in: from CreateInputBuffer
+	BufferDataPtr uintptr // out: host pointer to the locked buffer
+	Pitch         uint32  // out: row pitch in bytes
+	_res1         [251]uint32
+	_res2         [64]uintptr
+}
+
 // NV_ENC_REGISTER_RESOURCE — 1536 bytes
 type nvencRegisterResource struct {
 	Version    uint32
@@ -390,6 +419,8 @@ func init() {
 	assertSize("nvencFuncList", unsafe.Sizeof(nvencFuncList{}), 2552)
 	assertSize("nvencOpenSessionParams", unsafe.Sizeof(nvencOpenSessionParams{}), 1552)
 	assertSize("nvencCreateBitstreamBuffer", unsafe.Sizeof(nvencCreateBitstreamBuffer{}), 776)
+	assertSize("nvencCreateInputBuffer", unsafe.Sizeof(nvencCreateInputBuffer{}), 776)
+	assertSize("nvencLockInputBuffer", unsafe.Sizeof(nvencLockInputBuffer{}), 1544)
 	assertSize("nvencRegisterResource", unsafe.Sizeof(nvencRegisterResource{}), 1536)
 	assertSize("nvencMapInputResource", unsafe.Sizeof(nvencMapInputResource{}), 1544)
 	assertSize("nvencLockBitstream", unsafe.Sizeof(nvencLockBitstream{}), 1544)
@@ -0,0 +1,208 @@
+package desktop
+
+// Privacy ("curtain") mode for admin remote-desktop sessions.
+//
+// While engaged, the target's PHYSICAL display is blanked — optionally showing
+// a "maintenance in progress" banner — so someone standing at the machine
+// cannot watch the technician work with sensitive data. The capture stream is
+// unaffected: the operator keeps seeing the real desktop. That constraint
+// rules out the obvious primitives (monitor power-off stops desktop
+// duplication and is woken by the operator's own injected input;
+// CGDisplayCapture blanks what ScreenCaptureKit reads too), so each backend
+// blanks the glass without touching the framebuffer:
+//
+//   - Windows: a topmost, click-through, non-activating black overlay marked
+//     WDA_EXCLUDEFROMCAPTURE, so DXGI/GDI capture see straight through it.
+//   - macOS: a zeroed Quartz Display Services gamma table, applied at scanout
+//     after the framebuffer capture reads.
+//
+// Release paths mirror local-input blocking (see input_block.go): explicit
+// toggle, session end (doCleanup), a max-duration watchdog, and process death
+// (the overlay window dies with its thread; macOS restores gamma when the
+// process exits).
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxPrivacyModeDuration bounds how long the display can stay blanked without
+// an explicit release, matching maxBlockDuration.
+const maxPrivacyModeDuration = maxBlockDuration
+
+// DefaultPrivacyBanner is shown when the viewer asks for a banner without
+// supplying its own text.
+const DefaultPrivacyBanner = "Maintenance in progress — please do not use this computer"
+
+// maxPrivacyBannerRunes caps viewer-supplied banner text.
+const maxPrivacyBannerRunes = 200
+
+// privacyBackend is the platform-specific display blanker. Implementations
+// live in privacy_mode_{windows,darwin,other}.go.
+type privacyBackend interface {
+	// Enable blanks the physical display, drawing banner when non-empty and
+	// BannerSupported. The manager only calls it on the 0->1 transition.
+	Enable(banner string) error
+	// Disable restores the display. Must be safe after a failed Enable.
+	Disable() error
+	// Supported reports whether this platform/build can blank the display
+	// without also blanking the capture stream.
+	Supported() bool
+	// BannerSupported reports whether Enable can show banner text.
+	BannerSupported() bool
+}
+
+// PrivacyModeManager provides refcounted, watchdog-guarded display blanking.
+type PrivacyModeManager struct {
+	mu       sync.Mutex
+	refCount int
+	backend  privacyBackend
+	engaged  bool
+	banner   string
+
+	watchdogStop chan struct{}
+	maxDuration  time.Duration
+}
+
+var (
+	privacyMgrOnce     sync.Once
+	privacyMgrInstance *PrivacyModeManager
+)
+
+// GetPrivacyModeManager returns the package-level singleton PrivacyModeManager.
+func GetPrivacyModeManager() *PrivacyModeManager {
+	privacyMgrOnce.Do(func() {
+		privacyMgrInstance = &PrivacyModeManager{
+			backend:     newPrivacyBackend(),
+			maxDuration: maxPrivacyModeDuration,
+		}
+	})
+	return privacyMgrInstance
+}
+
+// Supported reports whether privacy mode is implemented on this platform.
+func (m *PrivacyModeManager) Supported() bool {
+	return m.backend.Supported()
+}
+
+// IsEngaged reports whether the display is currently blanked.
+func (m *PrivacyModeManager) IsEngaged() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.engaged
+}
+
+// Engage blanks the physical display. Reference-counted like
+// InputBlockManager.Engage: the first caller's banner is the one shown.
+// Returns (supported, bannerShown, error).
+func (m *PrivacyModeManager) Engage(banner string) (supported, bannerShown bool, err error) {
+	if !m.backend.Supported() {
+		return false, false, nil
+	}
+	if !m.backend.BannerSupported() {
+		banner = ""
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.refCount++
+	if m.refCount > 1 {
+		return true, m.banner != "", nil
+	}
+
+	if err := m.backend.Enable(banner); err != nil {
+		m.refCount--
+		return true, false, err
+	}
+	m.engaged = true
+	m.banner = banner
+	m.watchdogStop = make(chan struct{})
+	go m.runWatchdog(m.watchdogStop)
+
+	slog.Info("Privacy mode enabled on target", "banner", banner != "", "maxDuration", m.maxDuration.String())
+	return true, banner != "", nil
+}
+
+// Release restores the display once the last engaged session releases it.
+// Idempotent once the refcount reaches zero.
+func (m *PrivacyModeManager) Release() error {
+	if !m.backend.Supported() {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.refCount <= 0 {
+		m.refCount = 0
+		return nil
+	}
+	m.refCount--
+	if m.refCount > 0 {
+		return nil
+	}
+	return m.disableLocked()
+}
+
+// disableLocked restores the display and stops the watchdog. Caller holds m.mu.
+func (m *PrivacyModeManager) disableLocked() error {
+	if !m.engaged {
+		return nil
+	}
+	if m.watchdogStop != nil {
+		close(m.watchdogStop)
+		m.watchdogStop = nil
+	}
+	err := m.backend.Disable()
+	m.engaged = false
+	m.banner = ""
+	if err != nil {
+		slog.Warn("Failed to disable privacy mode", "error", err.Error())
+		return err
+	}
+	slog.Info("Privacy mode disabled on target")
+	return nil
+}
+
+// runWatchdog force-restores the display after maxDuration; see
+// InputBlockManager.runWatchdog.
+func (m *PrivacyModeManager) runWatchdog(stop chan struct{}) {
+	timer := time.NewTimer(m.maxDuration)
+	defer timer.Stop()
+
+	select {
+	case <-stop:
+		return
+	case <-timer.C:
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.watchdogStop != stop || !m.engaged {
+			return
+		}
+		slog.Warn("Privacy mode exceeded max duration, restoring display for safety",
+			"maxDuration", m.maxDuration.String())
+		m.refCount = 0
+		_ = m.disableLocked()
+	}
+}
+
+// privacyBannerText resolves the banner requested by the viewer: empty when
+// no banner was asked for, the default text when one was asked for without
+// text, otherwise the supplied text trimmed to a single bounded line.
+func privacyBannerText(show bool, text string) string {
+	if !show {
+		return ""
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return DefaultPrivacyBanner
+	}
+	if utf8.RuneCountInString(text) > maxPrivacyBannerRunes {
+		text = string([]rune(text)[:maxPrivacyBannerRunes])
+	}
+	return text
+}
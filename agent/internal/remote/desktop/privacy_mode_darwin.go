@@ -0,0 +1,108 @@
+//go:build darwin && cgo
+
+package desktop
+
+/*
+#cgo LDFLAGS: -framework CoreGraphics
+
+#include <CoreGraphics/CoreGraphics.h>
+
+// blankAllDisplays zeroes the gamma transfer of every online display so the
+// panel shows black while the framebuffer (what capture reads) is untouched.
+// Returns the number of displays blanked, or -1 if none could be.
+static int blankAllDisplays(void) {
+    CGDirectDisplayID displays[16];
+    uint32_t count = 0;
+    if (CGGetOnlineDisplayList(16, displays, &count) != kCGErrorSuccess || count == 0) {
+        return -1;
+    }
+    int blanked = 0;
+    for (uint32_t i = 0; i < count; i++) {
+        if (CGSetDisplayTransferByFormula(displays[i],
+                0, 0, 1,
+                0, 0, 1,
+                0, 0, 1) == kCGErrorSuccess) {
+            blanked++;
+        }
+    }
+    return blanked > 0 ? blanked : -1;
+}
+
+static void restoreAllDisplays(void) {
+    CGDisplayRestoreColorSyncSettings();
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// darwinPrivacyReassertInterval re-applies the zero gamma so a newly attached
+// display, or one the system reconfigured (which resets its gamma), is
+// blanked too.
+const darwinPrivacyReassertInterval = 2 * time.Second
+
+// darwinPrivacyBackend blanks the panel with Quartz Display Services gamma
+// tables. CGDisplayCapture would also blank the display, but it hides the
+// desktop from ScreenCaptureKit/CGDisplayStream as well; gamma is applied
+// at scanout, after capture reads the framebuffer. The window server
+// restores ColorSync gamma when the process exits, so a crashed helper
+// cannot leave the screen dark. A black panel cannot carry banner text.
+type darwinPrivacyBackend struct {
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+func newPrivacyBackend() privacyBackend {
+	return &darwinPrivacyBackend{}
+}
+
+func (b *darwinPrivacyBackend) Supported() bool       { return true }
+func (b *darwinPrivacyBackend) BannerSupported() bool { return false }
+
+func (b *darwinPrivacyBackend) Enable(string) error {
+	if C.blankAllDisplays() < 0 {
+		C.restoreAllDisplays()
+		return errors.New("CGSetDisplayTransferByFormula failed for every display")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stop == nil {
+		b.stop = make(chan struct{})
+		go b.reassert(b.stop)
+	}
+	return nil
+}
+
+func (b *darwinPrivacyBackend) Disable() error {
+	// Restore under the lock so an in-flight reassert tick cannot re-blank
+	// the display after it was restored.
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stop != nil {
+		close(b.stop)
+		b.stop = nil
+	}
+	C.restoreAllDisplays()
+	return nil
+}
+
+func (b *darwinPrivacyBackend) reassert(stop chan struct{}) {
+	ticker := time.NewTicker(darwinPrivacyReassertInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			if b.stop == stop {
+				C.blankAllDisplays()
+			}
+			b.mu.Unlock()
+		}
+	}
+}
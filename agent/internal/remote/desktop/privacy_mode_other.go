@@ -0,0 +1,18 @@
+//go:build !windows && !(darwin && cgo)
+
+package desktop
+
+// otherPrivacyBackend is a no-op for Linux, macOS builds without cgo, and any
+// other platform. On X11 a DPMS force-off would keep XGetImage capture intact
+// but is woken by the operator's own XTest input, and Wayland compositors
+// offer no equivalent, so Linux reports unsupported for now.
+type otherPrivacyBackend struct{}
+
+func newPrivacyBackend() privacyBackend {
+	return &otherPrivacyBackend{}
+}
+
+func (b *otherPrivacyBackend) Supported() bool       { return false }
+func (b *otherPrivacyBackend) BannerSupported() bool { return false }
+func (b *otherPrivacyBackend) Enable(string) error   { return nil }
+func (b *otherPrivacyBackend) Disable() error        { return nil }
@@ -0,0 +1,156 @@
+package desktop
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// stubPrivacyBackend records calls for testing the manager state machine.
+type stubPrivacyBackend struct {
+	mu           sync.Mutex
+	supported    bool
+	banner       bool
+	enableCount  int
+	disableCount int
+	lastBanner   string
+	failEnable   bool
+}
+
+func (s *stubPrivacyBackend) Supported() bool       { return s.supported }
+func (s *stubPrivacyBackend) BannerSupported() bool { return s.banner }
+
+func (s *stubPrivacyBackend) Enable(banner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enableCount++
+	if s.failEnable {
+		return errors.New("Enable failed")
+	}
+	s.lastBanner = banner
+	return nil
+}
+
+func (s *stubPrivacyBackend) Disable() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.disableCount++
+	return nil
+}
+
+func (s *stubPrivacyBackend) counts() (enable, disable int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enableCount, s.disableCount
+}
+
+func newTestPrivacyManager(backend *stubPrivacyBackend) *PrivacyModeManager {
+	return &PrivacyModeManager{backend: backend, maxDuration: time.Hour}
+}
+
+func TestPrivacyMode_RefcountedEngageAndRelease(t *testing.T) {
+	backend := &stubPrivacyBackend{supported: true, banner: true}
+	mgr := newTestPrivacyManager(backend)
+
+	supported, shown, err := mgr.Engage("Maintenance")
+	if err != nil || !supported || !shown {
+		t.Fatalf("Engage = (%v, %v, %v), want (true, true, nil)", supported, shown, err)
+	}
+	// A second session only bumps the refcount; the first banner stays.
+	if _, shown, _ := mgr.Engage(""); !shown {
+		t.Fatal("second Engage should report the banner already shown")
+	}
+	if e, _ := backend.counts(); e != 1 {
+		t.Fatalf("expected 1 Enable call, got %d", e)
+	}
+
+	_ = mgr.Release()
+	if !mgr.IsEngaged() {
+		t.Fatal("display restored while another session still holds privacy mode")
+	}
+	_ = mgr.Release()
+	if mgr.IsEngaged() {
+		t.Fatal("expected display restored after last Release")
+	}
+	if _, d := backend.counts(); d != 1 {
+		t.Fatalf("expected 1 Disable call, got %d", d)
+	}
+	// Extra releases are no-ops.
+	_ = mgr.Release()
+	if _, d := backend.counts(); d != 1 {
+		t.Fatalf("extra Release called Disable again (%d)", d)
+	}
+}
+
+func TestPrivacyMode_BannerDroppedWhenUnsupported(t *testing.T) {
+	backend := &stubPrivacyBackend{supported: true}
+	mgr := newTestPrivacyManager(backend)
+
+	_, shown, err := mgr.Engage("Maintenance")
+	if err != nil {
+		t.Fatalf("Engage: %v", err)
+	}
+	if shown || backend.lastBanner != "" {
+		t.Fatalf("banner passed to a backend without banner support (shown=%v, banner=%q)", shown, backend.lastBanner)
+	}
+}
+
+func TestPrivacyMode_EnableFailureRollsBack(t *testing.T) {
+	backend := &stubPrivacyBackend{supported: true, failEnable: true}
+	mgr := newTestPrivacyManager(backend)
+
+	if _, _, err := mgr.Engage(""); err == nil {
+		t.Fatal("expected Engage error")
+	}
+	if mgr.IsEngaged() || mgr.refCount != 0 {
+		t.Fatalf("failed Engage left state behind (engaged=%v, refCount=%d)", mgr.IsEngaged(), mgr.refCount)
+	}
+}
+
+func TestPrivacyMode_Unsupported(t *testing.T) {
+	backend := &stubPrivacyBackend{}
+	mgr := newTestPrivacyManager(backend)
+
+	supported, _, err := mgr.Engage("x")
+	if supported || err != nil {
+		t.Fatalf("Engage = (%v, %v), want (false, nil)", supported, err)
+	}
+	if e, _ := backend.counts(); e != 0 {
+		t.Fatalf("unsupported platform called Enable %d times", e)
+	}
+}
+
+func TestPrivacyMode_WatchdogRestoresDisplay(t *testing.T) {
+	backend := &stubPrivacyBackend{supported: true}
+	mgr := &PrivacyModeManager{backend: backend, maxDuration: 20 * time.Millisecond}
+
+	if _, _, err := mgr.Engage(""); err != nil {
+		t.Fatalf("Engage: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for mgr.IsEngaged() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if mgr.IsEngaged() {
+		t.Fatal("watchdog did not restore the display")
+	}
+}
+
+func TestPrivacyBannerText(t *testing.T) {
+	if got := privacyBannerText(false, "ignored"); got != "" {
+		t.Errorf("no banner requested: got %q", got)
+	}
+	if got := privacyBannerText(true, "  "); got != DefaultPrivacyBanner {
+		t.Errorf("empty text: got %q, want default", got)
+	}
+	if got := privacyBannerText(true, "Back\nin  5 minutes"); got != "Back in 5 minutes" {
+		t.Errorf("whitespace not collapsed: got %q", got)
+	}
+	long := privacyBannerText(true, strings.Repeat("é", 500))
+	if n := utf8.RuneCountInString(long); n != maxPrivacyBannerRunes {
+		t.Errorf("long text has %d runes, want %d", n, maxPrivacyBannerRunes)
+	}
+}
@@ -0,0 +1,379 @@
+//go:build windows
+
+package desktop
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// windowsPrivacyBackend blanks the physical display with a black overlay
+// window covering the whole virtual screen. The window is:
+//
+//   - WDA_EXCLUDEFROMCAPTURE, so Desktop Duplication, WGC and GDI BitBlt all
+//     capture the desktop underneath it — the operator's view is unchanged.
+//     This needs Windows 10 2004 (build 19041); on older builds the flag
+//     degrades to WDA_MONITOR, which would stream the overlay as a black
+//     rectangle, so the backend reports unsupported there.
+//   - topmost, non-activating and click-through (WS_EX_TRANSPARENT on a
+//     layered window), so the operator's injected input reaches the windows
+//     below and focus never moves to it. A 1s timer re-asserts topmost in
+//     case another topmost window surfaces above it.
+//
+// Monitor power-off (SC_MONITORPOWER) was rejected: Desktop Duplication stops
+// producing frames while the panel is off, and the operator's own SendInput
+// wakes it. The overlay lives on the default desktop; UAC and the lock screen
+// run on the secure desktop and are shown unblanked. If the helper dies the
+// window is destroyed with its thread, so the screen can never stay dark.
+type windowsPrivacyBackend struct{}
+
+var (
+	privacyGdi32 = syscall.NewLazyDLL("gdi32.dll")
+
+	procPrivacyRegisterClassExW  = user32.NewProc("RegisterClassExW")
+	procPrivacyCreateWindowExW   = user32.NewProc("CreateWindowExW")
+	procPrivacyDefWindowProcW    = user32.NewProc("DefWindowProcW")
+	procPrivacyDestroyWindow     = user32.NewProc("DestroyWindow")
+	procPrivacyShowWindow        = user32.NewProc("ShowWindow")
+	procPrivacyGetMessageW       = user32.NewProc("GetMessageW")
+	procPrivacyTranslateMessage  = user32.NewProc("TranslateMessage")
+	procPrivacyDispatchMessageW  = user32.NewProc("DispatchMessageW")
+	procPrivacyPostMessageW      = user32.NewProc("PostMessageW")
+	procPrivacyPostQuitMessage   = user32.NewProc("PostQuitMessage")
+	procPrivacyBeginPaint        = user32.NewProc("BeginPaint")
+	procPrivacyEndPaint          = user32.NewProc("EndPaint")
+	procPrivacyDrawTextW         = user32.NewProc("DrawTextW")
+	procPrivacyGetClientRect     = user32.NewProc("GetClientRect")
+	procPrivacySetWindowPos      = user32.NewProc("SetWindowPos")
+	procPrivacySetTimer          = user32.NewProc("SetTimer")
+	procPrivacySetLayeredAttrs   = user32.NewProc("SetLayeredWindowAttributes")
+	procSetWindowDisplayAffinity = user32.NewProc("SetWindowDisplayAffinity")
+	procPrivacyGetModuleHandleW  = kernel32.NewProc("GetModuleHandleW")
+	procPrivacyGetStockObject    = privacyGdi32.NewProc("GetStockObject")
+	procPrivacySetBkMode         = privacyGdi32.NewProc("SetBkMode")
+	procPrivacySetTextColor      = privacyGdi32.NewProc("SetTextColor")
+	procPrivacyCreateFontW       = privacyGdi32.NewProc("CreateFontW")
+	procPrivacySelectObject      = privacyGdi32.NewProc("SelectObject")
+	procPrivacyDeleteObject      = privacyGdi32.NewProc("DeleteObject")
+)
+
+const (
+	pwsPopup         = 0x80000000
+	pwsExTopmost     = 0x00000008
+	pwsExToolwindow  = 0x00000080
+	pwsExNoactivate  = 0x08000000
+	pwsExLayered     = 0x00080000
+	pwsExTransparent = 0x00000020
+	pwmDestroy       = 0x0002
+	pwmPaint         = 0x000F
+	pwmClose         = 0x0010
+	pwmDisplayChange = 0x007E
+	pwmTimer         = 0x0113
+	pswShowNoactive  = 4
+	pLWAAlpha        = 0x00000002
+	pBlackBrush      = 4 // GetStockObject(BLACK_BRUSH)
+	pTransparentBk   = 1
+	pdtCenter        = 0x0001
+	pdtVCenter       = 0x0004
+	pdtWordBreak     = 0x0010
+	pdtNoPrefix      = 0x0800
+	pSwpNoactivate   = 0x0010
+	pHwndTopmost     = ^uintptr(0) // (HWND)-1
+
+	wdaExcludeFromCapture = 0x00000011
+
+	// minExcludeFromCaptureBuild is Windows 10 2004, the first build where
+	// WDA_EXCLUDEFROMCAPTURE hides the window from capture.
+	minExcludeFromCaptureBuild = 19041
+
+	privacyTimerID       = 1
+	privacyTimerTickMs   = 1000
+	privacyCreateTimeout = 5 * time.Second
+	privacyClassName     = "BreezePrivacyOverlay"
+	privacyTextColor     = 0x00DCDCDC // light grey, COLORREF 0x00BBGGRR
+)
+
+var (
+	privacyMu       sync.Mutex
+	privacyHwnd     uintptr
+	privacyBannerU  []uint16
+	privacyClassReg sync.Once
+)
+
+type privacyRect struct{ left, top, right, bottom int32 }
+
+type privacyMsg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	ptX     int32
+	ptY     int32
+}
+
+type privacyPaintStruct struct {
+	hdc         uintptr
+	fErase      int32
+	rcPaint     privacyRect
+	fRestore    int32
+	fIncUpdate  int32
+	rgbReserved [32]byte
+}
+
+type privacyWndClassEx struct {
+	cbSize        uint32
+	style         uint32
+	lpfnWndProc   uintptr
+	cbClsExtra    int32
+	cbWndExtra    int32
+	hInstance     uintptr
+	hIcon         uintptr
+	hCursor       uintptr
+	hbrBackground uintptr
+	lpszMenuName  *uint16
+	lpszClassName *uint16
+	hIconSm       uintptr
+}
+
+func newPrivacyBackend() privacyBackend {
+	return &windowsPrivacyBackend{}
+}
+
+func (b *windowsPrivacyBackend) Supported() bool {
+	return procSetWindowDisplayAffinity.Find() == nil &&
+		windows.RtlGetVersion().BuildNumber >= minExcludeFromCaptureBuild
+}
+
+func (b *windowsPrivacyBackend) BannerSupported() bool { return true }
+
+func (b *windowsPrivacyBackend) Enable(banner string) error {
+	var u16 []uint16
+	if banner != "" {
+		var err error
+		if u16, err = syscall.UTF16FromString(banner); err != nil {
+			return fmt.Errorf("privacy banner: %w", err)
+		}
+	}
+	privacyMu.Lock()
+	if privacyHwnd != 0 {
+		privacyMu.Unlock()
+		return nil
+	}
+	privacyBannerU = u16
+	privacyMu.Unlock()
+
+	// Same handoff as the session banner window: ready is unbuffered, so a
+	// window created after Enable gave up is destroyed, never shown.
+	type created struct {
+		hwnd uintptr
+		err  error
+	}
+	ready := make(chan created)
+	abandoned := make(chan struct{})
+	go privacyWindowLoop(func(hwnd uintptr, err error) bool {
+		select {
+		case ready <- created{hwnd, err}:
+			return true
+		case <-abandoned:
+			return false
+		}
+	})
+	select {
+	case c := <-ready:
+		if c.err != nil {
+			return c.err
+		}
+		privacyMu.Lock()
+		privacyHwnd = c.hwnd
+		privacyMu.Unlock()
+		return nil
+	case <-time.After(privacyCreateTimeout):
+		close(abandoned)
+		return errors.New("privacy overlay creation timed out")
+	}
+}
+
+func (b *windowsPrivacyBackend) Disable() error {
+	privacyMu.Lock()
+	hwnd := privacyHwnd
+	privacyHwnd = 0
+	privacyBannerU = nil
+	privacyMu.Unlock()
+	if hwnd != 0 {
+		procPrivacyPostMessageW.Call(hwnd, pwmClose, 0, 0)
+	}
+	return nil
+}
+
+func registerPrivacyClass() {
+	privacyClassReg.Do(func() {
+		hInst, _, _ := procPrivacyGetModuleHandleW.Call(0)
+		brush, _, _ := procPrivacyGetStockObject.Call(pBlackBrush)
+		className, _ := syscall.UTF16PtrFromString(privacyClassName)
+		wc := privacyWndClassEx{
+			cbSize:        uint32(unsafe.Sizeof(privacyWndClassEx{})),
+			lpfnWndProc:   syscall.NewCallback(privacyWndProc),
+			hInstance:     hInst,
+			hbrBackground: brush,
+			lpszClassName: className,
+		}
+		procPrivacyRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc)))
+	})
+}
+
+// privacyVirtualScreen returns the bounds spanning every monitor.
+func privacyVirtualScreen() (x, y, w, h int32) {
+	vx, _, _ := getSystemMetrics.Call(SM_XVIRTUALSCREEN)
+	vy, _, _ := getSystemMetrics.Call(SM_YVIRTUALSCREEN)
+	vw, _, _ := getSystemMetrics.Call(SM_CXVIRTUALSCREEN)
+	vh, _, _ := getSystemMetrics.Call(SM_CYVIRTUALSCREEN)
+	return int32(vx), int32(vy), int32(vw), int32(vh)
+}
+
+func privacyCoverScreen(hwnd uintptr) {
+	x, y, w, h := privacyVirtualScreen()
+	procPrivacySetWindowPos.Call(hwnd, pHwndTopmost, uintptr(x), uintptr(y), uintptr(w), uintptr(h), pSwpNoactivate)
+}
+
+func privacyWndProc(hwnd uintptr, msg uint32, wParam, lParam uintptr) uintptr {
+	switch msg {
+	case pwmPaint:
+		privacyPaint(hwnd)
+		return 0
+	case pwmTimer, pwmDisplayChange:
+		privacyCoverScreen(hwnd)
+		return 0
+	case pwmClose:
+		procPrivacyDestroyWindow.Call(hwnd)
+		return 0
+	case pwmDestroy:
+		procPrivacyPostQuitMessage.Call(0)
+		return 0
+	}
+	ret, _, _ := procPrivacyDefWindowProcW.Call(hwnd, uintptr(msg), wParam, lParam)
+	return ret
+}
+
+// privacyPaint draws the banner centered on the primary monitor's area of
+// the overlay; the class brush has already painted the background black.
+func privacyPaint(hwnd uintptr) {
+	var ps privacyPaintStruct
+	hdc, _, _ := procPrivacyBeginPaint.Call(hwnd, uintptr(unsafe.Pointer(&ps)))
+	if hdc == 0 {
+		return
+	}
+	defer procPrivacyEndPaint.Call(hwnd, uintptr(unsafe.Pointer(&ps)))
+
+	privacyMu.Lock()
+	banner := privacyBannerU
+	privacyMu.Unlock()
+	if len(banner) <= 1 {
+		return
+	}
+
+	// The primary monitor's origin is (0,0) in screen coordinates; in client
+	// coordinates it sits at -virtualScreen origin.
+	vx, vy, _, _ := privacyVirtualScreen()
+	pw, _, _ := getSystemMetrics.Call(0) // SM_CXSCREEN
+	ph, _, _ := getSystemMetrics.Call(1) // SM_CYSCREEN
+	rc := privacyRect{-vx, -vy, -vx + int32(pw), -vy + int32(ph)}
+	if rc.right <= rc.left || rc.bottom <= rc.top {
+		procPrivacyGetClientRect.Call(hwnd, uintptr(unsafe.Pointer(&rc)))
+	}
+	// Keep the text to the middle band of the screen.
+	margin := (rc.right - rc.left) / 8
+	band := (rc.bottom - rc.top) / 3
+	textRect := privacyRect{rc.left + margin, rc.top + band, rc.right - margin, rc.bottom - band}
+
+	face, _ := syscall.UTF16FromString("Segoe UI")
+	height := -((rc.bottom - rc.top) / 24)
+	font, _, _ := procPrivacyCreateFontW.Call(
+		uintptr(height), 0, 0, 0,
+		400, // weight
+		0, 0, 0,
+		1, 0, 0, // DEFAULT_CHARSET
+		5, 0, // CLEARTYPE_QUALITY
+		uintptr(unsafe.Pointer(&face[0])),
+	)
+	if font != 0 {
+		prev, _, _ := procPrivacySelectObject.Call(hdc, font)
+		defer func() {
+			procPrivacySelectObject.Call(hdc, prev)
+			procPrivacyDeleteObject.Call(font)
+		}()
+	}
+	procPrivacySetBkMode.Call(hdc, pTransparentBk)
+	procPrivacySetTextColor.Call(hdc, privacyTextColor)
+	procPrivacyDrawTextW.Call(hdc, uintptr(unsafe.Pointer(&banner[0])), uintptr(len(banner)-1),
+		uintptr(unsafe.Pointer(&textRect)), pdtCenter|pdtVCenter|pdtWordBreak|pdtNoPrefix)
+}
+
+// privacyWindowLoop creates the overlay on the input desktop and pumps its
+// message loop on a dedicated locked OS thread. handoff reports the result;
+// it returns false when the caller stopped waiting, in which case the window
+// is destroyed without being shown.
+func privacyWindowLoop(handoff func(hwnd uintptr, err error) bool) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// The helper may run as SYSTEM; put this thread on the interactive
+	// desktop so the overlay appears where the local user is looking.
+	if hDesk, _, _ := procOpenInputDesktop.Call(0, 0, uintptr(desktopGenericAll)); hDesk != 0 {
+		if ret, _, _ := procSetThreadDesktop.Call(hDesk); ret == 0 {
+			procCloseDesktop.Call(hDesk)
+		} else {
+			defer procCloseDesktop.Call(hDesk)
+		}
+	}
+
+	registerPrivacyClass()
+	x, y, w, h := privacyVirtualScreen()
+	className, _ := syscall.UTF16PtrFromString(privacyClassName)
+	title, _ := syscall.UTF16PtrFromString("Privacy mode")
+	hInst, _, _ := procPrivacyGetModuleHandleW.Call(0)
+	hwnd, _, err := procPrivacyCreateWindowExW.Call(
+		pwsExTopmost|pwsExToolwindow|pwsExNoactivate|pwsExLayered|pwsExTransparent,
+		uintptr(unsafe.Pointer(className)),
+		uintptr(unsafe.Pointer(title)),
+		pwsPopup,
+		uintptr(x), uintptr(y), uintptr(w), uintptr(h),
+		0, 0, hInst, 0,
+	)
+	if hwnd == 0 {
+		handoff(0, fmt.Errorf("CreateWindowEx: %w", err))
+		return
+	}
+	// Exclude from capture BEFORE the window is ever visible, so not a single
+	// frame of the overlay reaches the stream.
+	if ret, _, err := procSetWindowDisplayAffinity.Call(hwnd, wdaExcludeFromCapture); ret == 0 {
+		procPrivacyDestroyWindow.Call(hwnd)
+		handoff(0, fmt.Errorf("SetWindowDisplayAffinity: %w", err))
+		return
+	}
+	if !handoff(hwnd, nil) {
+		procPrivacyDestroyWindow.Call(hwnd)
+		return
+	}
+	procPrivacySetLayeredAttrs.Call(hwnd, 0, 255, pLWAAlpha)
+	procPrivacyShowWindow.Call(hwnd, pswShowNoactive)
+	privacyCoverScreen(hwnd)
+	procPrivacySetTimer.Call(hwnd, privacyTimerID, privacyTimerTickMs, 0)
+
+	var msg privacyMsg
+	for {
+		ret, _, _ := procPrivacyGetMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+		if ret == 0 || int32(ret) == -1 { // WM_QUIT or error
+			return
+		}
+		procPrivacyTranslateMessage.Call(uintptr(unsafe.Pointer(&msg)))
+		procPrivacyDispatchMessageW.Call(uintptr(unsafe.Pointer(&msg)))
+	}
+}
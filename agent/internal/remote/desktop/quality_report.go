@@ -0,0 +1,52 @@
+package desktop
+
+import (
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/ipc"
+)
+
+// metricsInterval is how often a session logs its metrics and reports a
+// quality sample.
+const metricsInterval = 10 * time.Second
+
+// qualitySample builds the sample for the interval between two snapshots of
+// the same session's metrics. Frame and byte counts are differenced; RTT,
+// loss and encode time are the latest values.
+func qualitySample(sessionID string, prev, cur MetricsSnapshot, at time.Time) ipc.DesktopQualitySample {
+	sample := ipc.DesktopQualitySample{
+		SessionID:     sessionID,
+		AtUnixMs:      at.UnixMilli(),
+		IntervalMs:    (cur.Uptime - prev.Uptime).Milliseconds(),
+		EncodeMs:      cur.EncodeMs,
+		RTTMs:         cur.RTTMs,
+		LossPct:       cur.LossPct,
+		FramesDropped: cur.FramesDropped - prev.FramesDropped,
+	}
+	if secs := (cur.Uptime - prev.Uptime).Seconds(); secs > 0 {
+		sample.FPS = float64(cur.FramesSent-prev.FramesSent) / secs
+		sample.SentKbps = float64(cur.TotalBytesSent-prev.TotalBytesSent) * 8 / 1000 / secs
+	}
+	sample.GPUCapture = cur.FramesGPU > prev.FramesGPU
+	return sample
+}
+
+// reportQuality hands the interval's sample to the session's quality
+// handler, filling in the encoder and adaptive bitrate state.
+func (s *Session) reportQuality(prev, cur MetricsSnapshot) {
+	if s.qualityHandler == nil {
+		return
+	}
+	sample := qualitySample(s.id, prev, cur, time.Now())
+	sample.Codec = string(s.videoCodec())
+	if enc := s.encoder.Load(); enc != nil {
+		sample.Width, sample.Height = enc.Dimensions()
+		sample.Encoder = enc.BackendName()
+		sample.HardwareEncode = enc.BackendIsHardware()
+	}
+	if s.adaptive != nil {
+		sample.BitrateKbps = s.adaptive.TargetBitrate() / 1000
+		sample.DownscaleLevel = s.adaptive.ResolutionLevel()
+	}
+	s.qualityHandler(sample)
+}
@@ -0,0 +1,58 @@
+package desktop
+
+import (
+	"testing"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/ipc"
+)
+
+func TestQualitySampleCoversInterval(t *testing.T) {
+	prev := MetricsSnapshot{FramesSent: 100, FramesDropped: 2, TotalBytesSent: 1_000_000, FramesGPU: 100, Uptime: 10 * time.Second}
+	cur := MetricsSnapshot{
+		FramesSent:     400,
+		FramesDropped:  5,
+		TotalBytesSent: 3_500_000,
+		FramesGPU:      100,
+		EncodeMs:       4.5,
+		RTTMs:          42,
+		LossPct:        1.5,
+		Uptime:         20 * time.Second,
+	}
+	at := time.UnixMilli(1700000000000)
+
+	s := qualitySample("desk-1", prev, cur, at)
+	if s.SessionID != "desk-1" || s.AtUnixMs != 1700000000000 || s.IntervalMs != 10_000 {
+		t.Fatalf("sample header = %+v", s)
+	}
+	if s.FPS != 30 {
+		t.Fatalf("FPS = %v, want 30 from 300 frames over 10s", s.FPS)
+	}
+	if s.SentKbps != 2000 {
+		t.Fatalf("SentKbps = %v, want 2000 from 2.5MB over 10s", s.SentKbps)
+	}
+	if s.FramesDropped != 3 {
+		t.Fatalf("FramesDropped = %d, want the interval's 3", s.FramesDropped)
+	}
+	if s.EncodeMs != 4.5 || s.RTTMs != 42 || s.LossPct != 1.5 {
+		t.Fatalf("latest values not carried over: %+v", s)
+	}
+	if s.GPUCapture {
+		t.Fatal("no GPU frames were sent during the interval")
+	}
+}
+
+func TestReportQualityFillsEncoderState(t *testing.T) {
+	var got []string
+	s := &Session{id: "desk-1", codec: CodecVP8, adaptive: &AdaptiveBitrate{targetBitrate: 1_500_000}}
+	s.qualityHandler = func(sample ipc.DesktopQualitySample) {
+		got = append(got, sample.Codec)
+		if sample.BitrateKbps != 1500 {
+			t.Fatalf("BitrateKbps = %d, want 1500", sample.BitrateKbps)
+		}
+	}
+	s.reportQuality(MetricsSnapshot{}, MetricsSnapshot{Uptime: metricsInterval})
+	if len(got) != 1 || got[0] != string(CodecVP8) {
+		t.Fatalf("handler calls = %v", got)
+	}
+}
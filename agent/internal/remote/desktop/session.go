@@ -11,6 +11,7 @@ import (
 
 	"github.com/pion/webrtc/v4"
 
+	"github.com/breeze-rmm/agent/internal/ipc"
 	"github.com/breeze-rmm/agent/internal/remote/clipboard"
 	"github.com/breeze-rmm/agent/internal/remote/filedrop"
 )
@@ -39,25 +40,34 @@ type Session struct {
 	peerConn        *webrtc.PeerConnection
 	videoTrack      *webrtc.TrackLocalStaticSample
 	dataChannel     *webrtc.DataChannel
-	inputHandler    InputHandler
+	inputHandler    InputHandler // nil for view-only sessions
+	viewOnly        bool         // SessionPolicy.ViewOnly; fixed for the session's lifetime
+	bandwidth       bandwidthCap // SessionPolicy.MaxBitrate/AudioDisabled; fixed for the session's lifetime
 	capturer        ScreenCapturer
 	encoder         atomic.Pointer[VideoEncoder]
 	encoderPF       PixelFormat // cached encoder input format for CPU Encode() path
+	codec           Codec       // negotiated video codec; fixed for the session's lifetime
 	clipboardSync   *clipboard.ClipboardSync
 	fileDropHandler *filedrop.FileDropHandler
-	cursorDC        *webrtc.DataChannel
-	controlDC       *webrtc.DataChannel
-	audioTrack      *webrtc.TrackLocalStaticSample
-	audioCapturer   AudioCapturer
-	audioEnabled    atomic.Bool
-	done            chan struct{}
-	mu              sync.RWMutex
-	isActive        bool
-	fps             int
-	cleanupOnce     sync.Once
-	stopOnce        sync.Once
-	startOnce       sync.Once
-	wg              sync.WaitGroup
+	// viewers are additional peers fed from this session's encoder; see
+	// session_viewers.go. Guarded by viewersMu, not mu, because the capture
+	// loop reads it on every frame.
+	viewersMu     sync.RWMutex
+	viewers       map[string]*viewerPeer
+	cursorDC      *webrtc.DataChannel
+	controlDC     *webrtc.DataChannel
+	audioTrack    *webrtc.TrackLocalStaticSample
+	audioCapturer AudioCapturer
+	audioEncoder  audioEncoder
+	audioEnabled  atomic.Bool
+	done          chan struct{}
+	mu            sync.RWMutex
+	isActive      bool
+	fps           int
+	cleanupOnce   sync.Once
+	stopOnce      sync.Once
+	startOnce     sync.Once
+	wg            sync.WaitGroup
 
 	// Optimized pipeline components (shared with WS path)
 	differ   *frameDiffer
@@ -86,6 +96,20 @@ type Session struct {
 	// Disabled by default; viewer engages via the block_local_input control msg.
 	localInputBlocked atomic.Bool
 
+	// privacyModeEnabled tracks whether THIS session holds a privacy-mode
+	// reference with the PrivacyModeManager, guarding its refcount the same
+	// way localInputBlocked does. Set via the privacy_mode control message.
+	privacyModeEnabled atomic.Bool
+
+	// textModeSetting is the viewer's set_text_mode choice (textModeSetting);
+	// textModeActive reports whether textModeEncoder currently has text mode
+	// engaged. The detector and encoder pointer are owned by the capture
+	// loop. See text_mode.go.
+	textModeSetting atomic.Int32
+	textModeActive  atomic.Bool
+	textModeDetect  textModeDetector
+	textModeEncoder *VideoEncoder
+
 	// capturerSwapped is set by switch_monitor. The capture loop checks and
 	// clears it to re-read s.capturer and reinitialize GPU pipeline state.
 	capturerSwapped atomic.Bool
@@ -95,6 +119,16 @@ type Session struct {
 	// capture loop drains the swap.
 	oldCapturers []ScreenCapturer
 
+	// displayStreams are extra displays streamed on their own tracks; see
+	// session_displays.go. Fixed once StartSession returns.
+	displayStreams []*displayStream
+
+	// downscaleLevel is the encode-resolution level the adaptive controller
+	// asked for; videoScale is the downscale the CPU capture path actually
+	// applied (nil = native). See session_downscale.go.
+	downscaleLevel atomic.Int32
+	videoScale     atomic.Pointer[videoScale]
+
 	// gpuEncodeErrors tracks consecutive GPU encode failures. The GPU path
 	// is only permanently disabled after 3+ consecutive errors to allow the
 	// MFT to warm up after a monitor switch (first frame often fails).
@@ -124,6 +158,27 @@ type Session struct {
 	// sasHandler is set from SessionManager.OnSASRequest during creation.
 	sasHandler func() error
 
+	// chatDC is the in-session chat channel (see chat.go); nil when the
+	// manager has no OnChatMessage route. chatHandler is set from
+	// SessionManager.OnChatMessage during creation.
+	chatDC      *webrtc.DataChannel
+	chatHandler func(sessionID, text string)
+
+	// qualityHandler is set from SessionManager.OnQualitySample during
+	// creation; see quality_report.go.
+	qualityHandler func(ipc.DesktopQualitySample)
+
+	// annotations is the viewer's drawing layer (see annotation.go).
+	// annotationSurface shows it on the physical screen where supported;
+	// it and annotationSurfaceFailed are guarded by mu. annotationsShown is
+	// owned by the capture loop: the last frame carried composited
+	// annotations, so the next one must be sent to erase them.
+	annotations             *annotationLayer
+	annotationsDC           *webrtc.DataChannel
+	annotationSurface       annotationSurface
+	annotationSurfaceFailed bool
+	annotationsShown        bool
+
 	// displayIndex is the monitor index this session was started on.
 	displayIndex int
 	// captureConfig stores the context needed to recreate capturers on monitor switches.
@@ -183,6 +238,16 @@ type SessionManager struct {
 	// disconnected and allow reconnection.
 	OnSessionStopped func(sessionID string)
 
+	// OnChatMessage is called with each chat line the technician sends on a
+	// session's chat channel. The service relays it to the end user; replies
+	// come back through SendChatMessage. Sessions get no chat channel while
+	// it is nil.
+	OnChatMessage func(sessionID, text string)
+
+	// OnQualitySample is called with a stream quality sample for each
+	// session every metrics interval. The service uploads them to the API.
+	OnQualitySample func(ipc.DesktopQualitySample)
+
 	// lastDesktopState caches the most recently broadcast desktop state so
 	// late-connecting viewers can receive an initial state when their control
 	// channel opens. Protected by mu.
@@ -242,7 +307,9 @@ func (m *SessionManager) SetAtLoginWindow(atLoginWindow bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	for _, s := range m.sessions {
-		s.inputHandler.SetAtLoginWindow(atLoginWindow)
+		if s.inputHandler != nil {
+			s.inputHandler.SetAtLoginWindow(atLoginWindow)
+		}
 	}
 }
 
@@ -443,6 +510,9 @@ func (s *Session) doCleanup() {
 		if s.audioCapturer != nil {
 			s.audioCapturer.Stop()
 		}
+		if s.audioEncoder != nil {
+			s.audioEncoder.Close()
+		}
 		if s.clipboardSync != nil {
 			s.clipboardSync.Stop()
 		}
@@ -452,6 +522,13 @@ func (s *Session) doCleanup() {
 		if s.cursorDC != nil {
 			s.cursorDC.Close()
 		}
+		if s.chatDC != nil {
+			s.chatDC.Close()
+		}
+		if s.annotationsDC != nil {
+			s.annotationsDC.Close()
+		}
+		s.resetAnnotations()
 		s.clearCachedEncodedFrame()
 		if enc := s.encoder.Load(); enc != nil {
 			enc.Close()
@@ -463,26 +540,44 @@ func (s *Session) doCleanup() {
 		if s.capturer != nil {
 			s.capturer.Close()
 		}
+		s.closeDisplayStreams()
 		if s.peerConn != nil {
 			s.peerConn.Close()
 		}
+		s.closeViewers()
 
 		if err := GetWallpaperManager().Restore(); err != nil {
 			slog.Warn("Failed to restore wallpaper", "session", s.id, "error", err.Error())
 		}
 
-		// Auto-release any local-input block this session engaged (issue #966).
-		// CompareAndSwap ensures we only decrement the manager's refcount if this
-		// session was actually holding a block — never leave the local user
-		// locked out after a session ends.
-		if s.localInputBlocked.CompareAndSwap(true, false) {
-			if err := GetInputBlockManager().Release(); err != nil {
-				slog.Warn("Failed to release local-input block", "session", s.id, "error", err.Error())
+		// Auto-release any local-input block this session engaged (issue #966)
+		// — never leave the local user locked out after a session ends.
+		s.releaseLocalInputBlock("session ended")
+
+		// Restore the physical display if this session blanked it.
+		if s.privacyModeEnabled.CompareAndSwap(true, false) {
+			if err := GetPrivacyModeManager().Release(); err != nil {
+				slog.Warn("Failed to release privacy mode", "session", s.id, "error", err.Error())
 			}
 		}
 	})
 }
 
+// releaseLocalInputBlock drops this session's local-input block, if it holds
+// one. CompareAndSwap ensures the manager's refcount is only decremented by a
+// session that actually engaged it, so the session-end and data-channel-close
+// paths can both call this safely.
+func (s *Session) releaseLocalInputBlock(reason string) {
+	if !s.localInputBlocked.CompareAndSwap(true, false) {
+		return
+	}
+	if err := GetInputBlockManager().Release(); err != nil {
+		slog.Warn("Failed to release local-input block", "session", s.id, "reason", reason, "error", err.Error())
+		return
+	}
+	slog.Info("Local input unblocked for session", "session", s.id, "reason", reason)
+}
+
 func (s *Session) getFPS() int {
 	s.mu.RLock()
 	fps := s.fps
@@ -492,3 +587,11 @@ func (s *Session) getFPS() int {
 	}
 	return clampInt(fps, 1, maxFrameRate)
 }
+
+// videoCodec is the negotiated codec, H264 when none was recorded.
+func (s *Session) videoCodec() Codec {
+	if s.codec == "" {
+		return CodecH264
+	}
+	return s.codec
+}
@@ -2,6 +2,7 @@ package desktop
 
 import (
 	"fmt"
+	"image"
 	"log/slog"
 	"sync"
 	"sync/atomic"
@@ -180,7 +181,7 @@ func (s *Session) maybeResendCachedFrameOnSecureDesktop(cap ScreenCapturer, fram
 		Data:     frame,
 		Duration: s.sampleDuration(frameDuration),
 	}
-	if err := s.videoTrack.WriteSample(sample); err != nil {
+	if err := s.writeVideoSample(sample); err != nil {
 		slog.Debug("Failed to resend cached secure-desktop frame", "session", s.id, "error", err.Error())
 		return false
 	}
@@ -386,6 +387,7 @@ func (s *Session) captureLoopDXGI() captureMode {
 			wasIdle = false
 			s.gpuEncodeErrors = 0
 			s.frameIdx = 0 // reset so first frames after switch are logged
+			s.textModeDetect.reset()
 			// Pass new D3D11 device to encoder
 			if enc := s.encoder.Load(); hasTP && enc != nil {
 				enc.SetD3D11Device(tp.GetD3D11Device(), tp.GetD3D11Context())
@@ -529,6 +531,9 @@ func (s *Session) captureLoopDXGI() captureMode {
 			}
 			if handled {
 				frameSent = sent
+				if sent {
+					s.metrics.RecordGPUFrame()
+				}
 				sleepDur := frameDuration
 				if !frameSent {
 					if onSecure {
@@ -802,6 +807,7 @@ func (s *Session) captureAndSendFrame(frameDuration time.Duration) {
 		if !resent {
 			s.maybeResendCachedFrameOnIdle(frameDuration)
 		}
+		s.observeTextMode(0)
 		s.metrics.RecordSkip()
 		return
 	}
@@ -846,6 +852,11 @@ func (s *Session) captureAndSendFrame(frameDuration time.Duration) {
 		onSecure = dsn.OnSecureDesktop()
 	}
 
+	// Annotations drawn into the frame (or erased from it once they fade)
+	// must go out even when the screen itself did not change.
+	annotate := s.compositeAnnotations(time.Now())
+	forceFrame := annotate || s.annotationsShown
+
 	// 2. Frame differencing — skip if unchanged.
 	// DXGI capturers already filter via AccumulatedFrames in Capture(),
 	// so we only need CRC32 for non-DXGI capturers.
@@ -853,7 +864,7 @@ func (s *Session) captureAndSendFrame(frameDuration time.Duration) {
 	// frames even when UI is static; otherwise video can appear "stuck" until
 	// the next input event changes pixels.
 	if !dxgiActive && !onSecure {
-		if !s.differ.HasChanged(img.Pix) {
+		if !s.differ.HasChanged(img.Pix) && !forceFrame {
 			captureImagePool.Put(img)
 			// Static screen: bump the capture-alive heartbeat so the no-video
 			// watchdog does not kill a healthy idle session. Linux/X11 has no
@@ -865,22 +876,60 @@ func (s *Session) captureAndSendFrame(frameDuration time.Duration) {
 			if !resent {
 				s.maybeResendCachedFrameOnIdle(frameDuration)
 			}
+			s.observeTextMode(0)
 			s.metrics.RecordSkip()
 			return
 		}
 	}
 
+	// DXGI also reports which regions changed; the encoder re-converts only
+	// those. An empty (non-nil) list means only the pointer moved.
+	var damage []image.Rectangle
+	if dp, ok := cap.(DirtyRectProvider); ok && dxgiActive {
+		damage = dp.DirtyRects()
+	}
+	if forceFrame {
+		damage = nil // annotation pixels lie outside the reported damage
+	}
+	if damage != nil && len(damage) == 0 && !onSecure {
+		captureImagePool.Put(img)
+		s.maybeResendCachedFrameOnIdle(frameDuration)
+		s.observeTextMode(0)
+		s.metrics.RecordSkip()
+		return
+	}
+	changed := 1.0
+	if w, h := img.Rect.Dx(), img.Rect.Dy(); damage != nil && w > 0 && h > 0 {
+		changed = float64(damageArea(alignDamage(damage, w, h))) / float64(w*h)
+	}
+	s.observeTextMode(changed)
+
 	// 3. Cursor compositing — skip for DXGI since the viewer renders its own cursor.
 	// This saves a full-frame read+write pass at high resolutions.
 	if !dxgiActive && desiredPF == PixelFormatRGBA {
 		s.cursor.CompositeCursor(img)
 	}
+	// Annotations without an on-screen surface are composited the same way.
+	if annotate {
+		s.annotations.render(img.Pix, img.Stride, img.Rect.Dx(), img.Rect.Dy(), desiredPF == PixelFormatBGRA, time.Now())
+	}
+	s.annotationsShown = annotate
 
-	// 4. Encode to H264 via MFT (RGBA→NV12→H264 internally)
+	// 4. Scale down when the adaptive controller has stepped resolution.
+	frame := img
+	if scaled := s.downscaleFrame(enc, img); scaled != nil {
+		frame = scaled
+		damage = nil // rects are in capture coordinates
+	}
+
+	// 5. Encode to H264 via MFT (RGBA→NV12→H264 internally)
 	t1 := time.Now()
-	h264Data, err := enc.Encode(img.Pix)
+	h264Data, err := enc.EncodeDamaged(frame.Pix, damage)
 	encodeTime := time.Since(t1)
 	captureImagePool.Put(img)
+	if frame != img {
+		scaledImagePool.Put(frame)
+	}
 
 	if err != nil {
 		s.cpuEncodeErrors++
@@ -909,7 +958,7 @@ func (s *Session) captureAndSendFrame(frameDuration time.Duration) {
 	// Drop oversized P-frames (MFT keyframe bursts) — same guard as GPU path.
 	// Never drop IDR keyframes: the decoder MUST receive them or all subsequent
 	// P-frames decode against a stale reference, causing persistent corruption.
-	if s.frameIdx > 5 && len(h264Data) > maxFrameSizeBytes && !h264ContainsIDR(h264Data) {
+	if s.frameIdx > 5 && len(h264Data) > maxFrameSizeBytes && !containsKeyframe(s.videoCodec(), h264Data) {
 		slog.Debug("Dropping oversized P-frame (CPU path)",
 			"session", s.id, "bytes", len(h264Data), "maxBytes", maxFrameSizeBytes)
 		s.metrics.RecordDrop()
@@ -918,12 +967,12 @@ func (s *Session) captureAndSendFrame(frameDuration time.Duration) {
 		return
 	}
 
-	// 5. Write as pion media.Sample
+	// 6. Write as pion media.Sample
 	sample := media.Sample{
 		Data:     h264Data,
 		Duration: s.sampleDuration(frameDuration),
 	}
-	if err := s.videoTrack.WriteSample(sample); err != nil {
+	if err := s.writeVideoSample(sample); err != nil {
 		slog.Debug("Failed to write H264 sample", "session", s.id, "error", err.Error())
 		s.metrics.RecordDrop()
 		return
@@ -952,6 +1001,8 @@ func (s *Session) captureAndSendFrameGPU(tp TextureProvider, frameDuration time.
 		slog.Warn("captureAndSendFrameGPU: encoder is nil, skipping", "session", s.id)
 		return false, false, false // handled=false so CPU path can be tried
 	}
+	// Textures are encoded at native size; undo any CPU-path downscale.
+	s.clearDownscale(enc)
 
 	t0 := time.Now()
 	texture, err := tp.CaptureTexture()
@@ -964,11 +1015,13 @@ func (s *Session) captureAndSendFrameGPU(tp TextureProvider, frameDuration time.
 		cap := s.capturer
 		s.mu.RUnlock()
 		_ = s.maybeResendCachedFrameOnSecureDesktop(cap, frameDuration)
+		s.observeTextMode(0)
 		s.metrics.RecordSkip()
 		return true, false, false
 	}
 	defer tp.ReleaseTexture()
 	s.metrics.RecordCapture(time.Since(t0))
+	s.observeTextMode(1) // texture frames carry no damage
 
 	t1 := time.Now()
 	h264Data, err := enc.EncodeTexture(texture)
@@ -1021,7 +1074,7 @@ func (s *Session) captureAndSendFrameGPU(tp TextureProvider, frameDuration time.
 	// The encoder will produce a smaller P-frame on the next capture cycle.
 	// Skip the check for the first 5 frames to allow initial keyframes through.
 	// Never drop IDR keyframes — without them the decoder accumulates corruption.
-	if s.frameIdx > 5 && len(h264Data) > maxFrameSizeBytes && !h264ContainsIDR(h264Data) {
+	if s.frameIdx > 5 && len(h264Data) > maxFrameSizeBytes && !containsKeyframe(s.videoCodec(), h264Data) {
 		slog.Warn("Dropping oversized P-frame to prevent jitter burst",
 			"session", s.id, "bytes", len(h264Data), "maxBytes", maxFrameSizeBytes)
 		s.metrics.RecordDrop()
@@ -1034,7 +1087,7 @@ func (s *Session) captureAndSendFrameGPU(tp TextureProvider, frameDuration time.
 		Data:     h264Data,
 		Duration: s.sampleDuration(frameDuration),
 	}
-	if err := s.videoTrack.WriteSample(sample); err != nil {
+	if err := s.writeVideoSample(sample); err != nil {
 		slog.Warn("Failed to write H264 sample (GPU)", "session", s.id, "error", err.Error())
 		s.metrics.RecordDrop()
 		return true, false, false
@@ -1060,10 +1113,20 @@ func (s *Session) handleDesktopSwitch() {
 		return
 	}
 
-	if dsn.OnSecureDesktop() {
+	secure := dsn.OnSecureDesktop()
+	// Input must follow the capture onto the new desktop right away, or the
+	// first clicks on a UAC prompt go to the desktop that was just left.
+	if r, ok := s.inputHandler.(InputDesktopResyncer); ok {
+		r.ResyncInputDesktop()
+	}
+	s.sendSecureDesktopState(secure)
+
+	if secure {
 		// Secure desktop is always at origin — reset offsets
 		slog.Info("Desktop switch: entering secure desktop, resetting offsets", "session", s.id)
-		s.inputHandler.SetDisplayOffset(0, 0)
+		if s.inputHandler != nil {
+			s.inputHandler.SetDisplayOffset(0, 0)
+		}
 		s.cursorOffsetX.Store(0)
 		s.cursorOffsetY.Store(0)
 		// Prime secure desktop rendering: some credential/UAC surfaces do not
@@ -1116,13 +1179,19 @@ func (s *Session) handleDesktopSwitch() {
 // coordinate offset so viewer-relative (0,0) maps to the captured monitor's
 // top-left corner in virtual screen space. Also stores the offset atomically
 // for cursorStreamLoop to convert absolute cursor coords to display-relative.
+// handler is nil for view-only sessions; only the cursor offset is stored.
 func applyDisplayOffset(handler InputHandler, displayIndex int, cursorOffX, cursorOffY *atomic.Int32) {
+	set := func(x, y int) {
+		if handler != nil {
+			handler.SetDisplayOffset(x, y)
+		}
+		cursorOffX.Store(int32(x))
+		cursorOffY.Store(int32(y))
+	}
 	monitors, err := ListMonitors()
 	if err != nil {
 		slog.Warn("applyDisplayOffset: ListMonitors failed", "error", err.Error())
-		handler.SetDisplayOffset(0, 0)
-		cursorOffX.Store(0)
-		cursorOffY.Store(0)
+		set(0, 0)
 		return
 	}
 	for _, m := range monitors {
@@ -1135,16 +1204,12 @@ func applyDisplayOffset(handler InputHandler, displayIndex int, cursorOffX, curs
 		if m.Index == displayIndex {
 			slog.Debug("applyDisplayOffset: selected",
 				"display", displayIndex, "offsetX", m.X, "offsetY", m.Y)
-			handler.SetDisplayOffset(m.X, m.Y)
-			cursorOffX.Store(int32(m.X))
-			cursorOffY.Store(int32(m.Y))
+			set(m.X, m.Y)
 			return
 		}
 	}
 	slog.Warn("applyDisplayOffset: display not found, using 0,0", "display", displayIndex)
-	handler.SetDisplayOffset(0, 0)
-	cursorOffX.Store(0)
-	cursorOffY.Store(0)
+	set(0, 0)
 }
 
 // atomicEncoderSwap performs a clean encoder replacement in one atomic sequence.
@@ -1178,6 +1243,11 @@ func (s *Session) restoreHardwareEncoder(tp TextureProvider) {
 	if cur == nil || cur.BackendIsHardware() {
 		return
 	}
+	// Only H264 swaps between software and hardware: a VP9/AV1 session stays
+	// in software, and an HEVC session is always on hardware.
+	if s.videoCodec() != CodecH264 {
+		return
+	}
 
 	var w, h int
 	if c := s.capturer; c != nil {
@@ -1273,7 +1343,7 @@ func (s *Session) swapToSoftwareEncoder() {
 		fps = 30
 	}
 	newEnc, err := NewVideoEncoder(EncoderConfig{
-		Codec:          CodecH264,
+		Codec:          s.videoCodec(),
 		Quality:        QualityAuto,
 		Bitrate:        2_500_000,
 		FPS:            fps,
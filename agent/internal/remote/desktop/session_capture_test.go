@@ -141,3 +141,48 @@ func TestMaybeResendCachedFrameOnIdle_DoesNotAdvanceSampleClock(t *testing.T) {
 		t.Fatalf("idle heartbeat did not bump lastVideoWriteUnixNano (still %d)", got)
 	}
 }
+
+// switchingTestCapturer reports one pending desktop switch.
+type switchingTestCapturer struct {
+	staticTestCapturer
+	switched bool
+	secure   bool
+}
+
+func (c *switchingTestCapturer) ConsumeDesktopSwitch() bool {
+	was := c.switched
+	c.switched = false
+	return was
+}
+func (c *switchingTestCapturer) OnSecureDesktop() bool { return c.secure }
+
+type resyncInputHandler struct {
+	stubInputHandler
+	resyncs int
+}
+
+func (h *resyncInputHandler) ResyncInputDesktop() { h.resyncs++ }
+
+// TestHandleDesktopSwitchResyncsInputDesktop verifies a switch onto the
+// secure desktop (UAC prompt, lock screen) re-attaches input immediately, so
+// the viewer's first click lands on the prompt rather than the old desktop.
+func TestHandleDesktopSwitchResyncsInputDesktop(t *testing.T) {
+	handler := &resyncInputHandler{}
+	s := &Session{id: "test", isActive: true, inputHandler: handler}
+	s.capturer = &switchingTestCapturer{
+		staticTestCapturer: staticTestCapturer{img: image.NewRGBA(image.Rect(0, 0, 4, 4))},
+		switched:           true,
+		secure:             true,
+	}
+
+	s.handleDesktopSwitch()
+	if handler.resyncs != 1 {
+		t.Fatalf("resyncs after desktop switch = %d, want 1", handler.resyncs)
+	}
+
+	// No pending switch: nothing to resync.
+	s.handleDesktopSwitch()
+	if handler.resyncs != 1 {
+		t.Fatalf("resyncs without a switch = %d, want 1", handler.resyncs)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"time"
+	"unicode/utf8"
 
 	"github.com/breeze-rmm/agent/internal/ipc"
 	"github.com/breeze-rmm/agent/internal/sessionbroker"
@@ -40,10 +41,38 @@ func (s *Session) verifySecureDesktopTransition(timeout time.Duration) (supporte
 	}
 }
 
+// sendSecureDesktopState tells the viewer the target entered or left a secure
+// desktop (UAC prompt, lock screen, Ctrl+Alt+Del) so it can say why the
+// picture changed instead of looking frozen.
+func (s *Session) sendSecureDesktopState(active bool) {
+	s.sendControlMessage(map[string]any{
+		"type":    "secure_desktop",
+		"active":  active,
+		"desktop": getCurrentInputDesktopName(),
+	})
+}
+
+// sendControlMessage sends an unsolicited agent-to-viewer notice on the
+// control channel. Dropped silently when the channel isn't open.
+func (s *Session) sendControlMessage(msg map[string]any) {
+	resp, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	s.mu.RLock()
+	dc := s.controlDC
+	s.mu.RUnlock()
+	if dc != nil {
+		if err := dc.SendText(string(resp)); err != nil {
+			slog.Debug("Failed to send control message", "session", s.id, "type", msg["type"], "error", err.Error())
+		}
+	}
+}
+
 // sendInputStatus waits for the control data channel to become available and
 // sends an input_status message to the viewer indicating that input injection
-// is unavailable. Called asynchronously from startStreaming.
-func (s *Session) sendInputStatus() {
+// is unavailable, and why. Called asynchronously from startStreaming.
+func (s *Session) sendInputStatus(reason string) {
 	// Wait up to 5 seconds for the control DC to be set by the viewer.
 	deadline := time.Now().Add(5 * time.Second)
 	for {
@@ -60,7 +89,7 @@ func (s *Session) sendInputStatus() {
 			msg, err := json.Marshal(map[string]any{
 				"type":      "input_status",
 				"available": false,
-				"reason":    "IOHIDSystem unavailable at login window",
+				"reason":    reason,
 			})
 			if err != nil {
 				slog.Error("Failed to marshal input_status message", "session", s.id, "error", err.Error())
@@ -107,15 +136,19 @@ func (s *Session) onViewerDataChannelMessage(label string, data []byte) {
 		s.handleInputMessage(data)
 	case "control":
 		s.handleControlMessage(data)
+	case "chat":
+		s.handleChatMessage(data)
+	case "annotations":
+		s.handleAnnotationMessage(data)
 	}
 }
 
 // handleInputMessage processes input events from the data channel
 func (s *Session) handleInputMessage(data []byte) {
-	// Drop input events early when the handler cannot inject them (e.g. macOS
-	// login window without IOHIDSystem). The viewer is notified once via
-	// sendInputStatus(); no need to log per-event.
-	if !s.inputHandler.InputAvailable() {
+	// Drop input events early when there is no handler (view-only session) or
+	// it cannot inject them (e.g. macOS login window without IOHIDSystem). The
+	// viewer is notified once via sendInputStatus(); no need to log per-event.
+	if s.inputHandler == nil || !s.inputHandler.InputAvailable() {
 		return
 	}
 
@@ -134,6 +167,12 @@ func (s *Session) handleInputMessage(data []byte) {
 	// and polls at full speed. This covers mouse_move, key_down, scroll, etc.
 	s.inputActive.Store(true)
 
+	// The viewer maps pointer positions onto the video it receives; map them
+	// back onto the captured display when the stream is downscaled.
+	if vs := s.videoScale.Load(); vs != nil {
+		event.X, event.Y = vs.toSource(event.X, event.Y)
+	}
+
 	// On mouse down, signal the capture loop to flush the encoder pipeline so
 	// stale buffered frames are dropped and the click result appears immediately.
 	// NOTE: disabled for now — on AMF this forces an IDR keyframe on the next
@@ -144,6 +183,14 @@ func (s *Session) handleInputMessage(data []byte) {
 	// 	s.clickFlush.Store(true)
 	// }
 
+	// Committed text (IME, dead keys, layout mismatch) bypasses key mapping.
+	if event.Type == "text" {
+		if err := TypeText(s.inputHandler, event.Text); err != nil {
+			slog.Warn("Failed to type text input", "session", s.id, "runes", utf8.RuneCountInString(event.Text), "error", err.Error())
+		}
+		return
+	}
+
 	if err := s.inputHandler.HandleEvent(event); err != nil {
 		slog.Warn("Failed to handle input event", "session", s.id, "error", err.Error())
 	}
@@ -222,6 +269,103 @@ func (s *Session) handleBlockLocalInput(block bool) {
 	}
 }
 
+// handlePrivacyMode blanks or restores the target's physical display for this
+// session and reports the outcome to the viewer via a privacy_mode_result
+// control message. banner is the text to show on the blanked display ("" for
+// none). Refcounting mirrors handleBlockLocalInput.
+func (s *Session) handlePrivacyMode(enable bool, banner string) {
+	mgr := GetPrivacyModeManager()
+	var (
+		supported   = mgr.Supported()
+		ok          = true
+		bannerShown bool
+		errMsg      string
+	)
+
+	if enable {
+		if !s.privacyModeEnabled.Load() {
+			sup, shown, err := mgr.Engage(banner)
+			supported, bannerShown = sup, shown
+			if err != nil {
+				ok = false
+				errMsg = err.Error()
+				slog.Warn("Failed to enable privacy mode", "session", s.id, "error", errMsg)
+			} else if sup {
+				s.privacyModeEnabled.Store(true)
+				slog.Info("Privacy mode enabled for session", "session", s.id, "banner", shown)
+			} else {
+				slog.Info("Privacy mode not supported on this platform", "session", s.id)
+			}
+		}
+	} else if s.privacyModeEnabled.CompareAndSwap(true, false) {
+		if err := mgr.Release(); err != nil {
+			ok = false
+			errMsg = err.Error()
+			slog.Warn("Failed to release privacy mode", "session", s.id, "error", errMsg)
+		} else {
+			slog.Info("Privacy mode released for session", "session", s.id)
+		}
+	}
+
+	body := map[string]any{
+		"type":      "privacy_mode_result",
+		"supported": supported,
+		"enabled":   s.privacyModeEnabled.Load(),
+		"banner":    bannerShown,
+		"ok":        ok,
+	}
+	if errMsg != "" {
+		body["error"] = errMsg
+	}
+	resp, err := json.Marshal(body)
+	if err != nil {
+		slog.Warn("Failed to marshal privacy_mode_result", "session", s.id, "error", err.Error())
+		return
+	}
+	s.mu.RLock()
+	dc := s.controlDC
+	s.mu.RUnlock()
+	if dc != nil {
+		if err := dc.SendText(string(resp)); err != nil {
+			slog.Debug("Failed to send privacy_mode_result", "session", s.id, "error", err.Error())
+		}
+	}
+}
+
+// viewOnlyDeniedControls maps the control messages that act on the target
+// rather than the stream to the result type the viewer waits for. A view-only
+// session refuses them all.
+var viewOnlyDeniedControls = map[string]string{
+	"send_sas":          "sas_result",
+	"block_local_input": "block_local_input_result",
+	"block_input":       "block_local_input_result",
+	"privacy_mode":      "privacy_mode_result",
+	"lock_workstation":  "lock_result",
+}
+
+// refuseViewOnlyControl answers a control message a view-only session does not
+// permit with a failed result, so the viewer doesn't wait on it.
+func (s *Session) refuseViewOnlyControl(msgType, resultType string) {
+	slog.Warn("Refused control message in view-only session", "session", s.id, "type", msgType)
+	resp, err := json.Marshal(map[string]any{
+		"type":     resultType,
+		"ok":       false,
+		"viewOnly": true,
+		"error":    "not permitted in a view-only session",
+	})
+	if err != nil {
+		return
+	}
+	s.mu.RLock()
+	dc := s.controlDC
+	s.mu.RUnlock()
+	if dc != nil {
+		if err := dc.SendText(string(resp)); err != nil {
+			slog.Debug("Failed to send view-only refusal", "session", s.id, "type", resultType, "error", err.Error())
+		}
+	}
+}
+
 // handleControlMessage processes control messages (bitrate, quality changes)
 func (s *Session) handleControlMessage(data []byte) {
 	if len(data) > maxControlMessageBytes {
@@ -238,6 +382,13 @@ func (s *Session) handleControlMessage(data []byte) {
 		return
 	}
 
+	if s.viewOnly {
+		if resultType, denied := viewOnlyDeniedControls[msg.Type]; denied {
+			s.refuseViewOnlyControl(msg.Type, resultType)
+			return
+		}
+	}
+
 	// Absolute ceiling a viewer-requested bitrate may reach. Tracks the 4K
 	// resolution ceiling (and the BREEZE_REMOTE_MAX_BITRATE_BPS override) so a
 	// viewer quality slider can climb to the full 4K rate — the previous hard
@@ -256,6 +407,12 @@ func (s *Session) handleControlMessage(data []byte) {
 					"session", s.id, "requested", msg.Value, "cap", maxBitrateCap)
 				bitrate = maxBitrateCap
 			}
+			// The session's bandwidth policy is a harder ceiling still.
+			if capped := s.bandwidth.clamp(bitrate, s.audioEnabled.Load()); capped != bitrate {
+				slog.Debug("Clamping requested bitrate to session bandwidth cap",
+					"session", s.id, "requested", msg.Value, "cap", capped)
+				bitrate = capped
+			}
 			// Update the adaptive controller's ceiling so it ramps up to
 			// the user-chosen max rather than bypassing adaptive entirely.
 			if s.adaptive != nil {
@@ -266,6 +423,9 @@ func (s *Session) handleControlMessage(data []byte) {
 						slog.Warn("Failed to set bitrate", "session", s.id, "bitrate", bitrate, "error", err.Error())
 					}
 				}
+				s.forEachDisplayStream(func(ds *displayStream) {
+					_ = ds.encoder.SetBitrate(bitrate)
+				})
 			}
 		} else {
 			slog.Debug("Ignoring non-positive set_bitrate request", "session", s.id, "value", msg.Value)
@@ -283,12 +443,27 @@ func (s *Session) handleControlMessage(data []byte) {
 					slog.Warn("Failed to set fps", "session", s.id, "fps", msg.Value, "error", err.Error())
 				}
 			}
+			s.forEachDisplayStream(func(ds *displayStream) {
+				_ = ds.encoder.SetFPS(msg.Value)
+			})
 		}
 	case "request_keyframe":
 		// Viewer window regained focus — force IDR so picture is immediately sharp.
 		if enc := s.encoder.Load(); enc != nil {
 			_ = enc.ForceKeyframe()
 		}
+		s.forEachDisplayStream((*displayStream).requestKeyframe)
+	case "list_display_streams":
+		resp, _ := json.Marshal(map[string]any{
+			"type":    "display_streams",
+			"streams": s.displayStreamInfo(),
+		})
+		s.mu.RLock()
+		dc := s.controlDC
+		s.mu.RUnlock()
+		if dc != nil {
+			dc.SendText(string(resp))
+		}
 	case "list_sessions":
 		detector := sessionbroker.NewSessionDetector()
 		detected, err := detector.ListSessions()
@@ -347,7 +522,12 @@ func (s *Session) handleControlMessage(data []byte) {
 		}
 	case "toggle_audio":
 		enabled := msg.Value != 0
+		if enabled && !s.bandwidth.audioAllowed() {
+			s.refuseAudioForBandwidth()
+			return
+		}
 		s.audioEnabled.Store(enabled)
+		s.applyBandwidthCap()
 		slog.Info("Audio toggled", "session", s.id, "enabled", enabled)
 	case "set_cursor_stream":
 		enabled := msg.Value != 0
@@ -406,13 +586,43 @@ func (s *Session) handleControlMessage(data []byte) {
 		if dc != nil {
 			dc.SendText(string(resp))
 		}
-	case "block_local_input":
+	case "block_local_input", "block_input":
 		// Toggle blocking of the LOCAL physical keyboard/mouse on the target so
 		// the on-site user and the remote operator stop fighting for control
 		// (issue #966). Value != 0 engages, 0 releases. The block auto-releases
-		// on session end (doCleanup), agent crash (OS-level), and a max-duration
-		// watchdog — see input_block.go.
+		// on session end (doCleanup), input/control channel close, agent crash
+		// (OS-level), and a max-duration watchdog — see input_block.go.
 		s.handleBlockLocalInput(msg.Value != 0)
+	case "privacy_mode":
+		// Blank the PHYSICAL display while the technician works, optionally
+		// showing a banner; the stream keeps showing the real desktop. Value
+		// != 0 engages, 0 restores. Released on session end like
+		// block_local_input — see privacy_mode.go.
+		var pm struct {
+			Banner  bool   `json:"banner"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &pm)
+		s.handlePrivacyMode(msg.Value != 0, privacyBannerText(pm.Banner, pm.Message))
+	case "keyboard_layout":
+		// Viewer reports its keyboard layout (BCP 47 tag) and whether an IME
+		// is composing; the reply says whether to send key events or text
+		// events for printable characters — see keyboard_layout.go.
+		var kl struct {
+			Layout string `json:"layout"`
+			IME    bool   `json:"ime"`
+		}
+		_ = json.Unmarshal(data, &kl)
+		s.handleKeyboardLayout(kl.Layout, kl.IME)
+	case "set_text_mode":
+		// Cap the encoder quantizer so terminal/IDE text stays sharp. mode is
+		// "on", "off" or "auto" (engage while the screen is mostly static) —
+		// see text_mode.go.
+		var tm struct {
+			Mode string `json:"mode"`
+		}
+		_ = json.Unmarshal(data, &tm)
+		s.handleSetTextMode(tm.Mode)
 	case "lock_workstation":
 		slog.Info("Lock workstation requested via control channel", "session", s.id)
 		lockErr := LockWorkstation()
@@ -495,6 +705,10 @@ func (s *Session) handleControlMessage(data []byte) {
 				"iceRemote", vs.ICERemote,
 			)
 
+			if s.metrics != nil {
+				s.metrics.RecordNetwork(time.Duration(vs.RTTMs)*time.Millisecond, effectiveLoss)
+			}
+
 			// Feed viewer stats into the adaptive bitrate controller.
 			if s.adaptive != nil {
 				rtt := time.Duration(vs.RTTMs) * time.Millisecond
@@ -528,6 +742,7 @@ func (s *Session) handleControlMessage(data []byte) {
 		s.captureConfig = cfg
 		s.mu.Unlock()
 		s.capturerSwapped.Store(true)
+		s.resetAnnotations()
 		applyDisplayOffset(s.inputHandler, msg.Value, &s.cursorOffsetX, &s.cursorOffsetY)
 		// Get bounds for viewer notification — encoder dimensions are updated
 		// by the capture loop when it detects capturerSwapped, avoiding a race
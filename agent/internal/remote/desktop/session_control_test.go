@@ -199,3 +199,151 @@ func TestHandleBlockLocalInput_ResultMessageShape(t *testing.T) {
 		t.Fatalf("expected supported=false from stub, got %v", decoded["supported"])
 	}
 }
+
+func installStubPrivacyManager(t *testing.T, supported bool) *stubPrivacyBackend {
+	t.Helper()
+	backend := &stubPrivacyBackend{supported: supported, banner: true}
+	privacyMgrInstance = &PrivacyModeManager{backend: backend, maxDuration: time.Hour}
+	privacyMgrOnce.Do(func() {})
+	t.Cleanup(func() {
+		privacyMgrInstance = nil
+		privacyMgrOnce = sync.Once{}
+	})
+	return backend
+}
+
+func TestHandleControlMessage_PrivacyMode(t *testing.T) {
+	backend := installStubPrivacyManager(t, true)
+	session := &Session{id: "session-privacy"}
+
+	session.handleControlMessage([]byte(`{"type":"privacy_mode","value":1,"banner":true}`))
+	if !session.privacyModeEnabled.Load() || !GetPrivacyModeManager().IsEngaged() {
+		t.Fatal("expected privacy mode engaged after privacy_mode value=1")
+	}
+	if backend.lastBanner != DefaultPrivacyBanner {
+		t.Fatalf("banner = %q, want default banner", backend.lastBanner)
+	}
+
+	// Repeat engage is idempotent for the session.
+	session.handleControlMessage([]byte(`{"type":"privacy_mode","value":1}`))
+	if e, _ := backend.counts(); e != 1 {
+		t.Fatalf("expected 1 Enable call, got %d", e)
+	}
+
+	session.handleControlMessage([]byte(`{"type":"privacy_mode","value":0}`))
+	if session.privacyModeEnabled.Load() || GetPrivacyModeManager().IsEngaged() {
+		t.Fatal("expected privacy mode released after privacy_mode value=0")
+	}
+}
+
+func TestDoCleanup_ReleasesPrivacyMode(t *testing.T) {
+	backend := installStubPrivacyManager(t, true)
+	session := &Session{id: "session-privacy-cleanup"}
+
+	session.handleControlMessage([]byte(`{"type":"privacy_mode","value":1}`))
+	session.doCleanup()
+
+	if session.privacyModeEnabled.Load() || GetPrivacyModeManager().IsEngaged() {
+		t.Fatal("doCleanup must restore the display")
+	}
+	if _, d := backend.counts(); d != 1 {
+		t.Fatalf("expected 1 Disable call, got %d", d)
+	}
+}
+
+func TestHandleControlMessage_BlockInputAlias(t *testing.T) {
+	backend := installStubInputBlockManager(t, true)
+	session := &Session{id: "session-block-alias"}
+
+	session.handleControlMessage([]byte(`{"type":"block_input","value":1}`))
+	if !session.localInputBlocked.Load() {
+		t.Fatal("expected block_input to engage the local-input block")
+	}
+	session.handleControlMessage([]byte(`{"type":"block_input","value":0}`))
+	if session.localInputBlocked.Load() {
+		t.Fatal("expected block_input value=0 to release the local-input block")
+	}
+	if b, u := backend.counts(); b != 1 || u != 1 {
+		t.Fatalf("expected 1 Block and 1 Unblock, got %d/%d", b, u)
+	}
+}
+
+func TestReleaseLocalInputBlock_DataChannelClose(t *testing.T) {
+	backend := installStubInputBlockManager(t, true)
+	session := &Session{id: "session-dc-close"}
+
+	session.handleControlMessage([]byte(`{"type":"block_input","value":1}`))
+
+	// Both the input and control channels' OnClose call this; the second call
+	// must not underflow the manager's refcount.
+	session.releaseLocalInputBlock("input channel closed")
+	session.releaseLocalInputBlock("control channel closed")
+
+	if session.localInputBlocked.Load() {
+		t.Fatal("expected data-channel close to clear localInputBlocked")
+	}
+	if _, u := backend.counts(); u != 1 {
+		t.Fatalf("expected exactly 1 Unblock, got %d", u)
+	}
+	if GetInputBlockManager().IsEngaged() {
+		t.Fatal("manager must not be engaged after the data channel closes")
+	}
+
+	// doCleanup afterwards is a no-op for the already-released block.
+	session.doCleanup()
+	if _, u := backend.counts(); u != 1 {
+		t.Fatalf("expected doCleanup not to Unblock again, got %d", u)
+	}
+}
+
+func TestHandleControlMessage_ViewOnlyRefusesTargetActions(t *testing.T) {
+	backend := installStubInputBlockManager(t, true)
+	privacy := installStubPrivacyManager(t, true)
+	session := &Session{id: "session-view-only", viewOnly: true}
+
+	session.handleControlMessage([]byte(`{"type":"block_input","value":1}`))
+	session.handleControlMessage([]byte(`{"type":"block_local_input","value":1}`))
+	session.handleControlMessage([]byte(`{"type":"privacy_mode","value":1}`))
+
+	if session.localInputBlocked.Load() {
+		t.Fatal("view-only session must not block local input")
+	}
+	if b, _ := backend.counts(); b != 0 {
+		t.Fatalf("view-only session must not call backend.Block, got %d", b)
+	}
+	if session.privacyModeEnabled.Load() || privacy.enableCount != 0 {
+		t.Fatal("view-only session must not engage privacy mode")
+	}
+}
+
+func TestHandleInputMessage_ViewOnlyDropsInput(t *testing.T) {
+	// A view-only session has no InputHandler at all; input must be dropped
+	// without dereferencing it.
+	session := &Session{id: "session-view-only-input", viewOnly: true}
+	session.handleInputMessage([]byte(`{"type":"mouse_move","x":10,"y":10}`))
+	session.onViewerDataChannelMessage("input", []byte(`{"type":"key_press","key":"a"}`))
+}
+
+type textInputStub struct {
+	stubInputHandler
+	typed []string
+}
+
+func (h *textInputStub) TypeText(text string) error {
+	h.typed = append(h.typed, text)
+	return nil
+}
+
+func TestHandleInputMessage_TextEventTypesCommittedString(t *testing.T) {
+	handler := &textInputStub{}
+	session := &Session{id: "session-text", inputHandler: handler}
+
+	session.handleInputMessage([]byte(`{"type":"text","text":"日本語ü"}`))
+
+	if len(handler.typed) != 1 || handler.typed[0] != "日本語ü" {
+		t.Fatalf("typed = %q, want one committed string", handler.typed)
+	}
+	if len(handler.events) != 0 {
+		t.Fatalf("text event must not reach HandleEvent, got %+v", handler.events)
+	}
+}
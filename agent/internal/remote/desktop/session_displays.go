@@ -0,0 +1,326 @@
+package desktop
+
+// Simultaneous multi-monitor streaming.
+//
+// switch_monitor moves the one capture pipeline between displays. A viewer
+// that wants to see several displays at once instead lists them in the
+// start_desktop payload (SessionPolicy.ExtraDisplays) and offers one extra
+// video transceiver per display. Each extra display gets its own capturer,
+// encoder and video track, published under the stream ID
+// "desktop-display-<index>" so the viewer can tell the tracks apart; the
+// primary display keeps the "desktop" stream and all of the adaptive,
+// secure-desktop and GPU machinery in session_capture.go.
+//
+// Extra displays run a deliberately simple CPU loop: capture, skip unchanged
+// frames, encode, write. They follow the primary's FPS and bitrate, use the
+// session's negotiated codec, and are not fanned out to additional viewers.
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+// maxDisplayStreams bounds the extra displays per session. Every one is a
+// full capture + encode pipeline on the target and a full-bitrate stream on
+// its uplink.
+const maxDisplayStreams = 3
+
+// displayStream is the capture/encode pipeline for one extra display.
+type displayStream struct {
+	index    int
+	streamID string
+	capturer ScreenCapturer
+	encoder  *VideoEncoder
+	track    *webrtc.TrackLocalStaticSample
+	differ   *frameDiffer
+	width    int
+	height   int
+	// captureErrors counts consecutive capture failures so a display that
+	// went away logs once rather than every frame.
+	captureErrors int
+	// keyframePending is set by RTCP PLI/FIR and viewer requests; the loop
+	// bypasses frame differencing for the next frame so the IDR is sent
+	// even on a static display.
+	keyframePending atomic.Bool
+}
+
+// extraDisplayIndexes filters a viewer's requested extra displays: negative
+// and duplicate indexes and the primary display are dropped, and the result
+// is capped at limit (and maxDisplayStreams), preserving request order.
+func extraDisplayIndexes(requested []int, primary, limit int) []int {
+	if limit > maxDisplayStreams {
+		limit = maxDisplayStreams
+	}
+	var out []int
+	seen := map[int]bool{primary: true}
+	for _, idx := range requested {
+		if len(out) >= limit {
+			break
+		}
+		if idx < 0 || seen[idx] {
+			continue
+		}
+		seen[idx] = true
+		out = append(out, idx)
+	}
+	return out
+}
+
+// displayStreamID is the MediaStream ID an extra display's track is sent on.
+func displayStreamID(index int) string {
+	return fmt.Sprintf("desktop-display-%d", index)
+}
+
+// addDisplayStreams builds a pipeline and video track for each requested
+// extra display the offer has room for. Must run before SetRemoteDescription
+// so pion binds the tracks to the offer's extra video sections. A display
+// that fails to initialize is logged and skipped; it never fails the session.
+func (s *Session) addDisplayStreams(peerConn *webrtc.PeerConnection, offer string, requested []int) {
+	if len(requested) == 0 {
+		return
+	}
+	indexes := extraDisplayIndexes(requested, s.displayIndex, offeredVideoSections(offer)-1)
+	if len(indexes) < len(requested) {
+		slog.Info("Some requested displays will not be streamed",
+			"session", s.id, "requested", requested, "streaming", indexes)
+	}
+	for _, idx := range indexes {
+		ds, err := newDisplayStream(s.captureConfig, idx, s.codec, s.gpuVendor)
+		if err != nil {
+			slog.Warn("Failed to start display stream", "session", s.id, "display", idx, "error", err.Error())
+			continue
+		}
+		sender, err := peerConn.AddTrack(ds.track)
+		if err != nil {
+			slog.Warn("Failed to add display track", "session", s.id, "display", idx, "error", err.Error())
+			ds.close()
+			continue
+		}
+		go ds.drainRTCP(sender)
+		s.displayStreams = append(s.displayStreams, ds)
+		slog.Info("Display stream added", "session", s.id, "display", idx,
+			"width", ds.width, "height", ds.height, "backend", ds.encoder.BackendName())
+	}
+}
+
+// newDisplayStream creates the capturer, encoder and track for one display.
+// GPU-only encoders (AMF, NVENC) can't take the CPU frames this loop
+// produces, so one is replaced with a software encoder.
+func newDisplayStream(base CaptureConfig, index int, codec Codec, gpuVendor string) (*displayStream, error) {
+	cfg := base
+	cfg.DisplayIndex = index
+	capturer, err := NewScreenCapturer(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create screen capturer: %w", err)
+	}
+	w, h, err := capturer.GetScreenBounds()
+	if err != nil {
+		capturer.Close()
+		return nil, fmt.Errorf("failed to get screen bounds: %w", err)
+	}
+
+	encCfg := EncoderConfig{
+		Codec:          codec,
+		Quality:        QualityAuto,
+		Bitrate:        2_500_000,
+		FPS:            maxFrameRate,
+		PreferHardware: true,
+		GPUVendor:      gpuVendor,
+	}
+	enc, err := NewVideoEncoder(encCfg)
+	if err == nil && enc.IsGPUOnly() {
+		enc.Close()
+		encCfg.PreferHardware = false
+		enc, err = NewVideoEncoder(encCfg)
+	}
+	if err != nil {
+		capturer.Close()
+		return nil, fmt.Errorf("failed to create encoder: %w", err)
+	}
+	if enc.BackendIsPlaceholder() {
+		enc.Close()
+		capturer.Close()
+		return nil, fmt.Errorf("no %s encoder available (backend=%s)", codec, enc.BackendName())
+	}
+	if err := enc.SetDimensions(w, h); err != nil {
+		enc.Close()
+		capturer.Close()
+		return nil, fmt.Errorf("failed to set encoder dimensions: %w", err)
+	}
+	if bgraCap, ok := capturer.(BGRAProvider); ok && bgraCap.IsBGRA() {
+		enc.SetPixelFormat(PixelFormatBGRA)
+	}
+
+	streamID := displayStreamID(index)
+	track, err := webrtc.NewTrackLocalStaticSample(videoTrackCapability(codec), fmt.Sprintf("video-display-%d", index), streamID)
+	if err != nil {
+		enc.Close()
+		capturer.Close()
+		return nil, fmt.Errorf("failed to create video track: %w", err)
+	}
+	return &displayStream{
+		index:    index,
+		streamID: streamID,
+		capturer: capturer,
+		encoder:  enc,
+		track:    track,
+		differ:   newFrameDiffer(),
+		width:    w,
+		height:   h,
+	}, nil
+}
+
+// requestKeyframe forces an IDR on the display's next frame.
+func (ds *displayStream) requestKeyframe() {
+	ds.keyframePending.Store(true)
+	_ = ds.encoder.ForceKeyframe()
+}
+
+// drainRTCP serves PLI/FIR for this display's track, rate-limited the same
+// way drainVideoRTCP is for the primary. Exits when the peer connection
+// closes.
+func (ds *displayStream) drainRTCP(sender *webrtc.RTPSender) {
+	rtcpBuf := make([]byte, 1500)
+	var lastKF time.Time
+	for {
+		n, _, readErr := sender.Read(rtcpBuf)
+		if readErr != nil {
+			return
+		}
+		pkts, perr := rtcp.Unmarshal(rtcpBuf[:n])
+		if perr != nil {
+			continue
+		}
+		for _, p := range pkts {
+			switch p.(type) {
+			case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+				if !lastKF.IsZero() && time.Since(lastKF) < 500*time.Millisecond {
+					continue
+				}
+				lastKF = time.Now()
+				ds.requestKeyframe()
+			}
+		}
+	}
+}
+
+// displayStreamLoop runs one extra display's pipeline until the session
+// stops, pacing itself at the session's current FPS.
+func (s *Session) displayStreamLoop(ds *displayStream) {
+	// Same thread/desktop attachment as captureLoop.
+	prepareCaptureThread()
+	for {
+		frameDuration := time.Second / time.Duration(s.getFPS())
+		select {
+		case <-s.done:
+			return
+		case <-time.After(frameDuration):
+		}
+		ds.captureAndSend(s.id, frameDuration)
+	}
+}
+
+// captureAndSend captures, encodes and writes one frame of the display.
+func (ds *displayStream) captureAndSend(sessionID string, frameDuration time.Duration) {
+	img, err := ds.capturer.Capture()
+	if err != nil {
+		ds.captureErrors++
+		if ds.captureErrors == 1 || ds.captureErrors%300 == 0 {
+			slog.Warn("Display stream capture error", "session", sessionID, "display", ds.index,
+				"consecutive", ds.captureErrors, "error", err.Error())
+		}
+		return
+	}
+	ds.captureErrors = 0
+	if img == nil {
+		return
+	}
+	defer captureImagePool.Put(img)
+
+	forceSend := ds.keyframePending.Swap(false)
+	if w, h := img.Rect.Dx(), img.Rect.Dy(); w != ds.width || h != ds.height {
+		if err := ds.encoder.SetDimensions(w, h); err != nil {
+			slog.Warn("Display stream resize failed", "session", sessionID, "display", ds.index, "error", err.Error())
+			return
+		}
+		ds.width, ds.height = w, h
+		ds.differ.Reset()
+		forceSend = true
+	}
+	// DXGI capturers already return nil for unchanged frames.
+	if h, ok := ds.capturer.(TightLoopHint); !forceSend && (!ok || !h.TightLoop()) {
+		if !ds.differ.HasChanged(img.Pix) {
+			return
+		}
+	}
+
+	var damage []image.Rectangle
+	if dp, ok := ds.capturer.(DirtyRectProvider); ok {
+		damage = dp.DirtyRects()
+	}
+	data, err := ds.encoder.EncodeDamaged(img.Pix, damage)
+	if err != nil {
+		slog.Debug("Display stream encode error", "session", sessionID, "display", ds.index, "error", err.Error())
+		return
+	}
+	if data == nil {
+		return
+	}
+	if err := ds.track.WriteSample(media.Sample{Data: data, Duration: frameDuration}); err != nil {
+		slog.Debug("Failed to write display sample", "session", sessionID, "display", ds.index, "error", err.Error())
+	}
+}
+
+func (ds *displayStream) close() {
+	ds.encoder.Close()
+	ds.capturer.Close()
+}
+
+// startDisplayStreams launches a loop per extra display; called from
+// startStreaming so the loops are tracked by s.wg.
+func (s *Session) startDisplayStreams() {
+	for _, ds := range s.displayStreams {
+		ds.requestKeyframe()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.displayStreamLoop(ds)
+		}()
+	}
+}
+
+// closeDisplayStreams releases every extra display's capturer and encoder;
+// called from doCleanup after the loops have exited.
+func (s *Session) closeDisplayStreams() {
+	for _, ds := range s.displayStreams {
+		ds.close()
+	}
+}
+
+// forEachDisplayStream applies fn to every extra display. The slice is fixed
+// once StartSession returns, so no lock is needed.
+func (s *Session) forEachDisplayStream(fn func(ds *displayStream)) {
+	for _, ds := range s.displayStreams {
+		fn(ds)
+	}
+}
+
+// displayStreamInfo describes the session's streams for the viewer: which
+// MediaStream carries which display.
+func (s *Session) displayStreamInfo() []map[string]any {
+	s.mu.RLock()
+	primary := s.displayIndex
+	s.mu.RUnlock()
+	out := []map[string]any{{"index": primary, "streamId": "desktop", "primary": true}}
+	for _, ds := range s.displayStreams {
+		out = append(out, map[string]any{"index": ds.index, "streamId": ds.streamID, "primary": false})
+	}
+	return out
+}
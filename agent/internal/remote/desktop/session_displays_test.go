@@ -0,0 +1,50 @@
+package desktop
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtraDisplayIndexes(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested []int
+		primary   int
+		limit     int
+		want      []int
+	}{
+		{name: "none requested", requested: nil, primary: 0, limit: 3, want: nil},
+		{name: "primary, negative and duplicates dropped", requested: []int{0, 2, -1, 2, 1}, primary: 0, limit: 3, want: []int{2, 1}},
+		{name: "capped by offered sections", requested: []int{1, 2, 3}, primary: 0, limit: 1, want: []int{1}},
+		{name: "no extra sections offered", requested: []int{1}, primary: 0, limit: 0, want: nil},
+		{name: "capped at maxDisplayStreams", requested: []int{1, 2, 3, 4, 5}, primary: 0, limit: 10, want: []int{1, 2, 3}},
+		{name: "non-zero primary", requested: []int{0, 1}, primary: 1, limit: 3, want: []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extraDisplayIndexes(tt.requested, tt.primary, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("extraDisplayIndexes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisplayStreamInfo(t *testing.T) {
+	s := &Session{
+		id:           "s",
+		displayIndex: 1,
+		displayStreams: []*displayStream{
+			{index: 0, streamID: displayStreamID(0)},
+			{index: 2, streamID: displayStreamID(2)},
+		},
+	}
+	got := s.displayStreamInfo()
+	want := []map[string]any{
+		{"index": 1, "streamId": "desktop", "primary": true},
+		{"index": 0, "streamId": "desktop-display-0", "primary": false},
+		{"index": 2, "streamId": "desktop-display-2", "primary": false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("displayStreamInfo() = %v, want %v", got, want)
+	}
+}
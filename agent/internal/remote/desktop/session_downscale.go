@@ -0,0 +1,96 @@
+package desktop
+
+// Encode-resolution downscaling under constrained bandwidth.
+//
+// When AdaptiveBitrate is pinned at its bitrate floor and loss persists it
+// steps s.downscaleLevel down the downscaleHeights ladder. The CPU capture
+// path then resamples each frame to the smaller size before encoding, so a
+// 4K desktop on a poor link becomes a legible 1440p/1080p stream instead of
+// a full-resolution slideshow. The zero-copy texture path encodes at native
+// size and clears any downscale.
+//
+// The viewer works in video pixels: pointer input arrives in the encoded
+// frame's coordinate space and the cursor overlay is drawn on it, so both are
+// mapped through videoScale while a downscale is active.
+
+import (
+	"image"
+	"log/slog"
+)
+
+// videoScale records an applied downscale: the captured size, the encoded
+// size, and the encoder it was applied to (an encoder swap starts back at
+// native size, so the scale must be re-applied).
+type videoScale struct {
+	srcW, srcH int
+	encW, encH int
+	enc        *VideoEncoder
+}
+
+// toSource maps a point in video pixels onto the captured display.
+func (v *videoScale) toSource(x, y int) (int, int) {
+	return x * v.srcW / v.encW, y * v.srcH / v.encH
+}
+
+// toVideo maps a point on the captured display into video pixels.
+func (v *videoScale) toVideo(x, y int) (int, int) {
+	return x * v.encW / v.srcW, y * v.encH / v.srcH
+}
+
+// downscaleFrame applies the requested downscale level to a captured frame,
+// resizing the encoder on the way in and out of a downscale. Returns the
+// scaled frame (from scaledImagePool), or nil to encode img as-is.
+func (s *Session) downscaleFrame(enc *VideoEncoder, img *image.RGBA) *image.RGBA {
+	srcW, srcH := img.Rect.Dx(), img.Rect.Dy()
+	encW, encH := downscaledDimensions(srcW, srcH, int(s.downscaleLevel.Load()))
+	if encW == srcW && encH == srcH {
+		s.clearDownscale(enc)
+		return nil
+	}
+
+	want := videoScale{srcW: srcW, srcH: srcH, encW: encW, encH: encH, enc: enc}
+	if cur := s.videoScale.Load(); cur == nil || *cur != want {
+		if err := enc.SetDimensions(encW, encH); err != nil {
+			slog.Warn("Failed to set downscaled encoder dimensions", "session", s.id,
+				"width", encW, "height", encH, "error", err.Error())
+			return nil
+		}
+		_ = enc.ForceKeyframe()
+		s.differ.Reset()
+		s.videoScale.Store(&want)
+		slog.Info("Encode resolution downscaled", "session", s.id,
+			"source", [2]int{srcW, srcH}, "encode", [2]int{encW, encH})
+		s.sendResolutionState(srcW, srcH, encW, encH)
+	}
+	return scaleImageTo(img, encW, encH)
+}
+
+// clearDownscale returns the encoder to the captured size if a downscale is
+// active. Safe to call every frame.
+func (s *Session) clearDownscale(enc *VideoEncoder) {
+	vs := s.videoScale.Load()
+	if vs == nil {
+		return
+	}
+	s.videoScale.Store(nil)
+	if err := enc.SetDimensions(vs.srcW, vs.srcH); err != nil {
+		slog.Warn("Failed to restore native encoder dimensions", "session", s.id, "error", err.Error())
+	}
+	_ = enc.ForceKeyframe()
+	s.differ.Reset()
+	slog.Info("Encode resolution restored", "session", s.id, "width", vs.srcW, "height", vs.srcH)
+	s.sendResolutionState(vs.srcW, vs.srcH, vs.srcW, vs.srcH)
+}
+
+// sendResolutionState tells the viewer the stream's encoded size changed, and
+// the display size it represents, so it can label a downscaled stream.
+func (s *Session) sendResolutionState(srcW, srcH, encW, encH int) {
+	s.sendControlMessage(map[string]any{
+		"type":         "encode_resolution",
+		"width":        encW,
+		"height":       encH,
+		"sourceWidth":  srcW,
+		"sourceHeight": srcH,
+		"downscaled":   encW != srcW || encH != srcH,
+	})
+}
@@ -0,0 +1,28 @@
+package desktop
+
+import "testing"
+
+func TestVideoScaleMapsBetweenVideoAndSource(t *testing.T) {
+	vs := &videoScale{srcW: 3840, srcH: 2160, encW: 1920, encH: 1080}
+	if x, y := vs.toSource(960, 540); x != 1920 || y != 1080 {
+		t.Fatalf("toSource(960, 540) = (%d, %d), want (1920, 1080)", x, y)
+	}
+	if x, y := vs.toVideo(3839, 2159); x != 1919 || y != 1079 {
+		t.Fatalf("toVideo(3839, 2159) = (%d, %d), want (1919, 1079)", x, y)
+	}
+}
+
+func TestHandleInputMessageMapsDownscaledCoordinates(t *testing.T) {
+	handler := &stubInputHandler{}
+	session := &Session{id: "session-1", inputHandler: handler}
+	session.videoScale.Store(&videoScale{srcW: 2560, srcH: 1440, encW: 1280, encH: 720})
+
+	session.handleInputMessage([]byte(`{"type":"mouse_move","x":640,"y":360}`))
+
+	if len(handler.events) != 1 {
+		t.Fatalf("expected one event, got %d", len(handler.events))
+	}
+	if got := handler.events[0]; got.X != 1280 || got.Y != 720 {
+		t.Fatalf("expected source coordinates (1280, 720), got (%d, %d)", got.X, got.Y)
+	}
+}
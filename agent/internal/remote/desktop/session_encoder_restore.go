@@ -92,8 +92,10 @@ func (s *Session) maybeRestoreHardwareEncoder(now time.Time) {
 		return
 	}
 	enc := s.encoder.Load()
-	if enc == nil || enc.BackendIsHardware() {
-		// Nothing to restore (already on hardware, or no encoder yet).
+	if enc == nil || enc.BackendIsHardware() || s.videoCodec() != CodecH264 {
+		// Nothing to restore (already on hardware, no encoder yet, or a
+		// VP9/AV1 session, which has no hardware encoder; HEVC sessions
+		// are always on hardware).
 		s.hwRestore.onRestored()
 		return
 	}
@@ -42,6 +42,28 @@ func shouldStopForLifetime(now, startWall, lastActivity time.Time, policy Sessio
 	return false, ""
 }
 
+// idleWarningLead is how long before an idle stop the viewer is warned:
+// a minute, or a quarter of the timeout for very short limits.
+func idleWarningLead(timeout time.Duration) time.Duration {
+	if lead := timeout / 4; lead < time.Minute {
+		return lead
+	}
+	return time.Minute
+}
+
+// idleWarningDue reports whether the session is inside the idle-warning
+// window (idle, but not yet past the timeout) and how long remains.
+func idleWarningDue(now, lastActivity time.Time, timeout time.Duration) (bool, time.Duration) {
+	if timeout <= 0 {
+		return false, 0
+	}
+	remaining := timeout - now.Sub(lastActivity)
+	if remaining <= 0 || remaining > idleWarningLead(timeout) {
+		return false, 0
+	}
+	return true, remaining
+}
+
 // ResolveSessionPolicyFromIPC is the single authoritative decoder that turns an
 // ipc.DesktopStartRequest into a SessionPolicy. Callers (the user helper) must
 // NOT inline the nil→permissive logic — funnel through here so it can't drift
@@ -68,5 +90,11 @@ func ResolveSessionPolicyFromIPC(r ipc.DesktopStartRequest) SessionPolicy {
 	if r.MaxSessionDurationHours > 0 {
 		p.MaxDuration = time.Duration(r.MaxSessionDurationHours) * time.Hour
 	}
+	p.ViewOnly = r.ViewOnly
+	p.ExtraDisplays = r.ExtraDisplays
+	if r.MaxBitrateKbps > 0 {
+		p.MaxBitrate = r.MaxBitrateKbps * 1000
+	}
+	p.AudioDisabled = r.AudioDisabled
 	return p
 }
@@ -52,3 +52,31 @@ func TestResolveSessionPolicyFromIPCTimeoutUnits(t *testing.T) {
 		t.Fatalf("MaxDuration=%v want 2h", p.MaxDuration)
 	}
 }
+
+// TestResolveSessionPolicyFromIPCViewOnly proves the view-only flag survives
+// the service -> helper hop, and that its absence means full control.
+func TestResolveSessionPolicyFromIPCViewOnly(t *testing.T) {
+	if p := ResolveSessionPolicyFromIPC(ipc.DesktopStartRequest{SessionID: "s"}); p.ViewOnly {
+		t.Fatal("absent viewOnly must resolve to full control")
+	}
+	if p := ResolveSessionPolicyFromIPC(ipc.DesktopStartRequest{SessionID: "s", ViewOnly: true}); !p.ViewOnly {
+		t.Fatal("viewOnly must carry over from the IPC request")
+	}
+}
+
+func TestResolveSessionPolicyFromIPCBandwidthCap(t *testing.T) {
+	p := ResolveSessionPolicyFromIPC(ipc.DesktopStartRequest{SessionID: "s", MaxBitrateKbps: 3000, AudioDisabled: true})
+	if p.MaxBitrate != 3_000_000 || !p.AudioDisabled {
+		t.Fatalf("MaxBitrate = %d, AudioDisabled = %v; want 3000000, true", p.MaxBitrate, p.AudioDisabled)
+	}
+	if p := ResolveSessionPolicyFromIPC(ipc.DesktopStartRequest{SessionID: "s"}); p.MaxBitrate != 0 || p.AudioDisabled {
+		t.Fatal("absent bandwidth fields must leave the session uncapped with audio allowed")
+	}
+}
+
+func TestResolveSessionPolicyFromIPCExtraDisplays(t *testing.T) {
+	p := ResolveSessionPolicyFromIPC(ipc.DesktopStartRequest{SessionID: "s", ExtraDisplays: []int{2, 1}})
+	if len(p.ExtraDisplays) != 2 || p.ExtraDisplays[0] != 2 || p.ExtraDisplays[1] != 1 {
+		t.Fatalf("ExtraDisplays = %v, want [2 1]", p.ExtraDisplays)
+	}
+}
@@ -70,6 +70,7 @@ func (s *Session) startStreaming() {
 			defer observability.Recoverer("desktop.adaptiveLoop")
 			s.adaptiveLoop()
 		}()
+		s.startDisplayStreams()
 		if s.cursorDC != nil {
 			if cp, ok := s.capturer.(CursorProvider); ok {
 				s.wg.Add(1)
@@ -83,18 +84,23 @@ func (s *Session) startStreaming() {
 
 		// Initialize audio capture (WASAPI loopback on Windows).
 		// Audio is muted by default — the viewer sends toggle_audio to unmute.
-		if s.audioTrack != nil {
+		if s.audioTrack != nil && s.audioEncoder != nil {
 			ac := NewAudioCapturer()
 			if ac != nil {
 				s.audioCapturer = ac
-				audioTrack := s.audioTrack
-				err := ac.Start(func(frame []byte) {
+				audioEnc := s.audioEncoder
+				err := ac.Start(func(pcm []float32) {
 					if !s.audioEnabled.Load() {
-						return // muted — skip sending to save bandwidth
+						return // muted — skip encoding and sending to save CPU and bandwidth
 					}
-					_ = audioTrack.WriteSample(media.Sample{
+					frame, encErr := audioEnc.Encode(pcm)
+					if encErr != nil {
+						slog.Debug("Audio encode failed", "session", s.id, "codec", audioEnc.Name(), "error", encErr.Error())
+						return
+					}
+					s.writeAudioSample(media.Sample{
 						Data:     frame,
-						Duration: 20 * time.Millisecond,
+						Duration: audioFrameDuration,
 					})
 				})
 				if err != nil {
@@ -102,7 +108,7 @@ func (s *Session) startStreaming() {
 					ac.Stop() // release partially-initialized COM resources
 					s.audioCapturer = nil
 				} else {
-					slog.Info("Audio capture started (WASAPI loopback)", "session", s.id)
+					slog.Info("Audio capture started (WASAPI loopback)", "session", s.id, "codec", audioEnc.Name())
 				}
 			}
 		}
@@ -114,14 +120,21 @@ func (s *Session) startStreaming() {
 			"height", h,
 		)
 
-		// Notify viewer if input injection is unavailable (e.g. macOS login
-		// window without IOHIDSystem). Send asynchronously because the control
-		// data channel may not be open yet at startStreaming time.
-		if !s.inputHandler.InputAvailable() {
+		// Notify viewer if input injection is unavailable (view-only session,
+		// or macOS login window without IOHIDSystem). Send asynchronously
+		// because the control data channel may not be open yet at
+		// startStreaming time.
+		reason := ""
+		if s.inputHandler == nil {
+			reason = "view-only session"
+		} else if !s.inputHandler.InputAvailable() {
+			reason = "IOHIDSystem unavailable at login window"
+		}
+		if reason != "" {
 			s.wg.Add(1)
 			go func() {
 				defer s.wg.Done()
-				s.sendInputStatus()
+				s.sendInputStatus(reason)
 			}()
 		}
 	})
@@ -177,6 +190,10 @@ func (s *Session) cursorStreamLoop(prov CursorProvider) {
 			// so viewer can map directly using videoWidth/videoHeight.
 			relX := cx - s.cursorOffsetX.Load()
 			relY := cy - s.cursorOffsetY.Load()
+			if vs := s.videoScale.Load(); vs != nil {
+				x, y := vs.toVideo(int(relX), int(relY))
+				relX, relY = int32(x), int32(y)
+			}
 
 			// Get cursor shape if the provider supports it.
 			// CursorShape() is cheap — it reads the value already sampled
@@ -211,24 +228,26 @@ func (s *Session) cursorStreamLoop(prov CursorProvider) {
 				payload = append(payload, '"')
 			}
 			payload = append(payload, '}')
-			if err := s.cursorDC.SendText(string(payload)); err != nil {
-				slog.Debug("Failed to send cursor update", "session", s.id, "error", err.Error())
-			}
+			s.sendCursorUpdate(string(payload))
 		}
 	}
 }
 
-// metricsLogger periodically logs streaming metrics
+// metricsLogger periodically logs streaming metrics and reports a quality
+// sample for the interval.
 func (s *Session) metricsLogger() {
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(metricsInterval)
 	defer ticker.Stop()
 
+	prev := s.metrics.Snapshot()
 	for {
 		select {
 		case <-s.done:
 			return
 		case <-ticker.C:
 			snap := s.metrics.Snapshot()
+			s.reportQuality(prev, snap)
+			prev = snap
 			slog.Info("Desktop WebRTC metrics",
 				"session", s.id,
 				"captured", snap.FramesCaptured,
@@ -0,0 +1,348 @@
+package desktop
+
+// Additional viewers ("shadowing") on a running desktop session.
+//
+// StartSession replaces whatever session is running, because one capture and
+// encode pipeline per agent is all Desktop Duplication and the GPU encoders
+// tolerate. AddViewer instead attaches another peer connection to the
+// existing session: the viewer gets its own video/audio tracks and cursor
+// channel, and the capture loop writes every encoded sample to all of them.
+// That lets a senior tech watch — or co-drive — a junior tech's session
+// without a second capturer or encoder.
+//
+// The primary viewer owns the stream: bitrate, FPS, monitor, audio mute and
+// the negotiated codec all follow it, and an extra viewer's offer must support
+// that codec. Extra viewers only get keyframe requests on their control
+// channel, and their input is delivered only when they joined as co-control.
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+// maxSessionViewers bounds the extra viewers per session. Each one costs a
+// DTLS/SRTP stream of the full encoded bitrate on the target's uplink.
+const maxSessionViewers = 4
+
+// viewerPeer is one additional viewer attached to a Session.
+type viewerPeer struct {
+	id         string
+	peerConn   *webrtc.PeerConnection
+	videoTrack *webrtc.TrackLocalStaticSample
+	audioTrack *webrtc.TrackLocalStaticSample
+	cursorDC   *webrtc.DataChannel
+	viewOnly   bool
+	closeOnce  sync.Once
+}
+
+// close tears down the viewer's peer connection, which also closes its data
+// channels and ends its RTCP drain goroutine.
+func (v *viewerPeer) close() {
+	v.closeOnce.Do(func() {
+		if v.peerConn != nil {
+			_ = v.peerConn.Close()
+		}
+	})
+}
+
+// AddViewer attaches an additional viewer to a running session and returns
+// its SDP answer. Input from a viewOnly viewer is never delivered; on a
+// view-only session every viewer is view-only. Re-joining with the same
+// viewerID replaces the earlier peer so retries are safe.
+func (m *SessionManager) AddViewer(sessionID, viewerID, offer string, iceServers []ICEServerConfig, viewOnly bool) (answer string, err error) {
+	// Serialize with StartSession so we never attach to a session whose
+	// encoder and tracks are still being built.
+	m.startMu.Lock()
+	m.mu.RLock()
+	session := m.sessions[sessionID]
+	m.mu.RUnlock()
+	m.startMu.Unlock()
+
+	if session == nil || !session.active() {
+		return "", fmt.Errorf("%w: %s", ErrNoActiveSession, sessionID)
+	}
+	if session.videoTrack == nil {
+		return "", fmt.Errorf("session %s has no video track", sessionID)
+	}
+	if !offeredVideoCodecs(offer)[session.codec] {
+		return "", fmt.Errorf("viewer offer does not support the session's %s codec", session.codec)
+	}
+	viewOnly = viewOnly || session.viewOnly
+
+	peerConn, err := newDesktopPeerConnection(sessionID, iceServers)
+	if err != nil {
+		return "", err
+	}
+	v := &viewerPeer{id: viewerID, peerConn: peerConn, viewOnly: viewOnly}
+	if err := session.attachViewer(v); err != nil {
+		v.close()
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			session.removeViewer(v)
+		}
+	}()
+
+	videoTrack, err := webrtc.NewTrackLocalStaticSample(videoTrackCapability(session.codec), "video", "desktop")
+	if err != nil {
+		return "", fmt.Errorf("failed to create video track: %w", err)
+	}
+	sender, err := peerConn.AddTrack(videoTrack)
+	if err != nil {
+		return "", fmt.Errorf("failed to add video track: %w", err)
+	}
+	go session.drainVideoRTCP(sender)
+
+	var audioTrack *webrtc.TrackLocalStaticSample
+	if session.audioEncoder != nil {
+		track, trackErr := webrtc.NewTrackLocalStaticSample(session.audioEncoder.Capability(), "audio", "desktop-audio")
+		if trackErr == nil {
+			_, trackErr = peerConn.AddTrack(track)
+		}
+		if trackErr != nil {
+			slog.Warn("Failed to add audio track for viewer", "session", sessionID, "viewer", viewerID, "error", trackErr.Error())
+		} else {
+			audioTrack = track
+		}
+	}
+
+	ordered := false
+	maxRetransmits := uint16(0)
+	cursorDC, cursorErr := peerConn.CreateDataChannel("cursor", &webrtc.DataChannelInit{
+		Ordered:        &ordered,
+		MaxRetransmits: &maxRetransmits,
+	})
+	if cursorErr != nil {
+		slog.Warn("Failed to create cursor DataChannel for viewer", "session", sessionID, "viewer", viewerID, "error", cursorErr.Error())
+	}
+
+	peerConn.OnDataChannel(func(dc *webrtc.DataChannel) {
+		switch dc.Label() {
+		case "input":
+			if viewOnly {
+				return
+			}
+			dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+				session.onViewerDataChannelMessage("input", msg.Data)
+			})
+		case "control":
+			dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+				session.handleViewerControlMessage(viewerID, msg.Data)
+			})
+		}
+	})
+
+	var disconnectTimer *time.Timer
+	peerConn.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		slog.Info("Desktop viewer connection state", "session", sessionID, "viewer", viewerID, "state", state.String())
+		if disconnectTimer != nil {
+			disconnectTimer.Stop()
+			disconnectTimer = nil
+		}
+		switch state {
+		case webrtc.PeerConnectionStateConnected:
+			// The new viewer can't decode until the next IDR.
+			if enc := session.encoder.Load(); enc != nil {
+				_ = enc.ForceKeyframe()
+			}
+		case webrtc.PeerConnectionStateDisconnected:
+			// Same grace as the primary viewer; losing an extra viewer never
+			// stops the session.
+			disconnectTimer = time.AfterFunc(20*time.Second, func() {
+				if peerConn.ConnectionState() != webrtc.PeerConnectionStateConnected {
+					session.removeViewer(v)
+				}
+			})
+		case webrtc.PeerConnectionStateFailed, webrtc.PeerConnectionStateClosed:
+			session.removeViewer(v)
+		}
+	})
+
+	if err := peerConn.SetRemoteDescription(webrtc.SessionDescription{
+		Type: webrtc.SDPTypeOffer,
+		SDP:  offer,
+	}); err != nil {
+		return "", fmt.Errorf("failed to set remote description: %w", err)
+	}
+	pcAnswer, err := peerConn.CreateAnswer(nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create answer: %w", err)
+	}
+	if audioTrack != nil && session.audioEncoder.Name() == "opus" {
+		pcAnswer.SDP = withOpusStereo(pcAnswer.SDP)
+	}
+	if err := peerConn.SetLocalDescription(pcAnswer); err != nil {
+		return "", fmt.Errorf("failed to set local description: %w", err)
+	}
+	if err := awaitICECandidates(peerConn, sessionID, session.done); err != nil {
+		return "", err
+	}
+	ld := peerConn.LocalDescription()
+	if ld == nil {
+		return "", fmt.Errorf("local description not available")
+	}
+
+	// Publish the tracks last: the capture loop starts writing to them as
+	// soon as they're visible.
+	session.viewersMu.Lock()
+	v.videoTrack = videoTrack
+	v.audioTrack = audioTrack
+	if cursorErr == nil {
+		v.cursorDC = cursorDC
+	}
+	session.viewersMu.Unlock()
+
+	slog.Info("Desktop viewer attached", "session", sessionID, "viewer", viewerID, "viewOnly", viewOnly)
+	return ld.SDP, nil
+}
+
+// RemoveViewer detaches an additional viewer. Returns false when the session
+// or viewer is unknown.
+func (m *SessionManager) RemoveViewer(sessionID, viewerID string) bool {
+	m.mu.RLock()
+	session := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if session == nil {
+		return false
+	}
+	session.viewersMu.RLock()
+	v := session.viewers[viewerID]
+	session.viewersMu.RUnlock()
+	if v == nil {
+		return false
+	}
+	session.removeViewer(v)
+	return true
+}
+
+func (s *Session) active() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.isActive
+}
+
+// attachViewer registers v, replacing an earlier peer with the same id.
+func (s *Session) attachViewer(v *viewerPeer) error {
+	s.viewersMu.Lock()
+	old := s.viewers[v.id]
+	if old == nil && len(s.viewers) >= maxSessionViewers {
+		s.viewersMu.Unlock()
+		return fmt.Errorf("session already has the maximum of %d additional viewers", maxSessionViewers)
+	}
+	if s.viewers == nil {
+		s.viewers = make(map[string]*viewerPeer)
+	}
+	s.viewers[v.id] = v
+	s.viewersMu.Unlock()
+	if old != nil {
+		old.close()
+	}
+	return nil
+}
+
+// removeViewer detaches v if it is still the registered peer for its id.
+func (s *Session) removeViewer(v *viewerPeer) {
+	s.viewersMu.Lock()
+	removed := s.viewers[v.id] == v
+	if removed {
+		delete(s.viewers, v.id)
+	}
+	s.viewersMu.Unlock()
+	v.close()
+	if removed {
+		slog.Info("Desktop viewer detached", "session", s.id, "viewer", v.id)
+	}
+}
+
+// closeViewers detaches every additional viewer; called on session teardown.
+func (s *Session) closeViewers() {
+	s.viewersMu.Lock()
+	viewers := s.viewers
+	s.viewers = nil
+	s.viewersMu.Unlock()
+	for _, v := range viewers {
+		v.close()
+	}
+}
+
+// viewerCount reports how many additional viewers are attached.
+func (s *Session) viewerCount() int {
+	s.viewersMu.RLock()
+	defer s.viewersMu.RUnlock()
+	return len(s.viewers)
+}
+
+// handleViewerControlMessage serves an extra viewer's control channel. The
+// stream is shared, so the only thing such a viewer may ask for is a keyframe.
+func (s *Session) handleViewerControlMessage(viewerID string, data []byte) {
+	if len(data) > maxControlMessageBytes {
+		return
+	}
+	var msg struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+	if msg.Type != "request_keyframe" {
+		slog.Debug("Ignoring control message from additional viewer", "session", s.id, "viewer", viewerID, "type", msg.Type)
+		return
+	}
+	if enc := s.encoder.Load(); enc != nil {
+		_ = enc.ForceKeyframe()
+	}
+}
+
+// writeVideoSample writes an encoded frame to the primary viewer's track and
+// every additional viewer's. Only the primary write's error is returned — a
+// struggling extra viewer must not count as a dropped frame.
+func (s *Session) writeVideoSample(sample media.Sample) error {
+	err := s.videoTrack.WriteSample(sample)
+	s.viewersMu.RLock()
+	for _, v := range s.viewers {
+		if v.videoTrack == nil {
+			continue
+		}
+		if werr := v.videoTrack.WriteSample(sample); werr != nil {
+			slog.Debug("Failed to write sample to viewer", "session", s.id, "viewer", v.id, "error", werr.Error())
+		}
+	}
+	s.viewersMu.RUnlock()
+	return err
+}
+
+// writeAudioSample is the audio counterpart of writeVideoSample.
+func (s *Session) writeAudioSample(sample media.Sample) {
+	if s.audioTrack != nil {
+		_ = s.audioTrack.WriteSample(sample)
+	}
+	s.viewersMu.RLock()
+	for _, v := range s.viewers {
+		if v.audioTrack != nil {
+			_ = v.audioTrack.WriteSample(sample)
+		}
+	}
+	s.viewersMu.RUnlock()
+}
+
+// sendCursorUpdate sends a cursor position payload to the primary viewer and
+// every additional viewer whose cursor channel is open.
+func (s *Session) sendCursorUpdate(payload string) {
+	if err := s.cursorDC.SendText(payload); err != nil {
+		slog.Debug("Failed to send cursor update", "session", s.id, "error", err.Error())
+	}
+	s.viewersMu.RLock()
+	for _, v := range s.viewers {
+		if v.cursorDC != nil && v.cursorDC.ReadyState() == webrtc.DataChannelStateOpen {
+			_ = v.cursorDC.SendText(payload)
+		}
+	}
+	s.viewersMu.RUnlock()
+}
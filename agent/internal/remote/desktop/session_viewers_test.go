@@ -0,0 +1,114 @@
+package desktop
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+func newTestVideoTrack(t *testing.T) *webrtc.TrackLocalStaticSample {
+	t.Helper()
+	track, err := webrtc.NewTrackLocalStaticSample(videoTrackCapability(CodecH264), "video", "desktop")
+	if err != nil {
+		t.Fatalf("NewTrackLocalStaticSample: %v", err)
+	}
+	return track
+}
+
+func TestAttachViewer_ReplacesSameIDAndEnforcesLimit(t *testing.T) {
+	s := &Session{id: "shadow"}
+
+	first := &viewerPeer{id: "senior"}
+	if err := s.attachViewer(first); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	again := &viewerPeer{id: "senior"}
+	if err := s.attachViewer(again); err != nil {
+		t.Fatalf("re-attach with same id must replace, got %v", err)
+	}
+	if s.viewerCount() != 1 || s.viewers["senior"] != again {
+		t.Fatalf("expected the re-joined peer to replace the first, viewers=%v", s.viewers)
+	}
+
+	for i := 1; i < maxSessionViewers; i++ {
+		if err := s.attachViewer(&viewerPeer{id: fmt.Sprintf("v%d", i)}); err != nil {
+			t.Fatalf("attach %d: %v", i, err)
+		}
+	}
+	if err := s.attachViewer(&viewerPeer{id: "one-too-many"}); err == nil {
+		t.Fatalf("expected attach beyond %d viewers to fail", maxSessionViewers)
+	}
+}
+
+func TestRemoveViewer_IgnoresStalePeer(t *testing.T) {
+	s := &Session{id: "shadow"}
+	stale := &viewerPeer{id: "senior"}
+	_ = s.attachViewer(stale)
+	current := &viewerPeer{id: "senior"}
+	_ = s.attachViewer(current)
+
+	// A late state callback from the replaced peer must not detach its
+	// replacement.
+	s.removeViewer(stale)
+	if s.viewers["senior"] != current {
+		t.Fatal("removing a replaced peer must leave the current one attached")
+	}
+	s.removeViewer(current)
+	if s.viewerCount() != 0 {
+		t.Fatalf("expected no viewers, got %d", s.viewerCount())
+	}
+}
+
+func TestWriteVideoSample_FansOutToViewers(t *testing.T) {
+	s := &Session{id: "shadow", videoTrack: newTestVideoTrack(t)}
+	pending := &viewerPeer{id: "joining"}
+	ready := &viewerPeer{id: "watching", videoTrack: newTestVideoTrack(t)}
+	_ = s.attachViewer(pending)
+	_ = s.attachViewer(ready)
+
+	// Unbound tracks accept samples as no-ops; the point is that a viewer
+	// still negotiating (no track yet) is skipped rather than dereferenced.
+	if err := s.writeVideoSample(media.Sample{Data: []byte{0, 0, 0, 1, 0x65}, Duration: time.Millisecond}); err != nil {
+		t.Fatalf("writeVideoSample: %v", err)
+	}
+	s.writeAudioSample(media.Sample{Data: []byte{0xff}, Duration: audioFrameDuration})
+}
+
+func TestCloseViewers_DetachesAll(t *testing.T) {
+	s := &Session{id: "shadow"}
+	_ = s.attachViewer(&viewerPeer{id: "a"})
+	_ = s.attachViewer(&viewerPeer{id: "b"})
+	s.doCleanup()
+	if s.viewerCount() != 0 {
+		t.Fatalf("doCleanup must detach every viewer, %d left", s.viewerCount())
+	}
+}
+
+func TestAddViewer_RequiresRunningSession(t *testing.T) {
+	m := NewSessionManager()
+	_, err := m.AddViewer("missing", "senior", "v=0\r\n", nil, true)
+	if !errors.Is(err, ErrNoActiveSession) {
+		t.Fatalf("expected ErrNoActiveSession, got %v", err)
+	}
+	if m.RemoveViewer("missing", "senior") {
+		t.Fatal("RemoveViewer on an unknown session must report false")
+	}
+}
+
+func TestAddViewer_RejectsOfferWithoutSessionCodec(t *testing.T) {
+	m := NewSessionManager()
+	s := &Session{id: "shadow", isActive: true, codec: CodecAV1, videoTrack: newTestVideoTrack(t), done: make(chan struct{})}
+	m.sessions["shadow"] = s
+
+	offer := "v=0\r\nm=video 9 UDP/TLS/RTP/SAVPF 102\r\na=rtpmap:102 H264/90000\r\n"
+	if _, err := m.AddViewer("shadow", "senior", offer, nil, true); err == nil {
+		t.Fatal("expected a viewer that can't decode the session codec to be rejected")
+	}
+	if s.viewerCount() != 0 {
+		t.Fatal("a rejected viewer must not be attached")
+	}
+}
@@ -26,6 +26,22 @@ type SessionPolicy struct {
 	ClipboardViewerToHost bool
 	IdleTimeout           time.Duration // 0 = disabled
 	MaxDuration           time.Duration // 0 = disabled
+	// ViewOnly makes the session provably watch-only: no InputHandler is ever
+	// constructed, viewer->host clipboard and file drop are off, and control
+	// actions that act on the target (SAS, input blocking, privacy mode, lock)
+	// are refused. Hiding the controls in the viewer is not enough — the
+	// viewer is untrusted.
+	ViewOnly bool
+	// ExtraDisplays are display indexes streamed alongside the primary
+	// display, each on its own video track (see session_displays.go). Indexes
+	// the offer has no video section for are dropped.
+	ExtraDisplays []int
+	// MaxBitrate is a hard bandwidth ceiling in bits/s set by the server
+	// (e.g. for metered sites); 0 = none. It bounds the adaptive controller
+	// and the viewer's set_bitrate, and unmuted audio is paid for out of it.
+	MaxBitrate int
+	// AudioDisabled refuses the viewer's toggle_audio for the session.
+	AudioDisabled bool
 }
 
 // StartSession creates and starts a new remote desktop session.
@@ -42,6 +58,9 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 	defer m.startMu.Unlock()
 
 	sessionStart := time.Now()
+	if policy.ViewOnly {
+		policy.ClipboardViewerToHost = false
+	}
 	// Desktop Duplication and GPU pipelines get unstable with multiple concurrent
 	// sessions in one process. Enforce single active desktop session per agent.
 	var toStop []*Session
@@ -60,63 +79,35 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 		slog.Info("StartSession: stop existing sessions took long", "session", sessionID, "elapsed", elapsed)
 	}
 
-	// Create WebRTC configuration
-	parsedICE := parseICEServers(iceServers)
-	for _, s := range parsedICE {
-		hasCreds := s.Username != ""
-		slog.Info("ICE server configured", "session", sessionID, "urls", s.URLs, "hasCreds", hasCreds)
-	}
-	config := webrtc.Configuration{
-		ICEServers: parsedICE,
-	}
-
-	// Register playout-delay RTP header extension for low-latency screen sharing.
-	// This signals to Chrome that frames should be rendered immediately rather than
-	// buffered in a jitter buffer designed for video calls.
-	mediaEngine := &webrtc.MediaEngine{}
-	if err := mediaEngine.RegisterDefaultCodecs(); err != nil {
-		return "", fmt.Errorf("failed to register default codecs: %w", err)
-	}
-	const playoutDelayURI = "http://www.webrtc.org/experiments/rtp-hdrext/playout-delay"
-	if regErr := mediaEngine.RegisterHeaderExtension(
-		webrtc.RTPHeaderExtensionCapability{URI: playoutDelayURI},
-		webrtc.RTPCodecTypeVideo,
-	); regErr != nil {
-		slog.Warn("Failed to register playout-delay extension (non-fatal)", "error", regErr.Error())
-	}
-
-	// ICE timeout tuning: keep NAT bindings alive and detect failures faster.
-	se := webrtc.SettingEngine{}
-	se.SetICETimeouts(
-		5*time.Second,  // disconnectedTimeout — detect no-media quickly
-		15*time.Second, // failedTimeout — reduced from 25s default for faster recovery
-		2*time.Second,  // keepAliveInterval — refresh STUN bindings when no media
-	)
-
-	api := webrtc.NewAPI(
-		webrtc.WithMediaEngine(mediaEngine),
-		webrtc.WithSettingEngine(se),
-	)
-
-	// Create peer connection with custom API (playout-delay + ICE tuning)
-	peerConn, err := api.NewPeerConnection(config)
+	peerConn, err := newDesktopPeerConnection(sessionID, iceServers)
 	if err != nil {
-		return "", fmt.Errorf("failed to create peer connection: %w", err)
+		return "", err
 	}
 
 	// Create session early so external StopSession calls and peer callbacks can
 	// clean up even if we fail before returning an answer.
 	session := &Session{
-		id:           sessionID,
-		peerConn:     peerConn,
-		inputHandler: NewInputHandler(m.config.DesktopContext),
-		done:         make(chan struct{}),
-		isActive:     true,
-		fps:          defaultFrameRate,
-		differ:       newFrameDiffer(),
-		cursor:       newCursorOverlay(),
-		metrics:      newStreamMetrics(),
-		sasHandler:   m.OnSASRequest,
+		id:          sessionID,
+		peerConn:    peerConn,
+		viewOnly:    policy.ViewOnly,
+		bandwidth:   newBandwidthCap(policy),
+		done:        make(chan struct{}),
+		isActive:    true,
+		fps:         defaultFrameRate,
+		differ:      newFrameDiffer(),
+		cursor:      newCursorOverlay(),
+		metrics:     newStreamMetrics(),
+		sasHandler:  m.OnSASRequest,
+		chatHandler: m.OnChatMessage,
+
+		annotations: newAnnotationLayer(),
+
+		qualityHandler: m.OnQualitySample,
+	}
+	if policy.ViewOnly {
+		slog.Info("Desktop session is view-only, input injection disabled", "session", sessionID)
+	} else {
+		session.inputHandler = NewInputHandler(m.config.DesktopContext)
 	}
 	session.cursorStreamEnabled.Store(false)
 
@@ -142,6 +133,9 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 		go func() {
 			ticker := time.NewTicker(15 * time.Second)
 			defer ticker.Stop()
+			// warned is cleared once input resumes so a later idle stretch
+			// warns again.
+			warned := false
 			for {
 				select {
 				case <-session.done:
@@ -151,8 +145,17 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 					lastActivity := time.Unix(0, session.lastInputUnixNano.Load())
 					stop, reason := shouldStopForLifetime(now, startWall, lastActivity, policy)
 					if !stop {
+						due, remaining := idleWarningDue(now, lastActivity, policy.IdleTimeout)
+						if due && !warned {
+							session.sendControlMessage(map[string]any{
+								"type":             "idle_warning",
+								"secondsRemaining": int(remaining.Round(time.Second) / time.Second),
+							})
+						}
+						warned = due
 						continue
 					}
+					session.sendControlMessage(map[string]any{"type": "session_ending", "reason": reason})
 					if reason == "idle_timeout_exceeded" {
 						slog.Warn("Desktop session idle timeout, stopping",
 							"session", sessionID, "idleFor", now.Sub(lastActivity).Round(time.Second))
@@ -170,70 +173,6 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 		}()
 	}
 
-	// Create H264 video track
-	videoTrack, err := webrtc.NewTrackLocalStaticSample(
-		webrtc.RTPCodecCapability{
-			MimeType:  webrtc.MimeTypeH264,
-			ClockRate: 90000,
-			// Main profile Level 3.1 — matches MFT encoder's CABAC configuration.
-			// VideoToolbox uses Baseline; browser decoders accept both transparently.
-			SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=4d001f",
-		},
-		"video",
-		"desktop",
-	)
-	if err != nil {
-		return "", fmt.Errorf("failed to create video track: %w", err)
-	}
-	session.videoTrack = videoTrack
-
-	// Add video track to peer connection
-	sender, err := peerConn.AddTrack(videoTrack)
-	if err != nil {
-		return "", fmt.Errorf("failed to add video track: %w", err)
-	}
-
-	// Drain RTCP so we don't block on backpressure.
-	go func() {
-		rtcpBuf := make([]byte, 1500)
-		var lastKF time.Time
-		firstKF := true
-		var lastEnc *VideoEncoder
-		for {
-			n, _, readErr := sender.Read(rtcpBuf)
-			if readErr != nil {
-				return
-			}
-			pkts, perr := rtcp.Unmarshal(rtcpBuf[:n])
-			if perr != nil {
-				continue
-			}
-			for _, p := range pkts {
-				switch p.(type) {
-				case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
-					enc := session.encoder.Load()
-					// Reset PLI rate-limit when the encoder pointer changes
-					// (e.g. after swapToSoftwareEncoder) so the new encoder
-					// gets an immediate keyframe.
-					if enc != lastEnc {
-						firstKF = true
-						lastEnc = enc
-					}
-					// Allow the first PLI immediately for fast startup,
-					// then rate-limit subsequent ones to 500ms apart.
-					if !firstKF && time.Since(lastKF) < 500*time.Millisecond {
-						continue
-					}
-					firstKF = false
-					lastKF = time.Now()
-					if enc != nil {
-						_ = enc.ForceKeyframe()
-					}
-				}
-			}
-		}
-	}()
-
 	// Create screen capturer (optionally targeting a specific display)
 	capConfig := m.CaptureConfig()
 	if displayIndex > 0 {
@@ -287,7 +226,11 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 	// Start at 2.5Mbps — matches the viewer's default max-bitrate slider.
 	// Adaptive ramps from here. Too low and the MFT encoder can't produce
 	// good keyframes; too high and it bursts the jitter buffer.
-	initBitrate := 2_500_000
+	initBitrate := session.bandwidth.clamp(2_500_000, false)
+	if policy.MaxBitrate > 0 {
+		slog.Info("Desktop session has a bandwidth cap", "session", sessionID,
+			"maxBitrate", policy.MaxBitrate, "audioAllowed", session.bandwidth.audioAllowed())
+	}
 
 	// Probe the active input desktop BEFORE creating the encoder. On
 	// Windows, if we're starting on Winlogon / Screen-saver / UAC, DXGI
@@ -303,28 +246,76 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 		preferHardware = false
 	}
 
-	// Create H264 encoder via factory (will use MFT on Windows).
-	// Always configure the encoder for maxFrameRate so hardware MFT rate control
-	// is correct from first frame. The capture loop throttles if needed.
+	// Pick the video codec: HEVC/VP9/AV1 when the viewer offered them and the
+	// encoder (hardware for HEVC, library for VP9/AV1) initializes, otherwise
+	// H264 via factory (will use MFT on Windows). Always configure the encoder for maxFrameRate so hardware MFT
+	// rate control is correct from first frame. The capture loop throttles if
+	// needed.
 	encoderStart := time.Now()
-	enc, err := NewVideoEncoder(EncoderConfig{
-		Codec:          CodecH264,
-		Quality:        QualityAuto,
-		Bitrate:        initBitrate,
-		FPS:            maxFrameRate,
-		PreferHardware: preferHardware,
-		GPUVendor:      m.gpuVendor,
-	})
+	candidates := videoCodecCandidates(offeredVideoCodecs(offer), loadVideoCodecPreference(), w, h)
+	var enc *VideoEncoder
+	for _, codec := range candidates {
+		enc, err = NewVideoEncoder(EncoderConfig{
+			Codec:          codec,
+			Quality:        QualityAuto,
+			Bitrate:        initBitrate,
+			FPS:            maxFrameRate,
+			PreferHardware: preferHardware,
+			GPUVendor:      m.gpuVendor,
+		})
+		if err == nil && codec != CodecH264 {
+			// Non-H264 codecs initialize on SetDimensions; a failure there
+			// still leaves H264 to fall back to. The D3D11 device goes in
+			// first so a hardware HEVC MFT sets up zero-copy input during
+			// that initialization (see below).
+			if tp, ok := capturer.(TextureProvider); ok {
+				enc.SetD3D11Device(tp.GetD3D11Device(), tp.GetD3D11Context())
+			}
+			if err = enc.SetDimensions(w, h); err != nil {
+				enc.Close()
+			}
+		}
+		if err == nil {
+			session.codec = codec
+			break
+		}
+		if codec != CodecH264 {
+			slog.Info("StartSession: codec unavailable, trying next",
+				"session", sessionID, "codec", string(codec), "error", err.Error())
+		}
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to create H264 encoder: %w", err)
 	}
-	slog.Info("StartSession: encoder created", "session", sessionID, "backend", enc.BackendName(), "elapsed", time.Since(encoderStart))
+	slog.Info("StartSession: encoder created", "session", sessionID,
+		"codec", string(session.codec), "backend", enc.BackendName(), "elapsed", time.Since(encoderStart))
 	session.encoder.Store(enc)
 
 	if enc.BackendIsPlaceholder() {
 		return "", fmt.Errorf("no H264 encoder available (backend=%s)", enc.BackendName())
 	}
 
+	// Create the video track for the negotiated codec. The answer is built
+	// from the tracks added here, so this must follow encoder selection.
+	videoTrack, err := webrtc.NewTrackLocalStaticSample(
+		videoTrackCapability(session.codec),
+		"video",
+		"desktop",
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to create video track: %w", err)
+	}
+	session.videoTrack = videoTrack
+
+	// Add video track to peer connection
+	sender, err := peerConn.AddTrack(videoTrack)
+	if err != nil {
+		return "", fmt.Errorf("failed to add video track: %w", err)
+	}
+
+	// Drain RTCP so we don't block on backpressure.
+	go session.drainVideoRTCP(sender)
+
 	// Pass D3D11 device to encoder for GPU zero-copy pipeline setup.
 	// Must happen BEFORE SetDimensions: SetDimensions eagerly initializes the
 	// encoder, and the MFT zero-copy input path requires the DXGI device
@@ -380,6 +371,24 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 			if enc := session.encoder.Load(); enc != nil {
 				enc.SetFPS(fps)
 			}
+			session.forEachDisplayStream(func(ds *displayStream) {
+				_ = ds.encoder.SetFPS(fps)
+			})
+		},
+		// Opus follows the video bitrate so audio backs off with it under
+		// congestion. The audio encoder is created further down, before
+		// any viewer stats can arrive.
+		OnBitrateChange: func(bps int) {
+			if ae := session.audioEncoder; ae != nil {
+				ae.SetBitrate(audioBitrateForVideo(bps))
+			}
+			// Extra displays track the primary's bitrate.
+			session.forEachDisplayStream(func(ds *displayStream) {
+				_ = ds.encoder.SetBitrate(bps)
+			})
+		},
+		OnResolutionChange: func(level int) {
+			session.downscaleLevel.Store(int32(level))
 		},
 	})
 	if err == nil {
@@ -390,8 +399,14 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 		if !enc.BackendIsHardware() {
 			adaptive.CapForSoftwareEncoder()
 		}
+		session.applyBandwidthCap()
 	}
 
+	// Extra displays requested by the viewer. Their tracks must be added
+	// before SetRemoteDescription so they bind to the offer's extra video
+	// sections.
+	session.addDisplayStreams(peerConn, offer, policy.ExtraDisplays)
+
 	// Create clipboard DataChannel — gated by policy (finding #7). The viewer is
 	// untrusted, so the agent enforces direction here: with host→viewer off we
 	// never run the watcher (no passive exfiltration of whatever the end user
@@ -416,12 +431,46 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 		slog.Info("Clipboard sync disabled by policy", "session", sessionID)
 	}
 
-	// Create filedrop DataChannel
-	filedropDC, err := peerConn.CreateDataChannel("filedrop", nil)
-	if err != nil {
-		slog.Warn("Failed to create filedrop DataChannel", "session", sessionID, "error", err.Error())
-	} else if filedropDC != nil {
-		session.fileDropHandler = filedrop.NewFileDropHandler(filedropDC, "")
+	// Create filedrop DataChannel. File drop writes to the host and serves
+	// host files back to the viewer, so a view-only session never gets one.
+	if !policy.ViewOnly {
+		filedropDC, fdErr := peerConn.CreateDataChannel("filedrop", nil)
+		if fdErr != nil {
+			slog.Warn("Failed to create filedrop DataChannel", "session", sessionID, "error", fdErr.Error())
+		} else if filedropDC != nil {
+			session.fileDropHandler = filedrop.NewFileDropHandler(filedropDC, "")
+		}
+	}
+
+	// Create chat DataChannel. Chat only talks to the seated user, so
+	// view-only sessions get one too.
+	if session.chatHandler != nil {
+		chatDC, chatErr := peerConn.CreateDataChannel("chat", nil)
+		if chatErr != nil {
+			slog.Warn("Failed to create chat DataChannel", "session", sessionID, "error", chatErr.Error())
+		} else if chatDC != nil {
+			session.mu.Lock()
+			session.chatDC = chatDC
+			session.mu.Unlock()
+			chatDC.OnMessage(func(msg webrtc.DataChannelMessage) {
+				session.onViewerDataChannelMessage("chat", msg.Data)
+			})
+		}
+	}
+
+	// Create annotations DataChannel — pointer and drawing guidance for the
+	// seated user; it never injects input, so view-only sessions get it too.
+	annotationsDC, annErr := peerConn.CreateDataChannel("annotations", nil)
+	if annErr != nil {
+		slog.Warn("Failed to create annotations DataChannel", "session", sessionID, "error", annErr.Error())
+	} else if annotationsDC != nil {
+		session.mu.Lock()
+		session.annotationsDC = annotationsDC
+		session.mu.Unlock()
+		annotationsDC.OnOpen(session.sendAnnotationsReady)
+		annotationsDC.OnMessage(func(msg webrtc.DataChannelMessage) {
+			session.onViewerDataChannelMessage("annotations", msg.Data)
+		})
 	}
 
 	// Create cursor DataChannel — streams remote cursor position to viewer for
@@ -439,24 +488,25 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 		session.cursorDC = cursorDC
 	}
 
-	// Create PCMU audio track for system audio forwarding (loopback capture).
+	// Create the audio track for system audio forwarding (loopback capture):
+	// 48kHz stereo Opus when libopus is available, 8kHz mono PCMU otherwise.
 	// The viewer can mute/unmute; the track is always present in the SDP.
+	audioEnc := newAudioEncoder()
 	audioTrack, err := webrtc.NewTrackLocalStaticSample(
-		webrtc.RTPCodecCapability{
-			MimeType:  webrtc.MimeTypePCMU,
-			ClockRate: 8000,
-			Channels:  1,
-		},
+		audioEnc.Capability(),
 		"audio",
 		"desktop-audio",
 	)
 	if err != nil {
 		slog.Warn("Failed to create audio track", "session", sessionID, "error", err.Error())
+		audioEnc.Close()
 	} else {
 		if _, addErr := peerConn.AddTrack(audioTrack); addErr != nil {
 			slog.Warn("Failed to add audio track", "session", sessionID, "error", addErr.Error())
+			audioEnc.Close()
 		} else {
 			session.audioTrack = audioTrack
+			session.audioEncoder = audioEnc
 		}
 	}
 
@@ -470,6 +520,12 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 			dc.OnMessage(func(msg webrtc.DataChannelMessage) {
 				session.onViewerDataChannelMessage("input", msg.Data)
 			})
+			// The operator can no longer drive the machine once input stops
+			// flowing; don't leave the local user locked out while ICE
+			// recovers or the session winds down.
+			dc.OnClose(func() {
+				session.releaseLocalInputBlock("input channel closed")
+			})
 		case "control":
 			session.mu.Lock()
 			session.controlDC = dc
@@ -477,6 +533,9 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 			dc.OnMessage(func(msg webrtc.DataChannelMessage) {
 				session.onViewerDataChannelMessage("control", msg.Data)
 			})
+			dc.OnClose(func() {
+				session.releaseLocalInputBlock("control channel closed")
+			})
 			dc.OnOpen(func() {
 				// Send the current cached desktop state to this viewer so it
 				// gets an initial state even if it connected after the watcher
@@ -627,11 +686,128 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 		return "", fmt.Errorf("failed to create answer: %w", err)
 	}
 
+	// Opus decoders default to mono unless the answer asks for stereo.
+	if session.audioEncoder != nil && session.audioEncoder.Name() == "opus" {
+		pcAnswer.SDP = withOpusStereo(pcAnswer.SDP)
+	}
+
 	// Set local description
 	if err := peerConn.SetLocalDescription(pcAnswer); err != nil {
 		return "", fmt.Errorf("failed to set local description: %w", err)
 	}
 
+	if err := awaitICECandidates(peerConn, sessionID, session.done); err != nil {
+		return "", err
+	}
+
+	// Streaming starts on PeerConnectionStateConnected to avoid sending the first
+	// keyframe while the receiver is still negotiating.
+
+	ld := peerConn.LocalDescription()
+	if ld == nil {
+		return "", fmt.Errorf("local description not available")
+	}
+	slog.Info("StartSession: complete", "session", sessionID, "totalElapsed", time.Since(sessionStart))
+	return ld.SDP, nil
+}
+
+// newDesktopPeerConnection builds a peer connection with the desktop media
+// engine: default codecs plus the playout-delay extension, and tuned ICE
+// timeouts. Shared by StartSession and AddViewer.
+func newDesktopPeerConnection(sessionID string, iceServers []ICEServerConfig) (*webrtc.PeerConnection, error) {
+	// Create WebRTC configuration
+	parsedICE := parseICEServers(iceServers)
+	for _, s := range parsedICE {
+		hasCreds := s.Username != ""
+		slog.Info("ICE server configured", "session", sessionID, "urls", s.URLs, "hasCreds", hasCreds)
+	}
+	config := webrtc.Configuration{
+		ICEServers: parsedICE,
+	}
+
+	// Register playout-delay RTP header extension for low-latency screen sharing.
+	// This signals to Chrome that frames should be rendered immediately rather than
+	// buffered in a jitter buffer designed for video calls.
+	mediaEngine := &webrtc.MediaEngine{}
+	if err := mediaEngine.RegisterDefaultCodecs(); err != nil {
+		return nil, fmt.Errorf("failed to register default codecs: %w", err)
+	}
+	const playoutDelayURI = "http://www.webrtc.org/experiments/rtp-hdrext/playout-delay"
+	if regErr := mediaEngine.RegisterHeaderExtension(
+		webrtc.RTPHeaderExtensionCapability{URI: playoutDelayURI},
+		webrtc.RTPCodecTypeVideo,
+	); regErr != nil {
+		slog.Warn("Failed to register playout-delay extension (non-fatal)", "error", regErr.Error())
+	}
+
+	// ICE timeout tuning: keep NAT bindings alive and detect failures faster.
+	se := webrtc.SettingEngine{}
+	se.SetICETimeouts(
+		5*time.Second,  // disconnectedTimeout — detect no-media quickly
+		15*time.Second, // failedTimeout — reduced from 25s default for faster recovery
+		2*time.Second,  // keepAliveInterval — refresh STUN bindings when no media
+	)
+
+	api := webrtc.NewAPI(
+		webrtc.WithMediaEngine(mediaEngine),
+		webrtc.WithSettingEngine(se),
+	)
+
+	// Create peer connection with custom API (playout-delay + ICE tuning)
+	peerConn, err := api.NewPeerConnection(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create peer connection: %w", err)
+	}
+	return peerConn, nil
+}
+
+// drainVideoRTCP reads RTCP from a video sender so pion never blocks on
+// backpressure, forcing a keyframe on the shared encoder for PLI/FIR. Runs for
+// the primary viewer and every additional viewer; each sender rate-limits its
+// own keyframe requests.
+func (s *Session) drainVideoRTCP(sender *webrtc.RTPSender) {
+	rtcpBuf := make([]byte, 1500)
+	var lastKF time.Time
+	firstKF := true
+	var lastEnc *VideoEncoder
+	for {
+		n, _, readErr := sender.Read(rtcpBuf)
+		if readErr != nil {
+			return
+		}
+		pkts, perr := rtcp.Unmarshal(rtcpBuf[:n])
+		if perr != nil {
+			continue
+		}
+		for _, p := range pkts {
+			switch p.(type) {
+			case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+				enc := s.encoder.Load()
+				// Reset PLI rate-limit when the encoder pointer changes
+				// (e.g. after swapToSoftwareEncoder) so the new encoder
+				// gets an immediate keyframe.
+				if enc != lastEnc {
+					firstKF = true
+					lastEnc = enc
+				}
+				// Allow the first PLI immediately for fast startup,
+				// then rate-limit subsequent ones to 500ms apart.
+				if !firstKF && time.Since(lastKF) < 500*time.Millisecond {
+					continue
+				}
+				firstKF = false
+				lastKF = time.Now()
+				if enc != nil {
+					_ = enc.ForceKeyframe()
+				}
+			}
+		}
+	}
+}
+
+// awaitICECandidates waits until the local description carries usable
+// candidates, or fails on timeout / when done closes.
+func awaitICECandidates(peerConn *webrtc.PeerConnection, sessionID string, done <-chan struct{}) error {
 	// Fast ICE gathering: return as soon as we have usable candidates rather
 	// than waiting for full gathering. Host candidates appear in <10ms, STUN
 	// reflexive candidates in ~50-200ms. We wait for the first candidate,
@@ -703,25 +879,17 @@ func (m *SessionManager) StartSession(sessionID string, offer string, iceServers
 			slog.Info("ICE early-exit: returning answer with partial candidates",
 				"session", sessionID,
 				"gatheringState", peerConn.ICEGatheringState().String())
-		case <-session.done:
+		case <-done:
 			collectTimer.Stop()
-			return "", fmt.Errorf("session stopped during ICE gathering")
+			return fmt.Errorf("session stopped during ICE gathering")
 		}
 	case <-hardTimer.C:
-		return "", fmt.Errorf("ICE gathering timed out after %s (no candidates)", iceGatherTimeout)
-	case <-session.done:
-		return "", fmt.Errorf("session stopped during ICE gathering")
+		return fmt.Errorf("ICE gathering timed out after %s (no candidates)", iceGatherTimeout)
+	case <-done:
+		return fmt.Errorf("session stopped during ICE gathering")
 	}
 
-	// Streaming starts on PeerConnectionStateConnected to avoid sending the first
-	// keyframe while the receiver is still negotiating.
-
-	ld := peerConn.LocalDescription()
-	if ld == nil {
-		return "", fmt.Errorf("local description not available")
-	}
-	slog.Info("StartSession: complete", "session", sessionID, "totalElapsed", time.Since(sessionStart))
-	return ld.SDP, nil
+	return nil
 }
 
 // AddICECandidate adds an ICE candidate to the session
@@ -137,6 +137,32 @@ func TestShouldStopForLifetime(t *testing.T) {
 	}
 }
 
+func TestIdleWarningDue(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name          string
+		idleFor       time.Duration
+		timeout       time.Duration
+		wantDue       bool
+		wantRemaining time.Duration
+	}{
+		{name: "disabled", idleFor: time.Hour, timeout: 0},
+		{name: "active", idleFor: time.Minute, timeout: 30 * time.Minute},
+		{name: "inside one minute lead", idleFor: 29*time.Minute + 15*time.Second, timeout: 30 * time.Minute, wantDue: true, wantRemaining: 45 * time.Second},
+		{name: "short timeout uses quarter lead", idleFor: 100 * time.Second, timeout: 2 * time.Minute, wantDue: true, wantRemaining: 20 * time.Second},
+		{name: "short timeout before lead", idleFor: 80 * time.Second, timeout: 2 * time.Minute},
+		{name: "past timeout", idleFor: 31 * time.Minute, timeout: 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			due, remaining := idleWarningDue(now, now.Add(-tt.idleFor), tt.timeout)
+			if due != tt.wantDue || remaining != tt.wantRemaining {
+				t.Fatalf("idleWarningDue() = (%v, %v), want (%v, %v)", due, remaining, tt.wantDue, tt.wantRemaining)
+			}
+		})
+	}
+}
+
 // idlePolicyStops reports whether a 5-minute idle policy would reap a session
 // whose idle clock is at s.lastInputUnixNano. startWall is recent so only the
 // idle axis is in play.
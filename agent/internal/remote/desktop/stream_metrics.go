@@ -21,6 +21,12 @@ type StreamMetrics struct {
 	TotalBytesSent atomic.Uint64
 	CurrentQuality atomic.Int64
 	startTime      time.Time
+
+	// Network state from the viewer's stats and frames sent on the
+	// zero-copy GPU path, for the quality samples (quality_report.go).
+	LastRTTNanos atomic.Int64
+	LastLossPPM  atomic.Int64
+	FramesGPU    atomic.Uint64
 }
 
 func newStreamMetrics() *StreamMetrics {
@@ -55,6 +61,15 @@ func (m *StreamMetrics) RecordDrop() {
 	m.FramesDropped.Add(1)
 }
 
+func (m *StreamMetrics) RecordNetwork(rtt time.Duration, loss float64) {
+	m.LastRTTNanos.Store(rtt.Nanoseconds())
+	m.LastLossPPM.Store(int64(loss * 1e6))
+}
+
+func (m *StreamMetrics) RecordGPUFrame() {
+	m.FramesGPU.Add(1)
+}
+
 func (m *StreamMetrics) SetQuality(q int) {
 	m.CurrentQuality.Store(int64(q))
 }
@@ -73,6 +88,10 @@ type MetricsSnapshot struct {
 	BandwidthKBps  float64
 	CurrentQuality int
 	Uptime         time.Duration
+	TotalBytesSent uint64
+	FramesGPU      uint64
+	RTTMs          float64
+	LossPct        float64
 }
 
 func (m *StreamMetrics) Snapshot() MetricsSnapshot {
@@ -96,5 +115,9 @@ func (m *StreamMetrics) Snapshot() MetricsSnapshot {
 		BandwidthKBps:  bw,
 		CurrentQuality: int(m.CurrentQuality.Load()),
 		Uptime:         uptime,
+		TotalBytesSent: totalBytesSent,
+		FramesGPU:      m.FramesGPU.Load(),
+		RTTMs:          float64(time.Duration(m.LastRTTNanos.Load()).Microseconds()) / 1000.0,
+		LossPct:        float64(m.LastLossPPM.Load()) / 1e4,
 	}
 }
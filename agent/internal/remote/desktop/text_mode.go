@@ -0,0 +1,177 @@
+package desktop
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// Text mode.
+//
+// Terminal and IDE sessions are mostly static glyphs on flat backgrounds, and
+// the default screen-content rate control lets their QP drift high enough to
+// smear small text. Text mode caps the quantizer (textModeMaxQP) so static
+// text converges to near-lossless, trading frame rate under heavy motion for
+// sharpness. The viewer picks "on", "off" or "auto" via the set_text_mode
+// control message; in auto, textModeDetector engages it while the screen is
+// mostly static and releases it on scrolling or video.
+
+// textModeSetting is the viewer's choice for text mode.
+type textModeSetting int32
+
+const (
+	textModeAuto textModeSetting = iota
+	textModeOn
+	textModeOff
+)
+
+func parseTextModeSetting(s string) (textModeSetting, bool) {
+	switch s {
+	case "auto", "":
+		return textModeAuto, true
+	case "on":
+		return textModeOn, true
+	case "off":
+		return textModeOff, true
+	}
+	return textModeAuto, false
+}
+
+func (m textModeSetting) String() string {
+	switch m {
+	case textModeOn:
+		return "on"
+	case textModeOff:
+		return "off"
+	default:
+		return "auto"
+	}
+}
+
+// Quantizer bounds while text mode is engaged. H264 QP 30 keeps 1px glyph
+// strokes intact at typical screen-content bitrates. Only OpenH264 raises the
+// floor by default (18, which blurs ClearType fringes); other backends keep
+// their own lower bound.
+const (
+	textModeMaxQP = 30
+	textModeMinQP = 12
+)
+
+// Auto-detection thresholds: the mean changed-area share over the last
+// textModeWindow frames must fall to textModeEnterFraction to engage and rise
+// to textModeExitFraction to release. The gap keeps typing bursts from
+// toggling the encoder.
+const (
+	textModeWindow        = 60
+	textModeEnterFraction = 0.2
+	textModeExitFraction  = 0.4
+)
+
+// textModeDetector classifies recent screen activity as text-like (small,
+// sparse changes) or motion. Not safe for concurrent use; the capture loop
+// owns it.
+type textModeDetector struct {
+	samples [textModeWindow]float64
+	n       int
+	next    int
+	sum     float64
+	active  bool
+}
+
+// observe records the share of the frame that changed (0 for a skipped
+// frame, 1 for a changed frame whose damage is unknown) and reports whether
+// text mode should be engaged.
+func (d *textModeDetector) observe(changed float64) bool {
+	changed = min(max(changed, 0), 1)
+	if d.n == textModeWindow {
+		d.sum -= d.samples[d.next]
+	} else {
+		d.n++
+	}
+	d.samples[d.next] = changed
+	d.sum += changed
+	d.next = (d.next + 1) % textModeWindow
+
+	if d.n < textModeWindow {
+		return d.active
+	}
+	mean := d.sum / textModeWindow
+	switch {
+	case !d.active && mean <= textModeEnterFraction:
+		d.active = true
+	case d.active && mean >= textModeExitFraction:
+		d.active = false
+	}
+	return d.active
+}
+
+// reset forgets the history, e.g. after a display switch.
+func (d *textModeDetector) reset() {
+	*d = textModeDetector{}
+}
+
+// observeTextMode feeds one frame's changed-area share to the detector and
+// switches the encoder into or out of text mode when the effective state
+// changes. Called from the capture loop only.
+func (s *Session) observeTextMode(changed float64) {
+	auto := s.textModeDetect.observe(changed)
+	want := false
+	switch textModeSetting(s.textModeSetting.Load()) {
+	case textModeOn:
+		want = true
+	case textModeAuto:
+		want = auto
+	}
+	enc := s.encoder.Load()
+	if enc == nil {
+		return
+	}
+	// A replacement encoder (software fallback, codec switch) starts with
+	// text mode off.
+	if want == (s.textModeActive.Load() && enc == s.textModeEncoder) {
+		return
+	}
+	if err := enc.SetTextMode(want); err != nil {
+		slog.Warn("Failed to switch text mode", "session", s.id, "enabled", want, "error", err.Error())
+		return
+	}
+	s.textModeEncoder = enc
+	s.textModeActive.Store(want)
+	s.forEachDisplayStream(func(ds *displayStream) {
+		_ = ds.encoder.SetTextMode(want)
+	})
+	slog.Info("Text mode switched", "session", s.id, "enabled", want,
+		"setting", textModeSetting(s.textModeSetting.Load()).String())
+	s.sendTextModeState()
+}
+
+// handleSetTextMode applies a set_text_mode control message. The encoder
+// follows on the next captured frame.
+func (s *Session) handleSetTextMode(mode string) {
+	setting, ok := parseTextModeSetting(mode)
+	if !ok {
+		slog.Debug("Ignoring unknown text mode", "session", s.id, "mode", mode)
+		return
+	}
+	s.textModeSetting.Store(int32(setting))
+	slog.Info("Text mode setting changed", "session", s.id, "mode", setting.String())
+	s.sendTextModeState()
+}
+
+// sendTextModeState tells the viewer the current setting and whether text
+// mode is engaged.
+func (s *Session) sendTextModeState() {
+	resp, err := json.Marshal(map[string]any{
+		"type":   "text_mode",
+		"mode":   textModeSetting(s.textModeSetting.Load()).String(),
+		"active": s.textModeActive.Load(),
+	})
+	if err != nil {
+		return
+	}
+	s.mu.RLock()
+	dc := s.controlDC
+	s.mu.RUnlock()
+	if dc != nil {
+		dc.SendText(string(resp))
+	}
+}
@@ -0,0 +1,87 @@
+package desktop
+
+import "testing"
+
+func TestParseTextModeSetting(t *testing.T) {
+	tests := []struct {
+		in   string
+		want textModeSetting
+		ok   bool
+	}{
+		{"auto", textModeAuto, true},
+		{"", textModeAuto, true},
+		{"on", textModeOn, true},
+		{"off", textModeOff, true},
+		{"lossless", textModeAuto, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseTextModeSetting(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseTextModeSetting(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+		if ok && tt.in != "" && got.String() != tt.in {
+			t.Errorf("%v.String() = %q, want %q", got, got.String(), tt.in)
+		}
+	}
+}
+
+func TestTextModeDetectorNeedsFullWindow(t *testing.T) {
+	var d textModeDetector
+	for i := 0; i < textModeWindow-1; i++ {
+		if d.observe(0) {
+			t.Fatalf("engaged after %d samples, before the window filled", i+1)
+		}
+	}
+	if !d.observe(0) {
+		t.Fatal("static screen did not engage text mode once the window filled")
+	}
+}
+
+func TestTextModeDetectorTypingStaysEngaged(t *testing.T) {
+	var d textModeDetector
+	for i := 0; i < textModeWindow; i++ {
+		d.observe(0)
+	}
+	// Typing with unknown damage: one changed frame in six.
+	for i := 0; i < 5*textModeWindow; i++ {
+		changed := 0.0
+		if i%6 == 0 {
+			changed = 1
+		}
+		if !d.observe(changed) {
+			t.Fatalf("typing released text mode at sample %d", i)
+		}
+	}
+}
+
+func TestTextModeDetectorMotionReleases(t *testing.T) {
+	var d textModeDetector
+	for i := 0; i < textModeWindow; i++ {
+		d.observe(0.01)
+	}
+	released := -1
+	for i := 0; i < textModeWindow; i++ {
+		if !d.observe(1) {
+			released = i
+			break
+		}
+	}
+	// Mean crosses textModeExitFraction after ~40% of the window.
+	if released < 0 || released > textModeWindow/2 {
+		t.Fatalf("full-screen motion released text mode at sample %d", released)
+	}
+
+	// Between the thresholds the state holds.
+	var h textModeDetector
+	for i := 0; i < textModeWindow; i++ {
+		h.observe(0.3)
+	}
+	if h.observe(0.3) {
+		t.Fatal("engaged at a mean between the enter and exit thresholds")
+	}
+
+	d.reset()
+	if d.n != 0 || d.active {
+		t.Fatalf("reset left state %+v", d)
+	}
+}
@@ -0,0 +1,234 @@
+//go:build linux
+
+package desktop
+
+// =============================================================================
+// H264 parameter sets and GOP bookkeeping for the VA-API encoder
+//
+// VA-API drivers generate slice headers, and the common ones (iHD, Mesa) also
+// emit SPS/PPS on IDR frames. Older drivers expect the application to supply
+// them as packed headers, so when an IDR comes back without an SPS the encoder
+// prepends the Annex-B parameter sets built here from the same values it
+// passed in the sequence/picture parameter buffers.
+// =============================================================================
+
+const (
+	vaapiLog2MaxFrameNum  = 8 // log2_max_frame_num_minus4 = 4
+	vaapiLog2MaxPOCLsb    = 8 // log2_max_pic_order_cnt_lsb_minus4 = 4
+	vaapiH264ProfileMain  = 77
+	vaapiH264ProfileBase  = 66
+	vaapiH264PicInitQP    = 26
+	vaapiH264MaxRefFrames = 1
+)
+
+// h264SeqHeader describes the stream the VA-API encoder is configured for.
+type h264SeqHeader struct {
+	constrainedBaseline bool
+	levelIDC            uint8
+	widthMbs            int
+	heightMbs           int
+	cropRight           int // in luma samples / 2 (4:2:0 crop units)
+	cropBottom          int
+}
+
+// newH264SeqHeader derives the macroblock grid, crop and level for a frame.
+func newH264SeqHeader(width, height int, constrainedBaseline bool) h264SeqHeader {
+	hdr := h264SeqHeader{
+		constrainedBaseline: constrainedBaseline,
+		widthMbs:            (width + 15) / 16,
+		heightMbs:           (height + 15) / 16,
+	}
+	hdr.cropRight = (hdr.widthMbs*16 - width) / 2
+	hdr.cropBottom = (hdr.heightMbs*16 - height) / 2
+	switch mbs := hdr.widthMbs * hdr.heightMbs; {
+	case mbs <= 8192: // 1080p
+		hdr.levelIDC = 41
+	case mbs <= 36864: // 4096x2304
+		hdr.levelIDC = 51
+	default:
+		hdr.levelIDC = 52
+	}
+	return hdr
+}
+
+func (h h264SeqHeader) profileIDC() uint8 {
+	if h.constrainedBaseline {
+		return vaapiH264ProfileBase
+	}
+	return vaapiH264ProfileMain
+}
+
+// annexB returns start-code-prefixed SPS and PPS NAL units.
+func (h h264SeqHeader) annexB() []byte {
+	var sps h264BitWriter
+	sps.writeBits(uint32(h.profileIDC()), 8)
+	if h.constrainedBaseline {
+		sps.writeBits(0xC0, 8) // constraint_set0_flag, constraint_set1_flag
+	} else {
+		sps.writeBits(0, 8)
+	}
+	sps.writeBits(uint32(h.levelIDC), 8)
+	sps.writeUE(0)                        // seq_parameter_set_id
+	sps.writeUE(vaapiLog2MaxFrameNum - 4) // log2_max_frame_num_minus4
+	sps.writeUE(0)                        // pic_order_cnt_type
+	sps.writeUE(vaapiLog2MaxPOCLsb - 4)   // log2_max_pic_order_cnt_lsb_minus4
+	sps.writeUE(vaapiH264MaxRefFrames)    // max_num_ref_frames
+	sps.writeBits(0, 1)                   // gaps_in_frame_num_value_allowed_flag
+	sps.writeUE(uint32(h.widthMbs - 1))   // pic_width_in_mbs_minus1
+	sps.writeUE(uint32(h.heightMbs - 1))  // pic_height_in_map_units_minus1
+	sps.writeBits(1, 1)                   // frame_mbs_only_flag
+	sps.writeBits(1, 1)                   // direct_8x8_inference_flag
+	if h.cropRight > 0 || h.cropBottom > 0 {
+		sps.writeBits(1, 1)
+		sps.writeUE(0)
+		sps.writeUE(uint32(h.cropRight))
+		sps.writeUE(0)
+		sps.writeUE(uint32(h.cropBottom))
+	} else {
+		sps.writeBits(0, 1)
+	}
+	sps.writeBits(0, 1) // vui_parameters_present_flag
+	sps.trailingBits()
+
+	var pps h264BitWriter
+	pps.writeUE(0) // pic_parameter_set_id
+	pps.writeUE(0) // seq_parameter_set_id
+	if h.constrainedBaseline {
+		pps.writeBits(0, 1) // entropy_coding_mode_flag: CAVLC
+	} else {
+		pps.writeBits(1, 1) // CABAC
+	}
+	pps.writeBits(0, 1) // bottom_field_pic_order_in_frame_present_flag
+	pps.writeUE(0)      // num_slice_groups_minus1
+	pps.writeUE(0)      // num_ref_idx_l0_default_active_minus1
+	pps.writeUE(0)      // num_ref_idx_l1_default_active_minus1
+	pps.writeBits(0, 1) // weighted_pred_flag
+	pps.writeBits(0, 2) // weighted_bipred_idc
+	pps.writeSE(vaapiH264PicInitQP - 26)
+	pps.writeSE(0)      // pic_init_qs_minus26
+	pps.writeSE(0)      // chroma_qp_index_offset
+	pps.writeBits(1, 1) // deblocking_filter_control_present_flag
+	pps.writeBits(0, 1) // constrained_intra_pred_flag
+	pps.writeBits(0, 1) // redundant_pic_cnt_present_flag
+	pps.trailingBits()
+
+	out := make([]byte, 0, 64)
+	out = appendH264NAL(out, 0x67, sps.bytes()) // nal_ref_idc 3, type 7
+	out = appendH264NAL(out, 0x68, pps.bytes()) // nal_ref_idc 3, type 8
+	return out
+}
+
+// appendH264NAL appends a start code, the NAL header byte and the RBSP with
+// emulation prevention bytes inserted.
+func appendH264NAL(dst []byte, header byte, rbsp []byte) []byte {
+	dst = append(dst, 0, 0, 0, 1, header)
+	zeros := 0
+	for _, b := range rbsp {
+		if zeros >= 2 && b <= 3 {
+			dst = append(dst, 3)
+			zeros = 0
+		}
+		dst = append(dst, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return dst
+}
+
+// h264BitWriter is an MSB-first bit writer with Exp-Golomb helpers.
+type h264BitWriter struct {
+	buf   []byte
+	cur   byte
+	nbits uint
+}
+
+func (w *h264BitWriter) writeBits(v uint32, n uint) {
+	for i := n; i > 0; i-- {
+		w.cur = w.cur<<1 | byte(v>>(i-1)&1)
+		w.nbits++
+		if w.nbits == 8 {
+			w.buf = append(w.buf, w.cur)
+			w.cur, w.nbits = 0, 0
+		}
+	}
+}
+
+// writeUE writes an unsigned Exp-Golomb code.
+func (w *h264BitWriter) writeUE(v uint32) {
+	x := uint64(v) + 1
+	n := uint(0)
+	for t := x; t > 1; t >>= 1 {
+		n++
+	}
+	w.writeBits(0, n)
+	w.writeBits(uint32(x), n+1)
+}
+
+// writeSE writes a signed Exp-Golomb code.
+func (w *h264BitWriter) writeSE(v int32) {
+	if v > 0 {
+		w.writeUE(uint32(2*v - 1))
+	} else {
+		w.writeUE(uint32(-2 * v))
+	}
+}
+
+// trailingBits writes rbsp_stop_one_bit and aligns to a byte boundary.
+func (w *h264BitWriter) trailingBits() {
+	w.writeBits(1, 1)
+	for w.nbits != 0 {
+		w.writeBits(0, 1)
+	}
+}
+
+func (w *h264BitWriter) bytes() []byte { return w.buf }
+
+// h264HasSPS reports whether an Annex-B access unit contains an SPS.
+func h264HasSPS(au []byte) bool {
+	for i := 0; i+3 < len(au); i++ {
+		if au[i] == 0 && au[i+1] == 0 && au[i+2] == 1 && au[i+3]&0x1F == 7 {
+			return true
+		}
+	}
+	return false
+}
+
+// vaapiGOP tracks IDR placement and the frame_num / POC of an IP-only stream
+// with a single reference frame.
+type vaapiGOP struct {
+	idrPeriod int
+	sinceIDR  int
+	idrPicID  uint16
+	started   bool
+}
+
+// vaapiPicture is the position of one frame within the GOP.
+type vaapiPicture struct {
+	idr      bool
+	frameNum uint16
+	pocLsb   uint16
+	idrPicID uint16
+}
+
+// next returns the picture parameters for the next frame. An IDR is produced
+// on the first frame, when forced, and every idrPeriod frames.
+func (g *vaapiGOP) next(forceIDR bool) vaapiPicture {
+	if !g.started || forceIDR || (g.idrPeriod > 0 && g.sinceIDR >= g.idrPeriod) {
+		if g.started {
+			g.idrPicID++
+		}
+		g.started = true
+		g.sinceIDR = 0
+	}
+	n := g.sinceIDR
+	g.sinceIDR++
+	return vaapiPicture{
+		idr:      n == 0,
+		frameNum: uint16(n % (1 << vaapiLog2MaxFrameNum)),
+		pocLsb:   uint16((2 * n) % (1 << vaapiLog2MaxPOCLsb)),
+		idrPicID: g.idrPicID,
+	}
+}
@@ -0,0 +1,131 @@
+//go:build linux
+
+package desktop
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestH264BitWriterExpGolomb(t *testing.T) {
+	tests := []struct {
+		v    uint32
+		want string
+	}{
+		{0, "1"},
+		{1, "010"},
+		{2, "011"},
+		{3, "00100"},
+		{7, "0001000"},
+	}
+	for _, tt := range tests {
+		var w h264BitWriter
+		w.writeUE(tt.v)
+		if got := w.bitString(); got != tt.want {
+			t.Errorf("writeUE(%d) = %s, want %s", tt.v, got, tt.want)
+		}
+	}
+
+	var w h264BitWriter
+	w.writeSE(1)  // codeNum 1
+	w.writeSE(-1) // codeNum 2
+	w.writeSE(0)  // codeNum 0
+	if got, want := w.bitString(), "0100111"; got != want {
+		t.Errorf("writeSE(1,-1,0) = %s, want %s", got, want)
+	}
+}
+
+// bitString renders the written bits, including a partial last byte.
+func (w *h264BitWriter) bitString() string {
+	var b bytes.Buffer
+	for _, by := range w.buf {
+		for i := 7; i >= 0; i-- {
+			b.WriteByte('0' + by>>uint(i)&1)
+		}
+	}
+	for i := int(w.nbits) - 1; i >= 0; i-- {
+		b.WriteByte('0' + w.cur>>uint(i)&1)
+	}
+	return b.String()
+}
+
+func TestAppendH264NALEmulationPrevention(t *testing.T) {
+	got := appendH264NAL(nil, 0x67, []byte{0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x05})
+	want := []byte{0, 0, 0, 1, 0x67, 0x00, 0x00, 0x03, 0x01, 0x00, 0x00, 0x03, 0x00, 0x05}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("appendH264NAL = % x, want % x", got, want)
+	}
+}
+
+func TestH264SeqHeaderAnnexB(t *testing.T) {
+	hdr := newH264SeqHeader(1920, 1080, false)
+	if hdr.widthMbs != 120 || hdr.heightMbs != 68 {
+		t.Fatalf("mbs = %dx%d, want 120x68", hdr.widthMbs, hdr.heightMbs)
+	}
+	if hdr.cropRight != 0 || hdr.cropBottom != 4 {
+		t.Fatalf("crop = right %d bottom %d, want 0/4", hdr.cropRight, hdr.cropBottom)
+	}
+	if hdr.levelIDC != 41 {
+		t.Fatalf("level = %d, want 41", hdr.levelIDC)
+	}
+
+	au := hdr.annexB()
+	if !h264HasSPS(au) {
+		t.Fatal("annexB output has no SPS")
+	}
+	if !bytes.HasPrefix(au, []byte{0, 0, 0, 1, 0x67, vaapiH264ProfileMain, 0, 41}) {
+		t.Fatalf("SPS prefix = % x", au[:8])
+	}
+	if !bytes.Contains(au, []byte{0, 0, 0, 1, 0x68}) {
+		t.Fatalf("annexB output has no PPS: % x", au)
+	}
+
+	if got := newH264SeqHeader(3840, 2160, true).levelIDC; got != 51 {
+		t.Fatalf("4K level = %d, want 51", got)
+	}
+	base := newH264SeqHeader(1280, 720, true).annexB()
+	if !bytes.HasPrefix(base, []byte{0, 0, 0, 1, 0x67, vaapiH264ProfileBase, 0xC0}) {
+		t.Fatalf("constrained baseline SPS prefix = % x", base[:7])
+	}
+}
+
+func TestH264HasSPS(t *testing.T) {
+	if h264HasSPS([]byte{0, 0, 0, 1, 0x65, 0x88}) {
+		t.Fatal("IDR slice reported as SPS")
+	}
+	if !h264HasSPS([]byte{0, 0, 1, 0x09, 0x10, 0, 0, 1, 0x67, 0x4d}) {
+		t.Fatal("SPS after AUD not found")
+	}
+}
+
+func TestVAAPIGOP(t *testing.T) {
+	g := vaapiGOP{idrPeriod: 3}
+
+	want := []vaapiPicture{
+		{idr: true, frameNum: 0, pocLsb: 0, idrPicID: 0},
+		{frameNum: 1, pocLsb: 2, idrPicID: 0},
+		{frameNum: 2, pocLsb: 4, idrPicID: 0},
+		{idr: true, frameNum: 0, pocLsb: 0, idrPicID: 1}, // period elapsed
+		{frameNum: 1, pocLsb: 2, idrPicID: 1},
+	}
+	for i, w := range want {
+		if got := g.next(false); got != w {
+			t.Fatalf("frame %d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	if got := g.next(true); !got.idr || got.idrPicID != 2 {
+		t.Fatalf("forced IDR = %+v", got)
+	}
+}
+
+func TestVAAPIGOPFrameNumWraps(t *testing.T) {
+	g := vaapiGOP{idrPeriod: 1000}
+	var last vaapiPicture
+	for i := 0; i <= 1<<vaapiLog2MaxFrameNum; i++ {
+		last = g.next(false)
+	}
+	if last.idr || last.frameNum != 0 || last.pocLsb != 0 {
+		t.Fatalf("after %d frames = %+v, want frame_num and POC wrapped to 0", 1<<vaapiLog2MaxFrameNum, last)
+	}
+}
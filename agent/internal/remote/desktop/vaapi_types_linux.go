@@ -0,0 +1,292 @@
+//go:build linux
+
+package desktop
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// =============================================================================
+// VA-API (libva 2.x) — Type Definitions for H264 encoding
+//
+// Struct layouts match va/va.h and va/va_enc_h264.h. Bitfield unions are
+// carried as their uint32 `value` member and filled through the bit
+// constants below. vaapiStructSizes catches layout drift.
+// =============================================================================
+
+const (
+	vaStatusSuccess = 0
+
+	vaInvalidID      uint32 = 0xffffffff // VA_INVALID_ID / VA_INVALID_SURFACE
+	vaRTFormatYUV420 uint32 = 0x00000001
+	vaProgressive    int32  = 0x1
+	vaFourCCNV12     uint32 = 0x3231564e // 'N','V','1','2'
+
+	// VAProfile
+	vaProfileH264Main                int32 = 6
+	vaProfileH264ConstrainedBaseline int32 = 13
+
+	// VAEntrypoint
+	vaEntrypointEncSlice   int32 = 6
+	vaEntrypointEncSliceLP int32 = 8
+
+	// VAConfigAttribType
+	vaConfigAttribRTFormat    int32 = 0
+	vaConfigAttribRateControl int32 = 5
+
+	vaAttribNotSupported uint32 = 0x80000000
+	vaRCCBR              uint32 = 0x00000002
+
+	// VABufferType
+	vaEncCodedBufferType              int32  = 21
+	vaEncSequenceParameterBufferType  int32  = 22
+	vaEncPictureParameterBufferType   int32  = 23
+	vaEncSliceParameterBufferType     int32  = 24
+	vaEncMiscParameterBufferType      int32  = 27
+	vaEncMiscParameterTypeFrameRate   uint32 = 0
+	vaEncMiscParameterTypeRateControl uint32 = 1
+
+	// VAPictureH264 flags
+	vaPictureH264Invalid            uint32 = 0x00000001
+	vaPictureH264ShortTermReference uint32 = 0x00000008
+
+	// H264 slice_type as used by VAEncSliceParameterBufferH264
+	vaSliceTypeP uint8 = 0
+	vaSliceTypeI uint8 = 2
+)
+
+// seq_fields / pic_fields bits (VAEncSequenceParameterBufferH264 and
+// VAEncPictureParameterBufferH264)
+const (
+	vaSeqChromaFormat420      uint32 = 1 << 0 // chroma_format_idc (2 bits) = 1
+	vaSeqFrameMbsOnly         uint32 = 1 << 2 // frame_mbs_only_flag
+	vaSeqDirect8x8Inference   uint32 = 1 << 5 // direct_8x8_inference_flag
+	vaSeqLog2MaxFrameNumShift        = 6      // log2_max_frame_num_minus4 (4 bits)
+	vaSeqLog2MaxPOCLsbShift          = 12     // log2_max_pic_order_cnt_lsb_minus4 (4 bits)
+
+	vaPicIDR               uint32 = 1 << 0 // idr_pic_flag
+	vaPicReference         uint32 = 1 << 1 // reference_pic_flag (2 bits) = 1
+	vaPicEntropyCABAC      uint32 = 1 << 3 // entropy_coding_mode_flag
+	vaPicDeblockingControl uint32 = 1 << 9 // deblocking_filter_control_present_flag
+)
+
+// VAConfigAttrib — 8 bytes
+type vaConfigAttrib struct {
+	Type  int32
+	Value uint32
+}
+
+// VAImageFormat — 48 bytes
+type vaImageFormat struct {
+	FourCC       uint32
+	ByteOrder    uint32
+	BitsPerPixel uint32
+	Depth        uint32
+	RedMask      uint32
+	GreenMask    uint32
+	BlueMask     uint32
+	AlphaMask    uint32
+	_reserved    [4]uint32
+}
+
+// VAImage — 120 bytes
+type vaImage struct {
+	ImageID           uint32
+	Format            vaImageFormat
+	Buf               uint32
+	Width             uint16
+	Height            uint16
+	DataSize          uint32
+	NumPlanes         uint32
+	Pitches           [3]uint32
+	Offsets           [3]uint32
+	NumPaletteEntries int32
+	EntryBytes        int32
+	ComponentOrder    [4]int8
+	_reserved         [4]uint32
+}
+
+// VACodedBufferSegment — the list vaMapBuffer returns for a coded buffer.
+type vaCodedBufferSegment struct {
+	Size      uint32
+	BitOffset uint32
+	Status    uint32
+	_reserved uint32
+	Buf       unsafe.Pointer
+	Next      unsafe.Pointer
+	_vaRes    [4]uint32
+}
+
+// VAPictureH264 — 36 bytes
+type vaPictureH264 struct {
+	PictureID           uint32
+	FrameIdx            uint32
+	Flags               uint32
+	TopFieldOrderCnt    int32
+	BottomFieldOrderCnt int32
+	_reserved           [4]uint32
+}
+
+// invalidVAPicture is an unused reference slot.
+var invalidVAPicture = vaPictureH264{PictureID: vaInvalidID, Flags: vaPictureH264Invalid}
+
+// VAEncSequenceParameterBufferH264 — 1132 bytes
+type vaEncSequenceParameterBufferH264 struct {
+	SeqParameterSetID              uint8
+	LevelIDC                       uint8
+	_pad0                          [2]byte
+	IntraPeriod                    uint32
+	IntraIDRPeriod                 uint32
+	IPPeriod                       uint32
+	BitsPerSecond                  uint32
+	MaxNumRefFrames                uint32
+	PictureWidthInMbs              uint16
+	PictureHeightInMbs             uint16
+	SeqFields                      uint32
+	BitDepthLumaMinus8             uint8
+	BitDepthChromaMinus8           uint8
+	NumRefFramesInPicOrderCntCycle uint8
+	_pad1                          uint8
+	OffsetForNonRefPic             int32
+	OffsetForTopToBottomField      int32
+	OffsetForRefFrame              [256]int32
+	FrameCroppingFlag              uint8
+	_pad2                          [3]byte
+	FrameCropLeftOffset            uint32
+	FrameCropRightOffset           uint32
+	FrameCropTopOffset             uint32
+	FrameCropBottomOffset          uint32
+	VUIParametersPresentFlag       uint8
+	_pad3                          [3]byte
+	VUIFields                      uint32
+	AspectRatioIDC                 uint8
+	_pad4                          [3]byte
+	SarWidth                       uint32
+	SarHeight                      uint32
+	NumUnitsInTick                 uint32
+	TimeScale                      uint32
+	_reserved                      [4]uint32
+}
+
+// VAEncPictureParameterBufferH264 — 648 bytes
+type vaEncPictureParameterBufferH264 struct {
+	CurrPic                   vaPictureH264
+	ReferenceFrames           [16]vaPictureH264
+	CodedBuf                  uint32
+	PicParameterSetID         uint8
+	SeqParameterSetID         uint8
+	LastPicture               uint8
+	_pad0                     uint8
+	FrameNum                  uint16
+	PicInitQP                 uint8
+	NumRefIdxL0ActiveMinus1   uint8
+	NumRefIdxL1ActiveMinus1   uint8
+	ChromaQPIndexOffset       int8
+	SecondChromaQPIndexOffset int8
+	_pad1                     uint8
+	PicFields                 uint32
+	_reserved                 [4]uint32
+}
+
+// VAEncSliceParameterBufferH264 — 3140 bytes
+type vaEncSliceParameterBufferH264 struct {
+	MacroblockAddress           uint32
+	NumMacroblocks              uint32
+	MacroblockInfo              uint32
+	SliceType                   uint8
+	PicParameterSetID           uint8
+	IDRPicID                    uint16
+	PicOrderCntLsb              uint16
+	_pad0                       [2]byte
+	DeltaPicOrderCntBottom      int32
+	DeltaPicOrderCnt            [2]int32
+	DirectSpatialMvPredFlag     uint8
+	NumRefIdxActiveOverrideFlag uint8
+	NumRefIdxL0ActiveMinus1     uint8
+	NumRefIdxL1ActiveMinus1     uint8
+	RefPicList0                 [32]vaPictureH264
+	RefPicList1                 [32]vaPictureH264
+	LumaLog2WeightDenom         uint8
+	ChromaLog2WeightDenom       uint8
+	LumaWeightL0Flag            uint8
+	_pad1                       uint8
+	LumaWeightL0                [32]int16
+	LumaOffsetL0                [32]int16
+	ChromaWeightL0Flag          uint8
+	_pad2                       uint8
+	ChromaWeightL0              [32][2]int16
+	ChromaOffsetL0              [32][2]int16
+	LumaWeightL1Flag            uint8
+	_pad3                       uint8
+	LumaWeightL1                [32]int16
+	LumaOffsetL1                [32]int16
+	ChromaWeightL1Flag          uint8
+	_pad4                       uint8
+	ChromaWeightL1              [32][2]int16
+	ChromaOffsetL1              [32][2]int16
+	CabacInitIDC                uint8
+	SliceQPDelta                int8
+	DisableDeblockingFilterIDC  uint8
+	SliceAlphaC0OffsetDiv2      int8
+	SliceBetaOffsetDiv2         int8
+	_pad5                       uint8
+	_reserved                   [4]uint32
+}
+
+// vaEncMiscRateControl is VAEncMiscParameterBuffer{type} followed by
+// VAEncMiscParameterRateControl — 4 + 60 bytes.
+type vaEncMiscRateControl struct {
+	Type             uint32
+	BitsPerSecond    uint32
+	TargetPercentage uint32
+	WindowSize       uint32
+	InitialQP        uint32
+	MinQP            uint32
+	BasicUnitSize    uint32
+	RCFlags          uint32
+	ICQQualityFactor uint32
+	MaxQP            uint32
+	QualityFactor    uint32
+	TargetFrameSize  uint32
+	_reserved        [4]uint32
+}
+
+// vaEncMiscFrameRate is VAEncMiscParameterBuffer{type} followed by
+// VAEncMiscParameterFrameRate — 4 + 24 bytes.
+type vaEncMiscFrameRate struct {
+	Type      uint32
+	FrameRate uint32
+	Flags     uint32
+	_reserved [4]uint32
+}
+
+// vaapiStructSizes pairs each struct handed to libva with its size in
+// libva 2.x on 64-bit Linux.
+var vaapiStructSizes = []struct {
+	name string
+	got  uintptr
+	want uintptr
+}{
+	{"vaConfigAttrib", unsafe.Sizeof(vaConfigAttrib{}), 8},
+	{"vaImageFormat", unsafe.Sizeof(vaImageFormat{}), 48},
+	{"vaImage", unsafe.Sizeof(vaImage{}), 120},
+	{"vaPictureH264", unsafe.Sizeof(vaPictureH264{}), 36},
+	{"vaEncSequenceParameterBufferH264", unsafe.Sizeof(vaEncSequenceParameterBufferH264{}), 1132},
+	{"vaEncPictureParameterBufferH264", unsafe.Sizeof(vaEncPictureParameterBufferH264{}), 648},
+	{"vaEncSliceParameterBufferH264", unsafe.Sizeof(vaEncSliceParameterBufferH264{}), 3140},
+	{"vaEncMiscRateControl", unsafe.Sizeof(vaEncMiscRateControl{}), 64},
+	{"vaEncMiscFrameRate", unsafe.Sizeof(vaEncMiscFrameRate{}), 28},
+}
+
+// checkVAAPILayout reports the first struct whose size differs from libva's.
+// A mismatch (e.g. a 32-bit build) disables VA-API rather than handing libva
+// buffers of the wrong shape.
+func checkVAAPILayout() error {
+	for _, s := range vaapiStructSizes {
+		if s.got != s.want {
+			return fmt.Errorf("vaapi: %s size %d, expected %d — struct layout mismatch with libva", s.name, s.got, s.want)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build linux
+
+package desktop
+
+import "testing"
+
+func TestVAAPIStructSizesMatchLibva(t *testing.T) {
+	for _, s := range vaapiStructSizes {
+		if s.got != s.want {
+			t.Errorf("%s size %d, expected %d — struct layout mismatch with libva", s.name, s.got, s.want)
+		}
+	}
+	if err := checkVAAPILayout(); err != nil {
+		t.Fatalf("checkVAAPILayout: %v", err)
+	}
+}
@@ -5,6 +5,8 @@ package x11
 import (
 	"fmt"
 	"runtime"
+	"slices"
+	"strings"
 
 	"github.com/jezek/xgb/xproto"
 	"github.com/jezek/xgb/xtest"
@@ -20,6 +22,11 @@ type Injector struct {
 	conn   *Conn
 	keymap *KeyMap
 	root   xproto.Window
+
+	// borrowed are spare keycodes TypeRune has bound to keysyms the layout
+	// lacks, in rebind order; they are cleared again on Close.
+	borrowed []xproto.Keycode
+	nextLoan int
 }
 
 // NewInjector resolves the display, opens a dedicated connection, and inits XTEST.
@@ -118,6 +125,60 @@ func (in *Injector) KeyBySym(keysym uint32) error {
 	return in.key(kc, false)
 }
 
+// TypeRune types one character of committed text. Characters the current
+// layout produces with at most Shift are typed with their own key; anything
+// else (AltGr symbols, other scripts, IME output) is bound to a spare keycode
+// first, the way xdotool types arbitrary Unicode.
+func (in *Injector) TypeRune(r rune) error {
+	ks := KeysymForRune(r)
+	if _, _, ok := in.keymap.Resolve(ks); ok {
+		return in.KeyBySym(ks)
+	}
+	kc, err := in.borrowKeycode(ks)
+	if err != nil {
+		return err
+	}
+	if err := in.key(kc, true); err != nil {
+		return err
+	}
+	return in.key(kc, false)
+}
+
+// borrowKeycode binds keysym to a spare keycode, cycling through the spares
+// so a rebind never races an application still resolving the previous
+// character's key event.
+func (in *Injector) borrowKeycode(keysym uint32) (xproto.Keycode, error) {
+	spare := in.keymap.spare
+	if len(spare) == 0 {
+		return 0, fmt.Errorf("keysym 0x%X not on current layout and no spare keycode to bind it", keysym)
+	}
+	// Spares at the top of the range are least likely to be claimed later by
+	// hotplugged devices.
+	kc := spare[len(spare)-1-in.nextLoan%len(spare)]
+	in.nextLoan++
+	if err := in.setKeysym(kc, keysym); err != nil {
+		return 0, err
+	}
+	if !slices.Contains(in.borrowed, kc) {
+		in.borrowed = append(in.borrowed, kc)
+	}
+	return kc, nil
+}
+
+// setKeysym replaces every column of keycode's mapping with keysym (0 to
+// clear it) so the result does not depend on Shift or group state.
+func (in *Injector) setKeysym(kc xproto.Keycode, keysym uint32) error {
+	syms := make([]xproto.Keysym, in.keymap.perKeycode)
+	for i := range syms {
+		syms[i] = xproto.Keysym(keysym)
+	}
+	if err := xproto.ChangeKeyboardMappingChecked(in.conn.x, 1, kc, byte(len(syms)), syms).Check(); err != nil {
+		return fmt.Errorf("change keyboard mapping: %w", err)
+	}
+	in.keymap.bind(kc, keysym)
+	return nil
+}
+
 // KeyDownByName presses (without releasing) a named key. Used for modifiers
 // and for viewer-driven key-down/key-up event pairs.
 func (in *Injector) KeyDownByName(name string) error {
@@ -149,12 +210,42 @@ func (in *Injector) resolveKeycode(name string) (xproto.Keycode, error) {
 	return kc, nil
 }
 
+// XKBLayout returns the comma-separated XKB layouts and variants from the
+// root window's _XKB_RULES_NAMES property (what setxkbmap -query prints).
+func (in *Injector) XKBLayout() (layouts, variants string, err error) {
+	atom, err := xproto.InternAtom(in.conn.x, true, uint16(len("_XKB_RULES_NAMES")), "_XKB_RULES_NAMES").Reply()
+	if err != nil {
+		return "", "", fmt.Errorf("intern _XKB_RULES_NAMES: %w", err)
+	}
+	if atom.Atom == xproto.AtomNone {
+		return "", "", fmt.Errorf("_XKB_RULES_NAMES not set")
+	}
+	prop, err := xproto.GetProperty(in.conn.x, false, in.root, atom.Atom, xproto.AtomString, 0, 1024).Reply()
+	if err != nil {
+		return "", "", fmt.Errorf("get _XKB_RULES_NAMES: %w", err)
+	}
+	// rules NUL model NUL layout NUL variant NUL options
+	fields := strings.Split(string(prop.Value), "\x00")
+	if len(fields) < 3 {
+		return "", "", fmt.Errorf("malformed _XKB_RULES_NAMES")
+	}
+	layouts = fields[2]
+	if len(fields) > 3 {
+		variants = fields[3]
+	}
+	return layouts, variants, nil
+}
+
 // Close releases the underlying X connection. Safe to call more than once.
 func (in *Injector) Close() {
 	if in == nil {
 		return
 	}
 	if in.conn != nil {
+		for _, kc := range in.borrowed {
+			_ = in.setKeysym(kc, 0)
+		}
+		in.borrowed = nil
 		_ = in.conn.Close()
 		in.conn = nil
 	}
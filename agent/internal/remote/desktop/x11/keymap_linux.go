@@ -16,6 +16,9 @@ type KeyMap struct {
 	keysyms    []xproto.Keysym
 	shiftKeyc  xproto.Keycode
 	reverse    map[uint32]resolved
+	// spare lists keycodes with no keysyms bound; the injector borrows them
+	// to type characters the layout cannot produce.
+	spare []xproto.Keycode
 }
 
 type resolved struct {
@@ -41,12 +44,26 @@ func LoadKeyMap(x *xgb.Conn) (*KeyMap, error) {
 		reverse:    make(map[uint32]resolved),
 	}
 	for kc := 0; kc < count; kc++ {
+		keycode := xproto.Keycode(int(setup.MinKeycode) + kc)
+		empty := true
 		for col := 0; col < km.perKeycode; col++ {
+			if km.keysyms[kc*km.perKeycode+col] != 0 {
+				empty = false
+				break
+			}
+		}
+		if empty {
+			km.spare = append(km.spare, keycode)
+			continue
+		}
+		// Only the first group's unshifted/shifted columns are reachable with
+		// Shift alone; AltGr and second-group symbols would come out as the
+		// base character, so they are left to the spare-keycode path.
+		for col := 0; col < km.perKeycode && col < 2; col++ {
 			ks := uint32(km.keysyms[kc*km.perKeycode+col])
 			if ks == 0 {
 				continue
 			}
-			keycode := xproto.Keycode(int(setup.MinKeycode) + kc)
 			if ks == 0xFFE1 { // Shift_L
 				km.shiftKeyc = keycode
 			}
@@ -66,5 +83,18 @@ func (k *KeyMap) Resolve(keysym uint32) (xproto.Keycode, bool, bool) {
 	return r.keycode, r.shift, ok
 }
 
+// bind records keysym as the sole (unshifted) binding of keycode, dropping
+// whatever the keycode was previously resolved for.
+func (k *KeyMap) bind(keycode xproto.Keycode, keysym uint32) {
+	for ks, r := range k.reverse {
+		if r.keycode == keycode {
+			delete(k.reverse, ks)
+		}
+	}
+	if keysym != 0 {
+		k.reverse[keysym] = resolved{keycode: keycode}
+	}
+}
+
 // ShiftKeycode returns the Shift_L keycode (0 if not found).
 func (k *KeyMap) ShiftKeycode() xproto.Keycode { return k.shiftKeyc }
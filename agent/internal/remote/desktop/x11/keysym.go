@@ -44,8 +44,17 @@ func KeysymForName(name string) (uint32, bool) {
 }
 
 // KeysymForRune maps a printable rune to a keysym (Latin-1 identity, else the
-// 0x01000000|codepoint Unicode convention).
+// 0x01000000|codepoint Unicode convention). Line breaks, tab and backspace in
+// typed text map to their function keys.
 func KeysymForRune(r rune) uint32 {
+	switch r {
+	case '\n', '\r':
+		return 0xFF0D
+	case '\t':
+		return 0xFF09
+	case '\b':
+		return 0xFF08
+	}
 	if (r >= 0x20 && r <= 0x7E) || (r >= 0xA0 && r <= 0xFF) {
 		return uint32(r)
 	}
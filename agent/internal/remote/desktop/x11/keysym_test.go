@@ -39,3 +39,12 @@ func TestKeysymForRune(t *testing.T) {
 		t.Errorf("unicode keysym convention failed: got 0x%X", KeysymForRune('€'))
 	}
 }
+
+func TestKeysymForRuneControlCharacters(t *testing.T) {
+	cases := map[rune]uint32{'\n': 0xFF0D, '\r': 0xFF0D, '\t': 0xFF09, '\b': 0xFF08}
+	for r, want := range cases {
+		if got := KeysymForRune(r); got != want {
+			t.Errorf("KeysymForRune(%q) = 0x%X, want 0x%X", r, got, want)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package filedrop
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -11,6 +13,8 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/pion/webrtc/v4"
 )
@@ -20,8 +24,24 @@ const (
 	maxChunkPayloadSize    = 1 * 1024 * 1024
 	maxTransferSize        = 500 * 1024 * 1024 // 500MB max file transfer
 	maxConcurrentTransfers = 8
+
+	// maxBufferedAmount bounds how much outgoing data may sit in the data
+	// channel's send buffer; a download pauses above it so a large file
+	// isn't queued into memory faster than the viewer can take it.
+	maxBufferedAmount = 4 * 1024 * 1024
 )
 
+// downloadPathCheck vets a download before any of the file is read. The
+// file manager's deny lists and path policy live in the tools package, which
+// imports this one, so tools registers its check at init.
+var downloadPathCheck atomic.Pointer[func(string) error]
+
+// SetDownloadPathCheck installs the read check every download must pass.
+// Until one is installed, downloads are refused.
+func SetDownloadPathCheck(check func(string) error) {
+	downloadPathCheck.Store(&check)
+}
+
 type ReceivedFile struct {
 	TransferID string
 	Name       string
@@ -33,9 +53,13 @@ type FileDropHandler struct {
 	dc         *webrtc.DataChannel
 	chunkSize  int
 	receiveDir string
+	// send writes one encoded message to the viewer; nil when no data
+	// channel is configured.
+	send func(payload string) error
 
 	mu        sync.Mutex
 	transfers map[string]*incomingTransfer
+	downloads int
 	completed chan ReceivedFile
 	closed    bool
 }
@@ -57,6 +81,7 @@ func NewFileDropHandler(dc *webrtc.DataChannel, receiveDir string) *FileDropHand
 		completed:  make(chan ReceivedFile, 8),
 	}
 	if dc != nil {
+		handler.send = dc.SendText
 		dc.OnMessage(func(msg webrtc.DataChannelMessage) {
 			if err := handler.HandleDrop(msg); err != nil {
 				log.Printf("[filedrop] error handling drop message: %v", err)
@@ -82,13 +107,26 @@ func (h *FileDropHandler) HandleDrop(msg webrtc.DataChannelMessage) error {
 		return h.handleChunk(message)
 	case MessageTypeDropComplete:
 		return h.handleComplete(message)
+	case MessageTypeDownloadRequest:
+		return h.handleDownloadRequest(message)
 	default:
 		return fmt.Errorf("filedrop: unknown message type %q", message.Type)
 	}
 }
 
 func (h *FileDropHandler) SendFile(path string) error {
-	if h.dc == nil {
+	transferID, err := randomID()
+	if err != nil {
+		return err
+	}
+	return h.sendFile(path, transferID)
+}
+
+// sendFile streams a file to the viewer under transferID. The SHA-256 of the
+// bytes actually sent goes out on DROP_COMPLETE so the viewer can verify the
+// reassembled file.
+func (h *FileDropHandler) sendFile(path, transferID string) error {
+	if h.send == nil {
 		return errors.New("filedrop: data channel not configured")
 	}
 	file, err := os.Open(path)
@@ -101,13 +139,11 @@ func (h *FileDropHandler) SendFile(path string) error {
 	if err != nil {
 		return err
 	}
-	if info.IsDir() {
-		return errors.New("filedrop: directories not supported")
+	if !info.Mode().IsRegular() {
+		return errors.New("filedrop: only regular files are supported")
 	}
-
-	transferID, err := randomID()
-	if err != nil {
-		return err
+	if info.Size() > maxTransferSize {
+		return fmt.Errorf("filedrop: file size %d exceeds maximum %d", info.Size(), maxTransferSize)
 	}
 
 	start := Message{
@@ -129,6 +165,7 @@ func (h *FileDropHandler) SendFile(path string) error {
 	}
 
 	buffer := make([]byte, chunkSize)
+	hash := sha256.New()
 	var offset int64
 	for {
 		read, err := file.Read(buffer)
@@ -138,6 +175,13 @@ func (h *FileDropHandler) SendFile(path string) error {
 		if read == 0 {
 			break
 		}
+		if offset+int64(read) > info.Size() {
+			return fmt.Errorf("filedrop: %s grew during transfer", filepath.Base(path))
+		}
+		if err := h.waitForSendBuffer(); err != nil {
+			return err
+		}
+		hash.Write(buffer[:read])
 		chunk := Message{
 			Type:       MessageTypeDropChunk,
 			TransferID: transferID,
@@ -153,13 +197,148 @@ func (h *FileDropHandler) SendFile(path string) error {
 		}
 	}
 
+	if offset != info.Size() {
+		return fmt.Errorf("filedrop: %s shrank during transfer", filepath.Base(path))
+	}
+
 	complete := Message{
 		Type:       MessageTypeDropComplete,
 		TransferID: transferID,
+		Checksum:   hex.EncodeToString(hash.Sum(nil)),
 	}
 	return h.sendMessage(complete)
 }
 
+// handleDownloadRequest sends the requested host file back to the viewer on
+// its own goroutine, so a large download doesn't stall the data channel's
+// message handler. Failures after validation are reported to the viewer as
+// DOWNLOAD_ERROR.
+func (h *FileDropHandler) handleDownloadRequest(message Message) error {
+	if message.TransferID == "" {
+		return errors.New("filedrop: missing transfer id")
+	}
+	path, err := validateDownloadPath(message.Path)
+	if err == nil {
+		path, err = checkDownloadPath(path)
+	}
+	if err != nil {
+		h.sendDownloadError(message.TransferID, err)
+		return err
+	}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return errors.New("filedrop: handler closed")
+	}
+	if h.downloads >= maxConcurrentTransfers {
+		h.mu.Unlock()
+		err := fmt.Errorf("filedrop: too many active downloads (max %d)", maxConcurrentTransfers)
+		h.sendDownloadError(message.TransferID, err)
+		return err
+	}
+	h.downloads++
+	h.mu.Unlock()
+
+	// Audit the start of an outbound download (finding #8): a host file is
+	// leaving the machine. path + transfer id.
+	// NOTE: diagnostic-log (slog → agent_logs), not central audit_logs.
+	// TODO(#1012): route clipboard/filedrop transfers to central audit_logs.
+	slog.Info("filedrop download start",
+		"path", path,
+		"transferId", message.TransferID)
+
+	go func() {
+		defer func() {
+			h.mu.Lock()
+			h.downloads--
+			h.mu.Unlock()
+		}()
+		if err := h.sendFile(path, message.TransferID); err != nil {
+			slog.Warn("filedrop download failed",
+				"path", path,
+				"transferId", message.TransferID,
+				"error", err.Error())
+			h.sendDownloadError(message.TransferID, err)
+			return
+		}
+		slog.Info("filedrop download complete",
+			"path", path,
+			"transferId", message.TransferID)
+	}()
+	return nil
+}
+
+// validateDownloadPath requires an absolute path with no relative segments;
+// the viewer names files exactly, it never resolves them against the agent's
+// working directory.
+func validateDownloadPath(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("filedrop: missing path")
+	}
+	if strings.ContainsRune(path, 0) {
+		return "", errors.New("filedrop: invalid path")
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("filedrop: path %q is not absolute", path)
+	}
+	if filepath.Clean(path) != path {
+		return "", fmt.Errorf("filedrop: path %q is not clean", path)
+	}
+	return path, nil
+}
+
+// checkDownloadPath resolves symlinks and runs the registered read check on
+// both the requested and the resolved path. The resolved path is what gets
+// sent, so a link swapped afterwards cannot redirect the read.
+func checkDownloadPath(path string) (string, error) {
+	check := downloadPathCheck.Load()
+	if check == nil {
+		return "", errors.New("filedrop: downloads are not available")
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("filedrop: %w", err)
+	}
+	if err := (*check)(path); err != nil {
+		return "", fmt.Errorf("filedrop: %w", err)
+	}
+	if resolved != path {
+		if err := (*check)(resolved); err != nil {
+			return "", fmt.Errorf("filedrop: %w", err)
+		}
+	}
+	return resolved, nil
+}
+
+func (h *FileDropHandler) sendDownloadError(transferID string, cause error) {
+	if h.send == nil {
+		return
+	}
+	if err := h.sendMessage(Message{
+		Type:       MessageTypeDownloadError,
+		TransferID: transferID,
+		Error:      cause.Error(),
+	}); err != nil {
+		log.Printf("[filedrop] failed to send download error for %s: %v", transferID, err)
+	}
+}
+
+// waitForSendBuffer blocks while the data channel's send buffer is above
+// maxBufferedAmount, giving up if the channel closes.
+func (h *FileDropHandler) waitForSendBuffer() error {
+	if h.dc == nil {
+		return nil
+	}
+	for h.dc.BufferedAmount() > maxBufferedAmount {
+		if h.dc.ReadyState() != webrtc.DataChannelStateOpen {
+			return errors.New("filedrop: data channel closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
 func (h *FileDropHandler) ReceiveFile() (ReceivedFile, error) {
 	file, ok := <-h.completed
 	if !ok {
@@ -348,6 +527,12 @@ func (h *FileDropHandler) handleComplete(message Message) error {
 		_ = os.Remove(transfer.path)
 		return fmt.Errorf("filedrop: incomplete transfer %s: received %d of %d bytes", message.TransferID, transfer.received, transfer.size)
 	}
+	if message.Checksum != "" {
+		if err := verifyChecksum(transfer.path, message.Checksum); err != nil {
+			_ = os.Remove(transfer.path)
+			return fmt.Errorf("filedrop: transfer %s: %w", message.TransferID, err)
+		}
+	}
 
 	result := ReceivedFile{
 		TransferID: message.TransferID,
@@ -373,12 +558,30 @@ func (h *FileDropHandler) handleComplete(message Message) error {
 	return nil
 }
 
+// verifyChecksum compares a written file's SHA-256 against the hex digest
+// the sender reported.
+func verifyChecksum(path, want string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(got, want) {
+		return fmt.Errorf("checksum mismatch: got %s, want %s", got, want)
+	}
+	return nil
+}
+
 func (h *FileDropHandler) sendMessage(message Message) error {
 	payload, err := EncodeMessage(message)
 	if err != nil {
 		return err
 	}
-	return h.dc.SendText(string(payload))
+	return h.send(string(payload))
 }
 
 func randomID() (string, error) {
@@ -1,10 +1,13 @@
 package filedrop
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/pion/webrtc/v4"
 )
 
 func TestHandleStartUsesPrivateReceiveDirByDefault(t *testing.T) {
@@ -144,3 +147,153 @@ func TestHandleChunkRejectsOversizedPayload(t *testing.T) {
 		t.Fatal("expected oversized chunk payload to be rejected")
 	}
 }
+
+// allowDownloads installs a permissive download check for the test.
+func allowDownloads(t *testing.T) {
+	t.Helper()
+	prev := downloadPathCheck.Load()
+	SetDownloadPathCheck(func(string) error { return nil })
+	t.Cleanup(func() { downloadPathCheck.Store(prev) })
+}
+
+func TestDownloadRequestRoundTripsWithChecksum(t *testing.T) {
+	allowDownloads(t)
+	srcPath := filepath.Join(t.TempDir(), "agent.log")
+	content := []byte(strings.Repeat("log line\n", 1000))
+	if err := os.WriteFile(srcPath, content, 0o600); err != nil {
+		t.Fatalf("write source file: %v", err)
+	}
+
+	viewer := NewFileDropHandler(nil, t.TempDir())
+	defer viewer.Close()
+	agent := NewFileDropHandler(nil, "")
+	agent.chunkSize = 1024
+	agent.send = func(payload string) error {
+		return viewer.HandleDrop(webrtc.DataChannelMessage{IsString: true, Data: []byte(payload)})
+	}
+
+	if err := agent.HandleDrop(webrtc.DataChannelMessage{
+		IsString: true,
+		Data:     mustEncode(t, Message{Type: MessageTypeDownloadRequest, TransferID: "dl-1", Path: srcPath}),
+	}); err != nil {
+		t.Fatalf("download request returned error: %v", err)
+	}
+
+	received, err := viewer.ReceiveFile()
+	if err != nil {
+		t.Fatalf("ReceiveFile: %v", err)
+	}
+	if received.TransferID != "dl-1" || received.Name != "agent.log" {
+		t.Fatalf("unexpected received file %+v", received)
+	}
+	got, err := os.ReadFile(received.Path)
+	if err != nil {
+		t.Fatalf("read received file: %v", err)
+	}
+	if string(got) != string(content) {
+		t.Fatal("received file content does not match source")
+	}
+}
+
+func TestHandleCompleteRejectsChecksumMismatch(t *testing.T) {
+	handler := NewFileDropHandler(nil, t.TempDir())
+	defer handler.Close()
+
+	if err := handler.handleStart(Message{TransferID: "transfer-1", Name: "report.txt", Size: 4}); err != nil {
+		t.Fatalf("handleStart returned error: %v", err)
+	}
+	if err := handler.handleChunk(Message{TransferID: "transfer-1", Data: EncodeChunk([]byte("data"))}); err != nil {
+		t.Fatalf("handleChunk returned error: %v", err)
+	}
+	path := handler.transfers["transfer-1"].path
+
+	err := handler.handleComplete(Message{TransferID: "transfer-1", Checksum: strings.Repeat("0", 64)})
+	if err == nil {
+		t.Fatal("expected checksum mismatch to be rejected")
+	}
+	if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+		t.Fatalf("expected mismatched file to be removed, got %v", statErr)
+	}
+}
+
+func TestDownloadRequestReportsInvalidPath(t *testing.T) {
+	handler := NewFileDropHandler(nil, "")
+	var sent []Message
+	handler.send = func(payload string) error {
+		message, err := DecodeMessage([]byte(payload))
+		if err != nil {
+			return err
+		}
+		sent = append(sent, message)
+		return nil
+	}
+
+	for _, path := range []string{"", "relative/agent.log", t.TempDir() + string(filepath.Separator) + ".." + string(filepath.Separator) + "x"} {
+		sent = nil
+		err := handler.handleDownloadRequest(Message{TransferID: "dl-1", Path: path})
+		if err == nil {
+			t.Fatalf("expected path %q to be rejected", path)
+		}
+		if len(sent) != 1 || sent[0].Type != MessageTypeDownloadError || sent[0].TransferID != "dl-1" {
+			t.Fatalf("expected a DOWNLOAD_ERROR for %q, got %+v", path, sent)
+		}
+	}
+}
+
+func TestDownloadRequestEnforcesPathCheck(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret.key")
+	if err := os.WriteFile(secret, []byte("key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "notes.txt")
+	if err := os.Symlink(secret, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	resolvedSecret, err := filepath.EvalSymlinks(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := downloadPathCheck.Load()
+	SetDownloadPathCheck(func(p string) error {
+		if p == resolvedSecret || p == secret {
+			return errors.New("read denied on sensitive path")
+		}
+		return nil
+	})
+	t.Cleanup(func() { downloadPathCheck.Store(prev) })
+
+	handler := NewFileDropHandler(nil, "")
+	var sent []Message
+	handler.send = func(payload string) error {
+		message, err := DecodeMessage([]byte(payload))
+		if err != nil {
+			return err
+		}
+		sent = append(sent, message)
+		return nil
+	}
+	for _, path := range []string{secret, link} {
+		sent = nil
+		if err := handler.handleDownloadRequest(Message{TransferID: "dl-1", Path: path}); err == nil {
+			t.Fatalf("download of %q should be denied", path)
+		}
+		if len(sent) != 1 || sent[0].Type != MessageTypeDownloadError || !strings.Contains(sent[0].Error, "denied") {
+			t.Fatalf("expected a DOWNLOAD_ERROR for %q, got %+v", path, sent)
+		}
+	}
+
+	downloadPathCheck.Store(nil)
+	if _, err := checkDownloadPath(dir); err == nil {
+		t.Fatal("downloads must be refused when no check is installed")
+	}
+}
+
+func mustEncode(t *testing.T, message Message) []byte {
+	t.Helper()
+	payload, err := EncodeMessage(message)
+	if err != nil {
+		t.Fatalf("EncodeMessage: %v", err)
+	}
+	return payload
+}
@@ -9,6 +9,12 @@ const (
 	MessageTypeDropStart    = "DROP_START"
 	MessageTypeDropChunk    = "DROP_CHUNK"
 	MessageTypeDropComplete = "DROP_COMPLETE"
+
+	// MessageTypeDownloadRequest asks the agent to send a host file to the
+	// viewer. The agent answers with DROP_START/DROP_CHUNK/DROP_COMPLETE
+	// under the request's transfer_id, or DOWNLOAD_ERROR.
+	MessageTypeDownloadRequest = "DOWNLOAD_REQUEST"
+	MessageTypeDownloadError   = "DOWNLOAD_ERROR"
 )
 
 type Message struct {
@@ -18,6 +24,12 @@ type Message struct {
 	Size       int64  `json:"size,omitempty"`
 	Offset     int64  `json:"offset,omitempty"`
 	Data       string `json:"data,omitempty"`
+	// Path is the absolute host path of a DOWNLOAD_REQUEST.
+	Path string `json:"path,omitempty"`
+	// Checksum is the hex SHA-256 of the whole file, sent on DROP_COMPLETE.
+	// Optional for viewer uploads; always set on agent downloads.
+	Checksum string `json:"checksum,omitempty"`
+	Error    string `json:"error,omitempty"`
 }
 
 func EncodeMessage(message Message) ([]byte, error) {
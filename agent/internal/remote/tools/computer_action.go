@@ -164,22 +164,9 @@ func executeInputAction(action string, x, y int, text, key string, modifiers []s
 		if text == "" {
 			return fmt.Errorf("text field is required for type action")
 		}
-		// Prefer TypeChar (Unicode input) for each character — handles all
-		// characters including ":", "!", "@", non-ASCII, etc. Fall back to
-		// SendKeyPress for platforms without TypeChar support.
-		typer, hasTyper := input.(desktop.TypeCharHandler)
-		for _, ch := range text {
-			if hasTyper {
-				if err := typer.TypeChar(ch); err != nil {
-					return fmt.Errorf("failed typing character %q: %w", string(ch), err)
-				}
-			} else {
-				if err := input.SendKeyPress(string(ch), nil); err != nil {
-					return fmt.Errorf("failed typing character %q: %w", string(ch), err)
-				}
-			}
-		}
-		return nil
+		// Unicode text injection handles all characters including ":", "!",
+		// "@", non-ASCII, etc. regardless of the target's keyboard layout.
+		return desktop.TypeText(input, text)
 
 	default:
 		return fmt.Errorf("unknown action: %s", action)
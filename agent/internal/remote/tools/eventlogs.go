@@ -1,9 +1,26 @@
 package tools
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
+// eventLogQuery is a parsed event_logs_query payload. LogName is the Windows
+// event log channel, or on Linux a journald channel (see journalChannelArgs).
+// A zero Since or Until leaves that end of the time range open.
+type eventLogQuery struct {
+	LogName string
+	Level   string
+	Source  string
+	EventID int
+	Search  string
+	Since   time.Time
+	Until   time.Time
+	Page    int
+	Limit   int
+}
+
 // ListEventLogs returns available event logs
 func ListEventLogs(payload map[string]any) CommandResult {
 	startTime := time.Now()
@@ -14,24 +31,101 @@ func ListEventLogs(payload map[string]any) CommandResult {
 func QueryEventLogs(payload map[string]any) CommandResult {
 	startTime := time.Now()
 
-	logName, _ := truncateStringBytes(GetPayloadString(payload, "logName", "System"), maxEventLogFieldBytes)
-	level := GetPayloadString(payload, "level", "")
-	source, _ := truncateStringBytes(GetPayloadString(payload, "source", ""), maxEventLogFieldBytes)
-	eventID := GetPayloadInt(payload, "eventId", 0)
-	page := GetPayloadInt(payload, "page", 1)
-	limit := GetPayloadInt(payload, "limit", 50)
+	q, err := parseEventLogQuery(payload)
+	if err != nil {
+		return NewErrorResult(err, time.Since(startTime).Milliseconds())
+	}
+
+	return queryEventLogsOS(q, startTime)
+}
+
+// parseEventLogQuery reads the query filters. "channel" is accepted as an
+// alias of logName; startTime and endTime are RFC 3339 and inclusive.
+func parseEventLogQuery(payload map[string]any) (eventLogQuery, error) {
+	logName := GetPayloadString(payload, "logName", "")
+	if logName == "" {
+		logName = GetPayloadString(payload, "channel", "System")
+	}
+
+	q := eventLogQuery{
+		Level:   GetPayloadString(payload, "level", ""),
+		EventID: GetPayloadInt(payload, "eventId", 0),
+		Page:    GetPayloadInt(payload, "page", 1),
+		Limit:   GetPayloadInt(payload, "limit", 50),
+	}
+	q.LogName, _ = truncateStringBytes(logName, maxEventLogFieldBytes)
+	q.Source, _ = truncateStringBytes(GetPayloadString(payload, "source", ""), maxEventLogFieldBytes)
+	q.Search, _ = truncateStringBytes(strings.TrimSpace(GetPayloadString(payload, "search", "")), maxEventLogFieldBytes)
 
-	if page < 1 {
-		page = 1
+	var err error
+	if q.Since, err = parseEventLogTime(payload, "startTime"); err != nil {
+		return q, err
 	}
-	if page > maxEventLogQueryPage {
-		page = maxEventLogQueryPage
+	if q.Until, err = parseEventLogTime(payload, "endTime"); err != nil {
+		return q, err
 	}
-	if limit < 1 || limit > 500 {
-		limit = 50
+	if !q.Since.IsZero() && !q.Until.IsZero() && q.Until.Before(q.Since) {
+		return q, fmt.Errorf("endTime is before startTime")
 	}
 
-	return queryEventLogsOS(logName, level, source, eventID, page, limit, startTime)
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Page > maxEventLogQueryPage {
+		q.Page = maxEventLogQueryPage
+	}
+	if q.Limit < 1 || q.Limit > 500 {
+		q.Limit = 50
+	}
+	return q, nil
+}
+
+func parseEventLogTime(payload map[string]any, key string) (time.Time, error) {
+	raw := GetPayloadString(payload, key, "")
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: must be RFC 3339", key)
+	}
+	return t.UTC(), nil
+}
+
+// eventLogMatchesSearch reports whether the message or source contains
+// search, ignoring case.
+func eventLogMatchesSearch(entry EventLogEntry, search string) bool {
+	if search == "" {
+		return true
+	}
+	search = strings.ToLower(search)
+	return strings.Contains(strings.ToLower(entry.Message), search) ||
+		strings.Contains(strings.ToLower(entry.Source), search)
+}
+
+// paginateEventLogEntries returns one page of events, newest first as the
+// OS returned them.
+func paginateEventLogEntries(events []EventLogEntry, page, limit int, truncated bool) EventLogQueryResponse {
+	total := len(events)
+	totalPages := (total + limit - 1) / limit
+	start := (page - 1) * limit
+	end := start + limit
+
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+
+	return EventLogQueryResponse{
+		Events:     events[start:end],
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+		Truncated:  truncated,
+	}
 }
 
 // GetEventLogEntry returns a specific event log entry
@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// journaldChannels are the fixed channels offered on Linux besides systemd
+// units. They mirror the Windows logs the console already knows.
+var journaldChannels = []EventLog{
+	{Name: "System", DisplayName: "System (all journal entries)"},
+	{Name: "Kernel", DisplayName: "Kernel"},
+	{Name: "Security", DisplayName: "Security (auth, authpriv)"},
+}
+
+// journalChannelArgs maps a channel to journalctl arguments: the fixed
+// channels above, or otherwise a systemd unit name. The unit goes in the
+// --unit= form so a name starting with "-" cannot become an option.
+func journalChannelArgs(logName string) []string {
+	switch strings.ToLower(logName) {
+	case "", "system":
+		return nil
+	case "kernel":
+		// -k would also restrict to the current boot.
+		return []string{"_TRANSPORT=kernel"}
+	case "security", "auth":
+		// Repeating a field ORs the matches.
+		return []string{"SYSLOG_FACILITY=4", "SYSLOG_FACILITY=10"}
+	}
+	return []string{"--unit=" + logName}
+}
+
+// journalPriorityArg maps a console level to the matching syslog priority
+// band, the same bands journalEntryLevel reports. Unknown levels match all.
+func journalPriorityArg(level string) string {
+	switch strings.ToLower(level) {
+	case "critical":
+		return "--priority=0..2"
+	case "error":
+		return "--priority=3..3"
+	case "warning":
+		return "--priority=4..4"
+	case "information", "info":
+		return "--priority=5..6"
+	case "verbose":
+		return "--priority=7..7"
+	}
+	return ""
+}
+
+// journalEntryLevel names a syslog priority with the Windows level names
+// the console shows.
+func journalEntryLevel(priority string) string {
+	switch priority {
+	case "0", "1", "2":
+		return "Critical"
+	case "3":
+		return "Error"
+	case "4":
+		return "Warning"
+	case "7":
+		return "Verbose"
+	}
+	return "Information"
+}
+
+// journalQueryArgs builds the journalctl arguments for q, newest first.
+// eventId has no journald equivalent and is ignored.
+func journalQueryArgs(q eventLogQuery, maxEntries int) []string {
+	args := []string{"--output=json", "--no-pager", "--reverse", "-n", strconv.Itoa(maxEntries)}
+	if !q.Since.IsZero() {
+		args = append(args, "--since=@"+strconv.FormatInt(q.Since.Unix(), 10))
+	}
+	if !q.Until.IsZero() {
+		args = append(args, "--until=@"+strconv.FormatInt(q.Until.Unix(), 10))
+	}
+	if p := journalPriorityArg(q.Level); p != "" {
+		args = append(args, p)
+	}
+	args = append(args, journalChannelArgs(q.LogName)...)
+	if q.Source != "" {
+		args = append(args, "SYSLOG_IDENTIFIER="+q.Source)
+	}
+	return args
+}
+
+// journalRecord is the subset of journalctl --output=json fields the event
+// viewer shows.
+type journalRecord struct {
+	RealtimeTimestamp string         `json:"__REALTIME_TIMESTAMP"`
+	SyslogIdentifier  string         `json:"SYSLOG_IDENTIFIER"`
+	Unit              string         `json:"_SYSTEMD_UNIT"`
+	Message           journalMessage `json:"MESSAGE"`
+	Priority          string         `json:"PRIORITY"`
+	Hostname          string         `json:"_HOSTNAME"`
+	UID               string         `json:"_UID"`
+}
+
+// journalMessage decodes MESSAGE, which journalctl emits as an array of
+// bytes instead of a string when it is not valid UTF-8.
+type journalMessage string
+
+func (m *journalMessage) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*m = journalMessage(s)
+		return nil
+	}
+	var ints []int
+	if err := json.Unmarshal(data, &ints); err != nil {
+		// null or an unexpected shape: leave the message empty.
+		return nil
+	}
+	raw := make([]byte, len(ints))
+	for i, v := range ints {
+		raw[i] = byte(v)
+	}
+	*m = journalMessage(strings.ToValidUTF8(string(raw), "�"))
+	return nil
+}
+
+// parseJournalEventLogEntries converts journalctl JSON lines to event log
+// entries. RecordID is the realtime timestamp in microseconds, which is
+// what getEventLogEntryOS looks an entry up by.
+func parseJournalEventLogEntries(output []byte, logName string) []EventLogEntry {
+	entries := []EventLogEntry{}
+	scanner := newBoundedScanner(string(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var rec journalRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			continue
+		}
+		usec, err := strconv.ParseInt(rec.RealtimeTimestamp, 10, 64)
+		if err != nil {
+			continue
+		}
+		source := rec.SyslogIdentifier
+		if source == "" {
+			source = rec.Unit
+		}
+		entries = append(entries, EventLogEntry{
+			RecordID:    usec,
+			LogName:     logName,
+			Level:       journalEntryLevel(rec.Priority),
+			TimeCreated: time.UnixMicro(usec).UTC(),
+			Source:      source,
+			Message:     string(rec.Message),
+			Computer:    rec.Hostname,
+			UserID:      rec.UID,
+		})
+	}
+	return entries
+}
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEventLogQuery(t *testing.T) {
+	q, err := parseEventLogQuery(map[string]any{
+		"channel":   "sshd.service",
+		"level":     "error",
+		"search":    "  failed password ",
+		"startTime": "2026-03-01T10:00:00+02:00",
+		"endTime":   "2026-03-01T12:00:00Z",
+		"page":      float64(3),
+		"limit":     float64(1000),
+	})
+	if err != nil {
+		t.Fatalf("parseEventLogQuery: %v", err)
+	}
+	if q.LogName != "sshd.service" {
+		t.Errorf("LogName = %q, want channel alias", q.LogName)
+	}
+	if q.Search != "failed password" {
+		t.Errorf("Search = %q, want trimmed", q.Search)
+	}
+	if want := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC); !q.Since.Equal(want) {
+		t.Errorf("Since = %v, want %v", q.Since, want)
+	}
+	if q.Page != 3 || q.Limit != 50 {
+		t.Errorf("page/limit = %d/%d, want 3/50", q.Page, q.Limit)
+	}
+
+	q, err = parseEventLogQuery(map[string]any{})
+	if err != nil {
+		t.Fatalf("parseEventLogQuery(empty): %v", err)
+	}
+	if q.LogName != "System" || !q.Since.IsZero() || !q.Until.IsZero() {
+		t.Errorf("defaults = %+v", q)
+	}
+}
+
+func TestParseEventLogQueryRejectsBadTimes(t *testing.T) {
+	if _, err := parseEventLogQuery(map[string]any{"startTime": "yesterday"}); err == nil {
+		t.Error("expected error for non-RFC 3339 startTime")
+	}
+	_, err := parseEventLogQuery(map[string]any{
+		"startTime": "2026-03-02T00:00:00Z",
+		"endTime":   "2026-03-01T00:00:00Z",
+	})
+	if err == nil || !strings.Contains(err.Error(), "before") {
+		t.Errorf("expected inverted range error, got %v", err)
+	}
+}
+
+func TestJournalQueryArgs(t *testing.T) {
+	q := eventLogQuery{
+		LogName: "Security",
+		Level:   "warning",
+		Source:  "sshd",
+		Since:   time.Unix(1700000000, 0),
+		Until:   time.Unix(1700003600, 0),
+	}
+	got := strings.Join(journalQueryArgs(q, 100), " ")
+	want := "--output=json --no-pager --reverse -n 100 --since=@1700000000 --until=@1700003600 " +
+		"--priority=4..4 SYSLOG_FACILITY=4 SYSLOG_FACILITY=10 SYSLOG_IDENTIFIER=sshd"
+	if got != want {
+		t.Errorf("args =\n  %s\nwant\n  %s", got, want)
+	}
+}
+
+func TestJournalChannelArgs(t *testing.T) {
+	tests := map[string]string{
+		"System":        "",
+		"kernel":        "_TRANSPORT=kernel",
+		"nginx.service": "--unit=nginx.service",
+		"--boot":        "--unit=--boot",
+	}
+	for channel, want := range tests {
+		if got := strings.Join(journalChannelArgs(channel), " "); got != want {
+			t.Errorf("journalChannelArgs(%q) = %q, want %q", channel, got, want)
+		}
+	}
+}
+
+func TestParseJournalEventLogEntries(t *testing.T) {
+	output := strings.Join([]string{
+		`{"__REALTIME_TIMESTAMP":"1700000000123456","SYSLOG_IDENTIFIER":"sshd","PRIORITY":"3","MESSAGE":"Failed password for root","_HOSTNAME":"web1","_UID":"0"}`,
+		`{"__REALTIME_TIMESTAMP":"1700000001000000","_SYSTEMD_UNIT":"cron.service","PRIORITY":"6","MESSAGE":[104,105,255]}`,
+		`not json`,
+		`{"MESSAGE":"no timestamp"}`,
+	}, "\n")
+
+	entries := parseJournalEventLogEntries([]byte(output), "System")
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	e := entries[0]
+	if e.RecordID != 1700000000123456 || e.Level != "Error" || e.Source != "sshd" || e.Computer != "web1" {
+		t.Errorf("entry 0 = %+v", e)
+	}
+	if !e.TimeCreated.Equal(time.UnixMicro(1700000000123456)) {
+		t.Errorf("TimeCreated = %v", e.TimeCreated)
+	}
+	if entries[1].Source != "cron.service" || entries[1].Message != "hi�" || entries[1].Level != "Information" {
+		t.Errorf("entry 1 = %+v", entries[1])
+	}
+}
+
+func TestEventLogMatchesSearchAndPaginate(t *testing.T) {
+	events := []EventLogEntry{
+		{RecordID: 1, Source: "sshd", Message: "Failed password"},
+		{RecordID: 2, Source: "CRON", Message: "job ran"},
+		{RecordID: 3, Source: "kernel", Message: "oom"},
+	}
+	if !eventLogMatchesSearch(events[0], "FAILED") || !eventLogMatchesSearch(events[1], "cron") {
+		t.Error("search should match message and source case-insensitively")
+	}
+	if eventLogMatchesSearch(events[2], "password") {
+		t.Error("unexpected match")
+	}
+
+	resp := paginateEventLogEntries(events, 2, 2, false)
+	if resp.Total != 3 || resp.TotalPages != 2 || len(resp.Events) != 1 || resp.Events[0].RecordID != 3 {
+		t.Errorf("page 2 = %+v", resp)
+	}
+	if resp := paginateEventLogEntries(events, 5, 2, false); len(resp.Events) != 0 {
+		t.Errorf("page past end returned %d events", len(resp.Events))
+	}
+}
@@ -0,0 +1,117 @@
+//go:build linux
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// journalctlTimeout bounds one journalctl run; a text search over a large
+// journal is the slow case.
+const journalctlTimeout = 30 * time.Second
+
+// runJournalctl runs journalctl and returns its stdout. Exit status 1 with
+// no output means nothing matched, which is not an error.
+func runJournalctl(args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), journalctlTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "journalctl", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && len(output) == 0 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("journalctl failed: %w", err)
+	}
+	return output, nil
+}
+
+func listEventLogsOS(startTime time.Time) CommandResult {
+	if _, err := exec.LookPath("journalctl"); err != nil {
+		return NewErrorResult(fmt.Errorf("journalctl not found: event logs need systemd-journald"), time.Since(startTime).Milliseconds())
+	}
+
+	logs := append([]EventLog(nil), journaldChannels...)
+
+	// Every unit that has written to the journal is also a channel.
+	output, err := runJournalctl("--field=_SYSTEMD_UNIT", "--no-pager")
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("failed to list event logs: %w", err), time.Since(startTime).Milliseconds())
+	}
+	var units []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if unit := strings.TrimSpace(line); unit != "" {
+			units = append(units, unit)
+		}
+	}
+	sort.Strings(units)
+	for _, unit := range units {
+		logs = append(logs, EventLog{Name: unit, DisplayName: unit})
+	}
+
+	logs, truncated := sanitizeEventLogs(logs)
+	return NewSuccessResult(EventLogListResponse{Logs: logs, Truncated: truncated}, time.Since(startTime).Milliseconds())
+}
+
+func queryEventLogsOS(q eventLogQuery, startTime time.Time) CommandResult {
+	// A text search has to look past the page window, so it scans up to
+	// maxEventLogSearchScan entries and keeps the matches.
+	maxEntries := q.Page * q.Limit
+	if q.Search != "" {
+		maxEntries = maxEventLogSearchScan
+	}
+
+	output, err := runJournalctl(journalQueryArgs(q, maxEntries)...)
+	if err != nil {
+		return NewErrorResult(err, time.Since(startTime).Milliseconds())
+	}
+
+	events := parseJournalEventLogEntries(output, q.LogName)
+	if q.Search != "" {
+		matched := events[:0]
+		for _, e := range events {
+			if eventLogMatchesSearch(e, q.Search) {
+				matched = append(matched, e)
+			}
+		}
+		events = matched
+		if len(events) > q.Page*q.Limit {
+			events = events[:q.Page*q.Limit]
+		}
+	}
+
+	events, truncated := sanitizeEventLogEntries(events)
+	return NewSuccessResult(paginateEventLogEntries(events, q.Page, q.Limit, truncated), time.Since(startTime).Milliseconds())
+}
+
+// getEventLogEntryOS finds an entry by its realtime timestamp (the RecordID
+// journal queries return), searching the second it falls in.
+func getEventLogEntryOS(logName string, recordID int64, startTime time.Time) CommandResult {
+	if recordID <= 0 {
+		return NewErrorResult(fmt.Errorf("event not found"), time.Since(startTime).Milliseconds())
+	}
+	sec := recordID / 1_000_000
+	args := []string{"--output=json", "--no-pager",
+		"--since=@" + strconv.FormatInt(sec, 10),
+		"--until=@" + strconv.FormatInt(sec+1, 10)}
+	args = append(args, journalChannelArgs(logName)...)
+
+	output, err := runJournalctl(args...)
+	if err != nil {
+		return NewErrorResult(err, time.Since(startTime).Milliseconds())
+	}
+	for _, e := range parseJournalEventLogEntries(output, logName) {
+		if e.RecordID == recordID {
+			entries, _ := sanitizeEventLogEntries([]EventLogEntry{e})
+			return NewSuccessResult(entries[0], time.Since(startTime).Milliseconds())
+		}
+	}
+	return NewErrorResult(fmt.Errorf("event not found"), time.Since(startTime).Milliseconds())
+}
@@ -1,4 +1,4 @@
-//go:build !windows
+//go:build !windows && !linux
 
 package tools
 
@@ -9,21 +9,21 @@ import (
 
 func listEventLogsOS(startTime time.Time) CommandResult {
 	return NewErrorResult(
-		fmt.Errorf("event logs are only supported on Windows"),
+		fmt.Errorf("event logs are only supported on Windows and Linux"),
 		time.Since(startTime).Milliseconds(),
 	)
 }
 
-func queryEventLogsOS(logName, level, source string, eventID, page, limit int, startTime time.Time) CommandResult {
+func queryEventLogsOS(q eventLogQuery, startTime time.Time) CommandResult {
 	return NewErrorResult(
-		fmt.Errorf("event logs are only supported on Windows"),
+		fmt.Errorf("event logs are only supported on Windows and Linux"),
 		time.Since(startTime).Milliseconds(),
 	)
 }
 
 func getEventLogEntryOS(logName string, recordID int64, startTime time.Time) CommandResult {
 	return NewErrorResult(
-		fmt.Errorf("event logs are only supported on Windows"),
+		fmt.Errorf("event logs are only supported on Windows and Linux"),
 		time.Since(startTime).Milliseconds(),
 	)
 }
@@ -42,67 +42,57 @@ func listEventLogsOS(startTime time.Time) CommandResult {
 	return NewSuccessResult(response, time.Since(startTime).Milliseconds())
 }
 
-func queryEventLogsOS(logName, level, source string, eventID, page, limit int, startTime time.Time) CommandResult {
-	// Build PowerShell filter
-	filter := fmt.Sprintf("LogName='%s'", escapePowerShellSingleQuoted(logName))
-	if level != "" {
-		levelNum := levelToNumber(level)
+func queryEventLogsOS(q eventLogQuery, startTime time.Time) CommandResult {
+	// Build the Get-WinEvent filter hashtable
+	filter := []string{fmt.Sprintf("LogName='%s'", escapePowerShellSingleQuoted(q.LogName))}
+	if q.Level != "" {
+		levelNum := levelToNumber(q.Level)
 		if levelNum > 0 {
-			filter += fmt.Sprintf(" and Level=%d", levelNum)
+			filter = append(filter, fmt.Sprintf("Level=%d", levelNum))
 		}
 	}
-	if source != "" {
-		filter += fmt.Sprintf(" and ProviderName='%s'", escapePowerShellSingleQuoted(source))
+	if q.Source != "" {
+		filter = append(filter, fmt.Sprintf("ProviderName='%s'", escapePowerShellSingleQuoted(q.Source)))
 	}
-	if eventID > 0 {
-		filter += fmt.Sprintf(" and Id=%d", eventID)
+	if q.EventID > 0 {
+		filter = append(filter, fmt.Sprintf("Id=%d", q.EventID))
+	}
+	if !q.Since.IsZero() {
+		filter = append(filter, fmt.Sprintf("StartTime=[datetime]::Parse('%s').ToUniversalTime()", q.Since.Format(time.RFC3339)))
+	}
+	if !q.Until.IsZero() {
+		filter = append(filter, fmt.Sprintf("EndTime=[datetime]::Parse('%s').ToUniversalTime()", q.Until.Format(time.RFC3339)))
+	}
+
+	// A text search has to look past the page window, so it scans up to
+	// maxEventLogSearchScan events and keeps the matches.
+	maxEvents := q.Page * q.Limit
+	where := ""
+	if q.Search != "" {
+		where = fmt.Sprintf(`Where-Object { ([string]$_.Message + ' ' + $_.ProviderName).IndexOf('%s', [StringComparison]::OrdinalIgnoreCase) -ge 0 } | `+
+			`Select-Object -First %d | `, escapePowerShellSingleQuoted(q.Search), maxEvents)
+		maxEvents = maxEventLogSearchScan
 	}
 
 	// Query events using PowerShell
-	maxEvents := page * limit
 	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
 		utf8PowerShellCommand(fmt.Sprintf(`Get-WinEvent -FilterHashtable @{%s} -MaxEvents %d -ErrorAction SilentlyContinue | `+
-			`Select-Object RecordId, LogName, LevelDisplayName, TimeCreated, ProviderName, Id, Message | `+
-			`ConvertTo-Json -Depth 2`, filter, maxEvents)))
+			where+
+			`Select-Object RecordId, LogName, LevelDisplayName, @{n='TimeCreated';e={$_.TimeCreated.ToUniversalTime().ToString('o')}}, ProviderName, Id, Message | `+
+			`ConvertTo-Json -Depth 2`, strings.Join(filter, "; "), maxEvents)))
 
 	output, err := cmd.Output()
 	if err != nil {
 		// Return empty result if no events found
-		response := EventLogQueryResponse{
-			Events:     []EventLogEntry{},
-			Total:      0,
-			Page:       page,
-			Limit:      limit,
-			TotalPages: 0,
-		}
-		return NewSuccessResult(response, time.Since(startTime).Milliseconds())
+		return NewSuccessResult(paginateEventLogEntries([]EventLogEntry{}, q.Page, q.Limit, false), time.Since(startTime).Milliseconds())
 	}
 
 	events, truncated := sanitizeEventLogEntries(parseEventLogEntries(string(output)))
-
-	// Paginate
-	total := len(events)
-	totalPages := (total + limit - 1) / limit
-	start := (page - 1) * limit
-	end := start + limit
-
-	if start > total {
-		start = total
-	}
-	if end > total {
-		end = total
+	if events == nil {
+		events = []EventLogEntry{}
 	}
 
-	response := EventLogQueryResponse{
-		Events:     events[start:end],
-		Total:      total,
-		Page:       page,
-		Limit:      limit,
-		TotalPages: totalPages,
-		Truncated:  truncated,
-	}
-
-	return NewSuccessResult(response, time.Since(startTime).Milliseconds())
+	return NewSuccessResult(paginateEventLogEntries(events, q.Page, q.Limit, truncated), time.Since(startTime).Milliseconds())
 }
 
 func getEventLogEntryOS(logName string, recordID int64, startTime time.Time) CommandResult {
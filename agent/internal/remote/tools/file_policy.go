@@ -0,0 +1,150 @@
+package tools
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// FilePathPolicy restricts which paths the file manager commands (file_*)
+// may touch, on top of the built-in system-path and credential-store deny
+// lists. Entries are absolute path prefixes matched at a path-component
+// boundary, case- and separator-insensitively. Deny wins over Allow; an empty
+// Allow permits every path that is not denied.
+type FilePathPolicy struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+var filePathPolicy atomic.Pointer[FilePathPolicy]
+
+// SetFilePathPolicy replaces the file manager path policy. Relative entries
+// are dropped.
+func SetFilePathPolicy(p FilePathPolicy) {
+	filePathPolicy.Store(&FilePathPolicy{
+		Allow: normalizePolicyPaths(p.Allow),
+		Deny:  normalizePolicyPaths(p.Deny),
+	})
+}
+
+// CurrentFilePathPolicy returns the normalized policy in effect.
+func CurrentFilePathPolicy() FilePathPolicy {
+	if p := filePathPolicy.Load(); p != nil {
+		return *p
+	}
+	return FilePathPolicy{}
+}
+
+// normalizePolicyPath folds case and separators so Windows and Unix paths
+// compare the same way (see isSensitiveReadPath).
+func normalizePolicyPath(p string) string {
+	norm := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(p), "\\", "/"))
+	if norm == "" {
+		return ""
+	}
+	return path.Clean(norm)
+}
+
+// isAbsPolicyPath reports whether a normalized path is absolute on any OS:
+// "/x" (UNC shares clean to "/server/share") or "c:/x".
+func isAbsPolicyPath(norm string) bool {
+	return strings.HasPrefix(norm, "/") ||
+		(len(norm) >= 2 && norm[1] == ':' && norm[0] >= 'a' && norm[0] <= 'z')
+}
+
+func normalizePolicyPaths(paths []string) []string {
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if norm := normalizePolicyPath(p); norm != "" && isAbsPolicyPath(norm) {
+			out = append(out, norm)
+		}
+	}
+	return out
+}
+
+// policyPathMatches reports whether norm is prefix or lies beneath it.
+func policyPathMatches(norm, prefix string) bool {
+	if norm == prefix {
+		return true
+	}
+	if strings.HasSuffix(prefix, "/") {
+		return strings.HasPrefix(norm, prefix)
+	}
+	// "c:" (a cleaned "c:/") covers "c:/anything".
+	return strings.HasPrefix(norm, prefix+"/")
+}
+
+func (p *FilePathPolicy) permits(absPath string) bool {
+	norm := normalizePolicyPath(absPath)
+	for _, d := range p.Deny {
+		if policyPathMatches(norm, d) {
+			return false
+		}
+	}
+	if len(p.Allow) == 0 {
+		return true
+	}
+	for _, a := range p.Allow {
+		if policyPathMatches(norm, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFilePathPolicy rejects a path outside the file manager policy. Both
+// the literal path and its symlink-resolved form must be permitted, so a
+// link inside an allowed tree cannot reach a denied one.
+func checkFilePathPolicy(cleanPath string) error {
+	p := filePathPolicy.Load()
+	if p == nil || (len(p.Allow) == 0 && len(p.Deny) == 0) {
+		return nil
+	}
+	abs, err := filepath.Abs(cleanPath)
+	if err != nil {
+		return fmt.Errorf("resolve path %s: %w", cleanPath, err)
+	}
+	if !p.permits(abs) {
+		return fmt.Errorf("path not permitted by file policy: %s", cleanPath)
+	}
+	if resolved := resolveExistingPath(abs); resolved != abs && !p.permits(resolved) {
+		return fmt.Errorf("path not permitted by file policy (via symlink): %s", cleanPath)
+	}
+	return nil
+}
+
+// checkFilePathsPolicy applies checkFilePathPolicy to each path.
+func checkFilePathsPolicy(paths ...string) error {
+	for _, p := range paths {
+		if err := checkFilePathPolicy(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filePolicyPermitsEntry is the literal-path check used while walking a tree
+// whose root already passed checkFilePathPolicy (symlinks are not followed).
+func filePolicyPermitsEntry(absPath string) bool {
+	p := filePathPolicy.Load()
+	return p == nil || p.permits(absPath)
+}
+
+// resolveExistingPath resolves symlinks in the longest existing prefix of p,
+// so a not-yet-created target is judged by where its parent really is.
+func resolveExistingPath(p string) string {
+	dir, rest := p, ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return p
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func setTestFilePathPolicy(t *testing.T, p FilePathPolicy) {
+	t.Helper()
+	SetFilePathPolicy(p)
+	t.Cleanup(func() { SetFilePathPolicy(FilePathPolicy{}) })
+}
+
+// evalTempDir resolves a temp dir so policy prefixes match resolved paths
+// (macOS temp dirs live behind the /var -> /private/var symlink).
+func evalTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestFilePathPolicyPermits(t *testing.T) {
+	p := FilePathPolicy{
+		Allow: normalizePolicyPaths([]string{"/srv/data", `C:\Users`, "relative"}),
+		Deny:  normalizePolicyPaths([]string{"/srv/data/secret", `c:/users/admin`}),
+	}
+	if len(p.Allow) != 2 {
+		t.Fatalf("relative allow entry should be dropped: %v", p.Allow)
+	}
+	cases := map[string]bool{
+		"/srv/data":              true,
+		"/srv/data/reports/q1":   true,
+		"/srv/database":          false, // component boundary, not string prefix
+		"/srv/data/secret":       false,
+		"/srv/data/secret/x":     false,
+		"/srv/data/secretive":    true,
+		`C:\Users\alice\Desktop`: true,
+		`c:\USERS\Admin\file`:    false,
+		"/etc/passwd":            false,
+	}
+	for path, want := range cases {
+		if got := p.permits(path); got != want {
+			t.Errorf("permits(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	denyOnly := FilePathPolicy{Deny: normalizePolicyPaths([]string{"/"})}
+	if denyOnly.permits("/anything") {
+		t.Error("deny of / should cover every path")
+	}
+}
+
+func TestFilePathPolicyAppliesToFileOps(t *testing.T) {
+	root := evalTempDir(t)
+	allowed := filepath.Join(root, "allowed")
+	other := filepath.Join(root, "other")
+	for _, d := range []string{allowed, other} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setTestFilePathPolicy(t, FilePathPolicy{Allow: []string{allowed}})
+
+	if r := ListFiles(map[string]any{"path": allowed}); r.Status != "completed" {
+		t.Fatalf("list of allowed dir failed: %s", r.Error)
+	}
+	if r := ListFiles(map[string]any{"path": other}); r.Status == "completed" || !strings.Contains(r.Error, "file policy") {
+		t.Fatalf("list outside policy = %q, %q", r.Status, r.Error)
+	}
+	if r := WriteFile(map[string]any{"path": filepath.Join(other, "x.txt"), "content": "x"}); r.Status == "completed" {
+		t.Fatal("write outside policy should be denied")
+	}
+	if r := CopyFile(map[string]any{"sourcePath": allowed, "destPath": filepath.Join(other, "copy")}); r.Status == "completed" {
+		t.Fatal("copy to a destination outside policy should be denied")
+	}
+}
+
+func TestFilePathPolicyFollowsSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	root := evalTempDir(t)
+	allowed := filepath.Join(root, "allowed")
+	denied := filepath.Join(root, "denied")
+	for _, d := range []string{allowed, denied} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(allowed, "escape")
+	if err := os.Symlink(denied, link); err != nil {
+		t.Fatal(err)
+	}
+	setTestFilePathPolicy(t, FilePathPolicy{Allow: []string{allowed}})
+
+	if err := checkFilePathPolicy(link); err == nil {
+		t.Fatal("symlink out of the allowed tree should be denied")
+	}
+	if err := checkFilePathPolicy(filepath.Join(link, "new.txt")); err == nil {
+		t.Fatal("new file under an escaping symlink should be denied")
+	}
+	if err := checkFilePathPolicy(filepath.Join(allowed, "new", "file.txt")); err != nil {
+		t.Fatalf("not-yet-created path in allowed tree: %v", err)
+	}
+}
+
+func TestCheckReadPathCombinesDenyListAndPolicy(t *testing.T) {
+	root := evalTempDir(t)
+	allowed := filepath.Join(root, "allowed")
+	if err := os.MkdirAll(allowed, 0755); err != nil {
+		t.Fatal(err)
+	}
+	setTestFilePathPolicy(t, FilePathPolicy{Allow: []string{allowed}})
+
+	if err := CheckReadPath(filepath.Join(allowed, "report.txt")); err != nil {
+		t.Fatalf("allowed path denied: %v", err)
+	}
+	if err := CheckReadPath(filepath.Join(root, "other.txt")); err == nil || !strings.Contains(err.Error(), "file policy") {
+		t.Fatalf("path outside policy = %v", err)
+	}
+	if err := CheckReadPath(filepath.Join(allowed, ".ssh", "id_ed25519")); err == nil || !strings.Contains(err.Error(), "sensitive") {
+		t.Fatalf("SSH key inside allowed tree = %v", err)
+	}
+}
@@ -0,0 +1,514 @@
+package tools
+
+import (
+	"archive/zip"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// maxArchiveBytes caps the uncompressed bytes a file_zip reads or a
+	// file_unzip writes (2GB), whatever the archive headers claim.
+	maxArchiveBytes = 2 << 30
+	// maxArchiveEntries caps the number of entries in one archive.
+	maxArchiveEntries = 100000
+)
+
+var errArchiveTooLarge = fmt.Errorf("archive exceeds maximum of %d bytes uncompressed", int64(maxArchiveBytes))
+
+// StatFile returns metadata for a single path.
+func StatFile(payload map[string]any) CommandResult {
+	start := time.Now()
+
+	path := GetPayloadString(payload, "path", "")
+	if path == "" {
+		return NewErrorResult(fmt.Errorf("path is required"), time.Since(start).Milliseconds())
+	}
+	cleanPath := filepath.Clean(path)
+
+	if err := enforceReadContainment(cleanPath); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	if err := checkFilePathPolicy(cleanPath); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	info, err := os.Lstat(cleanPath)
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("failed to stat path: %w", err), time.Since(start).Milliseconds())
+	}
+
+	stat := FileStat{
+		Name:        info.Name(),
+		Path:        cleanPath,
+		Type:        fileStatType(info.Mode()),
+		Size:        info.Size(),
+		Modified:    info.ModTime().Format(time.RFC3339),
+		Permissions: info.Mode().String(),
+		Mode:        fmt.Sprintf("%04o", info.Mode().Perm()),
+		Owner:       getFileOwner(info),
+	}
+	if stat.Type == "symlink" {
+		stat.LinkTarget, _ = os.Readlink(cleanPath)
+	}
+	return NewSuccessResult(stat, time.Since(start).Milliseconds())
+}
+
+func fileStatType(mode os.FileMode) string {
+	switch {
+	case mode&os.ModeSymlink != 0:
+		return "symlink"
+	case mode.IsDir():
+		return "directory"
+	case mode.IsRegular():
+		return "file"
+	}
+	return "other"
+}
+
+// MoveFile moves a file or directory, falling back to copy + remove when a
+// rename is not possible (e.g. across volumes). An existing destination is
+// only replaced when "overwrite" is set, and never when it is a directory.
+func MoveFile(payload map[string]any) CommandResult {
+	start := time.Now()
+
+	sourcePath := GetPayloadString(payload, "sourcePath", "")
+	if sourcePath == "" {
+		return NewErrorResult(fmt.Errorf("sourcePath is required"), time.Since(start).Milliseconds())
+	}
+	destPath := GetPayloadString(payload, "destPath", "")
+	if destPath == "" {
+		return NewErrorResult(fmt.Errorf("destPath is required"), time.Since(start).Milliseconds())
+	}
+	overwrite := GetPayloadBool(payload, "overwrite", false)
+
+	cleanSrc := filepath.Clean(sourcePath)
+	cleanDst := filepath.Clean(destPath)
+
+	for _, p := range []string{cleanSrc, cleanDst} {
+		if isDeniedSystemPath(p) {
+			return NewErrorResult(fmt.Errorf("operation denied on system path: %s", p), time.Since(start).Milliseconds())
+		}
+	}
+	if err := checkFilePathsPolicy(cleanSrc, cleanDst); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	info, err := os.Lstat(cleanSrc)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewErrorResult(fmt.Errorf("source path does not exist: %s", cleanSrc), time.Since(start).Milliseconds())
+		}
+		return NewErrorResult(fmt.Errorf("failed to stat source: %w", err), time.Since(start).Milliseconds())
+	}
+	if info.IsDir() && (cleanDst == cleanSrc || strings.HasPrefix(cleanDst, cleanSrc+string(filepath.Separator))) {
+		return NewErrorResult(fmt.Errorf("cannot move directory into itself: %s -> %s", cleanSrc, cleanDst), time.Since(start).Milliseconds())
+	}
+
+	if dstInfo, err := os.Lstat(cleanDst); err == nil {
+		if !overwrite {
+			return NewErrorResult(fmt.Errorf("destination already exists: %s", cleanDst), time.Since(start).Milliseconds())
+		}
+		if dstInfo.IsDir() {
+			return NewErrorResult(fmt.Errorf("cannot overwrite directory: %s", cleanDst), time.Since(start).Milliseconds())
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cleanDst), 0755); err != nil {
+		return NewErrorResult(fmt.Errorf("failed to create destination directory: %w", err), time.Since(start).Milliseconds())
+	}
+
+	crossDevice := false
+	if err := os.Rename(cleanSrc, cleanDst); err != nil {
+		// Rename may fail across devices; fall back to copy + remove
+		crossDevice = true
+		if info.IsDir() {
+			if cpErr := copyDir(cleanSrc, cleanDst); cpErr != nil {
+				os.RemoveAll(cleanDst)
+				return NewErrorResult(fmt.Errorf("failed to move directory: %w", cpErr), time.Since(start).Milliseconds())
+			}
+			if err := os.RemoveAll(cleanSrc); err != nil {
+				return NewErrorResult(fmt.Errorf("copied but failed to remove source: %w", err), time.Since(start).Milliseconds())
+			}
+		} else {
+			if cpErr := copyFile(cleanSrc, cleanDst, info.Mode()); cpErr != nil {
+				return NewErrorResult(fmt.Errorf("failed to move file: %w", cpErr), time.Since(start).Milliseconds())
+			}
+			if err := os.Remove(cleanSrc); err != nil {
+				return NewErrorResult(fmt.Errorf("copied but failed to remove source: %w", err), time.Since(start).Milliseconds())
+			}
+		}
+	}
+
+	return NewSuccessResult(map[string]any{
+		"sourcePath": cleanSrc,
+		"destPath":   cleanDst,
+		"moved":      true,
+		"copied":     crossDevice,
+	}, time.Since(start).Milliseconds())
+}
+
+// ZipFiles archives one or more files or directories ("paths") into a zip at
+// "destPath". Each source is stored under its base name; symlinks and
+// credential stores are skipped.
+func ZipFiles(payload map[string]any) CommandResult {
+	start := time.Now()
+
+	sources := GetPayloadStringSlice(payload, "paths")
+	if len(sources) == 0 {
+		return NewErrorResult(fmt.Errorf("paths is required"), time.Since(start).Milliseconds())
+	}
+	destPath := GetPayloadString(payload, "destPath", "")
+	if destPath == "" {
+		return NewErrorResult(fmt.Errorf("destPath is required"), time.Since(start).Milliseconds())
+	}
+	overwrite := GetPayloadBool(payload, "overwrite", false)
+
+	cleanDst := filepath.Clean(destPath)
+	if isDeniedSystemPath(cleanDst) {
+		return NewErrorResult(fmt.Errorf("operation denied on system path: %s", cleanDst), time.Since(start).Milliseconds())
+	}
+	if err := checkFilePathPolicy(cleanDst); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	if _, err := os.Lstat(cleanDst); err == nil && !overwrite {
+		return NewErrorResult(fmt.Errorf("destination already exists: %s", cleanDst), time.Since(start).Milliseconds())
+	}
+
+	roots := make([]string, 0, len(sources))
+	names := make(map[string]bool, len(sources))
+	for _, src := range sources {
+		cleanSrc := filepath.Clean(src)
+		if err := enforceReadContainment(cleanSrc); err != nil {
+			return NewErrorResult(err, time.Since(start).Milliseconds())
+		}
+		if err := checkFilePathPolicy(cleanSrc); err != nil {
+			return NewErrorResult(err, time.Since(start).Milliseconds())
+		}
+		if _, err := os.Lstat(cleanSrc); err != nil {
+			return NewErrorResult(fmt.Errorf("failed to stat source: %w", err), time.Since(start).Milliseconds())
+		}
+		base := filepath.Base(cleanSrc)
+		if names[strings.ToLower(base)] {
+			return NewErrorResult(fmt.Errorf("duplicate archive entry name: %s", base), time.Since(start).Milliseconds())
+		}
+		names[strings.ToLower(base)] = true
+		roots = append(roots, cleanSrc)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cleanDst), 0755); err != nil {
+		return NewErrorResult(fmt.Errorf("failed to create destination directory: %w", err), time.Since(start).Milliseconds())
+	}
+	// Build next to the destination and rename into place, so a failed
+	// archive never leaves a partial zip behind.
+	tmp, err := os.CreateTemp(filepath.Dir(cleanDst), ".breeze-zip-*")
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("failed to create archive: %w", err), time.Since(start).Milliseconds())
+	}
+	tmpPath := tmp.Name()
+
+	stats, zipErr := writeZipArchive(tmp, roots, tmpPath)
+	if closeErr := tmp.Close(); zipErr == nil {
+		zipErr = closeErr
+	}
+	if zipErr == nil {
+		zipErr = os.Rename(tmpPath, cleanDst)
+	}
+	if zipErr != nil {
+		os.Remove(tmpPath)
+		return NewErrorResult(fmt.Errorf("failed to create archive: %w", zipErr), time.Since(start).Milliseconds())
+	}
+
+	result := map[string]any{
+		"destPath":    cleanDst,
+		"entries":     stats.entries,
+		"sourceBytes": stats.bytes,
+	}
+	if info, err := os.Stat(cleanDst); err == nil {
+		result["sizeBytes"] = info.Size()
+	}
+	if stats.skipped > 0 {
+		result["skipped"] = stats.skipped
+	}
+	return NewSuccessResult(result, time.Since(start).Milliseconds())
+}
+
+type archiveStats struct {
+	entries int
+	bytes   int64
+	skipped int
+}
+
+// writeZipArchive walks each root into w. Entries the read deny-list or the
+// path policy reject, symlinks, and the in-progress archive itself are
+// skipped and counted.
+func writeZipArchive(w io.Writer, roots []string, self string) (archiveStats, error) {
+	var stats archiveStats
+	zw := zip.NewWriter(w)
+	for _, root := range roots {
+		parent := filepath.Dir(root)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if path == self || d.Type()&fs.ModeSymlink != 0 {
+				return nil
+			}
+			if isSensitiveReadPath(path) || !filePolicyPermitsEntry(path) {
+				stats.skipped++
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.IsDir() && !d.Type().IsRegular() {
+				return nil
+			}
+			if stats.entries++; stats.entries > maxArchiveEntries {
+				return fmt.Errorf("archive exceeds maximum of %d entries", maxArchiveEntries)
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(parent, path)
+			if err != nil {
+				return err
+			}
+			hdr, err := zip.FileInfoHeader(info)
+			if err != nil {
+				return err
+			}
+			hdr.Name = filepath.ToSlash(rel)
+			if d.IsDir() {
+				hdr.Name += "/"
+				_, err = zw.CreateHeader(hdr)
+				return err
+			}
+			hdr.Method = zip.Deflate
+			if stats.bytes += info.Size(); stats.bytes > maxArchiveBytes {
+				return errArchiveTooLarge
+			}
+			entry, err := zw.CreateHeader(hdr)
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(entry, f)
+			return err
+		})
+		if err != nil {
+			zw.Close()
+			return stats, err
+		}
+	}
+	return stats, zw.Close()
+}
+
+// UnzipFile extracts the zip at "sourcePath" into the directory "destPath".
+// Entries that would land outside the destination (zip-slip) fail the whole
+// extraction; symlink entries are skipped. Existing files are only replaced
+// when "overwrite" is set.
+func UnzipFile(payload map[string]any) CommandResult {
+	start := time.Now()
+
+	sourcePath := GetPayloadString(payload, "sourcePath", "")
+	if sourcePath == "" {
+		return NewErrorResult(fmt.Errorf("sourcePath is required"), time.Since(start).Milliseconds())
+	}
+	destPath := GetPayloadString(payload, "destPath", "")
+	if destPath == "" {
+		return NewErrorResult(fmt.Errorf("destPath is required"), time.Since(start).Milliseconds())
+	}
+	overwrite := GetPayloadBool(payload, "overwrite", false)
+
+	cleanSrc := filepath.Clean(sourcePath)
+	cleanDst := filepath.Clean(destPath)
+	if isDeniedSystemPath(cleanDst) {
+		return NewErrorResult(fmt.Errorf("operation denied on system path: %s", cleanDst), time.Since(start).Milliseconds())
+	}
+	if err := enforceReadContainment(cleanSrc); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	if err := checkFilePathsPolicy(cleanSrc, cleanDst); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	zr, err := zip.OpenReader(cleanSrc)
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("failed to open archive: %w", err), time.Since(start).Milliseconds())
+	}
+	defer zr.Close()
+	if len(zr.File) > maxArchiveEntries {
+		return NewErrorResult(fmt.Errorf("archive has %d entries (max %d)", len(zr.File), maxArchiveEntries), time.Since(start).Milliseconds())
+	}
+
+	if err := os.MkdirAll(cleanDst, 0755); err != nil {
+		return NewErrorResult(fmt.Errorf("failed to create destination directory: %w", err), time.Since(start).Milliseconds())
+	}
+
+	var written int64
+	entries, skipped := 0, 0
+	for _, f := range zr.File {
+		target, err := archiveEntryTarget(cleanDst, f.Name)
+		if err != nil {
+			return NewErrorResult(err, time.Since(start).Milliseconds())
+		}
+		if f.Mode()&os.ModeSymlink != 0 || !filePolicyPermitsEntry(target) {
+			skipped++
+			continue
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return NewErrorResult(fmt.Errorf("failed to create directory: %w", err), time.Since(start).Milliseconds())
+			}
+			entries++
+			continue
+		}
+		if _, err := os.Lstat(target); err == nil && !overwrite {
+			return NewErrorResult(fmt.Errorf("destination already exists: %s", target), time.Since(start).Milliseconds())
+		}
+		n, err := extractZipEntry(f, target, maxArchiveBytes-written)
+		written += n
+		if err != nil {
+			return NewErrorResult(fmt.Errorf("failed to extract %s: %w", f.Name, err), time.Since(start).Milliseconds())
+		}
+		entries++
+	}
+
+	result := map[string]any{
+		"sourcePath": cleanSrc,
+		"destPath":   cleanDst,
+		"entries":    entries,
+		"sizeBytes":  written,
+	}
+	if skipped > 0 {
+		result["skipped"] = skipped
+	}
+	return NewSuccessResult(result, time.Since(start).Milliseconds())
+}
+
+// archiveEntryTarget maps an archive entry name to its path under dest,
+// rejecting absolute names and any that escape dest.
+func archiveEntryTarget(dest, name string) (string, error) {
+	if name == "" || strings.HasPrefix(name, "/") || strings.HasPrefix(name, "\\") || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("archive entry has an unsafe path: %q", name)
+	}
+	target := filepath.Join(dest, filepath.FromSlash(name))
+	if target != dest && !strings.HasPrefix(target, dest+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry escapes the destination: %q", name)
+	}
+	return target, nil
+}
+
+// extractZipEntry writes one file entry, stopping at budget bytes.
+func extractZipEntry(f *zip.File, target string, budget int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	mode := f.Mode().Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return 0, err
+	}
+	// Read one byte past the budget to tell "exactly at" from "over".
+	n, err := io.Copy(out, io.LimitReader(rc, budget+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > budget {
+		err = errArchiveTooLarge
+	}
+	if err != nil {
+		os.Remove(target)
+	}
+	return n, err
+}
+
+// HashFile returns the digest of a file ("algorithm": md5, sha1, sha256 or
+// sha512; default sha256).
+func HashFile(payload map[string]any) CommandResult {
+	start := time.Now()
+
+	path := GetPayloadString(payload, "path", "")
+	if path == "" {
+		return NewErrorResult(fmt.Errorf("path is required"), time.Since(start).Milliseconds())
+	}
+	algorithm := strings.ToLower(GetPayloadString(payload, "algorithm", "sha256"))
+	h, err := newFileHash(algorithm)
+	if err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	cleanPath := filepath.Clean(path)
+	if err := enforceReadContainment(cleanPath); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	if err := checkFilePathPolicy(cleanPath); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	f, err := os.Open(cleanPath)
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("failed to open file: %w", err), time.Since(start).Milliseconds())
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("failed to stat file: %w", err), time.Since(start).Milliseconds())
+	}
+	if !info.Mode().IsRegular() {
+		return NewErrorResult(errors.New("path is not a regular file"), time.Since(start).Milliseconds())
+	}
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("failed to read file: %w", err), time.Since(start).Milliseconds())
+	}
+
+	return NewSuccessResult(map[string]any{
+		"path":      cleanPath,
+		"algorithm": algorithm,
+		"hash":      hex.EncodeToString(h.Sum(nil)),
+		"size":      n,
+	}, time.Since(start).Milliseconds())
+}
+
+func newFileHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported hash algorithm: %s", algorithm)
+}
@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	var stat FileStat
+	decodeSuccessPayload(t, StatFile(map[string]any{"path": path}), &stat)
+	if stat.Type != "file" || stat.Size != 5 || stat.Name != "a.txt" || stat.Modified == "" {
+		t.Fatalf("unexpected stat: %+v", stat)
+	}
+
+	var dirStat FileStat
+	decodeSuccessPayload(t, StatFile(map[string]any{"path": dir}), &dirStat)
+	if dirStat.Type != "directory" {
+		t.Fatalf("type = %q, want directory", dirStat.Type)
+	}
+	if r := StatFile(map[string]any{"path": filepath.Join(dir, "missing")}); r.Status == "completed" {
+		t.Fatal("stat of a missing path should fail")
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "sub", "dst.txt")
+	if err := os.WriteFile(src, []byte("move me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if r := MoveFile(map[string]any{"sourcePath": src, "destPath": dst}); r.Status != "completed" {
+		t.Fatalf("move failed: %s", r.Error)
+	}
+	if got, err := os.ReadFile(dst); err != nil || string(got) != "move me" {
+		t.Fatalf("destination = %q, %v", got, err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Fatal("source should be gone after a move")
+	}
+
+	// An existing destination is kept unless overwrite is set.
+	if err := os.WriteFile(src, []byte("second"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if r := MoveFile(map[string]any{"sourcePath": src, "destPath": dst}); r.Status == "completed" {
+		t.Fatal("move onto an existing file without overwrite should fail")
+	}
+	if r := MoveFile(map[string]any{"sourcePath": src, "destPath": dst, "overwrite": true}); r.Status != "completed" {
+		t.Fatalf("overwrite move failed: %s", r.Error)
+	}
+	if got, _ := os.ReadFile(dst); string(got) != "second" {
+		t.Fatalf("destination = %q, want second", got)
+	}
+
+	if r := MoveFile(map[string]any{"sourcePath": dir, "destPath": filepath.Join(dir, "inner")}); r.Status == "completed" {
+		t.Fatal("moving a directory into itself should fail")
+	}
+}
+
+func TestZipUnzipRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	tree := filepath.Join(dir, "tree")
+	if err := os.MkdirAll(filepath.Join(tree, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"tree/top.txt":        "top",
+		"tree/nested/low.txt": "low",
+	}
+	for rel, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(rel)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	single := filepath.Join(dir, "single.txt")
+	if err := os.WriteFile(single, []byte("one"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(dir, "out", "bundle.zip")
+	var zipped struct {
+		Entries int `json:"entries"`
+	}
+	decodeSuccessPayload(t, ZipFiles(map[string]any{"paths": []any{tree, single}, "destPath": archive}), &zipped)
+	if zipped.Entries != 5 { // tree/, tree/nested/, 2 files under tree, single.txt
+		t.Fatalf("entries = %d, want 5", zipped.Entries)
+	}
+	if r := ZipFiles(map[string]any{"paths": []any{single}, "destPath": archive}); r.Status == "completed" {
+		t.Fatal("zip over an existing archive without overwrite should fail")
+	}
+
+	out := filepath.Join(dir, "extracted")
+	if r := UnzipFile(map[string]any{"sourcePath": archive, "destPath": out}); r.Status != "completed" {
+		t.Fatalf("unzip failed: %s", r.Error)
+	}
+	files["single.txt"] = "one"
+	for rel, content := range files {
+		got, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(rel)))
+		if err != nil || string(got) != content {
+			t.Fatalf("%s = %q, %v; want %q", rel, got, err, content)
+		}
+	}
+	if r := UnzipFile(map[string]any{"sourcePath": archive, "destPath": out}); r.Status == "completed" {
+		t.Fatal("unzip over existing files without overwrite should fail")
+	}
+	if r := UnzipFile(map[string]any{"sourcePath": archive, "destPath": out, "overwrite": true}); r.Status != "completed" {
+		t.Fatalf("unzip with overwrite failed: %s", r.Error)
+	}
+}
+
+func TestUnzipRejectsZipSlip(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "evil.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("../escaped.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("pwned"))
+	zw.Close()
+	f.Close()
+
+	out := filepath.Join(dir, "out")
+	r := UnzipFile(map[string]any{"sourcePath": archive, "destPath": out})
+	if r.Status == "completed" || !strings.Contains(r.Error, "escapes") {
+		t.Fatalf("zip-slip archive = %q, %q", r.Status, r.Error)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped.txt")); !os.IsNotExist(err) {
+		t.Fatal("entry was written outside the destination")
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "h.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Hash      string `json:"hash"`
+		Algorithm string `json:"algorithm"`
+		Size      int64  `json:"size"`
+	}
+	decodeSuccessPayload(t, HashFile(map[string]any{"path": path}), &got)
+	if got.Algorithm != "sha256" || got.Size != 5 || got.Hash != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Fatalf("unexpected hash result: %+v", got)
+	}
+	decodeSuccessPayload(t, HashFile(map[string]any{"path": path, "algorithm": "MD5"}), &got)
+	if got.Hash != "5d41402abc4b2a76b9719d911017c592" {
+		t.Fatalf("md5 = %s", got.Hash)
+	}
+	if r := HashFile(map[string]any{"path": path, "algorithm": "crc32"}); r.Status == "completed" {
+		t.Fatal("unsupported algorithm should fail")
+	}
+	if r := HashFile(map[string]any{"path": filepath.Dir(path)}); r.Status == "completed" {
+		t.Fatal("hashing a directory should fail")
+	}
+}
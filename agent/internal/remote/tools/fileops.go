@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/breeze-rmm/agent/internal/remote/filedrop"
 )
 
 const (
@@ -185,6 +187,20 @@ func enforceReadContainment(cleanPath string) error {
 	return nil
 }
 
+// CheckReadPath applies the file_read containment and the file path policy
+// to a path, so other channels that hand out host files enforce the same
+// limits as the file manager.
+func CheckReadPath(cleanPath string) error {
+	if err := enforceReadContainment(cleanPath); err != nil {
+		return err
+	}
+	return checkFilePathPolicy(cleanPath)
+}
+
+func init() {
+	filedrop.SetDownloadPathCheck(CheckReadPath)
+}
+
 // ListDrives enumerates available drives/mount points.
 func ListDrives(_ map[string]any) CommandResult {
 	return listDrivesOS(time.Now())
@@ -211,6 +227,9 @@ func ListFiles(payload map[string]any) CommandResult {
 	if err := enforceReadContainment(cleanPath); err != nil {
 		return NewErrorResult(err, time.Since(start).Milliseconds())
 	}
+	if err := checkFilePathPolicy(cleanPath); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
 
 	limit := GetPayloadInt(payload, "limit", defaultFileListLimit)
 	if limit < 1 {
@@ -287,6 +306,9 @@ func ReadFile(payload map[string]any) CommandResult {
 	if err := enforceReadContainment(cleanPath); err != nil {
 		return NewErrorResult(err, time.Since(start).Milliseconds())
 	}
+	if err := checkFilePathPolicy(cleanPath); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
 
 	// Check file info first
 	info, err := os.Stat(cleanPath)
@@ -342,6 +364,9 @@ func WriteFile(payload map[string]any) CommandResult {
 	if isDeniedSystemPath(cleanPath) {
 		return NewErrorResult(fmt.Errorf("operation denied on system path: %s", cleanPath), time.Since(start).Milliseconds())
 	}
+	if err := checkFilePathPolicy(cleanPath); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
 
 	// Ensure parent directory exists
 	parentDir := filepath.Dir(cleanPath)
@@ -400,6 +425,9 @@ func DeleteFile(payload map[string]any) CommandResult {
 	if isDeniedSystemPath(cleanPath) {
 		return NewErrorResult(fmt.Errorf("operation denied on system path: %s", cleanPath), time.Since(start).Milliseconds())
 	}
+	if err := checkFilePathPolicy(cleanPath); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
 
 	// Block recursive deletes on any top-level directory (e.g. /home, /var, /opt)
 	if recursive {
@@ -592,6 +620,10 @@ func TrashRestore(payload map[string]any) CommandResult {
 
 	contentPath := filepath.Join(trashItemDir, "content")
 
+	if err := checkFilePathPolicy(meta.OriginalPath); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
 	// Check if something already exists at the original path to prevent silent overwrite
 	if _, existErr := os.Stat(meta.OriginalPath); existErr == nil {
 		return NewErrorResult(fmt.Errorf("cannot restore: path already exists: %s", meta.OriginalPath), time.Since(start).Milliseconds())
@@ -746,6 +778,9 @@ func MakeDirectory(payload map[string]any) CommandResult {
 	if isDeniedSystemPath(cleanPath) {
 		return NewErrorResult(fmt.Errorf("operation denied on system path: %s", cleanPath), time.Since(start).Milliseconds())
 	}
+	if err := checkFilePathPolicy(cleanPath); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
 
 	// Create directory and any necessary parents
 	if err := os.MkdirAll(cleanPath, 0755); err != nil {
@@ -783,6 +818,9 @@ func RenameFile(payload map[string]any) CommandResult {
 	if isDeniedSystemPath(cleanNewPath) {
 		return NewErrorResult(fmt.Errorf("operation denied on system path: %s", cleanNewPath), time.Since(start).Milliseconds())
 	}
+	if err := checkFilePathsPolicy(cleanOldPath, cleanNewPath); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
 
 	// Check if source exists
 	if _, err := os.Stat(cleanOldPath); err != nil {
@@ -835,6 +873,9 @@ func CopyFile(payload map[string]any) CommandResult {
 	if isDeniedSystemPath(cleanDst) {
 		return NewErrorResult(fmt.Errorf("operation denied on system path: %s", cleanDst), time.Since(start).Milliseconds())
 	}
+	if err := checkFilePathsPolicy(cleanSrc, cleanDst); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
 
 	// Check if source exists
 	info, err := os.Stat(cleanSrc)
@@ -171,7 +171,9 @@ func collectConnectionEvidence() ([]EvidenceConnectionInfo, error) {
 }
 
 // ExecuteContainment performs a containment action on the device.
-// Supported actions: "process_kill", "network_isolation", "account_disable", "usb_block".
+// Supported actions: "process_kill", "account_disable", "usb_block";
+// "network_isolation" and "network_isolation_release" are handled by the
+// heartbeat before reaching here.
 func ExecuteContainment(payload map[string]any) CommandResult {
 	startTime := time.Now()
 
@@ -185,9 +187,10 @@ func ExecuteContainment(payload map[string]any) CommandResult {
 	case "process_kill":
 		return executeProcessKill(payload, startTime)
 
-	case "network_isolation":
+	case "network_isolation", "network_isolation_release":
+		// Handled by the heartbeat, which knows the server to keep reachable.
 		return NewErrorResult(
-			fmt.Errorf("network_isolation requires platform-specific implementation (current: %s)", runtime.GOOS),
+			fmt.Errorf("%s must be run through the agent heartbeat", actionType),
 			time.Since(startTime).Milliseconds(),
 		)
 
@@ -40,9 +40,14 @@ func TestScheduledTaskCommands_NonWindowsUnsupported(t *testing.T) {
 func TestRegistryCommands_NonWindowsUnsupported(t *testing.T) {
 	expected := "registry is only supported on Windows"
 
+	// Writes are refused by policy before reaching the OS stub unless enabled.
+	SetRegistryWriteEnabled(true)
+	t.Cleanup(func() { SetRegistryWriteEnabled(false) })
+
 	assertFailedWithMessage(t, ListRegistryKeys(map[string]any{}), expected)
 	assertFailedWithMessage(t, ListRegistryValues(map[string]any{}), expected)
 	assertFailedWithMessage(t, GetRegistryValue(map[string]any{}), expected)
 	assertFailedWithMessage(t, SetRegistryValue(map[string]any{}), expected)
 	assertFailedWithMessage(t, DeleteRegistryValue(map[string]any{}), expected)
+	assertFailedWithMessage(t, ExportRegistryKey(map[string]any{}), expected)
 }
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// Process priority classes accepted by process_set_priority. On Windows they
+// are the scheduler priority classes; on Unix they map to nice values
+// (processNiceValues). Realtime is deliberately not offered: a runaway
+// realtime process can starve the agent and the rest of the system.
+const (
+	processPriorityIdle        = "idle"
+	processPriorityBelowNormal = "below_normal"
+	processPriorityNormal      = "normal"
+	processPriorityAboveNormal = "above_normal"
+	processPriorityHigh        = "high"
+)
+
+var processNiceValues = map[string]int{
+	processPriorityIdle:        19,
+	processPriorityBelowNormal: 10,
+	processPriorityNormal:      0,
+	processPriorityAboveNormal: -5,
+	processPriorityHigh:        -10,
+}
+
+func normalizeProcessPriority(value string) (string, error) {
+	p := strings.ToLower(strings.TrimSpace(value))
+	p = strings.ReplaceAll(strings.ReplaceAll(p, "-", "_"), " ", "_")
+	if _, ok := processNiceValues[p]; ok {
+		return p, nil
+	}
+	if p == "realtime" {
+		return "", fmt.Errorf("realtime priority is not allowed")
+	}
+	return "", fmt.Errorf("priority must be idle, below_normal, normal, above_normal or high")
+}
+
+// SetProcessPriority changes the scheduling priority of a process by PID.
+func SetProcessPriority(payload map[string]any) CommandResult {
+	startTime := time.Now()
+
+	pid := GetPayloadInt(payload, "pid", 0)
+	if pid <= 0 {
+		return NewErrorResult(fmt.Errorf("pid is required"), time.Since(startTime).Milliseconds())
+	}
+	priority, err := normalizeProcessPriority(GetPayloadString(payload, "priority", ""))
+	if err != nil {
+		return NewErrorResult(err, time.Since(startTime).Milliseconds())
+	}
+
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("process not found: %w", err), time.Since(startTime).Milliseconds())
+	}
+	name, _ := p.Name()
+
+	if err := setProcessPriorityOS(int32(pid), priority); err != nil {
+		return NewErrorResult(fmt.Errorf("failed to set priority of process %d (%s): %w", pid, name, err), time.Since(startTime).Milliseconds())
+	}
+
+	return NewSuccessResult(map[string]any{
+		"pid":      pid,
+		"name":     name,
+		"priority": priority,
+		"success":  true,
+	}, time.Since(startTime).Milliseconds())
+}
@@ -0,0 +1,29 @@
+package tools
+
+import "testing"
+
+func TestNormalizeProcessPriority(t *testing.T) {
+	for in, want := range map[string]string{
+		"idle":         processPriorityIdle,
+		"Below Normal": processPriorityBelowNormal,
+		"above-normal": processPriorityAboveNormal,
+		" HIGH ":       processPriorityHigh,
+	} {
+		got, err := normalizeProcessPriority(in)
+		if err != nil || got != want {
+			t.Errorf("normalizeProcessPriority(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"realtime", "", "urgent"} {
+		if _, err := normalizeProcessPriority(in); err == nil {
+			t.Errorf("normalizeProcessPriority(%q) accepted", in)
+		}
+	}
+}
+
+func TestSetProcessPriorityRequiresPID(t *testing.T) {
+	result := SetProcessPriority(map[string]any{"priority": "normal"})
+	if result.Status != "failed" {
+		t.Fatalf("status = %q, want failed without pid", result.Status)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package tools
+
+import "syscall"
+
+func setProcessPriorityOS(pid int32, priority string) error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, int(pid), processNiceValues[priority])
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package tools
+
+import "golang.org/x/sys/windows"
+
+var processPriorityClasses = map[string]uint32{
+	processPriorityIdle:        windows.IDLE_PRIORITY_CLASS,
+	processPriorityBelowNormal: windows.BELOW_NORMAL_PRIORITY_CLASS,
+	processPriorityNormal:      windows.NORMAL_PRIORITY_CLASS,
+	processPriorityAboveNormal: windows.ABOVE_NORMAL_PRIORITY_CLASS,
+	processPriorityHigh:        windows.HIGH_PRIORITY_CLASS,
+}
+
+func setProcessPriorityOS(pid int32, priority string) error {
+	h, err := windows.OpenProcess(windows.PROCESS_SET_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(h)
+	return windows.SetPriorityClass(h, processPriorityClasses[priority])
+}
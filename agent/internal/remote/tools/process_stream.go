@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// ProcessTableSampler produces the frames of a live process-table stream
+// (the console's Task Manager view). It keeps each process's CPU-time counter
+// from the previous sample, so CPU% is measured over the stream interval with
+// no extra sleep, and it caches names and users so the slow username lookup
+// (see ListProcesses) runs once per process rather than once per frame.
+//
+// A sampler is not safe for concurrent use; each stream owns one.
+type ProcessTableSampler struct {
+	prev    map[int32]sampledProcess
+	prevSys cpu.TimesStat
+	last    time.Time
+	seq     uint64
+}
+
+type sampledProcess struct {
+	created    int64
+	cpuSeconds float64
+	row        ProcessRow
+}
+
+// NewProcessTableSampler returns a sampler with no baseline; call Prime
+// before the first Sample so that frame carries real CPU figures.
+func NewProcessTableSampler() *ProcessTableSampler {
+	return &ProcessTableSampler{}
+}
+
+// Prime records the CPU-time baseline without producing a frame.
+func (s *ProcessTableSampler) Prime(now time.Time) error {
+	_, err := s.collect(now)
+	return err
+}
+
+// Sample reads the process table and returns the frame relative to the
+// previous sample. The first frame, and any frame with full set, lists every
+// process.
+func (s *ProcessTableSampler) Sample(now time.Time, full bool) (*ProcessTableSnapshot, error) {
+	first := s.seq == 0
+	prevRows := make(map[int32]ProcessRow, len(s.prev))
+	for pid, sp := range s.prev {
+		prevRows[pid] = sp.row
+	}
+
+	sysCPU, err := s.collect(now)
+	if err != nil {
+		return nil, err
+	}
+
+	curRows := make(map[int32]ProcessRow, len(s.prev))
+	for pid, sp := range s.prev {
+		curRows[pid] = sp.row
+	}
+
+	full = full || first
+	upserts, removed := diffProcessRows(prevRows, curRows, full)
+
+	s.seq++
+	snap := &ProcessTableSnapshot{
+		Seq:        s.seq,
+		Full:       full,
+		Timestamp:  now.UnixMilli(),
+		Total:      len(curRows),
+		CPUPercent: sysCPU,
+		Upserts:    upserts,
+		Removed:    removed,
+	}
+	if vm, err := mem.VirtualMemory(); err == nil && vm != nil {
+		snap.MemoryUsedMB = roundTenth(float64(vm.Used) / 1024 / 1024)
+		snap.MemoryTotalMB = roundTenth(float64(vm.Total) / 1024 / 1024)
+	}
+	return snap, nil
+}
+
+// collect reads every process into s.prev and returns system-wide CPU% since
+// the previous collect (0 on the first).
+func (s *ProcessTableSampler) collect(now time.Time) (float64, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return 0, err
+	}
+
+	elapsed := now.Sub(s.last)
+	cur := make(map[int32]sampledProcess, len(procs))
+	for _, p := range procs {
+		created, _ := p.CreateTime()
+		prev, seen := s.prev[p.Pid]
+		if seen && prev.created != created {
+			// PID reused by a new process.
+			seen = false
+		}
+
+		row := ProcessRow{PID: p.Pid}
+		if seen {
+			row.Name, row.User = prev.row.Name, prev.row.User
+		} else {
+			name, err := p.Name()
+			if err != nil {
+				continue
+			}
+			row.Name, _ = truncateStringBytes(name, maxProcessFieldBytes)
+			row.User, _ = truncateStringBytes(resolveUsername(p), maxProcessFieldBytes)
+		}
+
+		sp := sampledProcess{created: created, row: row}
+		if ts, err := p.Times(); err == nil {
+			sp.cpuSeconds = cpuSeconds(ts)
+			if seen && !s.last.IsZero() {
+				sp.row.CPUPercent = roundTenth(cpuPercentFromCPUSeconds(prev.cpuSeconds, sp.cpuSeconds, elapsed))
+			}
+		}
+		if memInfo, err := p.MemoryInfo(); err == nil && memInfo != nil {
+			sp.row.MemoryMB = roundTenth(float64(memInfo.RSS) / 1024 / 1024)
+		}
+		cur[p.Pid] = sp
+	}
+
+	var sysCPU float64
+	if times, err := cpu.Times(false); err == nil && len(times) > 0 {
+		if !s.last.IsZero() {
+			sysCPU = roundTenth(systemCPUPercent(s.prevSys, times[0]))
+		}
+		s.prevSys = times[0]
+	}
+
+	s.prev = cur
+	s.last = now
+	return sysCPU, nil
+}
+
+// diffProcessRows returns the rows to send for cur given the previously sent
+// prev: every row when full, otherwise the new and changed ones, plus the
+// PIDs that are gone. Both lists are sorted by PID.
+func diffProcessRows(prev, cur map[int32]ProcessRow, full bool) ([]ProcessRow, []int32) {
+	upserts := make([]ProcessRow, 0, len(cur))
+	for pid, row := range cur {
+		if old, ok := prev[pid]; full || !ok || old != row {
+			upserts = append(upserts, row)
+		}
+	}
+	sort.Slice(upserts, func(i, j int) bool { return upserts[i].PID < upserts[j].PID })
+
+	var removed []int32
+	for pid := range prev {
+		if _, ok := cur[pid]; !ok {
+			removed = append(removed, pid)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i] < removed[j] })
+	return upserts, removed
+}
+
+// systemCPUPercent is the busy share of all cores between two cumulative
+// CPU-time readings. Idle and iowait count as not busy.
+func systemCPUPercent(prev, cur cpu.TimesStat) float64 {
+	idle := (cur.Idle + cur.Iowait) - (prev.Idle + prev.Iowait)
+	total := cur.Total() - prev.Total()
+	if total <= 0 {
+		return 0
+	}
+	busy := total - idle
+	if busy < 0 {
+		busy = 0
+	}
+	return 100 * busy / total
+}
+
+// roundTenth rounds to one decimal place so small jitter does not turn every
+// row into a delta.
+func roundTenth(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+func TestDiffProcessRows(t *testing.T) {
+	prev := map[int32]ProcessRow{
+		1: {PID: 1, Name: "init", CPUPercent: 0.1, MemoryMB: 10},
+		2: {PID: 2, Name: "worker", CPUPercent: 5, MemoryMB: 100},
+		3: {PID: 3, Name: "gone", MemoryMB: 1},
+	}
+	cur := map[int32]ProcessRow{
+		1: {PID: 1, Name: "init", CPUPercent: 0.1, MemoryMB: 10},
+		2: {PID: 2, Name: "worker", CPUPercent: 7.5, MemoryMB: 100},
+		4: {PID: 4, Name: "new", MemoryMB: 2},
+	}
+
+	upserts, removed := diffProcessRows(prev, cur, false)
+	var pids []int32
+	for _, r := range upserts {
+		pids = append(pids, r.PID)
+	}
+	if !reflect.DeepEqual(pids, []int32{2, 4}) {
+		t.Fatalf("delta upserts = %v, want changed and new rows [2 4]", pids)
+	}
+	if !reflect.DeepEqual(removed, []int32{3}) {
+		t.Fatalf("removed = %v, want [3]", removed)
+	}
+
+	upserts, removed = diffProcessRows(prev, cur, true)
+	if len(upserts) != 3 || upserts[0].PID != 1 {
+		t.Fatalf("full frame upserts = %+v, want all 3 rows sorted by pid", upserts)
+	}
+	if !reflect.DeepEqual(removed, []int32{3}) {
+		t.Fatalf("full frame removed = %v, want [3]", removed)
+	}
+}
+
+func TestSystemCPUPercent(t *testing.T) {
+	prev := cpu.TimesStat{User: 100, System: 50, Idle: 800, Iowait: 50}
+	cur := cpu.TimesStat{User: 130, System: 60, Idle: 850, Iowait: 60}
+	// 100s elapsed across all cores, 60 of them idle or iowait.
+	if got := systemCPUPercent(prev, cur); got != 40 {
+		t.Fatalf("systemCPUPercent = %v, want 40", got)
+	}
+	if got := systemCPUPercent(cur, cur); got != 0 {
+		t.Fatalf("systemCPUPercent with no elapsed time = %v, want 0", got)
+	}
+}
+
+func TestProcessTableSamplerFirstFrameIsFull(t *testing.T) {
+	s := NewProcessTableSampler()
+	now := time.Now()
+	if err := s.Prime(now); err != nil {
+		t.Fatalf("Prime: %v", err)
+	}
+	snap, err := s.Sample(now.Add(time.Second), false)
+	if err != nil {
+		t.Fatalf("Sample: %v", err)
+	}
+	if !snap.Full || snap.Seq != 1 {
+		t.Fatalf("first frame: full=%v seq=%d, want full seq 1", snap.Full, snap.Seq)
+	}
+	if snap.Total != len(snap.Upserts) {
+		t.Fatalf("full frame total %d != %d upserts", snap.Total, len(snap.Upserts))
+	}
+	self := int32(os.Getpid())
+	found := false
+	for _, r := range snap.Upserts {
+		if r.PID == self {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("own pid %d missing from full frame", self)
+	}
+
+	next, err := s.Sample(now.Add(2*time.Second), false)
+	if err != nil {
+		t.Fatalf("Sample: %v", err)
+	}
+	if next.Full || next.Seq != 2 {
+		t.Fatalf("second frame: full=%v seq=%d, want delta seq 2", next.Full, next.Seq)
+	}
+}
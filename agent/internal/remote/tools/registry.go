@@ -1,9 +1,29 @@
 package tools
 
 import (
+	"errors"
+	"sync/atomic"
 	"time"
 )
 
+// registryWriteEnabled gates the commands that change the registry
+// (registry_set, registry_delete, registry_key_create, registry_key_delete).
+// It is off until the organization's policy turns it on
+// (registry_write_enabled); reads and exports are always allowed.
+var registryWriteEnabled atomic.Bool
+
+var errRegistryWriteDisabled = errors.New("registry editing is disabled by policy")
+
+// SetRegistryWriteEnabled turns the registry write commands on or off.
+func SetRegistryWriteEnabled(on bool) {
+	registryWriteEnabled.Store(on)
+}
+
+// RegistryWriteEnabled reports whether the registry write commands may run.
+func RegistryWriteEnabled() bool {
+	return registryWriteEnabled.Load()
+}
+
 // ListRegistryKeys returns subkeys at a registry path
 func ListRegistryKeys(payload map[string]any) CommandResult {
 	startTime := time.Now()
@@ -38,6 +58,9 @@ func GetRegistryValue(payload map[string]any) CommandResult {
 // SetRegistryValue sets a registry value
 func SetRegistryValue(payload map[string]any) CommandResult {
 	startTime := time.Now()
+	if !registryWriteEnabled.Load() {
+		return NewErrorResult(errRegistryWriteDisabled, time.Since(startTime).Milliseconds())
+	}
 
 	hive := GetPayloadString(payload, "hive", "HKLM")
 	path := GetPayloadString(payload, "path", "")
@@ -51,6 +74,9 @@ func SetRegistryValue(payload map[string]any) CommandResult {
 // DeleteRegistryValue deletes a registry value
 func DeleteRegistryValue(payload map[string]any) CommandResult {
 	startTime := time.Now()
+	if !registryWriteEnabled.Load() {
+		return NewErrorResult(errRegistryWriteDisabled, time.Since(startTime).Milliseconds())
+	}
 
 	hive := GetPayloadString(payload, "hive", "HKLM")
 	path := GetPayloadString(payload, "path", "")
@@ -62,6 +88,9 @@ func DeleteRegistryValue(payload map[string]any) CommandResult {
 // CreateRegistryKey creates a registry key
 func CreateRegistryKey(payload map[string]any) CommandResult {
 	startTime := time.Now()
+	if !registryWriteEnabled.Load() {
+		return NewErrorResult(errRegistryWriteDisabled, time.Since(startTime).Milliseconds())
+	}
 
 	hive := GetPayloadString(payload, "hive", "HKLM")
 	path := GetPayloadString(payload, "path", "")
@@ -72,9 +101,25 @@ func CreateRegistryKey(payload map[string]any) CommandResult {
 // DeleteRegistryKey deletes a registry key
 func DeleteRegistryKey(payload map[string]any) CommandResult {
 	startTime := time.Now()
+	if !registryWriteEnabled.Load() {
+		return NewErrorResult(errRegistryWriteDisabled, time.Since(startTime).Milliseconds())
+	}
 
 	hive := GetPayloadString(payload, "hive", "HKLM")
 	path := GetPayloadString(payload, "path", "")
 
 	return deleteRegistryKeyOS(hive, path, startTime)
 }
+
+// ExportRegistryKey renders a key, and by default its subkeys, in regedit's
+// .reg format so a technician can back it up before a change or re-import it
+// elsewhere.
+func ExportRegistryKey(payload map[string]any) CommandResult {
+	startTime := time.Now()
+
+	hive := GetPayloadString(payload, "hive", "HKLM")
+	path := GetPayloadString(payload, "path", "")
+	recursive := GetPayloadBool(payload, "recursive", true)
+
+	return exportRegistryKeyOS(hive, path, recursive, startTime)
+}
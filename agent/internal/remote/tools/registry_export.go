@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// regExportHeader is the first line regedit writes and expects on import.
+const regExportHeader = "Windows Registry Editor Version 5.00\r\n"
+
+// Export limits. The .reg text travels JSON-escaped in the command result,
+// which the API caps at 5 MB.
+const (
+	maxRegistryExportBytes = 2 * 1024 * 1024
+	maxRegistryExportKeys  = 10000
+	maxRegistryExportDepth = 64
+	// regExportLineWidth is where regedit wraps long hex values.
+	regExportLineWidth = 80
+)
+
+// Registry value types (winnt.h), duplicated from x/sys/windows/registry so
+// the .reg formatter builds and is tested on every platform.
+const (
+	regSZ       = 1
+	regExpandSZ = 2
+	regBinary   = 3
+	regDWORD    = 4
+	regMultiSZ  = 7
+	regQWORD    = 11
+)
+
+// registryHiveLongName returns the root key name regedit uses in .reg files.
+func registryHiveLongName(hive string) string {
+	switch strings.ToUpper(hive) {
+	case "HKLM", "HKEY_LOCAL_MACHINE":
+		return "HKEY_LOCAL_MACHINE"
+	case "HKCU", "HKEY_CURRENT_USER":
+		return "HKEY_CURRENT_USER"
+	case "HKCR", "HKEY_CLASSES_ROOT":
+		return "HKEY_CLASSES_ROOT"
+	case "HKU", "HKEY_USERS":
+		return "HKEY_USERS"
+	case "HKCC", "HKEY_CURRENT_CONFIG":
+		return "HKEY_CURRENT_CONFIG"
+	}
+	return hive
+}
+
+// regExportWriter accumulates .reg text up to the export limits.
+type regExportWriter struct {
+	b         strings.Builder
+	keys      int
+	values    int
+	truncated bool
+}
+
+func newRegExportWriter() *regExportWriter {
+	w := &regExportWriter{}
+	w.b.WriteString(regExportHeader)
+	return w
+}
+
+// full reports whether the export hit a limit; callers stop walking.
+func (w *regExportWriter) full() bool {
+	return w.truncated
+}
+
+func (w *regExportWriter) write(s string) bool {
+	if w.truncated || w.b.Len()+len(s) > maxRegistryExportBytes {
+		w.truncated = true
+		return false
+	}
+	w.b.WriteString(s)
+	return true
+}
+
+// beginKey starts the section for a key, given its full path including the
+// hive. It returns false once the export is full.
+func (w *regExportWriter) beginKey(fullPath string) bool {
+	if w.keys >= maxRegistryExportKeys {
+		w.truncated = true
+		return false
+	}
+	if !w.write("\r\n[" + fullPath + "]\r\n") {
+		return false
+	}
+	w.keys++
+	return true
+}
+
+func (w *regExportWriter) value(name string, valType uint32, data []byte) {
+	if w.write(formatRegExportValue(name, valType, data)) {
+		w.values++
+	}
+}
+
+func (w *regExportWriter) String() string {
+	return w.b.String() + "\r\n"
+}
+
+// formatRegExportValue renders one value line (with a trailing CRLF) the way
+// regedit exports it: strings quoted, DWORDs as dword:, everything else as
+// comma-separated hex bytes wrapped with backslash continuations.
+func formatRegExportValue(name string, valType uint32, data []byte) string {
+	lhs := "@="
+	if name != "" {
+		lhs = `"` + escapeRegString(name) + `"=`
+	}
+
+	switch valType {
+	case regSZ:
+		// A string regedit cannot quote (embedded line breaks or NULs)
+		// falls through to the hex(1) form, which round-trips exactly.
+		if s, ok := regStringData(data); ok {
+			return lhs + `"` + escapeRegString(s) + "\"\r\n"
+		}
+	case regDWORD:
+		if len(data) == 4 {
+			return lhs + fmt.Sprintf("dword:%08x\r\n", binary.LittleEndian.Uint32(data))
+		}
+	}
+
+	prefix := fmt.Sprintf("hex(%x):", valType)
+	if valType == regBinary {
+		prefix = "hex:"
+	}
+	return wrapRegHex(lhs+prefix, data)
+}
+
+// regStringData decodes a REG_SZ buffer, reporting false when the text
+// cannot be written as a quoted .reg string.
+func regStringData(data []byte) (string, bool) {
+	if len(data)%2 != 0 {
+		return "", false
+	}
+	u16 := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		u16 = append(u16, binary.LittleEndian.Uint16(data[i:]))
+	}
+	for len(u16) > 0 && u16[len(u16)-1] == 0 {
+		u16 = u16[:len(u16)-1]
+	}
+	s := string(utf16.Decode(u16))
+	if strings.ContainsAny(s, "\r\n\x00") {
+		return "", false
+	}
+	return s, true
+}
+
+func escapeRegString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
+
+// wrapRegHex appends data as hex bytes to head, continuing onto indented
+// lines ending in a backslash when a line would pass regExportLineWidth.
+func wrapRegHex(head string, data []byte) string {
+	var b strings.Builder
+	line := head
+	for i, c := range data {
+		piece := fmt.Sprintf("%02x", c)
+		if i < len(data)-1 {
+			piece += ","
+		}
+		// Leave room for the trailing backslash.
+		if len(line)+len(piece) > regExportLineWidth-1 {
+			b.WriteString(line + "\\\r\n")
+			line = "  "
+		}
+		line += piece
+	}
+	b.WriteString(line + "\r\n")
+	return b.String()
+}
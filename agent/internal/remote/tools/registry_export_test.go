@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func regUTF16(s string) []byte {
+	u := utf16.Encode([]rune(s + "\x00"))
+	b := make([]byte, 2*len(u))
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(b[2*i:], c)
+	}
+	return b
+}
+
+func TestFormatRegExportValue(t *testing.T) {
+	dword := make([]byte, 4)
+	binary.LittleEndian.PutUint32(dword, 0x2a)
+	qword := make([]byte, 8)
+	binary.LittleEndian.PutUint64(qword, 1)
+
+	for _, tc := range []struct {
+		name    string
+		valType uint32
+		data    []byte
+		want    string
+	}{
+		{"", regSZ, regUTF16(`C:\Program Files\Breeze`), "@=\"C:\\\\Program Files\\\\Breeze\"\r\n"},
+		{`Say "hi"`, regSZ, regUTF16("x"), "\"Say \\\"hi\\\"\"=\"x\"\r\n"},
+		{"Count", regDWORD, dword, "\"Count\"=dword:0000002a\r\n"},
+		{"Blob", regBinary, []byte{0x00, 0xff, 0x10}, "\"Blob\"=hex:00,ff,10\r\n"},
+		{"Big", regQWORD, qword, "\"Big\"=hex(b):01,00,00,00,00,00,00,00\r\n"},
+		{"Path", regExpandSZ, regUTF16("%a%"), "\"Path\"=hex(2):25,00,61,00,25,00,00,00\r\n"},
+		{"Empty", regMultiSZ, nil, "\"Empty\"=hex(7):\r\n"},
+		// Line breaks cannot be quoted, so the value is written as hex(1).
+		{"Multi", regSZ, regUTF16("a\nb"), "\"Multi\"=hex(1):61,00,0a,00,62,00,00,00\r\n"},
+	} {
+		if got := formatRegExportValue(tc.name, tc.valType, tc.data); got != tc.want {
+			t.Errorf("formatRegExportValue(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFormatRegExportValueWrapsLongHex(t *testing.T) {
+	got := formatRegExportValue("Blob", regBinary, make([]byte, 64))
+	lines := strings.Split(strings.TrimSuffix(got, "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("64 bytes should wrap, got %q", got)
+	}
+	for i, line := range lines {
+		if len(line) > regExportLineWidth {
+			t.Errorf("line %d is %d chars: %q", i, len(line), line)
+		}
+		if i < len(lines)-1 && !strings.HasSuffix(line, ",\\") {
+			t.Errorf("continued line %d must end with ,\\: %q", i, line)
+		}
+		if i > 0 && !strings.HasPrefix(line, "  ") {
+			t.Errorf("continuation line %d must be indented: %q", i, line)
+		}
+	}
+	joined := strings.NewReplacer("\\\r\n  ", "").Replace(got)
+	if strings.Count(joined, "00") != 64 {
+		t.Fatalf("wrapped value lost bytes: %q", joined)
+	}
+}
+
+func TestRegExportWriterLimits(t *testing.T) {
+	w := newRegExportWriter()
+	if !w.beginKey(`HKEY_LOCAL_MACHINE\SOFTWARE\Breeze`) {
+		t.Fatal("first key refused")
+	}
+	w.value("Version", regSZ, regUTF16("1.0"))
+	out := w.String()
+	if !strings.HasPrefix(out, regExportHeader+"\r\n[HKEY_LOCAL_MACHINE\\SOFTWARE\\Breeze]\r\n\"Version\"=\"1.0\"\r\n") {
+		t.Fatalf("unexpected export: %q", out)
+	}
+	if w.keys != 1 || w.values != 1 || w.truncated {
+		t.Fatalf("keys=%d values=%d truncated=%v", w.keys, w.values, w.truncated)
+	}
+
+	w.value("Huge", regBinary, make([]byte, maxRegistryExportBytes))
+	if !w.full() || w.values != 1 {
+		t.Fatalf("oversized value should truncate the export (values=%d)", w.values)
+	}
+	if w.beginKey(`HKEY_LOCAL_MACHINE\SOFTWARE\Other`) {
+		t.Fatal("a full export must not accept more keys")
+	}
+}
+
+func TestRegistryHiveLongName(t *testing.T) {
+	if got := registryHiveLongName("hkcu"); got != "HKEY_CURRENT_USER" {
+		t.Fatalf("hkcu -> %q", got)
+	}
+	if got := registryHiveLongName("HKEY_USERS"); got != "HKEY_USERS" {
+		t.Fatalf("HKEY_USERS -> %q", got)
+	}
+}
+
+func TestRegistryWritesRefusedUntilEnabled(t *testing.T) {
+	t.Cleanup(func() { SetRegistryWriteEnabled(false) })
+	SetRegistryWriteEnabled(false)
+
+	payload := map[string]any{"hive": "HKLM", "path": `SOFTWARE\Breeze`, "name": "x", "data": "1"}
+	for name, fn := range map[string]func(map[string]any) CommandResult{
+		"set":        SetRegistryValue,
+		"delete":     DeleteRegistryValue,
+		"create key": CreateRegistryKey,
+		"delete key": DeleteRegistryKey,
+	} {
+		result := fn(payload)
+		if result.Status != "failed" || result.Error != errRegistryWriteDisabled.Error() {
+			t.Errorf("%s while disabled: status=%q error=%q", name, result.Status, result.Error)
+		}
+	}
+
+	SetRegistryWriteEnabled(true)
+	if result := SetRegistryValue(payload); result.Error == errRegistryWriteDisabled.Error() {
+		t.Fatal("set still refused after enabling")
+	}
+}
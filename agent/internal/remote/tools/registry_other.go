@@ -55,3 +55,10 @@ func deleteRegistryKeyOS(hive, path string, startTime time.Time) CommandResult {
 		time.Since(startTime).Milliseconds(),
 	)
 }
+
+func exportRegistryKeyOS(hive, path string, recursive bool, startTime time.Time) CommandResult {
+	return NewErrorResult(
+		fmt.Errorf("registry is only supported on Windows"),
+		time.Since(startTime).Milliseconds(),
+	)
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"time"
 	"unicode/utf16"
@@ -166,12 +167,14 @@ func getRegistryValueOS(hive, path, name string, startTime time.Time) CommandRes
 }
 
 func setRegistryValueOS(hive, path, name, valueType, data string, startTime time.Time) CommandResult {
-	key, err := openRegistryKey(hive, path, registry.SET_VALUE)
+	key, err := openRegistryKey(hive, path, registry.SET_VALUE|registry.QUERY_VALUE)
 	if err != nil {
 		return NewErrorResult(err, time.Since(startTime).Milliseconds())
 	}
 	defer key.Close()
 
+	previous := readPreviousRegistryValue(key, name)
+
 	switch valueType {
 	case "REG_SZ":
 		err = key.SetStringValue(name, data)
@@ -223,17 +226,22 @@ func setRegistryValueOS(hive, path, name, valueType, data string, startTime time
 		"data":    data,
 		"success": true,
 	}
+	if previous != nil {
+		result["previous"] = previous
+	}
 
 	return NewSuccessResult(result, time.Since(startTime).Milliseconds())
 }
 
 func deleteRegistryValueOS(hive, path, name string, startTime time.Time) CommandResult {
-	key, err := openRegistryKey(hive, path, registry.SET_VALUE)
+	key, err := openRegistryKey(hive, path, registry.SET_VALUE|registry.QUERY_VALUE)
 	if err != nil {
 		return NewErrorResult(err, time.Since(startTime).Milliseconds())
 	}
 	defer key.Close()
 
+	previous := readPreviousRegistryValue(key, name)
+
 	err = key.DeleteValue(name)
 	if err != nil {
 		return NewErrorResult(fmt.Errorf("failed to delete value: %w", err), time.Since(startTime).Milliseconds())
@@ -243,6 +251,9 @@ func deleteRegistryValueOS(hive, path, name string, startTime time.Time) Command
 		"name":    name,
 		"deleted": true,
 	}
+	if previous != nil {
+		result["previous"] = previous
+	}
 
 	return NewSuccessResult(result, time.Since(startTime).Milliseconds())
 }
@@ -293,6 +304,119 @@ func deleteRegistryKeyOS(hive, path string, startTime time.Time) CommandResult {
 	return NewSuccessResult(result, time.Since(startTime).Milliseconds())
 }
 
+// readPreviousRegistryValue returns the value about to be overwritten or
+// deleted, so the result (and the audit log) records what it was. Nil when
+// the value did not exist or is too large to read.
+func readPreviousRegistryValue(key registry.Key, name string) *RegistryValue {
+	size, _, err := key.GetValue(name, nil)
+	if err != nil || size > maxRegistryValueReadBytes {
+		return nil
+	}
+	buf := make([]byte, size)
+	n, valType, err := key.GetValue(name, buf)
+	if err != nil {
+		return nil
+	}
+	value := RegistryValue{Name: name, Type: typeToString(valType), Data: formatValue(buf[:n], valType)}
+	if sanitized, _ := sanitizeRegistryValues([]RegistryValue{value}); len(sanitized) == 1 {
+		value = sanitized[0]
+	}
+	return &value
+}
+
+func exportRegistryKeyOS(hive, path string, recursive bool, startTime time.Time) CommandResult {
+	root, err := resolveRegistryRoot(hive)
+	if err != nil {
+		return NewErrorResult(err, time.Since(startTime).Milliseconds())
+	}
+	path = strings.Trim(path, "\\")
+
+	w := newRegExportWriter()
+	if err := exportRegistryKeyTree(w, root, registryHiveLongName(hive), path, recursive, 0); err != nil {
+		return NewErrorResult(err, time.Since(startTime).Milliseconds())
+	}
+
+	return NewSuccessResult(RegistryExportResponse{
+		Hive:      hive,
+		Path:      path,
+		Recursive: recursive,
+		Content:   w.String(),
+		Keys:      w.keys,
+		Values:    w.values,
+		Truncated: w.truncated,
+	}, time.Since(startTime).Milliseconds())
+}
+
+// exportRegistryKeyTree writes a key's values, then its subkeys depth-first
+// in name order. Subkeys the agent cannot open are skipped, as regedit does;
+// only the requested key itself failing is an error.
+func exportRegistryKeyTree(w *regExportWriter, root registry.Key, hiveName, path string, recursive bool, depth int) error {
+	key, err := registry.OpenKey(root, path, registry.ENUMERATE_SUB_KEYS|registry.QUERY_VALUE)
+	if err != nil {
+		if depth == 0 {
+			return fmt.Errorf("failed to open key: %w", err)
+		}
+		return nil
+	}
+	defer key.Close()
+
+	fullPath := hiveName
+	if path != "" {
+		fullPath += "\\" + path
+	}
+	if !w.beginKey(fullPath) {
+		return nil
+	}
+
+	names, err := key.ReadValueNames(0)
+	if err != nil && err != io.EOF && depth == 0 {
+		return fmt.Errorf("failed to read values: %w", err)
+	}
+	sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+	for _, name := range names {
+		size, _, err := key.GetValue(name, nil)
+		if err != nil {
+			continue
+		}
+		if size > maxRegistryExportBytes {
+			w.truncated = true
+			continue
+		}
+		buf := make([]byte, size)
+		n, valType, err := key.GetValue(name, buf)
+		if err != nil {
+			continue
+		}
+		w.value(name, valType, buf[:n])
+		if w.full() {
+			return nil
+		}
+	}
+
+	if !recursive {
+		return nil
+	}
+	if depth >= maxRegistryExportDepth {
+		w.truncated = true
+		return nil
+	}
+	subkeys, _ := key.ReadSubKeyNames(0)
+	sort.Slice(subkeys, func(i, j int) bool { return strings.ToLower(subkeys[i]) < strings.ToLower(subkeys[j]) })
+	for _, sub := range subkeys {
+		childPath := sub
+		if path != "" {
+			childPath = path + "\\" + sub
+		}
+		if err := exportRegistryKeyTree(w, root, hiveName, childPath, true, depth+1); err != nil {
+			return err
+		}
+		if w.full() {
+			return nil
+		}
+	}
+	return nil
+}
+
 func openRegistryKey(hive, path string, access uint32) (registry.Key, error) {
 	root, err := resolveRegistryRoot(hive)
 	if err != nil {
@@ -6,6 +6,26 @@ import (
 	"time"
 )
 
+// Service startup types, as reported in ServiceInfo.StartupType and accepted
+// by service_set_startup.
+const (
+	serviceStartupAutomatic = "Automatic"
+	serviceStartupManual    = "Manual"
+	serviceStartupDisabled  = "Disabled"
+)
+
+func normalizeServiceStartupType(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "automatic", "auto":
+		return serviceStartupAutomatic, nil
+	case "manual", "demand":
+		return serviceStartupManual, nil
+	case "disabled":
+		return serviceStartupDisabled, nil
+	}
+	return "", fmt.Errorf("startupType must be automatic, manual or disabled")
+}
+
 func validateServiceName(name string) (string, error) {
 	trimmed := strings.TrimSpace(name)
 	if trimmed == "" {
@@ -178,3 +198,48 @@ func RestartService(payload map[string]any) CommandResult {
 
 	return NewSuccessResult(result, time.Since(startTime).Milliseconds())
 }
+
+// SetServiceStartup changes whether a service starts at boot
+// ("startupType": automatic, manual or disabled).
+func SetServiceStartup(payload map[string]any) CommandResult {
+	startTime := time.Now()
+
+	name := GetPayloadString(payload, "name", "")
+	var err error
+	name, err = validateServiceName(name)
+	if err != nil {
+		return NewErrorResult(err, time.Since(startTime).Milliseconds())
+	}
+	startupType, err := normalizeServiceStartupType(GetPayloadString(payload, "startupType", ""))
+	if err != nil {
+		return NewErrorResult(err, time.Since(startTime).Milliseconds())
+	}
+
+	if isAgentService(name) && startupType != serviceStartupAutomatic {
+		return NewErrorResult(
+			fmt.Errorf("cannot change the Breeze agent service away from automatic startup — the device would not reconnect after a reboot"),
+			time.Since(startTime).Milliseconds(),
+		)
+	}
+
+	previous := ""
+	if service, err := getServiceOS(name); err == nil {
+		previous = service.StartupType
+	}
+
+	if err := setServiceStartupOS(name, startupType); err != nil {
+		return NewErrorResult(err, time.Since(startTime).Milliseconds())
+	}
+
+	result := map[string]any{
+		"name":        name,
+		"action":      "set_startup",
+		"startupType": startupType,
+		"success":     true,
+	}
+	if previous != "" {
+		result["previousStartupType"] = previous
+	}
+
+	return NewSuccessResult(result, time.Since(startTime).Milliseconds())
+}
@@ -130,3 +130,20 @@ func restartServiceOS(name string) error {
 	}
 	return startServiceOS(name)
 }
+
+// setServiceStartupOS enables or disables a launchd job in the system
+// domain. launchd has no manual startup mode: a loaded, enabled job follows
+// its RunAtLoad/KeepAlive keys.
+func setServiceStartupOS(name, startupType string) error {
+	verb := "enable"
+	switch startupType {
+	case serviceStartupDisabled:
+		verb = "disable"
+	case serviceStartupManual:
+		return fmt.Errorf("launchd does not support manual startup; use automatic or disabled")
+	}
+	if output, err := exec.Command("launchctl", verb, "system/"+name).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set service startup (launchctl %s): %w: %s", verb, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -135,17 +135,42 @@ func restartServiceOS(name string) error {
 func getServiceStartType(name string) string {
 	cmd := exec.Command("systemctl", "is-enabled", name+".service")
 	output, _ := cmd.Output()
-	result := strings.TrimSpace(string(output))
-
-	switch result {
-	case "enabled":
-		return "Automatic"
-	case "disabled":
-		return "Disabled"
-	case "masked":
-		return "Disabled"
+	return systemdStartupType(strings.TrimSpace(string(output)))
+}
+
+// systemdStartupType maps `systemctl is-enabled` output to a startup type. A
+// disabled unit can still be started by hand (Manual); only a masked one
+// cannot (Disabled), matching what setServiceStartupOS applies.
+func systemdStartupType(isEnabled string) string {
+	switch isEnabled {
+	case "enabled", "enabled-runtime":
+		return serviceStartupAutomatic
+	case "masked", "masked-runtime":
+		return serviceStartupDisabled
+	default:
+		return serviceStartupManual
+	}
+}
+
+func setServiceStartupOS(name, startupType string) error {
+	for _, args := range systemctlStartupSteps(name+".service", startupType) {
+		if output, err := exec.Command("systemctl", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to set service startup (systemctl %s): %w: %s", args[0], err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// systemctlStartupSteps returns the systemctl invocations that give unit the
+// startup type: enabled (Automatic), disabled (Manual) or masked (Disabled).
+func systemctlStartupSteps(unit, startupType string) [][]string {
+	switch startupType {
+	case serviceStartupAutomatic:
+		return [][]string{{"unmask", unit}, {"enable", unit}}
+	case serviceStartupManual:
+		return [][]string{{"unmask", unit}, {"disable", unit}}
 	default:
-		return "Manual"
+		return [][]string{{"disable", unit}, {"mask", unit}}
 	}
 }
 
@@ -0,0 +1,32 @@
+//go:build linux
+
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSystemdStartupTypeRoundTrip(t *testing.T) {
+	for isEnabled, want := range map[string]string{
+		"enabled":  serviceStartupAutomatic,
+		"disabled": serviceStartupManual,
+		"static":   serviceStartupManual,
+		"masked":   serviceStartupDisabled,
+	} {
+		if got := systemdStartupType(isEnabled); got != want {
+			t.Fatalf("systemdStartupType(%q) = %q, want %q", isEnabled, got, want)
+		}
+	}
+
+	want := map[string][][]string{
+		serviceStartupAutomatic: {{"unmask", "nginx.service"}, {"enable", "nginx.service"}},
+		serviceStartupManual:    {{"unmask", "nginx.service"}, {"disable", "nginx.service"}},
+		serviceStartupDisabled:  {{"disable", "nginx.service"}, {"mask", "nginx.service"}},
+	}
+	for startupType, steps := range want {
+		if got := systemctlStartupSteps("nginx.service", startupType); !reflect.DeepEqual(got, steps) {
+			t.Fatalf("systemctlStartupSteps(%s) = %v, want %v", startupType, got, steps)
+		}
+	}
+}
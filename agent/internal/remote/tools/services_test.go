@@ -1,6 +1,9 @@
 package tools
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestValidateServiceName(t *testing.T) {
 	t.Parallel()
@@ -23,3 +26,34 @@ func TestValidateServiceName(t *testing.T) {
 		}
 	}
 }
+
+func TestNormalizeServiceStartupType(t *testing.T) {
+	for in, want := range map[string]string{
+		"automatic": serviceStartupAutomatic,
+		" Auto ":    serviceStartupAutomatic,
+		"Manual":    serviceStartupManual,
+		"demand":    serviceStartupManual,
+		"DISABLED":  serviceStartupDisabled,
+	} {
+		if got, err := normalizeServiceStartupType(in); err != nil || got != want {
+			t.Fatalf("normalizeServiceStartupType(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "boot", "delayed"} {
+		if _, err := normalizeServiceStartupType(bad); err == nil {
+			t.Fatalf("normalizeServiceStartupType(%q) should fail", bad)
+		}
+	}
+}
+
+func TestSetServiceStartupRejectsBadInput(t *testing.T) {
+	if r := SetServiceStartup(map[string]any{"name": "../x", "startupType": "manual"}); r.Status == "completed" {
+		t.Fatal("invalid service name should be rejected")
+	}
+	if r := SetServiceStartup(map[string]any{"name": "sshd", "startupType": "sometimes"}); r.Status == "completed" {
+		t.Fatal("invalid startup type should be rejected")
+	}
+	if r := SetServiceStartup(map[string]any{"name": agentServiceName, "startupType": "disabled"}); r.Status == "completed" || !strings.Contains(r.Error, "agent service") {
+		t.Fatalf("disabling the agent service = %q, %q", r.Status, r.Error)
+	}
+}
@@ -167,6 +167,37 @@ func restartServiceOS(name string) error {
 	return startServiceOS(name)
 }
 
+func setServiceStartupOS(name, startupType string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service not found: %w", err)
+	}
+	defer s.Close()
+
+	config, err := s.Config()
+	if err != nil {
+		return fmt.Errorf("failed to get service config: %w", err)
+	}
+	switch startupType {
+	case serviceStartupAutomatic:
+		config.StartType = mgr.StartAutomatic
+	case serviceStartupManual:
+		config.StartType = mgr.StartManual
+	default:
+		config.StartType = mgr.StartDisabled
+	}
+	if err := s.UpdateConfig(config); err != nil {
+		return fmt.Errorf("failed to set service startup: %w", err)
+	}
+	return nil
+}
+
 func waitForServiceState(s *mgr.Service, desiredState svc.State, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
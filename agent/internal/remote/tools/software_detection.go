@@ -42,11 +42,11 @@ type DetectionOutcome struct {
 	Detail string
 }
 
-// parseDetectionRules extracts detection rules from the command payload.
+// ParseDetectionRules extracts detection rules from the command payload.
 // payload["detectionRules"] must be []any of map[string]any (the natural
 // shape after JSON→map[string]any decode). Clauses with an empty Type are
 // silently skipped. Returns nil for absent, empty, or entirely-garbage input.
-func parseDetectionRules(payload map[string]any) []DetectionRule {
+func ParseDetectionRules(payload map[string]any) []DetectionRule {
 	raw, ok := payload["detectionRules"]
 	if !ok {
 		return nil
@@ -81,7 +81,7 @@ func parseDetectionRules(payload map[string]any) []DetectionRule {
 }
 
 // detectionStringField is a nil-safe type-asserting field reader for map[string]any
-// used by parseDetectionRules.
+// used by ParseDetectionRules.
 func detectionStringField(m map[string]any, key string) string {
 	if v, ok := m[key]; ok {
 		if s, ok := v.(string); ok {
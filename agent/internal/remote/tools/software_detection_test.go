@@ -8,33 +8,33 @@ import (
 )
 
 // ---------------------------------------------------------------------------
-// parseDetectionRules
+// ParseDetectionRules
 // ---------------------------------------------------------------------------
 
 func TestParseDetectionRules(t *testing.T) {
 	t.Run("absent key returns nil", func(t *testing.T) {
-		got := parseDetectionRules(map[string]any{})
+		got := ParseDetectionRules(map[string]any{})
 		if got != nil {
 			t.Fatalf("expected nil, got %v", got)
 		}
 	})
 
 	t.Run("nil slice value returns nil", func(t *testing.T) {
-		got := parseDetectionRules(map[string]any{"detectionRules": nil})
+		got := ParseDetectionRules(map[string]any{"detectionRules": nil})
 		if got != nil {
 			t.Fatalf("expected nil, got %v", got)
 		}
 	})
 
 	t.Run("wrong type returns nil", func(t *testing.T) {
-		got := parseDetectionRules(map[string]any{"detectionRules": "bad"})
+		got := ParseDetectionRules(map[string]any{"detectionRules": "bad"})
 		if got != nil {
 			t.Fatalf("expected nil, got %v", got)
 		}
 	})
 
 	t.Run("empty slice returns nil", func(t *testing.T) {
-		got := parseDetectionRules(map[string]any{"detectionRules": []any{}})
+		got := ParseDetectionRules(map[string]any{"detectionRules": []any{}})
 		if got != nil {
 			t.Fatalf("expected nil, got %v", got)
 		}
@@ -47,7 +47,7 @@ func TestParseDetectionRules(t *testing.T) {
 				map[string]any{"type": "file_exists", "path": "/foo"},
 			},
 		}
-		got := parseDetectionRules(payload)
+		got := ParseDetectionRules(payload)
 		if len(got) != 1 {
 			t.Fatalf("expected 1 rule, got %d", len(got))
 		}
@@ -63,7 +63,7 @@ func TestParseDetectionRules(t *testing.T) {
 				map[string]any{"type": "file_exists", "path": "/foo"},
 			},
 		}
-		got := parseDetectionRules(payload)
+		got := ParseDetectionRules(payload)
 		if len(got) != 1 {
 			t.Fatalf("expected 1 rule, got %d", len(got))
 		}
@@ -75,7 +75,7 @@ func TestParseDetectionRules(t *testing.T) {
 				map[string]any{"type": "file_exists", "path": "/usr/bin/foo"},
 			},
 		}
-		got := parseDetectionRules(payload)
+		got := ParseDetectionRules(payload)
 		if len(got) != 1 {
 			t.Fatalf("expected 1 rule, got %d", len(got))
 		}
@@ -100,7 +100,7 @@ func TestParseDetectionRules(t *testing.T) {
 				},
 			},
 		}
-		got := parseDetectionRules(payload)
+		got := ParseDetectionRules(payload)
 		if len(got) != 1 {
 			t.Fatalf("expected 1 rule, got %d", len(got))
 		}
@@ -120,7 +120,7 @@ func TestParseDetectionRules(t *testing.T) {
 				},
 			},
 		}
-		got := parseDetectionRules(payload)
+		got := ParseDetectionRules(payload)
 		if len(got) != 1 {
 			t.Fatalf("expected 1 rule, got %d", len(got))
 		}
@@ -140,7 +140,7 @@ func TestParseDetectionRules(t *testing.T) {
 				},
 			},
 		}
-		got := parseDetectionRules(payload)
+		got := ParseDetectionRules(payload)
 		if len(got) != 1 {
 			t.Fatalf("expected 1 rule, got %d", len(got))
 		}
@@ -55,7 +55,7 @@ func InstallSoftware(payload map[string]any) (result CommandResult) {
 	// Detection rules (#2022): evaluated against the device's real state so the
 	// reported status reflects whether the app is actually present rather than
 	// just the installer exit code.
-	detectionRules := parseDetectionRules(payload)
+	detectionRules := ParseDetectionRules(payload)
 	forceReinstall := GetPayloadBool(payload, "forceReinstall", false)
 
 	// Pre-install gate: when a detection rule is configured and the package is
@@ -226,7 +226,7 @@ func validateInstallInputs(fileName, fileType, checksum, silentInstallArgs, soft
 
 func isSupportedInstallFileType(fileType string) bool {
 	switch strings.ToLower(strings.TrimSpace(fileType)) {
-	case "exe", "msi", "deb", "pkg", "dmg":
+	case "exe", "msi", "deb", "rpm", "pkg", "dmg":
 		return true
 	default:
 		return false
@@ -379,6 +379,10 @@ func executeInstaller(localPath, fileType, silentInstallArgs string) (int, strin
 	case fileType == "deb" && runtime.GOOS == "linux":
 		cmd = exec.CommandContext(ctx, "dpkg", "-i", localPath)
 
+	case fileType == "rpm" && runtime.GOOS == "linux":
+		name, args := rpmInstallCommand(localPath)
+		cmd = exec.CommandContext(ctx, name, args...)
+
 	case fileType == "pkg" && runtime.GOOS == "darwin":
 		cmd = exec.CommandContext(ctx, "installer", "-pkg", localPath, "-target", "/")
 
@@ -409,6 +413,26 @@ func executeInstaller(localPath, fileType, silentInstallArgs string) (int, strin
 	return exitCode, procoutput.BytesToUTF8(output), fmt.Errorf("installer exited with code %d", exitCode)
 }
 
+// rpmInstallCommand installs a local RPM through the distribution's package
+// manager when one is present so its dependencies are pulled from the
+// configured repositories; bare rpm is the fallback and fails on a missing
+// dependency instead.
+func rpmInstallCommand(localPath string) (string, []string) {
+	for _, candidate := range []struct {
+		name string
+		args []string
+	}{
+		{"dnf", []string{"install", "-y", localPath}},
+		{"yum", []string{"install", "-y", localPath}},
+		{"zypper", []string{"--non-interactive", "install", localPath}},
+	} {
+		if _, err := exec.LookPath(candidate.name); err == nil {
+			return candidate.name, candidate.args
+		}
+	}
+	return "rpm", []string{"-U", "--replacepkgs", localPath}
+}
+
 // installerExitIndicatesSuccess reports whether an installer exit code means the
 // install succeeded. 0 is universal success. 3010 (ERROR_SUCCESS_REBOOT_REQUIRED)
 // and 1641 (ERROR_SUCCESS_REBOOT_INITIATED) are Windows installer "success, a
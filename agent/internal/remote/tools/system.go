@@ -2,40 +2,14 @@ package tools
 
 import (
 	"fmt"
+	"log/slog"
 	"os/exec"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 )
 
-// Reboot schedules a system reboot after the requested delay.
-// Maximum delay is 1440 minutes (24 hours).
-func Reboot(payload map[string]any) CommandResult {
-	startTime := time.Now()
-	delay := GetPayloadInt(payload, "delay", 0)
-	if delay < 0 {
-		delay = 0
-	} else if delay > 1440 {
-		delay = 1440
-	}
-
-	cmd, err := buildShutdownCommand(true, delay)
-	if err != nil {
-		return NewErrorResult(err, time.Since(startTime).Milliseconds())
-	}
-
-	if err := cmd.Run(); err != nil {
-		return NewErrorResult(fmt.Errorf("failed to reboot: %w", err), time.Since(startTime).Milliseconds())
-	}
-
-	result := map[string]any{
-		"command": CmdReboot,
-		"delay":   delay,
-	}
-
-	return NewSuccessResult(result, time.Since(startTime).Milliseconds())
-}
-
 // Shutdown schedules a system shutdown after the requested delay.
 // Maximum delay is 1440 minutes (24 hours).
 func Shutdown(payload map[string]any) CommandResult {
@@ -47,7 +21,7 @@ func Shutdown(payload map[string]any) CommandResult {
 		delay = 1440
 	}
 
-	cmd, err := buildShutdownCommand(false, delay)
+	cmd, err := buildShutdownCommand(delay)
 	if err != nil {
 		return NewErrorResult(err, time.Since(startTime).Milliseconds())
 	}
@@ -91,20 +65,79 @@ func Lock(payload map[string]any) CommandResult {
 	return NewSuccessResult(result, time.Since(startTime).Milliseconds())
 }
 
-func buildShutdownCommand(isReboot bool, delay int) (*exec.Cmd, error) {
+// buildShutdownCommand returns the OS command that powers off after delay
+// minutes. Reboots go through patching.RebootManager instead.
+func buildShutdownCommand(delay int) (*exec.Cmd, error) {
 	switch runtime.GOOS {
 	case "windows":
-		action := "/s"
-		if isReboot {
-			action = "/r"
-		}
-		return exec.Command("shutdown", action, "/t", strconv.Itoa(delay)), nil
+		// shutdown.exe takes seconds.
+		return exec.Command("shutdown", "/s", "/t", strconv.Itoa(delay*60)), nil
 	case "linux", "darwin":
-		action := "-h"
-		if isReboot {
-			action = "-r"
+		return exec.Command("shutdown", "-h", "+"+strconv.Itoa(delay)), nil
+	default:
+		return nil, fmt.Errorf("unsupported OS: %s", runtime.GOOS)
+	}
+}
+
+// SuspendGrace is how long Sleep and Hibernate wait before suspending, so
+// the command result reaches the API and the user sees the warning first.
+const SuspendGrace = 10 * time.Second
+
+// Sleep suspends the machine to RAM after SuspendGrace.
+func Sleep(payload map[string]any) CommandResult {
+	return suspend(CmdSleep, false)
+}
+
+// Hibernate suspends the machine to disk after SuspendGrace.
+func Hibernate(payload map[string]any) CommandResult {
+	return suspend(CmdHibernate, true)
+}
+
+func suspend(command string, hibernate bool) CommandResult {
+	startTime := time.Now()
+
+	cmd, err := buildSuspendCommand(hibernate)
+	if err != nil {
+		return NewErrorResult(err, time.Since(startTime).Milliseconds())
+	}
+
+	// The machine stops running once the command succeeds, so it runs after
+	// the result has been sent; a failure can only be logged.
+	go func() {
+		time.Sleep(SuspendGrace)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			slog.Warn("suspend failed", "command", command, "error", err.Error(), "output", strings.TrimSpace(string(output)))
+		}
+	}()
+
+	result := map[string]any{
+		"command":      command,
+		"delaySeconds": int(SuspendGrace / time.Second),
+	}
+
+	return NewSuccessResult(result, time.Since(startTime).Milliseconds())
+}
+
+func buildSuspendCommand(hibernate bool) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if hibernate {
+			return exec.Command("shutdown", "/h"), nil
+		}
+		// rundll32 powrprof.dll,SetSuspendState hibernates instead when
+		// hibernation is enabled; Application.SetSuspendState does not.
+		return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
+			"Add-Type -AssemblyName System.Windows.Forms; [void][System.Windows.Forms.Application]::SetSuspendState('Suspend', $false, $false)"), nil
+	case "linux":
+		if hibernate {
+			return exec.Command("systemctl", "hibernate"), nil
+		}
+		return exec.Command("systemctl", "suspend"), nil
+	case "darwin":
+		if hibernate {
+			return nil, fmt.Errorf("hibernate is not supported on macOS; hibernation is controlled by pmset hibernatemode")
 		}
-		return exec.Command("shutdown", action, "+"+strconv.Itoa(delay)), nil
+		return exec.Command("pmset", "sleepnow"), nil
 	default:
 		return nil, fmt.Errorf("unsupported OS: %s", runtime.GOOS)
 	}
@@ -26,10 +26,17 @@ const (
 
 // StartTerminal starts a new terminal session
 func StartTerminal(mgr *terminal.Manager, payload map[string]any, outputCallback OutputCallback) CommandResult {
+	return StartTerminalAs(mgr, payload, nil, outputCallback)
+}
+
+// StartTerminalAs starts a terminal session whose shell runs as runAs (nil
+// for the agent's own account). It takes ownership of runAs.
+func StartTerminalAs(mgr *terminal.Manager, payload map[string]any, runAs *terminal.Credential, outputCallback OutputCallback) CommandResult {
 	start := time.Now()
 
 	sessionId := GetPayloadString(payload, "sessionId", "")
 	if sessionId == "" {
+		runAs.Release()
 		return NewErrorResult(fmt.Errorf("sessionId is required"), time.Since(start).Milliseconds())
 	}
 
@@ -37,7 +44,17 @@ func StartTerminal(mgr *terminal.Manager, payload map[string]any, outputCallback
 		GetPayloadInt(payload, "cols", defaultTerminalCols),
 		GetPayloadInt(payload, "rows", defaultTerminalRows),
 	)
-	shell := GetPayloadString(payload, "shell", "")
+	opts := terminal.SessionOptions{
+		Shell: GetPayloadString(payload, "shell", ""),
+		Dir:   GetPayloadString(payload, "cwd", ""),
+	}
+	env, err := terminalEnvFromPayload(payload)
+	if err != nil {
+		runAs.Release()
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+	opts.Env = env
+	opts.RunAs = runAs
 
 	// Create output handler that streams data back
 	onOutput := func(data []byte) {
@@ -54,16 +71,44 @@ func StartTerminal(mgr *terminal.Manager, payload map[string]any, outputCallback
 		}
 	}
 
-	if err := mgr.StartSession(sessionId, cols, rows, shell, onOutput, onClose); err != nil {
+	if err := mgr.StartSessionWithOptions(sessionId, cols, rows, opts, onOutput, onClose); err != nil {
 		return NewErrorResult(err, time.Since(start).Milliseconds())
 	}
 
-	return NewSuccessResult(map[string]any{
+	result := map[string]any{
 		"sessionId": sessionId,
 		"cols":      cols,
 		"rows":      rows,
 		"started":   true,
-	}, time.Since(start).Milliseconds())
+	}
+	if session, ok := mgr.GetSession(sessionId); ok {
+		result["shell"] = session.Shell
+	}
+	if runAs != nil {
+		result["user"] = runAs.Username
+	}
+	return NewSuccessResult(result, time.Since(start).Milliseconds())
+}
+
+// terminalEnvFromPayload reads the optional "env" object of string values.
+func terminalEnvFromPayload(payload map[string]any) (map[string]string, error) {
+	raw, ok := payload["env"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("env must be an object of strings")
+	}
+	env := make(map[string]string, len(obj))
+	for k, v := range obj {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("env value for %s must be a string", k)
+		}
+		env[k] = s
+	}
+	return env, nil
 }
 
 // WriteTerminal writes data to an existing terminal session
@@ -139,6 +184,65 @@ func StopTerminal(mgr *terminal.Manager, payload map[string]any) CommandResult {
 	}, time.Since(start).Milliseconds())
 }
 
+// DetachTerminal keeps a terminal session's shell running without a viewer
+// for grace, buffering its output for a later AttachTerminal.
+func DetachTerminal(mgr *terminal.Manager, payload map[string]any, grace time.Duration) CommandResult {
+	start := time.Now()
+
+	sessionId := GetPayloadString(payload, "sessionId", "")
+	if sessionId == "" {
+		return NewErrorResult(fmt.Errorf("sessionId is required"), time.Since(start).Milliseconds())
+	}
+
+	if err := mgr.DetachSession(sessionId, grace); err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	return NewSuccessResult(map[string]any{
+		"sessionId":    sessionId,
+		"detached":     true,
+		"graceSeconds": int(grace.Seconds()),
+	}, time.Since(start).Milliseconds())
+}
+
+// AttachTerminal reattaches a viewer to a running terminal session: the
+// scrollback is replayed as terminal output, then live output resumes. When
+// the payload carries a size the session is resized after the replay, which
+// makes full-screen programs redraw.
+func AttachTerminal(mgr *terminal.Manager, payload map[string]any) CommandResult {
+	start := time.Now()
+
+	sessionId := GetPayloadString(payload, "sessionId", "")
+	if sessionId == "" {
+		return NewErrorResult(fmt.Errorf("sessionId is required"), time.Since(start).Milliseconds())
+	}
+
+	replayed, err := mgr.AttachSession(sessionId)
+	if err != nil {
+		return NewErrorResult(err, time.Since(start).Milliseconds())
+	}
+
+	result := map[string]any{
+		"sessionId":     sessionId,
+		"attached":      true,
+		"replayedBytes": replayed,
+	}
+	if _, hasCols := payload["cols"]; hasCols {
+		cols, rows := normalizeTerminalSize(
+			GetPayloadInt(payload, "cols", defaultTerminalCols),
+			GetPayloadInt(payload, "rows", defaultTerminalRows),
+		)
+		if err := mgr.ResizeSession(sessionId, cols, rows); err != nil {
+			terminalLog.Warn("failed to resize reattached terminal", "sessionId", sessionId, "error", err.Error())
+		} else {
+			result["cols"] = cols
+			result["rows"] = rows
+		}
+	}
+
+	return NewSuccessResult(result, time.Since(start).Milliseconds())
+}
+
 func normalizeTerminalSize(cols, rows int) (uint16, uint16) {
 	if cols < minTerminalCols {
 		cols = minTerminalCols
@@ -34,3 +34,18 @@ func TestNormalizeTerminalSizeClampsBounds(t *testing.T) {
 		t.Fatalf("expected max clamp to %dx%d, got %dx%d", maxTerminalCols, maxTerminalRows, cols, rows)
 	}
 }
+
+func TestTerminalEnvFromPayload(t *testing.T) {
+	env, err := terminalEnvFromPayload(map[string]any{"env": map[string]any{"FOO": "bar"}})
+	if err != nil || env["FOO"] != "bar" {
+		t.Fatalf("env = %v, %v", env, err)
+	}
+	if env, err := terminalEnvFromPayload(map[string]any{}); err != nil || env != nil {
+		t.Fatalf("missing env = %v, %v", env, err)
+	}
+	for _, bad := range []any{"FOO=bar", map[string]any{"N": 1}} {
+		if _, err := terminalEnvFromPayload(map[string]any{"env": bad}); err == nil {
+			t.Fatalf("terminalEnvFromPayload(%v) should fail", bad)
+		}
+	}
+}
@@ -9,9 +9,13 @@ import (
 // Command types
 const (
 	// Process management
-	CmdListProcesses = "list_processes"
-	CmdGetProcess    = "get_process"
-	CmdKillProcess   = "kill_process"
+	CmdListProcesses      = "list_processes"
+	CmdGetProcess         = "get_process"
+	CmdKillProcess        = "kill_process"
+	CmdProcessKill        = "process_kill"
+	CmdProcessSetPriority = "process_set_priority"
+	CmdProcessStreamStart = "process_stream_start"
+	CmdProcessStreamStop  = "process_stream_stop"
 
 	// Service management
 	CmdListServices   = "list_services"
@@ -20,6 +24,13 @@ const (
 	CmdStopService    = "stop_service"
 	CmdRestartService = "restart_service"
 
+	// service_* names for the same operations, plus startup-type control
+	CmdServiceList       = "service_list"
+	CmdServiceStart      = "service_start"
+	CmdServiceStop       = "service_stop"
+	CmdServiceRestart    = "service_restart"
+	CmdServiceSetStartup = "service_set_startup"
+
 	// Event logs (Windows)
 	CmdEventLogsList  = "event_logs_list"
 	CmdEventLogsQuery = "event_logs_query"
@@ -41,10 +52,13 @@ const (
 	CmdRegistryDelete    = "registry_delete"
 	CmdRegistryKeyCreate = "registry_key_create"
 	CmdRegistryKeyDelete = "registry_key_delete"
+	CmdRegistryExport    = "registry_export"
 
 	// System
 	CmdReboot         = "reboot"
 	CmdShutdown       = "shutdown"
+	CmdSleep          = "sleep"
+	CmdHibernate      = "hibernate"
 	CmdLock           = "lock"
 	CmdRebootSafeMode = "reboot_safe_mode"
 	CmdWakeOnLan      = "wake_on_lan"
@@ -60,6 +74,9 @@ const (
 	CmdSoftwareInstall   = "software_install"
 	CmdSoftwareUpdate    = "software_update"
 
+	// Third-party application catalog
+	CmdAppDeploy = "app_deploy"
+
 	// Boot performance
 	CmdCollectBootPerformance    = "collect_boot_performance"
 	CmdManageStartupItem         = "manage_startup_item"
@@ -72,6 +89,8 @@ const (
 	// Remote desktop (WebRTC - legacy)
 	CmdStartDesktop = "start_desktop"
 	CmdStopDesktop  = "stop_desktop"
+	CmdJoinDesktop  = "join_desktop"
+	CmdLeaveDesktop = "leave_desktop"
 
 	// Remote desktop (WebSocket streaming)
 	CmdDesktopStreamStart = "desktop_stream_start"
@@ -84,6 +103,7 @@ const (
 	CmdTerminalData   = "terminal_data"
 	CmdTerminalResize = "terminal_resize"
 	CmdTerminalStop   = "terminal_stop"
+	CmdTerminalAttach = "terminal_attach"
 
 	// Script execution
 	CmdScript    = "script"
@@ -94,6 +114,7 @@ const (
 	CmdInstallPatches  = "install_patches"
 	CmdRollbackPatches = "rollback_patches"
 	CmdDownloadPatches = "download_patches"
+	CmdPatchStage      = "patch_stage"
 
 	// Reboot management
 	CmdScheduleReboot  = "schedule_reboot"
@@ -101,17 +122,32 @@ const (
 	CmdGetRebootStatus = "get_reboot_status"
 
 	// Security
-	CmdSecurityCollectStatus    = "security_collect_status"
-	CmdSecurityScan             = "security_scan"
-	CmdSecurityThreatQuarantine = "security_threat_quarantine"
-	CmdSecurityThreatRemove     = "security_threat_remove"
-	CmdSecurityThreatRestore    = "security_threat_restore"
-	CmdSensitiveDataScan        = "sensitive_data_scan"
-	CmdEncryptionCollectKeys    = "encryption_collect_keys"
-	CmdEncryptionRotateKey      = "encryption_rotate_key"
-	CmdEncryptFile              = "encrypt_file"
-	CmdSecureDeleteFile         = "secure_delete_file"
-	CmdQuarantineFile           = "quarantine_file"
+	CmdSecurityCollectStatus     = "security_collect_status"
+	CmdSecurityScan              = "security_scan"
+	CmdAVScan                    = "av_scan"
+	CmdDefenderGetPreferences    = "defender_get_preferences"
+	CmdDefenderSetPreferences    = "defender_set_preferences"
+	CmdFirewallListRules         = "firewall_list_rules"
+	CmdFirewallAddRule           = "firewall_add_rule"
+	CmdFirewallRemoveRule        = "firewall_remove_rule"
+	CmdFirewallSetProfile        = "firewall_set_profile"
+	CmdIsolateHost               = "isolate_host"
+	CmdReleaseIsolation          = "release_isolation"
+	CmdSecurityThreatQuarantine  = "security_threat_quarantine"
+	CmdSecurityThreatRemove      = "security_threat_remove"
+	CmdSecurityThreatRestore     = "security_threat_restore"
+	CmdSensitiveDataScan         = "sensitive_data_scan"
+	CmdEncryptionCollectKeys     = "encryption_collect_keys"
+	CmdEncryptionRotateKey       = "encryption_rotate_key"
+	CmdEncryptionEnableBitLocker = "encryption_enable_bitlocker"
+	CmdEncryptionEnableFileVault = "encryption_enable_filevault"
+	CmdEncryptionEscrowLUKS      = "encryption_escrow_luks"
+	CmdLocalAdminRemove          = "local_admin_remove"
+	CmdLocalAdminDisable         = "local_admin_disable"
+	CmdLocalAdminRotatePassword  = "local_admin_rotate_password"
+	CmdEncryptFile               = "encrypt_file"
+	CmdSecureDeleteFile          = "secure_delete_file"
+	CmdQuarantineFile            = "quarantine_file"
 
 	// File operations
 	CmdFileList           = "file_list"
@@ -126,6 +162,11 @@ const (
 	CmdFileTrashPurge     = "file_trash_purge"
 	CmdFilesystemAnalysis = "filesystem_analysis"
 	CmdFileListDrives     = "file_list_drives"
+	CmdFileStat           = "file_stat"
+	CmdFileMove           = "file_move"
+	CmdFileZip            = "file_zip"
+	CmdFileUnzip          = "file_unzip"
+	CmdFileHash           = "file_hash"
 
 	// Network discovery
 	CmdNetworkDiscovery = "network_discovery"
@@ -177,6 +218,14 @@ const (
 	// result, so nothing is reachable off-box.
 	CmdCapturePprof = "capture_pprof"
 
+	// Customer audit transcripts: signed archive of every command run on the
+	// device over a period, compiled from the local audit log.
+	CmdTranscriptExport = "transcript_export"
+
+	// Guided removal of superseded RMM agents during migration to Breeze:
+	// vendor silent uninstall, leftover cleanup and verification.
+	CmdRMMCleanup = "rmm_cleanup"
+
 	// Dev push (fast dev binary update)
 	// Auto-update management
 	CmdSetAutoUpdate = "set_auto_update"
@@ -195,6 +244,7 @@ const (
 	// CIS benchmark compliance
 	CmdCisBenchmark        = "cis_benchmark"
 	CmdApplyCisRemediation = "apply_cis_remediation"
+	CmdComplianceEvaluate  = "compliance_evaluate"
 
 	// Peripheral control
 	CmdPeripheralPolicySync = "peripheral_policy_sync"
@@ -300,6 +350,31 @@ type ProcessListResponse struct {
 	Truncated  bool          `json:"truncated,omitempty"`
 }
 
+// ProcessRow is one process in a live process-table stream.
+type ProcessRow struct {
+	PID        int32   `json:"pid"`
+	Name       string  `json:"name"`
+	User       string  `json:"user,omitempty"`
+	CPUPercent float64 `json:"cpuPercent"`
+	MemoryMB   float64 `json:"memoryMb"`
+}
+
+// ProcessTableSnapshot is one frame of a live process-table stream. A full
+// frame lists every process in Upserts; a delta frame lists only processes
+// that appeared or whose CPU or memory changed, plus the PIDs that exited.
+type ProcessTableSnapshot struct {
+	Seq           uint64       `json:"seq"`
+	Full          bool         `json:"full"`
+	Timestamp     int64        `json:"timestamp"` // unix ms
+	IntervalMs    int64        `json:"intervalMs,omitempty"`
+	Total         int          `json:"total"`
+	CPUPercent    float64      `json:"cpuPercent"` // whole system, 100% == all cores
+	MemoryUsedMB  float64      `json:"memoryUsedMb"`
+	MemoryTotalMB float64      `json:"memoryTotalMb"`
+	Upserts       []ProcessRow `json:"upserts"`
+	Removed       []int32      `json:"removed,omitempty"`
+}
+
 // Service information types
 type ServiceInfo struct {
 	Name        string `json:"name"`
@@ -423,6 +498,16 @@ type RegistryValuesResponse struct {
 	Truncated bool            `json:"truncated,omitempty"`
 }
 
+type RegistryExportResponse struct {
+	Hive      string `json:"hive"`
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive"`
+	Content   string `json:"content"` // .reg text, CRLF line endings
+	Keys      int    `json:"keys"`
+	Values    int    `json:"values"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
 // FileEntry represents a file or directory in file listing responses
 type FileEntry struct {
 	Name        string `json:"name"`
@@ -433,6 +518,20 @@ type FileEntry struct {
 	Permissions string `json:"permissions,omitempty"`
 }
 
+// FileStat is the file_stat response: one entry's metadata without
+// following a final symlink.
+type FileStat struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Type        string `json:"type"` // "file", "directory", "symlink" or "other"
+	Size        int64  `json:"size"`
+	Modified    string `json:"modified"`
+	Permissions string `json:"permissions"`
+	Mode        string `json:"mode"` // octal permission bits, e.g. "0644"
+	Owner       string `json:"owner,omitempty"`
+	LinkTarget  string `json:"linkTarget,omitempty"`
+}
+
 // FileListResponse represents the response for file listing
 type FileListResponse struct {
 	Path      string      `json:"path"`
@@ -0,0 +1,723 @@
+package security
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/config"
+)
+
+// Antivirus engines an on-demand scan can be run through.
+const (
+	AVEngineDefender = "defender"
+	AVEngineMDATP    = "mdatp"
+	AVEngineClamAV   = "clamav"
+)
+
+// avEngineOrder is the preference order when no engine is requested: the
+// platform's native product first, ClamAV as the portable fallback.
+var avEngineOrder = []string{AVEngineDefender, AVEngineMDATP, AVEngineClamAV}
+
+// avScanProgressKeepalive is how often a scan that reports nothing is
+// re-reported. Defender and mdatp are silent for the whole of a full scan, so
+// without it the console shows nothing for an hour or more.
+var avScanProgressKeepalive = 30 * time.Second
+
+// AVScanRequest describes an on-demand scan through an installed antivirus
+// engine. Engine may be empty or "auto" to use the first available one.
+type AVScanRequest struct {
+	ScanType string
+	Paths    []string
+	Engine   string
+}
+
+// AVScanProgress is one progress event of a running engine scan.
+type AVScanProgress struct {
+	Engine       string `json:"engine"`
+	ScanType     string `json:"scanType"`
+	Phase        string `json:"phase"`
+	ItemsScanned int    `json:"itemsScanned,omitempty"`
+	ThreatsFound int    `json:"threatsFound"`
+	CurrentPath  string `json:"currentPath,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
+
+// AVScanProgressFunc receives progress events while a scan runs.
+type AVScanProgressFunc func(AVScanProgress)
+
+// AVScanResult captures the outcome of an engine scan. Status is the
+// collected posture with the scan's time, type and detection count applied.
+type AVScanResult struct {
+	Engine       string         `json:"engine"`
+	ScanType     string         `json:"scanType"`
+	ItemsScanned int            `json:"itemsScanned,omitempty"`
+	Detections   []Threat       `json:"detections"`
+	Status       SecurityStatus `json:"status"`
+	Duration     time.Duration  `json:"duration"`
+	Warning      string         `json:"warning,omitempty"`
+}
+
+// RunAVScan runs a quick, full or custom-path scan through an installed
+// antivirus engine and returns its detections. A status collection failure
+// does not fail the scan; it is reported as Warning.
+func RunAVScan(ctx context.Context, cfg *config.Config, req AVScanRequest, progress AVScanProgressFunc) (AVScanResult, error) {
+	start := time.Now()
+	scanType := strings.ToLower(strings.TrimSpace(req.ScanType))
+	switch scanType {
+	case "quick", "full":
+	case "custom":
+		if len(req.Paths) == 0 {
+			return AVScanResult{}, fmt.Errorf("custom scan requires one or more paths")
+		}
+	default:
+		return AVScanResult{}, fmt.Errorf("unsupported scanType: %s", req.ScanType)
+	}
+
+	engine, err := selectAVEngine(req.Engine, avEngineAvailable)
+	if err != nil {
+		return AVScanResult{}, err
+	}
+
+	reporter := newAVScanReporter(progress, engine, scanType)
+	reporter.update(func(p *AVScanProgress) { p.Phase = "starting" })
+	reporter.startKeepalive(start)
+
+	var (
+		detections []Threat
+		items      int
+	)
+	switch engine {
+	case AVEngineDefender:
+		detections, items, err = runDefenderAVScan(ctx, scanType, req.Paths, reporter)
+	case AVEngineMDATP:
+		detections, items, err = runMDATPScan(ctx, scanType, req.Paths, reporter, start)
+	case AVEngineClamAV:
+		detections, items, err = runClamAVScan(ctx, scanType, req.Paths, reporter)
+	}
+	reporter.stopKeepalive()
+	if err != nil {
+		reporter.update(func(p *AVScanProgress) {
+			p.Phase = "failed"
+			p.Message = err.Error()
+		})
+		return AVScanResult{}, err
+	}
+
+	status, statusErr := CollectStatus(cfg)
+	applyAVScanToStatus(&status, engine, scanType, len(detections), time.Now())
+	refreshScore(&status)
+
+	reporter.update(func(p *AVScanProgress) {
+		p.Phase = "completed"
+		p.ItemsScanned = max(p.ItemsScanned, items)
+		p.ThreatsFound = len(detections)
+		p.CurrentPath = ""
+		p.Message = ""
+	})
+
+	result := AVScanResult{
+		Engine:       engine,
+		ScanType:     scanType,
+		ItemsScanned: items,
+		Detections:   detections,
+		Status:       status,
+		Duration:     time.Since(start),
+	}
+	if detections == nil {
+		result.Detections = []Threat{}
+	}
+	if statusErr != nil {
+		result.Warning = statusErr.Error()
+	}
+	return result, nil
+}
+
+// selectAVEngine resolves the requested engine, or the first available one
+// in avEngineOrder when none is requested.
+func selectAVEngine(requested string, available func(string) bool) (string, error) {
+	requested = strings.ToLower(strings.TrimSpace(requested))
+	switch requested {
+	case "", "auto":
+		for _, engine := range avEngineOrder {
+			if available(engine) {
+				return engine, nil
+			}
+		}
+		return "", fmt.Errorf("no supported antivirus engine found (defender, mdatp or clamav)")
+	case "windows_defender", "microsoft_defender":
+		requested = AVEngineDefender
+	case "clamscan", "clamdscan":
+		requested = AVEngineClamAV
+	}
+	switch requested {
+	case AVEngineDefender, AVEngineMDATP, AVEngineClamAV:
+	default:
+		return "", fmt.Errorf("unsupported antivirus engine: %s", requested)
+	}
+	if !available(requested) {
+		return "", fmt.Errorf("antivirus engine %s is not installed: %w", requested, ErrNotSupported)
+	}
+	return requested, nil
+}
+
+func avEngineAvailable(engine string) bool {
+	switch engine {
+	case AVEngineDefender:
+		return defenderAVScanAvailable()
+	case AVEngineMDATP:
+		return hasCommand("mdatp")
+	case AVEngineClamAV:
+		_, _, ok := clamAVCommand()
+		return ok
+	default:
+		return false
+	}
+}
+
+// applyAVScanToStatus records a finished engine scan on the collected
+// posture, so the status the result carries reflects the scan just run.
+func applyAVScanToStatus(status *SecurityStatus, engine, scanType string, threats int, at time.Time) {
+	status.ThreatCount = threats
+	status.LastScanAt = at.UTC().Format(time.RFC3339)
+	status.LastScanType = scanType
+	if status.Provider == "" || status.Provider == "other" {
+		switch engine {
+		case AVEngineDefender, AVEngineMDATP:
+			status.Provider = "windows_defender"
+		}
+	}
+}
+
+// avScanReporter serializes progress events and re-sends the latest one as a
+// keepalive. A nil reporter (no progress callback) does nothing.
+type avScanReporter struct {
+	send AVScanProgressFunc
+
+	mu   sync.Mutex
+	last AVScanProgress
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newAVScanReporter(send AVScanProgressFunc, engine, scanType string) *avScanReporter {
+	if send == nil {
+		return nil
+	}
+	return &avScanReporter{send: send, last: AVScanProgress{Engine: engine, ScanType: scanType}}
+}
+
+func (r *avScanReporter) update(change func(*AVScanProgress)) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	change(&r.last)
+	r.send(r.last)
+}
+
+// detection records one more detection at path.
+func (r *avScanReporter) detection(path string) {
+	r.update(func(p *AVScanProgress) {
+		p.Phase = "scanning"
+		p.ThreatsFound++
+		p.CurrentPath = path
+	})
+}
+
+func (r *avScanReporter) startKeepalive(start time.Time) {
+	if r == nil {
+		return
+	}
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(avScanProgressKeepalive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				elapsed := time.Since(start).Round(time.Second)
+				r.update(func(p *AVScanProgress) {
+					p.Phase = "scanning"
+					p.Message = fmt.Sprintf("Still scanning (%s elapsed)", elapsed)
+				})
+			}
+		}
+	}()
+}
+
+func (r *avScanReporter) stopKeepalive() {
+	if r == nil || r.stop == nil {
+		return
+	}
+	close(r.stop)
+	<-r.done
+}
+
+// streamCommand runs an engine command, passing each line of its combined
+// output to onLine, and returns its exit code. A non-zero exit is not an
+// error: engines use exit codes to report detections.
+func streamCommand(ctx context.Context, onLine func(string), name string, args ...string) (int, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	if err := cmd.Start(); err != nil {
+		return -1, fmt.Errorf("failed to start %s: %w", name, err)
+	}
+
+	waitErr := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		writer.Close()
+		waitErr <- err
+	}()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			onLine(line)
+		}
+	}
+	// Drain anything left past a line too long to scan so Wait can finish.
+	_, _ = io.Copy(io.Discard, reader)
+
+	err := <-waitErr
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return -1, fmt.Errorf("%s scan interrupted: %w", name, ctxErr)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return 0, nil
+}
+
+// classifyDetection maps an engine's detection name to a threat type and
+// severity. Defender and mdatp prefix names with a category
+// ("Trojan:Win32/Foo", "PUA:Win32/Bar"); ClamAV embeds it ("PUA.Win.Packer").
+func classifyDetection(name string) (string, string) {
+	lower := strings.ToLower(name)
+	category := lower
+	if idx := strings.Index(lower, ":"); idx > 0 {
+		category = lower[:idx]
+	}
+	switch {
+	case strings.Contains(category, "ransom"):
+		return "ransomware", ThreatSeverityCritical
+	case strings.Contains(category, "backdoor"), strings.Contains(category, "hacktool"):
+		return "malware", ThreatSeverityCritical
+	case strings.Contains(category, "trojan"):
+		return "trojan", ThreatSeverityHigh
+	case strings.Contains(category, "pua"), strings.Contains(category, "adware"):
+		return "pua", ThreatSeverityMedium
+	case strings.Contains(category, "spyware"):
+		return "spyware", ThreatSeverityHigh
+	default:
+		return "malware", ThreatSeverityHigh
+	}
+}
+
+func newDetection(name, path string) Threat {
+	threatType, severity := classifyDetection(name)
+	return Threat{Name: name, Type: threatType, Severity: severity, Path: path}
+}
+
+// ---------------------------------------------------------------------------
+// ClamAV
+// ---------------------------------------------------------------------------
+
+// clamAVCommand prefers the standalone clamscan, which needs no running
+// daemon, and falls back to clamdscan.
+func clamAVCommand() (path string, daemon bool, ok bool) {
+	if path, ok := resolveExecutable("clamscan"); ok {
+		return path, false, true
+	}
+	if path, ok := resolveExecutable("clamdscan"); ok {
+		return path, true, true
+	}
+	return "", false, false
+}
+
+func runClamAVScan(ctx context.Context, scanType string, paths []string, reporter *avScanReporter) ([]Threat, int, error) {
+	command, daemon, ok := clamAVCommand()
+	if !ok {
+		return nil, 0, fmt.Errorf("clamav is not installed: %w", ErrNotSupported)
+	}
+	targets := avScanTargets(scanType, paths)
+	if len(targets) == 0 {
+		return nil, 0, fmt.Errorf("no existing paths to scan")
+	}
+
+	args := []string{"--infected"}
+	if daemon {
+		args = append(args, "--multiscan", "--fdpass")
+	} else {
+		args = append(args, "--recursive", "--cross-fs=no")
+	}
+	if quarantine := DefaultQuarantineDir(); quarantine != "" && !daemon {
+		args = append(args, "--exclude-dir="+regexp.QuoteMeta(quarantine))
+	}
+	args = append(args, targets...)
+
+	var (
+		detections []Threat
+		items      int
+		errLines   []string
+	)
+	exitCode, err := streamCommand(ctx, func(line string) {
+		if threat, ok := parseClamAVLine(line); ok {
+			detections = append(detections, threat)
+			reporter.detection(threat.Path)
+			return
+		}
+		if n, ok := parseClamAVScannedFiles(line); ok {
+			items = n
+			return
+		}
+		if strings.HasSuffix(line, " ERROR") || strings.HasPrefix(line, "ERROR:") {
+			errLines = append(errLines, line)
+		}
+	}, command, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	// 0: clean, 1: detections, anything else: the scan itself failed.
+	if exitCode > 1 && len(detections) == 0 {
+		detail := strings.Join(errLines, "; ")
+		if detail == "" {
+			detail = fmt.Sprintf("exit code %d", exitCode)
+		}
+		return nil, 0, fmt.Errorf("clamav scan failed: %s", detail)
+	}
+	return detections, items, nil
+}
+
+// parseClamAVLine parses an infected-file line such as
+// "/home/u/eicar.com: Win.Test.EICAR_HDB-1 FOUND".
+func parseClamAVLine(line string) (Threat, bool) {
+	rest, found := strings.CutSuffix(strings.TrimSpace(line), " FOUND")
+	if !found {
+		return Threat{}, false
+	}
+	idx := strings.LastIndex(rest, ": ")
+	if idx <= 0 {
+		return Threat{}, false
+	}
+	path := strings.TrimSpace(rest[:idx])
+	name := strings.TrimSpace(rest[idx+2:])
+	if path == "" || name == "" {
+		return Threat{}, false
+	}
+	return newDetection(name, path), true
+}
+
+// parseClamAVScannedFiles parses the "Scanned files: N" summary line.
+func parseClamAVScannedFiles(line string) (int, bool) {
+	value, found := strings.CutPrefix(line, "Scanned files:")
+	if !found {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	return n, err == nil
+}
+
+// avScanTargets returns the paths ClamAV scans. Defender and mdatp pick
+// their own quick and full scan locations.
+func avScanTargets(scanType string, paths []string) []string {
+	switch scanType {
+	case "quick":
+		return defaultQuickPaths()
+	case "full":
+		return defaultFullPaths()
+	default:
+		return filterExistingPaths(paths)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Microsoft Defender for Endpoint (mdatp, macOS and Linux)
+// ---------------------------------------------------------------------------
+
+var mdatpScannedPattern = regexp.MustCompile(`(?i)(\d+)\s+file\(?s?\)?\s+scanned|scanned\s+(\d+)\s+file`)
+
+func runMDATPScan(ctx context.Context, scanType string, paths []string, reporter *avScanReporter, started time.Time) ([]Threat, int, error) {
+	mdatpPath, ok := resolveExecutable("mdatp")
+	if !ok {
+		return nil, 0, fmt.Errorf("mdatp is not installed: %w", ErrNotSupported)
+	}
+
+	var runs [][]string
+	if scanType == "custom" {
+		for _, p := range paths {
+			runs = append(runs, []string{"scan", "custom", "--path", p})
+		}
+	} else {
+		runs = append(runs, []string{"scan", scanType})
+	}
+
+	items := 0
+	for _, args := range runs {
+		runItems := 0
+		exitCode, err := streamCommand(ctx, func(line string) {
+			if n, ok := parseMDATPScannedFiles(line); ok {
+				runItems = n
+				reporter.update(func(p *AVScanProgress) {
+					p.Phase = "scanning"
+					p.ItemsScanned = items + n
+				})
+			}
+		}, mdatpPath, args...)
+		if err != nil {
+			return nil, 0, err
+		}
+		if exitCode != 0 {
+			return nil, 0, fmt.Errorf("mdatp %s failed: exit code %d", strings.Join(args, " "), exitCode)
+		}
+		items += runItems
+	}
+
+	reporter.update(func(p *AVScanProgress) {
+		p.Phase = "collecting"
+		p.Message = "Reading detections"
+	})
+	output, err := runCommand(30*time.Second, mdatpPath, "threat", "list", "--output", "json")
+	if err != nil {
+		return nil, items, fmt.Errorf("failed to list mdatp detections: %w", err)
+	}
+	detections, err := parseMDATPThreatList(output, started)
+	if err != nil {
+		return nil, items, err
+	}
+	return detections, items, nil
+}
+
+func parseMDATPScannedFiles(line string) (int, bool) {
+	match := mdatpScannedPattern.FindStringSubmatch(line)
+	if match == nil {
+		return 0, false
+	}
+	value := match[1]
+	if value == "" {
+		value = match[2]
+	}
+	n, err := strconv.Atoi(value)
+	return n, err == nil
+}
+
+// parseMDATPThreatList parses `mdatp threat list --output json`. The list
+// holds every detection the product remembers, so only those detected at or
+// after since are returned; entries without a detection time are kept.
+func parseMDATPThreatList(output string, since time.Time) ([]Threat, error) {
+	output = strings.TrimSpace(output)
+	if output == "" || output == "[]" {
+		return nil, nil
+	}
+	value, err := parseJSONValue(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mdatp threat list: %w", err)
+	}
+	if wrapper, ok := value.(map[string]any); ok {
+		if threats, ok := lookupValue(wrapper, "threats"); ok {
+			value = threats
+		}
+	}
+
+	var detections []Threat
+	for _, entry := range toObjectSlice(value) {
+		if detectedAt, ok := mdatpDetectionTime(entry); ok && detectedAt.Before(since.Add(-time.Second)) {
+			continue
+		}
+		name := lookupString(entry, "name", "threatName")
+		if name == "" {
+			continue
+		}
+		paths := mdatpResourcePaths(entry)
+		if len(paths) == 0 {
+			paths = []string{""}
+		}
+		for _, p := range paths {
+			detections = append(detections, newDetection(name, p))
+		}
+	}
+	return detections, nil
+}
+
+func mdatpDetectionTime(entry map[string]any) (time.Time, bool) {
+	raw, ok := lookupValue(entry, "detectionTime")
+	if !ok {
+		return time.Time{}, false
+	}
+	switch v := raw.(type) {
+	case float64:
+		// Seconds since the epoch, possibly fractional.
+		return time.Unix(0, int64(v*float64(time.Second))), true
+	case string:
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(v)); err == nil {
+			return t, true
+		}
+		if secs, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return time.Unix(0, int64(secs*float64(time.Second))), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func mdatpResourcePaths(entry map[string]any) []string {
+	if p := lookupString(entry, "path", "filePath"); p != "" {
+		return []string{p}
+	}
+	raw, ok := lookupValue(entry, "resources")
+	if !ok {
+		return nil
+	}
+	var paths []string
+	items, _ := raw.([]any)
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			paths = append(paths, v)
+		case map[string]any:
+			if p := lookupString(v, "path", "filePath"); p != "" {
+				paths = append(paths, p)
+			}
+		}
+	}
+	return paths
+}
+
+// ---------------------------------------------------------------------------
+// Microsoft Defender (Windows) output parsing
+// ---------------------------------------------------------------------------
+
+// parseMpCmdRunOutput parses the threat blocks MpCmdRun -Scan prints:
+//
+//	Threat                  : Virus:DOS/EICAR_Test_File
+//	Resources               : 1 total
+//	    file                : C:\Users\u\Downloads\eicar.com
+func parseMpCmdRunOutput(output string) []Threat {
+	var (
+		detections []Threat
+		current    string
+		resources  int
+	)
+	flush := func() {
+		if current != "" && resources == 0 {
+			detections = append(detections, newDetection(current, ""))
+		}
+		current, resources = "", 0
+	}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "threat":
+			flush()
+			current = value
+		case "file":
+			if current != "" && value != "" {
+				detections = append(detections, newDetection(current, value))
+				resources++
+			}
+		}
+	}
+	flush()
+	return detections
+}
+
+// parseDefenderDetectionsJSON parses the Get-MpThreatDetection projection
+// the Start-MpScan fallback emits: objects with ThreatName, SeverityID and
+// Resources such as "file:_C:\path\to\file".
+func parseDefenderDetectionsJSON(output string) ([]Threat, error) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil, nil
+	}
+	value, err := parseJSONValue(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse defender detections: %w", err)
+	}
+
+	var detections []Threat
+	for _, entry := range toObjectSlice(value) {
+		name := lookupString(entry, "ThreatName", "Name")
+		if name == "" {
+			continue
+		}
+		severity := ""
+		if id, ok := lookupInt(entry, "SeverityID"); ok {
+			severity = defenderSeverity(id)
+		}
+
+		var resources []string
+		if raw, ok := lookupValue(entry, "Resources"); ok {
+			switch v := raw.(type) {
+			case string:
+				resources = []string{v}
+			case []any:
+				for _, item := range v {
+					if s, ok := item.(string); ok {
+						resources = append(resources, s)
+					}
+				}
+			}
+		}
+		if len(resources) == 0 {
+			resources = []string{""}
+		}
+		for _, resource := range resources {
+			threat := newDetection(name, defenderResourcePath(resource))
+			if severity != "" {
+				threat.Severity = severity
+			}
+			detections = append(detections, threat)
+		}
+	}
+	return detections, nil
+}
+
+// defenderResourcePath strips the "file:_" style prefix Defender puts on
+// detection resources.
+func defenderResourcePath(resource string) string {
+	if kind, rest, ok := strings.Cut(resource, ":_"); ok && !strings.ContainsAny(kind, `\/`) {
+		return rest
+	}
+	return resource
+}
+
+// defenderSeverity maps Defender's SeverityID to a threat severity.
+func defenderSeverity(id int) string {
+	switch id {
+	case 1:
+		return ThreatSeverityLow
+	case 2:
+		return ThreatSeverityMedium
+	case 4:
+		return ThreatSeverityHigh
+	case 5:
+		return ThreatSeverityCritical
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package security
+
+import "context"
+
+func defenderAVScanAvailable() bool {
+	return false
+}
+
+// runDefenderAVScan returns an unsupported error on non-Windows platforms;
+// Defender for Endpoint on macOS and Linux is driven through mdatp.
+func runDefenderAVScan(context.Context, string, []string, *avScanReporter) ([]Threat, int, error) {
+	return nil, 0, newPlatformError("Defender scan")
+}
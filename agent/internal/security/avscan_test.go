@@ -0,0 +1,215 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseClamAVLine(t *testing.T) {
+	threat, ok := parseClamAVLine("/home/u/my: files/eicar.com: Win.Test.EICAR_HDB-1 FOUND")
+	if !ok {
+		t.Fatal("expected an infected-file line to parse")
+	}
+	if threat.Path != "/home/u/my: files/eicar.com" || threat.Name != "Win.Test.EICAR_HDB-1" {
+		t.Fatalf("unexpected threat %+v", threat)
+	}
+	if threat.Type != "malware" || threat.Severity != ThreatSeverityHigh {
+		t.Fatalf("unexpected classification %+v", threat)
+	}
+
+	pua, ok := parseClamAVLine("/tmp/setup.exe: PUA.Win.Packer.Upx-1 FOUND")
+	if !ok || pua.Type != "pua" || pua.Severity != ThreatSeverityMedium {
+		t.Fatalf("PUA detection misclassified: %+v", pua)
+	}
+
+	for _, line := range []string{
+		"/tmp/ok.txt: OK",
+		"/tmp/locked: Access denied. ERROR",
+		"----------- SCAN SUMMARY -----------",
+	} {
+		if _, ok := parseClamAVLine(line); ok {
+			t.Errorf("%q should not parse as a detection", line)
+		}
+	}
+
+	if n, ok := parseClamAVScannedFiles("Scanned files: 1204"); !ok || n != 1204 {
+		t.Fatalf("scanned files = %d, %v", n, ok)
+	}
+}
+
+func TestParseMpCmdRunOutput(t *testing.T) {
+	output := `Scan starting...
+Scan finished.
+Scanning C:\Users\u\Downloads found 2 threats.
+
+<===========================LIST OF DETECTED THREATS==========================>
+----------------------------- Threat information ------------------------------
+Threat                  : Virus:DOS/EICAR_Test_File
+Resources               : 2 total
+    file                : C:\Users\u\Downloads\eicar.com
+    file                : C:\Users\u\Downloads\eicar copy.com
+-------------------------------------------------------------------------------
+----------------------------- Threat information ------------------------------
+Threat                  : Ransom:Win32/Locky.A
+Resources               : 0 total
+-------------------------------------------------------------------------------
+`
+	got := parseMpCmdRunOutput(output)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 detections, got %+v", got)
+	}
+	if got[1].Name != "Virus:DOS/EICAR_Test_File" || got[1].Path != `C:\Users\u\Downloads\eicar copy.com` {
+		t.Fatalf("unexpected second detection %+v", got[1])
+	}
+	if got[2].Name != "Ransom:Win32/Locky.A" || got[2].Path != "" || got[2].Severity != ThreatSeverityCritical {
+		t.Fatalf("a threat without resources should still be reported, got %+v", got[2])
+	}
+}
+
+func TestParseDefenderDetectionsJSON(t *testing.T) {
+	single := `{"ThreatName":"Trojan:Win32/Wacatac.B!ml","SeverityID":5,"Resources":["file:_C:\\Temp\\a.exe","file:_C:\\Temp\\b.exe"]}`
+	got, err := parseDefenderDetectionsJSON(single)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Path != `C:\Temp\a.exe` || got[0].Type != "trojan" || got[0].Severity != ThreatSeverityCritical {
+		t.Fatalf("unexpected detections %+v", got)
+	}
+
+	list := `[{"ThreatName":"PUA:Win32/Foo","SeverityID":1,"Resources":"file:_C:\\x.msi"},{"SeverityID":4}]`
+	got, err = parseDefenderDetectionsJSON(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Severity != ThreatSeverityLow || got[0].Path != `C:\x.msi` {
+		t.Fatalf("unexpected detections %+v", got)
+	}
+
+	if got, err := parseDefenderDetectionsJSON(""); err != nil || got != nil {
+		t.Fatalf("no output should mean no detections, got %+v, %v", got, err)
+	}
+}
+
+func TestParseMDATPThreatList(t *testing.T) {
+	since := time.Unix(1_760_000_000, 0)
+	output := `[
+		{"id":"1","name":"Virus:DOS/EICAR_Test_File","detectionTime":1760000100.5,"resources":[{"path":"/Users/u/eicar.com"}]},
+		{"id":"2","name":"Trojan:MacOS/Old","detectionTime":1750000000,"resources":[{"path":"/tmp/old"}]},
+		{"id":"3","name":"PUA:MacOS/Bundle","path":"/Applications/Bundle.app"}
+	]`
+	got, err := parseMDATPThreatList(output, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("detections from before the scan must be dropped, got %+v", got)
+	}
+	if got[0].Path != "/Users/u/eicar.com" || got[1].Type != "pua" {
+		t.Fatalf("unexpected detections %+v", got)
+	}
+
+	if n, ok := parseMDATPScannedFiles("Scanned 48231 file(s)"); !ok || n != 48231 {
+		t.Fatalf("scanned files = %d, %v", n, ok)
+	}
+}
+
+func TestSelectAVEngine(t *testing.T) {
+	only := func(installed ...string) func(string) bool {
+		return func(engine string) bool {
+			for _, e := range installed {
+				if e == engine {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	if engine, err := selectAVEngine("", only(AVEngineMDATP, AVEngineClamAV)); err != nil || engine != AVEngineMDATP {
+		t.Fatalf("auto should prefer mdatp over clamav, got %q, %v", engine, err)
+	}
+	if engine, err := selectAVEngine("clamscan", only(AVEngineClamAV)); err != nil || engine != AVEngineClamAV {
+		t.Fatalf("clamscan alias = %q, %v", engine, err)
+	}
+	if _, err := selectAVEngine("defender", only(AVEngineClamAV)); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("a missing engine should be unsupported, got %v", err)
+	}
+	if _, err := selectAVEngine("norton", only(AVEngineClamAV)); err == nil {
+		t.Fatal("expected an error for an unknown engine")
+	}
+	if _, err := selectAVEngine("auto", only()); err == nil {
+		t.Fatal("expected an error when no engine is installed")
+	}
+}
+
+func TestApplyAVScanToStatus(t *testing.T) {
+	at := time.Date(2026, time.October, 18, 9, 30, 0, 0, time.UTC)
+	status := SecurityStatus{Provider: "other", ThreatCount: 7, LastScanType: "full"}
+	applyAVScanToStatus(&status, AVEngineMDATP, "custom", 2, at)
+	if status.ThreatCount != 2 || status.LastScanType != "custom" || status.LastScanAt != "2026-10-18T09:30:00Z" {
+		t.Fatalf("scan not applied: %+v", status)
+	}
+	if status.Provider != "windows_defender" {
+		t.Fatalf("provider = %q, want windows_defender", status.Provider)
+	}
+
+	status = SecurityStatus{Provider: "sophos"}
+	applyAVScanToStatus(&status, AVEngineClamAV, "quick", 0, at)
+	if status.Provider != "sophos" {
+		t.Fatalf("a detected provider must not be replaced, got %q", status.Provider)
+	}
+}
+
+func TestRunClamAVScan_StreamsDetections(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake clamscan")
+	}
+	bin := t.TempDir()
+	script := `#!/bin/sh
+echo "/data/eicar.com: Win.Test.EICAR_HDB-1 FOUND"
+echo "/data/locked.bin: Access denied. ERROR"
+echo "----------- SCAN SUMMARY -----------"
+echo "Scanned files: 42"
+exit 1
+`
+	if err := os.WriteFile(filepath.Join(bin, "clamscan"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	var events []AVScanProgress
+	reporter := newAVScanReporter(func(p AVScanProgress) { events = append(events, p) }, AVEngineClamAV, "custom")
+	detections, items, err := runClamAVScan(context.Background(), "custom", []string{t.TempDir()}, reporter)
+	if err != nil {
+		t.Fatalf("runClamAVScan: %v", err)
+	}
+	if len(detections) != 1 || detections[0].Path != "/data/eicar.com" || items != 42 {
+		t.Fatalf("detections %+v, items %d", detections, items)
+	}
+	if len(events) != 1 || events[0].ThreatsFound != 1 || events[0].CurrentPath != "/data/eicar.com" {
+		t.Fatalf("expected one detection progress event, got %+v", events)
+	}
+}
+
+func TestRunClamAVScan_FailsOnScanError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake clamscan")
+	}
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho \"ERROR: Can't open file or directory\"\nexit 2\n"
+	if err := os.WriteFile(filepath.Join(bin, "clamscan"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	_, _, err := runClamAVScan(context.Background(), "custom", []string{t.TempDir()}, nil)
+	if err == nil || !strings.Contains(err.Error(), "Can't open file") {
+		t.Fatalf("expected the engine error to surface, got %v", err)
+	}
+}
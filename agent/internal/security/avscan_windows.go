@@ -0,0 +1,128 @@
+//go:build windows
+
+package security
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mpCmdRunPath returns the newest MpCmdRun.exe: the platform-update copy
+// under ProgramData when Defender has self-updated, else the inbox one.
+func mpCmdRunPath() (string, bool) {
+	if programData := os.Getenv("ProgramData"); programData != "" {
+		matches, _ := filepath.Glob(filepath.Join(programData, "Microsoft", "Windows Defender", "Platform", "*", "MpCmdRun.exe"))
+		sort.Strings(matches)
+		if len(matches) > 0 {
+			return matches[len(matches)-1], true
+		}
+	}
+	programFiles := os.Getenv("ProgramFiles")
+	if programFiles == "" {
+		programFiles = `C:\Program Files`
+	}
+	path := filepath.Join(programFiles, "Windows Defender", "MpCmdRun.exe")
+	return path, fileExists(path)
+}
+
+func defenderAVScanAvailable() bool {
+	if _, ok := mpCmdRunPath(); ok {
+		return true
+	}
+	status, err := GetDefenderStatus()
+	return err == nil && status.Enabled
+}
+
+// runDefenderAVScan scans with MpCmdRun, falling back to Start-MpScan and
+// Get-MpThreatDetection when MpCmdRun is missing.
+func runDefenderAVScan(ctx context.Context, scanType string, paths []string, reporter *avScanReporter) ([]Threat, int, error) {
+	mpCmdRun, ok := mpCmdRunPath()
+	if !ok {
+		return runDefenderPowerShellScan(ctx, scanType, paths, reporter)
+	}
+
+	var runs [][]string
+	switch scanType {
+	case "quick":
+		runs = append(runs, []string{"-Scan", "-ScanType", "1"})
+	case "full":
+		runs = append(runs, []string{"-Scan", "-ScanType", "2"})
+	default:
+		for _, p := range paths {
+			runs = append(runs, []string{"-Scan", "-ScanType", "3", "-File", p})
+		}
+	}
+
+	var detections []Threat
+	for _, args := range runs {
+		var output strings.Builder
+		exitCode, err := streamCommand(ctx, func(line string) {
+			output.WriteString(line)
+			output.WriteByte('\n')
+			if key, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(key), "file") {
+				reporter.detection(strings.TrimSpace(value))
+			}
+		}, mpCmdRun, args...)
+		if err != nil {
+			return nil, 0, err
+		}
+		// 0: clean, 2: threats found, anything else: the scan itself failed.
+		found := parseMpCmdRunOutput(output.String())
+		if exitCode != 0 && exitCode != 2 {
+			return nil, 0, fmt.Errorf("MpCmdRun %s failed: exit code %d", strings.Join(args, " "), exitCode)
+		}
+		detections = append(detections, found...)
+	}
+	return detections, 0, nil
+}
+
+func runDefenderPowerShellScan(ctx context.Context, scanType string, paths []string, reporter *avScanReporter) ([]Threat, int, error) {
+	var scans []string
+	switch scanType {
+	case "quick":
+		scans = append(scans, "Start-MpScan -ScanType QuickScan")
+	case "full":
+		scans = append(scans, "Start-MpScan -ScanType FullScan")
+	default:
+		for _, p := range paths {
+			scans = append(scans, fmt.Sprintf("Start-MpScan -ScanType CustomScan -ScanPath '%s'", strings.ReplaceAll(p, "'", "''")))
+		}
+	}
+
+	started := time.Now().Add(-time.Second).UTC().Format(time.RFC3339)
+	script := strings.Join(scans, "; ") + fmt.Sprintf(
+		"; Get-MpThreatDetection | Where-Object { $_.InitialDetectionTime -ge [datetime]'%s' } | "+
+			"ForEach-Object { $t = Get-MpThreat -ThreatID $_.ThreatID; "+
+			"[pscustomobject]@{ ThreatName = $t.ThreatName; SeverityID = [int]$t.SeverityID; Resources = @($_.Resources) } } | "+
+			"ConvertTo-Json -Compress -Depth 4", started)
+
+	var output strings.Builder
+	exitCode, err := streamCommand(ctx, func(line string) {
+		output.WriteString(line)
+		output.WriteByte('\n')
+	}, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	if err != nil {
+		return nil, 0, err
+	}
+	if exitCode != 0 {
+		return nil, 0, fmt.Errorf("Start-MpScan failed: exit code %d: %s", exitCode, strings.TrimSpace(output.String()))
+	}
+
+	payload := strings.TrimSpace(output.String())
+	if idx := strings.IndexAny(payload, "[{"); idx > 0 {
+		payload = payload[idx:]
+	}
+	detections, err := parseDefenderDetectionsJSON(payload)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, d := range detections {
+		reporter.detection(d.Path)
+	}
+	return detections, 0, nil
+}
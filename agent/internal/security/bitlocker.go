@@ -0,0 +1,271 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// BitLocker protectors that can unlock the OS volume at boot. A
+// recovery-password protector is always added alongside; that is the key
+// that gets escrowed.
+const (
+	BitLockerProtectorTPM    = "tpm"
+	BitLockerProtectorTPMPin = "tpm_pin"
+)
+
+// bitlockerEncryptionMethods maps payload values to Enable-BitLocker's
+// -EncryptionMethod names.
+var bitlockerEncryptionMethods = map[string]string{
+	"aes128":     "Aes128",
+	"aes256":     "Aes256",
+	"xts_aes128": "XtsAes128",
+	"xts_aes256": "XtsAes256",
+}
+
+// Pre-boot PINs are digits only unless the enhanced-PIN policy is set, so
+// only the form every machine accepts is allowed.
+var bitlockerPinPattern = regexp.MustCompile(`^[0-9]{6,20}$`)
+
+// BitLockerVolume is one volume as Get-BitLockerVolume reports it.
+type BitLockerVolume struct {
+	Mount                string   `json:"volumeMount"`
+	VolumeStatus         string   `json:"volumeStatus"`
+	ProtectionStatus     string   `json:"protectionStatus"`
+	EncryptionMethod     string   `json:"encryptionMethod"`
+	EncryptionPercentage *float64 `json:"encryptionPercentage,omitempty"`
+	Protectors           []string `json:"protectors,omitempty"`
+}
+
+// Protected reports whether BitLocker protection is on for the volume.
+func (v BitLockerVolume) Protected() bool {
+	return strings.EqualFold(v.ProtectionStatus, "On")
+}
+
+// InProgress reports whether the volume is part-way through encrypting or
+// decrypting, including when that has been paused.
+func (v BitLockerVolume) InProgress() bool {
+	return strings.HasSuffix(v.VolumeStatus, "InProgress") || strings.HasSuffix(v.VolumeStatus, "Paused")
+}
+
+// ConvertTo-Json in Windows PowerShell writes enums as numbers, so every enum
+// is cast to its name first. @() keeps a single volume an array.
+const bitlockerVolumeSelectPS = ` | ForEach-Object { [PSCustomObject]@{ MountPoint = $_.MountPoint; VolumeStatus = "$($_.VolumeStatus)"; ProtectionStatus = "$($_.ProtectionStatus)"; EncryptionMethod = "$($_.EncryptionMethod)"; EncryptionPercentage = $_.EncryptionPercentage; KeyProtector = @($_.KeyProtector | ForEach-Object { "$($_.KeyProtectorType)" }) } }`
+
+func bitlockerVolumeScript(mount string) string {
+	query := "Get-BitLockerVolume"
+	if mount != "" {
+		query += fmt.Sprintf(" -MountPoint '%s'", mount)
+	}
+	return "$r = @(" + query + bitlockerVolumeSelectPS + "); ConvertTo-Json -InputObject $r -Compress -Depth 3"
+}
+
+func parseBitLockerVolumes(output string) ([]BitLockerVolume, error) {
+	trimmed := strings.TrimSpace(output)
+	if trimmed == "" {
+		return nil, nil
+	}
+	parsed, err := parseJSONValue(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("parse bitlocker volume output: %w", err)
+	}
+	var volumes []BitLockerVolume
+	for _, item := range toObjectSlice(parsed) {
+		mount, _ := stringFromAny(item["MountPoint"])
+		volume := BitLockerVolume{Mount: strings.ToUpper(mount)}
+		volume.VolumeStatus, _ = stringFromAny(item["VolumeStatus"])
+		volume.ProtectionStatus, _ = stringFromAny(item["ProtectionStatus"])
+		volume.EncryptionMethod, _ = stringFromAny(item["EncryptionMethod"])
+		if percent, ok := floatFromAny(item["EncryptionPercentage"]); ok {
+			volume.EncryptionPercentage = &percent
+		}
+		switch protectors := item["KeyProtector"].(type) {
+		case []any:
+			for _, p := range protectors {
+				if name, ok := stringFromAny(p); ok {
+					volume.Protectors = append(volume.Protectors, name)
+				}
+			}
+		case string:
+			volume.Protectors = []string{protectors}
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes, nil
+}
+
+// GetBitLockerVolume reads the current state of one volume.
+func GetBitLockerVolume(mount string) (*BitLockerVolume, error) {
+	if runtime.GOOS != "windows" {
+		return nil, fmt.Errorf("bitlocker: %w", ErrNotSupported)
+	}
+	output, err := runCommand(15*time.Second, "powershell", "-NoProfile", "-NonInteractive", "-Command", bitlockerVolumeScript(mount))
+	if err != nil {
+		return nil, fmt.Errorf("bitlocker volume query failed: %w", err)
+	}
+	volumes, err := parseBitLockerVolumes(output)
+	if err != nil {
+		return nil, err
+	}
+	if len(volumes) == 0 {
+		return nil, fmt.Errorf("volume %s not found", mount)
+	}
+	return &volumes[0], nil
+}
+
+// BitLockerEnableOptions is the enable command's payload. The PIN is
+// required for the tpm_pin protector and never leaves this process except
+// on the PowerShell's stdin.
+type BitLockerEnableOptions struct {
+	Mount            string `json:"volumeMount"`
+	Protector        string `json:"protector"`
+	PIN              string `json:"-"`
+	EncryptionMethod string `json:"encryptionMethod"`
+	UsedSpaceOnly    bool   `json:"usedSpaceOnly"`
+	SkipHardwareTest bool   `json:"skipHardwareTest"`
+}
+
+// Normalize applies defaults (the system drive, a TPM protector and
+// XTS-AES 128, matching Windows) and validates the options.
+func (o *BitLockerEnableOptions) Normalize() error {
+	o.Mount = strings.ToUpper(strings.TrimSpace(o.Mount))
+	if o.Mount == "" {
+		o.Mount = "C:"
+	}
+	if !validBitLockerMount(o.Mount) {
+		return fmt.Errorf("invalid volume mount %q", o.Mount)
+	}
+
+	o.Protector = strings.ToLower(strings.TrimSpace(o.Protector))
+	switch o.Protector {
+	case "":
+		o.Protector = BitLockerProtectorTPM
+	case BitLockerProtectorTPM:
+	case BitLockerProtectorTPMPin:
+		if !bitlockerPinPattern.MatchString(o.PIN) {
+			return errors.New("tpm_pin requires a PIN of 6 to 20 digits")
+		}
+	default:
+		return fmt.Errorf("unknown protector %q: use tpm or tpm_pin", o.Protector)
+	}
+	if o.Protector != BitLockerProtectorTPMPin && o.PIN != "" {
+		return errors.New("a PIN is only used with the tpm_pin protector")
+	}
+
+	o.EncryptionMethod = strings.ToLower(strings.TrimSpace(o.EncryptionMethod))
+	if o.EncryptionMethod == "" {
+		o.EncryptionMethod = "xts_aes128"
+	}
+	if _, ok := bitlockerEncryptionMethods[o.EncryptionMethod]; !ok {
+		return fmt.Errorf("unknown encryption method %q", o.EncryptionMethod)
+	}
+	return nil
+}
+
+// bitlockerEnableScript builds the Enable-BitLocker call for normalized
+// options. The PIN is read from stdin so it never appears on a command line.
+func bitlockerEnableScript(o BitLockerEnableOptions) string {
+	var script strings.Builder
+	script.WriteString("$ErrorActionPreference = 'Stop'; ")
+	args := []string{
+		"Enable-BitLocker",
+		fmt.Sprintf("-MountPoint '%s'", o.Mount),
+		"-EncryptionMethod " + bitlockerEncryptionMethods[o.EncryptionMethod],
+	}
+	if o.Protector == BitLockerProtectorTPMPin {
+		script.WriteString("$pin = ConvertTo-SecureString ([Console]::In.ReadLine()) -AsPlainText -Force; ")
+		args = append(args, "-TpmAndPinProtector", "-Pin $pin")
+	} else {
+		args = append(args, "-TpmProtector")
+	}
+	if o.UsedSpaceOnly {
+		args = append(args, "-UsedSpaceOnly")
+	}
+	if o.SkipHardwareTest {
+		args = append(args, "-SkipHardwareTest")
+	}
+	script.WriteString(strings.Join(args, " ") + " | Out-Null")
+	return script.String()
+}
+
+// EnableBitLocker turns on BitLocker for a volume that is not yet encrypted.
+//
+// The recovery password is added and handed to escrow BEFORE encryption
+// starts; if escrow fails the volume is left unencrypted, so no data is ever
+// protected by a key the server does not hold. An existing recovery password
+// on the volume is reused, so a retry escrows the same key rather than
+// piling up protectors. The returned volume reflects the state right after
+// Enable-BitLocker; without SkipHardwareTest, encryption starts only after
+// the next restart.
+func EnableBitLocker(opts BitLockerEnableOptions, escrow func(RecoveryKey) error) (*BitLockerVolume, error) {
+	if runtime.GOOS != "windows" {
+		return nil, fmt.Errorf("bitlocker: %w", ErrNotSupported)
+	}
+	if err := opts.Normalize(); err != nil {
+		return nil, err
+	}
+
+	volume, err := GetBitLockerVolume(opts.Mount)
+	if err != nil {
+		return nil, err
+	}
+	if volume.VolumeStatus != "FullyDecrypted" || volume.Protected() {
+		return nil, fmt.Errorf("bitlocker is already enabled on %s (%s); rotate the recovery key instead", opts.Mount, volume.VolumeStatus)
+	}
+
+	key, err := bitlockerRecoveryPassword(opts.Mount)
+	if err != nil {
+		return nil, err
+	}
+	if err := escrow(key); err != nil {
+		return nil, fmt.Errorf("recovery key escrow failed, bitlocker was not enabled: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if _, err := runToolCommand(ctx, opts.PIN+"\n", "powershell", "-NoProfile", "-NonInteractive", "-Command", bitlockerEnableScript(opts)); err != nil {
+		return nil, fmt.Errorf("enable bitlocker: %w", err)
+	}
+	return GetBitLockerVolume(opts.Mount)
+}
+
+// bitlockerRecoveryPassword returns the volume's recovery password, adding
+// one first if it has none.
+func bitlockerRecoveryPassword(mount string) (RecoveryKey, error) {
+	find := func() (*RecoveryKey, error) {
+		keys, err := CollectRecoveryKeys()
+		if err != nil {
+			return nil, err
+		}
+		for i := range keys {
+			if strings.EqualFold(keys[i].Mount, mount) {
+				return &keys[i], nil
+			}
+		}
+		return nil, nil
+	}
+
+	key, err := find()
+	if err != nil {
+		return RecoveryKey{}, err
+	}
+	if key != nil {
+		return *key, nil
+	}
+	if _, err := runCommand(30*time.Second, "powershell", "-NoProfile", "-NonInteractive", "-Command",
+		fmt.Sprintf("Add-BitLockerKeyProtector -MountPoint '%s' -RecoveryPasswordProtector | Out-Null", mount)); err != nil {
+		return RecoveryKey{}, fmt.Errorf("add recovery password protector: %w", err)
+	}
+	key, err = find()
+	if err != nil {
+		return RecoveryKey{}, err
+	}
+	if key == nil {
+		return RecoveryKey{}, errors.New("recovery password protector not found after add")
+	}
+	return *key, nil
+}
@@ -0,0 +1,75 @@
+package security
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBitLockerVolumes(t *testing.T) {
+	output := `[{"MountPoint":"C:","VolumeStatus":"EncryptionInProgress","ProtectionStatus":"Off","EncryptionMethod":"XtsAes128","EncryptionPercentage":42.5,"KeyProtector":["Tpm","RecoveryPassword"]},` +
+		`{"MountPoint":"d:","VolumeStatus":"FullyDecrypted","ProtectionStatus":"Off","EncryptionMethod":"None","EncryptionPercentage":0,"KeyProtector":[]}]`
+	volumes, err := parseBitLockerVolumes(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(volumes) != 2 {
+		t.Fatalf("expected 2 volumes, got %+v", volumes)
+	}
+	c := volumes[0]
+	if c.Mount != "C:" || !c.InProgress() || c.Protected() || c.EncryptionPercentage == nil || *c.EncryptionPercentage != 42.5 || len(c.Protectors) != 2 {
+		t.Fatalf("unexpected volume %+v", c)
+	}
+	if d := volumes[1]; d.Mount != "D:" || d.InProgress() || len(d.Protectors) != 0 {
+		t.Fatalf("unexpected volume %+v", d)
+	}
+
+	details := bitlockerEncryptionDetails(volumes, "C:")
+	if details["systemDriveInProgress"] != true {
+		t.Fatalf("system drive should be in progress: %+v", details)
+	}
+	entry := details["volumes"].([]map[string]any)[0]
+	if entry["status"] != "EncryptionInProgress" || entry["method"] != "xtsaes128" || entry["percentEncrypted"] != 42.5 {
+		t.Fatalf("unexpected details entry %+v", entry)
+	}
+	if _, ok := bitlockerEncryptionDetails(volumes, "D:")["systemDriveInProgress"]; ok {
+		t.Fatal("only the system drive decides the in-progress flag")
+	}
+}
+
+func TestBitLockerEnableOptions(t *testing.T) {
+	opts := BitLockerEnableOptions{Mount: "c:"}
+	if err := opts.Normalize(); err != nil {
+		t.Fatalf("valid options rejected: %v", err)
+	}
+	if opts.Mount != "C:" || opts.Protector != BitLockerProtectorTPM || opts.EncryptionMethod != "xts_aes128" {
+		t.Fatalf("defaults not applied: %+v", opts)
+	}
+	if script := bitlockerEnableScript(opts); !strings.HasSuffix(script, "Enable-BitLocker -MountPoint 'C:' -EncryptionMethod XtsAes128 -TpmProtector | Out-Null") {
+		t.Fatalf("script = %s", script)
+	}
+
+	opts = BitLockerEnableOptions{Protector: "tpm_pin", PIN: "123456", EncryptionMethod: "xts_aes256", UsedSpaceOnly: true, SkipHardwareTest: true}
+	if err := opts.Normalize(); err != nil {
+		t.Fatalf("valid options rejected: %v", err)
+	}
+	script := bitlockerEnableScript(opts)
+	if strings.Contains(script, "123456") {
+		t.Fatal("the PIN must not be part of the script")
+	}
+	if !strings.HasSuffix(script, "-EncryptionMethod XtsAes256 -TpmAndPinProtector -Pin $pin -UsedSpaceOnly -SkipHardwareTest | Out-Null") {
+		t.Fatalf("script = %s", script)
+	}
+
+	for name, opts := range map[string]BitLockerEnableOptions{
+		"bad mount":       {Mount: "C:\\"},
+		"missing pin":     {Protector: "tpm_pin"},
+		"short pin":       {Protector: "tpm_pin", PIN: "1234"},
+		"pin without tpm": {Protector: "tpm", PIN: "123456"},
+		"bad protector":   {Protector: "password"},
+		"bad method":      {EncryptionMethod: "des"},
+	} {
+		if err := opts.Normalize(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
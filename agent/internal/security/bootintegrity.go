@@ -0,0 +1,232 @@
+package security
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// TPMStatus describes the device's Trusted Platform Module. Pointer fields
+// are nil when the platform does not report them.
+type TPMStatus struct {
+	Present bool `json:"present"`
+	// Version is the specification family, "2.0" or "1.2".
+	Version string `json:"version,omitempty"`
+	Enabled *bool  `json:"enabled,omitempty"`
+	// Ready means Windows has provisioned the TPM and can use it.
+	Ready        *bool  `json:"ready,omitempty"`
+	Owned        *bool  `json:"owned,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+}
+
+// SecureBootStatus describes UEFI Secure Boot, or Apple's equivalent.
+type SecureBootStatus struct {
+	// Supported is false when the firmware cannot enforce Secure Boot, such
+	// as a legacy BIOS boot.
+	Supported bool `json:"supported"`
+	Enabled   bool `json:"enabled"`
+	// Mode is the T2 policy on Intel Macs: full, medium or none.
+	Mode string `json:"mode,omitempty"`
+}
+
+// bootIntegrity is what collectBootIntegrity found. Each part is nil when it
+// could not be determined, which is not the same as absent or off.
+type bootIntegrity struct {
+	TPM          *TPMStatus
+	SecureBoot   *SecureBootStatus
+	MeasuredBoot *bool
+}
+
+func collectBootIntegrity() bootIntegrity {
+	switch runtime.GOOS {
+	case "windows":
+		output, err := runCommand(20*time.Second, "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsBootIntegrityScript)
+		if err != nil {
+			return bootIntegrity{}
+		}
+		return parseWindowsBootIntegrity(output)
+	case "linux":
+		return collectLinuxBootIntegrity("/")
+	case "darwin":
+		return collectDarwinBootIntegrity()
+	default:
+		return bootIntegrity{}
+	}
+}
+
+// Confirm-SecureBootUEFI throws PlatformNotSupportedException on BIOS
+// machines; any other failure leaves Secure Boot unknown. Windows keeps a
+// TCG log per boot in MeasuredBoot when the firmware measures the boot.
+const windowsBootIntegrityScript = `$ErrorActionPreference = 'Stop'
+$r = @{}
+try {
+  $t = Get-Tpm
+  $r.tpmPresent = [bool]$t.TpmPresent
+  $r.tpmEnabled = [bool]$t.TpmEnabled
+  $r.tpmReady = [bool]$t.TpmReady
+  $r.tpmOwned = [bool]$t.TpmOwned
+  $r.manufacturer = [string]$t.ManufacturerIdTxt
+} catch {}
+try {
+  $w = Get-CimInstance -Namespace 'root\cimv2\security\microsofttpm' -ClassName Win32_Tpm
+  if ($w) { $r.specVersion = [string]$w.SpecVersion }
+} catch {}
+try {
+  $r.secureBoot = [bool](Confirm-SecureBootUEFI)
+  $r.uefi = $true
+} catch [System.PlatformNotSupportedException] {
+  $r.uefi = $false
+} catch {}
+$r.measuredBootLogs = @(Get-ChildItem -Path (Join-Path $env:SystemRoot 'Logs\MeasuredBoot') -Filter '*.log' -ErrorAction SilentlyContinue).Count
+$r | ConvertTo-Json -Compress`
+
+func parseWindowsBootIntegrity(output string) bootIntegrity {
+	var raw struct {
+		TPMPresent       *bool  `json:"tpmPresent"`
+		TPMEnabled       *bool  `json:"tpmEnabled"`
+		TPMReady         *bool  `json:"tpmReady"`
+		TPMOwned         *bool  `json:"tpmOwned"`
+		Manufacturer     string `json:"manufacturer"`
+		SpecVersion      string `json:"specVersion"`
+		SecureBoot       *bool  `json:"secureBoot"`
+		UEFI             *bool  `json:"uefi"`
+		MeasuredBootLogs int    `json:"measuredBootLogs"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &raw); err != nil {
+		return bootIntegrity{}
+	}
+
+	var result bootIntegrity
+	if raw.TPMPresent != nil {
+		result.TPM = &TPMStatus{Present: *raw.TPMPresent}
+		if *raw.TPMPresent {
+			result.TPM.Enabled = raw.TPMEnabled
+			result.TPM.Ready = raw.TPMReady
+			result.TPM.Owned = raw.TPMOwned
+			result.TPM.Manufacturer = strings.TrimSpace(raw.Manufacturer)
+			// SpecVersion lists the family first: "2.0, 0, 1.38".
+			version, _, _ := strings.Cut(raw.SpecVersion, ",")
+			result.TPM.Version = strings.TrimSpace(version)
+		}
+		measured := *raw.TPMPresent && raw.MeasuredBootLogs > 0
+		result.MeasuredBoot = &measured
+	}
+	switch {
+	case raw.UEFI != nil && !*raw.UEFI:
+		result.SecureBoot = &SecureBootStatus{}
+	case raw.SecureBoot != nil:
+		result.SecureBoot = &SecureBootStatus{Supported: true, Enabled: *raw.SecureBoot}
+	}
+	return result
+}
+
+// The EFI global variable GUID; efivarfs prefixes the value with four bytes
+// of attributes.
+const linuxSecureBootVar = "sys/firmware/efi/efivars/SecureBoot-8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+// collectLinuxBootIntegrity reads sysfs and securityfs under root, which
+// is "/" outside tests.
+func collectLinuxBootIntegrity(root string) bootIntegrity {
+	var result bootIntegrity
+	exists := func(rel string) bool {
+		_, err := os.Stat(filepath.Join(root, rel))
+		return err == nil
+	}
+	readTrimmed := func(rel string) (string, bool) {
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			return "", false
+		}
+		return strings.TrimSpace(string(data)), true
+	}
+	readFlag := func(rels ...string) *bool {
+		for _, rel := range rels {
+			if value, ok := readTrimmed(rel); ok {
+				flag := value == "1"
+				return &flag
+			}
+		}
+		return nil
+	}
+
+	tpm := &TPMStatus{Present: exists("sys/class/tpm/tpm0")}
+	if tpm.Present {
+		switch major, _ := readTrimmed("sys/class/tpm/tpm0/tpm_version_major"); {
+		case major == "2":
+			tpm.Version = "2.0"
+		case major == "1":
+			tpm.Version = "1.2"
+		case exists("dev/tpmrm0"):
+			// The resource manager device only exists for TPM 2.0.
+			tpm.Version = "2.0"
+		}
+		// Only TPM 1.2 drivers expose these; older kernels keep them
+		// under device/.
+		tpm.Enabled = readFlag("sys/class/tpm/tpm0/enabled", "sys/class/tpm/tpm0/device/enabled")
+		tpm.Owned = readFlag("sys/class/tpm/tpm0/owned", "sys/class/tpm/tpm0/device/owned")
+	}
+	result.TPM = tpm
+
+	if !exists("sys/firmware/efi") {
+		result.SecureBoot = &SecureBootStatus{}
+	} else if data, err := os.ReadFile(filepath.Join(root, linuxSecureBootVar)); err == nil && len(data) >= 5 {
+		result.SecureBoot = &SecureBootStatus{Supported: true, Enabled: data[4] == 1}
+	} else if err != nil && os.IsNotExist(err) && exists("sys/firmware/efi/efivars") {
+		// UEFI firmware without the variable has no Secure Boot support.
+		result.SecureBoot = &SecureBootStatus{}
+	}
+
+	switch {
+	case exists("sys/kernel/security/tpm0/binary_bios_measurements"):
+		measured := true
+		result.MeasuredBoot = &measured
+	case !tpm.Present:
+		measured := false
+		result.MeasuredBoot = &measured
+	default:
+		// Without securityfs mounted the event log cannot be seen, so an
+		// absent log only counts when securityfs is there.
+		if entries, err := os.ReadDir(filepath.Join(root, "sys/kernel/security")); err == nil && len(entries) > 0 {
+			measured := false
+			result.MeasuredBoot = &measured
+		}
+	}
+	return result
+}
+
+// Macs have no TPM. Apple silicon always verifies the boot chain; Intel
+// Macs with a T2 chip report their policy in NVRAM and others have none.
+func collectDarwinBootIntegrity() bootIntegrity {
+	result := bootIntegrity{TPM: &TPMStatus{Present: false}}
+	if runtime.GOARCH == "arm64" {
+		result.SecureBoot = &SecureBootStatus{Supported: true, Enabled: true}
+		return result
+	}
+	output, err := runCommand(5*time.Second, "nvram", "94b73556-2197-4702-82a8-3e1337dafbfb:AppleSecureBootPolicy")
+	if err != nil {
+		result.SecureBoot = &SecureBootStatus{}
+		return result
+	}
+	result.SecureBoot = parseAppleSecureBootPolicy(output)
+	return result
+}
+
+func parseAppleSecureBootPolicy(output string) *SecureBootStatus {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return nil
+	}
+	switch fields[len(fields)-1] {
+	case "%02":
+		return &SecureBootStatus{Supported: true, Enabled: true, Mode: "full"}
+	case "%01":
+		return &SecureBootStatus{Supported: true, Enabled: true, Mode: "medium"}
+	case "%00":
+		return &SecureBootStatus{Supported: true, Enabled: false, Mode: "none"}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseWindowsBootIntegrity(t *testing.T) {
+	boot := parseWindowsBootIntegrity(`{"tpmPresent":true,"tpmEnabled":true,"tpmReady":true,"tpmOwned":true,"manufacturer":"INTC ","specVersion":"2.0, 0, 1.38","secureBoot":true,"uefi":true,"measuredBootLogs":12}`)
+	if boot.TPM == nil || !boot.TPM.Present || boot.TPM.Version != "2.0" || boot.TPM.Manufacturer != "INTC" || boot.TPM.Owned == nil || !*boot.TPM.Owned {
+		t.Fatalf("unexpected tpm %+v", boot.TPM)
+	}
+	if boot.SecureBoot == nil || !boot.SecureBoot.Supported || !boot.SecureBoot.Enabled {
+		t.Fatalf("unexpected secure boot %+v", boot.SecureBoot)
+	}
+	if boot.MeasuredBoot == nil || !*boot.MeasuredBoot {
+		t.Fatal("expected measured boot")
+	}
+
+	boot = parseWindowsBootIntegrity(`{"tpmPresent":false,"uefi":false,"measuredBootLogs":3}`)
+	if boot.TPM == nil || boot.TPM.Present || boot.TPM.Owned != nil {
+		t.Fatalf("unexpected tpm %+v", boot.TPM)
+	}
+	if boot.SecureBoot == nil || boot.SecureBoot.Supported || boot.SecureBoot.Enabled {
+		t.Fatalf("legacy BIOS should be unsupported: %+v", boot.SecureBoot)
+	}
+	if boot.MeasuredBoot == nil || *boot.MeasuredBoot {
+		t.Fatal("measured boot needs a TPM")
+	}
+
+	// Get-Tpm and Confirm-SecureBootUEFI both failed: nothing is known.
+	if boot := parseWindowsBootIntegrity(`{"measuredBootLogs":0}`); boot.TPM != nil || boot.SecureBoot != nil || boot.MeasuredBoot != nil {
+		t.Fatalf("unknown values must stay nil: %+v", boot)
+	}
+}
+
+func TestCollectLinuxBootIntegrity(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel string, data []byte) {
+		t.Helper()
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A legacy BIOS VM without a TPM.
+	boot := collectLinuxBootIntegrity(root)
+	if boot.TPM == nil || boot.TPM.Present || boot.SecureBoot == nil || boot.SecureBoot.Supported || boot.MeasuredBoot == nil || *boot.MeasuredBoot {
+		t.Fatalf("unexpected empty system %+v %+v", boot.TPM, boot.SecureBoot)
+	}
+
+	write("sys/class/tpm/tpm0/tpm_version_major", []byte("2\n"))
+	write(linuxSecureBootVar, []byte{0x06, 0x00, 0x00, 0x00, 0x01})
+	boot = collectLinuxBootIntegrity(root)
+	if !boot.TPM.Present || boot.TPM.Version != "2.0" || boot.TPM.Owned != nil {
+		t.Fatalf("unexpected tpm %+v", boot.TPM)
+	}
+	if !boot.SecureBoot.Supported || !boot.SecureBoot.Enabled {
+		t.Fatalf("unexpected secure boot %+v", boot.SecureBoot)
+	}
+	if boot.MeasuredBoot != nil {
+		t.Fatal("without securityfs, measured boot is unknown")
+	}
+
+	write("sys/kernel/security/tpm0/binary_bios_measurements", []byte{0})
+	write(linuxSecureBootVar, []byte{0x06, 0x00, 0x00, 0x00, 0x00})
+	boot = collectLinuxBootIntegrity(root)
+	if boot.MeasuredBoot == nil || !*boot.MeasuredBoot || boot.SecureBoot.Enabled {
+		t.Fatalf("unexpected %+v %+v", boot.MeasuredBoot, boot.SecureBoot)
+	}
+}
+
+func TestParseAppleSecureBootPolicy(t *testing.T) {
+	if status := parseAppleSecureBootPolicy("94b73556-2197-4702-82a8-3e1337dafbfb:AppleSecureBootPolicy\t%02"); status == nil || !status.Enabled || status.Mode != "full" {
+		t.Fatalf("unexpected %+v", status)
+	}
+	if status := parseAppleSecureBootPolicy("94b73556-2197-4702-82a8-3e1337dafbfb:AppleSecureBootPolicy\t%00"); status == nil || status.Enabled || !status.Supported {
+		t.Fatalf("unexpected %+v", status)
+	}
+	if status := parseAppleSecureBootPolicy(""); status != nil {
+		t.Fatalf("unexpected %+v", status)
+	}
+}
@@ -0,0 +1,373 @@
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Attack surface reduction rule actions.
+const (
+	ASRActionDisabled = "disabled"
+	ASRActionBlock    = "block"
+	ASRActionAudit    = "audit"
+	ASRActionWarn     = "warn"
+)
+
+// asrActionValues maps ASR actions to the AttackSurfaceReductionRules_Actions
+// values Get-MpPreference reports.
+var asrActionValues = map[string]int{
+	ASRActionDisabled: 0,
+	ASRActionBlock:    1,
+	ASRActionAudit:    2,
+	ASRActionWarn:     6,
+}
+
+// asrActionNames maps ASR actions to the names Add-MpPreference accepts.
+var asrActionNames = map[string]string{
+	ASRActionDisabled: "Disabled",
+	ASRActionBlock:    "Enabled",
+	ASRActionAudit:    "AuditMode",
+	ASRActionWarn:     "Warn",
+}
+
+// cloudBlockLevelValues maps cloud protection levels to CloudBlockLevel.
+var cloudBlockLevelValues = map[string]int{
+	"default":        0,
+	"moderate":       1,
+	"high":           2,
+	"high_plus":      4,
+	"zero_tolerance": 6,
+}
+
+// mapsReportingValues maps cloud-delivered protection membership to
+// MAPSReporting.
+var mapsReportingValues = map[string]int{
+	"disabled": 0,
+	"basic":    1,
+	"advanced": 2,
+}
+
+var asrRuleIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ErrTamperProtectionReadOnly is returned for a request to change tamper
+// protection, which Windows only allows from the Defender portal or Intune.
+var ErrTamperProtectionReadOnly = errors.New("tamper protection can only be changed from the Microsoft Defender portal or Intune")
+
+// DefenderPreferences is the Microsoft Defender policy the console manages.
+// ASRRules maps lower-case rule GUIDs to an ASRAction*. TamperProtection is
+// "enabled", "disabled" or "unknown" and is reported, never set.
+type DefenderPreferences struct {
+	ExclusionPaths      []string          `json:"exclusionPaths"`
+	ExclusionExtensions []string          `json:"exclusionExtensions"`
+	ExclusionProcesses  []string          `json:"exclusionProcesses"`
+	ASRRules            map[string]string `json:"asrRules"`
+	CloudBlockLevel     string            `json:"cloudBlockLevel"`
+	MAPSReporting       string            `json:"mapsReporting"`
+	TamperProtection    string            `json:"tamperProtection"`
+}
+
+// DefenderPreferenceChange is a requested policy update; empty and nil
+// fields leave the setting alone.
+type DefenderPreferenceChange struct {
+	AddExclusionPaths         []string          `json:"addExclusionPaths,omitempty"`
+	RemoveExclusionPaths      []string          `json:"removeExclusionPaths,omitempty"`
+	AddExclusionExtensions    []string          `json:"addExclusionExtensions,omitempty"`
+	RemoveExclusionExtensions []string          `json:"removeExclusionExtensions,omitempty"`
+	AddExclusionProcesses     []string          `json:"addExclusionProcesses,omitempty"`
+	RemoveExclusionProcesses  []string          `json:"removeExclusionProcesses,omitempty"`
+	ASRRules                  map[string]string `json:"asrRules,omitempty"`
+	CloudBlockLevel           *string           `json:"cloudBlockLevel,omitempty"`
+	MAPSReporting             *string           `json:"mapsReporting,omitempty"`
+	TamperProtection          *string           `json:"tamperProtection,omitempty"`
+}
+
+// DefenderPreferenceDiff is one setting that differs between two reads of
+// the preferences. Item names the exclusion or ASR rule for list settings.
+type DefenderPreferenceDiff struct {
+	Setting string `json:"setting"`
+	Item    string `json:"item,omitempty"`
+	Action  string `json:"action"`
+	Before  any    `json:"before,omitempty"`
+	After   any    `json:"after,omitempty"`
+}
+
+// Validate rejects a change that cannot be applied as asked.
+func (c DefenderPreferenceChange) Validate() error {
+	if c.TamperProtection != nil {
+		return ErrTamperProtectionReadOnly
+	}
+	empty := true
+	for _, list := range [][]string{
+		c.AddExclusionPaths, c.RemoveExclusionPaths,
+		c.AddExclusionExtensions, c.RemoveExclusionExtensions,
+		c.AddExclusionProcesses, c.RemoveExclusionProcesses,
+	} {
+		for _, item := range list {
+			if strings.TrimSpace(item) == "" {
+				return fmt.Errorf("exclusions must not be empty")
+			}
+			empty = false
+		}
+	}
+	for id, action := range c.ASRRules {
+		if !asrRuleIDPattern.MatchString(id) {
+			return fmt.Errorf("invalid ASR rule id: %s", id)
+		}
+		if _, ok := asrActionValues[action]; !ok {
+			return fmt.Errorf("invalid action %q for ASR rule %s", action, id)
+		}
+		empty = false
+	}
+	if c.CloudBlockLevel != nil {
+		if _, ok := cloudBlockLevelValues[*c.CloudBlockLevel]; !ok {
+			return fmt.Errorf("invalid cloud block level: %s", *c.CloudBlockLevel)
+		}
+		empty = false
+	}
+	if c.MAPSReporting != nil {
+		if _, ok := mapsReportingValues[*c.MAPSReporting]; !ok {
+			return fmt.Errorf("invalid MAPS reporting level: %s", *c.MAPSReporting)
+		}
+		empty = false
+	}
+	if empty {
+		return fmt.Errorf("no Defender preference changes requested")
+	}
+	return nil
+}
+
+// defenderSetScript builds the PowerShell that applies a validated change.
+// Add-MpPreference also updates the action of an ASR rule already present.
+func defenderSetScript(c DefenderPreferenceChange) string {
+	statements := []string{"$ErrorActionPreference = 'Stop'"}
+	list := func(verb, param string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		quoted := make([]string, len(items))
+		for i, item := range items {
+			quoted[i] = psQuote(strings.TrimSpace(item))
+		}
+		statements = append(statements, fmt.Sprintf("%s -%s %s", verb, param, strings.Join(quoted, ",")))
+	}
+	list("Remove-MpPreference", "ExclusionPath", c.RemoveExclusionPaths)
+	list("Remove-MpPreference", "ExclusionExtension", c.RemoveExclusionExtensions)
+	list("Remove-MpPreference", "ExclusionProcess", c.RemoveExclusionProcesses)
+	list("Add-MpPreference", "ExclusionPath", c.AddExclusionPaths)
+	list("Add-MpPreference", "ExclusionExtension", c.AddExclusionExtensions)
+	list("Add-MpPreference", "ExclusionProcess", c.AddExclusionProcesses)
+
+	ids := make([]string, 0, len(c.ASRRules))
+	for id := range c.ASRRules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		statements = append(statements, fmt.Sprintf(
+			"Add-MpPreference -AttackSurfaceReductionRules_Ids %s -AttackSurfaceReductionRules_Actions %s",
+			psQuote(strings.ToLower(id)), asrActionNames[c.ASRRules[id]]))
+	}
+
+	// MAPS membership first: cloud block levels above default need it.
+	if c.MAPSReporting != nil {
+		statements = append(statements, fmt.Sprintf("Set-MpPreference -MAPSReporting %d", mapsReportingValues[*c.MAPSReporting]))
+	}
+	if c.CloudBlockLevel != nil {
+		statements = append(statements, fmt.Sprintf("Set-MpPreference -CloudBlockLevel %d", cloudBlockLevelValues[*c.CloudBlockLevel]))
+	}
+	return strings.Join(statements, "; ")
+}
+
+func psQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// defenderPreferencesRaw is the projection defenderGetScript emits.
+type defenderPreferencesRaw struct {
+	ExclusionPath      []string `json:"ExclusionPath"`
+	ExclusionExtension []string `json:"ExclusionExtension"`
+	ExclusionProcess   []string `json:"ExclusionProcess"`
+	ASRRuleIDs         []string `json:"AsrIds"`
+	ASRRuleActions     []int    `json:"AsrActions"`
+	CloudBlockLevel    int      `json:"CloudBlockLevel"`
+	MAPSReporting      int      `json:"MAPSReporting"`
+	IsTamperProtected  *bool    `json:"IsTamperProtected"`
+}
+
+const defenderGetScript = "$p = Get-MpPreference; $s = Get-MpComputerStatus; " +
+	"[pscustomobject]@{ ExclusionPath = @($p.ExclusionPath); ExclusionExtension = @($p.ExclusionExtension); " +
+	"ExclusionProcess = @($p.ExclusionProcess); AsrIds = @($p.AttackSurfaceReductionRules_Ids); " +
+	"AsrActions = @($p.AttackSurfaceReductionRules_Actions | ForEach-Object { [int]$_ }); " +
+	"CloudBlockLevel = [int]$p.CloudBlockLevel; MAPSReporting = [int]$p.MAPSReporting; " +
+	"IsTamperProtected = $s.IsTamperProtected } | ConvertTo-Json -Compress -Depth 3"
+
+// parseDefenderPreferences parses the defenderGetScript output.
+func parseDefenderPreferences(output []byte) (DefenderPreferences, error) {
+	payload := strings.TrimSpace(string(output))
+	if idx := strings.Index(payload, "{"); idx > 0 {
+		payload = payload[idx:]
+	}
+	var raw defenderPreferencesRaw
+	if err := json.Unmarshal([]byte(payload), &raw); err != nil {
+		return DefenderPreferences{}, fmt.Errorf("failed to parse defender preferences: %w", err)
+	}
+
+	prefs := DefenderPreferences{
+		ExclusionPaths:      cleanExclusions(raw.ExclusionPath),
+		ExclusionExtensions: cleanExclusions(raw.ExclusionExtension),
+		ExclusionProcesses:  cleanExclusions(raw.ExclusionProcess),
+		ASRRules:            map[string]string{},
+		CloudBlockLevel:     nameForValue(cloudBlockLevelValues, raw.CloudBlockLevel),
+		MAPSReporting:       nameForValue(mapsReportingValues, raw.MAPSReporting),
+		TamperProtection:    "unknown",
+	}
+	for i, id := range raw.ASRRuleIDs {
+		if strings.TrimSpace(id) == "" {
+			continue
+		}
+		action := ASRActionDisabled
+		if i < len(raw.ASRRuleActions) {
+			action = nameForValue(asrActionValues, raw.ASRRuleActions[i])
+		}
+		prefs.ASRRules[strings.ToLower(strings.TrimSpace(id))] = action
+	}
+	if raw.IsTamperProtected != nil {
+		prefs.TamperProtection = "disabled"
+		if *raw.IsTamperProtected {
+			prefs.TamperProtection = "enabled"
+		}
+	}
+	return prefs, nil
+}
+
+// cleanExclusions drops the placeholder Get-MpPreference returns in place
+// of exclusions the caller may not read, and sorts the rest.
+func cleanExclusions(items []string) []string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" || strings.HasPrefix(item, "N/A") {
+			continue
+		}
+		out = append(out, item)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func nameForValue(values map[string]int, value int) string {
+	for name, v := range values {
+		if v == value {
+			return name
+		}
+	}
+	return strconv.Itoa(value)
+}
+
+// DiffDefenderPreferences lists the settings that differ between two reads,
+// one entry per added or removed exclusion and per changed ASR rule.
+func DiffDefenderPreferences(before, after DefenderPreferences) []DefenderPreferenceDiff {
+	var diffs []DefenderPreferenceDiff
+	diffList := func(setting string, was, now []string) {
+		for _, item := range now {
+			if !containsFold(was, item) {
+				diffs = append(diffs, DefenderPreferenceDiff{Setting: setting, Item: item, Action: "added", After: item})
+			}
+		}
+		for _, item := range was {
+			if !containsFold(now, item) {
+				diffs = append(diffs, DefenderPreferenceDiff{Setting: setting, Item: item, Action: "removed", Before: item})
+			}
+		}
+	}
+	diffList("exclusionPath", before.ExclusionPaths, after.ExclusionPaths)
+	diffList("exclusionExtension", before.ExclusionExtensions, after.ExclusionExtensions)
+	diffList("exclusionProcess", before.ExclusionProcesses, after.ExclusionProcesses)
+
+	ids := make([]string, 0, len(before.ASRRules)+len(after.ASRRules))
+	for id := range before.ASRRules {
+		ids = append(ids, id)
+	}
+	for id := range after.ASRRules {
+		if _, ok := before.ASRRules[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		was, hadRule := before.ASRRules[id]
+		now, hasRule := after.ASRRules[id]
+		switch {
+		case !hadRule:
+			diffs = append(diffs, DefenderPreferenceDiff{Setting: "asrRule", Item: id, Action: "added", After: now})
+		case !hasRule:
+			diffs = append(diffs, DefenderPreferenceDiff{Setting: "asrRule", Item: id, Action: "removed", Before: was})
+		case was != now:
+			diffs = append(diffs, DefenderPreferenceDiff{Setting: "asrRule", Item: id, Action: "modified", Before: was, After: now})
+		}
+	}
+
+	for _, scalar := range []struct {
+		setting       string
+		before, after string
+	}{
+		{"cloudBlockLevel", before.CloudBlockLevel, after.CloudBlockLevel},
+		{"mapsReporting", before.MAPSReporting, after.MAPSReporting},
+		{"tamperProtection", before.TamperProtection, after.TamperProtection},
+	} {
+		if scalar.before != scalar.after {
+			diffs = append(diffs, DefenderPreferenceDiff{Setting: scalar.setting, Action: "modified", Before: scalar.before, After: scalar.after})
+		}
+	}
+	return diffs
+}
+
+// UnappliedDefenderChanges lists the parts of a change the preferences read
+// back afterwards do not reflect. Defender drops changes silently when tamper
+// protection or a group policy owns the setting.
+func UnappliedDefenderChanges(c DefenderPreferenceChange, after DefenderPreferences) []string {
+	var unapplied []string
+	check := func(setting string, items []string, current []string, wantPresent bool) {
+		for _, item := range items {
+			if containsFold(current, strings.TrimSpace(item)) != wantPresent {
+				unapplied = append(unapplied, fmt.Sprintf("%s %s", setting, item))
+			}
+		}
+	}
+	check("exclusionPath", c.AddExclusionPaths, after.ExclusionPaths, true)
+	check("exclusionPath", c.RemoveExclusionPaths, after.ExclusionPaths, false)
+	check("exclusionExtension", c.AddExclusionExtensions, after.ExclusionExtensions, true)
+	check("exclusionExtension", c.RemoveExclusionExtensions, after.ExclusionExtensions, false)
+	check("exclusionProcess", c.AddExclusionProcesses, after.ExclusionProcesses, true)
+	check("exclusionProcess", c.RemoveExclusionProcesses, after.ExclusionProcesses, false)
+
+	ids := make([]string, 0, len(c.ASRRules))
+	for id := range c.ASRRules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if after.ASRRules[strings.ToLower(id)] != c.ASRRules[id] {
+			unapplied = append(unapplied, "asrRule "+strings.ToLower(id))
+		}
+	}
+	if c.CloudBlockLevel != nil && after.CloudBlockLevel != *c.CloudBlockLevel {
+		unapplied = append(unapplied, "cloudBlockLevel")
+	}
+	if c.MAPSReporting != nil && after.MAPSReporting != *c.MAPSReporting {
+		unapplied = append(unapplied, "mapsReporting")
+	}
+	return unapplied
+}
+
+func containsFold(items []string, value string) bool {
+	return slices.ContainsFunc(items, func(item string) bool {
+		return strings.EqualFold(item, value)
+	})
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package security
+
+// GetDefenderPreferences returns an unsupported error on non-Windows platforms.
+func GetDefenderPreferences() (DefenderPreferences, error) {
+	return DefenderPreferences{}, newPlatformError("GetDefenderPreferences")
+}
+
+// SetDefenderPreferences returns an unsupported error on non-Windows platforms.
+func SetDefenderPreferences(DefenderPreferenceChange) (*DefenderPreferences, *DefenderPreferences, error) {
+	return nil, nil, newPlatformError("SetDefenderPreferences")
+}
@@ -0,0 +1,153 @@
+package security
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const asrBlockOfficeChildProcesses = "d4f940ab-401b-4efc-aadc-ad5f3c50688a"
+const asrBlockCredentialStealing = "9e6c4e1f-7d60-472f-ba1a-a39ef669e4b2"
+
+func TestParseDefenderPreferences(t *testing.T) {
+	output := `WARNING: something noisy
+{"ExclusionPath":["D:\\Builds","C:\\Tools",null],"ExclusionExtension":[],"ExclusionProcess":["N/A: Must be an administrator to view exclusions"],` +
+		`"AsrIds":["D4F940AB-401B-4EFC-AADC-AD5F3C50688A","9e6c4e1f-7d60-472f-ba1a-a39ef669e4b2"],"AsrActions":[1,2],` +
+		`"CloudBlockLevel":2,"MAPSReporting":2,"IsTamperProtected":true}`
+
+	prefs, err := parseDefenderPreferences([]byte(output))
+	if err != nil {
+		t.Fatalf("parseDefenderPreferences: %v", err)
+	}
+	if strings.Join(prefs.ExclusionPaths, "|") != `C:\Tools|D:\Builds` {
+		t.Fatalf("exclusion paths = %v", prefs.ExclusionPaths)
+	}
+	if len(prefs.ExclusionProcesses) != 0 {
+		t.Fatalf("the not-an-administrator placeholder must be dropped, got %v", prefs.ExclusionProcesses)
+	}
+	if prefs.ASRRules[asrBlockOfficeChildProcesses] != ASRActionBlock || prefs.ASRRules[asrBlockCredentialStealing] != ASRActionAudit {
+		t.Fatalf("ASR rules = %v", prefs.ASRRules)
+	}
+	if prefs.CloudBlockLevel != "high" || prefs.MAPSReporting != "advanced" || prefs.TamperProtection != "enabled" {
+		t.Fatalf("unexpected scalar settings %+v", prefs)
+	}
+
+	prefs, err = parseDefenderPreferences([]byte(`{"AsrIds":[null],"AsrActions":[0],"CloudBlockLevel":0,"MAPSReporting":0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prefs.ASRRules) != 0 || prefs.TamperProtection != "unknown" || prefs.CloudBlockLevel != "default" {
+		t.Fatalf("unexpected defaults %+v", prefs)
+	}
+}
+
+func TestDefenderPreferenceChangeValidate(t *testing.T) {
+	level := "high_plus"
+	valid := DefenderPreferenceChange{
+		AddExclusionPaths: []string{`C:\Build`},
+		ASRRules:          map[string]string{asrBlockOfficeChildProcesses: ASRActionBlock},
+		CloudBlockLevel:   &level,
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid change rejected: %v", err)
+	}
+
+	on := "enabled"
+	if err := (DefenderPreferenceChange{TamperProtection: &on}).Validate(); !errors.Is(err, ErrTamperProtectionReadOnly) {
+		t.Fatalf("tamper protection must be read-only, got %v", err)
+	}
+
+	bad := "extreme"
+	for name, change := range map[string]DefenderPreferenceChange{
+		"empty":           {},
+		"blank exclusion": {RemoveExclusionPaths: []string{" "}},
+		"bad rule id":     {ASRRules: map[string]string{"not-a-guid": ASRActionAudit}},
+		"bad rule action": {ASRRules: map[string]string{asrBlockOfficeChildProcesses: "enabled"}},
+		"bad cloud level": {CloudBlockLevel: &bad},
+		"bad maps level":  {MAPSReporting: &bad},
+	} {
+		if err := change.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestDefenderSetScript(t *testing.T) {
+	level := "zero_tolerance"
+	maps := "advanced"
+	script := defenderSetScript(DefenderPreferenceChange{
+		AddExclusionPaths:    []string{`C:\O'Brien\Tools`},
+		RemoveExclusionPaths: []string{`D:\Old`},
+		ASRRules: map[string]string{
+			strings.ToUpper(asrBlockOfficeChildProcesses): ASRActionWarn,
+			asrBlockCredentialStealing:                    ASRActionDisabled,
+		},
+		CloudBlockLevel: &level,
+		MAPSReporting:   &maps,
+	})
+
+	want := []string{
+		"$ErrorActionPreference = 'Stop'",
+		`Remove-MpPreference -ExclusionPath 'D:\Old'`,
+		`Add-MpPreference -ExclusionPath 'C:\O''Brien\Tools'`,
+		"Add-MpPreference -AttackSurfaceReductionRules_Ids '" + asrBlockCredentialStealing + "' -AttackSurfaceReductionRules_Actions Disabled",
+		"Add-MpPreference -AttackSurfaceReductionRules_Ids '" + asrBlockOfficeChildProcesses + "' -AttackSurfaceReductionRules_Actions Warn",
+		"Set-MpPreference -MAPSReporting 2",
+		"Set-MpPreference -CloudBlockLevel 6",
+	}
+	if script != strings.Join(want, "; ") {
+		t.Fatalf("script =\n%s\nwant\n%s", script, strings.Join(want, "; "))
+	}
+}
+
+func TestDiffDefenderPreferences(t *testing.T) {
+	before := DefenderPreferences{
+		ExclusionPaths:   []string{`C:\Tools`, `D:\Old`},
+		ASRRules:         map[string]string{asrBlockOfficeChildProcesses: ASRActionAudit, asrBlockCredentialStealing: ASRActionBlock},
+		CloudBlockLevel:  "default",
+		MAPSReporting:    "advanced",
+		TamperProtection: "enabled",
+	}
+	after := before
+	after.ExclusionPaths = []string{`c:\tools`, `E:\New`}
+	after.ASRRules = map[string]string{asrBlockOfficeChildProcesses: ASRActionBlock, asrBlockCredentialStealing: ASRActionBlock}
+	after.CloudBlockLevel = "high"
+
+	diffs := DiffDefenderPreferences(before, after)
+	if len(diffs) != 4 {
+		t.Fatalf("expected 4 diffs, got %+v", diffs)
+	}
+	want := []DefenderPreferenceDiff{
+		{Setting: "exclusionPath", Item: `E:\New`, Action: "added", After: `E:\New`},
+		{Setting: "exclusionPath", Item: `D:\Old`, Action: "removed", Before: `D:\Old`},
+		{Setting: "asrRule", Item: asrBlockOfficeChildProcesses, Action: "modified", Before: ASRActionAudit, After: ASRActionBlock},
+		{Setting: "cloudBlockLevel", Action: "modified", Before: "default", After: "high"},
+	}
+	for i := range want {
+		if diffs[i] != want[i] {
+			t.Errorf("diff %d = %+v, want %+v", i, diffs[i], want[i])
+		}
+	}
+	if got := DiffDefenderPreferences(before, before); len(got) != 0 {
+		t.Fatalf("identical preferences should not differ, got %+v", got)
+	}
+}
+
+func TestUnappliedDefenderChanges(t *testing.T) {
+	level := "high"
+	change := DefenderPreferenceChange{
+		AddExclusionPaths:    []string{`C:\Build`},
+		RemoveExclusionPaths: []string{`D:\Old`},
+		ASRRules:             map[string]string{asrBlockOfficeChildProcesses: ASRActionBlock},
+		CloudBlockLevel:      &level,
+	}
+	after := DefenderPreferences{
+		ExclusionPaths:  []string{`c:\build`, `D:\Old`},
+		ASRRules:        map[string]string{asrBlockOfficeChildProcesses: ASRActionBlock},
+		CloudBlockLevel: "default",
+	}
+	got := UnappliedDefenderChanges(change, after)
+	if strings.Join(got, "|") != `exclusionPath D:\Old|cloudBlockLevel` {
+		t.Fatalf("unapplied = %v", got)
+	}
+}
@@ -0,0 +1,64 @@
+//go:build windows
+
+package security
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const defenderPolicyTimeout = 60 * time.Second
+
+// GetDefenderPreferences reads the managed Defender policy via PowerShell.
+func GetDefenderPreferences() (DefenderPreferences, error) {
+	output, err := runDefenderPolicyScript(defenderGetScript)
+	if err != nil {
+		return DefenderPreferences{}, fmt.Errorf("failed to read defender preferences: %w", err)
+	}
+	return parseDefenderPreferences(output)
+}
+
+// SetDefenderPreferences applies a change and returns the preferences read
+// before and after it, so the caller can record exactly what changed. Either
+// is nil when it could not be read.
+func SetDefenderPreferences(change DefenderPreferenceChange) (*DefenderPreferences, *DefenderPreferences, error) {
+	if err := change.Validate(); err != nil {
+		return nil, nil, err
+	}
+	before, err := GetDefenderPreferences()
+	if err != nil {
+		return nil, nil, err
+	}
+	var setErr error
+	if _, err := runDefenderPolicyScript(defenderSetScript(change)); err != nil {
+		// Statements before the failing one may have applied, so the
+		// preferences are still read back for the caller to record.
+		setErr = fmt.Errorf("failed to set defender preferences: %w", err)
+	}
+	after, err := GetDefenderPreferences()
+	if err != nil {
+		if setErr != nil {
+			return &before, nil, setErr
+		}
+		return &before, nil, err
+	}
+	return &before, &after, setErr
+}
+
+func runDefenderPolicyScript(script string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defenderPolicyTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("defender command timed out")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return output, nil
+}
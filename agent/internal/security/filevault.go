@@ -0,0 +1,142 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fdesetup accepts at most this many skipped login prompts before it
+// insists; more than a handful defeats the point of deploying FileVault.
+const maxFileVaultDeferrals = 10
+
+var (
+	fileVaultPercentPattern  = regexp.MustCompile(`Percent completed = ([0-9.]+)`)
+	fileVaultDeferredPattern = regexp.MustCompile(`Deferred enablement appears to be active for user '([^']*)'`)
+)
+
+// FileVaultStatus is what `fdesetup status` reports.
+type FileVaultStatus struct {
+	On bool `json:"on"`
+	// InProgress is set while the volume is encrypting or decrypting.
+	InProgress       bool     `json:"inProgress,omitempty"`
+	PercentEncrypted *float64 `json:"percentEncrypted,omitempty"`
+	// Deferred is set when enablement waits for DeferredUser to log out or in.
+	Deferred       bool   `json:"deferred,omitempty"`
+	DeferredUser   string `json:"deferredUser,omitempty"`
+	PendingRestart bool   `json:"pendingRestart,omitempty"`
+}
+
+func parseFileVaultStatus(output string) FileVaultStatus {
+	var status FileVaultStatus
+	status.On = strings.Contains(output, "FileVault is On")
+	status.PendingRestart = strings.Contains(output, "will be enabled after the next restart")
+	status.InProgress = strings.Contains(output, "in progress")
+	if m := fileVaultPercentPattern.FindStringSubmatch(output); m != nil && strings.Contains(output, "Encryption in progress") {
+		if percent, err := strconv.ParseFloat(m[1], 64); err == nil {
+			status.PercentEncrypted = &percent
+		}
+	}
+	if m := fileVaultDeferredPattern.FindStringSubmatch(output); m != nil {
+		status.Deferred = true
+		status.DeferredUser = m[1]
+	}
+	return status
+}
+
+// GetFileVaultStatus runs `fdesetup status`.
+func GetFileVaultStatus() (FileVaultStatus, error) {
+	if runtime.GOOS != "darwin" {
+		return FileVaultStatus{}, fmt.Errorf("filevault: %w", ErrNotSupported)
+	}
+	output, err := runCommand(6*time.Second, "fdesetup", "status")
+	if err != nil {
+		return FileVaultStatus{}, err
+	}
+	return parseFileVaultStatus(output), nil
+}
+
+// FileVaultDeferOptions is the enable command's payload.
+type FileVaultDeferOptions struct {
+	// MaxDeferrals is how many times the user may skip the prompt at login;
+	// 0 enables FileVault at the next login without a way to skip.
+	MaxDeferrals int `json:"maxDeferrals"`
+	// PromptAtLogout also asks when the user logs out, which is usually the
+	// first chance.
+	PromptAtLogout bool `json:"promptAtLogout"`
+}
+
+func fileVaultDeferArgs(outputPlist string, opts FileVaultDeferOptions) []string {
+	args := []string{"enable", "-defer", outputPlist, "-forceatlogin", strconv.Itoa(opts.MaxDeferrals)}
+	if !opts.PromptAtLogout {
+		args = append(args, "-dontaskatlogout")
+	}
+	return args
+}
+
+// DeferFileVault arranges for FileVault to be turned on the next time a
+// user logs out or in. macOS needs that user's password to add them as an
+// unlock user, which an agent running as root does not have, so fdesetup
+// prompts for it then and writes the personal recovery key to outputPlist
+// for escrow. The returned status tells whether enablement was already
+// deferred; deferring again is a no-op rather than an error.
+func DeferFileVault(outputPlist string, opts FileVaultDeferOptions) (FileVaultStatus, error) {
+	if runtime.GOOS != "darwin" {
+		return FileVaultStatus{}, fmt.Errorf("filevault: %w", ErrNotSupported)
+	}
+	if opts.MaxDeferrals < 0 || opts.MaxDeferrals > maxFileVaultDeferrals {
+		return FileVaultStatus{}, fmt.Errorf("maxDeferrals must be between 0 and %d", maxFileVaultDeferrals)
+	}
+
+	status, err := GetFileVaultStatus()
+	if err != nil {
+		return FileVaultStatus{}, err
+	}
+	if status.On || status.PendingRestart {
+		return status, errors.New("filevault is already enabled; rotate the recovery key instead")
+	}
+	if status.Deferred {
+		return status, nil
+	}
+
+	// fdesetup refuses to overwrite the file. With FileVault off, a leftover
+	// one is from an enablement that never completed, so its key is unused.
+	if err := os.Remove(outputPlist); err != nil && !os.IsNotExist(err) {
+		return FileVaultStatus{}, fmt.Errorf("remove stale deferral file: %w", err)
+	}
+	if _, err := runCommand(30*time.Second, "fdesetup", fileVaultDeferArgs(outputPlist, opts)...); err != nil {
+		return FileVaultStatus{}, fmt.Errorf("fdesetup enable -defer failed: %w", err)
+	}
+	return GetFileVaultStatus()
+}
+
+// ReadDeferredFileVaultKey returns the personal recovery key fdesetup wrote
+// after a deferred enablement completed, or ok=false while there is none.
+// The file holds the key in plain text; remove it once the key is escrowed.
+func ReadDeferredFileVaultKey(outputPlist string) (key RecoveryKey, ok bool, err error) {
+	data, err := os.ReadFile(outputPlist)
+	if os.IsNotExist(err) {
+		return RecoveryKey{}, false, nil
+	}
+	if err != nil {
+		return RecoveryKey{}, false, fmt.Errorf("read deferral file: %w", err)
+	}
+	recoveryKey, err := parseDeferredFileVaultPlist(string(data))
+	if err != nil {
+		return RecoveryKey{}, false, err
+	}
+	return RecoveryKey{Mount: "/", KeyType: KeyTypeFileVault, Key: recoveryKey}, true, nil
+}
+
+func parseDeferredFileVaultPlist(plist string) (string, error) {
+	_, rest, found := strings.Cut(plist, "<key>RecoveryKey</key>")
+	if !found {
+		return "", errors.New("no RecoveryKey in deferral file")
+	}
+	return parseFileVaultNewKey(rest)
+}
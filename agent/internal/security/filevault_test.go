@@ -0,0 +1,84 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFileVaultStatus(t *testing.T) {
+	status := parseFileVaultStatus("FileVault is Off.\nDeferred enablement appears to be active for user 'jane'.")
+	if status.On || !status.Deferred || status.DeferredUser != "jane" || status.InProgress {
+		t.Fatalf("unexpected deferred status %+v", status)
+	}
+
+	status = parseFileVaultStatus("FileVault is On.\nEncryption in progress: Percent completed = 37.5")
+	if !status.On || !status.InProgress || status.PercentEncrypted == nil || *status.PercentEncrypted != 37.5 {
+		t.Fatalf("unexpected encrypting status %+v", status)
+	}
+
+	status = parseFileVaultStatus("FileVault is On.\nDecryption in progress: Percent completed = 10")
+	if !status.InProgress || status.PercentEncrypted != nil {
+		t.Fatalf("decryption progress is not an encrypted percentage: %+v", status)
+	}
+
+	if status := parseFileVaultStatus("FileVault is Off, but will be enabled after the next restart."); status.On || !status.PendingRestart {
+		t.Fatalf("unexpected pending status %+v", status)
+	}
+
+	details := fileVaultEncryptionDetails("FileVault is On.\nEncryption in progress: Percent completed = 37.5")
+	if details["systemDriveInProgress"] != true {
+		t.Fatalf("expected in-progress details %+v", details)
+	}
+	if volume := details["volumes"].([]map[string]any)[0]; volume["protected"] != true || volume["percentEncrypted"] != 37.5 {
+		t.Fatalf("unexpected volume %+v", volume)
+	}
+}
+
+func TestFileVaultDeferArgs(t *testing.T) {
+	args := fileVaultDeferArgs("/tmp/fv.plist", FileVaultDeferOptions{MaxDeferrals: 3})
+	if strings.Join(args, " ") != "enable -defer /tmp/fv.plist -forceatlogin 3 -dontaskatlogout" {
+		t.Fatalf("args = %v", args)
+	}
+	args = fileVaultDeferArgs("/tmp/fv.plist", FileVaultDeferOptions{PromptAtLogout: true})
+	if strings.Join(args, " ") != "enable -defer /tmp/fv.plist -forceatlogin 0" {
+		t.Fatalf("args = %v", args)
+	}
+}
+
+func TestReadDeferredFileVaultKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filevault-deferral.plist")
+	if _, ok, err := ReadDeferredFileVaultKey(path); ok || err != nil {
+		t.Fatalf("missing file should be ok=false without error, got ok=%v err=%v", ok, err)
+	}
+
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>EnabledUser</key>
+	<string>jane</string>
+	<key>HardwareUUID</key>
+	<string>ABCD-1234</string>
+	<key>RecoveryKey</key>
+	<string>ABCD-EFGH-IJKL-MNOP-QRST-UVWX</string>
+</dict>
+</plist>`
+	if err := os.WriteFile(path, []byte(plist), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key, ok, err := ReadDeferredFileVaultKey(path)
+	if err != nil || !ok {
+		t.Fatalf("ok=%v err=%v", ok, err)
+	}
+	if key.Key != "ABCD-EFGH-IJKL-MNOP-QRST-UVWX" || key.KeyType != KeyTypeFileVault || key.Mount != "/" {
+		t.Fatalf("unexpected key %+v", key)
+	}
+
+	if err := os.WriteFile(path, []byte(`<plist><dict><key>EnabledUser</key><string>jane</string></dict></plist>`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadDeferredFileVaultKey(path); err == nil {
+		t.Fatal("expected an error for a file without a recovery key")
+	}
+}
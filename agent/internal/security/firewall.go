@@ -0,0 +1,347 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Firewall backends. Windows Firewall and ufw store a rule name natively,
+// pf keeps it as a rule label; firewalld rich rules have nowhere to put one,
+// so they are addressed by their rule text instead.
+const (
+	FirewallBackendWindows   = "windows_firewall"
+	FirewallBackendPF        = "pf"
+	FirewallBackendUFW       = "ufw"
+	FirewallBackendFirewalld = "firewalld"
+)
+
+const (
+	FirewallDirectionInbound  = "inbound"
+	FirewallDirectionOutbound = "outbound"
+
+	FirewallActionAllow = "allow"
+	FirewallActionBlock = "block"
+
+	FirewallProtocolTCP = "tcp"
+	FirewallProtocolUDP = "udp"
+	FirewallProtocolAny = "any"
+)
+
+// firewallRuleTag prefixes the name of every rule the agent creates (ufw
+// comment, pf label), so managed rules can be told apart from local ones.
+const firewallRuleTag = "breeze:"
+
+const firewallCommandTimeout = 60 * time.Second
+
+var (
+	firewallRuleNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.-]{0,63}$`)
+	firewallPortPattern     = regexp.MustCompile(`^(\d{1,5})(?:-(\d{1,5}))?$`)
+)
+
+// FirewallRule is a host firewall rule as reported by the active backend.
+// ID is what RemoveFirewallRule takes to delete it; Managed is true for rules
+// created through AddFirewallRule.
+type FirewallRule struct {
+	ID            string `json:"id"`
+	Name          string `json:"name,omitempty"`
+	Direction     string `json:"direction"`
+	Action        string `json:"action"`
+	Protocol      string `json:"protocol"`
+	Port          string `json:"port,omitempty"`
+	RemoteAddress string `json:"remoteAddress,omitempty"`
+	Profile       string `json:"profile,omitempty"`
+	Enabled       bool   `json:"enabled"`
+	Managed       bool   `json:"managed"`
+}
+
+// FirewallProfile is a firewall that can be switched on or off as a whole:
+// a Windows Firewall profile, the macOS application firewall or pf, or the
+// Linux firewall daemon.
+type FirewallProfile struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// FirewallState is the result of ListFirewallRules.
+type FirewallState struct {
+	Backend  string            `json:"backend"`
+	Profiles []FirewallProfile `json:"profiles"`
+	Rules    []FirewallRule    `json:"rules"`
+}
+
+// FirewallRuleSpec describes a rule to create. Port is the service port:
+// the local port for inbound rules and the remote port for outbound ones.
+type FirewallRuleSpec struct {
+	Name          string `json:"name"`
+	Direction     string `json:"direction,omitempty"`
+	Action        string `json:"action,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+	Port          string `json:"port,omitempty"`
+	RemoteAddress string `json:"remoteAddress,omitempty"`
+	// Profile limits the rule to one Windows Firewall profile (domain,
+	// private or public). Other backends ignore it.
+	Profile string `json:"profile,omitempty"`
+}
+
+// FirewallRuleRef identifies the rules to remove: by ID as returned from
+// ListFirewallRules, or by the name a managed rule was created with.
+type FirewallRuleRef struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// Normalize fills in defaults and validates the spec. Rules must name a
+// port or a remote address; a rule matching all traffic in one direction is
+// almost always a mistake, and a fleet-wide one is an outage.
+func (s *FirewallRuleSpec) Normalize() error {
+	s.Name = strings.TrimSpace(s.Name)
+	if !firewallRuleNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("invalid rule name %q: use up to 64 letters, digits, spaces, dots, dashes or underscores", s.Name)
+	}
+
+	s.Direction = strings.ToLower(strings.TrimSpace(s.Direction))
+	switch s.Direction {
+	case "", "in":
+		s.Direction = FirewallDirectionInbound
+	case "out":
+		s.Direction = FirewallDirectionOutbound
+	case FirewallDirectionInbound, FirewallDirectionOutbound:
+	default:
+		return fmt.Errorf("invalid direction %q", s.Direction)
+	}
+
+	s.Action = strings.ToLower(strings.TrimSpace(s.Action))
+	switch s.Action {
+	case "":
+		s.Action = FirewallActionBlock
+	case "deny":
+		s.Action = FirewallActionBlock
+	case FirewallActionAllow, FirewallActionBlock:
+	default:
+		return fmt.Errorf("invalid action %q", s.Action)
+	}
+
+	s.Protocol = strings.ToLower(strings.TrimSpace(s.Protocol))
+	if s.Protocol == "" {
+		s.Protocol = FirewallProtocolAny
+	}
+	if s.Protocol != FirewallProtocolTCP && s.Protocol != FirewallProtocolUDP && s.Protocol != FirewallProtocolAny {
+		return fmt.Errorf("invalid protocol %q", s.Protocol)
+	}
+
+	s.Port = strings.TrimSpace(s.Port)
+	if s.Port != "" {
+		if err := validateFirewallPort(s.Port); err != nil {
+			return err
+		}
+		if s.Protocol == FirewallProtocolAny {
+			return errors.New("a port rule needs protocol tcp or udp")
+		}
+	}
+
+	s.RemoteAddress = strings.TrimSpace(s.RemoteAddress)
+	if s.RemoteAddress != "" && net.ParseIP(s.RemoteAddress) == nil {
+		if _, _, err := net.ParseCIDR(s.RemoteAddress); err != nil {
+			return fmt.Errorf("invalid remote address %q: expected an IP address or CIDR", s.RemoteAddress)
+		}
+	}
+
+	if s.Port == "" && s.RemoteAddress == "" {
+		return errors.New("a rule needs a port or a remote address")
+	}
+
+	s.Profile = strings.ToLower(strings.TrimSpace(s.Profile))
+	switch s.Profile {
+	case "", "any":
+		s.Profile = ""
+	case "domain", "private", "public":
+	default:
+		return fmt.Errorf("invalid profile %q", s.Profile)
+	}
+	return nil
+}
+
+func validateFirewallPort(port string) error {
+	m := firewallPortPattern.FindStringSubmatch(port)
+	if m == nil {
+		return fmt.Errorf("invalid port %q: expected a port or a range like 8000-8100", port)
+	}
+	lo, _ := strconv.Atoi(m[1])
+	hi := lo
+	if m[2] != "" {
+		hi, _ = strconv.Atoi(m[2])
+	}
+	if lo < 1 || hi > 65535 || lo > hi {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// ListFirewallRules returns the rules and profile states of the host's
+// active firewall.
+func ListFirewallRules() (*FirewallState, error) {
+	backend, err := detectFirewallBackend()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), firewallCommandTimeout)
+	defer cancel()
+
+	state := &FirewallState{Backend: backend}
+	switch backend {
+	case FirewallBackendWindows:
+		state.Profiles, state.Rules, err = listWindowsFirewall(ctx)
+	case FirewallBackendPF:
+		state.Profiles = darwinFirewallProfiles(ctx)
+		state.Rules, err = listPFRules(ctx)
+	case FirewallBackendUFW:
+		state.Profiles, state.Rules, err = listUFW(ctx)
+	case FirewallBackendFirewalld:
+		state.Profiles, state.Rules, err = listFirewalld(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if state.Rules == nil {
+		state.Rules = []FirewallRule{}
+	}
+	return state, nil
+}
+
+// AddFirewallRule creates a managed rule on the host's active firewall and
+// returns it as ListFirewallRules would report it.
+func AddFirewallRule(spec FirewallRuleSpec) (*FirewallRule, error) {
+	if err := spec.Normalize(); err != nil {
+		return nil, err
+	}
+	backend, err := detectFirewallBackend()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), firewallCommandTimeout)
+	defer cancel()
+
+	switch backend {
+	case FirewallBackendWindows:
+		return addWindowsFirewallRule(ctx, spec)
+	case FirewallBackendPF:
+		return addPFRule(ctx, spec)
+	case FirewallBackendUFW:
+		return addUFWRule(ctx, spec)
+	default:
+		return addFirewalldRule(ctx, spec)
+	}
+}
+
+// RemoveFirewallRule deletes the rules ref identifies and returns how many
+// were removed. Removing a rule that no longer exists is not an error, so a
+// fleet-wide removal can be retried safely.
+func RemoveFirewallRule(ref FirewallRuleRef) (int, error) {
+	ref.ID = strings.TrimSpace(ref.ID)
+	ref.Name = strings.TrimSpace(ref.Name)
+	if (ref.ID == "") == (ref.Name == "") {
+		return 0, errors.New("exactly one of id or name is required")
+	}
+	if ref.Name != "" && !firewallRuleNamePattern.MatchString(ref.Name) {
+		return 0, fmt.Errorf("invalid rule name %q", ref.Name)
+	}
+	backend, err := detectFirewallBackend()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), firewallCommandTimeout)
+	defer cancel()
+
+	switch backend {
+	case FirewallBackendWindows:
+		return removeWindowsFirewallRule(ctx, ref)
+	case FirewallBackendPF:
+		return removePFRule(ctx, ref)
+	case FirewallBackendUFW:
+		return removeUFWRule(ctx, ref)
+	default:
+		return removeFirewalldRule(ctx, ref)
+	}
+}
+
+// SetFirewallProfile switches a firewall profile on or off. Profile names
+// are those ListFirewallRules reports for the host.
+func SetFirewallProfile(profile string, enabled bool) error {
+	profile = strings.ToLower(strings.TrimSpace(profile))
+	backend, err := detectFirewallBackend()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), firewallCommandTimeout)
+	defer cancel()
+
+	switch backend {
+	case FirewallBackendWindows:
+		return setWindowsFirewallProfile(ctx, profile, enabled)
+	case FirewallBackendPF:
+		return setDarwinFirewallProfile(ctx, profile, enabled)
+	default:
+		if profile != backend {
+			return fmt.Errorf("unknown firewall profile %q: this host uses %s", profile, backend)
+		}
+		if backend == FirewallBackendUFW {
+			return setUFWEnabled(ctx, enabled)
+		}
+		return setFirewalldEnabled(ctx, enabled)
+	}
+}
+
+// detectFirewallBackend picks the firewall rules are managed through. On
+// Linux an active ufw or firewalld wins over one that is merely installed,
+// since the inactive one's rules would have no effect.
+func detectFirewallBackend() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		return FirewallBackendWindows, nil
+	case "darwin":
+		return FirewallBackendPF, nil
+	case "linux":
+	default:
+		return "", fmt.Errorf("firewall management on %s: %w", runtime.GOOS, ErrNotSupported)
+	}
+
+	hasUFW := hasCommand("ufw")
+	hasFirewalld := hasCommand("firewall-cmd")
+	if hasUFW {
+		if output, err := runCommand(5*time.Second, "ufw", "status"); err == nil {
+			if enabled, known := interpretFirewallState("ufw", output); known && enabled {
+				return FirewallBackendUFW, nil
+			}
+		}
+	}
+	if hasFirewalld {
+		if stdout, zeroExit, ok := firewallStatusFromCommand(5*time.Second, "firewall-cmd", "--state"); ok && zeroExit {
+			if enabled, known := interpretFirewallState("firewall-cmd", stdout); known && enabled {
+				return FirewallBackendFirewalld, nil
+			}
+		}
+	}
+	switch {
+	case hasUFW:
+		return FirewallBackendUFW, nil
+	case hasFirewalld:
+		return FirewallBackendFirewalld, nil
+	}
+	return "", fmt.Errorf("neither ufw nor firewalld is installed: %w", ErrNotSupported)
+}
+
+// firewallRuleName strips the managed-rule tag from a ufw comment or pf
+// label, reporting whether it was there.
+func firewallRuleName(tag string) (string, bool) {
+	if name, ok := strings.CutPrefix(tag, firewallRuleTag); ok {
+		return name, true
+	}
+	return tag, false
+}
@@ -0,0 +1,180 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// firewalld rich rules have no comment field, so they are listed with the
+// rule text as their ID and can only be removed by it. Open ports from
+// --list-ports are reported as allow rules with a "port:" ID.
+const firewalldPortIDPrefix = "port:"
+
+var (
+	richRuleSourcePattern   = regexp.MustCompile(`source address="([^"]+)"`)
+	richRulePortPattern     = regexp.MustCompile(`port port="([^"]+)" protocol="([^"]+)"`)
+	richRuleProtocolPattern = regexp.MustCompile(`protocol value="([^"]+)"`)
+	richRuleQuotedPattern   = regexp.MustCompile(`"[^"]*"`)
+)
+
+// firewalldTool returns the command that edits firewalld's permanent
+// configuration. With the daemon running that is firewall-cmd --permanent,
+// followed by a reload; without it, firewall-offline-cmd edits the same
+// files directly.
+func firewalldTool() (name string, args []string, running bool) {
+	if stdout, zeroExit, ok := firewallStatusFromCommand(5*time.Second, "firewall-cmd", "--state"); ok && zeroExit {
+		if enabled, known := interpretFirewallState("firewall-cmd", stdout); known && enabled {
+			return "firewall-cmd", []string{"--permanent"}, true
+		}
+	}
+	return "firewall-offline-cmd", nil, false
+}
+
+func runFirewalld(ctx context.Context, args ...string) (string, error) {
+	name, base, _ := firewalldTool()
+	return runToolCommand(ctx, "", name, append(base, args...)...)
+}
+
+func reloadFirewalld(ctx context.Context) error {
+	if _, _, running := firewalldTool(); !running {
+		return nil
+	}
+	_, err := runToolCommand(ctx, "", "firewall-cmd", "--reload")
+	return err
+}
+
+func listFirewalld(ctx context.Context) ([]FirewallProfile, []FirewallRule, error) {
+	_, _, running := firewalldTool()
+	profiles := []FirewallProfile{{Name: FirewallBackendFirewalld, Enabled: running}}
+
+	richRules, err := runFirewalld(ctx, "--list-rich-rules")
+	if err != nil {
+		return nil, nil, err
+	}
+	ports, err := runFirewalld(ctx, "--list-ports")
+	if err != nil {
+		return nil, nil, err
+	}
+	return profiles, parseFirewalldRules(richRules, ports), nil
+}
+
+func parseFirewalldRules(richRules, ports string) []FirewallRule {
+	var rules []FirewallRule
+	for _, line := range strings.Split(richRules, "\n") {
+		if rule, ok := parseRichRule(strings.TrimSpace(line)); ok {
+			rules = append(rules, rule)
+		}
+	}
+	for _, entry := range strings.Fields(ports) {
+		port, proto, _ := strings.Cut(entry, "/")
+		rules = append(rules, FirewallRule{
+			ID:        firewalldPortIDPrefix + entry,
+			Direction: FirewallDirectionInbound,
+			Action:    FirewallActionAllow,
+			Protocol:  proto,
+			Port:      port,
+			Enabled:   true,
+		})
+	}
+	return rules
+}
+
+func parseRichRule(line string) (FirewallRule, bool) {
+	if !strings.HasPrefix(line, "rule ") {
+		return FirewallRule{}, false
+	}
+	rule := FirewallRule{
+		ID:        line,
+		Direction: FirewallDirectionInbound,
+		Action:    FirewallActionAllow,
+		Protocol:  FirewallProtocolAny,
+		Enabled:   true,
+	}
+	if m := richRuleSourcePattern.FindStringSubmatch(line); m != nil && !strings.Contains(line, "source NOT") {
+		rule.RemoteAddress = m[1]
+	}
+	if m := richRulePortPattern.FindStringSubmatch(line); m != nil {
+		rule.Port, rule.Protocol = m[1], m[2]
+	} else if m := richRuleProtocolPattern.FindStringSubmatch(line); m != nil {
+		rule.Protocol = m[1]
+	}
+	// The action is a bare keyword; quoted values such as a log prefix
+	// could contain the same words.
+	for _, field := range strings.Fields(richRuleQuotedPattern.ReplaceAllString(line, `""`)) {
+		if field == "drop" || field == "reject" {
+			rule.Action = FirewallActionBlock
+		}
+	}
+	return rule, true
+}
+
+// firewalldRichRule renders a normalized inbound spec as a rich rule.
+func firewalldRichRule(spec FirewallRuleSpec) string {
+	parts := []string{"rule"}
+	if spec.RemoteAddress != "" {
+		family := "ipv4"
+		if strings.Contains(spec.RemoteAddress, ":") {
+			family = "ipv6"
+		}
+		parts = append(parts, fmt.Sprintf(`family="%s" source address="%s"`, family, spec.RemoteAddress))
+	}
+	switch {
+	case spec.Port != "":
+		parts = append(parts, fmt.Sprintf(`port port="%s" protocol="%s"`, spec.Port, spec.Protocol))
+	case spec.Protocol != FirewallProtocolAny:
+		parts = append(parts, fmt.Sprintf(`protocol value="%s"`, spec.Protocol))
+	}
+	if spec.Action == FirewallActionAllow {
+		parts = append(parts, "accept")
+	} else {
+		parts = append(parts, "drop")
+	}
+	return strings.Join(parts, " ")
+}
+
+func addFirewalldRule(ctx context.Context, spec FirewallRuleSpec) (*FirewallRule, error) {
+	if spec.Direction == FirewallDirectionOutbound {
+		return nil, fmt.Errorf("firewalld zones filter inbound traffic only: %w", ErrNotSupported)
+	}
+	richRule := firewalldRichRule(spec)
+	if _, err := runFirewalld(ctx, "--add-rich-rule="+richRule); err != nil {
+		return nil, err
+	}
+	if err := reloadFirewalld(ctx); err != nil {
+		return nil, err
+	}
+	rule, _ := parseRichRule(richRule)
+	return &rule, nil
+}
+
+func removeFirewalldRule(ctx context.Context, ref FirewallRuleRef) (int, error) {
+	if ref.Name != "" {
+		return 0, errors.New("firewalld rules have no name; remove them by id")
+	}
+	arg := "--remove-rich-rule=" + ref.ID
+	if port, ok := strings.CutPrefix(ref.ID, firewalldPortIDPrefix); ok {
+		arg = "--remove-port=" + port
+	}
+	output, err := runFirewalld(ctx, arg)
+	if err != nil {
+		return 0, err
+	}
+	// Removing something that is not there only warns: "Warning: NOT_ENABLED".
+	if strings.Contains(output, "NOT_ENABLED") {
+		return 0, nil
+	}
+	return 1, reloadFirewalld(ctx)
+}
+
+func setFirewalldEnabled(ctx context.Context, enabled bool) error {
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	_, err := runToolCommand(ctx, "", "systemctl", action, "--now", "firewalld")
+	return err
+}
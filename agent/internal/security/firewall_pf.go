@@ -0,0 +1,259 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// pfAnchor holds managed rules on macOS. The stock /etc/pf.conf evaluates
+// anchor "com.apple/*", so a sub-anchor there takes effect without editing
+// pf.conf. Like everything loaded with pfctl, it lasts until reboot.
+const pfAnchor = "com.apple/breeze"
+
+const socketFilterFW = "/usr/libexec/ApplicationFirewall/socketfilterfw"
+
+// darwinFirewallProfiles reports the application firewall (ALF) and pf.
+// Managed rules are enforced by pf, so they only apply while it is enabled.
+func darwinFirewallProfiles(ctx context.Context) []FirewallProfile {
+	alf, _ := getFirewallStatusDarwin()
+	pfEnabled := false
+	if output, err := runToolCommand(ctx, "", "pfctl", "-s", "info"); err == nil {
+		pfEnabled = parsePFEnabled(output)
+	}
+	return []FirewallProfile{
+		{Name: "alf", Enabled: alf},
+		{Name: "pf", Enabled: pfEnabled},
+	}
+}
+
+func parsePFEnabled(info string) bool {
+	for _, line := range strings.Split(info, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "Status: Enabled") {
+			return true
+		}
+	}
+	return false
+}
+
+func setDarwinFirewallProfile(ctx context.Context, profile string, enabled bool) error {
+	switch profile {
+	case "alf":
+		state := "off"
+		if enabled {
+			state = "on"
+		}
+		_, err := runToolCommand(ctx, "", socketFilterFW, "--setglobalstate", state)
+		return err
+	case "pf":
+		flag, already := "-d", "not enabled"
+		if enabled {
+			flag, already = "-e", "already enabled"
+		}
+		// pfctl exits non-zero when pf is already in the requested state.
+		if output, err := runToolCommand(ctx, "", "pfctl", flag); err != nil && !strings.Contains(output, already) {
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown firewall profile %q: use alf or pf", profile)
+}
+
+// pfAnchorRules returns the rule lines currently loaded in the managed
+// anchor, dropping the ALTQ and similar notices pfctl mixes into its output.
+func pfAnchorRules(ctx context.Context) ([]string, error) {
+	output, err := runToolCommand(ctx, "", "pfctl", "-a", pfAnchor, "-s", "rules")
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "block ") || strings.HasPrefix(line, "pass ") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+func loadPFAnchor(ctx context.Context, lines []string) error {
+	ruleset := strings.Join(lines, "\n") + "\n"
+	_, err := runToolCommand(ctx, ruleset, "pfctl", "-a", pfAnchor, "-f", "-")
+	return err
+}
+
+func listPFRules(ctx context.Context) ([]FirewallRule, error) {
+	lines, err := pfAnchorRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]FirewallRule, 0, len(lines))
+	for _, line := range lines {
+		if rule, ok := parsePFRule(line); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func addPFRule(ctx context.Context, spec FirewallRuleSpec) (*FirewallRule, error) {
+	lines, err := pfAnchorRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Re-adding a rule under an existing name replaces it.
+	kept := removePFLines(lines, firewallRuleTag+spec.Name)
+	if err := loadPFAnchor(ctx, append(kept, pfRuleLine(spec))); err != nil {
+		return nil, err
+	}
+	rule, _ := parsePFRule(pfRuleLine(spec))
+	return &rule, nil
+}
+
+func removePFRule(ctx context.Context, ref FirewallRuleRef) (int, error) {
+	label := ref.ID
+	if ref.Name != "" {
+		label = firewallRuleTag + ref.Name
+	}
+	lines, err := pfAnchorRules(ctx)
+	if err != nil {
+		return 0, err
+	}
+	kept := removePFLines(lines, label)
+	removed := len(lines) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, loadPFAnchor(ctx, kept)
+}
+
+func removePFLines(lines []string, label string) []string {
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if rule, ok := parsePFRule(line); ok && rule.ID == label {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return kept
+}
+
+// pfRuleLine renders a normalized spec as a pf rule. Inbound rules match the
+// remote address as the source and the port on this host; outbound rules
+// match both on the destination.
+func pfRuleLine(spec FirewallRuleSpec) string {
+	parts := []string{"block drop"}
+	if spec.Action == FirewallActionAllow {
+		parts = []string{"pass"}
+	}
+	if spec.Direction == FirewallDirectionOutbound {
+		parts = append(parts, "out")
+	} else {
+		parts = append(parts, "in")
+	}
+	parts = append(parts, "quick")
+	if spec.Protocol != FirewallProtocolAny {
+		parts = append(parts, "proto", spec.Protocol)
+	}
+	remote := "any"
+	if spec.RemoteAddress != "" {
+		remote = spec.RemoteAddress
+	}
+	if spec.Direction == FirewallDirectionOutbound {
+		parts = append(parts, "from any to", remote)
+	} else {
+		parts = append(parts, "from", remote, "to any")
+	}
+	if spec.Port != "" {
+		parts = append(parts, "port", strings.Replace(spec.Port, "-", ":", 1))
+	}
+	parts = append(parts, fmt.Sprintf("label %q", firewallRuleTag+spec.Name))
+	return strings.Join(parts, " ")
+}
+
+// parsePFRule reads a rule as pfctl -s rules prints it, e.g.
+//
+//	block drop in quick proto tcp from 10.0.0.0/8 to any port = 22 label "breeze:Block SSH"
+func parsePFRule(line string) (FirewallRule, bool) {
+	tokens := pfTokens(line)
+	if len(tokens) == 0 || (tokens[0] != "block" && tokens[0] != "pass") {
+		return FirewallRule{}, false
+	}
+	rule := FirewallRule{
+		Direction: FirewallDirectionInbound,
+		Action:    FirewallActionAllow,
+		Protocol:  FirewallProtocolAny,
+		Enabled:   true,
+	}
+	if tokens[0] == "block" {
+		rule.Action = FirewallActionBlock
+	}
+
+	var from, to, port string
+	side := ""
+	for i := 1; i < len(tokens); i++ {
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		switch tokens[i] {
+		case "out":
+			rule.Direction = FirewallDirectionOutbound
+		case "proto":
+			rule.Protocol = next
+			i++
+		case "from":
+			side, from = "from", next
+			i++
+		case "to":
+			side, to = "to", next
+			i++
+		case "port":
+			if next == "=" && i+2 < len(tokens) {
+				next = tokens[i+2]
+				i++
+			}
+			if side == "to" {
+				port = strings.Replace(next, ":", "-", 1)
+			}
+			i++
+		case "label":
+			rule.ID = next
+			rule.Name, rule.Managed = firewallRuleName(next)
+			i++
+		}
+	}
+
+	rule.Port = port
+	remote := from
+	if rule.Direction == FirewallDirectionOutbound {
+		remote = to
+	}
+	if remote != "any" {
+		rule.RemoteAddress = remote
+	}
+	return rule, rule.ID != ""
+}
+
+// pfTokens splits a pf rule on whitespace, keeping quoted labels whole.
+func pfTokens(line string) []string {
+	var tokens []string
+	for {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return tokens
+		}
+		if line[0] == '"' {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				return append(tokens, line[1:])
+			}
+			tokens = append(tokens, line[1:end+1])
+			line = line[end+2:]
+			continue
+		}
+		field, rest, _ := strings.Cut(line, " ")
+		tokens = append(tokens, field)
+		line = rest
+	}
+}
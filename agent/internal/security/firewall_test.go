@@ -0,0 +1,142 @@
+package security
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFirewallRuleSpecNormalize(t *testing.T) {
+	spec := FirewallRuleSpec{Name: " Block SSH ", Protocol: "TCP", Port: "22", Direction: "in"}
+	if err := spec.Normalize(); err != nil {
+		t.Fatalf("valid spec rejected: %v", err)
+	}
+	if spec.Name != "Block SSH" || spec.Direction != FirewallDirectionInbound || spec.Action != FirewallActionBlock || spec.Protocol != FirewallProtocolTCP {
+		t.Fatalf("defaults not applied: %+v", spec)
+	}
+
+	for name, spec := range map[string]FirewallRuleSpec{
+		"bad name":          {Name: "rm -rf; '", Port: "22", Protocol: "tcp"},
+		"port without tcp":  {Name: "x", Port: "22"},
+		"reversed range":    {Name: "x", Port: "9000-8000", Protocol: "tcp"},
+		"port out of range": {Name: "x", Port: "70000", Protocol: "udp"},
+		"bad address":       {Name: "x", RemoteAddress: "example.com"},
+		"matches all":       {Name: "x", Protocol: "tcp"},
+		"bad profile":       {Name: "x", Port: "22", Protocol: "tcp", Profile: "home"},
+		"bad action":        {Name: "x", Port: "22", Protocol: "tcp", Action: "reject"},
+	} {
+		if err := spec.Normalize(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPFRuleRoundTrip(t *testing.T) {
+	inbound := FirewallRuleSpec{Name: "Block SSH", Direction: FirewallDirectionInbound, Action: FirewallActionBlock, Protocol: "tcp", Port: "8000-8100", RemoteAddress: "10.0.0.0/8"}
+	line := pfRuleLine(inbound)
+	if line != `block drop in quick proto tcp from 10.0.0.0/8 to any port 8000:8100 label "breeze:Block SSH"` {
+		t.Fatalf("pf rule = %s", line)
+	}
+
+	// As pfctl prints the rules back.
+	rule, ok := parsePFRule(`block drop in quick proto tcp from 10.0.0.0/8 to any port 8000:8100 label "breeze:Block SSH"`)
+	if !ok || rule.Name != "Block SSH" || !rule.Managed || rule.Port != "8000-8100" || rule.RemoteAddress != "10.0.0.0/8" || rule.Action != FirewallActionBlock {
+		t.Fatalf("unexpected rule %+v", rule)
+	}
+
+	rule, ok = parsePFRule(`pass out quick proto udp from any port = 53 to 1.1.1.1 port = 53 flags S/SA keep state label "breeze:DNS"`)
+	if !ok || rule.Direction != FirewallDirectionOutbound || rule.RemoteAddress != "1.1.1.1" || rule.Port != "53" || rule.Action != FirewallActionAllow {
+		t.Fatalf("unexpected outbound rule %+v", rule)
+	}
+
+	if _, ok := parsePFRule(`block drop in all`); ok {
+		t.Fatal("a rule without a label cannot be addressed and should be skipped")
+	}
+	kept := removePFLines([]string{line, `pass in quick proto tcp from any to any port = 443 label "breeze:HTTPS"`}, "breeze:Block SSH")
+	if len(kept) != 1 || !strings.Contains(kept[0], "HTTPS") {
+		t.Fatalf("kept %v", kept)
+	}
+}
+
+func TestParseUFWStatus(t *testing.T) {
+	output := `Status: active
+
+     To                         Action      From
+     --                         ------      ----
+[ 1] 22/tcp                     DENY IN     10.0.0.0/8                 # breeze:Block SSH
+[ 2] 443/tcp                    ALLOW IN    Anywhere
+[ 3] 1.2.3.4 25/tcp             DENY OUT    Anywhere                   (out) # breeze:No SMTP
+[ 4] 8000:8100/udp (v6)         ALLOW IN    Anywhere (v6)
+`
+	rules := parseUFWStatus(output)
+	if len(rules) != 4 {
+		t.Fatalf("expected 4 rules, got %+v", rules)
+	}
+	if r := rules[0]; r.ID != "1" || r.Name != "Block SSH" || !r.Managed || r.Port != "22" || r.Protocol != "tcp" || r.RemoteAddress != "10.0.0.0/8" || r.Action != FirewallActionBlock {
+		t.Fatalf("unexpected rule 1 %+v", r)
+	}
+	if r := rules[1]; r.Managed || r.Name != "" || r.Action != FirewallActionAllow || r.RemoteAddress != "" {
+		t.Fatalf("unexpected rule 2 %+v", r)
+	}
+	if r := rules[2]; r.Direction != FirewallDirectionOutbound || r.RemoteAddress != "1.2.3.4" || r.Port != "25" || r.Name != "No SMTP" {
+		t.Fatalf("unexpected rule 3 %+v", r)
+	}
+	if r := rules[3]; r.Port != "8000-8100" || r.Protocol != "udp" {
+		t.Fatalf("unexpected rule 4 %+v", r)
+	}
+
+	args := ufwAddArgs(FirewallRuleSpec{Name: "No SMTP", Direction: FirewallDirectionOutbound, Action: FirewallActionBlock, Protocol: "tcp", Port: "25", RemoteAddress: "1.2.3.4"})
+	if strings.Join(args, " ") != "prepend deny out proto tcp from any to 1.2.3.4 port 25 comment breeze:No SMTP" {
+		t.Fatalf("ufw args = %v", args)
+	}
+}
+
+func TestFirewalldRules(t *testing.T) {
+	spec := FirewallRuleSpec{Name: "Block SSH", Direction: FirewallDirectionInbound, Action: FirewallActionBlock, Protocol: "tcp", Port: "22", RemoteAddress: "2001:db8::/32"}
+	richRule := firewalldRichRule(spec)
+	if richRule != `rule family="ipv6" source address="2001:db8::/32" port port="22" protocol="tcp" drop` {
+		t.Fatalf("rich rule = %s", richRule)
+	}
+
+	rules := parseFirewalldRules(
+		richRule+"\n"+`rule family="ipv4" source address="10.1.0.0/16" log prefix="drop" level="info" accept`,
+		"443/tcp 8000-8100/udp",
+	)
+	if len(rules) != 4 {
+		t.Fatalf("expected 4 rules, got %+v", rules)
+	}
+	if r := rules[0]; r.ID != richRule || r.Port != "22" || r.RemoteAddress != "2001:db8::/32" || r.Action != FirewallActionBlock {
+		t.Fatalf("unexpected rule %+v", r)
+	}
+	if r := rules[1]; r.Action != FirewallActionAllow || r.Protocol != FirewallProtocolAny {
+		t.Fatalf("a quoted log prefix must not change the action: %+v", r)
+	}
+	if r := rules[3]; r.ID != "port:8000-8100/udp" || r.Port != "8000-8100" || r.Protocol != "udp" {
+		t.Fatalf("unexpected port rule %+v", r)
+	}
+}
+
+func TestWindowsFirewall(t *testing.T) {
+	output := `WARNING: noise
+{"Rules":[{"Name":"breeze:Block SSH","DisplayName":"Block SSH","Group":"Breeze","Direction":"Inbound","Action":"Block","Enabled":true,"Profile":"Domain, Private","Protocol":"TCP","LocalPort":"22","RemotePort":"Any","RemoteAddress":"10.0.0.0/255.0.0.0"},` +
+		`{"Name":"{1A2B}","DisplayName":"Core Networking - DNS (UDP-Out)","Group":"@FirewallAPI.dll,-25000","Direction":"Outbound","Action":"Allow","Enabled":false,"Profile":"Any","Protocol":"UDP","LocalPort":"Any","RemotePort":"53","RemoteAddress":"Any"}],` +
+		`"Profiles":[{"Name":"Domain","Enabled":true},{"Name":"Public","Enabled":false}]}`
+	profiles, rules, err := parseWindowsFirewallList([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 2 || profiles[0].Name != "domain" || profiles[1].Enabled {
+		t.Fatalf("unexpected profiles %+v", profiles)
+	}
+	if r := rules[0]; !r.Managed || r.Port != "22" || r.Profile != "domain,private" || r.Action != FirewallActionBlock {
+		t.Fatalf("unexpected rule %+v", r)
+	}
+	if r := rules[1]; r.Managed || r.Direction != FirewallDirectionOutbound || r.Port != "53" || r.RemoteAddress != "" || r.Enabled {
+		t.Fatalf("unexpected rule %+v", r)
+	}
+
+	script := windowsFirewallAddScript(FirewallRuleSpec{Name: "Block SSH", Direction: FirewallDirectionInbound, Action: FirewallActionBlock, Protocol: "tcp", Port: "22", Profile: "public"})
+	want := "New-NetFirewallRule -Name 'breeze:Block SSH' -DisplayName 'Block SSH' -Group 'Breeze' -Direction Inbound -Action Block -Protocol TCP -LocalPort '22' -Profile Public | Out-Null"
+	if !strings.HasSuffix(script, want) {
+		t.Fatalf("script = %s", script)
+	}
+}
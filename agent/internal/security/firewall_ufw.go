@@ -0,0 +1,182 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ufwRuleLine matches a row of `ufw status numbered`:
+//
+//	[ 1] 22/tcp                     DENY IN     10.0.0.0/8                 # breeze:Block SSH
+var ufwRuleLine = regexp.MustCompile(`^\[\s*(\d+)\]\s+(.+?)\s+(ALLOW|DENY|REJECT|LIMIT)(?:\s+(IN|OUT|FWD))?\s+(.+?)\s*(?:#\s*(.*))?$`)
+
+func listUFW(ctx context.Context) ([]FirewallProfile, []FirewallRule, error) {
+	output, err := runToolCommand(ctx, "", "ufw", "status", "numbered")
+	if err != nil {
+		return nil, nil, err
+	}
+	enabled, _ := interpretFirewallState("ufw", output)
+	return []FirewallProfile{{Name: FirewallBackendUFW, Enabled: enabled}}, parseUFWStatus(output), nil
+}
+
+func parseUFWStatus(output string) []FirewallRule {
+	var rules []FirewallRule
+	for _, line := range strings.Split(output, "\n") {
+		m := ufwRuleLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		rule := FirewallRule{
+			ID:        m[1],
+			Direction: FirewallDirectionInbound,
+			Action:    FirewallActionAllow,
+			Protocol:  FirewallProtocolAny,
+			Enabled:   true,
+		}
+		if m[3] == "DENY" || m[3] == "REJECT" {
+			rule.Action = FirewallActionBlock
+		}
+		if m[4] == "OUT" {
+			rule.Direction = FirewallDirectionOutbound
+		}
+		if m[6] != "" {
+			rule.Name, rule.Managed = firewallRuleName(strings.TrimSpace(m[6]))
+		}
+
+		toAddr, port, proto := parseUFWEndpoint(m[2])
+		fromAddr, _, _ := parseUFWEndpoint(m[5])
+		rule.Port = port
+		if proto != "" {
+			rule.Protocol = proto
+		}
+		rule.RemoteAddress = fromAddr
+		if rule.Direction == FirewallDirectionOutbound {
+			rule.RemoteAddress = toAddr
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// parseUFWEndpoint splits a To/From column such as "Anywhere (v6)",
+// "10.0.0.0/8" or "1.2.3.4 8000:8100/tcp" into address, port and protocol.
+func parseUFWEndpoint(column string) (addr, port, proto string) {
+	for _, field := range strings.Fields(column) {
+		switch {
+		case field == "Anywhere" || strings.HasPrefix(field, "("):
+		case net.ParseIP(field) != nil:
+			addr = field
+		default:
+			if _, _, err := net.ParseCIDR(field); err == nil {
+				addr = field
+				continue
+			}
+			p, pr, hasProto := strings.Cut(field, "/")
+			if hasProto {
+				proto = pr
+			}
+			port = strings.Replace(p, ":", "-", 1)
+		}
+	}
+	return addr, port, proto
+}
+
+// ufwAddArgs builds the ufw arguments for a normalized spec. Block rules are
+// prepended so they win over existing allow rules; ufw stops at the first
+// match.
+func ufwAddArgs(spec FirewallRuleSpec) []string {
+	action := "deny"
+	args := []string{"prepend"}
+	if spec.Action == FirewallActionAllow {
+		action = "allow"
+		args = nil
+	}
+	direction := "in"
+	if spec.Direction == FirewallDirectionOutbound {
+		direction = "out"
+	}
+	args = append(args, action, direction)
+	if spec.Protocol != FirewallProtocolAny {
+		args = append(args, "proto", spec.Protocol)
+	}
+	remote := "any"
+	if spec.RemoteAddress != "" {
+		remote = spec.RemoteAddress
+	}
+	if spec.Direction == FirewallDirectionOutbound {
+		args = append(args, "from", "any", "to", remote)
+	} else {
+		args = append(args, "from", remote, "to", "any")
+	}
+	if spec.Port != "" {
+		args = append(args, "port", strings.Replace(spec.Port, "-", ":", 1))
+	}
+	return append(args, "comment", firewallRuleTag+spec.Name)
+}
+
+func addUFWRule(ctx context.Context, spec FirewallRuleSpec) (*FirewallRule, error) {
+	// Re-adding a rule under an existing name replaces it.
+	if _, err := removeUFWRule(ctx, FirewallRuleRef{Name: spec.Name}); err != nil {
+		return nil, err
+	}
+	if _, err := runToolCommand(ctx, "", "ufw", ufwAddArgs(spec)...); err != nil {
+		return nil, err
+	}
+	_, rules, err := listUFW(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		if rules[i].Managed && rules[i].Name == spec.Name {
+			return &rules[i], nil
+		}
+	}
+	return nil, fmt.Errorf("ufw accepted rule %q but does not list it", spec.Name)
+}
+
+// removeUFWRule deletes by rule number, or every rule carrying a managed
+// name (ufw keeps separate IPv4 and IPv6 rows for one rule). Deleting from
+// the highest number down keeps the remaining numbers valid.
+func removeUFWRule(ctx context.Context, ref FirewallRuleRef) (int, error) {
+	var numbers []int
+	if ref.ID != "" {
+		n, err := strconv.Atoi(ref.ID)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid ufw rule number %q", ref.ID)
+		}
+		numbers = []int{n}
+	} else {
+		_, rules, err := listUFW(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, rule := range rules {
+			if rule.Managed && rule.Name == ref.Name {
+				n, _ := strconv.Atoi(rule.ID)
+				numbers = append(numbers, n)
+			}
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(numbers)))
+	for i, n := range numbers {
+		if _, err := runToolCommand(ctx, "", "ufw", "--force", "delete", strconv.Itoa(n)); err != nil {
+			return i, err
+		}
+	}
+	return len(numbers), nil
+}
+
+func setUFWEnabled(ctx context.Context, enabled bool) error {
+	args := []string{"disable"}
+	if enabled {
+		args = []string{"--force", "enable"}
+	}
+	_, err := runToolCommand(ctx, "", "ufw", args...)
+	return err
+}
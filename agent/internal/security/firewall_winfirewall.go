@@ -0,0 +1,211 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// windowsFirewallGroup is the rule group managed rules are created in, so
+// they sort together in wf.msc and can be told apart from local rules.
+const windowsFirewallGroup = "Breeze"
+
+// windowsFirewallListScript emits every rule with its port and address
+// filters. Piping the whole rule set through the filter cmdlets is one
+// batched query each; looking them up per rule takes minutes on hosts with
+// the usual several hundred inbox rules.
+const windowsFirewallListScript = `$ErrorActionPreference = 'Stop'
+$rules = @(Get-NetFirewallRule)
+$ports = @($rules | Get-NetFirewallPortFilter)
+$addrs = @($rules | Get-NetFirewallAddressFilter)
+$out = for ($i = 0; $i -lt $rules.Count; $i++) {
+  $r = $rules[$i]
+  [pscustomobject]@{
+    Name = $r.Name; DisplayName = $r.DisplayName; Group = $r.Group
+    Direction = [string]$r.Direction; Action = [string]$r.Action
+    Enabled = ([string]$r.Enabled -eq 'True'); Profile = [string]$r.Profile
+    Protocol = [string]$ports[$i].Protocol
+    LocalPort = (@($ports[$i].LocalPort) -join ','); RemotePort = (@($ports[$i].RemotePort) -join ',')
+    RemoteAddress = (@($addrs[$i].RemoteAddress) -join ',')
+  }
+}
+$profiles = @(Get-NetFirewallProfile | ForEach-Object { [pscustomobject]@{ Name = [string]$_.Name; Enabled = ([string]$_.Enabled -eq 'True') } })
+[pscustomobject]@{ Rules = @($out); Profiles = $profiles } | ConvertTo-Json -Compress -Depth 4`
+
+type windowsFirewallRaw struct {
+	Rules []struct {
+		Name          string `json:"Name"`
+		DisplayName   string `json:"DisplayName"`
+		Group         string `json:"Group"`
+		Direction     string `json:"Direction"`
+		Action        string `json:"Action"`
+		Enabled       bool   `json:"Enabled"`
+		Profile       string `json:"Profile"`
+		Protocol      string `json:"Protocol"`
+		LocalPort     string `json:"LocalPort"`
+		RemotePort    string `json:"RemotePort"`
+		RemoteAddress string `json:"RemoteAddress"`
+	} `json:"Rules"`
+	Profiles []FirewallProfile `json:"Profiles"`
+}
+
+func listWindowsFirewall(ctx context.Context) ([]FirewallProfile, []FirewallRule, error) {
+	output, err := runToolCommand(ctx, "", "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsFirewallListScript)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseWindowsFirewallList([]byte(output))
+}
+
+func parseWindowsFirewallList(output []byte) ([]FirewallProfile, []FirewallRule, error) {
+	if idx := bytes.IndexByte(output, '{'); idx > 0 {
+		output = output[idx:]
+	}
+	var raw windowsFirewallRaw
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, nil, fmt.Errorf("parse Get-NetFirewallRule output: %w", err)
+	}
+
+	profiles := make([]FirewallProfile, 0, len(raw.Profiles))
+	for _, p := range raw.Profiles {
+		profiles = append(profiles, FirewallProfile{Name: strings.ToLower(p.Name), Enabled: p.Enabled})
+	}
+
+	rules := make([]FirewallRule, 0, len(raw.Rules))
+	for _, r := range raw.Rules {
+		rule := FirewallRule{
+			ID:            r.Name,
+			Name:          r.DisplayName,
+			Direction:     FirewallDirectionInbound,
+			Action:        FirewallActionAllow,
+			Protocol:      windowsFirewallProtocol(r.Protocol),
+			RemoteAddress: windowsFirewallAny(r.RemoteAddress),
+			Profile:       strings.ToLower(windowsFirewallAny(strings.ReplaceAll(r.Profile, " ", ""))),
+			Enabled:       r.Enabled,
+			Managed:       r.Group == windowsFirewallGroup,
+		}
+		rule.Port = windowsFirewallAny(r.LocalPort)
+		if strings.EqualFold(r.Direction, "Outbound") {
+			rule.Direction = FirewallDirectionOutbound
+			rule.Port = windowsFirewallAny(r.RemotePort)
+		}
+		if strings.EqualFold(r.Action, "Block") {
+			rule.Action = FirewallActionBlock
+		}
+		rules = append(rules, rule)
+	}
+	return profiles, rules, nil
+}
+
+// windowsFirewallAny maps the cmdlets' "Any" wildcard to an empty value.
+func windowsFirewallAny(value string) string {
+	if strings.EqualFold(value, "Any") {
+		return ""
+	}
+	return value
+}
+
+func windowsFirewallProtocol(protocol string) string {
+	switch strings.ToUpper(protocol) {
+	case "TCP", "6":
+		return FirewallProtocolTCP
+	case "UDP", "17":
+		return FirewallProtocolUDP
+	case "", "ANY":
+		return FirewallProtocolAny
+	}
+	return strings.ToLower(protocol)
+}
+
+// windowsFirewallAddScript builds the New-NetFirewallRule call for a
+// normalized spec. Managed rules are named with the breeze: tag so a
+// fleet-wide removal can find them by the name they were created with.
+func windowsFirewallAddScript(spec FirewallRuleSpec) string {
+	direction := "Inbound"
+	portParam := "-LocalPort"
+	if spec.Direction == FirewallDirectionOutbound {
+		direction = "Outbound"
+		portParam = "-RemotePort"
+	}
+	action := "Block"
+	if spec.Action == FirewallActionAllow {
+		action = "Allow"
+	}
+
+	args := []string{
+		"New-NetFirewallRule",
+		"-Name", psQuote(firewallRuleTag + spec.Name),
+		"-DisplayName", psQuote(spec.Name),
+		"-Group", psQuote(windowsFirewallGroup),
+		"-Direction", direction,
+		"-Action", action,
+	}
+	if spec.Protocol != FirewallProtocolAny {
+		args = append(args, "-Protocol", strings.ToUpper(spec.Protocol))
+	}
+	if spec.Port != "" {
+		args = append(args, portParam, psQuote(spec.Port))
+	}
+	if spec.RemoteAddress != "" {
+		args = append(args, "-RemoteAddress", psQuote(spec.RemoteAddress))
+	}
+	profile := "Any"
+	if spec.Profile != "" {
+		profile = strings.ToUpper(spec.Profile[:1]) + spec.Profile[1:]
+	}
+	args = append(args, "-Profile", profile)
+	return "$ErrorActionPreference = 'Stop'; " + strings.Join(args, " ") + " | Out-Null"
+}
+
+func addWindowsFirewallRule(ctx context.Context, spec FirewallRuleSpec) (*FirewallRule, error) {
+	if _, err := runToolCommand(ctx, "", "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsFirewallAddScript(spec)); err != nil {
+		return nil, err
+	}
+	return &FirewallRule{
+		ID:            firewallRuleTag + spec.Name,
+		Name:          spec.Name,
+		Direction:     spec.Direction,
+		Action:        spec.Action,
+		Protocol:      spec.Protocol,
+		Port:          spec.Port,
+		RemoteAddress: spec.RemoteAddress,
+		Profile:       spec.Profile,
+		Enabled:       true,
+		Managed:       true,
+	}, nil
+}
+
+func removeWindowsFirewallRule(ctx context.Context, ref FirewallRuleRef) (int, error) {
+	id := ref.ID
+	if ref.Name != "" {
+		id = firewallRuleTag + ref.Name
+	}
+	script := fmt.Sprintf("$ErrorActionPreference = 'Stop'; $r = @(Get-NetFirewallRule -Name %s -ErrorAction SilentlyContinue); $r | Remove-NetFirewallRule; $r.Count", psQuote(id))
+	output, err := runToolCommand(ctx, "", "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	if err != nil {
+		return 0, err
+	}
+	var removed int
+	if _, err := fmt.Sscanf(strings.TrimSpace(output), "%d", &removed); err != nil {
+		return 0, fmt.Errorf("unexpected Remove-NetFirewallRule output %q", output)
+	}
+	return removed, nil
+}
+
+func setWindowsFirewallProfile(ctx context.Context, profile string, enabled bool) error {
+	switch profile {
+	case "domain", "private", "public":
+	default:
+		return fmt.Errorf("unknown Windows Firewall profile %q: use domain, private or public", profile)
+	}
+	state := "False"
+	if enabled {
+		state = "True"
+	}
+	script := fmt.Sprintf("$ErrorActionPreference = 'Stop'; Set-NetFirewallProfile -Profile %s -Enabled %s",
+		strings.ToUpper(profile[:1])+profile[1:], state)
+	_, err := runToolCommand(ctx, "", "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	return err
+}
@@ -0,0 +1,328 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Network isolation cuts a host off from everything except the Breeze
+// server. DNS, DHCP and loopback stay open so the agent can still resolve
+// and reach the server and keep its lease; every other flow in either
+// direction is dropped until ReleaseNetworkIsolation is called.
+const (
+	// isolationNFTable is a dedicated nftables table: its drop-policy chains
+	// apply on top of whatever ufw or firewalld load, since a packet dropped
+	// by any base chain is dropped.
+	isolationNFTable = "breeze_isolation"
+
+	// isolationPFAnchor sits next to pfAnchor under com.apple/*.
+	isolationPFAnchor = "com.apple/breeze-isolation"
+
+	isolationWindowsGroup = "Breeze Isolation"
+)
+
+var pfTokenPattern = regexp.MustCompile(`Token\s*:\s*(\d+)`)
+
+// NetworkIsolation is what IsolateNetwork changed on the host. Callers
+// persist it so ReleaseNetworkIsolation can undo exactly that, including
+// after an agent restart.
+type NetworkIsolation struct {
+	Backend string   `json:"backend"`
+	Allowed []string `json:"allowed"`
+	// PFToken is the pfctl -E reference that keeps pf enabled while isolated.
+	PFToken string `json:"pfToken,omitempty"`
+	// DisabledProfiles are the Windows Firewall profiles that were off before
+	// isolation switched them on; release switches them off again.
+	DisabledProfiles []string `json:"disabledProfiles,omitempty"`
+}
+
+// IsolateNetwork blocks all traffic except to and from the allowed
+// addresses. Applying it again replaces the allow list; pass the state
+// returned last time as previous so the firewall state recorded before the
+// first isolation is kept.
+func IsolateNetwork(allowed []net.IP, previous *NetworkIsolation) (*NetworkIsolation, error) {
+	if len(allowed) == 0 {
+		return nil, errors.New("network isolation needs at least one allowed address")
+	}
+	addrs := make([]string, 0, len(allowed))
+	for _, ip := range allowed {
+		addrs = append(addrs, ip.String())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), firewallCommandTimeout)
+	defer cancel()
+
+	switch runtime.GOOS {
+	case "windows":
+		output, err := runToolCommand(ctx, "", "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsIsolationScript(allowed))
+		if err != nil {
+			return nil, err
+		}
+		state := &NetworkIsolation{Backend: FirewallBackendWindows, Allowed: addrs, DisabledProfiles: parseDisabledProfiles(output)}
+		if previous != nil && previous.Backend == FirewallBackendWindows {
+			// The profiles are already on from the previous isolation.
+			state.DisabledProfiles = previous.DisabledProfiles
+		}
+		return state, nil
+	case "darwin":
+		if _, err := runToolCommand(ctx, pfIsolationRules(allowed), "pfctl", "-a", isolationPFAnchor, "-f", "-"); err != nil {
+			return nil, err
+		}
+		// -E takes a reference so pf stays on while isolated without
+		// overriding an admin's own pfctl -e/-d once the reference is released.
+		output, err := runToolCommand(ctx, "", "pfctl", "-E")
+		if err != nil {
+			return nil, err
+		}
+		// States created before the anchor was loaded would bypass it.
+		_, _ = runToolCommand(ctx, "", "pfctl", "-F", "states")
+		state := &NetworkIsolation{Backend: FirewallBackendPF, Allowed: addrs}
+		if m := pfTokenPattern.FindStringSubmatch(output); m != nil {
+			state.PFToken = m[1]
+		}
+		if previous != nil && previous.PFToken != "" && previous.PFToken != state.PFToken {
+			// Drop the earlier reference; it is already gone after a reboot.
+			_, _ = runToolCommand(ctx, "", "pfctl", "-X", previous.PFToken)
+		}
+		return state, nil
+	case "linux":
+		if !hasCommand("nft") {
+			return nil, fmt.Errorf("network isolation requires nftables (nft): %w", ErrNotSupported)
+		}
+		if _, err := runToolCommand(ctx, nftIsolationRuleset(allowed), "nft", "-f", "-"); err != nil {
+			return nil, err
+		}
+		return &NetworkIsolation{Backend: "nftables", Allowed: addrs}, nil
+	}
+	return nil, fmt.Errorf("network isolation on %s: %w", runtime.GOOS, ErrNotSupported)
+}
+
+// ReleaseNetworkIsolation removes the rules IsolateNetwork installed and
+// restores the firewall state it changed. Releasing an isolation that is no
+// longer in place is not an error.
+func ReleaseNetworkIsolation(state *NetworkIsolation) error {
+	if state == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), firewallCommandTimeout)
+	defer cancel()
+
+	switch state.Backend {
+	case FirewallBackendWindows:
+		_, err := runToolCommand(ctx, "", "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsReleaseIsolationScript(state.DisabledProfiles))
+		return err
+	case FirewallBackendPF:
+		if _, err := runToolCommand(ctx, "", "pfctl", "-a", isolationPFAnchor, "-F", "all"); err != nil {
+			return err
+		}
+		if state.PFToken != "" {
+			// Fails harmlessly when the token died with a reboot.
+			_, _ = runToolCommand(ctx, "", "pfctl", "-X", state.PFToken)
+		}
+		return nil
+	case "nftables":
+		output, err := runToolCommand(ctx, "", "nft", "delete", "table", "inet", isolationNFTable)
+		if err != nil && !strings.Contains(output, "No such file or directory") {
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown isolation backend %q", state.Backend)
+}
+
+// splitFamilies returns the IPv4 and IPv6 addresses in ips as strings.
+func splitFamilies(ips []net.IP) (v4, v6 []string) {
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			v4 = append(v4, ip.To4().String())
+		} else if ip.To16() != nil {
+			v6 = append(v6, ip.String())
+		}
+	}
+	return v4, v6
+}
+
+// nftIsolationRuleset renders the isolation table for nft -f. Declaring the
+// table before deleting it makes the load idempotent in a single transaction.
+// Only the input chain accepts established traffic, so connections opened
+// before isolation cannot keep sending out.
+func nftIsolationRuleset(allowed []net.IP) string {
+	v4, v6 := splitFamilies(allowed)
+	var b strings.Builder
+	fmt.Fprintf(&b, "table inet %s {}\ndelete table inet %s\ntable inet %s {\n", isolationNFTable, isolationNFTable, isolationNFTable)
+
+	b.WriteString("\tchain input {\n\t\ttype filter hook input priority -10; policy drop;\n")
+	b.WriteString("\t\tiif \"lo\" accept\n\t\tct state established,related accept\n")
+	if len(v4) > 0 {
+		fmt.Fprintf(&b, "\t\tip saddr { %s } accept\n", strings.Join(v4, ", "))
+	}
+	if len(v6) > 0 {
+		fmt.Fprintf(&b, "\t\tip6 saddr { %s } accept\n", strings.Join(v6, ", "))
+	}
+	b.WriteString("\t\tudp dport { 68, 546 } accept\n")
+	b.WriteString("\t\ticmpv6 type { nd-neighbor-solicit, nd-neighbor-advert, nd-router-advert } accept\n\t}\n")
+
+	b.WriteString("\tchain output {\n\t\ttype filter hook output priority -10; policy drop;\n")
+	b.WriteString("\t\toif \"lo\" accept\n")
+	if len(v4) > 0 {
+		fmt.Fprintf(&b, "\t\tip daddr { %s } accept\n", strings.Join(v4, ", "))
+	}
+	if len(v6) > 0 {
+		fmt.Fprintf(&b, "\t\tip6 daddr { %s } accept\n", strings.Join(v6, ", "))
+	}
+	b.WriteString("\t\tmeta l4proto { tcp, udp } th dport 53 accept\n")
+	b.WriteString("\t\tudp dport { 67, 547 } accept\n")
+	b.WriteString("\t\ticmpv6 type { nd-neighbor-solicit, nd-neighbor-advert, nd-router-solicit } accept\n\t}\n}\n")
+	return b.String()
+}
+
+// pfIsolationRules renders the isolation anchor. The server rules are
+// stateless so the agent's existing connection survives pf being enabled
+// mid-stream.
+func pfIsolationRules(allowed []net.IP) string {
+	v4, v6 := splitFamilies(allowed)
+	lines := []string{
+		"pass quick on lo0 all",
+		"pass out quick proto { tcp udp } from any to any port 53 keep state",
+		"pass out quick proto udp from any port 68 to any port 67 keep state",
+		"pass out quick proto udp from any port 546 to any port 547 keep state",
+		"pass quick inet6 proto icmp6 icmp6-type { neighbrsol neighbradv routersol routeradv }",
+	}
+	if len(v4) > 0 {
+		set := strings.Join(v4, " ")
+		lines = append(lines,
+			fmt.Sprintf("pass out quick inet from any to { %s } no state", set),
+			fmt.Sprintf("pass in quick inet from { %s } to any no state", set))
+	}
+	if len(v6) > 0 {
+		set := strings.Join(v6, " ")
+		lines = append(lines,
+			fmt.Sprintf("pass out quick inet6 from any to { %s } no state", set),
+			fmt.Sprintf("pass in quick inet6 from { %s } to any no state", set))
+	}
+	lines = append(lines, "block drop quick all")
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// windowsIsolationScript replaces the isolation rule group and switches every
+// firewall profile on, printing the profiles that were off. Windows Firewall
+// has no default-deny per rule group and a block rule always beats an allow
+// rule, so the block rules cover the complement of the allowed addresses
+// with DNS and DHCP ports cut out. ICMPv6 is left alone for neighbor
+// discovery.
+func windowsIsolationScript(allowed []net.IP) string {
+	remote := psStringArray(complementRanges(allowed))
+	var b strings.Builder
+	b.WriteString("$ErrorActionPreference = 'Stop'; ")
+	fmt.Fprintf(&b, "$g = %s; ", psQuote(isolationWindowsGroup))
+	b.WriteString("Get-NetFirewallRule -Group $g -ErrorAction SilentlyContinue | Remove-NetFirewallRule; ")
+	fmt.Fprintf(&b, "$remote = %s; ", remote)
+	rules := []struct{ name, direction, protocol, portParam, ports string }{
+		{"Block outbound TCP", "Outbound", "TCP", "-RemotePort", "'1-52','54-65535'"},
+		{"Block outbound UDP", "Outbound", "UDP", "-RemotePort", "'1-52','54-66','68-546','548-65535'"},
+		{"Block outbound ICMPv4", "Outbound", "ICMPv4", "", ""},
+		{"Block inbound TCP", "Inbound", "TCP", "", ""},
+		{"Block inbound UDP", "Inbound", "UDP", "-LocalPort", "'1-67','69-545','547-65535'"},
+		{"Block inbound ICMPv4", "Inbound", "ICMPv4", "", ""},
+	}
+	for _, r := range rules {
+		fmt.Fprintf(&b, "New-NetFirewallRule -DisplayName %s -Group $g -Direction %s -Action Block -Protocol %s -RemoteAddress $remote",
+			psQuote("Breeze isolation: "+r.name), r.direction, r.protocol)
+		if r.portParam != "" {
+			fmt.Fprintf(&b, " %s %s", r.portParam, r.ports)
+		}
+		b.WriteString(" -Profile Any | Out-Null; ")
+	}
+	b.WriteString("$off = @(Get-NetFirewallProfile | Where-Object { [string]$_.Enabled -eq 'False' } | ForEach-Object { [string]$_.Name }); ")
+	b.WriteString("Set-NetFirewallProfile -All -Enabled True; ")
+	b.WriteString("$off -join ','")
+	return b.String()
+}
+
+func windowsReleaseIsolationScript(disabledProfiles []string) string {
+	script := fmt.Sprintf("$ErrorActionPreference = 'Stop'; Get-NetFirewallRule -Group %s -ErrorAction SilentlyContinue | Remove-NetFirewallRule", psQuote(isolationWindowsGroup))
+	for _, profile := range disabledProfiles {
+		switch profile {
+		case "Domain", "Private", "Public":
+			script += "; Set-NetFirewallProfile -Profile " + profile + " -Enabled False"
+		}
+	}
+	return script
+}
+
+func parseDisabledProfiles(output string) []string {
+	var profiles []string
+	for _, name := range strings.Split(strings.TrimSpace(output), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			profiles = append(profiles, name)
+		}
+	}
+	return profiles
+}
+
+func psStringArray(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = psQuote(v)
+	}
+	return "@(" + strings.Join(quoted, ",") + ")"
+}
+
+// complementRanges returns the address ranges ("first-last", or a single
+// address) of both families that exclude every allowed address.
+func complementRanges(allowed []net.IP) []string {
+	v4, v6 := splitFamilies(allowed)
+	ranges := complementFamily(v4, net.IPv4len)
+	return append(ranges, complementFamily(v6, net.IPv6len)...)
+}
+
+func complementFamily(addrs []string, size int) []string {
+	var excluded []*big.Int
+	seen := map[string]bool{}
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr)
+		if size == net.IPv4len {
+			ip = ip.To4()
+		}
+		if ip == nil || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		excluded = append(excluded, new(big.Int).SetBytes(ip))
+	}
+	sort.Slice(excluded, func(i, j int) bool { return excluded[i].Cmp(excluded[j]) < 0 })
+
+	one := big.NewInt(1)
+	last := new(big.Int).Sub(new(big.Int).Lsh(one, uint(size*8)), one)
+	var ranges []string
+	start := big.NewInt(0)
+	for _, x := range excluded {
+		if start.Cmp(x) < 0 {
+			ranges = append(ranges, formatRange(start, new(big.Int).Sub(x, one), size))
+		}
+		start = new(big.Int).Add(x, one)
+	}
+	if start.Cmp(last) <= 0 {
+		ranges = append(ranges, formatRange(start, last, size))
+	}
+	return ranges
+}
+
+func formatRange(first, last *big.Int, size int) string {
+	if first.Cmp(last) == 0 {
+		return bigToIP(first, size).String()
+	}
+	return bigToIP(first, size).String() + "-" + bigToIP(last, size).String()
+}
+
+func bigToIP(n *big.Int, size int) net.IP {
+	ip := make(net.IP, size)
+	n.FillBytes(ip)
+	return ip
+}
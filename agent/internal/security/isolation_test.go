@@ -0,0 +1,74 @@
+package security
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComplementRanges(t *testing.T) {
+	got := complementRanges([]net.IP{net.ParseIP("203.0.113.10"), net.ParseIP("2001:db8::1"), net.ParseIP("0.0.0.0")})
+	want := []string{
+		"0.0.0.1-203.0.113.9",
+		"203.0.113.11-255.255.255.255",
+		"::-2001:db8::",
+		"2001:db8::2-ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("complementRanges = %v, want %v", got, want)
+	}
+
+	got = complementRanges([]net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.3"), net.ParseIP("10.0.0.1")})
+	if len(got) != 4 || got[0] != "0.0.0.0-10.0.0.0" || got[1] != "10.0.0.2" || got[2] != "10.0.0.4-255.255.255.255" {
+		t.Fatalf("adjacent exclusions = %v", got)
+	}
+}
+
+func TestNFTIsolationRuleset(t *testing.T) {
+	ruleset := nftIsolationRuleset([]net.IP{net.ParseIP("203.0.113.10"), net.ParseIP("2001:db8::1")})
+	for _, want := range []string{
+		"table inet breeze_isolation {}\ndelete table inet breeze_isolation\n",
+		"type filter hook output priority -10; policy drop;",
+		"ip daddr { 203.0.113.10 } accept",
+		"ip6 saddr { 2001:db8::1 } accept",
+		"th dport 53 accept",
+	} {
+		if !strings.Contains(ruleset, want) {
+			t.Errorf("ruleset missing %q:\n%s", want, ruleset)
+		}
+	}
+	output := ruleset[strings.Index(ruleset, "chain output"):]
+	if strings.Contains(output, "ct state") {
+		t.Fatal("output must not accept established flows to non-allowed hosts")
+	}
+}
+
+func TestPFIsolationRules(t *testing.T) {
+	rules := strings.Split(strings.TrimSpace(pfIsolationRules([]net.IP{net.ParseIP("203.0.113.10")})), "\n")
+	if rules[len(rules)-1] != "block drop quick all" {
+		t.Fatalf("last rule = %q, want the catch-all block", rules[len(rules)-1])
+	}
+	joined := strings.Join(rules, "\n")
+	if !strings.Contains(joined, "pass out quick inet from any to { 203.0.113.10 } no state") || strings.Contains(joined, "inet6 from any to") {
+		t.Fatalf("unexpected rules:\n%s", joined)
+	}
+}
+
+func TestWindowsIsolationScripts(t *testing.T) {
+	script := windowsIsolationScript([]net.IP{net.ParseIP("203.0.113.10")})
+	if !strings.Contains(script, "$remote = @('0.0.0.0-203.0.113.9','203.0.113.11-255.255.255.255','::-ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff')") {
+		t.Fatalf("remote ranges wrong:\n%s", script)
+	}
+	if strings.Count(script, "New-NetFirewallRule") != 6 || !strings.Contains(script, "-RemotePort '1-52','54-65535'") {
+		t.Fatalf("unexpected rules:\n%s", script)
+	}
+
+	if got := parseDisabledProfiles("Domain,Public\r\n"); !reflect.DeepEqual(got, []string{"Domain", "Public"}) {
+		t.Fatalf("parseDisabledProfiles = %v", got)
+	}
+	release := windowsReleaseIsolationScript([]string{"Public", "Evil; rm"})
+	if !strings.Contains(release, "-Profile Public -Enabled False") || strings.Contains(release, "Evil") {
+		t.Fatalf("release script = %s", release)
+	}
+}
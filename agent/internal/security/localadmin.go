@@ -0,0 +1,283 @@
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// KeyTypeLocalAdminPassword is a local account password set by Breeze and
+// escrowed. Its Mount is the account name.
+const KeyTypeLocalAdminPassword = "local_admin_password"
+
+// windowsAdministratorsSID is the builtin Administrators group, whose name
+// is localized.
+const windowsAdministratorsSID = "S-1-5-32-544"
+
+var (
+	// Windows group members may be domain principals (DOMAIN\name).
+	windowsAccountPattern = regexp.MustCompile(`^[^"/\[\]:;|=,+*?<>@\x00-\x1f]{1,104}$`)
+	unixAccountPattern    = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,63}\$?$`)
+)
+
+func validateAccountName(name string) error {
+	pattern := unixAccountPattern
+	if runtime.GOOS == "windows" {
+		pattern = windowsAccountPattern
+	}
+	if strings.TrimSpace(name) != name || !pattern.MatchString(name) {
+		return fmt.Errorf("invalid account name %q", name)
+	}
+	return nil
+}
+
+// accountMatches compares an admin group member with a requested account
+// name. Windows members are reported as COMPUTER\name or DOMAIN\name, and a
+// bare name matches either.
+func accountMatches(member, name string) bool {
+	if runtime.GOOS != "windows" {
+		return member == name
+	}
+	if strings.EqualFold(member, name) {
+		return true
+	}
+	if strings.Contains(name, `\`) {
+		return false
+	}
+	_, short, ok := strings.Cut(member, `\`)
+	return ok && strings.EqualFold(short, name)
+}
+
+// adminMembers lists the admin group members the security status reports.
+func adminMembers() ([]string, error) {
+	summary, err := collectLocalAdminSummary()
+	if err != nil {
+		return nil, err
+	}
+	accounts, _ := summary["accounts"].([]map[string]any)
+	members := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		if username, ok := account["username"].(string); ok && username != "" {
+			members = append(members, username)
+		}
+	}
+	return members, nil
+}
+
+// checkNotLastAdmin refuses a change that would leave the admin group with
+// no other member, which locks everyone out of administering the device.
+func checkNotLastAdmin(members []string, name string) (isAdmin bool, err error) {
+	others := 0
+	for _, member := range members {
+		if accountMatches(member, name) {
+			isAdmin = true
+		} else {
+			others++
+		}
+	}
+	if isAdmin && others == 0 {
+		return true, fmt.Errorf("%s is the only administrator; refusing to lock the device out of administration", name)
+	}
+	return isAdmin, nil
+}
+
+// RemoveLocalAdmin removes an account from the local administrators group
+// (Administrators on Windows, admin on macOS, sudo and wheel on Linux). The
+// last remaining member is never removed.
+func RemoveLocalAdmin(name string) error {
+	if err := validateAccountName(name); err != nil {
+		return err
+	}
+	members, err := adminMembers()
+	if err != nil {
+		return fmt.Errorf("list administrators: %w", err)
+	}
+	isAdmin, err := checkNotLastAdmin(members, name)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return fmt.Errorf("%s is not a member of the administrators group", name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	switch runtime.GOOS {
+	case "windows":
+		_, err = runToolCommand(ctx, "", "powershell", "-NoProfile", "-NonInteractive", "-Command",
+			fmt.Sprintf("Remove-LocalGroupMember -SID '%s' -Member %s -ErrorAction Stop", windowsAdministratorsSID, psQuote(name)))
+	case "darwin":
+		_, err = runToolCommand(ctx, "", "dseditgroup", "-o", "edit", "-d", name, "-t", "user", "admin")
+	case "linux":
+		removed := 0
+		for _, group := range []string{"sudo", "wheel"} {
+			output, lookupErr := runCommand(5*time.Second, "getent", "group", group)
+			if lookupErr != nil || !slices.Contains(parseGroupMembers(output), name) {
+				continue
+			}
+			if _, err = runToolCommand(ctx, "", "gpasswd", "-d", name, group); err != nil {
+				return err
+			}
+			removed++
+		}
+		if removed == 0 {
+			// Admin through the primary group or sudoers, not group membership.
+			return fmt.Errorf("%s is not listed in the sudo or wheel group", name)
+		}
+	default:
+		return ErrNotSupported
+	}
+	return err
+}
+
+// DisableLocalAccount disables a local account so it can no longer sign in.
+// root and the only remaining administrator are refused.
+func DisableLocalAccount(name string) error {
+	if err := validateAccountName(name); err != nil {
+		return err
+	}
+	if runtime.GOOS != "windows" && name == "root" {
+		return errors.New("refusing to disable root")
+	}
+	members, err := adminMembers()
+	if err != nil {
+		return fmt.Errorf("list administrators: %w", err)
+	}
+	if _, err := checkNotLastAdmin(members, name); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	switch runtime.GOOS {
+	case "windows":
+		_, err = runToolCommand(ctx, "", "powershell", "-NoProfile", "-NonInteractive", "-Command",
+			"Disable-LocalUser -Name "+psQuote(name)+" -ErrorAction Stop")
+	case "darwin":
+		_, err = runToolCommand(ctx, "", "pwpolicy", "-u", name, "-disableuser")
+	case "linux":
+		// Locking the password alone still allows SSH key logins; an expired
+		// account allows none.
+		_, err = runToolCommand(ctx, "", "usermod", "--lock", "--expiredate", "1", name)
+	default:
+		return ErrNotSupported
+	}
+	return err
+}
+
+// RotateLocalAdminPassword sets a generated password on a local account. The
+// password is handed to escrow first and only set once escrow succeeded, so
+// the account never has a password the server does not know. If setting it
+// fails afterwards the account keeps its previous password and the escrowed
+// one is handed to revoke, so the server does not offer a password that was
+// never applied.
+func RotateLocalAdminPassword(name string, escrow, revoke func(RecoveryKey) error) error {
+	if err := validateAccountName(name); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" && strings.Contains(name, `\`) {
+		return errors.New("only local accounts can have their password rotated")
+	}
+	switch runtime.GOOS {
+	case "windows", "darwin", "linux":
+	default:
+		return ErrNotSupported
+	}
+
+	password, err := generateLocalAdminPassword(20)
+	if err != nil {
+		return err
+	}
+	key := RecoveryKey{Mount: name, KeyType: KeyTypeLocalAdminPassword, Key: password}
+	if err := escrow(key); err != nil {
+		return fmt.Errorf("password escrow failed, account unchanged: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	stdin, command := setPasswordCommand(runtime.GOOS, name, password)
+	output, err := runAccountCommand(ctx, stdin, command[0], command[1:]...)
+	if err == nil && runtime.GOOS == "darwin" && strings.Contains(output, "DS Error") {
+		// dscl's interactive mode reports a failed command but exits 0.
+		err = fmt.Errorf("dscl failed: %s", output)
+	}
+	if err != nil {
+		if revokeErr := revoke(key); revokeErr != nil {
+			return fmt.Errorf("new password could not be set and its escrow could not be revoked (%v); the account keeps its previous password: %w", revokeErr, err)
+		}
+		return fmt.Errorf("new password could not be set and its escrow was revoked; the account keeps its previous password: %w", err)
+	}
+	return nil
+}
+
+// runAccountCommand is a variable so tests can rotate a password without
+// touching a real account.
+var runAccountCommand = runToolCommand
+
+// setPasswordCommand returns the stdin and command line that set password on
+// a local account. The password always travels on stdin, never in a command
+// line other local users could read from the process list.
+func setPasswordCommand(goos, name, password string) (stdin string, command []string) {
+	switch goos {
+	case "windows":
+		return password + "\n", []string{"powershell", "-NoProfile", "-NonInteractive", "-Command",
+			"$p = ConvertTo-SecureString ([Console]::In.ReadLine()) -AsPlainText -Force; Set-LocalUser -Name " + psQuote(name) + " -Password $p -ErrorAction Stop"}
+	case "darwin":
+		// dscl -passwd only takes the password as an argument; its
+		// interactive mode reads the same command from stdin instead.
+		return "passwd /Users/" + name + " " + password + "\n", []string{"dscl", "."}
+	default:
+		return name + ":" + password + "\n", []string{"chpasswd"}
+	}
+}
+
+const (
+	passwordUpper   = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	passwordLower   = "abcdefghijkmnopqrstuvwxyz"
+	passwordDigits  = "23456789"
+	passwordSymbols = "!#%+-=?@^_"
+)
+
+// generateLocalAdminPassword returns a password with at least one character
+// of each class, so it satisfies Windows complexity rules, and without the
+// easily confused I, l, O, 0 and 1.
+func generateLocalAdminPassword(length int) (string, error) {
+	classes := []string{passwordUpper, passwordLower, passwordDigits, passwordSymbols}
+	all := strings.Join(classes, "")
+	out := make([]byte, length)
+	for i := range out {
+		set := all
+		if i < len(classes) {
+			set = classes[i]
+		}
+		c, err := randomChar(set)
+		if err != nil {
+			return "", fmt.Errorf("generate password: %w", err)
+		}
+		out[i] = c
+	}
+	// Shuffle so the guaranteed classes are not always up front.
+	for i := len(out) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", fmt.Errorf("generate password: %w", err)
+		}
+		out[i], out[j.Int64()] = out[j.Int64()], out[i]
+	}
+	return string(out), nil
+}
+
+func randomChar(set string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(set))))
+	if err != nil {
+		return 0, err
+	}
+	return set[n.Int64()], nil
+}
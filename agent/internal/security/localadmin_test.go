@@ -0,0 +1,128 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestGenerateLocalAdminPasswordHasEveryClass(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		password, err := generateLocalAdminPassword(20)
+		if err != nil {
+			t.Fatalf("generateLocalAdminPassword: %v", err)
+		}
+		if len(password) != 20 {
+			t.Fatalf("length = %d, want 20", len(password))
+		}
+		for _, class := range []string{passwordUpper, passwordLower, passwordDigits, passwordSymbols} {
+			if !strings.ContainsAny(password, class) {
+				t.Fatalf("%q has no character from %q", password, class)
+			}
+		}
+		if strings.ContainsAny(password, "Il0O1:\n") {
+			t.Fatalf("%q contains an excluded character", password)
+		}
+	}
+}
+
+func TestCheckNotLastAdmin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("account names are matched case-insensitively on Windows")
+	}
+	if _, err := checkNotLastAdmin([]string{"alice"}, "alice"); err == nil {
+		t.Fatal("removing the only admin should be refused")
+	}
+	isAdmin, err := checkNotLastAdmin([]string{"alice", "bob"}, "alice")
+	if err != nil || !isAdmin {
+		t.Fatalf("checkNotLastAdmin = %v, %v; want true, nil", isAdmin, err)
+	}
+	isAdmin, err = checkNotLastAdmin([]string{"bob"}, "carol")
+	if err != nil || isAdmin {
+		t.Fatalf("non-member: checkNotLastAdmin = %v, %v; want false, nil", isAdmin, err)
+	}
+}
+
+func TestValidateAccountName(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix account name rules")
+	}
+	for _, name := range []string{"alice", "svc-backup", "j.doe", "build_01", "machine$"} {
+		if err := validateAccountName(name); err != nil {
+			t.Errorf("validateAccountName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "-rf", "alice bob", " alice", "a/b", "a:b", "$(id)", strings.Repeat("a", 65)} {
+		if err := validateAccountName(name); err == nil {
+			t.Errorf("validateAccountName(%q) accepted", name)
+		}
+	}
+}
+
+func TestWindowsAccountPattern(t *testing.T) {
+	for _, name := range []string{"Administrator", `CONTOSO\Domain Admins`, `HOST\it.admin`, "José"} {
+		if !windowsAccountPattern.MatchString(name) {
+			t.Errorf("%q rejected", name)
+		}
+	}
+	for _, name := range []string{"", "a;b", "a\nb", `a"b`, "a*"} {
+		if windowsAccountPattern.MatchString(name) {
+			t.Errorf("%q accepted", name)
+		}
+	}
+}
+
+func TestSetPasswordCommandKeepsPasswordOffTheCommandLine(t *testing.T) {
+	const password = "Kp7#vQ2m@Xr9_tWz4!hB"
+	for _, goos := range []string{"windows", "darwin", "linux"} {
+		stdin, command := setPasswordCommand(goos, "alice", password)
+		if !strings.Contains(stdin, password) {
+			t.Errorf("%s: password not passed on stdin", goos)
+		}
+		for _, arg := range command {
+			if strings.Contains(arg, password) {
+				t.Errorf("%s: password appears in argument %q", goos, arg)
+			}
+		}
+	}
+}
+
+func TestRotateLocalAdminPasswordRevokesEscrowWhenSetFails(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "windows" {
+		t.Skip("rotation is not supported on this platform")
+	}
+	orig := runAccountCommand
+	runAccountCommand = func(context.Context, string, string, ...string) (string, error) {
+		return "", errors.New("set failed")
+	}
+	t.Cleanup(func() { runAccountCommand = orig })
+
+	var escrowed, revoked RecoveryKey
+	err := RotateLocalAdminPassword("alice",
+		func(key RecoveryKey) error { escrowed = key; return nil },
+		func(key RecoveryKey) error { revoked = key; return nil })
+	if err == nil {
+		t.Fatal("a failed set must fail the rotation")
+	}
+	if escrowed.Key == "" || revoked != escrowed {
+		t.Fatalf("the escrowed password must be revoked, escrowed %+v revoked %+v", escrowed, revoked)
+	}
+}
+
+func TestRotateLocalAdminPasswordLeavesEscrowOnSuccess(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "windows" {
+		t.Skip("rotation is not supported on this platform")
+	}
+	orig := runAccountCommand
+	runAccountCommand = func(context.Context, string, string, ...string) (string, error) { return "", nil }
+	t.Cleanup(func() { runAccountCommand = orig })
+
+	err := RotateLocalAdminPassword("alice",
+		func(RecoveryKey) error { return nil },
+		func(RecoveryKey) error { t.Fatal("a successful rotation must not revoke its escrow"); return nil })
+	if err != nil {
+		t.Fatalf("RotateLocalAdminPassword: %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeyTypeLUKS is a passphrase in a LUKS keyslot that Breeze added and
+// escrowed. Its ProtectorID is the keyslot number.
+const KeyTypeLUKS = "luks_recovery_passphrase"
+
+var (
+	luks1KeySlotPattern = regexp.MustCompile(`^Key Slot (\d+): ENABLED`)
+	luks2KeySlotPattern = regexp.MustCompile(`^ {2}(\d+): `)
+)
+
+// LUKSHeader is the part of `cryptsetup luksDump` worth reporting.
+type LUKSHeader struct {
+	Version  int    `json:"version"`
+	UUID     string `json:"uuid,omitempty"`
+	Cipher   string `json:"cipher,omitempty"`
+	KeySlots []int  `json:"keySlots"`
+}
+
+func parseLUKSDump(output string) LUKSHeader {
+	header := LUKSHeader{KeySlots: []int{}}
+	var cipherName, cipherMode, section string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		key, value, _ := strings.Cut(trimmed, ":")
+		value = strings.TrimSpace(value)
+
+		// LUKS2 groups its details in unindented sections.
+		if line != "" && line[0] != ' ' && line[0] != '\t' && value == "" {
+			section = key
+			continue
+		}
+		switch {
+		case key == "Version":
+			header.Version, _ = strconv.Atoi(value)
+		case key == "UUID":
+			header.UUID = value
+		case key == "Cipher name":
+			cipherName = value
+		case key == "Cipher mode":
+			cipherMode = value
+		case key == "cipher" && section == "Data segments" && header.Cipher == "":
+			header.Cipher = value
+		}
+		if m := luks1KeySlotPattern.FindStringSubmatch(trimmed); m != nil {
+			slot, _ := strconv.Atoi(m[1])
+			header.KeySlots = append(header.KeySlots, slot)
+		} else if m := luks2KeySlotPattern.FindStringSubmatch(line); m != nil && section == "Keyslots" {
+			slot, _ := strconv.Atoi(m[1])
+			header.KeySlots = append(header.KeySlots, slot)
+		}
+	}
+	if header.Cipher == "" && cipherName != "" {
+		header.Cipher = cipherName
+		if cipherMode != "" {
+			header.Cipher += "-" + cipherMode
+		}
+	}
+	return header
+}
+
+// ReadLUKSHeader dumps the header of a LUKS device.
+func ReadLUKSHeader(device string) (LUKSHeader, error) {
+	output, err := runCommand(10*time.Second, "cryptsetup", "luksDump", device)
+	if err != nil {
+		return LUKSHeader{}, err
+	}
+	return parseLUKSDump(output), nil
+}
+
+// linuxVolume is one mounted filesystem and, when it is encrypted, the LUKS
+// device underneath it.
+type linuxVolume struct {
+	Mount      string
+	Device     string
+	Protected  bool
+	LUKSDevice string
+}
+
+type lsblkNode struct {
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	Mountpoint string      `json:"mountpoint"`
+	Fstype     string      `json:"fstype"`
+	Children   []lsblkNode `json:"children"`
+}
+
+func parseLsblkVolumes(output string) ([]linuxVolume, error) {
+	var payload struct {
+		Blockdevices []lsblkNode `json:"blockdevices"`
+	}
+	if err := json.Unmarshal([]byte(output), &payload); err != nil {
+		return nil, err
+	}
+
+	var volumes []linuxVolume
+	var walk func(node lsblkNode, inheritedProtected bool, luksDevice string)
+	walk = func(node lsblkNode, inheritedProtected bool, luksDevice string) {
+		isProtected := inheritedProtected ||
+			strings.EqualFold(node.Type, "crypt") ||
+			strings.Contains(strings.ToLower(node.Fstype), "luks")
+		if strings.Contains(strings.ToLower(node.Fstype), "luks") {
+			luksDevice = "/dev/" + node.Name
+		}
+
+		if strings.TrimSpace(node.Mountpoint) != "" {
+			volumes = append(volumes, linuxVolume{
+				Mount:      node.Mountpoint,
+				Device:     node.Name,
+				Protected:  isProtected,
+				LUKSDevice: luksDevice,
+			})
+		}
+
+		for _, child := range node.Children {
+			walk(child, isProtected, luksDevice)
+		}
+	}
+
+	for _, node := range payload.Blockdevices {
+		walk(node, false, "")
+	}
+	return volumes, nil
+}
+
+func listLinuxVolumes() ([]linuxVolume, error) {
+	output, err := runCommand(8*time.Second, "lsblk", "-J", "-o", "NAME,TYPE,MOUNTPOINT,FSTYPE")
+	if err != nil {
+		return nil, err
+	}
+	return parseLsblkVolumes(output)
+}
+
+// LUKSEscrowOptions is the escrow command's payload. Adding a keyslot needs
+// a passphrase that already opens the device: the user's, or the one
+// escrowed last time, which is then removed in favour of the new one.
+type LUKSEscrowOptions struct {
+	// Device is the LUKS block device; empty means the one under /.
+	Device            string
+	Passphrase        string
+	PreviousEscrowKey string
+}
+
+// LUKSEscrowResult reports what changed. It never carries a passphrase.
+type LUKSEscrowResult struct {
+	Device string `json:"device"`
+	// KeySlot is -1 if the new keyslot could not be told apart.
+	KeySlot         int  `json:"keySlot"`
+	PreviousRemoved bool `json:"previousRemoved"`
+	// Warning is set when the new key is escrowed but the previous escrowed
+	// keyslot could not be removed.
+	Warning string `json:"warning,omitempty"`
+}
+
+// AddLUKSEscrowKey adds a keyslot with a generated recovery passphrase and
+// hands it to escrow. If escrow fails the new keyslot is removed again, so
+// the device never holds a passphrase the server does not. Should that
+// removal fail too, the key is returned with the error and the caller must
+// keep trying to escrow it.
+func AddLUKSEscrowKey(opts LUKSEscrowOptions, escrow func(RecoveryKey) error) (*LUKSEscrowResult, RecoveryKey, error) {
+	if runtime.GOOS != "linux" {
+		return nil, RecoveryKey{}, fmt.Errorf("luks: %w", ErrNotSupported)
+	}
+	if !hasCommand("cryptsetup") {
+		return nil, RecoveryKey{}, fmt.Errorf("cryptsetup is not installed: %w", ErrNotSupported)
+	}
+	unlockKey := opts.Passphrase
+	if unlockKey == "" {
+		unlockKey = opts.PreviousEscrowKey
+	}
+	if unlockKey == "" {
+		return nil, RecoveryKey{}, errors.New("a passphrase that opens the device is required")
+	}
+
+	volumes, err := listLinuxVolumes()
+	if err != nil {
+		return nil, RecoveryKey{}, fmt.Errorf("list volumes: %w", err)
+	}
+	device, err := resolveLUKSDevice(volumes, opts.Device)
+	if err != nil {
+		return nil, RecoveryKey{}, err
+	}
+
+	before, err := ReadLUKSHeader(device)
+	if err != nil {
+		return nil, RecoveryKey{}, fmt.Errorf("read luks header: %w", err)
+	}
+	passphrase, err := generateLUKSPassphrase()
+	if err != nil {
+		return nil, RecoveryKey{}, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	// Key files are read verbatim, so neither passphrase gets a newline.
+	if err := runCryptsetup(ctx, unlockKey, passphrase, "luksAddKey", "--key-file=-", device, "/dev/fd/3"); err != nil {
+		return nil, RecoveryKey{}, fmt.Errorf("add keyslot: %w", err)
+	}
+
+	// The keyslot exists now, so from here on the key must reach escrow or
+	// be removed; the slot number is only informational.
+	key := RecoveryKey{Mount: device, KeyType: KeyTypeLUKS, Key: passphrase}
+	slot := -1
+	if after, err := ReadLUKSHeader(device); err == nil {
+		for _, s := range after.KeySlots {
+			if !slices.Contains(before.KeySlots, s) {
+				slot = s
+				key.ProtectorID = strconv.Itoa(s)
+				break
+			}
+		}
+	}
+
+	if err := escrow(key); err != nil {
+		if removeErr := runCryptsetup(ctx, passphrase, "", "luksRemoveKey", "--key-file=-", device); removeErr != nil {
+			return nil, key, fmt.Errorf("recovery passphrase escrow failed and its keyslot could not be removed: %w", err)
+		}
+		return nil, RecoveryKey{}, fmt.Errorf("recovery passphrase escrow failed, keyslot removed: %w", err)
+	}
+
+	result := &LUKSEscrowResult{Device: device, KeySlot: slot}
+	if opts.PreviousEscrowKey != "" && opts.PreviousEscrowKey != passphrase {
+		if err := runCryptsetup(ctx, opts.PreviousEscrowKey, "", "luksRemoveKey", "--key-file=-", device); err != nil {
+			result.Warning = "previous escrowed keyslot was not removed: " + err.Error()
+		} else {
+			result.PreviousRemoved = true
+		}
+	}
+	return result, RecoveryKey{}, nil
+}
+
+// resolveLUKSDevice accepts only devices lsblk reports as LUKS, so the
+// payload cannot point cryptsetup at anything else.
+func resolveLUKSDevice(volumes []linuxVolume, requested string) (string, error) {
+	for _, v := range volumes {
+		if v.LUKSDevice == "" {
+			continue
+		}
+		if requested == "" && v.Mount == "/" {
+			return v.LUKSDevice, nil
+		}
+		if requested != "" && v.LUKSDevice == requested {
+			return v.LUKSDevice, nil
+		}
+	}
+	if requested == "" {
+		return "", errors.New("the root filesystem is not on a LUKS device")
+	}
+	return "", fmt.Errorf("%s is not a LUKS device backing a mounted filesystem", requested)
+}
+
+// Crockford base32 without the easily confused I, L, O and U; eight groups
+// of four gives 160 bits.
+const luksPassphraseAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func generateLUKSPassphrase() (string, error) {
+	groups := make([]string, 8)
+	size := big.NewInt(int64(len(luksPassphraseAlphabet)))
+	for i := range groups {
+		var group [4]byte
+		for j := range group {
+			n, err := rand.Int(rand.Reader, size)
+			if err != nil {
+				return "", fmt.Errorf("generate passphrase: %w", err)
+			}
+			group[j] = luksPassphraseAlphabet[n.Int64()]
+		}
+		groups[i] = string(group[:])
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// runCryptsetup passes the existing key on stdin and, when set, a second key
+// on fd 3, so neither passphrase touches argv or disk. cryptsetup's output
+// never contains key material, so it is safe in errors.
+func runCryptsetup(ctx context.Context, stdin, fd3 string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "cryptsetup", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	if fd3 != "" {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		cmd.ExtraFiles = []*os.File{r}
+		go func() {
+			_, _ = w.WriteString(fd3)
+			w.Close()
+		}()
+	}
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return errors.New("cryptsetup timed out")
+		}
+		if text := strings.TrimSpace(output.String()); text != "" {
+			return fmt.Errorf("cryptsetup %s: %s", args[0], text)
+		}
+		return fmt.Errorf("cryptsetup %s: %w", args[0], err)
+	}
+	return nil
+}
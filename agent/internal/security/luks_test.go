@@ -0,0 +1,109 @@
+package security
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestParseLUKSDump(t *testing.T) {
+	luks2 := `LUKS header information
+Version:       	2
+Epoch:         	5
+Metadata area: 	16384 [bytes]
+Keyslots area: 	16744448 [bytes]
+UUID:          	0b1c7f3e-2f4a-4d6b-9a55-3c8e1f0a9b21
+Label:         	(no label)
+Subsystem:     	(no subsystem)
+Flags:       	(no flags)
+
+Data segments:
+  0: crypt
+	offset: 16777216 [bytes]
+	length: (whole device)
+	cipher: aes-xts-plain64
+	sector: 512 [bytes]
+
+Keyslots:
+  0: luks2
+	Key:        512 bits
+	Priority:   normal
+	Cipher:     aes-xts-plain64
+	Cipher key: 512 bits
+  2: luks2
+	Key:        512 bits
+	Cipher:     aes-xts-plain64
+Tokens:
+Digests:
+  0: pbkdf2
+	Hash:       sha256
+`
+	header := parseLUKSDump(luks2)
+	if header.Version != 2 || header.Cipher != "aes-xts-plain64" || header.UUID != "0b1c7f3e-2f4a-4d6b-9a55-3c8e1f0a9b21" {
+		t.Fatalf("unexpected luks2 header %+v", header)
+	}
+	if len(header.KeySlots) != 2 || header.KeySlots[0] != 0 || header.KeySlots[1] != 2 {
+		t.Fatalf("digests must not count as keyslots: %v", header.KeySlots)
+	}
+
+	luks1 := `LUKS header information for /dev/sda3
+
+Version:       	1
+Cipher name:   	aes
+Cipher mode:   	xts-plain64
+Hash spec:     	sha256
+UUID:          	5d2a9c1e-0000-4000-8000-000000000001
+
+Key Slot 0: ENABLED
+	Iterations:         	1421023
+Key Slot 1: DISABLED
+Key Slot 2: ENABLED
+	Iterations:         	1398101
+`
+	header = parseLUKSDump(luks1)
+	if header.Version != 1 || header.Cipher != "aes-xts-plain64" || len(header.KeySlots) != 2 || header.KeySlots[1] != 2 {
+		t.Fatalf("unexpected luks1 header %+v", header)
+	}
+}
+
+func TestParseLsblkVolumes(t *testing.T) {
+	output := `{"blockdevices":[{"name":"nvme0n1","type":"disk","mountpoint":null,"fstype":null,"children":[
+		{"name":"nvme0n1p1","type":"part","mountpoint":"/boot/efi","fstype":"vfat"},
+		{"name":"nvme0n1p3","type":"part","mountpoint":null,"fstype":"crypto_LUKS","children":[
+			{"name":"luks-0b1c","type":"crypt","mountpoint":null,"fstype":"LVM2_member","children":[
+				{"name":"vg-root","type":"lvm","mountpoint":"/","fstype":"ext4"}
+			]}
+		]}
+	]}]}`
+	volumes, err := parseLsblkVolumes(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(volumes) != 2 {
+		t.Fatalf("expected 2 mounted volumes, got %+v", volumes)
+	}
+	if v := volumes[0]; v.Protected || v.LUKSDevice != "" {
+		t.Fatalf("unexpected efi volume %+v", v)
+	}
+	if v := volumes[1]; v.Mount != "/" || !v.Protected || v.LUKSDevice != "/dev/nvme0n1p3" {
+		t.Fatalf("unexpected root volume %+v", v)
+	}
+
+	if device, err := resolveLUKSDevice(volumes, ""); err != nil || device != "/dev/nvme0n1p3" {
+		t.Fatalf("root device = %q, %v", device, err)
+	}
+	if _, err := resolveLUKSDevice(volumes, "/dev/nvme0n1p1"); err == nil {
+		t.Fatal("a device that is not LUKS must be rejected")
+	}
+}
+
+func TestGenerateLUKSPassphrase(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{4}(-[0-9A-HJKMNP-TV-Z]{4}){7}$`)
+	first, err := generateLUKSPassphrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := generateLUKSPassphrase()
+	if !pattern.MatchString(first) || first == second {
+		t.Fatalf("unexpected passphrases %q %q", first, second)
+	}
+}
@@ -65,6 +65,7 @@ func (s *SecurityScanner) scanPaths(paths []string) (ScanResult, error) {
 	if status.LastScanType == "" {
 		status.LastScanType = "custom"
 	}
+	refreshScore(&status)
 
 	result := ScanResult{
 		Threats:  threats,
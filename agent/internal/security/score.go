@@ -0,0 +1,369 @@
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/config"
+)
+
+// Score factors and their weights, which add up to 100. Where the server's
+// posture scoring has the same factor the thresholds match it, so the two
+// rank devices alike.
+const (
+	FactorAVHealth          = "av_health"
+	FactorFirewall          = "firewall"
+	FactorEncryption        = "encryption"
+	FactorPatchAge          = "patch_age"
+	FactorAdminExposure     = "admin_exposure"
+	FactorPasswordPolicy    = "password_policy"
+	FactorPlatformIntegrity = "platform_integrity"
+)
+
+var scoreWeights = map[string]int{
+	FactorAVHealth:          20,
+	FactorFirewall:          15,
+	FactorEncryption:        15,
+	FactorPatchAge:          20,
+	FactorAdminExposure:     10,
+	FactorPasswordPolicy:    10,
+	FactorPlatformIntegrity: 10,
+}
+
+// SecurityScore is the weighted 0-100 score of the collected signals.
+type SecurityScore struct {
+	Score      int                    `json:"score"`
+	RiskLevel  string                 `json:"riskLevel"`
+	ComputedAt string                 `json:"computedAt"`
+	Factors    map[string]ScoreFactor `json:"factors"`
+}
+
+// ScoreFactor is one factor's 0-100 score. A signal that could not be
+// collected gets a neutral score, low confidence and a DataGap saying why.
+type ScoreFactor struct {
+	Score      int            `json:"score"`
+	Weight     int            `json:"weight"`
+	Confidence float64        `json:"confidence"`
+	DataGap    string         `json:"dataGap,omitempty"`
+	Evidence   map[string]any `json:"evidence,omitempty"`
+}
+
+// PatchPosture is what the last patch scan found, kept for the patch_age
+// factor since patches are scanned far less often than security status is
+// collected.
+type PatchPosture struct {
+	CollectedAt      time.Time `json:"collectedAt"`
+	PendingCritical  int       `json:"pendingCritical"`
+	PendingImportant int       `json:"pendingImportant"`
+	// OldestPendingReleasedAt is the release date of the oldest pending
+	// critical or important patch, when known.
+	OldestPendingReleasedAt *time.Time `json:"oldestPendingReleasedAt,omitempty"`
+	LastInstalledAt         *time.Time `json:"lastInstalledAt,omitempty"`
+}
+
+const patchPostureFile = "patch_posture.json"
+
+// patchPostureCache holds the recorded patch posture; it is read from disk
+// once so the score survives an agent restart between patch scans.
+var patchPostureCache = struct {
+	sync.Mutex
+	loaded  bool
+	posture *PatchPosture
+}{}
+
+// patchPosturePath is a variable so tests can keep the file out of the real
+// data directory.
+var patchPosturePath = func() string {
+	return filepath.Join(config.GetDataDir(), patchPostureFile)
+}
+
+// RecordPatchPosture stores the result of a patch scan for later scores.
+func RecordPatchPosture(p PatchPosture) error {
+	patchPostureCache.Lock()
+	defer patchPostureCache.Unlock()
+	patchPostureCache.posture = &p
+	patchPostureCache.loaded = true
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := patchPosturePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func loadPatchPosture() *PatchPosture {
+	patchPostureCache.Lock()
+	defer patchPostureCache.Unlock()
+	if patchPostureCache.loaded {
+		return patchPostureCache.posture
+	}
+	patchPostureCache.loaded = true
+	// A missing or unreadable file leaves patch_age as a data gap until the
+	// next scan records a fresh posture.
+	data, err := os.ReadFile(patchPosturePath())
+	if err != nil {
+		return nil
+	}
+	var p PatchPosture
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil
+	}
+	patchPostureCache.posture = &p
+	return &p
+}
+
+// refreshScore recomputes status.Score; callers that change the status after
+// CollectStatus (e.g. a scan's threat count) call it again.
+func refreshScore(status *SecurityStatus) {
+	score := ComputeScore(*status, loadPatchPosture(), time.Now())
+	status.Score = &score
+}
+
+// ComputeScore scores a collected status. patches may be nil when no patch
+// scan has completed yet.
+func ComputeScore(status SecurityStatus, patches *PatchPosture, now time.Time) SecurityScore {
+	factors := map[string]ScoreFactor{
+		FactorAVHealth:          scoreAVHealth(status, now),
+		FactorFirewall:          scoreFirewall(status),
+		FactorEncryption:        scoreEncryption(status),
+		FactorPatchAge:          scorePatchAge(patches, now),
+		FactorAdminExposure:     scoreAdminExposure(status),
+		FactorPasswordPolicy:    scorePasswordPolicy(status),
+		FactorPlatformIntegrity: scorePlatformIntegrity(status),
+	}
+	weighted := 0.0
+	for name, factor := range factors {
+		factor.Weight = scoreWeights[name]
+		factors[name] = factor
+		weighted += float64(factor.Score*factor.Weight) / 100
+	}
+	score := clampScore(weighted)
+	return SecurityScore{
+		Score:      score,
+		RiskLevel:  scoreRiskLevel(score),
+		ComputedAt: now.UTC().Format(time.RFC3339),
+		Factors:    factors,
+	}
+}
+
+func clampScore(value float64) int {
+	return int(math.Max(0, math.Min(100, math.Round(value))))
+}
+
+func scoreRiskLevel(score int) string {
+	switch {
+	case score >= 85:
+		return "low"
+	case score >= 70:
+		return "medium"
+	case score >= 45:
+		return "high"
+	default:
+		return "critical"
+	}
+}
+
+func scoreAVHealth(status SecurityStatus, now time.Time) ScoreFactor {
+	score := 20.0
+	if status.RealTimeProtection {
+		score = 85
+	}
+	factor := ScoreFactor{Confidence: 0.9, Evidence: map[string]any{
+		"provider":           status.Provider,
+		"realTimeProtection": status.RealTimeProtection,
+		"threatCount":        status.ThreatCount,
+	}}
+	if updated, err := time.Parse(time.RFC3339, normalizeTimestampString(status.DefinitionsUpdatedAt)); err == nil {
+		ageHours := now.Sub(updated).Hours()
+		factor.Evidence["definitionsAgeHours"] = int(math.Round(ageHours))
+		switch {
+		case ageHours > 72:
+			score -= 35
+		case ageHours > 24:
+			score -= 20
+		}
+	} else {
+		factor.Confidence = 0.6
+		factor.Evidence["definitionsAgeHours"] = nil
+		score -= 10
+	}
+	score -= math.Min(30, float64(status.ThreatCount*5))
+	factor.Score = clampScore(score)
+	return factor
+}
+
+func scoreFirewall(status SecurityStatus) ScoreFactor {
+	factor := ScoreFactor{Confidence: 0.95, Evidence: map[string]any{"firewallEnabled": status.FirewallEnabled}}
+	if status.FirewallEnabled {
+		factor.Score = 100
+	}
+	return factor
+}
+
+// scoreEncryption scores the system volume. Other volumes are only evidence:
+// on Linux they include /boot and data mounts that are often unencrypted by
+// design.
+func scoreEncryption(status SecurityStatus) ScoreFactor {
+	factor := ScoreFactor{Evidence: map[string]any{"encryptionStatus": status.EncryptionStatus}}
+	if details, ok := status.EncryptionDetails.(map[string]any); ok {
+		if coverage, ok := volumeCoverage(details["volumes"]); ok {
+			factor.Evidence["volumeCoverage"] = coverage
+		}
+	}
+	switch status.EncryptionStatus {
+	case "encrypted":
+		factor.Score, factor.Confidence = 100, 0.9
+	case "partial":
+		factor.Score, factor.Confidence = 60, 0.85
+	case "unencrypted":
+		factor.Score, factor.Confidence = 0, 0.9
+	default:
+		factor.Score, factor.Confidence = 50, 0.3
+		factor.DataGap = "Encryption status was unavailable."
+	}
+	return factor
+}
+
+func volumeCoverage(raw any) (int, bool) {
+	volumes, ok := raw.([]map[string]any)
+	if !ok || len(volumes) == 0 {
+		return 0, false
+	}
+	protected := 0
+	for _, volume := range volumes {
+		if on, _ := boolFromAny(volume["protected"]); on {
+			protected++
+		}
+	}
+	return clampScore(float64(protected) / float64(len(volumes)) * 100), true
+}
+
+func scorePatchAge(p *PatchPosture, now time.Time) ScoreFactor {
+	if p == nil {
+		return ScoreFactor{Score: 50, Confidence: 0.3, DataGap: "No patch scan has completed yet."}
+	}
+	score := 100.0
+	score -= math.Min(60, float64(p.PendingCritical*15))
+	score -= math.Min(30, float64(p.PendingImportant*5))
+	factor := ScoreFactor{Confidence: 0.85, Evidence: map[string]any{
+		"pendingCritical":  p.PendingCritical,
+		"pendingImportant": p.PendingImportant,
+	}}
+	if p.OldestPendingReleasedAt != nil {
+		days := int(now.Sub(*p.OldestPendingReleasedAt).Hours() / 24)
+		factor.Evidence["oldestPendingDays"] = days
+		switch {
+		case days > 60:
+			score -= 25
+		case days > 30:
+			score -= 15
+		case days > 14:
+			score -= 5
+		}
+	}
+	if p.LastInstalledAt != nil {
+		factor.Evidence["daysSinceLastInstall"] = int(now.Sub(*p.LastInstalledAt).Hours() / 24)
+	}
+	if scanAge := now.Sub(p.CollectedAt); scanAge > 7*24*time.Hour {
+		factor.Confidence = 0.5
+		factor.DataGap = fmt.Sprintf("The last patch scan is %d days old.", int(scanAge.Hours()/24))
+	}
+	factor.Score = clampScore(score)
+	return factor
+}
+
+func scoreAdminExposure(status SecurityStatus) ScoreFactor {
+	summary, _ := status.LocalAdminSummary.(map[string]any)
+	adminCount, ok := intFromAny(summary["adminCount"])
+	if !ok {
+		return ScoreFactor{Score: 70, Confidence: 0.3, DataGap: "Local admin summary was unavailable."}
+	}
+	score := 100
+	switch {
+	case adminCount > 6:
+		score = 15
+	case adminCount > 4:
+		score = 40
+	case adminCount > 2:
+		score = 70
+	}
+	return ScoreFactor{Score: score, Confidence: 0.75, Evidence: map[string]any{"adminCount": adminCount}}
+}
+
+func scorePasswordPolicy(status SecurityStatus) ScoreFactor {
+	policy, _ := status.PasswordPolicySummary.(map[string]any)
+	var checks []bool
+	evidence := map[string]any{}
+	if n, ok := intFromAny(policy["minLength"]); ok {
+		checks = append(checks, n >= 12)
+		evidence["minLength"] = n
+	}
+	if on, ok := boolFromAny(policy["complexityEnabled"]); ok {
+		checks = append(checks, on)
+		evidence["complexityEnabled"] = on
+	}
+	if n, ok := intFromAny(policy["maxAgeDays"]); ok {
+		checks = append(checks, n <= 90)
+		evidence["maxAgeDays"] = n
+	}
+	if n, ok := intFromAny(policy["lockoutThreshold"]); ok {
+		checks = append(checks, n > 0 && n <= 5)
+		evidence["lockoutThreshold"] = n
+	}
+	if len(checks) == 0 {
+		return ScoreFactor{Score: 60, Confidence: 0.25, DataGap: "Password policy summary was unavailable."}
+	}
+	evidence["checksEvaluated"] = len(checks)
+	return ScoreFactor{
+		Score:      passedPercent(checks),
+		Confidence: math.Max(0.35, math.Min(0.9, float64(len(checks))/4)),
+		Evidence:   evidence,
+	}
+}
+
+// scorePlatformIntegrity checks Secure Boot and a TPM, plus Gatekeeper on
+// macOS. A legacy BIOS boot fails the Secure Boot check.
+func scorePlatformIntegrity(status SecurityStatus) ScoreFactor {
+	var checks []bool
+	evidence := map[string]any{}
+	if sb := status.SecureBoot; sb != nil {
+		checks = append(checks, sb.Supported && sb.Enabled)
+		evidence["secureBoot"] = sb.Supported && sb.Enabled
+	}
+	// Macs have no TPM; the Secure Enclave is not reported.
+	if tpm := status.TPM; tpm != nil && status.OS != "macos" {
+		checks = append(checks, tpm.Present)
+		evidence["tpmPresent"] = tpm.Present
+	}
+	if gk := status.GatekeeperEnabled; gk != nil {
+		checks = append(checks, *gk)
+		evidence["gatekeeperEnabled"] = *gk
+	}
+	if len(checks) == 0 {
+		return ScoreFactor{Score: 50, Confidence: 0.3, DataGap: "Secure Boot, TPM and Gatekeeper state were unavailable."}
+	}
+	return ScoreFactor{Score: passedPercent(checks), Confidence: 0.8, Evidence: evidence}
+}
+
+func passedPercent(checks []bool) int {
+	passed := 0
+	for _, ok := range checks {
+		if ok {
+			passed++
+		}
+	}
+	return clampScore(float64(passed) / float64(len(checks)) * 100)
+}
@@ -0,0 +1,164 @@
+package security
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var scoreNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func hardenedStatus() SecurityStatus {
+	on := true
+	return SecurityStatus{
+		OS:                   "windows",
+		Provider:             "windows_defender",
+		RealTimeProtection:   true,
+		DefinitionsUpdatedAt: scoreNow.Add(-2 * time.Hour).Format(time.RFC3339),
+		FirewallEnabled:      true,
+		EncryptionStatus:     "encrypted",
+		LocalAdminSummary:    map[string]any{"adminCount": 2},
+		PasswordPolicySummary: map[string]any{
+			"minLength": 14, "complexityEnabled": true, "maxAgeDays": 60, "lockoutThreshold": 5,
+		},
+		TPM:               &TPMStatus{Present: true, Version: "2.0"},
+		SecureBoot:        &SecureBootStatus{Supported: true, Enabled: true},
+		GatekeeperEnabled: nil,
+		GuardianEnabled:   &on,
+	}
+}
+
+func TestScoreWeightsAddUpTo100(t *testing.T) {
+	total := 0
+	for _, w := range scoreWeights {
+		total += w
+	}
+	if total != 100 {
+		t.Fatalf("weights add up to %d", total)
+	}
+}
+
+func TestComputeScoreHardenedDevice(t *testing.T) {
+	patches := &PatchPosture{CollectedAt: scoreNow.Add(-time.Hour)}
+	got := ComputeScore(hardenedStatus(), patches, scoreNow)
+	// AV health tops out at 85 with real-time protection on, as on the server.
+	if got.Score != 97 || got.RiskLevel != "low" {
+		t.Fatalf("score = %d (%s), want 97 (low); factors = %+v", got.Score, got.RiskLevel, got.Factors)
+	}
+	if len(got.Factors) != len(scoreWeights) {
+		t.Fatalf("got %d factors, want %d", len(got.Factors), len(scoreWeights))
+	}
+	for name, f := range got.Factors {
+		if f.Weight != scoreWeights[name] {
+			t.Errorf("%s weight = %d, want %d", name, f.Weight, scoreWeights[name])
+		}
+		if f.DataGap != "" {
+			t.Errorf("%s has data gap %q", name, f.DataGap)
+		}
+	}
+}
+
+func TestComputeScoreExposedDevice(t *testing.T) {
+	status := SecurityStatus{
+		OS:                "windows",
+		ThreatCount:       3,
+		EncryptionStatus:  "unencrypted",
+		LocalAdminSummary: map[string]any{"adminCount": 7},
+		SecureBoot:        &SecureBootStatus{Supported: false},
+		TPM:               &TPMStatus{Present: false},
+	}
+	released := scoreNow.AddDate(0, 0, -75)
+	patches := &PatchPosture{CollectedAt: scoreNow, PendingCritical: 5, PendingImportant: 2, OldestPendingReleasedAt: &released}
+	got := ComputeScore(status, patches, scoreNow)
+	if got.RiskLevel != "critical" {
+		t.Fatalf("score = %d (%s), want critical", got.Score, got.RiskLevel)
+	}
+	if f := got.Factors[FactorPatchAge]; f.Score != 5 || f.Evidence["oldestPendingDays"] != 75 {
+		t.Fatalf("patch_age = %+v", f)
+	}
+	if f := got.Factors[FactorAVHealth]; f.Score != 0 {
+		t.Fatalf("av_health = %+v, want 0", f)
+	}
+	if f := got.Factors[FactorPlatformIntegrity]; f.Score != 0 {
+		t.Fatalf("platform_integrity = %+v, want 0", f)
+	}
+}
+
+func TestComputeScoreMarksDataGaps(t *testing.T) {
+	got := ComputeScore(SecurityStatus{OS: "linux", EncryptionStatus: "unknown"}, nil, scoreNow)
+	for _, name := range []string{FactorEncryption, FactorPatchAge, FactorAdminExposure, FactorPasswordPolicy, FactorPlatformIntegrity} {
+		f := got.Factors[name]
+		if f.DataGap == "" || f.Confidence > 0.5 {
+			t.Errorf("%s = %+v, want a low-confidence data gap", name, f)
+		}
+	}
+}
+
+func TestScorePatchAgeStaleScan(t *testing.T) {
+	f := scorePatchAge(&PatchPosture{CollectedAt: scoreNow.AddDate(0, 0, -10)}, scoreNow)
+	if f.Score != 100 || f.Confidence != 0.5 || f.DataGap == "" {
+		t.Fatalf("stale scan = %+v", f)
+	}
+}
+
+func TestScorePlatformIntegrityMac(t *testing.T) {
+	off := false
+	f := scorePlatformIntegrity(SecurityStatus{
+		OS:                "macos",
+		TPM:               &TPMStatus{Present: false},
+		SecureBoot:        &SecureBootStatus{Supported: true, Enabled: true, Mode: "full"},
+		GatekeeperEnabled: &off,
+	})
+	if f.Score != 50 {
+		t.Fatalf("mac with Gatekeeper off = %+v, want 50", f)
+	}
+	if _, ok := f.Evidence["tpmPresent"]; ok {
+		t.Fatal("Macs should not be scored on a TPM")
+	}
+}
+
+func TestRecordPatchPosturePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), patchPostureFile)
+	origPath := patchPosturePath
+	patchPosturePath = func() string { return path }
+	t.Cleanup(func() {
+		patchPosturePath = origPath
+		patchPostureCache.Lock()
+		patchPostureCache.loaded, patchPostureCache.posture = false, nil
+		patchPostureCache.Unlock()
+	})
+
+	if err := RecordPatchPosture(PatchPosture{CollectedAt: scoreNow, PendingCritical: 2}); err != nil {
+		t.Fatal(err)
+	}
+	// A restarted agent reads it back from disk.
+	patchPostureCache.Lock()
+	patchPostureCache.loaded, patchPostureCache.posture = false, nil
+	patchPostureCache.Unlock()
+	got := loadPatchPosture()
+	if got == nil || got.PendingCritical != 2 || !got.CollectedAt.Equal(scoreNow) {
+		t.Fatalf("loadPatchPosture = %+v", got)
+	}
+}
+
+func TestSecurityScoreJSON(t *testing.T) {
+	status := hardenedStatus()
+	refreshScore(&status)
+	data, err := json.Marshal(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		SecurityScore struct {
+			Score   int                        `json:"score"`
+			Factors map[string]json.RawMessage `json:"factors"`
+		} `json:"securityScore"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.SecurityScore.Score == 0 || len(out.SecurityScore.Factors) != len(scoreWeights) {
+		t.Fatalf("securityScore = %s", data)
+	}
+}
@@ -1,6 +1,7 @@
 package security
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -57,6 +58,14 @@ type SecurityStatus struct {
 	GuardianEnabled                *bool       `json:"guardianEnabled,omitempty"`
 	WindowsSecurityCenterAvailable bool        `json:"windowsSecurityCenterAvailable,omitempty"`
 	AVProducts                     []AVProduct `json:"avProducts,omitempty"`
+	// TPM, SecureBoot and MeasuredBootAvailable are nil when they could not
+	// be determined.
+	TPM                   *TPMStatus        `json:"tpm,omitempty"`
+	SecureBoot            *SecureBootStatus `json:"secureBoot,omitempty"`
+	MeasuredBootAvailable *bool             `json:"measuredBootAvailable,omitempty"`
+	// Score weighs the signals above (and the last patch scan) into one
+	// number the console can rank devices by.
+	Score *SecurityScore `json:"securityScore,omitempty"`
 }
 
 // DefenderStatus captures Microsoft Defender health details.
@@ -516,50 +525,53 @@ func collectEncryptionDetailsWindows() (map[string]any, error) {
 		"-NoProfile",
 		"-NonInteractive",
 		"-Command",
-		"Get-BitLockerVolume | Select-Object MountPoint,ProtectionStatus,EncryptionMethod,VolumeStatus,EncryptionPercentage | ConvertTo-Json -Compress",
+		bitlockerVolumeScript(""),
 	)
 	if err != nil {
 		return nil, err
 	}
-	parsed, err := parseJSONValue(output)
+	parsed, err := parseBitLockerVolumes(output)
 	if err != nil {
 		return nil, err
 	}
+	return bitlockerEncryptionDetails(parsed, os.Getenv("SystemDrive")), nil
+}
 
-	rawVolumes := toObjectSlice(parsed)
-	volumes := make([]map[string]any, 0, len(rawVolumes))
-	for _, item := range rawVolumes {
-		mountPoint, _ := stringFromAny(item["MountPoint"])
-		method, _ := stringFromAny(item["EncryptionMethod"])
+func bitlockerEncryptionDetails(parsed []BitLockerVolume, systemDrive string) map[string]any {
+	volumes := make([]map[string]any, 0, len(parsed))
+	inProgress := false
+	for _, volume := range parsed {
+		method := volume.EncryptionMethod
 		if method == "" {
 			method = "bitlocker"
 		}
-		volumeStatus, _ := stringFromAny(item["VolumeStatus"])
-
-		protected := false
-		if value, ok := boolFromAny(item["ProtectionStatus"]); ok {
-			protected = value
-		} else if value, ok := stringFromAny(item["ProtectionStatus"]); ok {
-			normalized := strings.ToLower(strings.TrimSpace(value))
-			protected = normalized == "on" || normalized == "protected" || normalized == "1"
-		}
-
 		entry := map[string]any{
-			"mount":     mountPoint,
+			"mount":     volume.Mount,
 			"method":    strings.ToLower(method),
-			"protected": protected,
-			"status":    volumeStatus,
+			"protected": volume.Protected(),
+			"status":    volume.VolumeStatus,
+		}
+		if volume.EncryptionPercentage != nil {
+			entry["percentEncrypted"] = *volume.EncryptionPercentage
 		}
-		if percent, ok := floatFromAny(item["EncryptionPercentage"]); ok {
-			entry["percentEncrypted"] = percent
+		if len(volume.Protectors) > 0 {
+			entry["protectors"] = volume.Protectors
 		}
 		volumes = append(volumes, entry)
+		if strings.EqualFold(volume.Mount, systemDrive) && volume.InProgress() {
+			inProgress = true
+		}
 	}
 
-	return map[string]any{
+	details := map[string]any{
 		"source":  "bitlocker",
 		"volumes": volumes,
-	}, nil
+	}
+	// Lets the status report the system drive as partial while it encrypts.
+	if inProgress {
+		details["systemDriveInProgress"] = true
+	}
+	return details
 }
 
 func collectEncryptionDetailsDarwin() (map[string]any, error) {
@@ -567,70 +579,73 @@ func collectEncryptionDetailsDarwin() (map[string]any, error) {
 	if err != nil {
 		return nil, err
 	}
-	lower := strings.ToLower(output)
-	protected := strings.Contains(lower, "filevault is on")
-	return map[string]any{
-		"source": "filevault",
-		"volumes": []map[string]any{
-			{
-				"mount":     "/",
-				"method":    "filevault",
-				"protected": protected,
-				"status":    strings.TrimSpace(output),
-			},
-		},
-	}, nil
+	return fileVaultEncryptionDetails(output), nil
 }
 
-func collectEncryptionDetailsLinux() (map[string]any, error) {
-	type lsblkNode struct {
-		Name       string      `json:"name"`
-		Type       string      `json:"type"`
-		Mountpoint string      `json:"mountpoint"`
-		Fstype     string      `json:"fstype"`
-		Children   []lsblkNode `json:"children"`
+func fileVaultEncryptionDetails(output string) map[string]any {
+	status := parseFileVaultStatus(output)
+	volume := map[string]any{
+		"mount":     "/",
+		"method":    "filevault",
+		"protected": status.On,
+		"status":    strings.TrimSpace(output),
 	}
-	type lsblkPayload struct {
-		Blockdevices []lsblkNode `json:"blockdevices"`
+	if status.PercentEncrypted != nil {
+		volume["percentEncrypted"] = *status.PercentEncrypted
 	}
-
-	output, err := runCommand(8*time.Second, "lsblk", "-J", "-o", "NAME,TYPE,MOUNTPOINT,FSTYPE")
-	if err != nil {
-		return nil, err
+	details := map[string]any{
+		"source":  "filevault",
+		"volumes": []map[string]any{volume},
 	}
+	if status.InProgress {
+		details["systemDriveInProgress"] = true
+	}
+	if status.Deferred {
+		details["deferredEnablement"] = true
+		details["deferredUser"] = status.DeferredUser
+	}
+	return details
+}
 
-	var payload lsblkPayload
-	if err := json.Unmarshal([]byte(output), &payload); err != nil {
+func collectEncryptionDetailsLinux() (map[string]any, error) {
+	linuxVolumes, err := listLinuxVolumes()
+	if err != nil {
 		return nil, err
 	}
 
-	volumes := make([]map[string]any, 0)
-	var walk func(node lsblkNode, inheritedProtected bool)
-	walk = func(node lsblkNode, inheritedProtected bool) {
-		isProtected := inheritedProtected ||
-			strings.EqualFold(node.Type, "crypt") ||
-			strings.Contains(strings.ToLower(node.Fstype), "luks")
-
-		if strings.TrimSpace(node.Mountpoint) != "" {
-			method := "none"
-			if isProtected {
-				method = "luks"
+	// luksDump needs root; without it the volumes are still reported.
+	headers := make(map[string]*LUKSHeader)
+	if hasCommand("cryptsetup") {
+		for _, v := range linuxVolumes {
+			if _, seen := headers[v.LUKSDevice]; seen || v.LUKSDevice == "" {
+				continue
+			}
+			headers[v.LUKSDevice] = nil
+			if header, err := ReadLUKSHeader(v.LUKSDevice); err == nil {
+				headers[v.LUKSDevice] = &header
 			}
-			volumes = append(volumes, map[string]any{
-				"mount":     node.Mountpoint,
-				"device":    node.Name,
-				"method":    method,
-				"protected": isProtected,
-			})
-		}
-
-		for _, child := range node.Children {
-			walk(child, isProtected)
 		}
 	}
 
-	for _, node := range payload.Blockdevices {
-		walk(node, false)
+	volumes := make([]map[string]any, 0, len(linuxVolumes))
+	for _, v := range linuxVolumes {
+		method := "none"
+		if v.Protected {
+			method = "luks"
+		}
+		entry := map[string]any{
+			"mount":     v.Mount,
+			"device":    v.Device,
+			"method":    method,
+			"protected": v.Protected,
+		}
+		if v.LUKSDevice != "" {
+			entry["luksDevice"] = v.LUKSDevice
+		}
+		if header := headers[v.LUKSDevice]; header != nil {
+			entry["luks"] = header
+		}
+		volumes = append(volumes, entry)
 	}
 
 	return map[string]any{
@@ -1116,6 +1131,9 @@ func CollectStatus(cfg *config.Config) (SecurityStatus, error) {
 	status.EncryptionStatus = encryptionString(encryptionEnabled, encErr)
 	if details, err := collectEncryptionDetails(); err == nil {
 		status.EncryptionDetails = details
+		if inProgress, _ := details["systemDriveInProgress"].(bool); inProgress && encErr == nil {
+			status.EncryptionStatus = "partial"
+		}
 	}
 
 	if localAdmins, err := collectLocalAdminSummary(); err == nil {
@@ -1126,6 +1144,11 @@ func CollectStatus(cfg *config.Config) (SecurityStatus, error) {
 		status.PasswordPolicySummary = passwordPolicy
 	}
 
+	boot := collectBootIntegrity()
+	status.TPM = boot.TPM
+	status.SecureBoot = boot.SecureBoot
+	status.MeasuredBootAvailable = boot.MeasuredBoot
+
 	if runtime.GOOS == "darwin" {
 		gatekeeperEnabled, gatekeeperErr := getGatekeeperStatusDarwin()
 		if gatekeeperErr != nil {
@@ -1136,6 +1159,7 @@ func CollectStatus(cfg *config.Config) (SecurityStatus, error) {
 		}
 	}
 
+	refreshScore(&status)
 	return status, errors.Join(errs...)
 }
 
@@ -1400,3 +1424,29 @@ func runCommand(timeout time.Duration, name string, args ...string) (string, err
 	}
 	return strings.TrimSpace(string(output)), nil
 }
+
+// runToolCommand runs a system tool, feeding it stdin when non-empty so
+// secrets never appear in a command line. Unlike runCommand it keeps the
+// tool's output in the error, since that is often the only explanation a
+// tool gives for a rejected change.
+func runToolCommand(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	text := strings.TrimSpace(output.String())
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("%s timed out", name)
+	}
+	if err != nil {
+		if text != "" {
+			return text, fmt.Errorf("%s failed: %s", name, text)
+		}
+		return text, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return text, nil
+}
@@ -211,7 +211,7 @@ func (b *Broker) maybeStartKeepalive(session *Session, role ipc.HelperRole) {
 // dialogs; user-token helpers own script execution.
 var (
 	systemHelperScopes   = []string{"notify", "tray", "clipboard", "desktop", ipc.ScopePam}
-	userHelperScopes     = []string{"notify", "clipboard", "run_as_user"}
+	userHelperScopes     = []string{"notify", "clipboard", "run_as_user", ipc.ScopeUsageMetering}
 	watchdogHelperScopes = []string{"watchdog"}
 	// assistHelperScopes is least-privilege: the Breeze Assist helper receives
 	// only the helper token and must NOT get desktop/clipboard/run_as_user/notify/tray.
@@ -2491,18 +2491,35 @@ func (b *Broker) scopesForRole(role ipc.HelperRole, binaryKind, goos, peerPath s
 
 // grantScopes computes the final AllowedScopes for an authenticated helper:
 // the role's base scopes plus consent_ui_fallback when a user-role helper
-// advertised native consent support. Always returns a fresh slice — the
-// role-scope vars are shared package state and must not be appended to.
+// advertised native consent support, and desktop for a Linux user helper in
+// a Wayland session. Always returns a fresh slice — the role-scope vars are
+// shared package state and must not be appended to.
 func (b *Broker) grantScopes(role ipc.HelperRole, authReq ipc.AuthRequest, goos, peerPath string) []string {
 	base := b.scopesForRole(role, authReq.BinaryKind, goos, peerPath)
-	scopes := make([]string, len(base), len(base)+1)
+	scopes := make([]string, len(base), len(base)+2)
 	copy(scopes, base)
 	if role == ipc.HelperRoleUser && authReq.SupportsConsentUI {
 		scopes = append(scopes, ipc.ScopeConsentUIFallback)
 	}
+	if isLinuxWaylandUserHelper(role, authReq, goos) {
+		scopes = append(scopes, "desktop")
+	}
 	return scopes
 }
 
+// isLinuxWaylandUserHelper reports whether a user helper runs inside a Linux
+// Wayland session. The root agent cannot capture Wayland over the X11 wire
+// protocol, so capture goes through the xdg-desktop-portal ScreenCast API,
+// which only answers on the user's own session bus — that helper is the only
+// process that can stream the screen. X11 sessions keep direct capture in
+// the agent.
+func isLinuxWaylandUserHelper(role ipc.HelperRole, authReq ipc.AuthRequest, goos string) bool {
+	return role == ipc.HelperRoleUser &&
+		goos == "linux" &&
+		authReq.BinaryKind == ipc.HelperBinaryUserHelper &&
+		strings.HasPrefix(authReq.DisplayEnv, "wayland:")
+}
+
 func (b *Broker) isDesktopHelperPeerPath(peerPath string) bool {
 	peerResolved, err := filepath.EvalSymlinks(peerPath)
 	if err != nil {
@@ -2812,7 +2829,8 @@ func (b *Broker) dispatchHelperMessage(s *Session, env *ipc.Envelope) {
 			b.onMessage(s, env)
 		}
 	case ipc.TypeTrayAction, ipc.TypeNotifyResult, ipc.TypeClipboardData, ipc.TypeCommandResult, ipc.TypeSASRequest, ipc.TypeDesktopPeerDisconnected,
-		ipc.TypeDesktopStart, ipc.TypeDesktopStop, ipc.TypeLaunchResult:
+		ipc.TypeDesktopStart, ipc.TypeDesktopStop, ipc.TypeDesktopJoin, ipc.TypeDesktopLeave, ipc.TypeLaunchResult, ipc.TypeUsageReport,
+		ipc.TypeChatMessage, ipc.TypeDesktopQuality:
 		if !shouldForwardUnsolicitedHelperMessage(s, env) {
 			log.Warn("dropping unsolicited or unauthorized helper message",
 				"type", env.Type, "sessionId", s.SessionID, "role", s.HelperRole)
@@ -2998,10 +3016,16 @@ func shouldForwardUnsolicitedHelperMessage(session *Session, env *ipc.Envelope)
 		return session.HasScope("backup")
 	case ipc.TypeTrayAction:
 		return session.HasScope("tray")
-	case ipc.TypeSASRequest, ipc.TypeDesktopPeerDisconnected:
+	case ipc.TypeSASRequest, ipc.TypeDesktopPeerDisconnected, ipc.TypeDesktopQuality:
 		return session.HasScope("desktop")
+	case ipc.TypeChatMessage:
+		// Technician lines come from the desktop helper, user replies from
+		// the helper rendering the chat; the service checks which is which.
+		return session.HasScope("desktop") || session.HasScope(ipc.ScopeConsentUI) || session.HasScope(ipc.ScopeConsentUIFallback)
 	case ipc.TypeWatchdogCommandResult:
 		return session.HasScope("watchdog")
+	case ipc.TypeUsageReport:
+		return session.HasScope(ipc.ScopeUsageMetering)
 	case ipc.TypeNotifyResult, ipc.TypeClipboardData, ipc.TypeCommandResult:
 		return false
 	default:
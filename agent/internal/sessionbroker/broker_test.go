@@ -1168,3 +1168,37 @@ func TestConsentUIFallbackGrantDoesNotMutateSharedScopeSlices(t *testing.T) {
 		}
 	}
 }
+
+func TestLinuxWaylandUserHelperGetsDesktopScope(t *testing.T) {
+	b := &Broker{}
+	tests := []struct {
+		name       string
+		role       ipc.HelperRole
+		goos       string
+		displayEnv string
+		want       bool
+	}{
+		{"linux wayland user helper", ipc.HelperRoleUser, "linux", "wayland:wayland-0", true},
+		{"linux x11 user helper", ipc.HelperRoleUser, "linux", "x11::0", false},
+		{"linux headless user helper", ipc.HelperRoleUser, "linux", "", false},
+		{"windows user helper", ipc.HelperRoleUser, "windows", "wayland:wayland-0", false},
+		{"assist helper on wayland", ipc.HelperRoleAssist, "linux", "wayland:wayland-0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scopes := b.grantScopes(tt.role, ipc.AuthRequest{
+				BinaryKind: ipc.HelperBinaryUserHelper,
+				DisplayEnv: tt.displayEnv,
+			}, tt.goos, "")
+			got := false
+			for _, s := range scopes {
+				if s == "desktop" {
+					got = true
+				}
+			}
+			if got != tt.want {
+				t.Errorf("desktop scope granted = %v, want %v (scopes: %v)", got, tt.want, scopes)
+			}
+		})
+	}
+}
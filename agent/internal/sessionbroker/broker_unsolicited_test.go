@@ -20,6 +20,8 @@ func TestShouldForwardUnsolicitedHelperMessage(t *testing.T) {
 		{ipc.TypeTrayAction, true},
 		{ipc.TypeSASRequest, true},
 		{ipc.TypeDesktopPeerDisconnected, true},
+		{ipc.TypeChatMessage, true},
+		{ipc.TypeDesktopQuality, true},
 		{ipc.TypeNotifyResult, false},
 		{ipc.TypeClipboardData, false},
 		{ipc.TypeCommandResult, false},
@@ -34,6 +36,20 @@ func TestShouldForwardUnsolicitedHelperMessage(t *testing.T) {
 	}
 }
 
+func TestShouldForwardChatMessageScopes(t *testing.T) {
+	t.Parallel()
+
+	env := &ipc.Envelope{Type: ipc.TypeChatMessage}
+	for _, scopes := range [][]string{{"desktop"}, {ipc.ScopeConsentUI}, {ipc.ScopeConsentUIFallback}} {
+		if !shouldForwardUnsolicitedHelperMessage(&Session{AllowedScopes: scopes}, env) {
+			t.Fatalf("chat_message from scopes %v should be forwarded", scopes)
+		}
+	}
+	if shouldForwardUnsolicitedHelperMessage(&Session{AllowedScopes: []string{"notify", "tray"}}, env) {
+		t.Fatal("chat_message from a notify/tray-only helper should be dropped")
+	}
+}
+
 func TestSanitizeCapabilitiesForSessionScopes(t *testing.T) {
 	t.Parallel()
 
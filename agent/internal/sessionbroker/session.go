@@ -488,6 +488,10 @@ func expectedResponseType(cmdType string) string {
 		return ipc.TypeDesktopStart
 	case ipc.TypeDesktopStop:
 		return ipc.TypeDesktopStop
+	case ipc.TypeDesktopJoin:
+		return ipc.TypeDesktopJoin
+	case ipc.TypeDesktopLeave:
+		return ipc.TypeDesktopLeave
 	case ipc.TypeSASRequest:
 		return ipc.TypeSASResponse
 	case ipc.TypeLaunchProcess:
@@ -531,6 +535,22 @@ func responseValidator(cmdType string, payload any) func(*ipc.Envelope) error {
 			}
 			return nil
 		}
+	case ipc.TypeDesktopJoin:
+		req, ok := payload.(ipc.DesktopJoinRequest)
+		if !ok || req.SessionID == "" {
+			return nil
+		}
+		return func(env *ipc.Envelope) error {
+			var result ipc.DesktopJoinResponse
+			if err := json.Unmarshal(env.Payload, &result); err != nil {
+				return fmt.Errorf("unmarshal desktop join response: %w", err)
+			}
+			if result.SessionID != req.SessionID || result.ViewerID != req.ViewerID {
+				return fmt.Errorf("desktop join mismatch: expected %q/%q got %q/%q",
+					req.SessionID, req.ViewerID, result.SessionID, result.ViewerID)
+			}
+			return nil
+		}
 	case backupipc.TypeBackupCommand:
 		req, ok := payload.(backupipc.BackupCommandRequest)
 		if !ok || req.CommandID == "" {
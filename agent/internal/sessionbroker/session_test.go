@@ -316,6 +316,48 @@ func TestHandleResponseRejectsMismatchedDesktopStartSessionID(t *testing.T) {
 	}
 }
 
+func TestHandleResponseRejectsMismatchedDesktopJoinViewerID(t *testing.T) {
+	session, clientIPC := createTestSession(t)
+	defer session.Close()
+	defer clientIPC.Close()
+
+	ch := make(chan *ipc.Envelope, 1)
+	session.mu.Lock()
+	session.pending["desk-join-1"] = pendingResponse{
+		ch:           ch,
+		expectedType: ipc.TypeDesktopJoin,
+		validate: responseValidator(ipc.TypeDesktopJoin, ipc.DesktopJoinRequest{
+			SessionID: "session-expected",
+			ViewerID:  "viewer-expected",
+		}),
+	}
+	session.mu.Unlock()
+
+	payload, err := json.Marshal(ipc.DesktopJoinResponse{
+		SessionID: "session-expected",
+		ViewerID:  "viewer-other",
+		Answer:    "fake-answer",
+	})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	matched := session.HandleResponse(&ipc.Envelope{
+		ID:      "desk-join-1",
+		Type:    ipc.TypeDesktopJoin,
+		Payload: payload,
+	})
+	if !matched {
+		t.Fatal("expected pending command ID to be recognized")
+	}
+
+	select {
+	case <-ch:
+		t.Fatal("expected mismatched desktop join response not to be delivered")
+	default:
+	}
+}
+
 func TestHandleResponseRejectsMismatchedBackupCommandResultPayloadID(t *testing.T) {
 	session, clientIPC := createTestSession(t)
 	defer session.Close()
@@ -179,6 +179,24 @@ func acquireUserToken(sessionID uint32) (windows.Token, *uint16, string, error)
 	return token, nil, "SYSTEM", nil
 }
 
+// UserTokenForSession returns a primary token for the user logged in to the
+// given Windows session. Unlike acquireUserToken it never falls back to
+// SYSTEM: callers asked for the user's identity and must fail without it.
+// The caller owns (and closes) the token.
+func UserTokenForSession(sessionID uint32) (windows.Token, error) {
+	token, envBlock, _, err := getUserTokenViaWTS(sessionID)
+	if err != nil {
+		wtsErr := err
+		if token, envBlock, err = getUserTokenViaExplorer(sessionID); err != nil {
+			return 0, fmt.Errorf("no user token for session %d: %v; %w", sessionID, wtsErr, err)
+		}
+	}
+	if envBlock != nil {
+		windows.DestroyEnvironmentBlock(envBlock)
+	}
+	return token, nil
+}
+
 // getUserTokenViaWTS obtains the logged-in user's token via WTSQueryUserToken.
 func getUserTokenViaWTS(sessionID uint32) (windows.Token, *uint16, bool, error) {
 	var userToken windows.Token
@@ -4,6 +4,7 @@ package terminal
 
 import (
 	"fmt"
+	"strings"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
@@ -65,8 +66,10 @@ func closeConPTY(hPC uintptr) {
 }
 
 // startProcessWithConPTY creates a child process attached to the given ConPTY.
+// A nil env inherits the agent's environment and an empty dir its working
+// directory; a non-zero token runs the process as that token's account.
 // Returns the process handle, thread handle, and process ID.
-func startProcessWithConPTY(hPC uintptr, commandLine string) (windows.Handle, windows.Handle, uint32, error) {
+func startProcessWithConPTY(hPC uintptr, commandLine string, env []string, dir string, token windows.Token) (windows.Handle, windows.Handle, uint32, error) {
 	// Allocate and initialize a proc thread attribute list with 1 entry.
 	attrContainer, err := windows.NewProcThreadAttributeList(1)
 	if err != nil {
@@ -94,14 +97,47 @@ func startProcessWithConPTY(hPC uintptr, commandLine string) (windows.Handle, wi
 		return 0, 0, 0, err
 	}
 
+	flags := uint32(_EXTENDED_STARTUPINFO_PRESENT)
+	var envBlock *uint16
+	if env != nil {
+		block, err := windows.UTF16FromString(strings.Join(env, "\x00") + "\x00")
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("environment block: %w", err)
+		}
+		envBlock = &block[0]
+		flags |= windows.CREATE_UNICODE_ENVIRONMENT
+	}
+	var dirPtr *uint16
+	if dir != "" {
+		if dirPtr, err = windows.UTF16PtrFromString(dir); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+
 	var pi windows.ProcessInformation
+	if token != 0 {
+		if err := windows.CreateProcessAsUser(
+			token,
+			nil,
+			cmdLine,
+			nil, nil,
+			false,
+			flags,
+			envBlock, dirPtr,
+			&si.StartupInfo,
+			&pi,
+		); err != nil {
+			return 0, 0, 0, fmt.Errorf("CreateProcessAsUser: %w", err)
+		}
+		return pi.Process, pi.Thread, pi.ProcessId, nil
+	}
 	if err := windows.CreateProcess(
 		nil,
 		cmdLine,
 		nil, nil,
 		false,
-		_EXTENDED_STARTUPINFO_PRESENT,
-		nil, nil,
+		flags,
+		envBlock, dirPtr,
 		&si.StartupInfo,
 		&pi,
 	); err != nil {
@@ -0,0 +1,80 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"fmt"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// Credential is an account a session's shell runs as instead of the agent's
+// own (SessionOptions.RunAs). On Unix the agent runs as root and switches
+// user directly, so no password is involved.
+type Credential struct {
+	Username string
+	UID      uint32
+	GID      uint32
+	Groups   []uint32
+	Home     string
+}
+
+// LookupUserCredential returns the credential of a local account by name.
+func LookupUserCredential(username string) (*Credential, error) {
+	u, err := user.Lookup(username)
+	if err != nil {
+		return nil, fmt.Errorf("look up user %q: %w", username, err)
+	}
+	return credentialFromUser(u)
+}
+
+// LookupUserCredentialByID returns the credential of a local account by UID.
+func LookupUserCredentialByID(uid string) (*Credential, error) {
+	u, err := user.LookupId(uid)
+	if err != nil {
+		return nil, fmt.Errorf("look up uid %s: %w", uid, err)
+	}
+	return credentialFromUser(u)
+}
+
+func credentialFromUser(u *user.User) (*Credential, error) {
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("user %s has non-numeric uid %q", u.Username, u.Uid)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("user %s has non-numeric gid %q", u.Username, u.Gid)
+	}
+	c := &Credential{Username: u.Username, UID: uint32(uid), GID: uint32(gid), Home: u.HomeDir}
+	if ids, err := u.GroupIds(); err == nil {
+		for _, id := range ids {
+			if g, err := strconv.ParseUint(id, 10, 32); err == nil {
+				c.Groups = append(c.Groups, uint32(g))
+			}
+		}
+	}
+	return c, nil
+}
+
+// apply makes cmd run as the account: it sets the process credential, points
+// HOME/USER/LOGNAME at the account (env entries appended last win), and
+// starts in its home directory unless a directory was requested.
+func (c *Credential) apply(cmd *exec.Cmd, env []string) []string {
+	if c == nil {
+		return env
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = &syscall.Credential{Uid: c.UID, Gid: c.GID, Groups: c.Groups}
+	if cmd.Dir == "" && c.Home != "" {
+		cmd.Dir = c.Home
+	}
+	return append(env, "HOME="+c.Home, "USER="+c.Username, "LOGNAME="+c.Username)
+}
+
+// Release frees OS resources held by the credential; none on Unix.
+func (c *Credential) Release() {}
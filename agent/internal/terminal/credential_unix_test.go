@@ -0,0 +1,50 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"slices"
+	"testing"
+)
+
+func TestLookupUserCredential(t *testing.T) {
+	me, err := user.Current()
+	if err != nil {
+		t.Skipf("current user: %v", err)
+	}
+	byName, err := LookupUserCredential(me.Username)
+	if err != nil {
+		t.Fatalf("LookupUserCredential: %v", err)
+	}
+	byID, err := LookupUserCredentialByID(me.Uid)
+	if err != nil {
+		t.Fatalf("LookupUserCredentialByID: %v", err)
+	}
+	if byName.UID != uint32(os.Getuid()) || byID.UID != byName.UID || byName.Home != me.HomeDir {
+		t.Fatalf("credential mismatch: %+v vs %+v", byName, byID)
+	}
+	if _, err := LookupUserCredential("no-such-user-breeze"); err == nil {
+		t.Fatal("unknown user should fail")
+	}
+}
+
+func TestCredentialApply(t *testing.T) {
+	c := &Credential{Username: "alice", UID: 1001, GID: 1001, Home: "/home/alice"}
+	cmd := exec.Command("/bin/sh")
+	env := c.apply(cmd, []string{"TERM=xterm-256color", "HOME=/root"})
+	if cmd.SysProcAttr == nil || cmd.SysProcAttr.Credential == nil || cmd.SysProcAttr.Credential.Uid != 1001 {
+		t.Fatalf("credential not applied: %+v", cmd.SysProcAttr)
+	}
+	if cmd.Dir != "/home/alice" || !slices.Contains(env, "USER=alice") || !slices.Contains(env, "HOME=/home/alice") || env[len(env)-3] != "HOME=/home/alice" {
+		t.Fatalf("dir = %q, env = %v", cmd.Dir, env)
+	}
+
+	var none *Credential
+	plain := exec.Command("/bin/sh")
+	if got := none.apply(plain, []string{"A=1"}); len(got) != 1 || plain.SysProcAttr != nil {
+		t.Fatalf("nil credential changed the command: %v %+v", got, plain.SysProcAttr)
+	}
+}
@@ -0,0 +1,120 @@
+//go:build windows
+
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	advapi32DLL   = windows.NewLazySystemDLL("advapi32.dll")
+	procLogonUser = advapi32DLL.NewProc("LogonUserW")
+)
+
+const (
+	logon32LogonInteractive = 2
+	logon32ProviderDefault  = 0
+)
+
+// Credential is an account a session's shell runs as instead of the agent's
+// own (SessionOptions.RunAs): a primary token and the account's environment.
+type Credential struct {
+	Username string
+
+	token       windows.Token
+	env         []string
+	releaseOnce sync.Once
+}
+
+// NewTokenCredential wraps a primary token, e.g. the logged-in user's. The
+// credential takes ownership of the token, also on error.
+func NewTokenCredential(token windows.Token, username string) (*Credential, error) {
+	env, err := tokenEnvironment(token)
+	if err != nil {
+		token.Close()
+		return nil, fmt.Errorf("user environment: %w", err)
+	}
+	return &Credential{Username: username, token: token, env: env}, nil
+}
+
+// LogonUserCredential logs a local or domain account on with its password.
+// The account may be "user" (local), "DOMAIN\user" or "user@domain".
+func LogonUserCredential(username, password string) (*Credential, error) {
+	name, domain := splitWindowsAccount(username)
+	namePtr, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	var domainPtr *uint16
+	if domain != "" {
+		if domainPtr, err = windows.UTF16PtrFromString(domain); err != nil {
+			return nil, err
+		}
+	}
+	passPtr, err := windows.UTF16PtrFromString(password)
+	if err != nil {
+		return nil, err
+	}
+	var token windows.Token
+	r, _, callErr := procLogonUser.Call(
+		uintptr(unsafe.Pointer(namePtr)),
+		uintptr(unsafe.Pointer(domainPtr)),
+		uintptr(unsafe.Pointer(passPtr)),
+		logon32LogonInteractive,
+		logon32ProviderDefault,
+		uintptr(unsafe.Pointer(&token)),
+	)
+	if r == 0 {
+		return nil, fmt.Errorf("logon %s failed: %w", username, callErr)
+	}
+	return NewTokenCredential(token, username)
+}
+
+// tokenEnvironment returns the account's default environment (profile
+// variables, no inheritance from the agent).
+func tokenEnvironment(token windows.Token) ([]string, error) {
+	var block *uint16
+	if err := windows.CreateEnvironmentBlock(&block, token, false); err != nil {
+		return nil, err
+	}
+	defer windows.DestroyEnvironmentBlock(block)
+
+	var env []string
+	for p := unsafe.Pointer(block); ; {
+		entry := windows.UTF16PtrToString((*uint16)(p))
+		if entry == "" {
+			break
+		}
+		env = append(env, entry)
+		p = unsafe.Add(p, (len(windows.StringToUTF16(entry)))*2)
+	}
+	return env, nil
+}
+
+// environ returns the account's environment with extra entries applied.
+func (c *Credential) environ(extra []string) []string {
+	return dedupEnv(applyShellEnv(c.env, extra...), true)
+}
+
+// homeDir is the account's profile directory, "" when unknown.
+func (c *Credential) homeDir() string {
+	for _, kv := range c.env {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.EqualFold(k, "USERPROFILE") {
+			return v
+		}
+	}
+	return ""
+}
+
+// Release closes the token. Safe to call more than once.
+func (c *Credential) Release() {
+	if c == nil {
+		return
+	}
+	c.releaseOnce.Do(func() { c.token.Close() })
+}
@@ -85,11 +85,12 @@ func (s *Session) start() error {
 	if os.Getenv("SHELL") == "" {
 		env = append(env, "SHELL="+s.Shell)
 	}
-	env = applyShellEnv(env,
+	env = applyShellEnv(env, append([]string{
 		fmt.Sprintf("COLUMNS=%d", s.Cols),
 		fmt.Sprintf("LINES=%d", s.Rows),
-	)
+	}, s.extraEnv...)...)
 	cmd.Env = env
+	cmd.Dir = s.Dir
 
 	// Set up the command to use the TTY.
 	// NOTE: Do NOT use Setctty in SysProcAttr on macOS with CGO.
@@ -102,6 +103,7 @@ func (s *Session) start() error {
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		Setsid: true,
 	}
+	cmd.Env = s.runAs.apply(cmd, cmd.Env)
 
 	// Start the command
 	if err := cmd.Start(); err != nil {
@@ -69,11 +69,12 @@ func (s *Session) start() error {
 	if os.Getenv("SHELL") == "" {
 		env = append(env, "SHELL="+s.Shell)
 	}
-	env = applyShellEnv(env,
+	env = applyShellEnv(env, append([]string{
 		fmt.Sprintf("COLUMNS=%d", s.Cols),
 		fmt.Sprintf("LINES=%d", s.Rows),
-	)
+	}, s.extraEnv...)...)
 	cmd.Env = env
+	cmd.Dir = s.Dir
 
 	// Set up the command to use the TTY
 	cmd.Stdin = slave
@@ -85,6 +86,7 @@ func (s *Session) start() error {
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		Setsid: true,
 	}
+	cmd.Env = s.runAs.apply(cmd, cmd.Env)
 
 	// Start the command
 	if err := cmd.Start(); err != nil {
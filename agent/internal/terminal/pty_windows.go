@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync/atomic"
+	"syscall"
 
 	"golang.org/x/sys/windows"
 )
@@ -58,7 +59,22 @@ func (s *Session) startConPTY() error {
 	cmdLine := buildCommandLine(s.Shell)
 
 	// Create the child process attached to the pseudo console.
-	hProc, hThread, pid, err := startProcessWithConPTY(hPC, cmdLine)
+	// The child inherits the agent's environment unless extra variables
+	// were requested or it runs as another account.
+	var env []string
+	var token windows.Token
+	dir := s.Dir
+	switch {
+	case s.runAs != nil:
+		env = s.runAs.environ(s.extraEnv)
+		token = s.runAs.token
+		if dir == "" {
+			dir = s.runAs.homeDir()
+		}
+	case len(s.extraEnv) > 0:
+		env = dedupEnv(applyShellEnv(os.Environ(), s.extraEnv...), true)
+	}
+	hProc, hThread, pid, err := startProcessWithConPTY(hPC, cmdLine, env, dir, token)
 	if err != nil {
 		closeConPTY(hPC)
 		windows.CloseHandle(inWrite)
@@ -102,7 +118,15 @@ func (s *Session) startPipes() error {
 	} else {
 		cmd = exec.Command(s.Shell)
 	}
-	cmd.Env = applyShellEnv(os.Environ())
+	cmd.Env = applyShellEnv(os.Environ(), s.extraEnv...)
+	cmd.Dir = s.Dir
+	if s.runAs != nil {
+		cmd.Env = s.runAs.environ(s.extraEnv)
+		cmd.SysProcAttr = &syscall.SysProcAttr{Token: syscall.Token(s.runAs.token)}
+		if cmd.Dir == "" {
+			cmd.Dir = s.runAs.homeDir()
+		}
+	}
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
@@ -133,8 +157,8 @@ func (s *Session) startPipes() error {
 	// Forward on UTF-8 rune boundaries so a multibyte char split across a
 	// 4096-byte read isn't decoded into U+FFFD downstream (streamUTF8 flushes
 	// any held tail on EOF).
-	go func() { _ = streamUTF8(stdout, s.onOutput, nil) }()
-	go func() { _ = streamUTF8(stderr, s.onOutput, nil) }()
+	go func() { _ = streamUTF8(stdout, s.emit, nil) }()
+	go func() { _ = streamUTF8(stderr, s.emit, nil) }()
 
 	go func() {
 		err := s.waitCmd()
@@ -0,0 +1,88 @@
+package terminal
+
+import (
+	"fmt"
+	"time"
+)
+
+// A session whose viewer went away (browser reload, dropped WebSocket) can be
+// detached instead of stopped: the shell keeps running and its output goes
+// only to the scrollback. AttachSession replays the scrollback and resumes
+// streaming. A detached session is stopped once its grace period runs out.
+
+// emit records output in the scrollback and forwards it to the viewer unless
+// the session is detached.
+func (s *Session) emit(data []byte) {
+	s.rec.output(data)
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	s.scrollback.write(data)
+	if !s.detached && s.onOutput != nil {
+		s.onOutput(data)
+	}
+}
+
+// DetachSession stops forwarding a session's output and keeps its shell
+// running for grace. The session is stopped unless it is reattached in time.
+func (m *Manager) DetachSession(id string, grace time.Duration) error {
+	if grace <= 0 {
+		return fmt.Errorf("detach grace period must be positive")
+	}
+	session, exists := m.GetSession(id)
+	if !exists {
+		return fmt.Errorf("session %s not found", id)
+	}
+
+	session.outMu.Lock()
+	session.detached = true
+	session.detachGen++
+	gen := session.detachGen
+	session.outMu.Unlock()
+
+	time.AfterFunc(grace, func() {
+		session.outMu.Lock()
+		expired := session.detached && session.detachGen == gen
+		session.outMu.Unlock()
+		if !expired {
+			return
+		}
+		log.Info("detached session not reattached, stopping", "sessionId", id, "grace", grace)
+		m.removeSessionIfCurrent(id, session)
+		if err := session.close(); err != nil {
+			log.Warn("failed to close detached session", "sessionId", id, "error", err.Error())
+		}
+	})
+	log.Info("session detached", "sessionId", id, "grace", grace)
+	return nil
+}
+
+// AttachSession replays a session's scrollback through its output callback
+// and resumes live streaming. Works on attached sessions too, for a viewer
+// that reconnected without a detach. Returns the number of bytes replayed.
+func (m *Manager) AttachSession(id string) (int, error) {
+	session, exists := m.GetSession(id)
+	if !exists {
+		return 0, fmt.Errorf("session %s not found", id)
+	}
+
+	// Holding outMu across the replay keeps live output from interleaving
+	// with it; emit waits and follows the replay.
+	session.outMu.Lock()
+	defer session.outMu.Unlock()
+	session.detached = false
+	session.detachGen++
+	replay := session.scrollback.snapshot()
+	if len(replay) > 0 && session.onOutput != nil {
+		session.onOutput(replay)
+	}
+	session.touch()
+	log.Info("session attached", "sessionId", id, "replayedBytes", len(replay))
+	return len(replay), nil
+}
+
+// IsDetached reports whether a session is running without a viewer.
+func (s *Session) IsDetached() bool {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	return s.detached
+}
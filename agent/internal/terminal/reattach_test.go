@@ -0,0 +1,109 @@
+package terminal
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScrollbackTrimsOldestOnRuneBoundary(t *testing.T) {
+	b := scrollback{max: 8}
+	b.write([]byte("abcdef"))
+	b.write([]byte("g€h")) // € is 3 bytes; total 11, trimming 3 lands on 'd'
+	if got := string(b.snapshot()); got != "defg€h" {
+		t.Fatalf("snapshot = %q, want %q", got, "defg€h")
+	}
+
+	b = scrollback{max: 3}
+	b.write([]byte("a€b")) // trimming 2 bytes would land inside €
+	if got := string(b.snapshot()); got != "b" {
+		t.Fatalf("snapshot = %q, want the rune cut dropped", got)
+	}
+}
+
+func TestDetachBuffersOutputAndAttachReplays(t *testing.T) {
+	var mu sync.Mutex
+	var out bytes.Buffer
+	m := NewManager()
+	s := &Session{ID: "reattach", onOutput: func(p []byte) {
+		mu.Lock()
+		out.Write(p)
+		mu.Unlock()
+	}}
+	m.sessions[s.ID] = s
+
+	s.emit([]byte("before "))
+	if err := m.DetachSession(s.ID, time.Minute); err != nil {
+		t.Fatalf("DetachSession: %v", err)
+	}
+	s.emit([]byte("while-detached "))
+	mu.Lock()
+	if got := out.String(); got != "before " {
+		t.Fatalf("forwarded while detached: %q", got)
+	}
+	out.Reset()
+	mu.Unlock()
+
+	replayed, err := m.AttachSession(s.ID)
+	if err != nil {
+		t.Fatalf("AttachSession: %v", err)
+	}
+	s.emit([]byte("after"))
+	mu.Lock()
+	defer mu.Unlock()
+	if got := out.String(); got != "before while-detached after" {
+		t.Fatalf("output after attach = %q", got)
+	}
+	if replayed != len("before while-detached ") {
+		t.Fatalf("replayed = %d", replayed)
+	}
+	if s.IsDetached() {
+		t.Fatal("session still detached after attach")
+	}
+}
+
+func TestDetachedSessionStoppedAfterGrace(t *testing.T) {
+	m := NewManager()
+	s := &Session{ID: "expire"}
+	m.sessions[s.ID] = s
+
+	if err := m.DetachSession(s.ID, 20*time.Millisecond); err != nil {
+		t.Fatalf("DetachSession: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for m.GetSessionCount() != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if m.GetSessionCount() != 0 {
+		t.Fatal("detached session was not stopped after its grace period")
+	}
+}
+
+func TestAttachCancelsGraceStop(t *testing.T) {
+	m := NewManager()
+	s := &Session{ID: "keep"}
+	m.sessions[s.ID] = s
+
+	if err := m.DetachSession(s.ID, 30*time.Millisecond); err != nil {
+		t.Fatalf("DetachSession: %v", err)
+	}
+	if _, err := m.AttachSession(s.ID); err != nil {
+		t.Fatalf("AttachSession: %v", err)
+	}
+	time.Sleep(80 * time.Millisecond)
+	if _, ok := m.GetSession(s.ID); !ok {
+		t.Fatal("reattached session was stopped by the stale grace timer")
+	}
+}
+
+func TestAttachUnknownSession(t *testing.T) {
+	m := NewManager()
+	if _, err := m.AttachSession("missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("AttachSession(missing) error = %v", err)
+	}
+	if err := m.DetachSession("missing", time.Minute); err == nil {
+		t.Fatal("DetachSession(missing) should fail")
+	}
+}
@@ -0,0 +1,182 @@
+package terminal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sessions can be recorded as asciinema v2 cast files
+// (https://docs.asciinema.org/manual/asciicast/v2/): a JSON header line, then
+// one [elapsed seconds, code, data] line per event — "o" for output, "i" for
+// viewer input and "r" for resizes. Input is only recorded when the manager
+// opts in (RecordInput), since it carries passwords typed at prompts that do
+// not echo. The file is streamed to disk as the session runs, so a long
+// session costs no memory.
+
+// maxRecordingBytes caps a cast file. Past it events are dropped and a
+// marker notes the truncation; the session itself keeps running.
+const maxRecordingBytes = 64 << 20
+
+// RecordingInfo describes a finished session recording.
+type RecordingInfo struct {
+	SessionID string
+	Path      string
+	SHA256    string
+	SizeBytes int64
+	StartedAt time.Time
+	Duration  time.Duration
+	Truncated bool
+	// User is the account the shell ran as; empty for the agent's own.
+	User string
+}
+
+type recorder struct {
+	mu        sync.Mutex
+	f         *os.File
+	w         *bufio.Writer
+	sum       hash.Hash
+	info      RecordingInfo
+	closed    bool
+	truncated bool
+	// recordInput keeps "i" events; they are dropped otherwise.
+	recordInput bool
+}
+
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     uint16            `json:"width"`
+	Height    uint16            `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// newRecorder creates the cast file for a session in dir and writes its
+// header.
+func newRecorder(dir, sessionID string, cols, rows uint16, shell string, now time.Time) (*recorder, error) {
+	if sessionID == "" || strings.ContainsAny(sessionID, `/\`) || strings.Contains(sessionID, "..") {
+		return nil, fmt.Errorf("session ID %q is not usable as a file name", sessionID)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create recording dir: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%d.cast", sessionID, now.Unix()))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("create recording: %w", err)
+	}
+	r := &recorder{
+		f:   f,
+		sum: sha256.New(),
+		info: RecordingInfo{
+			SessionID: sessionID,
+			Path:      path,
+			StartedAt: now,
+		},
+	}
+	r.w = bufio.NewWriter(io.MultiWriter(f, r.sum))
+	header := castHeader{
+		Version:   2,
+		Width:     cols,
+		Height:    rows,
+		Timestamp: now.Unix(),
+		Env:       map[string]string{"SHELL": shell, "TERM": "xterm-256color"},
+	}
+	if err := r.writeLine(header); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("write recording header: %w", err)
+	}
+	return r, nil
+}
+
+func (r *recorder) output(data []byte) { r.event("o", string(data)) }
+func (r *recorder) input(data []byte) {
+	if r != nil && r.recordInput {
+		r.event("i", string(data))
+	}
+}
+func (r *recorder) resize(cols, rows uint16) {
+	if r != nil {
+		r.event("r", fmt.Sprintf("%dx%d", cols, rows))
+	}
+}
+
+func (r *recorder) event(code, data string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.truncated {
+		return
+	}
+	elapsed := time.Since(r.info.StartedAt).Seconds()
+	if r.info.SizeBytes+int64(len(data)) > maxRecordingBytes {
+		r.truncated = true
+		_ = r.writeLine([]any{elapsed, "m", "recording truncated: size limit reached"})
+		return
+	}
+	if err := r.writeLine([]any{elapsed, code, data}); err != nil {
+		log.Warn("failed to write session recording, stopping it", "sessionId", r.info.SessionID, "error", err.Error())
+		r.truncated = true
+	}
+}
+
+// writeLine appends one JSON line. Callers hold r.mu (or own r exclusively).
+func (r *recorder) writeLine(v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	n, err := r.w.Write(line)
+	r.info.SizeBytes += int64(n)
+	return err
+}
+
+// close flushes the cast file and returns its description.
+func (r *recorder) close() (RecordingInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return r.info, nil
+	}
+	r.closed = true
+	flushErr := r.w.Flush()
+	closeErr := r.f.Close()
+	r.info.SHA256 = hex.EncodeToString(r.sum.Sum(nil))
+	r.info.Duration = time.Since(r.info.StartedAt)
+	r.info.Truncated = r.truncated
+	if flushErr != nil {
+		return r.info, flushErr
+	}
+	return r.info, closeErr
+}
+
+// finishRecording closes the session's recording, if any, and hands it to
+// the manager's OnRecordingClosed. Runs once, on whichever of close or
+// process exit comes first.
+func (s *Session) finishRecording() {
+	if s.rec == nil {
+		return
+	}
+	s.recOnce.Do(func() {
+		info, err := s.rec.close()
+		if err != nil {
+			log.Warn("failed to finish session recording", "sessionId", s.ID, "path", info.Path, "error", err.Error())
+		}
+		if s.onRecording != nil {
+			s.onRecording(info)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readCast(t *testing.T, path string) (castHeader, [][]any) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open cast: %v", err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 1<<20), 1<<20)
+	var header castHeader
+	var events [][]any
+	for i := 0; sc.Scan(); i++ {
+		if i == 0 {
+			if err := json.Unmarshal(sc.Bytes(), &header); err != nil {
+				t.Fatalf("header: %v", err)
+			}
+			continue
+		}
+		var ev []any
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatalf("event %d: %v", i, err)
+		}
+		events = append(events, ev)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("scan cast: %v", err)
+	}
+	return header, events
+}
+
+func TestRecorderWritesAsciicastV2(t *testing.T) {
+	dir := t.TempDir()
+	r, err := newRecorder(dir, "sess-1", 80, 24, "/bin/bash", time.Now())
+	if err != nil {
+		t.Fatalf("newRecorder: %v", err)
+	}
+	r.recordInput = true
+	r.output([]byte("$ "))
+	r.input([]byte("ls\r"))
+	r.resize(120, 40)
+	info, err := r.close()
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	r.output([]byte("after close")) // dropped
+
+	header, events := readCast(t, info.Path)
+	if header.Version != 2 || header.Width != 80 || header.Height != 24 || header.Env["SHELL"] != "/bin/bash" {
+		t.Fatalf("header = %+v", header)
+	}
+	want := [][2]string{{"o", "$ "}, {"i", "ls\r"}, {"r", "120x40"}}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v", events)
+	}
+	for i, w := range want {
+		if events[i][1] != w[0] || events[i][2] != w[1] {
+			t.Fatalf("event %d = %v, want %v", i, events[i], w)
+		}
+	}
+	if st, _ := os.Stat(info.Path); st == nil || st.Size() != info.SizeBytes || len(info.SHA256) != 64 {
+		t.Fatalf("info = %+v", info)
+	}
+}
+
+func TestRecorderDropsInputUnlessEnabled(t *testing.T) {
+	r, err := newRecorder(t.TempDir(), "sess-2", 80, 24, "/bin/sh", time.Now())
+	if err != nil {
+		t.Fatalf("newRecorder: %v", err)
+	}
+	r.output([]byte("Password: "))
+	r.input([]byte("hunter2\r"))
+	info, _ := r.close()
+
+	_, events := readCast(t, info.Path)
+	if len(events) != 1 || events[0][1] != "o" {
+		t.Fatalf("events = %v, want only the output event", events)
+	}
+}
+
+func TestRecorderTruncatesAtLimit(t *testing.T) {
+	r, err := newRecorder(t.TempDir(), "big", 80, 24, "/bin/sh", time.Now())
+	if err != nil {
+		t.Fatalf("newRecorder: %v", err)
+	}
+	chunk := []byte(strings.Repeat("x", 512<<10))
+	for i := 0; i < maxRecordingBytes>>19+2; i++ {
+		r.output(chunk)
+	}
+	info, _ := r.close()
+	if !info.Truncated || info.SizeBytes > maxRecordingBytes+1024 {
+		t.Fatalf("info = %+v, want truncated near the limit", info)
+	}
+	_, events := readCast(t, info.Path)
+	if last := events[len(events)-1]; last[1] != "m" {
+		t.Fatalf("last event = %v, want the truncation marker", last[1])
+	}
+}
+
+func TestRecorderRejectsUnsafeSessionID(t *testing.T) {
+	for _, id := range []string{"", "../x", `a\b`, "a/b"} {
+		if _, err := newRecorder(t.TempDir(), id, 80, 24, "/bin/sh", time.Now()); err == nil {
+			t.Fatalf("newRecorder(%q) should fail", id)
+		}
+	}
+}
+
+func TestManagerRecordsSession(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("PTY test requires Unix/macOS")
+	}
+	m := NewManager()
+	m.RecordDir = t.TempDir()
+	m.RecordInput = true
+	done := make(chan RecordingInfo, 1)
+	m.OnRecordingClosed = func(info RecordingInfo) { done <- info }
+
+	if err := m.StartSession("rec", 80, 24, "/bin/sh", func([]byte) {}, nil); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := m.WriteToSession("rec", []byte("echo recorded-$((1+1))\n")); err != nil {
+		t.Fatalf("WriteToSession: %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if err := m.StopSession("rec"); err != nil {
+		t.Fatalf("StopSession: %v", err)
+	}
+
+	var info RecordingInfo
+	select {
+	case info = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnRecordingClosed not called")
+	}
+	_, events := readCast(t, info.Path)
+	var sawInput, sawOutput bool
+	for _, ev := range events {
+		data, _ := ev[2].(string)
+		sawInput = sawInput || (ev[1] == "i" && strings.Contains(data, "echo recorded"))
+		sawOutput = sawOutput || (ev[1] == "o" && strings.Contains(data, "recorded-2"))
+	}
+	if !sawInput || !sawOutput {
+		t.Fatalf("recording missing input/output: %v", events)
+	}
+}
@@ -0,0 +1,34 @@
+package terminal
+
+import "unicode/utf8"
+
+// scrollbackBytes is how much recent output each session keeps for replay on
+// reattach. Roughly a few thousand lines of a busy terminal.
+const scrollbackBytes = 256 * 1024
+
+// scrollback keeps the most recent output of a session, up to a byte limit.
+// When it trims, it cuts at a UTF-8 rune boundary so a replay never starts
+// mid-character.
+type scrollback struct {
+	buf []byte
+	max int
+}
+
+func (b *scrollback) write(p []byte) {
+	limit := b.max
+	if limit <= 0 {
+		limit = scrollbackBytes
+	}
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - limit; over > 0 {
+		for over < len(b.buf) && !utf8.RuneStart(b.buf[over]) {
+			over++
+		}
+		b.buf = append(b.buf[:0:0], b.buf[over:]...)
+	}
+}
+
+// snapshot returns a copy of the buffered output.
+func (b *scrollback) snapshot() []byte {
+	return append([]byte(nil), b.buf...)
+}
@@ -250,3 +250,25 @@ func TestWaitCmdIdempotent(t *testing.T) {
 		t.Fatalf("second waitCmd: %v", err)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Manager.IdleDurations – idle tracking
+// ---------------------------------------------------------------------------
+
+func TestManagerIdleDurationsTracksInput(t *testing.T) {
+	m := NewManager()
+	s := &Session{ID: "idle"}
+	m.sessions[s.ID] = s
+
+	now := time.Now()
+	s.lastInputUnixNano.Store(now.Add(-10 * time.Minute).UnixNano())
+	if got := m.IdleDurations(now)["idle"]; got != 10*time.Minute {
+		t.Fatalf("idle = %v, want 10m", got)
+	}
+
+	// A write counts as activity even when it fails (no PTY here).
+	_ = m.WriteToSession("idle", []byte("ls\n"))
+	if got := m.IdleDurations(time.Now())["idle"]; got > time.Second {
+		t.Fatalf("idle after write = %v, want ~0", got)
+	}
+}
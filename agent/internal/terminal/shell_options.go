@@ -0,0 +1,204 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// SessionOptions selects what a terminal session launches. Zero values keep
+// the defaults: the OS default shell, the agent's working directory and its
+// environment.
+type SessionOptions struct {
+	// Shell is a shell name (pwsh, powershell, cmd, bash, zsh, sh) or an
+	// absolute path to a shell executable.
+	Shell string
+	// Dir is the absolute working directory to start in.
+	Dir string
+	// Env holds extra environment variables, applied over the agent's.
+	Env map[string]string
+	// RunAs is the account to run the shell as; nil runs it as the agent
+	// (SYSTEM/root). The manager takes ownership, including on failure.
+	RunAs *Credential
+}
+
+// prepareSessionOptions validates opts and returns the shell to launch and
+// the extra environment entries.
+func prepareSessionOptions(opts SessionOptions) (string, []string, error) {
+	shell, err := ResolveShell(opts.Shell)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := validateSessionDir(opts.Dir); err != nil {
+		return "", nil, err
+	}
+	extraEnv, err := sessionEnvList(opts.Env)
+	if err != nil {
+		return "", nil, err
+	}
+	return shell, extraEnv, nil
+}
+
+// splitWindowsAccount splits "DOMAIN\\user" into user and domain. A bare
+// name is a local account ("."); a UPN (user@domain) is passed whole with no
+// domain, as LogonUser expects.
+func splitWindowsAccount(account string) (user, domain string) {
+	if d, u, ok := strings.Cut(account, `\`); ok {
+		return u, d
+	}
+	if strings.Contains(account, "@") {
+		return account, ""
+	}
+	return account, "."
+}
+
+// name is the account name for logs; "" for the agent's own account.
+func (c *Credential) name() string {
+	if c == nil {
+		return ""
+	}
+	return c.Username
+}
+
+const (
+	maxSessionEnvVars     = 64
+	maxSessionEnvBytes    = 32 * 1024
+	maxSessionEnvValueLen = 8 * 1024
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// shellNames maps the shell names a terminal_start may ask for to the
+// executables searched for, per OS.
+var shellNames = map[string]map[string][]string{
+	"windows": {
+		"pwsh":       {"pwsh.exe"},
+		"powershell": {"powershell.exe"},
+		"cmd":        {"cmd.exe"},
+		"bash":       {"bash.exe"},
+	},
+	"unix": {
+		"bash": {"bash"},
+		"zsh":  {"zsh"},
+		"sh":   {"sh"},
+		"pwsh": {"pwsh"},
+	},
+}
+
+// ResolveShell turns a requested shell into the executable to launch: the OS
+// default when empty, a PATH lookup for a known name, or an absolute path
+// that must exist.
+func ResolveShell(shell string) (string, error) {
+	shell = strings.TrimSpace(shell)
+	if shell == "" {
+		return getDefaultShell(), nil
+	}
+	if filepath.IsAbs(shell) {
+		if st, err := os.Stat(shell); err != nil || st.IsDir() {
+			return "", fmt.Errorf("shell %q not found", shell)
+		}
+		return shell, nil
+	}
+
+	osKey := "unix"
+	if runtime.GOOS == "windows" {
+		osKey = "windows"
+	}
+	name := strings.TrimSuffix(strings.ToLower(shell), ".exe")
+	candidates, ok := shellNames[osKey][name]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell %q", shell)
+	}
+	for _, c := range candidates {
+		if path, err := exec.LookPath(c); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("shell %q is not installed", shell)
+}
+
+// validateSessionDir checks that a requested working directory exists.
+func validateSessionDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if !filepath.IsAbs(dir) {
+		return fmt.Errorf("working directory %q must be an absolute path", dir)
+	}
+	st, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("working directory %q: %w", dir, err)
+	}
+	if !st.IsDir() {
+		return fmt.Errorf("working directory %q is not a directory", dir)
+	}
+	return nil
+}
+
+// sessionEnvList validates extra environment variables and returns them as
+// sorted KEY=VALUE entries.
+func sessionEnvList(env map[string]string) ([]string, error) {
+	if len(env) > maxSessionEnvVars {
+		return nil, fmt.Errorf("too many environment variables: %d (max %d)", len(env), maxSessionEnvVars)
+	}
+	list := make([]string, 0, len(env))
+	total := 0
+	for k, v := range env {
+		if !envNamePattern.MatchString(k) {
+			return nil, fmt.Errorf("invalid environment variable name %q", k)
+		}
+		if len(v) > maxSessionEnvValueLen || strings.ContainsRune(v, 0) {
+			return nil, fmt.Errorf("invalid value for environment variable %s", k)
+		}
+		total += len(k) + len(v) + 1
+		list = append(list, k+"="+v)
+	}
+	if total > maxSessionEnvBytes {
+		return nil, fmt.Errorf("environment variables too large: %d bytes (max %d)", total, maxSessionEnvBytes)
+	}
+	sort.Strings(list)
+	return list, nil
+}
+
+// dedupEnv keeps the last entry for each variable, in first-seen order.
+// os/exec does this itself; callers building a raw environment block (the
+// ConPTY path) must, or the later override is ignored. Windows variable
+// names are case-insensitive.
+func dedupEnv(env []string, caseInsensitive bool) []string {
+	last := make(map[string]int, len(env))
+	keyOf := func(kv string) string {
+		// Windows per-drive entries ("=C:=C:\\dir") have a leading '='
+		// that is part of the name.
+		start := 0
+		if strings.HasPrefix(kv, "=") {
+			start = 1
+		}
+		k := kv
+		if i := strings.IndexByte(kv[start:], '='); i >= 0 {
+			k = kv[:start+i]
+		}
+		if caseInsensitive {
+			k = strings.ToUpper(k)
+		}
+		return k
+	}
+	for i, kv := range env {
+		last[keyOf(kv)] = i
+	}
+	out := make([]string, 0, len(last))
+	seen := make(map[string]bool, len(last))
+	for _, kv := range env {
+		k := keyOf(kv)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, env[last[k]])
+	}
+	return out
+}
@@ -0,0 +1,138 @@
+package terminal
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResolveShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix shell names")
+	}
+	got, err := ResolveShell("sh")
+	if err != nil || !filepath.IsAbs(got) || filepath.Base(got) != "sh" {
+		t.Fatalf("ResolveShell(sh) = %q, %v", got, err)
+	}
+	if got, err := ResolveShell("/bin/sh"); err != nil || got != "/bin/sh" {
+		t.Fatalf("ResolveShell(/bin/sh) = %q, %v", got, err)
+	}
+	if got, err := ResolveShell(""); err != nil || got != getDefaultShell() {
+		t.Fatalf("ResolveShell(\"\") = %q, %v", got, err)
+	}
+	for _, bad := range []string{"fish; rm -rf /", "cmd", "/nonexistent/shell", "../sh"} {
+		if _, err := ResolveShell(bad); err == nil {
+			t.Fatalf("ResolveShell(%q) should fail", bad)
+		}
+	}
+}
+
+func TestSessionEnvList(t *testing.T) {
+	got, err := sessionEnvList(map[string]string{"B": "2", "A_1": "one=1"})
+	if err != nil || !reflect.DeepEqual(got, []string{"A_1=one=1", "B=2"}) {
+		t.Fatalf("sessionEnvList = %v, %v", got, err)
+	}
+	for _, bad := range []map[string]string{
+		{"1ABC": "x"},
+		{"A-B": "x"},
+		{"A": "nul\x00"},
+		{"A": strings.Repeat("x", maxSessionEnvValueLen+1)},
+	} {
+		if _, err := sessionEnvList(bad); err == nil {
+			t.Fatalf("sessionEnvList(%v) should fail", bad)
+		}
+	}
+}
+
+func TestValidateSessionDir(t *testing.T) {
+	if err := validateSessionDir(t.TempDir()); err != nil {
+		t.Fatalf("temp dir rejected: %v", err)
+	}
+	if err := validateSessionDir("relative/dir"); err == nil {
+		t.Fatal("relative dir should be rejected")
+	}
+	if err := validateSessionDir(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("missing dir should be rejected")
+	}
+}
+
+func TestDedupEnvKeepsLastValue(t *testing.T) {
+	env := []string{"=C:=C:\\x", "PATH=a", "=D:=D:\\y", "Path=b", "TERM=dumb", "TERM=xterm"}
+	want := []string{"=C:=C:\\x", "Path=b", "=D:=D:\\y", "TERM=xterm"}
+	if got := dedupEnv(env, true); !reflect.DeepEqual(got, want) {
+		t.Fatalf("dedupEnv = %v, want %v", got, want)
+	}
+	if got := dedupEnv([]string{"PATH=a", "Path=b"}, false); len(got) != 2 {
+		t.Fatalf("case-sensitive dedupEnv = %v", got)
+	}
+}
+
+func TestStartSessionWithOptionsAppliesDirAndEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("PTY test requires Unix/macOS")
+	}
+	dir := t.TempDir()
+	var mu sync.Mutex
+	var out strings.Builder
+	m := NewManager()
+	err := m.StartSessionWithOptions("opts", 80, 24, SessionOptions{
+		Shell: "sh",
+		Dir:   dir,
+		Env:   map[string]string{"BREEZE_TEST_VAR": "injected"},
+	}, func(p []byte) {
+		mu.Lock()
+		out.Write(p)
+		mu.Unlock()
+	}, nil)
+	if err != nil {
+		t.Fatalf("StartSessionWithOptions: %v", err)
+	}
+	defer m.StopSession("opts")
+
+	if err := m.WriteToSession("opts", []byte("echo \"v=$BREEZE_TEST_VAR\"; pwd\n")); err != nil {
+		t.Fatalf("WriteToSession: %v", err)
+	}
+	resolved, _ := filepath.EvalSymlinks(dir)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		s := out.String()
+		mu.Unlock()
+		if strings.Contains(s, "v=injected") && (strings.Contains(s, dir) || strings.Contains(s, resolved)) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	t.Fatalf("shell output missing env or cwd: %q", out.String())
+}
+
+func TestStartSessionWithOptionsRejectsBadOptions(t *testing.T) {
+	m := NewManager()
+	if err := m.StartSessionWithOptions("bad", 80, 24, SessionOptions{Shell: "nope"}, nil, nil); err == nil {
+		t.Fatal("unknown shell should be rejected")
+	}
+	if err := m.StartSessionWithOptions("bad", 80, 24, SessionOptions{Dir: "relative"}, nil, nil); err == nil {
+		t.Fatal("relative dir should be rejected")
+	}
+	if m.GetSessionCount() != 0 {
+		t.Fatal("rejected sessions must not be registered")
+	}
+}
+
+func TestSplitWindowsAccount(t *testing.T) {
+	for in, want := range map[string][2]string{
+		`CORP\alice`:     {"alice", "CORP"},
+		"alice@corp.com": {"alice@corp.com", ""},
+		"alice":          {"alice", "."},
+	} {
+		if u, d := splitWindowsAccount(in); u != want[0] || d != want[1] {
+			t.Fatalf("splitWindowsAccount(%q) = %q, %q; want %q", in, u, d, want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/breeze-rmm/agent/internal/logging"
@@ -46,6 +47,35 @@ type Session struct {
 	onOutput func(data []byte)
 	onClose  func(err error)
 
+	// lastInputUnixNano is when the viewer last typed into or resized the
+	// session. Shell output deliberately doesn't count: a forgotten
+	// `tail -f` must still go idle.
+	lastInputUnixNano atomic.Int64
+
+	// Output goes through emit: it is always kept in the scrollback and
+	// forwarded unless the viewer detached (reattach.go). outMu guards
+	// these fields and orders live output after a reattach replay.
+	outMu      sync.Mutex
+	scrollback scrollback
+	detached   bool
+	detachGen  uint64
+
+	// rec records the session when the manager has a RecordDir
+	// (recording.go); nil otherwise.
+	rec         *recorder
+	recOnce     sync.Once
+	onRecording func(RecordingInfo)
+
+	// Dir is the working directory the shell starts in ("" inherits the
+	// agent's); extraEnv holds the requested KEY=VALUE variables, applied
+	// last.
+	Dir      string
+	extraEnv []string
+
+	// runAs is the account the shell runs as; nil runs it as the agent
+	// (SYSTEM/root). The session owns it and releases it on close.
+	runAs *Credential
+
 	// Windows ConPTY handles (zero on Unix/macOS).
 	hConPty uintptr // HPCON pseudo console handle
 	hProc   uintptr // child process handle
@@ -56,6 +86,15 @@ type Session struct {
 type Manager struct {
 	sessions map[string]*Session
 	mu       sync.RWMutex
+
+	// RecordDir, when set, records every new session as an asciinema cast
+	// file in this directory, and OnRecordingClosed receives each finished
+	// recording. RecordInput adds viewer input to the recording; output
+	// alone is recorded otherwise. Set all three before the first session
+	// starts.
+	RecordDir         string
+	RecordInput       bool
+	OnRecordingClosed func(RecordingInfo)
 }
 
 // NewManager creates a new terminal session manager
@@ -67,26 +106,38 @@ func NewManager() *Manager {
 
 // StartSession starts a new terminal session
 func (m *Manager) StartSession(id string, cols, rows uint16, shell string, onOutput func(data []byte), onClose func(err error)) error {
+	return m.StartSessionWithOptions(id, cols, rows, SessionOptions{Shell: shell}, onOutput, onClose)
+}
+
+// StartSessionWithOptions starts a new terminal session with a chosen shell,
+// working directory and extra environment (shell_options.go).
+func (m *Manager) StartSessionWithOptions(id string, cols, rows uint16, opts SessionOptions, onOutput func(data []byte), onClose func(err error)) error {
+	shell, extraEnv, err := prepareSessionOptions(opts)
+	if err != nil {
+		opts.RunAs.Release()
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Check if session already exists
 	if _, exists := m.sessions[id]; exists {
+		opts.RunAs.Release()
 		return fmt.Errorf("session %s already exists", id)
 	}
 
-	// Determine shell to use
-	if shell == "" {
-		shell = getDefaultShell()
-	}
-
 	session := &Session{
 		ID:       id,
 		Cols:     cols,
 		Rows:     rows,
 		Shell:    shell,
+		Dir:      opts.Dir,
+		extraEnv: extraEnv,
+		runAs:    opts.RunAs,
 		onOutput: onOutput,
 	}
+	session.touch()
 	session.onClose = func(err error) {
 		m.removeSessionIfCurrent(id, session)
 		if onClose != nil {
@@ -96,12 +147,29 @@ func (m *Manager) StartSession(id string, cols, rows uint16, shell string, onOut
 
 	m.sessions[id] = session
 
+	if m.RecordDir != "" {
+		rec, err := newRecorder(m.RecordDir, id, cols, rows, shell, time.Now())
+		if err != nil {
+			log.Error("session recording unavailable, continuing unrecorded", "sessionId", id, "error", err.Error())
+		} else {
+			rec.info.User = opts.RunAs.name()
+			rec.recordInput = m.RecordInput
+			session.rec = rec
+			session.onRecording = m.OnRecordingClosed
+		}
+	}
+
 	// Start the PTY (platform-specific)
 	if err := session.start(); err != nil {
 		delete(m.sessions, id)
+		session.runAs.Release()
+		if session.rec != nil {
+			session.rec.close()
+			os.Remove(session.rec.info.Path)
+		}
 		return fmt.Errorf("failed to start PTY: %w", err)
 	}
-	log.Info("session started", "sessionId", id, "shell", shell, "cols", cols, "rows", rows)
+	log.Info("session started", "sessionId", id, "shell", shell, "user", opts.RunAs.name(), "dir", opts.Dir, "extraEnv", len(extraEnv), "cols", cols, "rows", rows)
 
 	return nil
 }
@@ -116,6 +184,7 @@ func (m *Manager) WriteToSession(id string, data []byte) error {
 		return fmt.Errorf("session %s not found", id)
 	}
 
+	session.touch()
 	return session.write(data)
 }
 
@@ -129,7 +198,12 @@ func (m *Manager) ResizeSession(id string, cols, rows uint16) error {
 		return fmt.Errorf("session %s not found", id)
 	}
 
-	return session.resize(cols, rows)
+	session.touch()
+	if err := session.resize(cols, rows); err != nil {
+		return err
+	}
+	session.rec.resize(cols, rows)
+	return nil
 }
 
 // StopSession stops and removes a terminal session
@@ -163,6 +237,17 @@ func (m *Manager) GetSessionCount() int {
 	return len(m.sessions)
 }
 
+// IdleDurations reports how long each session has gone without viewer input.
+func (m *Manager) IdleDurations(now time.Time) map[string]time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	idle := make(map[string]time.Duration, len(m.sessions))
+	for id, s := range m.sessions {
+		idle[id] = now.Sub(time.Unix(0, s.lastInputUnixNano.Load()))
+	}
+	return idle
+}
+
 // CloseAll closes all terminal sessions
 func (m *Manager) CloseAll() {
 	m.mu.Lock()
@@ -189,6 +274,11 @@ func (m *Manager) removeSessionIfCurrent(id string, target *Session) {
 	delete(m.sessions, id)
 }
 
+// touch records viewer activity for the idle timeout.
+func (s *Session) touch() {
+	s.lastInputUnixNano.Store(time.Now().UnixNano())
+}
+
 // write writes data to the session's PTY or stdin pipe.
 //
 // The blocking write deliberately happens OUTSIDE s.mu: a PTY write can block
@@ -205,6 +295,8 @@ func (s *Session) write(data []byte) error {
 		return fmt.Errorf("session is closed")
 	}
 
+	s.rec.input(data)
+
 	// Forward control characters as signals to the shell process.
 	// This runs on all platforms before writing data to the pipe/PTY.
 	for _, b := range data {
@@ -291,6 +383,8 @@ func (s *Session) close() error {
 	// Kill and wait for process — platform-specific
 	s.killProcess()
 	s.waitCmd()
+	s.finishRecording()
+	s.runAs.Release()
 
 	log.Debug("session closed", "sessionId", s.ID)
 
@@ -311,6 +405,7 @@ func (s *Session) waitCmd() error {
 
 func (s *Session) notifyClosed(err error) {
 	s.endOnce.Do(func() {
+		s.finishRecording()
 		if s.onClose != nil {
 			s.onClose(err)
 		}
@@ -324,7 +419,7 @@ func (s *Session) readLoop() {
 	defer observability.Recoverer("terminal.readLoop")
 	log.Info("readLoop started", "sessionId", s.ID)
 
-	err := streamUTF8(s.pty, s.onOutput, func(n int) {
+	err := streamUTF8(s.pty, s.emit, func(n int) {
 		log.Info("readLoop first data", "sessionId", s.ID, "bytes", n)
 	})
 	if err != nil && err != io.EOF {
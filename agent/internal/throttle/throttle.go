@@ -0,0 +1,362 @@
+// Package throttle keeps the agent's background work (inventory collectors
+// and patch providers) from competing with the user for CPU and disk.
+//
+// Every background child process is started at low priority (nice 10 plus
+// idle I/O class on Linux, the background band on macOS, below-normal
+// priority inside a CPU-rate-capped Job Object on Windows). On top of that a
+// governor samples the agent's total CPU use — its own process plus the
+// background children it has reaped — over a sliding window, and delays new
+// background commands while the configured budget is exceeded. The same
+// samples feed the compliance counters reported with every heartbeat.
+package throttle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/logging"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+var log = logging.L("throttle")
+
+const (
+	// DefaultCPUBudgetPercent is the share of total machine CPU (all cores)
+	// background work may use, averaged over the sampling window.
+	DefaultCPUBudgetPercent = 10
+	minCPUBudgetPercent     = 1
+	maxCPUBudgetPercent     = 100
+
+	// sampleWindow is the averaging window for budget decisions.
+	sampleWindow = 60 * time.Second
+	// minSampleSpacing rate-limits the (cheap, but not free) CPU probes.
+	minSampleSpacing = 5 * time.Second
+	// maxAdmissionDelay bounds how long one command waits for budget, so a
+	// noisy agent degrades to "slow" rather than "never collects".
+	maxAdmissionDelay = 30 * time.Second
+	admissionPoll     = time.Second
+)
+
+// Limits is the server-configurable throttle policy.
+type Limits struct {
+	CPUBudgetPercent int  `json:"cpu_budget_percent"`
+	LowPriority      bool `json:"low_priority"`
+}
+
+// DefaultLimits is what the agent configures at startup, until the server
+// sends resource_limits.
+func DefaultLimits() Limits {
+	return Limits{CPUBudgetPercent: DefaultCPUBudgetPercent, LowPriority: true}
+}
+
+// unconfiguredLimits apply before Configure is called, so code that shares
+// the collectors outside the agent service (tests, CLI tools) runs
+// unthrottled.
+func unconfiguredLimits() Limits {
+	return Limits{CPUBudgetPercent: maxCPUBudgetPercent}
+}
+
+// ParseLimits decodes a resource_limits config block. Missing fields keep
+// their defaults and the budget is clamped to [1, 100].
+func ParseLimits(raw any) (Limits, bool) {
+	limits := DefaultLimits()
+	data, err := json.Marshal(raw)
+	if err != nil {
+		log.Warn("failed to marshal resource limits", "error", err.Error())
+		return limits, false
+	}
+	var wire struct {
+		CPUBudgetPercent      *int  `json:"cpu_budget_percent"`
+		CPUBudgetPercentCamel *int  `json:"cpuBudgetPercent"`
+		LowPriority           *bool `json:"low_priority"`
+		LowPriorityCamel      *bool `json:"lowPriority"`
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		log.Warn("failed to parse resource limits", "error", err.Error())
+		return limits, false
+	}
+	if v := firstNonNil(wire.CPUBudgetPercent, wire.CPUBudgetPercentCamel); v != nil {
+		limits.CPUBudgetPercent = clampBudget(*v)
+	}
+	if v := firstNonNil(wire.LowPriority, wire.LowPriorityCamel); v != nil {
+		limits.LowPriority = *v
+	}
+	return limits, true
+}
+
+func firstNonNil[T any](values ...*T) *T {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func clampBudget(percent int) int {
+	if percent < minCPUBudgetPercent {
+		return minCPUBudgetPercent
+	}
+	if percent > maxCPUBudgetPercent {
+		return maxCPUBudgetPercent
+	}
+	return percent
+}
+
+// Stats is the compliance evidence reported with each heartbeat.
+type Stats struct {
+	CPUBudgetPercent  int     `json:"cpuBudgetPercent"`
+	LowPriority       bool    `json:"lowPriority"`
+	WindowSeconds     int     `json:"windowSeconds"`
+	CPUPercent        float64 `json:"cpuPercent"`
+	PeakCPUPercent    float64 `json:"peakCpuPercent"`
+	CompliantSamples  int64   `json:"compliantSamples"`
+	OverBudgetSamples int64   `json:"overBudgetSamples"`
+	Commands          int64   `json:"commands"`
+	ThrottledCommands int64   `json:"throttledCommands"`
+	ThrottledMs       int64   `json:"throttledMs"`
+	CPUCapEnforced    bool    `json:"cpuCapEnforced"`
+}
+
+type cpuSample struct {
+	at  time.Time
+	cpu time.Duration
+}
+
+// governor tracks CPU usage against the budget. selfCPU and numCPU are
+// injectable for tests.
+type governor struct {
+	mu       sync.Mutex
+	limits   Limits
+	selfCPU  func() time.Duration
+	numCPU   int
+	now      func() time.Time
+	sleep    func(context.Context, time.Duration) bool
+	childCPU time.Duration
+	samples  []cpuSample
+	stats    Stats
+}
+
+func newGovernor(selfCPU func() time.Duration, numCPU int) *governor {
+	if numCPU < 1 {
+		numCPU = 1
+	}
+	limits := unconfiguredLimits()
+	return &governor{
+		limits:  limits,
+		selfCPU: selfCPU,
+		numCPU:  numCPU,
+		now:     time.Now,
+		sleep:   sleepContext,
+		stats: Stats{
+			CPUBudgetPercent: limits.CPUBudgetPercent,
+			LowPriority:      limits.LowPriority,
+			WindowSeconds:    int(sampleWindow / time.Second),
+		},
+	}
+}
+
+var defaultGovernor = newGovernor(agentProcessCPU, runtime.NumCPU())
+
+// Configure replaces the active limits. Safe to call at any time; commands
+// already running keep the priority they were started with.
+func Configure(limits Limits) {
+	limits.CPUBudgetPercent = clampBudget(limits.CPUBudgetPercent)
+	enforced := configurePlatformCap(limits)
+	defaultGovernor.mu.Lock()
+	defaultGovernor.limits = limits
+	defaultGovernor.stats.CPUBudgetPercent = limits.CPUBudgetPercent
+	defaultGovernor.stats.LowPriority = limits.LowPriority
+	defaultGovernor.stats.CPUCapEnforced = enforced
+	defaultGovernor.mu.Unlock()
+}
+
+// Current returns the active limits.
+func Current() Limits {
+	defaultGovernor.mu.Lock()
+	defer defaultGovernor.mu.Unlock()
+	return defaultGovernor.limits
+}
+
+// Snapshot takes a fresh sample and returns the compliance counters.
+func Snapshot() Stats {
+	return defaultGovernor.snapshot()
+}
+
+func (g *governor) snapshot() Stats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sampleLocked(true)
+	return g.stats
+}
+
+// sampleLocked records a CPU sample (unless one was taken within
+// minSampleSpacing and force is false) and returns the windowed usage as a
+// percentage of total machine CPU.
+func (g *governor) sampleLocked(force bool) float64 {
+	now := g.now()
+	if n := len(g.samples); n > 0 && !force && now.Sub(g.samples[n-1].at) < minSampleSpacing {
+		return g.stats.CPUPercent
+	}
+	g.samples = append(g.samples, cpuSample{at: now, cpu: g.selfCPU() + g.childCPU})
+
+	// Keep the newest sample at or beyond the window edge as the baseline.
+	cut := 0
+	for cut+1 < len(g.samples) && now.Sub(g.samples[cut+1].at) >= sampleWindow {
+		cut++
+	}
+	g.samples = g.samples[cut:]
+
+	first, last := g.samples[0], g.samples[len(g.samples)-1]
+	elapsed := last.at.Sub(first.at)
+	if elapsed <= 0 {
+		return g.stats.CPUPercent
+	}
+	usage := 100 * float64(last.cpu-first.cpu) / (float64(elapsed) * float64(g.numCPU))
+	g.stats.CPUPercent = usage
+	if usage > g.stats.PeakCPUPercent {
+		g.stats.PeakCPUPercent = usage
+	}
+	if usage > float64(g.limits.CPUBudgetPercent) {
+		g.stats.OverBudgetSamples++
+	} else {
+		g.stats.CompliantSamples++
+	}
+	return usage
+}
+
+// admit blocks while the agent is over its CPU budget, up to
+// maxAdmissionDelay or until ctx is done. When ctx carries a deadline at
+// most half of the remaining time is spent waiting, so the command itself
+// still has room to run.
+func (g *governor) admit(ctx context.Context) {
+	start := g.now()
+	maxWait := maxAdmissionDelay
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = min(maxWait, time.Until(deadline)/2)
+	}
+	throttled := false
+	for {
+		g.mu.Lock()
+		usage := g.sampleLocked(false)
+		over := usage > float64(g.limits.CPUBudgetPercent)
+		g.mu.Unlock()
+		if !over || g.now().Sub(start) >= maxWait {
+			break
+		}
+		throttled = true
+		if !g.sleep(ctx, admissionPoll) {
+			break
+		}
+	}
+
+	g.mu.Lock()
+	g.stats.Commands++
+	if throttled {
+		g.stats.ThrottledCommands++
+		g.stats.ThrottledMs += g.now().Sub(start).Milliseconds()
+	}
+	g.mu.Unlock()
+}
+
+func (g *governor) recordChild(state *os.ProcessState) {
+	if state == nil {
+		return
+	}
+	g.mu.Lock()
+	g.childCPU += state.UserTime() + state.SystemTime()
+	g.mu.Unlock()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+func agentProcessCPU() time.Duration {
+	proc, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return 0
+	}
+	times, err := proc.Times()
+	if err != nil {
+		return 0
+	}
+	return time.Duration((times.User + times.System) * float64(time.Second))
+}
+
+// Admit waits for CPU budget before background work that does not spawn a
+// process (or before creating a command's timeout context, so the wait
+// does not eat into it).
+func Admit(ctx context.Context) {
+	defaultGovernor.admit(ctx)
+}
+
+// Start starts cmd as background work: at low priority when configured and,
+// on Windows, inside the CPU-capped job. Call Admit first.
+func Start(cmd *exec.Cmd) error {
+	lowPriority := Current().LowPriority
+	if lowPriority {
+		preparePlatform(cmd)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if lowPriority {
+		lowerPlatform(cmd)
+	}
+	return nil
+}
+
+// Wait waits for a command started with Start and accounts its CPU time.
+func Wait(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+	defaultGovernor.recordChild(cmd.ProcessState)
+	return err
+}
+
+// Run is exec.Cmd.Run for background work.
+func Run(cmd *exec.Cmd) error {
+	if err := Start(cmd); err != nil {
+		return err
+	}
+	return Wait(cmd)
+}
+
+// Output is exec.Cmd.Output for background work, including the captured
+// stderr on *exec.ExitError.
+func Output(cmd *exec.Cmd) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	var stderr *bytes.Buffer
+	if cmd.Stderr == nil {
+		stderr = &bytes.Buffer{}
+		cmd.Stderr = stderr
+	}
+	err := Run(cmd)
+	if exitErr, ok := err.(*exec.ExitError); ok && stderr != nil {
+		exitErr.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput is exec.Cmd.CombinedOutput for background work.
+func CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := Run(cmd)
+	return out.Bytes(), err
+}
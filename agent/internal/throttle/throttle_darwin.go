@@ -0,0 +1,30 @@
+//go:build darwin
+
+package throttle
+
+import (
+	"os/exec"
+
+	"golang.org/x/sys/unix"
+)
+
+// setpriority(2) on macOS: PRIO_DARWIN_BG moves a process into the
+// background band, which lowers its CPU priority and throttles its disk and
+// network I/O.
+const (
+	prioDarwinProcess = 4
+	prioDarwinBG      = 0x1000
+)
+
+func preparePlatform(cmd *exec.Cmd) {}
+
+func lowerPlatform(cmd *exec.Cmd) {
+	pid := cmd.Process.Pid
+	if err := unix.Setpriority(prioDarwinProcess, pid, prioDarwinBG); err != nil {
+		log.Debug("setpriority PRIO_DARWIN_BG failed", "pid", pid, "error", err.Error())
+	}
+}
+
+// configurePlatformCap: macOS has no hard CPU cap for arbitrary processes;
+// the budget is enforced by admission control only.
+func configurePlatformCap(Limits) bool { return false }
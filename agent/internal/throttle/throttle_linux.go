@@ -0,0 +1,38 @@
+//go:build linux
+
+package throttle
+
+import (
+	"os/exec"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	backgroundNice = 10
+
+	// ioprio_set(2): IOPRIO_WHO_PROCESS, and the idle scheduling class
+	// (served only when no other process wants the disk).
+	ioprioWhoProcess = 1
+	ioprioClassIdle  = 3
+	ioprioClassShift = 13
+)
+
+func preparePlatform(cmd *exec.Cmd) {}
+
+// lowerPlatform renices the freshly started child and moves it to the idle
+// I/O class. Descendants it spawns inherit both. Best-effort: a failure
+// leaves the command at normal priority rather than failing it.
+func lowerPlatform(cmd *exec.Cmd) {
+	pid := cmd.Process.Pid
+	if err := unix.Setpriority(unix.PRIO_PROCESS, pid, backgroundNice); err != nil {
+		log.Debug("setpriority failed", "pid", pid, "error", err.Error())
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), ioprioClassIdle<<ioprioClassShift); errno != 0 {
+		log.Debug("ioprio_set failed", "pid", pid, "error", errno.Error())
+	}
+}
+
+// configurePlatformCap: Linux has no per-process CPU cap without cgroup
+// delegation, so the budget is enforced by admission control only.
+func configurePlatformCap(Limits) bool { return false }
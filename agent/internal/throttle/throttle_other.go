@@ -0,0 +1,11 @@
+//go:build !windows && !linux && !darwin
+
+package throttle
+
+import "os/exec"
+
+func preparePlatform(cmd *exec.Cmd) {}
+
+func lowerPlatform(cmd *exec.Cmd) {}
+
+func configurePlatformCap(Limits) bool { return false }
@@ -0,0 +1,119 @@
+package throttle
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// fakeClock drives a governor whose "agent" burns cpuPerSecond of CPU for
+// every second of wall time.
+type fakeClock struct {
+	now          time.Time
+	cpu          time.Duration
+	cpuPerSecond time.Duration
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.cpu += time.Duration(float64(c.cpuPerSecond) * d.Seconds())
+}
+
+func newTestGovernor(clock *fakeClock, numCPU, budget int) *governor {
+	g := newGovernor(func() time.Duration { return clock.cpu }, numCPU)
+	g.now = func() time.Time { return clock.now }
+	g.sleep = func(_ context.Context, d time.Duration) bool {
+		clock.advance(d)
+		return true
+	}
+	g.limits.CPUBudgetPercent = budget
+	return g
+}
+
+func TestParseLimits(t *testing.T) {
+	limits, ok := ParseLimits(map[string]any{"cpu_budget_percent": float64(250)})
+	if !ok || limits.CPUBudgetPercent != maxCPUBudgetPercent || !limits.LowPriority {
+		t.Errorf("got %+v, %v; want clamped budget with default low priority", limits, ok)
+	}
+	limits, ok = ParseLimits(map[string]any{"cpuBudgetPercent": float64(0), "lowPriority": false})
+	if !ok || limits.CPUBudgetPercent != minCPUBudgetPercent || limits.LowPriority {
+		t.Errorf("camelCase keys: got %+v, %v", limits, ok)
+	}
+	if _, ok := ParseLimits([]any{"nope"}); ok {
+		t.Error("non-object payload should be rejected")
+	}
+}
+
+func TestGovernorCompliance(t *testing.T) {
+	// 4 cores, agent burning 0.2 CPU-seconds per second = 5% of the machine.
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0), cpuPerSecond: 200 * time.Millisecond}
+	g := newTestGovernor(clock, 4, 10)
+
+	for i := 0; i < 6; i++ {
+		g.admit(context.Background())
+		clock.advance(10 * time.Second)
+	}
+	stats := g.snapshot()
+	if stats.CPUPercent < 4.9 || stats.CPUPercent > 5.1 {
+		t.Errorf("CPUPercent = %.2f, want ~5", stats.CPUPercent)
+	}
+	if stats.OverBudgetSamples != 0 || stats.CompliantSamples == 0 || stats.ThrottledCommands != 0 || stats.Commands != 6 {
+		t.Errorf("under-budget run should be fully compliant: %+v", stats)
+	}
+}
+
+func TestGovernorThrottlesWhenOverBudget(t *testing.T) {
+	// A reaped child that used 3 CPU-seconds in a 10s span puts a 1-core
+	// agent at 30% against a 10% budget; idle afterwards, the average drains
+	// and admission resumes before maxAdmissionDelay.
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	g := newTestGovernor(clock, 1, 10)
+	g.admit(context.Background())
+	clock.advance(10 * time.Second)
+	g.childCPU += 3 * time.Second
+
+	g.admit(context.Background())
+	stats := g.snapshot()
+	if stats.ThrottledCommands != 1 || stats.ThrottledMs <= 0 || stats.OverBudgetSamples == 0 {
+		t.Fatalf("expected the second command to be throttled: %+v", stats)
+	}
+	if stats.ThrottledMs >= maxAdmissionDelay.Milliseconds() {
+		t.Errorf("throttled %dms, want admission before the max delay", stats.ThrottledMs)
+	}
+	if stats.PeakCPUPercent < 25 {
+		t.Errorf("PeakCPUPercent = %.1f, want the spike recorded", stats.PeakCPUPercent)
+	}
+}
+
+func TestGovernorAdmissionDelayIsBounded(t *testing.T) {
+	// The agent itself is pegged at 100%: admission must still give up.
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0), cpuPerSecond: time.Second}
+	g := newTestGovernor(clock, 1, 10)
+	g.admit(context.Background())
+	clock.advance(10 * time.Second)
+
+	g.admit(context.Background())
+	if stats := g.snapshot(); stats.ThrottledMs < maxAdmissionDelay.Milliseconds() {
+		t.Errorf("ThrottledMs = %d, want the full %s wait", stats.ThrottledMs, maxAdmissionDelay)
+	}
+}
+
+func TestOutputAccountsChildCPU(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	before := defaultGovernor.childCPU
+	out, err := Output(exec.Command("sh", "-c", "echo hello; echo oops >&2; exit 3"))
+	if string(out) != "hello\n" {
+		t.Errorf("stdout = %q", out)
+	}
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 3 || string(exitErr.Stderr) != "oops\n" {
+		t.Fatalf("err = %#v, want exit 3 with captured stderr", err)
+	}
+	if defaultGovernor.childCPU < before {
+		t.Error("child CPU accounting went backwards")
+	}
+}
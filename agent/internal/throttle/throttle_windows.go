@@ -0,0 +1,100 @@
+//go:build windows
+
+package throttle
+
+import (
+	"fmt"
+	"os/exec"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// JOBOBJECT_CPU_RATE_CONTROL_INFORMATION (winnt.h). CpuRate is in 1/100ths
+// of a percent of total machine CPU; HARD_CAP makes the kernel stop
+// scheduling the job's threads once the rate is reached in an interval.
+const (
+	jobObjectCPURateControlEnable  = 0x1
+	jobObjectCPURateControlHardCap = 0x4
+)
+
+type jobObjectCPURateControlInformation struct {
+	ControlFlags uint32
+	CpuRate      uint32
+}
+
+// backgroundJob holds every background child. It is created on first
+// Configure and updated in place when the budget changes.
+var backgroundJob struct {
+	mu     sync.Mutex
+	handle windows.Handle
+}
+
+func preparePlatform(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= windows.BELOW_NORMAL_PRIORITY_CLASS
+}
+
+// lowerPlatform moves the started child into the CPU-capped job. The child
+// already runs at below-normal priority via its creation flags.
+func lowerPlatform(cmd *exec.Cmd) {
+	backgroundJob.mu.Lock()
+	job := backgroundJob.handle
+	backgroundJob.mu.Unlock()
+	if job == 0 {
+		return
+	}
+	proc, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		log.Debug("OpenProcess for job assignment failed", "pid", cmd.Process.Pid, "error", err.Error())
+		return
+	}
+	defer windows.CloseHandle(proc)
+	if err := windows.AssignProcessToJobObject(job, proc); err != nil {
+		log.Debug("AssignProcessToJobObject failed", "pid", cmd.Process.Pid, "error", err.Error())
+	}
+}
+
+// configurePlatformCap creates (or updates) the background job with a hard
+// CPU rate cap equal to the budget. Returns whether the cap is in force.
+func configurePlatformCap(limits Limits) bool {
+	backgroundJob.mu.Lock()
+	defer backgroundJob.mu.Unlock()
+
+	if backgroundJob.handle == 0 {
+		handle, err := windows.CreateJobObject(nil, nil)
+		if err != nil {
+			log.Warn("failed to create background job object", "error", err.Error())
+			return false
+		}
+		backgroundJob.handle = handle
+	}
+	if err := setJobCPURate(backgroundJob.handle, limits.CPUBudgetPercent); err != nil {
+		log.Warn("failed to set background CPU rate cap", "budgetPercent", limits.CPUBudgetPercent, "error", err.Error())
+		return false
+	}
+	return limits.CPUBudgetPercent < maxCPUBudgetPercent
+}
+
+func setJobCPURate(job windows.Handle, percent int) error {
+	info := jobObjectCPURateControlInformation{
+		ControlFlags: jobObjectCPURateControlEnable | jobObjectCPURateControlHardCap,
+		CpuRate:      uint32(percent * 100),
+	}
+	if percent >= maxCPUBudgetPercent {
+		info = jobObjectCPURateControlInformation{} // disable the cap
+	}
+	if _, err := windows.SetInformationJobObject(
+		job,
+		windows.JobObjectCpuRateControlInformation,
+		uintptr(unsafe.Pointer(&info)),
+		uint32(unsafe.Sizeof(info)),
+	); err != nil {
+		return fmt.Errorf("SetInformationJobObject: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package userhelper
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/breeze-rmm/agent/internal/ipc"
+)
+
+// chatDialogTimeoutSec closes an unanswered chat dialog so it does not
+// outlive the session by long.
+const chatDialogTimeoutSec = 300
+
+const chatDialogTitle = "Message from your technician"
+
+// showChatDialogFn is the platform dialog seam; tests swap it for a fake. It
+// shows the technician's message, blocks until the user replies, closes the
+// dialog or the countdown expires, and returns the reply (ok=false for none).
+var showChatDialogFn = showChatDialogOS
+
+// chatDialogMu keeps one chat dialog on screen at a time; lines that arrive
+// while one is open are shown after it closes.
+var chatDialogMu sync.Mutex
+
+// sendTechnicianChat is the desktop manager's OnChatMessage hook: it hands a
+// line the technician typed in the viewer to the service.
+func (c *Client) sendTechnicianChat(sessionID, text string) {
+	msg := ipc.ChatMessage{
+		SessionID:    sessionID,
+		From:         ipc.ChatFromTechnician,
+		Text:         text,
+		SentAtUnixMs: time.Now().UnixMilli(),
+	}
+	if err := c.conn.SendTyped("chat-"+sessionID, ipc.TypeChatMessage, msg); err != nil {
+		log.Warn("failed to send chat message via IPC", "session", sessionID, "error", err)
+	}
+}
+
+// handleChatMessage handles a chat line relayed by the service: technician
+// lines are shown to the user (consent-UI fallback helpers only), user
+// replies are delivered to the viewer (desktop helpers only).
+func (c *Client) handleChatMessage(env *ipc.Envelope) {
+	var msg ipc.ChatMessage
+	if err := json.Unmarshal(env.Payload, &msg); err != nil {
+		log.Warn("invalid chat_message payload", "error", err)
+		return
+	}
+	switch msg.From {
+	case ipc.ChatFromTechnician:
+		if !c.hasScope(ipc.ScopeConsentUIFallback) {
+			log.Warn("dropping chat message without consent UI scope", "session", msg.SessionID)
+			return
+		}
+		c.showTechnicianChat(msg)
+	case ipc.ChatFromUser:
+		if !c.hasScope("desktop") {
+			log.Warn("dropping chat reply without desktop scope", "session", msg.SessionID)
+			return
+		}
+		sentAt := time.UnixMilli(msg.SentAtUnixMs)
+		if err := c.desktopMgr.mgr.SendChatMessage(msg.SessionID, msg.From, msg.Text, sentAt); err != nil {
+			log.Warn("failed to deliver chat reply", "session", msg.SessionID, "error", err)
+		}
+	}
+}
+
+// showTechnicianChat shows one technician line and sends the user's reply,
+// if any, back to the service.
+func (c *Client) showTechnicianChat(msg ipc.ChatMessage) {
+	body := sanitizeChatText(msg.Text)
+	if body == "" {
+		return
+	}
+	chatDialogMu.Lock()
+	reply, ok := showChatDialogFn(chatDialogTitle, body, chatDialogTimeoutSec)
+	chatDialogMu.Unlock()
+
+	reply = truncateChatText(strings.TrimSpace(reply), ipc.MaxChatMessageBytes)
+	if !ok || reply == "" {
+		return
+	}
+	resp := ipc.ChatMessage{
+		SessionID:    msg.SessionID,
+		From:         ipc.ChatFromUser,
+		Text:         reply,
+		SentAtUnixMs: time.Now().UnixMilli(),
+	}
+	if err := c.conn.SendTyped("chat-"+msg.SessionID, ipc.TypeChatMessage, resp); err != nil {
+		log.Warn("failed to send chat reply via IPC", "session", msg.SessionID, "error", err)
+	}
+}
+
+// sanitizeChatText drops control characters other than line breaks and tabs
+// and bounds the length.
+func sanitizeChatText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+	return truncateChatText(strings.TrimSpace(s), ipc.MaxChatMessageBytes)
+}
+
+// truncateChatText cuts s to at most max bytes without splitting a rune.
+func truncateChatText(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	i := max
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return s[:i]
+}
+
+// parseAppleScriptDialogReply extracts the typed reply from an osascript
+// "display dialog ... default answer" result such as
+// "button returned:Reply, text returned:hi, gave up:false".
+func parseAppleScriptDialogReply(out string) (string, bool) {
+	out = strings.TrimRight(out, "\r\n")
+	if !strings.Contains(out, "button returned:Reply") {
+		return "", false
+	}
+	_, text, found := strings.Cut(out, "text returned:")
+	if !found {
+		return "", false
+	}
+	if i := strings.LastIndex(text, ", gave up:"); i >= 0 {
+		if text[i:] == ", gave up:true" {
+			return "", false
+		}
+		text = text[:i]
+	}
+	return text, true
+}
@@ -0,0 +1,31 @@
+//go:build darwin
+
+package userhelper
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// showChatDialogOS renders the message in an osascript dialog with a reply
+// field, the same no-cgo technique consent_dialog_darwin.go uses. Close maps
+// to osascript's cancel (-128) and "giving up after N" to the countdown;
+// both return no reply.
+func showChatDialogOS(title, body string, timeoutSec int) (string, bool) {
+	script := fmt.Sprintf(
+		`display dialog "%s" with title "%s" default answer "" buttons {"Close", "Reply"} default button "Reply" cancel button "Close"`,
+		escapeAppleScript(body), escapeAppleScript(title),
+	)
+	if timeoutSec > 0 {
+		script += fmt.Sprintf(" giving up after %d", timeoutSec)
+	}
+	out, err := exec.Command("osascript", "-e", script).CombinedOutput()
+	if err != nil {
+		if !strings.Contains(string(out), "-128") {
+			log.Warn("osascript chat dialog failed", "error", err.Error())
+		}
+		return "", false
+	}
+	return parseAppleScriptDialogReply(string(out))
+}
@@ -0,0 +1,43 @@
+//go:build linux
+
+package userhelper
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/breeze-rmm/agent/internal/ipc"
+)
+
+// zenityMarkupEscaper escapes the Pango markup zenity interprets in --text,
+// so a technician's "<b>" renders literally.
+var zenityMarkupEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// showChatDialogOS renders the message in a zenity entry dialog. zenity exit
+// codes: 0=Reply (entry on stdout), 1=Close, 5=timeout. When zenity cannot
+// run at all the message still reaches the user as a notification, without
+// a way to reply.
+func showChatDialogOS(title, body string, timeoutSec int) (string, bool) {
+	args := []string{
+		"--entry",
+		"--title", title,
+		"--text", zenityMarkupEscaper.Replace(body),
+		"--ok-label", "Reply",
+		"--cancel-label", "Close",
+		"--width", "420",
+	}
+	if timeoutSec > 0 {
+		args = append(args, fmt.Sprintf("--timeout=%d", timeoutSec))
+	}
+	out, err := exec.Command("zenity", args...).Output()
+	if err == nil {
+		return strings.TrimRight(string(out), "\n"), true
+	}
+	if _, ok := err.(*exec.ExitError); ok {
+		return "", false
+	}
+	log.Warn("zenity chat dialog failed, falling back to a notification", "error", err.Error())
+	showNotificationOS(ipc.NotifyRequest{Title: title, Body: body, Urgency: "normal"})
+	return "", false
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package userhelper
+
+import "github.com/breeze-rmm/agent/internal/ipc"
+
+// showChatDialogOS shows the message as a toast. Windows has no stock
+// input dialog to reply from; replies need the assist app's chat window,
+// which receives chat messages ahead of this helper when it is connected.
+func showChatDialogOS(title, body string, timeoutSec int) (string, bool) {
+	showNotificationOS(ipc.NotifyRequest{Title: title, Body: body, Urgency: "normal"})
+	return "", false
+}
@@ -0,0 +1,37 @@
+package userhelper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeChatText(t *testing.T) {
+	if got := sanitizeChatText("  line one\nline\ttwo\x07\x1b[31m  "); got != "line one\nline\ttwo[31m" {
+		t.Fatalf("sanitizeChatText = %q", got)
+	}
+	long := strings.Repeat("é", 1500) // 3000 bytes
+	got := sanitizeChatText(long)
+	if len(got) > 2000 || !strings.HasPrefix(long, got) || len(got)%2 != 0 {
+		t.Fatalf("sanitizeChatText split a rune or overran the limit: %d bytes", len(got))
+	}
+}
+
+func TestParseAppleScriptDialogReply(t *testing.T) {
+	tests := []struct {
+		out    string
+		want   string
+		wantOK bool
+	}{
+		{"button returned:Reply, text returned:on my way, gave up:false\n", "on my way", true},
+		{"button returned:Reply, text returned:a, b, gave up:false", "a, b", true},
+		{"button returned:Reply, text returned:yes", "yes", true},
+		{"button returned:, text returned:, gave up:true", "", false},
+		{"button returned:Close, text returned:nope", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseAppleScriptDialogReply(tt.out)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseAppleScriptDialogReply(%q) = %q, %v; want %q, %v", tt.out, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -59,6 +59,7 @@ type Client struct {
 	pendingMu  sync.Mutex
 	pending    map[string]chan *ipc.Envelope
 	sasReqSeq  atomic.Uint64
+	usage      usageMeterState
 
 	// authenticatedAt is set when the broker accepts the helper. Zero when
 	// the client has never completed auth on this Run(). Reset on each Run().
@@ -128,6 +129,7 @@ func (c *Client) Run() error {
 	if err := c.sendCapabilities(); err != nil {
 		log.Warn("failed to send capabilities", "error", err)
 	}
+	defer c.stopUsageMetering()
 
 	// Set SAS callback: route through IPC to the SCM service which can call SendSAS
 	c.desktopMgr.mgr.OnSASRequest = func() error {
@@ -143,6 +145,17 @@ func (c *Client) Run() error {
 		}
 	}
 
+	// Relay the technician's chat lines to the service, which shows them to
+	// the end user (chat.go).
+	c.desktopMgr.mgr.OnChatMessage = c.sendTechnicianChat
+
+	// Stream quality samples go to the service, which uploads them.
+	c.desktopMgr.mgr.OnQualitySample = func(sample ipc.DesktopQualitySample) {
+		if err := c.conn.SendTyped("desk-quality-"+sample.SessionID, ipc.TypeDesktopQuality, sample); err != nil {
+			log.Debug("failed to send desktop quality sample via IPC", "session", sample.SessionID, "error", err)
+		}
+	}
+
 	// Start TCC permission check loop (macOS only; no-op on other platforms).
 	// Skip capture probes while a live session is active to avoid contending
 	// with the streaming capturer in the same helper process.
@@ -395,6 +408,9 @@ func (c *Client) commandLoop() error {
 		case ipc.TypeBannerHide:
 			safeGo("banner_hide", func() { c.handleBannerHideEnvelope(env) })
 
+		case ipc.TypeChatMessage:
+			safeGo("chat_message", func() { c.handleChatMessage(env) })
+
 		case ipc.TypeTrayUpdate:
 			safeGo("tray_update", func() { c.handleTrayUpdate(env) })
 
@@ -404,6 +420,15 @@ func (c *Client) commandLoop() error {
 		case ipc.TypeDesktopStop:
 			safeGo("desktop_stop", func() { c.handleDesktopStop(env) })
 
+		case ipc.TypeDesktopJoin:
+			safeGo("desktop_join", func() { c.handleDesktopJoin(env) })
+
+		case ipc.TypeDesktopLeave:
+			safeGo("desktop_leave", func() { c.handleDesktopLeave(env) })
+
+		case ipc.TypeDesktopICE:
+			safeGo("desktop_ice_servers", func() { c.handleDesktopICEServers(env) })
+
 		case ipc.TypeDesktopInput:
 			safeGo("desktop_input", func() { c.handleDesktopInput(env) })
 
@@ -419,6 +444,9 @@ func (c *Client) commandLoop() error {
 		case ipc.TypeLaunchProcess:
 			safeGo("launch_process", func() { c.handleLaunchProcess(env) })
 
+		case ipc.TypeUsageMeteringConfig:
+			safeGo("usage_metering_config", func() { c.handleUsageMeteringConfig(env) })
+
 		case ipc.TypeSASResponse:
 			if !c.resolvePendingResponse(env) {
 				log.Warn("unsolicited sas_response from daemon", "id", env.ID)
@@ -914,9 +942,10 @@ func (c *Client) handleNotify(env *ipc.Envelope) {
 		return
 	}
 
-	delivered := showNotification(req)
+	delivered, action := showNotification(req)
 	if err := c.conn.SendTyped(env.ID, ipc.TypeNotifyResult, ipc.NotifyResult{
-		Delivered: delivered,
+		Delivered:     delivered,
+		ActionClicked: action,
 	}); err != nil {
 		log.Warn("failed to send notify result", "id", env.ID, "error", err)
 	}
@@ -1055,6 +1084,80 @@ func (c *Client) handleDesktopStop(env *ipc.Envelope) {
 	}
 }
 
+func (c *Client) handleDesktopJoin(env *ipc.Envelope) {
+	var req ipc.DesktopJoinRequest
+	if err := json.Unmarshal(env.Payload, &req); err != nil {
+		log.Warn("invalid desktop_join payload", "error", err)
+		if sendErr := c.conn.SendError(env.ID, ipc.TypeDesktopJoin, fmt.Sprintf("invalid payload: %v", err)); sendErr != nil {
+			log.Warn("failed to send desktop_join error", "error", sendErr)
+		}
+		return
+	}
+	if err := validateDesktopJoinRequest(&req); err != nil {
+		log.Warn("invalid desktop_join request", "error", err.Error())
+		if sendErr := c.conn.SendError(env.ID, ipc.TypeDesktopJoin, err.Error()); sendErr != nil {
+			log.Warn("failed to send desktop_join error", "error", sendErr)
+		}
+		return
+	}
+
+	log.Info("joining desktop session via IPC",
+		"sessionId", req.SessionID,
+		"viewerId", req.ViewerID,
+		"viewOnly", req.ViewOnly,
+	)
+
+	resp, err := c.desktopMgr.joinSession(&req)
+	if err != nil {
+		log.Warn("desktop session join failed", "sessionId", req.SessionID, "viewerId", req.ViewerID, "error", err.Error())
+		if sendErr := c.conn.SendError(env.ID, ipc.TypeDesktopJoin, err.Error()); sendErr != nil {
+			log.Warn("failed to send desktop_join error", "error", sendErr)
+		}
+		return
+	}
+
+	if err := c.conn.SendTyped(env.ID, ipc.TypeDesktopJoin, resp); err != nil {
+		log.Warn("failed to send desktop_join response", "error", err)
+		c.desktopMgr.leaveSession(req.SessionID, req.ViewerID)
+	}
+}
+
+func (c *Client) handleDesktopLeave(env *ipc.Envelope) {
+	var req ipc.DesktopLeaveRequest
+	if err := json.Unmarshal(env.Payload, &req); err != nil {
+		log.Warn("invalid desktop_leave payload", "error", err)
+		return
+	}
+	if err := validateDesktopLeaveRequest(&req); err != nil {
+		log.Warn("invalid desktop_leave request", "error", err.Error())
+		return
+	}
+
+	log.Info("detaching desktop viewer via IPC", "sessionId", req.SessionID, "viewerId", req.ViewerID)
+	left := c.desktopMgr.leaveSession(req.SessionID, req.ViewerID)
+
+	if err := c.conn.SendTyped(env.ID, ipc.TypeDesktopLeave, map[string]any{"left": left}); err != nil {
+		log.Warn("failed to send desktop_leave response", "error", err)
+	}
+}
+
+// handleDesktopICEServers applies TURN credentials the service refreshed
+// mid-session. Fire-and-forget: the service re-sends on its next refresh.
+func (c *Client) handleDesktopICEServers(env *ipc.Envelope) {
+	var req ipc.DesktopICEServersUpdate
+	if err := json.Unmarshal(env.Payload, &req); err != nil {
+		log.Warn("invalid desktop_ice_servers payload", "error", err)
+		return
+	}
+	if err := validateDesktopICEServersUpdate(&req); err != nil {
+		log.Warn("invalid desktop_ice_servers request", "error", err.Error())
+		return
+	}
+	if err := c.desktopMgr.updateICEServers(&req); err != nil {
+		log.Warn("failed to update desktop ICE servers", "sessionId", req.SessionID, "error", err.Error())
+	}
+}
+
 func (c *Client) handleDesktopInput(env *ipc.Envelope) {
 	log.Debug("desktop_input received (not yet implemented)")
 }
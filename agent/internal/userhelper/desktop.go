@@ -8,12 +8,15 @@ import (
 
 	"github.com/breeze-rmm/agent/internal/ipc"
 	"github.com/breeze-rmm/agent/internal/remote/desktop"
+	"github.com/breeze-rmm/agent/internal/remote/tools"
 )
 
 const (
 	maxDesktopDisplayIndex = 16
 	maxDesktopOfferBytes   = 256 * 1024
 	maxDesktopICEBytes     = 64 * 1024
+	// maxDesktopFilePathEntries caps each file path policy list.
+	maxDesktopFilePathEntries = 256
 
 	// Sane upper bounds for caller-supplied lifetime limits. Values above the
 	// cap are almost certainly a bug or hostile input.
@@ -60,6 +63,10 @@ func (h *helperDesktopManager) startSession(req *ipc.DesktopStartRequest) (*ipc.
 	// already enforced by validateDesktopStartRequest.
 	policy := desktop.ResolveSessionPolicyFromIPC(*req)
 
+	// File drop downloads run in this process, so they need the service's
+	// file path policy; the helper has no config of its own to read it from.
+	tools.SetFilePathPolicy(tools.FilePathPolicy{Allow: req.FilePathAllow, Deny: req.FilePathDeny})
+
 	answer, err := h.mgr.StartSession(req.SessionID, req.Offer, iceServers, req.DisplayIndex, policy)
 	if err != nil {
 		return nil, fmt.Errorf("start desktop session: %w", err)
@@ -90,6 +97,14 @@ func validateDesktopStartRequest(req *ipc.DesktopStartRequest) error {
 	if req.DisplayIndex < 0 || req.DisplayIndex > maxDesktopDisplayIndex {
 		return fmt.Errorf("displayIndex out of range")
 	}
+	if len(req.ExtraDisplays) > maxDesktopDisplayIndex {
+		return fmt.Errorf("too many extraDisplays")
+	}
+	for _, di := range req.ExtraDisplays {
+		if di < 0 || di > maxDesktopDisplayIndex {
+			return fmt.Errorf("extraDisplays entry out of range")
+		}
+	}
 	// Clamp/reject lifetime bounds. Negative values must NOT silently decode to
 	// "disabled" (fail-open) — reject them outright rather than letting the >0
 	// guard in the policy decoder drop them. Cap at sane maxima to reject
@@ -106,6 +121,12 @@ func validateDesktopStartRequest(req *ipc.DesktopStartRequest) error {
 	if req.MaxSessionDurationHours > maxSessionDurationHours {
 		return fmt.Errorf("maxSessionDurationHours %d exceeds max %d", req.MaxSessionDurationHours, maxSessionDurationHours)
 	}
+	if len(req.FilePathAllow) > maxDesktopFilePathEntries || len(req.FilePathDeny) > maxDesktopFilePathEntries {
+		return fmt.Errorf("too many file path policy entries")
+	}
+	if req.MaxBitrateKbps != 0 && (req.MaxBitrateKbps < desktop.MinSessionMaxBitrateKbps || req.MaxBitrateKbps > desktop.MaxSessionMaxBitrateKbps) {
+		return fmt.Errorf("maxBitrateKbps %d outside %d-%d", req.MaxBitrateKbps, desktop.MinSessionMaxBitrateKbps, desktop.MaxSessionMaxBitrateKbps)
+	}
 	return nil
 }
 
@@ -119,6 +140,93 @@ func validateDesktopStopRequest(req *ipc.DesktopStopRequest) error {
 	return nil
 }
 
+// joinSession attaches an additional viewer to a running session and returns
+// the viewer's SDP answer.
+func (h *helperDesktopManager) joinSession(req *ipc.DesktopJoinRequest) (*ipc.DesktopJoinResponse, error) {
+	var iceServers []desktop.ICEServerConfig
+	if len(req.ICEServers) > 0 {
+		if err := json.Unmarshal(req.ICEServers, &iceServers); err != nil {
+			log.Warn("failed to parse ICE servers from IPC, using defaults", "error", err)
+		}
+	}
+
+	answer, err := h.mgr.AddViewer(req.SessionID, req.ViewerID, req.Offer, iceServers, req.ViewOnly)
+	if err != nil {
+		return nil, fmt.Errorf("join desktop session: %w", err)
+	}
+
+	return &ipc.DesktopJoinResponse{
+		SessionID: req.SessionID,
+		ViewerID:  req.ViewerID,
+		Answer:    answer,
+	}, nil
+}
+
+func validateDesktopJoinRequest(req *ipc.DesktopJoinRequest) error {
+	if req == nil {
+		return fmt.Errorf("desktop join request is required")
+	}
+	if !helperDesktopSessionIDPattern.MatchString(req.SessionID) {
+		return fmt.Errorf("invalid sessionId")
+	}
+	if !helperDesktopSessionIDPattern.MatchString(req.ViewerID) {
+		return fmt.Errorf("invalid viewerId")
+	}
+	if req.Offer == "" {
+		return fmt.Errorf("offer is required")
+	}
+	if len(req.Offer) > maxDesktopOfferBytes {
+		return fmt.Errorf("offer too large")
+	}
+	if len(req.ICEServers) > maxDesktopICEBytes {
+		return fmt.Errorf("iceServers too large")
+	}
+	return nil
+}
+
+func validateDesktopLeaveRequest(req *ipc.DesktopLeaveRequest) error {
+	if req == nil {
+		return fmt.Errorf("desktop leave request is required")
+	}
+	if !helperDesktopSessionIDPattern.MatchString(req.SessionID) {
+		return fmt.Errorf("invalid sessionId")
+	}
+	if !helperDesktopSessionIDPattern.MatchString(req.ViewerID) {
+		return fmt.Errorf("invalid viewerId")
+	}
+	return nil
+}
+
+func validateDesktopICEServersUpdate(req *ipc.DesktopICEServersUpdate) error {
+	if req == nil {
+		return fmt.Errorf("desktop ICE servers update is required")
+	}
+	if !helperDesktopSessionIDPattern.MatchString(req.SessionID) {
+		return fmt.Errorf("invalid sessionId")
+	}
+	if len(req.ICEServers) == 0 {
+		return fmt.Errorf("iceServers is required")
+	}
+	if len(req.ICEServers) > maxDesktopICEBytes {
+		return fmt.Errorf("iceServers too large")
+	}
+	return nil
+}
+
+// updateICEServers applies refreshed ICE servers to a running session.
+func (h *helperDesktopManager) updateICEServers(req *ipc.DesktopICEServersUpdate) error {
+	var iceServers []desktop.ICEServerConfig
+	if err := json.Unmarshal(req.ICEServers, &iceServers); err != nil {
+		return fmt.Errorf("invalid iceServers: %w", err)
+	}
+	return h.mgr.UpdateICEServers(req.SessionID, iceServers)
+}
+
+// leaveSession detaches an additional viewer.
+func (h *helperDesktopManager) leaveSession(sessionID, viewerID string) bool {
+	return h.mgr.RemoveViewer(sessionID, viewerID)
+}
+
 // stopSession tears down the desktop session.
 func (h *helperDesktopManager) stopSession(sessionID string) {
 	h.mgr.StopSession(sessionID)
@@ -1,6 +1,7 @@
 package userhelper
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -41,6 +42,38 @@ func TestValidateDesktopStartRequest(t *testing.T) {
 	}); err == nil {
 		t.Fatal("expected oversized iceServers to be rejected")
 	}
+
+	if err := validateDesktopStartRequest(&ipc.DesktopStartRequest{
+		SessionID:     "desktop-1",
+		Offer:         "offer",
+		ExtraDisplays: []int{0, 2},
+	}); err != nil {
+		t.Fatalf("expected extraDisplays to be accepted, got %v", err)
+	}
+
+	if err := validateDesktopStartRequest(&ipc.DesktopStartRequest{
+		SessionID:     "desktop-1",
+		Offer:         "offer",
+		ExtraDisplays: []int{maxDesktopDisplayIndex + 1},
+	}); err == nil {
+		t.Fatal("expected out-of-range extraDisplays entry to be rejected")
+	}
+
+	if err := validateDesktopStartRequest(&ipc.DesktopStartRequest{
+		SessionID:      "desktop-1",
+		Offer:          "offer",
+		MaxBitrateKbps: 3000,
+	}); err != nil {
+		t.Fatalf("expected bandwidth cap to be accepted, got %v", err)
+	}
+
+	if err := validateDesktopStartRequest(&ipc.DesktopStartRequest{
+		SessionID:      "desktop-1",
+		Offer:          "offer",
+		MaxBitrateKbps: -1,
+	}); err == nil {
+		t.Fatal("expected negative bandwidth cap to be rejected")
+	}
 }
 
 func TestValidateDesktopStopRequest(t *testing.T) {
@@ -52,6 +85,45 @@ func TestValidateDesktopStopRequest(t *testing.T) {
 	}
 }
 
+func TestValidateDesktopJoinRequest(t *testing.T) {
+	if err := validateDesktopJoinRequest(&ipc.DesktopJoinRequest{
+		SessionID: "desktop-1",
+		ViewerID:  "viewer-1",
+		Offer:     "offer",
+	}); err != nil {
+		t.Fatalf("expected valid desktop join request, got %v", err)
+	}
+	if err := validateDesktopJoinRequest(&ipc.DesktopJoinRequest{
+		SessionID: "desktop-1",
+		ViewerID:  "../bad",
+		Offer:     "offer",
+	}); err == nil {
+		t.Fatal("expected invalid viewer ID to be rejected")
+	}
+	if err := validateDesktopJoinRequest(&ipc.DesktopJoinRequest{
+		SessionID: "desktop-1",
+		ViewerID:  "viewer-1",
+	}); err == nil {
+		t.Fatal("expected missing offer to be rejected")
+	}
+	if err := validateDesktopLeaveRequest(&ipc.DesktopLeaveRequest{SessionID: "desktop-1", ViewerID: ""}); err == nil {
+		t.Fatal("expected empty viewer ID to be rejected")
+	}
+}
+
+func TestValidateDesktopICEServersUpdate(t *testing.T) {
+	ice := json.RawMessage(`[{"urls":"turn:turn.example.com:3478","username":"1700000000:breeze","credential":"c"}]`)
+	if err := validateDesktopICEServersUpdate(&ipc.DesktopICEServersUpdate{SessionID: "desktop-1", ICEServers: ice}); err != nil {
+		t.Fatalf("expected valid ICE servers update, got %v", err)
+	}
+	if err := validateDesktopICEServersUpdate(&ipc.DesktopICEServersUpdate{SessionID: "desktop-1"}); err == nil {
+		t.Fatal("expected empty iceServers to be rejected")
+	}
+	if err := validateDesktopICEServersUpdate(&ipc.DesktopICEServersUpdate{SessionID: "../bad", ICEServers: ice}); err == nil {
+		t.Fatal("expected invalid session ID to be rejected")
+	}
+}
+
 func TestNewHelperDesktopManagerPreservesDesktopContext(t *testing.T) {
 	manager := newHelperDesktopManager(ipc.DesktopContextLoginWindow)
 	if got := manager.mgr.CaptureConfig().DesktopContext; got != ipc.DesktopContextLoginWindow {
@@ -9,7 +9,12 @@ type Notifier interface {
 }
 
 // showNotification sends a desktop notification. Platform-specific.
-// Returns true if the notification was delivered.
-func showNotification(req ipc.NotifyRequest) bool {
-	return showNotificationOS(req)
+// Returns true if the notification was delivered. A notification with
+// actions is shown as a prompt and also returns the action the user picked,
+// or "" when none was picked before the timeout.
+func showNotification(req ipc.NotifyRequest) (bool, string) {
+	if len(req.Actions) == 0 {
+		return showNotificationOS(req), ""
+	}
+	return promptNotificationOS(sanitizeNotifyRequest(req))
 }
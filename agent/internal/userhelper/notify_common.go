@@ -1,6 +1,7 @@
 package userhelper
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/breeze-rmm/agent/internal/ipc"
@@ -10,6 +11,11 @@ const (
 	maxNotifyTitleBytes = 256
 	maxNotifyBodyBytes  = 2048
 	maxNotifyIconBytes  = 512
+
+	// defaultNotifyPromptTimeout and maxNotifyPromptTimeout bound how long a
+	// notification with actions waits for the user, in seconds.
+	defaultNotifyPromptTimeout = 300
+	maxNotifyPromptTimeout     = 3600
 )
 
 var allowedNotifyUrgencies = map[string]struct{}{
@@ -33,9 +39,40 @@ func sanitizeNotifyRequest(req ipc.NotifyRequest) ipc.NotifyRequest {
 	for i := range req.Actions {
 		req.Actions[i] = trimNotifyField(req.Actions[i], maxNotifyTitleBytes)
 	}
+	if req.TimeoutSeconds <= 0 {
+		req.TimeoutSeconds = defaultNotifyPromptTimeout
+	} else if req.TimeoutSeconds > maxNotifyPromptTimeout {
+		req.TimeoutSeconds = maxNotifyPromptTimeout
+	}
 	return req
 }
 
+// notifyActionAt maps the action index a toast reports back to its label.
+func notifyActionAt(actions []string, index string) string {
+	i, err := strconv.Atoi(strings.TrimSpace(index))
+	if err != nil || i < 0 || i >= len(actions) {
+		return ""
+	}
+	return actions[i]
+}
+
+// parseAppleScriptButton returns the button picked in an osascript
+// "display dialog", or "" when the dialog gave up.
+func parseAppleScriptButton(out string) string {
+	out = strings.TrimRight(out, "\r\n")
+	_, rest, found := strings.Cut(out, "button returned:")
+	if !found {
+		return ""
+	}
+	if i := strings.LastIndex(rest, ", gave up:"); i >= 0 {
+		if rest[i:] == ", gave up:true" {
+			return ""
+		}
+		rest = rest[:i]
+	}
+	return rest
+}
+
 func trimNotifyField(value string, max int) string {
 	value = strings.TrimSpace(value)
 	if max <= 0 || len(value) <= max {
@@ -3,7 +3,9 @@
 package userhelper
 
 import (
+	"fmt"
 	"os/exec"
+	"strings"
 
 	"github.com/breeze-rmm/agent/internal/ipc"
 )
@@ -48,3 +50,27 @@ func escapeAppleScript(s string) string {
 	}
 	return string(result)
 }
+
+// promptNotificationOS shows the actions as the buttons of an osascript
+// dialog, which allows at most three. "giving up after N" maps to the
+// timeout and returns no action.
+func promptNotificationOS(req ipc.NotifyRequest) (bool, string) {
+	actions := req.Actions
+	if len(actions) > 3 {
+		actions = actions[:3]
+	}
+	buttons := make([]string, len(actions))
+	for i, action := range actions {
+		buttons[i] = `"` + escapeAppleScript(action) + `"`
+	}
+	script := fmt.Sprintf(
+		`display dialog "%s" with title "%s" buttons {%s} default button 1 giving up after %d`,
+		escapeAppleScript(req.Body), escapeAppleScript(req.Title), strings.Join(buttons, ", "), req.TimeoutSeconds,
+	)
+	out, err := exec.Command("osascript", "-e", script).CombinedOutput()
+	if err != nil {
+		log.Warn("osascript prompt failed, falling back to a notification", "error", err.Error())
+		return showNotificationOS(req), ""
+	}
+	return true, parseAppleScriptButton(string(out))
+}
@@ -3,7 +3,9 @@
 package userhelper
 
 import (
+	"fmt"
 	"os/exec"
+	"strings"
 
 	"github.com/breeze-rmm/agent/internal/ipc"
 )
@@ -28,3 +30,35 @@ func showNotificationOS(req ipc.NotifyRequest) bool {
 	}
 	return true
 }
+
+// promptNotificationOS shows the actions in a zenity list dialog. zenity
+// exit codes: 0=picked (row on stdout), 1=dismissed, 5=timeout. Without
+// zenity the message is still shown as a plain notification.
+func promptNotificationOS(req ipc.NotifyRequest) (bool, string) {
+	args := []string{
+		"--list",
+		"--title", req.Title,
+		"--text", zenityMarkupEscaper.Replace(req.Body),
+		"--column", "Options",
+		"--hide-header",
+		"--width", "420",
+		"--height", "260",
+		fmt.Sprintf("--timeout=%d", req.TimeoutSeconds),
+	}
+	args = append(args, req.Actions...)
+	out, err := exec.Command("zenity", args...).Output()
+	if err == nil {
+		choice := strings.TrimRight(string(out), "\n")
+		for _, action := range req.Actions {
+			if action == choice {
+				return true, choice
+			}
+		}
+		return true, ""
+	}
+	if _, ok := err.(*exec.ExitError); ok {
+		return true, ""
+	}
+	log.Warn("zenity prompt failed, falling back to a notification", "error", err.Error())
+	return showNotificationOS(req), ""
+}
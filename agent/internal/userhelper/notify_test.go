@@ -40,3 +40,42 @@ func TestSanitizeNotifyRequest(t *testing.T) {
 		t.Fatalf("second action length = %d, want %d", len(req.Actions[1]), maxNotifyTitleBytes)
 	}
 }
+
+func TestSanitizeNotifyRequestClampsPromptTimeout(t *testing.T) {
+	t.Parallel()
+
+	if got := sanitizeNotifyRequest(ipc.NotifyRequest{}).TimeoutSeconds; got != defaultNotifyPromptTimeout {
+		t.Fatalf("default timeout = %d, want %d", got, defaultNotifyPromptTimeout)
+	}
+	if got := sanitizeNotifyRequest(ipc.NotifyRequest{TimeoutSeconds: 99999}).TimeoutSeconds; got != maxNotifyPromptTimeout {
+		t.Fatalf("clamped timeout = %d, want %d", got, maxNotifyPromptTimeout)
+	}
+}
+
+func TestNotifyActionAt(t *testing.T) {
+	t.Parallel()
+
+	actions := []string{"Postpone 1 hour", "Postpone 4 hours"}
+	if got := notifyActionAt(actions, "1"); got != "Postpone 4 hours" {
+		t.Fatalf("notifyActionAt(1) = %q", got)
+	}
+	for _, index := range []string{"", "2", "-1", "x"} {
+		if got := notifyActionAt(actions, index); got != "" {
+			t.Fatalf("notifyActionAt(%q) = %q, want empty", index, got)
+		}
+	}
+}
+
+func TestParseAppleScriptButton(t *testing.T) {
+	t.Parallel()
+
+	if got := parseAppleScriptButton("button returned:Postpone 1 hour, gave up:false\n"); got != "Postpone 1 hour" {
+		t.Fatalf("picked button = %q", got)
+	}
+	if got := parseAppleScriptButton("button returned:, gave up:true\n"); got != "" {
+		t.Fatalf("gave up = %q, want empty", got)
+	}
+	if got := parseAppleScriptButton(""); got != "" {
+		t.Fatalf("empty output = %q, want empty", got)
+	}
+}
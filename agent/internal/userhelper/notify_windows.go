@@ -6,6 +6,7 @@ import (
 	"encoding/xml"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -82,6 +83,59 @@ func showNotificationOS(req ipc.NotifyRequest) bool {
 	return true
 }
 
+// promptNotificationOS raises a reminder toast with one button per action
+// and waits for the Activated event. Buttons carry their index as the
+// activation argument so labels never pass through PowerShell. A toast the
+// user ignores is withdrawn at the timeout and returns no action.
+func promptNotificationOS(req ipc.NotifyRequest) (bool, string) {
+	notifyAumidOnce.Do(registerToastAUMID)
+
+	var actions strings.Builder
+	for i, action := range req.Actions {
+		actions.WriteString(`<action activationType="foreground" arguments="` + strconv.Itoa(i) +
+			`" content="` + xmlEscape(action) + `"/>`)
+	}
+	toastXML := `<toast scenario="reminder"><visual><binding template="ToastGeneric">` +
+		`<text>` + xmlEscape(req.Title) + `</text>` +
+		`<text>` + xmlEscape(req.Body) + `</text>` +
+		`</binding></visual><actions>` + actions.String() + `</actions></toast>`
+
+	script := `try {
+  [Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+  [Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+  $doc = [Windows.Data.Xml.Dom.XmlDocument]::new()
+  $doc.LoadXml($env:BREEZE_TOAST_XML)
+  $toast = [Windows.UI.Notifications.ToastNotification]::new($doc)
+  $notifier = [Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('` + notifyAUMID + `')
+  Register-ObjectEvent -InputObject $toast -EventName Activated -SourceIdentifier BreezeToastAction | Out-Null
+  $notifier.Show($toast)
+  $evt = Wait-Event -SourceIdentifier BreezeToastAction -Timeout ([int]$env:BREEZE_TOAST_TIMEOUT)
+  if ($evt) {
+    $activated = $evt.SourceArgs[1] -as [Windows.UI.Notifications.ToastActivatedEventArgs]
+    if ($activated) { [Console]::Out.Write($activated.Arguments) }
+  } else {
+    $notifier.Hide($toast)
+  }
+} catch {
+  [Console]::Error.WriteLine($_.Exception.Message)
+  exit 1
+}`
+
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	cmd.Env = append(os.Environ(),
+		"BREEZE_TOAST_XML="+toastXML,
+		"BREEZE_TOAST_TIMEOUT="+strconv.Itoa(req.TimeoutSeconds),
+	)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		log.Warn("prompt notification failed", "error", err, "output", strings.TrimSpace(stderr.String()))
+		return false, ""
+	}
+	return true, notifyActionAt(req.Actions, string(out))
+}
+
 // xmlEscape encodes a string so it is safe for embedding in XML text content.
 func xmlEscape(s string) string {
 	var b strings.Builder
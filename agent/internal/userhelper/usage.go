@@ -0,0 +1,262 @@
+package userhelper
+
+import (
+	"encoding/json"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/ipc"
+)
+
+// Software usage metering. While the agent has metering switched on (it is
+// opt-in per device), the user-role helper samples the foreground
+// application and periodically sends the per-app, per-day foreground time
+// it accumulated. Time while the user is idle or the session is locked is
+// not counted. The agent attributes reports to this helper's user.
+
+const (
+	defaultUsageSampleInterval = 15 * time.Second
+	defaultUsageReportInterval = 5 * time.Minute
+	defaultUsageIdleThreshold  = 5 * time.Minute
+
+	// maxUsageReportApps bounds a single report; anything beyond it stays
+	// accumulated for the next one.
+	maxUsageReportApps = 500
+)
+
+// Platform seams (swapped in tests). foregroundAppFn returns the display
+// name and executable/bundle path of the foreground application, ok=false
+// when none can be determined (locked, no display, unsupported). userIdleFn
+// returns the time since the last keyboard/mouse input, ok=false when
+// unknown.
+var (
+	foregroundAppFn = foregroundAppOS
+	userIdleFn      = userIdleOS
+)
+
+type usageSettings struct {
+	sample, report, idle time.Duration
+}
+
+func usageSettingsFromConfig(cfg ipc.UsageMeteringConfig) usageSettings {
+	s := usageSettings{
+		sample: defaultUsageSampleInterval,
+		report: defaultUsageReportInterval,
+		idle:   defaultUsageIdleThreshold,
+	}
+	if cfg.SampleIntervalSeconds >= 5 && cfg.SampleIntervalSeconds <= 300 {
+		s.sample = time.Duration(cfg.SampleIntervalSeconds) * time.Second
+	}
+	if cfg.ReportIntervalSeconds >= 60 && cfg.ReportIntervalSeconds <= 3600 {
+		s.report = time.Duration(cfg.ReportIntervalSeconds) * time.Second
+	}
+	if cfg.IdleThresholdSeconds >= 60 && cfg.IdleThresholdSeconds <= 3600 {
+		s.idle = time.Duration(cfg.IdleThresholdSeconds) * time.Second
+	}
+	if s.report < s.sample {
+		s.report = s.sample
+	}
+	return s
+}
+
+type usageKey struct {
+	date, name, path string
+}
+
+// usageAccumulator sums foreground time between reports.
+type usageAccumulator struct {
+	totals map[usageKey]time.Duration
+}
+
+func newUsageAccumulator() *usageAccumulator {
+	return &usageAccumulator{totals: make(map[usageKey]time.Duration)}
+}
+
+func (a *usageAccumulator) add(at time.Time, name, path string, d time.Duration) {
+	if name == "" || d <= 0 {
+		return
+	}
+	a.totals[usageKey{date: at.Format("2006-01-02"), name: name, path: path}] += d
+}
+
+// drain moves whole seconds into a report, keeping sub-second remainders
+// (and anything over maxUsageReportApps) for the next one.
+func (a *usageAccumulator) drain() ipc.UsageReport {
+	report := ipc.UsageReport{Apps: []ipc.AppUsage{}}
+	for key, d := range a.totals {
+		if len(report.Apps) >= maxUsageReportApps {
+			break
+		}
+		secs := int64(d / time.Second)
+		if secs == 0 {
+			continue
+		}
+		report.Apps = append(report.Apps, ipc.AppUsage{Date: key.date, Name: key.name, Path: key.path, Seconds: secs})
+		if rest := d - time.Duration(secs)*time.Second; rest > 0 {
+			a.totals[key] = rest
+		} else {
+			delete(a.totals, key)
+		}
+	}
+	return report
+}
+
+// restore puts an undelivered report back.
+func (a *usageAccumulator) restore(report ipc.UsageReport) {
+	for _, app := range report.Apps {
+		a.totals[usageKey{date: app.Date, name: app.Name, path: app.Path}] += time.Duration(app.Seconds) * time.Second
+	}
+}
+
+// runUsageMeter samples until stop is closed, then sends what is left.
+func runUsageMeter(s usageSettings, stop <-chan struct{}, send func(ipc.UsageReport) error) {
+	acc := newUsageAccumulator()
+	flush := func() {
+		report := acc.drain()
+		if len(report.Apps) == 0 {
+			return
+		}
+		if err := send(report); err != nil {
+			log.Warn("failed to send usage report", "error", err.Error())
+			acc.restore(report)
+		}
+	}
+
+	sample := time.NewTicker(s.sample)
+	defer sample.Stop()
+	report := time.NewTicker(s.report)
+	defer report.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-stop:
+			flush()
+			return
+		case now := <-sample.C:
+			elapsed := now.Sub(last)
+			last = now
+			// A long gap means the machine slept; credit one interval.
+			if elapsed > 2*s.sample {
+				elapsed = s.sample
+			}
+			if idle, ok := userIdleFn(); ok && idle >= s.idle {
+				continue
+			}
+			name, path, ok := foregroundAppFn()
+			if !ok {
+				continue
+			}
+			acc.add(now, name, path, elapsed)
+		case <-report.C:
+			flush()
+		}
+	}
+}
+
+// usageMeterState tracks the running meter for one client. mu serializes
+// whole start/stop operations, since config messages are each handled in
+// their own goroutine.
+type usageMeterState struct {
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// handleUsageMeteringConfig starts or stops metering. Only user-role helpers
+// granted the usage_metering scope meter; a system helper runs as SYSTEM
+// and cannot attribute time to a user.
+func (c *Client) handleUsageMeteringConfig(env *ipc.Envelope) {
+	var cfg ipc.UsageMeteringConfig
+	if err := json.Unmarshal(env.Payload, &cfg); err != nil {
+		log.Warn("invalid usage_metering_config payload", "error", err)
+		return
+	}
+	if cfg.Enabled && (c.role != ipc.HelperRoleUser || !c.hasScope(ipc.ScopeUsageMetering)) {
+		log.Warn("ignoring usage metering request without usage_metering scope", "role", c.role)
+		return
+	}
+
+	c.usage.mu.Lock()
+	defer c.usage.mu.Unlock()
+	c.stopUsageMeteringLocked()
+	if !cfg.Enabled {
+		return
+	}
+
+	settings := usageSettingsFromConfig(cfg)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	c.usage.stop, c.usage.done = stop, done
+
+	log.Info("software usage metering started", "sampleInterval", settings.sample.String(), "os", runtime.GOOS)
+	var seq uint64
+	safeGo("usage_meter", func() {
+		defer close(done)
+		runUsageMeter(settings, stop, func(report ipc.UsageReport) error {
+			seq++
+			return c.conn.SendTyped("usage-"+strconv.FormatUint(seq, 10), ipc.TypeUsageReport, report)
+		})
+	})
+}
+
+// stopUsageMetering stops the meter (flushing its last report) and waits
+// for it to exit.
+func (c *Client) stopUsageMetering() {
+	c.usage.mu.Lock()
+	defer c.usage.mu.Unlock()
+	c.stopUsageMeteringLocked()
+}
+
+func (c *Client) stopUsageMeteringLocked() {
+	if c.usage.stop == nil {
+		return
+	}
+	close(c.usage.stop)
+	<-c.usage.done
+	c.usage.stop, c.usage.done = nil, nil
+	log.Info("software usage metering stopped")
+}
+
+// lsappinfoNameRe matches the first line of `lsappinfo info`:
+// "Safari" ASN:0x0-0x1a01a:
+var lsappinfoNameRe = regexp.MustCompile(`^"(.*)"\s+ASN:`)
+
+// parseLSAppInfo extracts the display name and bundle path from
+// `lsappinfo info <asn>` output.
+func parseLSAppInfo(output string) (name, path string) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if name == "" {
+			if m := lsappinfoNameRe.FindStringSubmatch(line); m != nil {
+				name = m[1]
+				continue
+			}
+		}
+		if rest, ok := strings.CutPrefix(line, `bundle path="`); ok {
+			path = strings.TrimSuffix(rest, `"`)
+		}
+	}
+	return name, path
+}
+
+// parseHIDIdleTime reads HIDIdleTime (nanoseconds) from
+// `ioreg -c IOHIDSystem` output.
+func parseHIDIdleTime(output string) (time.Duration, bool) {
+	for _, line := range strings.Split(output, "\n") {
+		idx := strings.Index(line, `"HIDIdleTime" = `)
+		if idx < 0 {
+			continue
+		}
+		ns, err := strconv.ParseInt(strings.TrimSpace(line[idx+len(`"HIDIdleTime" = `):]), 10, 64)
+		if err != nil || ns < 0 {
+			return 0, false
+		}
+		return time.Duration(ns), true
+	}
+	return 0, false
+}
@@ -0,0 +1,48 @@
+//go:build darwin
+
+package userhelper
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const usageProbeTimeout = 3 * time.Second
+
+// foregroundAppOS asks Launch Services for the frontmost application.
+// loginwindow is frontmost while the screen is locked.
+func foregroundAppOS() (string, string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), usageProbeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "lsappinfo", "front").Output()
+	if err != nil {
+		return "", "", false
+	}
+	asn := strings.TrimSpace(string(out))
+	if !strings.HasPrefix(asn, "ASN:") {
+		return "", "", false
+	}
+	out, err = exec.CommandContext(ctx, "lsappinfo", "info", asn).Output()
+	if err != nil {
+		return "", "", false
+	}
+	name, path := parseLSAppInfo(string(out))
+	if name == "" || name == "loginwindow" {
+		return "", "", false
+	}
+	return name, path, true
+}
+
+func userIdleOS() (time.Duration, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), usageProbeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "ioreg", "-c", "IOHIDSystem", "-d", "4", "-r", "-k", "HIDIdleTime").Output()
+	if err != nil {
+		return 0, false
+	}
+	return parseHIDIdleTime(string(out))
+}
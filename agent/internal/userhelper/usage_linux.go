@@ -0,0 +1,64 @@
+//go:build linux
+
+package userhelper
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const usageProbeTimeout = 3 * time.Second
+
+// foregroundAppOS needs an X11 display and xdotool; Wayland has no portable
+// way to ask for the focused window, so native Wayland apps go unmetered.
+func foregroundAppOS() (string, string, bool) {
+	if os.Getenv("DISPLAY") == "" {
+		return "", "", false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), usageProbeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "xdotool", "getactivewindow", "getwindowpid").Output()
+	if err != nil {
+		return "", "", false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil || pid <= 0 {
+		return "", "", false
+	}
+	procDir := filepath.Join("/proc", strconv.Itoa(pid))
+	path, _ := os.Readlink(filepath.Join(procDir, "exe"))
+	comm, _ := os.ReadFile(filepath.Join(procDir, "comm"))
+	name := strings.TrimSpace(string(comm))
+	if name == "" && path != "" {
+		name = filepath.Base(path)
+	}
+	if name == "" {
+		return "", "", false
+	}
+	return name, path, true
+}
+
+// userIdleOS uses xprintidle (milliseconds) when installed.
+func userIdleOS() (time.Duration, bool) {
+	if os.Getenv("DISPLAY") == "" {
+		return 0, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), usageProbeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "xprintidle").Output()
+	if err != nil {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil || ms < 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
@@ -0,0 +1,13 @@
+//go:build !windows && !darwin && !linux
+
+package userhelper
+
+import "time"
+
+func foregroundAppOS() (string, string, bool) {
+	return "", "", false
+}
+
+func userIdleOS() (time.Duration, bool) {
+	return 0, false
+}
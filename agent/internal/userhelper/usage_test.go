@@ -0,0 +1,72 @@
+package userhelper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/breeze-rmm/agent/internal/ipc"
+)
+
+func TestUsageSettingsFromConfig(t *testing.T) {
+	s := usageSettingsFromConfig(ipc.UsageMeteringConfig{Enabled: true})
+	if s.sample != defaultUsageSampleInterval || s.report != defaultUsageReportInterval || s.idle != defaultUsageIdleThreshold {
+		t.Fatalf("unexpected defaults %+v", s)
+	}
+	s = usageSettingsFromConfig(ipc.UsageMeteringConfig{SampleIntervalSeconds: 1, ReportIntervalSeconds: 120, IdleThresholdSeconds: 600})
+	if s.sample != defaultUsageSampleInterval || s.report != 2*time.Minute || s.idle != 10*time.Minute {
+		t.Fatalf("unexpected clamped settings %+v", s)
+	}
+}
+
+func TestUsageAccumulator(t *testing.T) {
+	day := time.Date(2026, 3, 10, 9, 0, 0, 0, time.Local)
+	acc := newUsageAccumulator()
+	acc.add(day, "WINWORD.EXE", `C:\Office\WINWORD.EXE`, 15*time.Second)
+	acc.add(day, "WINWORD.EXE", `C:\Office\WINWORD.EXE`, 15500*time.Millisecond)
+	acc.add(day.Add(24*time.Hour), "WINWORD.EXE", `C:\Office\WINWORD.EXE`, 400*time.Millisecond)
+	acc.add(day, "", "", time.Minute)
+
+	report := acc.drain()
+	if len(report.Apps) != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if app := report.Apps[0]; app.Date != "2026-03-10" || app.Seconds != 30 || app.Path != `C:\Office\WINWORD.EXE` {
+		t.Fatalf("unexpected app usage %+v", app)
+	}
+	// Sub-second remainders stay for the next report.
+	if len(acc.totals) != 2 {
+		t.Fatalf("expected remainders to be kept, got %v", acc.totals)
+	}
+
+	acc.restore(report)
+	acc.add(day, "WINWORD.EXE", `C:\Office\WINWORD.EXE`, 500*time.Millisecond)
+	if report = acc.drain(); len(report.Apps) != 1 || report.Apps[0].Seconds != 31 {
+		t.Fatalf("restored usage not re-sent: %+v", report)
+	}
+}
+
+func TestParseLSAppInfo(t *testing.T) {
+	out := `"Safari" ASN:0x0-0x1a01a: 
+    bundleID="com.apple.Safari"
+    bundle path="/Applications/Safari.app"
+    executable path="/Applications/Safari.app/Contents/MacOS/Safari"
+    pid = 812 type="Foreground" flavor=3 Version="19.0"
+`
+	name, path := parseLSAppInfo(out)
+	if name != "Safari" || path != "/Applications/Safari.app" {
+		t.Fatalf("got %q %q", name, path)
+	}
+	if name, _ := parseLSAppInfo(""); name != "" {
+		t.Fatalf("expected empty name, got %q", name)
+	}
+}
+
+func TestParseHIDIdleTime(t *testing.T) {
+	out := "+-o IOHIDSystem  <class IOHIDSystem>\n    {\n      \"HIDIdleTime\" = 125000000000\n    }\n"
+	if d, ok := parseHIDIdleTime(out); !ok || d != 125*time.Second {
+		t.Fatalf("got %v %v", d, ok)
+	}
+	if _, ok := parseHIDIdleTime("nothing here"); ok {
+		t.Fatal("expected missing idle time to be reported as unknown")
+	}
+}
@@ -0,0 +1,68 @@
+//go:build windows
+
+package userhelper
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	procGetForegroundWindow      = pamDialogUser32.NewProc("GetForegroundWindow")
+	procGetWindowThreadProcessId = pamDialogUser32.NewProc("GetWindowThreadProcessId")
+	procGetLastInputInfo         = pamDialogUser32.NewProc("GetLastInputInfo")
+	procGetTickCount             = pamDialogKernel32.NewProc("GetTickCount")
+)
+
+type lastInputInfo struct {
+	cbSize uint32
+	dwTime uint32
+}
+
+// foregroundAppOS resolves the foreground window's process image. There is
+// no foreground window while the workstation is locked (the secure desktop
+// owns input); LockApp.exe is the lock screen itself.
+func foregroundAppOS() (string, string, bool) {
+	hwnd, _, _ := procGetForegroundWindow.Call()
+	if hwnd == 0 {
+		return "", "", false
+	}
+	var pid uint32
+	procGetWindowThreadProcessId.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+	if pid == 0 {
+		return "", "", false
+	}
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return "", "", false
+	}
+	defer windows.CloseHandle(h)
+
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(h, 0, &buf[0], &size); err != nil {
+		return "", "", false
+	}
+	path := windows.UTF16ToString(buf[:size])
+	name := filepath.Base(path)
+	if strings.EqualFold(name, "LockApp.exe") {
+		return "", "", false
+	}
+	return name, path, true
+}
+
+// userIdleOS compares the last input tick with the current one; both are
+// 32-bit millisecond tick counts, so the subtraction wraps correctly.
+func userIdleOS() (time.Duration, bool) {
+	info := lastInputInfo{cbSize: uint32(unsafe.Sizeof(lastInputInfo{}))}
+	if ok, _, _ := procGetLastInputInfo.Call(uintptr(unsafe.Pointer(&info))); ok == 0 {
+		return 0, false
+	}
+	now, _, _ := procGetTickCount.Call()
+	idleMs := uint32(now) - info.dwTime
+	return time.Duration(idleMs) * time.Millisecond, true
+}
@@ -0,0 +1,188 @@
+// Package vulnscan matches the installed software inventory against a
+// vulnerability snapshot delivered by the server, so findings reflect the
+// versions actually installed rather than what the OS patch providers
+// consider outstanding.
+package vulnscan
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/breeze-rmm/agent/internal/collectors"
+)
+
+// Range is an affected version range. Empty bounds are open; a range with
+// no bounds at all covers every version.
+type Range struct {
+	StartIncluding string `json:"startIncluding,omitempty"`
+	StartExcluding string `json:"startExcluding,omitempty"`
+	EndIncluding   string `json:"endIncluding,omitempty"`
+	EndExcluding   string `json:"endExcluding,omitempty"`
+}
+
+// Entry says that a product is vulnerable to a CVE within a version range.
+// Name and Vendor are the lowercased, trimmed inventory names the server
+// resolved to the product; an empty Vendor matches any vendor.
+type Entry struct {
+	Name           string   `json:"name"`
+	Vendor         string   `json:"vendor,omitempty"`
+	CVEID          string   `json:"cveId"`
+	CVSSScore      *float64 `json:"cvssScore,omitempty"`
+	Severity       string   `json:"severity,omitempty"`
+	KnownExploited bool     `json:"knownExploited,omitempty"`
+	Range
+}
+
+// Snapshot is the body of the agent vulnerability snapshot endpoint.
+// Enabled is false when vulnerability scanning is off for the device, in
+// which case Entries is empty.
+type Snapshot struct {
+	Enabled     bool    `json:"enabled"`
+	GeneratedAt string  `json:"generatedAt,omitempty"`
+	Entries     []Entry `json:"entries"`
+}
+
+// Finding is an installed package version affected by a CVE.
+type Finding struct {
+	Name           string   `json:"name"`
+	Version        string   `json:"version"`
+	Vendor         string   `json:"vendor,omitempty"`
+	CVEID          string   `json:"cveId"`
+	CVSSScore      *float64 `json:"cvssScore,omitempty"`
+	Severity       string   `json:"severity,omitempty"`
+	KnownExploited bool     `json:"knownExploited,omitempty"`
+	// FixedVersion is the first unaffected version, when the range has one.
+	FixedVersion string `json:"fixedVersion,omitempty"`
+}
+
+// Scan returns the findings for the installed software, highest CVSS first.
+// Items without a version are skipped: no range can be checked against them.
+func Scan(snapshot *Snapshot, software []collectors.SoftwareItem) []Finding {
+	if snapshot == nil || len(snapshot.Entries) == 0 {
+		return nil
+	}
+	byName := make(map[string][]Entry)
+	for _, e := range snapshot.Entries {
+		name := normalize(e.Name)
+		if name == "" || e.CVEID == "" {
+			continue
+		}
+		byName[name] = append(byName[name], e)
+	}
+
+	type key struct{ name, version, cve string }
+	seen := make(map[key]bool)
+	var findings []Finding
+	for _, item := range software {
+		version := strings.TrimSpace(item.Version)
+		if version == "" {
+			continue
+		}
+		vendor := normalize(item.Vendor)
+		for _, e := range byName[normalize(item.Name)] {
+			if e.Vendor != "" && normalize(e.Vendor) != vendor {
+				continue
+			}
+			if !InRange(version, e.Range) {
+				continue
+			}
+			k := key{item.Name, version, e.CVEID}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			findings = append(findings, Finding{
+				Name:           item.Name,
+				Version:        version,
+				Vendor:         item.Vendor,
+				CVEID:          e.CVEID,
+				CVSSScore:      e.CVSSScore,
+				Severity:       e.Severity,
+				KnownExploited: e.KnownExploited,
+				FixedVersion:   e.EndExcluding,
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if (a.CVSSScore == nil) != (b.CVSSScore == nil) {
+			return a.CVSSScore != nil
+		}
+		if a.CVSSScore != nil && *a.CVSSScore != *b.CVSSScore {
+			return *a.CVSSScore > *b.CVSSScore
+		}
+		if a.CVEID != b.CVEID {
+			return a.CVEID < b.CVEID
+		}
+		return a.Name < b.Name
+	})
+	return findings
+}
+
+// InRange reports whether version falls within r.
+func InRange(version string, r Range) bool {
+	if r.StartIncluding != "" && CompareVersions(version, r.StartIncluding) < 0 {
+		return false
+	}
+	if r.StartExcluding != "" && CompareVersions(version, r.StartExcluding) <= 0 {
+		return false
+	}
+	if r.EndIncluding != "" && CompareVersions(version, r.EndIncluding) > 0 {
+		return false
+	}
+	if r.EndExcluding != "" && CompareVersions(version, r.EndExcluding) >= 0 {
+		return false
+	}
+	return true
+}
+
+// CompareVersions compares dotted versions segment by segment, reading the
+// leading digits of each segment ("3rc1" is 3, "beta" is 0) and padding the
+// shorter version with zeros. It matches the server's build comparison so
+// agent and server findings agree.
+func CompareVersions(a, b string) int {
+	sa := strings.Split(strings.TrimSpace(a), ".")
+	sb := strings.Split(strings.TrimSpace(b), ".")
+	for i := 0; i < len(sa) || i < len(sb); i++ {
+		var x, y int64
+		if i < len(sa) {
+			x = leadingInt(sa[i])
+		}
+		if i < len(sb) {
+			y = leadingInt(sb[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+func leadingInt(s string) int64 {
+	s = strings.TrimSpace(s)
+	end := 0
+	if end < len(s) && (s[end] == '-' || s[end] == '+') {
+		end++
+	}
+	start := end
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	if end == start {
+		return 0
+	}
+	n, err := strconv.ParseInt(s[:end], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,81 @@
+package vulnscan
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/breeze-rmm/agent/internal/collectors"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0.0", 0},
+		{"1.10", "1.9", 1},
+		{"124.0.6367.60", "124.0.6367.91", -1},
+		{"10.0.19045.4291", "10.0.19045.4170", 1},
+		{"3.1rc1", "3.1", 0},
+		{"2.beta", "2.0", 0},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestInRange(t *testing.T) {
+	r := Range{StartIncluding: "7.0", EndExcluding: "7.4.2"}
+	for version, want := range map[string]bool{"6.9": false, "7.0": true, "7.4.1": true, "7.4.2": false} {
+		if got := InRange(version, r); got != want {
+			t.Errorf("InRange(%q) = %v, want %v", version, got, want)
+		}
+	}
+	if !InRange("2.0", Range{StartExcluding: "1.0", EndIncluding: "2.0"}) || InRange("1.0", Range{StartExcluding: "1.0"}) {
+		t.Fatal("exclusive start and inclusive end bounds are wrong")
+	}
+	if !InRange("99", Range{}) {
+		t.Fatal("an unbounded range covers every version")
+	}
+}
+
+func TestScan(t *testing.T) {
+	var snapshot Snapshot
+	if err := json.Unmarshal([]byte(`{
+		"enabled": true,
+		"generatedAt": "2026-10-18T13:00:00Z",
+		"entries": [
+			{"name": "7-zip", "vendor": "igor pavlov", "cveId": "CVE-2024-11477", "cvssScore": 7.8, "severity": "high", "endExcluding": "24.07"},
+			{"name": "google chrome", "cveId": "CVE-2024-4671", "cvssScore": 9.6, "knownExploited": true, "endExcluding": "124.0.6367.201"},
+			{"name": "google chrome", "cveId": "CVE-2024-4671", "cvssScore": 9.6, "knownExploited": true, "startIncluding": "124.0", "endExcluding": "124.0.6367.201"},
+			{"name": "notepad++", "cveId": "CVE-2023-40031", "endExcluding": "8.5.7"}
+		]
+	}`), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	findings := Scan(&snapshot, []collectors.SoftwareItem{
+		{Name: "7-Zip", Version: "23.01", Vendor: "Igor Pavlov"},
+		{Name: "7-Zip", Version: "23.01", Vendor: "Someone Else"},
+		{Name: "Google Chrome ", Version: "124.0.6367.91", Vendor: "Google LLC"},
+		{Name: "Notepad++", Version: "8.6"},
+		{Name: "Notepad++", Version: ""},
+	})
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	chrome, zip := findings[0], findings[1]
+	if chrome.CVEID != "CVE-2024-4671" || !chrome.KnownExploited || chrome.FixedVersion != "124.0.6367.201" {
+		t.Fatalf("highest CVSS should come first, got %+v", chrome)
+	}
+	if zip.Name != "7-Zip" || zip.Version != "23.01" || zip.Vendor != "Igor Pavlov" || zip.Severity != "high" {
+		t.Fatalf("unexpected finding %+v", zip)
+	}
+
+	if Scan(&Snapshot{Enabled: false}, []collectors.SoftwareItem{{Name: "7-Zip", Version: "1"}}) != nil {
+		t.Fatal("an empty snapshot has no findings")
+	}
+}
@@ -693,6 +693,30 @@ func (c *Client) SendPatchProgress(commandID string, event any) error {
 	}
 }
 
+// SendProcessSnapshot sends one frame of a live process-table stream.
+// Non-blocking: drops if send channel is full; the stream's next full frame
+// resynchronizes the viewer.
+func (c *Client) SendProcessSnapshot(streamID string, snapshot any) error {
+	msg := map[string]any{
+		"type":     "process_snapshot",
+		"streamId": streamID,
+		"snapshot": snapshot,
+	}
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal process snapshot: %w", err)
+	}
+
+	select {
+	case c.sendChan <- msgBytes:
+		return nil
+	case <-c.done:
+		return fmt.Errorf("client is stopped")
+	default:
+		return fmt.Errorf("send channel full, dropping process snapshot")
+	}
+}
+
 // SendUpdateStatus notifies the server that a self-update is about to start.
 // Non-blocking: drops if send channel is full.
 func (c *Client) SendUpdateStatus(targetVersion string) error {
@@ -761,6 +785,51 @@ func (c *Client) SendBackupProgress(commandID string, event any) error {
 	}
 }
 
+// SendAVScanProgress sends an antivirus scan progress event to the server.
+func (c *Client) SendAVScanProgress(commandID string, event any) error {
+	msg := map[string]any{
+		"type":      "av_scan_progress",
+		"commandId": commandID,
+		"progress":  event,
+	}
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal av scan progress: %w", err)
+	}
+
+	select {
+	case c.sendChan <- msgBytes:
+		return nil
+	case <-c.done:
+		return fmt.Errorf("client is stopped")
+	default:
+		return fmt.Errorf("send channel full, dropping progress")
+	}
+}
+
+// SendSecurityAlert sends an urgent security alert (a tripped ransomware
+// canary, a network isolation change) to the server ahead of the next
+// heartbeat.
+func (c *Client) SendSecurityAlert(alert any) error {
+	msg := map[string]any{
+		"type":  "security_alert",
+		"alert": alert,
+	}
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal security alert: %w", err)
+	}
+
+	select {
+	case c.sendChan <- msgBytes:
+		return nil
+	case <-c.done:
+		return fmt.Errorf("client is stopped")
+	default:
+		return fmt.Errorf("send channel full, dropping security alert")
+	}
+}
+
 // SendTerminalOutput sends terminal output data to the server.
 // When the server advertises terminal_output_base64 in its connected handshake,
 // output is base64-encoded so non-UTF-8 console bytes are not corrupted by JSON.
@@ -45,9 +45,13 @@ type EnrollRequest struct {
 	// on what hypervisor" attribute (issue #1387), derived by the agent from
 	// the same hardware identity strings that drive DeviceRole. They are a
 	// second policy-targeting axis, not a role.
-	IsVirtual              bool          `json:"isVirtual,omitempty"`
-	VirtualizationPlatform string        `json:"virtualizationPlatform,omitempty"`
-	HardwareInfo           *HardwareInfo `json:"hardwareInfo,omitempty"`
+	IsVirtual              bool   `json:"isVirtual,omitempty"`
+	VirtualizationPlatform string `json:"virtualizationPlatform,omitempty"`
+	// TranscriptPublicKey is the base64 Ed25519 key transcript exports are
+	// signed with; the server pins it and rejects archives signed by any
+	// other key.
+	TranscriptPublicKey string        `json:"transcriptPublicKey,omitempty"`
+	HardwareInfo        *HardwareInfo `json:"hardwareInfo,omitempty"`
 }
 
 type HardwareInfo struct {
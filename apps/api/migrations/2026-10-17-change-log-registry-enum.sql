@@ -0,0 +1,10 @@
+-- Real-time registry change watching.
+-- Adds the registry category to the device_change_log change_type enum so the
+-- agent can submit value changes under watched keys (Run keys, LSA, policies)
+-- as they happen instead of waiting for the next snapshot diff.
+--
+-- ALTER TYPE ... ADD VALUE is transaction-safe in PG12+ as long as the new
+-- value is not *used* in the same transaction, so this runs safely under
+-- autoMigrate's per-file transaction. Idempotent.
+
+ALTER TYPE change_type ADD VALUE IF NOT EXISTS 'registry';
@@ -0,0 +1,10 @@
+-- USB peripheral change detection.
+-- Adds the usb_device category to the device_change_log change_type enum so
+-- the agent can submit USB device connect/disconnect events (new mass-storage
+-- devices are flagged high priority for policy alerts).
+--
+-- ALTER TYPE ... ADD VALUE is transaction-safe in PG12+ as long as the new
+-- value is not *used* in the same transaction, so this runs safely under
+-- autoMigrate's per-file transaction. Idempotent.
+
+ALTER TYPE change_type ADD VALUE IF NOT EXISTS 'usb_device';
@@ -0,0 +1,4 @@
+-- Attached display inventory (monitor model, serial, resolution, connection
+-- type) decoded from EDID by the agent's hardware collector.
+ALTER TABLE device_hardware
+  ADD COLUMN IF NOT EXISTS displays jsonb;
@@ -0,0 +1,4 @@
+-- Last approximate device location, reported with the heartbeat while
+-- org/site policy enables geolocation (lost/stolen laptop workflows).
+ALTER TABLE devices
+  ADD COLUMN IF NOT EXISTS last_location jsonb;
@@ -0,0 +1,4 @@
+-- Postpone choices the logged-in user made for the scheduled patch reboot,
+-- reported by the agent with full pending-patch uploads.
+ALTER TABLE devices
+  ADD COLUMN IF NOT EXISTS patch_reboot_deferral jsonb;
@@ -0,0 +1,45 @@
+-- Per-device data keys for client-side backup encryption. Keys are wrapped
+-- by the org's escrowed client key (storage_encryption_keys with key_type
+-- 'client_aes_256'); the agent receives the unwrapped key only inside backup,
+-- restore and verify command payloads.
+--
+-- Shape 1 tenancy: direct org_id with forced RLS.
+
+CREATE TABLE IF NOT EXISTS device_backup_keys (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id uuid NOT NULL REFERENCES organizations(id),
+  device_id uuid NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+  wrapping_key_id uuid NOT NULL REFERENCES storage_encryption_keys(id),
+  wrapped_key text NOT NULL,
+  created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS device_backup_keys_device_uq
+  ON device_backup_keys(device_id);
+CREATE INDEX IF NOT EXISTS device_backup_keys_org_idx
+  ON device_backup_keys(org_id);
+
+ALTER TABLE device_backup_keys ENABLE ROW LEVEL SECURITY;
+ALTER TABLE device_backup_keys FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS breeze_org_isolation_select ON device_backup_keys;
+DROP POLICY IF EXISTS breeze_org_isolation_insert ON device_backup_keys;
+DROP POLICY IF EXISTS breeze_org_isolation_update ON device_backup_keys;
+DROP POLICY IF EXISTS breeze_org_isolation_delete ON device_backup_keys;
+
+CREATE POLICY breeze_org_isolation_select ON device_backup_keys FOR SELECT USING (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_insert ON device_backup_keys FOR INSERT WITH CHECK (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_update ON device_backup_keys FOR UPDATE USING (
+  public.breeze_has_org_access(org_id)
+) WITH CHECK (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_delete ON device_backup_keys FOR DELETE USING (
+  public.breeze_has_org_access(org_id)
+);
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON device_backup_keys TO breeze_app;
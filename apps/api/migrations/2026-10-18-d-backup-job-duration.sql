@@ -0,0 +1,4 @@
+-- Agent-measured run time of a backup job. started_at/completed_at are
+-- server-side and include dispatch and helper queueing; NULL = legacy agent
+-- that doesn't report it, or a run that failed before producing a result.
+ALTER TABLE backup_jobs ADD COLUMN IF NOT EXISTS duration_ms bigint;
@@ -0,0 +1,4 @@
+-- Latest agent-evaluated patch compliance verdict (max pending age per
+-- severity from the patch config policy), reported after each patch scan.
+ALTER TABLE devices
+  ADD COLUMN IF NOT EXISTS patch_compliance jsonb;
@@ -0,0 +1,10 @@
+-- Defender policy management.
+-- Adds the defender_policy category to the device_change_log change_type enum
+-- so the agent can record before/after values when exclusions, ASR rules or
+-- cloud protection settings are changed from the console.
+--
+-- ALTER TYPE ... ADD VALUE is transaction-safe in PG12+ as long as the new
+-- value is not *used* in the same transaction, so this runs safely under
+-- autoMigrate's per-file transaction. Idempotent.
+
+ALTER TYPE change_type ADD VALUE IF NOT EXISTS 'defender_policy';
@@ -0,0 +1,9 @@
+-- TPM and boot-chain posture reported with the agent's security status, for
+-- conditional access decisions. NULL = not determined (older agent, or the
+-- platform did not report it); false = checked and absent/off.
+ALTER TABLE security_status
+  ADD COLUMN IF NOT EXISTS tpm_present boolean,
+  ADD COLUMN IF NOT EXISTS tpm_version varchar(10),
+  ADD COLUMN IF NOT EXISTS tpm_owned boolean,
+  ADD COLUMN IF NOT EXISTS secure_boot_enabled boolean,
+  ADD COLUMN IF NOT EXISTS measured_boot_available boolean;
@@ -0,0 +1,54 @@
+-- Agent-side vulnerability scan results: installed package/version/CVE
+-- tuples the agent matched against the snapshot served by
+-- GET /agents/:id/vulnerability-snapshot. Each report replaces the device's
+-- rows; server-side correlation keeps writing device_vulnerabilities.
+--
+-- Shape 1 tenancy: direct org_id with forced RLS.
+
+CREATE TABLE IF NOT EXISTS device_vulnerability_scan_findings (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id uuid NOT NULL REFERENCES organizations(id),
+  device_id uuid NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+  software_name varchar(500) NOT NULL,
+  software_version varchar(120) NOT NULL,
+  software_vendor varchar(200),
+  cve_id varchar(32) NOT NULL,
+  cvss_score numeric(4, 1),
+  severity varchar(20),
+  known_exploited boolean NOT NULL DEFAULT false,
+  fixed_version varchar(120),
+  scanned_at timestamp NOT NULL,
+  created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS device_vuln_scan_findings_device_idx
+  ON device_vulnerability_scan_findings(device_id);
+CREATE INDEX IF NOT EXISTS device_vuln_scan_findings_org_idx
+  ON device_vulnerability_scan_findings(org_id);
+CREATE INDEX IF NOT EXISTS device_vuln_scan_findings_cve_idx
+  ON device_vulnerability_scan_findings(cve_id);
+
+ALTER TABLE device_vulnerability_scan_findings ENABLE ROW LEVEL SECURITY;
+ALTER TABLE device_vulnerability_scan_findings FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS breeze_org_isolation_select ON device_vulnerability_scan_findings;
+DROP POLICY IF EXISTS breeze_org_isolation_insert ON device_vulnerability_scan_findings;
+DROP POLICY IF EXISTS breeze_org_isolation_update ON device_vulnerability_scan_findings;
+DROP POLICY IF EXISTS breeze_org_isolation_delete ON device_vulnerability_scan_findings;
+
+CREATE POLICY breeze_org_isolation_select ON device_vulnerability_scan_findings FOR SELECT USING (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_insert ON device_vulnerability_scan_findings FOR INSERT WITH CHECK (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_update ON device_vulnerability_scan_findings FOR UPDATE USING (
+  public.breeze_has_org_access(org_id)
+) WITH CHECK (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_delete ON device_vulnerability_scan_findings FOR DELETE USING (
+  public.breeze_has_org_access(org_id)
+);
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON device_vulnerability_scan_findings TO breeze_app;
@@ -0,0 +1,8 @@
+-- Weighted security score computed by the agent from its own signals, with the
+-- per-factor breakdown, so the console can rank devices without re-deriving
+-- it. NULL = not reported (older agent).
+ALTER TABLE security_status
+  ADD COLUMN IF NOT EXISTS agent_score integer,
+  ADD COLUMN IF NOT EXISTS agent_risk_level security_risk_level,
+  ADD COLUMN IF NOT EXISTS agent_score_factors jsonb,
+  ADD COLUMN IF NOT EXISTS agent_score_computed_at timestamp;
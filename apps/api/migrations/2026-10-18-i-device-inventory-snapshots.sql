@@ -0,0 +1,46 @@
+-- Latest agent-reported inventory per device and kind (process table,
+-- certificates, containers, ...). Each upload replaces the device's row for
+-- that kind; the payload is kept as the agent sent it after validation.
+--
+-- Shape 1 tenancy: direct org_id with forced RLS.
+
+CREATE TABLE IF NOT EXISTS device_inventory_snapshots (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id uuid NOT NULL REFERENCES organizations(id),
+  device_id uuid NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+  kind varchar(64) NOT NULL,
+  data jsonb NOT NULL,
+  item_count integer NOT NULL DEFAULT 0,
+  collected_at timestamp,
+  updated_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS device_inventory_snapshots_device_kind_uniq
+  ON device_inventory_snapshots(device_id, kind);
+CREATE INDEX IF NOT EXISTS device_inventory_snapshots_org_kind_idx
+  ON device_inventory_snapshots(org_id, kind);
+
+ALTER TABLE device_inventory_snapshots ENABLE ROW LEVEL SECURITY;
+ALTER TABLE device_inventory_snapshots FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS breeze_org_isolation_select ON device_inventory_snapshots;
+DROP POLICY IF EXISTS breeze_org_isolation_insert ON device_inventory_snapshots;
+DROP POLICY IF EXISTS breeze_org_isolation_update ON device_inventory_snapshots;
+DROP POLICY IF EXISTS breeze_org_isolation_delete ON device_inventory_snapshots;
+
+CREATE POLICY breeze_org_isolation_select ON device_inventory_snapshots FOR SELECT USING (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_insert ON device_inventory_snapshots FOR INSERT WITH CHECK (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_update ON device_inventory_snapshots FOR UPDATE USING (
+  public.breeze_has_org_access(org_id)
+) WITH CHECK (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_delete ON device_inventory_snapshots FOR DELETE USING (
+  public.breeze_has_org_access(org_id)
+);
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON device_inventory_snapshots TO breeze_app;
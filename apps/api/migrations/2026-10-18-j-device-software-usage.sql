@@ -0,0 +1,50 @@
+-- Daily foreground time per device, user and application, from the agent's
+-- opt-in software usage metering. The agent resends today's running total
+-- until the day ends, so each (device, day, user, app) row is replaced on
+-- upload rather than added to. app_key is the lower-cased path, or the name
+-- when the agent has no path, matching how the agent groups rows.
+--
+-- Shape 1 tenancy: direct org_id with forced RLS.
+
+CREATE TABLE IF NOT EXISTS device_software_usage (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id uuid NOT NULL REFERENCES organizations(id),
+  device_id uuid NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+  usage_date date NOT NULL,
+  username varchar(512) NOT NULL,
+  app_key varchar(512) NOT NULL,
+  app_name varchar(512) NOT NULL,
+  app_path varchar(512),
+  seconds integer NOT NULL,
+  updated_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS device_software_usage_device_day_user_app_uniq
+  ON device_software_usage(device_id, usage_date, username, app_key);
+CREATE INDEX IF NOT EXISTS device_software_usage_org_app_day_idx
+  ON device_software_usage(org_id, app_key, usage_date);
+
+ALTER TABLE device_software_usage ENABLE ROW LEVEL SECURITY;
+ALTER TABLE device_software_usage FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS breeze_org_isolation_select ON device_software_usage;
+DROP POLICY IF EXISTS breeze_org_isolation_insert ON device_software_usage;
+DROP POLICY IF EXISTS breeze_org_isolation_update ON device_software_usage;
+DROP POLICY IF EXISTS breeze_org_isolation_delete ON device_software_usage;
+
+CREATE POLICY breeze_org_isolation_select ON device_software_usage FOR SELECT USING (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_insert ON device_software_usage FOR INSERT WITH CHECK (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_update ON device_software_usage FOR UPDATE USING (
+  public.breeze_has_org_access(org_id)
+) WITH CHECK (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_delete ON device_software_usage FOR DELETE USING (
+  public.breeze_has_org_access(org_id)
+);
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON device_software_usage TO breeze_app;
@@ -0,0 +1,54 @@
+-- Command transcript exports (transcript_export). The agent signs each
+-- archive with a per-device Ed25519 key. Its public half is pinned on the
+-- device at enrollment (or on the first export, for devices enrolled before
+-- agents sent it) and every upload is verified against the pinned key.
+--
+-- Shape 1 tenancy: direct org_id with forced RLS.
+
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS transcript_public_key varchar(64);
+
+CREATE TABLE IF NOT EXISTS device_command_transcripts (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id uuid NOT NULL REFERENCES organizations(id),
+  device_id uuid NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+  command_id uuid NOT NULL REFERENCES device_commands(id) ON DELETE CASCADE,
+  period_from timestamp NOT NULL,
+  period_to timestamp NOT NULL,
+  command_count integer NOT NULL,
+  entry_count integer NOT NULL,
+  chain_verified boolean NOT NULL,
+  archive_sha256 varchar(64) NOT NULL,
+  size_bytes integer NOT NULL,
+  storage_path text NOT NULL,
+  created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS device_command_transcripts_command_uniq
+  ON device_command_transcripts(command_id);
+CREATE INDEX IF NOT EXISTS device_command_transcripts_device_created_idx
+  ON device_command_transcripts(device_id, created_at);
+
+ALTER TABLE device_command_transcripts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE device_command_transcripts FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS breeze_org_isolation_select ON device_command_transcripts;
+DROP POLICY IF EXISTS breeze_org_isolation_insert ON device_command_transcripts;
+DROP POLICY IF EXISTS breeze_org_isolation_update ON device_command_transcripts;
+DROP POLICY IF EXISTS breeze_org_isolation_delete ON device_command_transcripts;
+
+CREATE POLICY breeze_org_isolation_select ON device_command_transcripts FOR SELECT USING (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_insert ON device_command_transcripts FOR INSERT WITH CHECK (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_update ON device_command_transcripts FOR UPDATE USING (
+  public.breeze_has_org_access(org_id)
+) WITH CHECK (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_delete ON device_command_transcripts FOR DELETE USING (
+  public.breeze_has_org_access(org_id)
+);
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON device_command_transcripts TO breeze_app;
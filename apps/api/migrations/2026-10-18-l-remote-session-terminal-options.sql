@@ -0,0 +1,5 @@
+-- Shell, working directory and extra environment variables requested when a
+-- terminal session is created. terminalWs forwards them to the agent in
+-- terminal_start; NULL keeps the per-OS default shell.
+
+ALTER TABLE remote_sessions ADD COLUMN IF NOT EXISTS terminal_options jsonb;
@@ -0,0 +1,5 @@
+-- Encrypted password for a named_user terminal session (AAD
+-- remote_sessions.terminal_password). Cleared once terminal_start has
+-- carried it to the agent.
+
+ALTER TABLE remote_sessions ADD COLUMN IF NOT EXISTS terminal_password text;
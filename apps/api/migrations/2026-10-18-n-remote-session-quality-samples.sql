@@ -0,0 +1,59 @@
+-- Desktop stream quality samples. The agent uploads one sample per 10s of a
+-- desktop session (fps, encode time, RTT, loss, bitrate, resolution and the
+-- capture/encode path) so support can review a session's quality later.
+--
+-- Shape 1 tenancy: direct org_id with forced RLS.
+
+CREATE TABLE IF NOT EXISTS remote_session_quality_samples (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id uuid NOT NULL REFERENCES organizations(id),
+  device_id uuid NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+  session_id uuid NOT NULL REFERENCES remote_sessions(id) ON DELETE CASCADE,
+  sampled_at timestamp NOT NULL,
+  interval_ms integer NOT NULL,
+  fps real NOT NULL,
+  encode_ms real NOT NULL,
+  rtt_ms real NOT NULL,
+  loss_pct real NOT NULL,
+  bitrate_kbps integer NOT NULL,
+  sent_kbps real NOT NULL,
+  width integer NOT NULL,
+  height integer NOT NULL,
+  downscale_level integer NOT NULL,
+  codec varchar(16),
+  encoder varchar(32),
+  hardware_encode boolean NOT NULL,
+  gpu_capture boolean NOT NULL,
+  frames_dropped bigint NOT NULL,
+  created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS remote_session_quality_samples_session_sampled_uniq
+  ON remote_session_quality_samples(session_id, sampled_at);
+CREATE INDEX IF NOT EXISTS remote_session_quality_samples_device_sampled_idx
+  ON remote_session_quality_samples(device_id, sampled_at);
+
+ALTER TABLE remote_session_quality_samples ENABLE ROW LEVEL SECURITY;
+ALTER TABLE remote_session_quality_samples FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS breeze_org_isolation_select ON remote_session_quality_samples;
+DROP POLICY IF EXISTS breeze_org_isolation_insert ON remote_session_quality_samples;
+DROP POLICY IF EXISTS breeze_org_isolation_update ON remote_session_quality_samples;
+DROP POLICY IF EXISTS breeze_org_isolation_delete ON remote_session_quality_samples;
+
+CREATE POLICY breeze_org_isolation_select ON remote_session_quality_samples FOR SELECT USING (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_insert ON remote_session_quality_samples FOR INSERT WITH CHECK (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_update ON remote_session_quality_samples FOR UPDATE USING (
+  public.breeze_has_org_access(org_id)
+) WITH CHECK (
+  public.breeze_has_org_access(org_id)
+);
+CREATE POLICY breeze_org_isolation_delete ON remote_session_quality_samples FOR DELETE USING (
+  public.breeze_has_org_access(org_id)
+);
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON remote_session_quality_samples TO breeze_app;
@@ -0,0 +1,4 @@
+-- Latest agent-evaluated compliance with the patch policy's third-party app
+-- update rings (winget, Chocolatey, Homebrew), reported after each patch scan.
+ALTER TABLE devices
+  ADD COLUMN IF NOT EXISTS app_update_compliance jsonb;
@@ -48,7 +48,7 @@ const parityCases: Array<{ name: string; mirror: unknown; serializedMirror?: str
     },
   },
   {
-    name: 'a provider-backed source may be combined with a providerless source',
+    name: 'an OS source may be combined with drivers',
     mirror: { ...meaningfulMirror, sources: ['os', 'drivers'] },
   },
   {
@@ -82,7 +82,7 @@ const parityCases: Array<{ name: string; mirror: unknown; serializedMirror?: str
   { name: 'sources cannot be empty', mirror: { ...meaningfulMirror, sources: [] } },
   { name: 'sources reject unknown values', mirror: { ...meaningfulMirror, sources: ['unknown'] } },
   {
-    name: 'providerless-only sources fail whole-document refinement',
+    name: 'firmware/drivers-only sources are a valid selection',
     mirror: { ...meaningfulMirror, sources: ['firmware', 'drivers'] },
   },
   { name: 'autoApprove must be boolean', mirror: { ...meaningfulMirror, autoApprove: 'true' } },
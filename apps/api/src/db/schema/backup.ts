@@ -230,6 +230,8 @@ export const backupJobs = pgTable(
     // dedup (legacy agent, or nothing was referenced).
     referencedSize: bigint('referenced_size', { mode: 'number' }),
     referencedFiles: integer('referenced_files'),
+    // Agent-measured run time. NULL = legacy agent or no structured result.
+    durationMs: bigint('duration_ms', { mode: 'number' }),
     createdAt: timestamp('created_at').defaultNow().notNull(),
     updatedAt: timestamp('updated_at').defaultNow().notNull(),
   },
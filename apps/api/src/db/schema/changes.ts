@@ -19,7 +19,10 @@ export const changeTypeEnum = pgEnum('change_type', [
   'scheduled_task',
   'user_account',
   'hardware',
-  'os_version'
+  'os_version',
+  'usb_device',
+  'registry',
+  'defender_policy'
 ]);
 
 export const changeActionEnum = pgEnum('change_action', [
@@ -1,7 +1,7 @@
 import { pgTable, uuid, varchar, text, timestamp, boolean, jsonb, pgEnum, integer, real, bigint, date, primaryKey, index, unique, uniqueIndex } from 'drizzle-orm/pg-core';
 import { organizations, sites } from './orgs';
 import { users } from './users';
-import type { BatteryStatus, DesktopAccessState, InterfaceBandwidth, TCCPermissions, VpnPresence } from '@breeze/shared';
+import type { BatteryStatus, DesktopAccessState, DeviceAppUpdateCompliance, DeviceLocation, DevicePatchCompliance, DevicePatchRebootDeferral, InterfaceBandwidth, TCCPermissions, VpnPresence } from '@breeze/shared';
 
 export const osTypeEnum = pgEnum('os_type', ['windows', 'macos', 'linux']);
 export const deviceStatusEnum = pgEnum('device_status', ['online', 'offline', 'maintenance', 'decommissioned', 'quarantined', 'updating', 'pending']);
@@ -38,6 +38,9 @@ export const devices = pgTable('devices', {
   mtlsCertExpiresAt: timestamp('mtls_cert_expires_at'),
   mtlsCertIssuedAt: timestamp('mtls_cert_issued_at'),
   mtlsCertCfId: varchar('mtls_cert_cf_id', { length: 128 }),
+  // Base64 Ed25519 public key the agent signs command transcripts with,
+  // pinned at enrollment.
+  transcriptPublicKey: varchar('transcript_public_key', { length: 64 }),
   quarantinedAt: timestamp('quarantined_at'),
   quarantinedReason: varchar('quarantined_reason', { length: 255 }),
   // Task 18: Auto-suspend agent tokens after repeated cross-tenant probe
@@ -118,6 +121,19 @@ export const devices = pgTable('devices', {
   // no active VPN. Backs the optional "VPN" list column and the device-detail
   // VPN section. Read-only telemetry — no secrets/peers/keys.
   activeVpns: jsonb('active_vpns').$type<VpnPresence[] | null>(),
+  // Last approximate location from the agent heartbeat, stored only while
+  // org/site policy enables geolocation and cleared when it is turned off.
+  // Latest value only; null when never reported or not permitted.
+  lastLocation: jsonb('last_location').$type<DeviceLocation | null>(),
+  // Latest agent-evaluated patch compliance verdict against the policy's
+  // complianceMaxAgeDays. null when never reported or no policy applies.
+  patchCompliance: jsonb('patch_compliance').$type<DevicePatchCompliance | null>(),
+  // Latest agent-evaluated per-app compliance with the patch policy's app
+  // update rings. null when never reported.
+  appUpdateCompliance: jsonb('app_update_compliance').$type<DeviceAppUpdateCompliance | null>(),
+  // The user's postpone choices for the scheduled patch reboot, from full
+  // pending-patch uploads. null when the current reboot was not postponed.
+  patchRebootDeferral: jsonb('patch_reboot_deferral').$type<DevicePatchRebootDeferral | null>(),
   watchdogStatus: watchdogStatusEnum('watchdog_status'),
   watchdogLastSeen: timestamp('watchdog_last_seen'),
   watchdogVersion: varchar('watchdog_version', { length: 50 }),
@@ -165,6 +181,23 @@ export const deviceLinkGroups = pgTable('device_link_groups', {
   idOrgUnique: uniqueIndex('device_link_groups_id_org_id_uniq').on(table.id, table.orgId),
 }));
 
+// Attached monitor reported by the agent's EDID inventory.
+export type DeviceDisplay = {
+  manufacturer?: string;
+  manufacturerId?: string;
+  model?: string;
+  productCode?: string;
+  serialNumber?: string;
+  manufactureYear?: number;
+  manufactureWeek?: number;
+  nativeResolution?: string;
+  currentResolution?: string;
+  connectionType?: string;
+  internal?: boolean;
+  widthCm?: number;
+  heightCm?: number;
+};
+
 export const deviceHardware = pgTable('device_hardware', {
   deviceId: uuid('device_id').primaryKey().references(() => devices.id),
   orgId: uuid('org_id').notNull().references(() => organizations.id),
@@ -181,6 +214,7 @@ export const deviceHardware = pgTable('device_hardware', {
   motherboardProduct: varchar('motherboard_product', { length: 255 }),
   motherboardVersion: varchar('motherboard_version', { length: 255 }),
   biosVersion: varchar('bios_version', { length: 100 }),
+  displays: jsonb('displays').$type<DeviceDisplay[]>(),
   updatedAt: timestamp('updated_at').defaultNow().notNull(),
   partnerExportUpdatedAt: timestamp('partner_export_updated_at', { precision: 3 }).defaultNow().notNull()
 });
@@ -439,6 +473,61 @@ export const deviceConnections = pgTable('device_connections', {
   deviceUpdatedIdx: index('device_connections_device_updated_idx').on(table.deviceId, table.updatedAt)
 }));
 
+// Latest agent-reported inventory per device and kind (processes,
+// certificates, containers, ...). Each upload replaces the row for its kind.
+export const deviceInventorySnapshots = pgTable('device_inventory_snapshots', {
+  id: uuid('id').primaryKey().defaultRandom(),
+  orgId: uuid('org_id').notNull().references(() => organizations.id),
+  deviceId: uuid('device_id').notNull().references(() => devices.id, { onDelete: 'cascade' }),
+  kind: varchar('kind', { length: 64 }).notNull(),
+  data: jsonb('data').notNull(),
+  itemCount: integer('item_count').notNull().default(0),
+  collectedAt: timestamp('collected_at'),
+  updatedAt: timestamp('updated_at').defaultNow().notNull()
+}, (table) => ({
+  deviceKindUnique: uniqueIndex('device_inventory_snapshots_device_kind_uniq').on(table.deviceId, table.kind),
+  orgKindIdx: index('device_inventory_snapshots_org_kind_idx').on(table.orgId, table.kind)
+}));
+
+// Daily foreground time per user and application from opt-in software usage
+// metering. Today's row is replaced by each upload until the day ends.
+export const deviceSoftwareUsage = pgTable('device_software_usage', {
+  id: uuid('id').primaryKey().defaultRandom(),
+  orgId: uuid('org_id').notNull().references(() => organizations.id),
+  deviceId: uuid('device_id').notNull().references(() => devices.id, { onDelete: 'cascade' }),
+  usageDate: date('usage_date').notNull(),
+  username: varchar('username', { length: 512 }).notNull(),
+  appKey: varchar('app_key', { length: 512 }).notNull(),
+  appName: varchar('app_name', { length: 512 }).notNull(),
+  appPath: varchar('app_path', { length: 512 }),
+  seconds: integer('seconds').notNull(),
+  updatedAt: timestamp('updated_at').defaultNow().notNull()
+}, (table) => ({
+  deviceDayUserAppUnique: uniqueIndex('device_software_usage_device_day_user_app_uniq').on(table.deviceId, table.usageDate, table.username, table.appKey),
+  orgAppDayIdx: index('device_software_usage_org_app_day_idx').on(table.orgId, table.appKey, table.usageDate)
+}));
+
+// Signed command transcript archives uploaded for transcript_export commands.
+// The archive itself lives under TRANSCRIPT_STORAGE_PATH.
+export const deviceCommandTranscripts = pgTable('device_command_transcripts', {
+  id: uuid('id').primaryKey().defaultRandom(),
+  orgId: uuid('org_id').notNull().references(() => organizations.id),
+  deviceId: uuid('device_id').notNull().references(() => devices.id, { onDelete: 'cascade' }),
+  commandId: uuid('command_id').notNull().references(() => deviceCommands.id, { onDelete: 'cascade' }),
+  periodFrom: timestamp('period_from').notNull(),
+  periodTo: timestamp('period_to').notNull(),
+  commandCount: integer('command_count').notNull(),
+  entryCount: integer('entry_count').notNull(),
+  chainVerified: boolean('chain_verified').notNull(),
+  archiveSha256: varchar('archive_sha256', { length: 64 }).notNull(),
+  sizeBytes: integer('size_bytes').notNull(),
+  storagePath: text('storage_path').notNull(),
+  createdAt: timestamp('created_at').defaultNow().notNull()
+}, (table) => ({
+  commandUnique: uniqueIndex('device_command_transcripts_command_uniq').on(table.commandId),
+  deviceCreatedIdx: index('device_command_transcripts_device_created_idx').on(table.deviceId, table.createdAt)
+}));
+
 // Boot performance metrics - stores boot time history and startup item analysis per device
 export interface BootStartupItem {
   itemId?: string;
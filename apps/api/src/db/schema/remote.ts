@@ -1,4 +1,4 @@
-import { pgTable, uuid, text, timestamp, jsonb, pgEnum, integer, bigint } from 'drizzle-orm/pg-core';
+import { pgTable, uuid, text, timestamp, jsonb, pgEnum, integer, bigint, real, varchar, boolean, index, uniqueIndex } from 'drizzle-orm/pg-core';
 import { devices } from './devices';
 import { users } from './users';
 import { organizations } from './orgs';
@@ -22,5 +22,44 @@ export const remoteSessions = pgTable('remote_sessions', {
   bytesTransferred: bigint('bytes_transferred', { mode: 'bigint' }),
   recordingUrl: text('recording_url'),
   errorMessage: text('error_message'),
+  // Terminal launch options from the create request; see routes/remote/schemas.ts.
+  terminalOptions: jsonb('terminal_options').$type<{
+    shell?: string;
+    cwd?: string;
+    env?: Record<string, string>;
+    mode?: 'system' | 'user' | 'named_user';
+    username?: string;
+  }>(),
+  // Encrypted named_user password (AAD remote_sessions.terminal_password).
+  terminalPassword: text('terminal_password'),
   createdAt: timestamp('created_at').defaultNow().notNull()
 });
+
+// Desktop stream quality samples uploaded by the agent, one per 10s of a
+// desktop session (POST /agents/:id/desktop-sessions/:sessionId/metrics).
+export const remoteSessionQualitySamples = pgTable('remote_session_quality_samples', {
+  id: uuid('id').primaryKey().defaultRandom(),
+  orgId: uuid('org_id').notNull().references(() => organizations.id),
+  deviceId: uuid('device_id').notNull().references(() => devices.id, { onDelete: 'cascade' }),
+  sessionId: uuid('session_id').notNull().references(() => remoteSessions.id, { onDelete: 'cascade' }),
+  sampledAt: timestamp('sampled_at').notNull(),
+  intervalMs: integer('interval_ms').notNull(),
+  fps: real('fps').notNull(),
+  encodeMs: real('encode_ms').notNull(),
+  rttMs: real('rtt_ms').notNull(),
+  lossPct: real('loss_pct').notNull(),
+  bitrateKbps: integer('bitrate_kbps').notNull(),
+  sentKbps: real('sent_kbps').notNull(),
+  width: integer('width').notNull(),
+  height: integer('height').notNull(),
+  downscaleLevel: integer('downscale_level').notNull(),
+  codec: varchar('codec', { length: 16 }),
+  encoder: varchar('encoder', { length: 32 }),
+  hardwareEncode: boolean('hardware_encode').notNull(),
+  gpuCapture: boolean('gpu_capture').notNull(),
+  framesDropped: bigint('frames_dropped', { mode: 'number' }).notNull(),
+  createdAt: timestamp('created_at').defaultNow().notNull()
+}, (table) => ({
+  sessionSampledUnique: uniqueIndex('remote_session_quality_samples_session_sampled_uniq').on(table.sessionId, table.sampledAt),
+  deviceSampledIdx: index('remote_session_quality_samples_device_sampled_idx').on(table.deviceId, table.sampledAt)
+}));
@@ -68,6 +68,15 @@ export const securityStatus = pgTable('security_status', {
   localAdminSummary: jsonb('local_admin_summary'),
   passwordPolicySummary: jsonb('password_policy_summary'),
   gatekeeperEnabled: boolean('gatekeeper_enabled'),
+  tpmPresent: boolean('tpm_present'),
+  tpmVersion: varchar('tpm_version', { length: 10 }),
+  tpmOwned: boolean('tpm_owned'),
+  secureBootEnabled: boolean('secure_boot_enabled'),
+  measuredBootAvailable: boolean('measured_boot_available'),
+  agentScore: integer('agent_score'),
+  agentRiskLevel: securityRiskLevelEnum('agent_risk_level'),
+  agentScoreFactors: jsonb('agent_score_factors'),
+  agentScoreComputedAt: timestamp('agent_score_computed_at'),
   updatedAt: timestamp('updated_at').defaultNow().notNull()
 }, (table) => ({
   deviceUnique: uniqueIndex('security_status_device_id_unique').on(table.deviceId),
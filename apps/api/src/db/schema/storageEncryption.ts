@@ -1,5 +1,6 @@
-import { pgTable, uuid, varchar, text, boolean, timestamp, index } from 'drizzle-orm/pg-core';
+import { pgTable, uuid, varchar, text, boolean, timestamp, index, uniqueIndex } from 'drizzle-orm/pg-core';
 import { organizations } from './orgs';
+import { devices } from './devices';
 
 export const storageEncryptionKeys = pgTable('storage_encryption_keys', {
   id: uuid('id').primaryKey().defaultRandom(),
@@ -17,3 +18,19 @@ export const storageEncryptionKeys = pgTable('storage_encryption_keys', {
   orgIdx: index('encryption_keys_org_idx').on(table.orgId),
   activeIdx: index('encryption_keys_active_idx').on(table.orgId, table.isActive),
 }));
+
+// Per-device data keys for client-side backup encryption. Each key is
+// AES-GCM wrapped by the org's escrowed client key (a storage_encryption_keys
+// row with key_type 'client_aes_256'), so the plaintext key never rests in
+// the database.
+export const deviceBackupKeys = pgTable('device_backup_keys', {
+  id: uuid('id').primaryKey().defaultRandom(),
+  orgId: uuid('org_id').notNull().references(() => organizations.id),
+  deviceId: uuid('device_id').notNull().references(() => devices.id, { onDelete: 'cascade' }),
+  wrappingKeyId: uuid('wrapping_key_id').notNull().references(() => storageEncryptionKeys.id),
+  wrappedKey: text('wrapped_key').notNull(),
+  createdAt: timestamp('created_at').defaultNow().notNull(),
+}, (table) => ({
+  deviceUnique: uniqueIndex('device_backup_keys_device_uq').on(table.deviceId),
+  orgIdx: index('device_backup_keys_org_idx').on(table.orgId),
+}));
@@ -147,3 +147,26 @@ export const deviceVulnerabilities = pgTable('device_vulnerabilities', {
   // software_inventory row (bypassing RLS) and would otherwise scan this table.
   softwareInventoryIdx: index('device_vuln_software_inventory_idx').on(table.softwareInventoryId).where(sql`software_inventory_id IS NOT NULL`),
 }));
+
+// Agent-side scan results: installed package versions the agent matched
+// against its vulnerability snapshot. Replaced wholesale on every report,
+// independent of the server-side correlation in device_vulnerabilities.
+export const deviceVulnerabilityScanFindings = pgTable('device_vulnerability_scan_findings', {
+  id: uuid('id').primaryKey().defaultRandom(),
+  orgId: uuid('org_id').notNull().references(() => organizations.id),
+  deviceId: uuid('device_id').notNull().references(() => devices.id, { onDelete: 'cascade' }),
+  softwareName: varchar('software_name', { length: 500 }).notNull(),
+  softwareVersion: varchar('software_version', { length: 120 }).notNull(),
+  softwareVendor: varchar('software_vendor', { length: 200 }),
+  cveId: varchar('cve_id', { length: 32 }).notNull(),
+  cvssScore: numeric('cvss_score', { precision: 4, scale: 1 }),
+  severity: varchar('severity', { length: 20 }),
+  knownExploited: boolean('known_exploited').notNull().default(false),
+  fixedVersion: varchar('fixed_version', { length: 120 }),
+  scannedAt: timestamp('scanned_at').notNull(),
+  createdAt: timestamp('created_at').defaultNow().notNull(),
+}, (table) => ({
+  deviceIdx: index('device_vuln_scan_findings_device_idx').on(table.deviceId),
+  orgIdx: index('device_vuln_scan_findings_org_idx').on(table.orgId),
+  cveIdx: index('device_vuln_scan_findings_cve_idx').on(table.cveId),
+}));
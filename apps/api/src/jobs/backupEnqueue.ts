@@ -53,10 +53,15 @@ export interface ProcessResultsResult {
   errorCount?: number;
   referencedFiles?: number;
   referencedBytes?: number;
+  // Agent-measured run time; persisted to backup_jobs.duration_ms.
+  durationMs?: number;
   // system_image (system-state) backups carry these; the WS handler must
   // forward them or the snapshot loses its type label + BMR restore manifest.
   backupType?: 'file' | 'system_image' | 'database' | 'application';
   systemStateManifest?: Record<string, unknown> | null;
+  // Windows file backups taken from a shadow copy; persisted to
+  // backup_jobs.vss_metadata so writer failures are visible on the job.
+  vssMetadata?: Record<string, unknown> | null;
   snapshot?: {
     id: string;
     timestamp?: string;
@@ -37,8 +37,9 @@ import {
 } from './backupRetention';
 import * as backupEnqueue from './backupEnqueue';
 import { resolveBackupStorageEncryptionPlan } from '../services/backupEncryption';
+import { getOrCreateDeviceBackupKey, type ClientBackupKey } from '../services/backupClientKeys';
 import { backupCommandResultSchema } from '../routes/backup/resultSchemas';
-import { getDueOccurrenceKey } from '../routes/backup/helpers';
+import { buildBackupRunLimits, getDueOccurrenceKey, type BackupRunLimits } from '../routes/backup/helpers';
 import { applyBackupCommandResultToJob } from '../services/backupResultPersistence';
 import { markBackupJobFailedIfInFlight } from '../services/backupResultPersistence';
 import { createScheduledBackupJobIfAbsent } from '../services/backupJobCreation';
@@ -122,6 +123,9 @@ type PolicySchedule = {
   timezone?: string;
   dayOfWeek?: number;
   dayOfMonth?: number;
+  windowStart?: string;
+  windowEnd?: string;
+  bandwidthLimitMbps?: number;
 };
 
 const SCHEDULE_LOOKBACK_MINUTES = 5;
@@ -531,6 +535,18 @@ async function processDispatchBackup(
     }
   }
 
+  // The policy schedule's backup window and upload cap ride every backup_run;
+  // the agent enforces both, so a late run can't saturate the link.
+  let runLimits: BackupRunLimits = {};
+  if (job?.featureLinkId) {
+    const [linkSettings] = await db
+      .select({ schedule: configPolicyBackupSettings.schedule })
+      .from(configPolicyBackupSettings)
+      .where(eq(configPolicyBackupSettings.featureLinkId, job.featureLinkId))
+      .limit(1);
+    runLimits = buildBackupRunLimits(linkSettings?.schedule as PolicySchedule | null);
+  }
+
   // Resolve targets into typed commands based on backup mode
   const targets = await resolveBackupTargets(backupMode, modeTargets, data.deviceId);
 
@@ -563,6 +579,19 @@ async function processDispatchBackup(
     return { dispatched: false };
   }
 
+  // Client-side encryption: the agent receives this device's data key and
+  // encrypts before upload. Fail closed rather than back up in plaintext.
+  let clientEncryption: ClientBackupKey | null = null;
+  if (encryptionPlan.required && encryptionPlan.mode === 'client-aes-gcm') {
+    try {
+      clientEncryption = await getOrCreateDeviceBackupKey(data.orgId, data.deviceId);
+    } catch (err) {
+      console.error(`[BackupWorker] Failed to resolve backup client key for device ${data.deviceId}:`, err);
+      await markJobFailed(data.jobId, 'Backup client-side encryption key is unavailable');
+      return { dispatched: false };
+    }
+  }
+
   const commandProviderConfig =
     encryptionPlan.required && encryptionPlan.status === 'enforced'
       ? { ...providerConfig, ...encryptionPlan.providerConfigPatch }
@@ -625,6 +654,8 @@ async function processDispatchBackup(
               required: false,
               mode: 'disabled',
             },
+        ...(clientEncryption ? { clientEncryption } : {}),
+        ...(target.commandType === 'backup_run' ? runLimits : {}),
         ...target.payload,
       },
     };
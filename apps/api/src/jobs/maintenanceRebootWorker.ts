@@ -9,8 +9,8 @@
  * Windows gets the rich warn-then-reboot manager (schedule_reboot). At a
  * 15-minute delay the agent's staged warning fires the 5-minutes-before "save
  * your work" notification (its thresholds are 60/15/5 min with strict `>`),
- * plus the circuit-breaker. Linux gets an OS-scheduled reboot
- * (`shutdown -r +15`, wall warning to logged-in users). macOS is never a
+ * plus the circuit-breaker. Linux gets `reboot` with a 15-minute delay, which
+ * the agent schedules through the same manager and limit. macOS is never a
  * candidate because `DetectPendingReboot()` is a deliberate no-op stub on
  * macOS — `pending_reboot` is therefore never true there.
  */
@@ -232,6 +232,20 @@ describe('backupProcessResultSchema — system_image manifest passthrough', () =
   });
 });
 
+describe('backupProcessResultSchema — VSS metadata passthrough', () => {
+  it('accepts vssMetadata on a Windows file backup result', () => {
+    const result = backupProcessResultSchema.parse({
+      status: 'completed',
+      snapshotId: 'snap-1',
+      vssMetadata: {
+        shadowCopyId: '{3f2a}',
+        writers: [{ name: 'SqlServerWriter', state: 'failed' }],
+      },
+    });
+    expect((result.vssMetadata as { shadowCopyId: string }).shadowCopyId).toBe('{3f2a}');
+  });
+});
+
 describe('backupProcessResultSchema — incremental dedup + partial-success passthrough', () => {
   // Regression guard: same failure mode as the system_image block above — the
   // strict schema (and the WS enqueue call) lacked referencedFiles/
@@ -247,10 +261,12 @@ describe('backupProcessResultSchema — incremental dedup + partial-success pass
       referencedFiles: 13,
       referencedBytes: 14351000,
       errorCount: 2,
+      durationMs: 61250,
     });
     expect(result.referencedFiles).toBe(13);
     expect(result.referencedBytes).toBe(14351000);
     expect(result.errorCount).toBe(2);
+    expect(result.durationMs).toBe(61250);
   });
 
   it('leaves the fields undefined when omitted (legacy agent / full backup)', () => {
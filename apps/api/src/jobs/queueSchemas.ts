@@ -30,6 +30,7 @@ export const backupProcessResultSchema = z.object({
   errorCount: z.number().int().nonnegative().optional(),
   referencedFiles: z.number().int().nonnegative().optional(),
   referencedBytes: z.number().nonnegative().optional(),
+  durationMs: z.number().int().nonnegative().optional(),
   // system_image (system-state) backups carry the OS-artifact manifest and a
   // derived backup type; forwarded through the queue so persistence can label
   // the snapshot and BMR restore can read the manifest. Manifest typed as an
@@ -38,6 +39,8 @@ export const backupProcessResultSchema = z.object({
   // still must be declared here or the whole job fails validation.
   backupType: z.enum(['file', 'system_image', 'database', 'application']).optional(),
   systemStateManifest: z.record(z.string(), z.unknown()).nullish(),
+  // Shadow-copy id, writer states and warnings for Windows file backups.
+  vssMetadata: z.record(z.string(), z.unknown()).nullish(),
   snapshot: backupSnapshotSummarySchema.optional(),
   error: z.string().min(1).optional(),
 }).strict();
@@ -6,7 +6,7 @@ import { createHash } from 'crypto';
 import { db, withDbAccessContext, withSystemDbAccessContext, runOutsideDbContext } from '../db';
 import { dbWriteExpectingRows } from '../db/dbWriteExpectingRows';
 import { devices, deviceCommands, discoveryJobs, scriptExecutions, scriptExecutionBatches, remoteSessions, backupJobs, restoreJobs, tunnelSessions } from '../db/schema';
-import { handleTerminalOutput, getActiveTerminalSession, unregisterTerminalOutputCallback } from './terminalWs';
+import { handleTerminalOutput, getActiveTerminalSession, restartTerminalSession, unregisterTerminalOutputCallback } from './terminalWs';
 import { handleDesktopFrame, isDesktopSessionOwnedByAgent } from './desktopWs';
 import { handleTunnelDataFromAgent, isTunnelOwnedByAgent, registerTunnelOwnership } from './tunnelWs';
 import { enqueueDiscoveryResults, type DiscoveredHostResult, type DeviceAdjacency } from '../jobs/discoveryWorker';
@@ -31,6 +31,9 @@ import {
   isLegacyBackupTimeoutResult,
   tryParseBackupResultPayload,
 } from '../services/backupProgress';
+import { applyPatchProgress } from '../services/patchProgress';
+import { applyAvScanProgress } from '../services/avScanProgress';
+import { applySecurityAlert } from '../services/securityAlerts';
 import { backupCommandResultSchema } from './backup/resultSchemas';
 import { matchRoleScopedAgentTokenHash, suspendAgentToken, type AgentCredentialRole } from '../middleware/agentAuth';
 import { AGENT_TOKEN_SUSPEND_REASON } from '../services/agentTokenSuspension';
@@ -47,6 +50,7 @@ import { revokeViewerSession } from '../services/viewerTokenRevocation';
 import { logSessionAudit, classifyConsentDenyAction, resolveConsentMarkerSessionId } from './remote/helpers';
 import { getActiveTrustKeyset } from '../services/manifestSigning';
 import { resolvePendingAgentCommand } from '../services/agentCommandAwait';
+import { relayProcessSnapshot } from '../services/processStream';
 import { UUID_REGEX } from '../utils/uuid';
 
 /** Capabilities advertised to agents in the post-connect `connected` message. */
@@ -466,6 +470,22 @@ async function handleSensitiveDataResult({ agentId, command, result, stdout }: P
   }
 }
 
+async function handleAvScanResult({ agentId, command, result, stdout }: Parameters<CommandResultHandler>[0]): Promise<void> {
+  try {
+    const { handleSecurityCommandResult } = await import('./agents/helpers');
+    await handleSecurityCommandResult(command, {
+      status: result.status,
+      exitCode: result.exitCode,
+      stdout,
+      stderr: result.stderr,
+      durationMs: result.durationMs,
+      error: result.error,
+    } as any);
+  } catch (err) {
+    console.error(`[AgentWs] Failed to process av scan result for ${agentId}:`, err);
+  }
+}
+
 async function handleCisResult({ agentId, command, result, stdout }: Parameters<CommandResultHandler>[0]): Promise<void> {
   try {
     const { handleCisCommandResult } = await import('./agents/helpers');
@@ -499,6 +519,7 @@ const commandResultHandlers: Record<string, CommandResultHandler> = {
   encrypt_file: handleSensitiveDataResult,
   secure_delete_file: handleSensitiveDataResult,
   quarantine_file: handleSensitiveDataResult,
+  av_scan: handleAvScanResult,
   cis_benchmark: handleCisResult,
   apply_cis_remediation: handleCisResult,
 };
@@ -795,11 +816,39 @@ const backupProgressMessageSchema = z.object({
   progress: z.record(z.string(), z.unknown()).optional(),
 });
 
+// Live install/download progress for an in-flight patch command (agent side:
+// websocket.Client.SendPatchProgress). Like backup_progress, `progress` is
+// loose here and validated field-by-field in applyPatchProgress.
+const patchProgressMessageSchema = z.object({
+  type: z.literal('patch_progress'),
+  commandId: z.string(),
+  progress: z.record(z.string(), z.unknown()),
+});
+
+// Live progress for an in-flight av_scan (agent side:
+// websocket.Client.SendAVScanProgress); validated in applyAvScanProgress.
+const avScanProgressMessageSchema = z.object({
+  type: z.literal('av_scan_progress'),
+  commandId: z.string(),
+  progress: z.record(z.string(), z.unknown()),
+});
+
+// Urgent security alert (tripped ransomware canary, network isolation
+// change; agent side: websocket.Client.SendSecurityAlert). Validated in
+// applySecurityAlert.
+const securityAlertMessageSchema = z.object({
+  type: z.literal('security_alert'),
+  alert: z.record(z.string(), z.unknown()),
+});
+
 const agentMessageSchema = z.discriminatedUnion('type', [
   commandResultSchema,
   heartbeatMessageSchema,
   terminalOutputSchema,
-  backupProgressMessageSchema
+  backupProgressMessageSchema,
+  patchProgressMessageSchema,
+  avScanProgressMessageSchema,
+  securityAlertMessageSchema
 ]);
 
 // Command types sent to agent
@@ -1467,8 +1516,10 @@ export async function processOrphanedCommandResult(
             errorCount: backupData?.errorCount,
             referencedFiles: backupData?.referencedFiles,
             referencedBytes: backupData?.referencedBytes,
+            durationMs: backupData?.durationMs,
             backupType: backupData?.backupType,
             systemStateManifest: backupData?.systemStateManifest,
+            vssMetadata: backupData?.vssMetadata,
             snapshot: backupData?.snapshot,
             error: malformedPayloadError || result.error || result.stderr,
           },
@@ -2041,6 +2092,22 @@ export function createAgentWsHandlers(agentId: string, preValidatedAgent: AgentD
           return;
         }
 
+        // Handle process_snapshot messages: live process-table frames for a
+        // console Task Manager view, relayed in memory and never persisted.
+        if (message?.type === 'process_snapshot') {
+          const parsed = processSnapshotFastPathSchema.safeParse(message);
+          if (!parsed.success) {
+            console.warn(`[AgentWs] Dropping malformed process_snapshot from agent ${agentId}: ${parsed.error.issues[0]?.message}`);
+            return;
+          }
+          const relayed = relayProcessSnapshot(agentId, parsed.data.streamId, parsed.data.snapshot);
+          if (!relayed.relayed && relayed.reason === 'agent-mismatch') {
+            console.warn(`[AgentWs] Dropping process_snapshot for unowned stream ${parsed.data.streamId} from agent ${agentId}`);
+            recordCrossTenantDrop(agentId, authenticatedAgent?.deviceId, 'process_snapshot');
+          }
+          return;
+        }
+
         // Handle update_status messages: agent is about to self-update
         if (message.type === 'update_status' && typeof message.targetVersion === 'string') {
           if (agentDb) {
@@ -2100,7 +2167,12 @@ export function createAgentWsHandlers(agentId: string, preValidatedAgent: AgentD
             const termSessionId = parts.length >= 3 ? parts.slice(2).join('-') : null;
             if (termSessionId) {
               const termSession = getActiveTerminalSession(termSessionId);
-              if (termSession && termSession.agentId === agentId) {
+              if (termSession && termSession.agentId === agentId &&
+                  fastCommandId.startsWith('term-attach-') && restartTerminalSession(termSessionId)) {
+                // The detached PTY is gone (grace expired, agent restarted);
+                // the viewer gets a fresh shell instead of an error.
+                console.warn(`[AgentWs] Terminal reattach failed for session ${termSessionId}, starting a new shell: ${fastError ?? 'Unknown error'}`);
+              } else if (termSession && termSession.agentId === agentId) {
                 const errorDetail = fastError ?? 'Unknown error';
                 try {
                   termSession.userWs.send(JSON.stringify({
@@ -2389,6 +2461,68 @@ export function createAgentWsHandlers(agentId: string, preValidatedAgent: AgentD
             break;
           }
 
+          case 'patch_progress': {
+            const progressMessage = parsed.data as z.infer<typeof patchProgressMessageSchema>;
+            await runWithAgentDbAccess(async () => {
+              const applied = await applyPatchProgress({
+                agentId,
+                commandId: progressMessage.commandId,
+                progress: progressMessage.progress,
+              });
+              if (!applied.applied) {
+                const dropLog = applied.reason === 'agent-mismatch' ? console.warn : console.debug;
+                dropLog(
+                  `[AgentWs] Dropping patch_progress for ${progressMessage.commandId} from agent ${agentId}: reason=${applied.reason}`
+                );
+              }
+            });
+            break;
+          }
+
+          case 'av_scan_progress': {
+            const progressMessage = parsed.data as z.infer<typeof avScanProgressMessageSchema>;
+            await runWithAgentDbAccess(async () => {
+              const applied = await applyAvScanProgress({
+                agentId,
+                commandId: progressMessage.commandId,
+                progress: progressMessage.progress,
+              });
+              if (!applied.applied) {
+                const dropLog = applied.reason === 'agent-mismatch' ? console.warn : console.debug;
+                dropLog(
+                  `[AgentWs] Dropping av_scan_progress for ${progressMessage.commandId} from agent ${agentId}: reason=${applied.reason}`
+                );
+              }
+            });
+            break;
+          }
+
+          case 'security_alert': {
+            const alertMessage = parsed.data as z.infer<typeof securityAlertMessageSchema>;
+            await runWithAgentDbAccess(async () => {
+              const applied = await applySecurityAlert({
+                deviceId: authenticatedAgent.deviceId,
+                orgId: authenticatedAgent.orgId,
+                alert: alertMessage.alert,
+              });
+              if (!applied.applied) {
+                console.warn(`[AgentWs] Dropping security_alert from agent ${agentId}: reason=${applied.reason}`);
+                return;
+              }
+              writeAuditEvent(WS_AUDIT_REQUEST, {
+                orgId: authenticatedAgent.orgId,
+                actorType: 'agent',
+                actorId: agentId,
+                action: `agent.security_alert.${applied.alert.alertType}`,
+                resourceType: 'device',
+                resourceId: authenticatedAgent.deviceId,
+                details: applied.alert,
+                result: applied.alert.alertType === 'network_isolation' && applied.alert.error ? 'failure' : 'success',
+              });
+            });
+            break;
+          }
+
           case 'heartbeat':
             {
               const heartbeatMessage = parsed.data as z.infer<typeof heartbeatMessageSchema>;
@@ -2683,6 +2817,12 @@ const terminalOutputFastPathSchema = z.object({
   encoding: z.enum(['base64']).optional(),
 });
 
+const processSnapshotFastPathSchema = z.object({
+  type: z.literal('process_snapshot'),
+  streamId: z.string().regex(/^[A-Za-z0-9_-]{8,128}$/),
+  snapshot: z.record(z.string(), z.unknown()),
+});
+
 const terminalCommandResultSchema = z.object({
   type: z.literal('command_result'),
   commandId: z.string().regex(/^term-[a-zA-Z0-9_-]+$/).max(128),
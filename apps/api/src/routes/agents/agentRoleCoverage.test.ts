@@ -4,6 +4,11 @@ import { describe, expect, it } from 'vitest';
 const MAIN_AGENT_ONLY_TELEMETRY_ROUTES = [
   new URL('./changes.ts', import.meta.url),
   new URL('./connections.ts', import.meta.url),
+  new URL('./vulnerabilityScan.ts', import.meta.url),
+  new URL('./inventorySnapshots.ts', import.meta.url),
+  new URL('./softwareUsage.ts', import.meta.url),
+  new URL('./transcripts.ts', import.meta.url),
+  new URL('./desktopSessions.ts', import.meta.url),
 ];
 
 describe('main-agent-only telemetry route invariant', () => {
@@ -324,6 +324,7 @@ commandsRoutes.post(
     if (
       command.type === securityCommandTypes.collectStatus ||
       command.type === securityCommandTypes.scan ||
+      command.type === securityCommandTypes.avScan ||
       command.type === securityCommandTypes.quarantine ||
       command.type === securityCommandTypes.remove ||
       command.type === securityCommandTypes.restore
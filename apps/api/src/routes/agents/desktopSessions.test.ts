@@ -0,0 +1,191 @@
+import { beforeEach, describe, expect, it, vi } from 'vitest';
+import { Hono } from 'hono';
+
+const AGENT_ID = 'agent-1';
+const DEVICE_ID = '11111111-1111-4111-8111-111111111111';
+const ORG_ID = '22222222-2222-4222-8222-222222222222';
+const SESSION_ID = '33333333-3333-4333-8333-333333333333';
+const USER_ID = '44444444-4444-4444-8444-444444444444';
+
+vi.mock('../../middleware/requireAgentRole', () => ({
+  requireAgentRole: async (c: any, next: any) => {
+    c.set('agent', { agentId: AGENT_ID, deviceId: DEVICE_ID, orgId: ORG_ID, role: 'agent' });
+    await next();
+  },
+}));
+
+const { sessionRows, where, getIceServers, insertValues, onConflictDoNothing } = vi.hoisted(() => ({
+  sessionRows: { current: [] as unknown[] },
+  where: vi.fn(),
+  getIceServers: vi.fn(),
+  insertValues: vi.fn(),
+  onConflictDoNothing: vi.fn(async () => undefined),
+}));
+
+vi.mock('../../db', () => ({
+  db: {
+    select: vi.fn(() => ({
+      from: vi.fn(() => ({
+        where: where.mockImplementation(() => ({ limit: vi.fn(async () => sessionRows.current) })),
+      })),
+    })),
+    insert: vi.fn(() => ({
+      values: insertValues.mockImplementation(() => ({ onConflictDoNothing })),
+    })),
+  },
+}));
+
+vi.mock('../../db/schema', () => ({
+  remoteSessionQualitySamples: {
+    sessionId: 'remoteSessionQualitySamples.sessionId',
+    sampledAt: 'remoteSessionQualitySamples.sampledAt',
+  },
+  remoteSessions: {
+    id: 'remoteSessions.id',
+    orgId: 'remoteSessions.orgId',
+    userId: 'remoteSessions.userId',
+    deviceId: 'remoteSessions.deviceId',
+    status: 'remoteSessions.status',
+    type: 'remoteSessions.type',
+  },
+}));
+
+vi.mock('drizzle-orm', () => ({
+  and: vi.fn((...conditions: unknown[]) => ({ and: conditions })),
+  eq: vi.fn((column: unknown, value: unknown) => ({ column, value })),
+}));
+
+vi.mock('../remote/helpers', () => ({ getIceServers }));
+
+import { desktopSessionRoutes } from './desktopSessions';
+
+function fetchIceServers(sessionId = SESSION_ID, agentId = AGENT_ID) {
+  const app = new Hono();
+  app.route('/', desktopSessionRoutes);
+  return app.request(`/${agentId}/desktop-sessions/${sessionId}/ice-servers`);
+}
+
+describe('agent desktop session routes', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    getIceServers.mockReturnValue([
+      { urls: 'stun:stun.l.google.com:19302' },
+      { urls: ['turn:turn.example.com:3478?transport=udp'], username: '1700000600:breeze:scope', credential: 'cred' },
+    ]);
+    sessionRows.current = [{ id: SESSION_ID, orgId: ORG_ID, userId: USER_ID, deviceId: DEVICE_ID, status: 'active' }];
+  });
+
+  it('mints fresh TURN credentials for a live desktop session on the device', async () => {
+    const res = await fetchIceServers();
+    expect(res.status).toBe(200);
+    const body = await res.json();
+    expect(getIceServers).toHaveBeenCalledWith({ sessionId: SESSION_ID, userId: USER_ID, deviceId: DEVICE_ID });
+    expect(body.iceServers).toHaveLength(2);
+    expect(body.expiresAt).toBe(1700000600);
+  });
+
+  it('omits expiresAt when no TURN server is configured', async () => {
+    getIceServers.mockReturnValue([{ urls: 'stun:stun.l.google.com:19302' }]);
+    const body = await (await fetchIceServers()).json();
+    expect(body.expiresAt).toBeUndefined();
+  });
+
+  it('scopes the lookup to desktop sessions of the authenticated device', async () => {
+    await fetchIceServers();
+    expect(where).toHaveBeenCalledWith({
+      and: [
+        { column: 'remoteSessions.id', value: SESSION_ID },
+        { column: 'remoteSessions.deviceId', value: DEVICE_ID },
+        { column: 'remoteSessions.type', value: 'desktop' },
+      ],
+    });
+  });
+
+  it('returns 404 for a session that is not a desktop session of this device', async () => {
+    sessionRows.current = [];
+    const res = await fetchIceServers();
+    expect(res.status).toBe(404);
+    expect(getIceServers).not.toHaveBeenCalled();
+  });
+
+  it('returns 410 once the session has ended', async () => {
+    sessionRows.current = [{ id: SESSION_ID, userId: USER_ID, deviceId: DEVICE_ID, status: 'disconnected' }];
+    expect((await fetchIceServers()).status).toBe(200);
+
+    sessionRows.current = [{ id: SESSION_ID, userId: USER_ID, deviceId: DEVICE_ID, status: 'failed' }];
+    expect((await fetchIceServers()).status).toBe(410);
+  });
+
+  it('rejects another agent id and malformed session ids', async () => {
+    expect((await fetchIceServers(SESSION_ID, 'agent-2')).status).toBe(403);
+    expect((await fetchIceServers('not-a-session')).status).toBe(404);
+  });
+
+  describe('metrics', () => {
+    const sample = {
+      sessionId: SESSION_ID,
+      atUnixMs: 1700000000000,
+      intervalMs: 10000,
+      fps: 29.5,
+      encodeMs: 4.2,
+      rttMs: 38,
+      lossPct: 0.5,
+      bitrateKbps: 2500,
+      sentKbps: 2310.4,
+      width: 1920,
+      height: 1080,
+      downscaleLevel: 0,
+      codec: 'h264',
+      encoder: 'nvenc',
+      hardwareEncode: true,
+      gpuCapture: true,
+      framesDropped: 3,
+    };
+
+    function postMetrics(body: unknown, sessionId = SESSION_ID) {
+      const app = new Hono();
+      app.route('/', desktopSessionRoutes);
+      return app.request(`/${AGENT_ID}/desktop-sessions/${sessionId}/metrics`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(body),
+      });
+    }
+
+    it('stores samples under the session org and device', async () => {
+      const res = await postMetrics({ samples: [sample] });
+      expect(res.status).toBe(200);
+      expect(insertValues).toHaveBeenCalledWith([expect.objectContaining({
+        orgId: ORG_ID,
+        deviceId: DEVICE_ID,
+        sessionId: SESSION_ID,
+        sampledAt: new Date(1700000000000),
+        fps: 29.5,
+        rttMs: 38,
+        encoder: 'nvenc',
+        framesDropped: 3,
+      })]);
+      expect(onConflictDoNothing).toHaveBeenCalledWith({
+        target: ['remoteSessionQualitySamples.sessionId', 'remoteSessionQualitySamples.sampledAt'],
+      });
+    });
+
+    it('accepts the final batch after the session has ended', async () => {
+      sessionRows.current = [{ id: SESSION_ID, orgId: ORG_ID, userId: USER_ID, deviceId: DEVICE_ID, status: 'disconnected' }];
+      expect((await postMetrics({ samples: [sample] })).status).toBe(200);
+    });
+
+    it('returns 404 for a session that is not a desktop session of this device', async () => {
+      sessionRows.current = [];
+      expect((await postMetrics({ samples: [sample] })).status).toBe(404);
+      expect(insertValues).not.toHaveBeenCalled();
+    });
+
+    it('rejects empty and out-of-range batches', async () => {
+      expect((await postMetrics({ samples: [] })).status).toBe(400);
+      expect((await postMetrics({ samples: [{ ...sample, lossPct: 101 }] })).status).toBe(400);
+      expect((await postMetrics({ samples: Array(121).fill(sample) })).status).toBe(400);
+      expect(insertValues).not.toHaveBeenCalled();
+    });
+  });
+});
@@ -0,0 +1,122 @@
+import { Hono } from 'hono';
+import { and, eq } from 'drizzle-orm';
+import { db } from '../../db';
+import { remoteSessionQualitySamples, remoteSessions } from '../../db/schema';
+import { zValidator } from '../../lib/validation';
+import { type AgentAuthContext } from '../../middleware/agentAuth';
+import { requireAgentRole } from '../../middleware/requireAgentRole';
+import { getIceServers } from '../remote/helpers';
+import { submitDesktopQualitySchema } from './schemas';
+
+export const desktopSessionRoutes = new Hono();
+// Desktop sessions run in the main agent; reject watchdog-role tokens.
+desktopSessionRoutes.use('*', requireAgentRole);
+
+// Same states the viewer may fetch ICE servers in (GET /remote/ice-servers).
+const ICE_SERVER_SESSION_STATUSES = ['pending', 'connecting', 'active', 'disconnected'];
+
+// Desktop session of the agent's device, or undefined.
+async function findDesktopSession(deviceId: string, sessionId: string) {
+  if (!/^[0-9a-f-]{36}$/i.test(sessionId)) return undefined;
+  const [session] = await db
+    .select({
+      id: remoteSessions.id,
+      orgId: remoteSessions.orgId,
+      userId: remoteSessions.userId,
+      deviceId: remoteSessions.deviceId,
+      status: remoteSessions.status,
+    })
+    .from(remoteSessions)
+    .where(
+      and(
+        eq(remoteSessions.id, sessionId),
+        eq(remoteSessions.deviceId, deviceId),
+        eq(remoteSessions.type, 'desktop')
+      )
+    )
+    .limit(1);
+  return session;
+}
+
+// GET /agents/:id/desktop-sessions/:sessionId/ice-servers — fresh ICE servers
+// for a desktop session on this agent's device, so the agent can renew TURN
+// credentials that expire during a long session. 404 when the session is not
+// a desktop session of this device, 410 once it has ended; the agent stops
+// refreshing on either.
+desktopSessionRoutes.get('/:id/desktop-sessions/:sessionId/ice-servers', async (c) => {
+  const agent = c.get('agent') as AgentAuthContext | undefined;
+  if (!agent || agent.agentId !== c.req.param('id')) {
+    return c.json({ error: 'Forbidden' }, 403);
+  }
+  const session = await findDesktopSession(agent.deviceId, c.req.param('sessionId'));
+  if (!session) {
+    return c.json({ error: 'Session not found' }, 404);
+  }
+  if (!ICE_SERVER_SESSION_STATUSES.includes(session.status)) {
+    return c.json({ error: 'Session has ended', status: session.status }, 410);
+  }
+
+  const iceServers = getIceServers({
+    sessionId: session.id,
+    userId: session.userId,
+    deviceId: session.deviceId,
+  });
+  // TURN usernames carry their expiry ("<unix expiry>:breeze:..."); surface
+  // it so the agent schedules the next refresh without parsing usernames.
+  const expiresAt = iceServers.reduce<number | undefined>((earliest, server) => {
+    const expiry = Number.parseInt(server.username?.split(':')[0] ?? '', 10);
+    if (!Number.isFinite(expiry)) return earliest;
+    return earliest === undefined ? expiry : Math.min(earliest, expiry);
+  }, undefined);
+
+  return c.json({ iceServers, ...(expiresAt !== undefined ? { expiresAt } : {}) });
+});
+
+// POST /agents/:id/desktop-sessions/:sessionId/metrics — a batch of stream
+// quality samples (one per 10s) for a desktop session on this agent's device.
+// Accepted after the session ends too: the agent flushes its last batch on
+// disconnect. A retried batch is idempotent on (session, sample time).
+desktopSessionRoutes.post(
+  '/:id/desktop-sessions/:sessionId/metrics',
+  zValidator('json', submitDesktopQualitySchema),
+  async (c) => {
+    const agent = c.get('agent') as AgentAuthContext | undefined;
+    if (!agent || agent.agentId !== c.req.param('id')) {
+      return c.json({ error: 'Forbidden' }, 403);
+    }
+    const session = await findDesktopSession(agent.deviceId, c.req.param('sessionId'));
+    if (!session) {
+      return c.json({ error: 'Session not found' }, 404);
+    }
+
+    const { samples } = c.req.valid('json');
+    await db
+      .insert(remoteSessionQualitySamples)
+      .values(samples.map((sample) => ({
+        orgId: session.orgId,
+        deviceId: session.deviceId,
+        sessionId: session.id,
+        sampledAt: new Date(sample.atUnixMs),
+        intervalMs: sample.intervalMs,
+        fps: sample.fps,
+        encodeMs: sample.encodeMs,
+        rttMs: sample.rttMs,
+        lossPct: sample.lossPct,
+        bitrateKbps: sample.bitrateKbps,
+        sentKbps: sample.sentKbps,
+        width: sample.width,
+        height: sample.height,
+        downscaleLevel: sample.downscaleLevel,
+        codec: sample.codec || null,
+        encoder: sample.encoder || null,
+        hardwareEncode: sample.hardwareEncode,
+        gpuCapture: sample.gpuCapture,
+        framesDropped: sample.framesDropped,
+      })))
+      .onConflictDoNothing({
+        target: [remoteSessionQualitySamples.sessionId, remoteSessionQualitySamples.sampledAt],
+      });
+
+    return c.json({ success: true, count: samples.length });
+  }
+);
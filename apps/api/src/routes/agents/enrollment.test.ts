@@ -1644,6 +1644,28 @@ describe('POST /agents/enroll — virtualization attribute persistence (#1387)',
     expect(deviceValues.virtualizationPlatform).toBeNull();
   });
 
+  it('pins the transcript signing key from the enroll payload', async () => {
+    const deviceInsertValues = arrangeFreshEnroll();
+    const transcriptPublicKey = Buffer.alloc(32, 7).toString('base64');
+    const resp = await buildApp().request('/agents/enroll', {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({ ...baseEnrollBody, transcriptPublicKey }),
+    });
+    expect(resp.status).toBe(201);
+    const deviceValues = (deviceInsertValues.mock.calls as any[])[0]?.[0] as Record<string, unknown>;
+    expect(deviceValues.transcriptPublicKey).toBe(transcriptPublicKey);
+  });
+
+  it('rejects a transcript signing key that is not a base64 Ed25519 key', async () => {
+    const resp = await buildApp().request('/agents/enroll', {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({ ...baseEnrollBody, transcriptPublicKey: Buffer.alloc(16).toString('base64') }),
+    });
+    expect(resp.status).toBe(400);
+  });
+
   it('carries the virtualization fields onto the fresh-id INSERT when re-enrolling over a decommissioned row (#914 path)', async () => {
     // The decom-bypass-fresh-id branch is a SECOND device INSERT site (it
     // renames the old row then inserts a new one). Assert it also persists the
@@ -661,6 +661,10 @@ enrollmentRoutes.post('/enroll', zValidator('json', enrollSchema), async (c) =>
             deviceRoleSource: 'auto',
             isVirtual: data.isVirtual ?? false,
             virtualizationPlatform: data.virtualizationPlatform ?? null,
+            // Re-enrollment may come from a reinstalled agent with a new
+            // signing key; older agents don't send one and get pinned on
+            // their first transcript export instead.
+            transcriptPublicKey: data.transcriptPublicKey ?? null,
             status: 'online',
             lastSeenAt: new Date(),
             updatedAt: new Date(),
@@ -690,6 +694,7 @@ enrollmentRoutes.post('/enroll', zValidator('json', enrollSchema), async (c) =>
             deviceRoleSource: 'auto',
             isVirtual: data.isVirtual ?? false,
             virtualizationPlatform: data.virtualizationPlatform ?? null,
+            transcriptPublicKey: data.transcriptPublicKey ?? null,
             status: 'online',
             lastSeenAt: new Date(),
             tags: []
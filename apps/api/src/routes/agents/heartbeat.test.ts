@@ -121,6 +121,10 @@ vi.mock('./helpers', () => ({
   buildMonitoringConfigUpdate: vi.fn(() => undefined),
   buildHelperConfigUpdate: vi.fn(() => undefined),
   buildPamConfigUpdate: vi.fn(async () => ({ uacInterceptionEnabled: false })),
+  buildPatchMaintenanceConfigUpdate: vi.fn(async () => []),
+  buildPatchComplianceConfigUpdate: vi.fn(async () => null),
+  buildPatchRebootDeferralConfigUpdate: vi.fn(async () => ({ maxDeferrals: 0 })),
+  buildAppUpdatePolicyConfigUpdate: vi.fn(async () => []),
   buildPatchSourceConfigUpdate: vi.fn(async () => ({ exclusiveWindowsUpdate: false })),
   // Null = no onedrive policy for the device. Tests that exercise delivery
   // override this per-test. Omitting it entirely would make every heartbeat
@@ -181,6 +185,18 @@ vi.mock('../../services/remoteAccessPolicy', () => ({
   })),
 }));
 
+vi.mock('../../services/deviceGeolocation', () => ({
+  resolveGeolocationEnabledForDevice: vi.fn(async () => false),
+}));
+
+vi.mock('../../services/registryPolicy', () => ({
+  resolveRegistryWriteEnabledForOrg: vi.fn(async () => false),
+}));
+
+vi.mock('../../services/ransomwareCanaryPolicy', () => ({
+  resolveRansomwareCanaryPolicyForOrg: vi.fn(async () => ({ enabled: false, isolateOnTrigger: false })),
+}));
+
 const getActiveTrustKeysetMock = vi.fn();
 
 vi.mock('../../services/manifestSigning', () => ({
@@ -1394,6 +1410,22 @@ describe('batteryStatus persistence', () => {
     expect(battery).not.toHaveProperty('timeRemainingMinutes');
   });
 
+  it('stores battery health alongside the current state', async () => {
+    const setSpy = vi.fn(() => ({ where: vi.fn(() => whereResultWithReturning()) }));
+    setupMocks(setSpy);
+
+    const health = { cycleCount: 412, designCapacityMWh: 57000, fullChargeCapacityMWh: 45600, healthPercent: 80 };
+    const resp = await buildApp().request('/agents/device-1/heartbeat', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ ...minimalHeartbeatBody, battery: { present: true, percent: 70, ...health } }),
+    });
+
+    expect(resp.status).toBe(200);
+    const updateArg = (setSpy.mock.calls as any[])[0]?.[0] as Record<string, unknown>;
+    expect(updateArg.batteryStatus).toMatchObject({ present: true, percent: 70, ...health });
+  });
+
   it('records a no-battery desktop as { present: false }', async () => {
     const setSpy = vi.fn(() => ({ where: vi.fn(() => whereResultWithReturning()) }));
     setupMocks(setSpy);
@@ -1428,6 +1460,114 @@ describe('batteryStatus persistence', () => {
   });
 });
 
+// ---------------------------------------------------------------------
+// Device geolocation policy gate
+// ---------------------------------------------------------------------
+
+describe('geolocation policy gate', () => {
+  const deviceRow = {
+    id: 'device-1',
+    orgId: 'org-1',
+    siteId: 'site-1',
+    hostname: 'host-1',
+    osType: 'windows',
+    osVersion: 'Windows 11',
+    osBuild: null,
+    architecture: 'amd64',
+    agentVersion: '0.65.10',
+    deviceRole: 'workstation',
+    deviceRoleSource: 'auto',
+    agentTokenHash: 'hash',
+    tokenIssuedAt: new Date(),
+    mainAgentSilentSince: null,
+    lastLocation: { latitude: 1, longitude: 2, source: 'ip', collectedAt: '2026-01-01T00:00:00Z', reportedAt: '2026-01-01T00:00:00Z' },
+  };
+  const location = { latitude: 52.52, longitude: 13.405, accuracyMeters: 40, source: 'wifi', collectedAt: '2026-03-10T15:00:00Z' };
+
+  async function send(setSpy: ReturnType<typeof vi.fn>) {
+    vi.clearAllMocks();
+    getActiveTrustKeysetMock.mockResolvedValue([]);
+    selectMock.mockReturnValueOnce(selectChainResolving([deviceRow]));
+    updateMock.mockReturnValue({ set: setSpy });
+    insertMock.mockReturnValue({ values: vi.fn().mockResolvedValue(undefined) });
+    selectMock.mockReturnValue(selectChainResolving([]));
+    const resp = await buildApp().request('/agents/device-1/heartbeat', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ ...minimalHeartbeatBody, location }),
+    });
+    expect(resp.status).toBe(200);
+    const body = (await resp.json()) as Record<string, unknown>;
+    const updateArg = (setSpy.mock.calls as any[])[0]?.[0] as Record<string, unknown>;
+    return { configUpdate: body.configUpdate as Record<string, unknown> | null, updateArg };
+  }
+
+  it('stores the location and enables the agent when policy allows', async () => {
+    const { resolveGeolocationEnabledForDevice } = await import('../../services/deviceGeolocation');
+    vi.mocked(resolveGeolocationEnabledForDevice).mockResolvedValueOnce(true);
+    const setSpy = vi.fn(() => ({ where: vi.fn(() => whereResultWithReturning()) }));
+
+    const { configUpdate, updateArg } = await send(setSpy);
+    expect(configUpdate?.geolocation_enabled).toBe(true);
+    expect(updateArg.lastLocation).toMatchObject(location);
+    expect(typeof (updateArg.lastLocation as Record<string, unknown>).reportedAt).toBe('string');
+  });
+
+  it('drops the report and clears the stored location when policy is off', async () => {
+    const setSpy = vi.fn(() => ({ where: vi.fn(() => whereResultWithReturning()) }));
+
+    const { configUpdate, updateArg } = await send(setSpy);
+    expect(configUpdate?.geolocation_enabled).toBe(false);
+    expect(updateArg.lastLocation).toBeNull();
+  });
+
+  it('neither stores nor revokes when the policy resolver throws', async () => {
+    const { resolveGeolocationEnabledForDevice } = await import('../../services/deviceGeolocation');
+    vi.mocked(resolveGeolocationEnabledForDevice).mockRejectedValueOnce(new Error('boom'));
+    const setSpy = vi.fn(() => ({ where: vi.fn(() => whereResultWithReturning()) }));
+
+    const { configUpdate, updateArg } = await send(setSpy);
+    expect(configUpdate?.geolocation_enabled).toBeUndefined();
+    expect(updateArg).not.toHaveProperty('lastLocation');
+  });
+
+  it('pushes the organization registry editing policy', async () => {
+    const { resolveRegistryWriteEnabledForOrg } = await import('../../services/registryPolicy');
+    vi.mocked(resolveRegistryWriteEnabledForOrg).mockResolvedValueOnce(true);
+    const setSpy = vi.fn(() => ({ where: vi.fn(() => whereResultWithReturning()) }));
+
+    const { configUpdate } = await send(setSpy);
+    expect(configUpdate?.registry_write_enabled).toBe(true);
+  });
+
+  it('omits the registry editing policy when its resolver throws', async () => {
+    const { resolveRegistryWriteEnabledForOrg } = await import('../../services/registryPolicy');
+    vi.mocked(resolveRegistryWriteEnabledForOrg).mockRejectedValueOnce(new Error('boom'));
+    const setSpy = vi.fn(() => ({ where: vi.fn(() => whereResultWithReturning()) }));
+
+    const { configUpdate } = await send(setSpy);
+    expect(configUpdate?.registry_write_enabled).toBeUndefined();
+  });
+
+  it('pushes the organization ransomware canary policy', async () => {
+    const { resolveRansomwareCanaryPolicyForOrg } = await import('../../services/ransomwareCanaryPolicy');
+    vi.mocked(resolveRansomwareCanaryPolicyForOrg).mockResolvedValueOnce({ enabled: true, isolateOnTrigger: true });
+    const setSpy = vi.fn(() => ({ where: vi.fn(() => whereResultWithReturning()) }));
+
+    const { configUpdate } = await send(setSpy);
+    expect(configUpdate?.ransomware_canary).toEqual({ enabled: true, isolateOnTrigger: true });
+  });
+
+  it('omits the ransomware canary policy when its resolver throws', async () => {
+    const { resolveRansomwareCanaryPolicyForOrg } = await import('../../services/ransomwareCanaryPolicy');
+    vi.mocked(resolveRansomwareCanaryPolicyForOrg).mockRejectedValueOnce(new Error('boom'));
+    const setSpy = vi.fn(() => ({ where: vi.fn(() => whereResultWithReturning()) }));
+
+    const { configUpdate } = await send(setSpy);
+    expect(configUpdate?.ransomware_canary).toBeUndefined();
+  });
+});
+
 // ---------------------------------------------------------------------
 // PAM config delivery (#uacInterceptionEnabled in heartbeat response)
 // ---------------------------------------------------------------------
@@ -1535,6 +1675,127 @@ describe('POST /agents/:id/heartbeat — uacInterceptionEnabled delivery', () =>
     expect(configUpdate?.patch_source_settings).toBeUndefined();
   });
 
+  it('includes patch_maintenance_windows in configUpdate', async () => {
+    const { buildPatchMaintenanceConfigUpdate } = await import('./helpers');
+    const windows = [{ days: ['sunday'], start: '00:00', durationMinutes: 240, timezone: 'America/New_York' }];
+    vi.mocked(buildPatchMaintenanceConfigUpdate).mockResolvedValueOnce(windows);
+
+    const resp = await buildApp().request('/agents/device-1/heartbeat', {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify(minimalHeartbeatBody),
+    });
+
+    expect(resp.status).toBe(200);
+    const body = (await resp.json()) as Record<string, unknown>;
+    const configUpdate = body.configUpdate as Record<string, unknown> | null;
+    expect(configUpdate?.patch_maintenance_windows).toEqual(windows);
+  });
+
+  it('omits patch_maintenance_windows when the maintenance resolver throws', async () => {
+    const { buildPatchMaintenanceConfigUpdate } = await import('./helpers');
+    vi.mocked(buildPatchMaintenanceConfigUpdate).mockRejectedValueOnce(new Error('boom'));
+
+    const resp = await buildApp().request('/agents/device-1/heartbeat', {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify(minimalHeartbeatBody),
+    });
+
+    expect(resp.status).toBe(200);
+    const body = (await resp.json()) as Record<string, unknown>;
+    const configUpdate = body.configUpdate as Record<string, unknown> | null;
+    expect(configUpdate?.patch_maintenance_windows).toBeUndefined();
+  });
+
+  it('includes patch_compliance_policy in configUpdate, null when no policy applies', async () => {
+    const { buildPatchComplianceConfigUpdate } = await import('./helpers');
+    const policy = { criticalMaxAgeDays: 14, importantMaxAgeDays: 30 };
+    vi.mocked(buildPatchComplianceConfigUpdate).mockResolvedValueOnce(policy);
+
+    let resp = await buildApp().request('/agents/device-1/heartbeat', {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify(minimalHeartbeatBody),
+    });
+    expect(resp.status).toBe(200);
+    let configUpdate = ((await resp.json()) as Record<string, unknown>).configUpdate as Record<string, unknown>;
+    expect(configUpdate.patch_compliance_policy).toEqual(policy);
+
+    resp = await buildApp().request('/agents/device-1/heartbeat', {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify(minimalHeartbeatBody),
+    });
+    configUpdate = ((await resp.json()) as Record<string, unknown>).configUpdate as Record<string, unknown>;
+    expect(configUpdate).toHaveProperty('patch_compliance_policy', null);
+  });
+
+  it('omits patch_compliance_policy when the patch resolver throws', async () => {
+    const { buildPatchComplianceConfigUpdate } = await import('./helpers');
+    vi.mocked(buildPatchComplianceConfigUpdate).mockRejectedValueOnce(new Error('boom'));
+
+    const resp = await buildApp().request('/agents/device-1/heartbeat', {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify(minimalHeartbeatBody),
+    });
+
+    expect(resp.status).toBe(200);
+    const body = (await resp.json()) as Record<string, unknown>;
+    const configUpdate = body.configUpdate as Record<string, unknown> | null;
+    expect(configUpdate).not.toHaveProperty('patch_compliance_policy');
+  });
+
+  it('includes patch_reboot_deferral in configUpdate and omits it when the resolver throws', async () => {
+    const { buildPatchRebootDeferralConfigUpdate } = await import('./helpers');
+    vi.mocked(buildPatchRebootDeferralConfigUpdate).mockResolvedValueOnce({ maxDeferrals: 3 });
+
+    let resp = await buildApp().request('/agents/device-1/heartbeat', {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify(minimalHeartbeatBody),
+    });
+    expect(resp.status).toBe(200);
+    let configUpdate = ((await resp.json()) as Record<string, unknown>).configUpdate as Record<string, unknown>;
+    expect(configUpdate.patch_reboot_deferral).toEqual({ maxDeferrals: 3 });
+
+    vi.mocked(buildPatchRebootDeferralConfigUpdate).mockRejectedValueOnce(new Error('boom'));
+    resp = await buildApp().request('/agents/device-1/heartbeat', {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify(minimalHeartbeatBody),
+    });
+    expect(resp.status).toBe(200);
+    configUpdate = ((await resp.json()) as Record<string, unknown>).configUpdate as Record<string, unknown>;
+    expect(configUpdate).not.toHaveProperty('patch_reboot_deferral');
+  });
+
+  it('includes app_update_policies in configUpdate and omits it when the resolver throws', async () => {
+    const { buildAppUpdatePolicyConfigUpdate } = await import('./helpers');
+    const policies = [{ provider: 'winget' as const, packageId: 'Mozilla.Firefox', channel: 'pin' as const, pinnedVersion: '128.0' }];
+    vi.mocked(buildAppUpdatePolicyConfigUpdate).mockResolvedValueOnce(policies);
+
+    let resp = await buildApp().request('/agents/device-1/heartbeat', {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify(minimalHeartbeatBody),
+    });
+    expect(resp.status).toBe(200);
+    let configUpdate = ((await resp.json()) as Record<string, unknown>).configUpdate as Record<string, unknown>;
+    expect(configUpdate.app_update_policies).toEqual(policies);
+
+    vi.mocked(buildAppUpdatePolicyConfigUpdate).mockRejectedValueOnce(new Error('boom'));
+    resp = await buildApp().request('/agents/device-1/heartbeat', {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify(minimalHeartbeatBody),
+    });
+    expect(resp.status).toBe(200);
+    configUpdate = ((await resp.json()) as Record<string, unknown>).configUpdate as Record<string, unknown>;
+    expect(configUpdate).not.toHaveProperty('app_update_policies');
+  });
+
   it('delivers onedrive_helper_settings in configUpdate alongside other config (post-#1105 hoist merge)', async () => {
     const { buildOnedriveHelperConfigUpdate, buildPatchSourceConfigUpdate } = await import('./helpers');
     const settings = {
@@ -2544,6 +2805,36 @@ describe('POST /agents/:id/heartbeat — agentRuntime gauges (#2389)', () => {
     expect(metricsInsert?.customMetrics).toEqual({ agentRuntime });
   });
 
+  it('persists GPU readings into device_metrics.custom_metrics', async () => {
+    const valuesSpy = vi.fn().mockResolvedValue(undefined);
+    insertMock.mockReturnValue({ values: valuesSpy });
+
+    const gpus = [{ index: 0, name: 'Radeon RX 7600', vendor: 'amd', utilizationPercent: 42, source: 'sysfs' }];
+    const resp = await buildApp().request('/agents/device-1/heartbeat', {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({ ...minimalHeartbeatBody, metrics: { ...minimalHeartbeatBody.metrics, gpus } }),
+    });
+
+    expect(resp.status).toBe(200);
+    expect(findMetricsInsert(valuesSpy)?.customMetrics).toEqual({ gpus });
+  });
+
+  it('persists the thermal snapshot into device_metrics.custom_metrics', async () => {
+    const valuesSpy = vi.fn().mockResolvedValue(undefined);
+    insertMock.mockReturnValue({ values: valuesSpy });
+
+    const thermal = { cpuTempC: 64, throttled: false, cpuSpeedLimitPercent: 100, source: 'acpi' };
+    const resp = await buildApp().request('/agents/device-1/heartbeat', {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({ ...minimalHeartbeatBody, metrics: { ...minimalHeartbeatBody.metrics, thermal } }),
+    });
+
+    expect(resp.status).toBe(200);
+    expect(findMetricsInsert(valuesSpy)?.customMetrics).toEqual({ thermal });
+  });
+
   it('writes customMetrics: null when an old agent omits agentRuntime', async () => {
     const valuesSpy = vi.fn().mockResolvedValue(undefined);
     insertMock.mockReturnValue({ values: valuesSpy });
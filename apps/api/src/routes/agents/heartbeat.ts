@@ -10,7 +10,7 @@ import {
   agentLogs,
   onedriveDeviceState,
 } from '../../db/schema';
-import type { BatteryStatus } from '@breeze/shared';
+import type { BatteryStatus, DeviceLocation } from '@breeze/shared';
 import { promotePendingAgentCredentials } from '../../services/agentTokenPromotion';
 import { writeAuditEvent } from '../../services/auditEvents';
 import { heartbeatSchema } from './schemas';
@@ -22,12 +22,19 @@ import {
   compareAgentVersions,
   buildEventLogConfigUpdate,
   buildMonitoringConfigUpdate,
+  buildAppUpdatePolicyConfigUpdate,
   buildHelperConfigUpdate,
   buildPamConfigUpdate,
   buildOnedriveHelperConfigUpdate,
+  buildPatchMaintenanceConfigUpdate,
+  buildPatchComplianceConfigUpdate,
+  buildPatchRebootDeferralConfigUpdate,
   buildPatchSourceConfigUpdate,
   getOrgAgentUpdateConfig,
   resolvePinnedUpgradeTarget,
+  type AgentAppUpdatePolicy,
+  type AgentPatchCompliancePolicy,
+  type AgentPatchMaintenanceWindow,
   type AgentVersionPins,
   type OnedriveConfigUpdate,
 } from './helpers';
@@ -39,6 +46,9 @@ import { isAgentTokenRotationDue } from '../../middleware/agentAuth';
 import type { AgentAuthContext } from '../../middleware/agentAuth';
 import { captureException } from '../../services/sentry';
 import { resolveRemoteAccessForDevice } from '../../services/remoteAccessPolicy';
+import { resolveGeolocationEnabledForDevice } from '../../services/deviceGeolocation';
+import { resolveRegistryWriteEnabledForOrg } from '../../services/registryPolicy';
+import { resolveRansomwareCanaryPolicyForOrg, type RansomwareCanaryPolicy } from '../../services/ransomwareCanaryPolicy';
 import { getActiveTrustKeyset, type ManifestTrustKey } from '../../services/manifestSigning';
 import { decryptClaimedCommandsForDelivery } from '../../services/commandDelivery';
 import { redactSecretsDeep } from '../../services/secretRedaction';
@@ -552,11 +562,40 @@ heartbeatRoutes.post('/:id/heartbeat', bodyLimit({ maxSize: 5 * 1024 * 1024, onE
       ...(data.battery.pluggedIn !== undefined ? { pluggedIn: data.battery.pluggedIn } : {}),
       ...(data.battery.timeRemainingMinutes !== undefined ? { timeRemainingMinutes: data.battery.timeRemainingMinutes } : {}),
       ...(data.battery.timeToFullMinutes !== undefined ? { timeToFullMinutes: data.battery.timeToFullMinutes } : {}),
+      ...(data.battery.cycleCount !== undefined ? { cycleCount: data.battery.cycleCount } : {}),
+      ...(data.battery.designCapacityMWh !== undefined ? { designCapacityMWh: data.battery.designCapacityMWh } : {}),
+      ...(data.battery.fullChargeCapacityMWh !== undefined ? { fullChargeCapacityMWh: data.battery.fullChargeCapacityMWh } : {}),
+      ...(data.battery.healthPercent !== undefined ? { healthPercent: data.battery.healthPercent } : {}),
       reportedAt: new Date().toISOString(),
     };
     deviceUpdates.batteryStatus = battery;
   }
 
+  // Geolocation is opt-in by org/site policy. null = resolver failed: the
+  // report is dropped and the agent is not told anything this heartbeat, so
+  // a transient error neither stores nor revokes anything.
+  let geolocationEnabled: boolean | null = null;
+  try {
+    geolocationEnabled = await resolveGeolocationEnabledForDevice(device.orgId, device.siteId);
+  } catch (err) {
+    console.error(`[agents] failed to resolve geolocation policy for ${agentId}:`, err);
+    captureException(err);
+  }
+  if (data.location && geolocationEnabled === true) {
+    const location: DeviceLocation = {
+      latitude: data.location.latitude,
+      longitude: data.location.longitude,
+      ...(data.location.accuracyMeters !== undefined ? { accuracyMeters: data.location.accuracyMeters } : {}),
+      source: data.location.source,
+      collectedAt: data.location.collectedAt,
+      reportedAt: new Date().toISOString(),
+    };
+    deviceUpdates.lastLocation = location;
+  } else if (geolocationEnabled === false && device.lastLocation) {
+    // Policy was turned off: don't keep the last known position either.
+    deviceUpdates.lastLocation = null;
+  }
+
   // agentAuthMiddleware already 403s decommissioned/quarantined devices, but
   // a decommission landing mid-request (between the auth fetch and this
   // write) would be silently flipped back to 'online' (#2230). Mirrors
@@ -660,6 +699,14 @@ heartbeatRoutes.post('/:id/heartbeat', bodyLimit({ maxSize: 5 * 1024 * 1024, onE
   }
 
   if (data.metrics) {
+    // jsonb sidecar for readings without their own columns, so no migration:
+    // the agent's Go runtime gauges (#2389), per-adapter GPU load and the
+    // thermal snapshot. null (not {}) when an old agent sends none of them.
+    const customMetrics = {
+      ...(data.agentRuntime ? { agentRuntime: data.agentRuntime } : {}),
+      ...(data.metrics.gpus?.length ? { gpus: data.metrics.gpus } : {}),
+      ...(data.metrics.thermal ? { thermal: data.metrics.thermal } : {}),
+    };
     await db
       .insert(deviceMetrics)
       .values({
@@ -684,9 +731,7 @@ heartbeatRoutes.post('/:id/heartbeat', bodyLimit({ maxSize: 5 * 1024 * 1024, onE
         bandwidthOutBps: data.metrics.bandwidthOutBps != null ? BigInt(data.metrics.bandwidthOutBps) : null,
         interfaceStats: data.metrics.interfaceStats ?? null,
         processCount: data.metrics.processCount,
-        // Agent's own Go runtime memory gauges (#2389) — jsonb sidecar, so no
-        // migration; null (not {}) when an old agent doesn't send them.
-        customMetrics: data.agentRuntime ? { agentRuntime: data.agentRuntime } : null
+        customMetrics: Object.keys(customMetrics).length > 0 ? customMetrics : null
       });
   } else if (data.agentRuntime) {
     // #2389 — the gauges ride the device_metrics insert, and that table's OS
@@ -986,6 +1031,68 @@ if (latestHelper) {
     captureException(err);
   }
 
+  // Patch maintenance windows the agent enforces itself: installs and patch
+  // reboots received outside them are queued on the device. Omitted on a
+  // resolver error so a transient failure never changes the restriction.
+  let patchMaintenanceWindows: AgentPatchMaintenanceWindow[] | null = null;
+  try {
+    patchMaintenanceWindows = await buildPatchMaintenanceConfigUpdate(device.id);
+  } catch (err) {
+    console.error(`[agents] failed to build patch maintenance windows for ${agentId}:`, err);
+    captureException(err);
+  }
+
+  // Patch compliance policy the agent evaluates after every scan. null clears
+  // it on the agent; the block is omitted only on a resolver error.
+  let patchCompliancePolicy: AgentPatchCompliancePolicy | null | undefined;
+  try {
+    patchCompliancePolicy = await buildPatchComplianceConfigUpdate(device.id);
+  } catch (err) {
+    console.error(`[agents] failed to build patch compliance policy for ${agentId}:`, err);
+    captureException(err);
+  }
+
+  // How many times the user may postpone a patch reboot from the agent's
+  // warning prompt. Omitted on a resolver error so the agent keeps its limit.
+  let patchRebootDeferral: { maxDeferrals: number } | null = null;
+  try {
+    patchRebootDeferral = await buildPatchRebootDeferralConfigUpdate(device.id);
+  } catch (err) {
+    console.error(`[agents] failed to build patch reboot deferral for ${agentId}:`, err);
+    captureException(err);
+  }
+
+  // Third-party app update rings (winget, Chocolatey, Homebrew) from the
+  // patch policy. Always a list so removing the last policy clears the
+  // agent's; omitted on a resolver error so the agent keeps its policies.
+  let appUpdatePolicies: AgentAppUpdatePolicy[] | null = null;
+  try {
+    appUpdatePolicies = await buildAppUpdatePolicyConfigUpdate(device.id);
+  } catch (err) {
+    console.error(`[agents] failed to build app update policies for ${agentId}:`, err);
+    captureException(err);
+  }
+
+  // Registry editing is an org policy flag. Omit it on a resolver error so a
+  // transient failure neither grants nor revokes the agent's permission.
+  let registryWriteEnabled: boolean | null = null;
+  try {
+    registryWriteEnabled = await resolveRegistryWriteEnabledForOrg(device.orgId);
+  } catch (err) {
+    console.error(`[agents] failed to resolve registry policy for ${agentId}:`, err);
+    captureException(err);
+  }
+
+  // Ransomware canaries are an org policy. Omitted on a resolver error so a
+  // transient failure neither plants nor removes the agent's canaries.
+  let ransomwareCanary: RansomwareCanaryPolicy | null = null;
+  try {
+    ransomwareCanary = await resolveRansomwareCanaryPolicyForOrg(device.orgId);
+  } catch (err) {
+    console.error(`[agents] failed to resolve ransomware canary policy for ${agentId}:`, err);
+    captureException(err);
+  }
+
   // #2288 — backup control-plane URL. ALWAYS present: the configured value,
   // or '' so agents clear a previously-pushed backup (absent = old API =
   // no change; '' = authoritative clear). Always non-null, so the final
@@ -1004,6 +1111,27 @@ if (latestHelper) {
   if (patchSourceSettings) {
     mergedConfigUpdate.patch_source_settings = patchSourceSettings;
   }
+  if (patchMaintenanceWindows) {
+    mergedConfigUpdate.patch_maintenance_windows = patchMaintenanceWindows;
+  }
+  if (patchCompliancePolicy !== undefined) {
+    mergedConfigUpdate.patch_compliance_policy = patchCompliancePolicy;
+  }
+  if (patchRebootDeferral) {
+    mergedConfigUpdate.patch_reboot_deferral = patchRebootDeferral;
+  }
+  if (appUpdatePolicies) {
+    mergedConfigUpdate.app_update_policies = appUpdatePolicies;
+  }
+  if (geolocationEnabled !== null) {
+    mergedConfigUpdate.geolocation_enabled = geolocationEnabled;
+  }
+  if (registryWriteEnabled !== null) {
+    mergedConfigUpdate.registry_write_enabled = registryWriteEnabled;
+  }
+  if (ransomwareCanary) {
+    mergedConfigUpdate.ransomware_canary = ransomwareCanary;
+  }
 
   const authenticatedWithPreviousToken = c.get('agentTokenRotationRequired') === true;
 
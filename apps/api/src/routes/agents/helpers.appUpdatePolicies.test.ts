@@ -0,0 +1,113 @@
+/**
+ * Tests for toAgentAppUpdatePolicies — the mapping from the patch policy's
+ * inline appUpdatePolicies to the per-device rings the agent enforces. Module
+ * mocks mirror helpers.patchSource.test.ts so helpers.ts imports without a DB.
+ */
+import { describe, expect, it, vi } from 'vitest';
+
+vi.mock('../../db', () => ({
+  runOutsideDbContext: vi.fn((fn: () => unknown) => fn()),
+  withDbAccessContext: vi.fn(async (_ctx: unknown, fn: () => Promise<unknown>) => fn()),
+  withSystemDbAccessContext: vi.fn(async (fn: () => Promise<unknown>) => fn()),
+  db: { select: vi.fn() },
+}));
+
+vi.mock('../../db/schema', () => ({
+  devices: {},
+  organizations: {},
+  deviceGroupMemberships: {},
+  configPolicyAssignments: {},
+  configurationPolicies: {},
+  configPolicyFeatureLinks: {},
+  pamOrgConfig: {},
+  softwarePolicies: {},
+  softwareComplianceStatus: {},
+  deviceCommands: { $inferSelect: {} },
+  deviceDisks: {},
+  deviceFilesystemSnapshots: {},
+  automationPolicies: {},
+  cisBaselines: {},
+  cisBaselineResults: {},
+  cisRemediationActions: {},
+  securityStatus: {},
+  securityThreats: {},
+  securityScans: {},
+  sensitiveDataFindings: {},
+  sensitiveDataScans: {},
+  sites: {},
+  users: {},
+  deviceGroups: {},
+  configPolicyMonitoringSettings: {},
+  configPolicyMonitoringWatches: {},
+  configPolicyEventLogSettings: {},
+}));
+
+vi.mock('../../services/redis', () => ({ getRedis: vi.fn(() => null) }));
+vi.mock('../../services/eventBus', () => ({ publishEvent: vi.fn() }));
+vi.mock('../../services/commandQueue', () => ({ queueCommandForExecution: vi.fn() }));
+vi.mock('../../services/cisHardening', () => ({ parseCisCollectorOutput: vi.fn() }));
+vi.mock('../../services/sentry', () => ({ captureException: vi.fn() }));
+vi.mock('../../services/cloudflareMtls', () => ({ CloudflareMtlsService: vi.fn() }));
+vi.mock('../../services/softwarePolicyService', () => ({ recordSoftwarePolicyAudit: vi.fn() }));
+vi.mock('../../services/featureConfigResolver', () => ({
+  resolvePatchConfigForDevice: vi.fn(),
+  resolvePatchConfigDetailsForDevice: vi.fn(),
+}));
+vi.mock('../../services/filesystemAnalysis', () => ({
+  getFilesystemScanState: vi.fn(),
+  mergeFilesystemAnalysisPayload: vi.fn(),
+  parseFilesystemAnalysisStdout: vi.fn(),
+  readCheckpointPendingDirectories: vi.fn(),
+  readHotDirectories: vi.fn(),
+  saveFilesystemSnapshot: vi.fn(),
+  upsertFilesystemScanState: vi.fn(),
+}));
+vi.mock('../metrics', () => ({
+  recordSoftwareRemediationDecision: vi.fn(),
+  recordSensitiveDataFinding: vi.fn(),
+  recordSensitiveDataRemediationDecision: vi.fn(),
+}));
+vi.mock('../../jobs/softwareComplianceWorker', () => ({
+  scheduleSoftwareComplianceCheck: vi.fn(),
+}));
+vi.mock('./policyProbeSafety', () => ({ isAllowedPolicyConfigProbe: vi.fn(() => true) }));
+
+import { toAgentAppUpdatePolicies } from './helpers';
+
+describe('toAgentAppUpdatePolicies', () => {
+  const DEVICE_ID = '11111111-1111-4111-8111-111111111111';
+  const OTHER_DEVICE_ID = '22222222-2222-4222-8222-222222222222';
+
+  it('resolves the test ring for the device and drops unset fields', () => {
+    const inline = {
+      appUpdatePolicies: [
+        {
+          provider: 'winget',
+          packageId: 'Mozilla.Firefox',
+          channel: 'n-1',
+          blockedVersions: ['129.*'],
+          ringDelayDays: 7,
+          testRingDeviceIds: [DEVICE_ID],
+        },
+        { provider: 'homebrew', packageId: 'git', channel: 'pin', pinnedVersion: '2.45.0' },
+      ],
+    };
+    expect(toAgentAppUpdatePolicies(inline, DEVICE_ID)).toEqual([
+      { provider: 'winget', packageId: 'Mozilla.Firefox', channel: 'n-1', blockedVersions: ['129.*'], testRing: true, ringDelayDays: 7 },
+      { provider: 'homebrew', packageId: 'git', channel: 'pin', pinnedVersion: '2.45.0' },
+    ]);
+    expect(toAgentAppUpdatePolicies(inline, OTHER_DEVICE_ID)[0]).not.toHaveProperty('testRing');
+  });
+
+  it('drops malformed entries and returns an empty list when unset', () => {
+    expect(toAgentAppUpdatePolicies(null, DEVICE_ID)).toEqual([]);
+    expect(toAgentAppUpdatePolicies({ sources: ['os'] }, DEVICE_ID)).toEqual([]);
+    expect(toAgentAppUpdatePolicies({
+      appUpdatePolicies: [
+        { provider: 'apt', packageId: 'curl' },
+        { provider: 'winget', packageId: 'Git.Git', channel: 'pin' },
+        { provider: 'chocolatey', packageId: '7zip' },
+      ],
+    }, DEVICE_ID)).toEqual([{ provider: 'chocolatey', packageId: '7zip', channel: 'latest' }]);
+  });
+});
@@ -58,6 +58,7 @@ vi.mock('../../services/cloudflareMtls', () => ({ CloudflareMtlsService: vi.fn()
 vi.mock('../../services/softwarePolicyService', () => ({ recordSoftwarePolicyAudit: vi.fn() }));
 vi.mock('../../services/featureConfigResolver', () => ({
   resolvePatchConfigForDevice: vi.fn(),
+  resolveMaintenanceConfigForDevice: vi.fn(),
 }));
 vi.mock('../../services/filesystemAnalysis', () => ({
   getFilesystemScanState: vi.fn(),
@@ -0,0 +1,118 @@
+/**
+ * Tests for toAgentPatchCompliancePolicy and toAgentPatchRebootDeferral — the
+ * mappings from the patch policy's inline-only fields to the blocks the agent
+ * enforces locally. Module mocks mirror helpers.patchMaintenance.test.ts so
+ * helpers.ts imports without a DB.
+ */
+import { describe, expect, it, vi } from 'vitest';
+
+vi.mock('../../db', () => ({
+  runOutsideDbContext: vi.fn((fn: () => unknown) => fn()),
+  withDbAccessContext: vi.fn(async (_ctx: unknown, fn: () => Promise<unknown>) => fn()),
+  withSystemDbAccessContext: vi.fn(async (fn: () => Promise<unknown>) => fn()),
+  db: { select: vi.fn() },
+}));
+
+vi.mock('../../db/schema', () => ({
+  devices: {},
+  organizations: {},
+  deviceGroupMemberships: {},
+  configPolicyAssignments: {},
+  configurationPolicies: {},
+  configPolicyFeatureLinks: {},
+  pamOrgConfig: {},
+  softwarePolicies: {},
+  softwareComplianceStatus: {},
+  deviceCommands: { $inferSelect: {} },
+  deviceDisks: {},
+  deviceFilesystemSnapshots: {},
+  automationPolicies: {},
+  cisBaselines: {},
+  cisBaselineResults: {},
+  cisRemediationActions: {},
+  securityStatus: {},
+  securityThreats: {},
+  securityScans: {},
+  sensitiveDataFindings: {},
+  sensitiveDataScans: {},
+  sites: {},
+  users: {},
+  deviceGroups: {},
+  configPolicyMonitoringSettings: {},
+  configPolicyMonitoringWatches: {},
+  configPolicyEventLogSettings: {},
+}));
+
+vi.mock('../../services/redis', () => ({ getRedis: vi.fn(() => null) }));
+vi.mock('../../services/eventBus', () => ({ publishEvent: vi.fn() }));
+vi.mock('../../services/commandQueue', () => ({ queueCommandForExecution: vi.fn() }));
+vi.mock('../../services/cisHardening', () => ({ parseCisCollectorOutput: vi.fn() }));
+vi.mock('../../services/sentry', () => ({ captureException: vi.fn() }));
+vi.mock('../../services/cloudflareMtls', () => ({ CloudflareMtlsService: vi.fn() }));
+vi.mock('../../services/softwarePolicyService', () => ({ recordSoftwarePolicyAudit: vi.fn() }));
+vi.mock('../../services/featureConfigResolver', () => ({
+  resolvePatchConfigForDevice: vi.fn(),
+  resolveMaintenanceConfigForDevice: vi.fn(),
+  resolvePatchConfigDetailsForDevice: vi.fn(),
+}));
+vi.mock('../../services/filesystemAnalysis', () => ({
+  getFilesystemScanState: vi.fn(),
+  mergeFilesystemAnalysisPayload: vi.fn(),
+  parseFilesystemAnalysisStdout: vi.fn(),
+  readCheckpointPendingDirectories: vi.fn(),
+  readHotDirectories: vi.fn(),
+  saveFilesystemSnapshot: vi.fn(),
+  upsertFilesystemScanState: vi.fn(),
+}));
+vi.mock('../metrics', () => ({
+  recordSoftwareRemediationDecision: vi.fn(),
+  recordSensitiveDataFinding: vi.fn(),
+  recordSensitiveDataRemediationDecision: vi.fn(),
+}));
+vi.mock('../../jobs/softwareComplianceWorker', () => ({
+  scheduleSoftwareComplianceCheck: vi.fn(),
+}));
+vi.mock('./policyProbeSafety', () => ({ isAllowedPolicyConfigProbe: vi.fn(() => true) }));
+
+import { toAgentPatchCompliancePolicy, toAgentPatchRebootDeferral } from './helpers';
+
+describe('toAgentPatchCompliancePolicy', () => {
+  it('maps both severities', () => {
+    expect(toAgentPatchCompliancePolicy({ complianceMaxAgeDays: { critical: 14, important: 30 } })).toEqual({
+      criticalMaxAgeDays: 14,
+      importantMaxAgeDays: 30,
+    });
+  });
+
+  it('leaves an unset severity unenforced', () => {
+    expect(toAgentPatchCompliancePolicy({ complianceMaxAgeDays: { critical: 7 } })).toEqual({
+      criticalMaxAgeDays: 7,
+      importantMaxAgeDays: 0,
+    });
+  });
+
+  it('returns null (clears the agent policy) when unset or malformed', () => {
+    expect(toAgentPatchCompliancePolicy(null)).toBeNull();
+    expect(toAgentPatchCompliancePolicy({ sources: ['os'] })).toBeNull();
+    expect(toAgentPatchCompliancePolicy({ complianceMaxAgeDays: {} })).toBeNull();
+    expect(toAgentPatchCompliancePolicy({ complianceMaxAgeDays: { critical: 0 } })).toBeNull();
+    expect(toAgentPatchCompliancePolicy({ complianceMaxAgeDays: { critical: 'soon' } })).toBeNull();
+  });
+});
+
+describe('toAgentPatchRebootDeferral', () => {
+  it('passes the policy limit through', () => {
+    expect(toAgentPatchRebootDeferral({ rebootMaxDeferrals: 3 })).toEqual({ maxDeferrals: 3 });
+  });
+
+  it('disables the prompt when unset or malformed', () => {
+    expect(toAgentPatchRebootDeferral(null)).toEqual({ maxDeferrals: 0 });
+    expect(toAgentPatchRebootDeferral({})).toEqual({ maxDeferrals: 0 });
+    expect(toAgentPatchRebootDeferral({ rebootMaxDeferrals: -1 })).toEqual({ maxDeferrals: 0 });
+    expect(toAgentPatchRebootDeferral({ rebootMaxDeferrals: 2.5 })).toEqual({ maxDeferrals: 0 });
+  });
+
+  it('caps the limit at what the agent accepts', () => {
+    expect(toAgentPatchRebootDeferral({ rebootMaxDeferrals: 50 })).toEqual({ maxDeferrals: 10 });
+  });
+});
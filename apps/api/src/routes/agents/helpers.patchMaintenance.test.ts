@@ -0,0 +1,106 @@
+/**
+ * Tests for toAgentPatchMaintenanceWindows — the mapping from the maintenance
+ * config policy to the patch windows the agent enforces locally. Module mocks
+ * mirror helpers.patchSource.test.ts so helpers.ts imports without a DB.
+ */
+import { describe, expect, it, vi } from 'vitest';
+
+vi.mock('../../db', () => ({
+  runOutsideDbContext: vi.fn((fn: () => unknown) => fn()),
+  withDbAccessContext: vi.fn(async (_ctx: unknown, fn: () => Promise<unknown>) => fn()),
+  withSystemDbAccessContext: vi.fn(async (fn: () => Promise<unknown>) => fn()),
+  db: { select: vi.fn() },
+}));
+
+vi.mock('../../db/schema', () => ({
+  devices: {},
+  organizations: {},
+  deviceGroupMemberships: {},
+  configPolicyAssignments: {},
+  configurationPolicies: {},
+  configPolicyFeatureLinks: {},
+  pamOrgConfig: {},
+  softwarePolicies: {},
+  softwareComplianceStatus: {},
+  deviceCommands: { $inferSelect: {} },
+  deviceDisks: {},
+  deviceFilesystemSnapshots: {},
+  automationPolicies: {},
+  cisBaselines: {},
+  cisBaselineResults: {},
+  cisRemediationActions: {},
+  securityStatus: {},
+  securityThreats: {},
+  securityScans: {},
+  sensitiveDataFindings: {},
+  sensitiveDataScans: {},
+  sites: {},
+  users: {},
+  deviceGroups: {},
+  configPolicyMonitoringSettings: {},
+  configPolicyMonitoringWatches: {},
+  configPolicyEventLogSettings: {},
+}));
+
+vi.mock('../../services/redis', () => ({ getRedis: vi.fn(() => null) }));
+vi.mock('../../services/eventBus', () => ({ publishEvent: vi.fn() }));
+vi.mock('../../services/commandQueue', () => ({ queueCommandForExecution: vi.fn() }));
+vi.mock('../../services/cisHardening', () => ({ parseCisCollectorOutput: vi.fn() }));
+vi.mock('../../services/sentry', () => ({ captureException: vi.fn() }));
+vi.mock('../../services/cloudflareMtls', () => ({ CloudflareMtlsService: vi.fn() }));
+vi.mock('../../services/softwarePolicyService', () => ({ recordSoftwarePolicyAudit: vi.fn() }));
+vi.mock('../../services/featureConfigResolver', () => ({
+  resolvePatchConfigForDevice: vi.fn(),
+  resolveMaintenanceConfigForDevice: vi.fn(),
+}));
+vi.mock('../../services/filesystemAnalysis', () => ({
+  getFilesystemScanState: vi.fn(),
+  mergeFilesystemAnalysisPayload: vi.fn(),
+  parseFilesystemAnalysisStdout: vi.fn(),
+  readCheckpointPendingDirectories: vi.fn(),
+  readHotDirectories: vi.fn(),
+  saveFilesystemSnapshot: vi.fn(),
+  upsertFilesystemScanState: vi.fn(),
+}));
+vi.mock('../metrics', () => ({
+  recordSoftwareRemediationDecision: vi.fn(),
+  recordSensitiveDataFinding: vi.fn(),
+  recordSensitiveDataRemediationDecision: vi.fn(),
+}));
+vi.mock('../../jobs/softwareComplianceWorker', () => ({
+  scheduleSoftwareComplianceCheck: vi.fn(),
+}));
+vi.mock('./policyProbeSafety', () => ({ isAllowedPolicyConfigProbe: vi.fn(() => true) }));
+
+import { toAgentPatchMaintenanceWindows } from './helpers';
+
+const base = { recurrence: 'weekly', durationHours: 4, timezone: 'America/New_York', suppressPatching: false };
+
+describe('toAgentPatchMaintenanceWindows', () => {
+  it('returns no windows without a maintenance policy (lifts the restriction)', () => {
+    expect(toAgentPatchMaintenanceWindows(null)).toEqual([]);
+  });
+
+  it('maps a weekly window to Sunday midnight', () => {
+    expect(toAgentPatchMaintenanceWindows(base)).toEqual([
+      { days: ['sunday'], start: '00:00', durationMinutes: 240, timezone: 'America/New_York' },
+    ]);
+  });
+
+  it('maps a daily window to every day and defaults the timezone to UTC', () => {
+    expect(toAgentPatchMaintenanceWindows({ ...base, recurrence: 'daily', timezone: null })).toEqual([
+      { days: [], start: '00:00', durationMinutes: 240, timezone: 'UTC' },
+    ]);
+  });
+
+  it('caps the duration at one week', () => {
+    const [window] = toAgentPatchMaintenanceWindows({ ...base, durationHours: 500 });
+    expect(window?.durationMinutes).toBe(7 * 24 * 60);
+  });
+
+  it('returns no windows for monthly, one-off and patch-suppressing policies', () => {
+    expect(toAgentPatchMaintenanceWindows({ ...base, recurrence: 'monthly' })).toEqual([]);
+    expect(toAgentPatchMaintenanceWindows({ ...base, recurrence: 'once' })).toEqual([]);
+    expect(toAgentPatchMaintenanceWindows({ ...base, suppressPatching: true })).toEqual([]);
+  });
+});
@@ -65,6 +65,7 @@ vi.mock('../../services/cloudflareMtls', () => ({ CloudflareMtlsService: vi.fn()
 vi.mock('../../services/softwarePolicyService', () => ({ recordSoftwarePolicyAudit: vi.fn() }));
 vi.mock('../../services/featureConfigResolver', () => ({
   resolvePatchConfigForDevice: resolvePatchConfigForDeviceMock,
+  resolveMaintenanceConfigForDevice: vi.fn(),
 }));
 vi.mock('../../services/filesystemAnalysis', () => ({
   getFilesystemScanState: vi.fn(),
@@ -54,7 +54,11 @@ import {
   upsertFilesystemScanState,
 } from '../../services/filesystemAnalysis';
 import { recordSoftwarePolicyAudit } from '../../services/softwarePolicyService';
-import { resolvePatchConfigForDevice } from '../../services/featureConfigResolver';
+import {
+  resolveMaintenanceConfigForDevice,
+  resolvePatchConfigDetailsForDevice,
+  resolvePatchConfigForDevice,
+} from '../../services/featureConfigResolver';
 import { resolveUserGroupMembershipCached } from '../../services/onedriveGraph';
 import { captureException } from '../../services/sentry';
 import { redactSecretsDeep, redactOptionalSecretText } from '../../services/secretRedaction';
@@ -65,7 +69,13 @@ import {
   normalizeAgentUpdatePolicy,
   type AgentUpdateSettings,
 } from './agentUpdatePolicy';
-import { isAlwaysMaintenanceWindow, parseMaintenanceWindow, normalizeVersionPin } from '@breeze/shared';
+import {
+  isAlwaysMaintenanceWindow,
+  parseMaintenanceWindow,
+  normalizeVersionPin,
+  patchComplianceMaxAgeDaysSchema,
+  appUpdatePolicySchema,
+} from '@breeze/shared';
 import {
   type SecurityProviderValue,
   type SecurityStatusPayload,
@@ -479,6 +489,20 @@ export async function upsertSecurityStatusForDevice(deviceId: string, orgId: str
   const avProducts = Array.isArray(payload.avProducts) ? payload.avProducts : [];
   const preferredProduct = avProducts.find((p) => p.realTimeProtection) ?? avProducts[0];
   const provider = normalizeProvider(payload.provider ?? preferredProduct?.provider);
+  // Unreported parts stay NULL so an older agent never reads as "no TPM".
+  const bootIntegrity = {
+    tpmPresent: payload.tpm?.present ?? null,
+    tpmVersion: asString(payload.tpm?.version) ?? null,
+    tpmOwned: payload.tpm?.owned ?? null,
+    secureBootEnabled: payload.secureBoot?.enabled ?? null,
+    measuredBootAvailable: payload.measuredBootAvailable ?? null
+  };
+  const agentScore = {
+    agentScore: payload.securityScore?.score ?? null,
+    agentRiskLevel: payload.securityScore?.riskLevel ?? null,
+    agentScoreFactors: payload.securityScore?.factors ?? null,
+    agentScoreComputedAt: parseDate(payload.securityScore?.computedAt)
+  };
 
   await db
     .insert(securityStatus)
@@ -499,6 +523,8 @@ export async function upsertSecurityStatusForDevice(deviceId: string, orgId: str
       localAdminSummary: payload.localAdminSummary ?? null,
       passwordPolicySummary: payload.passwordPolicySummary ?? null,
       gatekeeperEnabled: payload.gatekeeperEnabled ?? payload.guardianEnabled ?? null,
+      ...bootIntegrity,
+      ...agentScore,
       updatedAt: new Date()
     })
     .onConflictDoUpdate({
@@ -518,6 +544,8 @@ export async function upsertSecurityStatusForDevice(deviceId: string, orgId: str
         localAdminSummary: payload.localAdminSummary ?? null,
         passwordPolicySummary: payload.passwordPolicySummary ?? null,
         gatekeeperEnabled: payload.gatekeeperEnabled ?? payload.guardianEnabled ?? null,
+        ...bootIntegrity,
+        ...agentScore,
         updatedAt: new Date()
       }
     });
@@ -590,7 +618,9 @@ export async function handleSecurityCommandResult(
     return;
   }
 
-  if (command.type === securityCommandTypes.scan) {
+  // av_scan runs the installed engine (Defender, mdatp, ClamAV) instead of the
+  // agent's signature scanner but reports in the same shape.
+  if (command.type === securityCommandTypes.scan || command.type === securityCommandTypes.avScan) {
     const payload = isObject(command.payload) ? command.payload : {};
     const scanType = asString(resultJson?.scanType) ?? asString(payload.scanType) ?? 'quick';
     const scanRecordId = asString(resultJson?.scanRecordId) ?? asString(payload.scanRecordId);
@@ -599,6 +629,10 @@ export async function handleSecurityCommandResult(
     const threatsFound = typeof threatsFoundRaw === 'number'
       ? Math.max(0, Math.floor(threatsFoundRaw))
       : threatsValue.length;
+    const itemsScannedRaw = resultJson?.itemsScanned;
+    const itemsScanned = typeof itemsScannedRaw === 'number' && itemsScannedRaw > 0
+      ? Math.floor(itemsScannedRaw)
+      : undefined;
     const completedAt = new Date();
     const durationSeconds = Math.max(0, Math.round((resultData.durationMs ?? 0) / 1000));
 
@@ -618,7 +652,8 @@ export async function handleSecurityCommandResult(
           status: resultData.status === 'completed' ? 'completed' : 'failed',
           completedAt,
           duration: durationSeconds,
-          threatsFound
+          threatsFound,
+          ...(itemsScanned !== undefined ? { itemsScanned } : {})
         })
         .where(eq(securityScans.id, existingScan.id));
     } else {
@@ -631,7 +666,8 @@ export async function handleSecurityCommandResult(
         startedAt: command.createdAt ?? new Date(),
         completedAt,
         threatsFound,
-        duration: durationSeconds
+        duration: durationSeconds,
+        ...(itemsScanned !== undefined ? { itemsScanned } : {})
       });
     }
 
@@ -2691,6 +2727,211 @@ export async function buildPatchSourceConfigUpdate(deviceId: string): Promise<Pa
   return { exclusiveWindowsUpdate: patch?.exclusiveWindowsUpdate ?? false };
 }
 
+// ============================================
+// Patch Maintenance Windows
+// ============================================
+
+/**
+ * A recurring window the agent allows patch installs and patch reboots in;
+ * outside every window it queues them locally. Mirrors
+ * patching.MaintenanceWindow in the agent.
+ */
+export interface AgentPatchMaintenanceWindow {
+  /** Lowercase weekday names; empty means every day. */
+  days: string[];
+  /** Local start time, HH:MM. */
+  start: string;
+  durationMinutes: number;
+  /** IANA timezone the start time is in. */
+  timezone: string;
+}
+
+/** The agent rejects windows longer than a week. */
+const MAX_AGENT_WINDOW_MINUTES = 7 * 24 * 60;
+
+/**
+ * Translates the device's maintenance policy into agent patch windows, with
+ * the same start times isInMaintenanceWindow uses (daily: midnight; weekly:
+ * Sunday midnight). Monthly and one-off windows have no agent equivalent and
+ * a policy that suppresses patching during its window is not a patch window,
+ * so those — like no policy at all — yield an empty list, which tells the
+ * agent to lift any restriction it was sent before.
+ */
+export function toAgentPatchMaintenanceWindows(
+  settings: { recurrence: string; durationHours: number; timezone: string | null; suppressPatching: boolean } | null
+): AgentPatchMaintenanceWindow[] {
+  if (!settings || settings.suppressPatching || settings.durationHours <= 0) {
+    return [];
+  }
+  const durationMinutes = Math.min(settings.durationHours * 60, MAX_AGENT_WINDOW_MINUTES);
+  const timezone = settings.timezone || 'UTC';
+  switch (settings.recurrence) {
+    case 'daily':
+      return [{ days: [], start: '00:00', durationMinutes, timezone }];
+    case 'weekly':
+      return [{ days: ['sunday'], start: '00:00', durationMinutes, timezone }];
+    default:
+      return [];
+  }
+}
+
+/**
+ * Builds the patch_maintenance_windows block for the heartbeat config push.
+ * The caller omits the block on a resolver error so a transient failure
+ * neither lifts nor imposes a restriction.
+ */
+export async function buildPatchMaintenanceConfigUpdate(deviceId: string): Promise<AgentPatchMaintenanceWindow[]> {
+  return toAgentPatchMaintenanceWindows(await resolveMaintenanceConfigForDevice(deviceId));
+}
+
+// ============================================
+// Patch Compliance Policy
+// ============================================
+
+/**
+ * The max pending age per severity the agent evaluates after every patch
+ * scan. Mirrors patching.PatchCompliancePolicy in the agent.
+ */
+export interface AgentPatchCompliancePolicy {
+  criticalMaxAgeDays: number;
+  importantMaxAgeDays: number;
+}
+
+/**
+ * Reads complianceMaxAgeDays from a patch feature link's inline settings.
+ * It has no column on config_policy_patch_settings, so the inline JSON is the
+ * only source. Returns null when unset or malformed, which tells the agent to
+ * clear its policy and stop reporting.
+ */
+export function toAgentPatchCompliancePolicy(inlineSettings: unknown): AgentPatchCompliancePolicy | null {
+  if (!inlineSettings || typeof inlineSettings !== 'object' || Array.isArray(inlineSettings)) {
+    return null;
+  }
+  const parsed = patchComplianceMaxAgeDaysSchema.safeParse(
+    (inlineSettings as Record<string, unknown>).complianceMaxAgeDays
+  );
+  if (!parsed.success || (!parsed.data.critical && !parsed.data.important)) {
+    return null;
+  }
+  return {
+    criticalMaxAgeDays: parsed.data.critical ?? 0,
+    importantMaxAgeDays: parsed.data.important ?? 0,
+  };
+}
+
+/**
+ * Builds the patch_compliance_policy block for the heartbeat config push from
+ * the device's winning patch config policy. The caller omits the block on a
+ * resolver error so a transient failure does not clear the agent's policy.
+ */
+export async function buildPatchComplianceConfigUpdate(deviceId: string): Promise<AgentPatchCompliancePolicy | null> {
+  return toAgentPatchCompliancePolicy(await loadPatchInlineSettingsForDevice(deviceId));
+}
+
+/**
+ * Loads the inline settings of the device's winning patch feature link, the
+ * only source for the agent-evaluated fields that have no column on
+ * config_policy_patch_settings. null when no patch policy applies.
+ */
+async function loadPatchInlineSettingsForDevice(deviceId: string): Promise<unknown> {
+  const resolved = await resolvePatchConfigDetailsForDevice(deviceId);
+  if (!resolved) return null;
+  const [link] = await db
+    .select({ inlineSettings: configPolicyFeatureLinks.inlineSettings })
+    .from(configPolicyFeatureLinks)
+    .where(eq(configPolicyFeatureLinks.id, resolved.featureLinkId))
+    .limit(1);
+  return link?.inlineSettings ?? null;
+}
+
+// ============================================
+// Patch Reboot Deferral
+// ============================================
+
+/** Mirrors the agent's patching.RebootManager deferral limit. */
+const MAX_AGENT_REBOOT_DEFERRALS = 10;
+
+/**
+ * How many times the logged-in user may postpone a patch reboot from the
+ * agent's warning prompt, read from the patch policy's rebootMaxDeferrals.
+ * Unset or malformed values disable the prompt.
+ */
+export function toAgentPatchRebootDeferral(inlineSettings: unknown): { maxDeferrals: number } {
+  const raw =
+    inlineSettings && typeof inlineSettings === 'object' && !Array.isArray(inlineSettings)
+      ? (inlineSettings as Record<string, unknown>).rebootMaxDeferrals
+      : undefined;
+  const maxDeferrals =
+    typeof raw === 'number' && Number.isInteger(raw) && raw > 0 ? Math.min(raw, MAX_AGENT_REBOOT_DEFERRALS) : 0;
+  return { maxDeferrals };
+}
+
+/**
+ * Builds the patch_reboot_deferral block for the heartbeat config push. The
+ * caller omits the block on a resolver error so a transient failure keeps the
+ * agent's current limit.
+ */
+export async function buildPatchRebootDeferralConfigUpdate(deviceId: string): Promise<{ maxDeferrals: number }> {
+  return toAgentPatchRebootDeferral(await loadPatchInlineSettingsForDevice(deviceId));
+}
+
+// ============================================
+// App Update Policies
+// ============================================
+
+/**
+ * One third-party app update ring as the agent enforces it. Mirrors
+ * patching.AppUpdatePolicy in the agent; testRing is resolved per device.
+ */
+export interface AgentAppUpdatePolicy {
+  provider: 'winget' | 'chocolatey' | 'homebrew';
+  packageId: string;
+  channel: 'latest' | 'pin' | 'n-1';
+  pinnedVersion?: string;
+  blockedVersions?: string[];
+  testRing?: boolean;
+  ringDelayDays?: number;
+}
+
+/**
+ * Reads appUpdatePolicies from a patch feature link's inline settings for one
+ * device. Entries that fail validation are dropped rather than failing the
+ * whole list. An empty list clears the agent's policies.
+ */
+export function toAgentAppUpdatePolicies(inlineSettings: unknown, deviceId: string): AgentAppUpdatePolicy[] {
+  if (!inlineSettings || typeof inlineSettings !== 'object' || Array.isArray(inlineSettings)) {
+    return [];
+  }
+  const raw = (inlineSettings as Record<string, unknown>).appUpdatePolicies;
+  if (!Array.isArray(raw)) return [];
+
+  const policies: AgentAppUpdatePolicy[] = [];
+  for (const entry of raw) {
+    const parsed = appUpdatePolicySchema.safeParse(entry);
+    if (!parsed.success) continue;
+    const policy = parsed.data;
+    policies.push({
+      provider: policy.provider,
+      packageId: policy.packageId,
+      channel: policy.channel,
+      ...(policy.pinnedVersion ? { pinnedVersion: policy.pinnedVersion } : {}),
+      ...(policy.blockedVersions.length > 0 ? { blockedVersions: policy.blockedVersions } : {}),
+      ...(policy.testRingDeviceIds.includes(deviceId) ? { testRing: true } : {}),
+      ...(policy.ringDelayDays > 0 ? { ringDelayDays: policy.ringDelayDays } : {}),
+    });
+  }
+  return policies;
+}
+
+/**
+ * Builds the app_update_policies block for the heartbeat config push. The
+ * caller omits the block on a resolver error so a transient failure keeps the
+ * agent's current policies.
+ */
+export async function buildAppUpdatePolicyConfigUpdate(deviceId: string): Promise<AgentAppUpdatePolicy[]> {
+  return toAgentAppUpdatePolicies(await loadPatchInlineSettingsForDevice(deviceId), deviceId);
+}
+
 // ============================================
 // OneDrive Helper Config
 // ============================================
@@ -23,6 +23,11 @@ import { elevationRequestsRoutes } from './elevationRequests';
 import { processSampleRoutes } from './processSample';
 import { unifiTelemetryRoutes } from './unifiTelemetry';
 import { wingetBootstrapRoutes } from './wingetBootstrap';
+import { vulnerabilityScanRoutes } from './vulnerabilityScan';
+import { inventorySnapshotRoutes } from './inventorySnapshots';
+import { softwareUsageRoutes } from './softwareUsage';
+import { transcriptRoutes } from './transcripts';
+import { desktopSessionRoutes } from './desktopSessions';
 
 export const agentRoutes = new Hono();
 
@@ -83,3 +88,8 @@ agentRoutes.route('/', elevationRequestsRoutes);
 agentRoutes.route('/', processSampleRoutes);
 agentRoutes.route('/', unifiTelemetryRoutes);
 agentRoutes.route('/', wingetBootstrapRoutes);
+agentRoutes.route('/', vulnerabilityScanRoutes);
+agentRoutes.route('/', inventorySnapshotRoutes);
+agentRoutes.route('/', softwareUsageRoutes);
+agentRoutes.route('/', transcriptRoutes);
+agentRoutes.route('/', desktopSessionRoutes);
@@ -0,0 +1,322 @@
+import { beforeEach, describe, expect, it, vi } from 'vitest';
+import { Hono } from 'hono';
+
+const AGENT_ID = 'agent-1';
+const DEVICE_ID = '11111111-1111-4111-8111-111111111111';
+const ORG_ID = '22222222-2222-4222-8222-222222222222';
+
+vi.mock('../../middleware/requireAgentRole', () => ({
+  requireAgentRole: async (c: any, next: any) => {
+    c.set('agent', { agentId: AGENT_ID, deviceId: DEVICE_ID, orgId: ORG_ID, role: 'agent' });
+    await next();
+  },
+}));
+
+const { upsertMock, findCollectorPluginMock } = vi.hoisted(() => ({
+  upsertMock: vi.fn(async () => undefined),
+  findCollectorPluginMock: vi.fn(async (_orgId: string, slug: string) => (slug === 'smart-health' ? { installationId: 'inst-1' } : null)),
+}));
+
+vi.mock('../../services/deviceInventorySnapshots', () => ({ upsertInventorySnapshot: upsertMock }));
+vi.mock('../../services/plugins', () => ({ findEnabledCollectorPlugin: findCollectorPluginMock }));
+
+import { inventorySnapshotRoutes } from './inventorySnapshots';
+
+function buildApp() {
+  const app = new Hono();
+  app.route('/', inventorySnapshotRoutes);
+  return app;
+}
+
+function put(path: string, body: unknown, agentId = AGENT_ID) {
+  return buildApp().request(`/${agentId}/${path}`, {
+    method: 'PUT',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(body),
+  });
+}
+
+// One valid upload per inventory kind, as the agent sends it.
+const uploads: Array<{ path: string; kind: string; body: Record<string, unknown>; count: number }> = [
+  {
+    path: 'processes',
+    kind: 'processes',
+    body: {
+      collectedAt: '2026-03-01T12:00:00.123456789Z',
+      processes: [
+        { pid: 4, name: 'System', cpuPercent: 0.1, rssBytes: 1024, signatureStatus: 'unknown' },
+        {
+          pid: 812, parentPid: 4, name: 'chrome.exe', path: 'C:\\Program Files\\Google\\Chrome\\chrome.exe',
+          sha256: 'a'.repeat(64), user: 'CORP\\alice', cpuPercent: 3.5, rssBytes: 104857600,
+          startTime: '2026-03-01T08:00:00Z', signatureStatus: 'signed', signer: 'Google LLC',
+        },
+      ],
+    },
+    count: 2,
+  },
+  {
+    path: 'synthetics',
+    kind: 'synthetics',
+    body: {
+      results: [
+        {
+          monitorId: 'intranet', name: 'Intranet', url: 'https://intranet.corp.local/', status: 'online', statusCode: 200,
+          responseMs: 84.2, keywordMatched: true, certExpiresAt: '2026-09-01T00:00:00Z', certDaysRemaining: 184,
+          checkedAt: '2026-03-01T12:00:00Z',
+        },
+        { monitorId: 'erp', url: 'https://erp.corp.local/', status: 'offline', responseMs: 0, error: 'connection refused', checkedAt: '2026-03-01T12:00:00Z' },
+      ],
+    },
+    count: 2,
+  },
+  {
+    path: 'certificates',
+    kind: 'certificates',
+    body: {
+      certificates: [
+        {
+          store: 'LocalMachine\\My', subject: 'CN=vpn-client.corp.local', issuer: 'CN=Corp Issuing CA', serialNumber: '1a2b3c',
+          thumbprint: 'ab'.repeat(20), notBefore: '2025-03-01T00:00:00Z', notAfter: '2026-03-01T00:00:00Z',
+          keyUsage: ['digitalSignature'], extendedKeyUsage: ['clientAuth'], dnsNames: ['vpn-client.corp.local'],
+          isCA: false, selfSigned: false, hasPrivateKey: true,
+        },
+      ],
+    },
+    count: 1,
+  },
+  {
+    path: 'containers',
+    kind: 'containers',
+    body: {
+      runtimes: [
+        {
+          name: 'docker', version: '27.1.1',
+          containers: [
+            { id: 'f3a1', name: 'web', image: 'nginx:1.27', state: 'running', status: 'Up 2 hours', ports: '0.0.0.0:80->80/tcp', cpuPercent: 0.4, memoryUsageBytes: 10485760 },
+            { id: '9c2e', name: 'migrate', image: 'app:latest', state: 'exited', status: 'Exited (0) 3 days ago' },
+          ],
+          images: [{ id: 'sha256:abc', repository: 'nginx', tag: '1.27', sizeBytes: 192000000 }],
+        },
+        { name: 'podman', error: 'cannot connect to podman socket', containers: [], images: [] },
+      ],
+    },
+    count: 2,
+  },
+  {
+    path: 'browser-extensions',
+    kind: 'browser_extensions',
+    body: {
+      extensions: [
+        {
+          browser: 'chrome', scope: 'user', source: 'profile', user: 'alice', profile: 'Default',
+          id: 'cjpalhdlnbpafiamejdnhcphjbkeiagm', name: 'uBlock Origin', version: '1.58.0', enabled: true,
+          permissions: ['storage', 'webRequest'], hostPermissions: ['<all_urls>'],
+        },
+        { browser: 'edge', scope: 'machine', source: 'policy', id: 'odfafepnkmbhccpbejgmiehpchacaeak', updateUrl: 'https://edge.microsoft.com/extensionwebstorebase/v1/crx' },
+      ],
+    },
+    count: 2,
+  },
+  {
+    path: 'changelog',
+    kind: 'changelog',
+    body: {
+      entries: [
+        { timestamp: '2026-02-20T09:00:00Z', kind: 'agent_upgrade', from: '0.9.1', to: '0.9.2', initiator: 'auto_update' },
+        { timestamp: '2026-02-21T10:30:00Z', kind: 'feature_flag', name: 'rmm_cleanup', from: 'off', to: 'on' },
+      ],
+    },
+    count: 2,
+  },
+  {
+    path: 'management/cleanup',
+    kind: 'rmm_cleanup',
+    body: {
+      commandId: 'cmd-1',
+      dryRun: false,
+      completedAt: '2026-03-01T12:05:00Z',
+      results: [
+        {
+          name: 'ScreenConnect', status: 'removed', startedAt: '2026-03-01T12:00:00Z', durationMs: 42000,
+          steps: [{ type: 'uninstall_product', target: 'ScreenConnect Client (abc123)' }],
+        },
+        { name: 'Atera', status: 'incomplete', remaining: ['service:AteraAgent'], startedAt: '2026-03-01T12:01:00Z', durationMs: 60000 },
+      ],
+    },
+    count: 2,
+  },
+  {
+    path: 'usb-devices',
+    kind: 'usb_devices',
+    body: {
+      devices: [
+        {
+          vendorId: '0781', productId: '5581', vendor: 'SanDisk', product: 'Ultra', serialNumber: '4C530001',
+          class: 'mass_storage', massStorage: true, connected: true, firstSeen: '2026-02-01T09:00:00Z', lastSeen: '2026-03-01T12:00:00Z',
+        },
+        { vendorId: '046d', productId: 'c52b', class: 'hid', massStorage: false, connected: false, firstSeen: '2026-01-10T08:00:00Z', lastSeen: '2026-02-28T17:00:00Z' },
+      ],
+    },
+    count: 2,
+  },
+  {
+    path: 'local-users',
+    kind: 'local_users',
+    body: {
+      users: [
+        {
+          username: 'alice', fullName: 'Alice Smith', uid: '1000', homeDir: '/home/alice', shell: '/bin/bash',
+          disabled: false, locked: false, isAdmin: true, isSystem: false, groups: ['alice', 'sudo'],
+          lastLogon: '2026-03-01T08:00:00Z', passwordLastSet: '2026-01-01T00:00:00Z', passwordAgeDays: 59,
+        },
+        { username: 'daemon', uid: '1', disabled: true, locked: true, isAdmin: false, isSystem: true, groups: [] },
+      ],
+      groups: [{ name: 'sudo', gid: '27', isAdmin: true, members: ['alice'] }],
+    },
+    count: 2,
+  },
+  {
+    path: 'wifi',
+    kind: 'wifi',
+    body: {
+      connected: [
+        {
+          interface: 'wlan0', ssid: 'CorpWiFi', bssid: 'aa:bb:cc:dd:ee:ff', signalDbm: -52, signalPercent: 96,
+          channel: 36, band: '5GHz', frequencyMhz: 5180, security: 'WPA2 Enterprise', txRateMbps: 866.7,
+        },
+      ],
+      nearby: [{ ssid: 'Guest', signalPercent: 40, channel: 6, band: '2.4GHz' }],
+      nearbyScanned: true,
+    },
+    count: 1,
+  },
+  {
+    path: 'process-network',
+    kind: 'process_network',
+    body: {
+      source: 'etw',
+      intervalSeconds: 900.2,
+      processes: [
+        { pid: 812, processName: 'chrome.exe', bytesSent: 1048576, bytesReceived: 52428800 },
+        { pid: 4, processName: 'System', bytesSent: 2048, bytesReceived: 4096 },
+      ],
+    },
+    count: 2,
+  },
+  {
+    path: 'bluetooth-devices',
+    kind: 'bluetooth_devices',
+    body: {
+      devices: [
+        { address: 'A4:C1:38:12:34:56', name: 'MX Keys', class: 'peripheral', type: 'keyboard', paired: true, connected: true, batteryPercent: 80 },
+        { address: '00:1B:66:AA:BB:CC', name: 'Headset', class: 'audio_video', paired: true },
+      ],
+    },
+    count: 2,
+  },
+  {
+    path: 'licensing',
+    kind: 'licensing',
+    body: {
+      edition: 'Professional', productName: 'Windows 11 Pro', activated: true, status: 'licensed', channel: 'oem', officeActivated: false,
+      products: [
+        { application: 'windows', name: 'Windows(R), Professional edition', status: 'licensed', activated: true, channel: 'oem', partialProductKey: '3V66T' },
+        { application: 'office', name: 'Office 16, Office16ProPlusVL_KMS_Client edition', status: 'oob_grace', activated: false, channel: 'kms', graceMinutesRemaining: 43200, kmsHost: 'kms.corp.local:1688' },
+      ],
+    },
+    count: 2,
+  },
+];
+
+// One upload per kind that the schema must reject.
+const invalidUploads: Array<{ path: string; body: Record<string, unknown> }> = [
+  { path: 'processes', body: { processes: [{ pid: 1, name: 'init', cpuPercent: 0, rssBytes: 0, signatureStatus: 'trusted' }] } },
+  { path: 'certificates', body: { certificates: [{ store: 'My', subject: 'CN=x', issuer: 'CN=x', serialNumber: '1', thumbprint: 'xyz', notBefore: '', notAfter: '', isCA: false, selfSigned: true, hasPrivateKey: false }] } },
+  { path: 'synthetics', body: { results: [{ monitorId: 'x', url: 'https://x/', status: 'up', responseMs: 1, checkedAt: 'now' }] } },
+  { path: 'containers', body: { runtimes: [{ name: 'lxc', containers: [], images: [] }] } },
+  { path: 'browser-extensions', body: { extensions: [{ browser: 'safari', scope: 'user', source: 'profile', id: 'x' }] } },
+  { path: 'changelog', body: { entries: [{ timestamp: '2026-02-20T09:00:00Z', kind: 'reboot' }] } },
+  { path: 'management/cleanup', body: { dryRun: false, completedAt: '2026-03-01T12:05:00Z', results: [{ name: 'Atera', status: 'gone', startedAt: '', durationMs: 1 }] } },
+  { path: 'usb-devices', body: { devices: [{ vendorId: '0781', productId: '5581', class: 'storage', massStorage: true, connected: true, firstSeen: '', lastSeen: '' }] } },
+  { path: 'local-users', body: { users: [{ username: 'alice', disabled: false, locked: false, isAdmin: 'yes', isSystem: false, groups: [] }], groups: [] } },
+  { path: 'wifi', body: { connected: [{ ssid: 'CorpWiFi', signalPercent: 140 }], nearbyScanned: false } },
+  { path: 'process-network', body: { source: 'etw', intervalSeconds: 900, processes: [{ pid: 812, processName: 'chrome.exe', bytesSent: -1, bytesReceived: 0 }] } },
+  { path: 'bluetooth-devices', body: { devices: [{ address: 'a4-c1-38-12-34-56', class: 'peripheral', paired: true }] } },
+  { path: 'licensing', body: { activated: true, products: [{ application: 'windows', name: 'Windows', status: 'activated', activated: true, channel: 'oem' }] } },
+];
+
+describe('agent inventory snapshot routes', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  it.each(uploads)('stores the $path upload for the authenticated device', async ({ path, kind, body, count }) => {
+    const res = await put(path, body);
+    expect(res.status).toBe(200);
+    expect(await res.json()).toEqual({ success: true, count });
+    expect(upsertMock).toHaveBeenCalledWith(expect.objectContaining({
+      deviceId: DEVICE_ID,
+      orgId: ORG_ID,
+      kind,
+      itemCount: count,
+    }));
+  });
+
+  it.each(invalidUploads)('rejects a malformed $path upload with 400', async ({ path, body }) => {
+    const res = await put(path, body);
+    expect(res.status).toBe(400);
+    expect(upsertMock).not.toHaveBeenCalled();
+  });
+
+  it('records the agent-reported collection time', async () => {
+    await put('processes', { collectedAt: '2026-03-01T12:00:00Z', processes: [] });
+    expect(upsertMock).toHaveBeenCalledWith(expect.objectContaining({
+      collectedAt: new Date('2026-03-01T12:00:00Z'),
+    }));
+  });
+
+  it('merges synthetic results into the previous snapshot by monitor id', async () => {
+    const result = { monitorId: 'intranet', url: 'https://intranet/', status: 'degraded', responseMs: 2400, checkedAt: '2026-03-01T12:00:00Z' };
+    await put('synthetics', { results: [result] });
+    expect(upsertMock).toHaveBeenCalledWith(expect.objectContaining({
+      kind: 'synthetics',
+      merge: true,
+      data: { intranet: result },
+    }));
+  });
+
+  describe('collector plugins', () => {
+    const run = { plugin: 'disk-smart', version: '1.2.0', collectedAt: '2026-03-01T12:00:00Z', durationMs: 830, data: { disks: [{ name: 'sda', healthy: true }] } };
+
+    it('stores the result of a registered collector plugin under its endpoint', async () => {
+      const res = await put('plugins/smart-health', run);
+      expect(res.status).toBe(200);
+      expect(findCollectorPluginMock).toHaveBeenCalledWith(ORG_ID, 'smart-health');
+      expect(upsertMock).toHaveBeenCalledWith(expect.objectContaining({
+        deviceId: DEVICE_ID,
+        kind: 'plugins',
+        merge: true,
+        data: { 'smart-health': run },
+        collectedAt: new Date('2026-03-01T12:00:00Z'),
+      }));
+    });
+
+    it('rejects an endpoint with no enabled collector plugin', async () => {
+      const res = await put('plugins/unknown-endpoint', run);
+      expect(res.status).toBe(404);
+      expect(upsertMock).not.toHaveBeenCalled();
+    });
+
+    it('rejects a run with both data and error', async () => {
+      const res = await put('plugins/smart-health', { ...run, error: 'exit status 1' });
+      expect(res.status).toBe(400);
+      expect(upsertMock).not.toHaveBeenCalled();
+    });
+  });
+
+  it('refuses an upload for another agent id', async () => {
+    const res = await put('processes', { processes: [] }, 'agent-2');
+    expect(res.status).toBe(403);
+    expect(upsertMock).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,224 @@
+import { Hono } from 'hono';
+import { bodyLimit } from 'hono/body-limit';
+import type { z } from 'zod';
+import { zValidator } from '../../lib/validation';
+import { type AgentAuthContext } from '../../middleware/agentAuth';
+import { requireAgentRole } from '../../middleware/requireAgentRole';
+import { upsertInventorySnapshot, type InventorySnapshotKind } from '../../services/deviceInventorySnapshots';
+import { findEnabledCollectorPlugin } from '../../services/plugins';
+import {
+  agentChangelogIngestSchema,
+  bluetoothDeviceInventoryIngestSchema,
+  browserExtensionInventoryIngestSchema,
+  certificateInventoryIngestSchema,
+  collectorPluginResultIngestSchema,
+  containerInventoryIngestSchema,
+  licensingStatusIngestSchema,
+  localUserInventoryIngestSchema,
+  processInventoryIngestSchema,
+  processNetworkUsageIngestSchema,
+  rmmCleanupReportIngestSchema,
+  syntheticResultsIngestSchema,
+  usbDeviceInventoryIngestSchema,
+  wifiStatusIngestSchema,
+} from './schemas';
+
+export const inventorySnapshotRoutes = new Hono();
+// Inventory is collected by the main agent; reject watchdog-role tokens so a
+// weaker credential can't overwrite what the console shows for the device.
+inventorySnapshotRoutes.use('*', requireAgentRole);
+
+type SnapshotRoute<S extends z.ZodTypeAny> = {
+  path: string;
+  kind: InventorySnapshotKind;
+  schema: S;
+  count: (data: z.infer<S>) => number;
+  collectedAt?: (data: z.infer<S>) => string | undefined;
+  maxSize?: number;
+  /**
+   * For batched reports: store `keyed(data)` (an object keyed by item id)
+   * merged into the previous snapshot, so items missing from this batch keep
+   * their last value.
+   */
+  keyed?: (data: z.infer<S>) => Record<string, unknown>;
+};
+
+function parseCollectedAt(value: string | undefined): Date | null {
+  const parsed = value ? new Date(value) : null;
+  return parsed && !Number.isNaN(parsed.getTime()) ? parsed : null;
+}
+
+/**
+ * Registers PUT /:id/<path>: the agent's latest snapshot of one inventory
+ * kind, replacing the previous one (or merged into it, for `keyed` routes).
+ * Tenancy comes from the authenticated agent, never from the payload.
+ */
+function snapshotRoute<S extends z.ZodTypeAny>(route: SnapshotRoute<S>) {
+  inventorySnapshotRoutes.put(
+    `/:id/${route.path}`,
+    bodyLimit({ maxSize: route.maxSize ?? 5 * 1024 * 1024, onError: (c) => c.json({ error: 'Request body too large' }, 413) }),
+    zValidator('json', route.schema),
+    async (c) => {
+      const agent = c.get('agent') as AgentAuthContext | undefined;
+      if (!agent || agent.agentId !== c.req.param('id')) {
+        return c.json({ error: 'Forbidden' }, 403);
+      }
+      const data = c.req.valid('json') as z.infer<S>;
+      const count = route.count(data);
+      const stored = route.keyed ? route.keyed(data) : data;
+
+      await upsertInventorySnapshot({
+        deviceId: agent.deviceId,
+        orgId: agent.orgId,
+        kind: route.kind,
+        data: stored,
+        itemCount: route.keyed ? Object.keys(stored).length : count,
+        merge: Boolean(route.keyed),
+        collectedAt: parseCollectedAt(route.collectedAt?.(data)),
+      });
+      return c.json({ success: true, count });
+    }
+  );
+}
+
+snapshotRoute({
+  path: 'processes',
+  kind: 'processes',
+  schema: processInventoryIngestSchema,
+  count: (data) => data.processes.length,
+  collectedAt: (data) => data.collectedAt,
+});
+
+// Synthetic HTTP(S) checks report only the monitors that were due, so the
+// snapshot keeps the latest result per monitor.
+snapshotRoute({
+  path: 'synthetics',
+  kind: 'synthetics',
+  schema: syntheticResultsIngestSchema,
+  count: (data) => data.results.length,
+  keyed: (data) => Object.fromEntries(data.results.map((result) => [result.monitorId, result])),
+  maxSize: 1024 * 1024,
+});
+
+snapshotRoute({
+  path: 'certificates',
+  kind: 'certificates',
+  schema: certificateInventoryIngestSchema,
+  count: (data) => data.certificates.length,
+});
+
+snapshotRoute({
+  path: 'containers',
+  kind: 'containers',
+  schema: containerInventoryIngestSchema,
+  count: (data) => data.runtimes.reduce((total, runtime) => total + runtime.containers.length, 0),
+});
+
+snapshotRoute({
+  path: 'browser-extensions',
+  kind: 'browser_extensions',
+  schema: browserExtensionInventoryIngestSchema,
+  count: (data) => data.extensions.length,
+});
+
+snapshotRoute({
+  path: 'changelog',
+  kind: 'changelog',
+  schema: agentChangelogIngestSchema,
+  count: (data) => data.entries.length,
+  maxSize: 1024 * 1024,
+});
+
+// The migration-cleanup status is per tool: a later run for one tool must not
+// drop the status of the others.
+snapshotRoute({
+  path: 'management/cleanup',
+  kind: 'rmm_cleanup',
+  schema: rmmCleanupReportIngestSchema,
+  count: (data) => data.results.length,
+  collectedAt: (data) => data.completedAt,
+  keyed: (data) => Object.fromEntries(data.results.map((result) => [
+    result.name,
+    { ...result, commandId: data.commandId, dryRun: data.dryRun, completedAt: data.completedAt },
+  ])),
+  maxSize: 1024 * 1024,
+});
+
+snapshotRoute({
+  path: 'usb-devices',
+  kind: 'usb_devices',
+  schema: usbDeviceInventoryIngestSchema,
+  count: (data) => data.devices.length,
+});
+
+snapshotRoute({
+  path: 'local-users',
+  kind: 'local_users',
+  schema: localUserInventoryIngestSchema,
+  count: (data) => data.users.length,
+});
+
+snapshotRoute({
+  path: 'wifi',
+  kind: 'wifi',
+  schema: wifiStatusIngestSchema,
+  count: (data) => data.connected.length,
+});
+
+snapshotRoute({
+  path: 'process-network',
+  kind: 'process_network',
+  schema: processNetworkUsageIngestSchema,
+  count: (data) => data.processes.length,
+  maxSize: 256 * 1024,
+});
+
+// Collector plugins upload to plugins/<endpoint>. Only endpoints named after
+// a collector plugin the org has installed and enabled are accepted, so an
+// agent can't grow the snapshot with arbitrary keys. The snapshot keeps the
+// latest run per endpoint.
+const COLLECTOR_PLUGIN_ENDPOINT = /^[a-z0-9][a-z0-9-]{0,62}$/;
+
+inventorySnapshotRoutes.put(
+  '/:id/plugins/:endpoint',
+  bodyLimit({ maxSize: 9 * 1024 * 1024, onError: (c) => c.json({ error: 'Request body too large' }, 413) }),
+  zValidator('json', collectorPluginResultIngestSchema),
+  async (c) => {
+    const agent = c.get('agent') as AgentAuthContext | undefined;
+    if (!agent || agent.agentId !== c.req.param('id')) {
+      return c.json({ error: 'Forbidden' }, 403);
+    }
+    const endpoint = c.req.param('endpoint');
+    if (!COLLECTOR_PLUGIN_ENDPOINT.test(endpoint) || !(await findEnabledCollectorPlugin(agent.orgId, endpoint))) {
+      return c.json({ error: 'No enabled collector plugin for this endpoint' }, 404);
+    }
+    const result = c.req.valid('json');
+
+    await upsertInventorySnapshot({
+      deviceId: agent.deviceId,
+      orgId: agent.orgId,
+      kind: 'plugins',
+      data: { [endpoint]: result },
+      itemCount: 1,
+      merge: true,
+      collectedAt: parseCollectedAt(result.collectedAt),
+    });
+    return c.json({ success: true, count: 1 });
+  }
+);
+
+snapshotRoute({
+  path: 'bluetooth-devices',
+  kind: 'bluetooth_devices',
+  schema: bluetoothDeviceInventoryIngestSchema,
+  count: (data) => data.devices.length,
+  maxSize: 256 * 1024,
+});
+
+snapshotRoute({
+  path: 'licensing',
+  kind: 'licensing',
+  schema: licensingStatusIngestSchema,
+  count: (data) => data.products.length,
+  maxSize: 256 * 1024,
+});
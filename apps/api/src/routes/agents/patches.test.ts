@@ -42,6 +42,8 @@ const tables = vi.hoisted(() => ({
     lastCheckedAt: 'devicePatches.lastCheckedAt',
     installedAt: 'devicePatches.installedAt',
     installedVersion: 'devicePatches.installedVersion',
+    failureCount: 'devicePatches.failureCount',
+    lastError: 'devicePatches.lastError',
     updatedAt: 'devicePatches.updatedAt',
   },
 }));
@@ -69,6 +71,7 @@ vi.mock('../../db', () => ({
     transaction: vi.fn(),
     // tombstone prune (#1004) runs after the scan txn via db.delete(...).where(...)
     delete: vi.fn(() => ({ where: vi.fn().mockResolvedValue(undefined) })),
+    update: vi.fn(),
   },
   runOutsideDbContext: vi.fn((fn: () => unknown) => fn()),
   withDbAccessContext: vi.fn(async (_ctx: unknown, fn: () => Promise<unknown>) => fn()),
@@ -109,6 +112,7 @@ vi.mock('../../services/thirdPartyEnrichment', () => ({
 
 vi.mock('./helpers', () => ({
   inferPatchOsType: vi.fn((_source: string, osType: string | null | undefined) => osType),
+  isUuid: vi.fn((value: unknown) => typeof value === 'string' && /^[0-9a-f-]{36}$/i.test(value)),
   parseDate: vi.fn((value: string | undefined) => (value ? new Date(value) : null)),
   sanitizeDate: vi.fn((value: string | undefined) => value ?? null),
 }));
@@ -793,6 +797,229 @@ describe('PUT /agents/:id/patches/installed - pending rows survive installed inv
   });
 });
 
+describe('PUT /agents/:id/patches/pending - reboot deferral report', () => {
+  const rebootDeferral = {
+    rebootScheduled: true,
+    scheduledAt: '2026-10-18T22:00:00-04:00',
+    deferrals: 1,
+    maxDeferrals: 2,
+    choices: [{
+      choice: 'Postpone until tonight',
+      deferredAt: '2026-10-18T15:00:00.5-04:00',
+      rebootAt: '2026-10-18T22:00:00-04:00',
+    }],
+  };
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockDeviceLookup('windows');
+    const { tx } = mockPatchInsertTx();
+    vi.mocked(db.transaction).mockImplementation(async (fn) => fn(tx as unknown as Parameters<typeof fn>[0]));
+  });
+
+  async function submitFull(extra: Record<string, unknown>) {
+    return mountAgentPatchRoutes().request(`/agents/${AGENT_ID}/patches/pending`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ patches: [], full: true, coveredSources: ['microsoft'], ...extra }),
+    });
+  }
+
+  it('stores the reported choices on the device', async () => {
+    const set = vi.fn(() => ({ where: vi.fn().mockResolvedValue(undefined) }));
+    vi.mocked(db.update).mockReturnValue({ set } as never);
+
+    const res = await submitFull({ rebootDeferral });
+
+    expect(res.status).toBe(200);
+    expect(db.update).toHaveBeenCalledWith(tables.devices);
+    expect(set).toHaveBeenCalledWith({
+      patchRebootDeferral: { ...rebootDeferral, reportedAt: expect.any(String) },
+    });
+  });
+
+  it('clears the stored choices on null and leaves them alone when absent', async () => {
+    const set = vi.fn(() => ({ where: vi.fn().mockResolvedValue(undefined) }));
+    vi.mocked(db.update).mockReturnValue({ set } as never);
+
+    expect((await submitFull({ rebootDeferral: null })).status).toBe(200);
+    expect(set).toHaveBeenCalledWith({ patchRebootDeferral: null });
+
+    vi.mocked(db.update).mockClear();
+    expect((await submitFull({})).status).toBe(200);
+    expect(db.update).not.toHaveBeenCalled();
+  });
+});
+
+describe('PUT /agents/:id/patches/compliance', () => {
+  const report = {
+    status: 'non_compliant',
+    criticalMaxAgeDays: 14,
+    importantMaxAgeDays: 0,
+    pendingCritical: 1,
+    pendingImportant: 0,
+    violationCount: 1,
+    violations: [{
+      patchId: 'windows-update:kb1',
+      provider: 'windows-update',
+      title: 'KB1',
+      severity: 'critical',
+      since: '2026-09-01T00:00:00Z',
+      sinceSource: 'release',
+      ageDays: 47,
+      maxAgeDays: 14,
+    }],
+    scanComplete: true,
+    evaluatedAt: '2026-10-18T12:00:00.123456789Z',
+  };
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockDeviceLookup('windows');
+  });
+
+  it('stores the latest verdict on the device', async () => {
+    const set = vi.fn(() => ({ where: vi.fn().mockResolvedValue(undefined) }));
+    vi.mocked(db.update).mockReturnValue({ set } as never);
+
+    const res = await mountAgentPatchRoutes().request(`/agents/${AGENT_ID}/patches/compliance`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(report),
+    });
+
+    expect(res.status).toBe(200);
+    expect(await res.json()).toEqual({ success: true, status: 'non_compliant' });
+    expect(db.update).toHaveBeenCalledWith(tables.devices);
+    expect(set).toHaveBeenCalledWith({
+      patchCompliance: { ...report, reportedAt: expect.any(String) },
+    });
+  });
+
+  it('rejects a report with an unknown status', async () => {
+    const res = await mountAgentPatchRoutes().request(`/agents/${AGENT_ID}/patches/compliance`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ ...report, status: 'unknown' }),
+    });
+
+    expect(res.status).toBe(400);
+    expect(db.update).not.toHaveBeenCalled();
+  });
+});
+
+describe('PUT /agents/:id/patches/app-compliance', () => {
+  const report = {
+    apps: [
+      {
+        provider: 'winget',
+        packageId: 'Mozilla.Firefox',
+        channel: 'pin',
+        installedVersion: '129.0',
+        availableVersion: '130.0',
+        targetVersion: '128.0',
+        status: 'non_compliant',
+        reason: 'installed version is not the pinned version',
+      },
+      { provider: 'homebrew', packageId: 'git', channel: 'latest', installedVersion: '2.45.0', status: 'compliant' },
+    ],
+    collectedAt: '2026-10-18T12:00:00.123456789Z',
+  };
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockDeviceLookup('windows');
+  });
+
+  it('stores the latest per-app report on the device', async () => {
+    const set = vi.fn(() => ({ where: vi.fn().mockResolvedValue(undefined) }));
+    vi.mocked(db.update).mockReturnValue({ set } as never);
+
+    const res = await mountAgentPatchRoutes().request(`/agents/${AGENT_ID}/patches/app-compliance`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(report),
+    });
+
+    expect(res.status).toBe(200);
+    expect(await res.json()).toEqual({ success: true, apps: 2, nonCompliant: 1 });
+    expect(db.update).toHaveBeenCalledWith(tables.devices);
+    expect(set).toHaveBeenCalledWith({
+      appUpdateCompliance: { ...report, reportedAt: expect.any(String) },
+    });
+  });
+
+  it('rejects a row with an unknown provider', async () => {
+    const res = await mountAgentPatchRoutes().request(`/agents/${AGENT_ID}/patches/app-compliance`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ ...report, apps: [{ ...report.apps[1], provider: 'apt' }] }),
+    });
+
+    expect(res.status).toBe(400);
+    expect(db.update).not.toHaveBeenCalled();
+  });
+});
+
+describe('PUT /agents/:id/patches/reboot-verification', () => {
+  const ROLLED_BACK_PATCH_ID = '11111111-1111-4111-8111-111111111111';
+  const result = {
+    installId: 'windows-update:abc',
+    provider: 'windows-update',
+    source: 'microsoft',
+    title: 'KB5001',
+    installedAt: '2026-10-17T22:00:00Z',
+  };
+  const report = {
+    bootTime: '2026-10-18T06:00:00Z',
+    verifiedAt: '2026-10-18T06:15:00.123456789Z',
+    verifiedCount: 1,
+    rolledBackCount: 1,
+    failedFinalizationCount: 1,
+    results: [
+      { ...result, id: '22222222-2222-4222-8222-222222222222', status: 'verified' },
+      { ...result, id: ROLLED_BACK_PATCH_ID, status: 'rolled_back' },
+      { ...result, id: 'not-a-uuid', status: 'failed_finalization' },
+    ],
+  };
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockDeviceLookup('windows');
+  });
+
+  it('marks rolled-back patches failed and skips verified ones', async () => {
+    const set = vi.fn(() => ({ where: vi.fn().mockResolvedValue(undefined) }));
+    vi.mocked(db.update).mockReturnValue({ set } as never);
+
+    const res = await mountAgentPatchRoutes().request(`/agents/${AGENT_ID}/patches/reboot-verification`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(report),
+    });
+
+    expect(res.status).toBe(200);
+    expect(await res.json()).toEqual({ success: true, failed: 1 });
+    expect(db.update).toHaveBeenCalledTimes(1);
+    expect(db.update).toHaveBeenCalledWith(tables.devicePatches);
+    expect(set).toHaveBeenCalledWith(expect.objectContaining({
+      status: 'failed',
+      lastError: 'Rolled back during post-reboot servicing',
+    }));
+  });
+
+  it('rejects a result with an unknown status', async () => {
+    const res = await mountAgentPatchRoutes().request(`/agents/${AGENT_ID}/patches/reboot-verification`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ ...report, results: [{ ...result, status: 'pending' }] }),
+    });
+
+    expect(res.status).toBe(400);
+    expect(db.update).not.toHaveBeenCalled();
+  });
+});
+
 const patchIngestEndpoints = [
   {
     label: 'legacy combined patch ingest',
@@ -804,11 +1031,26 @@ const patchIngestEndpoints = [
     path: `/agents/${AGENT_ID}/patches/pending`,
     body: { patches: [] },
   },
+  {
+    label: 'patch compliance ingest',
+    path: `/agents/${AGENT_ID}/patches/compliance`,
+    body: {},
+  },
+  {
+    label: 'app update compliance ingest',
+    path: `/agents/${AGENT_ID}/patches/app-compliance`,
+    body: {},
+  },
   {
     label: 'installed patch ingest',
     path: `/agents/${AGENT_ID}/patches/installed`,
     body: { installed: [] },
   },
+  {
+    label: 'patch reboot verification ingest',
+    path: `/agents/${AGENT_ID}/patches/reboot-verification`,
+    body: {},
+  },
 ] as const;
 
 describe('agent patch ingest - requireAgentRole gate (F3)', () => {
@@ -7,8 +7,15 @@ import { devices, patches, devicePatches } from '../../db/schema';
 import { enqueueWingetReleaseTest } from '../../jobs/wingetReleaseTestWorker';
 import { writeAuditEvent } from '../../services/auditEvents';
 import { enrichFromCatalog } from '../../services/thirdPartyEnrichment';
-import { submitInstalledPatchesSchema, submitPatchesSchema, submitPendingPatchesSchema } from './schemas';
-import { inferPatchOsType, parseDate, sanitizeDate } from './helpers';
+import {
+  submitAppUpdateComplianceSchema,
+  submitInstalledPatchesSchema,
+  submitPatchComplianceSchema,
+  submitPatchRebootVerificationSchema,
+  submitPatchesSchema,
+  submitPendingPatchesSchema,
+} from './schemas';
+import { inferPatchOsType, isUuid, parseDate, sanitizeDate } from './helpers';
 import { requireAgentRole } from '../../middleware/requireAgentRole';
 
 type PendingPatchData = z.infer<typeof submitPendingPatchesSchema>['patches'][number];
@@ -355,6 +362,17 @@ patchesRoutes.put('/:id/patches/pending', zValidator('json', submitPendingPatche
 
   await pruneStaleTombstones(db, device.id, device.orgId);
 
+  if (data.rebootDeferral !== undefined) {
+    await db
+      .update(devices)
+      .set({
+        patchRebootDeferral: data.rebootDeferral
+          ? { ...data.rebootDeferral, reportedAt: new Date().toISOString() }
+          : null,
+      })
+      .where(eq(devices.id, device.id));
+  }
+
   writeAuditEvent(c, {
     orgId: agent?.orgId ?? device.orgId,
     actorType: 'agent',
@@ -367,6 +385,7 @@ patchesRoutes.put('/:id/patches/pending', zValidator('json', submitPendingPatche
       source: data.source ?? null,
       full: data.full,
       coveredSources: data.coveredSources ?? null,
+      rebootDeferrals: data.rebootDeferral?.deferrals ?? null,
     },
   });
 
@@ -405,6 +424,128 @@ patchesRoutes.put('/:id/patches/installed', zValidator('json', submitInstalledPa
   return c.json({ success: true, installed: installedCount, ignored: data.installed.length - installedCount });
 });
 
+patchesRoutes.put('/:id/patches/compliance', zValidator('json', submitPatchComplianceSchema), async (c) => {
+  const agentId = c.req.param('id');
+  const data = c.req.valid('json');
+  const agent = c.get('agent') as { orgId?: string; agentId?: string } | undefined;
+
+  const device = await getDeviceForPatchIngest(agentId);
+  if (!device) {
+    return c.json({ error: 'Device not found' }, 404);
+  }
+
+  // Latest verdict only; the agent re-evaluates after every patch scan.
+  await db
+    .update(devices)
+    .set({ patchCompliance: { ...data, reportedAt: new Date().toISOString() } })
+    .where(eq(devices.id, device.id));
+
+  writeAuditEvent(c, {
+    orgId: agent?.orgId ?? device.orgId,
+    actorType: 'agent',
+    actorId: agent?.agentId ?? agentId,
+    action: 'agent.patches.compliance.submit',
+    resourceType: 'device',
+    resourceId: device.id,
+    details: {
+      status: data.status,
+      violationCount: data.violationCount,
+      scanComplete: data.scanComplete,
+    },
+  });
+
+  return c.json({ success: true, status: data.status });
+});
+
+// Per-app compliance with the patch policy's app update rings, evaluated by
+// the agent after each patch scan. An empty list clears the previous report.
+patchesRoutes.put('/:id/patches/app-compliance', zValidator('json', submitAppUpdateComplianceSchema), async (c) => {
+  const agentId = c.req.param('id');
+  const data = c.req.valid('json');
+  const agent = c.get('agent') as { orgId?: string; agentId?: string } | undefined;
+
+  const device = await getDeviceForPatchIngest(agentId);
+  if (!device) {
+    return c.json({ error: 'Device not found' }, 404);
+  }
+
+  await db
+    .update(devices)
+    .set({ appUpdateCompliance: { ...data, reportedAt: new Date().toISOString() } })
+    .where(eq(devices.id, device.id));
+
+  const nonCompliantCount = data.apps.filter((app) => app.status === 'non_compliant').length;
+  writeAuditEvent(c, {
+    orgId: agent?.orgId ?? device.orgId,
+    actorType: 'agent',
+    actorId: agent?.agentId ?? agentId,
+    action: 'agent.patches.app_compliance.submit',
+    resourceType: 'device',
+    resourceId: device.id,
+    details: {
+      appCount: data.apps.length,
+      nonCompliantCount,
+    },
+  });
+
+  return c.json({ success: true, apps: data.apps.length, nonCompliant: nonCompliantCount });
+});
+
+const rebootVerificationErrors = {
+  rolled_back: 'Rolled back during post-reboot servicing',
+  failed_finalization: 'Did not finish installing after reboot',
+} as const;
+
+// Post-reboot verification: patches the install reported as installed (with a
+// reboot pending) that Windows rolled back or never finalized are marked
+// failed so the next deployment retries them.
+patchesRoutes.put('/:id/patches/reboot-verification', zValidator('json', submitPatchRebootVerificationSchema), async (c) => {
+  const agentId = c.req.param('id');
+  const data = c.req.valid('json');
+  const agent = c.get('agent') as { orgId?: string; agentId?: string } | undefined;
+
+  const device = await getDeviceForPatchIngest(agentId);
+  if (!device) {
+    return c.json({ error: 'Device not found' }, 404);
+  }
+
+  let failedCount = 0;
+  for (const result of data.results) {
+    if (result.status === 'verified' || !isUuid(result.id)) continue;
+    await db
+      .update(devicePatches)
+      .set({
+        status: 'failed',
+        lastError: rebootVerificationErrors[result.status],
+        failureCount: sql`${devicePatches.failureCount} + 1`,
+        lastCheckedAt: new Date(),
+        updatedAt: new Date(),
+      })
+      .where(and(eq(devicePatches.deviceId, device.id), eq(devicePatches.patchId, result.id)));
+    failedCount++;
+  }
+
+  writeAuditEvent(c, {
+    orgId: agent?.orgId ?? device.orgId,
+    actorType: 'agent',
+    actorId: agent?.agentId ?? agentId,
+    action: 'agent.patches.reboot_verification.submit',
+    resourceType: 'device',
+    resourceId: device.id,
+    details: {
+      bootTime: data.bootTime,
+      verifiedCount: data.verifiedCount,
+      rolledBackCount: data.rolledBackCount,
+      failedFinalizationCount: data.failedFinalizationCount,
+      rolledBack: data.results
+        .filter((result) => result.status !== 'verified')
+        .map((result) => ({ installId: result.installId, title: result.title, status: result.status })),
+    },
+  });
+
+  return c.json({ success: true, failed: failedCount });
+});
+
 patchesRoutes.put('/:id/patches', zValidator('json', submitPatchesSchema), async (c) => {
   const agentId = c.req.param('id');
   const data = c.req.valid('json');
@@ -16,12 +16,13 @@ vi.mock('../../middleware/requireAgentRole', () => ({
   },
 }));
 
-const { escrowMock, auditMock } = vi.hoisted(() => ({
+const { escrowMock, revokeMock, auditMock } = vi.hoisted(() => ({
   escrowMock: vi.fn(async () => ({ inserted: 1, superseded: 0, unchanged: 0 })),
+  revokeMock: vi.fn(async () => ({ revoked: 1, restored: 1 })),
   auditMock: vi.fn(),
 }));
 
-vi.mock('../../services/recoveryKeyEscrow', () => ({ escrowRecoveryKeys: escrowMock }));
+vi.mock('../../services/recoveryKeyEscrow', () => ({ escrowRecoveryKeys: escrowMock, revokeRecoveryKeys: revokeMock }));
 vi.mock('../../services/auditEvents', () => ({ writeAuditEvent: auditMock }));
 
 import { db } from '../../db';
@@ -99,4 +100,20 @@ describe('PUT /:id/security/recovery-keys', () => {
     });
     expect(res.status).toBe(400);
   });
+
+  it('revokes keys for a failed rotation instead of escrowing them', async () => {
+    mockDeviceLookup({ id: DEVICE_ID, orgId: ORG_ID });
+    const keys = [{ keyType: 'local_admin_password', volumeMount: 'admin', recoveryKey: KEY }];
+    const res = await buildApp().request('/agent-1/security/recovery-keys', {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ source: 'revoked', keys }),
+    });
+    expect(res.status).toBe(200);
+    expect(revokeMock).toHaveBeenCalledWith(DEVICE_ID, keys);
+    expect(escrowMock).not.toHaveBeenCalled();
+    const auditArg = auditMock.mock.calls[0]![1] as { action: string };
+    expect(JSON.stringify(auditArg)).not.toContain(KEY);
+    expect(auditArg.action).toBe('agent.recovery_keys.revoke');
+  });
 });
@@ -5,7 +5,7 @@ import { db } from '../../db';
 import { devices } from '../../db/schema';
 import { writeAuditEvent } from '../../services/auditEvents';
 import { recoveryKeysIngestSchema } from './schemas';
-import { escrowRecoveryKeys } from '../../services/recoveryKeyEscrow';
+import { escrowRecoveryKeys, revokeRecoveryKeys } from '../../services/recoveryKeyEscrow';
 import { requireAgentRole } from '../../middleware/requireAgentRole';
 
 export const agentRecoveryKeysRoutes = new Hono();
@@ -28,6 +28,20 @@ agentRecoveryKeysRoutes.put('/:id/security/recovery-keys', zValidator('json', re
     return c.json({ error: 'Device not found' }, 404);
   }
 
+  if (payload.source === 'revoked') {
+    const stats = await revokeRecoveryKeys(device.id, payload.keys);
+    writeAuditEvent(c, {
+      orgId: agent?.orgId ?? device.orgId,
+      actorType: 'agent',
+      actorId: agent?.agentId ?? agentId,
+      action: 'agent.recovery_keys.revoke',
+      resourceType: 'device',
+      resourceId: device.id,
+      details: { revoked: stats.revoked, restored: stats.restored },
+    });
+    return c.json({ success: true, stats });
+  }
+
   const stats = await escrowRecoveryKeys(device.id, device.orgId, payload.source, payload.keys);
 
   // Counts only — recovery-key material must never reach the audit trail.
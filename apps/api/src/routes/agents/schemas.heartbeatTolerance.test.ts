@@ -33,6 +33,23 @@ describe('heartbeatSchema — Layer A tolerance', () => {
     expect(result.data.battery).toMatchObject({ present: true, percent: 42.5, chargingState: 'discharging' });
   });
 
+  it('keeps battery health fields and drops only an out-of-range one', () => {
+    const result = heartbeatSchema.safeParse({
+      ...minimal,
+      battery: {
+        present: true,
+        cycleCount: 412,
+        designCapacityMWh: 57000,
+        fullChargeCapacityMWh: 45600,
+        healthPercent: 180,
+      },
+    });
+    expect(result.success).toBe(true);
+    if (!result.success) return;
+    expect(result.data.battery).toMatchObject({ cycleCount: 412, designCapacityMWh: 57000, fullChargeCapacityMWh: 45600 });
+    expect(result.data.battery?.healthPercent).toBeUndefined();
+  });
+
   it('drops an out-of-range battery percent but keeps the rest of the snapshot', () => {
     const result = heartbeatSchema.safeParse({
       ...minimal,
@@ -526,3 +543,85 @@ describe('desktopAccess Linux reasons', () => {
     expect(parsed.desktopAccess?.reason).toBeUndefined();
   });
 });
+
+describe('heartbeatSchema — GPU metrics', () => {
+  const base = {
+    status: 'ok' as const,
+    agentVersion: '0.95.0',
+    metrics: { cpuPercent: 5, ramPercent: 10, ramUsedMb: 1024, diskPercent: 15, diskUsedGb: 30 },
+  };
+  const gpu = {
+    index: 0,
+    name: 'NVIDIA RTX A4000',
+    vendor: 'nvidia',
+    driverVersion: '551.23',
+    utilizationPercent: 87,
+    memoryUsedMb: 10240,
+    memoryTotalMb: 16376,
+    temperatureC: 71,
+    source: 'nvml',
+  };
+
+  it('keeps the per-adapter readings', () => {
+    const parsed = heartbeatSchema.parse({ ...base, metrics: { ...base.metrics, gpus: [gpu] } });
+    expect(parsed.metrics?.gpus).toEqual([gpu]);
+  });
+
+  it('drops only an out-of-range reading', () => {
+    const parsed = heartbeatSchema.parse({
+      ...base,
+      metrics: { ...base.metrics, gpus: [{ ...gpu, utilizationPercent: 250 }] },
+    });
+    expect(parsed.metrics?.gpus?.[0]?.utilizationPercent).toBeUndefined();
+    expect(parsed.metrics?.gpus?.[0]?.memoryUsedMb).toBe(10240);
+  });
+
+  it('drops the list on an unknown source but keeps the metrics block', () => {
+    const parsed = heartbeatSchema.parse({
+      ...base,
+      metrics: { ...base.metrics, gpus: [{ ...gpu, source: 'opencl' }] },
+    });
+    expect(parsed.metrics?.gpus).toBeUndefined();
+    expect(parsed.metrics?.cpuPercent).toBe(5);
+  });
+});
+
+describe('heartbeatSchema — thermal metrics', () => {
+  const base = {
+    status: 'ok' as const,
+    agentVersion: '0.95.0',
+    metrics: { cpuPercent: 5, ramPercent: 10, ramUsedMb: 1024, diskPercent: 15, diskUsedGb: 30 },
+  };
+  const thermal = {
+    cpuTempC: 78.5,
+    sensors: [{ name: 'coretemp Package id 0', temperatureC: 78.5 }],
+    fans: [{ name: 'thinkpad fan1', rpm: 3200 }],
+    throttled: true,
+    throttleReasons: ['thermal_throttle_events'],
+    throttleEvents: 4,
+    source: 'hwmon',
+  };
+
+  it('keeps the thermal snapshot', () => {
+    const parsed = heartbeatSchema.parse({ ...base, metrics: { ...base.metrics, thermal } });
+    expect(parsed.metrics?.thermal).toEqual(thermal);
+  });
+
+  it('drops only a malformed fan list', () => {
+    const parsed = heartbeatSchema.parse({
+      ...base,
+      metrics: { ...base.metrics, thermal: { ...thermal, fans: [{ name: 'fan1', rpm: -1 }] } },
+    });
+    expect(parsed.metrics?.thermal?.fans).toBeUndefined();
+    expect(parsed.metrics?.thermal?.cpuTempC).toBe(78.5);
+  });
+
+  it('drops the snapshot on an unknown source but keeps the metrics block', () => {
+    const parsed = heartbeatSchema.parse({
+      ...base,
+      metrics: { ...base.metrics, thermal: { ...thermal, source: 'ipmi' } },
+    });
+    expect(parsed.metrics?.thermal).toBeUndefined();
+    expect(parsed.metrics?.cpuPercent).toBe(5);
+  });
+});
@@ -43,6 +43,8 @@ export const enrollSchema = z.object({
   deviceRole: z.enum(DEVICE_ROLES).optional(),
   isVirtual: z.boolean().optional(),
   virtualizationPlatform: z.enum(VIRTUALIZATION_PLATFORMS).optional(),
+  // Base64 Ed25519 public key for transcript signing; pinned on the device.
+  transcriptPublicKey: z.string().regex(/^[A-Za-z0-9+/]{43}=$/).optional(),
   hardwareInfo: z.object({
     cpuModel: z.string().optional(),
     cpuCores: z.number().int().optional(),
@@ -117,6 +119,40 @@ const ipEntrySchema = z.object({
 // and negatives fail .min(0), so bad input is caught either way.
 const uint64Counter = z.number().min(0).refine(Number.isInteger, 'expected integer');
 
+// One GPU adapter's utilization snapshot. Mirrors collectors.GPUMetrics; any
+// reading the source can't provide is omitted, so an unreadable value drops
+// just that reading.
+const gpuMetricsSchema = z.object({
+  index: z.number().int().min(0),
+  name: z.string().max(600),
+  vendor: z.string().max(32).optional().catch(undefined),
+  driverVersion: z.string().max(600).optional().catch(undefined),
+  utilizationPercent: z.number().min(0).max(100).optional().catch(undefined),
+  memoryUsedMb: z.number().int().min(0).optional().catch(undefined),
+  memoryTotalMb: z.number().int().min(0).optional().catch(undefined),
+  temperatureC: z.number().min(-50).max(200).optional().catch(undefined),
+  source: z.enum(['nvml', 'sysfs', 'dxgi', 'metal']),
+});
+
+// Device thermal snapshot. Mirrors collectors.ThermalMetrics; as with GPUs,
+// a reading the platform can't provide is omitted.
+const thermalMetricsSchema = z.object({
+  cpuTempC: z.number().min(-50).max(200).optional().catch(undefined),
+  sensors: z.array(z.object({
+    name: z.string().max(600),
+    temperatureC: z.number().min(-50).max(200),
+  })).max(32).optional().catch(undefined),
+  fans: z.array(z.object({
+    name: z.string().max(600),
+    rpm: z.number().int().min(0),
+  })).max(16).optional().catch(undefined),
+  throttled: z.boolean().optional().catch(undefined),
+  throttleReasons: z.array(z.enum(['thermal_throttle_events', 'cpu_speed_limit', 'thermal_pressure'])).max(3).optional().catch(undefined),
+  throttleEvents: uint64Counter.optional().catch(undefined),
+  cpuSpeedLimitPercent: z.number().min(0).max(100).optional().catch(undefined),
+  source: z.enum(['hwmon', 'powermetrics', 'acpi']),
+});
+
 export const heartbeatSchema = z.object({
   metrics: z.object({
     cpuPercent: z.number(),
@@ -147,7 +183,13 @@ export const heartbeatSchema = z.object({
       outErrors: uint64Counter,
       speed: z.number().int().min(0).optional().catch(undefined)
     })).max(100).optional().catch(undefined),
-    processCount: z.number().int().optional().catch(undefined)
+    processCount: z.number().int().optional().catch(undefined),
+    // Per-adapter GPU load. Informational — a bad adapter entry drops the
+    // list rather than 400-ing the heartbeat.
+    gpus: z.array(gpuMetricsSchema).max(16).optional().catch(undefined),
+    // Temperatures, fan speeds and throttling. Informational — an unknown
+    // source drops the snapshot rather than 400-ing the heartbeat.
+    thermal: thermalMetricsSchema.optional().catch(undefined)
   }).optional(),
   metricsAvailable: z.boolean().optional().catch(undefined),
   status: z.enum(['ok', 'warning', 'error']),
@@ -201,6 +243,20 @@ export const heartbeatSchema = z.object({
     pluggedIn: z.boolean().optional().catch(undefined),
     timeRemainingMinutes: z.number().int().min(0).optional().catch(undefined),
     timeToFullMinutes: z.number().int().min(0).optional().catch(undefined),
+    // Battery health, refreshed every few hours by the agent.
+    cycleCount: z.number().int().min(0).optional().catch(undefined),
+    designCapacityMWh: z.number().int().min(0).optional().catch(undefined),
+    fullChargeCapacityMWh: z.number().int().min(0).optional().catch(undefined),
+    healthPercent: z.number().min(0).max(100).optional().catch(undefined),
+  }).optional().catch(undefined),
+  // Approximate device location, sent only while org/site policy enables
+  // geolocation. Informational — a bad value drops the object (.catch).
+  location: z.object({
+    latitude: z.number().min(-90).max(90),
+    longitude: z.number().min(-180).max(180),
+    accuracyMeters: z.number().min(0).optional().catch(undefined),
+    source: z.enum(['os', 'wifi', 'ip']),
+    collectedAt: z.string().datetime({ offset: true }),
   }).optional().catch(undefined),
   // Agent's own Go runtime memory gauges (#2389). Informational — a bad value
   // drops the whole object (.catch) rather than 400-ing the heartbeat.
@@ -330,6 +386,35 @@ export const securityStatusIngestSchema = z.object({
   gatekeeperEnabled: z.boolean().optional(),
   guardianEnabled: z.boolean().optional(),
   windowsSecurityCenterAvailable: z.boolean().optional(),
+  tpm: z.object({
+    present: z.boolean(),
+    version: z.string().max(10).optional(),
+    enabled: z.boolean().optional(),
+    ready: z.boolean().optional(),
+    owned: z.boolean().optional(),
+    manufacturer: z.string().max(100).optional()
+  }).optional(),
+  secureBoot: z.object({
+    supported: z.boolean(),
+    enabled: z.boolean(),
+    mode: z.string().max(20).optional()
+  }).optional(),
+  measuredBootAvailable: z.boolean().optional(),
+  securityScore: z.object({
+    score: z.number().int().min(0).max(100),
+    riskLevel: z.enum(['low', 'medium', 'high', 'critical']),
+    computedAt: z.string().optional(),
+    factors: z.record(z.string().max(50), z.object({
+      score: z.number().int().min(0).max(100),
+      weight: z.number().int().min(0).max(100),
+      confidence: z.number().min(0).max(1),
+      dataGap: z.string().max(500).optional(),
+      evidence: z.record(z.string(), z.unknown()).optional()
+    })).refine(
+      (val) => Object.keys(val).length <= 20 && JSON.stringify(val).length <= 16384,
+      { message: 'Too many factors or object too large (max 16KB)' }
+    )
+  }).optional(),
   avProducts: z.array(
     z.object({
       displayName: z.string().optional(),
@@ -344,9 +429,10 @@ export const securityStatusIngestSchema = z.object({
 export type SecurityStatusPayload = z.infer<typeof securityStatusIngestSchema>;
 
 export const recoveryKeysIngestSchema = z.object({
-  source: z.enum(['snapshot', 'rotation']),
+  // 'revoked' withdraws keys escrowed by a rotation the device then failed to apply.
+  source: z.enum(['snapshot', 'rotation', 'revoked']),
   keys: z.array(z.object({
-    keyType: z.enum(['bitlocker_recovery_password', 'filevault_personal_recovery_key']),
+    keyType: z.enum(['bitlocker_recovery_password', 'filevault_personal_recovery_key', 'luks_recovery_passphrase', 'local_admin_password']),
     volumeMount: z.string().max(100).optional(),
     protectorId: z.string().max(100).optional(),
     recoveryKey: z.string().min(8).max(512)
@@ -355,11 +441,32 @@ export const recoveryKeysIngestSchema = z.object({
 
 export type RecoveryKeysIngestPayload = z.infer<typeof recoveryKeysIngestSchema>;
 
+// Agent-side vulnerability scan report: the installed package versions that
+// matched the device's vulnerability snapshot. Replaces the previous report.
+export const vulnerabilityScanIngestSchema = z.object({
+  scannedAt: z.string().datetime({ offset: true }),
+  snapshotGeneratedAt: z.string().max(64).optional(),
+  softwareScanned: z.number().int().min(0).optional(),
+  findings: z.array(z.object({
+    name: z.string().min(1).max(500),
+    version: z.string().min(1).max(120),
+    vendor: z.string().max(200).optional(),
+    cveId: z.string().max(32).regex(/^CVE-\d{4}-\d{4,}$/i).transform((v) => v.toUpperCase()),
+    cvssScore: z.number().min(0).max(10).optional(),
+    severity: z.string().max(20).optional(),
+    knownExploited: z.boolean().optional(),
+    fixedVersion: z.string().max(120).optional(),
+  })).max(10000),
+});
+
+export type VulnerabilityScanIngestPayload = z.infer<typeof vulnerabilityScanIngestSchema>;
+
 export const uuidRegex = /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
 
 export const securityCommandTypes = {
   collectStatus: 'security_collect_status',
   scan: 'security_scan',
+  avScan: 'av_scan',
   quarantine: 'security_threat_quarantine',
   remove: 'security_threat_remove',
   restore: 'security_threat_restore'
@@ -401,7 +508,12 @@ export const managementPostureIngestSchema = z.object({
     workplaceJoined: z.boolean(),
     domainName: z.string().optional(),
     tenantId: z.string().optional(),
+    tenantName: z.string().optional(),
+    deviceId: z.string().optional(),
     mdmUrl: z.string().optional(),
+    mdmEnrolled: z.boolean().optional(),
+    mdmAuthority: z.string().optional(),
+    mdmEnrollmentType: z.enum(['automated', 'user_approved', 'manual']).optional(),
     source: z.string(),
   }),
   errors: z.array(z.string()).max(100).optional(),
@@ -454,7 +566,22 @@ export const updateHardwareSchema = z.object({
   motherboardProduct: z.string().optional(),
   motherboardVersion: z.string().optional(),
   biosVersion: z.string().optional(),
-  gpuModel: z.string().optional()
+  gpuModel: z.string().optional(),
+  displays: z.array(z.object({
+    manufacturer: z.string().max(255).optional(),
+    manufacturerId: z.string().max(8).optional(),
+    model: z.string().max(255).optional(),
+    productCode: z.string().max(32).optional(),
+    serialNumber: z.string().max(255).optional(),
+    manufactureYear: z.number().int().optional(),
+    manufactureWeek: z.number().int().optional(),
+    nativeResolution: z.string().max(32).optional(),
+    currentResolution: z.string().max(32).optional(),
+    connectionType: z.string().max(32).optional(),
+    internal: z.boolean().optional(),
+    widthCm: z.number().int().optional(),
+    heightCm: z.number().int().optional(),
+  })).max(16).optional()
 });
 
 export const updateSoftwareSchema = z.object({
@@ -587,6 +714,18 @@ const installedPatchSchema = z.object({
   installedAt: z.string().optional()
 });
 
+const patchRebootDeferralSchema = z.object({
+  rebootScheduled: z.boolean(),
+  scheduledAt: z.string().datetime({ offset: true }),
+  deferrals: z.number().int().min(0).max(100),
+  maxDeferrals: z.number().int().min(0).max(100),
+  choices: z.array(z.object({
+    choice: z.string().min(1).max(64),
+    deferredAt: z.string().datetime({ offset: true }),
+    rebootAt: z.string().datetime({ offset: true }),
+  })).max(100),
+});
+
 export const submitPendingPatchesSchema = z.object({
   patches: z.array(pendingPatchSchema).max(5000),
   source: patchSourceSchema.optional(),
@@ -602,7 +741,11 @@ export const submitPendingPatchesSchema = z.object({
   // degrade). If this schema is ever hardened to .strict(), a new agent's
   // coveredSources payload would 400 and patch uploads would stop entirely —
   // do not do that without a coordinated agent-fleet rollout.
-  coveredSources: z.array(patchSourceSchema).max(10).optional()
+  coveredSources: z.array(patchSourceSchema).max(10).optional(),
+  // The logged-in user's postpone choices for the scheduled patch reboot,
+  // sent on full uploads. null clears what was stored; absent (old agent or
+  // partial upload) leaves it alone.
+  rebootDeferral: patchRebootDeferralSchema.nullable().optional(),
 });
 
 export const submitInstalledPatchesSchema = z.object({
@@ -614,10 +757,388 @@ export const submitPatchesSchema = z.object({
   installed: z.array(installedPatchSchema).max(5000).optional()
 });
 
+// Agent-evaluated patch compliance verdict (patching.PatchComplianceReport).
+// Non-strict so newer agents can add fields without failing the upload.
+export const submitPatchComplianceSchema = z.object({
+  status: z.enum(['compliant', 'non_compliant']),
+  criticalMaxAgeDays: z.number().int().min(0).max(3650),
+  importantMaxAgeDays: z.number().int().min(0).max(3650),
+  pendingCritical: z.number().int().min(0),
+  pendingImportant: z.number().int().min(0),
+  violationCount: z.number().int().min(0),
+  violations: z.array(z.object({
+    patchId: z.string().min(1).max(512),
+    provider: z.string().max(64),
+    title: z.string().max(1000),
+    severity: z.string().max(32),
+    kbNumber: z.string().max(64).optional(),
+    since: z.string().datetime({ offset: true }),
+    sinceSource: z.enum(['release', 'first_seen']),
+    ageDays: z.number().int().min(0),
+    maxAgeDays: z.number().int().min(0),
+  })).max(500),
+  scanComplete: z.boolean(),
+  evaluatedAt: z.string().datetime({ offset: true }),
+});
+
+export const submitAppUpdateComplianceSchema = z.object({
+  apps: z.array(z.object({
+    provider: z.enum(['winget', 'chocolatey', 'homebrew']),
+    packageId: z.string().min(1).max(256),
+    channel: z.enum(['latest', 'pin', 'n-1']),
+    installedVersion: z.string().max(128).optional(),
+    availableVersion: z.string().max(128).optional(),
+    targetVersion: z.string().max(128).optional(),
+    status: z.enum(['compliant', 'pending', 'deferred', 'non_compliant', 'not_installed']),
+    reason: z.string().max(1000).optional(),
+  })).max(200),
+  collectedAt: z.string().datetime({ offset: true }),
+});
+
+export const submitPatchRebootVerificationSchema = z.object({
+  bootTime: z.string().datetime({ offset: true }),
+  verifiedAt: z.string().datetime({ offset: true }),
+  verifiedCount: z.number().int().min(0),
+  rolledBackCount: z.number().int().min(0),
+  failedFinalizationCount: z.number().int().min(0),
+  results: z.array(z.object({
+    installId: z.string().min(1).max(512),
+    provider: z.string().max(64),
+    id: z.string().max(255).optional(),
+    source: z.string().max(64).optional(),
+    externalId: z.string().max(512).optional(),
+    title: z.string().max(1000).optional(),
+    kbNumber: z.string().max(64).optional(),
+    installedAt: z.string().datetime({ offset: true }),
+    status: z.enum(['verified', 'rolled_back', 'failed_finalization']),
+  })).max(500),
+});
+
 // ============================================
 // Connections
 // ============================================
 
+// Full running-process table from the agent's process inventory collector.
+export const processInventoryIngestSchema = z.object({
+  collectedAt: z.string().max(64).optional(),
+  processes: z.array(z.object({
+    pid: z.number().int().min(0),
+    parentPid: z.number().int().min(0).optional(),
+    name: z.string().max(512),
+    path: z.string().max(4096).optional(),
+    sha256: z.string().regex(/^[0-9a-fA-F]{64}$/).optional(),
+    user: z.string().max(256).optional(),
+    cpuPercent: z.number().min(0),
+    rssBytes: z.number().int().min(0),
+    startTime: z.string().max(64).optional(),
+    signatureStatus: z.enum(['signed', 'unsigned', 'invalid', 'unknown']),
+    signer: z.string().max(512).optional()
+  })).max(20000)
+});
+
+// Results of the agent's synthetic HTTP(S) availability checks.
+export const syntheticResultsIngestSchema = z.object({
+  results: z.array(z.object({
+    monitorId: z.string().min(1).max(128),
+    name: z.string().max(255).optional(),
+    url: z.string().max(2048),
+    status: z.enum(['online', 'degraded', 'offline']),
+    statusCode: z.number().int().min(0).max(999).optional(),
+    responseMs: z.number().min(0),
+    keywordMatched: z.boolean().optional(),
+    certExpiresAt: z.string().max(64).optional(),
+    certDaysRemaining: z.number().int().optional(),
+    error: z.string().max(2000).optional(),
+    checkedAt: z.string().max(64)
+  })).min(1).max(100)
+});
+
+// Machine-store certificate metadata. Only public fields; the agent never
+// reads private keys, just whether one is bound.
+export const certificateInventoryIngestSchema = z.object({
+  certificates: z.array(z.object({
+    store: z.string().max(255),
+    path: z.string().max(4096).optional(),
+    subject: z.string().max(4096),
+    issuer: z.string().max(4096),
+    serialNumber: z.string().max(256),
+    thumbprint: z.string().regex(/^[0-9a-fA-F]{40}$/),
+    notBefore: z.string().max(64),
+    notAfter: z.string().max(64),
+    keyUsage: z.array(z.string().max(64)).max(32).optional(),
+    extendedKeyUsage: z.array(z.string().max(128)).max(64).optional(),
+    dnsNames: z.array(z.string().max(255)).max(500).optional(),
+    isCA: z.boolean(),
+    selfSigned: z.boolean(),
+    hasPrivateKey: z.boolean()
+  })).max(500)
+});
+
+// Container runtimes (Docker, Podman, containerd) with their containers and
+// images. A runtime whose daemon is down is reported with `error` set.
+export const containerInventoryIngestSchema = z.object({
+  runtimes: z.array(z.object({
+    name: z.enum(['docker', 'podman', 'containerd']),
+    version: z.string().max(255).optional(),
+    error: z.string().max(2000).optional(),
+    containers: z.array(z.object({
+      id: z.string().max(255),
+      name: z.string().max(512),
+      image: z.string().max(512),
+      state: z.string().max(64),
+      status: z.string().max(512).optional(),
+      createdAt: z.string().max(128).optional(),
+      ports: z.string().max(512).optional(),
+      namespace: z.string().max(255).optional(),
+      cpuPercent: z.number().min(0).optional(),
+      memoryUsageBytes: z.number().int().min(0).optional(),
+      memoryLimitBytes: z.number().int().min(0).optional(),
+      memoryPercent: z.number().min(0).optional()
+    })).max(5000),
+    images: z.array(z.object({
+      id: z.string().max(255),
+      repository: z.string().max(512),
+      tag: z.string().max(255).optional(),
+      sizeBytes: z.number().int().min(0).optional(),
+      createdAt: z.string().max(128).optional(),
+      namespace: z.string().max(255).optional()
+    })).max(5000)
+  })).max(3)
+});
+
+// Extensions installed in each local user's browser profiles, plus those
+// force-installed machine-wide by policy.
+export const browserExtensionInventoryIngestSchema = z.object({
+  extensions: z.array(z.object({
+    browser: z.enum(['chrome', 'edge', 'chromium', 'brave', 'firefox']),
+    scope: z.enum(['user', 'machine']),
+    source: z.enum(['profile', 'policy']),
+    user: z.string().max(255).optional(),
+    profile: z.string().max(512).optional(),
+    id: z.string().min(1).max(255),
+    name: z.string().max(512).optional(),
+    version: z.string().max(128).optional(),
+    enabled: z.boolean().optional(),
+    permissions: z.array(z.string().max(512)).max(500).optional(),
+    hostPermissions: z.array(z.string().max(2048)).max(500).optional(),
+    updateUrl: z.string().max(2048).optional()
+  })).max(5000)
+});
+
+// The agent's bounded history of upgrades, applied configs, feature flag
+// changes and certificate renewals. Sent in full; replaces the stored copy.
+export const agentChangelogIngestSchema = z.object({
+  entries: z.array(z.object({
+    timestamp: z.string().max(64),
+    kind: z.enum(['agent_upgrade', 'config_applied', 'feature_flag', 'cert_renewal']),
+    name: z.string().max(255).optional(),
+    from: z.string().max(512).optional(),
+    to: z.string().max(512).optional(),
+    initiator: z.string().max(64).optional(),
+    detail: z.string().max(2000).optional()
+  })).max(250)
+});
+
+// Per-tool outcome of an rmm_cleanup command.
+export const rmmCleanupReportIngestSchema = z.object({
+  commandId: z.string().max(128).optional(),
+  dryRun: z.boolean(),
+  completedAt: z.string().max(64),
+  results: z.array(z.object({
+    name: z.string().min(1).max(128),
+    status: z.enum(['removed', 'incomplete', 'not_installed', 'planned', 'unsupported']),
+    steps: z.array(z.object({
+      type: z.string().max(64),
+      target: z.string().max(4096),
+      skipped: z.boolean().optional(),
+      error: z.string().max(4096).optional(),
+      output: z.string().max(4096).optional()
+    })).max(100).optional(),
+    remaining: z.array(z.string().max(1024)).max(200).optional(),
+    error: z.string().max(4096).optional(),
+    startedAt: z.string().max(64),
+    durationMs: z.number().int().min(0)
+  })).max(50)
+});
+
+// USB devices currently connected or seen in the last 90 days, with the
+// agent's first-seen/last-seen times.
+export const usbDeviceInventoryIngestSchema = z.object({
+  devices: z.array(z.object({
+    vendorId: z.string().regex(/^[0-9a-f]{4}$/),
+    productId: z.string().regex(/^[0-9a-f]{4}$/),
+    vendor: z.string().max(512).optional(),
+    product: z.string().max(512).optional(),
+    serialNumber: z.string().max(512).optional(),
+    class: z.enum([
+      'mass_storage', 'hid', 'audio', 'video', 'image', 'printer', 'communications',
+      'smart_card', 'wireless', 'hub', 'vendor_specific', 'other'
+    ]),
+    massStorage: z.boolean(),
+    location: z.string().max(512).optional(),
+    connected: z.boolean(),
+    firstSeen: z.string().max(64),
+    lastSeen: z.string().max(64)
+  })).max(5000)
+});
+
+// Local accounts and groups. `uid`/`gid` are numeric ids, or SIDs on Windows.
+export const localUserInventoryIngestSchema = z.object({
+  users: z.array(z.object({
+    username: z.string().min(1).max(256),
+    fullName: z.string().max(512).optional(),
+    uid: z.string().max(256).optional(),
+    homeDir: z.string().max(4096).optional(),
+    shell: z.string().max(1024).optional(),
+    disabled: z.boolean(),
+    locked: z.boolean(),
+    isAdmin: z.boolean(),
+    isSystem: z.boolean(),
+    groups: z.array(z.string().max(256)).max(1000),
+    lastLogon: z.string().max(64).optional(),
+    passwordLastSet: z.string().max(64).optional(),
+    passwordAgeDays: z.number().int().min(0).optional()
+  })).max(5000),
+  groups: z.array(z.object({
+    name: z.string().min(1).max(256),
+    gid: z.string().max(256).optional(),
+    isAdmin: z.boolean(),
+    members: z.array(z.string().max(512)).max(5000)
+  })).max(5000)
+});
+
+const wifiNetworkSchema = z.object({
+  interface: z.string().max(512).optional(),
+  ssid: z.string().max(512),
+  bssid: z.string().max(64).optional(),
+  signalDbm: z.number().int().optional(),
+  signalPercent: z.number().int().min(0).max(100).optional(),
+  noiseDbm: z.number().int().optional(),
+  channel: z.number().int().min(0).optional(),
+  band: z.string().max(32).optional(),
+  frequencyMhz: z.number().int().min(0).optional(),
+  security: z.string().max(512).optional(),
+  phyMode: z.string().max(512).optional(),
+  txRateMbps: z.number().min(0).optional()
+});
+
+// Connected Wi-Fi networks and, when the nearby scan is enabled, the
+// networks in range.
+export const wifiStatusIngestSchema = z.object({
+  connected: z.array(wifiNetworkSchema).max(32),
+  nearby: z.array(wifiNetworkSchema).max(100).optional(),
+  nearbyScanned: z.boolean()
+});
+
+// Bytes sent/received per process over the interval since the agent's
+// previous sample, busiest first.
+export const processNetworkUsageIngestSchema = z.object({
+  source: z.string().max(64),
+  intervalSeconds: z.number().min(0),
+  processes: z.array(z.object({
+    pid: z.number().int().min(0),
+    processName: z.string().max(512),
+    bytesSent: z.number().int().min(0),
+    bytesReceived: z.number().int().min(0)
+  })).max(200)
+});
+
+// One run of an agent collector plugin: its JSON output in `data`, or the
+// failure in `error`.
+export const collectorPluginResultIngestSchema = z.object({
+  plugin: z.string().regex(/^[a-z0-9][a-z0-9-]{0,62}$/),
+  version: z.string().max(64).optional(),
+  collectedAt: z.string().max(64),
+  durationMs: z.number().int().min(0),
+  data: z.unknown().optional(),
+  error: z.string().max(8192).optional()
+}).refine((result) => (result.data === undefined) !== (result.error === undefined), {
+  message: 'Exactly one of data or error must be set'
+});
+
+// Paired and connected Bluetooth devices. `address` is the upper-case MAC.
+export const bluetoothDeviceInventoryIngestSchema = z.object({
+  devices: z.array(z.object({
+    address: z.string().regex(/^[0-9A-F]{2}(:[0-9A-F]{2}){5}$/),
+    name: z.string().max(512).optional(),
+    class: z.enum([
+      'computer', 'phone', 'network', 'audio_video', 'peripheral', 'imaging',
+      'wearable', 'toy', 'health', 'other'
+    ]),
+    type: z.string().max(64).optional(),
+    paired: z.boolean(),
+    connected: z.boolean().optional(),
+    batteryPercent: z.number().int().min(0).max(100).optional()
+  })).max(128)
+});
+
+const licenseStatusSchema = z.enum([
+  'unlicensed', 'licensed', 'oob_grace', 'oot_grace', 'non_genuine_grace', 'notification', 'extended_grace', 'unknown'
+]);
+const licenseChannelSchema = z.enum(['retail', 'oem', 'kms', 'mak', 'subscription', 'unknown']);
+
+// Windows and Office activation state (Windows agents only). Only the last
+// five characters of each product key are sent.
+export const licensingStatusIngestSchema = z.object({
+  edition: z.string().max(255).optional(),
+  productName: z.string().max(512).optional(),
+  activated: z.boolean(),
+  status: licenseStatusSchema.optional(),
+  channel: licenseChannelSchema.optional(),
+  officeActivated: z.boolean().optional(),
+  products: z.array(z.object({
+    application: z.enum(['windows', 'office']),
+    name: z.string().max(512),
+    description: z.string().max(512).optional(),
+    status: licenseStatusSchema,
+    activated: z.boolean(),
+    channel: licenseChannelSchema,
+    partialProductKey: z.string().max(16).optional(),
+    graceMinutesRemaining: z.number().int().min(0).optional(),
+    kmsHost: z.string().max(512).optional()
+  })).max(32)
+});
+
+// Daily foreground time per user and application (opt-in metering). Today's
+// total is resent as it grows; the server replaces rather than adds.
+export const softwareUsageIngestSchema = z.object({
+  usage: z.array(z.object({
+    date: z.string().regex(/^\d{4}-\d{2}-\d{2}$/),
+    user: z.string().min(1).max(512),
+    name: z.string().min(1).max(512),
+    path: z.string().max(512).optional(),
+    seconds: z.number().int().min(1).max(24 * 60 * 60)
+  })).min(1).max(5000)
+});
+
+export const transcriptKeySchema = z.object({
+  publicKey: z.string().regex(/^[A-Za-z0-9+/]{43}=$/)
+});
+
+// Desktop stream quality samples; matches the agent's ipc.DesktopQualitySample.
+// The agent keeps at most 120 samples per session while uploads fail.
+export const submitDesktopQualitySchema = z.object({
+  samples: z.array(z.object({
+    sessionId: z.string().optional(),
+    atUnixMs: z.number().int().positive(),
+    intervalMs: z.number().int().nonnegative().max(3_600_000),
+    fps: z.number().nonnegative().max(1000),
+    encodeMs: z.number().nonnegative().max(60_000),
+    rttMs: z.number().nonnegative().max(600_000),
+    lossPct: z.number().min(0).max(100),
+    bitrateKbps: z.number().int().nonnegative().max(10_000_000),
+    sentKbps: z.number().nonnegative().max(10_000_000),
+    width: z.number().int().nonnegative().max(32_768),
+    height: z.number().int().nonnegative().max(32_768),
+    downscaleLevel: z.number().int().nonnegative().max(16),
+    codec: z.string().max(16).optional(),
+    encoder: z.string().max(32).optional(),
+    hardwareEncode: z.boolean(),
+    gpuCapture: z.boolean(),
+    framesDropped: z.number().int().nonnegative(),
+  })).min(1).max(120)
+});
+
 export const submitConnectionsSchema = z.object({
   connections: z.array(z.object({
     protocol: z.enum(['tcp', 'tcp6', 'udp', 'udp6']),
@@ -690,7 +1211,10 @@ export const changeTypeValues = [
   'scheduled_task',
   'user_account',
   'hardware',
-  'os_version'
+  'os_version',
+  'usb_device',
+  'registry',
+  'defender_policy'
 ] as const;
 
 export const changeActionValues = [
@@ -2,6 +2,7 @@ import { beforeEach, describe, expect, it, vi } from 'vitest';
 import { Hono } from 'hono';
 import { db } from '../../db';
 import { agentSecurityRoutes } from './security';
+import { upsertSecurityStatusForDevice } from './helpers';
 
 const AGENT_ID = 'agent-001';
 const DEVICE_ID = 'aaaaaaaa-aaaa-4aaa-8aaa-aaaaaaaaaaaa';
@@ -89,6 +90,74 @@ describe('agent security routes — requireAgentRole gate (F3)', () => {
       });
       expect(res.status).toBe(200);
     });
+
+    it('passes TPM and boot posture through to the upsert', async () => {
+      mockDeviceLookup();
+      const app = mountWithRole('agent');
+      const res = await app.request(`/agents/${AGENT_ID}/security/status`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+          provider: 'defender',
+          tpm: { present: true, version: '2.0', owned: true, manufacturer: 'INTC' },
+          secureBoot: { supported: true, enabled: true },
+          measuredBootAvailable: true,
+        }),
+      });
+      expect(res.status).toBe(200);
+      expect(upsertSecurityStatusForDevice).toHaveBeenCalledWith(DEVICE_ID, ORG_ID, expect.objectContaining({
+        tpm: expect.objectContaining({ present: true, version: '2.0', owned: true }),
+        secureBoot: { supported: true, enabled: true },
+        measuredBootAvailable: true,
+      }));
+    });
+
+    it('passes the agent security score through to the upsert', async () => {
+      mockDeviceLookup();
+      const app = mountWithRole('agent');
+      const securityScore = {
+        score: 72,
+        riskLevel: 'medium',
+        computedAt: '2026-03-01T12:00:00Z',
+        factors: {
+          patch_age: { score: 40, weight: 20, confidence: 1, evidence: { pendingCritical: 2 } },
+          encryption: { score: 50, weight: 15, confidence: 0.3, dataGap: 'Encryption status was unavailable.' },
+        },
+      };
+      const res = await app.request(`/agents/${AGENT_ID}/security/status`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ provider: 'defender', securityScore }),
+      });
+      expect(res.status).toBe(200);
+      expect(upsertSecurityStatusForDevice).toHaveBeenCalledWith(DEVICE_ID, ORG_ID, expect.objectContaining({
+        securityScore,
+      }));
+    });
+
+    it('rejects an out-of-range security score with 400', async () => {
+      mockDeviceLookup();
+      const app = mountWithRole('agent');
+      const res = await app.request(`/agents/${AGENT_ID}/security/status`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ provider: 'defender', securityScore: { score: 140, riskLevel: 'low', factors: {} } }),
+      });
+      expect(res.status).toBe(400);
+      expect(upsertSecurityStatusForDevice).not.toHaveBeenCalled();
+    });
+
+    it('rejects a malformed TPM report with 400', async () => {
+      mockDeviceLookup();
+      const app = mountWithRole('agent');
+      const res = await app.request(`/agents/${AGENT_ID}/security/status`, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ provider: 'defender', tpm: { version: '2.0' } }),
+      });
+      expect(res.status).toBe(400);
+      expect(upsertSecurityStatusForDevice).not.toHaveBeenCalled();
+    });
   });
 
   describe('PUT /agents/:id/management/posture', () => {
@@ -0,0 +1,68 @@
+import { beforeEach, describe, expect, it, vi } from 'vitest';
+import { Hono } from 'hono';
+
+const AGENT_ID = 'agent-1';
+const DEVICE_ID = '11111111-1111-4111-8111-111111111111';
+const ORG_ID = '22222222-2222-4222-8222-222222222222';
+
+vi.mock('../../middleware/requireAgentRole', () => ({
+  requireAgentRole: async (c: any, next: any) => {
+    c.set('agent', { agentId: AGENT_ID, deviceId: DEVICE_ID, orgId: ORG_ID, role: 'agent' });
+    await next();
+  },
+}));
+
+const { upsertMock } = vi.hoisted(() => ({
+  upsertMock: vi.fn(async (_deviceId: string, _orgId: string, rows: unknown[]) => rows.length),
+}));
+
+vi.mock('../../services/deviceSoftwareUsage', () => ({ upsertSoftwareUsage: upsertMock }));
+
+import { softwareUsageRoutes } from './softwareUsage';
+
+function put(body: unknown, agentId = AGENT_ID) {
+  const app = new Hono();
+  app.route('/', softwareUsageRoutes);
+  return app.request(`/${agentId}/software-usage`, {
+    method: 'PUT',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(body),
+  });
+}
+
+const usage = [
+  { date: '2026-02-28', user: 'alice', name: 'Visio', path: 'C:\\Program Files\\Microsoft Office\\root\\Office16\\VISIO.EXE', seconds: 5400 },
+  { date: '2026-03-01', user: 'alice', name: 'Slack', seconds: 1200 },
+];
+
+describe('agent software usage route', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  it('stores the daily totals for the authenticated device', async () => {
+    const res = await put({ usage });
+    expect(res.status).toBe(200);
+    expect(await res.json()).toEqual({ success: true, count: 2 });
+    expect(upsertMock).toHaveBeenCalledWith(DEVICE_ID, ORG_ID, usage);
+  });
+
+  it('rejects malformed rows', async () => {
+    for (const row of [
+      { ...usage[1], date: '03/01/2026' },
+      { ...usage[1], seconds: 0 },
+      { ...usage[1], seconds: 90000 },
+      { ...usage[1], name: '' },
+    ]) {
+      const res = await put({ usage: [row] });
+      expect(res.status, JSON.stringify(row)).toBe(400);
+    }
+    expect(upsertMock).not.toHaveBeenCalled();
+  });
+
+  it('refuses an upload for another agent id', async () => {
+    const res = await put({ usage }, 'agent-2');
+    expect(res.status).toBe(403);
+    expect(upsertMock).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,28 @@
+import { Hono } from 'hono';
+import { bodyLimit } from 'hono/body-limit';
+import { zValidator } from '../../lib/validation';
+import { type AgentAuthContext } from '../../middleware/agentAuth';
+import { requireAgentRole } from '../../middleware/requireAgentRole';
+import { upsertSoftwareUsage } from '../../services/deviceSoftwareUsage';
+import { softwareUsageIngestSchema } from './schemas';
+
+export const softwareUsageRoutes = new Hono();
+// Usage metering is reported by the main agent; reject watchdog-role tokens.
+softwareUsageRoutes.use('*', requireAgentRole);
+
+// PUT /agents/:id/software-usage — pending daily totals. The agent drops
+// completed days once this succeeds, so a failure must not return 2xx.
+softwareUsageRoutes.put(
+  '/:id/software-usage',
+  bodyLimit({ maxSize: 2 * 1024 * 1024, onError: (c) => c.json({ error: 'Request body too large' }, 413) }),
+  zValidator('json', softwareUsageIngestSchema),
+  async (c) => {
+    const agent = c.get('agent') as AgentAuthContext | undefined;
+    if (!agent || agent.agentId !== c.req.param('id')) {
+      return c.json({ error: 'Forbidden' }, 403);
+    }
+    const { usage } = c.req.valid('json');
+    const count = await upsertSoftwareUsage(agent.deviceId, agent.orgId, usage);
+    return c.json({ success: true, count });
+  }
+);
@@ -0,0 +1,123 @@
+import { beforeEach, describe, expect, it, vi } from 'vitest';
+import { Hono } from 'hono';
+import { createHash } from 'crypto';
+
+const AGENT_ID = 'agent-1';
+const DEVICE_ID = '11111111-1111-4111-8111-111111111111';
+const ORG_ID = '22222222-2222-4222-8222-222222222222';
+const COMMAND_ID = '33333333-3333-4333-8333-333333333333';
+const PUBLIC_KEY = Buffer.alloc(32, 7).toString('base64');
+
+vi.mock('../../middleware/requireAgentRole', () => ({
+  requireAgentRole: async (c: any, next: any) => {
+    c.set('agent', { agentId: AGENT_ID, deviceId: DEVICE_ID, orgId: ORG_ID, role: 'agent' });
+    await next();
+  },
+}));
+
+const { commandRows, service } = vi.hoisted(() => {
+  class TranscriptVerificationError extends Error {}
+  return {
+    commandRows: { current: [] as unknown[] },
+    service: {
+      TranscriptVerificationError,
+      getTranscriptPublicKey: vi.fn(),
+      pinTranscriptPublicKey: vi.fn(),
+      storeTranscript: vi.fn(),
+      verifyTranscriptArchive: vi.fn(),
+    },
+  };
+});
+
+vi.mock('../../db', () => ({
+  db: {
+    select: vi.fn(() => ({
+      from: vi.fn(() => ({
+        where: vi.fn(() => ({ limit: vi.fn(async () => commandRows.current) })),
+      })),
+    })),
+  },
+  runOutsideDbContext: (fn: () => unknown) => fn(),
+}));
+
+vi.mock('../../services/commandQueue', () => ({ CommandTypes: { TRANSCRIPT_EXPORT: 'transcript_export' } }));
+vi.mock('../../services/commandTranscripts', () => service);
+
+import { transcriptRoutes } from './transcripts';
+
+function app() {
+  const a = new Hono();
+  a.route('/', transcriptRoutes);
+  return a;
+}
+
+function upload(archive: Buffer, headers: Record<string, string> = {}, agentId = AGENT_ID) {
+  return app().request(`/${agentId}/transcripts/${COMMAND_ID}`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/gzip', ...headers },
+    body: archive,
+  });
+}
+
+const archive = Buffer.from('signed archive');
+const archiveSha256 = createHash('sha256').update(archive).digest('hex');
+const manifest = { agentId: AGENT_ID, from: '2026-03-01T00:00:00Z', to: '2026-03-31T00:00:00Z', commandCount: 3 };
+
+describe('agent transcript routes', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    commandRows.current = [{ id: COMMAND_ID }];
+    service.getTranscriptPublicKey.mockResolvedValue(PUBLIC_KEY);
+    service.verifyTranscriptArchive.mockReturnValue(manifest);
+    service.storeTranscript.mockResolvedValue({ id: 'transcript-1' });
+  });
+
+  it('verifies the archive against the pinned key and stores it', async () => {
+    const res = await upload(archive, { 'X-Archive-Sha256': archiveSha256 });
+    expect(res.status).toBe(201);
+    expect(service.verifyTranscriptArchive).toHaveBeenCalledWith(archive, { agentId: AGENT_ID, publicKey: PUBLIC_KEY });
+    expect(service.storeTranscript).toHaveBeenCalledWith(expect.objectContaining({
+      orgId: ORG_ID, deviceId: DEVICE_ID, commandId: COMMAND_ID, archiveSha256, manifest,
+    }));
+  });
+
+  it('rejects an archive that fails verification', async () => {
+    service.verifyTranscriptArchive.mockImplementation(() => {
+      throw new service.TranscriptVerificationError('Manifest signature does not match the device signing key');
+    });
+    const res = await upload(archive);
+    expect(res.status).toBe(422);
+    expect(service.storeTranscript).not.toHaveBeenCalled();
+  });
+
+  it('refuses uploads without a pinned key, for unknown commands, or with a bad checksum', async () => {
+    service.getTranscriptPublicKey.mockResolvedValueOnce(null);
+    expect((await upload(archive)).status).toBe(409);
+
+    commandRows.current = [];
+    expect((await upload(archive)).status).toBe(404);
+
+    commandRows.current = [{ id: COMMAND_ID }];
+    expect((await upload(archive, { 'X-Archive-Sha256': '0'.repeat(64) })).status).toBe(400);
+
+    expect((await upload(archive, {}, 'agent-2')).status).toBe(403);
+    expect(service.storeTranscript).not.toHaveBeenCalled();
+  });
+
+  it('pins the signing key once and conflicts on a different key', async () => {
+    const put = (publicKey: string) => app().request(`/${AGENT_ID}/transcript-key`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ publicKey }),
+    });
+
+    service.pinTranscriptPublicKey.mockResolvedValueOnce(true);
+    expect((await put(PUBLIC_KEY)).status).toBe(200);
+    expect(service.pinTranscriptPublicKey).toHaveBeenCalledWith(DEVICE_ID, PUBLIC_KEY);
+
+    service.pinTranscriptPublicKey.mockResolvedValueOnce(false);
+    expect((await put(Buffer.alloc(32, 9).toString('base64'))).status).toBe(409);
+
+    expect((await put('not a key')).status).toBe(400);
+  });
+});
@@ -0,0 +1,112 @@
+import { Hono } from 'hono';
+import { bodyLimit } from 'hono/body-limit';
+import { createHash } from 'crypto';
+import { and, eq } from 'drizzle-orm';
+import { db, runOutsideDbContext } from '../../db';
+import { deviceCommands } from '../../db/schema';
+import { zValidator } from '../../lib/validation';
+import { type AgentAuthContext } from '../../middleware/agentAuth';
+import { requireAgentRole } from '../../middleware/requireAgentRole';
+import { CommandTypes } from '../../services/commandQueue';
+import {
+  TranscriptVerificationError,
+  getTranscriptPublicKey,
+  pinTranscriptPublicKey,
+  storeTranscript,
+  verifyTranscriptArchive,
+} from '../../services/commandTranscripts';
+import { transcriptKeySchema } from './schemas';
+
+export const transcriptRoutes = new Hono();
+// Transcripts are signed by the main agent's key; reject watchdog-role tokens.
+transcriptRoutes.use('*', requireAgentRole);
+
+// Matches the agent's cap on the compressed archive, plus slack.
+const MAX_TRANSCRIPT_UPLOAD_BYTES = 101 * 1024 * 1024;
+
+// PUT /agents/:id/transcript-key — pins the signing key for devices enrolled
+// before agents sent it. Idempotent for the pinned key; a different key is a
+// 409 and only re-enrollment replaces it.
+transcriptRoutes.put(
+  '/:id/transcript-key',
+  zValidator('json', transcriptKeySchema),
+  async (c) => {
+    const agent = c.get('agent') as AgentAuthContext | undefined;
+    if (!agent || agent.agentId !== c.req.param('id')) {
+      return c.json({ error: 'Forbidden' }, 403);
+    }
+    const { publicKey } = c.req.valid('json');
+    if (!(await pinTranscriptPublicKey(agent.deviceId, publicKey))) {
+      return c.json({ error: 'A different transcript signing key is pinned for this device' }, 409);
+    }
+    return c.json({ success: true });
+  }
+);
+
+// POST /agents/:id/transcripts/:commandId — the signed archive for a
+// transcript_export command, verified against the pinned key before it is
+// stored.
+transcriptRoutes.post(
+  '/:id/transcripts/:commandId',
+  bodyLimit({ maxSize: MAX_TRANSCRIPT_UPLOAD_BYTES, onError: (c) => c.json({ error: 'Request body too large' }, 413) }),
+  async (c) => {
+    const agent = c.get('agent') as AgentAuthContext | undefined;
+    if (!agent || agent.agentId !== c.req.param('id')) {
+      return c.json({ error: 'Forbidden' }, 403);
+    }
+    const commandId = c.req.param('commandId');
+    if (!/^[0-9a-f-]{36}$/i.test(commandId)) {
+      return c.json({ error: 'Command not found' }, 404);
+    }
+
+    // device_commands has no RLS policy; see the matching read in commands.ts.
+    const [command] = await runOutsideDbContext(() =>
+      db
+        .select({ id: deviceCommands.id })
+        .from(deviceCommands)
+        .where(
+          and(
+            eq(deviceCommands.id, commandId),
+            eq(deviceCommands.deviceId, agent.deviceId),
+            eq(deviceCommands.type, CommandTypes.TRANSCRIPT_EXPORT)
+          )
+        )
+        .limit(1)
+    );
+    if (!command) {
+      return c.json({ error: 'Command not found' }, 404);
+    }
+
+    const publicKey = await getTranscriptPublicKey(agent.deviceId);
+    if (!publicKey) {
+      return c.json({ error: 'No transcript signing key is pinned for this device' }, 409);
+    }
+
+    const archive = Buffer.from(await c.req.arrayBuffer());
+    const archiveSha256 = createHash('sha256').update(archive).digest('hex');
+    const claimed = c.req.header('x-archive-sha256')?.toLowerCase();
+    if (claimed && claimed !== archiveSha256) {
+      return c.json({ error: 'Archive checksum mismatch' }, 400);
+    }
+
+    let manifest;
+    try {
+      manifest = verifyTranscriptArchive(archive, { agentId: agent.agentId, publicKey });
+    } catch (err) {
+      if (err instanceof TranscriptVerificationError) {
+        return c.json({ error: err.message }, 422);
+      }
+      throw err;
+    }
+
+    const row = await storeTranscript({
+      orgId: agent.orgId,
+      deviceId: agent.deviceId,
+      commandId,
+      archive,
+      archiveSha256,
+      manifest,
+    });
+    return c.json({ success: true, id: row?.id, archiveSha256 }, 201);
+  }
+);
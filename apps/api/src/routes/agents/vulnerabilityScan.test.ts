@@ -0,0 +1,162 @@
+import { beforeEach, describe, expect, it, vi } from 'vitest';
+import { Hono } from 'hono';
+
+vi.mock('../../db', () => ({
+  db: { select: vi.fn() },
+  runOutsideDbContext: vi.fn((fn: () => unknown) => fn()),
+  withSystemDbAccessContext: vi.fn(async (fn: () => Promise<unknown>) => fn()),
+}));
+
+vi.mock('../../db/schema', () => ({
+  devices: { id: 'devices.id', orgId: 'devices.orgId', agentId: 'devices.agentId', hostname: 'devices.hostname' },
+}));
+
+vi.mock('../../middleware/requireAgentRole', () => ({
+  requireAgentRole: async (c: any, next: any) => {
+    c.set('agent', { agentId: 'agent-1', orgId: '22222222-2222-4222-8222-222222222222', role: 'agent' });
+    await next();
+  },
+}));
+
+const { enabledMock, buildMock, replaceMock, auditMock } = vi.hoisted(() => ({
+  enabledMock: vi.fn(async () => true),
+  buildMock: vi.fn(async () => [] as unknown[]),
+  replaceMock: vi.fn(async (_d: string, _o: string, _s: Date, findings: unknown[]) => findings.length),
+  auditMock: vi.fn(),
+}));
+
+vi.mock('../../services/featureConfigResolver', () => ({ resolveVulnerabilityEnabledForDevice: enabledMock }));
+vi.mock('../../services/vulnerabilityScanSnapshot', async (importOriginal) => {
+  const actual = await importOriginal<typeof import('../../services/vulnerabilityScanSnapshot')>();
+  return {
+    snapshotEtag: actual.snapshotEtag,
+    buildVulnerabilitySnapshot: buildMock,
+    replaceVulnerabilityScanFindings: replaceMock,
+  };
+});
+vi.mock('../../services/auditEvents', () => ({ writeAuditEvent: auditMock }));
+
+import { db } from '../../db';
+import { vulnerabilityScanRoutes } from './vulnerabilityScan';
+
+const DEVICE_ID = '11111111-1111-4111-8111-111111111111';
+const ORG_ID = '22222222-2222-4222-8222-222222222222';
+
+function buildApp() {
+  const app = new Hono();
+  app.route('/', vulnerabilityScanRoutes);
+  return app;
+}
+
+function mockDeviceLookup(row: unknown) {
+  (db.select as ReturnType<typeof vi.fn>).mockReturnValue({
+    from: vi.fn().mockReturnValue({
+      where: vi.fn().mockReturnValue({
+        limit: vi.fn().mockResolvedValue(row ? [row] : []),
+      }),
+    }),
+  });
+}
+
+const chromeEntry = { name: 'google chrome', cveId: 'CVE-2024-4671', cvssScore: 9.6, knownExploited: true, endExcluding: '124.0.6367.201' };
+
+describe('GET /:id/vulnerability-snapshot', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    enabledMock.mockResolvedValue(true);
+    buildMock.mockResolvedValue([chromeEntry]);
+  });
+
+  it('returns the device snapshot with an ETag and honors If-None-Match', async () => {
+    mockDeviceLookup({ id: DEVICE_ID, orgId: ORG_ID, hostname: 'ws-1' });
+    const res = await buildApp().request('/agent-1/vulnerability-snapshot');
+    expect(res.status).toBe(200);
+    const body = await res.json();
+    expect(body.enabled).toBe(true);
+    expect(body.entries).toEqual([chromeEntry]);
+    expect(buildMock).toHaveBeenCalledWith(DEVICE_ID);
+
+    const etag = res.headers.get('ETag');
+    expect(etag).toMatch(/^"[0-9a-f]{32}"$/);
+    const again = await buildApp().request('/agent-1/vulnerability-snapshot', { headers: { 'If-None-Match': etag! } });
+    expect(again.status).toBe(304);
+  });
+
+  it('serves an empty snapshot without reading the catalog when scanning is off', async () => {
+    mockDeviceLookup({ id: DEVICE_ID, orgId: ORG_ID, hostname: 'ws-1' });
+    enabledMock.mockResolvedValue(false);
+    const res = await buildApp().request('/agent-1/vulnerability-snapshot');
+    expect(res.status).toBe(200);
+    expect(await res.json()).toMatchObject({ enabled: false, entries: [] });
+    expect(buildMock).not.toHaveBeenCalled();
+  });
+
+  it('404s when the agent id resolves to no device', async () => {
+    mockDeviceLookup(null);
+    const res = await buildApp().request('/nope/vulnerability-snapshot');
+    expect(res.status).toBe(404);
+  });
+});
+
+describe('PUT /:id/vulnerabilities', () => {
+  beforeEach(() => vi.clearAllMocks());
+
+  const validBody = {
+    scannedAt: '2026-10-18T13:05:00Z',
+    snapshotGeneratedAt: '2026-10-18T13:00:00.000Z',
+    softwareScanned: 212,
+    findings: [{ name: 'Google Chrome', version: '124.0.6367.91', cveId: 'cve-2024-4671', cvssScore: 9.6, knownExploited: true }],
+  };
+
+  it('replaces the device findings and audits counts', async () => {
+    mockDeviceLookup({ id: DEVICE_ID, orgId: ORG_ID, hostname: 'ws-1' });
+    const res = await buildApp().request('/agent-1/vulnerabilities', {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(validBody),
+    });
+    expect(res.status).toBe(200);
+    expect(await res.json()).toEqual({ success: true, count: 1 });
+    const [deviceId, orgId, scannedAt, findings] = replaceMock.mock.calls[0]!;
+    expect(deviceId).toBe(DEVICE_ID);
+    expect(orgId).toBe(ORG_ID);
+    expect((scannedAt as Date).toISOString()).toBe('2026-10-18T13:05:00.000Z');
+    expect((findings as Array<{ cveId: string }>)[0]!.cveId).toBe('CVE-2024-4671');
+    const auditArg = auditMock.mock.calls[0]![1] as { action: string; details: Record<string, unknown> };
+    expect(auditArg.action).toBe('agent.vulnerability_scan.submit');
+    expect(auditArg.details).toMatchObject({ findingCount: 1, knownExploitedCount: 1, softwareScanned: 212 });
+  });
+
+  it('accepts an empty report so stale findings are cleared', async () => {
+    mockDeviceLookup({ id: DEVICE_ID, orgId: ORG_ID, hostname: 'ws-1' });
+    const res = await buildApp().request('/agent-1/vulnerabilities', {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ scannedAt: validBody.scannedAt, findings: [] }),
+    });
+    expect(res.status).toBe(200);
+    expect(replaceMock).toHaveBeenCalledWith(DEVICE_ID, ORG_ID, expect.any(Date), []);
+  });
+
+  it('rejects a malformed CVE id with 400', async () => {
+    mockDeviceLookup({ id: DEVICE_ID, orgId: ORG_ID, hostname: 'ws-1' });
+    const res = await buildApp().request('/agent-1/vulnerabilities', {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ ...validBody, findings: [{ ...validBody.findings[0], cveId: 'CVE-2023-38039 mariner' }] }),
+    });
+    expect(res.status).toBe(400);
+    expect(replaceMock).not.toHaveBeenCalled();
+  });
+
+  it('403s when the device belongs to another org', async () => {
+    mockDeviceLookup({ id: DEVICE_ID, orgId: '33333333-3333-4333-8333-333333333333', hostname: 'ws-1' });
+    const res = await buildApp().request('/agent-1/vulnerabilities', {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(validBody),
+    });
+    expect(res.status).toBe(403);
+    expect(replaceMock).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,96 @@
+import { Hono } from 'hono';
+import { zValidator } from '../../lib/validation';
+import { eq } from 'drizzle-orm';
+import { db, runOutsideDbContext, withSystemDbAccessContext } from '../../db';
+import { devices } from '../../db/schema';
+import { writeAuditEvent } from '../../services/auditEvents';
+import { resolveVulnerabilityEnabledForDevice } from '../../services/featureConfigResolver';
+import {
+  buildVulnerabilitySnapshot,
+  replaceVulnerabilityScanFindings,
+  snapshotEtag,
+  type VulnerabilitySnapshotEntry,
+} from '../../services/vulnerabilityScanSnapshot';
+import { requireAgentRole } from '../../middleware/requireAgentRole';
+import { vulnerabilityScanIngestSchema } from './schemas';
+
+export const vulnerabilityScanRoutes = new Hono();
+// Scanning is the main agent's job; reject watchdog-role tokens so a weaker
+// credential can't clear or falsify a device's vulnerability findings.
+vulnerabilityScanRoutes.use('*', requireAgentRole);
+
+async function findDevice(agentId: string) {
+  const [device] = await db
+    .select({ id: devices.id, orgId: devices.orgId, hostname: devices.hostname })
+    .from(devices)
+    .where(eq(devices.agentId, agentId))
+    .limit(1);
+  return device;
+}
+
+// GET /agents/:id/vulnerability-snapshot — the CVE facts for the software this
+// device has reported. Empty (enabled:false) when vulnerability scanning is off
+// for the device, so the agent's next report clears any stale findings.
+vulnerabilityScanRoutes.get('/:id/vulnerability-snapshot', async (c) => {
+  const device = await findDevice(c.req.param('id'));
+  if (!device) {
+    return c.json({ error: 'Device not found' }, 404);
+  }
+
+  // The feature gate can be decided by a partner-wide policy and the catalog
+  // tables are system-only, neither visible in the agent's org context.
+  const { enabled, entries } = await runOutsideDbContext(() =>
+    withSystemDbAccessContext(async () => {
+      const enabled = await resolveVulnerabilityEnabledForDevice(device.id);
+      const entries: VulnerabilitySnapshotEntry[] = enabled ? await buildVulnerabilitySnapshot(device.id) : [];
+      return { enabled, entries };
+    })
+  );
+
+  const etag = snapshotEtag(enabled, entries);
+  c.header('ETag', etag);
+  if (c.req.header('If-None-Match') === etag) {
+    return c.body(null, 304);
+  }
+  return c.json({ enabled, generatedAt: new Date().toISOString(), entries });
+});
+
+// PUT /agents/:id/vulnerabilities — the agent's scan result; replaces the
+// device's previous findings.
+vulnerabilityScanRoutes.put('/:id/vulnerabilities', zValidator('json', vulnerabilityScanIngestSchema), async (c) => {
+  const agentId = c.req.param('id');
+  const data = c.req.valid('json');
+  const agent = c.get('agent') as { orgId?: string; agentId?: string } | undefined;
+
+  const device = await findDevice(agentId);
+  if (!device) {
+    return c.json({ error: 'Device not found' }, 404);
+  }
+  if (agent?.orgId && agent.orgId !== device.orgId) {
+    return c.json({ error: 'Organization mismatch' }, 403);
+  }
+
+  const count = await replaceVulnerabilityScanFindings(device.id, device.orgId, new Date(data.scannedAt), data.findings);
+
+  try {
+    writeAuditEvent(c, {
+      orgId: agent?.orgId ?? device.orgId,
+      actorType: 'agent',
+      actorId: agent?.agentId ?? agentId,
+      action: 'agent.vulnerability_scan.submit',
+      resourceType: 'device',
+      resourceId: device.id,
+      resourceName: device.hostname,
+      details: {
+        findingCount: count,
+        knownExploitedCount: data.findings.filter((f) => f.knownExploited).length,
+        softwareScanned: data.softwareScanned ?? null,
+        snapshotGeneratedAt: data.snapshotGeneratedAt ?? null,
+      },
+    });
+  } catch (error) {
+    console.error(`[vulnerabilityScan] Failed to write audit event for device ${device.id}:`, error);
+  }
+
+  return c.json({ success: true, count });
+});
@@ -303,6 +303,40 @@ describe('backup dashboard routes', () => {
     const body = await res.json();
     expect(body.data.attentionItems).toEqual([]);
   });
+
+  it('reports size, file count, errors and duration of the last run in device status', async () => {
+    selectMock
+      .mockReturnValueOnce(chainMock([{ id: DEVICE_ID, siteId: SITE_A }])) // device lookup
+      .mockReturnValueOnce(chainMock([
+        {
+          id: 'job-latest',
+          status: 'completed',
+          createdAt: new Date('2026-10-18T02:00:00.000Z'),
+          completedAt: new Date('2026-10-18T02:41:00.000Z'),
+          totalSize: 5_368_709_120,
+          fileCount: 48_211,
+          errorCount: 3,
+          durationMs: 2_310_000,
+          errorLog: '3 files failed to upload',
+        },
+      ])); // recent jobs
+
+    const res = await app.request(`/backup/status/${DEVICE_ID}`);
+
+    expect(res.status).toBe(200);
+    const body = await res.json();
+    expect(body.data.lastJob).toEqual({
+      id: 'job-latest',
+      status: 'completed',
+      createdAt: '2026-10-18T02:00:00.000Z',
+      completedAt: '2026-10-18T02:41:00.000Z',
+      totalSize: 5_368_709_120,
+      fileCount: 48_211,
+      errorCount: 3,
+      durationMs: 2_310_000,
+    });
+    expect(body.data.lastSuccessAt).toBe('2026-10-18T02:41:00.000Z');
+  });
 });
 
 function makeRecentJob(overrides: Record<string, unknown> = {}) {
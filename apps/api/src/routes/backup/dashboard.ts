@@ -428,6 +428,10 @@ dashboardRoutes.get('/status/:deviceId', requirePermission(PERMISSIONS.ORGS_READ
             status: lastJob.status,
             createdAt: lastJob.createdAt.toISOString(),
             completedAt: lastJob.completedAt?.toISOString() ?? null,
+            totalSize: lastJob.totalSize ?? null,
+            fileCount: lastJob.fileCount ?? null,
+            errorCount: lastJob.errorCount ?? null,
+            durationMs: lastJob.durationMs ?? null,
           }
         : null,
       lastSuccessAt: lastSuccess?.completedAt?.toISOString() ?? null,
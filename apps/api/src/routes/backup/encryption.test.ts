@@ -274,6 +274,22 @@ describe('encryption routes', () => {
     expect(body.error).toBe('Cannot rotate an inactive key');
   });
 
+  it('should reject rotating a server-managed client-side key', async () => {
+    selectMock.mockReturnValueOnce(chainMock([makeKey({ keyType: 'client_aes_256' })]));
+
+    const res = await app.request(`/backup/encryption/keys/${KEY_ID}/rotate`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json', Authorization: 'Bearer token' },
+      body: JSON.stringify({
+        newKeyHash: 'fedcba0987654321fedcba0987654321',
+        newEncryptedPrivateKey: 'enc:v1:new-wrapped-private-key',
+      }),
+    });
+
+    expect(res.status).toBe(400);
+    expect(transactionMock).not.toHaveBeenCalled();
+  });
+
   it('enforces multi-tenant isolation', async () => {
     selectMock.mockReturnValueOnce(chainMock([]));
 
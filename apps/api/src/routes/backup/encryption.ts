@@ -7,6 +7,7 @@ import { backupSnapshots, storageEncryptionKeys } from '../../db/schema';
 import { requireMfa, requirePermission, requireScope } from '../../middleware/auth';
 import { writeRouteAudit } from '../../services/auditEvents';
 import { PERMISSIONS } from '../../services/permissions';
+import { CLIENT_BACKUP_KEY_TYPE } from '../../services/backupClientKeys';
 import { resolveScopedOrgId } from './helpers';
 import { createEncryptionKeySchema, rotateEncryptionKeySchema } from './schemas';
 
@@ -218,6 +219,12 @@ encryptionRoutes.post(
       return c.json({ error: 'Cannot rotate an inactive key' }, 400);
     }
 
+    // The server generates and escrows client-side encryption keys itself;
+    // user-supplied material would orphan every device key wrapped by it.
+    if (oldKey.keyType === CLIENT_BACKUP_KEY_TYPE) {
+      return c.json({ error: 'Client-side backup keys are managed by the server and cannot be rotated here' }, 400);
+    }
+
     const now = new Date();
 
     // Deactivate old key and create new key atomically in a transaction.
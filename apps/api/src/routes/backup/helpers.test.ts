@@ -1,5 +1,5 @@
 import { describe, expect, it } from 'vitest';
-import { getDueOccurrenceKey } from './helpers';
+import { buildBackupRunLimits, getDueOccurrenceKey } from './helpers';
 
 describe('backup schedule helpers', () => {
   it('returns an occurrence key for an exact scheduled minute', () => {
@@ -45,3 +45,29 @@ describe('backup schedule helpers', () => {
     ).toBe('2026-03-31T23:58');
   });
 });
+
+describe('buildBackupRunLimits', () => {
+  it('turns an overnight window into a start + duration for the agent', () => {
+    expect(
+      buildBackupRunLimits({
+        frequency: 'daily',
+        time: '22:00',
+        timezone: 'America/Chicago',
+        windowStart: '19:00',
+        windowEnd: '07:00',
+      }),
+    ).toEqual({
+      windows: [{ start: '19:00', durationMinutes: 720, timezone: 'America/Chicago' }],
+    });
+  });
+
+  it('omits the window when start equals end (always open) or either is missing', () => {
+    expect(buildBackupRunLimits({ windowStart: '02:00', windowEnd: '02:00' })).toEqual({});
+    expect(buildBackupRunLimits({ windowStart: '02:00' })).toEqual({});
+    expect(buildBackupRunLimits(null)).toEqual({});
+  });
+
+  it('converts the Mbps cap to agent KB/s', () => {
+    expect(buildBackupRunLimits({ bandwidthLimitMbps: 10 })).toEqual({ bandwidthLimitKBps: 1220 });
+  });
+});
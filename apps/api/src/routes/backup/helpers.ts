@@ -363,3 +363,40 @@ export function resolveScopedOrgId(
 
   return null;
 }
+
+export type BackupRunLimits = {
+  windows?: Array<{ start: string; durationMinutes: number; timezone?: string }>;
+  bandwidthLimitKBps?: number;
+};
+
+/**
+ * Translates a policy schedule's backup window and upload cap into the
+ * backup_run payload fields the agent enforces. The agent refuses to start
+ * outside the window and stops (resumably) when it closes, so a run that
+ * fires late after a missed slot cannot saturate the link during business
+ * hours. A schedule without a timezone leaves the window in the device's
+ * local time.
+ */
+export function buildBackupRunLimits(
+  schedule: Partial<BackupPolicySchedule> | null | undefined,
+): BackupRunLimits {
+  const limits: BackupRunLimits = {};
+  if (!schedule) return limits;
+
+  const windowStart = parseWindowTime(schedule.windowStart);
+  const windowEnd = parseWindowTime(schedule.windowEnd);
+  // Equal start/end means "always open", matching isWithinBackupWindow.
+  if (windowStart !== null && windowEnd !== null && windowStart !== windowEnd) {
+    limits.windows = [{
+      start: schedule.windowStart!,
+      durationMinutes: (windowEnd - windowStart + 24 * 60) % (24 * 60),
+      ...(schedule.timezone ? { timezone: schedule.timezone } : {}),
+    }];
+  }
+
+  if (typeof schedule.bandwidthLimitMbps === 'number' && schedule.bandwidthLimitMbps > 0) {
+    // Megabits per second to the agent's kilobytes (1024 B) per second.
+    limits.bandwidthLimitKBps = Math.max(1, Math.floor((schedule.bandwidthLimitMbps * 1_000_000) / 8 / 1024));
+  }
+  return limits;
+}
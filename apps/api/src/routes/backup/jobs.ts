@@ -668,6 +668,8 @@ function toJobResponse(row: typeof backupJobs.$inferSelect) {
     referencedSize: row.referencedSize ?? null,
     referencedFiles: row.referencedFiles ?? null,
     errorCount: row.errorCount ?? null,
+    durationMs: row.durationMs ?? null,
     errorLog: row.errorLog ?? null,
+    vssMetadata: row.vssMetadata ?? null,
   };
 }
@@ -3,6 +3,7 @@ import { Hono } from 'hono';
 
 const queueCommandForExecutionMock = vi.fn();
 const queueBackupStopCommandMock = vi.fn();
+const attachClientBackupKeyMock = vi.fn(async (_type: string, payload: Record<string, unknown>, _deviceId: string, _snapshotDbId: string | null) => payload);
 const runOutsideDbContextMock = vi.fn((fn: () => unknown) => fn());
 const authzState = vi.hoisted(() => ({
   allowedPermissions: new Set<string>(['*:*']),
@@ -114,6 +115,10 @@ vi.mock('../../services/backupMetrics', () => ({
   recordBackupDispatchFailure: vi.fn(),
 }));
 
+vi.mock('../../services/backupClientKeys', () => ({
+  attachClientBackupKey: (...args: unknown[]) => attachClientBackupKeyMock(...(args as [string, Record<string, unknown>, string, string | null])),
+}));
+
 import { restoreRoutes } from './restore';
 
 describe('restore routes', () => {
@@ -272,6 +277,7 @@ describe('restore routes', () => {
     const body = await res.json();
     expect(body.commandId).toBe('command-1');
     expect(runOutsideDbContextMock).toHaveBeenCalled();
+    expect(attachClientBackupKeyMock).toHaveBeenCalledWith('backup_restore', expect.any(Object), 'device-1', 'snap-db-1');
     expect(queueCommandForExecutionMock).toHaveBeenCalledWith(
       'device-1',
       'backup_restore',
@@ -9,6 +9,7 @@ import { recordBackupDispatchFailure } from '../../services/backupMetrics';
 import { CommandTypes, queueBackupStopCommand, queueCommandForExecution } from '../../services/commandQueue';
 import { canAccessSite, PERMISSIONS, type UserPermissions } from '../../services/permissions';
 import { resolveBackupProviderConfig, resolveBackupDestinationError } from '../../services/backupProviderConfig';
+import { attachClientBackupKey } from '../../services/backupClientKeys';
 import { resolveScopedOrgId } from './helpers';
 import { restoreListSchema, restoreSchema } from './schemas';
 
@@ -283,9 +284,10 @@ restoreRoutes.post(
     let responseRow = row;
 
     try {
-      const { command, error } = await runInOrg(orgId, () =>
-        queueCommandForExecution(
-          row.deviceId,
+      // The key belongs to the device that wrote the snapshot, which may not
+      // be the restore target.
+      const commandPayload = await runInOrg(orgId, () =>
+        attachClientBackupKey(
           CommandTypes.BACKUP_RESTORE,
           {
             restoreJobId: row.id,
@@ -295,6 +297,15 @@ restoreRoutes.post(
             provider: backupProviderConfig.provider,
             providerConfig: backupProviderConfig.providerConfig,
           },
+          snapshot.deviceId,
+          snapshot.id
+        )
+      );
+      const { command, error } = await runInOrg(orgId, () =>
+        queueCommandForExecution(
+          row.deviceId,
+          CommandTypes.BACKUP_RESTORE,
+          commandPayload,
           { userId: auth?.user?.id ?? undefined }
         )
       );
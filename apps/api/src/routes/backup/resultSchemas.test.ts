@@ -72,3 +72,22 @@ describe('backupCommandResultSchema — incremental-backup referenced stats', ()
     expect(parsed.referencedFiles).toBeUndefined();
   });
 });
+
+describe('backupCommandResultSchema — VSS metadata', () => {
+  it('keeps the shadow-copy details and passes through unmodeled fields', () => {
+    const parsed = backupCommandResultSchema.parse({
+      snapshotId: 'snap-1',
+      vssMetadata: {
+        shadowCopyId: '{3f2a}',
+        creationTime: '2026-10-18T10:00:00Z',
+        writers: [{ name: 'System Writer', id: 'w-1', state: 'stable' }],
+        exposedPaths: { 'C:\\': '\\\\?\\GLOBALROOT\\Device\\HarddiskVolumeShadowCopy1\\' },
+        durationMs: 900,
+        setId: 'future-field',
+      },
+    });
+    expect(parsed.vssMetadata?.shadowCopyId).toBe('{3f2a}');
+    expect(parsed.vssMetadata?.writers).toHaveLength(1);
+    expect((parsed.vssMetadata as Record<string, unknown>).setId).toBe('future-field');
+  });
+});
@@ -36,6 +36,20 @@ export const backupSystemStateManifestResultSchema = z
   })
   .passthrough();
 
+// Windows shadow-copy details for a file backup (agent-side vss.VSSMetadata).
+// Absent when VSS was not used. Kept permissive for the same reason as the
+// system-state manifest: an unmodeled writer field must not drop the result.
+export const backupVssMetadataResultSchema = z
+  .object({
+    shadowCopyId: z.string().optional(),
+    creationTime: z.string().optional(),
+    writers: z.array(z.record(z.string(), z.unknown())).optional(),
+    exposedPaths: z.record(z.string(), z.string()).nullish(),
+    warnings: z.array(z.string()).nullish(),
+    durationMs: z.number().optional(),
+  })
+  .passthrough();
+
 export const backupCommandResultSchema = z.object({
   jobId: z.string().optional(),
   snapshotId: z.string().optional(),
@@ -54,9 +68,16 @@ export const backupCommandResultSchema = z.object({
   // are defined (see applyBackupCommandResultToJob).
   referencedBytes: z.number().nonnegative().refine(Number.isInteger, 'expected integer').optional(),
   referencedFiles: z.number().int().nonnegative().optional(),
+  // Agent-measured wall-clock run time (BackupJob.DurationMs). The server's
+  // own started/completed timestamps include dispatch and queueing.
+  durationMs: z.number().int().nonnegative().optional(),
   backupType: z.enum(['file', 'system_image', 'database', 'application']).optional(),
   systemStateManifest: backupSystemStateManifestResultSchema.optional(),
+  vssMetadata: backupVssMetadataResultSchema.optional(),
   metadata: z.record(z.string(), z.unknown()).optional(),
+  // The client-side encryption key the snapshot was written under
+  // (BackupJob.ClientEncryptionKeyID); omitted for plaintext snapshots.
+  clientEncryptionKeyId: z.string().min(1).max(255).optional(),
   snapshot: backupSnapshotResultSchema.optional(),
 });
 
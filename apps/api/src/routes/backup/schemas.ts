@@ -194,6 +194,7 @@ export const verificationRunSchema = z.object({
   backupJobId: z.string().min(1).optional(),
   snapshotId: z.string().min(1).optional(),
   verificationType: z.enum(['integrity', 'test_restore']).optional(),
+  sampleSize: z.number().int().positive().max(100_000).optional(),
 });
 
 export const verificationListSchema = z.object({
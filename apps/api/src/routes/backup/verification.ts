@@ -87,6 +87,7 @@ backupVerificationRoutes.post(
       verificationType,
       backupJobId: payload.backupJobId,
       snapshotId: payload.snapshotId,
+      sampleSize: payload.sampleSize,
       source: 'api.verify',
       requestedBy: auth?.user?.id ?? null
     });
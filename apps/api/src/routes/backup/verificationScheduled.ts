@@ -15,6 +15,7 @@ import type { BackupJob, BackupVerification, BackupVerificationStatus } from './
 import { recomputeRecoveryReadinessForDevice } from './readinessCalculator';
 import {
   BACKUP_MAX_RECENT_VERIFICATIONS,
+  BACKUP_TEST_RESTORE_SAMPLE_SIZE,
   BackupVerificationDispatchError,
   listBackupVerifications,
   persistVerificationToDb,
@@ -362,6 +363,10 @@ export async function processBackupVerificationResult(
   details.failedFiles = agentResult.failedFiles || [];
   details.cleanedUp = agentResult.cleanedUp;
   details.restorePath = agentResult.restorePath;
+  if (typeof agentResult.filesInSnapshot === 'number') {
+    // Sampled test-restore: filesVerified covers only the sample.
+    details.filesInSnapshot = agentResult.filesInSnapshot;
+  }
 
   await persistVerificationToDb(pending);
   recordBackupVerificationResult(pending.verificationType, pending.status);
@@ -561,6 +566,7 @@ export async function runWeeklyTestRestore(orgId?: string): Promise<number> {
         verificationType: 'test_restore',
         backupJobId: job.id,
         snapshotId: job.snapshotId ?? undefined,
+        sampleSize: BACKUP_TEST_RESTORE_SAMPLE_SIZE,
         source: 'weekly-test-restore'
       });
       queued += 1;
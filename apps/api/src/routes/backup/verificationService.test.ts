@@ -14,6 +14,10 @@ vi.mock('../../services/commandQueue', () => ({
   queueCommandForExecution: vi.fn(),
 }));
 
+vi.mock('../../services/backupClientKeys', () => ({
+  attachClientBackupKey: vi.fn(async (_type: string, payload: Record<string, unknown>) => payload),
+}));
+
 vi.mock('../../services/backupMetrics', () => ({
   recordBackupDispatchFailure: vi.fn(),
   recordBackupCommandTimeout: vi.fn(),
@@ -235,6 +239,33 @@ describe('backup verification service', () => {
     verificationOrgById.delete(verification.id);
   });
 
+  it('forwards sampleSize on a sampled test_restore dispatch', async () => {
+    vi.mocked(queueCommandForExecution).mockResolvedValueOnce({
+      command: { id: 'cmd-restore-sample-1', status: 'sent' } as any,
+    });
+
+    const { verification } = await runBackupVerification({
+      orgId: 'org-123',
+      deviceId: 'dev-001',
+      backupJobId: 'job-001',
+      verificationType: 'test_restore',
+      sampleSize: 100,
+      source: 'test'
+    });
+
+    expect(queueCommandForExecution).toHaveBeenCalledWith(
+      'dev-001',
+      'backup_test_restore',
+      expect.objectContaining({ sampleSize: 100 }),
+      expect.anything()
+    );
+    expect((verification.details as Record<string, unknown>).sampleSize).toBe(100);
+
+    const idx = backupVerifications.findIndex((v) => v.id === verification.id);
+    if (idx >= 0) backupVerifications.splice(idx, 1);
+    verificationOrgById.delete(verification.id);
+  });
+
   it('fails loudly instead of dispatching a config-less command when no backup destination can be resolved', async () => {
     const orgId = `org-noconfig-${Date.now()}`;
     const jobId = `job-noconfig-${Date.now()}`;
@@ -369,6 +400,38 @@ describe('processBackupVerificationResult', () => {
     expect(passedEvents[0]![1]).toBe(TEST_ORG_ID);
   });
 
+  it('records the snapshot file count for a sampled test restore', async () => {
+    const testCommandId = `cmd-test-sample-${Date.now()}`;
+    const verificationId = `verify-proc-sample-${Date.now()}`;
+
+    backupVerifications.push({
+      id: verificationId,
+      orgId: TEST_ORG_ID,
+      deviceId: 'dev-001',
+      backupJobId: 'job-001',
+      snapshotId: 'snap-001',
+      verificationType: 'test_restore',
+      status: 'pending',
+      startedAt: new Date().toISOString(),
+      completedAt: null,
+      filesVerified: 0,
+      filesFailed: 0,
+      details: { source: 'test', commandId: testCommandId, sampleSize: 100 },
+      createdAt: new Date().toISOString(),
+    });
+    verificationOrgById.set(verificationId, TEST_ORG_ID);
+
+    await processBackupVerificationResult(testCommandId, {
+      status: 'completed',
+      stdout: JSON.stringify({ status: 'passed', filesVerified: 100, filesFailed: 0, filesInSnapshot: 45678 }),
+    });
+
+    const updated = backupVerifications.find((v) => v.id === verificationId);
+    expect(updated?.status).toBe('passed');
+    expect(updated?.filesVerified).toBe(100);
+    expect((updated?.details as Record<string, unknown>).filesInSnapshot).toBe(45678);
+  });
+
   it('marks verification as failed on failed agent command', async () => {
     const testCommandId = `cmd-test-fail-${Date.now()}`;
     const verificationId = `verify-proc-fail-${Date.now()}`;
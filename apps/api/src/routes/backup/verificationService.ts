@@ -8,6 +8,7 @@ import {
 import { recordBackupDispatchFailure } from '../../services/backupMetrics';
 import { resolveBackupProviderConfig, resolveBackupDestinationError, type BackupProviderConfig } from '../../services/backupProviderConfig';
 import { queueCommandForExecution } from '../../services/commandQueue';
+import { attachClientBackupKey } from '../../services/backupClientKeys';
 import { publishEvent } from '../../services/eventBus';
 import { BACKUP_LOW_READINESS_THRESHOLD } from './constants';
 import {
@@ -45,6 +46,9 @@ export const BACKUP_READINESS_RECOVERY_THRESHOLD = 75;
 export const BACKUP_HIGH_READINESS_THRESHOLD = 85;
 export const BACKUP_RECENT_COVERAGE_DAYS = 30;
 export const BACKUP_MAX_RECENT_VERIFICATIONS = 12;
+// Files restored by the scheduled weekly test-restore. A random sample keeps
+// the run cheap on large snapshots; rotating samples cover the set over time.
+export const BACKUP_TEST_RESTORE_SAMPLE_SIZE = 100;
 
 export class BackupVerificationDispatchError extends Error {
   statusCode: number;
@@ -73,6 +77,9 @@ export type RunBackupVerificationInput = {
   verificationType: BackupVerificationType;
   backupJobId?: string;
   snapshotId?: string;
+  // test_restore only: restore a random sample of this many files instead of
+  // the whole snapshot. Omitted means a full restore.
+  sampleSize?: number;
   source: string;
   requestedBy?: string | null;
 };
@@ -561,15 +568,24 @@ export async function runBackupVerification(input: RunBackupVerificationInput):
   }
 
   const commandType = input.verificationType === 'integrity' ? 'backup_verify' : 'backup_test_restore';
-  const dispatchResult = await queueCommandForExecution(
-    input.deviceId,
+  const commandPayload = await attachClientBackupKey(
     commandType,
     {
       snapshotId: agentSnapshotId,
       verificationType: input.verificationType,
+      ...(input.verificationType === 'test_restore' && input.sampleSize
+        ? { sampleSize: input.sampleSize }
+        : {}),
       provider: providerConfig.provider,
       providerConfig: providerConfig.providerConfig,
     },
+    input.deviceId,
+    snapshotId
+  );
+  const dispatchResult = await queueCommandForExecution(
+    input.deviceId,
+    commandType,
+    commandPayload,
     { userId: input.requestedBy || undefined }
   );
 
@@ -607,6 +623,9 @@ export async function runBackupVerification(input: RunBackupVerificationInput):
       source: input.source,
       requestedBy: input.requestedBy ?? null,
       commandId: dispatchResult.command.id,
+      ...(input.verificationType === 'test_restore' && input.sampleSize
+        ? { sampleSize: input.sampleSize }
+        : {}),
     }
   }, input.orgId);
 
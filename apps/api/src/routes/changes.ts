@@ -15,7 +15,10 @@ const changeTypeValues = [
   'scheduled_task',
   'user_account',
   'hardware',
-  'os_version'
+  'os_version',
+  'usb_device',
+  'registry',
+  'defender_policy'
 ] as const;
 
 const changeActionValues = [
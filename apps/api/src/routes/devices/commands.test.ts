@@ -133,6 +133,60 @@ describe('device commands routes', () => {
       ]);
     });
 
+    it('queues a firewall rule for every device in one request', async () => {
+      vi.mocked(getDeviceWithOrgCheck)
+        .mockResolvedValueOnce({ id: 'device-a', orgId: 'org-123', status: 'online', hostname: 'host-a' } as never);
+      const values = vi.fn().mockReturnValue({
+        returning: vi.fn().mockResolvedValue([{
+          id: 'cmd-1',
+          deviceId: '11111111-1111-1111-1111-111111111111',
+          type: 'firewall_add_rule',
+          status: 'pending',
+          createdAt: new Date()
+        }])
+      });
+      vi.mocked(db.insert).mockReturnValueOnce({ values } as never);
+
+      const payload = { name: 'Block SMB', protocol: 'tcp', port: '445' };
+      const res = await app.request('/devices/bulk/commands', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json', Authorization: 'Bearer token' },
+        body: JSON.stringify({
+          deviceIds: ['11111111-1111-1111-1111-111111111111'],
+          type: 'firewall_add_rule',
+          payload
+        })
+      });
+
+      expect(res.status).toBe(201);
+      expect(values).toHaveBeenCalledWith(expect.objectContaining({ type: 'firewall_add_rule', payload }));
+    });
+
+    it('rejects invalid command payloads before queueing anything', async () => {
+      for (const [type, payload] of [
+        ['firewall_add_rule', { name: 'Block SMB', port: '445' }],
+        ['firewall_add_rule', { name: 'All traffic', protocol: 'tcp' }],
+        ['firewall_remove_rule', { id: '3', name: 'Block SMB' }],
+        ['firewall_set_profile', { profile: 'public' }],
+        ['isolate_host', { allow: ['0.0.0.0/0'] }],
+        ['local_admin_remove', {}],
+        ['local_admin_disable', { username: 'bad;name' }],
+        ['local_admin_rotate_password', { username: 'Administrator', password: 'Hunter2!' }],
+        ['rmm_cleanup', { tools: [] }],
+        ['rmm_cleanup', { tools: ['ScreenConnect'], force: true }],
+        ['transcript_export', { from: 'last month' }],
+        ['transcript_export', { from: '2026-03-31T00:00:00Z', to: '2026-03-01T00:00:00Z' }],
+      ] as const) {
+        const res = await app.request('/devices/bulk/commands', {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json', Authorization: 'Bearer token' },
+          body: JSON.stringify({ deviceIds: ['11111111-1111-1111-1111-111111111111'], type, payload })
+        });
+        expect(res.status, `${type} ${JSON.stringify(payload)}`).toBe(400);
+      }
+      expect(db.insert).not.toHaveBeenCalled();
+    });
+
     it('bulk refresh_inventory dedups already-pending devices, skips silently (caught by @xxiaoxiong on #831)', async () => {
       // Two devices: A has a pending refresh_inventory already, B does not.
       // Expected: A is silently skipped (not added to `failed`), B gets a
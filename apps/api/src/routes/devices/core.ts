@@ -104,15 +104,16 @@ const CORE_DEVICE_ORG_DENORMALIZED_TABLES = [
   'capacity_predictions',
   'cis_baseline_results', 'cis_remediation_actions',
   'deployment_invites',
-  'device_boot_metrics', 'device_change_log', 'device_config_state',
+  'device_backup_keys', 'device_boot_metrics', 'device_change_log', 'device_command_transcripts',
+  'device_config_state',
   'device_connections', 'device_disks', 'device_event_logs',
   'device_filesystem_cleanup_runs', 'device_filesystem_scan_state',
   'device_filesystem_snapshots',
-  'device_group_memberships', 'device_hardware', 'device_ip_history',
+  'device_group_memberships', 'device_hardware', 'device_inventory_snapshots', 'device_ip_history',
   'device_metrics', 'device_network', 'device_patches',
   'device_process_samples', 'device_recovery_keys', 'device_registry_state',
-  'device_reliability', 'device_reliability_history', 'device_sessions',
-  'device_vulnerabilities', 'device_warranty',
+  'device_reliability', 'device_reliability_history', 'device_sessions', 'device_software_usage',
+  'device_vulnerabilities', 'device_vulnerability_scan_findings', 'device_warranty',
   'dns_event_aggregations', 'dns_security_events',
   'elevation_requests',
   'group_membership_log',
@@ -121,7 +122,8 @@ const CORE_DEVICE_ORG_DENORMALIZED_TABLES = [
   'onedrive_device_state',
   'peripheral_events', 'playbook_executions', 'provision_credential_handles',
   'recovery_key_access_events',
-  'recovery_readiness', 'recovery_tokens', 'remediation_suggestions', 'remote_sessions', 'restore_jobs',
+  'recovery_readiness', 'recovery_tokens', 'remediation_suggestions', 'remote_session_quality_samples',
+  'remote_sessions', 'restore_jobs',
   's1_actions', 's1_agents', 's1_threats',
   'script_executions',
   'security_posture_snapshots', 'security_scans', 'security_status',
@@ -200,13 +202,18 @@ const CORE_DEVICE_CASCADE_DELETE_TABLES = [
   'device_metrics', 'device_software', 'device_registry_state', 'device_config_state',
   'device_commands', 'device_connections', 'device_boot_metrics',
   'device_sessions', 'device_change_log', 'device_warranty', 'device_vulnerabilities',
+  'device_vulnerability_scan_findings', 'device_inventory_snapshots', 'device_software_usage',
+  'device_command_transcripts',
+  // Client-side backup data keys (FK device_id → devices.id ON DELETE CASCADE;
+  // leaf table, no children)
+  'device_backup_keys',
   // Patches
   'device_patches', 'patch_job_results', 'patch_rollbacks',
   // Deployments & software
   'deployment_devices', 'deployment_results', 'software_inventory',
   'software_compliance_status', 'software_policy_audit',
-  // Remote access
-  'remote_sessions', 'tunnel_sessions',
+  // Remote access (quality samples FK remote_sessions, so they go first)
+  'remote_session_quality_samples', 'remote_sessions', 'tunnel_sessions',
   // Monitoring & logs
   'service_process_check_results', 'alerts', 'agent_logs', 'script_executions',
   'device_event_logs', 'automation_policy_compliance', 'backup_sla_events',
@@ -321,6 +321,7 @@ const actionLabels: Record<string, string> = {
   'script.execution.cancel': 'Script execution cancelled',
   'agent.command.install_patches': 'Patches installed',
   'agent.command.rollback_patches': 'Patches rolled back',
+  'agent.command.patch_stage': 'Patches staged',
   'agent.command.script': 'Script ran',
   'agent.command.software_uninstall': 'Software uninstalled',
   'agent.command.software_update': 'Software updated',
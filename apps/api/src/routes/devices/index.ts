@@ -27,6 +27,9 @@ import { networkRoutes } from './network';
 import { customFieldValuesRoutes } from './customFieldValues';
 import { linksRoutes } from './links';
 import { statsRoutes } from './stats';
+import { inventorySnapshotsRoutes } from './inventorySnapshots';
+import { softwareUsageRoutes } from './softwareUsage';
+import { transcriptRoutes } from './transcripts';
 
 export const deviceRoutes = new Hono();
 
@@ -94,6 +97,9 @@ deviceRoutes.route('/', diagnosticLogsRoutes);
 deviceRoutes.route('/', watchdogLogsRoutes);
 deviceRoutes.route('/', warrantyRoutes);
 deviceRoutes.route('/', bootMetricsRoutes);
+deviceRoutes.route('/', inventorySnapshotsRoutes);
+deviceRoutes.route('/', softwareUsageRoutes);
+deviceRoutes.route('/', transcriptRoutes);
 deviceRoutes.route('/', actuateElevationRoutes);
 
 // Re-export helpers and schemas for potential use elsewhere
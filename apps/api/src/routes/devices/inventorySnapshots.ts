@@ -0,0 +1,35 @@
+import { Hono } from 'hono';
+import { authMiddleware, requirePermission, requireScope } from '../../middleware/auth';
+import { PERMISSIONS } from '../../services/permissions';
+import { getInventorySnapshot, isInventorySnapshotKind } from '../../services/deviceInventorySnapshots';
+import { getDeviceWithOrgAndSiteCheck, SITE_ACCESS_DENIED } from './helpers';
+
+export const inventorySnapshotsRoutes = new Hono();
+
+inventorySnapshotsRoutes.use('*', authMiddleware);
+
+// GET /devices/:id/inventory/:kind - The agent's latest snapshot of one
+// inventory kind (processes, certificates, containers, ...)
+inventorySnapshotsRoutes.get(
+  '/:id/inventory/:kind',
+  requireScope('organization', 'partner', 'system'),
+  requirePermission(PERMISSIONS.DEVICES_READ.resource, PERMISSIONS.DEVICES_READ.action),
+  async (c) => {
+    const auth = c.get('auth');
+    const deviceId = c.req.param('id')!;
+    const kind = c.req.param('kind')!;
+    if (!isInventorySnapshotKind(kind)) {
+      return c.json({ error: 'Unknown inventory kind' }, 404);
+    }
+
+    const device = await getDeviceWithOrgAndSiteCheck(c, deviceId, auth);
+    if (device === SITE_ACCESS_DENIED) {
+      return c.json({ error: 'Access to this site denied' }, 403);
+    }
+    if (!device) {
+      return c.json({ error: 'Device not found' }, 404);
+    }
+
+    return c.json({ snapshot: await getInventorySnapshot(deviceId, kind) });
+  }
+);
@@ -50,6 +50,10 @@ vi.mock('../../services/deviceLinkGroups', () => ({
   dissolveLinkGroupIfBelowMinimum: vi.fn(async () => false),
 }));
 
+vi.mock('../../services/backupClientKeys', () => ({
+  rewrapDeviceBackupKeyForOrg: vi.fn(async () => false),
+}));
+
 vi.mock('../../extensions/tenancyRegistry', () => ({
   withExtensionDeviceCascade: (core: readonly string[]) => [...core],
   withExtensionDeviceOrgDenormalized: (core: readonly string[]) => [...core],
@@ -61,6 +65,7 @@ import { getDeviceWithOrgAndSiteCheck } from './helpers';
 import { writeRouteAudit } from '../../services/auditEvents';
 import { disconnectAgent } from '../agentWs';
 import { dissolveLinkGroupIfBelowMinimum } from '../../services/deviceLinkGroups';
+import { rewrapDeviceBackupKeyForOrg } from '../../services/backupClientKeys';
 import { moveOrgRoutes } from './moveOrg';
 import {
   CUSTOM_ORG_REWRITE_TABLES,
@@ -327,6 +332,29 @@ describe('POST /devices/:id/move-org', () => {
       expect(vi.mocked(dissolveLinkGroupIfBelowMinimum).mock.calls[0]![1]).toBe('grp-multiboot-1');
     });
 
+    it('re-wraps the device backup key under the target org inside the transaction', async () => {
+      vi.mocked(getDeviceWithOrgAndSiteCheck).mockResolvedValue(SAMPLE_DEVICE as never);
+      rigOrgAndSiteSelects({
+        orgRows: [
+          { id: SOURCE_ORG, partnerId: 'partner-1' },
+          { id: TARGET_ORG, partnerId: 'partner-1' },
+        ],
+        siteRow: { id: TARGET_SITE },
+      });
+      rigTransactionSuccess();
+
+      const res = await app.request(`/devices/${DEVICE_ID}/move-org`, {
+        method: 'POST',
+        headers: { Authorization: 'Bearer t', 'Content-Type': 'application/json' },
+        body: JSON.stringify({ orgId: TARGET_ORG, siteId: TARGET_SITE }),
+      });
+
+      expect(res.status).toBe(200);
+      // Re-stamping org_id alone would leave the key wrapped by the source
+      // org's key, and the device's snapshots unrestorable from the target.
+      expect(rewrapDeviceBackupKeyForOrg).toHaveBeenCalledWith(expect.anything(), DEVICE_ID, TARGET_ORG);
+    });
+
     it('rewrites ticket_alert_links org_id via the alert join inside the transaction', async () => {
       vi.mocked(getDeviceWithOrgAndSiteCheck).mockResolvedValue(SAMPLE_DEVICE as never);
       rigOrgAndSiteSelects({
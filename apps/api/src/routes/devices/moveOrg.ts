@@ -23,6 +23,7 @@ import {
   DEVICE_SITE_DENORMALIZED_TABLES,
 } from './core';
 import { dissolveLinkGroupIfBelowMinimum } from '../../services/deviceLinkGroups';
+import { rewrapDeviceBackupKeyForOrg } from '../../services/backupClientKeys';
 import { disconnectAgent } from '../agentWs';
 import { captureException } from '../../services/sentry';
 
@@ -170,6 +171,11 @@ moveOrgRoutes.post(
           linkGroupDissolved = await dissolveLinkGroupIfBelowMinimum(tx, device.linkGroupId);
         }
 
+        // The device's backup data key is wrapped by its org's client key.
+        // Re-wrap it under the target org's key before the loop below
+        // re-stamps org_id, so its snapshots stay restorable after the move.
+        await rewrapDeviceBackupKeyForOrg(tx, deviceId, targetOrgId);
+
         // Rewrite the denormalized org_id on every device-scoped table.
         // Skipping any of these strands pre-existing rows under RLS.
         for (const table of getDeviceOrgDenormalizedTables()) {
@@ -104,10 +104,15 @@ vi.mock('../../services/commandQueue', () => ({
   queueCommandForExecution: vi.fn()
 }));
 
+vi.mock('../../services/inventoryCveAnnotation', () => ({
+  loadPatchCveAnnotations: vi.fn().mockResolvedValue(new Map())
+}));
+
 import { db } from '../../db';
 import { getDeviceWithOrgAndSiteCheck } from './helpers';
 import { queueCommandForExecution } from '../../services/commandQueue';
 import { resolvePartnerIdForOrg } from '../patches/helpers';
+import { loadPatchCveAnnotations } from '../../services/inventoryCveAnnotation';
 
 function selectWhereResult(rows: unknown[]) {
   return {
@@ -268,6 +273,108 @@ describe('device patch routes', () => {
     expect(body.data.lastPatchScanStatus).toBe('completed');
   });
 
+  it('attaches CVE ids and CVSS scores to pending patches', async () => {
+    vi.mocked(getDeviceWithOrgAndSiteCheck).mockResolvedValue({ id: DEVICE_ID, orgId: '11111111-1111-1111-1111-111111111111' } as any);
+    vi.mocked(db.select)
+      .mockReturnValueOnce(selectPatchStatusResult([
+        {
+          id: 'dp-1',
+          patchId: '11111111-1111-4111-8111-111111111111',
+          status: 'pending',
+          installedAt: null,
+          lastCheckedAt: '2026-02-09T10:00:00.000Z',
+          failureCount: 0,
+          lastError: null,
+          externalId: 'winget:Mozilla.Firefox',
+          packageId: 'Mozilla.Firefox',
+          title: 'Mozilla Firefox',
+          description: null,
+          severity: 'important',
+          category: 'application',
+          source: 'third_party',
+          releaseDate: null,
+          requiresReboot: false,
+          cveIds: ['CVE-2024-9680', 'CVE-2024-9392']
+        },
+        {
+          id: 'dp-2',
+          patchId: '22222222-2222-4222-8222-222222222222',
+          status: 'pending',
+          installedAt: null,
+          lastCheckedAt: '2026-02-09T10:00:00.000Z',
+          failureCount: 0,
+          lastError: null,
+          externalId: 'winget:7zip.7zip',
+          packageId: '7zip.7zip',
+          title: '7-Zip',
+          description: null,
+          severity: 'unknown',
+          category: 'application',
+          source: 'third_party',
+          releaseDate: null,
+          requiresReboot: false,
+          cveIds: null
+        }
+      ]) as any)
+      .mockReturnValueOnce(selectWhereOrderLimitResult([]) as any)
+      .mockReturnValueOnce(selectWhereResult([]) as any);
+    vi.mocked(loadPatchCveAnnotations).mockResolvedValueOnce(new Map([
+      ['11111111-1111-4111-8111-111111111111', {
+        cveIds: ['CVE-2024-9680', 'CVE-2024-9392'],
+        cvssScore: 9.8,
+        knownExploited: true
+      }]
+    ]));
+
+    const res = await app.request(`/devices/${DEVICE_ID}/patches`, {
+      method: 'GET',
+      headers: { Authorization: 'Bearer token' }
+    });
+
+    expect(res.status).toBe(200);
+    const body = await res.json();
+    const [firefox, sevenZip] = body.data.pending;
+    expect(firefox.cveIds).toEqual(['CVE-2024-9680', 'CVE-2024-9392']);
+    expect(firefox.cvssScore).toBe(9.8);
+    expect(firefox.knownExploited).toBe(true);
+    expect(sevenZip.cveIds).toEqual([]);
+    expect(sevenZip.cvssScore).toBeNull();
+    expect(sevenZip.knownExploited).toBe(false);
+  });
+
+  it('serves pending patches without CVEs when the catalog lookup fails', async () => {
+    vi.mocked(getDeviceWithOrgAndSiteCheck).mockResolvedValue({ id: DEVICE_ID, orgId: '11111111-1111-1111-1111-111111111111' } as any);
+    vi.mocked(db.select)
+      .mockReturnValueOnce(selectPatchStatusResult([
+        {
+          id: 'dp-1',
+          patchId: '11111111-1111-4111-8111-111111111111',
+          status: 'pending',
+          installedAt: null,
+          lastCheckedAt: '2026-02-09T10:00:00.000Z',
+          failureCount: 0,
+          lastError: null,
+          externalId: 'winget:Mozilla.Firefox',
+          title: 'Mozilla Firefox',
+          source: 'third_party',
+          cveIds: ['CVE-2024-9680']
+        }
+      ]) as any)
+      .mockReturnValueOnce(selectWhereOrderLimitResult([]) as any)
+      .mockReturnValueOnce(selectWhereResult([]) as any);
+    vi.mocked(loadPatchCveAnnotations).mockRejectedValueOnce(new Error('catalog unavailable'));
+
+    const res = await app.request(`/devices/${DEVICE_ID}/patches`, {
+      method: 'GET',
+      headers: { Authorization: 'Bearer token' }
+    });
+
+    expect(res.status).toBe(200);
+    const body = await res.json();
+    expect(body.data.pending).toHaveLength(1);
+    expect(body.data.pending[0].cveIds).toEqual([]);
+  });
+
   it('excludes Linux installed package inventory from patch compliance', async () => {
     vi.mocked(getDeviceWithOrgAndSiteCheck).mockResolvedValue({ id: DEVICE_ID, orgId: '11111111-1111-1111-1111-111111111111' } as any);
     vi.mocked(db.select)
@@ -451,6 +558,84 @@ describe('device patch routes', () => {
     );
   });
 
+  it('forwards createSnapshot to the agent when requested', async () => {
+    vi.mocked(getDeviceWithOrgAndSiteCheck).mockResolvedValue({ id: DEVICE_ID, orgId: '11111111-1111-1111-1111-111111111111' } as any);
+    vi.mocked(db.select)
+      .mockReturnValueOnce(selectWhereResult([
+        { id: PATCH_ID, source: 'linux', externalId: 'apt:openssl', packageId: 'apt:openssl', title: 'OpenSSL' }
+      ]) as any)
+      .mockReturnValueOnce(selectWhereResult([
+        { patchId: PATCH_ID }
+      ]) as any);
+    vi.mocked(queueCommandForExecution).mockResolvedValue({
+      command: { id: 'cmd-install-2', status: 'sent' }
+    } as any);
+
+    const res = await app.request(`/devices/${DEVICE_ID}/patches/install`, {
+      method: 'POST',
+      headers: { Authorization: 'Bearer token', 'Content-Type': 'application/json' },
+      body: JSON.stringify({ patchIds: [PATCH_ID], createSnapshot: true })
+    });
+
+    expect(res.status).toBe(200);
+    expect(queueCommandForExecution).toHaveBeenCalledWith(
+      DEVICE_ID,
+      'install_patches',
+      expect.objectContaining({ patchIds: [PATCH_ID], createSnapshot: true }),
+      { userId: USER_ID, preferHeartbeat: false }
+    );
+  });
+
+  it('queues patch_stage with the bandwidth limit and install-in-window flag', async () => {
+    vi.mocked(getDeviceWithOrgAndSiteCheck).mockResolvedValue({ id: DEVICE_ID, orgId: '11111111-1111-1111-1111-111111111111' } as any);
+    vi.mocked(db.select)
+      .mockReturnValueOnce(selectWhereResult([
+        { id: PATCH_ID, source: 'linux', externalId: 'apt:openssl', packageId: 'apt:openssl', title: 'OpenSSL' }
+      ]) as any)
+      .mockReturnValueOnce(selectWhereResult([
+        { patchId: PATCH_ID }
+      ]) as any);
+    vi.mocked(queueCommandForExecution).mockResolvedValue({
+      command: { id: 'cmd-stage-1', status: 'sent' }
+    } as any);
+
+    const res = await app.request(`/devices/${DEVICE_ID}/patches/stage`, {
+      method: 'POST',
+      headers: { Authorization: 'Bearer token', 'Content-Type': 'application/json' },
+      body: JSON.stringify({ patchIds: [PATCH_ID], bandwidthLimitKbps: 512 })
+    });
+
+    expect(res.status).toBe(200);
+    const body = await res.json();
+    expect(body.commandId).toBe('cmd-stage-1');
+    expect(queueCommandForExecution).toHaveBeenCalledWith(
+      DEVICE_ID,
+      'patch_stage',
+      expect.objectContaining({ patchIds: [PATCH_ID], bandwidthLimitKbps: 512, installInWindow: true }),
+      { userId: USER_ID, preferHeartbeat: false }
+    );
+  });
+
+  it('rejects staging unapproved patches', async () => {
+    vi.mocked(getDeviceWithOrgAndSiteCheck).mockResolvedValue({ id: DEVICE_ID, orgId: '11111111-1111-1111-1111-111111111111' } as any);
+    vi.mocked(db.select)
+      .mockReturnValueOnce(selectWhereResult([
+        { id: PATCH_ID, source: 'linux', externalId: 'apt:openssl', title: 'OpenSSL' }
+      ]) as any)
+      .mockReturnValueOnce(selectWhereResult([]) as any);
+
+    const res = await app.request(`/devices/${DEVICE_ID}/patches/stage`, {
+      method: 'POST',
+      headers: { Authorization: 'Bearer token', 'Content-Type': 'application/json' },
+      body: JSON.stringify({ patchIds: [PATCH_ID], installInWindow: false })
+    });
+
+    expect(res.status).toBe(409);
+    const body = await res.json();
+    expect(body.unapprovedPatchIds).toEqual([PATCH_ID]);
+    expect(queueCommandForExecution).not.toHaveBeenCalled();
+  });
+
   it('rejects install when any requested patch is not approved', async () => {
     vi.mocked(getDeviceWithOrgAndSiteCheck).mockResolvedValue({ id: DEVICE_ID, orgId: '11111111-1111-1111-1111-111111111111' } as any);
     vi.mocked(db.select)
@@ -10,13 +10,26 @@ import { getDeviceWithOrgAndSiteCheck, SITE_ACCESS_DENIED } from './helpers';
 import { queueCommandForExecution } from '../../services/commandQueue';
 import { writeRouteAudit } from '../../services/auditEvents';
 import { resolvePartnerIdForOrg } from '../patches/helpers';
+import { loadPatchCveAnnotations, type CveAnnotation } from '../../services/inventoryCveAnnotation';
 
 export const patchesRoutes = new Hono();
 
 patchesRoutes.use('*', authMiddleware);
 
 const installPatchesSchema = z.object({
-  patchIds: z.array(z.string().guid()).min(1)
+  patchIds: z.array(z.string().guid()).min(1),
+  // Take a restore point / filesystem snapshot first. Omitted → the agent's
+  // patch_snapshot_before_install config decides.
+  createSnapshot: z.boolean().optional()
+});
+
+const stagePatchesSchema = z.object({
+  patchIds: z.array(z.string().guid()).min(1),
+  // KiB/s cap for the download. Omitted → the agent's
+  // patch_stage_bandwidth_limit_kbps config; 0 → unlimited.
+  bandwidthLimitKbps: z.number().int().min(0).max(10_000_000).optional(),
+  // Queue the install for the device's next patch maintenance window.
+  installInWindow: z.boolean().default(true)
 });
 
 const rollbackPatchParamsSchema = z.object({
@@ -32,7 +45,7 @@ const patchHistoryQuerySchema = z.object({
   completedAfter: z.string().datetime({ offset: true }).optional()
 });
 
-const PATCH_COMMAND_TYPES = ['install_patches', 'patch_scan', 'rollback_patches', 'download_patches'] as const;
+const PATCH_COMMAND_TYPES = ['install_patches', 'patch_scan', 'rollback_patches', 'download_patches', 'patch_stage'] as const;
 const LINUX_SOFTWARE_UPDATE_COMMAND_TYPE = 'software_update';
 
 const TYPE_FILTER_MAP: Record<string, string[]> = {
@@ -322,7 +335,8 @@ patchesRoutes.get(
         source: patches.source,
         packageId: patches.packageId,
         releaseDate: patches.releaseDate,
-        requiresReboot: patches.requiresReboot
+        requiresReboot: patches.requiresReboot,
+        cveIds: patches.cveIds
       })
       .from(devicePatches)
       .innerJoin(patches, eq(devicePatches.patchId, patches.id))
@@ -355,6 +369,25 @@ patchesRoutes.get(
       ? await getApprovedPatchIdsForPartner(partnerId, patchIds)
       : new Set<string>();
 
+    // CVE ids and CVSS scores are prioritization hints; a catalog lookup
+    // failure serves the patch list without them.
+    let cveAnnotations = new Map<string, CveAnnotation>();
+    try {
+      cveAnnotations = await loadPatchCveAnnotations(
+        devicePatchList.filter((p) => p.status === 'pending' || p.status === 'missing')
+      );
+    } catch (err) {
+      console.error(`[DevicePatches] CVE annotation failed for device ${deviceId}:`, err);
+    }
+    const cveFields = (patchId: string) => {
+      const cve = cveAnnotations.get(patchId);
+      return {
+        cveIds: cve?.cveIds ?? [],
+        cvssScore: cve?.cvssScore ?? null,
+        knownExploited: cve?.knownExploited ?? false
+      };
+    };
+
     // Separate actionable pending updates from stale missing records.
     const pending = devicePatchList
       .filter(p => p.status === 'pending')
@@ -371,7 +404,8 @@ patchesRoutes.get(
         category: p.category,
         source: p.source,
         requiresReboot: p.requiresReboot,
-        approvalStatus: approvedPatchIds.has(p.patchId) ? 'approved' : 'pending'
+        approvalStatus: approvedPatchIds.has(p.patchId) ? 'approved' : 'pending',
+        ...cveFields(p.patchId)
       }));
 
     const missing = devicePatchList
@@ -389,7 +423,8 @@ patchesRoutes.get(
         category: p.category,
         source: p.source,
         requiresReboot: p.requiresReboot,
-        approvalStatus: approvedPatchIds.has(p.patchId) ? 'approved' : 'pending'
+        approvalStatus: approvedPatchIds.has(p.patchId) ? 'approved' : 'pending',
+        ...cveFields(p.patchId)
       }));
 
     const installed = devicePatchList
@@ -519,7 +554,8 @@ patchesRoutes.post(
       'install_patches',
       {
         patchIds: data.patchIds,
-        patches: patchRefs
+        patches: patchRefs,
+        ...(data.createSnapshot !== undefined ? { createSnapshot: data.createSnapshot } : {})
       },
       {
         userId: auth.user.id,
@@ -559,6 +595,108 @@ patchesRoutes.post(
   }
 );
 
+// POST /devices/:id/patches/stage - Queue a download of approved patches now and
+// their install in the device's next maintenance window
+patchesRoutes.post(
+  '/:id/patches/stage',
+  requireScope('organization', 'partner', 'system'),
+  requirePermission(PERMISSIONS.DEVICES_EXECUTE.resource, PERMISSIONS.DEVICES_EXECUTE.action),
+  requireMfa(),
+  zValidator('json', stagePatchesSchema),
+  async (c) => {
+    const auth = c.get('auth');
+    const deviceId = c.req.param('id')!;
+    const data = c.req.valid('json');
+
+    const device = await getDeviceWithOrgAndSiteCheck(c, deviceId, auth);
+    if (device === SITE_ACCESS_DENIED) {
+      return c.json({ error: 'Access to this site denied' }, 403);
+    }
+    if (!device) {
+      return c.json({ error: 'Device not found' }, 404);
+    }
+
+    const patchRefs = await db
+      .select({
+        id: patches.id,
+        source: patches.source,
+        externalId: patches.externalId,
+        packageId: patches.packageId,
+        title: patches.title
+      })
+      .from(patches)
+      .where(inArray(patches.id, data.patchIds));
+
+    const foundPatchIds = new Set(patchRefs.map((patch) => patch.id));
+    const missingPatchIds = data.patchIds.filter((patchId) => !foundPatchIds.has(patchId));
+    if (missingPatchIds.length > 0) {
+      return c.json({
+        error: 'Some patches were not found',
+        missingPatchIds
+      }, 404);
+    }
+
+    // Staging queues the install too, so it is held to the same approval gate.
+    const partnerId = await resolvePartnerIdForOrg(device.orgId);
+    const approvedPatchIds = partnerId
+      ? await getApprovedPatchIdsForPartner(partnerId, data.patchIds)
+      : new Set<string>();
+    const unapprovedPatchIds = data.patchIds.filter((patchId) => !approvedPatchIds.has(patchId));
+    if (unapprovedPatchIds.length > 0) {
+      return c.json({
+        error: 'Only approved patches can be staged',
+        unapprovedPatchIds
+      }, 409);
+    }
+
+    const queued = await queueCommandForExecution(
+      deviceId,
+      'patch_stage',
+      {
+        patchIds: data.patchIds,
+        patches: patchRefs,
+        installInWindow: data.installInWindow,
+        ...(data.bandwidthLimitKbps !== undefined ? { bandwidthLimitKbps: data.bandwidthLimitKbps } : {})
+      },
+      {
+        userId: auth.user.id,
+        preferHeartbeat: false
+      }
+    );
+
+    if (!queued.command) {
+      return c.json({ error: queued.error || 'Failed to queue patch_stage command' }, 503);
+    }
+
+    const command = queued.command;
+    const patchNames = patchRefs.map(p => p.title).filter(Boolean);
+
+    writeRouteAudit(c, {
+      orgId: device.orgId,
+      action: 'device.patch.stage.queue',
+      resourceType: 'device',
+      resourceId: deviceId,
+      resourceName: device.hostname,
+      details: {
+        commandId: command.id,
+        commandStatus: command.status,
+        patchCount: data.patchIds.length,
+        patchNames,
+        installInWindow: data.installInWindow,
+        bandwidthLimitKbps: data.bandwidthLimitKbps ?? null
+      }
+    });
+
+    return c.json({
+      success: true,
+      commandId: command.id,
+      commandStatus: command.status,
+      patchCount: data.patchIds.length,
+      patchNames
+    });
+  }
+);
+
 // POST /devices/:id/patches/:patchId/rollback - Queue patch rollback command for a device
 patchesRoutes.post(
   '/:id/patches/:patchId/rollback',
@@ -145,13 +145,89 @@ export const processSamplesQuerySchema = z.object({
   message: 'Provide either ?at=<ts> or both ?from and ?to'
 });
 
+export const softwareUsageQuerySchema = z.object({
+  days: z.coerce.number().int().min(1).max(365).default(30)
+});
+
+const deviceCommandTypeSchema = z.enum([
+  'script', 'reboot', 'reboot_safe_mode', 'shutdown', 'sleep', 'hibernate', 'cancel_reboot', 'update',
+  'collect_evidence', 'execute_containment', 'wake', 'refresh_inventory',
+  'firewall_add_rule', 'firewall_remove_rule', 'firewall_set_profile',
+  'isolate_host', 'release_isolation',
+  'local_admin_remove', 'local_admin_disable', 'local_admin_rotate_password',
+  'rmm_cleanup', 'transcript_export'
+]);
+
+const firewallRuleNameSchema = z.string().trim().regex(/^[A-Za-z0-9][A-Za-z0-9 _.-]{0,63}$/, 'Use up to 64 letters, digits, spaces, dots, dashes or underscores');
+
+// Local account names as Windows, macOS and Linux accept them; Windows
+// administrators group members may be DOMAIN\name. The agent applies its
+// OS's stricter rules.
+const localAccountNameSchema = z.string().trim().min(1).max(104)
+  .regex(/^[^"\/[\]:;|=,+*?<>@\x00-\x1f]+$/, 'Not a valid account name');
+
+// Firewall, isolation, local account, RMM cleanup and transcript export
+// commands are usually sent to many devices at once, so their payloads are
+// checked here rather than failing on every agent. The agent validates again
+// (e.g. that a remote address parses).
+export const commandPayloadSchemas = {
+  firewall_add_rule: z.object({
+    name: firewallRuleNameSchema,
+    direction: z.enum(['inbound', 'outbound']).default('inbound'),
+    action: z.enum(['allow', 'block']).default('block'),
+    protocol: z.enum(['tcp', 'udp', 'any']).default('any'),
+    port: z.string().trim().regex(/^\d{1,5}(-\d{1,5})?$/, 'Expected a port or a range like 8000-8100').optional(),
+    remoteAddress: z.string().trim().min(1).max(64).optional(),
+    profile: z.enum(['any', 'domain', 'private', 'public']).optional()
+  }).strict()
+    .refine((rule) => rule.port || rule.remoteAddress, { message: 'A rule needs a port or a remote address' })
+    .refine((rule) => !rule.port || rule.protocol !== 'any', { message: 'A port rule needs protocol tcp or udp', path: ['protocol'] }),
+  firewall_remove_rule: z.object({
+    id: z.string().trim().min(1).max(1024).optional(),
+    name: firewallRuleNameSchema.optional()
+  }).strict().refine((ref) => Boolean(ref.id) !== Boolean(ref.name), { message: 'Provide exactly one of id or name' }),
+  firewall_set_profile: z.object({
+    profile: z.enum(['domain', 'private', 'public', 'alf', 'pf', 'ufw', 'firewalld']),
+    enabled: z.boolean()
+  }).strict(),
+  // The agent derives the allow list from its own server URL; nothing in
+  // the payload may widen it.
+  isolate_host: z.object({}).strict(),
+  release_isolation: z.object({}).strict(),
+  local_admin_remove: z.object({ username: localAccountNameSchema }).strict(),
+  local_admin_disable: z.object({ username: localAccountNameSchema }).strict(),
+  // The agent generates the password and escrows it; none is ever sent.
+  local_admin_rotate_password: z.object({ username: localAccountNameSchema }).strict(),
+  // Tool names as the management posture reports them (e.g. "ScreenConnect").
+  rmm_cleanup: z.object({
+    tools: z.array(z.string().trim().min(1).max(64)).min(1).max(20),
+    dryRun: z.boolean().optional()
+  }).strict(),
+  // RFC 3339 bounds; the agent defaults to the 30 days before now.
+  transcript_export: z.object({
+    from: z.string().datetime({ offset: true }).optional(),
+    to: z.string().datetime({ offset: true }).optional()
+  }).strict().refine((p) => !p.from || !p.to || Date.parse(p.from) < Date.parse(p.to), { message: 'from must be before to', path: ['from'] })
+} as const;
+
+function refineCommandPayload(data: { type: string; payload?: unknown }, ctx: z.RefinementCtx) {
+  const schema = commandPayloadSchemas[data.type as keyof typeof commandPayloadSchemas];
+  if (!schema) return;
+  const result = schema.safeParse(data.payload ?? {});
+  if (!result.success) {
+    for (const issue of result.error.issues) {
+      ctx.addIssue({ code: 'custom', message: issue.message, path: ['payload', ...issue.path] });
+    }
+  }
+}
+
 export const createCommandSchema = z.object({
   // 'wake' is the user-facing wake action. Internally it dispatches via the
   // wakeOnLan service and writes a deviceCommands row of type 'wake_on_lan'
   // addressed to a relay agent. See apps/api/src/services/wakeOnLan.ts.
-  type: z.enum(['script', 'reboot', 'reboot_safe_mode', 'shutdown', 'update', 'collect_evidence', 'execute_containment', 'wake', 'refresh_inventory']),
+  type: deviceCommandTypeSchema,
   payload: z.any().optional()
-});
+}).superRefine(refineCommandPayload);
 
 /**
  * Per-request cap on bulk command operations. 500 keeps the worst-case
@@ -164,9 +240,9 @@ export const BULK_COMMAND_MAX_DEVICES = 500;
 
 export const bulkCommandSchema = z.object({
   deviceIds: z.array(z.string().guid()).min(1).max(BULK_COMMAND_MAX_DEVICES),
-  type: z.enum(['script', 'reboot', 'reboot_safe_mode', 'shutdown', 'update', 'collect_evidence', 'execute_containment', 'wake', 'refresh_inventory']),
+  type: deviceCommandTypeSchema,
   payload: z.any().optional()
-});
+}).superRefine(refineCommandPayload);
 
 export const maintenanceModeSchema = z.object({
   enable: z.boolean(),
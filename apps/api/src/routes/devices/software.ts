@@ -10,6 +10,7 @@ import { captureException } from '../../services/sentry';
 import { getPagination, getDeviceWithOrgAndSiteCheck, SITE_ACCESS_DENIED } from './helpers';
 import { softwareQuerySchema } from './schemas';
 import { buildUpdateIndex, annotateSoftwareRow } from './softwareUpdateMatch';
+import { loadSoftwareCveAnnotations } from '../../services/inventoryCveAnnotation';
 
 export const softwareRoutes = new Hono();
 
@@ -110,6 +111,27 @@ softwareRoutes.get(
       );
     }
 
+    // Best-effort, like the update annotation: attach the open CVE findings the
+    // vulnerability correlation linked to each row.
+    try {
+      const cves = await loadSoftwareCveAnnotations(deviceId);
+      data = data.map((row) => {
+        const cve = cves.get(row.id as string);
+        return {
+          ...row,
+          cveIds: cve?.cveIds ?? [],
+          cvssScore: cve?.cvssScore ?? null,
+          knownExploited: cve?.knownExploited ?? false,
+        };
+      });
+    } catch (err) {
+      captureException(err, c);
+      console.error(
+        `[Software] CVE annotation failed for device ${deviceId}; serving list without CVEs:`,
+        err
+      );
+    }
+
     return c.json({
       data,
       thirdPartyUpdatesManaged,
@@ -0,0 +1,32 @@
+import { Hono } from 'hono';
+import { zValidator } from '../../lib/validation';
+import { authMiddleware, requirePermission, requireScope } from '../../middleware/auth';
+import { PERMISSIONS } from '../../services/permissions';
+import { listSoftwareUsage } from '../../services/deviceSoftwareUsage';
+import { getDeviceWithOrgAndSiteCheck, SITE_ACCESS_DENIED } from './helpers';
+import { softwareUsageQuerySchema } from './schemas';
+
+export const softwareUsageRoutes = new Hono();
+
+softwareUsageRoutes.use('*', authMiddleware);
+
+// GET /devices/:id/software-usage?days=30 - Daily foreground time per user
+// and application, newest day first
+softwareUsageRoutes.get(
+  '/:id/software-usage',
+  requireScope('organization', 'partner', 'system'),
+  requirePermission(PERMISSIONS.DEVICES_READ.resource, PERMISSIONS.DEVICES_READ.action),
+  zValidator('query', softwareUsageQuerySchema),
+  async (c) => {
+    const auth = c.get('auth');
+    const deviceId = c.req.param('id');
+    const { days } = c.req.valid('query');
+
+    const device = await getDeviceWithOrgAndSiteCheck(c, deviceId, auth);
+    if (device === SITE_ACCESS_DENIED) return c.json({ error: 'Access to this site denied' }, 403);
+    if (!device) return c.json({ error: 'Device not found' }, 404);
+
+    const since = new Date(Date.now() - days * 24 * 60 * 60 * 1000).toISOString().slice(0, 10);
+    return c.json({ since, usage: await listSoftwareUsage(deviceId, since) });
+  }
+);
@@ -0,0 +1,41 @@
+import { Hono } from 'hono';
+import { readFile } from 'fs/promises';
+import { authMiddleware, requirePermission, requireScope } from '../../middleware/auth';
+import { PERMISSIONS } from '../../services/permissions';
+import { getTranscript } from '../../services/commandTranscripts';
+import { getDeviceWithOrgAndSiteCheck, SITE_ACCESS_DENIED } from './helpers';
+
+export const transcriptRoutes = new Hono();
+
+transcriptRoutes.use('*', authMiddleware);
+
+// GET /devices/:id/transcripts/:commandId - The signed archive uploaded for a
+// transcript_export command
+transcriptRoutes.get(
+  '/:id/transcripts/:commandId',
+  requireScope('organization', 'partner', 'system'),
+  requirePermission(PERMISSIONS.DEVICES_READ.resource, PERMISSIONS.DEVICES_READ.action),
+  async (c) => {
+    const auth = c.get('auth');
+    const deviceId = c.req.param('id');
+    const commandId = c.req.param('commandId');
+
+    const device = await getDeviceWithOrgAndSiteCheck(c, deviceId, auth);
+    if (device === SITE_ACCESS_DENIED) return c.json({ error: 'Access to this site denied' }, 403);
+    if (!device) return c.json({ error: 'Device not found' }, 404);
+
+    const transcript = await getTranscript(deviceId, commandId);
+    if (!transcript) return c.json({ error: 'Transcript not found' }, 404);
+
+    const archive = await readFile(transcript.storagePath);
+    return new Response(archive, {
+      status: 200,
+      headers: {
+        'Content-Type': 'application/gzip',
+        'Content-Disposition': `attachment; filename="transcript-${commandId}.tar.gz"`,
+        'Content-Length': String(archive.length),
+        'X-Archive-Sha256': transcript.archiveSha256,
+      },
+    });
+  }
+);
@@ -85,6 +85,8 @@ export const ALLOWED_STATUS_TRANSITIONS: Record<IncidentStatus, IncidentStatus[]
 
 export const HIGH_RISK_CONTAINMENT_ACTIONS = new Set([
   'network_isolation',
+  // Reconnects a host that may still be infected.
+  'network_isolation_release',
   'account_disable',
   'usb_block',
   'process_kill',
@@ -130,6 +130,9 @@ function canonicalizePolicyPatchSettings(
       ...normalizedMaterial.data,
       autoApproveDeferralDays: canonicalMirror.autoApproveDeferralDays,
       apps: canonicalMirror.apps,
+      complianceMaxAgeDays: canonicalMirror.complianceMaxAgeDays,
+      rebootMaxDeferrals: canonicalMirror.rebootMaxDeferrals,
+      appUpdatePolicies: canonicalMirror.appUpdatePolicies,
     });
     features.push({ ...feature, settings });
   }
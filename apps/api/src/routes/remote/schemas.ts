@@ -1,9 +1,56 @@
 import { z } from 'zod';
 
+// Terminal launch options, forwarded to the agent in terminal_start. The
+// limits mirror the agent's own checks (internal/terminal/shell_options.go)
+// so a bad request is a 400 here rather than an agent error after connect.
+export const TERMINAL_SHELLS = ['pwsh', 'powershell', 'cmd', 'bash', 'zsh', 'sh'] as const;
+const TERMINAL_ENV_MAX_VARS = 64;
+const TERMINAL_ENV_MAX_BYTES = 32 * 1024;
+
+export const terminalOptionsSchema = z.object({
+  shell: z.enum(TERMINAL_SHELLS).optional(),
+  // Security context: system (SYSTEM/root, the default), the logged-in user
+  // via the session broker, or a named local account with its password.
+  mode: z.enum(['system', 'user', 'named_user']).optional(),
+  username: z.string().trim().min(1).max(256).optional(),
+  password: z.string().max(256).optional(),
+  // POSIX (/...), drive (C:\...) or UNC (\\server\...) absolute path.
+  cwd: z.string().max(1024).regex(/^(\/|[A-Za-z]:[\\/]|\\\\)/, 'cwd must be an absolute path').optional(),
+  env: z.record(
+    z.string().regex(/^[A-Za-z_][A-Za-z0-9_]*$/, 'Invalid environment variable name'),
+    z.string().max(8 * 1024).refine((v) => !v.includes('\0'), 'Environment values cannot contain NUL')
+  )
+    .refine((env) => Object.keys(env).length <= TERMINAL_ENV_MAX_VARS, `At most ${TERMINAL_ENV_MAX_VARS} environment variables`)
+    .refine(
+      (env) => Object.entries(env).reduce((n, [k, v]) => n + k.length + v.length + 1, 0) <= TERMINAL_ENV_MAX_BYTES,
+      'Environment variables too large'
+    )
+    .optional()
+}).superRefine((opts, ctx) => {
+  if (opts.mode === 'named_user' && !opts.username) {
+    ctx.addIssue({ code: 'custom', message: 'username is required for named_user', path: ['username'] });
+  }
+  if (opts.mode !== 'named_user' && (opts.username !== undefined || opts.password !== undefined)) {
+    ctx.addIssue({ code: 'custom', message: 'username and password are only valid for named_user', path: ['mode'] });
+  }
+});
+
+export type TerminalOptions = z.infer<typeof terminalOptionsSchema>;
+
+/** Whether the agent can launch shell by name on a device of osType. */
+export function isTerminalShellAvailable(shell: (typeof TERMINAL_SHELLS)[number], osType: string): boolean {
+  if (osType === 'windows') return shell !== 'zsh' && shell !== 'sh';
+  return shell !== 'powershell' && shell !== 'cmd';
+}
+
 // Session schemas
 export const createSessionSchema = z.object({
   deviceId: z.string().guid(),
-  type: z.enum(['terminal', 'desktop', 'file_transfer'])
+  type: z.enum(['terminal', 'desktop', 'file_transfer']),
+  terminal: terminalOptionsSchema.optional()
+}).refine((data) => data.type === 'terminal' || data.terminal === undefined, {
+  message: 'terminal options are only valid for terminal sessions',
+  path: ['terminal']
 });
 
 export const listSessionsSchema = z.object({
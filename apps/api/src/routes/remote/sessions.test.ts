@@ -159,6 +159,14 @@ vi.mock('../../services/remoteAccessPolicy', () => ({
 
 vi.mock('../agentWs', () => ({ sendCommandToAgent }));
 
+vi.mock('../../services/terminalDetach', () => ({
+  isTerminalDetached: vi.fn().mockResolvedValue(false),
+}));
+
+vi.mock('../../services/secretCrypto', () => ({
+  encryptSecret: vi.fn((value: string | null | undefined) => (value ? `enc:${value}` : null)),
+}));
+
 vi.mock('../../services/remoteSessionAuth', () => ({
   createDesktopConnectCode: vi.fn(),
   createWsTicket: vi.fn(),
@@ -677,6 +685,117 @@ describe('remote sessions — site-scope enforcement', () => {
     });
   });
 
+  describe('POST /sessions — terminal options', () => {
+    function rigTerminalCreate(osType = 'linux') {
+      vi.mocked(db.update).mockReturnValueOnce({
+        set: vi.fn().mockReturnValue({ where: vi.fn().mockReturnValue({ returning: vi.fn().mockResolvedValue([]) }) }),
+      } as never);
+      const values = vi.fn().mockReturnValue({
+        returning: vi.fn().mockResolvedValue([
+          {
+            id: SESSION_ID,
+            deviceId: DEVICE_IN_ALLOWED,
+            userId: 'user-1',
+            type: 'terminal',
+            status: 'pending',
+            createdAt: new Date('2026-01-01T00:00:00Z'),
+          },
+        ]),
+      });
+      (db as any).insert = vi.fn().mockReturnValue({ values });
+      getDeviceWithOrgCheck.mockResolvedValue({
+        id: DEVICE_IN_ALLOWED,
+        orgId: ORG_ID,
+        siteId: ALLOWED_SITE,
+        agentId: 'agent-1',
+        hostname: 'host-1',
+        osType,
+        status: 'online',
+      });
+      return { values };
+    }
+
+    const createTerminal = (terminal: unknown, type = 'terminal') =>
+      app.request('/remote/sessions', {
+        method: 'POST',
+        headers: { Authorization: 'Bearer t', 'Content-Type': 'application/json' },
+        body: JSON.stringify({ deviceId: DEVICE_IN_ALLOWED, type, terminal }),
+      });
+
+    it('stores the requested shell, cwd and env on the session', async () => {
+      const { values } = rigTerminalCreate();
+      const terminal = { shell: 'zsh', cwd: '/srv/app', env: { APP_ENV: 'staging' } };
+
+      const res = await createTerminal(terminal);
+
+      expect(res.status).toBe(201);
+      expect(values).toHaveBeenCalledWith(expect.objectContaining({ type: 'terminal', terminalOptions: terminal }));
+    });
+
+    it('rejects a shell the device OS does not have', async () => {
+      const { values } = rigTerminalCreate();
+
+      const res = await createTerminal({ shell: 'cmd' });
+
+      expect(res.status).toBe(400);
+      expect(values).not.toHaveBeenCalled();
+    });
+
+    it.each([
+      ['a relative cwd', { cwd: 'srv/app' }],
+      ['an invalid env name', { env: { 'BAD-NAME': 'x' } }],
+      ['an unknown shell', { shell: 'fish' }],
+    ])('rejects %s', async (_label, terminal) => {
+      rigTerminalCreate();
+      const res = await createTerminal(terminal);
+      expect(res.status).toBe(400);
+    });
+
+    it('stores a named_user password encrypted and apart from the options', async () => {
+      const { values } = rigTerminalCreate('windows');
+
+      const res = await createTerminal({ mode: 'named_user', username: 'svc-deploy', password: 'hunter2' });
+
+      expect(res.status).toBe(201);
+      expect(values).toHaveBeenCalledWith(expect.objectContaining({
+        terminalOptions: { mode: 'named_user', username: 'svc-deploy' },
+        terminalPassword: 'enc:hunter2',
+      }));
+    });
+
+    it('accepts named_user without a password on macOS/Linux and rejects one', async () => {
+      let { values } = rigTerminalCreate();
+      let res = await createTerminal({ mode: 'named_user', username: 'deploy' });
+      expect(res.status).toBe(201);
+      expect(values).toHaveBeenCalledWith(expect.objectContaining({
+        terminalOptions: { mode: 'named_user', username: 'deploy' },
+        terminalPassword: null,
+      }));
+
+      ({ values } = rigTerminalCreate());
+      res = await createTerminal({ mode: 'named_user', username: 'deploy', password: 'hunter2' });
+      expect(res.status).toBe(400);
+      expect(values).not.toHaveBeenCalled();
+    });
+
+    it.each([
+      ['named_user without a username', { mode: 'named_user' }],
+      ['a username outside named_user', { mode: 'system', username: 'svc-deploy' }],
+      ['a password without a mode', { password: 'hunter2' }],
+    ])('rejects %s', async (_label, terminal) => {
+      const { values } = rigTerminalCreate();
+      const res = await createTerminal(terminal);
+      expect(res.status).toBe(400);
+      expect(values).not.toHaveBeenCalled();
+    });
+
+    it('rejects terminal options on a desktop session', async () => {
+      rigTerminalCreate();
+      const res = await createTerminal({ shell: 'bash' }, 'desktop');
+      expect(res.status).toBe(400);
+    });
+  });
+
   describe('GET /sessions/:id', () => {
     it('returns 403 when caller is site-restricted away from the session device site', async () => {
       getSessionWithOrgCheck.mockResolvedValue({
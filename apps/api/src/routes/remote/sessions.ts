@@ -15,9 +15,12 @@ import { requireScope, requirePermission } from '../../middleware/auth';
 import { sendCommandToAgent } from '../agentWs';
 import { checkRemoteAccess, resolveDesktopSessionPolicy } from '../../services/remoteAccessPolicy';
 import { createDesktopConnectCode, createWsTicket } from '../../services/remoteSessionAuth';
+import { isTerminalDetached } from '../../services/terminalDetach';
+import { encryptSecret } from '../../services/secretCrypto';
 import { getTrustedClientIp, getTrustedClientIpOrUndefined } from '../../services/clientIp';
 import {
   createSessionSchema,
+  isTerminalShellAvailable,
   listSessionsSchema,
   sessionHistorySchema,
   webrtcOfferSchema,
@@ -174,6 +177,17 @@ sessionRoutes.post(
       return c.json({ error: 'Device is not online', deviceStatus: device.status }, 400);
     }
 
+    const shell = data.terminal?.shell;
+    if (shell && !isTerminalShellAvailable(shell, device.osType)) {
+      return c.json({ error: `Shell ${shell} is not available on ${device.osType} devices` }, 400);
+    }
+    // Only Windows logs a named_user on with its password. macOS/Linux agents
+    // run as root and switch to the account without authenticating, so a
+    // password there would be accepted but never checked.
+    if (data.terminal?.password !== undefined && device.osType !== 'windows') {
+      return c.json({ error: `named_user passwords are not supported on ${device.osType} devices; omit the password` }, 400);
+    }
+
     // Remote access policy enforcement
     const capability = data.type === 'desktop' ? 'webrtcDesktop' as const
       : 'remoteTools' as const; // terminal + file_transfer are both remote tools
@@ -248,6 +262,10 @@ sessionRoutes.post(
       console.error('[remote] Failed to terminate stale sessions for device', data.deviceId, err);
     }
 
+    // The named_user password is kept apart from the other terminal options,
+    // encrypted, and cleared by terminalWs once terminal_start carries it.
+    const { password: terminalPassword, ...terminalOptions } = data.terminal ?? {};
+
     // Create session
     const [session] = await db
       .insert(remoteSessions)
@@ -257,7 +275,9 @@ sessionRoutes.post(
         userId: auth.user.id,
         type: data.type,
         status: 'pending',
-        iceCandidates: []
+        iceCandidates: [],
+        terminalOptions: data.terminal ? terminalOptions : null,
+        terminalPassword: encryptSecret(terminalPassword, { aad: 'remote_sessions.terminal_password' })
       })
       .returning();
 
@@ -274,7 +294,18 @@ sessionRoutes.post(
         sessionId: session.id,
         deviceId: data.deviceId,
         deviceHostname: device.hostname,
-        type: data.type
+        type: data.type,
+        // Environment values can carry secrets; only their names are audited,
+        // and the named_user password never is.
+        ...(data.terminal
+          ? {
+            shell: data.terminal.shell,
+            cwd: data.terminal.cwd,
+            envNames: data.terminal.env ? Object.keys(data.terminal.env) : undefined,
+            terminalMode: data.terminal.mode ?? 'system',
+            terminalUser: data.terminal.username
+          }
+          : {})
       },
       getTrustedClientIpOrUndefined(c)
     );
@@ -685,7 +716,11 @@ sessionRoutes.post(
       return c.json({ error: 'WebSocket ticket only supported for terminal or desktop sessions' }, 400);
     }
 
-    if (!['pending', 'connecting', 'active'].includes(session.status)) {
+    // A terminal whose viewer dropped stays reattachable while the agent
+    // holds its detached PTY.
+    const reattachable = session.type === 'terminal' && session.status === 'disconnected' &&
+      (await isTerminalDetached(session.id));
+    if (!reattachable && !['pending', 'connecting', 'active'].includes(session.status)) {
       return c.json({
         error: 'Cannot mint WebSocket ticket for session in current state',
         status: session.status
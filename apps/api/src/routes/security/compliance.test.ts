@@ -15,7 +15,7 @@ vi.mock('../../db', async () => {
 // deviceRecoveryKeys is swapped for a minimal column stub.
 vi.mock('../../db/schema', async () => {
   const actual = await vi.importActual<any>('../../db/schema');
-  return { ...actual, deviceRecoveryKeys: { deviceId: 'drk.deviceId', status: 'drk.status' } };
+  return { ...actual, deviceRecoveryKeys: { deviceId: 'drk.deviceId', status: 'drk.status', keyType: 'drk.keyType' } };
 });
 
 vi.mock('../../middleware/auth', async () => {
@@ -48,6 +48,8 @@ function statusRow(overrides: Record<string, unknown>) {
     definitionsDate: null, realTimeProtection: true, threatCount: 0,
     firewallEnabled: true, encryptionStatus: 'encrypted', encryptionDetails: null,
     localAdminSummary: null, passwordPolicySummary: null, gatekeeperEnabled: null,
+    tpmPresent: null, tpmVersion: null, tpmOwned: null, secureBootEnabled: null,
+    measuredBootAvailable: null, agentScore: null, agentRiskLevel: null, agentScoreFactors: null,
     lastScan: null, lastScanType: null,
     ...overrides,
   };
@@ -120,4 +122,19 @@ describe('GET /encryption', () => {
     expect(byName['fallback'].volumes[0].status).toBeNull();
     expect(byName['fallback'].volumes[0]).not.toHaveProperty('size');
   });
+
+  it('reports the agent-detected TPM instead of guessing from the OS', async () => {
+    listStatusRowsMock.mockResolvedValue([
+      statusRow({ deviceId: DEV_ESCROWED, deviceName: 'no-tpm', tpmPresent: false }),
+      statusRow({ deviceId: DEV_BARE, deviceName: 'linux-tpm', os: 'linux', tpmPresent: true }),
+      statusRow({ deviceId: '44444444-4444-4444-8444-444444444444', deviceName: 'old-agent' }),
+    ]);
+    mockEscrowRows([]);
+    const res = await buildApp().request('/encryption');
+    const json = await res.json();
+    const byName = Object.fromEntries(json.data.map((d: any) => [d.deviceName, d]));
+    expect(byName['no-tpm'].tpmPresent).toBe(false);
+    expect(byName['linux-tpm'].tpmPresent).toBe(true);
+    expect(byName['old-agent'].tpmPresent).toBeNull();
+  });
 });
@@ -24,6 +24,8 @@ import {
   parseEncryptionVolumes
 } from './helpers';
 
+const DISK_RECOVERY_KEY_TYPES = ['bitlocker_recovery_password', 'filevault_personal_recovery_key', 'luks_recovery_passphrase'];
+
 export const complianceRoutes = new Hono();
 
 complianceRoutes.get(
@@ -131,7 +133,8 @@ complianceRoutes.get(
     const rows = await listStatusRows(auth, query.orgId);
     const statuses = rows.map(toStatusResponse);
 
-    // Real escrow status: devices with at least one active escrowed key.
+    // Real escrow status: devices with at least one active escrowed disk
+    // recovery key (escrowed local admin passwords don't count).
     // Constrained to the org-scoped device set already resolved by
     // listStatusRows so the enrichment never touches a device outside the
     // caller's accessible orgs (and avoids an unbounded cross-tenant scan).
@@ -143,6 +146,7 @@ complianceRoutes.get(
           .where(
             and(
               eq(deviceRecoveryKeys.status, 'active'),
+              inArray(deviceRecoveryKeys.keyType, DISK_RECOVERY_KEY_TYPES),
               inArray(deviceRecoveryKeys.deviceId, deviceIds)
             )
           )
@@ -174,7 +178,7 @@ complianceRoutes.get(
         encryptionMethod: method,
         encryptionStatus: encStatus,
         volumes: parseEncryptionVolumes(row.encryptionDetails) ?? [fallbackVolume],
-        tpmPresent: status.os === 'windows',
+        tpmPresent: status.tpmPresent,
         recoveryKeyEscrowed: escrowedDeviceIds.has(status.deviceId)
       };
     });
@@ -0,0 +1,190 @@
+import { beforeEach, describe, expect, it, vi } from 'vitest';
+import { Hono } from 'hono';
+
+vi.mock('../../db', () => ({
+  db: { select: vi.fn() },
+}));
+
+vi.mock('../../db/schema', () => ({
+  devices: { id: 'devices.id', orgId: 'devices.orgId', siteId: 'devices.siteId', hostname: 'devices.hostname', osType: 'devices.osType' },
+}));
+
+const { getUserPermissionsMock, writeRouteAuditMock, executeCommandMock } = vi.hoisted(() => ({
+  getUserPermissionsMock: vi.fn(),
+  writeRouteAuditMock: vi.fn(),
+  executeCommandMock: vi.fn(),
+}));
+
+vi.mock('../../services/permissions', async () => {
+  const actual = await vi.importActual<any>('../../services/permissions');
+  return { ...actual, getUserPermissions: getUserPermissionsMock };
+});
+vi.mock('../../middleware/auth', async () => {
+  const actual = await vi.importActual<any>('../../middleware/auth');
+  return { ...actual, requireScope: vi.fn(() => async (_c: any, next: any) => next()) };
+});
+vi.mock('../../services/auditEvents', () => ({ writeRouteAudit: writeRouteAuditMock }));
+vi.mock('../../services/commandQueue', () => ({
+  CommandTypes: {
+    DEFENDER_GET_PREFERENCES: 'defender_get_preferences',
+    DEFENDER_SET_PREFERENCES: 'defender_set_preferences',
+  },
+  executeCommand: executeCommandMock,
+}));
+
+import { db } from '../../db';
+import { defenderRoutes } from './defender';
+
+const ORG_ID = '11111111-1111-1111-1111-111111111111';
+const DEVICE_ID = '22222222-2222-2222-2222-222222222222';
+const ASR_RULE_ID = 'd4f940ab-401b-4efc-aadc-ad5f3c50688a';
+
+function buildApp(): Hono {
+  const app = new Hono();
+  app.use('*', async (c, next) => {
+    c.set('auth', {
+      scope: 'organization',
+      orgId: ORG_ID,
+      partnerId: null,
+      accessibleOrgIds: [ORG_ID],
+      user: { id: 'user-1', email: 'test@example.com', name: 'Test User' },
+      canAccessOrg: () => true,
+      orgCondition: () => undefined,
+    } as any);
+    await next();
+  });
+  app.route('/security', defenderRoutes);
+  return app;
+}
+
+function mockDeviceSelect(overrides: Partial<{ siteId: string | null; osType: string }> = {}) {
+  vi.mocked(db.select).mockReturnValueOnce({
+    from: vi.fn().mockReturnValue({
+      where: vi.fn().mockReturnValue({
+        limit: vi.fn().mockResolvedValue([{
+          id: DEVICE_ID,
+          hostname: 'test-host',
+          orgId: ORG_ID,
+          siteId: overrides.siteId ?? null,
+          osType: overrides.osType ?? 'windows',
+        }]),
+      }),
+    }),
+  } as any);
+}
+
+function grant(...actions: string[]) {
+  getUserPermissionsMock.mockResolvedValue({
+    permissions: actions.map((action) => ({ resource: 'devices', action })),
+    allowedSiteIds: undefined,
+  });
+}
+
+function putPreferences(app: Hono, body: unknown) {
+  return app.request(`/security/defender/devices/${DEVICE_ID}/preferences`, {
+    method: 'PUT',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(body),
+  });
+}
+
+describe('GET /defender/devices/:deviceId/preferences', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  it('returns 403 without devices:read', async () => {
+    getUserPermissionsMock.mockResolvedValue(null);
+    const res = await buildApp().request(`/security/defender/devices/${DEVICE_ID}/preferences`);
+    expect(res.status).toBe(403);
+    expect(executeCommandMock).not.toHaveBeenCalled();
+  });
+
+  it('returns the preferences reported by the agent', async () => {
+    grant('read');
+    mockDeviceSelect();
+    const preferences = { exclusionPaths: ['C:\\Build'], asrRules: { [ASR_RULE_ID]: 'block' }, cloudBlockLevel: 'high', tamperProtection: 'enabled' };
+    executeCommandMock.mockResolvedValue({ status: 'completed', stdout: JSON.stringify(preferences) });
+
+    const res = await buildApp().request(`/security/defender/devices/${DEVICE_ID}/preferences`);
+
+    expect(res.status).toBe(200);
+    expect((await res.json()).data).toEqual(preferences);
+    expect(executeCommandMock).toHaveBeenCalledWith(DEVICE_ID, 'defender_get_preferences', {}, expect.objectContaining({ userId: 'user-1' }));
+  });
+
+  it('rejects non-Windows devices before dispatching a command', async () => {
+    grant('read');
+    mockDeviceSelect({ osType: 'macos' });
+    const res = await buildApp().request(`/security/defender/devices/${DEVICE_ID}/preferences`);
+    expect(res.status).toBe(400);
+    expect(executeCommandMock).not.toHaveBeenCalled();
+  });
+
+  it('returns 403 when the device site is outside the caller allowlist', async () => {
+    getUserPermissionsMock.mockResolvedValue({
+      permissions: [{ resource: 'devices', action: 'read' }],
+      allowedSiteIds: ['aaaaaaaa-aaaa-4aaa-8aaa-aaaaaaaaaaaa'],
+    });
+    mockDeviceSelect({ siteId: 'bbbbbbbb-bbbb-4bbb-8bbb-bbbbbbbbbbbb' });
+    const res = await buildApp().request(`/security/defender/devices/${DEVICE_ID}/preferences`);
+    expect(res.status).toBe(403);
+    expect(executeCommandMock).not.toHaveBeenCalled();
+  });
+});
+
+describe('PUT /defender/devices/:deviceId/preferences', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  it('returns 403 with only devices:read', async () => {
+    grant('read');
+    const res = await putPreferences(buildApp(), { cloudBlockLevel: 'high' });
+    expect(res.status).toBe(403);
+    expect(executeCommandMock).not.toHaveBeenCalled();
+  });
+
+  it('rejects tamper protection changes and empty bodies', async () => {
+    grant('execute');
+    const app = buildApp();
+    expect((await putPreferences(app, { tamperProtection: 'disabled' })).status).toBe(400);
+    expect((await putPreferences(app, { asrRules: {} })).status).toBe(400);
+    expect((await putPreferences(app, { asrRules: { 'not-a-guid': 'block' } })).status).toBe(400);
+    expect(executeCommandMock).not.toHaveBeenCalled();
+  });
+
+  it('applies the change and audits the before/after differences', async () => {
+    grant('execute');
+    mockDeviceSelect();
+    const changes = [{ setting: 'cloudBlockLevel', action: 'modified', before: 'default', after: 'high' }];
+    executeCommandMock.mockResolvedValue({
+      status: 'completed',
+      stdout: JSON.stringify({ before: {}, after: {}, changes, notApplied: [] }),
+    });
+
+    const body = { cloudBlockLevel: 'high', asrRules: { [ASR_RULE_ID]: 'audit' } };
+    const res = await putPreferences(buildApp(), body);
+
+    expect(res.status).toBe(200);
+    expect((await res.json()).data.changes).toEqual(changes);
+    expect(executeCommandMock).toHaveBeenCalledWith(DEVICE_ID, 'defender_set_preferences', body, expect.objectContaining({ userId: 'user-1' }));
+    const auditArg = writeRouteAuditMock.mock.calls[0]![1];
+    expect(auditArg.action).toBe('device.defender.preferences.update');
+    expect(auditArg.result).toBe('success');
+    expect(auditArg.details.changes).toEqual(changes);
+  });
+
+  it('audits a failed change and returns 502', async () => {
+    grant('execute');
+    mockDeviceSelect();
+    executeCommandMock.mockResolvedValue({ status: 'failed', error: 'Set-MpPreference: access denied' });
+
+    const res = await putPreferences(buildApp(), { mapsReporting: 'advanced' });
+
+    expect(res.status).toBe(502);
+    const auditArg = writeRouteAuditMock.mock.calls[0]![1];
+    expect(auditArg.result).toBe('failure');
+    expect(auditArg.details.error).toBe('Set-MpPreference: access denied');
+  });
+});
@@ -0,0 +1,142 @@
+import { Hono } from 'hono';
+import { zValidator } from '../../lib/validation';
+import { and, eq } from 'drizzle-orm';
+import type { Context } from 'hono';
+
+import { db } from '../../db';
+import { devices } from '../../db/schema';
+import { requirePermission, requireScope } from '../../middleware/auth';
+import type { AuthContext } from '../../middleware/auth';
+import { canAccessSite, getUserPermissions, type UserPermissions } from '../../services/permissions';
+import { writeRouteAudit } from '../../services/auditEvents';
+import { CommandTypes, executeCommand } from '../../services/commandQueue';
+import { defenderPreferenceChangeSchema, deviceIdParamSchema } from './schemas';
+
+const DEFENDER_COMMAND_TIMEOUT_MS = 90_000;
+
+/**
+ * Site-scope gate: partner-scope users restricted via `allowedSiteIds` must
+ * not see/touch a device in a site they cannot access. RLS does not defend
+ * the site axis — mirrors security/scans.ts (PR #864/#868).
+ */
+async function canAccessDeviceSite(
+  c: Context,
+  auth: Pick<AuthContext, 'user' | 'partnerId' | 'orgId'>,
+  deviceSiteId: string | null,
+): Promise<boolean> {
+  let userPerms = c.get('permissions') as UserPermissions | undefined;
+  if (!userPerms) {
+    const fetched = await getUserPermissions(auth.user.id, {
+      partnerId: auth.partnerId || undefined,
+      orgId: auth.orgId || undefined,
+    });
+    userPerms = fetched || undefined;
+  }
+  if (!userPerms?.allowedSiteIds) return true;
+  if (typeof deviceSiteId !== 'string') return false;
+  return canAccessSite(userPerms, deviceSiteId);
+}
+
+async function loadWindowsDevice(c: Context, deviceId: string) {
+  const auth = c.get('auth');
+  const orgCondition = auth.orgCondition(devices.orgId);
+  const conditions = [eq(devices.id, deviceId)];
+  if (orgCondition) conditions.push(orgCondition);
+  const [device] = await db
+    .select({ id: devices.id, hostname: devices.hostname, orgId: devices.orgId, siteId: devices.siteId, osType: devices.osType })
+    .from(devices)
+    .where(and(...conditions))
+    .limit(1);
+  if (!device) return { device: null as null, denied: c.json({ error: 'Device not found' }, 404) };
+  if (!(await canAccessDeviceSite(c, auth, device.siteId))) {
+    return { device: null as null, denied: c.json({ error: 'Access to this site denied' }, 403) };
+  }
+  if ((device.osType ?? '').toLowerCase() !== 'windows') {
+    return { device: null as null, denied: c.json({ error: 'Microsoft Defender policy management is only available on Windows devices' }, 400) };
+  }
+  return { device, denied: null as null };
+}
+
+function parseAgentPayload(stdout: string | undefined): Record<string, unknown> | null {
+  try {
+    const parsed = JSON.parse(stdout || '{}');
+    return parsed && typeof parsed === 'object' && !Array.isArray(parsed) ? parsed : null;
+  } catch {
+    return null;
+  }
+}
+
+export const defenderRoutes = new Hono();
+
+// Live read of exclusions, ASR rules, cloud protection and tamper protection.
+defenderRoutes.get(
+  '/defender/devices/:deviceId/preferences',
+  requireScope('organization', 'partner', 'system'),
+  requirePermission('devices', 'read'),
+  zValidator('param', deviceIdParamSchema),
+  async (c) => {
+    const auth = c.get('auth');
+    const { deviceId } = c.req.valid('param');
+    const { device, denied } = await loadWindowsDevice(c, deviceId);
+    if (!device) return denied;
+
+    const result = await executeCommand(device.id, CommandTypes.DEFENDER_GET_PREFERENCES, {}, {
+      userId: auth.user?.id,
+      timeoutMs: DEFENDER_COMMAND_TIMEOUT_MS,
+    });
+    if (result.status !== 'completed') {
+      return c.json({ error: result.error || 'Failed to read Defender preferences' }, 502);
+    }
+
+    const preferences = parseAgentPayload(result.stdout);
+    if (!preferences) {
+      return c.json({ error: 'Failed to parse agent response for Defender preferences' }, 502);
+    }
+    return c.json({ data: preferences });
+  }
+);
+
+// Apply a preference change. The agent reads the preferences before and
+// after applying it and reports the per-setting differences, which are
+// recorded here and in the device change log.
+defenderRoutes.put(
+  '/defender/devices/:deviceId/preferences',
+  requireScope('organization', 'partner', 'system'),
+  requirePermission('devices', 'execute'),
+  zValidator('param', deviceIdParamSchema),
+  zValidator('json', defenderPreferenceChangeSchema),
+  async (c) => {
+    const auth = c.get('auth');
+    const { deviceId } = c.req.valid('param');
+    const body = c.req.valid('json');
+    const { device, denied } = await loadWindowsDevice(c, deviceId);
+    if (!device) return denied;
+
+    const result = await executeCommand(device.id, CommandTypes.DEFENDER_SET_PREFERENCES, body, {
+      userId: auth.user?.id,
+      timeoutMs: DEFENDER_COMMAND_TIMEOUT_MS,
+    });
+    const payload = result.status === 'completed' ? parseAgentPayload(result.stdout) : null;
+    const succeeded = payload !== null;
+
+    writeRouteAudit(c, {
+      orgId: device.orgId,
+      action: 'device.defender.preferences.update',
+      resourceType: 'device',
+      resourceId: device.id,
+      resourceName: device.hostname,
+      details: {
+        requested: body,
+        changes: payload?.changes ?? [],
+        notApplied: payload?.notApplied ?? [],
+        error: succeeded ? undefined : (result.error || 'Invalid agent response'),
+      },
+      result: succeeded ? 'success' : 'failure',
+    });
+
+    if (!succeeded) {
+      return c.json({ error: result.error || 'Failed to update Defender preferences' }, 502);
+    }
+    return c.json({ data: payload });
+  }
+);
@@ -0,0 +1,123 @@
+import { beforeEach, describe, expect, it, vi } from 'vitest';
+import { Hono } from 'hono';
+
+vi.mock('../../db', () => ({
+  db: { select: vi.fn() },
+}));
+
+vi.mock('../../db/schema', () => ({
+  devices: { id: 'devices.id', orgId: 'devices.orgId', siteId: 'devices.siteId', hostname: 'devices.hostname', osType: 'devices.osType' },
+}));
+
+const { getUserPermissionsMock, executeCommandMock } = vi.hoisted(() => ({
+  getUserPermissionsMock: vi.fn(),
+  executeCommandMock: vi.fn(),
+}));
+
+vi.mock('../../services/permissions', async () => {
+  const actual = await vi.importActual<any>('../../services/permissions');
+  return { ...actual, getUserPermissions: getUserPermissionsMock };
+});
+vi.mock('../../middleware/auth', async () => {
+  const actual = await vi.importActual<any>('../../middleware/auth');
+  return { ...actual, requireScope: vi.fn(() => async (_c: any, next: any) => next()) };
+});
+vi.mock('../../services/commandQueue', () => ({
+  CommandTypes: {
+    FIREWALL_LIST_RULES: 'firewall_list_rules',
+  },
+  executeCommand: executeCommandMock,
+}));
+
+import { db } from '../../db';
+import { firewallRoutes } from './firewall';
+
+const ORG_ID = '11111111-1111-1111-1111-111111111111';
+const DEVICE_ID = '22222222-2222-2222-2222-222222222222';
+
+function buildApp(): Hono {
+  const app = new Hono();
+  app.use('*', async (c, next) => {
+    c.set('auth', {
+      scope: 'organization',
+      orgId: ORG_ID,
+      partnerId: null,
+      accessibleOrgIds: [ORG_ID],
+      user: { id: 'user-1', email: 'test@example.com', name: 'Test User' },
+      canAccessOrg: () => true,
+      orgCondition: () => undefined,
+    } as any);
+    await next();
+  });
+  app.route('/security', firewallRoutes);
+  return app;
+}
+
+function mockDeviceSelect(overrides: Partial<{ siteId: string | null }> = {}) {
+  vi.mocked(db.select).mockReturnValueOnce({
+    from: vi.fn().mockReturnValue({
+      where: vi.fn().mockReturnValue({
+        limit: vi.fn().mockResolvedValue([{ id: DEVICE_ID, siteId: overrides.siteId ?? null }]),
+      }),
+    }),
+  } as any);
+}
+
+function grant(...actions: string[]) {
+  getUserPermissionsMock.mockResolvedValue({
+    permissions: actions.map((action) => ({ resource: 'devices', action })),
+    allowedSiteIds: undefined,
+  });
+}
+
+const RULES_URL = `/security/firewall/devices/${DEVICE_ID}/rules`;
+
+describe('GET /firewall/devices/:deviceId/rules', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  it('returns 403 without devices:read', async () => {
+    getUserPermissionsMock.mockResolvedValue(null);
+    const res = await buildApp().request(RULES_URL);
+    expect(res.status).toBe(403);
+    expect(executeCommandMock).not.toHaveBeenCalled();
+  });
+
+  it('returns the firewall state reported by the agent', async () => {
+    grant('read');
+    mockDeviceSelect();
+    const state = {
+      backend: 'ufw',
+      profiles: [{ name: 'ufw', enabled: true }],
+      rules: [{ id: '1', name: 'Block SSH', direction: 'inbound', action: 'block', protocol: 'tcp', port: '22', enabled: true, managed: true }],
+    };
+    executeCommandMock.mockResolvedValue({ status: 'completed', stdout: JSON.stringify(state) });
+
+    const res = await buildApp().request(RULES_URL);
+
+    expect(res.status).toBe(200);
+    expect((await res.json()).data).toEqual(state);
+    expect(executeCommandMock).toHaveBeenCalledWith(DEVICE_ID, 'firewall_list_rules', {}, expect.objectContaining({ userId: 'user-1' }));
+  });
+
+  it('returns 403 when the device site is outside the caller allowlist', async () => {
+    getUserPermissionsMock.mockResolvedValue({
+      permissions: [{ resource: 'devices', action: 'read' }],
+      allowedSiteIds: ['aaaaaaaa-aaaa-4aaa-8aaa-aaaaaaaaaaaa'],
+    });
+    mockDeviceSelect({ siteId: 'bbbbbbbb-bbbb-4bbb-8bbb-bbbbbbbbbbbb' });
+    const res = await buildApp().request(RULES_URL);
+    expect(res.status).toBe(403);
+    expect(executeCommandMock).not.toHaveBeenCalled();
+  });
+
+  it('returns 502 when the agent cannot list rules', async () => {
+    grant('read');
+    mockDeviceSelect();
+    executeCommandMock.mockResolvedValue({ status: 'failed', error: 'neither ufw nor firewalld is installed' });
+    const res = await buildApp().request(RULES_URL);
+    expect(res.status).toBe(502);
+    expect((await res.json()).error).toContain('ufw');
+  });
+});
@@ -0,0 +1,83 @@
+import { Hono } from 'hono';
+import { zValidator } from '../../lib/validation';
+import { and, eq } from 'drizzle-orm';
+import type { Context } from 'hono';
+
+import { db } from '../../db';
+import { devices } from '../../db/schema';
+import { requirePermission, requireScope } from '../../middleware/auth';
+import type { AuthContext } from '../../middleware/auth';
+import { canAccessSite, getUserPermissions, type UserPermissions } from '../../services/permissions';
+import { CommandTypes, executeCommand } from '../../services/commandQueue';
+import { deviceIdParamSchema } from './schemas';
+
+// Listing every Windows Firewall rule with its port filters takes a while on
+// hosts with the full inbox rule set.
+const FIREWALL_LIST_TIMEOUT_MS = 90_000;
+
+/**
+ * Site-scope gate: partner-scope users restricted via `allowedSiteIds` must
+ * not see/touch a device in a site they cannot access. RLS does not defend
+ * the site axis — mirrors security/scans.ts (PR #864/#868).
+ */
+async function canAccessDeviceSite(
+  c: Context,
+  auth: Pick<AuthContext, 'user' | 'partnerId' | 'orgId'>,
+  deviceSiteId: string | null,
+): Promise<boolean> {
+  let userPerms = c.get('permissions') as UserPermissions | undefined;
+  if (!userPerms) {
+    const fetched = await getUserPermissions(auth.user.id, {
+      partnerId: auth.partnerId || undefined,
+      orgId: auth.orgId || undefined,
+    });
+    userPerms = fetched || undefined;
+  }
+  if (!userPerms?.allowedSiteIds) return true;
+  if (typeof deviceSiteId !== 'string') return false;
+  return canAccessSite(userPerms, deviceSiteId);
+}
+
+export const firewallRoutes = new Hono();
+
+// Live read of the host firewall: backend, profile states and rules.
+// Changes go through the device command endpoints (firewall_add_rule,
+// firewall_remove_rule, firewall_set_profile) so they can target many
+// devices at once.
+firewallRoutes.get(
+  '/firewall/devices/:deviceId/rules',
+  requireScope('organization', 'partner', 'system'),
+  requirePermission('devices', 'read'),
+  zValidator('param', deviceIdParamSchema),
+  async (c) => {
+    const auth = c.get('auth');
+    const { deviceId } = c.req.valid('param');
+
+    const orgCondition = auth.orgCondition(devices.orgId);
+    const conditions = [eq(devices.id, deviceId)];
+    if (orgCondition) conditions.push(orgCondition);
+    const [device] = await db
+      .select({ id: devices.id, siteId: devices.siteId })
+      .from(devices)
+      .where(and(...conditions))
+      .limit(1);
+    if (!device) return c.json({ error: 'Device not found' }, 404);
+    if (!(await canAccessDeviceSite(c, auth, device.siteId))) {
+      return c.json({ error: 'Access to this site denied' }, 403);
+    }
+
+    const result = await executeCommand(device.id, CommandTypes.FIREWALL_LIST_RULES, {}, {
+      userId: auth.user?.id,
+      timeoutMs: FIREWALL_LIST_TIMEOUT_MS,
+    });
+    if (result.status !== 'completed') {
+      return c.json({ error: result.error || 'Failed to list firewall rules' }, 502);
+    }
+
+    try {
+      return c.json({ data: JSON.parse(result.stdout || '{}') });
+    } catch {
+      return c.json({ error: 'Failed to parse agent response for firewall rules' }, 502);
+    }
+  }
+);
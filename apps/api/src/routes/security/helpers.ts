@@ -173,7 +173,15 @@ export function toStatusResponse(row: StatusRow) {
     realTimeProtection: row.realTimeProtection,
     firewallEnabled: row.firewallEnabled,
     encryptionStatus: normalizeEncryption(row.encryptionStatus),
-    gatekeeperEnabled: row.gatekeeperEnabled
+    gatekeeperEnabled: row.gatekeeperEnabled,
+    tpmPresent: row.tpmPresent ?? null,
+    tpmVersion: row.tpmVersion ?? null,
+    tpmOwned: row.tpmOwned ?? null,
+    secureBootEnabled: row.secureBootEnabled ?? null,
+    measuredBootAvailable: row.measuredBootAvailable ?? null,
+    agentScore: row.agentScore ?? null,
+    agentRiskLevel: row.agentRiskLevel ?? null,
+    agentScoreFactors: row.agentScoreFactors ?? null
   };
 }
 
@@ -224,6 +232,14 @@ export async function listStatusRows(auth: AuthContext, orgId?: string): Promise
       localAdminSummary: securityStatus.localAdminSummary,
       passwordPolicySummary: securityStatus.passwordPolicySummary,
       gatekeeperEnabled: securityStatus.gatekeeperEnabled,
+      tpmPresent: securityStatus.tpmPresent,
+      tpmVersion: securityStatus.tpmVersion,
+      tpmOwned: securityStatus.tpmOwned,
+      secureBootEnabled: securityStatus.secureBootEnabled,
+      measuredBootAvailable: securityStatus.measuredBootAvailable,
+      agentScore: securityStatus.agentScore,
+      agentRiskLevel: securityStatus.agentRiskLevel,
+      agentScoreFactors: securityStatus.agentScoreFactors,
       lastScan: securityStatus.lastScan,
       lastScanType: securityStatus.lastScanType
     })
@@ -249,6 +265,14 @@ export async function listStatusRows(auth: AuthContext, orgId?: string): Promise
     localAdminSummary: row.localAdminSummary ?? null,
     passwordPolicySummary: row.passwordPolicySummary ?? null,
     gatekeeperEnabled: row.gatekeeperEnabled ?? null,
+    tpmPresent: row.tpmPresent ?? null,
+    tpmVersion: row.tpmVersion ?? null,
+    tpmOwned: row.tpmOwned ?? null,
+    secureBootEnabled: row.secureBootEnabled ?? null,
+    measuredBootAvailable: row.measuredBootAvailable ?? null,
+    agentScore: row.agentScore ?? null,
+    agentRiskLevel: row.agentRiskLevel ?? null,
+    agentScoreFactors: row.agentScoreFactors ?? null,
     lastScan: row.lastScan,
     lastScanType: row.lastScanType
   }));
@@ -9,6 +9,8 @@ import { postureRoutes } from './posture';
 import { complianceRoutes } from './compliance';
 import { recommendationsRoutes } from './recommendations';
 import { recoveryKeysRoutes } from './recoveryKeys';
+import { defenderRoutes } from './defender';
+import { firewallRoutes } from './firewall';
 
 export const securityRoutes = new Hono();
 
@@ -22,5 +24,7 @@ securityRoutes.route('/', dashboardRoutes);
 securityRoutes.route('/', postureRoutes);
 securityRoutes.route('/', complianceRoutes);
 securityRoutes.route('/', recoveryKeysRoutes);
+securityRoutes.route('/', defenderRoutes);
+securityRoutes.route('/', firewallRoutes);
 securityRoutes.route('/', recommendationsRoutes);
 
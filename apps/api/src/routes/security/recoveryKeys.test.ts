@@ -36,7 +36,13 @@ vi.mock('../../middleware/auth', async () => {
 });
 vi.mock('../../services/auditEvents', () => ({ writeRouteAudit: writeRouteAuditMock }));
 vi.mock('../../services/commandQueue', () => ({
-  CommandTypes: { ENCRYPTION_ROTATE_KEY: 'encryption_rotate_key', ENCRYPTION_COLLECT_KEYS: 'encryption_collect_keys' },
+  CommandTypes: {
+    ENCRYPTION_ROTATE_KEY: 'encryption_rotate_key',
+    ENCRYPTION_COLLECT_KEYS: 'encryption_collect_keys',
+    ENCRYPTION_ENABLE_BITLOCKER: 'encryption_enable_bitlocker',
+    ENCRYPTION_ENABLE_FILEVAULT: 'encryption_enable_filevault',
+    ENCRYPTION_ESCROW_LUKS: 'encryption_escrow_luks',
+  },
   queueCommand: queueCommandMock,
 }));
 vi.mock('../../services/secretCrypto', () => ({ decryptForColumn: decryptForColumnMock }));
@@ -296,4 +302,172 @@ describe('recoveryKeysRoutes', () => {
     expect(body.data.status).toBe('queued');
     expect(body.data.commandId).toBe('cccccccc-cccc-4ccc-8ccc-cccccccccccc');
   });
+
+  it('enable bitlocker applies defaults and queues the command', async () => {
+    mockDeviceSelect({ osType: 'windows' });
+    const app = buildApp();
+
+    const res = await app.request(`/security/encryption/devices/${DEVICE_ID}/bitlocker/enable`, {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({}),
+    });
+    expect(res.status).toBe(202);
+    expect(encryptFieldsMock).toHaveBeenCalledWith('encryption_enable_bitlocker', {
+      volumeMount: 'C:',
+      protector: 'tpm',
+      encryptionMethod: 'xts_aes128',
+      usedSpaceOnly: false,
+      skipHardwareTest: false,
+    });
+    expect(queueCommandMock).toHaveBeenCalledWith(DEVICE_ID, 'encryption_enable_bitlocker', expect.objectContaining({ protector: 'tpm' }), 'user-1');
+    const auditArg = writeRouteAuditMock.mock.calls[0]![1] as any;
+    expect(auditArg.action).toBe('device.bitlocker.enable');
+  });
+
+  it('enable bitlocker with a PIN encrypts it and keeps it out of the audit', async () => {
+    mockDeviceSelect({ osType: 'windows' });
+    const app = buildApp();
+
+    const res = await app.request(`/security/encryption/devices/${DEVICE_ID}/bitlocker/enable`, {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({ protector: 'tpm_pin', pin: '246810', encryptionMethod: 'xts_aes256' }),
+    });
+    expect(res.status).toBe(202);
+    expect(encryptFieldsMock).toHaveBeenCalledWith('encryption_enable_bitlocker', expect.objectContaining({ pin: '246810' }));
+    const queuedPayload = (queueCommandMock.mock.calls[0] as any[])[2];
+    expect(queuedPayload).toEqual(expect.objectContaining({ __encrypted: true }));
+
+    const auditArg = writeRouteAuditMock.mock.calls[0]![1] as any;
+    expect(JSON.stringify(auditArg.details)).not.toContain('246810');
+  });
+
+  it('enable bitlocker rejects a PIN that does not match the protector', async () => {
+    const app = buildApp();
+
+    for (const body of [{ protector: 'tpm_pin' }, { protector: 'tpm', pin: '246810' }, { protector: 'tpm_pin', pin: '12' }]) {
+      const res = await app.request(`/security/encryption/devices/${DEVICE_ID}/bitlocker/enable`, {
+        method: 'POST',
+        headers: { 'content-type': 'application/json' },
+        body: JSON.stringify(body),
+      });
+      expect(res.status).toBe(400);
+    }
+    expect(queueCommandMock).not.toHaveBeenCalled();
+  });
+
+  it('enable bitlocker on macos returns 400', async () => {
+    mockDeviceSelect({ osType: 'macos' });
+    const app = buildApp();
+
+    const res = await app.request(`/security/encryption/devices/${DEVICE_ID}/bitlocker/enable`, {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({}),
+    });
+    expect(res.status).toBe(400);
+    expect(queueCommandMock).not.toHaveBeenCalled();
+  });
+
+  it('enable filevault queues a deferred enablement with defaults', async () => {
+    mockDeviceSelect({ osType: 'macos' });
+    const app = buildApp();
+
+    const res = await app.request(`/security/encryption/devices/${DEVICE_ID}/filevault/enable`, {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({}),
+    });
+    expect(res.status).toBe(202);
+    expect(queueCommandMock).toHaveBeenCalledWith(DEVICE_ID, 'encryption_enable_filevault', { maxDeferrals: 0, promptAtLogout: true }, 'user-1');
+    const auditArg = writeRouteAuditMock.mock.calls[0]![1] as any;
+    expect(auditArg.action).toBe('device.filevault.enable');
+  });
+
+  it('enable filevault rejects too many deferrals', async () => {
+    const app = buildApp();
+
+    const res = await app.request(`/security/encryption/devices/${DEVICE_ID}/filevault/enable`, {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({ maxDeferrals: 50 }),
+    });
+    expect(res.status).toBe(400);
+    expect(queueCommandMock).not.toHaveBeenCalled();
+  });
+
+  it('enable filevault on windows returns 400', async () => {
+    mockDeviceSelect({ osType: 'windows' });
+    const app = buildApp();
+
+    const res = await app.request(`/security/encryption/devices/${DEVICE_ID}/filevault/enable`, {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({}),
+    });
+    expect(res.status).toBe(400);
+    expect(queueCommandMock).not.toHaveBeenCalled();
+  });
+
+  it('luks escrow without a previous key requires the current passphrase', async () => {
+    mockDeviceSelect({ osType: 'linux' });
+    mockKeyLookupSelect(null);
+    const app = buildApp();
+
+    const res = await app.request(`/security/encryption/devices/${DEVICE_ID}/luks/escrow`, {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({}),
+    });
+    expect(res.status).toBe(400);
+    expect(queueCommandMock).not.toHaveBeenCalled();
+  });
+
+  it('luks escrow encrypts the passphrase and keeps it out of the audit', async () => {
+    mockDeviceSelect({ osType: 'linux' });
+    mockKeyLookupSelect(null);
+    const app = buildApp();
+
+    const res = await app.request(`/security/encryption/devices/${DEVICE_ID}/luks/escrow`, {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({ device: '/dev/nvme0n1p3', passphrase: 'correct horse' }),
+    });
+    expect(res.status).toBe(202);
+    expect(encryptFieldsMock).toHaveBeenCalledWith('encryption_escrow_luks', { device: '/dev/nvme0n1p3', passphrase: 'correct horse' });
+    expect(queueCommandMock).toHaveBeenCalledWith(DEVICE_ID, 'encryption_escrow_luks', expect.objectContaining({ __encrypted: true }), 'user-1');
+    const auditArg = writeRouteAuditMock.mock.calls[0]![1] as any;
+    expect(auditArg.action).toBe('device.luks.escrow');
+    expect(JSON.stringify(auditArg.details)).not.toContain('correct horse');
+  });
+
+  it('luks escrow re-run sends the previously escrowed passphrase', async () => {
+    mockDeviceSelect({ osType: 'linux' });
+    mockKeyLookupSelect({ id: KEY_ID, volumeMount: '/dev/nvme0n1p3', encryptedKey: 'enc:old' });
+    decryptForColumnMock.mockReturnValueOnce('OLD0-OLD1-OLD2-OLD3-OLD4-OLD5-OLD6-OLD7');
+    const app = buildApp();
+
+    const res = await app.request(`/security/encryption/devices/${DEVICE_ID}/luks/escrow`, {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({}),
+    });
+    expect(res.status).toBe(202);
+    expect(encryptFieldsMock).toHaveBeenCalledWith('encryption_escrow_luks', { previousEscrowKey: 'OLD0-OLD1-OLD2-OLD3-OLD4-OLD5-OLD6-OLD7' });
+    const auditArg = writeRouteAuditMock.mock.calls[0]![1] as any;
+    expect(auditArg.details.replacesKeyId).toBe(KEY_ID);
+  });
+
+  it('luks escrow rejects a device outside /dev', async () => {
+    const app = buildApp();
+
+    const res = await app.request(`/security/encryption/devices/${DEVICE_ID}/luks/escrow`, {
+      method: 'POST',
+      headers: { 'content-type': 'application/json' },
+      body: JSON.stringify({ device: '/etc/passwd;', passphrase: 'x' }),
+    });
+    expect(res.status).toBe(400);
+    expect(queueCommandMock).not.toHaveBeenCalled();
+  });
 });
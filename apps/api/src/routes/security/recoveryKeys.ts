@@ -12,7 +12,14 @@ import { decryptForColumn } from '../../services/secretCrypto';
 import { writeRouteAudit } from '../../services/auditEvents';
 import { CommandTypes, queueCommand } from '../../services/commandQueue';
 import { encryptSensitivePayloadFields } from '../../services/sensitiveCommandPayload';
-import { deviceIdParamSchema, recoveryKeyRevealParamSchema, rotateRecoveryKeySchema } from './schemas';
+import {
+  deviceIdParamSchema,
+  enableBitLockerSchema,
+  enableFileVaultSchema,
+  escrowLuksKeySchema,
+  recoveryKeyRevealParamSchema,
+  rotateRecoveryKeySchema,
+} from './schemas';
 
 /**
  * Site-scope gate: partner-scope users restricted via `allowedSiteIds` must
@@ -224,3 +231,150 @@ recoveryKeysRoutes.post(
     return c.json({ data: { commandId: command.id, status: 'queued' } }, 202);
   }
 );
+
+// Enable BitLocker (Windows). The agent escrows the volume's recovery
+// password through the recovery-key ingest before encryption starts; the PIN
+// is encrypted into the command payload and never audited.
+recoveryKeysRoutes.post(
+  '/encryption/devices/:deviceId/bitlocker/enable',
+  requireScope('organization', 'partner', 'system'),
+  requirePermission('devices', 'execute'),
+  zValidator('param', deviceIdParamSchema),
+  zValidator('json', enableBitLockerSchema),
+  async (c) => {
+    const auth = c.get('auth');
+    const { deviceId } = c.req.valid('param');
+    const body = c.req.valid('json');
+    const { device, denied } = await loadAccessibleDevice(c, deviceId);
+    if (!device) return denied;
+
+    if ((device.osType ?? '').toLowerCase() !== 'windows') {
+      return c.json({ error: `BitLocker is not available on ${device.osType}` }, 400);
+    }
+
+    const { pin, ...options } = body;
+    const volumeMount = (options.volumeMount ?? 'C:').toUpperCase();
+    const raw: Record<string, unknown> = { ...options, volumeMount };
+    if (pin) raw.pin = pin;
+    const payload = encryptSensitivePayloadFields(CommandTypes.ENCRYPTION_ENABLE_BITLOCKER, raw);
+
+    const command = await queueCommand(device.id, CommandTypes.ENCRYPTION_ENABLE_BITLOCKER, payload, auth.user.id);
+
+    writeRouteAudit(c, {
+      orgId: device.orgId,
+      action: 'device.bitlocker.enable',
+      resourceType: 'device',
+      resourceId: deviceId,
+      resourceName: device.hostname,
+      details: { ...options, volumeMount },
+    });
+
+    return c.json({ data: { commandId: command.id, status: 'queued' } }, 202);
+  }
+);
+
+// Enable FileVault (macOS). The agent defers enablement to the user's next
+// logout/login, when macOS can ask for their password, and escrows the
+// personal recovery key through the recovery-key ingest once it completes.
+recoveryKeysRoutes.post(
+  '/encryption/devices/:deviceId/filevault/enable',
+  requireScope('organization', 'partner', 'system'),
+  requirePermission('devices', 'execute'),
+  zValidator('param', deviceIdParamSchema),
+  zValidator('json', enableFileVaultSchema),
+  async (c) => {
+    const auth = c.get('auth');
+    const { deviceId } = c.req.valid('param');
+    const body = c.req.valid('json');
+    const { device, denied } = await loadAccessibleDevice(c, deviceId);
+    if (!device) return denied;
+
+    const os = (device.osType ?? '').toLowerCase();
+    if (os !== 'macos' && os !== 'darwin') {
+      return c.json({ error: `FileVault is not available on ${device.osType}` }, 400);
+    }
+
+    const command = await queueCommand(device.id, CommandTypes.ENCRYPTION_ENABLE_FILEVAULT, body, auth.user.id);
+
+    writeRouteAudit(c, {
+      orgId: device.orgId,
+      action: 'device.filevault.enable',
+      resourceType: 'device',
+      resourceId: deviceId,
+      resourceName: device.hostname,
+      details: body,
+    });
+
+    return c.json({ data: { commandId: command.id, status: 'queued' } }, 202);
+  }
+);
+
+// Escrow a LUKS recovery passphrase (Linux). The agent adds a keyslot with a
+// generated passphrase and escrows it; a previously escrowed passphrase for
+// the device is sent along (encrypted) to authorise the change and have its
+// keyslot removed, so re-running rotates rather than piling up keyslots.
+recoveryKeysRoutes.post(
+  '/encryption/devices/:deviceId/luks/escrow',
+  requireScope('organization', 'partner', 'system'),
+  requirePermission('devices', 'execute'),
+  zValidator('param', deviceIdParamSchema),
+  zValidator('json', escrowLuksKeySchema),
+  async (c) => {
+    const auth = c.get('auth');
+    const { deviceId } = c.req.valid('param');
+    const body = c.req.valid('json');
+    const { device, denied } = await loadAccessibleDevice(c, deviceId);
+    if (!device) return denied;
+
+    if ((device.osType ?? '').toLowerCase() !== 'linux') {
+      return c.json({ error: `LUKS escrow is not available on ${device.osType}` }, 400);
+    }
+
+    const active = await db
+      .select({ id: deviceRecoveryKeys.id, volumeMount: deviceRecoveryKeys.volumeMount, encryptedKey: deviceRecoveryKeys.encryptedKey })
+      .from(deviceRecoveryKeys)
+      .where(and(
+        eq(deviceRecoveryKeys.deviceId, deviceId),
+        eq(deviceRecoveryKeys.keyType, 'luks_recovery_passphrase'),
+        eq(deviceRecoveryKeys.status, 'active'),
+      ))
+      .limit(10);
+    // Without a device the agent picks the one under /, which is only known
+    // here when a single LUKS device has been escrowed before.
+    const previous = body.device
+      ? active.find((row) => row.volumeMount === body.device)
+      : active.length === 1 ? active[0] : undefined;
+
+    let previousEscrowKey: string | null = null;
+    if (previous) {
+      try {
+        previousEscrowKey = decryptForColumn('device_recovery_keys', 'encrypted_key', previous.encryptedKey);
+      } catch (err) {
+        console.error('[security] recovery key decrypt failed:', { keyId: previous.id, error: err });
+        return c.json({ error: 'Failed to decrypt recovery key — check APP_ENCRYPTION_KEY configuration' }, 500);
+      }
+    }
+    if (!body.passphrase && !previousEscrowKey) {
+      return c.json({ error: 'A passphrase that opens the LUKS device is required for the first escrow' }, 400);
+    }
+
+    const raw: Record<string, unknown> = {};
+    if (body.device) raw.device = body.device;
+    if (body.passphrase) raw.passphrase = body.passphrase;
+    if (previousEscrowKey) raw.previousEscrowKey = previousEscrowKey;
+    const payload = encryptSensitivePayloadFields(CommandTypes.ENCRYPTION_ESCROW_LUKS, raw);
+
+    const command = await queueCommand(device.id, CommandTypes.ENCRYPTION_ESCROW_LUKS, payload, auth.user.id);
+
+    writeRouteAudit(c, {
+      orgId: device.orgId,
+      action: 'device.luks.escrow',
+      resourceType: 'device',
+      resourceId: deviceId,
+      resourceName: device.hostname,
+      details: { device: body.device ?? null, replacesKeyId: previous?.id ?? null },
+    });
+
+    return c.json({ data: { commandId: command.id, status: 'queued' } }, 202);
+  }
+);
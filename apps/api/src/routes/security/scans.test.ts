@@ -33,7 +33,7 @@ vi.mock('../../db/schema', () => ({
 }));
 
 vi.mock('../../services/commandQueue', () => ({
-  CommandTypes: { SECURITY_SCAN: 'security_scan' },
+  CommandTypes: { SECURITY_SCAN: 'security_scan', AV_SCAN: 'av_scan' },
   queueCommand: vi.fn(async () => undefined),
 }));
 
@@ -60,6 +60,7 @@ vi.mock('../../middleware/auth', async () => {
 });
 
 import { db } from '../../db';
+import { queueCommand } from '../../services/commandQueue';
 import { scansRoutes } from './scans';
 
 const ORG_ID = '11111111-1111-1111-1111-111111111111';
@@ -231,3 +232,68 @@ describe('GET /scans/:deviceId — site-scope gate', () => {
     expect(res.status).not.toBe(403);
   });
 });
+
+describe('POST /scan/:deviceId — engine selection', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    getUserPermissionsMock.mockResolvedValue({
+      permissions: [{ resource: 'devices', action: 'execute' }],
+      allowedSiteIds: undefined,
+    });
+    vi.mocked(db.insert).mockReturnValue({
+      values: vi.fn().mockResolvedValue(undefined),
+    } as any);
+  });
+
+  it('queues an av_scan through the installed engine when one is requested', async () => {
+    mockDeviceSelect();
+    const app = buildApp();
+
+    const res = await app.request(`/security/scan/${DEVICE_ID}`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ scanType: 'custom', paths: ['/home/u/Downloads'], engine: 'clamav' }),
+    });
+
+    expect(res.status).toBe(202);
+    const body = await res.json();
+    expect(queueCommand).toHaveBeenCalledWith(
+      DEVICE_ID,
+      'av_scan',
+      { scanRecordId: body.data.id, scanType: 'custom', paths: ['/home/u/Downloads'], engine: 'clamav' },
+      'user-1'
+    );
+  });
+
+  it('keeps the agent signature scan when no engine is given', async () => {
+    mockDeviceSelect();
+    const app = buildApp();
+
+    const res = await app.request(`/security/scan/${DEVICE_ID}`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ scanType: 'quick' }),
+    });
+
+    expect(res.status).toBe(202);
+    expect(queueCommand).toHaveBeenCalledWith(
+      DEVICE_ID,
+      'security_scan',
+      expect.objectContaining({ scanType: 'quick', triggerDefender: true }),
+      'user-1'
+    );
+  });
+
+  it('rejects an unknown engine', async () => {
+    const app = buildApp();
+
+    const res = await app.request(`/security/scan/${DEVICE_ID}`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ scanType: 'quick', engine: 'norton' }),
+    });
+
+    expect(res.status).toBe(400);
+    expect(queueCommand).not.toHaveBeenCalled();
+  });
+});
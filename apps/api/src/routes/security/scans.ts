@@ -83,17 +83,31 @@ scansRoutes.post(
       initiatedBy: auth.user.id
     });
 
-    await queueCommand(
-      device.id,
-      CommandTypes.SECURITY_SCAN,
-      {
-        scanRecordId: scanId,
-        scanType: payload.scanType,
-        paths: payload.paths,
-        triggerDefender: true
-      },
-      auth.user.id
-    );
+    if (payload.engine) {
+      await queueCommand(
+        device.id,
+        CommandTypes.AV_SCAN,
+        {
+          scanRecordId: scanId,
+          scanType: payload.scanType,
+          paths: payload.paths,
+          engine: payload.engine
+        },
+        auth.user.id
+      );
+    } else {
+      await queueCommand(
+        device.id,
+        CommandTypes.SECURITY_SCAN,
+        {
+          scanRecordId: scanId,
+          scanType: payload.scanType,
+          paths: payload.paths,
+          triggerDefender: true
+        },
+        auth.user.id
+      );
+    }
 
     return c.json({
       data: {
@@ -37,6 +37,14 @@ export type StatusRow = {
   localAdminSummary: unknown;
   passwordPolicySummary: unknown;
   gatekeeperEnabled: boolean | null;
+  tpmPresent: boolean | null;
+  tpmVersion: string | null;
+  tpmOwned: boolean | null;
+  secureBootEnabled: boolean | null;
+  measuredBootAvailable: boolean | null;
+  agentScore: number | null;
+  agentRiskLevel: RiskLevel | null;
+  agentScoreFactors: unknown;
   lastScan: Date | null;
   lastScanType: string | null;
 };
@@ -130,7 +138,8 @@ export const listStatusQuerySchema = z.object({
   riskLevel: z.enum(['low', 'medium', 'high', 'critical']).optional(),
   os: z.enum(['windows', 'macos', 'linux']).optional(),
   orgId: z.string().guid().optional(),
-  search: z.string().optional()
+  search: z.string().optional(),
+  sortBy: z.enum(['agentScore']).optional()
 });
 
 export const listThreatsQuerySchema = z.object({
@@ -148,7 +157,10 @@ export const listThreatsQuerySchema = z.object({
 
 export const scanRequestSchema = z.object({
   scanType: z.enum(['quick', 'full', 'custom']),
-  paths: z.array(z.string().min(1)).optional()
+  paths: z.array(z.string().min(1)).optional(),
+  // Run the device's installed antivirus (av_scan) instead of the agent's
+  // signature scan; 'auto' picks Defender, mdatp or ClamAV in that order.
+  engine: z.enum(['auto', 'defender', 'mdatp', 'clamav']).optional()
 });
 
 export const listScansQuerySchema = z.object({
@@ -217,6 +229,60 @@ export const rotateRecoveryKeySchema = z.object({
   currentRecoveryKey: z.string().min(8).max(128).optional()
 });
 
+// Mirrors the agent's BitLockerEnableOptions. The recovery password is
+// always added and escrowed by the agent, so it is not a choice here.
+export const enableBitLockerSchema = z.object({
+  volumeMount: z.string().regex(/^[A-Za-z]:$/).optional(),
+  protector: z.enum(['tpm', 'tpm_pin']).default('tpm'),
+  pin: z.string().regex(/^[0-9]{6,20}$/).optional(),
+  encryptionMethod: z.enum(['aes128', 'aes256', 'xts_aes128', 'xts_aes256']).default('xts_aes128'),
+  usedSpaceOnly: z.boolean().default(false),
+  skipHardwareTest: z.boolean().default(false)
+}).refine((body) => (body.protector === 'tpm_pin') === (body.pin !== undefined), {
+  message: 'A PIN is required for, and only used with, the tpm_pin protector',
+  path: ['pin']
+});
+
+// Mirrors the agent's FileVaultDeferOptions (fdesetup -forceatlogin and
+// -dontaskatlogout).
+export const enableFileVaultSchema = z.object({
+  maxDeferrals: z.number().int().min(0).max(10).default(0),
+  promptAtLogout: z.boolean().default(true)
+});
+
+// `device` defaults to the LUKS device under / on the agent. `passphrase` is
+// any passphrase that already opens it; it is only needed the first time,
+// after that the previously escrowed passphrase is used.
+export const escrowLuksKeySchema = z.object({
+  device: z.string().max(100).regex(/^\/dev\/[A-Za-z0-9._/-]+$/).optional(),
+  passphrase: z.string().min(1).max(512).optional()
+});
+
+const defenderExclusionListSchema = z.array(z.string().trim().min(1).max(1024)).max(100).optional();
+
+// Mirrors the agent's DefenderPreferenceChange. Tamper protection is
+// reported by the agent but can only be changed through Intune/Defender
+// for Endpoint, so it is deliberately absent here.
+export const defenderPreferenceChangeSchema = z.object({
+  addExclusionPaths: defenderExclusionListSchema,
+  removeExclusionPaths: defenderExclusionListSchema,
+  addExclusionExtensions: defenderExclusionListSchema,
+  removeExclusionExtensions: defenderExclusionListSchema,
+  addExclusionProcesses: defenderExclusionListSchema,
+  removeExclusionProcesses: defenderExclusionListSchema,
+  asrRules: z.record(z.string().guid(), z.enum(['disabled', 'block', 'audit', 'warn'])).optional(),
+  cloudBlockLevel: z.enum(['default', 'moderate', 'high', 'high_plus', 'zero_tolerance']).optional(),
+  mapsReporting: z.enum(['disabled', 'basic', 'advanced']).optional()
+}).strict().refine(
+  (body) => Object.values(body).some((value) => {
+    if (value === undefined) return false;
+    if (Array.isArray(value)) return value.length > 0;
+    if (typeof value === 'object') return Object.keys(value).length > 0;
+    return true;
+  }),
+  { message: 'At least one preference change is required' }
+);
+
 export const threatIdParamSchema = z.object({
   id: z.string().guid()
 });
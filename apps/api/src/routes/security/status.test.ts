@@ -109,6 +109,57 @@ describe('GET /status — requirePermission(devices, read)', () => {
   });
 });
 
+describe('GET /status — sortBy=agentScore', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  function statusRow(deviceId: string, agentScore: number | null) {
+    return {
+      deviceId,
+      orgId: ORG_ID,
+      deviceName: `host-${deviceId.slice(0, 4)}`,
+      os: 'windows',
+      deviceState: 'online',
+      provider: 'defender',
+      realTimeProtection: true,
+      threatCount: 0,
+      firewallEnabled: true,
+      encryptionStatus: 'encrypted',
+      agentScore,
+      agentRiskLevel: null,
+      agentScoreFactors: null,
+      lastScan: null,
+      lastScanType: null,
+    };
+  }
+
+  it('ranks devices by agent score, lowest first, with unscored devices last', async () => {
+    getUserPermissionsMock.mockResolvedValue({
+      permissions: [{ resource: 'devices', action: 'read' }],
+      allowedSiteIds: undefined,
+    });
+    vi.mocked(db.select).mockReturnValueOnce({
+      from: vi.fn().mockReturnValue({
+        leftJoin: vi.fn().mockReturnValue({
+          where: vi.fn().mockResolvedValue([
+            statusRow('aaaa0000-0000-4000-8000-000000000000', 91),
+            statusRow('bbbb0000-0000-4000-8000-000000000000', null),
+            statusRow('cccc0000-0000-4000-8000-000000000000', 42),
+          ]),
+        }),
+      }),
+    } as any);
+    const app = buildApp();
+
+    const res = await app.request('/security/status?sortBy=agentScore', { method: 'GET' });
+
+    expect(res.status).toBe(200);
+    const body = await res.json();
+    expect(body.data.map((s: { agentScore: number | null }) => s.agentScore)).toEqual([42, 91, null]);
+  });
+});
+
 describe('GET /status/:deviceId — requirePermission(devices, read)', () => {
   beforeEach(() => {
     vi.clearAllMocks();
@@ -53,6 +53,11 @@ statusRoutes.get(
       });
     }
 
+    if (query.sortBy === 'agentScore') {
+      // Lowest agent-computed score first; devices that have not reported one last.
+      results = [...results].sort((a, b) => (a.agentScore ?? 101) - (b.agentScore ?? 101));
+    }
+
     const response = paginate(results, page, limit);
     return c.json(response);
   }
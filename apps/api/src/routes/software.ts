@@ -114,7 +114,7 @@ function getPagination(query: { page?: string; limit?: string }) {
   return { page, limit, offset: (page - 1) * limit };
 }
 
-const ALLOWED_EXTENSIONS = new Set(['.msi', '.exe', '.dmg', '.deb', '.pkg']);
+const ALLOWED_EXTENSIONS = new Set(['.msi', '.exe', '.dmg', '.deb', '.rpm', '.pkg']);
 const MAX_UPLOAD_SIZE = 500 * 1024 * 1024; // 500 MB
 type SoftwareDeploymentAggregateStatus =
   | 'pending'
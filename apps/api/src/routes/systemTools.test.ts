@@ -30,6 +30,7 @@ vi.mock('../services/commandQueue', () => ({
     REGISTRY_DELETE: 'REGISTRY_DELETE',
     REGISTRY_KEY_CREATE: 'REGISTRY_KEY_CREATE',
     REGISTRY_KEY_DELETE: 'REGISTRY_KEY_DELETE',
+    REGISTRY_EXPORT: 'REGISTRY_EXPORT',
     EVENT_LOGS_LIST: 'EVENT_LOGS_LIST',
     EVENT_LOGS_QUERY: 'EVENT_LOGS_QUERY',
     EVENT_LOG_GET: 'EVENT_LOG_GET',
@@ -75,6 +76,10 @@ vi.mock('../db/schema', () => ({
   peripheralPolicies: {}
 }));
 
+vi.mock('../services/registryPolicy', () => ({
+  resolveRegistryWriteEnabledForOrg: vi.fn().mockResolvedValue(true),
+}));
+
 vi.mock('../services/remoteAccessPolicy', () => ({
   checkRemoteAccess: vi.fn().mockResolvedValue({ allowed: true }),
   resolveRemoteAccessForDevice: vi.fn().mockResolvedValue({
@@ -129,6 +134,7 @@ vi.mock('../services/permissions', () => ({
 import { db } from '../db';
 import { createAuditLog } from '../services/auditService';
 import { getUserPermissions } from '../services/permissions';
+import { resolveRegistryWriteEnabledForOrg } from '../services/registryPolicy';
 
 describe('system tools routes', () => {
   let app: Hono;
@@ -637,6 +643,51 @@ describe('system tools routes', () => {
     expect(createAuditLog).toHaveBeenCalled();
   });
 
+  it('refuses registry writes when the organization has not enabled them', async () => {
+    mockDeviceSelect();
+    vi.mocked(resolveRegistryWriteEnabledForOrg).mockResolvedValueOnce(false);
+
+    const res = await app.request(`/system-tools/devices/${deviceId}/registry/value`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({
+        hive: 'HKEY_LOCAL_MACHINE',
+        path: 'SOFTWARE',
+        name: 'TestValue',
+        type: 'REG_SZ',
+        data: 'Hello'
+      })
+    });
+
+    expect(res.status).toBe(403);
+    expect(mockExecuteCommand).not.toHaveBeenCalled();
+  });
+
+  it('exports a registry key as .reg text and logs audit', async () => {
+    mockDeviceSelect();
+    const content = 'Windows Registry Editor Version 5.00\r\n\r\n[HKEY_LOCAL_MACHINE\\SOFTWARE\\Breeze]\r\n';
+    mockExecuteCommand.mockResolvedValue({
+      status: 'completed',
+      stdout: JSON.stringify({ content, keys: 1, values: 0 })
+    });
+
+    const res = await app.request(
+      `/system-tools/devices/${deviceId}/registry/export?hive=HKEY_LOCAL_MACHINE&path=SOFTWARE\\Breeze&recursive=false`
+    );
+
+    expect(res.status).toBe(200);
+    const body = await res.json();
+    expect(body.data.content).toBe(content);
+    expect(body.data.fileName).toBe('Breeze.reg');
+    expect(mockExecuteCommand).toHaveBeenCalledWith(
+      deviceId,
+      'REGISTRY_EXPORT',
+      { hive: 'HKEY_LOCAL_MACHINE', path: 'SOFTWARE\\Breeze', recursive: false },
+      expect.anything()
+    );
+    expect(createAuditLog).toHaveBeenCalled();
+  });
+
   it('lists event logs via agent command', async () => {
     mockDeviceSelect();
     mockExecuteCommand.mockResolvedValue({
@@ -692,6 +743,44 @@ describe('system tools routes', () => {
     expect(body.meta.total).toBe(1);
   });
 
+  it('forwards time range and text filters to the agent query', async () => {
+    mockDeviceSelect();
+    mockExecuteCommand.mockResolvedValue({
+      status: 'completed',
+      stdout: JSON.stringify({ events: [], total: 0, page: 1, limit: 50, totalPages: 0 })
+    });
+
+    const res = await app.request(
+      `/system-tools/devices/${deviceId}/eventlogs/sshd.service/events?search=failed%20password` +
+        '&startTime=2026-02-08T00:00:00Z&endTime=2026-02-08T12:00:00Z'
+    );
+
+    expect(res.status).toBe(200);
+    expect(mockExecuteCommand).toHaveBeenCalledWith(
+      deviceId,
+      'EVENT_LOGS_QUERY',
+      expect.objectContaining({
+        logName: 'sshd.service',
+        search: 'failed password',
+        startTime: '2026-02-08T00:00:00Z',
+        endTime: '2026-02-08T12:00:00Z'
+      }),
+      expect.anything()
+    );
+  });
+
+  it('rejects an event query whose endTime is before startTime', async () => {
+    mockDeviceSelect();
+
+    const res = await app.request(
+      `/system-tools/devices/${deviceId}/eventlogs/System/events` +
+        '?startTime=2026-02-08T12:00:00Z&endTime=2026-02-08T00:00:00Z'
+    );
+
+    expect(res.status).toBe(400);
+    expect(mockExecuteCommand).not.toHaveBeenCalled();
+  });
+
   it('gets event log details via agent command', async () => {
     mockDeviceSelect();
     mockExecuteCommand.mockResolvedValue({
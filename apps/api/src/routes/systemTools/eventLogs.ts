@@ -156,7 +156,8 @@ eventLogsRoutes.get(
   }
 );
 
-// GET /devices/:deviceId/eventlogs/:name/events - Query events
+// GET /devices/:deviceId/eventlogs/:name/events - Query events. On Linux
+// agents :name is a journald channel (System, Kernel, Security) or unit.
 eventLogsRoutes.get(
   '/devices/:deviceId/eventlogs/:name/events',
   authMiddleware,
@@ -178,11 +179,18 @@ eventLogsRoutes.get(
 
     const { page, limit } = getPagination(query);
 
+    if (query.startTime && query.endTime && Date.parse(query.endTime) < Date.parse(query.startTime)) {
+      return c.json({ error: 'endTime must not be before startTime' }, 400);
+    }
+
     const result = await executeCommand(deviceId, CommandTypes.EVENT_LOGS_QUERY, {
       logName: name,
       level: query.level ?? '',
       source: query.source ?? '',
       eventId: query.eventId ?? 0,
+      ...(query.search ? { search: query.search } : {}),
+      ...(query.startTime ? { startTime: query.startTime } : {}),
+      ...(query.endTime ? { endTime: query.endTime } : {}),
       page,
       limit
     }, { userId: auth.user?.id, timeoutMs: 30000 });
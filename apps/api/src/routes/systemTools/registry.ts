@@ -3,6 +3,7 @@ import { zValidator } from '../../lib/validation';
 import { authMiddleware, requireScope } from '../../middleware/auth';
 import { executeCommand, CommandTypes } from '../../services/commandQueue';
 import { createAuditLog } from '../../services/auditService';
+import { resolveRegistryWriteEnabledForOrg } from '../../services/registryPolicy';
 import { getTrustedClientIpOrUndefined } from '../../services/clientIp';
 import { getDeviceWithOrgAndSiteCheck, SITE_ACCESS_DENIED, asRecord, asString, asNumber } from './helpers';
 import {
@@ -11,10 +12,13 @@ import {
   registryValueQuerySchema,
   registryValueBodySchema,
   registryKeyBodySchema,
-  registryKeyQuerySchema
+  registryKeyQuerySchema,
+  registryExportQuerySchema
 } from './schemas';
 import type { RegistryKey, RegistryValue } from './types';
 
+const REGISTRY_WRITE_DISABLED_ERROR = 'Registry editing is disabled for this organization';
+
 function parseNumericLike(value: string): number | null {
   const trimmed = value.trim();
   if (!trimmed) return null;
@@ -334,6 +338,10 @@ registryRoutes.put(
       return c.json({ error: 'Device not found or access denied' }, 404);
     }
 
+    if (!(await resolveRegistryWriteEnabledForOrg(device.orgId))) {
+      return c.json({ error: REGISTRY_WRITE_DISABLED_ERROR }, 403);
+    }
+
     const normalizedName = normalizeRegistryValueName(name);
     const commandData = toRegistryCommandData(type, data);
     const result = await executeCommand(deviceId, CommandTypes.REGISTRY_SET, {
@@ -403,6 +411,10 @@ registryRoutes.delete(
       return c.json({ error: 'Device not found or access denied' }, 404);
     }
 
+    if (!(await resolveRegistryWriteEnabledForOrg(device.orgId))) {
+      return c.json({ error: REGISTRY_WRITE_DISABLED_ERROR }, 403);
+    }
+
     const normalizedName = normalizeRegistryValueName(name);
     const result = await executeCommand(deviceId, CommandTypes.REGISTRY_DELETE, {
       hive,
@@ -460,6 +472,10 @@ registryRoutes.post(
       return c.json({ error: 'Device not found or access denied' }, 404);
     }
 
+    if (!(await resolveRegistryWriteEnabledForOrg(device.orgId))) {
+      return c.json({ error: REGISTRY_WRITE_DISABLED_ERROR }, 403);
+    }
+
     const normalizedPath = path.replace(/\\+$/, '');
     if (!normalizedPath) {
       return c.json({ error: 'Invalid registry key path' }, 400);
@@ -519,6 +535,10 @@ registryRoutes.delete(
       return c.json({ error: 'Device not found or access denied' }, 404);
     }
 
+    if (!(await resolveRegistryWriteEnabledForOrg(device.orgId))) {
+      return c.json({ error: REGISTRY_WRITE_DISABLED_ERROR }, 403);
+    }
+
     const normalizedPath = path.replace(/\\+$/, '');
     if (!normalizedPath) {
       return c.json({ error: 'Invalid registry key path' }, 400);
@@ -557,3 +577,79 @@ registryRoutes.delete(
     });
   }
 );
+
+// GET /devices/:deviceId/registry/export - Export a key (and by default its subkeys) as .reg text
+registryRoutes.get(
+  '/devices/:deviceId/registry/export',
+  authMiddleware,
+  requireScope('system', 'partner', 'organization'),
+  zValidator('param', deviceIdParamSchema),
+  zValidator('query', registryExportQuerySchema),
+  async (c) => {
+    const { deviceId } = c.req.valid('param');
+    const { hive, path, recursive } = c.req.valid('query');
+    const auth = c.get('auth');
+
+    const device = await getDeviceWithOrgAndSiteCheck(c, deviceId, auth);
+    if (device === SITE_ACCESS_DENIED) {
+      return c.json({ error: 'Access to this site denied' }, 403);
+    }
+    if (!device) {
+      return c.json({ error: 'Device not found or access denied' }, 404);
+    }
+
+    const normalizedPath = path.replace(/\\+$/, '');
+    const result = await executeCommand(deviceId, CommandTypes.REGISTRY_EXPORT, {
+      hive,
+      path: normalizedPath,
+      recursive: recursive !== 'false'
+    }, { userId: auth.user?.id, timeoutMs: 60000 });
+
+    await createAuditLog({
+      orgId: device.orgId,
+      actorId: auth.user.id,
+      actorEmail: auth.user.email,
+      action: 'export_registry_key',
+      resourceType: 'device',
+      resourceId: deviceId,
+      resourceName: device.hostname ?? device.id,
+      details: {
+        hive,
+        path: normalizedPath,
+        recursive: recursive !== 'false'
+      },
+      ipAddress: getTrustedClientIpOrUndefined(c),
+      result: result.status === 'completed' ? 'success' : 'failure',
+      errorMessage: result.error
+    });
+
+    if (result.status === 'failed') {
+      const error = result.error || 'Failed to export registry key';
+      return c.json({ error }, error.toLowerCase().includes('not found') ? 404 : 500);
+    }
+
+    try {
+      const payload = asRecord(JSON.parse(result.stdout || '{}'));
+      const content = asString(payload?.content);
+      if (content === undefined) {
+        return c.json({ error: 'Invalid registry export payload from agent' }, 502);
+      }
+
+      const keyName = normalizedPath.split('\\').filter(Boolean).pop() ?? hive;
+      return c.json({
+        data: {
+          hive,
+          path: normalizedPath,
+          fileName: `${keyName.replace(/[^A-Za-z0-9._-]+/g, '_')}.reg`,
+          content,
+          keys: asNumber(payload?.keys) ?? 0,
+          values: asNumber(payload?.values) ?? 0,
+          truncated: payload?.truncated === true
+        }
+      });
+    } catch (error) {
+      console.error('Failed to parse agent response for registry export:', error);
+      return c.json({ error: 'Failed to parse agent response for registry export' }, 502);
+    }
+  }
+);
@@ -47,6 +47,10 @@ export const registryKeyQuerySchema = z.object({
   path: z.string().min(1).max(1024)
 });
 
+export const registryExportQuerySchema = registryQuerySchema.extend({
+  recursive: z.enum(['true', 'false']).optional()
+});
+
 export const eventLogNameParamSchema = z.object({
   deviceId: z.string().guid(),
   name: z.string().min(1).max(256)
@@ -57,8 +61,9 @@ export const eventLogQuerySchema = z.object({
   limit: z.string().optional(),
   level: z.enum(['information', 'warning', 'error', 'critical', 'verbose']).optional(),
   source: z.string().optional(),
-  startTime: z.string().datetime().optional(),
-  endTime: z.string().datetime().optional(),
+  search: z.string().trim().min(1).max(256).optional(),
+  startTime: z.string().datetime({ offset: true }).optional(),
+  endTime: z.string().datetime({ offset: true }).optional(),
   eventId: z.string().transform(val => parseInt(val, 10)).optional()
 });
 
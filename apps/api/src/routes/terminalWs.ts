@@ -13,6 +13,8 @@ import { logSessionAudit } from './remote/helpers';
 import { getTrustedClientIp } from '../services/clientIp';
 import { createAuditLogAsync } from '../services/auditService';
 import { isViewerSessionRevoked } from '../services/viewerTokenRevocation';
+import { decryptForColumn } from '../services/secretCrypto';
+import { claimDetachedTerminal, isTerminalDetached, markTerminalDetached } from '../services/terminalDetach';
 
 // Zod validation for terminal user messages
 const terminalMessageSchema = z.discriminatedUnion('type', [
@@ -30,6 +32,9 @@ interface TerminalSession {
   deviceId: string;
   orgId: string;
   startedAt: Date;
+  // terminal_start payload, kept to start a fresh shell when a reattach finds
+  // the agent's PTY already gone.
+  startPayload: Record<string, unknown>;
   pingInterval?: ReturnType<typeof setInterval>;
   lastPongAt: number;
   // Per-session input rate limiting (sliding window)
@@ -82,7 +87,7 @@ async function validateTerminalAccess(
   sessionId: string,
   ticket: string | undefined,
   caller: { ip: string; userAgent: string }
-): Promise<{ valid: boolean; error?: string; session?: typeof remoteSessions.$inferSelect; device?: typeof devices.$inferSelect; userId?: string }> {
+): Promise<{ valid: boolean; error?: string; session?: typeof remoteSessions.$inferSelect; device?: typeof devices.$inferSelect; userId?: string; reattach?: boolean }> {
   if (!ticket) {
     return { valid: false, error: 'Missing connection ticket' };
   }
@@ -154,8 +159,10 @@ async function validateTerminalAccess(
       return { valid: false, error: 'Session does not belong to this user' };
     }
 
-    // Check session status
-    if (!['pending', 'connecting', 'active'].includes(session.status)) {
+    // Check session status. A disconnected session whose viewer dropped is
+    // still live on the agent (detached PTY) until the grace period runs out.
+    const reattach = session.status === 'disconnected' && (await isTerminalDetached(sessionId));
+    if (!reattach && !['pending', 'connecting', 'active'].includes(session.status)) {
       return { valid: false, error: `Session is ${session.status}` };
     }
 
@@ -170,7 +177,12 @@ async function validateTerminalAccess(
       return { valid: false, error: policyCheck.reason ?? 'Remote tools disabled by policy' };
     }
 
-    return { valid: true, session, device, userId: user.id };
+    // Claim the detached PTY last so a rejected connect leaves it reattachable.
+    if (reattach && !(await claimDetachedTerminal(sessionId))) {
+      return { valid: false, error: 'Session is disconnected' };
+    }
+
+    return { valid: true, session, device, userId: user.id, reattach };
   });
 }
 
@@ -252,6 +264,21 @@ export function closeTerminalSession(sessionId: string): boolean {
   return true;
 }
 
+/**
+ * Start a fresh shell for a session whose `terminal_attach` failed because the
+ * agent no longer holds its PTY (grace expired, agent restarted, or detach
+ * disabled on the agent). Returns false when there is no live socket here.
+ */
+export function restartTerminalSession(sessionId: string): boolean {
+  const termSession = activeTerminalSessions.get(sessionId);
+  if (!termSession) return false;
+  return sendCommandToAgent(termSession.agentId, {
+    id: `term-start-${sessionId}`,
+    type: 'terminal_start',
+    payload: termSession.startPayload,
+  });
+}
+
 /**
  * Create WebSocket handlers for terminal session
  */
@@ -284,7 +311,7 @@ function createTerminalWsHandlers(
           return;
         }
 
-        const { session, device, userId } = validationResult;
+        const { session, device, userId, reattach } = validationResult;
         if (!session || !device || !userId) {
           ws.close(4001, 'Invalid session data');
           return;
@@ -319,6 +346,23 @@ function createTerminalWsHandlers(
         // All validation passed — safe to touch DB state for this session
         validated = true;
 
+        // Shell, cwd, env and run-as context come from the session create
+        // request, validated there; without a shell Windows keeps PowerShell
+        // and others the agent's default. The agent records the mode in its
+        // audit log and never falls back to SYSTEM/root for a user mode.
+        const options = session.terminalOptions ?? {};
+        const startPayload: Record<string, unknown> = {
+          sessionId,
+          cols: 80,
+          rows: 24,
+          shell: options.shell ?? (device.osType === 'windows' ? 'powershell' : undefined),
+          cwd: options.cwd,
+          env: options.env,
+          mode: options.mode,
+          username: options.username,
+          password: decryptForColumn('remote_sessions', 'terminal_password', session.terminalPassword) ?? undefined
+        };
+
         // Store the terminal session
         const now = Date.now();
         activeTerminalSessions.set(sessionId, {
@@ -327,7 +371,8 @@ function createTerminalWsHandlers(
           userId,
           deviceId: device.id,
           orgId: device.orgId,
-          startedAt: new Date(),
+          startedAt: reattach && session.startedAt ? session.startedAt : new Date(),
+          startPayload,
           lastPongAt: now,
           msgTimestamps: [],
           msgByteTimestamps: [],
@@ -348,16 +393,16 @@ function createTerminalWsHandlers(
           }
         });
 
-        console.log(`Terminal session ${sessionId} connected for device ${device.hostname}`);
+        console.log(`Terminal session ${sessionId} ${reattach ? 'reattached' : 'connected'} for device ${device.hostname}`);
 
-        // Update session status
+        // Update session status. A reattach keeps the original startedAt and
+        // reopens the row the dropped socket closed.
         await withSystemDbAccessContext(async () => {
           await db
             .update(remoteSessions)
-            .set({
-              status: 'active',
-              startedAt: new Date()
-            })
+            .set(reattach
+              ? { status: 'active', endedAt: null, durationSeconds: null }
+              : { status: 'active', startedAt: new Date(), terminalPassword: null })
             .where(eq(remoteSessions.id, sessionId));
         });
 
@@ -365,23 +410,26 @@ function createTerminalWsHandlers(
         ws.send(JSON.stringify({
           type: 'connected',
           sessionId,
+          reattached: reattach === true,
           device: {
             hostname: device.hostname,
             osType: device.osType
           }
         }));
 
-        // Send terminal_start command to agent
-        const startCommand = {
-          id: `term-start-${sessionId}`,
-          type: 'terminal_start',
-          payload: {
-            sessionId,
-            cols: 80,
-            rows: 24,
-            shell: device.osType === 'windows' ? 'powershell' : undefined
+        // Reattach to the detached PTY (the agent replays its scrollback), or
+        // send terminal_start for a new shell.
+        const startCommand = reattach
+          ? {
+            id: `term-attach-${sessionId}`,
+            type: 'terminal_attach',
+            payload: { sessionId, cols: 80, rows: 24 }
           }
-        };
+          : {
+            id: `term-start-${sessionId}`,
+            type: 'terminal_start',
+            payload: startPayload
+          };
 
         const sent = sendCommandToAgent(device.agentId, startCommand);
         if (!sent) {
@@ -607,7 +655,7 @@ function createTerminalWsHandlers(
       }
     },
 
-    onClose: async (_event: unknown, _ws: WSContext) => {
+    onClose: async (event: unknown, _ws: WSContext) => {
       const termSession = activeTerminalSessions.get(sessionId);
 
       if (termSession) {
@@ -616,17 +664,24 @@ function createTerminalWsHandlers(
           clearInterval(termSession.pingInterval);
         }
 
+        // Clean up
+        activeTerminalSessions.delete(sessionId);
+        unregisterTerminalOutputCallback(sessionId);
+
+        // A deliberate close (1000) ends the shell. Anything else is a dropped
+        // viewer (network blip, browser reload), so ask the agent to keep the
+        // PTY detached for a reconnect — but only once the marker that lets
+        // the reconnect find it is written.
+        const closeCode = (event as { code?: number } | null)?.code;
+        const detach = closeCode !== 1000 && (await markTerminalDetached(sessionId));
+
         // Send terminal_stop command to agent
         sendCommandToAgent(termSession.agentId, {
           id: `term-stop-${sessionId}`,
           type: 'terminal_stop',
-          payload: { sessionId }
+          payload: detach ? { sessionId, detach: true } : { sessionId }
         });
 
-        // Clean up
-        activeTerminalSessions.delete(sessionId);
-        unregisterTerminalOutputCallback(sessionId);
-
         // Update session status
         const endedAt = new Date();
         const startedAt = termSession.startedAt;
@@ -661,7 +716,7 @@ function createTerminalWsHandlers(
           console.error(`[TerminalWs] Failed to write session summary for ${sessionId}:`, auditErr);
         }
 
-        console.log(`Terminal session ${sessionId} disconnected (duration: ${durationSeconds}s)`);
+        console.log(`Terminal session ${sessionId} ${detach ? 'detached' : 'disconnected'} (duration: ${durationSeconds}s)`);
       }
     },
 
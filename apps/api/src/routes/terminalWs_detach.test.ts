@@ -0,0 +1,244 @@
+import { beforeEach, describe, expect, it, vi } from 'vitest';
+
+// -------------------------------------------------------------------
+// Mocks — declared before any import that triggers terminalWs's deps.
+// -------------------------------------------------------------------
+
+vi.mock('../db', () => ({
+  runOutsideDbContext: vi.fn((fn: () => unknown) => fn()),
+  withDbAccessContext: vi.fn(async (_ctx: unknown, fn: () => Promise<unknown>) => fn()),
+  withSystemDbAccessContext: vi.fn(async (fn: () => Promise<unknown>) => fn()),
+  db: { select: vi.fn(), update: vi.fn(), insert: vi.fn() },
+}));
+
+vi.mock('../db/schema', () => ({
+  remoteSessions: { id: 'remoteSessions.id', deviceId: 'remoteSessions.deviceId', status: 'remoteSessions.status' },
+  devices: { id: 'devices.id' },
+  users: { id: 'users.id', status: 'users.status' },
+}));
+
+vi.mock('../services/remoteSessionAuth', () => ({ consumeWsTicket: vi.fn() }));
+
+vi.mock('./agentWs', () => ({
+  sendCommandToAgent: vi.fn(() => true),
+  isAgentConnected: vi.fn(() => true),
+}));
+
+vi.mock('../services/remoteAccessPolicy', () => ({
+  checkRemoteAccess: vi.fn().mockResolvedValue({ allowed: true }),
+}));
+
+vi.mock('../services/redis', () => ({ getRedis: vi.fn(() => ({})) }));
+
+vi.mock('../services/rate-limit', () => ({
+  rateLimiter: vi.fn(async () => ({ allowed: true, remaining: 9, resetAt: new Date(Date.now() + 60_000) })),
+}));
+
+vi.mock('./remote/helpers', () => ({
+  logSessionAudit: vi.fn(async () => undefined),
+  getIceServers: vi.fn(() => []),
+}));
+
+vi.mock('../services/viewerTokenRevocation', () => ({
+  isViewerSessionRevoked: vi.fn().mockResolvedValue(false),
+}));
+
+vi.mock('../services/terminalDetach', () => ({
+  markTerminalDetached: vi.fn().mockResolvedValue(true),
+  isTerminalDetached: vi.fn().mockResolvedValue(false),
+  claimDetachedTerminal: vi.fn().mockResolvedValue(false),
+}));
+
+import { db } from '../db';
+import { consumeWsTicket } from '../services/remoteSessionAuth';
+import { sendCommandToAgent, isAgentConnected } from './agentWs';
+import { claimDetachedTerminal, isTerminalDetached, markTerminalDetached } from '../services/terminalDetach';
+import {
+  closeTerminalSession,
+  createTerminalWsRoutes,
+  getActiveTerminalSession,
+  restartTerminalSession,
+} from './terminalWs';
+
+const DEVICE_ID = 'device-detach';
+const AGENT_ID = 'agent-detach';
+
+let sessionCounter = 0;
+const nextSessionId = () => `session-detach-${++sessionCounter}`;
+
+function wsMock() {
+  return { send: vi.fn(), close: vi.fn() };
+}
+
+function mockUpdateCapture() {
+  const set = vi.fn().mockReturnValue({ where: vi.fn().mockResolvedValue(undefined) });
+  return { update: { set } as any, set };
+}
+
+function captureWsHandlers(sessionId: string) {
+  let capturedFactory: any;
+  const upgradeWebSocket = vi.fn((factory: any) => {
+    capturedFactory = factory;
+    return (_c: any, _next: any) => {};
+  });
+  createTerminalWsRoutes(upgradeWebSocket);
+  const fakeContext = {
+    req: {
+      param: vi.fn((key: string) => (key === 'id' ? sessionId : undefined)),
+      query: vi.fn((key: string) => (key === 'ticket' ? 'ticket-xyz' : undefined)),
+      header: vi.fn(() => undefined),
+    },
+  };
+  return capturedFactory(fakeContext);
+}
+
+/** Drive onOpen for a session row in the given status. */
+async function openSession(sessionId: string, status: string, startedAt?: Date) {
+  const userId = `user-${sessionId}`;
+  vi.mocked(consumeWsTicket).mockResolvedValue({
+    ok: true as const,
+    sessionId,
+    sessionType: 'terminal' as const,
+    userId,
+    expiresAt: Date.now() + 60_000,
+  });
+  const user = { id: userId, status: 'active' };
+  const session = { id: sessionId, type: 'terminal', userId, status, deviceId: DEVICE_ID, startedAt: startedAt ?? null };
+  const device = { id: DEVICE_ID, agentId: AGENT_ID, hostname: 'h', osType: 'linux', status: 'online', orgId: 'org-1' };
+
+  vi.mocked(db.select)
+    .mockReturnValueOnce({
+      from: vi.fn().mockReturnValue({
+        where: vi.fn().mockReturnValue({ limit: vi.fn().mockResolvedValue([user]) }),
+      }),
+    } as any)
+    .mockReturnValueOnce({
+      from: vi.fn().mockReturnValue({
+        innerJoin: vi.fn().mockReturnValue({
+          where: vi.fn().mockReturnValue({ limit: vi.fn().mockResolvedValue([{ session, device }]) }),
+        }),
+      }),
+    } as any);
+  vi.mocked(isAgentConnected).mockReturnValue(true);
+  vi.mocked(sendCommandToAgent).mockReturnValue(true);
+  const { update, set } = mockUpdateCapture();
+  vi.mocked(db.update).mockReturnValue(update);
+
+  const ws = wsMock();
+  const handlers = captureWsHandlers(sessionId);
+  await handlers.onOpen({}, ws);
+  return { ws, handlers, set };
+}
+
+describe('terminal detach on socket close', () => {
+  beforeEach(() => vi.clearAllMocks());
+
+  it('asks the agent to detach the PTY when the viewer drops', async () => {
+    const sessionId = nextSessionId();
+    const { ws, handlers } = await openSession(sessionId, 'pending');
+    vi.mocked(sendCommandToAgent).mockClear();
+
+    await handlers.onClose({ code: 1006 }, ws);
+
+    expect(markTerminalDetached).toHaveBeenCalledWith(sessionId);
+    expect(sendCommandToAgent).toHaveBeenCalledWith(AGENT_ID, {
+      id: `term-stop-${sessionId}`,
+      type: 'terminal_stop',
+      payload: { sessionId, detach: true },
+    });
+    expect(getActiveTerminalSession(sessionId)).toBeUndefined();
+  });
+
+  it('stops the PTY on a deliberate close (1000)', async () => {
+    const sessionId = nextSessionId();
+    const { ws, handlers } = await openSession(sessionId, 'pending');
+    vi.mocked(sendCommandToAgent).mockClear();
+
+    await handlers.onClose({ code: 1000 }, ws);
+
+    expect(markTerminalDetached).not.toHaveBeenCalled();
+    expect(sendCommandToAgent).toHaveBeenCalledWith(AGENT_ID, {
+      id: `term-stop-${sessionId}`,
+      type: 'terminal_stop',
+      payload: { sessionId },
+    });
+  });
+
+  it('stops the PTY when the detached marker cannot be written', async () => {
+    const sessionId = nextSessionId();
+    const { ws, handlers } = await openSession(sessionId, 'pending');
+    vi.mocked(markTerminalDetached).mockResolvedValueOnce(false);
+    vi.mocked(sendCommandToAgent).mockClear();
+
+    await handlers.onClose({ code: 1006 }, ws);
+
+    expect(sendCommandToAgent).toHaveBeenCalledWith(
+      AGENT_ID,
+      expect.objectContaining({ type: 'terminal_stop', payload: { sessionId } })
+    );
+  });
+});
+
+describe('terminal reattach', () => {
+  beforeEach(() => vi.clearAllMocks());
+
+  it('sends terminal_attach for a disconnected session with a detached PTY', async () => {
+    const sessionId = nextSessionId();
+    const startedAt = new Date('2026-10-18T10:00:00Z');
+    vi.mocked(isTerminalDetached).mockResolvedValueOnce(true);
+    vi.mocked(claimDetachedTerminal).mockResolvedValueOnce(true);
+
+    const { ws, set } = await openSession(sessionId, 'disconnected', startedAt);
+
+    expect(sendCommandToAgent).toHaveBeenCalledWith(AGENT_ID, {
+      id: `term-attach-${sessionId}`,
+      type: 'terminal_attach',
+      payload: { sessionId, cols: 80, rows: 24 },
+    });
+    expect(sendCommandToAgent).not.toHaveBeenCalledWith(AGENT_ID, expect.objectContaining({ type: 'terminal_start' }));
+    expect(set).toHaveBeenCalledWith({ status: 'active', endedAt: null, durationSeconds: null });
+    const connected = ws.send.mock.calls.map(([raw]) => JSON.parse(raw)).find((m) => m.type === 'connected');
+    expect(connected?.reattached).toBe(true);
+    expect(getActiveTerminalSession(sessionId)?.startedAt).toEqual(startedAt);
+
+    closeTerminalSession(sessionId);
+  });
+
+  it('rejects a disconnected session whose PTY is no longer detached', async () => {
+    const sessionId = nextSessionId();
+    const { ws } = await openSession(sessionId, 'disconnected');
+
+    expect(ws.close).toHaveBeenCalledWith(4001, 'Authentication failed');
+    expect(claimDetachedTerminal).not.toHaveBeenCalled();
+    expect(sendCommandToAgent).not.toHaveBeenCalled();
+  });
+
+  it('rejects when another socket claimed the detached PTY first', async () => {
+    const sessionId = nextSessionId();
+    vi.mocked(isTerminalDetached).mockResolvedValueOnce(true);
+    vi.mocked(claimDetachedTerminal).mockResolvedValueOnce(false);
+
+    const { ws } = await openSession(sessionId, 'disconnected');
+
+    expect(ws.close).toHaveBeenCalledWith(4001, 'Authentication failed');
+    expect(sendCommandToAgent).not.toHaveBeenCalled();
+  });
+
+  it('restartTerminalSession starts a fresh shell with the stored start payload', async () => {
+    const sessionId = nextSessionId();
+    vi.mocked(isTerminalDetached).mockResolvedValueOnce(true);
+    vi.mocked(claimDetachedTerminal).mockResolvedValueOnce(true);
+    await openSession(sessionId, 'disconnected');
+    vi.mocked(sendCommandToAgent).mockClear();
+
+    expect(restartTerminalSession(sessionId)).toBe(true);
+    expect(sendCommandToAgent).toHaveBeenCalledWith(AGENT_ID, {
+      id: `term-start-${sessionId}`,
+      type: 'terminal_start',
+      payload: expect.objectContaining({ sessionId, cols: 80, rows: 24 }),
+    });
+
+    closeTerminalSession(sessionId);
+    expect(restartTerminalSession(sessionId)).toBe(false);
+  });
+});
@@ -67,6 +67,11 @@ vi.mock('./remote/helpers', () => ({
   getIceServers: vi.fn(() => []),
 }));
 
+vi.mock('../services/secretCrypto', () => ({
+  decryptForColumn: vi.fn((_table: string, _column: string, value: string | null | undefined) =>
+    value ? value.replace(/^enc:/, '') : null),
+}));
+
 // -------------------------------------------------------------------
 // Imports (after mocks)
 // -------------------------------------------------------------------
@@ -160,7 +165,11 @@ function captureWsHandlers(sessionId: string, ticket?: string) {
  * Set up database + auth mocks so that onOpen succeeds.
  * Uses a unique user ID each time to avoid the in-memory rate limiter.
  */
-function setupSuccessfulValidation(overrides?: { osType?: string }) {
+function setupSuccessfulValidation(overrides?: {
+  osType?: string;
+  terminalOptions?: Record<string, unknown>;
+  terminalPassword?: string;
+}) {
   const userId = nextUserId();
 
   const ticketRecord = {
@@ -179,7 +188,9 @@ function setupSuccessfulValidation(overrides?: { osType?: string }) {
     type: 'terminal',
     userId,
     status: 'pending',
-    deviceId: DEVICE_ID
+    deviceId: DEVICE_ID,
+    terminalOptions: overrides?.terminalOptions ?? null,
+    terminalPassword: overrides?.terminalPassword ?? null
   };
   const device = {
     id: DEVICE_ID,
@@ -530,6 +541,59 @@ describe('terminalWs', () => {
         })
       );
     });
+
+    it('forwards the shell, cwd and env requested for the session', async () => {
+      setupSuccessfulValidation({
+        osType: 'windows',
+        terminalOptions: { shell: 'cmd', cwd: 'C:\\Temp', env: { BUILD: '1' } }
+      });
+      vi.mocked(db.update).mockReturnValue(mockUpdateNoReturn() as any);
+
+      const handlers = captureWsHandlers(SESSION_ID, 'valid-ticket');
+      const ws = wsMock();
+
+      await handlers.onOpen({}, ws);
+
+      expect(sendCommandToAgent).toHaveBeenCalledWith(
+        AGENT_ID,
+        expect.objectContaining({
+          type: 'terminal_start',
+          payload: expect.objectContaining({
+            shell: 'cmd',
+            cwd: 'C:\\Temp',
+            env: { BUILD: '1' }
+          })
+        })
+      );
+    });
+
+    it('forwards the run-as mode and credentials, then clears the stored password', async () => {
+      setupSuccessfulValidation({
+        osType: 'windows',
+        terminalOptions: { mode: 'named_user', username: 'CORP\\svc-deploy' },
+        terminalPassword: 'enc:hunter2'
+      });
+      const update = mockUpdateNoReturn();
+      vi.mocked(db.update).mockReturnValue(update);
+
+      const handlers = captureWsHandlers(SESSION_ID, 'valid-ticket');
+      const ws = wsMock();
+
+      await handlers.onOpen({}, ws);
+
+      expect(sendCommandToAgent).toHaveBeenCalledWith(
+        AGENT_ID,
+        expect.objectContaining({
+          type: 'terminal_start',
+          payload: expect.objectContaining({
+            mode: 'named_user',
+            username: 'CORP\\svc-deploy',
+            password: 'hunter2'
+          })
+        })
+      );
+      expect(update.set).toHaveBeenCalledWith(expect.objectContaining({ status: 'active', terminalPassword: null }));
+    });
   });
 
 });
@@ -75,6 +75,9 @@ vi.mock('../services/vulnerabilityFleetQueries', () => ({
   fetchFleetFindingRows: vi.fn(async () => []),
   fetchCveCatalogRecord: vi.fn(async () => null),
 }));
+vi.mock('../services/vulnerabilityScanSnapshot', () => ({
+  listVulnerabilityScanFindings: vi.fn(async () => []),
+}));
 vi.mock('../services/auditEvents', () => ({ writeRouteAudit: vi.fn() }));
 vi.mock('../services/ticketService', async () => {
   const actual = await vi.importActual<typeof import('../services/ticketService')>('../services/ticketService');
@@ -89,6 +92,7 @@ vi.mock('../jobs/vulnerabilityJobs', () => ({
 
 import { vulnerabilityRoutes, vulnerabilitySyncRoutes } from './vulnerabilities';
 import { fetchFleetFindingRows, fetchCveCatalogRecord } from '../services/vulnerabilityFleetQueries';
+import { listVulnerabilityScanFindings } from '../services/vulnerabilityScanSnapshot';
 import type { FleetFindingRow } from '../services/vulnerabilityFleetAggregation';
 import { createTicket, TicketServiceError } from '../services/ticketService';
 
@@ -657,6 +661,46 @@ describe('GET /vulnerabilities/devices/:deviceId', () => {
   });
 });
 
+describe('GET /vulnerabilities/devices/:deviceId/agent-scan', () => {
+  beforeEach(() => {
+    granted.clear();
+    granted.add('devices:read');
+    vi.mocked(db.select).mockReset();
+    vi.mocked(listVulnerabilityScanFindings).mockReset().mockResolvedValue([]);
+  });
+
+  it('returns the device’s latest agent scan findings', async () => {
+    vi.mocked(db.select).mockReturnValueOnce({
+      from: vi.fn().mockReturnValue({
+        where: vi.fn().mockReturnValue({ limit: vi.fn().mockResolvedValue([{ siteId: 'site-1' }]) }),
+      }),
+    } as never);
+    vi.mocked(listVulnerabilityScanFindings).mockResolvedValue([{
+      softwareName: 'Google Chrome',
+      softwareVersion: '124.0.6367.91',
+      softwareVendor: 'Google LLC',
+      cveId: 'CVE-2024-4671',
+      cvssScore: 9.6,
+      severity: 'critical',
+      knownExploited: true,
+      fixedVersion: '124.0.6367.201',
+      scannedAt: '2026-10-18T13:05:00.000Z',
+    }]);
+    const res = await app().request(`/vulnerabilities/devices/${ID}/agent-scan`);
+    expect(res.status).toBe(200);
+    const body = await res.json();
+    expect(body.scannedAt).toBe('2026-10-18T13:05:00.000Z');
+    expect(body.findings[0].cveId).toBe('CVE-2024-4671');
+    expect(vi.mocked(listVulnerabilityScanFindings)).toHaveBeenCalledWith(ID);
+  });
+
+  it('404s when the device is not in the caller’s tenant/site scope', async () => {
+    const res = await app().request(`/vulnerabilities/devices/${ID}/agent-scan`);
+    expect(res.status).toBe(404);
+    expect(vi.mocked(listVulnerabilityScanFindings)).not.toHaveBeenCalled();
+  });
+});
+
 describe('GET /vulnerabilities/:cveId/devices (CVE drawer payload)', () => {
   beforeEach(() => {
     vi.mocked(fetchFleetFindingRows).mockReset().mockResolvedValue([]);
@@ -29,6 +29,7 @@ import {
   type FleetFindingRow,
 } from '../services/vulnerabilityFleetAggregation';
 import { fetchCveCatalogRecord, fetchFleetFindingRows } from '../services/vulnerabilityFleetQueries';
+import { listVulnerabilityScanFindings } from '../services/vulnerabilityScanSnapshot';
 import { writeRouteAudit } from '../services/auditEvents';
 import { createTicket } from '../services/ticketService';
 import { platformAdminMiddleware } from '../middleware/platformAdmin';
@@ -734,11 +735,30 @@ vulnerabilityRoutes.get(
   },
 );
 
+// The device's latest agent-side scan: installed package versions the agent
+// matched against its vulnerability snapshot. Reported independently of the
+// correlated findings above, so the two can be compared.
+vulnerabilityRoutes.get(
+  '/devices/:deviceId/agent-scan',
+  zValidator('param', deviceParamSchema),
+  async (c) => {
+    const auth = c.get('auth');
+    const { deviceId } = c.req.valid('param');
+    // Intra-org site gate (RLS isolates orgs, not sites).
+    const access = await assertDeviceSiteAccess(deviceId, auth);
+    if (!access.ok) {
+      return c.json({ error: access.error }, access.status);
+    }
+    const findings = await listVulnerabilityScanFindings(deviceId);
+    return c.json({ scannedAt: findings[0]?.scannedAt ?? null, findings });
+  },
+);
+
 // CVE drawer payload: catalog record + fleet findings for that CVE. Registered
 // LAST among the GET routes — a param in the first path segment would
 // otherwise shadow every static-first-segment GET route above it
 // (/software, /software/:groupKey, /stats, /devices/:deviceId,
-// /devices/:deviceId/software).
+// /devices/:deviceId/software, /devices/:deviceId/agent-scan).
 vulnerabilityRoutes.get('/:cveId/devices', zValidator('param', cveIdParamSchema), zValidator('query', orgScopeQuerySchema), async (c) => {
   const auth = c.get('auth');
   const { cveId } = c.req.valid('param');
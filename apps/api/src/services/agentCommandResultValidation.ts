@@ -70,6 +70,7 @@ export const backupVerificationStructuredResultSchema = z.object({
   status: z.enum(['passed', 'failed', 'partial']),
   filesVerified: z.number().int().nonnegative().optional(),
   filesFailed: z.number().int().nonnegative().optional(),
+  filesInSnapshot: z.number().int().nonnegative().optional(),
   sizeBytes: z.number().nonnegative().refine(Number.isInteger, 'expected integer').optional(),
   durationMs: z.number().int().nonnegative().optional(),
   restoreTimeSeconds: z.number().int().nonnegative().optional(),
@@ -852,7 +852,7 @@ export const toolInputSchemas: Record<string, z.ZodType> = {
     deviceId: uuid.optional(),
     startTime: z.string().datetime({ offset: true }).optional(),
     endTime: z.string().datetime({ offset: true }).optional(),
-    changeType: z.enum(['software', 'service', 'startup', 'network', 'scheduled_task', 'user_account', 'hardware', 'os_version']).optional(),
+    changeType: z.enum(['software', 'service', 'startup', 'network', 'scheduled_task', 'user_account', 'hardware', 'os_version', 'usb_device', 'registry', 'defender_policy']).optional(),
     changeAction: z.enum(['added', 'removed', 'modified', 'updated']).optional(),
     limit: z.number().int().min(1).max(500).optional(),
   }),
@@ -203,7 +203,7 @@ export function registerAuditTools(aiTools: Map<string, AiTool>): void {
           endTime: { type: 'string', description: 'Optional ISO timestamp upper bound (inclusive)' },
           changeType: {
             type: 'string',
-            enum: ['software', 'service', 'startup', 'network', 'scheduled_task', 'user_account', 'hardware', 'os_version'],
+            enum: ['software', 'service', 'startup', 'network', 'scheduled_task', 'user_account', 'hardware', 'os_version', 'usb_device', 'registry', 'defender_policy'],
             description: 'Optional change category filter'
           },
           changeAction: {
@@ -192,7 +192,7 @@ export function registerIncidentTools(aiTools: Map<string, AiTool>): void {
           },
           actionType: {
             type: 'string',
-            enum: ['process_kill', 'network_isolation', 'account_disable', 'usb_block'],
+            enum: ['process_kill', 'network_isolation', 'network_isolation_release', 'account_disable', 'usb_block'],
             description: 'Type of containment action',
           },
           parameters: {
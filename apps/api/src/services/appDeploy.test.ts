@@ -0,0 +1,68 @@
+import { describe, expect, it, vi } from 'vitest';
+
+vi.mock('../db/schema', () => ({
+  softwareCatalog: {},
+  softwareVersions: {},
+}));
+
+import { buildAppDeployDefinition, buildAppDeployPayload } from './appDeploy';
+
+const VERSION = {
+  id: 'version-1',
+  version: '4.2.0',
+  downloadUrl: 'https://downloads.example.com/acme-4.2.0.rpm',
+  fileType: 'rpm',
+  originalFileName: 'acme-4.2.0.rpm',
+  checksum: 'a'.repeat(64),
+  silentInstallArgs: null,
+  detectionRules: [{ type: 'file_exists', path: '/opt/acme/bin/acme' }],
+};
+
+describe('buildAppDeployDefinition', () => {
+  it('maps a catalog version to the agent app definition', () => {
+    const result = buildAppDeployDefinition({ name: 'Acme Client' }, VERSION);
+
+    expect(result).toEqual({
+      definition: {
+        id: 'version-1',
+        name: 'Acme Client',
+        version: '4.2.0',
+        downloadUrl: 'https://downloads.example.com/acme-4.2.0.rpm',
+        fileName: 'acme-4.2.0.rpm',
+        fileType: 'rpm',
+        checksum: 'a'.repeat(64),
+        detectionRules: [{ type: 'file_exists', path: '/opt/acme/bin/acme' }],
+      },
+    });
+  });
+
+  it('prefers an override URL and carries forceReinstall', () => {
+    const result = buildAppDeployDefinition({ name: 'Acme Client' }, VERSION, {
+      downloadUrl: 'https://s3.example.com/presigned',
+      forceReinstall: true,
+    });
+
+    expect(result).toMatchObject({
+      definition: { downloadUrl: 'https://s3.example.com/presigned', forceReinstall: true },
+    });
+  });
+
+  it('rejects versions without an installer or checksum', () => {
+    expect(buildAppDeployDefinition({ name: 'Acme' }, { ...VERSION, downloadUrl: null })).toEqual({
+      error: 'No installer available for this version',
+    });
+    expect(buildAppDeployDefinition({ name: 'Acme' }, { ...VERSION, checksum: null })).toHaveProperty('error');
+  });
+});
+
+describe('buildAppDeployPayload', () => {
+  it('wraps definitions with the requested action', () => {
+    const result = buildAppDeployDefinition({ name: 'Acme Client' }, VERSION);
+    if (!('definition' in result)) throw new Error('expected a definition');
+
+    expect(buildAppDeployPayload([result.definition], 'detect')).toEqual({
+      action: 'detect',
+      apps: [result.definition],
+    });
+  });
+});
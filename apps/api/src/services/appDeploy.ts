@@ -0,0 +1,78 @@
+import { softwareCatalog, softwareVersions } from '../db/schema';
+
+/**
+ * App definition the agent's `app_deploy` command consumes (agent side:
+ * internal/appdeploy). One command carries one or more definitions under
+ * `apps`; results come back in the install_patches result schema with
+ * `source: 'app_catalog'` and the definition id as `externalId`.
+ */
+export interface AppDeployDefinition {
+  id: string;
+  name: string;
+  version: string;
+  downloadUrl: string;
+  fileName: string;
+  fileType: string;
+  checksum: string;
+  silentInstallArgs?: string;
+  detectionRules?: unknown[];
+  forceReinstall?: boolean;
+}
+
+export type AppDeployAction = 'install' | 'detect';
+
+type CatalogItem = Pick<typeof softwareCatalog.$inferSelect, 'name'>;
+type VersionRecord = Pick<
+  typeof softwareVersions.$inferSelect,
+  'id' | 'version' | 'downloadUrl' | 'fileType' | 'originalFileName' | 'checksum' | 'silentInstallArgs' | 'detectionRules'
+>;
+
+export type BuildAppDeployDefinitionResult =
+  | { definition: AppDeployDefinition }
+  | { error: string };
+
+/**
+ * Builds the app_deploy definition for a catalog version. The agent refuses
+ * to run a catalog installer without a SHA-256, so versions missing one (or
+ * an installer URL) are rejected here rather than failing on every device.
+ * `downloadUrl` overrides the stored URL, e.g. with a presigned S3 link.
+ */
+export function buildAppDeployDefinition(
+  catalogItem: CatalogItem,
+  versionRecord: VersionRecord,
+  options: { downloadUrl?: string | null; forceReinstall?: boolean } = {},
+): BuildAppDeployDefinitionResult {
+  const downloadUrl = options.downloadUrl ?? versionRecord.downloadUrl;
+  if (!downloadUrl) {
+    return { error: 'No installer available for this version' };
+  }
+  if (!versionRecord.checksum) {
+    return { error: 'Catalog app versions need a SHA-256 checksum before they can be deployed' };
+  }
+
+  const fileType = versionRecord.fileType ?? 'exe';
+  return {
+    definition: {
+      id: versionRecord.id,
+      name: catalogItem.name,
+      version: versionRecord.version,
+      downloadUrl,
+      fileName: versionRecord.originalFileName ?? `package.${fileType}`,
+      fileType,
+      checksum: versionRecord.checksum,
+      ...(versionRecord.silentInstallArgs ? { silentInstallArgs: versionRecord.silentInstallArgs } : {}),
+      ...(Array.isArray(versionRecord.detectionRules) && versionRecord.detectionRules.length > 0
+        ? { detectionRules: versionRecord.detectionRules }
+        : {}),
+      ...(options.forceReinstall ? { forceReinstall: true } : {}),
+    },
+  };
+}
+
+/** Payload for an app_deploy command. */
+export function buildAppDeployPayload(
+  definitions: AppDeployDefinition[],
+  action: AppDeployAction = 'install',
+): { action: AppDeployAction; apps: AppDeployDefinition[] } {
+  return { action, apps: definitions };
+}
@@ -0,0 +1,147 @@
+import { beforeEach, describe, expect, it, vi } from 'vitest';
+
+vi.mock('../db', () => ({
+  db: {
+    select: vi.fn(),
+    update: vi.fn(),
+  },
+}));
+
+vi.mock('../db/schema', () => ({
+  deviceCommands: {
+    id: 'deviceCommands.id',
+    deviceId: 'deviceCommands.deviceId',
+    type: 'deviceCommands.type',
+    status: 'deviceCommands.status',
+    payload: 'deviceCommands.payload',
+    result: 'deviceCommands.result',
+  },
+  devices: {
+    id: 'devices.id',
+    agentId: 'devices.agentId',
+  },
+  securityScans: {
+    id: 'securityScans.id',
+    deviceId: 'securityScans.deviceId',
+    status: 'securityScans.status',
+    threatsFound: 'securityScans.threatsFound',
+    itemsScanned: 'securityScans.itemsScanned',
+  },
+}));
+
+import { db } from '../db';
+import { applyAvScanProgress } from './avScanProgress';
+
+const COMMAND_UUID = '5b1c2d3e-4f50-4a61-8b72-9c8d7e6f5a4b';
+const SCAN_UUID = '6c2d3e4f-5a61-4b72-8c83-9d8e7f6a5b4c';
+const DEVICE_UUID = '7d3e4f5a-6b72-4c83-9d94-ae9f8a7b6c5d';
+
+// Shape the agent sends for security.AVScanProgress.
+const AGENT_EVENT = {
+  engine: 'clamav',
+  scanType: 'full',
+  phase: 'scanning',
+  itemsScanned: 1200,
+  threatsFound: 1,
+  currentPath: '/home/u/Downloads/eicar.com',
+  message: 'Still scanning (5m0s elapsed)',
+};
+
+function selectChain(rows: unknown[]) {
+  const chain: Record<string, any> = {};
+  for (const method of ['from', 'innerJoin', 'where']) {
+    chain[method] = vi.fn(() => chain);
+  }
+  chain.limit = vi.fn(() => Promise.resolve(rows));
+  return chain;
+}
+
+function updateChain(rows: unknown[]) {
+  const chain: Record<string, any> = {};
+  chain.set = vi.fn(() => chain);
+  chain.where = vi.fn(() => chain);
+  chain.returning = vi.fn(() => Promise.resolve(rows));
+  return chain;
+}
+
+function commandRow(overrides: Record<string, unknown> = {}) {
+  return {
+    id: COMMAND_UUID,
+    deviceId: DEVICE_UUID,
+    type: 'av_scan',
+    status: 'sent',
+    payload: { scanType: 'full', scanRecordId: SCAN_UUID },
+    agentId: 'agent-1',
+    ...overrides,
+  };
+}
+
+describe('applyAvScanProgress', () => {
+  beforeEach(() => {
+    vi.resetAllMocks();
+  });
+
+  it('records the event on the command and marks the linked scan running', async () => {
+    vi.mocked(db.select).mockReturnValue(selectChain([commandRow()]) as any);
+    vi.mocked(db.update)
+      .mockReturnValueOnce(updateChain([{ id: COMMAND_UUID }]) as any)
+      .mockReturnValueOnce(updateChain([]) as any);
+
+    const result = await applyAvScanProgress({ agentId: 'agent-1', commandId: COMMAND_UUID, progress: AGENT_EVENT });
+
+    expect(result).toEqual({ applied: true });
+    const commandUpdate = vi.mocked(db.update).mock.results[0]!.value;
+    expect(commandUpdate.set).toHaveBeenCalledWith({
+      result: { progress: { ...AGENT_EVENT, updatedAt: expect.any(String) } },
+    });
+    const scanUpdate = vi.mocked(db.update).mock.results[1]!.value;
+    expect(scanUpdate.set).toHaveBeenCalledWith({ status: 'running', threatsFound: 1, itemsScanned: 1200 });
+  });
+
+  it('does not touch security_scans when the command has no scan record', async () => {
+    vi.mocked(db.select).mockReturnValue(selectChain([commandRow({ payload: { scanType: 'quick' } })]) as any);
+    vi.mocked(db.update).mockReturnValue(updateChain([{ id: COMMAND_UUID }]) as any);
+
+    const result = await applyAvScanProgress({ agentId: 'agent-1', commandId: COMMAND_UUID, progress: AGENT_EVENT });
+
+    expect(result).toEqual({ applied: true });
+    expect(db.update).toHaveBeenCalledTimes(1);
+  });
+
+  it('drops progress for a command that already has its result', async () => {
+    vi.mocked(db.select).mockReturnValue(selectChain([commandRow({ status: 'completed' })]) as any);
+
+    const result = await applyAvScanProgress({ agentId: 'agent-1', commandId: COMMAND_UUID, progress: AGENT_EVENT });
+
+    expect(result).toEqual({ applied: false, reason: 'not-in-flight' });
+    expect(db.update).not.toHaveBeenCalled();
+  });
+
+  it('rejects progress from an agent that does not own the command', async () => {
+    vi.mocked(db.select).mockReturnValue(selectChain([commandRow()]) as any);
+
+    const result = await applyAvScanProgress({ agentId: 'agent-evil', commandId: COMMAND_UUID, progress: AGENT_EVENT });
+
+    expect(result).toEqual({ applied: false, reason: 'agent-mismatch' });
+    expect(db.update).not.toHaveBeenCalled();
+  });
+
+  it('ignores commands that are not av scans', async () => {
+    vi.mocked(db.select).mockReturnValue(selectChain([commandRow({ type: 'security_scan' })]) as any);
+
+    const result = await applyAvScanProgress({ agentId: 'agent-1', commandId: COMMAND_UUID, progress: AGENT_EVENT });
+
+    expect(result).toEqual({ applied: false, reason: 'not-found' });
+    expect(db.update).not.toHaveBeenCalled();
+  });
+
+  it('drops malformed payloads and non-UUID command ids before querying', async () => {
+    expect(
+      await applyAvScanProgress({ agentId: 'agent-1', commandId: COMMAND_UUID, progress: { ...AGENT_EVENT, engine: 'norton' } })
+    ).toEqual({ applied: false, reason: 'invalid-payload' });
+    expect(
+      await applyAvScanProgress({ agentId: 'agent-1', commandId: 'not-a-uuid', progress: AGENT_EVENT })
+    ).toEqual({ applied: false, reason: 'invalid-command-id' });
+    expect(db.select).not.toHaveBeenCalled();
+  });
+});
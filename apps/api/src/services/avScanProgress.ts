@@ -0,0 +1,108 @@
+import { z } from 'zod';
+import { and, eq, inArray } from 'drizzle-orm';
+import { db } from '../db';
+import { deviceCommands, devices, securityScans } from '../db/schema';
+import { UUID_REGEX } from '../utils/uuid';
+
+/**
+ * Payload of the agent's `av_scan_progress` WS message (agent side:
+ * security.AVScanProgress, sent by websocket.Client.SendAVScanProgress).
+ * Engines report little while they run, so the agent also re-sends the
+ * latest event every 30 s as a keepalive.
+ */
+export const avScanProgressPayloadSchema = z.object({
+  engine: z.enum(['defender', 'mdatp', 'clamav']),
+  scanType: z.string().max(32),
+  phase: z.string().max(32),
+  itemsScanned: z.number().int().nonnegative().optional(),
+  threatsFound: z.number().int().nonnegative(),
+  currentPath: z.string().max(2048).optional(),
+  message: z.string().max(1024).optional(),
+});
+
+export type AvScanProgressPayload = z.infer<typeof avScanProgressPayloadSchema>;
+
+export type ApplyAvScanProgressResult =
+  | { applied: true }
+  | {
+      applied: false;
+      reason: 'invalid-command-id' | 'invalid-payload' | 'not-found' | 'agent-mismatch' | 'not-in-flight';
+    };
+
+/**
+ * Records the latest progress event on an in-flight av_scan command as
+ * `result.progress` and moves the linked security_scans row to 'running'
+ * with its live counts. The terminal command_result replaces both. Drops
+ * (no throw) on anything that does not match a `sent` av_scan owned by the
+ * agent — this is a live signal, not a source of truth.
+ */
+export async function applyAvScanProgress(params: {
+  agentId: string;
+  commandId: string;
+  progress: unknown;
+}): Promise<ApplyAvScanProgressResult> {
+  if (!UUID_REGEX.test(params.commandId)) {
+    return { applied: false, reason: 'invalid-command-id' };
+  }
+
+  const parsed = avScanProgressPayloadSchema.safeParse(params.progress);
+  if (!parsed.success) {
+    return { applied: false, reason: 'invalid-payload' };
+  }
+
+  const [command] = await db
+    .select({
+      id: deviceCommands.id,
+      deviceId: deviceCommands.deviceId,
+      type: deviceCommands.type,
+      status: deviceCommands.status,
+      payload: deviceCommands.payload,
+      agentId: devices.agentId,
+    })
+    .from(deviceCommands)
+    .innerJoin(devices, eq(deviceCommands.deviceId, devices.id))
+    .where(eq(deviceCommands.id, params.commandId))
+    .limit(1);
+
+  if (!command || command.type !== 'av_scan') {
+    return { applied: false, reason: 'not-found' };
+  }
+
+  if (!command.agentId || command.agentId !== params.agentId) {
+    return { applied: false, reason: 'agent-mismatch' };
+  }
+
+  if (command.status !== 'sent') {
+    return { applied: false, reason: 'not-in-flight' };
+  }
+
+  const updated = await db
+    .update(deviceCommands)
+    .set({ result: { progress: { ...parsed.data, updatedAt: new Date().toISOString() } } })
+    .where(and(eq(deviceCommands.id, command.id), eq(deviceCommands.status, 'sent')))
+    .returning({ id: deviceCommands.id });
+
+  if (updated.length === 0) {
+    return { applied: false, reason: 'not-in-flight' };
+  }
+
+  const payload = (command.payload ?? {}) as Record<string, unknown>;
+  const scanRecordId = typeof payload.scanRecordId === 'string' ? payload.scanRecordId : undefined;
+  if (scanRecordId && UUID_REGEX.test(scanRecordId)) {
+    // Only queued/running rows: never reopen a scan the result already closed.
+    await db
+      .update(securityScans)
+      .set({
+        status: 'running',
+        threatsFound: parsed.data.threatsFound,
+        ...(parsed.data.itemsScanned !== undefined ? { itemsScanned: parsed.data.itemsScanned } : {}),
+      })
+      .where(and(
+        eq(securityScans.id, scanRecordId),
+        eq(securityScans.deviceId, command.deviceId),
+        inArray(securityScans.status, ['queued', 'running'])
+      ));
+  }
+
+  return { applied: true };
+}
@@ -0,0 +1,141 @@
+import { randomBytes } from 'crypto';
+import { beforeEach, describe, expect, it, vi } from 'vitest';
+
+process.env.APP_ENCRYPTION_KEY = process.env.APP_ENCRYPTION_KEY || 'test-app-encryption-key-for-vitest';
+
+const selectResults = vi.hoisted(() => [] as unknown[][]);
+
+function chain(rows: unknown[]) {
+  const builder: Record<string, unknown> = {};
+  for (const method of ['from', 'where', 'orderBy']) {
+    builder[method] = vi.fn(() => builder);
+  }
+  builder.limit = vi.fn(async () => rows);
+  return builder;
+}
+
+vi.mock('../db', () => ({
+  db: {
+    select: vi.fn(() => chain(selectResults.shift() ?? [])),
+    insert: vi.fn(),
+    update: vi.fn(),
+  },
+}));
+
+vi.mock('../db/schema', () => ({
+  backupSnapshots: { id: 'backupSnapshots.id', metadata: 'backupSnapshots.metadata' },
+  deviceBackupKeys: { id: 'deviceBackupKeys.id', deviceId: 'deviceBackupKeys.deviceId' },
+  storageEncryptionKeys: {
+    id: 'storageEncryptionKeys.id',
+    orgId: 'storageEncryptionKeys.orgId',
+    keyType: 'storageEncryptionKeys.keyType',
+    isActive: 'storageEncryptionKeys.isActive',
+    createdAt: 'storageEncryptionKeys.createdAt',
+    encryptedPrivateKey: 'storageEncryptionKeys.encryptedPrivateKey',
+  },
+}));
+
+import { db } from '../db';
+import { encryptSecret } from './secretCrypto';
+import { decryptSensitivePayloadFields } from './sensitiveCommandPayload';
+import {
+  attachClientBackupKey,
+  getDeviceBackupKey,
+  rewrapDeviceBackupKeyForOrg,
+  unwrapDeviceBackupKey,
+  wrapDeviceBackupKey,
+} from './backupClientKeys';
+
+const DEVICE_ID = '11111111-1111-4111-8111-111111111111';
+
+describe('backup client keys', () => {
+  beforeEach(() => {
+    selectResults.length = 0;
+    vi.mocked(db.update).mockReset();
+  });
+
+  it('wraps device keys bound to the device id', () => {
+    const orgKey = randomBytes(32);
+    const deviceKey = randomBytes(32);
+    const wrapped = wrapDeviceBackupKey(deviceKey, orgKey, DEVICE_ID);
+
+    expect(wrapped.startsWith('bwk1:')).toBe(true);
+    expect(unwrapDeviceBackupKey(wrapped, orgKey, DEVICE_ID).equals(deviceKey)).toBe(true);
+    expect(() => unwrapDeviceBackupKey(wrapped, orgKey, 'another-device')).toThrow();
+    expect(() => unwrapDeviceBackupKey(wrapped, randomBytes(32), DEVICE_ID)).toThrow();
+  });
+
+  it('returns null for a device that never used client-side encryption', async () => {
+    selectResults.push([]);
+    await expect(getDeviceBackupKey(DEVICE_ID)).resolves.toBeNull();
+    await expect(attachClientBackupKey('backup_restore', { snapshotId: 'snap-1' }, DEVICE_ID, 'snap-db-1'))
+      .resolves.toEqual({ snapshotId: 'snap-1' });
+  });
+
+  function orgKeyRow(id: string, orgKey: Buffer) {
+    return {
+      id,
+      encryptedPrivateKey: encryptSecret(orgKey.toString('base64'), { aad: 'storage_encryption_keys.encrypted_private_key' }),
+    };
+  }
+
+  function deviceKeyRows() {
+    const orgKey = randomBytes(32);
+    const deviceKey = randomBytes(32);
+    const deviceRow = {
+      id: 'device-key-1',
+      orgId: 'org-1',
+      deviceId: DEVICE_ID,
+      wrappingKeyId: 'org-key-1',
+      wrappedKey: wrapDeviceBackupKey(deviceKey, orgKey, DEVICE_ID),
+    };
+    return { deviceKey, rows: [[deviceRow], [orgKeyRow('org-key-1', orgKey)]] };
+  }
+
+  it('attaches the unwrapped device key, encrypted for storage', async () => {
+    const { deviceKey, rows } = deviceKeyRows();
+    selectResults.push(...rows, [{ metadata: { clientEncryptionKeyId: 'device-key-1' } }]);
+
+    const payload = await attachClientBackupKey('backup_restore', { snapshotId: 'snap-1' }, DEVICE_ID, 'snap-db-1');
+    const stored = payload.clientEncryption as Record<string, unknown>;
+    expect(stored.keyId).toBe('device-key-1');
+    expect(String(stored.key)).toMatch(/^enc:/);
+
+    const delivered = decryptSensitivePayloadFields('backup_restore', payload) as Record<string, any>;
+    expect(delivered.clientEncryption).toEqual({ keyId: 'device-key-1', key: deviceKey.toString('base64') });
+  });
+
+  it('lets the agent accept unencrypted objects only for snapshots written before encryption', async () => {
+    const plaintext = deviceKeyRows();
+    selectResults.push(...plaintext.rows, [{ metadata: { hasIndexedFiles: true } }]);
+    const legacy = await attachClientBackupKey('backup_restore', { snapshotId: 'snap-0' }, DEVICE_ID, 'snap-db-0');
+    expect((legacy.clientEncryption as Record<string, unknown>).plaintextSnapshot).toBe(true);
+
+    // A snapshot the server has no record of is treated as encrypted.
+    const unknown = deviceKeyRows();
+    selectResults.push(...unknown.rows);
+    const verify = await attachClientBackupKey('backup_verify', { snapshotId: 'snap-2' }, DEVICE_ID, null);
+    expect((verify.clientEncryption as Record<string, unknown>).plaintextSnapshot).toBeUndefined();
+  });
+
+  it('re-wraps the device key under the target org key on org move', async () => {
+    const { deviceKey, rows } = deviceKeyRows();
+    const targetOrgKey = randomBytes(32);
+    selectResults.push(...rows, [orgKeyRow('org-key-2', targetOrgKey)]);
+    const set = vi.fn(() => ({ where: vi.fn(async () => undefined) }));
+    vi.mocked(db.update).mockReturnValue({ set } as never);
+
+    await expect(rewrapDeviceBackupKeyForOrg(db, DEVICE_ID, 'org-2')).resolves.toBe(true);
+
+    const update = (set.mock.calls[0] as unknown[])[0] as Record<string, string>;
+    expect(update.orgId).toBe('org-2');
+    expect(update.wrappingKeyId).toBe('org-key-2');
+    expect(unwrapDeviceBackupKey(update.wrappedKey!, targetOrgKey, DEVICE_ID).equals(deviceKey)).toBe(true);
+  });
+
+  it('leaves devices without a backup key alone on org move', async () => {
+    selectResults.push([]);
+    await expect(rewrapDeviceBackupKeyForOrg(db, DEVICE_ID, 'org-2')).resolves.toBe(false);
+    expect(db.update).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,239 @@
+import { createCipheriv, createDecipheriv, createHash, randomBytes } from 'crypto';
+import { and, asc, eq } from 'drizzle-orm';
+import { db } from '../db';
+import { backupSnapshots, deviceBackupKeys, storageEncryptionKeys } from '../db/schema';
+import { decryptForColumn, encryptSecret } from './secretCrypto';
+import { encryptSensitivePayloadFields } from './sensitiveCommandPayload';
+
+// Client-side backup encryption keys.
+//
+// Each org has one escrowed client key: a storage_encryption_keys row with
+// key_type 'client_aes_256' whose material sits in encrypted_private_key,
+// encrypted with the server secret like every other registered secret
+// column. Each device gets its own data key, AES-256-GCM wrapped by the org
+// key. The agent only ever sees its device key, inside command payloads.
+
+export const CLIENT_BACKUP_KEY_TYPE = 'client_aes_256';
+
+const ORG_KEY_TABLE = 'storage_encryption_keys';
+const ORG_KEY_COLUMN = 'encrypted_private_key';
+const WRAPPED_KEY_PREFIX = 'bwk1:';
+const KEY_BYTES = 32;
+
+type DbExecutor = typeof db | Parameters<Parameters<typeof db.transaction>[0]>[0];
+
+/** A device data key as sent to the agent (`clientEncryption` payload field). */
+export interface ClientBackupKey {
+  keyId: string;
+  key: string; // base64
+  // Restore/verify only: the snapshot predates client-side encryption, so
+  // the agent accepts objects without an encryption header.
+  plaintextSnapshot?: boolean;
+}
+
+export function wrapDeviceBackupKey(deviceKey: Buffer, orgKey: Buffer, deviceId: string): string {
+  const iv = randomBytes(12);
+  const cipher = createCipheriv('aes-256-gcm', orgKey, iv);
+  cipher.setAAD(Buffer.from(deviceId, 'utf8'));
+  const ciphertext = Buffer.concat([cipher.update(deviceKey), cipher.final()]);
+  const tag = cipher.getAuthTag();
+  return `${WRAPPED_KEY_PREFIX}${Buffer.concat([iv, tag, ciphertext]).toString('base64')}`;
+}
+
+export function unwrapDeviceBackupKey(wrapped: string, orgKey: Buffer, deviceId: string): Buffer {
+  if (!wrapped.startsWith(WRAPPED_KEY_PREFIX)) {
+    throw new Error('Unsupported wrapped backup key format');
+  }
+  const raw = Buffer.from(wrapped.slice(WRAPPED_KEY_PREFIX.length), 'base64');
+  const iv = raw.subarray(0, 12);
+  const tag = raw.subarray(12, 28);
+  const ciphertext = raw.subarray(28);
+  const decipher = createDecipheriv('aes-256-gcm', orgKey, iv);
+  decipher.setAAD(Buffer.from(deviceId, 'utf8'));
+  decipher.setAuthTag(tag);
+  return Buffer.concat([decipher.update(ciphertext), decipher.final()]);
+}
+
+function decodeOrgKey(encrypted: string | null): Buffer {
+  const material = decryptForColumn(ORG_KEY_TABLE, ORG_KEY_COLUMN, encrypted);
+  const key = material ? Buffer.from(material, 'base64') : Buffer.alloc(0);
+  if (key.length !== KEY_BYTES) {
+    throw new Error('Escrowed backup client key is unreadable');
+  }
+  return key;
+}
+
+async function loadOrgClientKey(
+  orgId: string,
+  keyId?: string,
+  exec: DbExecutor = db,
+): Promise<{ id: string; key: Buffer } | null> {
+  const conditions = [
+    eq(storageEncryptionKeys.orgId, orgId),
+    eq(storageEncryptionKeys.keyType, CLIENT_BACKUP_KEY_TYPE),
+  ];
+  if (keyId) {
+    conditions.push(eq(storageEncryptionKeys.id, keyId));
+  } else {
+    conditions.push(eq(storageEncryptionKeys.isActive, true));
+  }
+  // Oldest first, so a key created by a concurrent first backup is ignored
+  // consistently rather than splitting devices across two org keys.
+  const [row] = await exec
+    .select({ id: storageEncryptionKeys.id, encryptedPrivateKey: storageEncryptionKeys.encryptedPrivateKey })
+    .from(storageEncryptionKeys)
+    .where(and(...conditions))
+    .orderBy(asc(storageEncryptionKeys.createdAt))
+    .limit(1);
+  return row ? { id: row.id, key: decodeOrgKey(row.encryptedPrivateKey) } : null;
+}
+
+async function getOrCreateOrgClientKey(orgId: string, exec: DbExecutor = db): Promise<{ id: string; key: Buffer }> {
+  const existing = await loadOrgClientKey(orgId, undefined, exec);
+  if (existing) return existing;
+
+  const key = randomBytes(KEY_BYTES);
+  const [row] = await exec
+    .insert(storageEncryptionKeys)
+    .values({
+      orgId,
+      name: 'Backup client-side encryption key',
+      keyType: CLIENT_BACKUP_KEY_TYPE,
+      encryptedPrivateKey: encryptSecret(key.toString('base64'), { aad: `${ORG_KEY_TABLE}.${ORG_KEY_COLUMN}` }),
+      keyHash: createHash('sha256').update(key).digest('hex'),
+      isActive: true,
+    })
+    .returning({ id: storageEncryptionKeys.id });
+  if (!row) {
+    throw new Error('Failed to create backup client key');
+  }
+  return (await loadOrgClientKey(orgId, undefined, exec)) ?? { id: row.id, key };
+}
+
+async function loadDeviceKeyRow(deviceId: string) {
+  const [row] = await db
+    .select()
+    .from(deviceBackupKeys)
+    .where(eq(deviceBackupKeys.deviceId, deviceId))
+    .limit(1);
+  return row ?? null;
+}
+
+async function unwrapRow(row: typeof deviceBackupKeys.$inferSelect): Promise<ClientBackupKey> {
+  const orgKey = await loadOrgClientKey(row.orgId, row.wrappingKeyId);
+  if (!orgKey) {
+    throw new Error('Backup client key for this device is missing its org key');
+  }
+  const key = unwrapDeviceBackupKey(row.wrappedKey, orgKey.key, row.deviceId);
+  return { keyId: row.id, key: key.toString('base64') };
+}
+
+/**
+ * Returns the device's backup data key, creating it (and the org key) on the
+ * device's first client-encrypted backup.
+ */
+export async function getOrCreateDeviceBackupKey(orgId: string, deviceId: string): Promise<ClientBackupKey> {
+  const existing = await loadDeviceKeyRow(deviceId);
+  if (existing) return unwrapRow(existing);
+
+  const orgKey = await getOrCreateOrgClientKey(orgId);
+  await db
+    .insert(deviceBackupKeys)
+    .values({
+      orgId,
+      deviceId,
+      wrappingKeyId: orgKey.id,
+      wrappedKey: wrapDeviceBackupKey(randomBytes(KEY_BYTES), orgKey.key, deviceId),
+    })
+    .onConflictDoNothing({ target: deviceBackupKeys.deviceId });
+
+  // Re-read so a concurrent first backup converges on the stored key.
+  const row = await loadDeviceKeyRow(deviceId);
+  if (!row) {
+    throw new Error('Failed to create backup client key for device');
+  }
+  return unwrapRow(row);
+}
+
+/**
+ * Re-wraps a device's backup data key under the target org's client key when
+ * the device moves org, inside the move transaction. The data key itself is
+ * unchanged, so the device's existing snapshots (which move with it) stay
+ * decryptable; only the wrapping follows the device. No-op for a device
+ * that never used client-side encryption.
+ */
+export async function rewrapDeviceBackupKeyForOrg(
+  exec: DbExecutor,
+  deviceId: string,
+  targetOrgId: string,
+): Promise<boolean> {
+  const [row] = await exec
+    .select()
+    .from(deviceBackupKeys)
+    .where(eq(deviceBackupKeys.deviceId, deviceId))
+    .limit(1);
+  if (!row) return false;
+
+  const sourceKey = await loadOrgClientKey(row.orgId, row.wrappingKeyId, exec);
+  if (!sourceKey) {
+    throw new Error('Backup client key for this device is missing its org key');
+  }
+  const deviceKey = unwrapDeviceBackupKey(row.wrappedKey, sourceKey.key, deviceId);
+  const targetKey = await getOrCreateOrgClientKey(targetOrgId, exec);
+  await exec
+    .update(deviceBackupKeys)
+    .set({
+      orgId: targetOrgId,
+      wrappingKeyId: targetKey.id,
+      wrappedKey: wrapDeviceBackupKey(deviceKey, targetKey.key, deviceId),
+    })
+    .where(eq(deviceBackupKeys.id, row.id));
+  return true;
+}
+
+/**
+ * Returns the backup data key of the device that wrote a snapshot, for
+ * restore and verify payloads, or null when it never used client-side
+ * encryption.
+ */
+export async function getDeviceBackupKey(deviceId: string): Promise<ClientBackupKey | null> {
+  const row = await loadDeviceKeyRow(deviceId);
+  return row ? unwrapRow(row) : null;
+}
+
+/**
+ * Whether a recorded snapshot was written without client-side encryption.
+ * Agents report the key each encrypted snapshot was written under, so a
+ * snapshot row without one is plaintext. An unknown snapshot is not.
+ */
+async function isPlaintextSnapshot(snapshotDbId: string | null): Promise<boolean> {
+  if (!snapshotDbId) return false;
+  const [row] = await db
+    .select({ metadata: backupSnapshots.metadata })
+    .from(backupSnapshots)
+    .where(eq(backupSnapshots.id, snapshotDbId))
+    .limit(1);
+  if (!row) return false;
+  const metadata = row.metadata as Record<string, unknown> | null;
+  return typeof metadata?.clientEncryptionKeyId !== 'string';
+}
+
+/**
+ * Adds the snapshot writer's data key to a restore or verify command payload,
+ * encrypted for storage in device_commands, when that device used client-side
+ * encryption. The agent rejects unencrypted objects unless the snapshot is
+ * recorded as written before encryption was enabled.
+ */
+export async function attachClientBackupKey(
+  commandType: string,
+  payload: Record<string, unknown>,
+  sourceDeviceId: string,
+  snapshotDbId: string | null,
+): Promise<Record<string, unknown>> {
+  const key = await getDeviceBackupKey(sourceDeviceId);
+  if (!key) return payload;
+  const clientEncryption: ClientBackupKey = (await isPlaintextSnapshot(snapshotDbId))
+    ? { ...key, plaintextSnapshot: true }
+    : key;
+  return encryptSensitivePayloadFields(commandType, { ...payload, clientEncryption });
+}
@@ -25,6 +25,26 @@ describe('backup encryption policy', () => {
     })).toThrow('Backup encryption is currently enforceable only for S3 storage');
   });
 
+  it('enforces client-side encryption on any provider', () => {
+    const expected = {
+      required: true,
+      mode: 'client-aes-gcm',
+      status: 'enforced',
+      providerConfigPatch: {},
+      keyReference: null,
+    };
+    expect(resolveBackupStorageEncryptionPlan({
+      encryption: true,
+      provider: 'local',
+      providerConfig: { path: '/backups', encryption: { mode: 'client' } },
+    })).toEqual(expected);
+    expect(resolveBackupStorageEncryptionPlan({
+      encryption: true,
+      provider: 's3',
+      providerConfig: { bucket: 'backups', clientSideEncryption: true, serverSideEncryption: 'AES256' },
+    })).toEqual(expected);
+  });
+
   it('accepts S3 SSE-S3 as an enforceable provider encryption policy', () => {
     expect(resolveBackupStorageEncryptionPlan({
       encryption: true,
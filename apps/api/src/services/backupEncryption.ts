@@ -8,7 +8,7 @@ export type BackupStorageEncryptionPlan =
     }
   | {
       required: true;
-      mode: 's3-sse-s3' | 's3-sse-kms';
+      mode: 's3-sse-s3' | 's3-sse-kms' | 'client-aes-gcm';
       status: 'enforced';
       providerConfigPatch: JsonRecord;
       keyReference: string | null;
@@ -55,6 +55,17 @@ function normalizeS3SseAlgorithm(providerConfig: JsonRecord): string | null {
   return null;
 }
 
+// Client-side encryption works for every provider: the agent encrypts with a
+// per-device key (services/backupClientKeys.ts) before anything reaches storage.
+function wantsClientSideEncryption(providerConfig: JsonRecord): boolean {
+  if (providerConfig.clientSideEncryption === true) return true;
+  const nested = getNestedEncryptionConfig(providerConfig);
+  const raw = getStringValue(nested, 'mode') ?? getStringValue(nested, 'algorithm');
+  if (!raw) return false;
+  const normalized = raw.toLowerCase();
+  return normalized === 'client' || normalized === 'client-side' || normalized === 'client-aes-gcm';
+}
+
 function resolveS3KmsKeyId(providerConfig: JsonRecord): string | null {
   const nested = getNestedEncryptionConfig(providerConfig);
   return (
@@ -83,12 +94,22 @@ export function resolveBackupStorageEncryptionPlan(input: {
   const provider = input.provider ?? null;
   const providerConfig = isRecord(input.providerConfig) ? input.providerConfig : {};
 
+  if (wantsClientSideEncryption(providerConfig)) {
+    return {
+      required: true,
+      mode: 'client-aes-gcm',
+      status: 'enforced',
+      providerConfigPatch: {},
+      keyReference: null,
+    };
+  }
+
   if (provider !== 's3') {
     return {
       required: true,
       mode: 'unsupported',
       status: 'unsupported',
-      reason: 'Backup encryption is currently enforceable only for S3 storage with explicit server-side encryption settings.',
+      reason: 'Backup encryption is currently enforceable only for S3 storage with explicit server-side encryption settings, or with client-side encryption (encryption.mode "client").',
     };
   }
 
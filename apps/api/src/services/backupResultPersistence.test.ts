@@ -337,6 +337,33 @@ describe('backup result persistence', () => {
     expect(insertValues).toHaveBeenCalledWith(expect.objectContaining({ backupType: 'database' }));
   });
 
+  it('records the client-side encryption key the snapshot was written under', async () => {
+    vi.mocked(db.update)
+      .mockReturnValueOnce(chainMock([{ id: 'job-1', configId: 'config-1', backupType: null, backupMode: 'file' }]) as any)
+      .mockReturnValueOnce(chainMock([]) as any)
+      .mockReturnValueOnce(chainMock([]) as any);
+    vi.mocked(db.select)
+      .mockReturnValueOnce(chainMock([]) as any)
+      .mockReturnValueOnce(chainMock([{ featureLinkId: 'feature-1', policyId: null, deviceId: 'device-1' }]) as any);
+    vi.mocked(db.insert).mockReturnValueOnce(chainMock([{ id: 'snapshot-db-1', jobId: 'job-1', snapshotId: 'provider-snap-1' }]) as any);
+    vi.mocked(applyGfsTagsToSnapshot).mockResolvedValue({ daily: true });
+    vi.mocked(resolveGfsConfigForJob).mockResolvedValue(null);
+    vi.mocked(computeExpiresAt).mockReturnValue(null);
+
+    await applyBackupCommandResultToJob({
+      jobId: 'job-1',
+      orgId: 'org-1',
+      deviceId: 'device-1',
+      resultStatus: 'completed',
+      result: { snapshotId: 'provider-snap-1', filesBackedUp: 1, clientEncryptionKeyId: 'device-key-1' } as any,
+    });
+
+    const insertValues = vi.mocked(db.insert).mock.results[0]?.value?.values;
+    expect(insertValues).toHaveBeenCalledWith(expect.objectContaining({
+      metadata: expect.objectContaining({ clientEncryptionKeyId: 'device-key-1' }),
+    }));
+  });
+
   it('applies provider immutability when the winning feature link requests it', async () => {
     resolveBackupProtectionForDeviceMock.mockResolvedValueOnce({
       legalHold: false,
@@ -640,6 +667,61 @@ describe('backup result persistence', () => {
     const setArg = updateChain.set.mock.calls[0][0] as Record<string, unknown>;
     expect(setArg).not.toHaveProperty('referencedSize');
     expect(setArg).not.toHaveProperty('referencedFiles');
+    expect(setArg).not.toHaveProperty('durationMs');
+  });
+
+  it('persists the agent-measured run duration', async () => {
+    const updateChain = chainMock([{ id: 'job-1', configId: null, backupType: 'file' }]);
+    vi.mocked(db.update).mockReturnValue(updateChain as any);
+
+    await applyBackupCommandResultToJob({
+      jobId: 'job-1',
+      orgId: 'org-1',
+      deviceId: 'device-1',
+      resultStatus: 'completed',
+      result: { filesBackedUp: 4, bytesBackedUp: 2_048, durationMs: 95_400 },
+    });
+
+    const setArg = updateChain.set.mock.calls[0][0] as Record<string, unknown>;
+    expect(setArg.durationMs).toBe(95_400);
+  });
+
+  it('persists vssMetadata for a shadow-copy run, including a failed writer', async () => {
+    const updateChain = chainMock([{ id: 'job-1', configId: null, backupType: 'file' }]);
+    vi.mocked(db.update).mockReturnValue(updateChain as any);
+
+    const vssMetadata = {
+      shadowCopyId: '{3f2a}',
+      writers: [{ name: 'SqlServerWriter', id: 'w-1', state: 'failed', lastError: 'timeout' }],
+      exposedPaths: { 'C:\\': '\\\\?\\GLOBALROOT\\Device\\HarddiskVolumeShadowCopy1\\' },
+      durationMs: 1200,
+    };
+    await applyBackupCommandResultToJob({
+      jobId: 'job-1',
+      orgId: 'org-1',
+      deviceId: 'device-1',
+      resultStatus: 'completed',
+      result: { filesBackedUp: 4, vssMetadata },
+    });
+
+    const setArg = updateChain.set.mock.calls[0][0] as Record<string, unknown>;
+    expect(setArg.vssMetadata).toEqual(vssMetadata);
+  });
+
+  it('does not write vssMetadata when VSS was not used', async () => {
+    const updateChain = chainMock([{ id: 'job-1', configId: null, backupType: 'file' }]);
+    vi.mocked(db.update).mockReturnValue(updateChain as any);
+
+    await applyBackupCommandResultToJob({
+      jobId: 'job-1',
+      orgId: 'org-1',
+      deviceId: 'device-1',
+      resultStatus: 'completed',
+      result: { filesBackedUp: 4 },
+    });
+
+    const setArg = updateChain.set.mock.calls[0][0] as Record<string, unknown>;
+    expect(setArg).not.toHaveProperty('vssMetadata');
   });
 
   it('FIX 7: records a late success on a reaper-failed job (flips failed→completed, clears the reaper errorLog, creates the snapshot)', async () => {
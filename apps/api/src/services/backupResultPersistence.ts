@@ -449,6 +449,9 @@ export async function applyBackupCommandResultToJob(params: {
     if (result.referencedFiles !== undefined) {
       updateData.referencedFiles = result.referencedFiles;
     }
+    if (result.durationMs !== undefined) {
+      updateData.durationMs = result.durationMs;
+    }
   } else {
     updateData.status = 'failed';
     updateData.errorLog = redactSecretsFromOutput(result.error ?? result.warning ?? 'Unknown error');
@@ -460,6 +463,10 @@ export async function applyBackupCommandResultToJob(params: {
   if (providerSnapshotId) {
     updateData.snapshotId = providerSnapshotId;
   }
+  if (result.vssMetadata) {
+    // Written on failure too: a failed VSS writer is often the reason.
+    updateData.vssMetadata = result.vssMetadata;
+  }
 
   // FIX 7: a genuinely-successful backup whose result lands AFTER the stale
   // reaper already flagged the job `failed` must still be recorded — otherwise
@@ -536,6 +543,9 @@ export async function applyBackupCommandResultToJob(params: {
     ...metadata,
     hasIndexedFiles: Boolean(result.snapshot?.files?.length),
     fileIndexVersion: result.snapshot?.files?.length ? 1 : 0,
+    // Restores of a snapshot without it accept unencrypted objects; see
+    // attachClientBackupKey.
+    ...(result.clientEncryptionKeyId ? { clientEncryptionKeyId: result.clientEncryptionKeyId } : {}),
   };
   const snapshotLabel = buildSnapshotLabel(snapshotMetadata, timestamp);
 
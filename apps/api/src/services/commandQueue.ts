@@ -38,6 +38,10 @@ export const CommandTypes = {
   LIST_PROCESSES: 'list_processes',
   GET_PROCESS: 'get_process',
   KILL_PROCESS: 'kill_process',
+  PROCESS_KILL: 'process_kill',
+  PROCESS_SET_PRIORITY: 'process_set_priority',
+  PROCESS_STREAM_START: 'process_stream_start',
+  PROCESS_STREAM_STOP: 'process_stream_stop',
 
   // Service management
   LIST_SERVICES: 'list_services',
@@ -45,6 +49,11 @@ export const CommandTypes = {
   START_SERVICE: 'start_service',
   STOP_SERVICE: 'stop_service',
   RESTART_SERVICE: 'restart_service',
+  SERVICE_LIST: 'service_list',
+  SERVICE_START: 'service_start',
+  SERVICE_STOP: 'service_stop',
+  SERVICE_RESTART: 'service_restart',
+  SERVICE_SET_STARTUP: 'service_set_startup',
 
   // Event logs (Windows)
   EVENT_LOGS_LIST: 'event_logs_list',
@@ -67,6 +76,7 @@ export const CommandTypes = {
   REGISTRY_DELETE: 'registry_delete',
   REGISTRY_KEY_CREATE: 'registry_key_create',
   REGISTRY_KEY_DELETE: 'registry_key_delete',
+  REGISTRY_EXPORT: 'registry_export',
 
   // File operations
   FILE_LIST: 'file_list',
@@ -81,12 +91,18 @@ export const CommandTypes = {
   FILE_TRASH_RESTORE: 'file_trash_restore',
   FILE_TRASH_PURGE: 'file_trash_purge',
   FILE_LIST_DRIVES: 'file_list_drives',
+  FILE_STAT: 'file_stat',
+  FILE_MOVE: 'file_move',
+  FILE_ZIP: 'file_zip',
+  FILE_UNZIP: 'file_unzip',
+  FILE_HASH: 'file_hash',
 
   // Terminal
   TERMINAL_START: 'terminal_start',
   TERMINAL_DATA: 'terminal_data',
   TERMINAL_RESIZE: 'terminal_resize',
   TERMINAL_STOP: 'terminal_stop',
+  TERMINAL_ATTACH: 'terminal_attach',
 
   // Script execution
   SCRIPT: 'script',
@@ -94,18 +110,34 @@ export const CommandTypes = {
   // Software management
   SOFTWARE_UNINSTALL: 'software_uninstall',
   SOFTWARE_UPDATE: 'software_update',
+  APP_DEPLOY: 'app_deploy',
+  // Guided removal of superseded RMM agents; the per-tool report is also
+  // uploaded to /agents/:id/management/cleanup
+  RMM_CLEANUP: 'rmm_cleanup',
   CIS_BENCHMARK: 'cis_benchmark',
   APPLY_CIS_REMEDIATION: 'apply_cis_remediation',
+  COMPLIANCE_EVALUATE: 'compliance_evaluate',
 
   // Patch management
   PATCH_SCAN: 'patch_scan',
   INSTALL_PATCHES: 'install_patches',
   ROLLBACK_PATCHES: 'rollback_patches',
+  PATCH_STAGE: 'patch_stage',
   COLLECT_RELIABILITY_METRICS: 'collect_reliability_metrics',
 
   // Security
   SECURITY_COLLECT_STATUS: 'security_collect_status',
   SECURITY_SCAN: 'security_scan',
+  AV_SCAN: 'av_scan',
+  DEFENDER_GET_PREFERENCES: 'defender_get_preferences',
+  DEFENDER_SET_PREFERENCES: 'defender_set_preferences',
+  FIREWALL_LIST_RULES: 'firewall_list_rules',
+  FIREWALL_ADD_RULE: 'firewall_add_rule',
+  FIREWALL_REMOVE_RULE: 'firewall_remove_rule',
+  FIREWALL_SET_PROFILE: 'firewall_set_profile',
+  // Blocks all traffic but agent↔server, DNS and DHCP until released
+  ISOLATE_HOST: 'isolate_host',
+  RELEASE_ISOLATION: 'release_isolation',
   SECURITY_THREAT_QUARANTINE: 'security_threat_quarantine',
   SECURITY_THREAT_REMOVE: 'security_threat_remove',
   SECURITY_THREAT_RESTORE: 'security_threat_restore',
@@ -117,6 +149,15 @@ export const CommandTypes = {
   // Disk encryption (BitLocker / FileVault)
   ENCRYPTION_COLLECT_KEYS: 'encryption_collect_keys',
   ENCRYPTION_ROTATE_KEY: 'encryption_rotate_key',
+  ENCRYPTION_ENABLE_BITLOCKER: 'encryption_enable_bitlocker',
+  ENCRYPTION_ENABLE_FILEVAULT: 'encryption_enable_filevault',
+  ENCRYPTION_ESCROW_LUKS: 'encryption_escrow_luks',
+
+  // Local administrator remediation; rotated passwords are escrowed as
+  // recovery keys of type local_admin_password
+  LOCAL_ADMIN_REMOVE: 'local_admin_remove',
+  LOCAL_ADMIN_DISABLE: 'local_admin_disable',
+  LOCAL_ADMIN_ROTATE_PASSWORD: 'local_admin_rotate_password',
 
   // Peripheral control — pushes full active policy set to agent
   PERIPHERAL_POLICY_SYNC: 'peripheral_policy_sync',
@@ -142,9 +183,17 @@ export const CommandTypes = {
   // Audit policy compliance
   COLLECT_AUDIT_POLICY: 'collect_audit_policy',
   APPLY_AUDIT_POLICY_BASELINE: 'apply_audit_policy_baseline',
+  // Signed export of every command the agent received in a period, uploaded
+  // to /agents/:id/transcripts/:commandId.
+  TRANSCRIPT_EXPORT: 'transcript_export',
 
   // Safe mode reboot (Windows only)
   REBOOT_SAFE_MODE: 'reboot_safe_mode',
+  // Power management. 'reboot' and 'schedule_reboot' share the agent's
+  // reboots-per-day limit; sleep/hibernate suspend after a short warning.
+  SLEEP: 'sleep',
+  HIBERNATE: 'hibernate',
+  CANCEL_REBOOT: 'cancel_reboot',
   // Wake-on-LAN — sent to a relay agent on the target's LAN, not the offline target itself
   WAKE_ON_LAN: 'wake_on_lan',
   // On-demand inventory refresh — agent re-runs every send*Inventory collector,
@@ -250,9 +299,15 @@ const BACKUP_COMMAND_TYPES = new Set([
 // Commands that modify system state or access sensitive data (e.g., screen capture) and should always be audit-logged
 const AUDITED_COMMANDS: Set<string> = new Set([
   CommandTypes.KILL_PROCESS,
+  CommandTypes.PROCESS_KILL,
+  CommandTypes.PROCESS_SET_PRIORITY,
   CommandTypes.START_SERVICE,
   CommandTypes.STOP_SERVICE,
   CommandTypes.RESTART_SERVICE,
+  CommandTypes.SERVICE_START,
+  CommandTypes.SERVICE_STOP,
+  CommandTypes.SERVICE_RESTART,
+  CommandTypes.SERVICE_SET_STARTUP,
   CommandTypes.TASK_RUN,
   CommandTypes.TASK_ENABLE,
   CommandTypes.TASK_DISABLE,
@@ -260,11 +315,15 @@ const AUDITED_COMMANDS: Set<string> = new Set([
   CommandTypes.REGISTRY_DELETE,
   CommandTypes.REGISTRY_KEY_CREATE,
   CommandTypes.REGISTRY_KEY_DELETE,
+  CommandTypes.REGISTRY_EXPORT,
   CommandTypes.FILE_WRITE,
   CommandTypes.FILE_DELETE,
   CommandTypes.FILE_MKDIR,
   CommandTypes.FILE_RENAME,
   CommandTypes.FILE_COPY,
+  CommandTypes.FILE_MOVE,
+  CommandTypes.FILE_ZIP,
+  CommandTypes.FILE_UNZIP,
   CommandTypes.FILE_TRASH_RESTORE,
   CommandTypes.FILE_TRASH_PURGE,
   CommandTypes.TERMINAL_START,
@@ -274,9 +333,20 @@ const AUDITED_COMMANDS: Set<string> = new Set([
   CommandTypes.ROLLBACK_PATCHES,
   CommandTypes.SOFTWARE_UNINSTALL,
   CommandTypes.SOFTWARE_UPDATE,
+  CommandTypes.RMM_CLEANUP,
   CommandTypes.CIS_BENCHMARK,
   CommandTypes.APPLY_CIS_REMEDIATION,
   CommandTypes.SECURITY_SCAN,
+  CommandTypes.AV_SCAN,
+  CommandTypes.DEFENDER_SET_PREFERENCES,
+  CommandTypes.FIREWALL_ADD_RULE,
+  CommandTypes.FIREWALL_REMOVE_RULE,
+  CommandTypes.FIREWALL_SET_PROFILE,
+  CommandTypes.ISOLATE_HOST,
+  CommandTypes.RELEASE_ISOLATION,
+  CommandTypes.LOCAL_ADMIN_REMOVE,
+  CommandTypes.LOCAL_ADMIN_DISABLE,
+  CommandTypes.LOCAL_ADMIN_ROTATE_PASSWORD,
   CommandTypes.SECURITY_THREAT_QUARANTINE,
   CommandTypes.SECURITY_THREAT_REMOVE,
   CommandTypes.SECURITY_THREAT_RESTORE,
@@ -291,11 +361,16 @@ const AUDITED_COMMANDS: Set<string> = new Set([
   CommandTypes.CAPTURE_PPROF,
   CommandTypes.MANAGE_STARTUP_ITEM,
   CommandTypes.APPLY_AUDIT_POLICY_BASELINE,
+  CommandTypes.TRANSCRIPT_EXPORT,
   // Peripheral control — pushes full active policy set to agent
   CommandTypes.PERIPHERAL_POLICY_SYNC,
-  // Reboots — manual and maintenance-window-automated
+  // Reboots — manual and maintenance-window-automated — and other power actions
   'reboot',
   'schedule_reboot',
+  'shutdown',
+  CommandTypes.SLEEP,
+  CommandTypes.HIBERNATE,
+  CommandTypes.CANCEL_REBOOT,
   // Safe mode reboot
   CommandTypes.REBOOT_SAFE_MODE,
   // (Wake-on-LAN audit is written by the wakeOnLan service against the target device,
@@ -345,6 +420,11 @@ const INTERACTIVE_COMMAND_TYPES: Set<string> = new Set([
   CommandTypes.FILE_MKDIR,
   CommandTypes.FILE_RENAME,
   CommandTypes.FILE_COPY,
+  CommandTypes.FILE_STAT,
+  CommandTypes.FILE_MOVE,
+  CommandTypes.FILE_ZIP,
+  CommandTypes.FILE_UNZIP,
+  CommandTypes.FILE_HASH,
   CommandTypes.FILE_TRASH_LIST,
   CommandTypes.FILE_TRASH_RESTORE,
   CommandTypes.FILE_TRASH_PURGE,
@@ -352,8 +432,13 @@ const INTERACTIVE_COMMAND_TYPES: Set<string> = new Set([
   CommandTypes.TERMINAL_DATA,
   CommandTypes.TERMINAL_RESIZE,
   CommandTypes.TERMINAL_STOP,
+  CommandTypes.TERMINAL_ATTACH,
   CommandTypes.TAKE_SCREENSHOT,
   CommandTypes.COMPUTER_ACTION,
+  CommandTypes.PROCESS_KILL,
+  CommandTypes.PROCESS_SET_PRIORITY,
+  CommandTypes.PROCESS_STREAM_START,
+  CommandTypes.PROCESS_STREAM_STOP,
 ]);
 
 /**
@@ -15,6 +15,7 @@ export const EXCLUDED_COMMAND_TYPES = new Set<string>([
   CommandTypes.TERMINAL_DATA,
   CommandTypes.TERMINAL_RESIZE,
   CommandTypes.TERMINAL_STOP,
+  CommandTypes.TERMINAL_ATTACH,
 ]);
 
 // ── Per-type timeout map ──────────────────────────────────────────
@@ -43,6 +44,18 @@ const SHORT_TIMEOUT_TYPES = new Set<string>([
   CommandTypes.REGISTRY_DELETE,
   CommandTypes.REGISTRY_KEY_CREATE,
   CommandTypes.REGISTRY_KEY_DELETE,
+  CommandTypes.REGISTRY_EXPORT,
+  CommandTypes.DEFENDER_GET_PREFERENCES,
+  CommandTypes.DEFENDER_SET_PREFERENCES,
+  CommandTypes.FIREWALL_LIST_RULES,
+  CommandTypes.FIREWALL_ADD_RULE,
+  CommandTypes.FIREWALL_REMOVE_RULE,
+  CommandTypes.FIREWALL_SET_PROFILE,
+  CommandTypes.ISOLATE_HOST,
+  CommandTypes.RELEASE_ISOLATION,
+  CommandTypes.LOCAL_ADMIN_REMOVE,
+  CommandTypes.LOCAL_ADMIN_DISABLE,
+  CommandTypes.LOCAL_ADMIN_ROTATE_PASSWORD,
   CommandTypes.FILE_LIST,
   CommandTypes.FILE_READ,
   CommandTypes.FILE_WRITE,
@@ -77,7 +90,9 @@ const MEDIUM_TIMEOUT_TYPES = new Set<string>([
   CommandTypes.ROLLBACK_PATCHES,
   CommandTypes.COLLECT_RELIABILITY_METRICS,
   CommandTypes.APPLY_CIS_REMEDIATION,
+  CommandTypes.COMPLIANCE_EVALUATE,
   CommandTypes.APPLY_AUDIT_POLICY_BASELINE,
+  CommandTypes.TRANSCRIPT_EXPORT,
   CommandTypes.SECURITY_THREAT_QUARANTINE,
   CommandTypes.SECURITY_THREAT_REMOVE,
   CommandTypes.SECURITY_THREAT_RESTORE,
@@ -94,6 +109,7 @@ const RESTORE_TIMEOUT_TYPES = new Set<string>([
 
 const LONG_TIMEOUT_TYPES = new Set<string>([
   CommandTypes.INSTALL_PATCHES,
+  CommandTypes.RMM_CLEANUP,
   CommandTypes.BACKUP_VERIFY,
   CommandTypes.BACKUP_TEST_RESTORE,
   CommandTypes.BACKUP_CLEANUP,
@@ -101,6 +117,7 @@ const LONG_TIMEOUT_TYPES = new Set<string>([
   CommandTypes.HYPERV_BACKUP,
   CommandTypes.CIS_BENCHMARK,
   CommandTypes.SENSITIVE_DATA_SCAN,
+  CommandTypes.AV_SCAN,
   CommandTypes.ENCRYPT_FILE,
   CommandTypes.SECURE_DELETE_FILE,
   CommandTypes.QUARANTINE_FILE,
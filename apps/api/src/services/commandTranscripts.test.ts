@@ -0,0 +1,97 @@
+import { describe, expect, it, vi } from 'vitest';
+import { createHash, generateKeyPairSync, sign } from 'crypto';
+import { gzipSync } from 'zlib';
+
+vi.mock('../db', () => ({ db: {} }));
+
+import { TranscriptVerificationError, verifyTranscriptArchive } from './commandTranscripts';
+
+function tarEntry(name: string, data: Buffer): Buffer {
+  const header = Buffer.alloc(512);
+  header.write(name, 0, 'utf8');
+  header.write('0000600\0', 100);
+  header.write('0000000\0', 108);
+  header.write('0000000\0', 116);
+  header.write(`${data.length.toString(8).padStart(11, '0')}\0`, 124);
+  header.write('00000000000\0', 136);
+  header.write('        ', 148);
+  header.write('0', 156);
+  header.write('ustar\0' + '00', 257);
+  let checksum = 0;
+  for (const b of header) checksum += b;
+  header.write(`${checksum.toString(8).padStart(6, '0')}\0 `, 148);
+  const padding = Buffer.alloc((512 - (data.length % 512)) % 512);
+  return Buffer.concat([header, data, padding]);
+}
+
+function makeKey() {
+  const { publicKey, privateKey } = generateKeyPairSync('ed25519');
+  const raw = publicKey.export({ format: 'der', type: 'spki' }).subarray(-32);
+  return { privateKey, publicKey: raw.toString('base64') };
+}
+
+const sha256 = (data: Buffer) => createHash('sha256').update(data).digest('hex');
+
+function buildArchive(
+  key: ReturnType<typeof makeKey>,
+  overrides: { agentId?: string; auditJsonl?: string; extra?: [string, Buffer] } = {}
+): Buffer {
+  const transcript = Buffer.from(JSON.stringify([{ commandId: 'c-1', type: 'reboot' }]));
+  const audit = Buffer.from('{"seq":1}\n');
+  const manifest = Buffer.from(JSON.stringify({
+    agentId: overrides.agentId ?? 'agent-1',
+    from: '2026-03-01T00:00:00Z',
+    to: '2026-03-31T00:00:00Z',
+    generatedAt: '2026-04-01T00:00:00Z',
+    commandCount: 1,
+    entryCount: 1,
+    chainVerified: true,
+    files: { 'transcript.json': sha256(transcript), 'audit.jsonl': sha256(audit) },
+    publicKey: key.publicKey,
+  }, null, 2));
+  const signature = Buffer.from(sign(null, manifest, key.privateKey).toString('base64'));
+  const entries = [
+    tarEntry('manifest.json', manifest),
+    tarEntry('manifest.sig', signature),
+    tarEntry('transcript.json', transcript),
+    tarEntry('audit.jsonl', overrides.auditJsonl === undefined ? audit : Buffer.from(overrides.auditJsonl)),
+  ];
+  if (overrides.extra) entries.push(tarEntry(...overrides.extra));
+  return gzipSync(Buffer.concat([...entries, Buffer.alloc(1024)]));
+}
+
+describe('verifyTranscriptArchive', () => {
+  const key = makeKey();
+
+  it('accepts an archive signed by the pinned key', () => {
+    const manifest = verifyTranscriptArchive(buildArchive(key), { agentId: 'agent-1', publicKey: key.publicKey });
+    expect(manifest.commandCount).toBe(1);
+    expect(manifest.chainVerified).toBe(true);
+  });
+
+  it('rejects an archive signed by a key other than the pinned one', () => {
+    const other = makeKey();
+    expect(() => verifyTranscriptArchive(buildArchive(other), { agentId: 'agent-1', publicKey: key.publicKey }))
+      .toThrow(TranscriptVerificationError);
+  });
+
+  it('rejects an archive whose files do not match the signed manifest', () => {
+    const archive = buildArchive(key, { auditJsonl: '{"seq":2}\n' });
+    expect(() => verifyTranscriptArchive(archive, { agentId: 'agent-1', publicKey: key.publicKey }))
+      .toThrow(/audit.jsonl does not match/);
+  });
+
+  it('rejects an archive exported by another agent', () => {
+    const archive = buildArchive(key, { agentId: 'agent-2' });
+    expect(() => verifyTranscriptArchive(archive, { agentId: 'agent-1', publicKey: key.publicKey }))
+      .toThrow(/different agent/);
+  });
+
+  it('rejects unexpected archive entries and non-gzip bodies', () => {
+    const archive = buildArchive(key, { extra: ['../../etc/passwd', Buffer.from('x')] });
+    expect(() => verifyTranscriptArchive(archive, { agentId: 'agent-1', publicKey: key.publicKey }))
+      .toThrow(/Unexpected archive entry/);
+    expect(() => verifyTranscriptArchive(Buffer.from('not gzip'), { agentId: 'agent-1', publicKey: key.publicKey }))
+      .toThrow(/not valid gzip/);
+  });
+});
@@ -0,0 +1,211 @@
+/**
+ * Command Transcript Service
+ *
+ * Verifies and stores the signed archives agents upload for transcript_export
+ * commands. An archive is a tar.gz of manifest.json, manifest.sig (base64
+ * Ed25519 signature over the manifest bytes), transcript.json and
+ * audit.jsonl. The signature is checked against the key pinned on the device
+ * at enrollment, never the key the manifest carries, so an archive can only
+ * come from the agent that holds the device's signing key.
+ */
+
+import { createHash, createPublicKey, verify } from 'crypto';
+import { mkdir, writeFile } from 'fs/promises';
+import { join } from 'path';
+import { gunzipSync } from 'zlib';
+import { and, eq, isNull } from 'drizzle-orm';
+import { db } from '../db';
+import { deviceCommandTranscripts, devices } from '../db/schema';
+
+const TRANSCRIPT_STORAGE_PATH = process.env.TRANSCRIPT_STORAGE_PATH || './data/transcripts';
+
+// The audit log keeps a few hundred MB of JSONL at most; anything that
+// inflates past this is not an archive the agent wrote.
+const MAX_UNCOMPRESSED_BYTES = 1024 * 1024 * 1024;
+
+const ARCHIVE_FILES = new Set(['manifest.json', 'manifest.sig', 'transcript.json', 'audit.jsonl']);
+const SIGNED_FILES = ['transcript.json', 'audit.jsonl'];
+
+// DER SubjectPublicKeyInfo prefix for a raw 32-byte Ed25519 key.
+const ED25519_SPKI_PREFIX = Buffer.from('302a300506032b6570032100', 'hex');
+
+export interface TranscriptManifest {
+  agentId: string;
+  hostname?: string;
+  from: string;
+  to: string;
+  generatedAt: string;
+  commandCount: number;
+  entryCount: number;
+  chainVerified: boolean;
+  files: Record<string, string>;
+  publicKey: string;
+}
+
+export class TranscriptVerificationError extends Error {}
+
+/** Extracts the regular files from a ustar archive, skipping PAX headers. */
+function readTarFiles(tar: Buffer): Map<string, Buffer> {
+  const files = new Map<string, Buffer>();
+  let offset = 0;
+  while (offset + 512 <= tar.length) {
+    const header = tar.subarray(offset, offset + 512);
+    if (header.every((b) => b === 0)) break;
+
+    const field = (start: number, length: number) =>
+      header.subarray(start, start + length).toString('utf8').replace(/\0.*$/s, '');
+    const prefix = field(345, 155);
+    const name = prefix ? `${prefix}/${field(0, 100)}` : field(0, 100);
+    const size = parseInt(field(124, 12).trim() || '0', 8);
+    const type = field(156, 1) || '0';
+    if (!Number.isFinite(size) || size < 0) {
+      throw new TranscriptVerificationError('Malformed archive entry');
+    }
+
+    const dataStart = offset + 512;
+    const dataEnd = dataStart + size;
+    if (dataEnd > tar.length) {
+      throw new TranscriptVerificationError('Truncated archive');
+    }
+    if (type === '0') {
+      if (!ARCHIVE_FILES.has(name) || files.has(name)) {
+        throw new TranscriptVerificationError(`Unexpected archive entry ${name}`);
+      }
+      files.set(name, tar.subarray(dataStart, dataEnd));
+    } else if (type !== 'x' && type !== 'g') {
+      throw new TranscriptVerificationError(`Unexpected archive entry ${name}`);
+    }
+    offset = dataStart + Math.ceil(size / 512) * 512;
+  }
+  return files;
+}
+
+/**
+ * Checks an uploaded archive: the manifest must be signed by the pinned key,
+ * name the uploading agent, and hash every file it lists. Throws
+ * TranscriptVerificationError otherwise.
+ */
+export function verifyTranscriptArchive(
+  archive: Buffer,
+  expected: { agentId: string; publicKey: string }
+): TranscriptManifest {
+  let tar: Buffer;
+  try {
+    tar = gunzipSync(archive, { maxOutputLength: MAX_UNCOMPRESSED_BYTES });
+  } catch {
+    throw new TranscriptVerificationError('Archive is not valid gzip');
+  }
+
+  const files = readTarFiles(tar);
+  const manifestJson = files.get('manifest.json');
+  const signature = files.get('manifest.sig');
+  if (!manifestJson || !signature) {
+    throw new TranscriptVerificationError('Archive is missing its manifest or signature');
+  }
+
+  const key = createPublicKey({
+    key: Buffer.concat([ED25519_SPKI_PREFIX, Buffer.from(expected.publicKey, 'base64')]),
+    format: 'der',
+    type: 'spki',
+  });
+  if (!verify(null, manifestJson, key, Buffer.from(signature.toString('utf8').trim(), 'base64'))) {
+    throw new TranscriptVerificationError('Manifest signature does not match the device signing key');
+  }
+
+  let manifest: TranscriptManifest;
+  try {
+    manifest = JSON.parse(manifestJson.toString('utf8')) as TranscriptManifest;
+  } catch {
+    throw new TranscriptVerificationError('Manifest is not valid JSON');
+  }
+  if (manifest.publicKey !== expected.publicKey) {
+    throw new TranscriptVerificationError('Manifest names a different signing key');
+  }
+  if (manifest.agentId !== expected.agentId) {
+    throw new TranscriptVerificationError('Manifest names a different agent');
+  }
+  if (Number.isNaN(Date.parse(manifest.from)) || Number.isNaN(Date.parse(manifest.to))) {
+    throw new TranscriptVerificationError('Manifest period is invalid');
+  }
+  for (const name of SIGNED_FILES) {
+    const data = files.get(name);
+    const want = manifest.files?.[name];
+    if (!data || !want || createHash('sha256').update(data).digest('hex') !== want) {
+      throw new TranscriptVerificationError(`${name} does not match the manifest`);
+    }
+  }
+  return manifest;
+}
+
+/**
+ * Pins key as the device's transcript signing key if it has none. Returns
+ * false when a different key is already pinned.
+ */
+export async function pinTranscriptPublicKey(deviceId: string, publicKey: string): Promise<boolean> {
+  const pinned = await db
+    .update(devices)
+    .set({ transcriptPublicKey: publicKey, updatedAt: new Date() })
+    .where(and(eq(devices.id, deviceId), isNull(devices.transcriptPublicKey)))
+    .returning({ id: devices.id });
+  if (pinned.length > 0) return true;
+  return (await getTranscriptPublicKey(deviceId)) === publicKey;
+}
+
+export async function getTranscriptPublicKey(deviceId: string): Promise<string | null> {
+  const [device] = await db
+    .select({ transcriptPublicKey: devices.transcriptPublicKey })
+    .from(devices)
+    .where(eq(devices.id, deviceId))
+    .limit(1);
+  return device?.transcriptPublicKey ?? null;
+}
+
+interface StoreTranscriptParams {
+  orgId: string;
+  deviceId: string;
+  commandId: string;
+  archive: Buffer;
+  archiveSha256: string;
+  manifest: TranscriptManifest;
+}
+
+/** Writes the archive to disk and records it. A retried upload replaces it. */
+export async function storeTranscript(params: StoreTranscriptParams) {
+  const { orgId, deviceId, commandId, archive, archiveSha256, manifest } = params;
+  const dir = join(TRANSCRIPT_STORAGE_PATH, orgId, deviceId);
+  await mkdir(dir, { recursive: true });
+  const storagePath = join(dir, `${commandId}.tar.gz`);
+  await writeFile(storagePath, archive);
+
+  const values = {
+    orgId,
+    deviceId,
+    commandId,
+    periodFrom: new Date(manifest.from),
+    periodTo: new Date(manifest.to),
+    commandCount: manifest.commandCount,
+    entryCount: manifest.entryCount,
+    chainVerified: manifest.chainVerified,
+    archiveSha256,
+    sizeBytes: archive.length,
+    storagePath,
+  };
+  const [row] = await db
+    .insert(deviceCommandTranscripts)
+    .values(values)
+    .onConflictDoUpdate({
+      target: deviceCommandTranscripts.commandId,
+      set: { ...values, createdAt: new Date() },
+    })
+    .returning();
+  return row;
+}
+
+export async function getTranscript(deviceId: string, commandId: string) {
+  const [row] = await db
+    .select()
+    .from(deviceCommandTranscripts)
+    .where(and(eq(deviceCommandTranscripts.deviceId, deviceId), eq(deviceCommandTranscripts.commandId, commandId)))
+    .limit(1);
+  return row ?? null;
+}
@@ -302,10 +302,10 @@ export async function loadPolicyLocalPatchConfig(
     configPolicyId: row.configPolicyId,
     featureLinkId: row.featureLinkId,
   });
-  // Constraint: autoApproveDeferralDays and apps have no columns on
-  // config_policy_patch_settings — they live only in the feature link's
-  // inline JSON. The mixed sourcing below (columns for everything else,
-  // storedInline for these two) is therefore intentional, not an oversight.
+  // Constraint: autoApproveDeferralDays, apps, complianceMaxAgeDays,
+  // rebootMaxDeferrals and appUpdatePolicies have no columns on config_policy_patch_settings — they
+  // live only in the feature link's inline JSON. The mixed sourcing below (columns for everything else,
+  // storedInline for these) is therefore intentional, not an oversight.
   const settings = row.patchSettings
     ? normalizePatchInlineSettings({
         sources: row.patchSettings.sources,
@@ -313,6 +313,9 @@ export async function loadPolicyLocalPatchConfig(
         autoApproveSeverities: row.patchSettings.autoApproveSeverities ?? [],
         autoApproveDeferralDays: storedInline.autoApproveDeferralDays,
         apps: storedInline.apps,
+        complianceMaxAgeDays: storedInline.complianceMaxAgeDays,
+        rebootMaxDeferrals: storedInline.rebootMaxDeferrals,
+        appUpdatePolicies: storedInline.appUpdatePolicies,
         scheduleFrequency: row.patchSettings.scheduleFrequency,
         scheduleTime: row.patchSettings.scheduleTime,
         scheduleDayOfWeek: row.patchSettings.scheduleDayOfWeek ?? undefined,
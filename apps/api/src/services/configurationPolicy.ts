@@ -933,8 +933,9 @@ async function assembleInlineSettings(
         .where(eq(configPolicyPatchSettings.featureLinkId, linkId))
         .limit(1);
       if (!row) return null;
-      // NOTE: autoApproveDeferralDays and apps (block/pin rules) are intentionally
-      // absent here — config_policy_patch_settings has no columns for them; they
+      // NOTE: autoApproveDeferralDays, apps (block/pin rules),
+      // complianceMaxAgeDays, rebootMaxDeferrals and appUpdatePolicies are intentionally absent
+      // here — config_policy_patch_settings has no columns for them; they
       // live ONLY in the feature link's inline JSONB. Callers (listFeatureLinks)
       // MUST merge them back in from the stored inlineSettings, otherwise reads
       // come back with apps: [] and the next save destroys every app rule.
@@ -1345,9 +1346,10 @@ export async function listFeatureLinks(configPolicyId: string) {
       const assembled = await assembleInlineSettings(featureType, link.id);
       let effectiveInlineSettings: unknown;
       if (featureType === 'patch') {
-        // CONSTRAINT: autoApproveDeferralDays and apps (block/pin rules) have NO
-        // columns on config_policy_patch_settings — they live ONLY in the feature
-        // link's inline JSONB. They must be merged in even when the relational row
+        // CONSTRAINT: autoApproveDeferralDays, apps (block/pin rules),
+        // complianceMaxAgeDays, rebootMaxDeferrals and appUpdatePolicies have NO columns on
+        // config_policy_patch_settings — they live ONLY in the feature link's
+        // inline JSONB. They must be merged in even when the relational row
         // wins, exactly mirroring loadPolicyLocalPatchConfig in configPolicyPatching.ts.
         // Without this merge every read returns apps: [] / autoApproveDeferralDays: 0,
         // and the next save writes that emptiness back to the JSONB — permanently
@@ -1361,6 +1363,9 @@ export async function listFeatureLinks(configPolicyId: string) {
               ...(assembled as Record<string, unknown>),
               autoApproveDeferralDays: storedInline.autoApproveDeferralDays,
               apps: storedInline.apps,
+              complianceMaxAgeDays: storedInline.complianceMaxAgeDays,
+              rebootMaxDeferrals: storedInline.rebootMaxDeferrals,
+              appUpdatePolicies: storedInline.appUpdatePolicies,
             })
           : storedInline;
       } else if (featureType === 'remote_access' && assembled) {
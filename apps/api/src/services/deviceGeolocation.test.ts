@@ -0,0 +1,24 @@
+import { describe, expect, it, vi } from 'vitest';
+
+vi.mock('../db', () => ({ db: {} }));
+vi.mock('../db/schema/orgs', () => ({ organizations: {}, sites: {} }));
+
+import { isGeolocationEnabled } from './deviceGeolocation';
+
+describe('isGeolocationEnabled', () => {
+  it('is off unless a policy enables it', () => {
+    expect(isGeolocationEnabled(null, null)).toBe(false);
+    expect(isGeolocationEnabled({}, {})).toBe(false);
+    expect(isGeolocationEnabled({ geolocation: { enabled: 'yes' } }, null)).toBe(false);
+  });
+
+  it('uses the org setting when the site has none', () => {
+    expect(isGeolocationEnabled({ geolocation: { enabled: true } }, null)).toBe(true);
+    expect(isGeolocationEnabled({ geolocation: { enabled: true } }, { geolocation: {} })).toBe(true);
+  });
+
+  it('lets the site override the org in either direction', () => {
+    expect(isGeolocationEnabled({ geolocation: { enabled: true } }, { geolocation: { enabled: false } })).toBe(false);
+    expect(isGeolocationEnabled({ geolocation: { enabled: false } }, { geolocation: { enabled: true } })).toBe(true);
+  });
+});
@@ -0,0 +1,35 @@
+import { eq } from 'drizzle-orm';
+import { db } from '../db';
+import { organizations, sites } from '../db/schema/orgs';
+
+// Device geolocation (lost/stolen laptop workflows) is opt-in and gated by
+// policy: `settings.geolocation.enabled` on the site wins when it is a
+// boolean, otherwise the organization's value applies, and anything else
+// means off. The heartbeat pushes the result to the agent as
+// `geolocation_enabled` and drops reported locations while it is off.
+
+function asRecord(value: unknown): Record<string, unknown> {
+  return value && typeof value === 'object' ? (value as Record<string, unknown>) : {};
+}
+
+/** Pure decision from the org and site settings JSONB. */
+export function isGeolocationEnabled(orgSettings: unknown, siteSettings: unknown): boolean {
+  const site = asRecord(asRecord(siteSettings).geolocation).enabled;
+  if (typeof site === 'boolean') return site;
+  return asRecord(asRecord(orgSettings).geolocation).enabled === true;
+}
+
+export async function resolveGeolocationEnabledForDevice(orgId: string, siteId: string): Promise<boolean> {
+  const [org] = await db
+    .select({ settings: organizations.settings })
+    .from(organizations)
+    .where(eq(organizations.id, orgId))
+    .limit(1);
+  const [site] = await db
+    .select({ settings: sites.settings })
+    .from(sites)
+    .where(eq(sites.id, siteId))
+    .limit(1);
+
+  return isGeolocationEnabled(org?.settings, site?.settings);
+}
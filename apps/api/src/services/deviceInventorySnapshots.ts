@@ -0,0 +1,96 @@
+import { and, eq, sql } from 'drizzle-orm';
+import { db } from '../db';
+import { deviceInventorySnapshots } from '../db/schema';
+
+/**
+ * Inventory kinds the agent uploads as whole snapshots. Each kind has its own
+ * ingest route under /agents/:id and is readable at
+ * GET /devices/:id/inventory/:kind.
+ */
+export const INVENTORY_SNAPSHOT_KINDS = [
+  'processes',
+  'synthetics',
+  'certificates',
+  'containers',
+  'browser_extensions',
+  'changelog',
+  'rmm_cleanup',
+  'usb_devices',
+  'local_users',
+  'wifi',
+  'process_network',
+  'plugins',
+  'bluetooth_devices',
+  'licensing',
+] as const;
+
+export type InventorySnapshotKind = (typeof INVENTORY_SNAPSHOT_KINDS)[number];
+
+export function isInventorySnapshotKind(value: string): value is InventorySnapshotKind {
+  return (INVENTORY_SNAPSHOT_KINDS as readonly string[]).includes(value);
+}
+
+export interface InventorySnapshotInput {
+  deviceId: string;
+  orgId: string;
+  kind: InventorySnapshotKind;
+  data: unknown;
+  itemCount: number;
+  collectedAt?: Date | null;
+  /**
+   * Merge into the stored object instead of replacing it. `data` must then be
+   * an object keyed by item id (e.g. monitor id), and the item count becomes
+   * the number of keys after the merge.
+   */
+  merge?: boolean;
+}
+
+/** Replaces (or, with `merge`, updates) the device's snapshot for one kind. */
+export async function upsertInventorySnapshot(input: InventorySnapshotInput): Promise<void> {
+  const now = new Date();
+  const values = {
+    data: input.data,
+    itemCount: input.itemCount,
+    collectedAt: input.collectedAt ?? now,
+    updatedAt: now,
+  };
+  const merged = sql`${deviceInventorySnapshots.data} || excluded.data`;
+  await db
+    .insert(deviceInventorySnapshots)
+    .values({ deviceId: input.deviceId, orgId: input.orgId, kind: input.kind, ...values })
+    .onConflictDoUpdate({
+      target: [deviceInventorySnapshots.deviceId, deviceInventorySnapshots.kind],
+      set: input.merge
+        ? {
+            ...values,
+            orgId: input.orgId,
+            data: merged,
+            itemCount: sql`(SELECT count(*) FROM jsonb_object_keys(${merged}))::int`,
+          }
+        : { orgId: input.orgId, ...values },
+    });
+}
+
+export interface InventorySnapshot {
+  kind: string;
+  data: unknown;
+  itemCount: number;
+  collectedAt: string | null;
+  updatedAt: string;
+}
+
+export async function getInventorySnapshot(deviceId: string, kind: InventorySnapshotKind): Promise<InventorySnapshot | null> {
+  const [row] = await db
+    .select()
+    .from(deviceInventorySnapshots)
+    .where(and(eq(deviceInventorySnapshots.deviceId, deviceId), eq(deviceInventorySnapshots.kind, kind)))
+    .limit(1);
+  if (!row) return null;
+  return {
+    kind: row.kind,
+    data: row.data,
+    itemCount: row.itemCount,
+    collectedAt: row.collectedAt?.toISOString() ?? null,
+    updatedAt: row.updatedAt.toISOString(),
+  };
+}
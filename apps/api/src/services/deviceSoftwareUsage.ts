@@ -0,0 +1,73 @@
+import { and, desc, eq, gte, sql } from 'drizzle-orm';
+import { db } from '../db';
+import { deviceSoftwareUsage } from '../db/schema';
+
+export interface SoftwareUsageRow {
+  date: string;
+  user: string;
+  name: string;
+  path?: string;
+  seconds: number;
+}
+
+/** How the agent groups usage rows: by path, or by name when there is none. */
+export function softwareUsageAppKey(row: Pick<SoftwareUsageRow, 'name' | 'path'>): string {
+  return (row.path || row.name).toLowerCase();
+}
+
+/**
+ * Stores the agent's daily totals. A row for a day the server already has
+ * replaces it: today's total is resent as it grows.
+ */
+export async function upsertSoftwareUsage(deviceId: string, orgId: string, rows: SoftwareUsageRow[]): Promise<number> {
+  if (rows.length === 0) return 0;
+
+  // The agent never sends two rows with one key, but a batch with a
+  // duplicate would fail the whole insert; keep the last.
+  const byKey = new Map<string, typeof deviceSoftwareUsage.$inferInsert>();
+  const now = new Date();
+  for (const row of rows) {
+    const appKey = softwareUsageAppKey(row);
+    byKey.set(`${row.date}\0${row.user}\0${appKey}`, {
+      orgId,
+      deviceId,
+      usageDate: row.date,
+      username: row.user,
+      appKey,
+      appName: row.name,
+      appPath: row.path || null,
+      seconds: row.seconds,
+      updatedAt: now,
+    });
+  }
+
+  await db
+    .insert(deviceSoftwareUsage)
+    .values([...byKey.values()])
+    .onConflictDoUpdate({
+      target: [deviceSoftwareUsage.deviceId, deviceSoftwareUsage.usageDate, deviceSoftwareUsage.username, deviceSoftwareUsage.appKey],
+      set: {
+        orgId,
+        appName: sql`excluded.app_name`,
+        appPath: sql`excluded.app_path`,
+        seconds: sql`excluded.seconds`,
+        updatedAt: now,
+      },
+    });
+  return byKey.size;
+}
+
+/** Daily usage rows for a device from `since` (YYYY-MM-DD), newest day first. */
+export async function listSoftwareUsage(deviceId: string, since: string) {
+  return db
+    .select({
+      date: deviceSoftwareUsage.usageDate,
+      user: deviceSoftwareUsage.username,
+      name: deviceSoftwareUsage.appName,
+      path: deviceSoftwareUsage.appPath,
+      seconds: deviceSoftwareUsage.seconds,
+    })
+    .from(deviceSoftwareUsage)
+    .where(and(eq(deviceSoftwareUsage.deviceId, deviceId), gte(deviceSoftwareUsage.usageDate, since)))
+    .orderBy(desc(deviceSoftwareUsage.usageDate), desc(deviceSoftwareUsage.seconds));
+}
@@ -80,6 +80,7 @@ export const encryptedColumnRegistry: EncryptedColumnSpec[] = [
   { table: 'td_synnex_ec_express_integrations', column: 'credentials', kind: 'json', description: 'TD SYNNEX EC Express API credentials' },
   { table: 'td_synnex_sftp_integrations', column: 'credentials', kind: 'json', description: 'TD SYNNEX nightly SFTP P&A password (credentials.password)' },
   { table: 'device_recovery_keys', column: 'encrypted_key', kind: 'text', description: 'escrowed BitLocker/FileVault recovery key (#2021)' },
+  { table: 'remote_sessions', column: 'terminal_password', kind: 'text', description: 'named_user terminal password, cleared once sent to the agent' },
 ];
 
 const SECRET_JSON_KEYS = new Set([
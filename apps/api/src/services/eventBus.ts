@@ -60,6 +60,8 @@ export type EventType =
   | 'ticket.sla_breached'
   // Security events
   | 'security.score_changed'
+  | 'security.ransomware_canary_triggered'
+  | 'security.network_isolation_changed'
   // CIS compliance events
   | 'compliance.cis_deviation'
   | 'compliance.cis_score_changed'
@@ -618,6 +620,8 @@ export const EVENT_TYPES = {
   BACKUP_RECOVERY_READINESS_LOW: 'backup.recovery_readiness_low' as const,
   // Security
   SECURITY_SCORE_CHANGED: 'security.score_changed' as const,
+  SECURITY_RANSOMWARE_CANARY_TRIGGERED: 'security.ransomware_canary_triggered' as const,
+  SECURITY_NETWORK_ISOLATION_CHANGED: 'security.network_isolation_changed' as const,
   CIS_DEVIATION: 'compliance.cis_deviation' as const,
   CIS_SCORE_CHANGED: 'compliance.cis_score_changed' as const,
   CIS_REMEDIATION_APPLIED: 'compliance.cis_remediation_applied' as const,
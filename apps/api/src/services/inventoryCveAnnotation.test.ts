@@ -0,0 +1,137 @@
+import { beforeEach, describe, expect, it, vi } from 'vitest';
+
+vi.mock('drizzle-orm', () => ({
+  and: (...conditions: unknown[]) => ({ op: 'and', conditions }),
+  eq: (left: unknown, right: unknown) => ({ op: 'eq', left, right }),
+  inArray: (left: unknown, right: unknown) => ({ op: 'inArray', left, right }),
+  isNotNull: (value: unknown) => ({ op: 'isNotNull', value }),
+}));
+
+vi.mock('../db', () => ({
+  db: { select: vi.fn() },
+}));
+
+vi.mock('../db/schema', () => ({
+  deviceVulnerabilities: {
+    deviceId: 'dv.deviceId',
+    status: 'dv.status',
+    softwareInventoryId: 'dv.softwareInventoryId',
+    vulnerabilityId: 'dv.vulnerabilityId',
+  },
+  vulnerabilities: {
+    id: 'v.id',
+    cveId: 'v.cveId',
+    cvssScore: 'v.cvssScore',
+    knownExploited: 'v.knownExploited',
+  },
+}));
+
+vi.mock('./sentry', () => ({ captureMessage: vi.fn() }));
+
+import { db } from '../db';
+import {
+  loadPatchCveAnnotations,
+  loadSoftwareCveAnnotations,
+  summarizeCves,
+} from './inventoryCveAnnotation';
+
+function selectWhereResult(rows: unknown[]) {
+  const where = vi.fn().mockResolvedValue(rows);
+  return {
+    from: vi.fn().mockReturnValue({
+      where,
+      innerJoin: vi.fn().mockReturnValue({ where }),
+    }),
+    where,
+  };
+}
+
+describe('summarizeCves', () => {
+  it('orders by CVSS descending with unscored CVEs last', () => {
+    const summary = summarizeCves([
+      { cveId: 'CVE-2024-0002', cvssScore: '5.3', knownExploited: false },
+      { cveId: 'CVE-2024-0003', cvssScore: null, knownExploited: null },
+      { cveId: 'CVE-2024-0001', cvssScore: '9.8', knownExploited: true },
+    ]);
+
+    expect(summary).toEqual({
+      cveIds: ['CVE-2024-0001', 'CVE-2024-0002', 'CVE-2024-0003'],
+      cvssScore: 9.8,
+      knownExploited: true,
+    });
+  });
+
+  it('deduplicates a CVE reported twice and keeps its highest score', () => {
+    const summary = summarizeCves([
+      { cveId: 'CVE-2024-0001', cvssScore: null, knownExploited: false },
+      { cveId: 'CVE-2024-0001', cvssScore: 7.5, knownExploited: false },
+    ]);
+
+    expect(summary.cveIds).toEqual(['CVE-2024-0001']);
+    expect(summary.cvssScore).toBe(7.5);
+    expect(summary.knownExploited).toBe(false);
+  });
+
+  it('reports no score when nothing is scored', () => {
+    expect(summarizeCves([{ cveId: 'CVE-2024-0001', cvssScore: null, knownExploited: null }]).cvssScore).toBeNull();
+  });
+});
+
+describe('loadPatchCveAnnotations', () => {
+  beforeEach(() => {
+    vi.mocked(db.select).mockReset();
+  });
+
+  it('skips the catalog query when no patch carries CVE ids', async () => {
+    const result = await loadPatchCveAnnotations([
+      { patchId: 'p1', cveIds: null },
+      { patchId: 'p2', cveIds: [] },
+    ]);
+
+    expect(result.size).toBe(0);
+    expect(db.select).not.toHaveBeenCalled();
+  });
+
+  it('scores patch CVEs from the catalog and keeps unknown ones unscored', async () => {
+    vi.mocked(db.select).mockReturnValueOnce(
+      selectWhereResult([{ cveId: 'CVE-2024-9680', cvssScore: '9.8', knownExploited: true }]) as any
+    );
+
+    const result = await loadPatchCveAnnotations([
+      { patchId: 'p1', cveIds: ['CVE-2024-9392', 'CVE-2024-9680', 'not-a-cve'] },
+      { patchId: 'p2', cveIds: null },
+    ]);
+
+    expect(result.size).toBe(1);
+    expect(result.get('p1')).toEqual({
+      cveIds: ['CVE-2024-9680', 'CVE-2024-9392'],
+      cvssScore: 9.8,
+      knownExploited: true,
+    });
+  });
+});
+
+describe('loadSoftwareCveAnnotations', () => {
+  beforeEach(() => {
+    vi.mocked(db.select).mockReset();
+  });
+
+  it('groups open findings by software inventory row', async () => {
+    vi.mocked(db.select).mockReturnValueOnce(
+      selectWhereResult([
+        { softwareInventoryId: 'sw-1', cveId: 'CVE-2024-0001', cvssScore: '6.1', knownExploited: false },
+        { softwareInventoryId: 'sw-1', cveId: 'CVE-2024-0002', cvssScore: '8.8', knownExploited: false },
+        { softwareInventoryId: 'sw-2', cveId: 'CVE-2024-0003', cvssScore: null, knownExploited: true },
+      ]) as any
+    );
+
+    const result = await loadSoftwareCveAnnotations('device-1');
+
+    expect(result.get('sw-1')).toEqual({
+      cveIds: ['CVE-2024-0002', 'CVE-2024-0001'],
+      cvssScore: 8.8,
+      knownExploited: false,
+    });
+    expect(result.get('sw-2')?.knownExploited).toBe(true);
+  });
+});
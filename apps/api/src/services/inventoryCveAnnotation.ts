@@ -0,0 +1,141 @@
+import { and, eq, inArray, isNotNull } from 'drizzle-orm';
+
+import { db } from '../db';
+import { deviceVulnerabilities, vulnerabilities } from '../db/schema';
+import { isValidCveId } from './cveId';
+
+/**
+ * Attaches CVE ids and CVSS scores to the Software and Patches tabs so a
+ * technician can prioritize by vulnerability rather than vendor severity.
+ *
+ * Both lookups read data the vulnerability pipeline already maintains — no
+ * feed is queried at request time:
+ * - software rows: open `device_vulnerabilities` findings, which
+ *   `correlateOrg` links to the inventory row it matched by name/version/CPE;
+ * - patches: the `patches.cve_ids` written by the OSV enrichment job, scored
+ *   against the locally synced NVD/MSRC catalog in `vulnerabilities`.
+ *
+ * A CVE missing from the local catalog is still listed, just unscored.
+ */
+
+export interface CveAnnotation {
+  /** CVE ids, highest CVSS first; unscored ids last in id order. */
+  cveIds: string[];
+  /** Highest CVSS base score across `cveIds`, or null when none is scored. */
+  cvssScore: number | null;
+  /** True when any of `cveIds` is on the CISA KEV list. */
+  knownExploited: boolean;
+}
+
+interface CveFact {
+  cveId: string;
+  cvssScore: string | number | null;
+  knownExploited: boolean | null;
+}
+
+/** Cap on CVE ids looked up per request; patches carrying more are rare. */
+const MAX_LOOKUP_CVES = 5000;
+
+function toScore(value: string | number | null): number | null {
+  if (value == null) return null;
+  const n = Number(value);
+  return Number.isFinite(n) ? n : null;
+}
+
+/** Folds one item's CVE facts into its annotation. Pure; exported for tests. */
+export function summarizeCves(facts: CveFact[]): CveAnnotation {
+  const byId = new Map<string, { score: number | null; kev: boolean }>();
+  for (const fact of facts) {
+    const score = toScore(fact.cvssScore);
+    const prev = byId.get(fact.cveId);
+    byId.set(fact.cveId, {
+      score: prev?.score == null ? score : score == null ? prev.score : Math.max(prev.score, score),
+      kev: (prev?.kev ?? false) || fact.knownExploited === true,
+    });
+  }
+
+  const ordered = [...byId.entries()].sort(([aId, a], [bId, b]) => {
+    if (a.score !== b.score) {
+      if (a.score == null) return 1;
+      if (b.score == null) return -1;
+      return b.score - a.score;
+    }
+    return aId.localeCompare(bId);
+  });
+
+  return {
+    cveIds: ordered.map(([id]) => id),
+    cvssScore: ordered.reduce<number | null>(
+      (max, [, v]) => (v.score != null && (max == null || v.score > max) ? v.score : max),
+      null
+    ),
+    knownExploited: ordered.some(([, v]) => v.kev),
+  };
+}
+
+/** Open CVE findings on a device, keyed by `software_inventory.id`. */
+export async function loadSoftwareCveAnnotations(deviceId: string): Promise<Map<string, CveAnnotation>> {
+  const rows = await db
+    .select({
+      softwareInventoryId: deviceVulnerabilities.softwareInventoryId,
+      cveId: vulnerabilities.cveId,
+      cvssScore: vulnerabilities.cvssScore,
+      knownExploited: vulnerabilities.knownExploited,
+    })
+    .from(deviceVulnerabilities)
+    .innerJoin(vulnerabilities, eq(deviceVulnerabilities.vulnerabilityId, vulnerabilities.id))
+    .where(
+      and(
+        eq(deviceVulnerabilities.deviceId, deviceId),
+        eq(deviceVulnerabilities.status, 'open'),
+        isNotNull(deviceVulnerabilities.softwareInventoryId)
+      )
+    );
+
+  const grouped = new Map<string, CveFact[]>();
+  for (const row of rows) {
+    if (!row.softwareInventoryId) continue;
+    const facts = grouped.get(row.softwareInventoryId) ?? [];
+    facts.push(row);
+    grouped.set(row.softwareInventoryId, facts);
+  }
+
+  return new Map([...grouped].map(([id, facts]) => [id, summarizeCves(facts)]));
+}
+
+/**
+ * Scores each patch's `cve_ids` against the local vulnerability catalog,
+ * keyed by patch id. Patches without CVE ids are omitted.
+ */
+export async function loadPatchCveAnnotations(
+  rows: Array<{ patchId: string; cveIds: string[] | null }>
+): Promise<Map<string, CveAnnotation>> {
+  const patchCves = new Map<string, string[]>();
+  const allIds = new Set<string>();
+  for (const row of rows) {
+    const ids = (row.cveIds ?? []).filter(isValidCveId);
+    if (ids.length === 0) continue;
+    patchCves.set(row.patchId, ids);
+    for (const id of ids) allIds.add(id);
+  }
+  if (patchCves.size === 0) return new Map();
+
+  const catalog = new Map<string, CveFact>();
+  const lookupIds = [...allIds].slice(0, MAX_LOOKUP_CVES);
+  const facts = await db
+    .select({
+      cveId: vulnerabilities.cveId,
+      cvssScore: vulnerabilities.cvssScore,
+      knownExploited: vulnerabilities.knownExploited,
+    })
+    .from(vulnerabilities)
+    .where(inArray(vulnerabilities.cveId, lookupIds));
+  for (const fact of facts) catalog.set(fact.cveId, fact);
+
+  return new Map(
+    [...patchCves].map(([patchId, ids]) => [
+      patchId,
+      summarizeCves(ids.map((cveId) => catalog.get(cveId) ?? { cveId, cvssScore: null, knownExploited: null })),
+    ])
+  );
+}
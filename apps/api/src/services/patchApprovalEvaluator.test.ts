@@ -24,6 +24,7 @@ import {
   comparePatchVersions,
   evaluateAppRule,
   isCategoryAllowed,
+  isPatchAllowedBySources,
   resolveApprovedPatchesForDevice,
   THIRD_PARTY_PATCH_SOURCES,
   type ApprovalEvaluationConfig,
@@ -120,7 +121,7 @@ describe('buildAllowedPatchSources', () => {
     expect(buildAllowedPatchSources(['microsoft', 'custom'])).toEqual(new Set(['microsoft', 'custom']));
   });
 
-  it('ignores firmware/drivers (no provider exists) without blocking other sources', () => {
+  it('leaves firmware/drivers to category matching without blocking other sources', () => {
     expect(buildAllowedPatchSources(['os', 'firmware', 'drivers'])).toEqual(
       new Set(['microsoft', 'apple', 'linux'])
     );
@@ -131,11 +132,35 @@ describe('buildAllowedPatchSources', () => {
     expect(buildAllowedPatchSources([])).toBeNull();
   });
 
-  it('returns an empty set (block all) when only unsupported sources are selected', () => {
+  it('returns an empty set when only category-matched sources are selected', () => {
     expect(buildAllowedPatchSources(['firmware', 'drivers'])).toEqual(new Set());
   });
 });
 
+describe('isPatchAllowedBySources', () => {
+  it('admits driver and firmware patches only through their own selections', () => {
+    const driver = { source: 'microsoft', category: 'driver' };
+    const firmware = { source: 'microsoft', category: 'Firmware' };
+
+    expect(isPatchAllowedBySources(driver, ['os'])).toBe(false);
+    expect(isPatchAllowedBySources(driver, ['microsoft'])).toBe(false);
+    expect(isPatchAllowedBySources(driver, ['drivers'])).toBe(true);
+    expect(isPatchAllowedBySources(firmware, ['drivers'])).toBe(false);
+    expect(isPatchAllowedBySources(firmware, ['os', 'firmware'])).toBe(true);
+  });
+
+  it('matches other patches on source', () => {
+    expect(isPatchAllowedBySources({ source: 'microsoft', category: 'security' }, ['os'])).toBe(true);
+    expect(isPatchAllowedBySources({ source: 'microsoft', category: null }, ['drivers'])).toBe(false);
+    expect(isPatchAllowedBySources({ source: 'third_party', category: 'application' }, ['os'])).toBe(false);
+  });
+
+  it('does not filter legacy jobs without sources', () => {
+    expect(isPatchAllowedBySources({ source: 'microsoft', category: 'driver' }, undefined)).toBe(true);
+    expect(isPatchAllowedBySources({ source: 'microsoft', category: 'driver' }, [])).toBe(true);
+  });
+});
+
 // ---- resolveApprovedPatchesForDevice with mocked Drizzle chains ----
 
 const ORG_ID = '11111111-1111-1111-1111-111111111111';
@@ -263,6 +288,23 @@ describe('resolveApprovedPatchesForDevice source filtering', () => {
     expect(approved.map((p) => p.patchId)).toEqual(['aaaaaaaa-0000-0000-0000-000000000003']);
   });
 
+  it('approves driver updates separately from OS updates', async () => {
+    mockPendingAndApprovals(
+      [
+        pendingRow({ patchId: 'aaaaaaaa-0000-0000-0000-000000000001', source: 'microsoft', category: 'security' }),
+        pendingRow({ patchId: 'aaaaaaaa-0000-0000-0000-000000000002', source: 'microsoft', category: 'driver' }),
+      ],
+      []
+    );
+
+    const approved = await resolveApprovedPatchesForDevice(DEVICE_ID, ORG_ID, {
+      ...baseRing,
+      sources: ['drivers'],
+    });
+
+    expect(approved.map((p) => p.patchId)).toEqual(['aaaaaaaa-0000-0000-0000-000000000002']);
+  });
+
   it('applies no source filtering when sources is absent (legacy jobs)', async () => {
     mockPendingAndApprovals(
       [
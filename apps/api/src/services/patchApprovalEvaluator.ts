@@ -182,7 +182,8 @@ export const THIRD_PARTY_PATCH_SOURCES = ['third_party', 'custom'] as const;
  * Expand policy-level source selections ('os', 'third_party', ...) into the
  * set of patches.source values they allow. Returns null when no filtering
  * should be applied (legacy jobs created before sources were enforced).
- * 'firmware' / 'drivers' have no patch provider yet and expand to nothing.
+ * 'firmware' / 'drivers' select by category, not source, and expand to
+ * nothing here — see isPatchAllowedBySources.
  */
 export function buildAllowedPatchSources(sources: string[] | undefined): Set<string> | null {
   if (!sources || sources.length === 0) return null;
@@ -202,12 +203,45 @@ export function buildAllowedPatchSources(sources: string[] | undefined): Set<str
       case 'custom':
         allowed.add(source);
         break;
-      // 'firmware', 'drivers': no patch provider exists — expand to nothing
+      // 'firmware', 'drivers': matched by category in isPatchAllowedBySources
     }
   }
   return allowed;
 }
 
+/**
+ * Policy source selections that admit patches by category. Driver and
+ * firmware updates arrive with source 'microsoft' (the agent's
+ * windows-drivers provider), so they are matched on patches.category instead.
+ */
+const HARDWARE_CATEGORY_SOURCES: Record<string, string> = {
+  driver: 'drivers',
+  firmware: 'firmware',
+};
+
+/**
+ * Decide whether a patch survives the policy source filter. Driver and
+ * firmware patches need the 'drivers' / 'firmware' selection — 'os' or
+ * 'microsoft' alone no longer admits them — so a policy approves them
+ * independently of quality and security updates. Everything else matches on
+ * patches.source as expanded by buildAllowedPatchSources. No sources (legacy
+ * jobs) means no filtering.
+ */
+export function isPatchAllowedBySources(
+  patch: { source: string; category: string | null },
+  sources: string[] | undefined
+): boolean {
+  const allowed = buildAllowedPatchSources(sources);
+  if (!allowed) return true;
+
+  const category = patch.category ? canonicalizePatchCategory(patch.category) : null;
+  const hardwareSource = category ? HARDWARE_CATEGORY_SOURCES[category] : undefined;
+  if (hardwareSource) {
+    return (sources ?? []).includes(hardwareSource);
+  }
+  return allowed.has(patch.source);
+}
+
 export function isThirdPartyPatchSource(source: string | null | undefined): boolean {
   return (THIRD_PARTY_PATCH_SOURCES as readonly string[]).includes(source ?? '');
 }
@@ -357,11 +391,8 @@ export async function resolveApprovedPatchesForDevice(
 
   if (pendingPatches.length === 0) return [];
 
-  // Apply policy-level source filtering ('os' vs 'third_party' etc.).
-  const allowedSources = buildAllowedPatchSources(ringConfig.sources);
-  const candidatePatches = allowedSources
-    ? pendingPatches.filter((p) => allowedSources.has(p.source))
-    : pendingPatches;
+  // Apply policy-level source filtering ('os' vs 'third_party' vs 'drivers' etc.).
+  const candidatePatches = pendingPatches.filter((p) => isPatchAllowedBySources(p, ringConfig.sources));
 
   if (candidatePatches.length === 0) {
     console.warn(
@@ -0,0 +1,121 @@
+import { beforeEach, describe, expect, it, vi } from 'vitest';
+
+vi.mock('../db', () => ({
+  db: {
+    select: vi.fn(),
+    update: vi.fn(),
+  },
+}));
+
+vi.mock('../db/schema', () => ({
+  deviceCommands: {
+    id: 'deviceCommands.id',
+    deviceId: 'deviceCommands.deviceId',
+    type: 'deviceCommands.type',
+    status: 'deviceCommands.status',
+    result: 'deviceCommands.result',
+  },
+  devices: {
+    id: 'devices.id',
+    agentId: 'devices.agentId',
+  },
+}));
+
+import { db } from '../db';
+import { applyPatchProgress } from './patchProgress';
+
+const COMMAND_UUID = '5b1c2d3e-4f50-4a61-8b72-9c8d7e6f5a4b';
+
+// Shape the agent sends for patching.ProgressEvent.
+const AGENT_EVENT = {
+  phase: 'installing',
+  patchId: 'windows-update:kb5034441',
+  patchTitle: '2024-01 Cumulative Update',
+  percent: 37.5,
+  bytesTotal: 0,
+  bytesDone: 0,
+  currentItem: 2,
+  totalItems: 4,
+  message: 'Still working on 2024-01 Cumulative Update (5m0s elapsed)',
+};
+
+function selectChain(rows: unknown[]) {
+  const chain: Record<string, any> = {};
+  for (const method of ['from', 'innerJoin', 'where']) {
+    chain[method] = vi.fn(() => chain);
+  }
+  chain.limit = vi.fn(() => Promise.resolve(rows));
+  return chain;
+}
+
+function updateChain(rows: unknown[]) {
+  const chain: Record<string, any> = {};
+  chain.set = vi.fn(() => chain);
+  chain.where = vi.fn(() => chain);
+  chain.returning = vi.fn(() => Promise.resolve(rows));
+  return chain;
+}
+
+describe('applyPatchProgress', () => {
+  beforeEach(() => {
+    vi.resetAllMocks();
+  });
+
+  it('records the latest event on an in-flight install command', async () => {
+    vi.mocked(db.select).mockReturnValue(
+      selectChain([{ id: COMMAND_UUID, type: 'install_patches', status: 'sent', agentId: 'agent-1' }]) as any
+    );
+    vi.mocked(db.update).mockReturnValue(updateChain([{ id: COMMAND_UUID }]) as any);
+
+    const result = await applyPatchProgress({ agentId: 'agent-1', commandId: COMMAND_UUID, progress: AGENT_EVENT });
+
+    expect(result).toEqual({ applied: true });
+    const updateCall = vi.mocked(db.update).mock.results[0]!.value;
+    expect(updateCall.set).toHaveBeenCalledWith({
+      result: { progress: { ...AGENT_EVENT, updatedAt: expect.any(String) } },
+    });
+  });
+
+  it('drops progress for a command that already has its result', async () => {
+    vi.mocked(db.select).mockReturnValue(
+      selectChain([{ id: COMMAND_UUID, type: 'install_patches', status: 'completed', agentId: 'agent-1' }]) as any
+    );
+
+    const result = await applyPatchProgress({ agentId: 'agent-1', commandId: COMMAND_UUID, progress: AGENT_EVENT });
+
+    expect(result).toEqual({ applied: false, reason: 'not-in-flight' });
+    expect(db.update).not.toHaveBeenCalled();
+  });
+
+  it('rejects progress from an agent that does not own the command', async () => {
+    vi.mocked(db.select).mockReturnValue(
+      selectChain([{ id: COMMAND_UUID, type: 'install_patches', status: 'sent', agentId: 'agent-1' }]) as any
+    );
+
+    const result = await applyPatchProgress({ agentId: 'agent-evil', commandId: COMMAND_UUID, progress: AGENT_EVENT });
+
+    expect(result).toEqual({ applied: false, reason: 'agent-mismatch' });
+    expect(db.update).not.toHaveBeenCalled();
+  });
+
+  it('ignores commands that are not patch operations', async () => {
+    vi.mocked(db.select).mockReturnValue(
+      selectChain([{ id: COMMAND_UUID, type: 'run_script', status: 'sent', agentId: 'agent-1' }]) as any
+    );
+
+    const result = await applyPatchProgress({ agentId: 'agent-1', commandId: COMMAND_UUID, progress: AGENT_EVENT });
+
+    expect(result).toEqual({ applied: false, reason: 'not-found' });
+    expect(db.update).not.toHaveBeenCalled();
+  });
+
+  it('drops malformed payloads and non-UUID command ids before querying', async () => {
+    expect(
+      await applyPatchProgress({ agentId: 'agent-1', commandId: COMMAND_UUID, progress: { phase: 'installing', percent: 140 } })
+    ).toEqual({ applied: false, reason: 'invalid-payload' });
+    expect(
+      await applyPatchProgress({ agentId: 'agent-1', commandId: 'not-a-uuid', progress: AGENT_EVENT })
+    ).toEqual({ applied: false, reason: 'invalid-command-id' });
+    expect(db.select).not.toHaveBeenCalled();
+  });
+});
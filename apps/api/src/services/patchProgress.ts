@@ -0,0 +1,107 @@
+import { z } from 'zod';
+import { and, eq } from 'drizzle-orm';
+import { db } from '../db';
+import { deviceCommands, devices } from '../db/schema';
+import { UUID_REGEX } from '../utils/uuid';
+
+/**
+ * Payload of the agent's `patch_progress` WS message (agent side:
+ * patching.ProgressEvent, sent by websocket.Client.SendPatchProgress).
+ * `percent` covers the whole batch; `currentItem`/`totalItems` say which
+ * patch is running. Install batches also send a keepalive every 30 s while a
+ * single patch runs long, so a Windows cumulative update never looks hung.
+ */
+export const patchProgressPayloadSchema = z.object({
+  phase: z.string().max(32),
+  patchId: z.string().max(512).optional(),
+  patchTitle: z.string().max(512).optional(),
+  percent: z.number().min(0).max(100),
+  bytesTotal: z.number().nonnegative().optional(),
+  bytesDone: z.number().nonnegative().optional(),
+  currentItem: z.number().int().nonnegative().optional(),
+  totalItems: z.number().int().nonnegative().optional(),
+  message: z.string().max(1024).optional(),
+});
+
+export type PatchProgressPayload = z.infer<typeof patchProgressPayloadSchema>;
+
+/**
+ * Command types whose agent handlers stream patch_progress. app_deploy
+ * reports catalog app installs with the same events and result schema.
+ */
+export const PATCH_PROGRESS_COMMAND_TYPES = [
+  'install_patches',
+  'rollback_patches',
+  'download_patches',
+  'patch_stage',
+  'app_deploy',
+] as const;
+
+export type ApplyPatchProgressResult =
+  | { applied: true }
+  | {
+      applied: false;
+      reason: 'invalid-command-id' | 'invalid-payload' | 'not-found' | 'agent-mismatch' | 'not-in-flight';
+    };
+
+/**
+ * Records the latest progress event on an in-flight patch command as
+ * `result.progress`, where the console's command poll
+ * (GET /devices/:id/commands/:commandId) picks it up. The terminal
+ * command_result replaces the whole result, so progress never outlives the
+ * command. Drops (no throw) on anything that does not match a `sent` patch
+ * command owned by the agent — this is a live signal, not a source of truth.
+ */
+export async function applyPatchProgress(params: {
+  agentId: string;
+  commandId: string;
+  progress: unknown;
+}): Promise<ApplyPatchProgressResult> {
+  // device_commands.id is uuid-typed; reject garbage before it reaches
+  // Postgres as a 22P02.
+  if (!UUID_REGEX.test(params.commandId)) {
+    return { applied: false, reason: 'invalid-command-id' };
+  }
+
+  const parsed = patchProgressPayloadSchema.safeParse(params.progress);
+  if (!parsed.success) {
+    return { applied: false, reason: 'invalid-payload' };
+  }
+
+  const [command] = await db
+    .select({
+      id: deviceCommands.id,
+      type: deviceCommands.type,
+      status: deviceCommands.status,
+      agentId: devices.agentId,
+    })
+    .from(deviceCommands)
+    .innerJoin(devices, eq(deviceCommands.deviceId, devices.id))
+    .where(eq(deviceCommands.id, params.commandId))
+    .limit(1);
+
+  if (!command || !(PATCH_PROGRESS_COMMAND_TYPES as readonly string[]).includes(command.type)) {
+    return { applied: false, reason: 'not-found' };
+  }
+
+  if (!command.agentId || command.agentId !== params.agentId) {
+    return { applied: false, reason: 'agent-mismatch' };
+  }
+
+  if (command.status !== 'sent') {
+    return { applied: false, reason: 'not-in-flight' };
+  }
+
+  // Guard on status again so a progress ping racing the terminal result can
+  // never overwrite it.
+  const updated = await db
+    .update(deviceCommands)
+    .set({ result: { progress: { ...parsed.data, updatedAt: new Date().toISOString() } } })
+    .where(and(eq(deviceCommands.id, command.id), eq(deviceCommands.status, 'sent')))
+    .returning({ id: deviceCommands.id });
+
+  if (updated.length === 0) {
+    return { applied: false, reason: 'not-in-flight' };
+  }
+  return { applied: true };
+}
@@ -1028,6 +1028,29 @@ export async function uninstallPlugin(
   return getPluginLoader().uninstallPlugin(orgId, installationId);
 }
 
+/**
+ * Find the org's enabled, installed collector plugin with this catalog slug.
+ * Agent collector plugins upload to an endpoint named after the slug.
+ */
+export async function findEnabledCollectorPlugin(
+  orgId: string,
+  slug: string
+): Promise<{ installationId: string } | null> {
+  const [row] = await db
+    .select({ installationId: pluginInstallations.id })
+    .from(pluginInstallations)
+    .innerJoin(pluginCatalog, eq(pluginInstallations.catalogId, pluginCatalog.id))
+    .where(and(
+      eq(pluginInstallations.orgId, orgId),
+      eq(pluginInstallations.enabled, true),
+      eq(pluginInstallations.status, 'installed'),
+      eq(pluginCatalog.slug, slug),
+      eq(pluginCatalog.type, 'collector')
+    ))
+    .limit(1);
+  return row ?? null;
+}
+
 /**
  * Execute a plugin in the sandbox
  */
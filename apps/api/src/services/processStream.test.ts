@@ -0,0 +1,38 @@
+import { describe, expect, it, vi } from 'vitest';
+import { relayProcessSnapshot, subscribeProcessStream } from './processStream';
+
+describe('relayProcessSnapshot', () => {
+  it('delivers frames to the subscriber of the stream', () => {
+    const listener = vi.fn();
+    const unsubscribe = subscribeProcessStream('stream-1', 'agent-a', listener);
+
+    const frame = { seq: 1, full: true, upserts: [] };
+    expect(relayProcessSnapshot('agent-a', 'stream-1', frame)).toEqual({ relayed: true });
+    expect(listener).toHaveBeenCalledWith(frame);
+
+    unsubscribe();
+    expect(relayProcessSnapshot('agent-a', 'stream-1', frame)).toEqual({ relayed: false, reason: 'no-subscriber' });
+  });
+
+  it('drops frames from an agent that does not own the stream', () => {
+    const listener = vi.fn();
+    const unsubscribe = subscribeProcessStream('stream-2', 'agent-a', listener);
+
+    expect(relayProcessSnapshot('agent-b', 'stream-2', { seq: 1 })).toEqual({ relayed: false, reason: 'agent-mismatch' });
+    expect(listener).not.toHaveBeenCalled();
+    unsubscribe();
+  });
+
+  it('keeps a replacement subscription when the old one unsubscribes', () => {
+    const first = vi.fn();
+    const second = vi.fn();
+    const unsubscribeFirst = subscribeProcessStream('stream-3', 'agent-a', first);
+    const unsubscribeSecond = subscribeProcessStream('stream-3', 'agent-a', second);
+
+    unsubscribeFirst();
+    expect(relayProcessSnapshot('agent-a', 'stream-3', { seq: 2 })).toEqual({ relayed: true });
+    expect(second).toHaveBeenCalledTimes(1);
+    expect(first).not.toHaveBeenCalled();
+    unsubscribeSecond();
+  });
+});
@@ -0,0 +1,63 @@
+/**
+ * In-memory relay for live process-table frames (the console's Task Manager
+ * view). The console starts a stream with a `process_stream_start` command
+ * carrying a streamId and keeps it alive by re-sending it; the agent then
+ * emits a `process_snapshot` WS message every 2–5 s (agent side:
+ * websocket.Client.SendProcessSnapshot in agent/internal/websocket/client.go)
+ * until `process_stream_stop` or its idle timeout.
+ *
+ * Frames are relayed to whoever subscribed to the streamId on THIS instance;
+ * they are never persisted. A frame from an agent other than the one the
+ * stream was opened against is dropped.
+ */
+
+export type ProcessSnapshotListener = (snapshot: Record<string, unknown>) => void;
+
+type ProcessStreamSubscription = {
+  agentId: string;
+  listener: ProcessSnapshotListener;
+};
+
+const subscriptions = new Map<string, ProcessStreamSubscription>();
+
+export type RelayProcessSnapshotResult =
+  | { relayed: true }
+  | { relayed: false; reason: 'no-subscriber' | 'agent-mismatch' };
+
+/**
+ * Deliver frames for `streamId` from `agentId` to `listener`, replacing any
+ * earlier subscription for the stream. Returns the unsubscribe function.
+ */
+export function subscribeProcessStream(
+  streamId: string,
+  agentId: string,
+  listener: ProcessSnapshotListener,
+): () => void {
+  const subscription = { agentId, listener };
+  subscriptions.set(streamId, subscription);
+  return () => {
+    if (subscriptions.get(streamId) === subscription) {
+      subscriptions.delete(streamId);
+    }
+  };
+}
+
+/**
+ * Relay one `process_snapshot` frame. Called by agentWs; drops (no throw)
+ * when nobody is watching the stream or the agent does not own it.
+ */
+export function relayProcessSnapshot(
+  agentId: string,
+  streamId: string,
+  snapshot: Record<string, unknown>,
+): RelayProcessSnapshotResult {
+  const subscription = subscriptions.get(streamId);
+  if (!subscription) {
+    return { relayed: false, reason: 'no-subscriber' };
+  }
+  if (subscription.agentId !== agentId) {
+    return { relayed: false, reason: 'agent-mismatch' };
+  }
+  subscription.listener(snapshot);
+  return { relayed: true };
+}
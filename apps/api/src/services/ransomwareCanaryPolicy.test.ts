@@ -0,0 +1,24 @@
+import { describe, expect, it, vi } from 'vitest';
+
+vi.mock('../db', () => ({ db: {} }));
+vi.mock('../db/schema/orgs', () => ({ organizations: {} }));
+
+import { parseRansomwareCanaryPolicy } from './ransomwareCanaryPolicy';
+
+describe('parseRansomwareCanaryPolicy', () => {
+  it('is off unless the organization enables it', () => {
+    const off = { enabled: false, isolateOnTrigger: false };
+    expect(parseRansomwareCanaryPolicy(null)).toEqual(off);
+    expect(parseRansomwareCanaryPolicy({})).toEqual(off);
+    expect(parseRansomwareCanaryPolicy({ ransomwareCanary: { enabled: 'true' } })).toEqual(off);
+  });
+
+  it('only isolates when canaries are enabled', () => {
+    expect(parseRansomwareCanaryPolicy({ ransomwareCanary: { enabled: true } }))
+      .toEqual({ enabled: true, isolateOnTrigger: false });
+    expect(parseRansomwareCanaryPolicy({ ransomwareCanary: { enabled: true, isolateOnTrigger: true } }))
+      .toEqual({ enabled: true, isolateOnTrigger: true });
+    expect(parseRansomwareCanaryPolicy({ ransomwareCanary: { enabled: false, isolateOnTrigger: true } }))
+      .toEqual({ enabled: false, isolateOnTrigger: false });
+  });
+});
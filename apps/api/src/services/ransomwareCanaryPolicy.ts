@@ -0,0 +1,34 @@
+import { eq } from 'drizzle-orm';
+import { db } from '../db';
+import { organizations } from '../db/schema/orgs';
+
+// Ransomware canaries are off unless the organization turns on
+// `settings.ransomwareCanary.enabled`. `isolateOnTrigger` additionally lets
+// the agent isolate the host (everything but the Breeze server blocked) as
+// soon as a canary trips. The heartbeat pushes both as `ransomware_canary`.
+
+export interface RansomwareCanaryPolicy {
+  enabled: boolean;
+  isolateOnTrigger: boolean;
+}
+
+function asRecord(value: unknown): Record<string, unknown> {
+  return value && typeof value === 'object' ? (value as Record<string, unknown>) : {};
+}
+
+/** Pure decision from the organization settings JSONB. */
+export function parseRansomwareCanaryPolicy(orgSettings: unknown): RansomwareCanaryPolicy {
+  const settings = asRecord(asRecord(orgSettings).ransomwareCanary);
+  const enabled = settings.enabled === true;
+  return { enabled, isolateOnTrigger: enabled && settings.isolateOnTrigger === true };
+}
+
+export async function resolveRansomwareCanaryPolicyForOrg(orgId: string): Promise<RansomwareCanaryPolicy> {
+  const [org] = await db
+    .select({ settings: organizations.settings })
+    .from(organizations)
+    .where(eq(organizations.id, orgId))
+    .limit(1);
+
+  return parseRansomwareCanaryPolicy(org?.settings);
+}
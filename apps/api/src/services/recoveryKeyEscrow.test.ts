@@ -29,7 +29,7 @@ vi.mock('./encryptedColumnRegistry', () => ({
 }));
 
 import { db } from '../db';
-import { escrowRecoveryKeys, fingerprintRecoveryKey } from './recoveryKeyEscrow';
+import { escrowRecoveryKeys, fingerprintRecoveryKey, revokeRecoveryKeys } from './recoveryKeyEscrow';
 
 const DEVICE_ID = '11111111-1111-4111-8111-111111111111';
 const ORG_ID = '22222222-2222-4222-8222-222222222222';
@@ -159,3 +159,53 @@ describe('escrowRecoveryKeys', () => {
     expect(set).not.toHaveBeenCalled();
   });
 });
+
+describe('revokeRecoveryKeys', () => {
+  beforeEach(() => vi.clearAllMocks());
+
+  const PASSWORD = 'Kp7#vQ2m@Xr9_tWz4!hB';
+
+  function mockRevokeUpdates(revokedRows: unknown[]) {
+    const set = vi.fn()
+      .mockReturnValueOnce({ where: vi.fn().mockReturnValue({ returning: vi.fn().mockResolvedValue(revokedRows) }) })
+      .mockReturnValue({ where: vi.fn().mockResolvedValue(undefined) });
+    (db.update as ReturnType<typeof vi.fn>).mockReturnValue({ set });
+    return set;
+  }
+
+  function mockPreviousRow(rows: unknown[]) {
+    (db.select as ReturnType<typeof vi.fn>).mockReturnValue({
+      from: vi.fn().mockReturnValue({
+        where: vi.fn().mockReturnValue({
+          orderBy: vi.fn().mockReturnValue({ limit: vi.fn().mockResolvedValue(rows) }),
+        }),
+      }),
+    });
+  }
+
+  it('revokes the unapplied key and reactivates the one it superseded', async () => {
+    const set = mockRevokeUpdates([{ id: 'row-new' }]);
+    mockPreviousRow([{ id: 'row-old' }]);
+
+    const stats = await revokeRecoveryKeys(DEVICE_ID, [
+      { keyType: 'local_admin_password', volumeMount: 'admin', recoveryKey: PASSWORD },
+    ]);
+
+    expect(stats).toEqual({ revoked: 1, restored: 1 });
+    expect(set).toHaveBeenNthCalledWith(1, expect.objectContaining({ status: 'revoked' }));
+    expect(set).toHaveBeenNthCalledWith(2, expect.objectContaining({ status: 'active', supersededAt: null }));
+  });
+
+  it('leaves the slot alone when the key is not the active one', async () => {
+    const set = mockRevokeUpdates([]);
+    mockPreviousRow([{ id: 'row-old' }]);
+
+    const stats = await revokeRecoveryKeys(DEVICE_ID, [
+      { keyType: 'local_admin_password', volumeMount: 'admin', recoveryKey: PASSWORD },
+    ]);
+
+    expect(stats).toEqual({ revoked: 0, restored: 0 });
+    expect(set).toHaveBeenCalledTimes(1);
+    expect(db.select).not.toHaveBeenCalled();
+  });
+});
@@ -1,17 +1,18 @@
 import { createHash } from 'crypto';
-import { and, eq, inArray } from 'drizzle-orm';
+import { and, desc, eq, inArray, isNull } from 'drizzle-orm';
 import { db } from '../db';
 import { deviceRecoveryKeys } from '../db/schema';
 import { encryptColumnValueForWrite } from './encryptedColumnRegistry';
 
 export type IncomingRecoveryKey = {
-  keyType: 'bitlocker_recovery_password' | 'filevault_personal_recovery_key';
+  keyType: 'bitlocker_recovery_password' | 'filevault_personal_recovery_key' | 'luks_recovery_passphrase' | 'local_admin_password';
   volumeMount?: string | null;
   protectorId?: string | null;
   recoveryKey: string;
 };
 
 export type EscrowStats = { inserted: number; superseded: number; unchanged: number };
+export type RevokeStats = { revoked: number; restored: number };
 
 export function fingerprintRecoveryKey(key: string): string {
   return createHash('sha256').update(key, 'utf8').digest('hex');
@@ -28,9 +29,11 @@ function slotOf(keyType: string, volumeMount: string | null | undefined): string
  *
  * `source === 'snapshot'` means the batch is the device's FULL current set of
  * BitLocker keys: active bitlocker rows absent from the batch are superseded
- * (the protector no longer exists on the device). FileVault rows are exempt —
- * they are only written by the rotate command (`source === 'rotation'`),
- * which never snapshot-supersedes anything.
+ * (the protector no longer exists on the device). FileVault and LUKS rows are
+ * exempt — they are only written by the rotate/enable/escrow commands
+ * (`source === 'rotation'`), which never snapshot-supersede anything. For LUKS
+ * the volumeMount is the LUKS block device and protectorId the keyslot; for a
+ * rotated local admin password (`local_admin_password`) it is the account name.
  */
 export async function escrowRecoveryKeys(
   deviceId: string,
@@ -98,3 +101,50 @@ export async function escrowRecoveryKeys(
 
   return { inserted, superseded: toSupersede.length, unchanged };
 }
+
+/**
+ * Revoke keys the agent escrowed but could not apply (a local admin password
+ * whose set failed after the upload). The matching active row is marked
+ * revoked and the slot's most recently superseded row becomes active again,
+ * since that key is still the one in effect on the device.
+ */
+export async function revokeRecoveryKeys(
+  deviceId: string,
+  keys: IncomingRecoveryKey[],
+): Promise<RevokeStats> {
+  let revoked = 0;
+  let restored = 0;
+  for (const key of keys) {
+    const slot = and(
+      eq(deviceRecoveryKeys.deviceId, deviceId),
+      eq(deviceRecoveryKeys.keyType, key.keyType),
+      key.volumeMount ? eq(deviceRecoveryKeys.volumeMount, key.volumeMount) : isNull(deviceRecoveryKeys.volumeMount),
+    );
+    const rows = await db
+      .update(deviceRecoveryKeys)
+      .set({ status: 'revoked', supersededAt: new Date(), updatedAt: new Date() })
+      .where(and(
+        slot,
+        eq(deviceRecoveryKeys.status, 'active'),
+        eq(deviceRecoveryKeys.keyFingerprint, fingerprintRecoveryKey(key.recoveryKey)),
+      ))
+      .returning({ id: deviceRecoveryKeys.id });
+    if (rows.length === 0) continue;
+    revoked++;
+
+    const [previous] = await db
+      .select({ id: deviceRecoveryKeys.id })
+      .from(deviceRecoveryKeys)
+      .where(and(slot, eq(deviceRecoveryKeys.status, 'superseded')))
+      .orderBy(desc(deviceRecoveryKeys.supersededAt))
+      .limit(1);
+    if (previous) {
+      await db
+        .update(deviceRecoveryKeys)
+        .set({ status: 'active', supersededAt: null, updatedAt: new Date() })
+        .where(eq(deviceRecoveryKeys.id, previous.id));
+      restored++;
+    }
+  }
+  return { revoked, restored };
+}
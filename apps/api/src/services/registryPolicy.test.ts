@@ -0,0 +1,20 @@
+import { describe, expect, it, vi } from 'vitest';
+
+vi.mock('../db', () => ({ db: {} }));
+vi.mock('../db/schema/orgs', () => ({ organizations: {} }));
+
+import { isRegistryWriteEnabled } from './registryPolicy';
+
+describe('isRegistryWriteEnabled', () => {
+  it('is off unless the organization enables it', () => {
+    expect(isRegistryWriteEnabled(null)).toBe(false);
+    expect(isRegistryWriteEnabled({})).toBe(false);
+    expect(isRegistryWriteEnabled({ remoteRegistry: {} })).toBe(false);
+    expect(isRegistryWriteEnabled({ remoteRegistry: { writeEnabled: 'true' } })).toBe(false);
+  });
+
+  it('is on only for an explicit true', () => {
+    expect(isRegistryWriteEnabled({ remoteRegistry: { writeEnabled: true } })).toBe(true);
+    expect(isRegistryWriteEnabled({ remoteRegistry: { writeEnabled: false } })).toBe(false);
+  });
+});
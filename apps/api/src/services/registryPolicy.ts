@@ -0,0 +1,28 @@
+import { eq } from 'drizzle-orm';
+import { db } from '../db';
+import { organizations } from '../db/schema/orgs';
+
+// Remote registry editing (set/delete value, create/delete key) is off unless
+// the organization turns on `settings.remoteRegistry.writeEnabled`. Reads and
+// .reg exports are not gated. The system-tools routes refuse writes up front,
+// and the heartbeat pushes the flag to the agent as `registry_write_enabled`
+// so the agent enforces it too.
+
+function asRecord(value: unknown): Record<string, unknown> {
+  return value && typeof value === 'object' ? (value as Record<string, unknown>) : {};
+}
+
+/** Pure decision from the organization settings JSONB. */
+export function isRegistryWriteEnabled(orgSettings: unknown): boolean {
+  return asRecord(asRecord(orgSettings).remoteRegistry).writeEnabled === true;
+}
+
+export async function resolveRegistryWriteEnabledForOrg(orgId: string): Promise<boolean> {
+  const [org] = await db
+    .select({ settings: organizations.settings })
+    .from(organizations)
+    .where(eq(organizations.id, orgId))
+    .limit(1);
+
+  return isRegistryWriteEnabled(org?.settings);
+}
@@ -0,0 +1,73 @@
+import { beforeEach, describe, expect, it, vi } from 'vitest';
+
+const { publishEvent } = vi.hoisted(() => ({ publishEvent: vi.fn(async () => 'event-id') }));
+
+vi.mock('./eventBus', () => ({ publishEvent }));
+
+import { applySecurityAlert } from './securityAlerts';
+
+const DEVICE_ID = '11111111-1111-1111-1111-111111111111';
+const ORG_ID = '22222222-2222-2222-2222-222222222222';
+
+describe('applySecurityAlert', () => {
+  beforeEach(() => {
+    publishEvent.mockClear();
+  });
+
+  it('publishes a tripped canary at critical priority', async () => {
+    const result = await applySecurityAlert({
+      deviceId: DEVICE_ID,
+      orgId: ORG_ID,
+      alert: {
+        alertType: 'ransomware_canary',
+        severity: 'critical',
+        occurredAt: '2026-10-18T09:15:02.123456789Z',
+        path: 'C:\\Users\\alice\\Documents\\.breeze-canary.docx',
+        change: 'modified',
+        isolationRequested: true,
+      },
+    });
+
+    expect(result.applied).toBe(true);
+    expect(publishEvent).toHaveBeenCalledWith(
+      'security.ransomware_canary_triggered',
+      ORG_ID,
+      expect.objectContaining({ deviceId: DEVICE_ID, change: 'modified', isolationRequested: true }),
+      'agent',
+      { priority: 'critical' }
+    );
+  });
+
+  it('publishes an isolation release at normal priority', async () => {
+    await applySecurityAlert({
+      deviceId: DEVICE_ID,
+      orgId: ORG_ID,
+      alert: {
+        alertType: 'network_isolation',
+        severity: 'info',
+        occurredAt: '2026-10-18T10:00:00Z',
+        isolated: false,
+        reason: 'containment_release',
+      },
+    });
+
+    expect(publishEvent).toHaveBeenCalledWith(
+      'security.network_isolation_changed',
+      ORG_ID,
+      expect.objectContaining({ isolated: false, reason: 'containment_release', allowed: [] }),
+      'agent',
+      { priority: 'normal' }
+    );
+  });
+
+  it('drops malformed alerts without publishing', async () => {
+    const result = await applySecurityAlert({
+      deviceId: DEVICE_ID,
+      orgId: ORG_ID,
+      alert: { alertType: 'ransomware_canary', severity: 'critical', occurredAt: 'yesterday', path: '/x', change: 'renamed' },
+    });
+
+    expect(result).toEqual({ applied: false, reason: 'invalid-payload' });
+    expect(publishEvent).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,86 @@
+import { z } from 'zod';
+import { publishEvent } from './eventBus';
+
+/**
+ * Payload of the agent's `security_alert` WS message (agent side:
+ * heartbeat.securityAlert, sent by websocket.Client.SendSecurityAlert).
+ * These are sent the moment the agent notices them rather than on the next
+ * heartbeat, and the agent re-sends undelivered ones after a reconnect.
+ */
+const alertBase = {
+  severity: z.enum(['info', 'critical']),
+  occurredAt: z.string().datetime({ offset: true }),
+};
+
+export const securityAlertPayloadSchema = z.discriminatedUnion('alertType', [
+  z.object({
+    alertType: z.literal('ransomware_canary'),
+    ...alertBase,
+    path: z.string().min(1).max(4096),
+    change: z.enum(['modified', 'deleted']),
+    isolationRequested: z.boolean().optional(),
+  }),
+  z.object({
+    alertType: z.literal('network_isolation'),
+    ...alertBase,
+    isolated: z.boolean(),
+    reason: z.enum(['ransomware_canary', 'containment', 'containment_release', 'command', 'command_release', 'restored']),
+    allowed: z.array(z.string().max(64)).max(64).optional(),
+    error: z.string().max(2048).optional(),
+  }),
+]);
+
+export type SecurityAlertPayload = z.infer<typeof securityAlertPayloadSchema>;
+
+export type ApplySecurityAlertResult =
+  | { applied: true; alert: SecurityAlertPayload }
+  | { applied: false; reason: 'invalid-payload' };
+
+/**
+ * Publishes a validated agent security alert on the event bus at critical
+ * priority (isolation releases at normal) so alert rules and the events
+ * stream see it without waiting for the next heartbeat.
+ */
+export async function applySecurityAlert(params: {
+  deviceId: string;
+  orgId: string;
+  alert: unknown;
+}): Promise<ApplySecurityAlertResult> {
+  const parsed = securityAlertPayloadSchema.safeParse(params.alert);
+  if (!parsed.success) {
+    return { applied: false, reason: 'invalid-payload' };
+  }
+  const alert = parsed.data;
+
+  if (alert.alertType === 'ransomware_canary') {
+    await publishEvent(
+      'security.ransomware_canary_triggered',
+      params.orgId,
+      {
+        deviceId: params.deviceId,
+        path: alert.path,
+        change: alert.change,
+        isolationRequested: alert.isolationRequested ?? false,
+        occurredAt: alert.occurredAt,
+      },
+      'agent',
+      { priority: 'critical' }
+    );
+  } else {
+    await publishEvent(
+      'security.network_isolation_changed',
+      params.orgId,
+      {
+        deviceId: params.deviceId,
+        isolated: alert.isolated,
+        reason: alert.reason,
+        allowed: alert.allowed ?? [],
+        error: alert.error ?? null,
+        occurredAt: alert.occurredAt,
+      },
+      'agent',
+      { priority: alert.severity === 'critical' ? 'critical' : 'normal' }
+    );
+  }
+  return { applied: true, alert };
+}
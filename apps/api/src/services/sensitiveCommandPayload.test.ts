@@ -30,6 +30,21 @@ describe('sensitiveCommandPayload', () => {
     expect(decrypted.currentRecoveryKey).toBe('AAAA-BBBB-CCCC-DDDD-EEEE-FFFF');
   });
 
+  it('encrypts the BitLocker PIN and nothing else in an enable payload', () => {
+    const encrypted = encryptSensitivePayloadFields('encryption_enable_bitlocker', { volumeMount: 'C:', protector: 'tpm_pin', pin: '246810' });
+    expect(encrypted.protector).toBe('tpm_pin');
+    expect(String(encrypted.pin)).toMatch(/^enc:/);
+    const decrypted = decryptSensitivePayloadFields('encryption_enable_bitlocker', encrypted) as Record<string, unknown>;
+    expect(decrypted.pin).toBe('246810');
+  });
+
+  it('encrypts both LUKS passphrases in an escrow payload', () => {
+    const encrypted = encryptSensitivePayloadFields('encryption_escrow_luks', { device: '/dev/sda3', passphrase: 'pw', previousEscrowKey: 'old' });
+    expect(encrypted.device).toBe('/dev/sda3');
+    expect(String(encrypted.passphrase)).toMatch(/^enc:/);
+    expect(String(encrypted.previousEscrowKey)).toMatch(/^enc:/);
+  });
+
   it('is a passthrough for non-sensitive command types and non-object payloads', () => {
     const payload = { password: 'plaintext-untouched' };
     expect(encryptSensitivePayloadFields('security_scan', payload)).toBe(payload);
@@ -38,6 +53,19 @@ describe('sensitiveCommandPayload', () => {
     expect(decryptSensitivePayloadFields('encryption_rotate_key', 'str')).toBe('str');
   });
 
+  it('round-trips the nested backup client key without touching its key id', () => {
+    const input = { snapshotId: 'snap-1', clientEncryption: { keyId: 'key-1', key: 'c2VjcmV0LWRhdGEta2V5' } };
+    const encrypted = encryptSensitivePayloadFields('backup_restore', input);
+    const clientEncryption = encrypted.clientEncryption as Record<string, unknown>;
+    expect(clientEncryption.keyId).toBe('key-1');
+    expect(String(clientEncryption.key)).toMatch(/^enc:/);
+    expect(input.clientEncryption.key).toBe('c2VjcmV0LWRhdGEta2V5');
+
+    const decrypted = decryptSensitivePayloadFields('backup_restore', encrypted) as Record<string, unknown>;
+    expect(decrypted).toEqual(input);
+    expect(encryptSensitivePayloadFields('backup_verify', { snapshotId: 'snap-1' })).toEqual({ snapshotId: 'snap-1' });
+  });
+
   it('skips absent/non-string sensitive fields', () => {
     const encrypted = encryptSensitivePayloadFields('encryption_rotate_key', { volumeMount: 'C:' });
     expect(encrypted).toEqual({ volumeMount: 'C:' });
@@ -12,10 +12,39 @@ import { captureException } from './sentry';
 // payload once the command reaches a terminal state.
 const AAD = 'device_commands.payload';
 
+// Fields are top-level names, or `parent.child` for one level of nesting.
+// Backup read commands carry the client-side encryption data key of the
+// device that wrote the snapshot (services/backupClientKeys.ts).
 const SENSITIVE_PAYLOAD_FIELDS: Record<string, readonly string[]> = {
   encryption_rotate_key: ['password', 'currentRecoveryKey'],
+  encryption_enable_bitlocker: ['pin'],
+  encryption_escrow_luks: ['passphrase', 'previousEscrowKey'],
+  backup_restore: ['clientEncryption.key'],
+  backup_verify: ['clientEncryption.key'],
+  backup_test_restore: ['clientEncryption.key'],
 };
 
+function transformField(
+  payload: Record<string, unknown>,
+  field: string,
+  fn: (value: string) => string | null,
+): void {
+  const [parent, child] = field.split('.', 2) as [string, string | undefined];
+  if (child === undefined) {
+    const value = payload[parent];
+    if (typeof value === 'string' && value) {
+      payload[parent] = fn(value);
+    }
+    return;
+  }
+  const nested = payload[parent];
+  if (!nested || typeof nested !== 'object' || Array.isArray(nested)) return;
+  const value = (nested as Record<string, unknown>)[child];
+  if (typeof value === 'string' && value) {
+    payload[parent] = { ...(nested as Record<string, unknown>), [child]: fn(value) };
+  }
+}
+
 export function hasSensitivePayload(type: string): boolean {
   return type in SENSITIVE_PAYLOAD_FIELDS;
 }
@@ -28,10 +57,7 @@ export function encryptSensitivePayloadFields(
   if (!fields) return payload;
   const out: Record<string, unknown> = { ...payload };
   for (const field of fields) {
-    const value = out[field];
-    if (typeof value === 'string' && value) {
-      out[field] = encryptSecret(value, { aad: AAD });
-    }
+    transformField(out, field, (value) => encryptSecret(value, { aad: AAD }));
   }
   return out;
 }
@@ -41,10 +67,7 @@ export function decryptSensitivePayloadFields(type: string, payload: unknown): u
   if (!fields || !payload || typeof payload !== 'object' || Array.isArray(payload)) return payload;
   const out: Record<string, unknown> = { ...(payload as Record<string, unknown>) };
   for (const field of fields) {
-    const value = out[field];
-    if (typeof value === 'string' && value) {
-      out[field] = decryptSecret(value, { aad: AAD });
-    }
+    transformField(out, field, (value) => decryptSecret(value, { aad: AAD }));
   }
   return out;
 }
@@ -141,8 +141,10 @@ const CORE_ORG_CASCADE_DELETE_ORDER: ReadonlyArray<string> = Object.freeze([
   'delegant_m365_connections',
   'deployment_invites',
   'deployments',
+  'device_backup_keys',
   'device_boot_metrics',
   'device_change_log',
+  'device_command_transcripts',
   'device_config_state',
   'device_connections',
   'device_disks',
@@ -153,6 +155,7 @@ const CORE_ORG_CASCADE_DELETE_ORDER: ReadonlyArray<string> = Object.freeze([
   'device_group_memberships',
   'device_groups',
   'device_hardware',
+  'device_inventory_snapshots',
   'device_ip_history',
   // #2138 — linked multi-boot profiles. The topo-sort deletes `devices` before
   // this (devices carries the FK to device_link_groups), so members are cleared
@@ -167,7 +170,9 @@ const CORE_ORG_CASCADE_DELETE_ORDER: ReadonlyArray<string> = Object.freeze([
   'device_reliability',
   'device_reliability_history',
   'device_sessions',
+  'device_software_usage',
   'device_vulnerabilities',
+  'device_vulnerability_scan_findings',
   'device_warranty',
   'devices',
   'discovered_assets',
@@ -261,6 +266,8 @@ const CORE_ORG_CASCADE_DELETE_ORDER: ReadonlyArray<string> = Object.freeze([
   'recovery_readiness',
   'recovery_tokens',
   'remediation_suggestions',
+  // NB: sorts BEFORE remote_sessions — '_' before 's' (prefix-extension trap).
+  'remote_session_quality_samples',
   'remote_sessions',
   'reports',
   'restore_jobs',
@@ -0,0 +1,60 @@
+import { getRedis } from './redis';
+import { isViewerSessionRevoked } from './viewerTokenRevocation';
+
+// How long the API keeps a terminal session reattachable after its viewer's
+// socket dropped. Matches the agent's default terminal_detach_grace_seconds;
+// an agent configured shorter (or 0) stops the PTY first, the attach fails,
+// and terminalWs starts a fresh shell instead.
+export const TERMINAL_DETACH_GRACE_SECONDS = 300;
+
+const detachedKey = (sessionId: string) => `terminal-detached:${sessionId}`;
+
+/**
+ * Record that a terminal session's PTY was detached rather than stopped.
+ * Returns false when the marker could not be written (no Redis), in which
+ * case the caller must stop the PTY — nothing could reattach to it.
+ */
+export async function markTerminalDetached(sessionId: string): Promise<boolean> {
+  try {
+    const redis = getRedis();
+    if (!redis) return false;
+    await redis.set(detachedKey(sessionId), '1', 'EX', TERMINAL_DETACH_GRACE_SECONDS);
+    return true;
+  } catch (err) {
+    console.error(`[terminalDetach] Failed to mark session ${sessionId} detached:`, err);
+    return false;
+  }
+}
+
+/**
+ * Whether a disconnected terminal session can still be reattached: it was
+ * detached within the grace period and has not been revoked since (a
+ * teardown revokes the viewer session and stops the PTY).
+ */
+export async function isTerminalDetached(sessionId: string): Promise<boolean> {
+  try {
+    const redis = getRedis();
+    if (!redis) return false;
+    if ((await redis.exists(detachedKey(sessionId))) !== 1) return false;
+    return !(await isViewerSessionRevoked(sessionId));
+  } catch (err) {
+    console.error(`[terminalDetach] Failed to check detached session ${sessionId}:`, err);
+    return false;
+  }
+}
+
+/**
+ * Consume the detached marker. Only one reconnecting viewer wins the DEL, so
+ * two sockets racing for the same session cannot both reattach.
+ */
+export async function claimDetachedTerminal(sessionId: string): Promise<boolean> {
+  try {
+    const redis = getRedis();
+    if (!redis) return false;
+    if ((await redis.del(detachedKey(sessionId))) !== 1) return false;
+    return !(await isViewerSessionRevoked(sessionId));
+  } catch (err) {
+    console.error(`[terminalDetach] Failed to claim detached session ${sessionId}:`, err);
+    return false;
+  }
+}
@@ -0,0 +1,60 @@
+import { describe, expect, it, vi } from 'vitest';
+
+vi.mock('../db', () => ({ db: {} }));
+vi.mock('../db/schema', () => ({}));
+
+import { snapshotEtag, toSnapshotEntry } from './vulnerabilityScanSnapshot';
+
+const row = {
+  name: '7-zip',
+  vendor: null,
+  cveId: 'CVE-2024-11477',
+  cvssScore: '7.8',
+  severity: 'HIGH',
+  knownExploited: false,
+  startIncluding: null,
+  startExcluding: null,
+  endIncluding: null,
+  endExcluding: '24.07',
+};
+
+describe('toSnapshotEntry', () => {
+  it('drops empty fields and normalizes score and severity', () => {
+    expect(toSnapshotEntry(row)).toEqual({
+      name: '7-zip',
+      cveId: 'CVE-2024-11477',
+      cvssScore: 7.8,
+      severity: 'high',
+      endExcluding: '24.07',
+    });
+  });
+
+  it('keeps the vendor, KEV flag and every range bound when set', () => {
+    expect(toSnapshotEntry({
+      ...row,
+      vendor: 'igor pavlov',
+      cvssScore: null,
+      knownExploited: true,
+      startIncluding: '9.20',
+      endExcluding: null,
+      endIncluding: '24.06',
+    })).toEqual({
+      name: '7-zip',
+      vendor: 'igor pavlov',
+      cveId: 'CVE-2024-11477',
+      severity: 'high',
+      knownExploited: true,
+      startIncluding: '9.20',
+      endIncluding: '24.06',
+    });
+  });
+});
+
+describe('snapshotEtag', () => {
+  it('depends only on the content', () => {
+    const entries = [toSnapshotEntry(row)];
+    expect(snapshotEtag(true, entries)).toBe(snapshotEtag(true, [toSnapshotEntry(row)]));
+    expect(snapshotEtag(true, entries)).not.toBe(snapshotEtag(true, []));
+    expect(snapshotEtag(true, [])).not.toBe(snapshotEtag(false, []));
+  });
+});
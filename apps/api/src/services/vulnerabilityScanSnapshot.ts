@@ -0,0 +1,209 @@
+import { createHash } from 'node:crypto';
+import { and, asc, desc, eq, isNotNull, or, sql } from 'drizzle-orm';
+
+import { db } from '../db';
+import {
+  deviceVulnerabilityScanFindings,
+  softwareInventory,
+  softwareProductResolutions,
+  softwareProducts,
+  softwareVulnerabilities,
+  vulnerabilities,
+} from '../db/schema';
+
+/**
+ * The vulnerability snapshot the agent scans its software inventory against
+ * (GET /agents/:id/vulnerability-snapshot), and storage for what the scan
+ * found.
+ *
+ * The snapshot is the device-scoped slice of the synced NVD/MSRC catalog:
+ * every product fact whose resolution matches software the device has
+ * reported, with the affected version range, using the same fact selection
+ * as `correlateOrg`. The agent evaluates the ranges against what is
+ * installed at scan time, so its findings do not wait for the daily
+ * correlation pass or depend on the patch providers' view of the device.
+ */
+
+/** Cap on snapshot entries; a device matching more is pathological. */
+export const MAX_SNAPSHOT_ENTRIES = 50_000;
+
+export interface VulnerabilitySnapshotEntry {
+  /** Lowercased, trimmed inventory name the product resolved from. */
+  name: string;
+  vendor?: string;
+  cveId: string;
+  cvssScore?: number;
+  severity?: string;
+  knownExploited?: boolean;
+  startIncluding?: string;
+  startExcluding?: string;
+  endIncluding?: string;
+  endExcluding?: string;
+}
+
+interface SnapshotRow {
+  name: string;
+  vendor: string | null;
+  cveId: string;
+  cvssScore: string | number | null;
+  severity: string | null;
+  knownExploited: boolean | null;
+  startIncluding: string | null;
+  startExcluding: string | null;
+  endIncluding: string | null;
+  endExcluding: string | null;
+}
+
+/** Maps a query row to its wire shape, dropping empty fields. Pure; exported for tests. */
+export function toSnapshotEntry(row: SnapshotRow): VulnerabilitySnapshotEntry {
+  const score = row.cvssScore == null ? null : Number(row.cvssScore);
+  return {
+    name: row.name,
+    ...(row.vendor ? { vendor: row.vendor } : {}),
+    cveId: row.cveId,
+    ...(score != null && Number.isFinite(score) ? { cvssScore: score } : {}),
+    ...(row.severity ? { severity: row.severity.toLowerCase() } : {}),
+    ...(row.knownExploited ? { knownExploited: true } : {}),
+    ...(row.startIncluding ? { startIncluding: row.startIncluding } : {}),
+    ...(row.startExcluding ? { startExcluding: row.startExcluding } : {}),
+    ...(row.endIncluding ? { endIncluding: row.endIncluding } : {}),
+    ...(row.endExcluding ? { endExcluding: row.endExcluding } : {}),
+  };
+}
+
+/**
+ * Strong ETag over the snapshot content (not its generation time), so an
+ * agent re-polling an unchanged catalog gets a 304.
+ */
+export function snapshotEtag(enabled: boolean, entries: VulnerabilitySnapshotEntry[]): string {
+  const digest = createHash('sha256').update(JSON.stringify({ enabled, entries })).digest('hex');
+  return `"${digest.slice(0, 32)}"`;
+}
+
+/**
+ * Reads the device's snapshot entries. The catalog tables are system-only,
+ * so callers run this under a system DB context; the device id comes from
+ * the authenticated agent.
+ */
+export async function buildVulnerabilitySnapshot(deviceId: string): Promise<VulnerabilitySnapshotEntry[]> {
+  const rows = await db
+    .selectDistinct({
+      name: softwareProductResolutions.lookupName,
+      vendor: softwareProductResolutions.lookupVendor,
+      cveId: vulnerabilities.cveId,
+      cvssScore: vulnerabilities.cvssScore,
+      severity: vulnerabilities.severity,
+      knownExploited: vulnerabilities.knownExploited,
+      startIncluding: softwareVulnerabilities.versionStartIncluding,
+      startExcluding: softwareVulnerabilities.versionStartExcluding,
+      endIncluding: softwareVulnerabilities.versionEndIncluding,
+      endExcluding: softwareVulnerabilities.versionEndExcluding,
+    })
+    .from(softwareInventory)
+    // Same cache-key join as correlateOrg (see vulnerabilityCorrelation.ts).
+    .innerJoin(
+      softwareProductResolutions,
+      sql`${softwareProductResolutions.lookupName} = lower(trim(${softwareInventory.name}))
+          AND ${softwareProductResolutions.lookupVendor} IS NOT DISTINCT FROM lower(trim(${softwareInventory.vendor}))`
+    )
+    .innerJoin(softwareProducts, eq(softwareProducts.id, softwareProductResolutions.softwareProductId))
+    .innerJoin(softwareVulnerabilities, eq(softwareVulnerabilities.productId, softwareProducts.id))
+    .innerJoin(vulnerabilities, eq(vulnerabilities.id, softwareVulnerabilities.vulnerabilityId))
+    .where(and(
+      eq(softwareInventory.deviceId, deviceId),
+      // The two fact kinds correlateOrg matches: MSRC fixed builds and
+      // CPE-resolved NVD ranges.
+      or(
+        and(eq(vulnerabilities.source, 'msrc'), isNotNull(softwareVulnerabilities.versionEndExcluding)),
+        isNotNull(softwareProducts.cpe)
+      )
+    ))
+    .orderBy(asc(softwareProductResolutions.lookupName), asc(vulnerabilities.cveId))
+    .limit(MAX_SNAPSHOT_ENTRIES);
+
+  return rows.map(toSnapshotEntry);
+}
+
+export interface VulnerabilityScanFindingInput {
+  name: string;
+  version: string;
+  vendor?: string;
+  cveId: string;
+  cvssScore?: number;
+  severity?: string;
+  knownExploited?: boolean;
+  fixedVersion?: string;
+}
+
+/** Replaces the device's agent scan findings with the latest report. */
+export async function replaceVulnerabilityScanFindings(
+  deviceId: string,
+  orgId: string,
+  scannedAt: Date,
+  findings: VulnerabilityScanFindingInput[]
+): Promise<number> {
+  return db.transaction(async (tx) => {
+    await tx
+      .delete(deviceVulnerabilityScanFindings)
+      .where(eq(deviceVulnerabilityScanFindings.deviceId, deviceId));
+
+    const rows = findings.map((f) => ({
+      orgId,
+      deviceId,
+      softwareName: f.name,
+      softwareVersion: f.version,
+      softwareVendor: f.vendor || null,
+      cveId: f.cveId.toUpperCase(),
+      cvssScore: f.cvssScore == null ? null : String(f.cvssScore),
+      severity: f.severity || null,
+      knownExploited: f.knownExploited ?? false,
+      fixedVersion: f.fixedVersion || null,
+      scannedAt,
+    }));
+    for (let i = 0; i < rows.length; i += 500) {
+      await tx.insert(deviceVulnerabilityScanFindings).values(rows.slice(i, i + 500));
+    }
+    return rows.length;
+  });
+}
+
+export interface VulnerabilityScanFinding {
+  softwareName: string;
+  softwareVersion: string;
+  softwareVendor: string | null;
+  cveId: string;
+  cvssScore: number | null;
+  severity: string | null;
+  knownExploited: boolean;
+  fixedVersion: string | null;
+  scannedAt: string;
+}
+
+/** The device's latest agent scan findings, highest CVSS first. */
+export async function listVulnerabilityScanFindings(deviceId: string): Promise<VulnerabilityScanFinding[]> {
+  const rows = await db
+    .select({
+      softwareName: deviceVulnerabilityScanFindings.softwareName,
+      softwareVersion: deviceVulnerabilityScanFindings.softwareVersion,
+      softwareVendor: deviceVulnerabilityScanFindings.softwareVendor,
+      cveId: deviceVulnerabilityScanFindings.cveId,
+      cvssScore: deviceVulnerabilityScanFindings.cvssScore,
+      severity: deviceVulnerabilityScanFindings.severity,
+      knownExploited: deviceVulnerabilityScanFindings.knownExploited,
+      fixedVersion: deviceVulnerabilityScanFindings.fixedVersion,
+      scannedAt: deviceVulnerabilityScanFindings.scannedAt,
+    })
+    .from(deviceVulnerabilityScanFindings)
+    .where(eq(deviceVulnerabilityScanFindings.deviceId, deviceId))
+    .orderBy(
+      sql`${deviceVulnerabilityScanFindings.cvssScore} DESC NULLS LAST`,
+      desc(deviceVulnerabilityScanFindings.knownExploited),
+      asc(deviceVulnerabilityScanFindings.cveId)
+    );
+
+  return rows.map((row) => ({
+    ...row,
+    cvssScore: row.cvssScore == null ? null : Number(row.cvssScore),
+    scannedAt: row.scannedAt.toISOString(),
+  }));
+}
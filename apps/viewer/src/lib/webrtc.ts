@@ -1,6 +1,7 @@
 /**
  * WebRTC session management for remote desktop P2P streaming.
- * Uses H264 video track from the agent's pion peer connection.
+ * Uses the agent's pion video track: AV1 or VP9 when both sides support it,
+ * otherwise H264.
  */
 
 import { apiFetch } from './api';
@@ -100,7 +101,8 @@ export async function createWebRTCSession(
 
   const pc = new RTCPeerConnection({ iceServers });
 
-  // Receive-only video transceiver (agent sends H264 video track)
+  // Receive-only video transceiver. The browser offers every codec it can
+  // decode; the agent picks AV1/VP9 when available and falls back to H264.
   pc.addTransceiver('video', { direction: 'recvonly' });
 
   // DataChannels for input events and control messages.
@@ -44,6 +44,7 @@ type BackupJobRaw = {
   totalFiles?: number | null;
   lastProgressAt?: string | null;
   errorCount?: number | null;
+  durationMs?: number | null;
   errorLog?: string | null;
   policyId?: string | null;
   featureLinkId?: string | null;
@@ -137,15 +138,21 @@ function formatBytes(bytes: number): string {
   return `${formatNumber(value, { minimumFractionDigits: precision, maximumFractionDigits: precision })} ${units[unitIndex]}`;
 }
 
-function formatDuration(startedAt?: string | null, completedAt?: string | null): string {
-  if (!startedAt) return '--';
-  const start = new Date(startedAt).getTime();
-  if (Number.isNaN(start)) return '--';
-
-  const end = completedAt ? new Date(completedAt).getTime() : Date.now();
-  if (Number.isNaN(end)) return '--';
+function formatDuration(startedAt?: string | null, completedAt?: string | null, durationMs?: number | null): string {
+  let diffMs: number;
+  if (completedAt && durationMs != null) {
+    // Agent-measured run time; the timestamps also cover dispatch and queueing.
+    diffMs = durationMs;
+  } else {
+    if (!startedAt) return '--';
+    const start = new Date(startedAt).getTime();
+    if (Number.isNaN(start)) return '--';
+
+    const end = completedAt ? new Date(completedAt).getTime() : Date.now();
+    if (Number.isNaN(end)) return '--';
+    diffMs = Math.max(0, end - start);
+  }
 
-  const diffMs = Math.max(0, end - start);
   const seconds = Math.floor(diffMs / 1000);
   if (seconds < 60) return `${seconds}s`;
   const minutes = Math.floor(seconds / 60);
@@ -175,7 +182,7 @@ function mapJob(raw: BackupJobRaw): BackupJob {
     status: normalizeStatus(raw.status),
     startedAt: raw.startedAt ?? null,
     completedAt: raw.completedAt ?? null,
-    duration: formatDuration(raw.startedAt, raw.completedAt),
+    duration: formatDuration(raw.startedAt, raw.completedAt, raw.durationMs),
     size: raw.totalSize ? formatBytes(raw.totalSize) : '--',
     transferredSize: raw.transferredSize ?? null,
     totalSizeBytes: raw.totalSize ?? null,
@@ -952,6 +952,25 @@ export default function DeviceInfoTab({ deviceId }: DeviceInfoTabProps) {
               )}
             />
           )}
+          {typeof info.batteryStatus.healthPercent === "number" && (
+            <InfoRow
+              label={t("deviceInfoTab.batteryHealth")}
+              value={`${Math.round(info.batteryStatus.healthPercent)}%`}
+            />
+          )}
+          {typeof info.batteryStatus.fullChargeCapacityMWh === "number" &&
+            typeof info.batteryStatus.designCapacityMWh === "number" && (
+              <InfoRow
+                label={t("deviceInfoTab.batteryCapacity")}
+                value={`${(info.batteryStatus.fullChargeCapacityMWh / 1000).toFixed(1)} / ${(info.batteryStatus.designCapacityMWh / 1000).toFixed(1)} Wh`}
+              />
+            )}
+          {typeof info.batteryStatus.cycleCount === "number" && (
+            <InfoRow
+              label={t("deviceInfoTab.cycleCount")}
+              value={String(info.batteryStatus.cycleCount)}
+            />
+          )}
           <InfoRow
             label={t("deviceInfoTab.lastReported")}
             value={formatDate(info.batteryStatus.reportedAt)}
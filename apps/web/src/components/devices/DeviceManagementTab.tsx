@@ -45,7 +45,12 @@ type IdentityStatus = {
   workplaceJoined: boolean;
   domainName?: string;
   tenantId?: string;
+  tenantName?: string;
+  deviceId?: string;
   mdmUrl?: string;
+  mdmEnrolled?: boolean;
+  mdmAuthority?: string;
+  mdmEnrollmentType?: "automated" | "user_approved" | "manual";
   source: string;
 };
 
@@ -308,6 +313,11 @@ export default function DeviceManagementTab({
                     {t("deviceManagementTab.tenantId")}{" "}
                   </span>
                   <span className="font-mono text-xs">{identity.tenantId}</span>
+                  {identity.tenantName && (
+                    <span className="ml-1 font-medium">
+                      ({identity.tenantName})
+                    </span>
+                  )}
                 </div>
               )}
               {identity.mdmUrl && (
@@ -315,6 +325,11 @@ export default function DeviceManagementTab({
                   <span className="text-muted-foreground">
                     {t("deviceManagementTab.mdmEnrollment")}{" "}
                   </span>
+                  {identity.mdmAuthority && (
+                    <span className="mr-1 font-medium">
+                      {identity.mdmAuthority}
+                    </span>
+                  )}
                   <span className="font-mono text-xs break-all">
                     {identity.mdmUrl}
                   </span>
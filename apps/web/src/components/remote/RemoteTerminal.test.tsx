@@ -205,7 +205,7 @@ describe('RemoteTerminal auto-connect / disconnect (#2137)', () => {
     expect(await screen.findByRole('button', { name: /reconnect/i })).toBeInTheDocument();
   });
 
-  it('surfaces a failed connection with a Retry button that reconnects', async () => {
+  it('surfaces a failed connection with a Retry button that reattaches to the dropped session', async () => {
     renderTerminal();
 
     await waitFor(() => expect(MockWebSocket.instances).toHaveLength(1), { timeout: 2000 });
@@ -222,7 +222,34 @@ describe('RemoteTerminal auto-connect / disconnect (#2137)', () => {
 
     await userEvent.click(retryBtn);
 
+    await waitFor(() => expect(MockWebSocket.instances).toHaveLength(2), { timeout: 2000 });
+    // The agent still holds the dropped shell, so no new session is created.
+    expect(sessionPostCount()).toBe(1);
+    expect(MockWebSocket.instances[1]!.url).toContain('/remote/sessions/session-1/ws');
+  });
+
+  it('starts a new session on Retry when the dropped session can no longer be reattached', async () => {
+    renderTerminal();
+
+    await waitFor(() => expect(MockWebSocket.instances).toHaveLength(1), { timeout: 2000 });
+    act(() => {
+      MockWebSocket.instances[0]!.close(1006);
+    });
+    const retryBtn = await screen.findByRole('button', { name: /retry/i });
+
+    // The detached shell's grace period ran out: the server refuses a ticket.
+    const defaultImpl = fetchMock.getMockImplementation()!;
+    fetchMock.mockImplementation(async (url: string, opts?: RequestInit) => {
+      if (url === '/remote/sessions/session-1/ws-ticket') {
+        return makeResponse({ error: 'Cannot mint WebSocket ticket for session in current state' }, false);
+      }
+      return defaultImpl(url, opts);
+    });
+
+    await userEvent.click(retryBtn);
+
     await waitFor(() => expect(MockWebSocket.instances).toHaveLength(2), { timeout: 2000 });
     expect(sessionPostCount()).toBe(2);
+    expect(MockWebSocket.instances[1]!.url).toContain('/remote/sessions/session-2/ws');
   });
 });
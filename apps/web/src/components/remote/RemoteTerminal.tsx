@@ -72,6 +72,10 @@ export default function RemoteTerminal({
   const webSocketRef = useRef<WebSocket | null>(null);
   const resizeObserverRef = useRef<ResizeObserver | null>(null);
   const disconnectFiredRef = useRef(false);
+  // Session whose socket dropped unexpectedly. The agent keeps its shell
+  // detached for a few minutes, so Retry reattaches to it before falling back
+  // to a new session.
+  const droppedSessionIdRef = useRef<string | null>(null);
 
   const [status, setStatus] = useState<ConnectionStatus>('disconnected');
   // Tracks whether the one-shot auto-connect has already fired. This gates the
@@ -177,6 +181,22 @@ export default function RemoteTerminal({
     try {
       // Create or use existing session
       let currentSessionId = sessionId;
+      let ticketResponse: Response | null = null;
+
+      const droppedSessionId = droppedSessionIdRef.current;
+      droppedSessionIdRef.current = null;
+      if (!currentSessionId && droppedSessionId) {
+        // The server only mints a ticket while the dropped shell is still
+        // detached on the agent; otherwise start over with a new session.
+        const reattachResponse = await fetchWithAuth(`/remote/sessions/${droppedSessionId}/ws-ticket`, {
+          method: 'POST'
+        });
+        if (reattachResponse.ok) {
+          currentSessionId = droppedSessionId;
+          ticketResponse = reattachResponse;
+          setSessionId(currentSessionId);
+        }
+      }
 
       if (!currentSessionId) {
         const response = await fetchWithAuth('/remote/sessions', {
@@ -198,9 +218,11 @@ export default function RemoteTerminal({
         if (currentSessionId) onSessionCreated?.(currentSessionId);
       }
 
-      const ticketResponse = await fetchWithAuth(`/remote/sessions/${currentSessionId}/ws-ticket`, {
-        method: 'POST'
-      });
+      if (!ticketResponse) {
+        ticketResponse = await fetchWithAuth(`/remote/sessions/${currentSessionId}/ws-ticket`, {
+          method: 'POST'
+        });
+      }
       if (!ticketResponse.ok) {
         const error = await ticketResponse.json().catch(() => ({ error: t('remoteTerminal.errors.createTicket') }));
         throw new Error(error.error || t('remoteTerminal.errors.createTicket'));
@@ -295,6 +317,7 @@ export default function RemoteTerminal({
         if (event.code !== 1000) {
           setStatus('failed');
           setSessionId(null);
+          droppedSessionIdRef.current = currentSessionId;
           terminalRef.current?.writeln(`\x1b[1;31m${t('remoteTerminal.errors.closedUnexpectedly')}\x1b[0m`);
           callOnDisconnect();
         } else {
@@ -30,7 +30,7 @@ type EncryptionDevice = {
   encryptionMethod: string;
   encryptionStatus: "encrypted" | "partial" | "unencrypted";
   volumes: Volume[];
-  tpmPresent: boolean;
+  tpmPresent: boolean | null;
   recoveryKeyEscrowed: boolean;
 };
 type Summary = {
@@ -358,9 +358,11 @@ export default function EncryptionPage() {
                           </span>
                         </div>
                         <div className="px-4 py-3 text-sm">
-                          {d.tpmPresent
-                            ? t("securityEncryptionPage.yes")
-                            : t("securityEncryptionPage.no")}
+                          {d.tpmPresent === null
+                            ? t("securityEncryptionPage.unknown")
+                            : d.tpmPresent
+                              ? t("securityEncryptionPage.yes")
+                              : t("securityEncryptionPage.no")}
                         </div>
                         <div className="px-4 py-3 text-sm">
                           {d.recoveryKeyEscrowed ? (
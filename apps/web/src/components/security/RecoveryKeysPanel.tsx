@@ -17,10 +17,14 @@ import { ActionError, handleActionError, runAction } from "@/lib/runAction";
 import AccessDenied from "../shared/AccessDenied";
 type KeyMeta = {
   id: string;
-  keyType: "bitlocker_recovery_password" | "filevault_personal_recovery_key";
+  keyType:
+    | "bitlocker_recovery_password"
+    | "filevault_personal_recovery_key"
+    | "luks_recovery_passphrase"
+    | "local_admin_password";
   volumeMount: string | null;
   protectorId: string | null;
-  status: "active" | "superseded";
+  status: "active" | "superseded" | "revoked";
   escrowedAt: string;
   supersededAt: string | null;
 };
@@ -72,6 +76,8 @@ export default function RecoveryKeysPanel({
   const keyTypeLabels: Record<KeyMeta["keyType"], string> = {
     bitlocker_recovery_password: t("securityRecoveryKeysPanel.bitlocker"),
     filevault_personal_recovery_key: t("securityRecoveryKeysPanel.filevault"),
+    luks_recovery_passphrase: t("securityRecoveryKeysPanel.luks"),
+    local_admin_password: t("securityRecoveryKeysPanel.localAdminPassword"),
   };
   const fetchKeys = useCallback(async () => {
     abortRef.current?.abort();
@@ -255,7 +261,9 @@ export default function RecoveryKeysPanel({
           {os === "macos" &&
             t("securityRecoveryKeysPanel.filevaultKeysCanOnlyBeCapturedBy")}
           {os === "linux" &&
-            t("securityRecoveryKeysPanel.recoveryKeyEscrowIsNotSupportedOn")}
+            t(
+              "securityRecoveryKeysPanel.luksRecoveryPassphrasesAreEscrowedByAdding",
+            )}
         </p>
       ) : (
         <div className="space-y-2">
@@ -264,7 +272,7 @@ export default function RecoveryKeysPanel({
               key={k.id}
               className={cn(
                 "rounded-md border p-3",
-                k.status === "superseded" && "opacity-70",
+                k.status !== "active" && "opacity-70",
               )}
             >
               <div className="flex items-center justify-between gap-3">
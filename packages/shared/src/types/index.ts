@@ -163,8 +163,8 @@ export interface Device {
 // `devices` table (jsonb), so it lives next to other per-heartbeat current
 // state (uptime, pendingReboot, status). `present` distinguishes a real
 // no-battery desktop (false) from an old agent that never reported (the column
-// is null). Battery HEALTH (design/full capacity, cycle count, condition) is
-// intentionally out of scope for v1 — this is operational current state only.
+// is null). Battery health (cycle count, design vs. full-charge capacity) rides
+// the same snapshot; the agent refreshes it every few hours.
 export type BatteryChargingState = 'charging' | 'discharging' | 'full' | 'not_charging' | 'unknown';
 
 export interface BatteryStatus {
@@ -179,10 +179,94 @@ export interface BatteryStatus {
   timeRemainingMinutes?: number;
   /** Estimated time to full charge, in minutes, when the OS reports it. */
   timeToFullMinutes?: number;
+  /** Charge cycles the battery has been through, when the OS reports it. */
+  cycleCount?: number;
+  /** Capacity the battery was built with, in mWh. */
+  designCapacityMWh?: number;
+  /** Capacity the battery holds at full charge today, in mWh. */
+  fullChargeCapacityMWh?: number;
+  /** Full-charge capacity as a percentage of design capacity, capped at 100. */
+  healthPercent?: number;
   /** ISO timestamp the API stamped when it ingested this snapshot. */
   reportedAt: string;
 }
 
+// Approximate device location for lost/stolen laptop workflows. Opt-in per
+// org/site policy; the agent uses the OS location service where permitted,
+// else a Wi-Fi BSSID / public IP lookup. Latest value only, on the `devices`
+// row next to batteryStatus.
+export type DeviceLocationSource = 'os' | 'wifi' | 'ip';
+
+export interface DeviceLocation {
+  latitude: number;
+  longitude: number;
+  /** Radius of the fix in meters, when the source reports one. */
+  accuracyMeters?: number;
+  source: DeviceLocationSource;
+  /** ISO timestamp the agent obtained the fix. */
+  collectedAt: string;
+  /** ISO timestamp the API stamped when it ingested this location. */
+  reportedAt: string;
+}
+
+// Agent-evaluated patch compliance: the verdict the agent computes after every
+// patch scan against the policy's complianceMaxAgeDays, stored latest-only on
+// the `devices` row so it stays current even when the server-side compliance
+// snapshot lags. null when the device has never reported or has no policy.
+export interface DevicePatchComplianceViolation {
+  patchId: string;
+  provider: string;
+  title: string;
+  severity: string;
+  kbNumber?: string;
+  /** ISO timestamp the age counts from. */
+  since: string;
+  /** 'release' when the provider reported a release date, else 'first_seen'. */
+  sinceSource: 'release' | 'first_seen';
+  ageDays: number;
+  maxAgeDays: number;
+}
+
+export interface DevicePatchCompliance {
+  status: 'compliant' | 'non_compliant';
+  criticalMaxAgeDays: number;
+  importantMaxAgeDays: number;
+  pendingCritical: number;
+  pendingImportant: number;
+  violationCount: number;
+  /** Oldest first; capped by the agent, violationCount covers all of them. */
+  violations: DevicePatchComplianceViolation[];
+  /** false when a patch provider was skipped or failed during the scan. */
+  scanComplete: boolean;
+  /** ISO timestamp the agent evaluated the policy. */
+  evaluatedAt: string;
+  /** ISO timestamp the API stamped when it ingested the report. */
+  reportedAt: string;
+}
+
+// Postpone choices the logged-in user made for the device's scheduled patch
+// reboot from the agent's warning prompt, reported with full pending-patch
+// uploads and stored latest-only on the `devices` row. null when the user
+// has not postponed the current reboot.
+export interface DevicePatchRebootDeferralChoice {
+  choice: string;
+  /** ISO timestamp the user postponed. */
+  deferredAt: string;
+  /** ISO timestamp the reboot was moved to. */
+  rebootAt: string;
+}
+
+export interface DevicePatchRebootDeferral {
+  rebootScheduled: boolean;
+  /** ISO timestamp the reboot is scheduled for. */
+  scheduledAt: string;
+  deferrals: number;
+  maxDeferrals: number;
+  choices: DevicePatchRebootDeferralChoice[];
+  /** ISO timestamp the API stamped when it ingested the report. */
+  reportedAt: string;
+}
+
 // Active-VPN-client presence telemetry (#2139). Read-only current state: the
 // agent detects which VPN overlay clients have an active tunnel from local
 // interface / adapter-description heuristics plus per-OS service/process
@@ -223,6 +307,28 @@ export interface VpnPresence {
   reportedAt: string;
 }
 
+// Per-app compliance with the patch policy's app update rings, evaluated by
+// the agent after each patch scan. Mirrors patching.AppUpdateCompliance.
+export interface DeviceAppUpdateComplianceApp {
+  provider: 'winget' | 'chocolatey' | 'homebrew';
+  packageId: string;
+  channel: 'latest' | 'pin' | 'n-1';
+  installedVersion?: string;
+  availableVersion?: string;
+  /** The version the policy wants installed, when it is not simply the newest. */
+  targetVersion?: string;
+  status: 'compliant' | 'pending' | 'deferred' | 'non_compliant' | 'not_installed';
+  reason?: string;
+}
+
+export interface DeviceAppUpdateCompliance {
+  apps: DeviceAppUpdateComplianceApp[];
+  /** ISO timestamp of the scan the agent evaluated. */
+  collectedAt: string;
+  /** ISO timestamp the API stamped when it ingested the report. */
+  reportedAt: string;
+}
+
 export interface DeviceHardware {
   deviceId: string;
   cpuModel: string | null;
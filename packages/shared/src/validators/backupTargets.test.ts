@@ -190,6 +190,13 @@ describe('backupScheduleSchema', () => {
     });
     expect(result.success).toBe(false);
   });
+
+  it('bounds the bandwidth limit', () => {
+    const base = { frequency: 'daily', time: '02:00' } as const;
+    expect(backupScheduleSchema.safeParse({ ...base, bandwidthLimitMbps: 50 }).success).toBe(true);
+    expect(backupScheduleSchema.safeParse({ ...base, bandwidthLimitMbps: 0 }).success).toBe(false);
+    expect(backupScheduleSchema.safeParse({ ...base, bandwidthLimitMbps: 1.5 }).success).toBe(false);
+  });
 });
 
 describe('backupRetentionSchema', () => {
@@ -110,6 +110,8 @@ export const backupScheduleSchema = z.object({
   dayOfMonth: z.number().int().min(1).max(28).optional(),
   windowStart: z.string().regex(/^\d{2}:\d{2}$/).optional(),
   windowEnd: z.string().regex(/^\d{2}:\d{2}$/).optional(),
+  // Upload cap for the run, enforced by the agent alongside the window.
+  bandwidthLimitMbps: z.number().int().min(1).max(10000).optional(),
 });
 
 const backupRetentionSchemaBase = z.object({
@@ -581,14 +581,6 @@ const patchSourceValueSchema = z.enum([
   'linux',
 ]);
 
-/**
- * Patch sources with no backing patch provider yet: they expand to an empty
- * allow-set at approval time (see patchApprovalEvaluator.buildAllowedPatchSources).
- * A selection made up ONLY of these would silently approve zero patches, so it
- * is rejected rather than saved as a no-op. Keep in sync with that expander.
- */
-const PROVIDERLESS_PATCH_SOURCES = new Set<string>(['firmware', 'drivers']);
-
 export const policyAppRuleSchema = z.object({
   source: z.enum(['third_party', 'custom']),
   packageId: z.string().min(1).max(256),
@@ -642,6 +634,46 @@ export const ringAutoApproveSchema = z.object({
 
 export type RingAutoApprove = z.infer<typeof ringAutoApproveSchema>;
 
+/**
+ * Maximum days a critical/important update may stay pending before the agent
+ * reports the device non-compliant. An unset severity is not enforced.
+ */
+export const patchComplianceMaxAgeDaysSchema = z.object({
+  critical: z.number().int().min(1).max(365).optional(),
+  important: z.number().int().min(1).max(365).optional(),
+});
+
+export type PatchComplianceMaxAgeDays = z.infer<typeof patchComplianceMaxAgeDaysSchema>;
+
+/**
+ * A per-application update ring for a third-party package manager, enforced
+ * by the agent: the newest release ("latest"), a fixed version ("pin") or the
+ * release before the newest ("n-1"). Blocked versions (glob wildcards
+ * allowed) are never installed. Devices in testRingDeviceIds take a new
+ * version as soon as it appears; the rest wait ringDelayDays.
+ */
+export const appUpdatePolicySchema = z.object({
+  provider: z.enum(['winget', 'chocolatey', 'homebrew']),
+  packageId: z.string().min(1).max(256),
+  displayName: z.string().max(255).optional(),
+  channel: z.enum(['latest', 'pin', 'n-1']).default('latest'),
+  // Same charset the agent accepts as a package manager --version argument.
+  pinnedVersion: z.string().regex(/^[a-zA-Z0-9][a-zA-Z0-9.+_-]{0,63}$/).optional(),
+  blockedVersions: z.array(z.string().min(1).max(64)).max(50).default([]),
+  ringDelayDays: z.number().int().min(0).max(90).default(0),
+  testRingDeviceIds: z.array(z.string().uuid()).max(500).default([]),
+}).superRefine((data, ctx) => {
+  if (data.channel === 'pin' && !data.pinnedVersion) {
+    ctx.addIssue({
+      code: z.ZodIssueCode.custom,
+      path: ['pinnedVersion'],
+      message: 'Pinned version is required for the pin channel.',
+    });
+  }
+});
+
+export type AppUpdatePolicy = z.infer<typeof appUpdatePolicySchema>;
+
 export const patchInlineSettingsSchema = z.object({
   sources: z.array(patchSourceValueSchema).min(1).default(['os']),
   autoApprove: z.boolean().default(false),
@@ -657,6 +689,13 @@ export const patchInlineSettingsSchema = z.object({
   // true the agent suppresses the native Windows Update automatic-install
   // channel (NoAutoUpdate=1); Breeze's own WUA-driven installs are unaffected.
   exclusiveWindowsUpdate: z.boolean().default(false),
+  complianceMaxAgeDays: patchComplianceMaxAgeDaysSchema.optional(),
+  // How many times the logged-in user may postpone a patch reboot from the
+  // agent's warning prompt. Unset or 0 means the reboot is only announced.
+  rebootMaxDeferrals: z.number().int().min(0).max(10).optional(),
+  // Per-application update rings the agent enforces for winget, Chocolatey
+  // and Homebrew packages; see appUpdatePolicySchema.
+  appUpdatePolicies: z.array(appUpdatePolicySchema).max(200).optional(),
 }).superRefine((data, ctx) => {
   if (data.autoApprove && data.autoApproveSeverities.length === 0) {
     ctx.addIssue({
@@ -666,14 +705,6 @@ export const patchInlineSettingsSchema = z.object({
     });
   }
 
-  if (data.sources.length > 0 && data.sources.every((s) => PROVIDERLESS_PATCH_SOURCES.has(s))) {
-    ctx.addIssue({
-      code: z.ZodIssueCode.custom,
-      path: ['sources'],
-      message: 'The selected patch sources (firmware/drivers) have no patch provider yet and would approve nothing. Include at least one of: os, third_party, custom.',
-    });
-  }
-
   const seen = new Set<string>();
   for (const [i, app] of data.apps.entries()) {
     // The approval evaluator matches 'third_party' and 'custom' as a single
@@ -689,6 +720,19 @@ export const patchInlineSettingsSchema = z.object({
     }
     seen.add(key);
   }
+
+  const seenPolicies = new Set<string>();
+  for (const [i, policy] of (data.appUpdatePolicies ?? []).entries()) {
+    const key = `${policy.provider}|${policy.packageId.toLowerCase()}`;
+    if (seenPolicies.has(key)) {
+      ctx.addIssue({
+        code: z.ZodIssueCode.custom,
+        path: ['appUpdatePolicies', i],
+        message: 'Duplicate app update policy for the same provider and package.',
+      });
+    }
+    seenPolicies.add(key);
+  }
 });
 
 export const eventLogInlineSettingsSchema = z.object({
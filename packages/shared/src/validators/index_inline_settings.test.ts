@@ -85,15 +85,12 @@ describe('patchInlineSettingsSchema', () => {
     expect(result.success).toBe(true);
   });
 
-  it('rejects a firmware/drivers-only selection (no provider — would approve nothing)', () => {
+  it('accepts a firmware/drivers-only selection (driver updates approved on their own)', () => {
     const result = patchInlineSettingsSchema.safeParse({ sources: ['firmware', 'drivers'] });
-    expect(result.success).toBe(false);
-    if (!result.success) {
-      expect(result.error.issues.some((i) => i.path.includes('sources'))).toBe(true);
-    }
+    expect(result.success).toBe(true);
   });
 
-  it('accepts firmware/drivers when combined with a provider-backed source', () => {
+  it('accepts firmware/drivers when combined with another source', () => {
     const result = patchInlineSettingsSchema.safeParse({ sources: ['os', 'drivers'] });
     expect(result.success).toBe(true);
   });